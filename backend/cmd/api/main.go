@@ -2,6 +2,7 @@ package main
 
 import (
 	"basekarya-backend/internal/bootstrap"
+	"basekarya-backend/internal/keyrotation"
 	"basekarya-backend/internal/routes"
 	"basekarya-backend/internal/seeder"
 	"basekarya-backend/pkg/logger"
@@ -19,6 +20,7 @@ import (
 const (
 	httpServerMode = "http"
 	seedMode       = "seed"
+	rotateKeysMode = "rotate-keys"
 )
 
 // @title BaseKarya API
@@ -62,18 +64,27 @@ func main() {
 	}
 	defer appContainer.Close()
 
+	appContainer.AuditWriter.Start()
+
 	switch mode {
 	case seedMode:
 		if err := seeder.Execute(appContainer.DB.GetDB(), appContainer.Config, appContainer.Bcrypt); err != nil {
 			logger.Errorw("Seeding failed: ", err)
 			os.Exit(1)
 		}
+	case rotateKeysMode:
+		if err := keyrotation.Execute(appContainer.DB.GetDB(), appContainer.Keyring); err != nil {
+			logger.Errorw("Key rotation failed: ", err)
+			os.Exit(1)
+		}
 	case httpServerMode:
 		// start server, scheduler, worker, websocket
-		appContainer.GeocodeWorker.Start(1)
+		appContainer.GeocodeWorker.Start(appContainer.Config.ExternalServiceConfig.GeocodeWorkerCount)
 		appContainer.LeaveScheduler.Start()
 		appContainer.NotificationScheduler.Start()
 		appContainer.SubscriptionScheduler.Start()
+		appContainer.ApprovalScheduler.Start()
+		appContainer.EmploymentScheduler.Start()
 		go appContainer.WebsocketHub.Run()
 
 		logger.Info("Starting BaseKarya API Server...")
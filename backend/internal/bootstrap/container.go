@@ -3,10 +3,13 @@ package bootstrap
 import (
 	"basekarya-backend/internal/config"
 	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/keyrotation"
 	"basekarya-backend/internal/middleware"
 	"basekarya-backend/internal/modules/announcement"
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/internal/modules/asset"
 	"basekarya-backend/internal/modules/attendance"
+	"basekarya-backend/internal/modules/audit"
 	"basekarya-backend/internal/modules/auth"
 	"basekarya-backend/internal/modules/bpjs"
 	"basekarya-backend/internal/modules/company"
@@ -14,19 +17,26 @@ import (
 	"basekarya-backend/internal/modules/department"
 	"basekarya-backend/internal/modules/finance"
 	"basekarya-backend/internal/modules/health"
+	"basekarya-backend/internal/modules/inbox"
 	"basekarya-backend/internal/modules/leave"
 	"basekarya-backend/internal/modules/loan"
 	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/notification"
+	"basekarya-backend/internal/modules/offboarding"
 	"basekarya-backend/internal/modules/onboarding"
 	"basekarya-backend/internal/modules/overtime"
 	"basekarya-backend/internal/modules/payroll"
 	"basekarya-backend/internal/modules/rbac"
 	"basekarya-backend/internal/modules/recruitment"
 	"basekarya-backend/internal/modules/reimbursement"
+	"basekarya-backend/internal/modules/sso"
 	"basekarya-backend/internal/modules/subscription"
 	"basekarya-backend/internal/modules/tax"
 	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"context"
+	"net"
+	"time"
 )
 
 type Container struct {
@@ -40,6 +50,7 @@ type Container struct {
 	Redis        *infrastructure.RedisClientProvider
 	Email        *infrastructure.EmailProvider
 	Excel        infrastructure.ExcelProvider
+	Keyring      *infrastructure.Keyring
 
 	HealthCheckHandler   *health.Handler
 	AuthHandler          *auth.Handler
@@ -64,40 +75,68 @@ type Container struct {
 	SubscriptionHandler  *subscription.Handler
 	TaxHandler           *tax.Handler
 	BpjsHandler          *bpjs.Handler
+	ApprovalHandler      *approval.Handler
+	InboxHandler         *inbox.Handler
+	OffboardingHandler   *offboarding.Handler
+	SSOHandler           *sso.Handler
+	AuditHandler         *audit.Handler
 
 	AuthMiddleware        *middleware.AuthMiddleware
 	RateLimiterMiddleware *middleware.RateLimiterMiddleware
 	SubscriptionMiddleware *middleware.SubscriptionMiddleware
 
 	GeocodeWorker         attendance.GeocodeWorker
+	AuditWriter           audit.Writer
 	LeaveScheduler        leave.Scheduler
 	NotificationScheduler notification.Scheduler
 	ContractScheduler      contract.Scheduler
 	SubscriptionScheduler  subscription.Scheduler
+	ApprovalScheduler      approval.Scheduler
+	EmploymentScheduler    user.Scheduler
 }
 
 func NewContainer() (*Container, error) {
 	cfg := config.Load()
 
 	db := infrastructure.NewGormConnection(&cfg.Database)
+	keyring, err := infrastructure.NewKeyring(&cfg.Encryption, infrastructure.NewGormDataKeyStore(db.GetDB()))
+	if err != nil {
+		return nil, err
+	}
+	if err := keyring.Preload(context.Background()); err != nil {
+		return nil, err
+	}
+	infrastructure.UseKeyring(keyring)
+	// before the audit callbacks, the backfill is maintenance rather than a change to record
+	if err := keyrotation.BackfillBlindIndexes(db.GetDB()); err != nil {
+		return nil, err
+	}
+	auditWriter := audit.NewWriter(db.GetDB(), constants.AuditQueueSize)
+	if err := audit.RegisterCallbacks(db.GetDB(), auditWriter); err != nil {
+		return nil, err
+	}
 	storage := infrastructure.NewMinioStorage(&cfg.Minio)
 	jwt := infrastructure.NewJWTProvider(&cfg.JWT)
 	bcrypt := infrastructure.NewBcryptHasher(12)
 	cronScheduler := infrastructure.NewCronProvider()
 	redis := infrastructure.NewRedisClient(&cfg.Redis)
+	sessionStore := infrastructure.NewSessionStore(redis, &cfg.JWT)
 	transactionManager := infrastructure.NewGormTransactionManager(db.GetDB())
 	httpClient := infrastructure.NewHttpClientProvider()
 	nominatim := infrastructure.NewNominatimFetcher(&cfg.ExternalServiceConfig, httpClient.GetClient())
 	email := infrastructure.NewEmailProvider(&cfg.Email)
 	excel := infrastructure.NewExcelProvider()
+	ocr := infrastructure.NewTesseractOCR(&cfg.ExternalServiceConfig)
 
 	wsHub := infrastructure.NewHub(redis.GetClient())
-	geocodeWorker := attendance.NewGeocodeWorker(db.GetDB(), nominatim, 100)
 
 	healthRepo := health.NewRepository(db.GetDB())
 	userRepo := user.NewRepository(db.GetDB())
 	attendanceRepo := attendance.NewRepository(db.GetDB())
 	masterRepo := master.NewRepository(db.GetDB())
+	geocodeCacheTTL := time.Duration(cfg.ExternalServiceConfig.GeocodeCacheTTLHour) * time.Hour
+	locationFetcher := attendance.NewFallbackLocationFetcher(attendance.NewCachedLocationFetcher(nominatim, redis, geocodeCacheTTL), masterRepo)
+	geocodeWorker := attendance.NewGeocodeWorker(db.GetDB(), locationFetcher, cfg.ExternalServiceConfig.GeocodeQueueSize)
 	departmentRepo := department.NewRepository(db.GetDB())
 	reimburseRepo := reimbursement.NewRepository(db.GetDB())
 	payrollRepo := payroll.NewRepository(db.GetDB())
@@ -114,33 +153,43 @@ func NewContainer() (*Container, error) {
 	astRepo := asset.NewRepository(db.GetDB())
 	subscriptionRepo := subscription.NewRepository(db.GetDB())
 	planCache := subscription.NewPlanCacheService(db.GetDB(), redis)
+	permissionCache := rbac.NewPermissionCacheService(db.GetDB(), redis)
 	taxRepo := tax.NewRepository(db.GetDB())
 	bpjsRepo := bpjs.NewRepository(db.GetDB())
+	approvalRepo := approval.NewRepository(db.GetDB())
+	offboardingRepo := offboarding.NewRepository(db.GetDB())
+	ssoRepo := sso.NewRepository(db.GetDB())
+	auditRepo := audit.NewRepository(db.GetDB())
 	taxSvc := tax.NewService(taxRepo)
 	bpjsSvc := bpjs.NewService(bpjsRepo)
 	subscriptionMW := middleware.NewSubscriptionMiddleware(planCache)
 
 	healthSvc := health.NewService(healthRepo)
 	notificationSvc := notification.NewService(wsHub, notificationRepo)
-	authSvc := auth.NewService(userRepo, bcrypt, jwt, redis, email, companyRepo, rbacRepo, masterRepo)
-	attendanceSvc := attendance.NewService(attendanceRepo, userRepo, storage, geocodeWorker, transactionManager, excel)
+	approvalSvc := approval.NewService(approvalRepo, userRepo, notificationSvc, transactionManager)
+	attendanceSvc := attendance.NewService(attendanceRepo, userRepo, leaveRepo, overtimeRepo, storage, geocodeWorker, attendance.NewAnomalyDetector(attendanceRepo), transactionManager, excel)
 	masterSvc := master.NewService(masterRepo, redis)
 	departmentSvc := department.NewService(departmentRepo, redis)
-	payrollSvc := payroll.NewService(payrollRepo, userRepo, reimburseRepo, attendanceRepo, companyRepo, notificationSvc, transactionManager, httpClient.GetClient(), email, loanRepo, overtimeRepo, taxSvc, bpjsSvc)
-	leaveSvc := leave.NewService(leaveRepo, storage, notificationSvc, userRepo, transactionManager, excel)
-	userSvc := user.NewService(userRepo, bcrypt, storage, redis, leaveSvc, transactionManager, subscriptionMW, email)
-	reimburseSvc := reimbursement.NewService(reimburseRepo, storage, notificationSvc, userRepo, transactionManager, excel)
+	payrollSvc := payroll.NewService(payrollRepo, userRepo, reimburseRepo, attendanceSvc, companyRepo, notificationSvc, transactionManager, httpClient.GetClient(), email, loanRepo, overtimeRepo, taxSvc, bpjsSvc)
+	leaveSvc := leave.NewService(leaveRepo, storage, notificationSvc, userRepo, approvalSvc, transactionManager, excel)
+	userSvc := user.NewService(userRepo, bcrypt, storage, redis, leaveSvc, transactionManager, subscriptionMW, email, sessionStore, excel)
+	authSvc := auth.NewService(userRepo, bcrypt, jwt, sessionStore, userSvc, redis, email, companyRepo, rbacRepo, masterRepo, reimburseRepo, ssoRepo)
+	reimburseSvc := reimbursement.NewService(reimburseRepo, storage, notificationSvc, approvalSvc, transactionManager, excel, userRepo, ocr)
 	companySvc := company.NewService(companyRepo, redis, storage)
-	loanSvc := loan.NewService(loanRepo, notificationSvc, userRepo, transactionManager, excel)
-	overtimeSvc := overtime.NewService(overtimeRepo, notificationSvc, userRepo, transactionManager, excel)
+	loanSvc := loan.NewService(loanRepo, userRepo, notificationSvc, approvalSvc, transactionManager, excel)
+	overtimeSvc := overtime.NewService(overtimeRepo, notificationSvc, approvalSvc, transactionManager, excel, attendanceRepo, userRepo)
 	rbacSvc := rbac.NewService(rbacRepo, redis, companyRepo, transactionManager)
 	announcementSvc := announcement.NewService(userRepo, notificationSvc)
 	contractSvc := contract.NewService(contractRepo, storage, notificationSvc, userRepo, excel)
 	onboardingSvc := onboarding.NewService(onboardingRepo, notificationSvc, userSvc, email, companyRepo, rbacRepo, departmentRepo, masterRepo, transactionManager)
 	recruitmentSvc := recruitment.NewService(recruitmentRepo, storage, notificationSvc, userRepo, onboardingSvc, transactionManager)
-	astSvc := asset.NewService(astRepo, notificationSvc, userRepo, transactionManager, excel)
-	financeSvc := finance.NewService(financeRepo, notificationSvc, userRepo, transactionManager, excel)
+	astSvc := asset.NewService(astRepo, notificationSvc, approvalSvc, transactionManager, excel)
+	financeSvc := finance.NewService(financeRepo, notificationSvc, approvalSvc, transactionManager, excel)
+	inboxSvc := inbox.NewService(approvalSvc, subscriptionMW, leaveSvc, loanSvc, overtimeSvc, reimburseSvc, financeSvc, astSvc)
+	offboardingSvc := offboarding.NewService(offboardingRepo, userRepo, leaveSvc, loanSvc, astSvc, payrollSvc, taxSvc, sessionStore, transactionManager)
 	subscriptionSvc := subscription.NewService(subscriptionRepo, companyRepo, rbacRepo, userRepo, planCache)
+	auditSvc := audit.NewService(auditRepo, excel)
+	ssoSvc := sso.NewService(ssoRepo, redis, authSvc, userRepo, userSvc, httpClient.GetClient(), net.DefaultResolver, &cfg.SSO)
 
 	healthHandler := health.NewHandler(healthSvc)
 	authHandler := auth.NewHandler(authSvc)
@@ -165,8 +214,13 @@ func NewContainer() (*Container, error) {
 	subscriptionHandler := subscription.NewHandler(subscriptionSvc)
 	taxHandler := tax.NewHandler(taxSvc)
 	bpjsHandler := bpjs.NewHandler(bpjsSvc)
+	approvalHandler := approval.NewHandler(approvalSvc)
+	inboxHandler := inbox.NewHandler(inboxSvc)
+	offboardingHandler := offboarding.NewHandler(offboardingSvc)
+	ssoHandler := sso.NewHandler(ssoSvc)
+	auditHandler := audit.NewHandler(auditSvc)
 
-	authMiddleware := middleware.NewAuthMiddleware(jwt)
+	authMiddleware := middleware.NewAuthMiddleware(jwt, sessionStore, permissionCache)
 	rateLimiterMiddleware := middleware.NewRateLimiterMiddleware()
 	subscriptionMiddleware := subscriptionMW
 
@@ -174,6 +228,8 @@ func NewContainer() (*Container, error) {
 	notificationScheduler := notification.NewScheduler(cronScheduler, notificationSvc)
 	contractScheduler := contract.NewScheduler(cronScheduler, contractSvc)
 	subscriptionScheduler := subscription.NewScheduler(cronScheduler, subscriptionRepo, planCache)
+	approvalScheduler := approval.NewScheduler(cronScheduler, approvalSvc)
+	employmentScheduler := user.NewScheduler(cronScheduler, userSvc)
 
 	return &Container{
 		Config:       cfg,
@@ -186,6 +242,7 @@ func NewContainer() (*Container, error) {
 		Redis:        redis,
 		Email:        email,
 		Excel:        excel,
+		Keyring:      keyring,
 
 		HealthCheckHandler:   healthHandler,
 		AuthHandler:          authHandler,
@@ -210,21 +267,34 @@ func NewContainer() (*Container, error) {
 		SubscriptionHandler:  subscriptionHandler,
 		TaxHandler:           taxHandler,
 		BpjsHandler:          bpjsHandler,
+		ApprovalHandler:      approvalHandler,
+		InboxHandler:         inboxHandler,
+		OffboardingHandler:   offboardingHandler,
+		SSOHandler:           ssoHandler,
+		AuditHandler:         auditHandler,
 
 		AuthMiddleware:        authMiddleware,
 		RateLimiterMiddleware: rateLimiterMiddleware,
 		SubscriptionMiddleware: subscriptionMiddleware,
 
 		GeocodeWorker:         geocodeWorker,
+		AuditWriter:           auditWriter,
 		LeaveScheduler:        leaveScheduler,
 		NotificationScheduler: notificationScheduler,
 		ContractScheduler:      contractScheduler,
 		SubscriptionScheduler:  subscriptionScheduler,
+		ApprovalScheduler:      approvalScheduler,
+		EmploymentScheduler:    employmentScheduler,
 	}, nil
 }
 
 // Close properly closes all resources
 func (c *Container) Close() error {
+	// flush pending audit entries while the database is still open
+	if c.AuditWriter != nil {
+		c.AuditWriter.Stop()
+	}
+
 	if c.DB != nil {
 		return c.DB.Close()
 	}
@@ -249,6 +319,14 @@ func (c *Container) Close() error {
 		c.SubscriptionScheduler.Stop()
 	}
 
+	if c.ApprovalScheduler != nil {
+		c.ApprovalScheduler.Stop()
+	}
+
+	if c.EmploymentScheduler != nil {
+		c.EmploymentScheduler.Stop()
+	}
+
 	if c.Redis != nil {
 		c.Redis.Close()
 	}
@@ -18,6 +18,8 @@ type Config struct {
 	CredentialConfig      CredentialConfig
 	Redis                 RedisConfig
 	Email                 EmailConfig
+	SSO                   SSOConfig
+	Encryption            EncryptionConfig
 }
 
 type RedisConfig struct {
@@ -35,8 +37,9 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret    string
-	ExpiresIn int
+	Secret                string
+	AccessExpiresInMinute int
+	RefreshExpiresInDay   int
 }
 
 type ServerConfig struct {
@@ -64,7 +67,14 @@ type FileUploadConfig struct {
 }
 
 type ExternalServiceConfig struct {
-	NominatimUrl string
+	NominatimUrl         string
+	NominatimRateLimitMs int
+	GeocodeWorkerCount   int
+	GeocodeQueueSize     int
+	GeocodeCacheTTLHour  int
+	TesseractPath        string
+	TesseractLang        string
+	TesseractTimeoutSec  int
 }
 
 type CredentialConfig struct {
@@ -80,12 +90,37 @@ type EmailConfig struct {
 	From     string
 }
 
+// SSOConfig holds the public addresses used in single sign-on redirects, the identity provider calls back to
+// BaseURL and the browser is then sent on to FrontendURL.
+type SSOConfig struct {
+	BaseURL     string
+	FrontendURL string
+}
+
+// EncryptionConfig holds the base64 encoded 32 byte keys for sensitive columns. MasterKey wraps the per-company
+// data keys, PreviousMasterKey is only read to unwrap keys while rotating to a new master key. BlindIndexKey
+// derives the lookup hashes of encrypted columns and must never change.
+type EncryptionConfig struct {
+	MasterKey         string
+	PreviousMasterKey string
+	BlindIndexKey     string
+}
+
 func Load() *Config {
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
 		panic("JWT_SECRET environment variable is required")
 	}
 
+	masterKey := os.Getenv("ENCRYPTION_MASTER_KEY")
+	if masterKey == "" {
+		panic("ENCRYPTION_MASTER_KEY environment variable is required")
+	}
+	blindIndexKey := os.Getenv("ENCRYPTION_BLIND_INDEX_KEY")
+	if blindIndexKey == "" {
+		panic("ENCRYPTION_BLIND_INDEX_KEY environment variable is required")
+	}
+
 	superadminUsername := os.Getenv("SUPERADMIN_USERNAME")
 	if superadminUsername == "" {
 		panic("SUPERADMIN_USERNAME environment variable is required")
@@ -105,8 +140,9 @@ func Load() *Config {
 			SSLMode:  getEnv("MYSQL_SSLMODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret:    jwtSecret,
-			ExpiresIn: getEnvInt("JWT_EXPIRES_IN_HOUR", 24),
+			Secret:                jwtSecret,
+			AccessExpiresInMinute: getEnvInt("JWT_ACCESS_EXPIRES_IN_MINUTE", 15),
+			RefreshExpiresInDay:   getEnvInt("JWT_REFRESH_EXPIRES_IN_DAY", 30),
 		},
 		Server: ServerConfig{
 			Port: getEnvInt("SERVER_PORT", 8080),
@@ -129,7 +165,14 @@ func Load() *Config {
 			MaxFileSizeMB:        getEnvInt("MAX_FILE_SIZE_MB", 40),
 		},
 		ExternalServiceConfig: ExternalServiceConfig{
-			NominatimUrl: getEnv("NOMINATIM_URL", ""),
+			NominatimUrl:         getEnv("NOMINATIM_URL", ""),
+			NominatimRateLimitMs: getEnvInt("NOMINATIM_RATE_LIMIT_MS", 1000),
+			GeocodeWorkerCount:   getEnvInt("GEOCODE_WORKER_COUNT", 2),
+			GeocodeQueueSize:     getEnvInt("GEOCODE_QUEUE_SIZE", 500),
+			GeocodeCacheTTLHour:  getEnvInt("GEOCODE_CACHE_TTL_HOUR", 720),
+			TesseractPath:        getEnv("TESSERACT_PATH", "tesseract"),
+			TesseractLang:        getEnv("TESSERACT_LANG", "ind+eng"),
+			TesseractTimeoutSec:  getEnvInt("TESSERACT_TIMEOUT_SEC", 20),
 		},
 		CredentialConfig: CredentialConfig{
 			SuperadminUsername: superadminUsername,
@@ -146,6 +189,15 @@ func Load() *Config {
 			Password: getEnv("SMTP_PASS", ""),
 			From:     getEnv("SMTP_FROM", ""),
 		},
+		SSO: SSOConfig{
+			BaseURL:     getEnv("SSO_BASE_URL", "http://localhost:8080"),
+			FrontendURL: getEnv("SSO_FRONTEND_URL", "http://localhost:5173"),
+		},
+		Encryption: EncryptionConfig{
+			MasterKey:         masterKey,
+			PreviousMasterKey: getEnv("ENCRYPTION_PREVIOUS_MASTER_KEY", ""),
+			BlindIndexKey:     blindIndexKey,
+		},
 	}
 
 	return config
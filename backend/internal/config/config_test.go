@@ -8,7 +8,7 @@ import (
 func TestLoad_Defaults(t *testing.T) {
 	envKeys := []string{
 		"MYSQL_HOST", "MYSQL_PORT", "MYSQL_USER", "MYSQL_PASSWORD", "MYSQL_DATABASE", "MYSQL_SSLMODE",
-		"JWT_SECRET", "JWT_EXPIRES_IN_HOUR",
+		"JWT_SECRET", "JWT_ACCESS_EXPIRES_IN_MINUTE", "JWT_REFRESH_EXPIRES_IN_DAY",
 		"SERVER_PORT", "SERVER_ENV",
 		"LOG_LEVEL",
 		"MINIO_ENDPOINT", "MINIO_ACCESS_KEY", "MINIO_SECRET_KEY", "MINIO_BUCKET_NAME", "MINIO_BUCKET_LOCATION", "MINIO_IS_SECURE", "MINIO_PUBLIC_DOMAIN",
@@ -17,6 +17,8 @@ func TestLoad_Defaults(t *testing.T) {
 		"SUPERADMIN_USERNAME", "SUPERADMIN_PASSWORD",
 		"REDIS_ADDR", "REDIS_PASSWORD",
 		"SMTP_HOST", "SMTP_PORT", "SMTP_USER", "SMTP_PASS", "SMTP_FROM",
+		"SSO_BASE_URL", "SSO_FRONTEND_URL",
+		"ENCRYPTION_MASTER_KEY", "ENCRYPTION_PREVIOUS_MASTER_KEY", "ENCRYPTION_BLIND_INDEX_KEY",
 	}
 	for _, k := range envKeys {
 		os.Unsetenv(k)
@@ -25,10 +27,14 @@ func TestLoad_Defaults(t *testing.T) {
 	os.Setenv("JWT_SECRET", "test-secret")
 	os.Setenv("SUPERADMIN_USERNAME", "superadmin")
 	os.Setenv("SUPERADMIN_PASSWORD", "superpass")
+	os.Setenv("ENCRYPTION_MASTER_KEY", "master-key")
+	os.Setenv("ENCRYPTION_BLIND_INDEX_KEY", "blind-index-key")
 	t.Cleanup(func() {
 		os.Unsetenv("JWT_SECRET")
 		os.Unsetenv("SUPERADMIN_USERNAME")
 		os.Unsetenv("SUPERADMIN_PASSWORD")
+		os.Unsetenv("ENCRYPTION_MASTER_KEY")
+		os.Unsetenv("ENCRYPTION_BLIND_INDEX_KEY")
 	})
 
 	cfg := Load()
@@ -54,8 +60,11 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.JWT.Secret != "test-secret" {
 		t.Errorf("expected JWT.Secret test-secret, got %s", cfg.JWT.Secret)
 	}
-	if cfg.JWT.ExpiresIn != 24 {
-		t.Errorf("expected JWT.ExpiresIn 24, got %d", cfg.JWT.ExpiresIn)
+	if cfg.JWT.AccessExpiresInMinute != 15 {
+		t.Errorf("expected JWT.AccessExpiresInMinute 15, got %d", cfg.JWT.AccessExpiresInMinute)
+	}
+	if cfg.JWT.RefreshExpiresInDay != 30 {
+		t.Errorf("expected JWT.RefreshExpiresInDay 30, got %d", cfg.JWT.RefreshExpiresInDay)
 	}
 	if cfg.Server.Port != 8080 {
 		t.Errorf("expected Server.Port 8080, got %d", cfg.Server.Port)
@@ -78,13 +87,44 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.Email.Port != 0 {
 		t.Errorf("expected Email.Port 0, got %d", cfg.Email.Port)
 	}
+	if cfg.SSO.BaseURL != "http://localhost:8080" {
+		t.Errorf("expected SSO.BaseURL http://localhost:8080, got %s", cfg.SSO.BaseURL)
+	}
+	if cfg.SSO.FrontendURL != "http://localhost:5173" {
+		t.Errorf("expected SSO.FrontendURL http://localhost:5173, got %s", cfg.SSO.FrontendURL)
+	}
+	if cfg.Encryption.MasterKey != "master-key" {
+		t.Errorf("expected Encryption.MasterKey master-key, got %s", cfg.Encryption.MasterKey)
+	}
+	if cfg.Encryption.PreviousMasterKey != "" {
+		t.Errorf("expected Encryption.PreviousMasterKey empty, got %s", cfg.Encryption.PreviousMasterKey)
+	}
+}
+
+func TestLoad_MissingEncryptionKey(t *testing.T) {
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Setenv("SUPERADMIN_USERNAME", "superadmin")
+	os.Setenv("SUPERADMIN_PASSWORD", "superpass")
+	os.Unsetenv("ENCRYPTION_MASTER_KEY")
+	t.Cleanup(func() {
+		os.Unsetenv("JWT_SECRET")
+		os.Unsetenv("SUPERADMIN_USERNAME")
+		os.Unsetenv("SUPERADMIN_PASSWORD")
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic without ENCRYPTION_MASTER_KEY")
+		}
+	}()
+	Load()
 }
 
 func TestLoad_EnvVars(t *testing.T) {
 	os.Setenv("MYSQL_HOST", "dbhost")
 	os.Setenv("MYSQL_PORT", "5432")
 	os.Setenv("JWT_SECRET", "mysecret")
-	os.Setenv("JWT_EXPIRES_IN_HOUR", "48")
+	os.Setenv("JWT_ACCESS_EXPIRES_IN_MINUTE", "5")
 	os.Setenv("SERVER_PORT", "9090")
 	os.Setenv("SERVER_ENV", "production")
 	os.Setenv("LOG_LEVEL", "info")
@@ -92,11 +132,15 @@ func TestLoad_EnvVars(t *testing.T) {
 	os.Setenv("SMTP_PORT", "587")
 	os.Setenv("SUPERADMIN_USERNAME", "superadmin")
 	os.Setenv("SUPERADMIN_PASSWORD", "superpass")
+	os.Setenv("ENCRYPTION_MASTER_KEY", "master-key")
+	os.Setenv("ENCRYPTION_BLIND_INDEX_KEY", "blind-index-key")
 	t.Cleanup(func() {
+		os.Unsetenv("ENCRYPTION_MASTER_KEY")
+		os.Unsetenv("ENCRYPTION_BLIND_INDEX_KEY")
 		os.Unsetenv("MYSQL_HOST")
 		os.Unsetenv("MYSQL_PORT")
 		os.Unsetenv("JWT_SECRET")
-		os.Unsetenv("JWT_EXPIRES_IN_HOUR")
+		os.Unsetenv("JWT_ACCESS_EXPIRES_IN_MINUTE")
 		os.Unsetenv("SERVER_PORT")
 		os.Unsetenv("SERVER_ENV")
 		os.Unsetenv("LOG_LEVEL")
@@ -117,8 +161,8 @@ func TestLoad_EnvVars(t *testing.T) {
 	if cfg.JWT.Secret != "mysecret" {
 		t.Errorf("expected JWT.Secret mysecret, got %s", cfg.JWT.Secret)
 	}
-	if cfg.JWT.ExpiresIn != 48 {
-		t.Errorf("expected JWT.ExpiresIn 48, got %d", cfg.JWT.ExpiresIn)
+	if cfg.JWT.AccessExpiresInMinute != 5 {
+		t.Errorf("expected JWT.AccessExpiresInMinute 5, got %d", cfg.JWT.AccessExpiresInMinute)
 	}
 	if cfg.Server.Port != 9090 {
 		t.Errorf("expected Server.Port 9090, got %d", cfg.Server.Port)
@@ -0,0 +1,605 @@
+package infrastructure
+
+import (
+	"basekarya-backend/internal/config"
+	"basekarya-backend/pkg/constants"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	ErrKeyringNotConfigured = errors.New("encryption keyring is not configured")
+	ErrDataKeyNotFound      = errors.New("encryption data key not found")
+	ErrInvalidCiphertext    = errors.New("invalid ciphertext")
+)
+
+const (
+	// ciphertextPrefix marks encrypted column values, anything else is legacy plaintext
+	ciphertextPrefix = "enc:v1:"
+	// activeKeyTTL bounds how long a process keeps encrypting with a data key after it was rotated elsewhere
+	activeKeyTTL = 5 * time.Minute
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", EncryptedSerializer{})
+}
+
+// EncryptionKey is a per-company data key, stored wrapped by the master key. Older versions stay to
+// decrypt values written before a rotation.
+type EncryptionKey struct {
+	ID         uint   `gorm:"primaryKey"`
+	CompanyID  uint   `gorm:"uniqueIndex:idx_encryption_keys_company_version;not null"`
+	Version    int    `gorm:"uniqueIndex:idx_encryption_keys_company_version;not null"`
+	WrappedKey string `gorm:"type:varchar(255);not null"`
+	Active     bool   `gorm:"not null;default:false"`
+	CreatedAt  time.Time
+}
+
+type DataKeyStore interface {
+	// FindActive returns nil when the company has no data key yet.
+	FindActive(ctx context.Context, companyID uint) (*EncryptionKey, error)
+	Find(ctx context.Context, companyID uint, version int) (*EncryptionKey, error)
+	FindAll(ctx context.Context) ([]EncryptionKey, error)
+	// Create stores key as the only active data key of its company.
+	Create(ctx context.Context, key *EncryptionKey) error
+	UpdateWrappedKey(ctx context.Context, id uint, wrappedKey string) error
+}
+
+type gormDataKeyStore struct {
+	db *gorm.DB
+}
+
+// NewGormDataKeyStore keeps data keys in the encryption_keys table. It never joins the caller's
+// transaction, a data key must survive a rollback of the write that created it.
+func NewGormDataKeyStore(db *gorm.DB) DataKeyStore {
+	return &gormDataKeyStore{db}
+}
+
+func (s *gormDataKeyStore) FindActive(ctx context.Context, companyID uint) (*EncryptionKey, error) {
+	var key EncryptionKey
+	err := s.db.WithContext(context.WithoutCancel(ctx)).
+		Where("company_id = ? AND active = ?", companyID, true).
+		Order("version DESC").
+		First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *gormDataKeyStore) Find(ctx context.Context, companyID uint, version int) (*EncryptionKey, error) {
+	var key EncryptionKey
+	err := s.db.WithContext(context.WithoutCancel(ctx)).
+		Where("company_id = ? AND version = ?", companyID, version).
+		First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDataKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *gormDataKeyStore) FindAll(ctx context.Context) ([]EncryptionKey, error) {
+	var keys []EncryptionKey
+	err := s.db.WithContext(context.WithoutCancel(ctx)).Order("company_id, version").Find(&keys).Error
+	return keys, err
+}
+
+func (s *gormDataKeyStore) Create(ctx context.Context, key *EncryptionKey) error {
+	return s.db.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&EncryptionKey{}).Where("company_id = ?", key.CompanyID).Update("active", false).Error; err != nil {
+			return err
+		}
+		key.Active = true
+		return tx.Create(key).Error
+	})
+}
+
+func (s *gormDataKeyStore) UpdateWrappedKey(ctx context.Context, id uint, wrappedKey string) error {
+	return s.db.WithContext(context.WithoutCancel(ctx)).Model(&EncryptionKey{}).Where("id = ?", id).Update("wrapped_key", wrappedKey).Error
+}
+
+type dataKeyRef struct {
+	companyID uint
+	version   int
+}
+
+type activeDataKey struct {
+	version   int
+	expiresAt time.Time
+}
+
+// Keyring encrypts column values with per-company data keys (envelope encryption), the data keys
+// themselves are only stored wrapped by the master key from config.
+type Keyring struct {
+	store    DataKeyStore
+	master   cipher.AEAD
+	previous cipher.AEAD
+	blindKey []byte
+
+	mu       sync.RWMutex
+	dataKeys map[dataKeyRef]cipher.AEAD
+	active   map[uint]activeDataKey
+}
+
+func NewKeyring(cfg *config.EncryptionConfig, store DataKeyStore) (*Keyring, error) {
+	masterKey, err := decodeKey("ENCRYPTION_MASTER_KEY", cfg.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	blindKey, err := decodeKey("ENCRYPTION_BLIND_INDEX_KEY", cfg.BlindIndexKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring := &Keyring{
+		store:    store,
+		master:   master,
+		blindKey: blindKey,
+		dataKeys: make(map[dataKeyRef]cipher.AEAD),
+		active:   make(map[uint]activeDataKey),
+	}
+
+	if cfg.PreviousMasterKey != "" {
+		previousKey, err := decodeKey("ENCRYPTION_PREVIOUS_MASTER_KEY", cfg.PreviousMasterKey)
+		if err != nil {
+			return nil, err
+		}
+		if keyring.previous, err = newAEAD(previousKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return keyring, nil
+}
+
+// Encrypt seals plaintext with the active data key of the company. The result names the company and
+// key version, so it can be decrypted after the data key was rotated.
+func (k *Keyring) Encrypt(ctx context.Context, companyID uint, aad string, plaintext []byte) (string, error) {
+	version, aead, err := k.activeKey(ctx, companyID)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := seal(aead, plaintext, []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%d:%d:%s", ciphertextPrefix, companyID, version, sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt, aad must match the one it was sealed with.
+func (k *Keyring) Decrypt(ctx context.Context, aad, ciphertext string) ([]byte, error) {
+	parts := strings.SplitN(strings.TrimPrefix(ciphertext, ciphertextPrefix), ":", 3)
+	if !IsCiphertext(ciphertext) || len(parts) != 3 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	var ref dataKeyRef
+	if _, err := fmt.Sscan(parts[0], &ref.companyID); err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	if _, err := fmt.Sscan(parts[1], &ref.version); err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	aead, err := k.dataKey(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, parts[2], []byte(aad))
+}
+
+// BlindIndex is a keyed hash of value for exact lookups and uniqueness on encrypted columns. It is
+// case and surrounding whitespace insensitive.
+func (k *Keyring) BlindIndex(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, k.blindKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RotateDataKey makes a new data key the active one of the company and returns its version.
+// Values sealed with older versions stay readable until they are written again.
+func (k *Keyring) RotateDataKey(ctx context.Context, companyID uint) (int, error) {
+	current, err := k.store.FindActive(ctx, companyID)
+	if err != nil {
+		return 0, err
+	}
+
+	version := 1
+	if current != nil {
+		version = current.Version + 1
+	}
+
+	aead, err := k.createDataKey(ctx, companyID, version)
+	if err != nil {
+		return 0, err
+	}
+
+	k.mu.Lock()
+	k.dataKeys[dataKeyRef{companyID, version}] = aead
+	k.active[companyID] = activeDataKey{version: version, expiresAt: time.Now().Add(activeKeyTTL)}
+	k.mu.Unlock()
+
+	return version, nil
+}
+
+// Preload unwraps every stored data key up front. Values are sealed and opened while gorm holds the
+// statement's connection, so a key that is not cached yet costs a second connection from the pool.
+func (k *Keyring) Preload(ctx context.Context) error {
+	keys, err := k.store.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(activeKeyTTL)
+	for i := range keys {
+		aead, err := k.unwrapDataKey(&keys[i])
+		if err != nil {
+			return err
+		}
+
+		k.mu.Lock()
+		k.dataKeys[dataKeyRef{keys[i].CompanyID, keys[i].Version}] = aead
+		if keys[i].Active {
+			k.active[keys[i].CompanyID] = activeDataKey{version: keys[i].Version, expiresAt: expiresAt}
+		}
+		k.mu.Unlock()
+	}
+	return nil
+}
+
+// RewrapAll wraps every data key with the current master key and returns how many were still
+// wrapped by the previous one.
+func (k *Keyring) RewrapAll(ctx context.Context) (int, error) {
+	keys, err := k.store.FindAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, key := range keys {
+		raw, current, err := k.unwrap(key.WrappedKey)
+		if err != nil {
+			return rewrapped, fmt.Errorf("data key %d of company %d: %w", key.Version, key.CompanyID, err)
+		}
+		if current {
+			continue
+		}
+
+		wrapped, err := seal(k.master, raw, nil)
+		if err != nil {
+			return rewrapped, err
+		}
+		if err := k.store.UpdateWrappedKey(ctx, key.ID, wrapped); err != nil {
+			return rewrapped, err
+		}
+		rewrapped++
+	}
+	return rewrapped, nil
+}
+
+func (k *Keyring) activeKey(ctx context.Context, companyID uint) (int, cipher.AEAD, error) {
+	k.mu.RLock()
+	active, ok := k.active[companyID]
+	aead := k.dataKeys[dataKeyRef{companyID, active.version}]
+	k.mu.RUnlock()
+	if ok && aead != nil && time.Now().Before(active.expiresAt) {
+		return active.version, aead, nil
+	}
+
+	key, err := k.store.FindActive(ctx, companyID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	aead = nil
+	if key == nil {
+		aead, err = k.createDataKey(ctx, companyID, 1)
+		if err != nil {
+			// another writer may have created the first key at the same time
+			if key, _ = k.store.FindActive(ctx, companyID); key == nil {
+				return 0, nil, err
+			}
+		} else {
+			key = &EncryptionKey{CompanyID: companyID, Version: 1}
+		}
+	}
+
+	if aead == nil {
+		if aead, err = k.unwrapDataKey(key); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	k.mu.Lock()
+	k.dataKeys[dataKeyRef{companyID, key.Version}] = aead
+	k.active[companyID] = activeDataKey{version: key.Version, expiresAt: time.Now().Add(activeKeyTTL)}
+	k.mu.Unlock()
+
+	return key.Version, aead, nil
+}
+
+func (k *Keyring) dataKey(ctx context.Context, ref dataKeyRef) (cipher.AEAD, error) {
+	k.mu.RLock()
+	aead, ok := k.dataKeys[ref]
+	k.mu.RUnlock()
+	if ok {
+		return aead, nil
+	}
+
+	key, err := k.store.Find(ctx, ref.companyID, ref.version)
+	if err != nil {
+		return nil, err
+	}
+	if aead, err = k.unwrapDataKey(key); err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	k.dataKeys[ref] = aead
+	k.mu.Unlock()
+
+	return aead, nil
+}
+
+func (k *Keyring) createDataKey(ctx context.Context, companyID uint, version int) (cipher.AEAD, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := seal(k.master, raw, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &EncryptionKey{CompanyID: companyID, Version: version, WrappedKey: wrapped}
+	if err := k.store.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return newAEAD(raw)
+}
+
+func (k *Keyring) unwrapDataKey(key *EncryptionKey) (cipher.AEAD, error) {
+	raw, _, err := k.unwrap(key.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("data key %d of company %d: %w", key.Version, key.CompanyID, err)
+	}
+	return newAEAD(raw)
+}
+
+// unwrap opens a wrapped data key with the master key, falling back to the previous master key
+// during a rotation. current reports whether the master key was used.
+func (k *Keyring) unwrap(wrapped string) (raw []byte, current bool, err error) {
+	if raw, err = open(k.master, wrapped, nil); err == nil {
+		return raw, true, nil
+	}
+	if k.previous != nil {
+		if raw, err = open(k.previous, wrapped, nil); err == nil {
+			return raw, false, nil
+		}
+	}
+	return nil, false, errors.New("cannot be unwrapped with the configured master keys")
+}
+
+var (
+	keyringMu     sync.RWMutex
+	globalKeyring *Keyring
+)
+
+// UseKeyring sets the keyring used by the encrypted serializer and BlindIndex.
+func UseKeyring(keyring *Keyring) {
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	globalKeyring = keyring
+}
+
+func currentKeyring() (*Keyring, error) {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+	if globalKeyring == nil {
+		return nil, ErrKeyringNotConfigured
+	}
+	return globalKeyring, nil
+}
+
+// BlindIndex hashes value with the keyring set by UseKeyring, see Keyring.BlindIndex.
+func BlindIndex(value string) string {
+	keyring, err := currentKeyring()
+	if err != nil {
+		return ""
+	}
+	return keyring.BlindIndex(value)
+}
+
+// IsCiphertext reports whether a column value was written by the encrypted serializer.
+func IsCiphertext(value string) bool {
+	return strings.HasPrefix(value, ciphertextPrefix)
+}
+
+// EncryptedSerializer stores a field JSON encoded and sealed with the data key of the row's company,
+// use it with `gorm:"serializer:encrypted"`. Empty strings are stored as is and values that are not
+// ciphertext are read as legacy plaintext until they are written again.
+type EncryptedSerializer struct{}
+
+func (EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	value, err := decryptField(ctx, field, dbValue)
+	if err != nil {
+		return err
+	}
+	field.ReflectValueOf(ctx, dst).Set(value)
+	return nil
+}
+
+func (EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	if fieldValue == nil {
+		return nil, nil
+	}
+	if s, ok := fieldValue.(string); ok && s == "" {
+		return "", nil
+	}
+
+	keyring, err := currentKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, err
+	}
+	return keyring.Encrypt(ctx, rowCompanyID(ctx, field, dst), fieldAAD(field), plaintext)
+}
+
+// IsEncryptedField reports whether field uses the encrypted serializer.
+func IsEncryptedField(field *schema.Field) bool {
+	_, ok := field.Serializer.(EncryptedSerializer)
+	return ok
+}
+
+// DecryptField turns a raw column value of an encrypted field into its plain value, for code that
+// reads rows into maps and so bypasses the serializer.
+func DecryptField(ctx context.Context, field *schema.Field, dbValue interface{}) (interface{}, error) {
+	value, err := decryptField(ctx, field, dbValue)
+	if err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+func decryptField(ctx context.Context, field *schema.Field, dbValue interface{}) (reflect.Value, error) {
+	target := reflect.New(field.FieldType)
+
+	var raw string
+	switch v := dbValue.(type) {
+	case nil:
+		return target.Elem(), nil
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		raw = fmt.Sprint(v)
+	}
+
+	if raw == "" {
+		return target.Elem(), nil
+	}
+
+	if !IsCiphertext(raw) {
+		if field.FieldType.Kind() == reflect.String {
+			target.Elem().SetString(raw)
+			return target.Elem(), nil
+		}
+		if err := json.Unmarshal([]byte(raw), target.Interface()); err != nil {
+			return target.Elem(), fmt.Errorf("legacy value of %s: %w", field.DBName, err)
+		}
+		return target.Elem(), nil
+	}
+
+	keyring, err := currentKeyring()
+	if err != nil {
+		return target.Elem(), err
+	}
+
+	plaintext, err := keyring.Decrypt(ctx, fieldAAD(field), raw)
+	if err != nil {
+		return target.Elem(), fmt.Errorf("decrypt %s: %w", field.DBName, err)
+	}
+	if err := json.Unmarshal(plaintext, target.Interface()); err != nil {
+		return target.Elem(), err
+	}
+	return target.Elem(), nil
+}
+
+// fieldAAD binds a ciphertext to its column, so it cannot be copied into another one.
+func fieldAAD(field *schema.Field) string {
+	return field.Schema.Table + "." + field.DBName
+}
+
+// rowCompanyID picks the company of the row being written, falling back to the caller's company
+// when the model does not carry it.
+func rowCompanyID(ctx context.Context, field *schema.Field, dst reflect.Value) uint {
+	if companyField := field.Schema.LookUpField("company_id"); companyField != nil && dst.Kind() == reflect.Struct {
+		if v, zero := companyField.ValueOf(ctx, dst); !zero {
+			if id, ok := v.(uint); ok {
+				return id
+			}
+		}
+	}
+
+	companyID, _ := ctx.Value(constants.CompanyIDContextKey).(uint)
+	return companyID
+}
+
+func decodeKey(name, encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not valid base64: %w", name, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%s must be 32 bytes, got %d", name, len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns base64(nonce | ciphertext).
+func seal(aead cipher.AEAD, plaintext, aad []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, aad)), nil
+}
+
+func open(aead cipher.AEAD, sealed string, aad []byte) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	plaintext, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
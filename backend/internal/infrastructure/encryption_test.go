@@ -0,0 +1,217 @@
+package infrastructure
+
+import (
+	"basekarya-backend/internal/config"
+	"basekarya-backend/pkg/constants"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	testMasterKey     = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testNextMasterKey = "bmV4dG1hc3RlcmtleW5leHRtYXN0ZXJrZXluZXh0bWE="
+	testBlindIndexKey = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+type testSecret struct {
+	ID        uint    `gorm:"primaryKey"`
+	CompanyID uint    `gorm:"not null"`
+	Number    string  `gorm:"serializer:encrypted;type:varchar(255)"`
+	Amount    float64 `gorm:"serializer:encrypted;type:varchar(255)"`
+}
+
+func (testSecret) TableName() string {
+	return "test_secrets"
+}
+
+func setupKeyringTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	// one connection and no implicit transaction, so the key store sees the same in-memory database
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger:                 logger.Default.LogMode(logger.Silent),
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&EncryptionKey{}, &testSecret{}))
+	return db
+}
+
+func newTestKeyring(t *testing.T, db *gorm.DB, masterKey, previousMasterKey string) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyring(&config.EncryptionConfig{
+		MasterKey:         masterKey,
+		PreviousMasterKey: previousMasterKey,
+		BlindIndexKey:     testBlindIndexKey,
+	}, NewGormDataKeyStore(db))
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestNewKeyring_InvalidKey(t *testing.T) {
+	_, err := NewKeyring(&config.EncryptionConfig{MasterKey: "c2hvcnQ=", BlindIndexKey: testBlindIndexKey}, nil)
+	assert.ErrorContains(t, err, "ENCRYPTION_MASTER_KEY must be 32 bytes")
+
+	_, err = NewKeyring(&config.EncryptionConfig{MasterKey: testMasterKey, BlindIndexKey: "%%%"}, nil)
+	assert.ErrorContains(t, err, "ENCRYPTION_BLIND_INDEX_KEY is not valid base64")
+}
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	db := setupKeyringTestDB(t)
+	keyring := newTestKeyring(t, db, testMasterKey, "")
+	ctx := context.Background()
+
+	ciphertext, err := keyring.Encrypt(ctx, 7, "employees.npwp", []byte("123456789012345"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ciphertext, "enc:v1:7:1:"))
+	assert.NotContains(t, ciphertext, "123456789012345")
+
+	plaintext, err := keyring.Decrypt(ctx, "employees.npwp", ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012345", string(plaintext))
+
+	_, err = keyring.Decrypt(ctx, "employees.nik", ciphertext)
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	var keys []EncryptionKey
+	require.NoError(t, db.Find(&keys).Error)
+	require.Len(t, keys, 1)
+	assert.Equal(t, uint(7), keys[0].CompanyID)
+	assert.True(t, keys[0].Active)
+}
+
+func TestKeyring_BlindIndex(t *testing.T) {
+	keyring := newTestKeyring(t, setupKeyringTestDB(t), testMasterKey, "")
+
+	assert.Equal(t, keyring.BlindIndex("emp-001"), keyring.BlindIndex(" EMP-001 "))
+	assert.NotEqual(t, keyring.BlindIndex("EMP-001"), keyring.BlindIndex("EMP-002"))
+	assert.Len(t, keyring.BlindIndex("EMP-001"), 64)
+	assert.Empty(t, keyring.BlindIndex("  "))
+}
+
+func TestKeyring_RotateDataKey(t *testing.T) {
+	db := setupKeyringTestDB(t)
+	keyring := newTestKeyring(t, db, testMasterKey, "")
+	ctx := context.Background()
+
+	before, err := keyring.Encrypt(ctx, 1, "aad", []byte("old"))
+	require.NoError(t, err)
+
+	version, err := keyring.RotateDataKey(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	after, err := keyring.Encrypt(ctx, 1, "aad", []byte("new"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(after, "enc:v1:1:2:"))
+
+	// a fresh process has no cached keys and reads both versions from the store
+	restarted := newTestKeyring(t, db, testMasterKey, "")
+	require.NoError(t, restarted.Preload(ctx))
+	assert.Len(t, restarted.dataKeys, 2)
+	assert.Equal(t, 2, restarted.active[1].version)
+	plaintext, err := restarted.Decrypt(ctx, "aad", before)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(plaintext))
+	plaintext, err = restarted.Decrypt(ctx, "aad", after)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(plaintext))
+
+	var active int64
+	db.Model(&EncryptionKey{}).Where("company_id = ? AND active = ?", 1, true).Count(&active)
+	assert.Equal(t, int64(1), active)
+}
+
+func TestKeyring_RewrapAll(t *testing.T) {
+	db := setupKeyringTestDB(t)
+	ctx := context.Background()
+
+	ciphertext, err := newTestKeyring(t, db, testMasterKey, "").Encrypt(ctx, 1, "aad", []byte("secret"))
+	require.NoError(t, err)
+
+	rotating := newTestKeyring(t, db, testNextMasterKey, testMasterKey)
+	plaintext, err := rotating.Decrypt(ctx, "aad", ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	rewrapped, err := rotating.RewrapAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rewrapped)
+
+	rewrapped, err = rotating.RewrapAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rewrapped)
+
+	plaintext, err = newTestKeyring(t, db, testNextMasterKey, "").Decrypt(ctx, "aad", ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	_, err = newTestKeyring(t, db, testMasterKey, "").Decrypt(ctx, "aad", ciphertext)
+	assert.ErrorContains(t, err, "cannot be unwrapped")
+}
+
+func TestEncryptedSerializer(t *testing.T) {
+	db := setupKeyringTestDB(t)
+	keyring := newTestKeyring(t, db, testMasterKey, "")
+	UseKeyring(keyring)
+	t.Cleanup(func() { UseKeyring(nil) })
+	ctx := context.WithValue(context.Background(), constants.CompanyIDContextKey, uint(3))
+
+	// the only connection is busy while a value is sealed, so the key has to be cached beforehand
+	_, err := keyring.RotateDataKey(ctx, 5)
+	require.NoError(t, err)
+
+	secret := testSecret{CompanyID: 5, Number: "1234567890", Amount: 7500000}
+	require.NoError(t, db.WithContext(ctx).Create(&secret).Error)
+
+	var raw map[string]interface{}
+	require.NoError(t, db.Table("test_secrets").Where("id = ?", secret.ID).Take(&raw).Error)
+	assert.True(t, strings.HasPrefix(raw["number"].(string), "enc:v1:5:1:"), "row company wins over the caller's")
+	assert.True(t, IsCiphertext(raw["amount"].(string)))
+
+	var loaded testSecret
+	require.NoError(t, db.WithContext(ctx).First(&loaded, secret.ID).Error)
+	assert.Equal(t, "1234567890", loaded.Number)
+	assert.Equal(t, 7500000.0, loaded.Amount)
+
+	stmt := db.Model(&testSecret{}).Statement
+	require.NoError(t, stmt.Parse(&testSecret{}))
+	assert.True(t, IsEncryptedField(stmt.Schema.LookUpField("amount")))
+	value, err := DecryptField(ctx, stmt.Schema.LookUpField("amount"), raw["amount"])
+	require.NoError(t, err)
+	assert.Equal(t, 7500000.0, value)
+}
+
+func TestEncryptedSerializer_LegacyPlaintext(t *testing.T) {
+	db := setupKeyringTestDB(t)
+	UseKeyring(newTestKeyring(t, db, testMasterKey, ""))
+	t.Cleanup(func() { UseKeyring(nil) })
+
+	require.NoError(t, db.Exec("INSERT INTO test_secrets (id, company_id, number, amount) VALUES (1, 1, '0987654321', '5000000.00'), (2, 1, '', '')").Error)
+
+	var secrets []testSecret
+	require.NoError(t, db.Order("id").Find(&secrets).Error)
+	require.Len(t, secrets, 2)
+	assert.Equal(t, "0987654321", secrets[0].Number)
+	assert.Equal(t, 5000000.0, secrets[0].Amount)
+	assert.Empty(t, secrets[1].Number)
+	assert.Zero(t, secrets[1].Amount)
+}
+
+func TestEncryptedSerializer_NoKeyring(t *testing.T) {
+	db := setupKeyringTestDB(t)
+	UseKeyring(nil)
+
+	err := db.Create(&testSecret{CompanyID: 1, Number: "1"}).Error
+	assert.ErrorIs(t, err, ErrKeyringNotConfigured)
+	assert.Empty(t, BlindIndex("1"))
+}
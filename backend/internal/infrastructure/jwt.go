@@ -2,6 +2,7 @@ package infrastructure
 
 import (
 	"basekarya-backend/internal/config"
+	"basekarya-backend/pkg/constants"
 	"errors"
 	"fmt"
 	"time"
@@ -19,29 +20,51 @@ func NewJWTProvider(cfg *config.JWTConfig) *JwtProvider {
 	return &JwtProvider{
 		secretKey:      cfg.Secret,
 		issuer:         "hris-app",
-		expireDuration: time.Hour * time.Duration(cfg.ExpiresIn),
+		expireDuration: time.Minute * time.Duration(cfg.AccessExpiresInMinute),
 	}
 }
 
 type MyClaims struct {
-	UserID          uint     `json:"user_id"`
-	CompanyID       uint     `json:"company_id"`
-	IsPlatformAdmin bool     `json:"is_platform_admin"`
-	Role            string   `json:"role"`
-	EmployeeID      *uint    `json:"employee_id"`
-	Permissions     []string `json:"permissions"`
+	UserID          uint   `json:"user_id"`
+	CompanyID       uint   `json:"company_id"`
+	IsPlatformAdmin bool   `json:"is_platform_admin"`
+	Role            string `json:"role"`
+	RoleID          uint   `json:"role_id"`
+	EmployeeID      *uint  `json:"employee_id"`
+	// bumped when the user moves to another role, a token with an older version has to be refreshed
+	PermissionVersion int64 `json:"permission_version"`
+	// resolved from the role on every request, never part of the signed token
+	Permissions []string                       `json:"-"`
+	DataScopes  map[string]constants.DataScope `json:"-"`
 	jwt.RegisteredClaims
 }
 
-func (p *JwtProvider) GenerateToken(userID uint, companyID uint, isPlatformAdmin bool, role string, employeeID *uint, permissions []string) (string, error) {
+// DataScopeOf returns how far the permission reaches, permissions without a scope cover the whole company.
+func (c *MyClaims) DataScopeOf(permission string) constants.DataScope {
+	if scope, ok := c.DataScopes[permission]; ok {
+		return scope
+	}
+	return constants.DataScopeCompany
+}
+
+// AccessTTL is how long an access token stays valid, the client refreshes it with the session's refresh token.
+func (p *JwtProvider) AccessTTL() time.Duration {
+	return p.expireDuration
+}
+
+// GenerateToken issues an access token bound to a session, the token ID carries the session ID so a revoked
+// session invalidates its tokens before they expire.
+func (p *JwtProvider) GenerateToken(sessionID string, userID uint, companyID uint, isPlatformAdmin bool, role string, roleID uint, employeeID *uint, permissionVersion int64) (string, error) {
 	claims := &MyClaims{
-		UserID:          userID,
-		CompanyID:       companyID,
-		IsPlatformAdmin: isPlatformAdmin,
-		Role:            role,
-		EmployeeID:      employeeID,
-		Permissions:     permissions,
+		UserID:            userID,
+		CompanyID:         companyID,
+		IsPlatformAdmin:   isPlatformAdmin,
+		Role:              role,
+		RoleID:            roleID,
+		EmployeeID:        employeeID,
+		PermissionVersion: permissionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(p.expireDuration)),
 			Issuer:    p.issuer,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -2,6 +2,7 @@ package infrastructure
 
 import (
 	"basekarya-backend/internal/config"
+	"basekarya-backend/pkg/constants"
 	"testing"
 	"time"
 
@@ -10,10 +11,10 @@ import (
 )
 
 func TestJwtProvider_GenerateAndValidateToken(t *testing.T) {
-	p := NewJWTProvider(&config.JWTConfig{Secret: "test-secret", ExpiresIn: 1})
+	p := NewJWTProvider(&config.JWTConfig{Secret: "test-secret", AccessExpiresInMinute: 1})
 
 	employeeID := uint(10)
-	token, err := p.GenerateToken(1, 2, true, "ADMIN", &employeeID, []string{"READ", "WRITE"})
+	token, err := p.GenerateToken("sess-1", 1, 2, true, "ADMIN", 3, &employeeID, 4)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -24,14 +25,15 @@ func TestJwtProvider_GenerateAndValidateToken(t *testing.T) {
 	assert.Equal(t, uint(2), claims.CompanyID)
 	assert.True(t, claims.IsPlatformAdmin)
 	assert.Equal(t, "ADMIN", claims.Role)
+	assert.Equal(t, uint(3), claims.RoleID)
 	assert.Equal(t, &employeeID, claims.EmployeeID)
-	assert.Equal(t, []string{"READ", "WRITE"}, claims.Permissions)
+	assert.Equal(t, int64(4), claims.PermissionVersion)
 }
 
 func TestJwtProvider_ValidateToken_Expired(t *testing.T) {
-	p := NewJWTProvider(&config.JWTConfig{Secret: "test-secret", ExpiresIn: 0})
+	p := NewJWTProvider(&config.JWTConfig{Secret: "test-secret", AccessExpiresInMinute: 0})
 
-	token, err := p.GenerateToken(1, 1, false, "USER", nil, nil)
+	token, err := p.GenerateToken("sess-1", 1, 1, false, "USER", 1, nil, 0)
 	require.NoError(t, err)
 
 	time.Sleep(1 * time.Second)
@@ -41,17 +43,17 @@ func TestJwtProvider_ValidateToken_Expired(t *testing.T) {
 }
 
 func TestJwtProvider_ValidateToken_InvalidString(t *testing.T) {
-	p := NewJWTProvider(&config.JWTConfig{Secret: "test-secret", ExpiresIn: 1})
+	p := NewJWTProvider(&config.JWTConfig{Secret: "test-secret", AccessExpiresInMinute: 1})
 
 	_, err := p.ValidateToken("not.a.valid.token")
 	assert.Error(t, err)
 }
 
 func TestJwtProvider_ValidateToken_WrongSecret(t *testing.T) {
-	p1 := NewJWTProvider(&config.JWTConfig{Secret: "secret-one", ExpiresIn: 1})
-	p2 := NewJWTProvider(&config.JWTConfig{Secret: "secret-two", ExpiresIn: 1})
+	p1 := NewJWTProvider(&config.JWTConfig{Secret: "secret-one", AccessExpiresInMinute: 1})
+	p2 := NewJWTProvider(&config.JWTConfig{Secret: "secret-two", AccessExpiresInMinute: 1})
 
-	token, err := p1.GenerateToken(1, 1, false, "USER", nil, nil)
+	token, err := p1.GenerateToken("sess-1", 1, 1, false, "USER", 1, nil, 0)
 	require.NoError(t, err)
 
 	_, err = p2.ValidateToken(token)
@@ -59,11 +61,10 @@ func TestJwtProvider_ValidateToken_WrongSecret(t *testing.T) {
 }
 
 func TestJwtProvider_TokenContainsAllClaims(t *testing.T) {
-	p := NewJWTProvider(&config.JWTConfig{Secret: "my-secret", ExpiresIn: 24})
+	p := NewJWTProvider(&config.JWTConfig{Secret: "my-secret", AccessExpiresInMinute: 24})
 
 	employeeID := uint(99)
-	perms := []string{"CREATE", "READ", "UPDATE", "DELETE"}
-	token, err := p.GenerateToken(42, 7, false, "MANAGER", &employeeID, perms)
+	token, err := p.GenerateToken("sess-1", 42, 7, false, "MANAGER", 5, &employeeID, 2)
 	require.NoError(t, err)
 
 	claims, err := p.ValidateToken(token)
@@ -75,6 +76,16 @@ func TestJwtProvider_TokenContainsAllClaims(t *testing.T) {
 	assert.Equal(t, "MANAGER", claims.Role)
 	assert.NotNil(t, claims.EmployeeID)
 	assert.Equal(t, uint(99), *claims.EmployeeID)
-	assert.Equal(t, perms, claims.Permissions)
+	assert.Equal(t, uint(5), claims.RoleID)
+	assert.Equal(t, int64(2), claims.PermissionVersion)
+	assert.Nil(t, claims.Permissions, "permissions are resolved per request, not signed into the token")
 	assert.Equal(t, "hris-app", claims.Issuer)
+	assert.Equal(t, "sess-1", claims.ID)
+}
+
+func TestMyClaims_DataScopeOf(t *testing.T) {
+	claims := &MyClaims{DataScopes: map[string]constants.DataScope{"READ": constants.DataScopeDepartment}}
+
+	assert.Equal(t, constants.DataScopeDepartment, claims.DataScopeOf("READ"))
+	assert.Equal(t, constants.DataScopeCompany, claims.DataScopeOf("UPDATE"))
 }
@@ -3,9 +3,12 @@ package infrastructure
 import (
 	"basekarya-backend/internal/config"
 	"basekarya-backend/pkg/logger"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -14,22 +17,27 @@ type nominatimResponse struct {
 }
 
 type NominatimFetcher struct {
-	client *http.Client
-	url    string
+	client   *http.Client
+	url      string
+	interval time.Duration
+
+	mu          sync.Mutex
+	nextRequest time.Time
 }
 
 func NewNominatimFetcher(cfg *config.ExternalServiceConfig, client *http.Client) *NominatimFetcher {
 	return &NominatimFetcher{
-		client: client,
-		url:    cfg.NominatimUrl,
+		client:   client,
+		url:      cfg.NominatimUrl,
+		interval: time.Duration(cfg.NominatimRateLimitMs) * time.Millisecond,
 	}
 }
 
-func (n *NominatimFetcher) GetAddressFromCoords(lat, long float64) string {
+func (n *NominatimFetcher) GetAddressFromCoords(ctx context.Context, lat, long float64) (string, error) {
 	url := fmt.Sprintf(n.url, lat, long)
 
 	maxRetries := 3
-	var lastErr error
+	lastErr := errors.New("empty address from nominatim")
 
 	for i := 0; i < maxRetries; i++ {
 		if i > 0 {
@@ -37,9 +45,13 @@ func (n *NominatimFetcher) GetAddressFromCoords(lat, long float64) string {
 			logger.Infof("Retrying nominatim fetch... attempt %d", i+1)
 		}
 
-		req, err := http.NewRequest("GET", url, nil)
+		if err := n.wait(ctx); err != nil {
+			return "", err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
-			return fmt.Sprintf("%f, %f", lat, long)
+			return "", err
 		}
 
 		req.Header.Set("User-Agent", "HRIS-App-Backend/2.8.2 (taufik@januar35@gmail.com)")
@@ -56,14 +68,42 @@ func (n *NominatimFetcher) GetAddressFromCoords(lat, long float64) string {
 
 		if decodeErr != nil {
 			logger.Errorw("failed decode JSON", decodeErr)
-			return fmt.Sprintf("%f, %f", lat, long)
+			return "", decodeErr
 		}
 
 		if result.DisplayName != "" {
-			return result.DisplayName
+			return result.DisplayName, nil
 		}
 	}
 
 	logger.Warnf("failed to fetch nominatim after retries: %v", lastErr)
-	return fmt.Sprintf("Unknown Location (%f, %f)", lat, long)
+	return "", fmt.Errorf("nominatim unavailable: %w", lastErr)
+}
+
+// wait blocks until the shared rate limit allows the next outgoing request,
+// so any number of workers together stay within the provider usage policy.
+func (n *NominatimFetcher) wait(ctx context.Context) error {
+	n.mu.Lock()
+	now := time.Now()
+	slot := n.nextRequest
+	if slot.Before(now) {
+		slot = now
+	}
+	n.nextRequest = slot.Add(n.interval)
+	n.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -2,10 +2,12 @@ package infrastructure
 
 import (
 	"basekarya-backend/internal/config"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -23,7 +25,8 @@ func TestNominatimFetcher_GetAddressFromCoords_Success(t *testing.T) {
 		server.Client(),
 	)
 
-	result := fetcher.GetAddressFromCoords(39.7817, -89.6501)
+	result, err := fetcher.GetAddressFromCoords(context.Background(), 39.7817, -89.6501)
+	assert.NoError(t, err)
 	assert.Equal(t, "123 Main St, Springfield, IL", result)
 }
 
@@ -40,9 +43,9 @@ func TestNominatimFetcher_GetAddressFromCoords_EmptyDisplayName(t *testing.T) {
 		server.Client(),
 	)
 
-	result := fetcher.GetAddressFromCoords(39.7817, -89.6501)
-	assert.Contains(t, result, "39.781700")
-	assert.Contains(t, result, "-89.650100")
+	result, err := fetcher.GetAddressFromCoords(context.Background(), 39.7817, -89.6501)
+	assert.Error(t, err)
+	assert.Empty(t, result)
 }
 
 func TestNominatimFetcher_GetAddressFromCoords_AllRetriesFail(t *testing.T) {
@@ -56,6 +59,29 @@ func TestNominatimFetcher_GetAddressFromCoords_AllRetriesFail(t *testing.T) {
 		client,
 	)
 
-	result := fetcher.GetAddressFromCoords(39.7817, -89.6501)
-	assert.Contains(t, result, "Unknown Location")
+	result, err := fetcher.GetAddressFromCoords(context.Background(), 39.7817, -89.6501)
+	assert.ErrorContains(t, err, "nominatim unavailable")
+	assert.Empty(t, result)
+}
+
+func TestNominatimFetcher_GetAddressFromCoords_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := nominatimResponse{DisplayName: "Jl. Sudirman, Jakarta"}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	fetcher := NewNominatimFetcher(
+		&config.ExternalServiceConfig{NominatimUrl: server.URL + "?lat=%f&lon=%f", NominatimRateLimitMs: 200},
+		server.Client(),
+	)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := fetcher.GetAddressFromCoords(context.Background(), -6.2, 106.8)
+		assert.NoError(t, err)
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
 }
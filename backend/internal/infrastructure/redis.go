@@ -46,6 +46,16 @@ func (r *RedisClientProvider) Get(ctx context.Context, key string) (string, erro
 	return r.Client.Get(ctx, key).Result()
 }
 
+// SetNX sets the key only when it does not exist yet, it reports whether this call set it.
+func (r *RedisClientProvider) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, key, value, expiration).Result()
+}
+
+// GetDel reads the key and deletes it in one step, of concurrent callers only one gets the value.
+func (r *RedisClientProvider) GetDel(ctx context.Context, key string) (string, error) {
+	return r.Client.GetDel(ctx, key).Result()
+}
+
 func (r *RedisClientProvider) Del(ctx context.Context, key string) error {
 	return r.Client.Del(ctx, key).Err()
 }
@@ -53,3 +63,22 @@ func (r *RedisClientProvider) Del(ctx context.Context, key string) error {
 func (r *RedisClientProvider) FlushDB(ctx context.Context) error {
 	return r.Client.FlushDB(ctx).Err()
 }
+
+// Incr increments a counter, the expiration starts when the counter is created.
+func (r *RedisClientProvider) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	count, err := r.Client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := r.Client.Expire(ctx, key, expiration).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// Decr takes back a count made with Incr, the expiration of the counter is left as it is.
+func (r *RedisClientProvider) Decr(ctx context.Context, key string) error {
+	return r.Client.Decr(ctx, key).Err()
+}
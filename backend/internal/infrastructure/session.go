@@ -0,0 +1,295 @@
+package infrastructure
+
+import (
+	"basekarya-backend/internal/config"
+	"basekarya-backend/pkg/constants"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrSessionNotFound     = errors.New("session not found or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token already used, session revoked")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+)
+
+// sessionTouchInterval throttles last seen updates so every request does not write to redis.
+const sessionTouchInterval = time.Minute
+
+// rotateAttempts bounds how often Rotate starts over when the session changed while it was being rotated.
+const rotateAttempts = 3
+
+// Session is a signed in device, it lives in redis for as long as its refresh token is valid.
+type Session struct {
+	ID          string    `json:"id"`
+	UserID      uint      `json:"user_id"`
+	CompanyID   uint      `json:"company_id"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	RefreshHash string    `json:"refresh_hash"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type SessionStore struct {
+	redis *RedisClientProvider
+	ttl   time.Duration
+}
+
+func NewSessionStore(redis *RedisClientProvider, cfg *config.JWTConfig) *SessionStore {
+	return &SessionStore{
+		redis: redis,
+		ttl:   time.Hour * 24 * time.Duration(cfg.RefreshExpiresInDay),
+	}
+}
+
+// Create starts a session and returns it with its refresh token, only the hash of the token is stored.
+func (s *SessionStore) Create(ctx context.Context, userID, companyID uint, userAgent, ipAddress string) (*Session, string, error) {
+	now := time.Now()
+	session := &Session{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		CompanyID:  companyID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	refreshToken, err := s.issueRefreshToken(session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.save(ctx, session); err != nil {
+		return nil, "", err
+	}
+
+	return session, refreshToken, nil
+}
+
+// Rotate exchanges a refresh token for a new one, presenting a token that was already rotated means it leaked
+// so the whole session is revoked. The hash is compared and replaced under WATCH, of two refreshes racing with
+// the same token only one gets a new token.
+func (s *SessionStore) Rotate(ctx context.Context, refreshToken string) (*Session, string, error) {
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	key := fmt.Sprintf(constants.SESSION_CACHE_KEY, sessionID)
+	for attempt := 0; attempt < rotateAttempts; attempt++ {
+		var session *Session
+		var newToken string
+		err := s.redis.Client.Watch(ctx, func(tx *redis.Tx) error {
+			raw, err := tx.Get(ctx, key).Result()
+			if errors.Is(err, redis.Nil) {
+				return ErrSessionNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal([]byte(raw), &session); err != nil {
+				return fmt.Errorf("failed to parse session: %w", err)
+			}
+
+			if subtle.ConstantTimeCompare([]byte(session.RefreshHash), []byte(hashSecret(secret))) != 1 {
+				return ErrRefreshTokenReused
+			}
+
+			newToken, err = s.issueRefreshToken(session)
+			if err != nil {
+				return err
+			}
+			session.LastSeenAt = time.Now()
+			payload, err := json.Marshal(session)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				s.queueSave(ctx, pipe, session, payload)
+				return nil
+			})
+			return err
+		}, key)
+
+		switch {
+		case errors.Is(err, redis.TxFailedErr):
+			// the session changed under the watch, the next attempt sees the new hash
+			continue
+		case errors.Is(err, ErrRefreshTokenReused):
+			_ = s.Revoke(ctx, session.UserID, session.ID)
+			return nil, "", err
+		case err != nil:
+			return nil, "", err
+		}
+		return session, newToken, nil
+	}
+
+	return nil, "", fmt.Errorf("failed to rotate session: %w", redis.TxFailedErr)
+}
+
+// Find returns the session with the last activity recorded by IsActive.
+func (s *SessionStore) Find(ctx context.Context, sessionID string) (*Session, error) {
+	raw, err := s.redis.Get(ctx, fmt.Sprintf(constants.SESSION_CACHE_KEY, sessionID))
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(raw), &session); err != nil {
+		return nil, fmt.Errorf("failed to parse session: %w", err)
+	}
+
+	if lastSeen, ok := s.lastSeen(ctx, sessionID); ok && lastSeen.After(session.LastSeenAt) {
+		session.LastSeenAt = lastSeen
+	}
+
+	return &session, nil
+}
+
+// IsActive reports whether the session behind an access token is still signed in and records the activity.
+// Activity goes to a key of its own, the session itself is only written by Create and Rotate so a touch can
+// never put back a refresh hash that was rotated meanwhile.
+func (s *SessionStore) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	exists, err := s.redis.Client.Exists(ctx, fmt.Sprintf(constants.SESSION_CACHE_KEY, sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	if exists == 0 {
+		return false, nil
+	}
+
+	if lastSeen, ok := s.lastSeen(ctx, sessionID); !ok || time.Since(lastSeen) >= sessionTouchInterval {
+		key := fmt.Sprintf(constants.SESSION_LAST_SEEN_CACHE_KEY, sessionID)
+		if err := s.redis.Client.Set(ctx, key, time.Now().Unix(), s.ttl).Err(); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// ListByUser returns the active sessions of a user, ids of expired sessions are dropped from the index.
+func (s *SessionStore) ListByUser(ctx context.Context, userID uint) ([]Session, error) {
+	userKey := fmt.Sprintf(constants.USER_SESSIONS_CACHE_KEY, userID)
+	ids, err := s.redis.Client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(ids))
+	for _, id := range ids {
+		session, err := s.Find(ctx, id)
+		if errors.Is(err, ErrSessionNotFound) {
+			_ = s.redis.Client.SRem(ctx, userKey, id).Err()
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, nil
+}
+
+// Revoke signs out a single session of the user, a session of another user is reported as not found.
+func (s *SessionStore) Revoke(ctx context.Context, userID uint, sessionID string) error {
+	session, err := s.Find(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	pipe := s.redis.Client.TxPipeline()
+	pipe.Del(ctx, fmt.Sprintf(constants.SESSION_CACHE_KEY, sessionID), fmt.Sprintf(constants.SESSION_LAST_SEEN_CACHE_KEY, sessionID))
+	pipe.SRem(ctx, fmt.Sprintf(constants.USER_SESSIONS_CACHE_KEY, userID), sessionID)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// RevokeUser signs out every session of the user.
+func (s *SessionStore) RevokeUser(ctx context.Context, userID uint) error {
+	userKey := fmt.Sprintf(constants.USER_SESSIONS_CACHE_KEY, userID)
+	ids, err := s.redis.Client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, 2*len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, fmt.Sprintf(constants.SESSION_CACHE_KEY, id), fmt.Sprintf(constants.SESSION_LAST_SEEN_CACHE_KEY, id))
+	}
+	keys = append(keys, userKey)
+
+	return s.redis.Client.Del(ctx, keys...).Err()
+}
+
+// issueRefreshToken sets a fresh secret on the session and slides its expiry, the token is prefixed with the
+// session id so it can be looked up.
+func (s *SessionStore) issueRefreshToken(session *Session) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	secret := hex.EncodeToString(buf)
+
+	session.RefreshHash = hashSecret(secret)
+	session.ExpiresAt = time.Now().Add(s.ttl)
+
+	return session.ID + "." + secret, nil
+}
+
+// save stores the session until its refresh token expires and keeps it in the user's index. The index expiry slides
+// with every save, an index expiring before a session kept alive by refreshes would hide it from RevokeUser.
+func (s *SessionStore) save(ctx context.Context, session *Session) error {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.redis.Client.TxPipeline()
+	s.queueSave(ctx, pipe, session, payload)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *SessionStore) queueSave(ctx context.Context, pipe redis.Pipeliner, session *Session, payload []byte) {
+	userKey := fmt.Sprintf(constants.USER_SESSIONS_CACHE_KEY, session.UserID)
+	pipe.Set(ctx, fmt.Sprintf(constants.SESSION_CACHE_KEY, session.ID), payload, time.Until(session.ExpiresAt))
+	pipe.SAdd(ctx, userKey, session.ID)
+	pipe.Expire(ctx, userKey, s.ttl)
+}
+
+// lastSeen reads the activity recorded by IsActive, ok is false when nothing was recorded yet.
+func (s *SessionStore) lastSeen(ctx context.Context, sessionID string) (time.Time, bool) {
+	unix, err := s.redis.Client.Get(ctx, fmt.Sprintf(constants.SESSION_LAST_SEEN_CACHE_KEY, sessionID)).Int64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,200 @@
+package infrastructure
+
+import (
+	"basekarya-backend/internal/config"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSessionStore(t *testing.T) *SessionStore {
+	rdb, mr := setupRedis(t)
+	t.Cleanup(mr.Close)
+
+	return NewSessionStore(&RedisClientProvider{Client: rdb}, &config.JWTConfig{RefreshExpiresInDay: 1})
+}
+
+func TestSessionStore_CreateAndRotate(t *testing.T) {
+	store := setupSessionStore(t)
+	ctx := context.Background()
+
+	session, refreshToken, err := store.Create(ctx, 1, 2, "Mozilla/5.0", "10.0.0.1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, session.ID)
+	assert.Contains(t, refreshToken, session.ID+".")
+	assert.Equal(t, hashSecret(strings.TrimPrefix(refreshToken, session.ID+".")), session.RefreshHash)
+
+	rotated, newToken, err := store.Rotate(ctx, refreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, rotated.ID)
+	assert.NotEqual(t, refreshToken, newToken)
+
+	active, err := store.IsActive(ctx, session.ID)
+	require.NoError(t, err)
+	assert.True(t, active)
+}
+
+func TestSessionStore_Rotate_ReusedTokenRevokesSession(t *testing.T) {
+	store := setupSessionStore(t)
+	ctx := context.Background()
+
+	session, refreshToken, err := store.Create(ctx, 1, 2, "", "")
+	require.NoError(t, err)
+	_, _, err = store.Rotate(ctx, refreshToken)
+	require.NoError(t, err)
+
+	_, _, err = store.Rotate(ctx, refreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	active, err := store.IsActive(ctx, session.ID)
+	require.NoError(t, err)
+	assert.False(t, active)
+}
+
+func TestSessionStore_Rotate_InvalidToken(t *testing.T) {
+	store := setupSessionStore(t)
+	ctx := context.Background()
+
+	_, _, err := store.Rotate(ctx, "garbage")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	_, _, err = store.Rotate(ctx, "unknown.secret")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestSessionStore_IsActive_TouchesLastSeen(t *testing.T) {
+	store := setupSessionStore(t)
+	ctx := context.Background()
+
+	session, _, err := store.Create(ctx, 1, 2, "", "")
+	require.NoError(t, err)
+
+	session.LastSeenAt = time.Now().Add(-time.Hour)
+	require.NoError(t, store.save(ctx, session))
+
+	active, err := store.IsActive(ctx, session.ID)
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	found, err := store.Find(ctx, session.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), found.LastSeenAt, 5*time.Second)
+
+	ttl := store.redis.Client.TTL(ctx, "session:"+session.ID).Val()
+	assert.Greater(t, ttl, time.Duration(0))
+}
+
+func TestSessionStore_IsActive_KeepsRotatedRefreshToken(t *testing.T) {
+	store := setupSessionStore(t)
+	ctx := context.Background()
+
+	session, token, err := store.Create(ctx, 1, 2, "", "")
+	require.NoError(t, err)
+	session.LastSeenAt = time.Now().Add(-time.Hour)
+	require.NoError(t, store.save(ctx, session))
+
+	_, rotated, err := store.Rotate(ctx, token)
+	require.NoError(t, err)
+	before := store.redis.Client.Get(ctx, "session:"+session.ID).Val()
+
+	active, err := store.IsActive(ctx, session.ID)
+	require.NoError(t, err)
+	assert.True(t, active)
+	assert.Equal(t, before, store.redis.Client.Get(ctx, "session:"+session.ID).Val())
+
+	_, _, err = store.Rotate(ctx, rotated)
+	assert.NoError(t, err)
+}
+
+func TestSessionStore_Rotate_ConcurrentRefreshesOnlyOneWins(t *testing.T) {
+	store := setupSessionStore(t)
+	ctx := context.Background()
+
+	_, token, err := store.Create(ctx, 1, 2, "", "")
+	require.NoError(t, err)
+
+	const refreshes = 8
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < refreshes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := store.Rotate(ctx, token); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, succeeded, 1)
+}
+
+func TestSessionStore_ListAndRevoke(t *testing.T) {
+	store := setupSessionStore(t)
+	ctx := context.Background()
+
+	first, _, err := store.Create(ctx, 1, 2, "laptop", "")
+	require.NoError(t, err)
+	second, _, err := store.Create(ctx, 1, 2, "phone", "")
+	require.NoError(t, err)
+	other, _, err := store.Create(ctx, 9, 2, "other", "")
+	require.NoError(t, err)
+
+	sessions, err := store.ListByUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	assert.ErrorIs(t, store.Revoke(ctx, 1, other.ID), ErrSessionNotFound)
+	require.NoError(t, store.Revoke(ctx, 1, first.ID))
+
+	sessions, err = store.ListByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, second.ID, sessions[0].ID)
+
+	require.NoError(t, store.RevokeUser(ctx, 1))
+	sessions, err = store.ListByUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	active, err := store.IsActive(ctx, other.ID)
+	require.NoError(t, err)
+	assert.True(t, active)
+}
+
+func TestSessionStore_RevokeUser_AfterRefreshingPastLoginTTL(t *testing.T) {
+	rdb, mr := setupRedis(t)
+	t.Cleanup(mr.Close)
+	store := NewSessionStore(&RedisClientProvider{Client: rdb}, &config.JWTConfig{RefreshExpiresInDay: 1})
+	ctx := context.Background()
+
+	session, refreshToken, err := store.Create(ctx, 1, 2, "", "")
+	require.NoError(t, err)
+
+	// refreshed within every TTL window, the session outlives the TTL set at login
+	mr.FastForward(20 * time.Hour)
+	_, refreshToken, err = store.Rotate(ctx, refreshToken)
+	require.NoError(t, err)
+	mr.FastForward(20 * time.Hour)
+	_, _, err = store.Rotate(ctx, refreshToken)
+	require.NoError(t, err)
+
+	sessions, err := store.ListByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	require.NoError(t, store.RevokeUser(ctx, 1))
+
+	active, err := store.IsActive(ctx, session.ID)
+	require.NoError(t, err)
+	assert.False(t, active)
+}
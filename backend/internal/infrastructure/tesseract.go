@@ -0,0 +1,48 @@
+package infrastructure
+
+import (
+	"basekarya-backend/internal/config"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// OCRProvider reads the text of an image.
+type OCRProvider interface {
+	ExtractText(ctx context.Context, image []byte) (string, error)
+}
+
+// TesseractOCR runs the local tesseract binary, receipts never leave the server.
+type TesseractOCR struct {
+	binary  string
+	lang    string
+	timeout time.Duration
+}
+
+func NewTesseractOCR(cfg *config.ExternalServiceConfig) *TesseractOCR {
+	return &TesseractOCR{
+		binary:  cfg.TesseractPath,
+		lang:    cfg.TesseractLang,
+		timeout: time.Duration(cfg.TesseractTimeoutSec) * time.Second,
+	}
+}
+
+func (t *TesseractOCR) ExtractText(ctx context.Context, image []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.binary, "stdin", "stdout", "-l", t.lang)
+	cmd.Stdin = bytes.NewReader(image)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
@@ -3,6 +3,7 @@ package infrastructure
 import (
 	"context"
 	"basekarya-backend/pkg/constants"
+	"sync"
 
 	"gorm.io/gorm"
 )
@@ -19,14 +20,50 @@ func NewGormTransactionManager(db *gorm.DB) TransactionManager {
 	return &gormTransactionManager{db}
 }
 
+// afterCommitHooks collects work that may only happen once the transaction commits.
+type afterCommitHooks struct {
+	mu    sync.Mutex
+	hooks []func()
+}
+
 func (t *gormTransactionManager) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
 	// check if already in transaction
 	if _, ok := ctx.Value(constants.TxContextKey).(*gorm.DB); ok {
 		return fn(ctx)
 	}
 
-	return t.db.Transaction(func(tx *gorm.DB) error {
+	pending := &afterCommitHooks{}
+	ctx = context.WithValue(ctx, constants.AfterCommitContextKey, pending)
+
+	err := t.db.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
 		txCtx := context.WithValue(ctx, constants.TxContextKey, tx)
 		return fn(txCtx)
 	})
+	if err != nil {
+		return err
+	}
+
+	pending.mu.Lock()
+	hooks := pending.hooks
+	pending.hooks = nil
+	pending.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction started by RunInTransaction commits, fn is
+// dropped on rollback. Outside such a transaction fn runs right away.
+func AfterCommit(ctx context.Context, fn func()) {
+	pending, ok := ctx.Value(constants.AfterCommitContextKey).(*afterCommitHooks)
+	if !ok {
+		fn()
+		return
+	}
+
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+	pending.hooks = append(pending.hooks, fn)
 }
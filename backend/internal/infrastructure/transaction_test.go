@@ -90,3 +90,40 @@ func TestRunInTransaction_ReusesExistingTx(t *testing.T) {
 	require.NoError(t, db.First(&found, "name = ?", "INNER").Error)
 	assert.Equal(t, "INNER", found.Name)
 }
+
+func TestAfterCommit(t *testing.T) {
+	_, tm := setupTransactionTestDB(t)
+
+	t.Run("runs once the transaction commits", func(t *testing.T) {
+		var ran []string
+		err := tm.RunInTransaction(context.Background(), func(ctx context.Context) error {
+			AfterCommit(ctx, func() { ran = append(ran, "outer") })
+
+			return tm.RunInTransaction(ctx, func(ctx context.Context) error {
+				AfterCommit(ctx, func() { ran = append(ran, "inner") })
+				assert.Empty(t, ran)
+				return nil
+			})
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer", "inner"}, ran)
+	})
+
+	t.Run("dropped on rollback", func(t *testing.T) {
+		ran := false
+		err := tm.RunInTransaction(context.Background(), func(ctx context.Context) error {
+			AfterCommit(ctx, func() { ran = true })
+			return assert.AnError
+		})
+
+		assert.Error(t, err)
+		assert.False(t, ran)
+	})
+
+	t.Run("runs right away outside a transaction", func(t *testing.T) {
+		ran := false
+		AfterCommit(context.Background(), func() { ran = true })
+		assert.True(t, ran)
+	})
+}
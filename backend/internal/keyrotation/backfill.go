@@ -0,0 +1,33 @@
+package keyrotation
+
+import (
+	"context"
+
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// BackfillBlindIndexes encrypts and indexes the employees still lacking a NIK blind index, rows carried over by
+// migration 000045 in plaintext. Lookups and NIK uniqueness go through the index, so it runs on every start
+// before anything is served and costs a single query once no row is left. keyring must be set with UseKeyring.
+func BackfillBlindIndexes(db *gorm.DB) error {
+	ctx := context.Background()
+
+	var pending int64
+	if err := db.WithContext(ctx).Model(&user.Employee{}).Where("nik_bidx IS NULL").Count(&pending).Error; err != nil {
+		return err
+	}
+	if pending == 0 {
+		return nil
+	}
+
+	count, err := rewriteEmployees(ctx, db, db.WithContext(ctx).Where("nik_bidx IS NULL"))
+	if err != nil {
+		logger.Errorf("Backfilling NIK blind indexes failed: %v", err)
+		return err
+	}
+	logger.Infof("Encrypted and indexed %d employees without a NIK blind index", count)
+	return nil
+}
@@ -0,0 +1,109 @@
+package keyrotation
+
+import (
+	"context"
+
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const batchSize = 200
+
+// sensitiveEmployeeColumns are the encrypted employee columns and the blind index derived from them.
+var sensitiveEmployeeColumns = []string{"nik", "nik_bidx", "base_salary", "bank_account_number", "npwp"}
+
+// sensitiveEventColumns are the encrypted salaries in the employment history.
+var sensitiveEventColumns = []string{"base_salary", "previous_base_salary"}
+
+// Execute rotates the keys of encrypted columns. Every data key is wrapped again with the current
+// master key, after which ENCRYPTION_PREVIOUS_MASTER_KEY can be dropped. Then every company gets a
+// new data key and its employees are encrypted again with it, which also encrypts rows still in
+// plaintext and fills in missing blind indexes. The salaries in the employment history follow the same way. keyring must be the one set with UseKeyring.
+func Execute(db *gorm.DB, keyring *infrastructure.Keyring) error {
+	ctx := context.Background()
+
+	rewrapped, err := keyring.RewrapAll(ctx)
+	if err != nil {
+		logger.Errorf("Rewrapping data keys failed: %v", err)
+		return err
+	}
+	logger.Infof("Rewrapped %d data keys with the current master key", rewrapped)
+
+	var companyIDs []uint
+	if err := db.Model(&user.Employee{}).Distinct().Pluck("company_id", &companyIDs).Error; err != nil {
+		return err
+	}
+
+	for _, companyID := range companyIDs {
+		version, err := keyring.RotateDataKey(ctx, companyID)
+		if err != nil {
+			logger.Errorf("Rotating data key of company %d failed: %v", companyID, err)
+			return err
+		}
+
+		count, err := reencryptEmployees(ctx, db, companyID)
+		if err != nil {
+			logger.Errorf("Re-encrypting employees of company %d failed: %v", companyID, err)
+			return err
+		}
+		events, err := reencryptEmploymentEvents(ctx, db, companyID)
+		if err != nil {
+			logger.Errorf("Re-encrypting employment events of company %d failed: %v", companyID, err)
+			return err
+		}
+		logger.Infof("Company %d: data key version %d, %d employees and %d employment events re-encrypted", companyID, version, count, events)
+	}
+
+	logger.Info("Key rotation completed successfully!")
+	return nil
+}
+
+// reencryptEmployees loads the employees of a company, which decrypts them with whatever key they were
+// written with, and writes the sensitive columns back with the company's active data key.
+func reencryptEmployees(ctx context.Context, db *gorm.DB, companyID uint) (int, error) {
+	return rewriteEmployees(ctx, db, db.WithContext(ctx).Where("company_id = ?", companyID))
+}
+
+// rewriteEmployees writes the sensitive columns of the employees matched by query back with the active data key of
+// their company.
+func rewriteEmployees(ctx context.Context, db *gorm.DB, query *gorm.DB) (int, error) {
+	var employees []user.Employee
+	count := 0
+
+	err := query.FindInBatches(&employees, batchSize, func(tx *gorm.DB, batch int) error {
+		for i := range employees {
+			emp := &employees[i]
+			// UpdateColumns skips hooks, so the blind index is set here
+			emp.NIKIndex = infrastructure.BlindIndex(emp.NIK)
+
+			if err := db.WithContext(ctx).Model(emp).Select(sensitiveEmployeeColumns).UpdateColumns(emp).Error; err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	}).Error
+
+	return count, err
+}
+
+// reencryptEmploymentEvents writes the salaries of the employment history of a company back with its active data key.
+func reencryptEmploymentEvents(ctx context.Context, db *gorm.DB, companyID uint) (int, error) {
+	var events []user.EmploymentEvent
+	count := 0
+
+	err := db.WithContext(ctx).Where("company_id = ?", companyID).FindInBatches(&events, batchSize, func(tx *gorm.DB, batch int) error {
+		for i := range events {
+			if err := db.WithContext(ctx).Model(&events[i]).Select(sensitiveEventColumns).UpdateColumns(&events[i]).Error; err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	}).Error
+
+	return count, err
+}
@@ -0,0 +1,99 @@
+package keyrotation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/department"
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/internal/modules/rbac"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecute(t *testing.T) {
+	tdb := testutil.NewTestDB(&rbac.Role{}, &department.Department{}, &master.Shift{}, &user.User{}, &user.Employee{}, &user.EmploymentEvent{})
+	t.Cleanup(tdb.Close)
+	db := tdb.DB
+
+	keyring := testutil.NewTestKeyring()
+	infrastructure.UseKeyring(keyring)
+
+	encrypted := &user.Employee{UserID: 1, CompanyID: 1, NIK: "EMP001", FullName: "Jane", BaseSalary: 5000000, NPWP: "123456789012345"}
+	require.NoError(t, db.Create(encrypted).Error)
+	// written before encryption existed
+	require.NoError(t, db.Exec("INSERT INTO employees (id, user_id, company_id, nik, full_name, base_salary, bank_account_number) VALUES (2, 2, 2, 'EMP002', 'John', '4000000.00', '1234567890')").Error)
+
+	// hire carried over by the migration with the salary still in plaintext
+	require.NoError(t, db.Exec("INSERT INTO employment_events (id, employee_id, company_id, type, effective_date, base_salary) VALUES (1, 2, 2, 'HIRE', '2024-01-02', '4000000.00')").Error)
+
+	require.NoError(t, Execute(db, keyring))
+
+	var rows []map[string]interface{}
+	require.NoError(t, db.Table("employees").Order("id").Find(&rows).Error)
+	require.Len(t, rows, 2)
+	assert.True(t, strings.HasPrefix(rows[0]["nik"].(string), "enc:v1:1:2:"), "company 1 moves to a new data key")
+	assert.True(t, strings.HasPrefix(rows[1]["nik"].(string), "enc:v1:2:1:"))
+	assert.True(t, strings.HasPrefix(rows[1]["bank_account_number"].(string), "enc:v1:2:1:"))
+	assert.Equal(t, keyring.BlindIndex("EMP002"), rows[1]["nik_bidx"])
+
+	var employees []user.Employee
+	require.NoError(t, db.WithContext(context.Background()).Order("id").Find(&employees).Error)
+	assert.Equal(t, "EMP001", employees[0].NIK)
+	assert.Equal(t, 5000000.0, employees[0].BaseSalary)
+	assert.Equal(t, "123456789012345", employees[0].NPWP)
+	assert.Equal(t, "EMP002", employees[1].NIK)
+	assert.Equal(t, 4000000.0, employees[1].BaseSalary)
+	assert.Equal(t, "1234567890", employees[1].BankAccountNumber)
+
+	var salary string
+	require.NoError(t, db.Table("employment_events").Where("id = ?", 1).Pluck("base_salary", &salary).Error)
+	assert.True(t, strings.HasPrefix(salary, "enc:v1:2:1:"))
+
+	var event user.EmploymentEvent
+	require.NoError(t, db.First(&event, 1).Error)
+	assert.Equal(t, 4000000.0, event.BaseSalary)
+}
+
+func TestBackfillBlindIndexes(t *testing.T) {
+	tdb := testutil.NewTestDB(&rbac.Role{}, &department.Department{}, &master.Shift{}, &user.User{}, &user.Employee{})
+	t.Cleanup(tdb.Close)
+	db := tdb.DB
+
+	keyring := testutil.NewTestKeyring()
+	infrastructure.UseKeyring(keyring)
+
+	indexed := &user.Employee{UserID: 1, CompanyID: 1, NIK: "EMP001", FullName: "Jane"}
+	require.NoError(t, db.Create(indexed).Error)
+	// carried over by migration 000045
+	require.NoError(t, db.Exec("INSERT INTO employees (id, user_id, company_id, nik, full_name, npwp) VALUES (2, 2, 2, 'EMP002', 'John', '123456789012345')").Error)
+
+	var before string
+	require.NoError(t, db.Table("employees").Where("id = ?", 1).Pluck("nik", &before).Error)
+
+	require.NoError(t, BackfillBlindIndexes(db))
+
+	var rows []map[string]interface{}
+	require.NoError(t, db.Table("employees").Order("id").Find(&rows).Error)
+	assert.Equal(t, before, rows[0]["nik"], "indexed rows are left alone")
+	assert.Equal(t, keyring.BlindIndex("EMP002"), rows[1]["nik_bidx"])
+	assert.True(t, strings.HasPrefix(rows[1]["nik"].(string), "enc:v1:2:"))
+	assert.True(t, strings.HasPrefix(rows[1]["npwp"].(string), "enc:v1:2:"))
+
+	var pending int64
+	require.NoError(t, db.Model(&user.Employee{}).Where("nik_bidx IS NULL").Count(&pending).Error)
+	assert.Zero(t, pending)
+
+	// the employee can be found by NIK again
+	var found user.Employee
+	require.NoError(t, db.Where("nik_bidx = ?", infrastructure.BlindIndex("emp002")).First(&found).Error)
+	assert.Equal(t, "EMP002", found.NIK)
+	assert.Equal(t, "123456789012345", found.NPWP)
+
+	require.NoError(t, BackfillBlindIndexes(db))
+}
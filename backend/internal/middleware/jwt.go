@@ -13,13 +13,27 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+type SessionValidator interface {
+	IsActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// PermissionResolver looks up what a role may do and which permission version a user's tokens must carry.
+type PermissionResolver interface {
+	RolePermissions(ctx context.Context, roleID uint) ([]string, map[string]constants.DataScope, error)
+	PermissionVersion(ctx context.Context, userID uint) (int64, error)
+}
+
 type AuthMiddleware struct {
 	jwtProvider *infrastructure.JwtProvider
+	sessions    SessionValidator
+	permissions PermissionResolver
 }
 
-func NewAuthMiddleware(jwtProvider *infrastructure.JwtProvider) *AuthMiddleware {
+func NewAuthMiddleware(jwtProvider *infrastructure.JwtProvider, sessions SessionValidator, permissions PermissionResolver) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtProvider: jwtProvider,
+		sessions:    sessions,
+		permissions: permissions,
 	}
 }
 
@@ -48,11 +62,40 @@ func (m *AuthMiddleware) VerifyToken(next echo.HandlerFunc) echo.HandlerFunc {
 			return response.NewResponses[any](ctx, http.StatusUnauthorized, "invalid or expired token", nil, err, nil)
 		}
 
+		// a signed out or revoked session rejects its access tokens before they expire
+		if claims.ID == "" {
+			return response.NewResponses[any](ctx, http.StatusUnauthorized, "invalid or expired token", nil, nil, nil)
+		}
+		active, err := m.sessions.IsActive(ctx.Request().Context(), claims.ID)
+		if err != nil {
+			return response.NewResponses[any](ctx, http.StatusUnauthorized, "failed to verify session", nil, err, nil)
+		}
+		if !active {
+			return response.NewResponses[any](ctx, http.StatusUnauthorized, "session expired or revoked", nil, nil, nil)
+		}
+
+		// a user moved to another role refreshes the token to act under the new one
+		version, err := m.permissions.PermissionVersion(ctx.Request().Context(), claims.UserID)
+		if err != nil {
+			return response.NewResponses[any](ctx, http.StatusUnauthorized, "failed to verify permissions", nil, err, nil)
+		}
+		if version != claims.PermissionVersion {
+			return response.NewResponses[any](ctx, http.StatusUnauthorized, "permissions changed, refresh the token", nil, nil, nil)
+		}
+
+		claims.Permissions, claims.DataScopes, err = m.permissions.RolePermissions(ctx.Request().Context(), claims.RoleID)
+		if err != nil {
+			return response.NewResponses[any](ctx, http.StatusUnauthorized, "failed to verify permissions", nil, err, nil)
+		}
+
 		ctx.Set("user", claims)
 
 		stdCtx := context.WithValue(ctx.Request().Context(), constants.CompanyIDContextKey, claims.CompanyID)
 		stdCtx = context.WithValue(stdCtx, constants.IsPlatformAdminContextKey, claims.IsPlatformAdmin)
 		stdCtx = context.WithValue(stdCtx, constants.UserIDContextKey, claims.UserID)
+		if claims.EmployeeID != nil {
+			stdCtx = context.WithValue(stdCtx, constants.EmployeeIDContextKey, *claims.EmployeeID)
+		}
 		ctx.SetRequest(ctx.Request().WithContext(stdCtx))
 
 		return next(ctx)
@@ -75,6 +118,8 @@ func (m *AuthMiddleware) GrantPermission(permission string) echo.MiddlewareFunc
 				return response.NewResponses[any](ctx, http.StatusForbidden, "You dont have access to this resource", nil, nil, nil)
 			}
 
+			setDataScope(ctx, userContext.DataScopeOf(permission))
+
 			return next(ctx)
 		}
 	}
@@ -92,23 +137,32 @@ func (m *AuthMiddleware) GrantAnyPermission(permissions ...string) echo.Middlewa
 				return next(ctx)
 			}
 
-			hasPermission := false
+			// the first listed permission the user holds decides the data scope
+			granted := ""
 			for _, p := range permissions {
 				if slices.Contains(userContext.Permissions, p) {
-					hasPermission = true
+					granted = p
 					break
 				}
 			}
 
-			if !hasPermission {
+			if granted == "" {
 				return response.NewResponses[any](ctx, http.StatusForbidden, "You dont have access to this resource", nil, nil, nil)
 			}
 
+			setDataScope(ctx, userContext.DataScopeOf(granted))
+
 			return next(ctx)
 		}
 	}
 }
 
+// setDataScope hands the granted permission's data scope to the repositories through the request context.
+func setDataScope(ctx echo.Context, scope constants.DataScope) {
+	stdCtx := context.WithValue(ctx.Request().Context(), constants.DataScopeContextKey, scope)
+	ctx.SetRequest(ctx.Request().WithContext(stdCtx))
+}
+
 func RequirePlatformAdmin(authMW *AuthMiddleware) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
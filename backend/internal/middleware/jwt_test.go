@@ -15,10 +15,40 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+type stubSessions struct {
+	active map[string]bool
+}
+
+func (s *stubSessions) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	return s.active[sessionID], nil
+}
+
+type stubPermissions struct {
+	permissions map[uint][]string
+	scopes      map[uint]map[string]constants.DataScope
+	versions    map[uint]int64
+}
+
+func (s *stubPermissions) RolePermissions(ctx context.Context, roleID uint) ([]string, map[string]constants.DataScope, error) {
+	return s.permissions[roleID], s.scopes[roleID], nil
+}
+
+func (s *stubPermissions) PermissionVersion(ctx context.Context, userID uint) (int64, error) {
+	return s.versions[userID], nil
+}
+
+func newTestPermissions() *stubPermissions {
+	return &stubPermissions{
+		permissions: map[uint][]string{testutil.TestRoleID: {"read"}},
+		scopes:      map[uint]map[string]constants.DataScope{testutil.TestRoleID: {"read": constants.DataScopeSelf}},
+		versions:    map[uint]int64{},
+	}
+}
+
 func newTestAuthMiddleware(t *testing.T) (*AuthMiddleware, *infrastructure.JwtProvider) {
 	t.Helper()
 	jwtProvider := testutil.NewTestJWT()
-	authMW := NewAuthMiddleware(jwtProvider)
+	authMW := NewAuthMiddleware(jwtProvider, &stubSessions{active: map[string]bool{testutil.TestSessionID: true}}, newTestPermissions())
 	return authMW, jwtProvider
 }
 
@@ -28,7 +58,7 @@ func okHandler(ctx echo.Context) error {
 
 func TestAuthMiddleware_VerifyToken_ValidBearerToken(t *testing.T) {
 	authMW, jwtProvider := newTestAuthMiddleware(t)
-	token := testutil.GenerateTestToken(t, jwtProvider, 1, 1, false, "ADMIN", []string{"read"})
+	token := testutil.GenerateTestToken(t, jwtProvider, 1, 1, false, "ADMIN")
 
 	at := testutil.NewAPITest(t, http.MethodGet, "/test", nil)
 	at.WithToken(token)
@@ -40,6 +70,21 @@ func TestAuthMiddleware_VerifyToken_ValidBearerToken(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 }
 
+func TestAuthMiddleware_VerifyToken_RevokedSession(t *testing.T) {
+	jwtProvider := testutil.NewTestJWT()
+	authMW := NewAuthMiddleware(jwtProvider, &stubSessions{active: map[string]bool{}}, newTestPermissions())
+	token := testutil.GenerateTestToken(t, jwtProvider, 1, 1, false, "ADMIN")
+
+	at := testutil.NewAPITest(t, http.MethodGet, "/test", nil)
+	at.WithToken(token)
+
+	handler := authMW.VerifyToken(okHandler)
+	rec, err := at.Execute(handler)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
 func TestAuthMiddleware_VerifyToken_MissingAuthorizationHeader(t *testing.T) {
 	authMW, _ := newTestAuthMiddleware(t)
 
@@ -67,7 +112,7 @@ func TestAuthMiddleware_VerifyToken_InvalidToken(t *testing.T) {
 
 func TestAuthMiddleware_VerifyToken_QueryParamToken(t *testing.T) {
 	authMW, jwtProvider := newTestAuthMiddleware(t)
-	token := testutil.GenerateTestToken(t, jwtProvider, 1, 1, false, "ADMIN", []string{"read"})
+	token := testutil.GenerateTestToken(t, jwtProvider, 1, 1, false, "ADMIN")
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/ws?token="+token, nil)
 	rec := httptest.NewRecorder()
@@ -84,7 +129,7 @@ func TestAuthMiddleware_VerifyToken_QueryParamToken(t *testing.T) {
 
 func TestAuthMiddleware_VerifyToken_SetsClaimsOnContext(t *testing.T) {
 	authMW, jwtProvider := newTestAuthMiddleware(t)
-	token := testutil.GenerateTestToken(t, jwtProvider, 1, 1, false, "ADMIN", []string{"read"})
+	token := testutil.GenerateTestToken(t, jwtProvider, 1, 1, false, "ADMIN")
 
 	at := testutil.NewAPITest(t, http.MethodGet, "/test", nil)
 	at.WithToken(token)
@@ -103,12 +148,31 @@ func TestAuthMiddleware_VerifyToken_SetsClaimsOnContext(t *testing.T) {
 	assert.Equal(t, uint(1), gotClaims.UserID)
 	assert.Equal(t, uint(1), gotClaims.CompanyID)
 	assert.Equal(t, "ADMIN", gotClaims.Role)
-	assert.Equal(t, []string{"read"}, gotClaims.Permissions)
+	assert.Equal(t, []string{"read"}, gotClaims.Permissions, "permissions come from the role, not the token")
+	assert.Equal(t, constants.DataScopeSelf, gotClaims.DataScopeOf("read"))
+}
+
+func TestAuthMiddleware_VerifyToken_StalePermissionVersion(t *testing.T) {
+	jwtProvider := testutil.NewTestJWT()
+	permissions := newTestPermissions()
+	// the user was moved to another role after the token was issued
+	permissions.versions[1] = 1
+	authMW := NewAuthMiddleware(jwtProvider, &stubSessions{active: map[string]bool{testutil.TestSessionID: true}}, permissions)
+	token := testutil.GenerateTestToken(t, jwtProvider, 1, 1, false, "ADMIN")
+
+	at := testutil.NewAPITest(t, http.MethodGet, "/test", nil)
+	at.WithToken(token)
+
+	rec, err := at.Execute(authMW.VerifyToken(okHandler))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "permissions changed")
 }
 
 func TestAuthMiddleware_VerifyToken_SetsContextValues(t *testing.T) {
 	authMW, jwtProvider := newTestAuthMiddleware(t)
-	token := testutil.GenerateTestToken(t, jwtProvider, 5, 10, true, "ADMIN", nil)
+	token := testutil.GenerateTestToken(t, jwtProvider, 5, 10, true, "ADMIN")
 
 	at := testutil.NewAPITest(t, http.MethodGet, "/test", nil)
 	at.WithToken(token)
@@ -125,6 +189,7 @@ func TestAuthMiddleware_VerifyToken_SetsContextValues(t *testing.T) {
 	assert.Equal(t, uint(5), reqCtx.Value(constants.UserIDContextKey))
 	assert.Equal(t, uint(10), reqCtx.Value(constants.CompanyIDContextKey))
 	assert.Equal(t, true, reqCtx.Value(constants.IsPlatformAdminContextKey))
+	assert.Equal(t, uint(1), reqCtx.Value(constants.EmployeeIDContextKey))
 }
 
 func TestAuthMiddleware_GrantPermission_UserHasPermission(t *testing.T) {
@@ -146,6 +211,35 @@ func TestAuthMiddleware_GrantPermission_UserHasPermission(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 }
 
+func TestAuthMiddleware_GrantPermission_SetsDataScope(t *testing.T) {
+	authMW, _ := newTestAuthMiddleware(t)
+	claims := &infrastructure.MyClaims{
+		UserID:      1,
+		CompanyID:   1,
+		Permissions: []string{"leave.read", "users.read"},
+		DataScopes:  map[string]constants.DataScope{"leave.read": constants.DataScopeDepartment},
+	}
+
+	for permission, expected := range map[string]constants.DataScope{
+		"leave.read": constants.DataScopeDepartment,
+		"users.read": constants.DataScopeCompany,
+	} {
+		at := testutil.NewAPITest(t, http.MethodGet, "/test", nil)
+		at.WithAuthContext(claims)
+
+		var scope interface{}
+		handler := authMW.GrantPermission(permission)(func(ctx echo.Context) error {
+			scope = ctx.Request().Context().Value(constants.DataScopeContextKey)
+			return ctx.String(http.StatusOK, "ok")
+		})
+		rec, err := at.Execute(handler)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, expected, scope, permission)
+	}
+}
+
 func TestAuthMiddleware_GrantPermission_UserNoPermission(t *testing.T) {
 	authMW, _ := newTestAuthMiddleware(t)
 	claims := &infrastructure.MyClaims{
@@ -203,6 +297,29 @@ func TestAuthMiddleware_GrantAnyPermission_UserHasOne(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 }
 
+func TestAuthMiddleware_GrantAnyPermission_FirstHeldPermissionSetsDataScope(t *testing.T) {
+	authMW, _ := newTestAuthMiddleware(t)
+	claims := &infrastructure.MyClaims{
+		UserID:      1,
+		CompanyID:   1,
+		Permissions: []string{"leave.read.self", "leave.read"},
+		DataScopes:  map[string]constants.DataScope{"leave.read": constants.DataScopeSubordinates},
+	}
+
+	at := testutil.NewAPITest(t, http.MethodGet, "/test", nil)
+	at.WithAuthContext(claims)
+
+	var scope interface{}
+	handler := authMW.GrantAnyPermission("leave.read", "leave.read.self")(func(ctx echo.Context) error {
+		scope = ctx.Request().Context().Value(constants.DataScopeContextKey)
+		return ctx.String(http.StatusOK, "ok")
+	})
+	_, err := at.Execute(handler)
+
+	require.NoError(t, err)
+	assert.Equal(t, constants.DataScopeSubordinates, scope)
+}
+
 func TestAuthMiddleware_GrantAnyPermission_UserHasNone(t *testing.T) {
 	authMW, _ := newTestAuthMiddleware(t)
 	claims := &infrastructure.MyClaims{
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"basekarya-backend/pkg/constants"
+	"context"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestContext copies the request ID and client IP into the request context so
+// services and database callbacks can read them. It must run after RequestID.
+func RequestContext() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			stdCtx := context.WithValue(ctx.Request().Context(), constants.RequestIDContextKey, ctx.Response().Header().Get(echo.HeaderXRequestID))
+			stdCtx = context.WithValue(stdCtx, constants.IPAddressContextKey, ctx.RealIP())
+			ctx.SetRequest(ctx.Request().WithContext(stdCtx))
+
+			return next(ctx)
+		}
+	}
+}
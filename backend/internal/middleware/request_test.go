@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"basekarya-backend/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestContext(t *testing.T) {
+	e := echo.New()
+	e.Use(echoMiddleware.RequestID(), RequestContext())
+
+	var requestID, ipAddress string
+	e.GET("/", func(ctx echo.Context) error {
+		requestID = utils.GetRequestIDFromCtx(ctx.Request().Context())
+		ipAddress = utils.GetIPAddressFromCtx(ctx.Request().Context())
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXRealIP, "10.0.0.1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.NotEmpty(t, requestID)
+	assert.Equal(t, rec.Header().Get(echo.HeaderXRequestID), requestID)
+	assert.Equal(t, "10.0.0.1", ipAddress)
+}
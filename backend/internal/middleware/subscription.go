@@ -13,6 +13,7 @@ import (
 type ModuleAccessProvider interface {
 	HasAccess(ctx context.Context, companyID uint, module string) (bool, error)
 	CheckEmployeeLimit(ctx context.Context) (bool, error)
+	CheckEmployeeCapacity(ctx context.Context, additional int) (bool, error)
 }
 
 type SubscriptionMiddleware struct {
@@ -26,16 +27,7 @@ func NewSubscriptionMiddleware(planCache ModuleAccessProvider) *SubscriptionMidd
 func (m *SubscriptionMiddleware) RequireModule(moduleName string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
-			if utils.IsPlatformAdminFromCtx(ctx.Request().Context()) {
-				return next(ctx)
-			}
-
-			companyID := utils.GetCompanyIDFromCtx(ctx.Request().Context())
-			if companyID == 0 {
-				return next(ctx)
-			}
-
-			hasAccess, err := m.planCache.HasAccess(ctx.Request().Context(), companyID, moduleName)
+			hasAccess, err := m.HasModule(ctx.Request().Context(), moduleName)
 			if err != nil {
 				return response.NewResponses[any](ctx, http.StatusForbidden, "subscription plan not found", nil, nil, nil)
 			}
@@ -49,6 +41,26 @@ func (m *SubscriptionMiddleware) RequireModule(moduleName string) echo.Middlewar
 	}
 }
 
+// HasModule reports whether the current company's plan includes the module, the same check RequireModule
+// applies to a route.
+func (m *SubscriptionMiddleware) HasModule(ctx context.Context, moduleName string) (bool, error) {
+	if utils.IsPlatformAdminFromCtx(ctx) {
+		return true, nil
+	}
+
+	companyID := utils.GetCompanyIDFromCtx(ctx)
+	if companyID == 0 {
+		return true, nil
+	}
+
+	return m.planCache.HasAccess(ctx, companyID, moduleName)
+}
+
 func (m *SubscriptionMiddleware) CheckEmployeeLimit(ctx context.Context) (bool, error) {
 	return m.planCache.CheckEmployeeLimit(ctx)
 }
+
+// CheckEmployeeCapacity reports whether the plan leaves room for that many more employees, as for an import.
+func (m *SubscriptionMiddleware) CheckEmployeeCapacity(ctx context.Context, additional int) (bool, error) {
+	return m.planCache.CheckEmployeeCapacity(ctx, additional)
+}
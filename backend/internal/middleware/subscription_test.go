@@ -18,6 +18,7 @@ import (
 type mockPlanCache struct {
 	hasAccess     func(ctx context.Context, companyID uint, module string) (bool, error)
 	checkEmpLimit func(ctx context.Context) (bool, error)
+	checkCapacity func(ctx context.Context, additional int) (bool, error)
 }
 
 func (m *mockPlanCache) HasAccess(ctx context.Context, companyID uint, module string) (bool, error) {
@@ -34,6 +35,13 @@ func (m *mockPlanCache) CheckEmployeeLimit(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+func (m *mockPlanCache) CheckEmployeeCapacity(ctx context.Context, additional int) (bool, error) {
+	if m.checkCapacity != nil {
+		return m.checkCapacity(ctx, additional)
+	}
+	return true, nil
+}
+
 func TestSubscriptionMiddleware_RequireModule_PlatformAdminPasses(t *testing.T) {
 	mock := &mockPlanCache{}
 	mw := NewSubscriptionMiddleware(mock)
@@ -143,3 +151,28 @@ func TestSubscriptionMiddleware_CheckEmployeeLimit(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, allowed)
 }
+
+func TestSubscriptionMiddleware_HasModule(t *testing.T) {
+	mock := &mockPlanCache{
+		hasAccess: func(ctx context.Context, companyID uint, module string) (bool, error) {
+			return module == "leave", nil
+		},
+	}
+	mw := NewSubscriptionMiddleware(mock)
+
+	ctx := context.WithValue(context.Background(), constants.CompanyIDContextKey, uint(1))
+	ctx = context.WithValue(ctx, constants.IsPlatformAdminContextKey, false)
+
+	allowed, err := mw.HasModule(ctx, "leave")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = mw.HasModule(ctx, "asset")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// platform admins are not bound to a plan
+	allowed, err = mw.HasModule(context.WithValue(ctx, constants.IsPlatformAdminContextKey, true), "asset")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
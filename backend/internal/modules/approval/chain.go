@@ -0,0 +1,146 @@
+package approval
+
+import (
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"slices"
+	"sort"
+)
+
+// requestTypes lists every request type the engine approves, in the order they are shown.
+var requestTypes = []constants.ApprovalRequestType{
+	constants.ApprovalRequestLeave,
+	constants.ApprovalRequestLoan,
+	constants.ApprovalRequestOvertime,
+	constants.ApprovalRequestReimbursement,
+	constants.ApprovalRequestFinance,
+	constants.ApprovalRequestAssetAssignment,
+}
+
+// defaultPermissions is the approval permission of each request type. It backs the default chain,
+// approves steps that resolve to nobody and receives escalations.
+var defaultPermissions = map[constants.ApprovalRequestType]string{
+	constants.ApprovalRequestLeave:           constants.APPROVAL_LEAVE,
+	constants.ApprovalRequestLoan:            constants.APPROVAL_LOAN,
+	constants.ApprovalRequestOvertime:        constants.APPROVAL_OVERTIME,
+	constants.ApprovalRequestReimbursement:   constants.APPROVAL_REIMBURSEMENT,
+	constants.ApprovalRequestFinance:         constants.APPROVAL_FINANCE,
+	constants.ApprovalRequestAssetAssignment: constants.APPROVAL_ASSET,
+}
+
+// defaultChain is used while a company has not configured the request type,
+// a single step any holder of the approval permission can approve as before the engine existed.
+func defaultChain(requestType constants.ApprovalRequestType) ApprovalChain {
+	return ApprovalChain{
+		RequestType: requestType,
+		Name:        "Default",
+		IsActive:    true,
+		Steps: []ApprovalChainStep{
+			{Level: 1, ApproverType: constants.ApproverPermission, Permission: defaultPermissions[requestType]},
+		},
+	}
+}
+
+// buildSteps turns the chain into the steps of a request, leaving out steps whose amount threshold
+// the request does not reach. Every step starts waiting for its level.
+func buildSteps(chain ApprovalChain, amount float64) []ApprovalStep {
+	var steps []ApprovalStep
+	for _, cs := range chain.Steps {
+		if cs.MinAmount > 0 && amount < cs.MinAmount {
+			continue
+		}
+
+		steps = append(steps, ApprovalStep{
+			Level:          cs.Level,
+			ApproverType:   cs.ApproverType,
+			Permission:     cs.Permission,
+			ApproverUserID: cs.ApproverUserID,
+			SLAHours:       cs.SLAHours,
+			Status:         constants.ApprovalStepWaiting,
+		})
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Level < steps[j].Level
+	})
+
+	return steps
+}
+
+// nextLevel returns the first level after the given one, 0 when there is none.
+func nextLevel(steps []ApprovalStep, after int) int {
+	for _, step := range steps {
+		if step.Level > after {
+			return step.Level
+		}
+	}
+	return 0
+}
+
+// levelApproved reports whether every step of the level has been approved.
+func levelApproved(steps []ApprovalStep, level int) bool {
+	for _, step := range steps {
+		if step.Level == level && step.Status != constants.ApprovalStepApproved {
+			return false
+		}
+	}
+	return true
+}
+
+// withDelegates adds the delegate of every approver with an active delegation as an assignee,
+// and asks the delegate instead of the approver.
+func withDelegates(approverIDs, notifyIDs []uint, delegations []ApprovalDelegation, requesterUserID uint) ([]ApprovalStepAssignee, []uint) {
+	assignees := make([]ApprovalStepAssignee, 0, len(approverIDs))
+	for _, id := range approverIDs {
+		assignees = append(assignees, ApprovalStepAssignee{UserID: id})
+	}
+
+	delegateOf := make(map[uint]uint, len(delegations))
+	for _, d := range delegations {
+		// nobody approves their own request, even as a delegate
+		if d.DelegateUserID == requesterUserID || !slices.Contains(approverIDs, d.DelegatorUserID) {
+			continue
+		}
+		if _, ok := delegateOf[d.DelegatorUserID]; ok {
+			continue
+		}
+
+		delegateOf[d.DelegatorUserID] = d.DelegateUserID
+		assignees = append(assignees, ApprovalStepAssignee{UserID: d.DelegateUserID, DelegatedFrom: &d.DelegatorUserID})
+	}
+
+	notify := make([]uint, 0, len(notifyIDs))
+	for _, id := range notifyIDs {
+		if delegate, ok := delegateOf[id]; ok {
+			id = delegate
+		}
+		notify = appendUnique(notify, id)
+	}
+
+	return assignees, notify
+}
+
+func appendUnique(ids []uint, values ...uint) []uint {
+	for _, v := range values {
+		if !slices.Contains(ids, v) {
+			ids = append(ids, v)
+		}
+	}
+	return ids
+}
+
+func without(ids []uint, exclude uint) []uint {
+	return slices.DeleteFunc(slices.Clone(ids), func(id uint) bool {
+		return id == exclude
+	})
+}
+
+func displayName(u *user.User) string {
+	if u == nil {
+		return "-"
+	}
+	if u.Employee != nil && u.Employee.FullName != "" {
+		return u.Employee.FullName
+	}
+	return u.Username
+}
@@ -0,0 +1,77 @@
+package approval
+
+import (
+	"testing"
+
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSteps(t *testing.T) {
+	userID := uint(7)
+	chain := ApprovalChain{
+		Steps: []ApprovalChainStep{
+			{Level: 2, ApproverType: constants.ApproverPermission, Permission: constants.APPROVAL_FINANCE},
+			{Level: 1, ApproverType: constants.ApproverManager, SLAHours: 24},
+			{Level: 3, ApproverType: constants.ApproverUser, ApproverUserID: &userID, MinAmount: 10000000},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		amount     float64
+		wantLevels []int
+	}{
+		{name: "amount below the threshold skips the step", amount: 5000000, wantLevels: []int{1, 2}},
+		{name: "amount at the threshold includes the step", amount: 10000000, wantLevels: []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := buildSteps(chain, tt.amount)
+
+			require.Len(t, steps, len(tt.wantLevels))
+			for i, step := range steps {
+				assert.Equal(t, tt.wantLevels[i], step.Level)
+				assert.Equal(t, constants.ApprovalStepWaiting, step.Status)
+			}
+			assert.Equal(t, 24, steps[0].SLAHours)
+		})
+	}
+}
+
+func TestLevelHelpers(t *testing.T) {
+	steps := []ApprovalStep{
+		{Level: 1, Status: constants.ApprovalStepApproved},
+		{Level: 2, Status: constants.ApprovalStepApproved},
+		{Level: 2, Status: constants.ApprovalStepPending},
+	}
+
+	assert.True(t, levelApproved(steps, 1))
+	assert.False(t, levelApproved(steps, 2))
+	assert.Equal(t, 2, nextLevel(steps, 1))
+	assert.Equal(t, 0, nextLevel(steps, 2))
+}
+
+func TestWithDelegates(t *testing.T) {
+	delegations := []ApprovalDelegation{
+		{DelegatorUserID: 10, DelegateUserID: 20},
+		{DelegatorUserID: 10, DelegateUserID: 21},
+		// the requester never approves their own request
+		{DelegatorUserID: 11, DelegateUserID: 1},
+		// not an approver of the step
+		{DelegatorUserID: 12, DelegateUserID: 22},
+	}
+
+	assignees, notify := withDelegates([]uint{10, 11}, []uint{10}, delegations, 1)
+
+	require.Len(t, assignees, 3)
+	assert.Equal(t, uint(10), assignees[0].UserID)
+	assert.Equal(t, uint(11), assignees[1].UserID)
+	assert.Equal(t, uint(20), assignees[2].UserID)
+	require.NotNil(t, assignees[2].DelegatedFrom)
+	assert.Equal(t, uint(10), *assignees[2].DelegatedFrom)
+	assert.Equal(t, []uint{20}, notify)
+}
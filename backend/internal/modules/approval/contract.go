@@ -0,0 +1,21 @@
+package approval
+
+import (
+	"basekarya-backend/internal/modules/user"
+	"context"
+)
+
+type NotificationProvider interface {
+	BlastNotification(ctx context.Context, userIDs []uint,
+		Type string,
+		Title string,
+		Message string,
+		relatedID uint) error
+}
+
+type UserProvider interface {
+	FindByID(ctx context.Context, id uint) (*user.User, error)
+	FindEmployeeByID(ctx context.Context, id uint) (*user.Employee, error)
+	FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error)
+	FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error)
+}
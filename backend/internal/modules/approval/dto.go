@@ -0,0 +1,167 @@
+package approval
+
+import (
+	"basekarya-backend/pkg/constants"
+	"time"
+)
+
+// SubmitRequest starts the approval of a newly created request.
+type SubmitRequest struct {
+	RequestType     constants.ApprovalRequestType
+	ReferenceID     uint
+	RequesterUserID uint
+	// compared against the step thresholds of the chain, e.g. the loan total or the leave days
+	Amount float64
+}
+
+// DecideRequest records an approver's decision, the requester and amount are used to start
+// the approval of requests created before the engine existed.
+type DecideRequest struct {
+	RequestType     constants.ApprovalRequestType
+	ReferenceID     uint
+	RequesterUserID uint
+	Amount          float64
+	ActorUserID     uint
+	Action          constants.ApprovalAction
+	Notes           string
+}
+
+type Decision struct {
+	// PENDING while further approvals are needed, the module only finalises APPROVED and REJECTED
+	Status constants.ApprovalStatus
+	// users asked to approve once the request moves on to the next level
+	NextApproverIDs []uint
+}
+
+type SaveChainRequest struct {
+	RequestType string             `json:"-"`
+	Name        string             `json:"name" validate:"max=100"`
+	IsActive    bool               `json:"is_active"`
+	Steps       []ChainStepRequest `json:"steps" validate:"required,min=1,dive"`
+}
+
+type ChainStepRequest struct {
+	Level          int     `json:"level" validate:"required,min=1"`
+	ApproverType   string  `json:"approver_type" validate:"required,oneof=MANAGER DEPARTMENT_HEAD PERMISSION USER"`
+	Permission     string  `json:"permission"`
+	ApproverUserID *uint   `json:"approver_user_id"`
+	MinAmount      float64 `json:"min_amount" validate:"min=0"`
+	SLAHours       int     `json:"sla_hours" validate:"min=0"`
+}
+
+type ApprovalChainResponse struct {
+	RequestType constants.ApprovalRequestType `json:"request_type"`
+	Name        string                        `json:"name"`
+	IsActive    bool                          `json:"is_active"`
+	IsDefault   bool                          `json:"is_default"`
+	Steps       []ChainStepResponse           `json:"steps"`
+}
+
+type ChainStepResponse struct {
+	Level          int                    `json:"level"`
+	ApproverType   constants.ApproverType `json:"approver_type"`
+	Permission     string                 `json:"permission"`
+	ApproverUserID *uint                  `json:"approver_user_id"`
+	MinAmount      float64                `json:"min_amount"`
+	SLAHours       int                    `json:"sla_hours"`
+}
+
+type ApprovalDetailResponse struct {
+	ID              uint                          `json:"id"`
+	RequestType     constants.ApprovalRequestType `json:"request_type"`
+	ReferenceID     uint                          `json:"reference_id"`
+	RequesterUserID uint                          `json:"requester_user_id"`
+	Amount          float64                       `json:"amount"`
+	Status          constants.ApprovalStatus      `json:"status"`
+	CurrentLevel    int                           `json:"current_level"`
+	Steps           []ApprovalStepResponse        `json:"steps"`
+	Histories       []ApprovalHistoryResponse     `json:"histories"`
+}
+
+type ApprovalStepResponse struct {
+	ID           uint                         `json:"id"`
+	Level        int                          `json:"level"`
+	ApproverType constants.ApproverType       `json:"approver_type"`
+	Permission   string                       `json:"permission"`
+	Status       constants.ApprovalStepStatus `json:"status"`
+	DueAt        *time.Time                   `json:"due_at"`
+	EscalatedAt  *time.Time                   `json:"escalated_at"`
+	ActedBy      *uint                        `json:"acted_by"`
+	ActedAt      *time.Time                   `json:"acted_at"`
+	Assignees    []ApprovalAssigneeResponse   `json:"assignees"`
+}
+
+type ApprovalAssigneeResponse struct {
+	UserID        uint   `json:"user_id"`
+	Name          string `json:"name"`
+	DelegatedFrom *uint  `json:"delegated_from"`
+}
+
+type ApprovalHistoryResponse struct {
+	ID          uint                            `json:"id"`
+	Level       int                             `json:"level"`
+	Action      constants.ApprovalHistoryAction `json:"action"`
+	ActorUserID *uint                           `json:"actor_user_id"`
+	ActorName   string                          `json:"actor_name"`
+	OnBehalfOf  *uint                           `json:"on_behalf_of"`
+	Notes       string                          `json:"notes"`
+	CreatedAt   time.Time                       `json:"created_at"`
+}
+
+type DelegationFilter struct {
+	Page       int  `json:"page"`
+	Limit      int  `json:"limit"`
+	UserID     uint `json:"user_id"`
+	ActiveOnly bool `json:"active_only"`
+}
+
+// PendingApprovalFilter lists the requests waiting on UserID, directly or as someone's delegate.
+type PendingApprovalFilter struct {
+	Page         int
+	Limit        int
+	UserID       uint
+	RequestTypes []constants.ApprovalRequestType
+}
+
+type PendingApprovalResponse struct {
+	ID              uint                          `json:"id"`
+	RequestType     constants.ApprovalRequestType `json:"request_type"`
+	ReferenceID     uint                          `json:"reference_id"`
+	RequesterUserID uint                          `json:"requester_user_id"`
+	RequesterName   string                        `json:"requester_name"`
+	Amount          float64                       `json:"amount"`
+	CurrentLevel    int                           `json:"current_level"`
+	DueAt           *time.Time                    `json:"due_at"`
+	SubmittedAt     time.Time                     `json:"submitted_at"`
+}
+
+type CreateDelegationRequest struct {
+	DelegatorUserID uint   `json:"delegator_user_id"`
+	DelegateUserID  uint   `json:"delegate_user_id" validate:"required"`
+	StartDate       string `json:"start_date" validate:"required"`
+	EndDate         string `json:"end_date" validate:"required"`
+	Reason          string `json:"reason" validate:"max=255"`
+
+	// set by the handler, only managers of approval chains may delegate on behalf of someone else
+	UserID    uint `json:"-"`
+	CanManage bool `json:"-"`
+}
+
+type RevokeDelegationRequest struct {
+	ID        uint `json:"-"`
+	UserID    uint `json:"-"`
+	CanManage bool `json:"-"`
+}
+
+type DelegationResponse struct {
+	ID              uint      `json:"id"`
+	DelegatorUserID uint      `json:"delegator_user_id"`
+	DelegatorName   string    `json:"delegator_name"`
+	DelegateUserID  uint      `json:"delegate_user_id"`
+	DelegateName    string    `json:"delegate_name"`
+	StartDate       string    `json:"start_date"`
+	EndDate         string    `json:"end_date"`
+	Reason          string    `json:"reason"`
+	IsActive        bool      `json:"is_active"`
+	CreatedAt       time.Time `json:"created_at"`
+}
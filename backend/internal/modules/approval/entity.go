@@ -0,0 +1,124 @@
+package approval
+
+import (
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"time"
+)
+
+// ApprovalChain is the company's configuration of who approves a request type.
+// Steps sharing a level run in parallel, levels run one after another.
+type ApprovalChain struct {
+	ID          uint                          `gorm:"primaryKey" json:"id"`
+	CompanyID   uint                          `gorm:"uniqueIndex:idx_approval_chains_company_type;not null" json:"company_id"`
+	RequestType constants.ApprovalRequestType `gorm:"type:varchar(30);uniqueIndex:idx_approval_chains_company_type;not null" json:"request_type"`
+	Name        string                        `gorm:"type:varchar(100)" json:"name"`
+	IsActive    bool                          `json:"is_active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Steps []ApprovalChainStep `gorm:"foreignKey:ChainID" json:"steps,omitempty"`
+}
+
+type ApprovalChainStep struct {
+	ID           uint                   `gorm:"primaryKey" json:"id"`
+	ChainID      uint                   `gorm:"index;not null" json:"chain_id"`
+	Level        int                    `gorm:"not null" json:"level"`
+	ApproverType constants.ApproverType `gorm:"type:varchar(20);not null" json:"approver_type"`
+
+	// set for PERMISSION and USER approvers respectively
+	Permission     string `gorm:"type:varchar(100)" json:"permission"`
+	ApproverUserID *uint  `json:"approver_user_id"`
+
+	// the step only applies to requests of at least this amount, 0 applies to every request
+	MinAmount float64 `gorm:"type:decimal(15,2);default:0" json:"min_amount"`
+	// hours before a pending step is escalated, 0 never escalates
+	SLAHours int `gorm:"default:0" json:"sla_hours"`
+}
+
+// ApprovalRequest is the running approval of one leave, loan, overtime, reimbursement,
+// finance transaction or asset assignment.
+type ApprovalRequest struct {
+	ID              uint                          `gorm:"primaryKey" json:"id"`
+	CompanyID       uint                          `gorm:"index;not null" json:"company_id"`
+	RequestType     constants.ApprovalRequestType `gorm:"type:varchar(30);uniqueIndex:idx_approval_requests_reference;not null" json:"request_type"`
+	ReferenceID     uint                          `gorm:"uniqueIndex:idx_approval_requests_reference;not null" json:"reference_id"`
+	RequesterUserID uint                          `gorm:"index;not null" json:"requester_user_id"`
+	Amount          float64                       `gorm:"type:decimal(15,2);default:0" json:"amount"`
+	Status          constants.ApprovalStatus      `gorm:"type:varchar(20);default:'PENDING'" json:"status"`
+	CurrentLevel    int                           `json:"current_level"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Steps     []ApprovalStep    `gorm:"foreignKey:ApprovalRequestID" json:"steps,omitempty"`
+	Histories []ApprovalHistory `gorm:"foreignKey:ApprovalRequestID" json:"histories,omitempty"`
+	Requester *user.User        `gorm:"foreignKey:RequesterUserID" json:"requester,omitempty"`
+}
+
+type ApprovalStep struct {
+	ID                uint                         `gorm:"primaryKey" json:"id"`
+	ApprovalRequestID uint                         `gorm:"index;not null" json:"approval_request_id"`
+	Level             int                          `gorm:"not null" json:"level"`
+	ApproverType      constants.ApproverType       `gorm:"type:varchar(20);not null" json:"approver_type"`
+	Permission        string                       `gorm:"type:varchar(100)" json:"permission"`
+	ApproverUserID    *uint                        `json:"approver_user_id"`
+	SLAHours          int                          `gorm:"default:0" json:"sla_hours"`
+	Status            constants.ApprovalStepStatus `gorm:"type:varchar(20);index;default:'WAITING'" json:"status"`
+
+	DueAt       *time.Time `gorm:"index" json:"due_at"`
+	EscalatedAt *time.Time `json:"escalated_at"`
+	ActedBy     *uint      `json:"acted_by"`
+	ActedAt     *time.Time `json:"acted_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Assignees []ApprovalStepAssignee `gorm:"foreignKey:ApprovalStepID" json:"assignees,omitempty"`
+}
+
+// ApprovalStepAssignee is a user allowed to act on a step, DelegatedFrom is set when they stand in for someone.
+type ApprovalStepAssignee struct {
+	ID             uint  `gorm:"primaryKey" json:"id"`
+	ApprovalStepID uint  `gorm:"index;not null" json:"approval_step_id"`
+	UserID         uint  `gorm:"index;not null" json:"user_id"`
+	DelegatedFrom  *uint `json:"delegated_from"`
+
+	User *user.User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+type ApprovalHistory struct {
+	ID                uint                            `gorm:"primaryKey" json:"id"`
+	CompanyID         uint                            `gorm:"index;not null" json:"company_id"`
+	ApprovalRequestID uint                            `gorm:"index;not null" json:"approval_request_id"`
+	ApprovalStepID    *uint                           `json:"approval_step_id"`
+	Level             int                             `json:"level"`
+	Action            constants.ApprovalHistoryAction `gorm:"type:varchar(20);not null" json:"action"`
+	// nil for actions taken by the system such as escalation
+	ActorUserID *uint     `json:"actor_user_id"`
+	OnBehalfOf  *uint     `json:"on_behalf_of"`
+	Notes       string    `gorm:"type:varchar(500)" json:"notes"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Actor *user.User `gorm:"foreignKey:ActorUserID" json:"actor,omitempty"`
+}
+
+// ApprovalDelegation lets DelegateUserID act on DelegatorUserID's approvals between StartDate and EndDate,
+// typically while the delegator is on leave.
+type ApprovalDelegation struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	CompanyID       uint      `gorm:"index;not null" json:"company_id"`
+	DelegatorUserID uint      `gorm:"index;not null" json:"delegator_user_id"`
+	DelegateUserID  uint      `gorm:"index;not null" json:"delegate_user_id"`
+	StartDate       time.Time `gorm:"type:date;not null" json:"start_date"`
+	EndDate         time.Time `gorm:"type:date;not null" json:"end_date"`
+	Reason          string    `gorm:"type:varchar(255)" json:"reason"`
+	IsActive        bool      `gorm:"default:true" json:"is_active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Delegator *user.User `gorm:"foreignKey:DelegatorUserID" json:"delegator,omitempty"`
+	Delegate  *user.User `gorm:"foreignKey:DelegateUserID" json:"delegate,omitempty"`
+}
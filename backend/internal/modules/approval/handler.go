@@ -0,0 +1,162 @@
+package approval
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/logger"
+	"basekarya-backend/pkg/response"
+	"basekarya-backend/pkg/utils"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service}
+}
+
+func (h *Handler) GetChains(ctx echo.Context) error {
+	data, err := h.service.GetChains(ctx.Request().Context())
+	if err != nil {
+		logger.Errorw("get approval chains failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Approval Chains Success", data, nil, nil)
+}
+
+func (h *Handler) SaveChain(ctx echo.Context) error {
+	var req SaveChainRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.RequestType = strings.ToUpper(ctx.Param("type"))
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := h.service.SaveChain(ctx.Request().Context(), &req); err != nil {
+		logger.Errorw("save approval chain failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Save Approval Chain Success", nil, nil, nil)
+}
+
+func (h *Handler) GetApproval(ctx echo.Context) error {
+	referenceID, err := strconv.Atoi(ctx.Param("referenceId"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid reference id", nil, err, nil)
+	}
+
+	requestType := constants.ApprovalRequestType(strings.ToUpper(ctx.Param("type")))
+
+	data, err := h.service.GetApproval(ctx.Request().Context(), requestType, uint(referenceID))
+	if err != nil {
+		logger.Errorw("get approval detail failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Approval Detail Success", data, nil, nil)
+}
+
+func (h *Handler) GetDelegations(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	page, _ := strconv.Atoi(ctx.QueryParam("page"))
+	limit, _ := strconv.Atoi(ctx.QueryParam("limit"))
+	activeOnly, _ := strconv.ParseBool(ctx.QueryParam("active_only"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	filter := DelegationFilter{
+		Page:       page,
+		Limit:      limit,
+		ActiveOnly: activeOnly,
+	}
+
+	if !slices.Contains(userContext.Permissions, constants.MANAGE_APPROVAL_CHAIN) {
+		filter.UserID = userContext.UserID
+	}
+
+	data, meta, err := h.service.GetDelegations(ctx.Request().Context(), &filter)
+	if err != nil {
+		logger.Errorw("get approval delegations failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Approval Delegations Success", data, nil, meta)
+}
+
+func (h *Handler) CreateDelegation(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req CreateDelegationRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.UserID = userContext.UserID
+	req.CanManage = slices.Contains(userContext.Permissions, constants.MANAGE_APPROVAL_CHAIN)
+
+	if err := h.service.CreateDelegation(ctx.Request().Context(), &req); err != nil {
+		logger.Errorw("create approval delegation failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusCreated, "Create Approval Delegation Success", nil, nil, nil)
+}
+
+func (h *Handler) RevokeDelegation(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	req := RevokeDelegationRequest{
+		ID:        uint(id),
+		UserID:    userContext.UserID,
+		CanManage: slices.Contains(userContext.Permissions, constants.MANAGE_APPROVAL_CHAIN),
+	}
+
+	if err := h.service.RevokeDelegation(ctx.Request().Context(), &req); err != nil {
+		logger.Errorw("revoke approval delegation failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Revoke Approval Delegation Success", nil, nil, nil)
+}
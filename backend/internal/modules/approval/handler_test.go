@@ -0,0 +1,244 @@
+package approval
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_SaveChain(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: SaveChainRequest{
+				IsActive: true,
+				Steps:    []ChainStepRequest{{Level: 1, ApproverType: string(constants.ApproverManager)}},
+			},
+			setupMocks: func(svc *mockService) {
+				svc.On("SaveChain", mock.Anything, mock.MatchedBy(func(req *SaveChainRequest) bool {
+					return req.RequestType == string(constants.ApprovalRequestLoan)
+				})).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing steps",
+			body:       SaveChainRequest{IsActive: true},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown approver type",
+			body: SaveChainRequest{
+				Steps: []ChainStepRequest{{Level: 1, ApproverType: "ANYONE"}},
+			},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: SaveChainRequest{
+				Steps: []ChainStepRequest{{Level: 1, ApproverType: string(constants.ApproverPermission)}},
+			},
+			setupMocks: func(svc *mockService) {
+				svc.On("SaveChain", mock.Anything, mock.Anything).Return(errors.New("permission is required for permission approvers"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPut, "/api/approvals/chains/loan", tt.body)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      1,
+				CompanyID:   1,
+				Permissions: []string{constants.MANAGE_APPROVAL_CHAIN},
+			})
+			at.WithPathParams(map[string]string{"type": "loan"})
+
+			rec, err := at.Execute(handler.SaveChain)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_GetApproval(t *testing.T) {
+	tests := []struct {
+		name        string
+		referenceID string
+		setupMocks  func(*mockService)
+		wantStatus  int
+	}{
+		{
+			name:        "success",
+			referenceID: "12",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetApproval", mock.Anything, constants.ApprovalRequestLeave, uint(12)).Return(&ApprovalDetailResponse{ID: 1}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "invalid reference id",
+			referenceID: "abc",
+			setupMocks:  func(svc *mockService) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "not found",
+			referenceID: "99",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetApproval", mock.Anything, constants.ApprovalRequestLeave, uint(99)).Return(nil, errors.New("record not found"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/approvals/leave/"+tt.referenceID, nil)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      1,
+				CompanyID:   1,
+				Permissions: []string{constants.APPROVAL_LEAVE},
+			})
+			at.WithPathParams(map[string]string{"type": "leave", "referenceId": tt.referenceID})
+
+			rec, err := at.Execute(handler.GetApproval)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_GetDelegations(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		wantUserID  uint
+	}{
+		{name: "approver only sees their own delegations", permissions: []string{constants.APPROVAL_LEAVE}, wantUserID: 5},
+		{name: "chain manager sees every delegation", permissions: []string{constants.MANAGE_APPROVAL_CHAIN}, wantUserID: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			svc.On("GetDelegations", mock.Anything, mock.MatchedBy(func(f *DelegationFilter) bool {
+				return f.UserID == tt.wantUserID && f.Page == 1 && f.Limit == 10 && f.ActiveOnly
+			})).Return([]DelegationResponse{}, (*response.Meta)(nil), nil)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/approvals/delegations?active_only=true", nil)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      5,
+				CompanyID:   1,
+				Permissions: tt.permissions,
+			})
+
+			rec, err := at.Execute(handler.GetDelegations)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_CreateDelegation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: CreateDelegationRequest{DelegateUserID: 20, StartDate: "2026-06-01", EndDate: "2026-06-05"},
+			setupMocks: func(svc *mockService) {
+				svc.On("CreateDelegation", mock.Anything, mock.MatchedBy(func(req *CreateDelegationRequest) bool {
+					return req.UserID == 5 && !req.CanManage
+				})).Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "missing dates",
+			body:       CreateDelegationRequest{DelegateUserID: 20},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: CreateDelegationRequest{DelegateUserID: 5, StartDate: "2026-06-01", EndDate: "2026-06-05"},
+			setupMocks: func(svc *mockService) {
+				svc.On("CreateDelegation", mock.Anything, mock.Anything).Return(errors.New("cannot delegate approvals to yourself"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/approvals/delegations", tt.body)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      5,
+				CompanyID:   1,
+				Permissions: []string{constants.APPROVAL_LEAVE},
+			})
+
+			rec, err := at.Execute(handler.CreateDelegation)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_RevokeDelegation(t *testing.T) {
+	svc := new(mockService)
+	svc.On("RevokeDelegation", mock.Anything, mock.MatchedBy(func(req *RevokeDelegationRequest) bool {
+		return req.ID == 3 && req.UserID == 5
+	})).Return(nil)
+	handler := NewHandler(svc)
+
+	at := testutil.NewAPITest(t, http.MethodDelete, "/api/approvals/delegations/3", nil)
+	at.WithAuthContext(&infrastructure.MyClaims{
+		UserID:      5,
+		CompanyID:   1,
+		Permissions: []string{constants.APPROVAL_LEAVE},
+	})
+	at.WithPathParams(map[string]string{"id": "3"})
+
+	rec, err := at.Execute(handler.RevokeDelegation)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	svc.AssertExpectations(t)
+}
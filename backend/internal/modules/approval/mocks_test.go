@@ -0,0 +1,222 @@
+package approval
+
+import (
+	"context"
+	"time"
+
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Repository Mock ---
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) FindChainByType(ctx context.Context, requestType constants.ApprovalRequestType) (*ApprovalChain, error) {
+	args := m.Called(ctx, requestType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ApprovalChain), args.Error(1)
+}
+
+func (m *mockRepo) FindAllChains(ctx context.Context) ([]ApprovalChain, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]ApprovalChain), args.Error(1)
+}
+
+func (m *mockRepo) SaveChain(ctx context.Context, chain *ApprovalChain) error {
+	return m.Called(ctx, chain).Error(0)
+}
+
+func (m *mockRepo) FindRequestByID(ctx context.Context, id uint) (*ApprovalRequest, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ApprovalRequest), args.Error(1)
+}
+
+func (m *mockRepo) FindRequestByReference(ctx context.Context, requestType constants.ApprovalRequestType, referenceID uint) (*ApprovalRequest, error) {
+	args := m.Called(ctx, requestType, referenceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ApprovalRequest), args.Error(1)
+}
+
+func (m *mockRepo) LockRequestByReference(ctx context.Context, requestType constants.ApprovalRequestType, referenceID uint) (*ApprovalRequest, error) {
+	args := m.Called(ctx, requestType, referenceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ApprovalRequest), args.Error(1)
+}
+
+func (m *mockRepo) CreateRequest(ctx context.Context, req *ApprovalRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockRepo) UpdateRequest(ctx context.Context, req *ApprovalRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockRepo) UpdateStep(ctx context.Context, step *ApprovalStep) error {
+	return m.Called(ctx, step).Error(0)
+}
+
+func (m *mockRepo) CreateAssignees(ctx context.Context, assignees []ApprovalStepAssignee) error {
+	return m.Called(ctx, assignees).Error(0)
+}
+
+func (m *mockRepo) CreateHistory(ctx context.Context, history *ApprovalHistory) error {
+	return m.Called(ctx, history).Error(0)
+}
+
+func (m *mockRepo) FindOverdueSteps(ctx context.Context, now time.Time) ([]ApprovalStep, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]ApprovalStep), args.Error(1)
+}
+
+func (m *mockRepo) FindActiveDelegations(ctx context.Context, delegatorUserIDs []uint, on time.Time) ([]ApprovalDelegation, error) {
+	args := m.Called(ctx, delegatorUserIDs, on)
+	return args.Get(0).([]ApprovalDelegation), args.Error(1)
+}
+
+func (m *mockRepo) FindActiveDelegationsByDelegate(ctx context.Context, delegateUserID uint, on time.Time) ([]ApprovalDelegation, error) {
+	args := m.Called(ctx, delegateUserID, on)
+	return args.Get(0).([]ApprovalDelegation), args.Error(1)
+}
+
+func (m *mockRepo) FindPendingRequests(ctx context.Context, assigneeIDs []uint, filter *PendingApprovalFilter) ([]ApprovalRequest, int64, error) {
+	args := m.Called(ctx, assigneeIDs, filter)
+	return args.Get(0).([]ApprovalRequest), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockRepo) FindAllDelegations(ctx context.Context, filter *DelegationFilter) ([]ApprovalDelegation, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]ApprovalDelegation), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockRepo) FindDelegationByID(ctx context.Context, id uint) (*ApprovalDelegation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ApprovalDelegation), args.Error(1)
+}
+
+func (m *mockRepo) CreateDelegation(ctx context.Context, delegation *ApprovalDelegation) error {
+	return m.Called(ctx, delegation).Error(0)
+}
+
+func (m *mockRepo) UpdateDelegation(ctx context.Context, delegation *ApprovalDelegation) error {
+	return m.Called(ctx, delegation).Error(0)
+}
+
+// --- Provider Mocks ---
+
+type mockUserProvider struct{ mock.Mock }
+
+func (m *mockUserProvider) FindByID(ctx context.Context, id uint) (*user.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *mockUserProvider) FindEmployeeByID(ctx context.Context, id uint) (*user.Employee, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Employee), args.Error(1)
+}
+
+func (m *mockUserProvider) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
+	args := m.Called(ctx, permissionApprovalName)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *mockUserProvider) FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error) {
+	args := m.Called(ctx, requesterUserID, permissionApprovalName)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+type mockNotification struct{ mock.Mock }
+
+func (m *mockNotification) BlastNotification(ctx context.Context, userIDs []uint, Type string, Title string, Message string, relatedID uint) error {
+	return m.Called(ctx, userIDs, Type, Title, Message, relatedID).Error(0)
+}
+
+// --- Service Mock (for handler tests) ---
+
+type mockService struct{ mock.Mock }
+
+func (m *mockService) Submit(ctx context.Context, req *SubmitRequest) ([]uint, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *mockService) Decide(ctx context.Context, req *DecideRequest) (*Decision, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Decision), args.Error(1)
+}
+
+func (m *mockService) Cancel(ctx context.Context, requestType constants.ApprovalRequestType, referenceID, actorUserID uint) error {
+	return m.Called(ctx, requestType, referenceID, actorUserID).Error(0)
+}
+
+func (m *mockService) GetApproval(ctx context.Context, requestType constants.ApprovalRequestType, referenceID uint) (*ApprovalDetailResponse, error) {
+	args := m.Called(ctx, requestType, referenceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ApprovalDetailResponse), args.Error(1)
+}
+
+func (m *mockService) GetChains(ctx context.Context) ([]ApprovalChainResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]ApprovalChainResponse), args.Error(1)
+}
+
+func (m *mockService) SaveChain(ctx context.Context, req *SaveChainRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) GetPendingApprovals(ctx context.Context, filter *PendingApprovalFilter) ([]PendingApprovalResponse, *response.Meta, error) {
+	args := m.Called(ctx, filter)
+	var meta *response.Meta
+	if args.Get(1) != nil {
+		meta = args.Get(1).(*response.Meta)
+	}
+	return args.Get(0).([]PendingApprovalResponse), meta, args.Error(2)
+}
+
+func (m *mockService) GetDelegations(ctx context.Context, filter *DelegationFilter) ([]DelegationResponse, *response.Meta, error) {
+	args := m.Called(ctx, filter)
+	var meta *response.Meta
+	if args.Get(1) != nil {
+		meta = args.Get(1).(*response.Meta)
+	}
+	return args.Get(0).([]DelegationResponse), meta, args.Error(2)
+}
+
+func (m *mockService) CreateDelegation(ctx context.Context, req *CreateDelegationRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) RevokeDelegation(ctx context.Context, req *RevokeDelegationRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) EscalateOverdue(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
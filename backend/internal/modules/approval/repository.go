@@ -0,0 +1,306 @@
+package approval
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	// For Chain
+	FindChainByType(ctx context.Context, requestType constants.ApprovalRequestType) (*ApprovalChain, error)
+	FindAllChains(ctx context.Context) ([]ApprovalChain, error)
+	SaveChain(ctx context.Context, chain *ApprovalChain) error
+
+	// For Approval Request
+	FindRequestByID(ctx context.Context, id uint) (*ApprovalRequest, error)
+	FindRequestByReference(ctx context.Context, requestType constants.ApprovalRequestType, referenceID uint) (*ApprovalRequest, error)
+	LockRequestByReference(ctx context.Context, requestType constants.ApprovalRequestType, referenceID uint) (*ApprovalRequest, error)
+	CreateRequest(ctx context.Context, req *ApprovalRequest) error
+	UpdateRequest(ctx context.Context, req *ApprovalRequest) error
+	UpdateStep(ctx context.Context, step *ApprovalStep) error
+	CreateAssignees(ctx context.Context, assignees []ApprovalStepAssignee) error
+	CreateHistory(ctx context.Context, history *ApprovalHistory) error
+	FindOverdueSteps(ctx context.Context, now time.Time) ([]ApprovalStep, error)
+	FindPendingRequests(ctx context.Context, assigneeIDs []uint, filter *PendingApprovalFilter) ([]ApprovalRequest, int64, error)
+
+	// For Delegation
+	FindActiveDelegations(ctx context.Context, delegatorUserIDs []uint, on time.Time) ([]ApprovalDelegation, error)
+	FindActiveDelegationsByDelegate(ctx context.Context, delegateUserID uint, on time.Time) ([]ApprovalDelegation, error)
+	FindAllDelegations(ctx context.Context, filter *DelegationFilter) ([]ApprovalDelegation, int64, error)
+	FindDelegationByID(ctx context.Context, id uint) (*ApprovalDelegation, error)
+	CreateDelegation(ctx context.Context, delegation *ApprovalDelegation) error
+	UpdateDelegation(ctx context.Context, delegation *ApprovalDelegation) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) FindChainByType(ctx context.Context, requestType constants.ApprovalRequestType) (*ApprovalChain, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ApprovalChain{}))
+	var chain ApprovalChain
+	err := db.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("level ASC, id ASC")
+	}).Where("request_type = ?", requestType).First(&chain).Error
+	if err != nil {
+		return nil, err
+	}
+	return &chain, nil
+}
+
+func (r *repository) FindAllChains(ctx context.Context) ([]ApprovalChain, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ApprovalChain{}))
+	var chains []ApprovalChain
+	err := db.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("level ASC, id ASC")
+	}).Find(&chains).Error
+	if err != nil {
+		return nil, err
+	}
+	return chains, nil
+}
+
+// SaveChain creates or updates the chain and replaces all of its steps.
+func (r *repository) SaveChain(ctx context.Context, chain *ApprovalChain) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+
+	if err := db.Omit("Steps").Save(chain).Error; err != nil {
+		return err
+	}
+
+	if err := db.Where("chain_id = ?", chain.ID).Delete(&ApprovalChainStep{}).Error; err != nil {
+		return err
+	}
+
+	if len(chain.Steps) == 0 {
+		return nil
+	}
+
+	for i := range chain.Steps {
+		chain.Steps[i].ID = 0
+		chain.Steps[i].ChainID = chain.ID
+	}
+
+	return db.Create(&chain.Steps).Error
+}
+
+func (r *repository) FindRequestByID(ctx context.Context, id uint) (*ApprovalRequest, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ApprovalRequest{}))
+	var req ApprovalRequest
+	if err := r.preloadRequest(db).First(&req, id).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *repository) FindRequestByReference(ctx context.Context, requestType constants.ApprovalRequestType, referenceID uint) (*ApprovalRequest, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ApprovalRequest{}))
+	var req ApprovalRequest
+	err := r.preloadRequest(db).
+		Where("request_type = ? AND reference_id = ?", requestType, referenceID).
+		First(&req).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// LockRequestByReference locks the request row for the rest of the transaction before its steps are read, decisions
+// on the same request run one after another and each sees the steps the previous one left behind.
+func (r *repository) LockRequestByReference(ctx context.Context, requestType constants.ApprovalRequestType, referenceID uint) (*ApprovalRequest, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ApprovalRequest{}))
+	var req ApprovalRequest
+	err := r.preloadRequest(db.Clauses(clause.Locking{Strength: "UPDATE"})).
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			// a locking read sees the latest committed steps, not the snapshot the transaction started with
+			return db.Clauses(clause.Locking{Strength: "UPDATE"}).Order("level ASC, id ASC")
+		}).
+		Where("request_type = ? AND reference_id = ?", requestType, referenceID).
+		First(&req).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *repository) preloadRequest(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("level ASC, id ASC")
+		}).
+		Preload("Steps.Assignees.User.Employee").
+		Preload("Histories", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, id ASC")
+		}).
+		Preload("Histories.Actor.Employee")
+}
+
+func (r *repository) CreateRequest(ctx context.Context, req *ApprovalRequest) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Create(req).Error
+}
+
+func (r *repository) UpdateRequest(ctx context.Context, req *ApprovalRequest) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Omit(clause.Associations).Save(req).Error
+}
+
+func (r *repository) UpdateStep(ctx context.Context, step *ApprovalStep) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Omit(clause.Associations).Save(step).Error
+}
+
+func (r *repository) CreateAssignees(ctx context.Context, assignees []ApprovalStepAssignee) error {
+	if len(assignees) == 0 {
+		return nil
+	}
+
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Omit(clause.Associations).Create(&assignees).Error
+}
+
+func (r *repository) CreateHistory(ctx context.Context, history *ApprovalHistory) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Omit(clause.Associations).Create(history).Error
+}
+
+// FindOverdueSteps returns the pending steps past their SLA that were not escalated yet, across every company.
+func (r *repository) FindOverdueSteps(ctx context.Context, now time.Time) ([]ApprovalStep, error) {
+	db := utils.GetDBFromContext(ctx, r.db)
+	var steps []ApprovalStep
+	err := db.Model(&ApprovalStep{}).
+		Joins("JOIN approval_requests ON approval_requests.id = approval_steps.approval_request_id").
+		Where("approval_steps.status = ?", constants.ApprovalStepPending).
+		Where("approval_steps.due_at <= ?", now).
+		Where("approval_steps.escalated_at IS NULL").
+		Where("approval_requests.status = ?", constants.ApprovalStatusPending).
+		Preload("Assignees").
+		Order("approval_steps.due_at ASC").
+		Find(&steps).Error
+	if err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// FindPendingRequests returns the pending requests whose current level is waiting on any of the assignees,
+// oldest first and leaving out the ones filter.UserID requested themselves.
+func (r *repository) FindPendingRequests(ctx context.Context, assigneeIDs []uint, filter *PendingApprovalFilter) ([]ApprovalRequest, int64, error) {
+	if len(assigneeIDs) == 0 || len(filter.RequestTypes) == 0 {
+		return nil, 0, nil
+	}
+
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ApprovalRequest{}))
+	db = db.Where("approval_requests.status = ?", constants.ApprovalStatusPending).
+		Where("approval_requests.request_type IN ?", filter.RequestTypes).
+		Where("approval_requests.requester_user_id <> ?", filter.UserID).
+		Where(`EXISTS (SELECT 1 FROM approval_steps
+			JOIN approval_step_assignees ON approval_step_assignees.approval_step_id = approval_steps.id
+			WHERE approval_steps.approval_request_id = approval_requests.id
+			AND approval_steps.level = approval_requests.current_level
+			AND approval_steps.status = ?
+			AND approval_step_assignees.user_id IN ?)`, constants.ApprovalStepPending, assigneeIDs)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var requests []ApprovalRequest
+	err := db.Preload("Steps", "status = ?", constants.ApprovalStepPending).
+		Preload("Requester.Employee").
+		Order("approval_requests.created_at ASC, approval_requests.id ASC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&requests).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return requests, total, nil
+}
+
+func (r *repository) FindActiveDelegations(ctx context.Context, delegatorUserIDs []uint, on time.Time) ([]ApprovalDelegation, error) {
+	if len(delegatorUserIDs) == 0 {
+		return nil, nil
+	}
+
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ApprovalDelegation{}))
+	var delegations []ApprovalDelegation
+	err := db.Where("delegator_user_id IN ?", delegatorUserIDs).
+		Where("is_active = ? AND start_date <= ? AND end_date >= ?", true, on, on).
+		Find(&delegations).Error
+	if err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+func (r *repository) FindActiveDelegationsByDelegate(ctx context.Context, delegateUserID uint, on time.Time) ([]ApprovalDelegation, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ApprovalDelegation{}))
+	var delegations []ApprovalDelegation
+	err := db.Where("delegate_user_id = ?", delegateUserID).
+		Where("is_active = ? AND start_date <= ? AND end_date >= ?", true, on, on).
+		Find(&delegations).Error
+	if err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+func (r *repository) FindAllDelegations(ctx context.Context, filter *DelegationFilter) ([]ApprovalDelegation, int64, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ApprovalDelegation{}))
+
+	if filter.UserID != 0 {
+		db = db.Where("(delegator_user_id = ? OR delegate_user_id = ?)", filter.UserID, filter.UserID)
+	}
+	if filter.ActiveOnly {
+		db = db.Where("is_active = ?", true)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var delegations []ApprovalDelegation
+	err := db.Preload("Delegator.Employee").
+		Preload("Delegate.Employee").
+		Order("start_date DESC, id DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&delegations).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return delegations, total, nil
+}
+
+func (r *repository) FindDelegationByID(ctx context.Context, id uint) (*ApprovalDelegation, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ApprovalDelegation{}))
+	var delegation ApprovalDelegation
+	if err := db.First(&delegation, id).Error; err != nil {
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+func (r *repository) CreateDelegation(ctx context.Context, delegation *ApprovalDelegation) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Omit(clause.Associations).Create(delegation).Error
+}
+
+func (r *repository) UpdateDelegation(ctx context.Context, delegation *ApprovalDelegation) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Omit(clause.Associations).Save(delegation).Error
+}
@@ -0,0 +1,263 @@
+package approval
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/department"
+	"basekarya-backend/internal/modules/rbac"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupApprovalTestDB(t *testing.T) *testutil.TestDB {
+	t.Helper()
+	tdb := testutil.NewTestDB(
+		&rbac.Role{},
+		&department.Department{},
+		&user.User{},
+		&user.Employee{},
+		&ApprovalChain{},
+		&ApprovalChainStep{},
+		&ApprovalRequest{},
+		&ApprovalStep{},
+		&ApprovalStepAssignee{},
+		&ApprovalHistory{},
+		&ApprovalDelegation{},
+	)
+	t.Cleanup(tdb.Close)
+	return tdb
+}
+
+func seedApprovalUsers(t *testing.T, db *testutil.TestDB) {
+	t.Helper()
+	require.NoError(t, db.DB.Create(&rbac.Role{ID: 1, Name: "Admin", CompanyID: 1}).Error)
+	for _, u := range []user.User{
+		{ID: 1, Username: "john", RoleID: 1, CompanyID: 1},
+		{ID: 5, Username: "manager", RoleID: 1, CompanyID: 1},
+		{ID: 20, Username: "deputy", RoleID: 1, CompanyID: 1},
+	} {
+		require.NoError(t, db.DB.Create(&u).Error)
+	}
+}
+
+func TestRepository_SaveChain(t *testing.T) {
+	tdb := setupApprovalTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	chain := &ApprovalChain{
+		CompanyID:   1,
+		RequestType: constants.ApprovalRequestLoan,
+		Name:        "Loan",
+		IsActive:    true,
+		Steps: []ApprovalChainStep{
+			{Level: 2, ApproverType: constants.ApproverPermission, Permission: constants.APPROVAL_LOAN},
+			{Level: 1, ApproverType: constants.ApproverManager},
+		},
+	}
+	require.NoError(t, repo.SaveChain(ctx, chain))
+
+	// saving again replaces the steps instead of appending to them
+	chain.Steps = []ApprovalChainStep{{Level: 1, ApproverType: constants.ApproverDepartmentHead, SLAHours: 8}}
+	require.NoError(t, repo.SaveChain(ctx, chain))
+
+	found, err := repo.FindChainByType(ctx, constants.ApprovalRequestLoan)
+	require.NoError(t, err)
+	require.Len(t, found.Steps, 1)
+	assert.Equal(t, constants.ApproverDepartmentHead, found.Steps[0].ApproverType)
+	assert.Equal(t, 8, found.Steps[0].SLAHours)
+
+	// chains are per company
+	_, err = repo.FindChainByType(testutil.CtxWithTenant(2, 1, false), constants.ApprovalRequestLoan)
+	assert.Error(t, err)
+}
+
+func TestRepository_Request(t *testing.T) {
+	tdb := setupApprovalTestDB(t)
+	seedApprovalUsers(t, tdb)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	dueAt := time.Date(2026, 6, 1, 17, 0, 0, 0, time.UTC)
+	approvalReq := &ApprovalRequest{
+		CompanyID:       1,
+		RequestType:     constants.ApprovalRequestLeave,
+		ReferenceID:     9,
+		RequesterUserID: 1,
+		Status:          constants.ApprovalStatusPending,
+		CurrentLevel:    1,
+		Steps: []ApprovalStep{
+			{Level: 1, ApproverType: constants.ApproverManager, Status: constants.ApprovalStepPending, SLAHours: 8, DueAt: &dueAt, Assignees: []ApprovalStepAssignee{{UserID: 5}}},
+			{Level: 2, ApproverType: constants.ApproverPermission, Permission: constants.APPROVAL_LEAVE, Status: constants.ApprovalStepWaiting},
+		},
+	}
+	require.NoError(t, repo.CreateRequest(ctx, approvalReq))
+	require.NoError(t, repo.CreateHistory(ctx, &ApprovalHistory{
+		CompanyID:         1,
+		ApprovalRequestID: approvalReq.ID,
+		Action:            constants.ApprovalHistorySubmitted,
+		ActorUserID:       &approvalReq.RequesterUserID,
+	}))
+
+	t.Run("find by reference loads steps, assignees and history", func(t *testing.T) {
+		found, err := repo.FindRequestByReference(ctx, constants.ApprovalRequestLeave, 9)
+		require.NoError(t, err)
+		require.Len(t, found.Steps, 2)
+		require.Len(t, found.Steps[0].Assignees, 1)
+		assert.Equal(t, "manager", found.Steps[0].Assignees[0].User.Username)
+		require.Len(t, found.Histories, 1)
+		assert.Equal(t, "john", found.Histories[0].Actor.Username)
+	})
+
+	t.Run("lock by reference reads the latest steps inside the transaction", func(t *testing.T) {
+		err := testutil.NewTestTransactionManager(tdb.DB).RunInTransaction(ctx, func(txCtx context.Context) error {
+			locked, err := repo.LockRequestByReference(txCtx, constants.ApprovalRequestLeave, 9)
+			require.NoError(t, err)
+			require.Len(t, locked.Steps, 2)
+			assert.Equal(t, constants.ApprovalStepPending, locked.Steps[0].Status)
+			require.Len(t, locked.Steps[0].Assignees, 1)
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, err = repo.LockRequestByReference(ctx, constants.ApprovalRequestLeave, 404)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("overdue steps are found across companies until escalated", func(t *testing.T) {
+		steps, err := repo.FindOverdueSteps(ctx, dueAt.Add(-time.Minute))
+		require.NoError(t, err)
+		assert.Empty(t, steps)
+
+		steps, err = repo.FindOverdueSteps(testutil.CtxWithTenant(0, 0, true), dueAt.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, steps, 1)
+		require.Len(t, steps[0].Assignees, 1)
+
+		escalatedAt := dueAt.Add(time.Hour)
+		steps[0].EscalatedAt = &escalatedAt
+		require.NoError(t, repo.UpdateStep(ctx, &steps[0]))
+
+		steps, err = repo.FindOverdueSteps(ctx, dueAt.Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, steps)
+	})
+}
+
+func TestRepository_FindPendingRequests(t *testing.T) {
+	tdb := setupApprovalTestDB(t)
+	seedApprovalUsers(t, tdb)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	requests := []*ApprovalRequest{
+		// waiting on the manager at the current level
+		{CompanyID: 1, RequestType: constants.ApprovalRequestLeave, ReferenceID: 1, RequesterUserID: 1, Status: constants.ApprovalStatusPending, CurrentLevel: 1,
+			Steps: []ApprovalStep{{Level: 1, ApproverType: constants.ApproverManager, Status: constants.ApprovalStepPending, Assignees: []ApprovalStepAssignee{{UserID: 5}}}}},
+		// the manager is only asked at the next level
+		{CompanyID: 1, RequestType: constants.ApprovalRequestLoan, ReferenceID: 2, RequesterUserID: 1, Status: constants.ApprovalStatusPending, CurrentLevel: 1,
+			Steps: []ApprovalStep{
+				{Level: 1, ApproverType: constants.ApproverUser, Status: constants.ApprovalStepPending, Assignees: []ApprovalStepAssignee{{UserID: 20}}},
+				{Level: 2, ApproverType: constants.ApproverManager, Status: constants.ApprovalStepWaiting, Assignees: []ApprovalStepAssignee{{UserID: 5}}},
+			}},
+		// already decided
+		{CompanyID: 1, RequestType: constants.ApprovalRequestOvertime, ReferenceID: 3, RequesterUserID: 1, Status: constants.ApprovalStatusApproved, CurrentLevel: 1,
+			Steps: []ApprovalStep{{Level: 1, ApproverType: constants.ApproverManager, Status: constants.ApprovalStepApproved, Assignees: []ApprovalStepAssignee{{UserID: 5}}}}},
+		// the manager's own request
+		{CompanyID: 1, RequestType: constants.ApprovalRequestLeave, ReferenceID: 4, RequesterUserID: 5, Status: constants.ApprovalStatusPending, CurrentLevel: 1,
+			Steps: []ApprovalStep{{Level: 1, ApproverType: constants.ApproverDepartmentHead, Status: constants.ApprovalStepPending, Assignees: []ApprovalStepAssignee{{UserID: 5}}}}},
+	}
+	for _, r := range requests {
+		require.NoError(t, repo.CreateRequest(ctx, r))
+	}
+
+	filter := &PendingApprovalFilter{
+		Page:         1,
+		Limit:        10,
+		UserID:       5,
+		RequestTypes: []constants.ApprovalRequestType{constants.ApprovalRequestLeave, constants.ApprovalRequestLoan, constants.ApprovalRequestOvertime},
+	}
+
+	found, total, err := repo.FindPendingRequests(ctx, []uint{5}, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, found, 1)
+	assert.Equal(t, uint(1), found[0].ReferenceID)
+	assert.Equal(t, "john", found[0].Requester.Username)
+
+	// standing in for user 20 brings in the loan
+	_, total, err = repo.FindPendingRequests(ctx, []uint{5, 20}, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	// request types outside the filter are left out
+	filter.RequestTypes = []constants.ApprovalRequestType{constants.ApprovalRequestLoan}
+	_, total, err = repo.FindPendingRequests(ctx, []uint{5}, filter)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestRepository_Delegations(t *testing.T) {
+	tdb := setupApprovalTestDB(t)
+	seedApprovalUsers(t, tdb)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	require.NoError(t, repo.CreateDelegation(ctx, &ApprovalDelegation{
+		CompanyID:       1,
+		DelegatorUserID: 5,
+		DelegateUserID:  20,
+		StartDate:       time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC),
+		IsActive:        true,
+	}))
+	revoked := &ApprovalDelegation{
+		CompanyID:       1,
+		DelegatorUserID: 1,
+		DelegateUserID:  5,
+		StartDate:       time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC),
+		IsActive:        true,
+	}
+	require.NoError(t, repo.CreateDelegation(ctx, revoked))
+	revoked.IsActive = false
+	require.NoError(t, repo.UpdateDelegation(ctx, revoked))
+
+	t.Run("active delegations cover the whole period", func(t *testing.T) {
+		for _, on := range []time.Time{
+			time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC),
+		} {
+			found, err := repo.FindActiveDelegations(ctx, []uint{5}, on)
+			require.NoError(t, err)
+			assert.Len(t, found, 1)
+		}
+
+		found, err := repo.FindActiveDelegations(ctx, []uint{5}, time.Date(2026, 6, 6, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Empty(t, found)
+
+		found, err = repo.FindActiveDelegationsByDelegate(ctx, 20, time.Date(2026, 6, 3, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Len(t, found, 1)
+	})
+
+	t.Run("list filters by user and active state", func(t *testing.T) {
+		list, total, err := repo.FindAllDelegations(ctx, &DelegationFilter{Page: 1, Limit: 10, UserID: 5})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, list, 2)
+
+		list, total, err = repo.FindAllDelegations(ctx, &DelegationFilter{Page: 1, Limit: 10, UserID: 5, ActiveOnly: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "deputy", list[0].Delegate.Username)
+	})
+}
@@ -0,0 +1,47 @@
+package approval
+
+import (
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/pkg/logger"
+	"context"
+)
+
+type Scheduler interface {
+	Start()
+	Stop()
+}
+
+type scheduler struct {
+	cronProvider *infrastructure.CronProvider
+	service      Service
+}
+
+func NewScheduler(cronProvider *infrastructure.CronProvider, service Service) Scheduler {
+	return &scheduler{cronProvider, service}
+}
+
+func (sch *scheduler) Start() {
+	logger.Info("Approval Scheduler Started...")
+
+	// SLAs are set in hours, so overdue steps are checked at the top of every hour
+	_, err := sch.cronProvider.GetCron().AddFunc("0 * * * *", func() {
+		logger.Info("[SCHEDULER] Escalating overdue approvals...")
+
+		if err := sch.service.EscalateOverdue(context.Background()); err != nil {
+			logger.Errorf("[SCHEDULER] Failed: %v\n", err)
+		}
+	})
+
+	if err != nil {
+		logger.Errorf("Failed to start approval scheduler ", err)
+	}
+
+	sch.cronProvider.GetCron().Start()
+}
+
+func (sch *scheduler) Stop() {
+	if sch.cronProvider != nil && sch.cronProvider.GetCron() != nil {
+		sch.cronProvider.GetCron().Stop()
+		logger.Info("Approval Scheduler Stopped.")
+	}
+}
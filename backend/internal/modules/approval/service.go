@@ -0,0 +1,864 @@
+package approval
+
+import (
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/response"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Submit(ctx context.Context, req *SubmitRequest) ([]uint, error)
+	Decide(ctx context.Context, req *DecideRequest) (*Decision, error)
+	Cancel(ctx context.Context, requestType constants.ApprovalRequestType, referenceID, actorUserID uint) error
+	GetApproval(ctx context.Context, requestType constants.ApprovalRequestType, referenceID uint) (*ApprovalDetailResponse, error)
+	GetPendingApprovals(ctx context.Context, filter *PendingApprovalFilter) ([]PendingApprovalResponse, *response.Meta, error)
+	GetChains(ctx context.Context) ([]ApprovalChainResponse, error)
+	SaveChain(ctx context.Context, req *SaveChainRequest) error
+	GetDelegations(ctx context.Context, filter *DelegationFilter) ([]DelegationResponse, *response.Meta, error)
+	CreateDelegation(ctx context.Context, req *CreateDelegationRequest) error
+	RevokeDelegation(ctx context.Context, req *RevokeDelegationRequest) error
+	EscalateOverdue(ctx context.Context) error
+}
+
+var timeNow = time.Now
+
+type service struct {
+	repo               Repository
+	user               UserProvider
+	notification       NotificationProvider
+	transactionManager infrastructure.TransactionManager
+}
+
+func NewService(repo Repository, user UserProvider, notification NotificationProvider, transactionManager infrastructure.TransactionManager) Service {
+	return &service{repo, user, notification, transactionManager}
+}
+
+// Submit starts the approval of a request and returns who is asked to approve its first level.
+func (s *service) Submit(ctx context.Context, req *SubmitRequest) ([]uint, error) {
+	var notifyIDs []uint
+	err := s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		_, notifyIDs, err = s.start(ctx, req)
+		return err
+	})
+	return notifyIDs, err
+}
+
+func (s *service) start(ctx context.Context, req *SubmitRequest) (*ApprovalRequest, []uint, error) {
+	if _, ok := defaultPermissions[req.RequestType]; !ok {
+		return nil, nil, fmt.Errorf("invalid request type: %s", req.RequestType)
+	}
+
+	chain, err := s.resolveChain(ctx, req.RequestType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	approvalReq := &ApprovalRequest{
+		CompanyID:       utils.GetCompanyIDFromCtx(ctx),
+		RequestType:     req.RequestType,
+		ReferenceID:     req.ReferenceID,
+		RequesterUserID: req.RequesterUserID,
+		Amount:          req.Amount,
+		Status:          constants.ApprovalStatusPending,
+		Steps:           buildSteps(*chain, req.Amount),
+	}
+
+	// a chain whose every step sits above the amount still needs someone to approve
+	if len(approvalReq.Steps) == 0 {
+		approvalReq.Steps = buildSteps(defaultChain(req.RequestType), 0)
+	}
+
+	notifyIDs, err := s.activateLevel(ctx, approvalReq, approvalReq.Steps[0].Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.CreateRequest(ctx, approvalReq); err != nil {
+		return nil, nil, err
+	}
+
+	err = s.repo.CreateHistory(ctx, &ApprovalHistory{
+		CompanyID:         approvalReq.CompanyID,
+		ApprovalRequestID: approvalReq.ID,
+		Action:            constants.ApprovalHistorySubmitted,
+		ActorUserID:       &req.RequesterUserID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return approvalReq, notifyIDs, nil
+}
+
+// Decide records the decision of the actor on every step of the current level they may act on.
+// A rejection ends the approval, an approval moves on to the next level once the whole level is approved.
+func (s *service) Decide(ctx context.Context, req *DecideRequest) (*Decision, error) {
+	var decision *Decision
+	err := s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		approvalReq, err := s.repo.LockRequestByReference(ctx, req.RequestType, req.ReferenceID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// requests created before the engine existed start their approval on the first decision
+			approvalReq, _, err = s.start(ctx, &SubmitRequest{
+				RequestType:     req.RequestType,
+				ReferenceID:     req.ReferenceID,
+				RequesterUserID: req.RequesterUserID,
+				Amount:          req.Amount,
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		if approvalReq.Status != constants.ApprovalStatusPending {
+			return fmt.Errorf("cannot decide approval with status %s", approvalReq.Status)
+		}
+
+		steps, onBehalfOf, err := s.actionableSteps(ctx, approvalReq, req.ActorUserID)
+		if err != nil {
+			return err
+		}
+		if len(steps) == 0 {
+			return errors.New("you are not an approver of the current approval step")
+		}
+
+		now := timeNow()
+
+		switch req.Action {
+		case constants.ApprovalActionApprove:
+			for _, step := range steps {
+				if err := s.actOnStep(ctx, approvalReq, step, constants.ApprovalStepApproved, req, onBehalfOf[step.ID], now); err != nil {
+					return err
+				}
+			}
+
+			if !levelApproved(approvalReq.Steps, approvalReq.CurrentLevel) {
+				decision = &Decision{Status: constants.ApprovalStatusPending}
+				return nil
+			}
+
+			next := nextLevel(approvalReq.Steps, approvalReq.CurrentLevel)
+			if next == 0 {
+				approvalReq.Status = constants.ApprovalStatusApproved
+				decision = &Decision{Status: constants.ApprovalStatusApproved}
+				return s.repo.UpdateRequest(ctx, approvalReq)
+			}
+
+			notifyIDs, err := s.activateLevel(ctx, approvalReq, next)
+			if err != nil {
+				return err
+			}
+			if err := s.saveLevel(ctx, approvalReq, next); err != nil {
+				return err
+			}
+
+			decision = &Decision{Status: constants.ApprovalStatusPending, NextApproverIDs: notifyIDs}
+			return s.repo.UpdateRequest(ctx, approvalReq)
+
+		case constants.ApprovalActionReject:
+			if err := s.actOnStep(ctx, approvalReq, steps[0], constants.ApprovalStepRejected, req, onBehalfOf[steps[0].ID], now); err != nil {
+				return err
+			}
+			if err := s.skipOpenSteps(ctx, approvalReq); err != nil {
+				return err
+			}
+
+			approvalReq.Status = constants.ApprovalStatusRejected
+			decision = &Decision{Status: constants.ApprovalStatusRejected}
+			return s.repo.UpdateRequest(ctx, approvalReq)
+
+		default:
+			return fmt.Errorf("invalid action: %s", req.Action)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
+
+// Cancel stops the approval of a request withdrawn by the requester or an admin.
+// Requests without an approval, e.g. created before the engine existed, are left alone.
+func (s *service) Cancel(ctx context.Context, requestType constants.ApprovalRequestType, referenceID, actorUserID uint) error {
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		approvalReq, err := s.repo.LockRequestByReference(ctx, requestType, referenceID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if approvalReq.Status == constants.ApprovalStatusPending {
+			if err := s.skipOpenSteps(ctx, approvalReq); err != nil {
+				return err
+			}
+
+			approvalReq.Status = constants.ApprovalStatusCancelled
+			if err := s.repo.UpdateRequest(ctx, approvalReq); err != nil {
+				return err
+			}
+		}
+
+		return s.repo.CreateHistory(ctx, &ApprovalHistory{
+			CompanyID:         approvalReq.CompanyID,
+			ApprovalRequestID: approvalReq.ID,
+			Level:             approvalReq.CurrentLevel,
+			Action:            constants.ApprovalHistoryCancelled,
+			ActorUserID:       &actorUserID,
+		})
+	})
+}
+
+func (s *service) GetApproval(ctx context.Context, requestType constants.ApprovalRequestType, referenceID uint) (*ApprovalDetailResponse, error) {
+	approvalReq, err := s.repo.FindRequestByReference(ctx, requestType, referenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := make([]ApprovalStepResponse, 0, len(approvalReq.Steps))
+	for _, step := range approvalReq.Steps {
+		assignees := make([]ApprovalAssigneeResponse, 0, len(step.Assignees))
+		for _, a := range step.Assignees {
+			assignees = append(assignees, ApprovalAssigneeResponse{
+				UserID:        a.UserID,
+				Name:          displayName(a.User),
+				DelegatedFrom: a.DelegatedFrom,
+			})
+		}
+
+		steps = append(steps, ApprovalStepResponse{
+			ID:           step.ID,
+			Level:        step.Level,
+			ApproverType: step.ApproverType,
+			Permission:   step.Permission,
+			Status:       step.Status,
+			DueAt:        step.DueAt,
+			EscalatedAt:  step.EscalatedAt,
+			ActedBy:      step.ActedBy,
+			ActedAt:      step.ActedAt,
+			Assignees:    assignees,
+		})
+	}
+
+	histories := make([]ApprovalHistoryResponse, 0, len(approvalReq.Histories))
+	for _, h := range approvalReq.Histories {
+		actorName := "System"
+		if h.ActorUserID != nil {
+			actorName = displayName(h.Actor)
+		}
+
+		histories = append(histories, ApprovalHistoryResponse{
+			ID:          h.ID,
+			Level:       h.Level,
+			Action:      h.Action,
+			ActorUserID: h.ActorUserID,
+			ActorName:   actorName,
+			OnBehalfOf:  h.OnBehalfOf,
+			Notes:       h.Notes,
+			CreatedAt:   h.CreatedAt,
+		})
+	}
+
+	return &ApprovalDetailResponse{
+		ID:              approvalReq.ID,
+		RequestType:     approvalReq.RequestType,
+		ReferenceID:     approvalReq.ReferenceID,
+		RequesterUserID: approvalReq.RequesterUserID,
+		Amount:          approvalReq.Amount,
+		Status:          approvalReq.Status,
+		CurrentLevel:    approvalReq.CurrentLevel,
+		Steps:           steps,
+		Histories:       histories,
+	}, nil
+}
+
+// GetPendingApprovals lists the requests the user can act on now, including the ones of approvers
+// they currently stand in for.
+func (s *service) GetPendingApprovals(ctx context.Context, filter *PendingApprovalFilter) ([]PendingApprovalResponse, *response.Meta, error) {
+	now := timeNow()
+	delegations, err := s.repo.FindActiveDelegationsByDelegate(ctx, filter.UserID, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	assigneeIDs := []uint{filter.UserID}
+	for _, d := range delegations {
+		if !slices.Contains(assigneeIDs, d.DelegatorUserID) {
+			assigneeIDs = append(assigneeIDs, d.DelegatorUserID)
+		}
+	}
+
+	requests, total, err := s.repo.FindPendingRequests(ctx, assigneeIDs, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := make([]PendingApprovalResponse, 0, len(requests))
+	for _, r := range requests {
+		item := PendingApprovalResponse{
+			ID:              r.ID,
+			RequestType:     r.RequestType,
+			ReferenceID:     r.ReferenceID,
+			RequesterUserID: r.RequesterUserID,
+			RequesterName:   displayName(r.Requester),
+			Amount:          r.Amount,
+			CurrentLevel:    r.CurrentLevel,
+			SubmittedAt:     r.CreatedAt,
+		}
+		for _, step := range r.Steps {
+			if step.Level == r.CurrentLevel && step.DueAt != nil && (item.DueAt == nil || step.DueAt.Before(*item.DueAt)) {
+				item.DueAt = step.DueAt
+			}
+		}
+		list = append(list, item)
+	}
+
+	meta := response.NewMetaOffset(filter.Page, filter.Limit, total)
+	return list, meta, nil
+}
+
+func (s *service) GetChains(ctx context.Context) ([]ApprovalChainResponse, error) {
+	configured, err := s.repo.FindAllChains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[constants.ApprovalRequestType]ApprovalChain, len(configured))
+	for _, chain := range configured {
+		byType[chain.RequestType] = chain
+	}
+
+	list := make([]ApprovalChainResponse, 0, len(requestTypes))
+	for _, requestType := range requestTypes {
+		chain, ok := byType[requestType]
+		if !ok {
+			chain = defaultChain(requestType)
+		}
+
+		steps := make([]ChainStepResponse, 0, len(chain.Steps))
+		for _, step := range chain.Steps {
+			steps = append(steps, ChainStepResponse{
+				Level:          step.Level,
+				ApproverType:   step.ApproverType,
+				Permission:     step.Permission,
+				ApproverUserID: step.ApproverUserID,
+				MinAmount:      step.MinAmount,
+				SLAHours:       step.SLAHours,
+			})
+		}
+
+		list = append(list, ApprovalChainResponse{
+			RequestType: requestType,
+			Name:        chain.Name,
+			IsActive:    chain.IsActive,
+			IsDefault:   !ok,
+			Steps:       steps,
+		})
+	}
+
+	return list, nil
+}
+
+func (s *service) SaveChain(ctx context.Context, req *SaveChainRequest) error {
+	requestType := constants.ApprovalRequestType(req.RequestType)
+	if _, ok := defaultPermissions[requestType]; !ok {
+		return fmt.Errorf("invalid request type: %s", req.RequestType)
+	}
+
+	steps := make([]ApprovalChainStep, 0, len(req.Steps))
+	for _, step := range req.Steps {
+		approverType := constants.ApproverType(step.ApproverType)
+
+		switch approverType {
+		case constants.ApproverPermission:
+			if step.Permission == "" {
+				return errors.New("permission is required for permission approvers")
+			}
+			step.ApproverUserID = nil
+		case constants.ApproverUser:
+			if step.ApproverUserID == nil || *step.ApproverUserID == 0 {
+				return errors.New("approver user is required for user approvers")
+			}
+			if _, err := s.user.FindByID(ctx, *step.ApproverUserID); err != nil {
+				return errors.New("approver user not found")
+			}
+			step.Permission = ""
+		default:
+			step.Permission = ""
+			step.ApproverUserID = nil
+		}
+
+		steps = append(steps, ApprovalChainStep{
+			Level:          step.Level,
+			ApproverType:   approverType,
+			Permission:     step.Permission,
+			ApproverUserID: step.ApproverUserID,
+			MinAmount:      step.MinAmount,
+			SLAHours:       step.SLAHours,
+		})
+	}
+
+	name := req.Name
+	if name == "" {
+		name = string(requestType)
+	}
+
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		chain, err := s.repo.FindChainByType(ctx, requestType)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			chain = &ApprovalChain{
+				CompanyID:   utils.GetCompanyIDFromCtx(ctx),
+				RequestType: requestType,
+			}
+		} else if err != nil {
+			return err
+		}
+
+		chain.Name = name
+		chain.IsActive = req.IsActive
+		chain.Steps = steps
+
+		return s.repo.SaveChain(ctx, chain)
+	})
+}
+
+func (s *service) GetDelegations(ctx context.Context, filter *DelegationFilter) ([]DelegationResponse, *response.Meta, error) {
+	delegations, total, err := s.repo.FindAllDelegations(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := make([]DelegationResponse, 0, len(delegations))
+	for _, d := range delegations {
+		list = append(list, DelegationResponse{
+			ID:              d.ID,
+			DelegatorUserID: d.DelegatorUserID,
+			DelegatorName:   displayName(d.Delegator),
+			DelegateUserID:  d.DelegateUserID,
+			DelegateName:    displayName(d.Delegate),
+			StartDate:       d.StartDate.Format(constants.DefaultTimeFormat),
+			EndDate:         d.EndDate.Format(constants.DefaultTimeFormat),
+			Reason:          d.Reason,
+			IsActive:        d.IsActive,
+			CreatedAt:       d.CreatedAt,
+		})
+	}
+
+	meta := response.NewMetaOffset(filter.Page, filter.Limit, total)
+	return list, meta, nil
+}
+
+func (s *service) CreateDelegation(ctx context.Context, req *CreateDelegationRequest) error {
+	delegatorUserID := req.UserID
+	if req.DelegatorUserID != 0 && req.DelegatorUserID != req.UserID {
+		if !req.CanManage {
+			return errors.New("you can only delegate your own approvals")
+		}
+		delegatorUserID = req.DelegatorUserID
+	}
+
+	if req.DelegateUserID == delegatorUserID {
+		return errors.New("cannot delegate approvals to yourself")
+	}
+
+	start, err := time.Parse(constants.DefaultTimeFormat, req.StartDate)
+	if err != nil {
+		return errors.New("invalid start date format")
+	}
+	end, err := time.Parse(constants.DefaultTimeFormat, req.EndDate)
+	if err != nil {
+		return errors.New("invalid end date format")
+	}
+	if end.Before(start) {
+		return errors.New("end date must be after start date")
+	}
+
+	for _, id := range []uint{delegatorUserID, req.DelegateUserID} {
+		if _, err := s.user.FindByID(ctx, id); err != nil {
+			return fmt.Errorf("user %d not found", id)
+		}
+	}
+
+	return s.repo.CreateDelegation(ctx, &ApprovalDelegation{
+		CompanyID:       utils.GetCompanyIDFromCtx(ctx),
+		DelegatorUserID: delegatorUserID,
+		DelegateUserID:  req.DelegateUserID,
+		StartDate:       start,
+		EndDate:         end,
+		Reason:          req.Reason,
+		IsActive:        true,
+	})
+}
+
+// RevokeDelegation ends a delegation, approvals already assigned to the delegate stay with them.
+func (s *service) RevokeDelegation(ctx context.Context, req *RevokeDelegationRequest) error {
+	delegation, err := s.repo.FindDelegationByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if !req.CanManage && delegation.DelegatorUserID != req.UserID {
+		return errors.New("you can only revoke your own delegation")
+	}
+	if !delegation.IsActive {
+		return errors.New("delegation is already revoked")
+	}
+
+	delegation.IsActive = false
+	return s.repo.UpdateDelegation(ctx, delegation)
+}
+
+// EscalateOverdue hands every step past its SLA to the holders of the request type's approval permission.
+// It runs across companies, one failing step must not block the others.
+func (s *service) EscalateOverdue(ctx context.Context) error {
+	now := timeNow()
+
+	steps, err := s.repo.FindOverdueSteps(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range steps {
+		if err := s.escalate(ctx, &steps[i], now); err != nil {
+			errs = append(errs, fmt.Errorf("approval step %d: %w", steps[i].ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *service) escalate(ctx context.Context, step *ApprovalStep, now time.Time) error {
+	approvalReq, err := s.repo.FindRequestByID(ctx, step.ApprovalRequestID)
+	if err != nil {
+		return err
+	}
+
+	tenantCtx := context.WithValue(ctx, constants.CompanyIDContextKey, approvalReq.CompanyID)
+
+	var notifyIDs []uint
+	err = s.transactionManager.RunInTransaction(tenantCtx, func(ctx context.Context) error {
+		holders, err := s.user.FindApprovalUsers(ctx, defaultPermissions[approvalReq.RequestType])
+		if err != nil {
+			return err
+		}
+
+		var added []ApprovalStepAssignee
+		for _, id := range without(holders, approvalReq.RequesterUserID) {
+			if !slices.ContainsFunc(step.Assignees, func(a ApprovalStepAssignee) bool { return a.UserID == id }) {
+				added = append(added, ApprovalStepAssignee{ApprovalStepID: step.ID, UserID: id})
+				notifyIDs = append(notifyIDs, id)
+			}
+		}
+
+		// nobody new to hand it to, remind the current approvers instead
+		if len(notifyIDs) == 0 {
+			for _, a := range step.Assignees {
+				notifyIDs = appendUnique(notifyIDs, a.UserID)
+			}
+		}
+
+		if err := s.repo.CreateAssignees(ctx, added); err != nil {
+			return err
+		}
+
+		step.EscalatedAt = &now
+		if err := s.repo.UpdateStep(ctx, step); err != nil {
+			return err
+		}
+
+		return s.repo.CreateHistory(ctx, &ApprovalHistory{
+			CompanyID:         approvalReq.CompanyID,
+			ApprovalRequestID: approvalReq.ID,
+			ApprovalStepID:    &step.ID,
+			Level:             step.Level,
+			Action:            constants.ApprovalHistoryEscalated,
+			Notes:             fmt.Sprintf("Not decided within %d hours", step.SLAHours),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		_ = s.notification.BlastNotification(
+			utils.DetachContext(tenantCtx),
+			notifyIDs,
+			string(constants.NotificationTypeApprovalEscalated),
+			"Persetujuan Tertunda",
+			fmt.Sprintf("Pengajuan %s melewati batas waktu persetujuan dan menunggu tindakan Anda", approvalReq.RequestType),
+			approvalReq.ReferenceID,
+		)
+	}()
+
+	return nil
+}
+
+// resolveChain returns the company's active chain for the request type, else the default chain.
+func (s *service) resolveChain(ctx context.Context, requestType constants.ApprovalRequestType) (*ApprovalChain, error) {
+	chain, err := s.repo.FindChainByType(ctx, requestType)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && (!chain.IsActive || len(chain.Steps) == 0)) {
+		fallback := defaultChain(requestType)
+		return &fallback, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// activateLevel opens the steps of the level: it resolves who may approve them, starts their SLA
+// and returns who is asked to approve. Changes are kept in memory, see saveLevel.
+func (s *service) activateLevel(ctx context.Context, approvalReq *ApprovalRequest, level int) ([]uint, error) {
+	now := timeNow()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var notifyIDs []uint
+	for i := range approvalReq.Steps {
+		step := &approvalReq.Steps[i]
+		if step.Level != level {
+			continue
+		}
+
+		approverIDs, stepNotifyIDs, err := s.resolveApprovers(ctx, approvalReq, step)
+		if err != nil {
+			return nil, err
+		}
+
+		delegations, err := s.repo.FindActiveDelegations(ctx, approverIDs, today)
+		if err != nil {
+			return nil, err
+		}
+
+		step.Assignees, stepNotifyIDs = withDelegates(approverIDs, stepNotifyIDs, delegations, approvalReq.RequesterUserID)
+		step.Status = constants.ApprovalStepPending
+		if step.SLAHours > 0 {
+			dueAt := now.Add(time.Duration(step.SLAHours) * time.Hour)
+			step.DueAt = &dueAt
+		}
+
+		notifyIDs = appendUnique(notifyIDs, stepNotifyIDs...)
+	}
+
+	approvalReq.CurrentLevel = level
+	return notifyIDs, nil
+}
+
+// saveLevel persists the steps of a level opened by activateLevel on a stored request.
+func (s *service) saveLevel(ctx context.Context, approvalReq *ApprovalRequest, level int) error {
+	for i := range approvalReq.Steps {
+		step := &approvalReq.Steps[i]
+		if step.Level != level {
+			continue
+		}
+
+		if err := s.repo.UpdateStep(ctx, step); err != nil {
+			return err
+		}
+
+		for j := range step.Assignees {
+			step.Assignees[j].ApprovalStepID = step.ID
+		}
+		if err := s.repo.CreateAssignees(ctx, step.Assignees); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveApprovers returns who may approve the step and who is asked to. They only differ for
+// permission steps: any holder may approve, but the requester's manager is asked first when they hold it.
+// A step nobody can approve falls back to the holders of the request type's approval permission.
+func (s *service) resolveApprovers(ctx context.Context, approvalReq *ApprovalRequest, step *ApprovalStep) ([]uint, []uint, error) {
+	var approverIDs, notifyIDs []uint
+
+	switch step.ApproverType {
+	case constants.ApproverManager, constants.ApproverDepartmentHead:
+		id, err := s.findSupervisor(ctx, approvalReq.RequesterUserID, step.ApproverType)
+		if err != nil {
+			return nil, nil, err
+		}
+		if id != 0 {
+			approverIDs = []uint{id}
+		}
+
+	case constants.ApproverUser:
+		if step.ApproverUserID != nil {
+			u, err := s.user.FindByID(ctx, *step.ApproverUserID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, err
+			}
+			if err == nil && u.IsActive {
+				approverIDs = []uint{u.ID}
+			}
+		}
+
+	case constants.ApproverPermission:
+		holders, err := s.user.FindApprovalUsers(ctx, step.Permission)
+		if err != nil {
+			return nil, nil, err
+		}
+		approverIDs = holders
+
+		asked, err := s.user.FindRequestApprovers(ctx, approvalReq.RequesterUserID, step.Permission)
+		if err != nil {
+			return nil, nil, err
+		}
+		notifyIDs = without(asked, approvalReq.RequesterUserID)
+	}
+
+	approverIDs = without(approverIDs, approvalReq.RequesterUserID)
+
+	if len(approverIDs) == 0 {
+		holders, err := s.user.FindApprovalUsers(ctx, defaultPermissions[approvalReq.RequestType])
+		if err != nil {
+			return nil, nil, err
+		}
+		approverIDs = without(holders, approvalReq.RequesterUserID)
+		notifyIDs = nil
+	}
+
+	if len(notifyIDs) == 0 {
+		notifyIDs = approverIDs
+	}
+
+	return approverIDs, notifyIDs, nil
+}
+
+// findSupervisor returns the user of the requester's manager or department head, 0 when there is none
+// or they cannot approve.
+func (s *service) findSupervisor(ctx context.Context, requesterUserID uint, approverType constants.ApproverType) (uint, error) {
+	requester, err := s.user.FindByID(ctx, requesterUserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if requester.Employee == nil {
+		return 0, nil
+	}
+
+	var supervisorID *uint
+	if approverType == constants.ApproverManager {
+		supervisorID = requester.Employee.ManagerID
+	} else if requester.Employee.Department != nil {
+		supervisorID = requester.Employee.Department.HeadEmployeeID
+	}
+	if supervisorID == nil || *supervisorID == requester.Employee.ID {
+		return 0, nil
+	}
+
+	supervisor, err := s.user.FindEmployeeByID(ctx, *supervisorID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if supervisor.UserID == requesterUserID || !supervisor.User.IsActive {
+		return 0, nil
+	}
+	return supervisor.UserID, nil
+}
+
+// actionableSteps returns the pending steps of the current level the actor may act on, with the
+// user they stand in for when they act as a delegate.
+func (s *service) actionableSteps(ctx context.Context, approvalReq *ApprovalRequest, actorUserID uint) ([]*ApprovalStep, map[uint]*uint, error) {
+	var (
+		steps       []*ApprovalStep
+		onBehalfOf  = make(map[uint]*uint)
+		delegators  []uint
+		delegations []ApprovalDelegation
+		loaded      bool
+	)
+
+	for i := range approvalReq.Steps {
+		step := &approvalReq.Steps[i]
+		if step.Level != approvalReq.CurrentLevel || step.Status != constants.ApprovalStepPending {
+			continue
+		}
+
+		if idx := slices.IndexFunc(step.Assignees, func(a ApprovalStepAssignee) bool { return a.UserID == actorUserID }); idx >= 0 {
+			steps = append(steps, step)
+			onBehalfOf[step.ID] = step.Assignees[idx].DelegatedFrom
+			continue
+		}
+
+		// delegations made after the step was opened still let the delegate act
+		if !loaded {
+			now := timeNow()
+			var err error
+			delegations, err = s.repo.FindActiveDelegationsByDelegate(ctx, actorUserID, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, d := range delegations {
+				delegators = append(delegators, d.DelegatorUserID)
+			}
+			loaded = true
+		}
+
+		for _, a := range step.Assignees {
+			if slices.Contains(delegators, a.UserID) && a.UserID != approvalReq.RequesterUserID && actorUserID != approvalReq.RequesterUserID {
+				delegator := a.UserID
+				steps = append(steps, step)
+				onBehalfOf[step.ID] = &delegator
+				break
+			}
+		}
+	}
+
+	return steps, onBehalfOf, nil
+}
+
+func (s *service) actOnStep(ctx context.Context, approvalReq *ApprovalRequest, step *ApprovalStep, status constants.ApprovalStepStatus, req *DecideRequest, onBehalfOf *uint, now time.Time) error {
+	step.Status = status
+	step.ActedBy = &req.ActorUserID
+	step.ActedAt = &now
+	if err := s.repo.UpdateStep(ctx, step); err != nil {
+		return err
+	}
+
+	action := constants.ApprovalHistoryApproved
+	if status == constants.ApprovalStepRejected {
+		action = constants.ApprovalHistoryRejected
+	}
+
+	return s.repo.CreateHistory(ctx, &ApprovalHistory{
+		CompanyID:         approvalReq.CompanyID,
+		ApprovalRequestID: approvalReq.ID,
+		ApprovalStepID:    &step.ID,
+		Level:             step.Level,
+		Action:            action,
+		ActorUserID:       &req.ActorUserID,
+		OnBehalfOf:        onBehalfOf,
+		Notes:             req.Notes,
+	})
+}
+
+// skipOpenSteps closes every step nobody has acted on once the approval ends early.
+func (s *service) skipOpenSteps(ctx context.Context, approvalReq *ApprovalRequest) error {
+	for i := range approvalReq.Steps {
+		step := &approvalReq.Steps[i]
+		if step.Status != constants.ApprovalStepPending && step.Status != constants.ApprovalStepWaiting {
+			continue
+		}
+
+		step.Status = constants.ApprovalStepSkipped
+		if err := s.repo.UpdateStep(ctx, step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
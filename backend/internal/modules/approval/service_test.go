@@ -0,0 +1,628 @@
+package approval
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestApprovalService() (Service, *mockRepo, *mockUserProvider, *mockNotification) {
+	repo := new(mockRepo)
+	userProv := new(mockUserProvider)
+	notif := new(mockNotification)
+	tm := testutil.NewMockTransactionManager()
+
+	svc := NewService(repo, userProv, notif, tm)
+	return svc, repo, userProv, notif
+}
+
+// pinClock freezes the service clock so due dates and delegations do not depend on the day the tests run
+func pinClock(t *testing.T, at time.Time) {
+	t.Helper()
+	timeNow = func() time.Time { return at }
+	t.Cleanup(func() { timeNow = time.Now })
+}
+
+// twoLevelRequest is a loan approval waiting for the manager (user 5) at level 1
+// and for the finance approvers (users 10 and 11) at level 2.
+func twoLevelRequest() *ApprovalRequest {
+	return &ApprovalRequest{
+		ID:              1,
+		CompanyID:       1,
+		RequestType:     constants.ApprovalRequestLoan,
+		ReferenceID:     100,
+		RequesterUserID: 1,
+		Amount:          5000000,
+		Status:          constants.ApprovalStatusPending,
+		CurrentLevel:    1,
+		Steps: []ApprovalStep{
+			{ID: 1, ApprovalRequestID: 1, Level: 1, ApproverType: constants.ApproverManager, Status: constants.ApprovalStepPending, Assignees: []ApprovalStepAssignee{{UserID: 5}}},
+			{ID: 2, ApprovalRequestID: 1, Level: 2, ApproverType: constants.ApproverPermission, Permission: constants.APPROVAL_LOAN, SLAHours: 24, Status: constants.ApprovalStepWaiting},
+		},
+	}
+}
+
+func TestService_Submit(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	pinClock(t, time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC))
+
+	managerID := uint(2)
+
+	tests := []struct {
+		name       string
+		req        *SubmitRequest
+		setupMocks func(*mockRepo, *mockUserProvider)
+		wantIDs    []uint
+		wantErr    bool
+		errMsg     string
+	}{
+		{
+			name: "default chain asks the requester's approvers",
+			req:  &SubmitRequest{RequestType: constants.ApprovalRequestLoan, ReferenceID: 100, RequesterUserID: 1, Amount: 5000000},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				repo.On("FindChainByType", mock.Anything, constants.ApprovalRequestLoan).Return(nil, gorm.ErrRecordNotFound)
+				userProv.On("FindApprovalUsers", mock.Anything, constants.APPROVAL_LOAN).Return([]uint{1, 10, 11}, nil)
+				userProv.On("FindRequestApprovers", mock.Anything, uint(1), constants.APPROVAL_LOAN).Return([]uint{10}, nil)
+				repo.On("FindActiveDelegations", mock.Anything, []uint{10, 11}, mock.Anything).Return([]ApprovalDelegation{}, nil)
+				repo.On("CreateRequest", mock.Anything, mock.MatchedBy(func(r *ApprovalRequest) bool {
+					return r.CurrentLevel == 1 && len(r.Steps) == 1 &&
+						r.Steps[0].Status == constants.ApprovalStepPending && len(r.Steps[0].Assignees) == 2
+				})).Return(nil)
+				repo.On("CreateHistory", mock.Anything, mock.MatchedBy(func(h *ApprovalHistory) bool {
+					return h.Action == constants.ApprovalHistorySubmitted
+				})).Return(nil)
+			},
+			wantIDs: []uint{10},
+		},
+		{
+			name: "configured chain starts with the manager and skips steps above the amount",
+			req:  &SubmitRequest{RequestType: constants.ApprovalRequestLoan, ReferenceID: 100, RequesterUserID: 1, Amount: 5000000},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				repo.On("FindChainByType", mock.Anything, constants.ApprovalRequestLoan).Return(&ApprovalChain{
+					IsActive: true,
+					Steps: []ApprovalChainStep{
+						{Level: 1, ApproverType: constants.ApproverManager, SLAHours: 8},
+						{Level: 2, ApproverType: constants.ApproverPermission, Permission: constants.APPROVAL_LOAN, MinAmount: 10000000},
+					},
+				}, nil)
+				userProv.On("FindByID", mock.Anything, uint(1)).Return(&user.User{ID: 1, Employee: &user.Employee{ID: 1, ManagerID: &managerID}}, nil)
+				userProv.On("FindEmployeeByID", mock.Anything, managerID).Return(&user.Employee{ID: 2, UserID: 5, User: user.User{ID: 5, IsActive: true}}, nil)
+				repo.On("FindActiveDelegations", mock.Anything, []uint{5}, mock.Anything).Return([]ApprovalDelegation{}, nil)
+				repo.On("CreateRequest", mock.Anything, mock.MatchedBy(func(r *ApprovalRequest) bool {
+					return len(r.Steps) == 1 && r.Steps[0].ApproverType == constants.ApproverManager &&
+						r.Steps[0].DueAt != nil && r.Steps[0].DueAt.Equal(time.Date(2026, 6, 1, 17, 0, 0, 0, time.UTC))
+				})).Return(nil)
+				repo.On("CreateHistory", mock.Anything, mock.Anything).Return(nil)
+			},
+			wantIDs: []uint{5},
+		},
+		{
+			name: "delegate is asked instead of the approver on leave",
+			req:  &SubmitRequest{RequestType: constants.ApprovalRequestOvertime, ReferenceID: 5, RequesterUserID: 1, Amount: 2},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				repo.On("FindChainByType", mock.Anything, constants.ApprovalRequestOvertime).Return(nil, gorm.ErrRecordNotFound)
+				userProv.On("FindApprovalUsers", mock.Anything, constants.APPROVAL_OVERTIME).Return([]uint{10}, nil)
+				userProv.On("FindRequestApprovers", mock.Anything, uint(1), constants.APPROVAL_OVERTIME).Return([]uint{10}, nil)
+				repo.On("FindActiveDelegations", mock.Anything, []uint{10}, mock.Anything).Return([]ApprovalDelegation{
+					{DelegatorUserID: 10, DelegateUserID: 20},
+				}, nil)
+				repo.On("CreateRequest", mock.Anything, mock.MatchedBy(func(r *ApprovalRequest) bool {
+					return len(r.Steps[0].Assignees) == 2 && r.Steps[0].Assignees[1].UserID == 20
+				})).Return(nil)
+				repo.On("CreateHistory", mock.Anything, mock.Anything).Return(nil)
+			},
+			wantIDs: []uint{20},
+		},
+		{
+			name:       "error invalid request type",
+			req:        &SubmitRequest{RequestType: "PAYSLIP", ReferenceID: 1, RequesterUserID: 1},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {},
+			wantErr:    true,
+			errMsg:     "invalid request type: PAYSLIP",
+		},
+		{
+			name: "error create request fails",
+			req:  &SubmitRequest{RequestType: constants.ApprovalRequestLeave, ReferenceID: 1, RequesterUserID: 1, Amount: 1},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				repo.On("FindChainByType", mock.Anything, constants.ApprovalRequestLeave).Return(nil, gorm.ErrRecordNotFound)
+				userProv.On("FindApprovalUsers", mock.Anything, constants.APPROVAL_LEAVE).Return([]uint{10}, nil)
+				userProv.On("FindRequestApprovers", mock.Anything, uint(1), constants.APPROVAL_LEAVE).Return([]uint{10}, nil)
+				repo.On("FindActiveDelegations", mock.Anything, mock.Anything, mock.Anything).Return([]ApprovalDelegation{}, nil)
+				repo.On("CreateRequest", mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			wantErr: true,
+			errMsg:  "db error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, userProv, _ := newTestApprovalService()
+			tt.setupMocks(repo, userProv)
+
+			ids, err := svc.Submit(ctx, tt.req)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantIDs, ids)
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestService_Decide(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	pinClock(t, time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name       string
+		req        *DecideRequest
+		setupMocks func(*mockRepo, *mockUserProvider)
+		want       *Decision
+		errMsg     string
+	}{
+		{
+			name: "manager approval moves on to the next level",
+			req:  &DecideRequest{RequestType: constants.ApprovalRequestLoan, ReferenceID: 100, ActorUserID: 5, Action: constants.ApprovalActionApprove},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestLoan, uint(100)).Return(twoLevelRequest(), nil)
+				repo.On("UpdateStep", mock.Anything, mock.Anything).Return(nil)
+				repo.On("CreateHistory", mock.Anything, mock.MatchedBy(func(h *ApprovalHistory) bool {
+					return h.Action == constants.ApprovalHistoryApproved && h.Level == 1
+				})).Return(nil)
+				userProv.On("FindApprovalUsers", mock.Anything, constants.APPROVAL_LOAN).Return([]uint{10, 11}, nil)
+				userProv.On("FindRequestApprovers", mock.Anything, uint(1), constants.APPROVAL_LOAN).Return([]uint{10, 11}, nil)
+				repo.On("FindActiveDelegations", mock.Anything, []uint{10, 11}, mock.Anything).Return([]ApprovalDelegation{}, nil)
+				repo.On("CreateAssignees", mock.Anything, mock.MatchedBy(func(a []ApprovalStepAssignee) bool {
+					return len(a) == 2 && a[0].ApprovalStepID == 2
+				})).Return(nil)
+				repo.On("UpdateRequest", mock.Anything, mock.MatchedBy(func(r *ApprovalRequest) bool {
+					return r.CurrentLevel == 2 && r.Status == constants.ApprovalStatusPending
+				})).Return(nil)
+			},
+			want: &Decision{Status: constants.ApprovalStatusPending, NextApproverIDs: []uint{10, 11}},
+		},
+		{
+			name: "last level approval approves the request",
+			req:  &DecideRequest{RequestType: constants.ApprovalRequestLoan, ReferenceID: 100, ActorUserID: 10, Action: constants.ApprovalActionApprove},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				approvalReq := twoLevelRequest()
+				approvalReq.CurrentLevel = 2
+				approvalReq.Steps[0].Status = constants.ApprovalStepApproved
+				approvalReq.Steps[1].Status = constants.ApprovalStepPending
+				approvalReq.Steps[1].Assignees = []ApprovalStepAssignee{{UserID: 10}, {UserID: 11}}
+
+				repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestLoan, uint(100)).Return(approvalReq, nil)
+				repo.On("UpdateStep", mock.Anything, mock.Anything).Return(nil)
+				repo.On("CreateHistory", mock.Anything, mock.Anything).Return(nil)
+				repo.On("UpdateRequest", mock.Anything, mock.MatchedBy(func(r *ApprovalRequest) bool {
+					return r.Status == constants.ApprovalStatusApproved
+				})).Return(nil)
+			},
+			want: &Decision{Status: constants.ApprovalStatusApproved},
+		},
+		{
+			name: "rejection skips the remaining levels",
+			req:  &DecideRequest{RequestType: constants.ApprovalRequestLoan, ReferenceID: 100, ActorUserID: 5, Action: constants.ApprovalActionReject, Notes: "Too large"},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestLoan, uint(100)).Return(twoLevelRequest(), nil)
+				repo.On("UpdateStep", mock.Anything, mock.MatchedBy(func(s *ApprovalStep) bool {
+					return s.ID == 1 && s.Status == constants.ApprovalStepRejected
+				})).Return(nil).Once()
+				repo.On("UpdateStep", mock.Anything, mock.MatchedBy(func(s *ApprovalStep) bool {
+					return s.ID == 2 && s.Status == constants.ApprovalStepSkipped
+				})).Return(nil).Once()
+				repo.On("CreateHistory", mock.Anything, mock.MatchedBy(func(h *ApprovalHistory) bool {
+					return h.Action == constants.ApprovalHistoryRejected && h.Notes == "Too large"
+				})).Return(nil)
+				repo.On("UpdateRequest", mock.Anything, mock.MatchedBy(func(r *ApprovalRequest) bool {
+					return r.Status == constants.ApprovalStatusRejected
+				})).Return(nil)
+			},
+			want: &Decision{Status: constants.ApprovalStatusRejected},
+		},
+		{
+			name: "delegate approves on behalf of the manager",
+			req:  &DecideRequest{RequestType: constants.ApprovalRequestLoan, ReferenceID: 100, ActorUserID: 20, Action: constants.ApprovalActionReject},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestLoan, uint(100)).Return(twoLevelRequest(), nil)
+				repo.On("FindActiveDelegationsByDelegate", mock.Anything, uint(20), mock.Anything).Return([]ApprovalDelegation{
+					{DelegatorUserID: 5, DelegateUserID: 20},
+				}, nil)
+				repo.On("UpdateStep", mock.Anything, mock.Anything).Return(nil)
+				repo.On("CreateHistory", mock.Anything, mock.MatchedBy(func(h *ApprovalHistory) bool {
+					return h.OnBehalfOf != nil && *h.OnBehalfOf == 5 && *h.ActorUserID == 20
+				})).Return(nil)
+				repo.On("UpdateRequest", mock.Anything, mock.Anything).Return(nil)
+			},
+			want: &Decision{Status: constants.ApprovalStatusRejected},
+		},
+		{
+			name: "error actor is not an approver of the current level",
+			req:  &DecideRequest{RequestType: constants.ApprovalRequestLoan, ReferenceID: 100, ActorUserID: 10, Action: constants.ApprovalActionApprove},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestLoan, uint(100)).Return(twoLevelRequest(), nil)
+				repo.On("FindActiveDelegationsByDelegate", mock.Anything, uint(10), mock.Anything).Return([]ApprovalDelegation{}, nil)
+			},
+			errMsg: "you are not an approver of the current approval step",
+		},
+		{
+			name: "error step already approved by a decision that held the lock first",
+			req:  &DecideRequest{RequestType: constants.ApprovalRequestLoan, ReferenceID: 100, ActorUserID: 5, Action: constants.ApprovalActionApprove},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				approvalReq := twoLevelRequest()
+				approvalReq.Steps[0].Status = constants.ApprovalStepApproved
+				repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestLoan, uint(100)).Return(approvalReq, nil)
+			},
+			errMsg: "you are not an approver of the current approval step",
+		},
+		{
+			name: "error approval already decided",
+			req:  &DecideRequest{RequestType: constants.ApprovalRequestLoan, ReferenceID: 100, ActorUserID: 5, Action: constants.ApprovalActionApprove},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				approvalReq := twoLevelRequest()
+				approvalReq.Status = constants.ApprovalStatusRejected
+				repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestLoan, uint(100)).Return(approvalReq, nil)
+			},
+			errMsg: "cannot decide approval with status REJECTED",
+		},
+		{
+			name: "error invalid action",
+			req:  &DecideRequest{RequestType: constants.ApprovalRequestLoan, ReferenceID: 100, ActorUserID: 5, Action: "HOLD"},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestLoan, uint(100)).Return(twoLevelRequest(), nil)
+			},
+			errMsg: "invalid action: HOLD",
+		},
+		{
+			name: "request created before the engine starts its approval on the first decision",
+			req:  &DecideRequest{RequestType: constants.ApprovalRequestReimbursement, ReferenceID: 7, RequesterUserID: 1, Amount: 50000, ActorUserID: 10, Action: constants.ApprovalActionApprove},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestReimbursement, uint(7)).Return(nil, gorm.ErrRecordNotFound)
+				repo.On("FindChainByType", mock.Anything, constants.ApprovalRequestReimbursement).Return(nil, gorm.ErrRecordNotFound)
+				userProv.On("FindApprovalUsers", mock.Anything, constants.APPROVAL_REIMBURSEMENT).Return([]uint{10}, nil)
+				userProv.On("FindRequestApprovers", mock.Anything, uint(1), constants.APPROVAL_REIMBURSEMENT).Return([]uint{10}, nil)
+				repo.On("FindActiveDelegations", mock.Anything, []uint{10}, mock.Anything).Return([]ApprovalDelegation{}, nil)
+				repo.On("CreateRequest", mock.Anything, mock.Anything).Return(nil)
+				repo.On("CreateHistory", mock.Anything, mock.Anything).Return(nil)
+				repo.On("UpdateStep", mock.Anything, mock.Anything).Return(nil)
+				repo.On("UpdateRequest", mock.Anything, mock.MatchedBy(func(r *ApprovalRequest) bool {
+					return r.Status == constants.ApprovalStatusApproved && r.Amount == 50000
+				})).Return(nil)
+			},
+			want: &Decision{Status: constants.ApprovalStatusApproved},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, userProv, _ := newTestApprovalService()
+			tt.setupMocks(repo, userProv)
+
+			decision, err := svc.Decide(ctx, tt.req)
+
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+				repo.AssertNotCalled(t, "UpdateRequest", mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, decision)
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestService_Cancel(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("pending approval is cancelled", func(t *testing.T) {
+		svc, repo, _, _ := newTestApprovalService()
+		repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestLeave, uint(3)).Return(twoLevelRequest(), nil)
+		repo.On("UpdateStep", mock.Anything, mock.MatchedBy(func(s *ApprovalStep) bool {
+			return s.Status == constants.ApprovalStepSkipped
+		})).Return(nil).Twice()
+		repo.On("UpdateRequest", mock.Anything, mock.MatchedBy(func(r *ApprovalRequest) bool {
+			return r.Status == constants.ApprovalStatusCancelled
+		})).Return(nil)
+		repo.On("CreateHistory", mock.Anything, mock.MatchedBy(func(h *ApprovalHistory) bool {
+			return h.Action == constants.ApprovalHistoryCancelled && *h.ActorUserID == 1
+		})).Return(nil)
+
+		require.NoError(t, svc.Cancel(ctx, constants.ApprovalRequestLeave, 3, 1))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("request without approval is left alone", func(t *testing.T) {
+		svc, repo, _, _ := newTestApprovalService()
+		repo.On("LockRequestByReference", mock.Anything, constants.ApprovalRequestLeave, uint(4)).Return(nil, gorm.ErrRecordNotFound)
+
+		require.NoError(t, svc.Cancel(ctx, constants.ApprovalRequestLeave, 4, 1))
+		repo.AssertNotCalled(t, "CreateHistory", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_GetPendingApprovals(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	pinClock(t, time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC))
+
+	svc, repo, _, _ := newTestApprovalService()
+
+	filter := &PendingApprovalFilter{Page: 1, Limit: 10, UserID: 20, RequestTypes: []constants.ApprovalRequestType{constants.ApprovalRequestLoan}}
+	early := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	late := early.Add(4 * time.Hour)
+	req := twoLevelRequest()
+	req.Steps[0].DueAt = &late
+	req.Steps = append(req.Steps, ApprovalStep{ID: 3, Level: 1, Status: constants.ApprovalStepPending, DueAt: &early})
+	req.Requester = &user.User{Username: "john", Employee: &user.Employee{FullName: "John Doe"}}
+
+	// user 20 stands in for the manager, so the manager's requests are listed too
+	repo.On("FindActiveDelegationsByDelegate", ctx, uint(20), time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)).
+		Return([]ApprovalDelegation{{DelegatorUserID: 5}}, nil)
+	repo.On("FindPendingRequests", ctx, []uint{20, 5}, filter).Return([]ApprovalRequest{*req}, int64(1), nil)
+
+	list, meta, err := svc.GetPendingApprovals(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "John Doe", list[0].RequesterName)
+	assert.Equal(t, uint(100), list[0].ReferenceID)
+	require.NotNil(t, list[0].DueAt)
+	assert.Equal(t, early, *list[0].DueAt)
+	assert.Equal(t, int64(1), meta.TotalData)
+	repo.AssertExpectations(t)
+}
+
+func TestService_SaveChain(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	approverID := uint(9)
+
+	tests := []struct {
+		name       string
+		req        *SaveChainRequest
+		setupMocks func(*mockRepo, *mockUserProvider)
+		errMsg     string
+	}{
+		{
+			name: "success creates the chain",
+			req: &SaveChainRequest{
+				RequestType: string(constants.ApprovalRequestLoan),
+				IsActive:    true,
+				Steps: []ChainStepRequest{
+					{Level: 1, ApproverType: string(constants.ApproverManager), Permission: "ignored"},
+					{Level: 2, ApproverType: string(constants.ApproverUser), ApproverUserID: &approverID, MinAmount: 10000000, SLAHours: 24},
+				},
+			},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				userProv.On("FindByID", mock.Anything, approverID).Return(&user.User{ID: approverID}, nil)
+				repo.On("FindChainByType", mock.Anything, constants.ApprovalRequestLoan).Return(nil, gorm.ErrRecordNotFound)
+				repo.On("SaveChain", mock.Anything, mock.MatchedBy(func(c *ApprovalChain) bool {
+					return c.CompanyID == 1 && c.Name == "LOAN" && len(c.Steps) == 2 && c.Steps[0].Permission == ""
+				})).Return(nil)
+			},
+		},
+		{
+			name:       "error invalid request type",
+			req:        &SaveChainRequest{RequestType: "PAYSLIP", Steps: []ChainStepRequest{{Level: 1, ApproverType: string(constants.ApproverManager)}}},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {},
+			errMsg:     "invalid request type: PAYSLIP",
+		},
+		{
+			name:       "error permission step without permission",
+			req:        &SaveChainRequest{RequestType: string(constants.ApprovalRequestLeave), Steps: []ChainStepRequest{{Level: 1, ApproverType: string(constants.ApproverPermission)}}},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {},
+			errMsg:     "permission is required for permission approvers",
+		},
+		{
+			name:       "error user step without user",
+			req:        &SaveChainRequest{RequestType: string(constants.ApprovalRequestLeave), Steps: []ChainStepRequest{{Level: 1, ApproverType: string(constants.ApproverUser)}}},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {},
+			errMsg:     "approver user is required for user approvers",
+		},
+		{
+			name: "error user step with unknown user",
+			req:  &SaveChainRequest{RequestType: string(constants.ApprovalRequestLeave), Steps: []ChainStepRequest{{Level: 1, ApproverType: string(constants.ApproverUser), ApproverUserID: &approverID}}},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				userProv.On("FindByID", mock.Anything, approverID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			errMsg: "approver user not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, userProv, _ := newTestApprovalService()
+			tt.setupMocks(repo, userProv)
+
+			err := svc.SaveChain(ctx, tt.req)
+
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				require.NoError(t, err)
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestService_GetChains(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, repo, _, _ := newTestApprovalService()
+
+	repo.On("FindAllChains", mock.Anything).Return([]ApprovalChain{
+		{RequestType: constants.ApprovalRequestLoan, Name: "Loan", IsActive: true, Steps: []ApprovalChainStep{
+			{Level: 1, ApproverType: constants.ApproverManager},
+		}},
+	}, nil)
+
+	chains, err := svc.GetChains(ctx)
+
+	require.NoError(t, err)
+	require.Len(t, chains, len(requestTypes))
+	for _, chain := range chains {
+		if chain.RequestType == constants.ApprovalRequestLoan {
+			assert.False(t, chain.IsDefault)
+			assert.Equal(t, constants.ApproverManager, chain.Steps[0].ApproverType)
+			continue
+		}
+		assert.True(t, chain.IsDefault)
+		assert.Equal(t, defaultPermissions[chain.RequestType], chain.Steps[0].Permission)
+	}
+}
+
+func TestService_CreateDelegation(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	tests := []struct {
+		name       string
+		req        *CreateDelegationRequest
+		setupMocks func(*mockRepo, *mockUserProvider)
+		errMsg     string
+	}{
+		{
+			name: "success delegates own approvals",
+			req:  &CreateDelegationRequest{UserID: 5, DelegateUserID: 20, StartDate: "2026-06-01", EndDate: "2026-06-05", Reason: "Annual leave"},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				userProv.On("FindByID", mock.Anything, mock.Anything).Return(&user.User{}, nil)
+				repo.On("CreateDelegation", mock.Anything, mock.MatchedBy(func(d *ApprovalDelegation) bool {
+					return d.DelegatorUserID == 5 && d.DelegateUserID == 20 && d.IsActive
+				})).Return(nil)
+			},
+		},
+		{
+			name: "success manager delegates on behalf of someone else",
+			req:  &CreateDelegationRequest{UserID: 1, CanManage: true, DelegatorUserID: 5, DelegateUserID: 20, StartDate: "2026-06-01", EndDate: "2026-06-01"},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				userProv.On("FindByID", mock.Anything, mock.Anything).Return(&user.User{}, nil)
+				repo.On("CreateDelegation", mock.Anything, mock.MatchedBy(func(d *ApprovalDelegation) bool {
+					return d.DelegatorUserID == 5
+				})).Return(nil)
+			},
+		},
+		{
+			name:       "error delegating someone else's approvals",
+			req:        &CreateDelegationRequest{UserID: 1, DelegatorUserID: 5, DelegateUserID: 20, StartDate: "2026-06-01", EndDate: "2026-06-05"},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {},
+			errMsg:     "you can only delegate your own approvals",
+		},
+		{
+			name:       "error delegating to yourself",
+			req:        &CreateDelegationRequest{UserID: 5, DelegateUserID: 5, StartDate: "2026-06-01", EndDate: "2026-06-05"},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {},
+			errMsg:     "cannot delegate approvals to yourself",
+		},
+		{
+			name:       "error end date before start date",
+			req:        &CreateDelegationRequest{UserID: 5, DelegateUserID: 20, StartDate: "2026-06-05", EndDate: "2026-06-01"},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {},
+			errMsg:     "end date must be after start date",
+		},
+		{
+			name: "error delegate not found",
+			req:  &CreateDelegationRequest{UserID: 5, DelegateUserID: 20, StartDate: "2026-06-01", EndDate: "2026-06-05"},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				userProv.On("FindByID", mock.Anything, uint(5)).Return(&user.User{}, nil)
+				userProv.On("FindByID", mock.Anything, uint(20)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			errMsg: "user 20 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, userProv, _ := newTestApprovalService()
+			tt.setupMocks(repo, userProv)
+
+			err := svc.CreateDelegation(ctx, tt.req)
+
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+				repo.AssertNotCalled(t, "CreateDelegation", mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestService_RevokeDelegation(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	tests := []struct {
+		name       string
+		req        *RevokeDelegationRequest
+		delegation *ApprovalDelegation
+		errMsg     string
+	}{
+		{name: "delegator revokes", req: &RevokeDelegationRequest{ID: 1, UserID: 5}, delegation: &ApprovalDelegation{ID: 1, DelegatorUserID: 5, IsActive: true}},
+		{name: "manager revokes", req: &RevokeDelegationRequest{ID: 1, UserID: 1, CanManage: true}, delegation: &ApprovalDelegation{ID: 1, DelegatorUserID: 5, IsActive: true}},
+		{name: "error someone else's delegation", req: &RevokeDelegationRequest{ID: 1, UserID: 20}, delegation: &ApprovalDelegation{ID: 1, DelegatorUserID: 5, IsActive: true}, errMsg: "you can only revoke your own delegation"},
+		{name: "error already revoked", req: &RevokeDelegationRequest{ID: 1, UserID: 5}, delegation: &ApprovalDelegation{ID: 1, DelegatorUserID: 5}, errMsg: "delegation is already revoked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, _ := newTestApprovalService()
+			repo.On("FindDelegationByID", mock.Anything, uint(1)).Return(tt.delegation, nil)
+			repo.On("UpdateDelegation", mock.Anything, mock.MatchedBy(func(d *ApprovalDelegation) bool {
+				return !d.IsActive
+			})).Return(nil).Maybe()
+
+			err := svc.RevokeDelegation(ctx, tt.req)
+
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+				repo.AssertNotCalled(t, "UpdateDelegation", mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				repo.AssertCalled(t, "UpdateDelegation", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestService_EscalateOverdue(t *testing.T) {
+	now := time.Date(2026, 6, 2, 9, 0, 0, 0, time.UTC)
+	pinClock(t, now)
+
+	svc, repo, userProv, notif := newTestApprovalService()
+
+	approvalReq := twoLevelRequest()
+	step := approvalReq.Steps[0]
+	step.SLAHours = 24
+
+	repo.On("FindOverdueSteps", mock.Anything, now).Return([]ApprovalStep{step}, nil)
+	repo.On("FindRequestByID", mock.Anything, uint(1)).Return(approvalReq, nil)
+	userProv.On("FindApprovalUsers", mock.Anything, constants.APPROVAL_LOAN).Return([]uint{1, 5, 10}, nil)
+	repo.On("CreateAssignees", mock.Anything, mock.MatchedBy(func(a []ApprovalStepAssignee) bool {
+		return len(a) == 1 && a[0].UserID == 10 && a[0].ApprovalStepID == 1
+	})).Return(nil)
+	repo.On("UpdateStep", mock.Anything, mock.MatchedBy(func(s *ApprovalStep) bool {
+		return s.EscalatedAt != nil && s.EscalatedAt.Equal(now)
+	})).Return(nil)
+	repo.On("CreateHistory", mock.Anything, mock.MatchedBy(func(h *ApprovalHistory) bool {
+		return h.Action == constants.ApprovalHistoryEscalated && h.ActorUserID == nil && h.Notes == "Not decided within 24 hours"
+	})).Return(nil)
+	notif.On("BlastNotification", mock.Anything, []uint{10}, string(constants.NotificationTypeApprovalEscalated), mock.Anything, mock.Anything, uint(100)).Return(nil).Maybe()
+
+	require.NoError(t, svc.EscalateOverdue(testutil.CtxWithTenant(0, 0, true)))
+	repo.AssertExpectations(t)
+}
@@ -3,6 +3,7 @@ package asset
 import (
 	"context"
 
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
 
@@ -82,6 +83,11 @@ func (m *mockRepo) FindActiveAssignmentByAssetID(ctx context.Context, assetID ui
 	return args.Get(0).(*AssetAssignment), args.Error(1)
 }
 
+func (m *mockRepo) FindActiveAssignmentsByEmployee(ctx context.Context, employeeID uint) ([]AssetAssignment, error) {
+	args := m.Called(ctx, employeeID)
+	return args.Get(0).([]AssetAssignment), args.Error(1)
+}
+
 func (m *mockRepo) FindAllAssignments(ctx context.Context, filter AssetAssignmentFilter) ([]AssetAssignment, int64, error) {
 	args := m.Called(ctx, filter)
 	return args.Get(0).([]AssetAssignment), args.Get(1).(int64), args.Error(2)
@@ -101,13 +107,21 @@ func (m *mockNotification) BlastNotification(ctx context.Context, userIDs []uint
 	return m.Called(ctx, userIDs, notifType, title, message, relatedID).Error(0)
 }
 
-type mockUserProvider struct{ mock.Mock }
+type mockApprovalEngine struct{ mock.Mock }
 
-func (m *mockUserProvider) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
-	args := m.Called(ctx, permissionApprovalName)
+func (m *mockApprovalEngine) Submit(ctx context.Context, req *approval.SubmitRequest) ([]uint, error) {
+	args := m.Called(ctx, req)
 	return args.Get(0).([]uint), args.Error(1)
 }
 
+func (m *mockApprovalEngine) Decide(ctx context.Context, req *approval.DecideRequest) (*approval.Decision, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*approval.Decision), args.Error(1)
+}
+
 type mockExcel struct{ mock.Mock }
 
 func (m *mockExcel) GenerateSimpleExcel(sheetName string, headers []string, rows [][]interface{}) ([]byte, error) {
@@ -206,6 +220,11 @@ func (m *mockService) GetAssignmentDetail(ctx context.Context, id uint) (*AssetA
 	return args.Get(0).(*AssetAssignmentDetailResponse), args.Error(1)
 }
 
+func (m *mockService) GetActiveAssignments(ctx context.Context, employeeID uint) ([]AssetAssignmentListResponse, error) {
+	args := m.Called(ctx, employeeID)
+	return args.Get(0).([]AssetAssignmentListResponse), args.Error(1)
+}
+
 func (m *mockService) GetAssignments(ctx context.Context, filter AssetAssignmentFilter) ([]AssetAssignmentListResponse, *response.Meta, error) {
 	args := m.Called(ctx, filter)
 	var meta *response.Meta
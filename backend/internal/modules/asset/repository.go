@@ -23,6 +23,7 @@ type Repository interface {
 	CreateAssignment(ctx context.Context, assignment *AssetAssignment) error
 	FindAssignmentByID(ctx context.Context, id uint) (*AssetAssignment, error)
 	FindActiveAssignmentByAssetID(ctx context.Context, assetID uint) (*AssetAssignment, error)
+	FindActiveAssignmentsByEmployee(ctx context.Context, employeeID uint) ([]AssetAssignment, error)
 	FindAllAssignments(ctx context.Context, filter AssetAssignmentFilter) ([]AssetAssignment, int64, error)
 	UpdateAssignment(ctx context.Context, assignment *AssetAssignment) error
 }
@@ -170,6 +171,19 @@ func (r *repository) FindActiveAssignmentByAssetID(ctx context.Context, assetID
 	return &assignment, nil
 }
 
+func (r *repository) FindActiveAssignmentsByEmployee(ctx context.Context, employeeID uint) ([]AssetAssignment, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var assignments []AssetAssignment
+	err := db.
+		Preload("Asset").
+		Preload("Employee").
+		Where("employee_id = ?", employeeID).
+		Where("status = ?", "ACTIVE").
+		Order("id ASC").
+		Find(&assignments).Error
+	return assignments, err
+}
+
 func (r *repository) FindAllAssignments(ctx context.Context, filter AssetAssignmentFilter) ([]AssetAssignment, int64, error) {
 	db := utils.GetDBFromContext(ctx, r.db)
 	var assignments []AssetAssignment
@@ -2,6 +2,7 @@ package asset
 
 import (
 	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
 	"basekarya-backend/pkg/utils"
@@ -25,6 +26,7 @@ type Service interface {
 	CreateAssignment(ctx context.Context, req *CreateAssetAssignmentRequest) error
 	GetAssignmentDetail(ctx context.Context, id uint) (*AssetAssignmentDetailResponse, error)
 	GetAssignments(ctx context.Context, filter AssetAssignmentFilter) ([]AssetAssignmentListResponse, *response.Meta, error)
+	GetActiveAssignments(ctx context.Context, employeeID uint) ([]AssetAssignmentListResponse, error)
 	ProcessAction(ctx context.Context, req *ActionRequest) error
 	ProcessReturn(ctx context.Context, req *ReturnRequest) error
 
@@ -34,13 +36,13 @@ type Service interface {
 type service struct {
 	repo               Repository
 	notification       NotificationProvider
-	user               UserProvider
+	approval           ApprovalEngine
 	transactionManager infrastructure.TransactionManager
 	excel              infrastructure.ExcelProvider
 }
 
-func NewService(repo Repository, notification NotificationProvider, user UserProvider, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
-	return &service{repo, notification, user, transactionManager, excel}
+func NewService(repo Repository, notification NotificationProvider, approval ApprovalEngine, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
+	return &service{repo, notification, approval, transactionManager, excel}
 }
 
 func (s *service) CreateCategory(ctx context.Context, req *CreateAssetCategoryRequest) error {
@@ -253,7 +255,11 @@ func (s *service) CreateAssignment(ctx context.Context, req *CreateAssetAssignme
 			return err
 		}
 
-		approvalUserIDs, err := s.user.FindApprovalUsers(ctx, string(constants.APPROVAL_ASSET))
+		approvalUserIDs, err := s.approval.Submit(ctx, &approval.SubmitRequest{
+			RequestType:     constants.ApprovalRequestAssetAssignment,
+			ReferenceID:     assignment.ID,
+			RequesterUserID: req.UserID,
+		})
 		if err != nil {
 			return err
 		}
@@ -346,6 +352,33 @@ func (s *service) GetAssignments(ctx context.Context, filter AssetAssignmentFilt
 	return list, meta, nil
 }
 
+// GetActiveAssignments lists the assets an employee still holds and has to return.
+func (s *service) GetActiveAssignments(ctx context.Context, employeeID uint) ([]AssetAssignmentListResponse, error) {
+	assignments, err := s.repo.FindActiveAssignmentsByEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]AssetAssignmentListResponse, 0, len(assignments))
+	for _, a := range assignments {
+		list = append(list, AssetAssignmentListResponse{
+			ID:                 a.ID,
+			AssetID:            a.AssetID,
+			AssetName:          a.Asset.Name,
+			EmployeeID:         a.EmployeeID,
+			EmployeeName:       a.Employee.FullName,
+			EmployeeNIK:        a.Employee.NIK,
+			Purpose:            a.Purpose,
+			ExpectedReturnDate: a.ExpectedReturnDate,
+			ActualReturnDate:   a.ActualReturnDate,
+			Status:             a.Status,
+			CreatedAt:          a.CreatedAt,
+		})
+	}
+
+	return list, nil
+}
+
 func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
 		data, err := s.repo.FindAssignmentByID(ctx, req.ID)
@@ -362,8 +395,36 @@ func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 			notificationTitle   string
 			notificationMessage string
 		)
+		decideReq := &approval.DecideRequest{
+			RequestType:     constants.ApprovalRequestAssetAssignment,
+			ReferenceID:     data.ID,
+			RequesterUserID: data.UserID,
+			ActorUserID:     req.SuperAdminID,
+		}
+
 		switch constants.AssetAssignmentAction(req.Action) {
 		case constants.AssetAssignmentActionApprove:
+			decideReq.Action = constants.ApprovalActionApprove
+			decision, err := s.approval.Decide(ctx, decideReq)
+			if err != nil {
+				return err
+			}
+
+			// the asset stays available until the last level of the approval chain approves the assignment
+			if decision.Status == constants.ApprovalStatusPending {
+				go func() {
+					_ = s.notification.BlastNotification(
+						utils.DetachContext(ctx),
+						decision.NextApproverIDs,
+						string(constants.NotificationTypeAssetApprovalReq),
+						"Persetujuan Aset Lanjutan",
+						fmt.Sprintf("Permintaan aset %s menunggu persetujuan Anda", data.Asset.Name),
+						data.ID,
+					)
+				}()
+				return nil
+			}
+
 			data.Status = constants.AssetAssignmentStatusActive
 			data.ApprovedBy = &req.SuperAdminID
 
@@ -389,6 +450,13 @@ func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 			if req.RejectionReason == "" {
 				return fmt.Errorf("rejection reason is required")
 			}
+
+			decideReq.Action = constants.ApprovalActionReject
+			decideReq.Notes = req.RejectionReason
+			if _, err := s.approval.Decide(ctx, decideReq); err != nil {
+				return err
+			}
+
 			data.Status = constants.AssetAssignmentStatusRejected
 			data.RejectionReason.String = req.RejectionReason
 			data.RejectionReason.Valid = true
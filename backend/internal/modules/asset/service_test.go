@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/internal/testutil"
 	"basekarya-backend/pkg/constants"
@@ -15,15 +16,15 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func newTestAssetService() (Service, *mockRepo, *mockNotification, *mockUserProvider, *testutil.MockTransactionManager, *mockExcel) {
+func newTestAssetService() (Service, *mockRepo, *mockNotification, *mockApprovalEngine, *testutil.MockTransactionManager, *mockExcel) {
 	repo := new(mockRepo)
 	notif := new(mockNotification)
-	userProv := new(mockUserProvider)
+	approvalEngine := new(mockApprovalEngine)
 	tm := testutil.NewMockTransactionManager()
 	excel := new(mockExcel)
 
-	svc := NewService(repo, notif, userProv, tm, excel)
-	return svc, repo, notif, userProv, tm, excel
+	svc := NewService(repo, notif, approvalEngine, tm, excel)
+	return svc, repo, notif, approvalEngine, tm, excel
 }
 
 func TestService_CreateCategory(t *testing.T) {
@@ -360,7 +361,7 @@ func TestService_CreateAssignment(t *testing.T) {
 	tests := []struct {
 		name       string
 		req        *CreateAssetAssignmentRequest
-		setupMocks func(*mockRepo, *mockNotification, *mockUserProvider)
+		setupMocks func(*mockRepo, *mockNotification, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -373,10 +374,12 @@ func TestService_CreateAssignment(t *testing.T) {
 				Purpose:            "Need for presentation",
 				ExpectedReturnDate: "2025-12-31",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindAssetByID", mock.Anything, uint(1)).Return(&Asset{ID: 1, Name: "MacBook Pro", Status: constants.AssetStatusAvailable}, nil)
 				repo.On("CreateAssignment", mock.Anything, mock.AnythingOfType("*asset.AssetAssignment")).Return(nil)
-				userProv.On("FindApprovalUsers", mock.Anything, string(constants.APPROVAL_ASSET)).Return([]uint{10, 11}, nil)
+				approvalEngine.On("Submit", mock.Anything, mock.MatchedBy(func(req *approval.SubmitRequest) bool {
+					return req.RequestType == constants.ApprovalRequestAssetAssignment
+				})).Return([]uint{10, 11}, nil)
 				notif.On("BlastNotification", mock.Anything, []uint{10, 11}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
@@ -387,7 +390,7 @@ func TestService_CreateAssignment(t *testing.T) {
 				UserID:     0,
 				EmployeeID: 0,
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {},
 			wantErr:    true,
 			errMsg:     "user not found",
 		},
@@ -398,7 +401,7 @@ func TestService_CreateAssignment(t *testing.T) {
 				EmployeeID: 1,
 				AssetID:    99,
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindAssetByID", mock.Anything, uint(99)).Return(nil, errors.New("not found"))
 			},
 			wantErr: true,
@@ -411,7 +414,7 @@ func TestService_CreateAssignment(t *testing.T) {
 				EmployeeID: 1,
 				AssetID:    1,
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindAssetByID", mock.Anything, uint(1)).Return(&Asset{ID: 1, Status: constants.AssetStatusAssigned}, nil)
 			},
 			wantErr: true,
@@ -424,7 +427,7 @@ func TestService_CreateAssignment(t *testing.T) {
 				EmployeeID: 1,
 				AssetID:    1,
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindAssetByID", mock.Anything, uint(1)).Return(&Asset{ID: 1, Status: constants.AssetStatusAvailable}, nil)
 				repo.On("CreateAssignment", mock.Anything, mock.AnythingOfType("*asset.AssetAssignment")).Return(errors.New("db error"))
 			},
@@ -438,10 +441,10 @@ func TestService_CreateAssignment(t *testing.T) {
 				EmployeeID: 1,
 				AssetID:    1,
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindAssetByID", mock.Anything, uint(1)).Return(&Asset{ID: 1, Status: constants.AssetStatusAvailable}, nil)
 				repo.On("CreateAssignment", mock.Anything, mock.AnythingOfType("*asset.AssetAssignment")).Return(nil)
-				userProv.On("FindApprovalUsers", mock.Anything, string(constants.APPROVAL_ASSET)).Return([]uint(nil), errors.New("user service error"))
+				approvalEngine.On("Submit", mock.Anything, mock.Anything).Return([]uint(nil), errors.New("user service error"))
 			},
 			wantErr: true,
 			errMsg:  "user service error",
@@ -450,8 +453,8 @@ func TestService_CreateAssignment(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, notif, userProv, _, _ := newTestAssetService()
-			tt.setupMocks(repo, notif, userProv)
+			svc, repo, notif, approvalEngine, _, _ := newTestAssetService()
+			tt.setupMocks(repo, notif, approvalEngine)
 			err := svc.CreateAssignment(ctx, tt.req)
 			if tt.wantErr {
 				require.Error(t, err)
@@ -588,13 +591,38 @@ func TestService_GetAssignments(t *testing.T) {
 	}
 }
 
+func TestService_GetActiveAssignments(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("success", func(t *testing.T) {
+		svc, repo, _, _, _, _ := newTestAssetService()
+		repo.On("FindActiveAssignmentsByEmployee", mock.Anything, uint(1)).Return([]AssetAssignment{
+			{ID: 1, AssetID: 3, EmployeeID: 1, Employee: user.Employee{FullName: "John Doe", NIK: "EMP001"}, Asset: Asset{Name: "MacBook Pro"}, Status: constants.AssetAssignmentStatusActive},
+		}, nil)
+
+		list, err := svc.GetActiveAssignments(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, "MacBook Pro", list[0].AssetName)
+		assert.Equal(t, constants.AssetAssignmentStatusActive, list[0].Status)
+	})
+
+	t.Run("error is returned", func(t *testing.T) {
+		svc, repo, _, _, _, _ := newTestAssetService()
+		repo.On("FindActiveAssignmentsByEmployee", mock.Anything, uint(1)).Return([]AssetAssignment{}, errors.New("db error"))
+
+		_, err := svc.GetActiveAssignments(ctx, 1)
+		require.Error(t, err)
+	})
+}
+
 func TestService_ProcessAction(t *testing.T) {
 	ctx := testutil.CtxWithTenant(1, 1, false)
 
 	tests := []struct {
 		name       string
 		req        *ActionRequest
-		setupMocks func(*mockRepo, *mockNotification)
+		setupMocks func(*mockRepo, *mockNotification, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -605,17 +633,18 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.AssetAssignmentActionApprove),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				assignment := &AssetAssignment{
 					ID:     1,
 					UserID: 1,
 					Status: constants.AssetAssignmentStatusPending,
 				}
 				repo.On("FindAssignmentByID", mock.Anything, uint(1)).Return(assignment, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.AnythingOfType("*approval.DecideRequest")).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
 				repo.On("UpdateAssignment", mock.Anything, mock.AnythingOfType("*asset.AssetAssignment")).Return(nil)
 				repo.On("FindAssetByID", mock.Anything, mock.Anything).Return(&Asset{ID: 1, Status: constants.AssetStatusAvailable}, nil)
 				repo.On("UpdateAsset", mock.Anything, mock.AnythingOfType("*asset.Asset")).Return(nil)
-				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 			},
 			wantErr: false,
 		},
@@ -627,14 +656,17 @@ func TestService_ProcessAction(t *testing.T) {
 				Action:          string(constants.AssetAssignmentActionReject),
 				RejectionReason: "Not available",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindAssignmentByID", mock.Anything, uint(2)).Return(&AssetAssignment{
 					ID:     2,
 					UserID: 1,
 					Status: constants.AssetAssignmentStatusPending,
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.Action == constants.ApprovalActionReject && req.Notes == "Not available"
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusRejected}, nil)
 				repo.On("UpdateAssignment", mock.Anything, mock.AnythingOfType("*asset.AssetAssignment")).Return(nil)
-				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 			},
 			wantErr: false,
 		},
@@ -645,7 +677,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.AssetAssignmentActionApprove),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindAssignmentByID", mock.Anything, uint(3)).Return(&AssetAssignment{
 					ID:     3,
 					Status: constants.AssetAssignmentStatusActive,
@@ -662,7 +694,7 @@ func TestService_ProcessAction(t *testing.T) {
 				Action:          string(constants.AssetAssignmentActionReject),
 				RejectionReason: "",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindAssignmentByID", mock.Anything, uint(4)).Return(&AssetAssignment{
 					ID:     4,
 					UserID: 1,
@@ -679,7 +711,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.AssetAssignmentActionApprove),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindAssignmentByID", mock.Anything, uint(99)).Return(nil, errors.New("not found"))
 			},
 			wantErr: true,
@@ -692,7 +724,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       "INVALID",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindAssignmentByID", mock.Anything, uint(5)).Return(&AssetAssignment{
 					ID:     5,
 					UserID: 1,
@@ -702,12 +734,52 @@ func TestService_ProcessAction(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid action: INVALID",
 		},
+		{
+			name: "approve waits for the next approval level",
+			req: &ActionRequest{
+				ID:           6,
+				SuperAdminID: 10,
+				Action:       string(constants.AssetAssignmentActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindAssignmentByID", mock.Anything, uint(6)).Return(&AssetAssignment{
+					ID:     6,
+					UserID: 1,
+					Status: constants.AssetAssignmentStatusPending,
+					Asset:  Asset{ID: 1, Name: "Laptop"},
+				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(&approval.Decision{
+					Status:          constants.ApprovalStatusPending,
+					NextApproverIDs: []uint{20},
+				}, nil)
+				notif.On("BlastNotification", mock.Anything, []uint{20}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+			},
+			wantErr: false,
+		},
+		{
+			name: "error actor is not an approver",
+			req: &ActionRequest{
+				ID:           7,
+				SuperAdminID: 10,
+				Action:       string(constants.AssetAssignmentActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindAssignmentByID", mock.Anything, uint(7)).Return(&AssetAssignment{
+					ID:     7,
+					UserID: 1,
+					Status: constants.AssetAssignmentStatusPending,
+				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(nil, errors.New("you are not an approver of the current approval step"))
+			},
+			wantErr: true,
+			errMsg:  "you are not an approver of the current approval step",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, notif, _, _, _ := newTestAssetService()
-			tt.setupMocks(repo, notif)
+			svc, repo, notif, approvalEngine, _, _ := newTestAssetService()
+			tt.setupMocks(repo, notif, approvalEngine)
 			err := svc.ProcessAction(ctx, tt.req)
 			if tt.wantErr {
 				require.Error(t, err)
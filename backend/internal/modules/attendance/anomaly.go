@@ -0,0 +1,191 @@
+package attendance
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AnomalyDetector interface {
+	Detect(ctx context.Context, input *AnomalyInput) ([]AttendanceAnomaly, error)
+}
+
+// AnomalyInput is the clock event being scored, CheckIn is today's record on check-out.
+type AnomalyInput struct {
+	EmployeeID     uint
+	Latitude       float64
+	Longitude      float64
+	ImageHash      string
+	DeviceID       string
+	IsMockLocation bool
+	Time           time.Time
+	CheckIn        *Attendance
+}
+
+type anomalyRule func(ctx context.Context, input *AnomalyInput) (*AttendanceAnomaly, error)
+
+type anomalyDetector struct {
+	repo  Repository
+	rules []anomalyRule
+}
+
+func NewAnomalyDetector(repo Repository) AnomalyDetector {
+	d := &anomalyDetector{repo: repo}
+	d.rules = []anomalyRule{
+		d.mockLocation,
+		d.identicalCoordinates,
+		d.imageReused,
+		d.sharedDevice,
+		d.impossibleTravel,
+	}
+	return d
+}
+
+func (d *anomalyDetector) Detect(ctx context.Context, input *AnomalyInput) ([]AttendanceAnomaly, error) {
+	var anomalies []AttendanceAnomaly
+	for _, rule := range d.rules {
+		anomaly, err := rule(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		if anomaly == nil {
+			continue
+		}
+
+		anomaly.CompanyID = utils.GetCompanyIDFromCtx(ctx)
+		anomaly.IsCheckout = input.CheckIn != nil
+		anomalies = append(anomalies, *anomaly)
+	}
+
+	return anomalies, nil
+}
+
+func (d *anomalyDetector) mockLocation(ctx context.Context, input *AnomalyInput) (*AttendanceAnomaly, error) {
+	if !input.IsMockLocation {
+		return nil, nil
+	}
+
+	return &AttendanceAnomaly{
+		Rule:   string(constants.AttendanceAnomalyMockLocation),
+		Score:  60,
+		Detail: "Client reported a mock location provider",
+	}, nil
+}
+
+func (d *anomalyDetector) identicalCoordinates(ctx context.Context, input *AnomalyInput) (*AttendanceAnomaly, error) {
+	today := input.Time.Format(constants.DefaultTimeFormat)
+	since := input.Time.AddDate(0, 0, -constants.AttendanceAnomalyLookbackDays).Format(constants.DefaultTimeFormat)
+
+	days, err := d.repo.CountIdenticalCoordinates(ctx, input.EmployeeID, input.Latitude, input.Longitude, since, today)
+	if err != nil {
+		return nil, err
+	}
+
+	// real GPS readings jitter, exact repeats on several days point to spoofed coordinates
+	if days < constants.AttendanceIdenticalCoordinateDays {
+		return nil, nil
+	}
+
+	return &AttendanceAnomaly{
+		Rule:   string(constants.AttendanceAnomalyIdenticalCoordinates),
+		Score:  30,
+		Detail: fmt.Sprintf("Exact coordinates (%f, %f) already used on %d previous days", input.Latitude, input.Longitude, days),
+	}, nil
+}
+
+func (d *anomalyDetector) imageReused(ctx context.Context, input *AnomalyInput) (*AttendanceAnomaly, error) {
+	if input.ImageHash == "" {
+		return nil, nil
+	}
+
+	var excludeID uint
+	if input.CheckIn != nil {
+		excludeID = input.CheckIn.ID
+	}
+
+	total, err := d.repo.CountImageHash(ctx, input.ImageHash, excludeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if total == 0 && (input.CheckIn == nil || input.CheckIn.CheckInImageHash != input.ImageHash) {
+		return nil, nil
+	}
+
+	return &AttendanceAnomaly{
+		Rule:   string(constants.AttendanceAnomalyImageReused),
+		Score:  50,
+		Detail: "Selfie image is identical to a previously submitted one",
+	}, nil
+}
+
+func (d *anomalyDetector) sharedDevice(ctx context.Context, input *AnomalyInput) (*AttendanceAnomaly, error) {
+	if input.DeviceID == "" {
+		return nil, nil
+	}
+
+	since := input.Time.AddDate(0, 0, -constants.AttendanceAnomalyLookbackDays).Format(constants.DefaultTimeFormat)
+
+	others, err := d.repo.CountEmployeesByDevice(ctx, input.DeviceID, since, input.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if others+1 < constants.AttendanceSharedDeviceEmployees {
+		return nil, nil
+	}
+
+	return &AttendanceAnomaly{
+		Rule:   string(constants.AttendanceAnomalySharedDevice),
+		Score:  40,
+		Detail: fmt.Sprintf("Device used by %d other employees in the last %d days", others, constants.AttendanceAnomalyLookbackDays),
+	}, nil
+}
+
+func (d *anomalyDetector) impossibleTravel(ctx context.Context, input *AnomalyInput) (*AttendanceAnomaly, error) {
+	// on check-out compare with today's check-in, otherwise with the last point of the previous attendance
+	var (
+		prevLat, prevLong float64
+		prevTime          time.Time
+	)
+
+	if input.CheckIn != nil {
+		prevLat, prevLong, prevTime = input.CheckIn.CheckInLat, input.CheckIn.CheckInLong, input.CheckIn.CheckInTime
+	} else {
+		prev, err := d.repo.GetLastAttendanceBefore(ctx, input.EmployeeID, input.Time.Format(constants.DefaultTimeFormat))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		prevLat, prevLong, prevTime = prev.CheckInLat, prev.CheckInLong, prev.CheckInTime
+		if prev.CheckOutTime != nil && prev.CheckOutLat != nil && prev.CheckOutLong != nil {
+			prevLat, prevLong, prevTime = *prev.CheckOutLat, *prev.CheckOutLong, *prev.CheckOutTime
+		}
+	}
+
+	distanceKm := utils.CalculateDistance(prevLat, prevLong, input.Latitude, input.Longitude) / 1000.0
+	durationHours := input.Time.Sub(prevTime).Hours()
+
+	if durationHours <= 0.01 || distanceKm <= constants.AttendanceMinTravelDistanceKm {
+		return nil, nil
+	}
+
+	speedKmH := distanceKm / durationHours
+	if speedKmH <= constants.AttendanceMaxTravelSpeedKmH {
+		return nil, nil
+	}
+
+	return &AttendanceAnomaly{
+		Rule:   string(constants.AttendanceAnomalyImpossibleTravel),
+		Score:  50,
+		Detail: fmt.Sprintf("Travelled %.2f km in %.2f hours (%.2f km/h) since the previous attendance", distanceKm, durationHours, speedKmH),
+	}, nil
+}
@@ -0,0 +1,164 @@
+package attendance
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAnomalyDetector_Detect(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, time.Local)
+
+	// stubs every rule query as clean so each case only overrides what it checks
+	cleanRepo := func(r *mockRepo) {
+		r.On("CountIdenticalCoordinates", mock.Anything, uint(1), mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(int64(0), nil).Maybe()
+		r.On("CountImageHash", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), nil).Maybe()
+		r.On("CountEmployeesByDevice", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(int64(0), nil).Maybe()
+		r.On("GetLastAttendanceBefore", mock.Anything, uint(1), mock.Anything).Return(nil, gorm.ErrRecordNotFound).Maybe()
+	}
+
+	tests := []struct {
+		name       string
+		input      *AnomalyInput
+		setupMocks func(*mockRepo)
+		wantRules  []string
+		wantErr    bool
+	}{
+		{
+			name: "clean check-in",
+			input: &AnomalyInput{
+				EmployeeID: 1, Latitude: -6.2, Longitude: 106.8,
+				ImageHash: "abc", DeviceID: "device-1", Time: now,
+			},
+			setupMocks: cleanRepo,
+			wantRules:  nil,
+		},
+		{
+			name: "mock location reported by client",
+			input: &AnomalyInput{
+				EmployeeID: 1, Latitude: -6.2, Longitude: 106.8,
+				IsMockLocation: true, Time: now,
+			},
+			setupMocks: cleanRepo,
+			wantRules:  []string{string(constants.AttendanceAnomalyMockLocation)},
+		},
+		{
+			name: "identical coordinates on previous days",
+			input: &AnomalyInput{
+				EmployeeID: 1, Latitude: -6.2, Longitude: 106.8, Time: now,
+			},
+			setupMocks: func(r *mockRepo) {
+				r.On("CountIdenticalCoordinates", mock.Anything, uint(1), -6.2, 106.8, "2026-02-08", "2026-03-10").Return(int64(2), nil)
+				cleanRepo(r)
+			},
+			wantRules: []string{string(constants.AttendanceAnomalyIdenticalCoordinates)},
+		},
+		{
+			name: "selfie hash reused",
+			input: &AnomalyInput{
+				EmployeeID: 1, Latitude: -6.2, Longitude: 106.8, ImageHash: "abc", Time: now,
+			},
+			setupMocks: func(r *mockRepo) {
+				r.On("CountImageHash", mock.Anything, "abc", uint(0)).Return(int64(1), nil)
+				cleanRepo(r)
+			},
+			wantRules: []string{string(constants.AttendanceAnomalyImageReused)},
+		},
+		{
+			name: "check-out selfie equals check-in selfie",
+			input: &AnomalyInput{
+				EmployeeID: 1, Latitude: -6.2, Longitude: 106.8, ImageHash: "abc", Time: now,
+				CheckIn: &Attendance{ID: 5, CheckInImageHash: "abc", CheckInLat: -6.2, CheckInLong: 106.8, CheckInTime: now.Add(-8 * time.Hour)},
+			},
+			setupMocks: func(r *mockRepo) {
+				r.On("CountImageHash", mock.Anything, "abc", uint(5)).Return(int64(0), nil)
+				cleanRepo(r)
+			},
+			wantRules: []string{string(constants.AttendanceAnomalyImageReused)},
+		},
+		{
+			name: "device shared by many employees",
+			input: &AnomalyInput{
+				EmployeeID: 1, Latitude: -6.2, Longitude: 106.8, DeviceID: "device-1", Time: now,
+			},
+			setupMocks: func(r *mockRepo) {
+				r.On("CountEmployeesByDevice", mock.Anything, "device-1", "2026-02-08", uint(1)).Return(int64(2), nil)
+				cleanRepo(r)
+			},
+			wantRules: []string{string(constants.AttendanceAnomalySharedDevice)},
+		},
+		{
+			name: "impossible travel since previous day check-out",
+			input: &AnomalyInput{
+				EmployeeID: 1, Latitude: -6.2, Longitude: 106.8, Time: now,
+			},
+			setupMocks: func(r *mockRepo) {
+				// Surabaya to Jakarta (~660 km) within 2 hours
+				prevOut := now.Add(-2 * time.Hour)
+				prevLat, prevLong := -7.25, 112.75
+				r.On("GetLastAttendanceBefore", mock.Anything, uint(1), "2026-03-10").Return(&Attendance{
+					CheckInTime:  now.Add(-12 * time.Hour),
+					CheckInLat:   -7.25,
+					CheckInLong:  112.75,
+					CheckOutTime: &prevOut,
+					CheckOutLat:  &prevLat,
+					CheckOutLong: &prevLong,
+				}, nil)
+				cleanRepo(r)
+			},
+			wantRules: []string{string(constants.AttendanceAnomalyImpossibleTravel)},
+		},
+		{
+			name: "impossible travel since check-in",
+			input: &AnomalyInput{
+				EmployeeID: 1, Latitude: -6.2, Longitude: 106.8, Time: now,
+				CheckIn: &Attendance{ID: 5, CheckInLat: -7.25, CheckInLong: 112.75, CheckInTime: now.Add(-1 * time.Hour)},
+			},
+			setupMocks: cleanRepo,
+			wantRules:  []string{string(constants.AttendanceAnomalyImpossibleTravel)},
+		},
+		{
+			name: "repo error",
+			input: &AnomalyInput{
+				EmployeeID: 1, Latitude: -6.2, Longitude: 106.8, Time: now,
+			},
+			setupMocks: func(r *mockRepo) {
+				r.On("CountIdenticalCoordinates", mock.Anything, uint(1), mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(int64(0), errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			tt.setupMocks(repo)
+
+			anomalies, err := NewAnomalyDetector(repo).Detect(ctx, tt.input)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			var rules []string
+			for _, a := range anomalies {
+				rules = append(rules, a.Rule)
+				assert.Equal(t, uint(1), a.CompanyID)
+				assert.Equal(t, tt.input.CheckIn != nil, a.IsCheckout)
+				assert.Positive(t, a.Score)
+			}
+			assert.Equal(t, tt.wantRules, rules)
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package attendance
 
 import (
-	"context"
+	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/user"
+	"context"
 	"io"
+	"time"
 )
 
 type StorageProvider interface {
@@ -11,10 +13,29 @@ type StorageProvider interface {
 }
 
 type LocationFetcher interface {
-	GetAddressFromCoords(lat, long float64) string
+	GetAddressFromCoords(ctx context.Context, lat, long float64) (string, error)
+}
+
+type CacheProvider interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+}
+
+type WorkLocationProvider interface {
+	FindAllWorkLocations(ctx context.Context) ([]master.WorkLocation, error)
 }
 
 type UserProvider interface {
 	FindByID(ctx context.Context, id uint) (*user.User, error)
 	CountActiveEmployee(ctx context.Context) (int64, error)
+	FindAllEmployeeActiveInScope(ctx context.Context) ([]user.Employee, error)
+	FindSupervisor(ctx context.Context, employeeID uint) (*user.Employee, error)
+}
+
+type LeaveProvider interface {
+	GetBulkApprovedLeaveDays(ctx context.Context, startDate, endDate string) (map[uint]map[string]float64, error)
+}
+
+type OvertimeProvider interface {
+	GetBulkApprovedMinutes(ctx context.Context, startDate, endDate string) (map[uint]int, error)
 }
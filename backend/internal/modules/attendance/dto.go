@@ -3,11 +3,13 @@ package attendance
 import "time"
 
 type ClockRequest struct {
-	Latitude    float64 `json:"latitude" validate:"required,latitude"`
-	Longitude   float64 `json:"longitude" validate:"required,longitude"`
-	ImageBase64 string  `json:"image_base64" validate:"required,base64"`
-	Address     string  `json:"address" validate:"omitempty,max=500"`
-	Notes       string  `json:"notes" validate:"omitempty,max=500"`
+	Latitude       float64 `json:"latitude" validate:"required,latitude"`
+	Longitude      float64 `json:"longitude" validate:"required,longitude"`
+	ImageBase64    string  `json:"image_base64" validate:"required,base64"`
+	Address        string  `json:"address" validate:"omitempty,max=500"`
+	Notes          string  `json:"notes" validate:"omitempty,max=500"`
+	DeviceID       string  `json:"device_id" validate:"omitempty,max=100"`
+	IsMockLocation bool    `json:"is_mock_location"`
 }
 
 type AttendanceResponse struct {
@@ -53,3 +55,65 @@ type DashboardStatResponse struct {
 	LateToday      int64 `json:"late_today"`
 	AbsentToday    int64 `json:"absent_today"`
 }
+
+type AnomalyFilterParams struct {
+	ReviewStatus string
+	StartDate    string
+	EndDate      string
+	Page         int
+	Limit        int
+}
+
+type AnomalyReviewRequest struct {
+	ID         uint   `json:"-"`
+	ReviewerID uint   `json:"-"`
+	Action     string `json:"action" validate:"required,oneof=CLEAR CONFIRM"`
+	Notes      string `json:"notes" validate:"omitempty,max=500"`
+}
+
+type AnomalyReasonResponse struct {
+	Rule       string `json:"rule"`
+	Score      int    `json:"score"`
+	Detail     string `json:"detail"`
+	IsCheckout bool   `json:"is_checkout"`
+}
+
+type AnomalyQueueResponse struct {
+	ID           uint                    `json:"id"`
+	Date         string                  `json:"date"`
+	EmployeeID   uint                    `json:"employee_id"`
+	EmployeeName string                  `json:"employee_name"`
+	NIK          string                  `json:"nik"`
+	CheckInTime  time.Time               `json:"check_in_time"`
+	CheckOutTime *time.Time              `json:"check_out_time"`
+	RiskScore    int                     `json:"risk_score"`
+	ReviewStatus string                  `json:"review_status"`
+	ReviewNotes  string                  `json:"review_notes"`
+	Reasons      []AnomalyReasonResponse `json:"reasons"`
+}
+
+type TimesheetFilter struct {
+	Month        int
+	Year         int
+	DepartmentID uint
+	EmployeeID   uint
+}
+
+type TimesheetSummary struct {
+	EmployeeID       uint               `json:"employee_id"`
+	NIK              string             `json:"nik"`
+	EmployeeName     string             `json:"employee_name"`
+	Position         string             `json:"position"`
+	DepartmentName   string             `json:"department_name"`
+	Month            int                `json:"month"`
+	Year             int                `json:"year"`
+	ScheduledDays    int                `json:"scheduled_days"`
+	PresentDays      int                `json:"present_days"`
+	LateCount        int                `json:"late_count"`
+	LateMinutes      int                `json:"late_minutes"`
+	AbsentDays       int                `json:"absent_days"`
+	LeaveDays        map[string]float64 `json:"leave_days"`
+	OvertimeHours    float64            `json:"overtime_hours"`
+	WorkHours        float64            `json:"work_hours"`
+	MissingCheckouts int                `json:"missing_checkouts"`
+}
@@ -22,26 +22,60 @@ type Attendance struct {
 
 	CheckInAddress string `gorm:"type:varchar(500);not null" json:"check_in_address"`
 
+	CheckInImageHash string `gorm:"size:64;index" json:"-"`
+	CheckInDeviceID  string `gorm:"size:100;index" json:"check_in_device_id"`
+
 	CheckOutTime     *time.Time `json:"check_out_time"`
 	CheckOutLat      *float64   `gorm:"type:decimal(10,8)" json:"check_out_lat"`
 	CheckOutLong     *float64   `gorm:"type:decimal(11,8)" json:"check_out_long"`
 	CheckOutImageURL *string    `gorm:"size:255" json:"check_out_image_url"`
 	CheckOutAddress  *string    `gorm:"type:varchar(500)" json:"check_out_address"`
 
+	CheckOutImageHash *string `gorm:"size:64;index" json:"-"`
+	CheckOutDeviceID  *string `gorm:"size:100;index" json:"check_out_device_id"`
+
 	Status string `gorm:"type:enum('PRESENT', 'LATE', 'EXCUSED', 'ABSENT');default:'ABSENT';index:idx_date_status,priority:2" json:"status"`
 
 	IsSuspicious bool   `gorm:"default:false;index" json:"is_suspicious"`
 	Notes        string `gorm:"type:varchar(500)" json:"notes"`
 
+	RiskScore    int        `gorm:"default:0" json:"risk_score"`
+	ReviewStatus string     `gorm:"type:enum('NONE','PENDING','CLEARED','CONFIRMED');default:'NONE';index" json:"review_status"`
+	ReviewedBy   *uint      `json:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	ReviewNotes  string     `gorm:"type:varchar(500)" json:"review_notes"`
+
 	LateDurationMinute int `gorm:"default:0" json:"late_duration_minute"`
 
+	// set when the day is (partly) covered by approved leave, excused hours only for half day and hourly leave
+	LeaveRequestID *uint      `gorm:"index" json:"leave_request_id"`
+	ExcusedFrom    *time.Time `json:"excused_from"`
+	ExcusedUntil   *time.Time `json:"excused_until"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
 	Employee *user.Employee `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
 	Shift    *master.Shift  `gorm:"foreignKey:ShiftID" json:"shift,omitempty"`
+
+	Anomalies []AttendanceAnomaly `gorm:"foreignKey:AttendanceID" json:"anomalies,omitempty"`
+}
+
+type AttendanceAnomaly struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	CompanyID    uint      `gorm:"index;not null" json:"company_id"`
+	AttendanceID uint      `gorm:"index;not null" json:"attendance_id"`
+	Rule         string    `gorm:"size:50;not null" json:"rule"`
+	Score        int       `gorm:"not null" json:"score"`
+	Detail       string    `gorm:"size:255" json:"detail"`
+	IsCheckout   bool      `gorm:"default:false" json:"is_checkout"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 func (Attendance) TableName() string {
 	return "attendances"
 }
+
+func (AttendanceAnomaly) TableName() string {
+	return "attendance_anomalies"
+}
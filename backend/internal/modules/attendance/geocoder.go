@@ -0,0 +1,99 @@
+package attendance
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/logger"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type cachedLocationFetcher struct {
+	fetcher LocationFetcher
+	cache   CacheProvider
+	ttl     time.Duration
+}
+
+// NewCachedLocationFetcher wraps a fetcher with a redis cache keyed by coordinates
+// rounded to 4 decimals (~10 m), so repeated clock-ins from the same spot resolve without
+// hitting the external provider.
+func NewCachedLocationFetcher(fetcher LocationFetcher, cache CacheProvider, ttl time.Duration) LocationFetcher {
+	return &cachedLocationFetcher{fetcher, cache, ttl}
+}
+
+func (f *cachedLocationFetcher) GetAddressFromCoords(ctx context.Context, lat, long float64) (string, error) {
+	key := fmt.Sprintf(constants.GEOCODE_CACHE_KEY, roundCoordinate(lat), roundCoordinate(long))
+
+	cached, err := f.cache.Get(ctx, key)
+	if err == nil && cached != "" {
+		return cached, nil
+	}
+	if err != nil && err != redis.Nil {
+		logger.Warnf("failed to read geocode cache %s: %v", key, err)
+	}
+
+	address, err := f.fetcher.GetAddressFromCoords(ctx, lat, long)
+	if err != nil {
+		return "", err
+	}
+
+	if err := f.cache.Set(ctx, key, address, f.ttl); err != nil {
+		logger.Warnf("failed to write geocode cache %s: %v", key, err)
+	}
+
+	return address, nil
+}
+
+type fallbackLocationFetcher struct {
+	fetcher      LocationFetcher
+	workLocation WorkLocationProvider
+}
+
+// NewFallbackLocationFetcher resolves to the nearest work location of the tenant
+// when the wrapped fetcher is unavailable.
+func NewFallbackLocationFetcher(fetcher LocationFetcher, workLocation WorkLocationProvider) LocationFetcher {
+	return &fallbackLocationFetcher{fetcher, workLocation}
+}
+
+func (f *fallbackLocationFetcher) GetAddressFromCoords(ctx context.Context, lat, long float64) (string, error) {
+	address, err := f.fetcher.GetAddressFromCoords(ctx, lat, long)
+	if err == nil {
+		return address, nil
+	}
+
+	// without tenant the lookup would span every company
+	if utils.GetCompanyIDFromCtx(ctx) == 0 {
+		return "", err
+	}
+
+	locations, locErr := f.workLocation.FindAllWorkLocations(ctx)
+	if locErr != nil {
+		return "", errors.Join(err, locErr)
+	}
+	if len(locations) == 0 {
+		return "", err
+	}
+
+	nearestName := ""
+	nearestDistance := math.MaxFloat64
+	for _, loc := range locations {
+		distance := utils.CalculateDistance(lat, long, loc.Latitude, loc.Longitude)
+		if distance < nearestDistance {
+			nearestName = loc.Name
+			nearestDistance = distance
+		}
+	}
+
+	logger.Warnf("geocode provider unavailable, resolved to work location %s: %v", nearestName, err)
+
+	return fmt.Sprintf("Near %s (~%.0f m)", nearestName, nearestDistance), nil
+}
+
+func roundCoordinate(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
@@ -0,0 +1,149 @@
+package attendance
+
+import (
+	"errors"
+	"testing"
+
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/internal/testutil"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedLocationFetcher_GetAddressFromCoords(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	tests := []struct {
+		name       string
+		setupMocks func(*mockLocationFetcher, *mockCacheProvider)
+		want       string
+		wantErr    bool
+	}{
+		{
+			name: "cache hit skips provider",
+			setupMocks: func(f *mockLocationFetcher, c *mockCacheProvider) {
+				c.On("Get", mock.Anything, "geocode:-6.2001:106.8166").Return("Jl. Sudirman, Jakarta", nil)
+			},
+			want: "Jl. Sudirman, Jakarta",
+		},
+		{
+			name: "cache miss stores provider result",
+			setupMocks: func(f *mockLocationFetcher, c *mockCacheProvider) {
+				c.On("Get", mock.Anything, "geocode:-6.2001:106.8166").Return("", redis.Nil)
+				f.On("GetAddressFromCoords", mock.Anything, -6.20012, 106.81655).Return("Jl. Sudirman, Jakarta", nil)
+				c.On("Set", mock.Anything, "geocode:-6.2001:106.8166", "Jl. Sudirman, Jakarta", mock.Anything).Return(nil)
+			},
+			want: "Jl. Sudirman, Jakarta",
+		},
+		{
+			name: "cache unavailable still resolves",
+			setupMocks: func(f *mockLocationFetcher, c *mockCacheProvider) {
+				c.On("Get", mock.Anything, mock.Anything).Return("", errors.New("connection refused"))
+				f.On("GetAddressFromCoords", mock.Anything, mock.Anything, mock.Anything).Return("Jl. Sudirman, Jakarta", nil)
+				c.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+			},
+			want: "Jl. Sudirman, Jakarta",
+		},
+		{
+			name: "provider error is not cached",
+			setupMocks: func(f *mockLocationFetcher, c *mockCacheProvider) {
+				c.On("Get", mock.Anything, mock.Anything).Return("", redis.Nil)
+				f.On("GetAddressFromCoords", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("timeout"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := new(mockLocationFetcher)
+			cache := new(mockCacheProvider)
+			tt.setupMocks(fetcher, cache)
+
+			got, err := NewCachedLocationFetcher(fetcher, cache, 0).GetAddressFromCoords(ctx, -6.20012, 106.81655)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				cache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			fetcher.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFallbackLocationFetcher_GetAddressFromCoords(t *testing.T) {
+	locations := []master.WorkLocation{
+		{ID: 1, Name: "Head Office", Latitude: -6.2250, Longitude: 106.8000},
+		{ID: 2, Name: "Warehouse", Latitude: -6.2001, Longitude: 106.8166},
+	}
+
+	tests := []struct {
+		name       string
+		companyID  uint
+		setupMocks func(*mockLocationFetcher, *mockWorkLocationProvider)
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:      "provider available",
+			companyID: 1,
+			setupMocks: func(f *mockLocationFetcher, w *mockWorkLocationProvider) {
+				f.On("GetAddressFromCoords", mock.Anything, mock.Anything, mock.Anything).Return("Jl. Sudirman, Jakarta", nil)
+			},
+			want: "Jl. Sudirman, Jakarta",
+		},
+		{
+			name:      "provider down resolves nearest work location",
+			companyID: 1,
+			setupMocks: func(f *mockLocationFetcher, w *mockWorkLocationProvider) {
+				f.On("GetAddressFromCoords", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("timeout"))
+				w.On("FindAllWorkLocations", mock.Anything).Return(locations, nil)
+			},
+			want: "Near Warehouse (~0 m)",
+		},
+		{
+			name:      "provider down without work locations",
+			companyID: 1,
+			setupMocks: func(f *mockLocationFetcher, w *mockWorkLocationProvider) {
+				f.On("GetAddressFromCoords", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("timeout"))
+				w.On("FindAllWorkLocations", mock.Anything).Return([]master.WorkLocation{}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:      "provider down without tenant",
+			companyID: 0,
+			setupMocks: func(f *mockLocationFetcher, w *mockWorkLocationProvider) {
+				f.On("GetAddressFromCoords", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("timeout"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := new(mockLocationFetcher)
+			workLocation := new(mockWorkLocationProvider)
+			tt.setupMocks(fetcher, workLocation)
+
+			ctx := testutil.CtxWithTenant(tt.companyID, 1, false)
+			got, err := NewFallbackLocationFetcher(fetcher, workLocation).GetAddressFromCoords(ctx, -6.2001, 106.8166)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			fetcher.AssertExpectations(t)
+			workLocation.AssertExpectations(t)
+		})
+	}
+}
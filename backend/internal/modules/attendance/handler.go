@@ -6,6 +6,7 @@ import (
 	"basekarya-backend/pkg/utils"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -135,6 +136,167 @@ func (h *Handler) GetDashboardStats(ctx echo.Context) error {
 	return response.NewResponses[any](ctx, http.StatusOK, "Get Dashboard Stats Success", resp, nil, nil)
 }
 
+func (h *Handler) GetAnomalyQueue(ctx echo.Context) error {
+	page := 1
+	limit := 10
+
+	if p := ctx.QueryParam("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if l := ctx.QueryParam("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	filter := &AnomalyFilterParams{
+		ReviewStatus: ctx.QueryParam("review_status"),
+		StartDate:    ctx.QueryParam("start_date"),
+		EndDate:      ctx.QueryParam("end_date"),
+		Page:         page,
+		Limit:        limit,
+	}
+
+	resp, meta, err := h.service.GetAnomalyQueue(ctx.Request().Context(), filter)
+	if err != nil {
+		logger.Errorw("Get Anomaly Queue failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Anomaly Queue Success", resp, nil, meta)
+}
+
+func (h *Handler) ReviewAnomaly(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	var req AnomalyReviewRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.ID = uint(id)
+	req.ReviewerID = userContext.UserID
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := h.service.ReviewAnomaly(ctx.Request().Context(), &req); err != nil {
+		logger.Errorw("Review Anomaly failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Review Anomaly Success", nil, nil, nil)
+}
+
+func (h *Handler) GetMonthlyTimesheet(ctx echo.Context) error {
+	filter, err := h.parseTimesheetFilter(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	resp, err := h.service.GetMonthlyTimesheet(ctx.Request().Context(), filter)
+	if err != nil {
+		logger.Errorw("Get Monthly Timesheet failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Monthly Timesheet Success", resp, nil, nil)
+}
+
+func (h *Handler) ExportTimesheet(ctx echo.Context) error {
+	filter, err := h.parseTimesheetFilter(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	excelFile, err := h.service.GenerateTimesheetExcel(ctx.Request().Context(), filter)
+	if err != nil {
+		logger.Errorw("Generate Excel Timesheet Failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	filename := fmt.Sprintf("Timesheet_%04d_%02d.xlsx", filter.Year, filter.Month)
+	ctx.Response().Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	return ctx.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelFile)
+}
+
+func (h *Handler) DownloadTimesheetPDF(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	filter, err := h.parseTimesheetFilter(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+	filter.EmployeeID = uint(id)
+
+	pdfFile, data, err := h.service.GenerateTimesheetPDF(ctx.Request().Context(), filter)
+	if err != nil {
+		logger.Errorw("Generate PDF Timesheet Failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	filename := fmt.Sprintf("Timesheet-%s-%04d%02d.pdf", data.NIK, filter.Year, filter.Month)
+	ctx.Response().Header().Set("Content-Type", "application/pdf")
+	ctx.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	return ctx.Blob(http.StatusOK, "application/pdf", pdfFile)
+}
+
+func (h *Handler) parseTimesheetFilter(ctx echo.Context) (*TimesheetFilter, error) {
+	now := time.Now()
+	month := int(now.Month())
+	year := now.Year()
+
+	if m := ctx.QueryParam("month"); m != "" {
+		v, err := strconv.Atoi(m)
+		if err != nil || v < 1 || v > 12 {
+			return nil, fmt.Errorf("invalid month")
+		}
+		month = v
+	}
+	if y := ctx.QueryParam("year"); y != "" {
+		v, err := strconv.Atoi(y)
+		if err != nil || v < 2000 {
+			return nil, fmt.Errorf("invalid year")
+		}
+		year = v
+	}
+
+	deptID := 0
+	if d := ctx.QueryParam("department_id"); d != "" {
+		fmt.Sscanf(d, "%d", &deptID)
+	}
+
+	return &TimesheetFilter{
+		Month:        month,
+		Year:         year,
+		DepartmentID: uint(deptID),
+	}, nil
+}
+
 func (h *Handler) parseFilter(ctx echo.Context) *FilterParams {
 	limit := 10
 	cursor := ""
@@ -396,3 +396,282 @@ func TestHandler_ExportAttendance(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_GetAnomalyQueue(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryParams string
+		setupMocks  func(*mockService)
+		wantStatus  int
+	}{
+		{
+			name:        "success",
+			queryParams: "?page=1&limit=10&review_status=PENDING",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetAnomalyQueue", mock.Anything, mock.MatchedBy(func(f *AnomalyFilterParams) bool {
+					return f.Page == 1 && f.Limit == 10 && f.ReviewStatus == "PENDING"
+				})).Return([]AnomalyQueueResponse{}, response.NewMetaOffset(1, 10, 0), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "service error",
+			queryParams: "",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetAnomalyQueue", mock.Anything, mock.AnythingOfType("*attendance.AnomalyFilterParams")).Return(nil, nil, errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/attendance/anomalies"+tt.queryParams, nil)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      1,
+				CompanyID:   1,
+				Permissions: []string{constants.REVIEW_ATTENDANCE},
+			})
+
+			rec, err := at.Execute(handler.GetAnomalyQueue)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_ReviewAnomaly(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathParams map[string]string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			pathParams: map[string]string{"id": "1"},
+			body:       map[string]interface{}{"action": "CLEAR", "notes": "verified"},
+			setupMocks: func(svc *mockService) {
+				svc.On("ReviewAnomaly", mock.Anything, mock.MatchedBy(func(req *AnomalyReviewRequest) bool {
+					return req.ID == 1 && req.ReviewerID == 5 && req.Action == "CLEAR"
+				})).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid id",
+			pathParams: map[string]string{"id": "abc"},
+			body:       map[string]interface{}{"action": "CLEAR"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid action",
+			pathParams: map[string]string{"id": "1"},
+			body:       map[string]interface{}{"action": "IGNORE"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "service error",
+			pathParams: map[string]string{"id": "1"},
+			body:       map[string]interface{}{"action": "CONFIRM"},
+			setupMocks: func(svc *mockService) {
+				svc.On("ReviewAnomaly", mock.Anything, mock.AnythingOfType("*attendance.AnomalyReviewRequest")).Return(errors.New("cannot review attendance with review status CLEARED"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPut, "/api/attendance/anomalies/"+tt.pathParams["id"]+"/review", tt.body)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      5,
+				CompanyID:   1,
+				Permissions: []string{constants.REVIEW_ATTENDANCE},
+			})
+			at.WithPathParams(tt.pathParams)
+
+			rec, err := at.Execute(handler.ReviewAnomaly)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_GetMonthlyTimesheet(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryParams string
+		setupMocks  func(*mockService)
+		wantStatus  int
+	}{
+		{
+			name:        "success",
+			queryParams: "?month=3&year=2026&department_id=2",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetMonthlyTimesheet", mock.Anything, &TimesheetFilter{Month: 3, Year: 2026, DepartmentID: 2}).Return([]TimesheetSummary{}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "invalid month",
+			queryParams: "?month=13&year=2026",
+			setupMocks:  func(svc *mockService) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "service error",
+			queryParams: "?month=3&year=2026",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetMonthlyTimesheet", mock.Anything, mock.AnythingOfType("*attendance.TimesheetFilter")).Return(nil, errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/attendance/timesheet"+tt.queryParams, nil)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      1,
+				CompanyID:   1,
+				Permissions: []string{constants.VIEW_ATTENDANCE},
+			})
+
+			rec, err := at.Execute(handler.GetMonthlyTimesheet)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ExportTimesheet(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryParams string
+		setupMocks  func(*mockService)
+		wantStatus  int
+	}{
+		{
+			name:        "success",
+			queryParams: "?month=3&year=2026",
+			setupMocks: func(svc *mockService) {
+				svc.On("GenerateTimesheetExcel", mock.Anything, &TimesheetFilter{Month: 3, Year: 2026}).Return([]byte("excel-data"), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "invalid year",
+			queryParams: "?month=3&year=abc",
+			setupMocks:  func(svc *mockService) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "service error",
+			queryParams: "?month=3&year=2026",
+			setupMocks: func(svc *mockService) {
+				svc.On("GenerateTimesheetExcel", mock.Anything, mock.AnythingOfType("*attendance.TimesheetFilter")).Return(nil, errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/attendance/timesheet/export"+tt.queryParams, nil)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      1,
+				CompanyID:   1,
+				Permissions: []string{constants.EXPORT_ATTENDANCE},
+			})
+
+			rec, err := at.Execute(handler.ExportTimesheet)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Contains(t, rec.Header().Get("Content-Disposition"), "Timesheet_2026_03.xlsx")
+			}
+		})
+	}
+}
+
+func TestHandler_DownloadTimesheetPDF(t *testing.T) {
+	tests := []struct {
+		name        string
+		pathParams  map[string]string
+		queryParams string
+		setupMocks  func(*mockService)
+		wantStatus  int
+	}{
+		{
+			name:        "success",
+			pathParams:  map[string]string{"id": "1"},
+			queryParams: "?month=3&year=2026",
+			setupMocks: func(svc *mockService) {
+				svc.On("GenerateTimesheetPDF", mock.Anything, &TimesheetFilter{Month: 3, Year: 2026, EmployeeID: 1}).Return([]byte("%PDF"), &TimesheetSummary{NIK: "001"}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "invalid id",
+			pathParams:  map[string]string{"id": "abc"},
+			queryParams: "?month=3&year=2026",
+			setupMocks:  func(svc *mockService) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "service error",
+			pathParams:  map[string]string{"id": "99"},
+			queryParams: "?month=3&year=2026",
+			setupMocks: func(svc *mockService) {
+				svc.On("GenerateTimesheetPDF", mock.Anything, mock.AnythingOfType("*attendance.TimesheetFilter")).Return(nil, nil, errors.New("employee not found"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/attendance/timesheet/employees/"+tt.pathParams["id"]+"/pdf"+tt.queryParams, nil)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      1,
+				CompanyID:   1,
+				Permissions: []string{constants.EXPORT_ATTENDANCE},
+			})
+			at.WithPathParams(tt.pathParams)
+
+			rec, err := at.Execute(handler.DownloadTimesheetPDF)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, "application/pdf", rec.Header().Get("Content-Type"))
+				assert.Contains(t, rec.Header().Get("Content-Disposition"), "Timesheet-001-202603.pdf")
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package attendance
 import (
 	"context"
 	"io"
+	"time"
 
+	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
@@ -64,12 +66,81 @@ func (m *mockRepo) CountAttendanceToday(ctx context.Context, todayDate string) (
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *mockRepo) GetBulkLateDuration(ctx context.Context, month, year int) (map[uint]int, error) {
-	args := m.Called(ctx, month, year)
+func (m *mockRepo) FindByPeriod(ctx context.Context, startDate, endDate string) ([]Attendance, error) {
+	args := m.Called(ctx, startDate, endDate)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(map[uint]int), args.Error(1)
+	return args.Get(0).([]Attendance), args.Error(1)
+}
+
+func (m *mockRepo) FindHolidays(ctx context.Context, from, to time.Time) ([]master.Holiday, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]master.Holiday), args.Error(1)
+}
+
+func (m *mockRepo) FindByEmployeesAndPeriod(ctx context.Context, employeeIDs []uint, startDate, endDate string) ([]Attendance, error) {
+	args := m.Called(ctx, employeeIDs, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Attendance), args.Error(1)
+}
+
+func (m *mockRepo) FindByID(ctx context.Context, id uint) (*Attendance, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Attendance), args.Error(1)
+}
+
+func (m *mockRepo) GetLastAttendanceBefore(ctx context.Context, employeeID uint, date string) (*Attendance, error) {
+	args := m.Called(ctx, employeeID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Attendance), args.Error(1)
+}
+
+func (m *mockRepo) CountIdenticalCoordinates(ctx context.Context, employeeID uint, lat, long float64, startDate, endDate string) (int64, error) {
+	args := m.Called(ctx, employeeID, lat, long, startDate, endDate)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) CountImageHash(ctx context.Context, hash string, excludeID uint) (int64, error) {
+	args := m.Called(ctx, hash, excludeID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) CountEmployeesByDevice(ctx context.Context, deviceID string, startDate string, excludeEmployeeID uint) (int64, error) {
+	args := m.Called(ctx, deviceID, startDate, excludeEmployeeID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) CreateAnomalies(ctx context.Context, anomalies []AttendanceAnomaly) error {
+	return m.Called(ctx, anomalies).Error(0)
+}
+
+func (m *mockRepo) FindFlagged(ctx context.Context, filter *AnomalyFilterParams) ([]Attendance, int64, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]Attendance), args.Get(1).(int64), args.Error(2)
+}
+
+type mockAnomalyDetector struct{ mock.Mock }
+
+func (m *mockAnomalyDetector) Detect(ctx context.Context, input *AnomalyInput) ([]AttendanceAnomaly, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]AttendanceAnomaly), args.Error(1)
 }
 
 type mockStorage struct{ mock.Mock }
@@ -81,9 +152,30 @@ func (m *mockStorage) UploadFileByte(ctx context.Context, objectName string, rea
 
 type mockLocationFetcher struct{ mock.Mock }
 
-func (m *mockLocationFetcher) GetAddressFromCoords(lat, long float64) string {
-	args := m.Called(lat, long)
-	return args.String(0)
+func (m *mockLocationFetcher) GetAddressFromCoords(ctx context.Context, lat, long float64) (string, error) {
+	args := m.Called(ctx, lat, long)
+	return args.String(0), args.Error(1)
+}
+
+type mockCacheProvider struct{ mock.Mock }
+
+func (m *mockCacheProvider) Get(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockCacheProvider) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return m.Called(ctx, key, value, expiration).Error(0)
+}
+
+type mockWorkLocationProvider struct{ mock.Mock }
+
+func (m *mockWorkLocationProvider) FindAllWorkLocations(ctx context.Context) ([]master.WorkLocation, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]master.WorkLocation), args.Error(1)
 }
 
 type mockUserProvider struct{ mock.Mock }
@@ -101,6 +193,42 @@ func (m *mockUserProvider) CountActiveEmployee(ctx context.Context) (int64, erro
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *mockUserProvider) FindAllEmployeeActiveInScope(ctx context.Context) ([]user.Employee, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.Employee), args.Error(1)
+}
+
+func (m *mockUserProvider) FindSupervisor(ctx context.Context, employeeID uint) (*user.Employee, error) {
+	args := m.Called(ctx, employeeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Employee), args.Error(1)
+}
+
+type mockLeaveProvider struct{ mock.Mock }
+
+func (m *mockLeaveProvider) GetBulkApprovedLeaveDays(ctx context.Context, startDate, endDate string) (map[uint]map[string]float64, error) {
+	args := m.Called(ctx, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint]map[string]float64), args.Error(1)
+}
+
+type mockOvertimeProvider struct{ mock.Mock }
+
+func (m *mockOvertimeProvider) GetBulkApprovedMinutes(ctx context.Context, startDate, endDate string) (map[uint]int, error) {
+	args := m.Called(ctx, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint]int), args.Error(1)
+}
+
 type mockGeocodeWorker struct{ mock.Mock }
 
 func (m *mockGeocodeWorker) Start(workerCount int) {
@@ -198,3 +326,51 @@ func (m *mockService) GetDashboardStats(ctx context.Context) (*DashboardStatResp
 	}
 	return args.Get(0).(*DashboardStatResponse), args.Error(1)
 }
+
+func (m *mockService) GetAnomalyQueue(ctx context.Context, filter *AnomalyFilterParams) ([]AnomalyQueueResponse, *response.Meta, error) {
+	args := m.Called(ctx, filter)
+	var meta *response.Meta
+	if args.Get(1) != nil {
+		meta = args.Get(1).(*response.Meta)
+	}
+	if args.Get(0) == nil {
+		return nil, meta, args.Error(2)
+	}
+	return args.Get(0).([]AnomalyQueueResponse), meta, args.Error(2)
+}
+
+func (m *mockService) ReviewAnomaly(ctx context.Context, req *AnomalyReviewRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) GetMonthlyTimesheet(ctx context.Context, filter *TimesheetFilter) ([]TimesheetSummary, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]TimesheetSummary), args.Error(1)
+}
+
+func (m *mockService) GetBulkMonthlySummary(ctx context.Context, month, year int) (map[uint]TimesheetSummary, error) {
+	args := m.Called(ctx, month, year)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint]TimesheetSummary), args.Error(1)
+}
+
+func (m *mockService) GenerateTimesheetExcel(ctx context.Context, filter *TimesheetFilter) ([]byte, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *mockService) GenerateTimesheetPDF(ctx context.Context, filter *TimesheetFilter) ([]byte, *TimesheetSummary, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]byte), args.Get(1).(*TimesheetSummary), args.Error(2)
+}
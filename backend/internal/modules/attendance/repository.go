@@ -2,6 +2,8 @@ package attendance
 
 import (
 	"context"
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
 	"basekarya-backend/pkg/utils"
@@ -18,7 +20,16 @@ type Repository interface {
 	FindAll(ctx context.Context, filter *FilterParams) ([]Attendance, *response.Cursor, error)
 	CountByStatus(ctx context.Context, status constants.AttendanceStatus, todayDate string) (int64, error)
 	CountAttendanceToday(ctx context.Context, todayDate string) (int64, error)
-	GetBulkLateDuration(ctx context.Context, month, year int) (map[uint]int, error)
+	FindByPeriod(ctx context.Context, startDate, endDate string) ([]Attendance, error)
+	FindByEmployeesAndPeriod(ctx context.Context, employeeIDs []uint, startDate, endDate string) ([]Attendance, error)
+	FindHolidays(ctx context.Context, from, to time.Time) ([]master.Holiday, error)
+	FindByID(ctx context.Context, id uint) (*Attendance, error)
+	GetLastAttendanceBefore(ctx context.Context, employeeID uint, date string) (*Attendance, error)
+	CountIdenticalCoordinates(ctx context.Context, employeeID uint, lat, long float64, startDate, endDate string) (int64, error)
+	CountImageHash(ctx context.Context, hash string, excludeID uint) (int64, error)
+	CountEmployeesByDevice(ctx context.Context, deviceID string, startDate string, excludeEmployeeID uint) (int64, error)
+	CreateAnomalies(ctx context.Context, anomalies []AttendanceAnomaly) error
+	FindFlagged(ctx context.Context, filter *AnomalyFilterParams) ([]Attendance, int64, error)
 }
 
 type repository struct {
@@ -103,7 +114,7 @@ func (r *repository) FindAll(ctx context.Context, filter *FilterParams) ([]Atten
 	db := utils.GetDBFromContext(ctx, r.db)
 	var logs []Attendance
 
-	query := utils.TenantScope(ctx, db.Model(&Attendance{})).
+	query := utils.DataScope(ctx, utils.TenantScope(ctx, db.Model(&Attendance{})), "attendances.employee_id").
 		Select("attendances.*").
 		Joins("JOIN employees ON employees.id = attendances.employee_id").
 		Joins("JOIN ref_departments ON ref_departments.id = employees.department_id").
@@ -122,10 +133,10 @@ func (r *repository) FindAll(ctx context.Context, filter *FilterParams) ([]Atten
 		query = query.Where("employees.department_id = ?", filter.DepartmentID)
 	}
 
-	// filter search by full name or NIK
+	// filter search by full name or exact NIK, the NIK is encrypted so only its blind index can be matched
 	if filter.Search != "" {
 		searchParam := "%" + filter.Search + "%"
-		query = query.Where("LOWER(employees.full_name) LIKE LOWER(?) OR LOWER(employees.nik) LIKE LOWER(?)", searchParam, searchParam)
+		query = query.Where("LOWER(employees.full_name) LIKE LOWER(?) OR employees.nik_bidx = ?", searchParam, infrastructure.BlindIndex(filter.Search))
 	}
 
 	// check if limit more than 0 (not an export but fetching data)
@@ -164,9 +175,9 @@ func (r *repository) FindAll(ctx context.Context, filter *FilterParams) ([]Atten
 }
 
 func (r *repository) CountByStatus(ctx context.Context, status constants.AttendanceStatus, todayDate string) (int64, error) {
-	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	db := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Attendance{})), "attendances.employee_id")
 	var totalStatus int64
-	if err := db.Model(&Attendance{}).
+	if err := db.
 		Where("date = ? AND status = ?", todayDate, string(status)).
 		Count(&totalStatus).Error; err != nil {
 		return 0, err
@@ -176,9 +187,9 @@ func (r *repository) CountByStatus(ctx context.Context, status constants.Attenda
 }
 
 func (r *repository) CountAttendanceToday(ctx context.Context, todayDate string) (int64, error) {
-	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	db := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Attendance{})), "attendances.employee_id")
 	var totalStatus int64
-	if err := db.Model(&Attendance{}).
+	if err := db.
 		Where("date = ?", todayDate).
 		Count(&totalStatus).Error; err != nil {
 		return 0, err
@@ -187,27 +198,155 @@ func (r *repository) CountAttendanceToday(ctx context.Context, todayDate string)
 	return totalStatus, nil
 }
 
-func (r *repository) GetBulkLateDuration(ctx context.Context, month, year int) (map[uint]int, error) {
+func (r *repository) FindByPeriod(ctx context.Context, startDate, endDate string) ([]Attendance, error) {
+	db := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Attendance{})), "attendances.employee_id")
+	var logs []Attendance
+
+	err := db.Where("date BETWEEN ? AND ?", startDate, endDate).
+		Order("employee_id ASC, date ASC").
+		Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// FindHolidays returns the company holidays between from and to, nobody is expected at work on them.
+func (r *repository) FindHolidays(ctx context.Context, from, to time.Time) ([]master.Holiday, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&master.Holiday{}))
+	var holidays []master.Holiday
+	if err := db.Where("date BETWEEN ? AND ?", from, to).Order("date ASC").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+func (r *repository) FindByEmployeesAndPeriod(ctx context.Context, employeeIDs []uint, startDate, endDate string) ([]Attendance, error) {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
-	type Result struct {
-		UserID      uint
-		TotalMinute int
+	var logs []Attendance
+
+	err := db.Preload("Shift").
+		Where("employee_id IN ?", employeeIDs).
+		Where("date BETWEEN ? AND ?", startDate, endDate).
+		Order("employee_id ASC, date ASC").
+		Find(&logs).Error
+	if err != nil {
+		return nil, err
 	}
-	var results []Result
 
-	err := db.Model(&Attendance{}).
-		Select("employee_id, COALESCE(SUM(late_duration_minute), 0) as total_minute").
-		Where("MONTH(check_in_time) = ? AND YEAR(check_in_time) = ?", month, year).
-		Group("employee_id").
-		Scan(&results).Error
+	return logs, nil
+}
+
+func (r *repository) FindByID(ctx context.Context, id uint) (*Attendance, error) {
+	db := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Attendance{})), "attendances.employee_id")
+	var att Attendance
+
+	err := db.Preload("Anomalies").First(&att, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &att, nil
+}
+
+func (r *repository) GetLastAttendanceBefore(ctx context.Context, employeeID uint, date string) (*Attendance, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var att Attendance
+
+	err := db.Where("employee_id = ? AND date < ?", employeeID, date).
+		Order("date DESC").
+		First(&att).Error
 	if err != nil {
 		return nil, err
 	}
 
-	dataMap := make(map[uint]int)
-	for _, res := range results {
-		dataMap[res.UserID] = res.TotalMinute
+	return &att, nil
+}
+
+func (r *repository) CountIdenticalCoordinates(ctx context.Context, employeeID uint, lat, long float64, startDate, endDate string) (int64, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var total int64
+
+	err := db.Model(&Attendance{}).
+		Where("employee_id = ? AND date >= ? AND date < ?", employeeID, startDate, endDate).
+		Where("(check_in_lat = ? AND check_in_long = ?) OR (check_out_lat = ? AND check_out_long = ?)", lat, long, lat, long).
+		Distinct("date").
+		Count(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *repository) CountImageHash(ctx context.Context, hash string, excludeID uint) (int64, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var total int64
+
+	err := db.Model(&Attendance{}).
+		Where("id <> ?", excludeID).
+		Where("check_in_image_hash = ? OR check_out_image_hash = ?", hash, hash).
+		Count(&total).Error
+	if err != nil {
+		return 0, err
 	}
 
-	return dataMap, err
+	return total, nil
+}
+
+func (r *repository) CountEmployeesByDevice(ctx context.Context, deviceID string, startDate string, excludeEmployeeID uint) (int64, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var total int64
+
+	err := db.Model(&Attendance{}).
+		Where("employee_id <> ? AND date >= ?", excludeEmployeeID, startDate).
+		Where("check_in_device_id = ? OR check_out_device_id = ?", deviceID, deviceID).
+		Distinct("employee_id").
+		Count(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *repository) CreateAnomalies(ctx context.Context, anomalies []AttendanceAnomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	return utils.GetDBFromContext(ctx, r.db).Create(&anomalies).Error
+}
+
+func (r *repository) FindFlagged(ctx context.Context, filter *AnomalyFilterParams) ([]Attendance, int64, error) {
+	db := utils.GetDBFromContext(ctx, r.db)
+	var logs []Attendance
+	var total int64
+
+	query := utils.TenantScope(ctx, db.Model(&Attendance{})).
+		Where("attendances.review_status <> ?", string(constants.AttendanceReviewNone))
+
+	if filter.ReviewStatus != "" {
+		query = query.Where("attendances.review_status = ?", filter.ReviewStatus)
+	}
+
+	if filter.StartDate != "" && filter.EndDate != "" {
+		query = query.Where("attendances.date BETWEEN ? AND ?", filter.StartDate, filter.EndDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.
+		Preload("Employee").
+		Preload("Anomalies").
+		Order("attendances.risk_score DESC, attendances.date DESC, attendances.id DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&logs).Error
+
+	return logs, total, err
 }
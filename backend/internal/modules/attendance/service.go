@@ -7,6 +7,7 @@ import (
 	"basekarya-backend/pkg/utils"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"time"
@@ -22,23 +23,36 @@ type Service interface {
 	GetAllRecap(ctx context.Context, filter *FilterParams) ([]RecapResponse, *response.Meta, error)
 	GenerateExcel(ctx context.Context, filter *FilterParams) ([]byte, error)
 	GetDashboardStats(ctx context.Context) (*DashboardStatResponse, error)
+	GetAnomalyQueue(ctx context.Context, filter *AnomalyFilterParams) ([]AnomalyQueueResponse, *response.Meta, error)
+	ReviewAnomaly(ctx context.Context, req *AnomalyReviewRequest) error
+	GetMonthlyTimesheet(ctx context.Context, filter *TimesheetFilter) ([]TimesheetSummary, error)
+	GetBulkMonthlySummary(ctx context.Context, month, year int) (map[uint]TimesheetSummary, error)
+	GenerateTimesheetExcel(ctx context.Context, filter *TimesheetFilter) ([]byte, error)
+	GenerateTimesheetPDF(ctx context.Context, filter *TimesheetFilter) ([]byte, *TimesheetSummary, error)
 }
 
+// timeNow is the clock used by Clock, replaced in tests to pin the time of day.
+var timeNow = time.Now
+
 type service struct {
 	repo               Repository
 	user               UserProvider
+	leave              LeaveProvider
+	overtime           OvertimeProvider
 	storage            StorageProvider
 	geocodeWorker      GeocodeWorker
+	anomalyDetector    AnomalyDetector
 	transactionManager infrastructure.TransactionManager
 	excel              infrastructure.ExcelProvider
 }
 
-func NewService(repo Repository, user UserProvider, storage StorageProvider, geocodeWorker GeocodeWorker, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
-	return &service{repo, user, storage, geocodeWorker, transactionManager, excel}
+func NewService(repo Repository, user UserProvider, leave LeaveProvider, overtime OvertimeProvider, storage StorageProvider, geocodeWorker GeocodeWorker, anomalyDetector AnomalyDetector, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
+	return &service{repo, user, leave, overtime, storage, geocodeWorker, anomalyDetector, transactionManager, excel}
 }
 
 func (s *service) Clock(ctx context.Context, userID uint, req *ClockRequest) (*AttendanceResponse, error) {
 	var resp *AttendanceResponse
+	var job *GeocodeJob
 	err := s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
 		u, err := s.user.FindByID(ctx, userID)
 		if err != nil || u.Employee == nil {
@@ -56,11 +70,12 @@ func (s *service) Clock(ctx context.Context, userID uint, req *ClockRequest) (*A
 			return errors.New("invalid image")
 		}
 		imageReader := bytes.NewReader(imgBytes)
+		imageHash := fmt.Sprintf("%x", sha256.Sum256(imgBytes))
 
 		// set address temporary
-		tempAddress := fmt.Sprintf("Processing location... (%f, %f)", req.Latitude, req.Longitude)
+		tempAddress := fmt.Sprintf("%s (%f, %f)", pendingAddressPrefix, req.Latitude, req.Longitude)
 
-		now := time.Now()
+		now := timeNow()
 		todayString := now.Format(constants.DefaultTimeFormat)
 		fileName := fmt.Sprintf("attendance/%d/%s-%d.jpg", employee.ID, todayString, now.Unix())
 
@@ -75,15 +90,23 @@ func (s *service) Clock(ctx context.Context, userID uint, req *ClockRequest) (*A
 			now.Location(),
 		)
 
+		todayAtt, err := s.repo.GetTodayAttendance(ctx, employee.ID)
+
+		// a half day or hourly leave covering the start of the shift moves the expected arrival to the end of the leave
+		placeholder := isLeavePlaceholder(todayAtt)
+		if placeholder && !todayAtt.ExcusedFrom.After(expectedTime) && todayAtt.ExcusedUntil.After(expectedTime) {
+			expectedTime = *todayAtt.ExcusedUntil
+			shiftStartToday = expectedTime
+		}
+
 		lateMinute := 0
 		if now.After(expectedTime) {
 			diff := now.Sub(expectedTime)
 			lateMinute = int(diff.Minutes())
 		}
 
-		todayAtt, err := s.repo.GetTodayAttendance(ctx, employee.ID)
-		// if today no data, its check-in of that employee
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		// if today no data (or only the excused hours of a leave), its check-in of that employee
+		if errors.Is(err, gorm.ErrRecordNotFound) || placeholder {
 			earliersAllowed := shiftStartToday.Add(-2 * time.Hour)
 			if now.Before(earliersAllowed) {
 				return errors.New("cannot check-in, too early")
@@ -98,6 +121,19 @@ func (s *service) Clock(ctx context.Context, userID uint, req *ClockRequest) (*A
 				status = string(constants.AttendanceStatusLate)
 			}
 
+			anomalies, err := s.anomalyDetector.Detect(ctx, &AnomalyInput{
+				EmployeeID:     employee.ID,
+				Latitude:       req.Latitude,
+				Longitude:      req.Longitude,
+				ImageHash:      imageHash,
+				DeviceID:       req.DeviceID,
+				IsMockLocation: req.IsMockLocation,
+				Time:           now,
+			})
+			if err != nil {
+				return err
+			}
+
 			imgUrl, err := s.storage.UploadFileByte(ctx, fmt.Sprintf("in-%s", fileName), imageReader, int64(len(imgBytes)), "image/jpg")
 			if err != nil {
 				return err
@@ -107,29 +143,49 @@ func (s *service) Clock(ctx context.Context, userID uint, req *ClockRequest) (*A
 			CompanyID:          utils.GetCompanyIDFromCtx(ctx),
 			EmployeeID:         employee.ID,
 				ShiftID:            employee.ShiftID,
-				Date:               time.Now(),
+				Date:               now,
 				CheckInTime:        now,
 				CheckInLat:         req.Latitude,
 				CheckInLong:        req.Longitude,
 				CheckInImageURL:    imgUrl,
 				CheckInAddress:     tempAddress,
+				CheckInImageHash:   imageHash,
+				CheckInDeviceID:    req.DeviceID,
 				Status:             status,
 				Notes:              req.Notes,
 				LateDurationMinute: lateMinute,
 				IsSuspicious:       false,
+				ReviewStatus:       string(constants.AttendanceReviewNone),
+			}
+			applyAnomalies(newAtt, anomalies)
+
+			if placeholder {
+				newAtt.ID = todayAtt.ID
+				newAtt.Date = todayAtt.Date
+				newAtt.CreatedAt = todayAtt.CreatedAt
+				newAtt.LeaveRequestID = todayAtt.LeaveRequestID
+				newAtt.ExcusedFrom = todayAtt.ExcusedFrom
+				newAtt.ExcusedUntil = todayAtt.ExcusedUntil
+
+				if err := s.repo.Update(ctx, newAtt); err != nil {
+					return err
+				}
+			} else if err := s.repo.Create(ctx, newAtt); err != nil {
+				return err
 			}
 
-			if err := s.repo.Create(ctx, newAtt); err != nil {
+			if err := s.saveAnomalies(ctx, newAtt.ID, anomalies); err != nil {
 				return err
 			}
 
-			// process this attendance (check-in) to geocode worker queue
-			s.geocodeWorker.Enqueue(GeocodeJob{
+			// process this attendance (check-in) to geocode worker queue once committed
+			job = &GeocodeJob{
 				AttendanceID: newAtt.ID,
+				CompanyID:    newAtt.CompanyID,
 				Latitude:     req.Latitude,
 				Longitude:    req.Longitude,
 				IsCheckout:   false,
-			})
+			}
 
 			resp = &AttendanceResponse{
 				Type:    string(constants.AttendanceTypeCheckIn),
@@ -143,22 +199,19 @@ func (s *service) Clock(ctx context.Context, userID uint, req *ClockRequest) (*A
 		// if today already have attendance, but the checkout time is still null, its checkout of that employee
 		if todayAtt != nil && todayAtt.CheckOutTime == nil {
 
-			// Calculate Teleportation Check (to detect distance between location check-in & check-out employee, will get mark if suspicious )
-			distanceMeters := utils.CalculateDistance(todayAtt.CheckInLat, todayAtt.CheckInLong, req.Latitude, req.Longitude)
-			distanceKm := distanceMeters / 1000.0
-
-			durationHours := time.Since(todayAtt.CheckInTime).Hours()
-
-			isSuspicious := false
-			notes := ""
-
-			if durationHours > 0.01 {
-				speedKmH := distanceKm / durationHours
-
-				if speedKmH > 200 && distanceKm > 2.0 {
-					isSuspicious = true
-					notes = fmt.Sprintf("[SUSPICIOUS] Speed %.2f km/h detected. Teleportation check failed.", speedKmH)
-				}
+			// run anomaly rules (teleportation from check-in, reused selfie, shared device, etc)
+			anomalies, err := s.anomalyDetector.Detect(ctx, &AnomalyInput{
+				EmployeeID:     employee.ID,
+				Latitude:       req.Latitude,
+				Longitude:      req.Longitude,
+				ImageHash:      imageHash,
+				DeviceID:       req.DeviceID,
+				IsMockLocation: req.IsMockLocation,
+				Time:           now,
+				CheckIn:        todayAtt,
+			})
+			if err != nil {
+				return err
 			}
 
 			imgUrl, err := s.storage.UploadFileByte(ctx, fmt.Sprintf("out-%s", fileName), imageReader, int64(len(imgBytes)), "image/jpg")
@@ -171,23 +224,26 @@ func (s *service) Clock(ctx context.Context, userID uint, req *ClockRequest) (*A
 			todayAtt.CheckOutLong = &req.Longitude
 			todayAtt.CheckOutImageURL = &imgUrl
 			todayAtt.CheckOutAddress = &tempAddress
+			todayAtt.CheckOutImageHash = &imageHash
+			todayAtt.CheckOutDeviceID = &req.DeviceID
+			applyAnomalies(todayAtt, anomalies)
 
-			if isSuspicious {
-				todayAtt.IsSuspicious = true
-				todayAtt.Notes = todayAtt.Notes + " " + notes
+			if err := s.repo.Update(ctx, todayAtt); err != nil {
+				return err
 			}
 
-			if err := s.repo.Update(ctx, todayAtt); err != nil {
+			if err := s.saveAnomalies(ctx, todayAtt.ID, anomalies); err != nil {
 				return err
 			}
 
-			// process this attendance (check-out) to geocode worker queue
-			s.geocodeWorker.Enqueue(GeocodeJob{
+			// process this attendance (check-out) to geocode worker queue once committed
+			job = &GeocodeJob{
 				AttendanceID: todayAtt.ID,
+				CompanyID:    todayAtt.CompanyID,
 				Latitude:     req.Latitude,
 				Longitude:    req.Longitude,
 				IsCheckout:   true,
-			})
+			}
 
 			resp = &AttendanceResponse{
 				Type:    string(constants.AttendanceTypeCheckOut),
@@ -204,6 +260,10 @@ func (s *service) Clock(ctx context.Context, userID uint, req *ClockRequest) (*A
 	if err != nil {
 		return nil, err
 	}
+
+	if job != nil {
+		s.geocodeWorker.Enqueue(*job)
+	}
 	return resp, nil
 }
 
@@ -428,6 +488,12 @@ func (s *service) GetDashboardStats(ctx context.Context) (*DashboardStatResponse
 	return stats, nil
 }
 
+// isLeavePlaceholder reports whether the row only holds the excused hours of a half day or hourly leave
+// and the employee has not checked in yet.
+func isLeavePlaceholder(att *Attendance) bool {
+	return att != nil && att.LeaveRequestID != nil && att.ExcusedFrom != nil && att.ExcusedUntil != nil && att.CheckInImageURL == ""
+}
+
 func combineDateAndTime(date time.Time, timeStr string) (time.Time, error) {
 	parsedTime, err := time.Parse(constants.AttendanceTimeFormat, timeStr)
 	if err != nil {
@@ -442,3 +508,103 @@ func combineDateAndTime(date time.Time, timeStr string) (time.Time, error) {
 
 	return fullTime, nil
 }
+
+func (s *service) GetAnomalyQueue(ctx context.Context, filter *AnomalyFilterParams) ([]AnomalyQueueResponse, *response.Meta, error) {
+	data, total, err := s.repo.FindFlagged(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := []AnomalyQueueResponse{}
+	for _, item := range data {
+		res := AnomalyQueueResponse{
+			ID:           item.ID,
+			Date:         item.Date.Format(constants.DefaultTimeFormat),
+			EmployeeID:   item.EmployeeID,
+			CheckInTime:  item.CheckInTime,
+			CheckOutTime: item.CheckOutTime,
+			RiskScore:    item.RiskScore,
+			ReviewStatus: item.ReviewStatus,
+			ReviewNotes:  item.ReviewNotes,
+			Reasons:      []AnomalyReasonResponse{},
+		}
+
+		if item.Employee != nil {
+			res.EmployeeName = item.Employee.FullName
+			res.NIK = item.Employee.NIK
+		}
+
+		for _, a := range item.Anomalies {
+			res.Reasons = append(res.Reasons, AnomalyReasonResponse{
+				Rule:       a.Rule,
+				Score:      a.Score,
+				Detail:     a.Detail,
+				IsCheckout: a.IsCheckout,
+			})
+		}
+
+		results = append(results, res)
+	}
+
+	meta := response.NewMetaOffset(filter.Page, filter.Limit, total)
+	return results, meta, nil
+}
+
+func (s *service) ReviewAnomaly(ctx context.Context, req *AnomalyReviewRequest) error {
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		att, err := s.repo.FindByID(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+
+		// only flagged attendance waiting for review can be processed
+		if att.ReviewStatus != string(constants.AttendanceReviewPending) {
+			return fmt.Errorf("cannot review attendance with review status %s", att.ReviewStatus)
+		}
+
+		switch constants.AttendanceReviewAction(req.Action) {
+		case constants.AttendanceReviewActionClear:
+			att.IsSuspicious = false
+			att.ReviewStatus = string(constants.AttendanceReviewCleared)
+		case constants.AttendanceReviewActionConfirm:
+			att.IsSuspicious = true
+			att.ReviewStatus = string(constants.AttendanceReviewConfirmed)
+		default:
+			return fmt.Errorf("invalid action: %s", req.Action)
+		}
+
+		now := time.Now()
+		att.ReviewedBy = &req.ReviewerID
+		att.ReviewedAt = &now
+		att.ReviewNotes = req.Notes
+
+		// anomalies are kept in their own table, avoid re-saving the association
+		att.Anomalies = nil
+
+		return s.repo.Update(ctx, att)
+	})
+}
+
+func (s *service) saveAnomalies(ctx context.Context, attendanceID uint, anomalies []AttendanceAnomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	for i := range anomalies {
+		anomalies[i].AttendanceID = attendanceID
+	}
+
+	return s.repo.CreateAnomalies(ctx, anomalies)
+}
+
+func applyAnomalies(att *Attendance, anomalies []AttendanceAnomaly) {
+	if len(anomalies) == 0 {
+		return
+	}
+
+	for _, a := range anomalies {
+		att.RiskScore += a.Score
+	}
+	att.IsSuspicious = true
+	att.ReviewStatus = string(constants.AttendanceReviewPending)
+}
@@ -18,25 +18,46 @@ import (
 	"gorm.io/gorm"
 )
 
-func newTestAttendanceService() (Service, *mockRepo, *mockUserProvider, *mockStorage, *mockGeocodeWorker, *testutil.MockTransactionManager, *mockExcel) {
+func newTestAttendanceService() (Service, *mockRepo, *mockUserProvider, *mockStorage, *mockGeocodeWorker, *mockAnomalyDetector, *testutil.MockTransactionManager, *mockExcel) {
 	repo := new(mockRepo)
 	userProv := new(mockUserProvider)
 	storage := new(mockStorage)
 	geo := new(mockGeocodeWorker)
+	detector := new(mockAnomalyDetector)
 	tm := testutil.NewMockTransactionManager()
 	excel := new(mockExcel)
 
-	svc := NewService(repo, userProv, storage, geo, tm, excel)
-	return svc, repo, userProv, storage, geo, tm, excel
+	svc := NewService(repo, userProv, new(mockLeaveProvider), new(mockOvertimeProvider), storage, geo, detector, tm, excel)
+	return svc, repo, userProv, storage, geo, detector, tm, excel
+}
+
+func newTestTimesheetService() (Service, *mockRepo, *mockUserProvider, *mockLeaveProvider, *mockOvertimeProvider, *mockExcel) {
+	repo := new(mockRepo)
+	userProv := new(mockUserProvider)
+	leaveProv := new(mockLeaveProvider)
+	overtimeProv := new(mockOvertimeProvider)
+	excel := new(mockExcel)
+
+	svc := NewService(repo, userProv, leaveProv, overtimeProv, new(mockStorage), new(mockGeocodeWorker), new(mockAnomalyDetector), testutil.NewMockTransactionManager(), excel)
+	return svc, repo, userProv, leaveProv, overtimeProv, excel
+}
+
+// pinClock fixes the service clock to 10:00 today so shift based cases do not depend on when tests run.
+func pinClock(t *testing.T) {
+	t.Helper()
+	now := time.Now()
+	fixed := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, now.Location())
+	timeNow = func() time.Time { return fixed }
+	t.Cleanup(func() { timeNow = time.Now })
 }
 
 func shiftTimeForPresent() string {
-	t := time.Now().Add(1 * time.Hour)
+	t := timeNow().Add(1 * time.Hour)
 	return t.Format("15:04:05")
 }
 
 func shiftTimeForLate() string {
-	now := time.Now()
+	now := timeNow()
 	if now.Hour() >= 3 {
 		return now.Add(-2 * time.Hour).Format("15:04:05")
 	}
@@ -44,11 +65,12 @@ func shiftTimeForLate() string {
 }
 
 func shiftTimeForTooEarly() string {
-	t := time.Now().Add(5 * time.Hour)
+	t := timeNow().Add(5 * time.Hour)
 	return t.Format("15:04:05")
 }
 
 func TestService_Clock(t *testing.T) {
+	pinClock(t)
 	ctx := testutil.CtxWithTenant(1, 1, false)
 
 	tests := []struct {
@@ -111,6 +133,48 @@ func TestService_Clock(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:   "check-in after morning leave counts lateness from the end of the leave",
+			userID: 1,
+			req: &ClockRequest{
+				Latitude:    -6.2,
+				Longitude:   106.8,
+				ImageBase64: "aGVsbG8=",
+			},
+			setupMocks: func(r *mockRepo, u *mockUserProvider, s *mockStorage, g *mockGeocodeWorker) {
+				u.On("FindByID", mock.Anything, uint(1)).Return(&user.User{
+					Employee: &user.Employee{
+						ID:      1,
+						ShiftID: 1,
+						Shift: &master.Shift{
+							ID:        1,
+							StartTime: shiftTimeForLate(),
+						},
+					},
+				}, nil)
+				now := timeNow()
+				leaveRequestID := uint(7)
+				excusedFrom := now.Add(-2 * time.Hour)
+				excusedUntil := now.Add(-10 * time.Minute)
+				r.On("GetTodayAttendance", mock.Anything, uint(1)).Return(&Attendance{
+					ID:             5,
+					EmployeeID:     1,
+					Date:           now,
+					CheckInTime:    excusedFrom,
+					Status:         string(constants.AttendanceStatusExcused),
+					LeaveRequestID: &leaveRequestID,
+					ExcusedFrom:    &excusedFrom,
+					ExcusedUntil:   &excusedUntil,
+				}, nil)
+				s.On("UploadFileByte", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("http://img.url/in.jpg", nil)
+				r.On("Update", mock.Anything, mock.MatchedBy(func(att *Attendance) bool {
+					return att.ID == 5 && att.Status == string(constants.AttendanceStatusPresent) && att.LateDurationMinute == 10 &&
+						att.LeaveRequestID != nil && *att.LeaveRequestID == leaveRequestID
+				})).Return(nil)
+				g.On("Enqueue", mock.Anything)
+			},
+			wantErr: false,
+		},
 		{
 			name:   "check-in too early",
 			userID: 1,
@@ -154,7 +218,7 @@ func TestService_Clock(t *testing.T) {
 						},
 					},
 				}, nil)
-				checkInTime := time.Now().Add(-1 * time.Hour)
+				checkInTime := timeNow().Add(-1 * time.Hour)
 				r.On("GetTodayAttendance", mock.Anything, uint(1)).Return(&Attendance{
 					ID:          1,
 					CheckInTime: checkInTime,
@@ -314,7 +378,7 @@ func TestService_Clock(t *testing.T) {
 						},
 					},
 				}, nil)
-				checkInTime := time.Now().Add(-1 * time.Hour)
+				checkInTime := timeNow().Add(-1 * time.Hour)
 				r.On("GetTodayAttendance", mock.Anything, uint(1)).Return(&Attendance{
 					ID:          1,
 					CheckInTime: checkInTime,
@@ -345,7 +409,7 @@ func TestService_Clock(t *testing.T) {
 						},
 					},
 				}, nil)
-				checkInTime := time.Now().Add(-1 * time.Hour)
+				checkInTime := timeNow().Add(-1 * time.Hour)
 				r.On("GetTodayAttendance", mock.Anything, uint(1)).Return(&Attendance{
 					ID:          1,
 					CheckInTime: checkInTime,
@@ -362,7 +426,8 @@ func TestService_Clock(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, userProv, storage, geo, _, _ := newTestAttendanceService()
+			svc, repo, userProv, storage, geo, detector, _, _ := newTestAttendanceService()
+			detector.On("Detect", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
 			tt.setupMocks(repo, userProv, storage, geo)
 
 			resp, err := svc.Clock(ctx, tt.userID, tt.req)
@@ -485,7 +550,7 @@ func TestService_GetTodayStatus(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, userProv, _, _, _, _ := newTestAttendanceService()
+			svc, repo, userProv, _, _, _, _, _ := newTestAttendanceService()
 			tt.setupMocks(repo, userProv)
 
 			resp, err := svc.GetTodayStatus(ctx, tt.userID)
@@ -587,7 +652,7 @@ func TestService_GetMyHistory(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, userProv, _, _, _, _ := newTestAttendanceService()
+			svc, repo, userProv, _, _, _, _, _ := newTestAttendanceService()
 			tt.setupMocks(repo, userProv)
 
 			logs, meta, err := svc.GetMyHistory(ctx, tt.userID, 5, 2026, 10, tt.cursor)
@@ -674,7 +739,7 @@ func TestService_GetAllRecap(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, _ := newTestAttendanceService()
+			svc, repo, _, _, _, _, _, _ := newTestAttendanceService()
 			tt.setupMocks(repo)
 
 			result, meta, err := svc.GetAllRecap(ctx, tt.filter)
@@ -782,7 +847,7 @@ func TestService_GenerateExcel(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, excel := newTestAttendanceService()
+			svc, repo, _, _, _, _, _, excel := newTestAttendanceService()
 			tt.setupMocks(repo, excel)
 
 			data, err := svc.GenerateExcel(ctx, tt.filter)
@@ -864,7 +929,7 @@ func TestService_GetDashboardStats(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, userProv, _, _, _, _ := newTestAttendanceService()
+			svc, repo, userProv, _, _, _, _, _ := newTestAttendanceService()
 			tt.setupMocks(repo, userProv)
 
 			resp, err := svc.GetDashboardStats(ctx)
@@ -881,3 +946,251 @@ func TestService_GetDashboardStats(t *testing.T) {
 		})
 	}
 }
+
+func TestService_Clock_Anomalies(t *testing.T) {
+	pinClock(t)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	employee := &user.User{
+		Employee: &user.Employee{
+			ID:      1,
+			ShiftID: 1,
+			Shift:   &master.Shift{ID: 1, StartTime: shiftTimeForPresent()},
+		},
+	}
+	flagged := []AttendanceAnomaly{
+		{CompanyID: 1, Rule: string(constants.AttendanceAnomalyMockLocation), Score: 60},
+		{CompanyID: 1, Rule: string(constants.AttendanceAnomalySharedDevice), Score: 40},
+	}
+
+	t.Run("check-in flagged for review", func(t *testing.T) {
+		svc, repo, userProv, storage, geo, detector, _, _ := newTestAttendanceService()
+		req := &ClockRequest{Latitude: -6.2, Longitude: 106.8, ImageBase64: "aGVsbG8=", DeviceID: "device-1", IsMockLocation: true}
+
+		userProv.On("FindByID", mock.Anything, uint(1)).Return(employee, nil)
+		repo.On("GetTodayAttendance", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+		detector.On("Detect", mock.Anything, mock.MatchedBy(func(in *AnomalyInput) bool {
+			return in.CheckIn == nil && in.IsMockLocation && in.DeviceID == "device-1" && in.ImageHash != ""
+		})).Return(flagged, nil)
+		storage.On("UploadFileByte", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("http://img.url/in.jpg", nil)
+		repo.On("Create", mock.Anything, mock.MatchedBy(func(a *Attendance) bool {
+			return a.IsSuspicious && a.RiskScore == 100 &&
+				a.ReviewStatus == string(constants.AttendanceReviewPending) &&
+				a.CheckInDeviceID == "device-1"
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*Attendance).ID = 10
+		}).Return(nil)
+		repo.On("CreateAnomalies", mock.Anything, mock.MatchedBy(func(items []AttendanceAnomaly) bool {
+			return len(items) == 2 && items[0].AttendanceID == 10 && items[1].AttendanceID == 10
+		})).Return(nil)
+		geo.On("Enqueue", mock.Anything)
+
+		resp, err := svc.Clock(ctx, 1, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, string(constants.AttendanceTypeCheckIn), resp.Type)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("check-out flagged for review", func(t *testing.T) {
+		svc, repo, userProv, storage, geo, detector, _, _ := newTestAttendanceService()
+		req := &ClockRequest{Latitude: -6.2, Longitude: 106.8, ImageBase64: "aGVsbG8="}
+		todayAtt := &Attendance{ID: 7, CheckInTime: timeNow().Add(-1 * time.Hour), Status: string(constants.AttendanceStatusPresent), ReviewStatus: string(constants.AttendanceReviewNone)}
+
+		userProv.On("FindByID", mock.Anything, uint(1)).Return(employee, nil)
+		repo.On("GetTodayAttendance", mock.Anything, uint(1)).Return(todayAtt, nil)
+		detector.On("Detect", mock.Anything, mock.MatchedBy(func(in *AnomalyInput) bool {
+			return in.CheckIn == todayAtt
+		})).Return(flagged[:1], nil)
+		storage.On("UploadFileByte", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("http://img.url/out.jpg", nil)
+		repo.On("Update", mock.Anything, mock.MatchedBy(func(a *Attendance) bool {
+			return a.IsSuspicious && a.RiskScore == 60 &&
+				a.ReviewStatus == string(constants.AttendanceReviewPending) &&
+				a.CheckOutImageHash != nil
+		})).Return(nil)
+		repo.On("CreateAnomalies", mock.Anything, mock.MatchedBy(func(items []AttendanceAnomaly) bool {
+			return len(items) == 1 && items[0].AttendanceID == 7
+		})).Return(nil)
+		geo.On("Enqueue", mock.Anything)
+
+		resp, err := svc.Clock(ctx, 1, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, string(constants.AttendanceTypeCheckOut), resp.Type)
+		assert.Empty(t, todayAtt.Notes)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("detector error", func(t *testing.T) {
+		svc, repo, userProv, _, _, detector, _, _ := newTestAttendanceService()
+		req := &ClockRequest{Latitude: -6.2, Longitude: 106.8, ImageBase64: "aGVsbG8="}
+
+		userProv.On("FindByID", mock.Anything, uint(1)).Return(employee, nil)
+		repo.On("GetTodayAttendance", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+		detector.On("Detect", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+		resp, err := svc.Clock(ctx, 1, req)
+
+		require.Error(t, err)
+		assert.Nil(t, resp)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_GetAnomalyQueue(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	tests := []struct {
+		name       string
+		filter     *AnomalyFilterParams
+		setupMocks func(*mockRepo)
+		wantErr    bool
+		wantLen    int
+	}{
+		{
+			name:   "success",
+			filter: &AnomalyFilterParams{Page: 1, Limit: 10},
+			setupMocks: func(r *mockRepo) {
+				r.On("FindFlagged", mock.Anything, mock.AnythingOfType("*attendance.AnomalyFilterParams")).Return([]Attendance{
+					{
+						ID:           1,
+						Date:         time.Now(),
+						CheckInTime:  time.Now(),
+						RiskScore:    60,
+						ReviewStatus: string(constants.AttendanceReviewPending),
+						Employee:     &user.Employee{FullName: "Jane", NIK: "002"},
+						Anomalies: []AttendanceAnomaly{
+							{Rule: string(constants.AttendanceAnomalyMockLocation), Score: 60, Detail: "mock"},
+						},
+					},
+				}, int64(1), nil)
+			},
+			wantLen: 1,
+		},
+		{
+			name:   "repo error",
+			filter: &AnomalyFilterParams{Page: 1, Limit: 10},
+			setupMocks: func(r *mockRepo) {
+				r.On("FindFlagged", mock.Anything, mock.AnythingOfType("*attendance.AnomalyFilterParams")).Return(nil, int64(0), errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, _, _, _, _, _ := newTestAttendanceService()
+			tt.setupMocks(repo)
+
+			result, meta, err := svc.GetAnomalyQueue(ctx, tt.filter)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, result, tt.wantLen)
+			require.NotNil(t, meta)
+			assert.Equal(t, "Jane", result[0].EmployeeName)
+			require.Len(t, result[0].Reasons, 1)
+			assert.Equal(t, string(constants.AttendanceAnomalyMockLocation), result[0].Reasons[0].Rule)
+		})
+	}
+}
+
+func TestService_ReviewAnomaly(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	pending := func() *Attendance {
+		return &Attendance{
+			ID:           1,
+			IsSuspicious: true,
+			ReviewStatus: string(constants.AttendanceReviewPending),
+			Anomalies:    []AttendanceAnomaly{{ID: 1, Rule: string(constants.AttendanceAnomalyMockLocation)}},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		req            *AnomalyReviewRequest
+		setupMocks     func(*mockRepo)
+		wantErr        bool
+		errMsg         string
+		wantStatus     string
+		wantSuspicious bool
+	}{
+		{
+			name: "clear flag",
+			req:  &AnomalyReviewRequest{ID: 1, ReviewerID: 9, Action: string(constants.AttendanceReviewActionClear), Notes: "verified with manager"},
+			setupMocks: func(r *mockRepo) {
+				r.On("FindByID", mock.Anything, uint(1)).Return(pending(), nil)
+				r.On("Update", mock.Anything, mock.AnythingOfType("*attendance.Attendance")).Return(nil)
+			},
+			wantStatus:     string(constants.AttendanceReviewCleared),
+			wantSuspicious: false,
+		},
+		{
+			name: "confirm flag",
+			req:  &AnomalyReviewRequest{ID: 1, ReviewerID: 9, Action: string(constants.AttendanceReviewActionConfirm)},
+			setupMocks: func(r *mockRepo) {
+				r.On("FindByID", mock.Anything, uint(1)).Return(pending(), nil)
+				r.On("Update", mock.Anything, mock.AnythingOfType("*attendance.Attendance")).Return(nil)
+			},
+			wantStatus:     string(constants.AttendanceReviewConfirmed),
+			wantSuspicious: true,
+		},
+		{
+			name: "already reviewed",
+			req:  &AnomalyReviewRequest{ID: 1, ReviewerID: 9, Action: string(constants.AttendanceReviewActionClear)},
+			setupMocks: func(r *mockRepo) {
+				r.On("FindByID", mock.Anything, uint(1)).Return(&Attendance{ID: 1, ReviewStatus: string(constants.AttendanceReviewCleared)}, nil)
+			},
+			wantErr: true,
+			errMsg:  "cannot review attendance with review status CLEARED",
+		},
+		{
+			name: "invalid action",
+			req:  &AnomalyReviewRequest{ID: 1, ReviewerID: 9, Action: "IGNORE"},
+			setupMocks: func(r *mockRepo) {
+				r.On("FindByID", mock.Anything, uint(1)).Return(pending(), nil)
+			},
+			wantErr: true,
+			errMsg:  "invalid action: IGNORE",
+		},
+		{
+			name: "not found",
+			req:  &AnomalyReviewRequest{ID: 1, ReviewerID: 9, Action: string(constants.AttendanceReviewActionClear)},
+			setupMocks: func(r *mockRepo) {
+				r.On("FindByID", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErr: true,
+			errMsg:  gorm.ErrRecordNotFound.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, _, _, _, _, _ := newTestAttendanceService()
+			tt.setupMocks(repo)
+
+			err := svc.ReviewAnomaly(ctx, tt.req)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+				repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			updated := repo.Calls[len(repo.Calls)-1].Arguments.Get(1).(*Attendance)
+			assert.Equal(t, tt.wantStatus, updated.ReviewStatus)
+			assert.Equal(t, tt.wantSuspicious, updated.IsSuspicious)
+			assert.Equal(t, uint(9), *updated.ReviewedBy)
+			assert.NotNil(t, updated.ReviewedAt)
+			assert.Equal(t, tt.req.Notes, updated.ReviewNotes)
+			assert.Nil(t, updated.Anomalies)
+		})
+	}
+}
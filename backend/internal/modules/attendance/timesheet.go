@@ -0,0 +1,482 @@
+package attendance
+
+import (
+	"basekarya-backend/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/signintech/gopdf"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+const noDepartmentName = "No Department"
+
+func (s *service) GetMonthlyTimesheet(ctx context.Context, filter *TimesheetFilter) ([]TimesheetSummary, error) {
+	summaries, _, err := s.buildTimesheet(ctx, filter)
+	return summaries, err
+}
+
+func (s *service) GetBulkMonthlySummary(ctx context.Context, month, year int) (map[uint]TimesheetSummary, error) {
+	summaries, _, err := s.buildTimesheet(ctx, &TimesheetFilter{Month: month, Year: year})
+	if err != nil {
+		return nil, err
+	}
+
+	dataMap := make(map[uint]TimesheetSummary, len(summaries))
+	for _, sum := range summaries {
+		dataMap[sum.EmployeeID] = sum
+	}
+
+	return dataMap, nil
+}
+
+// buildTimesheet summarizes the month per active employee and returns the daily logs it was built from.
+func (s *service) buildTimesheet(ctx context.Context, filter *TimesheetFilter) ([]TimesheetSummary, map[uint][]Attendance, error) {
+	start := time.Date(filter.Year, time.Month(filter.Month), 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, -1)
+	startDate := start.Format(constants.DefaultTimeFormat)
+	endDate := end.Format(constants.DefaultTimeFormat)
+
+	employees, err := s.user.FindAllEmployeeActiveInScope(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch all employee active: %w", err)
+	}
+
+	logs, err := s.repo.FindByPeriod(ctx, startDate, endDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch attendance by period: %w", err)
+	}
+
+	leaveMap, err := s.leave.GetBulkApprovedLeaveDays(ctx, startDate, endDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch bulk approved leave days: %w", err)
+	}
+
+	overtimeMap, err := s.overtime.GetBulkApprovedMinutes(ctx, startDate, endDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch bulk approved overtime minutes: %w", err)
+	}
+
+	holidays, err := s.repo.FindHolidays(ctx, start, end)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch holidays: %w", err)
+	}
+	closed := make(map[string]bool, len(holidays))
+	for _, h := range holidays {
+		closed[h.Date.Format(constants.DefaultTimeFormat)] = true
+	}
+
+	logMap := make(map[uint][]Attendance)
+	for _, att := range logs {
+		logMap[att.EmployeeID] = append(logMap[att.EmployeeID], att)
+	}
+
+	// days before today are final, a working day that is no holiday and has no record is counted as absent
+	now := timeNow()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	lastClosedDay := end
+	if !today.After(end) {
+		lastClosedDay = today.AddDate(0, 0, -1)
+	}
+
+	scheduledDays := countWorkingDays(start, end, closed)
+
+	summaries := []TimesheetSummary{}
+	for _, emp := range employees {
+		if filter.DepartmentID > 0 && emp.DepartmentID != filter.DepartmentID {
+			continue
+		}
+		if filter.EmployeeID > 0 && emp.ID != filter.EmployeeID {
+			continue
+		}
+
+		sum := TimesheetSummary{
+			EmployeeID:     emp.ID,
+			NIK:            emp.NIK,
+			EmployeeName:   emp.FullName,
+			Position:       emp.Position,
+			DepartmentName: noDepartmentName,
+			Month:          filter.Month,
+			Year:           filter.Year,
+			ScheduledDays:  scheduledDays,
+			LeaveDays:      map[string]float64{},
+			OvertimeHours:  roundHours(float64(overtimeMap[emp.ID]) / 60.0),
+		}
+		if emp.Department != nil {
+			sum.DepartmentName = emp.Department.Name
+		}
+		for leaveType, days := range leaveMap[emp.ID] {
+			sum.LeaveDays[leaveType] = days
+		}
+
+		recorded := make(map[string]bool)
+		var workMinutes float64
+		for _, att := range logMap[emp.ID] {
+			day := att.Date.Format(constants.DefaultTimeFormat)
+			recorded[day] = true
+
+			switch constants.AttendanceStatus(att.Status) {
+			case constants.AttendanceStatusPresent, constants.AttendanceStatusLate:
+				sum.PresentDays++
+				if att.Status == string(constants.AttendanceStatusLate) {
+					sum.LateCount++
+				}
+				sum.LateMinutes += att.LateDurationMinute
+
+				if att.CheckOutTime != nil {
+					workMinutes += att.CheckOutTime.Sub(att.CheckInTime).Minutes()
+				} else if day < today.Format(constants.DefaultTimeFormat) {
+					sum.MissingCheckouts++
+				}
+			case constants.AttendanceStatusAbsent:
+				sum.AbsentDays++
+			}
+		}
+		sum.WorkHours = roundHours(workMinutes / 60.0)
+
+		// nobody is absent before they joined
+		from := start
+		if emp.JoinDate != nil {
+			joined := time.Date(emp.JoinDate.Year(), emp.JoinDate.Month(), emp.JoinDate.Day(), 0, 0, 0, 0, time.Local)
+			if joined.After(from) {
+				from = joined
+			}
+		}
+		for d := from; !d.After(lastClosedDay); d = d.AddDate(0, 0, 1) {
+			day := d.Format(constants.DefaultTimeFormat)
+			if isWorkingDay(d) && !closed[day] && !recorded[day] {
+				sum.AbsentDays++
+			}
+		}
+
+		summaries = append(summaries, sum)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].DepartmentName != summaries[j].DepartmentName {
+			return summaries[i].DepartmentName < summaries[j].DepartmentName
+		}
+		return summaries[i].EmployeeName < summaries[j].EmployeeName
+	})
+
+	return summaries, logMap, nil
+}
+
+func (s *service) GenerateTimesheetExcel(ctx context.Context, filter *TimesheetFilter) ([]byte, error) {
+	summaries, _, err := s.buildTimesheet(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// leave types become columns, same set on every sheet
+	leaveTypeSet := make(map[string]bool)
+	var departments []string
+	byDepartment := make(map[string][]TimesheetSummary)
+	for _, sum := range summaries {
+		for leaveType := range sum.LeaveDays {
+			leaveTypeSet[leaveType] = true
+		}
+		if _, ok := byDepartment[sum.DepartmentName]; !ok {
+			departments = append(departments, sum.DepartmentName)
+		}
+		byDepartment[sum.DepartmentName] = append(byDepartment[sum.DepartmentName], sum)
+	}
+
+	leaveTypes := make([]string, 0, len(leaveTypeSet))
+	for leaveType := range leaveTypeSet {
+		leaveTypes = append(leaveTypes, leaveType)
+	}
+	sort.Strings(leaveTypes)
+
+	headers := []string{"NIK", "Name", "Position", "Scheduled Days", "Present", "Late Count", "Late Minutes", "Absent"}
+	for _, leaveType := range leaveTypes {
+		headers = append(headers, "Leave "+leaveType)
+	}
+	headers = append(headers, "Overtime Hours", "Work Hours", "Missing Check-out")
+
+	f := s.excel.NewFile()
+	styleHeader, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#DDEBF7"}, Pattern: 1},
+		Font: &excelize.Font{Color: "#1F4E78", Bold: true},
+	})
+
+	if len(departments) == 0 {
+		departments = []string{"Timesheet"}
+	}
+
+	usedNames := make(map[string]bool)
+	for i, dept := range departments {
+		sheet := timesheetSheetName(dept, usedNames)
+		if i == 0 {
+			f.SetSheetName("Sheet1", sheet)
+		} else {
+			if _, err := f.NewSheet(sheet); err != nil {
+				return nil, err
+			}
+		}
+
+		for col, h := range headers {
+			cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+			f.SetCellValue(sheet, cell, h)
+			f.SetCellStyle(sheet, cell, cell, styleHeader)
+		}
+
+		for r, sum := range byDepartment[dept] {
+			values := []interface{}{sum.NIK, sum.EmployeeName, sum.Position, sum.ScheduledDays, sum.PresentDays, sum.LateCount, sum.LateMinutes, sum.AbsentDays}
+			for _, leaveType := range leaveTypes {
+				values = append(values, sum.LeaveDays[leaveType])
+			}
+			values = append(values, sum.OvertimeHours, sum.WorkHours, sum.MissingCheckouts)
+
+			for col, v := range values {
+				cell, _ := excelize.CoordinatesToCellName(col+1, r+2)
+				f.SetCellValue(sheet, cell, v)
+			}
+		}
+
+		f.SetColWidth(sheet, "A", "A", 15)
+		f.SetColWidth(sheet, "B", "B", 25)
+		f.SetColWidth(sheet, "C", "C", 20)
+	}
+
+	return s.excel.WriteToBuffer(f)
+}
+
+func (s *service) GenerateTimesheetPDF(ctx context.Context, filter *TimesheetFilter) ([]byte, *TimesheetSummary, error) {
+	if filter.EmployeeID == 0 {
+		return nil, nil, errors.New("employee is required")
+	}
+
+	summaries, logMap, err := s.buildTimesheet(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(summaries) == 0 {
+		return nil, nil, errors.New("employee not found")
+	}
+	sum := summaries[0]
+
+	// the timesheet is signed off by whoever the employee reports to, the line stays blank without one
+	supervisorName := ""
+	supervisor, err := s.user.FindSupervisor(ctx, sum.EmployeeID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, fmt.Errorf("failed to fetch supervisor: %w", err)
+	}
+	if supervisor != nil {
+		supervisorName = "( " + supervisor.FullName + " )"
+	}
+
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+	pdf.AddPage()
+	pdf.SetTextColor(0, 0, 0)
+
+	if err := pdf.AddTTFFont("Roboto", "assets/fonts/Roboto-Regular.ttf"); err != nil {
+		return nil, nil, fmt.Errorf("failed load font regular: %w", err)
+	}
+	if err := pdf.AddTTFFont("Roboto-Bold", "assets/fonts/Roboto-Bold.ttf"); err != nil {
+		return nil, nil, fmt.Errorf("failed load font bold: %w", err)
+	}
+
+	marginLeft := 40.0
+	contentWidth := gopdf.PageSizeA4.W - (marginLeft * 2)
+	pageBottom := gopdf.PageSizeA4.H - 60.0
+	currentY := 40.0
+
+	// --- SECTION: HEADER ---
+	period := time.Date(sum.Year, time.Month(sum.Month), 1, 0, 0, 0, 0, time.Local)
+	_ = pdf.SetFont("Roboto-Bold", "", 16)
+	pdf.SetXY(marginLeft, currentY)
+	_ = pdf.CellWithOption(&gopdf.Rect{W: contentWidth, H: 20}, "MONTHLY TIMESHEET", gopdf.CellOption{Align: gopdf.Center})
+	currentY += 20
+
+	_ = pdf.SetFont("Roboto", "", 11)
+	pdf.SetXY(marginLeft, currentY)
+	_ = pdf.CellWithOption(&gopdf.Rect{W: contentWidth, H: 15}, "Period: "+period.Format("January 2006"), gopdf.CellOption{Align: gopdf.Center})
+	currentY += 30
+
+	// --- SECTION: EMPLOYEE INFO ---
+	infos := [][2]string{
+		{"Name", sum.EmployeeName},
+		{"NIK", sum.NIK},
+		{"Department", sum.DepartmentName},
+		{"Position", sum.Position},
+	}
+	for _, info := range infos {
+		_ = pdf.SetFont("Roboto-Bold", "", 10)
+		pdf.SetXY(marginLeft, currentY)
+		_ = pdf.Cell(nil, info[0])
+		_ = pdf.SetFont("Roboto", "", 10)
+		pdf.SetXY(marginLeft+80, currentY)
+		_ = pdf.Cell(nil, ": "+info[1])
+		currentY += 15
+	}
+	currentY += 10
+
+	// --- SECTION: DAILY LOG ---
+	columns := []struct {
+		Title string
+		Width float64
+	}{
+		{"Date", 90}, {"Day", 80}, {"Check In", 70}, {"Check Out", 70}, {"Work Hours", 70}, {"Status", contentWidth - 380},
+	}
+	rowH := 16.0
+
+	drawHeader := func() {
+		_ = pdf.SetFont("Roboto-Bold", "", 9)
+		x := marginLeft
+		for _, col := range columns {
+			pdf.SetXY(x, currentY)
+			_ = pdf.CellWithOption(&gopdf.Rect{W: col.Width, H: rowH}, col.Title, gopdf.CellOption{Border: gopdf.AllBorders, Align: gopdf.Middle | gopdf.Center})
+			x += col.Width
+		}
+		currentY += rowH
+	}
+	drawHeader()
+
+	for _, att := range logMap[sum.EmployeeID] {
+		if currentY+rowH > pageBottom {
+			pdf.AddPage()
+			currentY = 40
+			drawHeader()
+		}
+
+		cIn := att.CheckInTime.Format(constants.ShiftHourFormat)
+		cOut, workHours := "-", "-"
+		if att.CheckOutTime != nil {
+			cOut = att.CheckOutTime.Format(constants.ShiftHourFormat)
+			workHours = fmt.Sprintf("%.2f", roundHours(att.CheckOutTime.Sub(att.CheckInTime).Hours()))
+		}
+		if att.Status == string(constants.AttendanceStatusExcused) || att.Status == string(constants.AttendanceStatusSick) {
+			cIn = "-"
+		}
+
+		values := []string{att.Date.Format(constants.DefaultTimeFormat), att.Date.Weekday().String(), cIn, cOut, workHours, att.Status}
+
+		_ = pdf.SetFont("Roboto", "", 9)
+		x := marginLeft
+		for i, col := range columns {
+			pdf.SetXY(x, currentY)
+			_ = pdf.CellWithOption(&gopdf.Rect{W: col.Width, H: rowH}, values[i], gopdf.CellOption{Border: gopdf.AllBorders, Align: gopdf.Middle | gopdf.Center})
+			x += col.Width
+		}
+		currentY += rowH
+	}
+	currentY += 20
+
+	// --- SECTION: SUMMARY ---
+	leaveTypes := make([]string, 0, len(sum.LeaveDays))
+	for leaveType := range sum.LeaveDays {
+		leaveTypes = append(leaveTypes, leaveType)
+	}
+	sort.Strings(leaveTypes)
+
+	totals := [][2]string{
+		{"Scheduled Days", fmt.Sprintf("%d", sum.ScheduledDays)},
+		{"Present", fmt.Sprintf("%d", sum.PresentDays)},
+		{"Late", fmt.Sprintf("%d times (%d minutes)", sum.LateCount, sum.LateMinutes)},
+		{"Absent", fmt.Sprintf("%d", sum.AbsentDays)},
+	}
+	for _, leaveType := range leaveTypes {
+		totals = append(totals, [2]string{"Leave " + leaveType, fmt.Sprintf("%g", sum.LeaveDays[leaveType])})
+	}
+	totals = append(totals,
+		[2]string{"Overtime Hours", fmt.Sprintf("%.2f", sum.OvertimeHours)},
+		[2]string{"Work Hours", fmt.Sprintf("%.2f", sum.WorkHours)},
+		[2]string{"Missing Check-out", fmt.Sprintf("%d", sum.MissingCheckouts)},
+	)
+
+	// keep summary and signatures together
+	if currentY+float64(len(totals))*15+130 > pageBottom {
+		pdf.AddPage()
+		currentY = 40
+	}
+
+	_ = pdf.SetFont("Roboto-Bold", "", 11)
+	pdf.SetXY(marginLeft, currentY)
+	_ = pdf.Cell(nil, "SUMMARY")
+	currentY += 18
+
+	for _, total := range totals {
+		_ = pdf.SetFont("Roboto", "", 10)
+		pdf.SetXY(marginLeft, currentY)
+		_ = pdf.Cell(nil, total[0])
+		pdf.SetXY(marginLeft+120, currentY)
+		_ = pdf.Cell(nil, ": "+total[1])
+		currentY += 15
+	}
+	currentY += 30
+
+	// --- SECTION: SIGNATURE ---
+	signatureW := 150.0
+	supervisorX := marginLeft + contentWidth - signatureW
+
+	_ = pdf.SetFont("Roboto", "", 11)
+	pdf.SetXY(marginLeft, currentY)
+	_ = pdf.CellWithOption(&gopdf.Rect{W: signatureW, H: 15}, "Employee,", gopdf.CellOption{Align: gopdf.Center})
+	pdf.SetXY(supervisorX, currentY)
+	_ = pdf.CellWithOption(&gopdf.Rect{W: signatureW, H: 15}, "Approved by,", gopdf.CellOption{Align: gopdf.Center})
+
+	currentY += 70
+	_ = pdf.SetFont("Roboto-Bold", "", 11)
+	pdf.SetXY(marginLeft, currentY)
+	_ = pdf.CellWithOption(&gopdf.Rect{W: signatureW, H: 15}, "( "+sum.EmployeeName+" )", gopdf.CellOption{Border: gopdf.Top, Align: gopdf.Center})
+	pdf.SetXY(supervisorX, currentY)
+	_ = pdf.CellWithOption(&gopdf.Rect{W: signatureW, H: 15}, supervisorName, gopdf.CellOption{Border: gopdf.Top, Align: gopdf.Center})
+
+	return pdf.GetBytesPdf(), &sum, nil
+}
+
+// countWorkingDays counts the weekdays between start and end that are not in closed.
+func countWorkingDays(start, end time.Time, closed map[string]bool) int {
+	total := 0
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if isWorkingDay(d) && !closed[d.Format(constants.DefaultTimeFormat)] {
+			total++
+		}
+	}
+	return total
+}
+
+func isWorkingDay(d time.Time) bool {
+	return d.Weekday() != time.Saturday && d.Weekday() != time.Sunday
+}
+
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
+
+// timesheetSheetName strips characters excel rejects in sheet names and keeps names unique.
+func timesheetSheetName(name string, used map[string]bool) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	if cleaned == "" {
+		cleaned = noDepartmentName
+	}
+	if runes := []rune(cleaned); len(runes) > 31 {
+		cleaned = string(runes[:31])
+	}
+
+	sheet := cleaned
+	for i := 2; used[sheet]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		base := []rune(cleaned)
+		if len(base)+len(suffix) > 31 {
+			base = base[:31-len(suffix)]
+		}
+		sheet = string(base) + suffix
+	}
+	used[sheet] = true
+
+	return sheet
+}
@@ -0,0 +1,342 @@
+package attendance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/department"
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/internal/modules/rbac"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// March 2026 starts on a Sunday and has 22 working days, 21 with the holiday on Friday 20 March. Jane joined on
+// Monday 16 March.
+func setupTimesheetMocks(r *mockRepo, u *mockUserProvider, l *mockLeaveProvider, o *mockOvertimeProvider) {
+	day := func(d, hour, minute int) time.Time {
+		return time.Date(2026, 3, d, hour, minute, 0, 0, time.Local)
+	}
+	checkOut2 := day(2, 17, 0)
+	checkOut3 := day(3, 18, 0)
+	janeJoined := day(16, 0, 0)
+
+	u.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{
+		{ID: 1, NIK: "001", FullName: "John", DepartmentID: 1, Department: &department.Department{ID: 1, Name: "Engineering"}},
+		{ID: 2, NIK: "002", FullName: "Jane", DepartmentID: 2, Department: &department.Department{ID: 2, Name: "Finance"}, JoinDate: &janeJoined},
+	}, nil)
+	r.On("FindByPeriod", mock.Anything, "2026-03-01", "2026-03-31").Return([]Attendance{
+		{EmployeeID: 1, Date: day(2, 0, 0), CheckInTime: day(2, 9, 0), CheckOutTime: &checkOut2, Status: string(constants.AttendanceStatusPresent)},
+		{EmployeeID: 1, Date: day(3, 0, 0), CheckInTime: day(3, 9, 30), CheckOutTime: &checkOut3, Status: string(constants.AttendanceStatusLate), LateDurationMinute: 15},
+		{EmployeeID: 1, Date: day(4, 0, 0), CheckInTime: day(4, 9, 0), Status: string(constants.AttendanceStatusPresent)},
+		{EmployeeID: 1, Date: day(5, 0, 0), CheckInTime: day(5, 0, 0), Status: string(constants.AttendanceStatusExcused)},
+		{EmployeeID: 1, Date: day(6, 0, 0), CheckInTime: day(6, 0, 0), Status: string(constants.AttendanceStatusAbsent)},
+	}, nil)
+	l.On("GetBulkApprovedLeaveDays", mock.Anything, "2026-03-01", "2026-03-31").Return(map[uint]map[string]float64{1: {"Annual": 1}}, nil)
+	o.On("GetBulkApprovedMinutes", mock.Anything, "2026-03-01", "2026-03-31").Return(map[uint]int{1: 90}, nil)
+	r.On("FindHolidays", mock.Anything, day(1, 0, 0), day(31, 0, 0)).Return([]master.Holiday{{Date: day(20, 0, 0), Name: "Nyepi"}}, nil)
+}
+
+func pinTimesheetClock(t *testing.T) {
+	t.Helper()
+	timeNow = func() time.Time { return time.Date(2026, 4, 15, 10, 0, 0, 0, time.Local) }
+	t.Cleanup(func() { timeNow = time.Now })
+}
+
+func TestService_GetMonthlyTimesheet(t *testing.T) {
+	pinTimesheetClock(t)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	tests := []struct {
+		name       string
+		filter     *TimesheetFilter
+		setupMocks func(*mockRepo, *mockUserProvider, *mockLeaveProvider, *mockOvertimeProvider)
+		wantErr    bool
+		wantLen    int
+	}{
+		{
+			name:       "success all departments",
+			filter:     &TimesheetFilter{Month: 3, Year: 2026},
+			setupMocks: setupTimesheetMocks,
+			wantLen:    2,
+		},
+		{
+			name:       "filter by department",
+			filter:     &TimesheetFilter{Month: 3, Year: 2026, DepartmentID: 1},
+			setupMocks: setupTimesheetMocks,
+			wantLen:    1,
+		},
+		{
+			name:   "error fetch employees",
+			filter: &TimesheetFilter{Month: 3, Year: 2026},
+			setupMocks: func(r *mockRepo, u *mockUserProvider, l *mockLeaveProvider, o *mockOvertimeProvider) {
+				u.On("FindAllEmployeeActiveInScope", mock.Anything).Return(nil, errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name:   "error fetch leave",
+			filter: &TimesheetFilter{Month: 3, Year: 2026},
+			setupMocks: func(r *mockRepo, u *mockUserProvider, l *mockLeaveProvider, o *mockOvertimeProvider) {
+				u.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{}, nil)
+				r.On("FindByPeriod", mock.Anything, "2026-03-01", "2026-03-31").Return([]Attendance{}, nil)
+				l.On("GetBulkApprovedLeaveDays", mock.Anything, "2026-03-01", "2026-03-31").Return(nil, errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, userProv, leaveProv, overtimeProv, _ := newTestTimesheetService()
+			tt.setupMocks(repo, userProv, leaveProv, overtimeProv)
+
+			result, err := svc.GetMonthlyTimesheet(ctx, tt.filter)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, result, tt.wantLen)
+
+			john := result[0]
+			assert.Equal(t, uint(1), john.EmployeeID)
+			assert.Equal(t, "Engineering", john.DepartmentName)
+			assert.Equal(t, 21, john.ScheduledDays)
+			assert.Equal(t, 3, john.PresentDays)
+			assert.Equal(t, 1, john.LateCount)
+			assert.Equal(t, 15, john.LateMinutes)
+			// one recorded absence plus 16 working days without any record
+			assert.Equal(t, 17, john.AbsentDays)
+			assert.Equal(t, map[string]float64{"Annual": 1}, john.LeaveDays)
+			assert.Equal(t, 1.5, john.OvertimeHours)
+			assert.Equal(t, 16.5, john.WorkHours)
+			assert.Equal(t, 1, john.MissingCheckouts)
+
+			if tt.wantLen > 1 {
+				jane := result[1]
+				assert.Equal(t, "Finance", jane.DepartmentName)
+				assert.Equal(t, 0, jane.PresentDays)
+				// working days from her join date, the holiday excepted
+				assert.Equal(t, 11, jane.AbsentDays)
+				assert.Empty(t, jane.LeaveDays)
+			}
+		})
+	}
+}
+
+func TestService_GetMonthlyTimesheet_CurrentMonth(t *testing.T) {
+	// on 10 March only the working days up to 9 March are closed
+	timeNow = func() time.Time { return time.Date(2026, 3, 10, 8, 0, 0, 0, time.Local) }
+	t.Cleanup(func() { timeNow = time.Now })
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	svc, repo, userProv, leaveProv, overtimeProv, _ := newTestTimesheetService()
+	userProv.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{{ID: 2, NIK: "002", FullName: "Jane"}}, nil)
+	repo.On("FindByPeriod", mock.Anything, "2026-03-01", "2026-03-31").Return([]Attendance{
+		{EmployeeID: 2, Date: time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local), CheckInTime: time.Date(2026, 3, 10, 7, 55, 0, 0, time.Local), Status: string(constants.AttendanceStatusPresent)},
+	}, nil)
+	leaveProv.On("GetBulkApprovedLeaveDays", mock.Anything, mock.Anything, mock.Anything).Return(map[uint]map[string]float64{}, nil)
+	overtimeProv.On("GetBulkApprovedMinutes", mock.Anything, mock.Anything, mock.Anything).Return(map[uint]int{}, nil)
+	repo.On("FindHolidays", mock.Anything, mock.Anything, mock.Anything).Return([]master.Holiday{}, nil)
+
+	result, err := svc.GetMonthlyTimesheet(ctx, &TimesheetFilter{Month: 3, Year: 2026})
+
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, noDepartmentName, result[0].DepartmentName)
+	assert.Equal(t, 1, result[0].PresentDays)
+	assert.Equal(t, 6, result[0].AbsentDays)
+	assert.Equal(t, 0, result[0].MissingCheckouts)
+}
+
+func TestService_GetBulkMonthlySummary(t *testing.T) {
+	pinTimesheetClock(t)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	svc, repo, userProv, leaveProv, overtimeProv, _ := newTestTimesheetService()
+	setupTimesheetMocks(repo, userProv, leaveProv, overtimeProv)
+
+	result, err := svc.GetBulkMonthlySummary(ctx, 3, 2026)
+
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, 15, result[1].LateMinutes)
+	assert.Equal(t, 0, result[2].LateMinutes)
+}
+
+func TestService_GenerateTimesheetExcel(t *testing.T) {
+	pinTimesheetClock(t)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("one sheet per department", func(t *testing.T) {
+		svc, repo, userProv, leaveProv, overtimeProv, excel := newTestTimesheetService()
+		setupTimesheetMocks(repo, userProv, leaveProv, overtimeProv)
+
+		file := excelize.NewFile()
+		excel.On("NewFile").Return(file)
+		excel.On("WriteToBuffer", file).Return([]byte("fake-excel"), nil)
+
+		result, err := svc.GenerateTimesheetExcel(ctx, &TimesheetFilter{Month: 3, Year: 2026})
+
+		require.NoError(t, err)
+		assert.Equal(t, []byte("fake-excel"), result)
+		assert.Equal(t, []string{"Engineering", "Finance"}, file.GetSheetList())
+
+		header, _ := file.GetCellValue("Engineering", "I1")
+		assert.Equal(t, "Leave Annual", header)
+		name, _ := file.GetCellValue("Engineering", "B2")
+		assert.Equal(t, "John", name)
+		name, _ = file.GetCellValue("Finance", "B2")
+		assert.Equal(t, "Jane", name)
+	})
+
+	t.Run("error fetch attendance", func(t *testing.T) {
+		svc, repo, userProv, _, _, _ := newTestTimesheetService()
+		userProv.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{}, nil)
+		repo.On("FindByPeriod", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+		result, err := svc.GenerateTimesheetExcel(ctx, &TimesheetFilter{Month: 3, Year: 2026})
+
+		require.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
+
+func TestService_GenerateTimesheetPDF(t *testing.T) {
+	pinTimesheetClock(t)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	tests := []struct {
+		name       string
+		filter     *TimesheetFilter
+		setupMocks func(*mockRepo, *mockUserProvider, *mockLeaveProvider, *mockOvertimeProvider)
+		wantErr    bool
+		errMsg     string
+	}{
+		{
+			name:   "success",
+			filter: &TimesheetFilter{Month: 3, Year: 2026, EmployeeID: 1},
+			setupMocks: func(r *mockRepo, u *mockUserProvider, l *mockLeaveProvider, o *mockOvertimeProvider) {
+				setupTimesheetMocks(r, u, l, o)
+				u.On("FindSupervisor", mock.Anything, uint(1)).Return(&user.Employee{ID: 7, FullName: "Maria"}, nil)
+			},
+		},
+		{
+			name:   "without a supervisor",
+			filter: &TimesheetFilter{Month: 3, Year: 2026, EmployeeID: 1},
+			setupMocks: func(r *mockRepo, u *mockUserProvider, l *mockLeaveProvider, o *mockOvertimeProvider) {
+				setupTimesheetMocks(r, u, l, o)
+				u.On("FindSupervisor", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+			},
+		},
+		{
+			name:       "employee required",
+			filter:     &TimesheetFilter{Month: 3, Year: 2026},
+			setupMocks: func(r *mockRepo, u *mockUserProvider, l *mockLeaveProvider, o *mockOvertimeProvider) {},
+			wantErr:    true,
+			errMsg:     "employee is required",
+		},
+		{
+			name:       "employee not found",
+			filter:     &TimesheetFilter{Month: 3, Year: 2026, EmployeeID: 99},
+			setupMocks: setupTimesheetMocks,
+			wantErr:    true,
+			errMsg:     "employee not found",
+		},
+	}
+
+	// fonts are loaded relative to the backend root
+	t.Chdir("../../..")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, userProv, leaveProv, overtimeProv, _ := newTestTimesheetService()
+			tt.setupMocks(repo, userProv, leaveProv, overtimeProv)
+
+			result, sum, err := svc.GenerateTimesheetPDF(ctx, tt.filter)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(result, []byte("%PDF")))
+			assert.Equal(t, "001", sum.NIK)
+			userProv.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_GetMonthlyTimesheet_DepartmentScope(t *testing.T) {
+	pinTimesheetClock(t)
+	tdb := testutil.NewTestDB(&rbac.Role{}, &department.Department{}, &master.Shift{}, &master.Holiday{}, &user.User{}, &user.Employee{})
+	t.Cleanup(tdb.Close)
+	createAttendancesTable(t, tdb.DB)
+
+	require.NoError(t, tdb.DB.Create(&rbac.Role{ID: 1, Name: "EMPLOYEE", CompanyID: 1}).Error)
+	require.NoError(t, tdb.DB.Create(&[]department.Department{{ID: 1, Name: "Engineering", CompanyID: 1}, {ID: 2, Name: "Finance", CompanyID: 1}}).Error)
+	require.NoError(t, tdb.DB.Create(&master.Shift{ID: 1, Name: "Day", StartTime: "09:00", EndTime: "17:00", CompanyID: 1}).Error)
+	// 1 and 2 work in engineering, 3 in finance
+	for i, deptID := range []uint{1, 1, 2} {
+		id := uint(i + 1)
+		require.NoError(t, tdb.DB.Create(&user.User{ID: id, Username: fmt.Sprintf("user%d", id), PasswordHash: "hash", RoleID: 1, CompanyID: 1, IsActive: true}).Error)
+		require.NoError(t, tdb.DB.Create(&user.Employee{
+			ID: id, UserID: id, CompanyID: 1, DepartmentID: deptID, ShiftID: 1,
+			NIK: fmt.Sprintf("00%d", id), FullName: fmt.Sprintf("Employee %d", id), Email: fmt.Sprintf("user%d@example.com", id),
+		}).Error)
+	}
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+	for _, employeeID := range []uint{1, 3} {
+		require.NoError(t, tdb.DB.Create(&Attendance{
+			EmployeeID: employeeID, ShiftID: 1, CompanyID: 1, Date: day, CheckInTime: day.Add(9 * time.Hour),
+			Status: string(constants.AttendanceStatusPresent),
+		}).Error)
+	}
+
+	leaveProv, overtimeProv := new(mockLeaveProvider), new(mockOvertimeProvider)
+	leaveProv.On("GetBulkApprovedLeaveDays", mock.Anything, "2026-03-01", "2026-03-31").Return(map[uint]map[string]float64{}, nil)
+	overtimeProv.On("GetBulkApprovedMinutes", mock.Anything, "2026-03-01", "2026-03-31").Return(map[uint]int{}, nil)
+	svc := NewService(NewRepository(tdb.DB), user.NewRepository(tdb.DB), leaveProv, overtimeProv, new(mockStorage), new(mockGeocodeWorker), new(mockAnomalyDetector), testutil.NewMockTransactionManager(), nil)
+
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	ctx = context.WithValue(ctx, constants.DataScopeContextKey, constants.DataScopeDepartment)
+	ctx = context.WithValue(ctx, constants.EmployeeIDContextKey, uint(1))
+
+	result, err := svc.GetMonthlyTimesheet(ctx, &TimesheetFilter{Month: 3, Year: 2026})
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	for _, sum := range result {
+		assert.Equal(t, "Engineering", sum.DepartmentName)
+	}
+	assert.Equal(t, 1, result[0].PresentDays)
+
+	// an employee outside the department cannot be picked by id either
+	_, _, err = svc.GenerateTimesheetPDF(ctx, &TimesheetFilter{Month: 3, Year: 2026, EmployeeID: 3})
+	require.Error(t, err)
+	assert.Equal(t, "employee not found", err.Error())
+}
+
+func TestTimesheetSheetName(t *testing.T) {
+	used := make(map[string]bool)
+
+	assert.Equal(t, "R-D", timesheetSheetName("R/D", used))
+	assert.Equal(t, noDepartmentName, timesheetSheetName("", used))
+	assert.Equal(t, "Operations and Field Maintenanc", timesheetSheetName("Operations and Field Maintenance", used))
+	assert.Equal(t, "Operations and Field Mainte (2)", timesheetSheetName("Operations and Field Maintenance Team", used))
+}
@@ -1,13 +1,19 @@
 package attendance
 
 import (
+	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/logger"
+	"context"
+	"strings"
 	"sync"
-	"time"
 
 	"gorm.io/gorm"
 )
 
+// pendingAddressPrefix marks an address that has not been geocoded yet,
+// rows still carrying it are picked up again when the worker starts.
+const pendingAddressPrefix = "Processing location..."
+
 type GeocodeWorker interface {
 	Start(workerCount int)
 	Enqueue(job GeocodeJob)
@@ -16,26 +22,40 @@ type GeocodeWorker interface {
 
 type GeocodeJob struct {
 	AttendanceID uint
+	CompanyID    uint
 	Latitude     float64
 	Longitude    float64
 	IsCheckout   bool
 }
 
 type geocodeWorker struct {
-	db      *gorm.DB
-	fetcher LocationFetcher
-	queue   chan GeocodeJob
-	wg      *sync.WaitGroup
-	quit    chan bool
+	db        *gorm.DB
+	fetcher   LocationFetcher
+	queue     chan GeocodeJob
+	wg        *sync.WaitGroup
+	recoverWg *sync.WaitGroup
+	quit      chan bool
+}
+
+type pendingGeocode struct {
+	ID              uint
+	CompanyID       uint
+	CheckInLat      float64
+	CheckInLong     float64
+	CheckInAddress  string
+	CheckOutLat     *float64
+	CheckOutLong    *float64
+	CheckOutAddress *string
 }
 
 func NewGeocodeWorker(db *gorm.DB, fetcher LocationFetcher, bufferSize int) GeocodeWorker {
 	return &geocodeWorker{
-		db:      db,
-		fetcher: fetcher,
-		queue:   make(chan GeocodeJob, bufferSize),
-		wg:      &sync.WaitGroup{},
-		quit:    make(chan bool),
+		db:        db,
+		fetcher:   fetcher,
+		queue:     make(chan GeocodeJob, bufferSize),
+		wg:        &sync.WaitGroup{},
+		recoverWg: &sync.WaitGroup{},
+		quit:      make(chan bool),
 	}
 }
 
@@ -44,18 +64,24 @@ func (w *geocodeWorker) Start(workerCount int) {
 		w.wg.Add(1)
 		go w.runWorker(i)
 	}
+
+	w.recoverWg.Add(1)
+	go w.recoverPending()
 }
 
 func (w *geocodeWorker) Enqueue(job GeocodeJob) {
 	select {
 	case w.queue <- job:
 	default:
-		logger.Warn("Geocode queue is full, skipping job for ID:", job.AttendanceID)
+		// the row keeps its pending address, so it is retried on the next start
+		logger.Warn("Geocode queue is full, deferring job for ID:", job.AttendanceID)
 	}
 }
 
 func (w *geocodeWorker) Stop() {
 	logger.Info("Stopping Geocode Workers...")
+	close(w.quit)
+	w.recoverWg.Wait()
 	close(w.queue)
 	w.wg.Wait()
 	logger.Info("All Geocode Workers stopped.")
@@ -65,18 +91,69 @@ func (w *geocodeWorker) runWorker(id int) {
 	defer w.wg.Done()
 	logger.Infof("Geocode Worker #%d Started", id)
 
-	limiter := time.NewTicker(1500 * time.Millisecond)
-	defer limiter.Stop()
-
 	for job := range w.queue {
-		<-limiter.C
-
 		w.processJob(job)
 	}
 }
 
+// recoverPending re-enqueues attendances whose address was never resolved,
+// e.g. because the job was deferred or the process stopped before finishing it.
+func (w *geocodeWorker) recoverPending() {
+	defer w.recoverWg.Done()
+
+	var rows []pendingGeocode
+	err := w.db.Table("attendances").
+		Select("id, company_id, check_in_lat, check_in_long, check_in_address, check_out_lat, check_out_long, check_out_address").
+		Where("check_in_address LIKE ? OR check_out_address LIKE ?", pendingAddressPrefix+"%", pendingAddressPrefix+"%").
+		Order("id ASC").
+		Find(&rows).Error
+	if err != nil {
+		logger.Errorw("Failed to load pending geocode jobs", "error", err)
+		return
+	}
+
+	if len(rows) > 0 {
+		logger.Infof("Recovering %d pending geocode jobs", len(rows))
+	}
+
+	for _, row := range rows {
+		var jobs []GeocodeJob
+		if isPendingAddress(row.CheckInAddress) {
+			jobs = append(jobs, GeocodeJob{
+				AttendanceID: row.ID,
+				CompanyID:    row.CompanyID,
+				Latitude:     row.CheckInLat,
+				Longitude:    row.CheckInLong,
+			})
+		}
+		if row.CheckOutAddress != nil && isPendingAddress(*row.CheckOutAddress) && row.CheckOutLat != nil && row.CheckOutLong != nil {
+			jobs = append(jobs, GeocodeJob{
+				AttendanceID: row.ID,
+				CompanyID:    row.CompanyID,
+				Latitude:     *row.CheckOutLat,
+				Longitude:    *row.CheckOutLong,
+				IsCheckout:   true,
+			})
+		}
+
+		for _, job := range jobs {
+			select {
+			case w.queue <- job:
+			case <-w.quit:
+				return
+			}
+		}
+	}
+}
+
 func (w *geocodeWorker) processJob(job GeocodeJob) {
-	address := w.fetcher.GetAddressFromCoords(job.Latitude, job.Longitude)
+	ctx := context.WithValue(context.Background(), constants.CompanyIDContextKey, job.CompanyID)
+
+	address, err := w.fetcher.GetAddressFromCoords(ctx, job.Latitude, job.Longitude)
+	if err != nil {
+		logger.Warnf("Failed to geocode ID %d, keeping pending address: %v", job.AttendanceID, err)
+		return
+	}
 
 	if address == "" {
 		logger.Warnf("Empty address for ID %d, skipping update", job.AttendanceID)
@@ -96,3 +173,7 @@ func (w *geocodeWorker) processJob(job GeocodeJob) {
 	}
 
 }
+
+func isPendingAddress(address string) bool {
+	return strings.HasPrefix(address, pendingAddressPrefix)
+}
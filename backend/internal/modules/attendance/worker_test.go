@@ -0,0 +1,137 @@
+package attendance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupWorkerTestDB(t *testing.T) *testutil.TestDB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	// in-memory sqlite is per connection, keep workers on the same one
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	createAttendancesTable(t, db)
+
+	tdb := &testutil.TestDB{DB: db}
+	t.Cleanup(tdb.Close)
+	return tdb
+}
+
+// createAttendancesTable creates the table by hand, sqlite cannot migrate the enum columns.
+func createAttendancesTable(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	require.NoError(t, db.Exec(`CREATE TABLE IF NOT EXISTS attendances (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		employee_id INTEGER NOT NULL,
+		shift_id INTEGER NOT NULL DEFAULT 0,
+		company_id INTEGER NOT NULL,
+		date DATE NOT NULL,
+		check_in_time DATETIME NOT NULL,
+		check_in_lat REAL NOT NULL DEFAULT 0,
+		check_in_long REAL NOT NULL DEFAULT 0,
+		check_in_image_url TEXT NOT NULL DEFAULT '',
+		check_in_address TEXT NOT NULL,
+		check_in_image_hash TEXT NOT NULL DEFAULT '',
+		check_in_device_id TEXT NOT NULL DEFAULT '',
+		check_out_time DATETIME,
+		check_out_lat REAL,
+		check_out_long REAL,
+		check_out_image_url TEXT,
+		check_out_address TEXT,
+		check_out_image_hash TEXT,
+		check_out_device_id TEXT,
+		status TEXT DEFAULT 'ABSENT',
+		is_suspicious NUMERIC DEFAULT false,
+		notes TEXT,
+		late_duration_minute INTEGER DEFAULT 0,
+		risk_score INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'NONE',
+		reviewed_by INTEGER,
+		reviewed_at DATETIME,
+		review_notes TEXT,
+		leave_request_id INTEGER,
+		excused_from DATETIME,
+		excused_until DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`).Error)
+}
+
+func TestGeocodeWorker_RecoverPending(t *testing.T) {
+	tdb := setupWorkerTestDB(t)
+
+	outLat, outLong := -6.3, 106.9
+	pendingOut := pendingAddressPrefix + " (-6.300000, 106.900000)"
+	rows := []Attendance{
+		{ID: 1, EmployeeID: 1, CompanyID: 1, Date: time.Now(), CheckInTime: time.Now(), CheckInLat: -6.2, CheckInLong: 106.8, CheckInAddress: pendingAddressPrefix + " (-6.200000, 106.800000)"},
+		{ID: 2, EmployeeID: 2, CompanyID: 1, Date: time.Now(), CheckInTime: time.Now(), CheckInLat: -6.2, CheckInLong: 106.8, CheckInAddress: "Jl. Sudirman, Jakarta", CheckOutLat: &outLat, CheckOutLong: &outLong, CheckOutAddress: &pendingOut},
+		{ID: 3, EmployeeID: 3, CompanyID: 1, Date: time.Now(), CheckInTime: time.Now(), CheckInLat: -6.4, CheckInLong: 107.0, CheckInAddress: pendingAddressPrefix + " (-6.400000, 107.000000)"},
+	}
+	require.NoError(t, tdb.DB.Create(&rows).Error)
+
+	fetcher := new(mockLocationFetcher)
+	fetcher.On("GetAddressFromCoords", mock.Anything, -6.2, 106.8).Return("Jl. Sudirman, Jakarta", nil)
+	fetcher.On("GetAddressFromCoords", mock.Anything, -6.3, 106.9).Return("Jl. Thamrin, Jakarta", nil)
+	fetcher.On("GetAddressFromCoords", mock.Anything, -6.4, 107.0).Return("", errors.New("timeout"))
+
+	worker := NewGeocodeWorker(tdb.DB, fetcher, 1)
+	worker.Start(1)
+
+	assert.Eventually(t, func() bool {
+		var pending int64
+		tdb.DB.Model(&Attendance{}).Where("id IN ?", []uint{1, 2}).
+			Where("check_in_address LIKE ? OR check_out_address LIKE ?", pendingAddressPrefix+"%", pendingAddressPrefix+"%").
+			Count(&pending)
+		return pending == 0
+	}, 2*time.Second, 10*time.Millisecond)
+	worker.Stop()
+
+	var got []Attendance
+	require.NoError(t, tdb.DB.Order("id ASC").Find(&got).Error)
+
+	assert.Equal(t, "Jl. Sudirman, Jakarta", got[0].CheckInAddress)
+	assert.Equal(t, "Jl. Thamrin, Jakarta", *got[1].CheckOutAddress)
+	// failed lookups keep the pending marker so they are retried on the next start
+	assert.True(t, isPendingAddress(got[2].CheckInAddress))
+}
+
+func TestGeocodeWorker_ProcessJobWithTenant(t *testing.T) {
+	tdb := setupWorkerTestDB(t)
+
+	require.NoError(t, tdb.DB.Create(&Attendance{ID: 1, EmployeeID: 1, CompanyID: 5, Date: time.Now(), CheckInTime: time.Now(), CheckInAddress: "Jl. Sudirman, Jakarta"}).Error)
+
+	fetcher := new(mockLocationFetcher)
+	fetcher.On("GetAddressFromCoords", mock.MatchedBy(func(ctx context.Context) bool {
+		return utils.GetCompanyIDFromCtx(ctx) == 5
+	}), -6.2, 106.8).Return("Near Head Office (~12 m)", nil)
+
+	worker := NewGeocodeWorker(tdb.DB, fetcher, 1)
+	worker.Start(1)
+	worker.Enqueue(GeocodeJob{AttendanceID: 1, CompanyID: 5, Latitude: -6.2, Longitude: 106.8, IsCheckout: true})
+	worker.Stop()
+
+	var got Attendance
+	require.NoError(t, tdb.DB.First(&got, 1).Error)
+	require.NotNil(t, got.CheckOutAddress)
+	assert.Equal(t, "Near Head Office (~12 m)", *got.CheckOutAddress)
+	fetcher.AssertExpectations(t)
+}
@@ -0,0 +1,438 @@
+package audit
+
+import (
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/logger"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const snapshotKey = "audit:snapshot"
+
+// skippedTables are never audited: the audit trail itself and rows that hold
+// nothing an auditor needs but would flood the log.
+var skippedTables = map[string]bool{
+	"audit_logs":         true,
+	"notifications":      true,
+	"password_histories": true,
+	"mfa_recovery_codes": true,
+	"encryption_keys":    true,
+}
+
+// ignoredColumns change on every write or only mirror another column, they say nothing about the change itself.
+var ignoredColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"nik_bidx":   true,
+}
+
+// redactedColumns are secrets, an entry only shows that they changed.
+var redactedColumns = map[string]bool{
+	"password_hash": true,
+	"mfa_secret":    true,
+	"code_hash":     true,
+	"client_secret": true,
+	"base_salary":   true,
+}
+
+// partiallyMaskedColumns keep their last four characters so an auditor can tell accounts apart.
+var partiallyMaskedColumns = map[string]bool{
+	"bank_account_number": true,
+	"npwp":                true,
+	"nik":                 true,
+}
+
+type callbacks struct {
+	writer Writer
+}
+
+// RegisterCallbacks hooks the audit trail into every create, update and delete made
+// through db. Entries are handed to the writer once the change is committed.
+func RegisterCallbacks(db *gorm.DB, writer Writer) error {
+	c := &callbacks{writer}
+
+	if err := db.Callback().Create().After("gorm:commit_or_rollback_transaction").Register("audit:after_create", c.afterCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("audit:before_update", c.snapshot); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:commit_or_rollback_transaction").Register("audit:after_update", c.afterUpdate); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("audit:before_delete", c.snapshot); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:commit_or_rollback_transaction").Register("audit:after_delete", c.afterDelete)
+}
+
+func (c *callbacks) afterCreate(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	var entries []AuditLog
+	for _, row := range createdRows(db) {
+		if entry, ok := newEntry(db, constants.AuditActionCreate, nil, row); ok {
+			entries = append(entries, entry)
+		}
+	}
+	c.enqueue(db.Statement.Context, entries)
+}
+
+// snapshot loads the rows an update or delete is about to touch.
+func (c *callbacks) snapshot(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	query, ok := targetQuery(db)
+	if !ok {
+		return
+	}
+
+	rows, err := loadRows(db, query.Limit(constants.AuditMaxSnapshotRows))
+	if err != nil {
+		logger.Warnf("Audit snapshot of %s failed: %v", db.Statement.Table, err)
+		return
+	}
+	db.InstanceSet(snapshotKey, rows)
+}
+
+func (c *callbacks) afterUpdate(db *gorm.DB) {
+	before, ok := snapshotRows(db)
+	if !ok {
+		return
+	}
+
+	pk := db.Statement.Schema.PrioritizedPrimaryField.DBName
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[pk])
+	}
+
+	after, err := loadRows(db, modelQuery(db).Where(clause.IN{Column: clause.Column{Name: pk}, Values: ids}))
+	if err != nil {
+		logger.Warnf("Audit reload of %s failed: %v", db.Statement.Table, err)
+		return
+	}
+
+	afterByID := make(map[string]map[string]interface{}, len(after))
+	for _, row := range after {
+		afterByID[fmt.Sprint(row[pk])] = row
+	}
+
+	var entries []AuditLog
+	for _, row := range before {
+		current, found := afterByID[fmt.Sprint(row[pk])]
+		if !found {
+			continue
+		}
+		if entry, ok := newEntry(db, constants.AuditActionUpdate, row, current); ok {
+			entries = append(entries, entry)
+		}
+	}
+	c.enqueue(db.Statement.Context, entries)
+}
+
+func (c *callbacks) afterDelete(db *gorm.DB) {
+	before, ok := snapshotRows(db)
+	if !ok {
+		return
+	}
+
+	var entries []AuditLog
+	for _, row := range before {
+		if entry, ok := newEntry(db, constants.AuditActionDelete, row, nil); ok {
+			entries = append(entries, entry)
+		}
+	}
+	c.enqueue(db.Statement.Context, entries)
+}
+
+// enqueue hands entries to the writer once the surrounding transaction commits,
+// so a rolled back change leaves no trail.
+func (c *callbacks) enqueue(ctx context.Context, entries []AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+
+	infrastructure.AfterCommit(ctx, func() {
+		c.writer.Enqueue(entries...)
+	})
+}
+
+func audited(db *gorm.DB) bool {
+	stmt := db.Statement
+	return db.Error == nil && !db.DryRun && stmt.Schema != nil && stmt.Schema.PrioritizedPrimaryField != nil && !skippedTables[stmt.Table]
+}
+
+func snapshotRows(db *gorm.DB) ([]map[string]interface{}, bool) {
+	if !audited(db) {
+		return nil, false
+	}
+
+	value, ok := db.InstanceGet(snapshotKey)
+	if !ok {
+		return nil, false
+	}
+	rows, ok := value.([]map[string]interface{})
+	return rows, ok && len(rows) > 0
+}
+
+// modelQuery starts a query on the statement's table. The model resolves primary key
+// conditions and adds the soft delete scope.
+func modelQuery(db *gorm.DB) *gorm.DB {
+	stmt := db.Statement
+	query := db.Session(&gorm.Session{NewDB: true}).Model(reflect.New(stmt.Schema.ModelType).Interface()).Table(stmt.Table)
+	if stmt.Unscoped {
+		query = query.Unscoped()
+	}
+	return query
+}
+
+// targetQuery builds a query for the rows matched by the statement, from its
+// WHERE clause and the primary keys of the model it was given.
+func targetQuery(db *gorm.DB) (*gorm.DB, bool) {
+	stmt := db.Statement
+	query := modelQuery(db)
+	scoped := false
+
+	if where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+		query.Statement.AddClause(where)
+		scoped = true
+	}
+
+	if ids := primaryKeys(db); len(ids) > 0 {
+		query = query.Where(clause.IN{Column: clause.Column{Name: stmt.Schema.PrioritizedPrimaryField.DBName}, Values: ids})
+		scoped = true
+	}
+
+	// a statement without conditions is refused by gorm anyway
+	return query, scoped
+}
+
+func primaryKeys(db *gorm.DB) []interface{} {
+	stmt := db.Statement
+	pk := stmt.Schema.PrioritizedPrimaryField
+
+	var ids []interface{}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Struct:
+		if id, zero := pk.ValueOf(stmt.Context, stmt.ReflectValue); !zero {
+			ids = append(ids, id)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			if id, zero := pk.ValueOf(stmt.Context, reflect.Indirect(stmt.ReflectValue.Index(i))); !zero {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// createdRows reads the inserted values back from the model passed to Create.
+func createdRows(db *gorm.DB) []map[string]interface{} {
+	stmt := db.Statement
+
+	var values []reflect.Value
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Struct:
+		values = append(values, stmt.ReflectValue)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			values = append(values, reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	}
+	return rowsOf(db, values, true)
+}
+
+// loadRows runs query into models rather than maps, so serialized columns such as
+// encrypted ones are decoded, and flattens them into rows.
+func loadRows(db *gorm.DB, query *gorm.DB) ([]map[string]interface{}, error) {
+	models := reflect.New(reflect.SliceOf(db.Statement.Schema.ModelType))
+	if err := query.Find(models.Interface()).Error; err != nil {
+		return nil, err
+	}
+
+	values := make([]reflect.Value, models.Elem().Len())
+	for i := range values {
+		values[i] = models.Elem().Index(i)
+	}
+	return rowsOf(db, values, false), nil
+}
+
+func rowsOf(db *gorm.DB, values []reflect.Value, skipZero bool) []map[string]interface{} {
+	stmt := db.Statement
+
+	rows := make([]map[string]interface{}, 0, len(values))
+	for _, value := range values {
+		if value.Kind() != reflect.Struct {
+			continue
+		}
+		row := make(map[string]interface{})
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || !field.Readable {
+				continue
+			}
+			// the serializer would hand out a fresh ciphertext, read the plain field instead
+			if infrastructure.IsEncryptedField(field) {
+				if v := field.ReflectValueOf(stmt.Context, value); !skipZero || !v.IsZero() {
+					row[field.DBName] = v.Interface()
+				}
+				continue
+			}
+			if v, zero := field.ValueOf(stmt.Context, value); !skipZero || !zero {
+				row[field.DBName] = v
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func newEntry(db *gorm.DB, action constants.AuditAction, before, after map[string]interface{}) (AuditLog, bool) {
+	changes := diff(db.Statement.Schema, before, after)
+	if len(changes) == 0 {
+		return AuditLog{}, false
+	}
+
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		logger.Warnf("Audit entry of %s could not be encoded: %v", db.Statement.Table, err)
+		return AuditLog{}, false
+	}
+
+	row := after
+	if row == nil {
+		row = before
+	}
+
+	ctx := db.Statement.Context
+	companyID := toUint(row["company_id"])
+	if companyID == 0 {
+		companyID = utils.GetCompanyIDFromCtx(ctx)
+	}
+
+	return AuditLog{
+		CompanyID:  companyID,
+		UserID:     utils.GetUserIDFromCtx(ctx),
+		EntityType: db.Statement.Table,
+		EntityID:   toUint(row[db.Statement.Schema.PrioritizedPrimaryField.DBName]),
+		Action:     action,
+		Changes:    string(raw),
+		RequestID:  utils.GetRequestIDFromCtx(ctx),
+		IPAddress:  utils.GetIPAddressFromCtx(ctx),
+	}, true
+}
+
+// diff keeps the columns whose value differs, a nil side means the row did not exist.
+func diff(sch *schema.Schema, before, after map[string]interface{}) map[string]FieldChange {
+	columns := make(map[string]bool)
+	for column := range before {
+		columns[column] = true
+	}
+	for column := range after {
+		columns[column] = true
+	}
+
+	changes := make(map[string]FieldChange)
+	for column := range columns {
+		if ignoredColumns[column] || sch.LookUpField(column) == nil {
+			continue
+		}
+
+		var oldValue, newValue interface{}
+		if before != nil {
+			oldValue = normalize(before[column])
+		}
+		if after != nil {
+			newValue = normalize(after[column])
+		}
+		if before != nil && after != nil && fmt.Sprint(oldValue) == fmt.Sprint(newValue) {
+			continue
+		}
+
+		changes[column] = FieldChange{
+			Before: mask(column, oldValue),
+			After:  mask(column, newValue),
+		}
+	}
+	return changes
+}
+
+// normalize turns driver and model values into plain JSON friendly ones.
+func normalize(value interface{}) interface{} {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return nil
+		}
+		value = v
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface())
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return value
+}
+
+func mask(column string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	if redactedColumns[column] {
+		return constants.AuditMaskedValue
+	}
+
+	if partiallyMaskedColumns[column] {
+		return utils.MaskString(fmt.Sprint(value), 4)
+	}
+
+	return value
+}
+
+func toUint(value interface{}) uint {
+	switch v := normalize(value).(type) {
+	case uint:
+		return v
+	case uint32:
+		return uint(v)
+	case uint64:
+		return uint(v)
+	case int:
+		return uint(v)
+	case int32:
+		return uint(v)
+	case int64:
+		return uint(v)
+	case string:
+		var id uint
+		fmt.Sscan(v, &id)
+		return id
+	}
+	return 0
+}
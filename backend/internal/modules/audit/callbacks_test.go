@@ -0,0 +1,196 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type auditedEmployee struct {
+	ID                uint `gorm:"primaryKey"`
+	CompanyID         uint
+	FullName          string
+	BaseSalary        float64 `gorm:"serializer:encrypted"`
+	BankAccountNumber string  `gorm:"serializer:encrypted"`
+	PasswordHash      string
+	DeletedAt         gorm.DeletedAt
+}
+
+func (auditedEmployee) TableName() string { return "employees" }
+
+type auditedNotification struct {
+	ID        uint `gorm:"primaryKey"`
+	CompanyID uint
+	Title     string
+}
+
+func (auditedNotification) TableName() string { return "notifications" }
+
+func setupCallbacks(t *testing.T) (*gorm.DB, *memoryWriter) {
+	t.Helper()
+	tdb := testutil.NewTestDB(&auditedEmployee{}, &auditedNotification{})
+	t.Cleanup(tdb.Close)
+
+	writer := &memoryWriter{}
+	require.NoError(t, RegisterCallbacks(tdb.DB, writer))
+	return tdb.DB, writer
+}
+
+func requestCtx() context.Context {
+	ctx := testutil.CtxWithTenant(1, 7, false)
+	ctx = context.WithValue(ctx, constants.RequestIDContextKey, "req-1")
+	return context.WithValue(ctx, constants.IPAddressContextKey, "10.0.0.1")
+}
+
+func changesOf(t *testing.T, entry AuditLog) map[string]FieldChange {
+	t.Helper()
+	var changes map[string]FieldChange
+	require.NoError(t, json.Unmarshal([]byte(entry.Changes), &changes))
+	return changes
+}
+
+func TestCallbacks_Create(t *testing.T) {
+	db, writer := setupCallbacks(t)
+
+	emp := &auditedEmployee{CompanyID: 1, FullName: "Jane", BaseSalary: 5000000, BankAccountNumber: "1234567890", PasswordHash: "secret"}
+	require.NoError(t, db.WithContext(requestCtx()).Create(emp).Error)
+
+	entries := writer.Entries()
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, constants.AuditActionCreate, entry.Action)
+	assert.Equal(t, "employees", entry.EntityType)
+	assert.Equal(t, emp.ID, entry.EntityID)
+	assert.Equal(t, uint(1), entry.CompanyID)
+	assert.Equal(t, uint(7), entry.UserID)
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.Equal(t, "10.0.0.1", entry.IPAddress)
+
+	changes := changesOf(t, entry)
+	assert.Nil(t, changes["full_name"].Before)
+	assert.Equal(t, "Jane", changes["full_name"].After)
+	assert.Equal(t, constants.AuditMaskedValue, changes["base_salary"].After)
+	assert.Equal(t, "******7890", changes["bank_account_number"].After)
+	assert.Equal(t, constants.AuditMaskedValue, changes["password_hash"].After)
+}
+
+func TestCallbacks_Update(t *testing.T) {
+	db, writer := setupCallbacks(t)
+	emp := &auditedEmployee{CompanyID: 1, FullName: "Jane", BaseSalary: 5000000, BankAccountNumber: "1234567890"}
+	require.NoError(t, db.Create(emp).Error)
+	other := &auditedEmployee{CompanyID: 1, FullName: "John", BaseSalary: 4000000}
+	require.NoError(t, db.Create(other).Error)
+	writer.entries = nil
+
+	t.Run("records only changed fields", func(t *testing.T) {
+		err := db.WithContext(requestCtx()).Model(&auditedEmployee{}).Where("id = ?", emp.ID).
+			Updates(&auditedEmployee{BaseSalary: 6000000, BankAccountNumber: "9999000011", FullName: "Jane"}).Error
+		require.NoError(t, err)
+
+		var stored string
+		require.NoError(t, db.Table("employees").Select("bank_account_number").Where("id = ?", emp.ID).Scan(&stored).Error)
+		assert.True(t, infrastructure.IsCiphertext(stored))
+
+		entries := writer.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, constants.AuditActionUpdate, entries[0].Action)
+		assert.Equal(t, emp.ID, entries[0].EntityID)
+
+		changes := changesOf(t, entries[0])
+		assert.Len(t, changes, 2)
+		assert.Equal(t, FieldChange{Before: constants.AuditMaskedValue, After: constants.AuditMaskedValue}, changes["base_salary"])
+		assert.Equal(t, FieldChange{Before: "******7890", After: "******0011"}, changes["bank_account_number"])
+	})
+
+	t.Run("save by primary key", func(t *testing.T) {
+		writer.entries = nil
+		other.FullName = "Johnny"
+
+		require.NoError(t, db.WithContext(requestCtx()).Save(other).Error)
+
+		entries := writer.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, other.ID, entries[0].EntityID)
+		assert.Equal(t, FieldChange{Before: "John", After: "Johnny"}, changesOf(t, entries[0])["full_name"])
+	})
+
+	t.Run("no entry without a change", func(t *testing.T) {
+		writer.entries = nil
+
+		require.NoError(t, db.Model(&auditedEmployee{}).Where("id = ?", other.ID).Update("full_name", "Johnny").Error)
+
+		assert.Empty(t, writer.Entries())
+	})
+}
+
+func TestCallbacks_Delete(t *testing.T) {
+	db, writer := setupCallbacks(t)
+	emp := &auditedEmployee{CompanyID: 1, FullName: "Jane"}
+	require.NoError(t, db.Create(emp).Error)
+	writer.entries = nil
+
+	require.NoError(t, db.WithContext(requestCtx()).Delete(&auditedEmployee{}, emp.ID).Error)
+
+	entries := writer.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, constants.AuditActionDelete, entries[0].Action)
+	assert.Equal(t, emp.ID, entries[0].EntityID)
+	assert.Equal(t, FieldChange{Before: "Jane", After: nil}, changesOf(t, entries[0])["full_name"])
+
+	// already soft deleted rows are not touched again
+	writer.entries = nil
+	require.NoError(t, db.Delete(&auditedEmployee{}, emp.ID).Error)
+	assert.Empty(t, writer.Entries())
+}
+
+func TestCallbacks_Transaction(t *testing.T) {
+	db, writer := setupCallbacks(t)
+	tm := testutil.NewTestTransactionManager(db)
+
+	t.Run("written once committed", func(t *testing.T) {
+		err := tm.RunInTransaction(requestCtx(), func(ctx context.Context) error {
+			tx := ctx.Value(constants.TxContextKey).(*gorm.DB)
+			if err := tx.Create(&auditedEmployee{CompanyID: 1, FullName: "Jane"}).Error; err != nil {
+				return err
+			}
+			assert.Empty(t, writer.Entries())
+			return nil
+		})
+
+		require.NoError(t, err)
+		entries := writer.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, uint(7), entries[0].UserID)
+	})
+
+	t.Run("dropped on rollback", func(t *testing.T) {
+		writer.entries = nil
+
+		err := tm.RunInTransaction(requestCtx(), func(ctx context.Context) error {
+			tx := ctx.Value(constants.TxContextKey).(*gorm.DB)
+			if err := tx.Create(&auditedEmployee{CompanyID: 1, FullName: "John"}).Error; err != nil {
+				return err
+			}
+			return assert.AnError
+		})
+
+		assert.Error(t, err)
+		assert.Empty(t, writer.Entries())
+	})
+}
+
+func TestCallbacks_SkippedTable(t *testing.T) {
+	db, writer := setupCallbacks(t)
+
+	require.NoError(t, db.Create(&auditedNotification{CompanyID: 1, Title: "Hello"}).Error)
+
+	assert.Empty(t, writer.Entries())
+}
@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"basekarya-backend/pkg/constants"
+	"time"
+)
+
+type AuditLogFilter struct {
+	EntityType string
+	EntityID   uint
+	UserID     uint
+	Action     string
+	RequestID  string
+	StartDate  string
+	EndDate    string
+	Page       int
+	Limit      int
+}
+
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type AuditLogResponse struct {
+	ID            uint                   `json:"id"`
+	CompanyID     uint                   `json:"company_id"`
+	UserID        uint                   `json:"user_id"`
+	ActorUsername string                 `json:"actor_username"`
+	EntityType    string                 `json:"entity_type"`
+	EntityID      uint                   `json:"entity_id"`
+	Action        constants.AuditAction  `json:"action"`
+	Changes       map[string]FieldChange `json:"changes"`
+	RequestID     string                 `json:"request_id"`
+	IPAddress     string                 `json:"ip_address"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
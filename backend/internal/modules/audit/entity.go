@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"basekarya-backend/pkg/constants"
+	"time"
+)
+
+// AuditLog is one audited row change. Changes holds the changed columns as
+// {"column": {"before": ..., "after": ...}} with sensitive values masked.
+type AuditLog struct {
+	ID         uint                  `gorm:"primaryKey" json:"id"`
+	CompanyID  uint                  `gorm:"index;not null" json:"company_id"`
+	UserID     uint                  `gorm:"index" json:"user_id"`
+	EntityType string                `gorm:"type:varchar(100);not null" json:"entity_type"`
+	EntityID   uint                  `json:"entity_id"`
+	Action     constants.AuditAction `gorm:"type:varchar(10);not null" json:"action"`
+	Changes    string                `gorm:"type:json" json:"changes"`
+	RequestID  string                `gorm:"type:varchar(64)" json:"request_id"`
+	IPAddress  string                `gorm:"type:varchar(45)" json:"ip_address"`
+	CreatedAt  time.Time             `json:"created_at"`
+
+	ActorUsername string `gorm:"->;column:actor_username" json:"actor_username"`
+}
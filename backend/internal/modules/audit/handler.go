@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"basekarya-backend/pkg/logger"
+	"basekarya-backend/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service}
+}
+
+func (h *Handler) GetAll(ctx echo.Context) error {
+	page, _ := strconv.Atoi(ctx.QueryParam("page"))
+	limit, _ := strconv.Atoi(ctx.QueryParam("limit"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	filter := filterFromQuery(ctx)
+	filter.Page = page
+	filter.Limit = limit
+
+	data, meta, err := h.service.GetList(ctx.Request().Context(), &filter)
+	if err != nil {
+		logger.Errorw("get audit logs failed: ", err)
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Audit Log List Success", data, nil, meta)
+}
+
+func (h *Handler) Export(ctx echo.Context) error {
+	filter := filterFromQuery(ctx)
+
+	excelFile, err := h.service.Export(ctx.Request().Context(), &filter)
+	if err != nil {
+		logger.Errorw("export audit logs failed: ", err)
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	ctx.Response().Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Response().Header().Set("Content-Disposition", "attachment; filename=audit_logs.xlsx")
+	return ctx.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelFile)
+}
+
+func filterFromQuery(ctx echo.Context) AuditLogFilter {
+	entityID, _ := strconv.Atoi(ctx.QueryParam("entity_id"))
+	userID, _ := strconv.Atoi(ctx.QueryParam("user_id"))
+
+	return AuditLogFilter{
+		EntityType: ctx.QueryParam("entity_type"),
+		EntityID:   uint(entityID),
+		UserID:     uint(userID),
+		Action:     ctx.QueryParam("action"),
+		RequestID:  ctx.QueryParam("request_id"),
+		StartDate:  ctx.QueryParam("start_date"),
+		EndDate:    ctx.QueryParam("end_date"),
+	}
+}
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_GetAll(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryParams string
+		setupMocks  func(*mockService)
+		wantStatus  int
+	}{
+		{
+			name:        "success",
+			queryParams: "?entity_type=employees&entity_id=3&action=UPDATE",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetList", mock.Anything, &AuditLogFilter{EntityType: "employees", EntityID: 3, Action: "UPDATE", Page: 1, Limit: 10}).
+					Return([]AuditLogResponse{}, (*response.Meta)(nil), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "service error",
+			queryParams: "?page=1&limit=10",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetList", mock.Anything, mock.AnythingOfType("*audit.AuditLogFilter")).
+					Return([]AuditLogResponse(nil), (*response.Meta)(nil), errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/audit-logs"+tt.queryParams, nil)
+			at.WithAuthContext(&infrastructure.MyClaims{UserID: 1, CompanyID: 1})
+
+			rec, err := at.Execute(handler.GetAll)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Export(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			setupMocks: func(svc *mockService) {
+				svc.On("Export", mock.Anything, mock.AnythingOfType("*audit.AuditLogFilter")).Return([]byte("fake-excel"), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "service error",
+			setupMocks: func(svc *mockService) {
+				svc.On("Export", mock.Anything, mock.AnythingOfType("*audit.AuditLogFilter")).Return(nil, errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/audit-logs/export", nil)
+			at.WithAuthContext(&infrastructure.MyClaims{UserID: 1, CompanyID: 1})
+
+			rec, err := at.Execute(handler.Export)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"basekarya-backend/pkg/response"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/xuri/excelize/v2"
+)
+
+// memoryWriter keeps enqueued entries in memory.
+type memoryWriter struct {
+	mu      sync.Mutex
+	entries []AuditLog
+}
+
+func (w *memoryWriter) Start() {}
+
+func (w *memoryWriter) Stop() {}
+
+func (w *memoryWriter) Enqueue(entries ...AuditLog) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries = append(w.entries, entries...)
+}
+
+func (w *memoryWriter) Entries() []AuditLog {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]AuditLog(nil), w.entries...)
+}
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) FindAll(ctx context.Context, filter *AuditLogFilter) ([]AuditLog, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]AuditLog), args.Get(1).(int64), args.Error(2)
+}
+
+type mockService struct{ mock.Mock }
+
+func (m *mockService) GetList(ctx context.Context, filter *AuditLogFilter) ([]AuditLogResponse, *response.Meta, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]AuditLogResponse), args.Get(1).(*response.Meta), args.Error(2)
+}
+
+func (m *mockService) Export(ctx context.Context, filter *AuditLogFilter) ([]byte, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+type mockExcel struct{ mock.Mock }
+
+func (m *mockExcel) GenerateSimpleExcel(sheetName string, headers []string, rows [][]interface{}) ([]byte, error) {
+	args := m.Called(sheetName, headers, rows)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *mockExcel) NewFile() *excelize.File {
+	return excelize.NewFile()
+}
+
+func (m *mockExcel) WriteToBuffer(file *excelize.File) ([]byte, error) {
+	args := m.Called(file)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"basekarya-backend/pkg/utils"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	FindAll(ctx context.Context, filter *AuditLogFilter) ([]AuditLog, int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) FindAll(ctx context.Context, filter *AuditLogFilter) ([]AuditLog, int64, error) {
+	var logs []AuditLog
+	var total int64
+	query := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&AuditLog{}))
+
+	if filter.EntityType != "" {
+		query = query.Where("audit_logs.entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("audit_logs.entity_id = ?", filter.EntityID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("audit_logs.user_id = ?", filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("audit_logs.action = ?", filter.Action)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("audit_logs.request_id = ?", filter.RequestID)
+	}
+	if filter.StartDate != "" {
+		query = query.Where("DATE(audit_logs.created_at) >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("DATE(audit_logs.created_at) <= ?", filter.EndDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Select("audit_logs.*, users.username AS actor_username").
+		Joins("LEFT JOIN users ON users.id = audit_logs.user_id").
+		Order("audit_logs.created_at DESC, audit_logs.id DESC").
+		Offset(offset).Limit(filter.Limit).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/rbac"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAuditTestDB(t *testing.T) *testutil.TestDB {
+	t.Helper()
+	tdb := testutil.NewTestDB(&rbac.Role{}, &user.User{}, &AuditLog{})
+	t.Cleanup(tdb.Close)
+
+	require.NoError(t, tdb.DB.Create(&rbac.Role{ID: 1, Name: "Admin", CompanyID: 1}).Error)
+	require.NoError(t, tdb.DB.Create(&user.User{ID: 7, Username: "hr.admin", RoleID: 1, CompanyID: 1}).Error)
+
+	logs := []AuditLog{
+		{CompanyID: 1, UserID: 7, EntityType: "employees", EntityID: 3, Action: constants.AuditActionUpdate, Changes: `{"base_salary":{"before":5000000,"after":6000000}}`, RequestID: "req-1", CreatedAt: time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)},
+		{CompanyID: 1, UserID: 7, EntityType: "role_permissions", EntityID: 9, Action: constants.AuditActionDelete, Changes: `{}`, RequestID: "req-2", CreatedAt: time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC)},
+		{CompanyID: 1, EntityType: "payrolls", EntityID: 4, Action: constants.AuditActionUpdate, Changes: `{}`, CreatedAt: time.Date(2026, 10, 3, 9, 0, 0, 0, time.UTC)},
+		{CompanyID: 2, UserID: 8, EntityType: "employees", EntityID: 5, Action: constants.AuditActionCreate, Changes: `{}`, CreatedAt: time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)},
+	}
+	require.NoError(t, tdb.DB.Create(&logs).Error)
+	return tdb
+}
+
+func TestRepo_FindAll(t *testing.T) {
+	tdb := setupAuditTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 7, false)
+
+	tests := []struct {
+		name      string
+		filter    AuditLogFilter
+		wantTotal int64
+	}{
+		{name: "tenant only", filter: AuditLogFilter{}, wantTotal: 3},
+		{name: "by entity", filter: AuditLogFilter{EntityType: "employees", EntityID: 3}, wantTotal: 1},
+		{name: "by actor", filter: AuditLogFilter{UserID: 7}, wantTotal: 2},
+		{name: "by action", filter: AuditLogFilter{Action: "DELETE"}, wantTotal: 1},
+		{name: "by request", filter: AuditLogFilter{RequestID: "req-1"}, wantTotal: 1},
+		{name: "by date range", filter: AuditLogFilter{StartDate: "2026-10-02", EndDate: "2026-10-03"}, wantTotal: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Page = 1
+			tt.filter.Limit = 10
+
+			logs, total, err := repo.FindAll(ctx, &tt.filter)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+			assert.Len(t, logs, int(tt.wantTotal))
+		})
+	}
+
+	t.Run("newest first with actor", func(t *testing.T) {
+		logs, _, err := repo.FindAll(ctx, &AuditLogFilter{Page: 1, Limit: 10})
+
+		require.NoError(t, err)
+		require.Len(t, logs, 3)
+		assert.Equal(t, "payrolls", logs[0].EntityType)
+		assert.Empty(t, logs[0].ActorUsername)
+		assert.Equal(t, "hr.admin", logs[2].ActorUsername)
+	})
+
+	t.Run("platform admin sees every company", func(t *testing.T) {
+		_, total, err := repo.FindAll(testutil.CtxWithTenant(0, 1, true), &AuditLogFilter{Page: 1, Limit: 10})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+	})
+}
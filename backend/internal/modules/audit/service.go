@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/pkg/response"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Service interface {
+	GetList(ctx context.Context, filter *AuditLogFilter) ([]AuditLogResponse, *response.Meta, error)
+	Export(ctx context.Context, filter *AuditLogFilter) ([]byte, error)
+}
+
+type service struct {
+	repo  Repository
+	excel infrastructure.ExcelProvider
+}
+
+func NewService(repo Repository, excel infrastructure.ExcelProvider) Service {
+	return &service{repo, excel}
+}
+
+func (s *service) GetList(ctx context.Context, filter *AuditLogFilter) ([]AuditLogResponse, *response.Meta, error) {
+	logs, total, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := make([]AuditLogResponse, 0, len(logs))
+	for _, log := range logs {
+		data = append(data, toResponse(log))
+	}
+
+	return data, response.NewMetaOffset(filter.Page, filter.Limit, total), nil
+}
+
+func (s *service) Export(ctx context.Context, filter *AuditLogFilter) ([]byte, error) {
+	filter.Page = 1
+	filter.Limit = 999999
+	logs, _, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := []string{
+		"Waktu", "Pengguna", "Entitas", "ID Entitas", "Aksi", "Perubahan", "Request ID", "IP Address",
+	}
+
+	var rows [][]interface{}
+	for _, log := range logs {
+		actor := log.ActorUsername
+		if actor == "" {
+			actor = "system"
+		}
+
+		rows = append(rows, []interface{}{
+			log.CreatedAt.Format("2006-01-02 15:04:05"),
+			actor,
+			log.EntityType,
+			log.EntityID,
+			log.Action,
+			describeChanges(toResponse(log).Changes),
+			log.RequestID,
+			log.IPAddress,
+		})
+	}
+
+	return s.excel.GenerateSimpleExcel("Audit Logs", headers, rows)
+}
+
+func toResponse(log AuditLog) AuditLogResponse {
+	changes := make(map[string]FieldChange)
+	_ = json.Unmarshal([]byte(log.Changes), &changes)
+
+	return AuditLogResponse{
+		ID:            log.ID,
+		CompanyID:     log.CompanyID,
+		UserID:        log.UserID,
+		ActorUsername: log.ActorUsername,
+		EntityType:    log.EntityType,
+		EntityID:      log.EntityID,
+		Action:        log.Action,
+		Changes:       changes,
+		RequestID:     log.RequestID,
+		IPAddress:     log.IPAddress,
+		CreatedAt:     log.CreatedAt,
+	}
+}
+
+// describeChanges renders changes one column per line, e.g. "position: Staff -> Lead".
+func describeChanges(changes map[string]FieldChange) string {
+	columns := make([]string, 0, len(changes))
+	for column := range changes {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	lines := make([]string, 0, len(columns))
+	for _, column := range columns {
+		change := changes[column]
+		lines = append(lines, fmt.Sprintf("%s: %s -> %s", column, describeValue(change.Before), describeValue(change.After)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
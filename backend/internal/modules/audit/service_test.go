@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_GetList(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	repo := new(mockRepo)
+	repo.On("FindAll", mock.Anything, mock.AnythingOfType("*audit.AuditLogFilter")).Return([]AuditLog{
+		{ID: 1, EntityType: "employees", EntityID: 3, Action: constants.AuditActionUpdate, Changes: `{"base_salary":{"before":5000000,"after":6000000}}`},
+	}, int64(1), nil)
+	svc := NewService(repo, new(mockExcel))
+
+	data, meta, err := svc.GetList(ctx, &AuditLogFilter{Page: 1, Limit: 10})
+
+	require.NoError(t, err)
+	require.Len(t, data, 1)
+	assert.Equal(t, FieldChange{Before: float64(5000000), After: float64(6000000)}, data[0].Changes["base_salary"])
+	assert.Equal(t, int64(1), meta.TotalData)
+}
+
+func TestService_Export(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("success", func(t *testing.T) {
+		repo := new(mockRepo)
+		excel := new(mockExcel)
+		repo.On("FindAll", mock.Anything, mock.AnythingOfType("*audit.AuditLogFilter")).Return([]AuditLog{
+			{
+				EntityType: "employees", EntityID: 3, Action: constants.AuditActionUpdate,
+				Changes:   `{"full_name":{"before":"Jane","after":"Jane Doe"},"base_salary":{"before":5000000,"after":6000000}}`,
+				CreatedAt: time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC),
+			},
+		}, int64(1), nil)
+		excel.On("GenerateSimpleExcel", "Audit Logs", mock.Anything, mock.MatchedBy(func(rows [][]interface{}) bool {
+			return len(rows) == 1 &&
+				rows[0][1] == "system" &&
+				rows[0][5] == "base_salary: 5000000 -> 6000000\nfull_name: Jane -> Jane Doe"
+		})).Return([]byte("fake-excel"), nil)
+		svc := NewService(repo, excel)
+
+		data, err := svc.Export(ctx, &AuditLogFilter{})
+
+		require.NoError(t, err)
+		assert.Equal(t, []byte("fake-excel"), data)
+		excel.AssertExpectations(t)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repo := new(mockRepo)
+		repo.On("FindAll", mock.Anything, mock.AnythingOfType("*audit.AuditLogFilter")).Return([]AuditLog(nil), int64(0), errors.New("db error"))
+		svc := NewService(repo, new(mockExcel))
+
+		data, err := svc.Export(ctx, &AuditLogFilter{})
+
+		require.Error(t, err)
+		assert.Nil(t, data)
+	})
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/logger"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Writer persists audit entries off the request path, in batches.
+type Writer interface {
+	Start()
+	Enqueue(entries ...AuditLog)
+	Stop()
+}
+
+type writer struct {
+	db      *gorm.DB
+	queue   chan AuditLog
+	wg      *sync.WaitGroup
+	mu      sync.RWMutex
+	stopped bool
+}
+
+func NewWriter(db *gorm.DB, bufferSize int) Writer {
+	return &writer{
+		db:    db,
+		queue: make(chan AuditLog, bufferSize),
+		wg:    &sync.WaitGroup{},
+	}
+}
+
+func (w *writer) Start() {
+	w.wg.Add(1)
+	go w.run()
+}
+
+func (w *writer) Enqueue(entries ...AuditLog) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.stopped {
+		logger.Warn("Audit Writer is stopped, dropping entries:", len(entries))
+		return
+	}
+
+	for _, entry := range entries {
+		select {
+		case w.queue <- entry:
+		default:
+			// never block the request that made the change, but leave a trace of what was lost
+			logger.Errorw("Audit queue is full, dropping entry", "entity_type", entry.EntityType, "entity_id", entry.EntityID, "action", entry.Action, "request_id", entry.RequestID)
+		}
+	}
+}
+
+// Stop flushes what is still queued, call it before the database is closed.
+func (w *writer) Stop() {
+	logger.Info("Stopping Audit Writer...")
+	w.mu.Lock()
+	w.stopped = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	logger.Info("Audit Writer stopped.")
+}
+
+func (w *writer) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(constants.AuditFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]AuditLog, 0, constants.AuditBatchSize)
+	for {
+		select {
+		case entry, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= constants.AuditBatchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			w.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (w *writer) flush(batch []AuditLog) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := w.db.Create(&batch).Error; err != nil {
+		logger.Errorw("Failed to write audit entries", "error", err, "count", len(batch))
+	}
+}
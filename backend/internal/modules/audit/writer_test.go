@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"testing"
+
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_FlushesOnStop(t *testing.T) {
+	tdb := testutil.NewTestDB(&AuditLog{})
+	defer tdb.Close()
+
+	w := NewWriter(tdb.DB, 10)
+	w.Start()
+	for i := 0; i < 3; i++ {
+		w.Enqueue(AuditLog{CompanyID: 1, EntityType: "employees", EntityID: uint(i + 1), Action: constants.AuditActionUpdate, Changes: "{}"})
+	}
+	w.Stop()
+
+	var count int64
+	require.NoError(t, tdb.DB.Model(&AuditLog{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+
+	// entries after stop are dropped instead of panicking on the closed queue
+	w.Enqueue(AuditLog{CompanyID: 1, EntityType: "employees", Action: constants.AuditActionUpdate, Changes: "{}"})
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/internal/modules/company"
 	"basekarya-backend/internal/modules/rbac"
 	"basekarya-backend/internal/modules/user"
@@ -14,20 +15,40 @@ type Hasher interface {
 }
 
 type TokenProvider interface {
-	GenerateToken(userID uint, companyID uint, isPlatformAdmin bool, role string, employeeID *uint, permissions []string) (string, error)
+	GenerateToken(sessionID string, userID uint, companyID uint, isPlatformAdmin bool, role string, roleID uint, employeeID *uint, permissionVersion int64) (string, error)
+	AccessTTL() time.Duration
+}
+
+type SessionManager interface {
+	Create(ctx context.Context, userID, companyID uint, userAgent, ipAddress string) (*infrastructure.Session, string, error)
+	Rotate(ctx context.Context, refreshToken string) (*infrastructure.Session, string, error)
+	ListByUser(ctx context.Context, userID uint) ([]infrastructure.Session, error)
+	Revoke(ctx context.Context, userID uint, sessionID string) error
+	RevokeUser(ctx context.Context, userID uint) error
 }
 
 type UserProvider interface {
 	FindByUsername(ctx context.Context, username string) (*user.User, error)
+	FindByID(ctx context.Context, id uint) (*user.User, error)
 	FindEmployeeByEmail(ctx context.Context, email string) (*user.Employee, error)
-	UpdatePasswordByEmail(ctx context.Context, email string, password string) error
 	CreateUser(ctx context.Context, user *user.User) error
+	UpdateMFA(ctx context.Context, userID, companyID uint, secret string, recoveryHashes []string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID, companyID uint, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uint) (int64, error)
+}
+
+type PasswordUpdater interface {
+	UpdatePassword(ctx context.Context, userID uint, password string) error
 }
 
 type CacheProvider interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value any, expiration time.Duration) error
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error)
 	Del(ctx context.Context, key string) error
+	Incr(ctx context.Context, key string, expiration time.Duration) (int64, error)
+	Decr(ctx context.Context, key string) error
 	FlushDB(ctx context.Context) error
 }
 
@@ -38,6 +59,8 @@ type EmailProvider interface {
 type CompanyProvider interface {
 	CreateCompany(ctx context.Context, c *company.Company) error
 	FindPlanIDBySlug(ctx context.Context, slug string) (uint, error)
+	FindMFAPermissions(ctx context.Context, companyID uint) ([]string, error)
+	ReplaceMFAPermissions(ctx context.Context, companyID uint, names []string) error
 }
 
 type RoleProvider interface {
@@ -52,6 +75,15 @@ type MasterProvider interface {
 	SeedDefaults(ctx context.Context, companyID uint) error
 }
 
+type ReimbursementSeeder interface {
+	SeedDefaultCategories(ctx context.Context, companyID uint) error
+}
+
 type MasterSeeder interface {
 	SeedDefaults(ctx context.Context, companyID uint) error
 }
+
+// SSOPolicy tells whether a company lets its users in only through its identity provider.
+type SSOPolicy interface {
+	IsSSOEnforced(ctx context.Context, companyID uint) (bool, error)
+}
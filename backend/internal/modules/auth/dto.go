@@ -1,13 +1,92 @@
 package auth
 
+import (
+	"time"
+
+	"basekarya-backend/pkg/constants"
+)
+
 type LoginRequest struct {
-	Username string `json:"username" validate:"required,min=3,max=50"`
-	Password string `json:"password" validate:"required,min=1,max=72"`
+	Username  string `json:"username" validate:"required,min=3,max=50"`
+	Password  string `json:"password" validate:"required,min=1,max=72"`
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
 }
 
 type LoginResponse struct {
 	Token              string `json:"token"`
+	RefreshToken       string `json:"refresh_token"`
+	ExpiresIn          int    `json:"expires_in"`
 	MustChangePassword bool   `json:"must_change_password"`
+
+	// set instead of the tokens when the login needs a second step, the mfa token carries the login to it
+	MFARequired      bool   `json:"mfa_required,omitempty"`
+	MFASetupRequired bool   `json:"mfa_setup_required,omitempty"`
+	MFAToken         string `json:"mfa_token,omitempty"`
+	// shown once when MFA is enrolled during login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// PermissionsResponse is what the signed in user may do right now, resolved from their role on this request
+type PermissionsResponse struct {
+	Permissions     []string                       `json:"permissions"`
+	DataScopes      map[string]constants.DataScope `json:"data_scopes"`
+	IsPlatformAdmin bool                           `json:"is_platform_admin"`
+	CompanyID       uint                           `json:"company_id"`
+}
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	// an authenticator code or one of the recovery codes
+	Code      string `json:"code" validate:"required,min=6,max=20"`
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+type MFAEnrolmentRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+type ConfirmMFAEnrolmentRequest struct {
+	MFAToken  string `json:"mfa_token" validate:"required"`
+	Code      string `json:"code" validate:"required,len=6"`
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,min=6,max=20"`
+}
+
+type MFASetupResponse struct {
+	Secret string `json:"secret"`
+	// otpauth:// URI, rendered as a QR code for authenticator apps
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFAStatusResponse struct {
+	Enabled bool `json:"enabled"`
+	// the company policy forces MFA on the user's role, it cannot be disabled
+	Required          bool  `json:"required"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 type SendOrResendOTPRequest struct {
@@ -15,17 +94,20 @@ type SendOrResendOTPRequest struct {
 }
 
 type VerifyOTPRequest struct {
-	Code string `json:"code" validate:"required,len=6"`
+	Email     string `json:"email" validate:"required,email"`
+	Code      string `json:"code" validate:"required,len=6"`
+	IPAddress string `json:"-"`
 }
 
 type VerifyOTPResponse struct {
 	IsValid bool `json:"is_valid"`
+	// exchanged once for the new password in ResetPassword
+	ResetToken string `json:"reset_token"`
 }
 
 type ResetPasswordRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Code     string `json:"code" validate:"required,len=6"`
-	Password string `json:"password" validate:"required,min=6,max=72"`
+	ResetToken string `json:"reset_token" validate:"required"`
+	Password   string `json:"password" validate:"required,min=8,max=72"`
 }
 
 type RegisterCompanyRequest struct {
@@ -1,8 +1,12 @@
 package auth
 
 import (
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/logger"
 	"basekarya-backend/pkg/response"
+	"basekarya-backend/pkg/utils"
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -26,10 +30,16 @@ func (h *Handler) Login(ctx echo.Context) error {
 		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
 	}
 
-	resp, err := h.service.Login(ctx.Request().Context(), req.Username, req.Password)
+	req.UserAgent = ctx.Request().UserAgent()
+	req.IPAddress = ctx.RealIP()
+
+	resp, err := h.service.Login(ctx.Request().Context(), &req)
 	if err != nil {
 		logger.Errorw("Login failed : ", err)
 
+		if errors.Is(err, ErrSSORequired) {
+			return response.NewResponses[any](ctx, http.StatusForbidden, err.Error(), nil, err, nil)
+		}
 		return response.NewResponses[any](ctx, http.StatusInternalServerError, "login failed", nil, err, nil)
 	}
 
@@ -50,6 +60,9 @@ func (h *Handler) ForgotPassword(ctx echo.Context) error {
 	if err != nil {
 		logger.Errorw("Forgot Password failed : ", err)
 
+		if errors.Is(err, ErrOTPCooldown) {
+			return response.NewResponses[any](ctx, http.StatusTooManyRequests, err.Error(), nil, err, nil)
+		}
 		return response.NewResponses[any](ctx, http.StatusInternalServerError, "failed to send OTP", nil, err, nil)
 	}
 
@@ -70,6 +83,9 @@ func (h *Handler) ResendOTP(ctx echo.Context) error {
 	if err != nil {
 		logger.Errorw("Resend OTP failed : ", err)
 
+		if errors.Is(err, ErrOTPCooldown) {
+			return response.NewResponses[any](ctx, http.StatusTooManyRequests, err.Error(), nil, err, nil)
+		}
 		return response.NewResponses[any](ctx, http.StatusInternalServerError, "failed to resend OTP", nil, err, nil)
 	}
 
@@ -86,10 +102,18 @@ func (h *Handler) VerifyOTP(ctx echo.Context) error {
 		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
 	}
 
+	req.IPAddress = ctx.RealIP()
+
 	resp, err := h.service.VerifyOTP(ctx.Request().Context(), &req)
 	if err != nil {
 		logger.Errorw("Verify OTP failed : ", err)
 
+		switch {
+		case errors.Is(err, ErrTooManyAttempts):
+			return response.NewResponses[any](ctx, http.StatusTooManyRequests, err.Error(), nil, err, nil)
+		case errors.Is(err, ErrInvalidOTP):
+			return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+		}
 		return response.NewResponses[any](ctx, http.StatusInternalServerError, "failed to verify OTP", nil, err, nil)
 	}
 
@@ -134,3 +158,303 @@ func (h *Handler) RegisterCompany(ctx echo.Context) error {
 
 	return response.NewResponses[any](ctx, http.StatusCreated, "Company registered successfully", resp, nil, nil)
 }
+
+func (h *Handler) Refresh(ctx echo.Context) error {
+	var req RefreshRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	resp, err := h.service.Refresh(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("Refresh token failed : ", err)
+
+		return response.NewResponses[any](ctx, http.StatusUnauthorized, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Refresh Token Success", resp, nil, nil)
+}
+
+func (h *Handler) Logout(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	err = h.service.Logout(ctx.Request().Context(), userContext.UserID, userContext.ID)
+	if err != nil && !errors.Is(err, infrastructure.ErrSessionNotFound) {
+		logger.Errorw("Logout failed : ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "logout failed", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Logout Success", nil, nil, nil)
+}
+
+func (h *Handler) LogoutAll(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	err = h.service.LogoutAll(ctx.Request().Context(), userContext.UserID)
+	if err != nil {
+		logger.Errorw("Logout all sessions failed : ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "logout failed", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Logout All Sessions Success", nil, nil, nil)
+}
+
+func (h *Handler) GetSessions(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	resp, err := h.service.GetSessions(ctx.Request().Context(), userContext.UserID, userContext.ID)
+	if err != nil {
+		logger.Errorw("Get sessions failed : ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "failed to get sessions", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Success Get Sessions", resp, nil, nil)
+}
+
+func (h *Handler) RevokeSession(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	err = h.service.RevokeSession(ctx.Request().Context(), userContext.UserID, ctx.Param("id"))
+	if err != nil {
+		logger.Errorw("Revoke session failed : ", err)
+
+		if errors.Is(err, infrastructure.ErrSessionNotFound) {
+			return response.NewResponses[any](ctx, http.StatusNotFound, err.Error(), nil, err, nil)
+		}
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "failed to revoke session", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Revoke Session Success", nil, nil, nil)
+}
+
+// GetPermissions returns the permissions resolved for this request, the access token no longer lists them
+func (h *Handler) GetPermissions(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	resp := PermissionsResponse{
+		Permissions:     userContext.Permissions,
+		DataScopes:      userContext.DataScopes,
+		IsPlatformAdmin: userContext.IsPlatformAdmin,
+		CompanyID:       userContext.CompanyID,
+	}
+	if resp.Permissions == nil {
+		resp.Permissions = []string{}
+	}
+	if resp.DataScopes == nil {
+		resp.DataScopes = map[string]constants.DataScope{}
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Success Get Permissions", resp, nil, nil)
+}
+
+func (h *Handler) VerifyMFA(ctx echo.Context) error {
+	var req VerifyMFARequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.UserAgent = ctx.Request().UserAgent()
+	req.IPAddress = ctx.RealIP()
+
+	resp, err := h.service.VerifyMFA(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("Verify MFA failed : ", err)
+
+		return response.NewResponses[any](ctx, mfaErrorStatus(err), err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Login Success", resp, nil, nil)
+}
+
+func (h *Handler) StartMFAEnrolment(ctx echo.Context) error {
+	var req MFAEnrolmentRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	resp, err := h.service.StartMFAEnrolment(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("Start MFA enrolment failed : ", err)
+
+		return response.NewResponses[any](ctx, mfaErrorStatus(err), err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Success Setup MFA", resp, nil, nil)
+}
+
+func (h *Handler) CompleteMFAEnrolment(ctx echo.Context) error {
+	var req ConfirmMFAEnrolmentRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.UserAgent = ctx.Request().UserAgent()
+	req.IPAddress = ctx.RealIP()
+
+	resp, err := h.service.CompleteMFAEnrolment(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("Complete MFA enrolment failed : ", err)
+
+		return response.NewResponses[any](ctx, mfaErrorStatus(err), err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Login Success", resp, nil, nil)
+}
+
+func (h *Handler) GetMFAStatus(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	resp, err := h.service.GetMFAStatus(ctx.Request().Context(), userContext.UserID)
+	if err != nil {
+		logger.Errorw("Get MFA status failed : ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "failed to get MFA status", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Success Get MFA Status", resp, nil, nil)
+}
+
+func (h *Handler) SetupMFA(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	resp, err := h.service.SetupMFA(ctx.Request().Context(), userContext.UserID)
+	if err != nil {
+		logger.Errorw("Setup MFA failed : ", err)
+
+		return response.NewResponses[any](ctx, mfaErrorStatus(err), err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Success Setup MFA", resp, nil, nil)
+}
+
+func (h *Handler) EnableMFA(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req MFACodeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	resp, err := h.service.EnableMFA(ctx.Request().Context(), userContext.UserID, &req)
+	if err != nil {
+		logger.Errorw("Enable MFA failed : ", err)
+
+		return response.NewResponses[any](ctx, mfaErrorStatus(err), err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Enable MFA Success", resp, nil, nil)
+}
+
+func (h *Handler) DisableMFA(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req MFACodeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	err = h.service.DisableMFA(ctx.Request().Context(), userContext.UserID, &req)
+	if err != nil {
+		logger.Errorw("Disable MFA failed : ", err)
+
+		return response.NewResponses[any](ctx, mfaErrorStatus(err), err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Disable MFA Success", nil, nil, nil)
+}
+
+func (h *Handler) RegenerateRecoveryCodes(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req MFACodeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	resp, err := h.service.RegenerateRecoveryCodes(ctx.Request().Context(), userContext.UserID, &req)
+	if err != nil {
+		logger.Errorw("Regenerate recovery codes failed : ", err)
+
+		return response.NewResponses[any](ctx, mfaErrorStatus(err), err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Regenerate Recovery Codes Success", resp, nil, nil)
+}
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrTooManyAttempts):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrInvalidMFAToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrInvalidMFACode),
+		errors.Is(err, ErrMFAAlreadyEnabled),
+		errors.Is(err, ErrMFANotEnabled),
+		errors.Is(err, ErrMFASetupExpired),
+		errors.Is(err, ErrMFARequiredByPolicy),
+		errors.Is(err, ErrMFAEnrolmentPending):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"testing"
 
+	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -27,7 +29,9 @@ func TestHandler_Login(t *testing.T) {
 				Password: "pass123",
 			},
 			setupMocks: func(svc *mockService) {
-				svc.On("Login", mock.Anything, "admin", "pass123").Return(&LoginResponse{
+				svc.On("Login", mock.Anything, mock.MatchedBy(func(req *LoginRequest) bool {
+					return req.Username == "admin" && req.Password == "pass123"
+				})).Return(&LoginResponse{
 					Token:              "jwt-token",
 					MustChangePassword: false,
 				}, nil)
@@ -41,10 +45,23 @@ func TestHandler_Login(t *testing.T) {
 				Password: "wrong",
 			},
 			setupMocks: func(svc *mockService) {
-				svc.On("Login", mock.Anything, "admin", "wrong").Return(nil, errors.New("invalid credentials"))
+				svc.On("Login", mock.Anything, mock.MatchedBy(func(req *LoginRequest) bool {
+					return req.Username == "admin" && req.Password == "wrong"
+				})).Return(nil, errors.New("invalid credentials"))
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "company signs in with SSO only",
+			body: LoginRequest{
+				Username: "staff",
+				Password: "pass123",
+			},
+			setupMocks: func(svc *mockService) {
+				svc.On("Login", mock.Anything, mock.Anything).Return(nil, ErrSSORequired)
+			},
+			wantStatus: http.StatusForbidden,
+		},
 		{
 			name:       "missing fields",
 			body:       LoginRequest{},
@@ -92,12 +109,22 @@ func TestHandler_ForgotPassword(t *testing.T) {
 			wantStatus: http.StatusOK,
 		},
 		{
-			name: "employee not found",
+			name: "cooldown",
+			body: SendOrResendOTPRequest{
+				Email: "user@example.com",
+			},
+			setupMocks: func(svc *mockService) {
+				svc.On("SendOrResendOTP", mock.Anything, mock.AnythingOfType("*auth.SendOrResendOTPRequest")).Return(ErrOTPCooldown)
+			},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name: "email failure",
 			body: SendOrResendOTPRequest{
-				Email: "unknown@example.com",
+				Email: "user@example.com",
 			},
 			setupMocks: func(svc *mockService) {
-				svc.On("SendOrResendOTP", mock.Anything, mock.AnythingOfType("*auth.SendOrResendOTPRequest")).Return(errors.New("employee not found"))
+				svc.On("SendOrResendOTP", mock.Anything, mock.AnythingOfType("*auth.SendOrResendOTPRequest")).Return(errors.New("smtp down"))
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
@@ -134,22 +161,35 @@ func TestHandler_VerifyOTP(t *testing.T) {
 		{
 			name: "valid otp",
 			body: VerifyOTPRequest{
-				Code: "123456",
+				Email: "test@email.com",
+				Code:  "123456",
 			},
 			setupMocks: func(svc *mockService) {
-				svc.On("VerifyOTP", mock.Anything, mock.AnythingOfType("*auth.VerifyOTPRequest")).Return(&VerifyOTPResponse{IsValid: true}, nil)
+				svc.On("VerifyOTP", mock.Anything, mock.AnythingOfType("*auth.VerifyOTPRequest")).Return(&VerifyOTPResponse{IsValid: true, ResetToken: "reset-token"}, nil)
 			},
 			wantStatus: http.StatusOK,
 		},
 		{
 			name: "invalid otp",
 			body: VerifyOTPRequest{
-				Code: "000000",
+				Email: "test@email.com",
+				Code:  "000000",
 			},
 			setupMocks: func(svc *mockService) {
-				svc.On("VerifyOTP", mock.Anything, mock.AnythingOfType("*auth.VerifyOTPRequest")).Return(&VerifyOTPResponse{IsValid: false}, nil)
+				svc.On("VerifyOTP", mock.Anything, mock.AnythingOfType("*auth.VerifyOTPRequest")).Return(nil, ErrInvalidOTP)
 			},
-			wantStatus: http.StatusOK,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "locked out",
+			body: VerifyOTPRequest{
+				Email: "test@email.com",
+				Code:  "000000",
+			},
+			setupMocks: func(svc *mockService) {
+				svc.On("VerifyOTP", mock.Anything, mock.AnythingOfType("*auth.VerifyOTPRequest")).Return(nil, ErrTooManyAttempts)
+			},
+			wantStatus: http.StatusTooManyRequests,
 		},
 		{
 			name:       "missing code",
@@ -184,9 +224,8 @@ func TestHandler_ResetPassword(t *testing.T) {
 		{
 			name: "success",
 			body: ResetPasswordRequest{
-				Email:    "test@email.com",
-				Code:     "123456",
-				Password: "newpass123",
+				ResetToken: "reset-token",
+				Password:   "karya2026new",
 			},
 			setupMocks: func(svc *mockService) {
 				svc.On("ResetPassword", mock.Anything, mock.AnythingOfType("*auth.ResetPasswordRequest")).Return(nil)
@@ -194,14 +233,13 @@ func TestHandler_ResetPassword(t *testing.T) {
 			wantStatus: http.StatusOK,
 		},
 		{
-			name: "invalid otp",
+			name: "invalid reset token",
 			body: ResetPasswordRequest{
-				Email:    "test@email.com",
-				Code:     "000000",
-				Password: "newpass123",
+				ResetToken: "expired",
+				Password:   "karya2026new",
 			},
 			setupMocks: func(svc *mockService) {
-				svc.On("ResetPassword", mock.Anything, mock.AnythingOfType("*auth.ResetPasswordRequest")).Return(errors.New("invalid OTP"))
+				svc.On("ResetPassword", mock.Anything, mock.AnythingOfType("*auth.ResetPasswordRequest")).Return(ErrInvalidResetToken)
 			},
 			wantStatus: http.StatusBadRequest,
 		},
@@ -297,3 +335,179 @@ func TestHandler_RegisterCompany(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_Refresh(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: RefreshRequest{RefreshToken: "sess-1.secret"},
+			setupMocks: func(svc *mockService) {
+				svc.On("Refresh", mock.Anything, &RefreshRequest{RefreshToken: "sess-1.secret"}).Return(&LoginResponse{
+					Token:        "jwt-token",
+					RefreshToken: "sess-1.rotated",
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "reused token",
+			body: RefreshRequest{RefreshToken: "sess-1.secret"},
+			setupMocks: func(svc *mockService) {
+				svc.On("Refresh", mock.Anything, mock.Anything).Return(nil, infrastructure.ErrRefreshTokenReused)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing token",
+			body:       RefreshRequest{},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/auth/refresh", tt.body)
+			rec, err := at.Execute(handler.Refresh)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_VerifyMFA(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: VerifyMFARequest{MFAToken: "token", Code: "123456"},
+			setupMocks: func(svc *mockService) {
+				svc.On("VerifyMFA", mock.Anything, mock.AnythingOfType("*auth.VerifyMFARequest")).Return(&LoginResponse{Token: "jwt-token"}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "wrong code",
+			body: VerifyMFARequest{MFAToken: "token", Code: "000000"},
+			setupMocks: func(svc *mockService) {
+				svc.On("VerifyMFA", mock.Anything, mock.Anything).Return(nil, ErrInvalidMFACode)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "expired challenge",
+			body: VerifyMFARequest{MFAToken: "token", Code: "000000"},
+			setupMocks: func(svc *mockService) {
+				svc.On("VerifyMFA", mock.Anything, mock.Anything).Return(nil, ErrInvalidMFAToken)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "too many attempts",
+			body: VerifyMFARequest{MFAToken: "token", Code: "000000"},
+			setupMocks: func(svc *mockService) {
+				svc.On("VerifyMFA", mock.Anything, mock.Anything).Return(nil, ErrTooManyAttempts)
+			},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "missing token",
+			body:       VerifyMFARequest{Code: "123456"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/auth/mfa/verify", tt.body)
+			rec, err := at.Execute(handler.VerifyMFA)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_RevokeSession(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			setupMocks: func(svc *mockService) {
+				svc.On("RevokeSession", mock.Anything, uint(1), "sess-2").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "session of another user",
+			setupMocks: func(svc *mockService) {
+				svc.On("RevokeSession", mock.Anything, uint(1), "sess-2").Return(infrastructure.ErrSessionNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			claims := &infrastructure.MyClaims{UserID: 1, CompanyID: 1}
+			claims.ID = "sess-1"
+			at := testutil.NewAPITest(t, http.MethodDelete, "/api/auth/sessions/sess-2", nil).
+				WithAuthContext(claims).
+				WithPathParams(map[string]string{"id": "sess-2"})
+			rec, err := at.Execute(handler.RevokeSession)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_GetPermissions(t *testing.T) {
+	handler := NewHandler(new(mockService))
+
+	claims := &infrastructure.MyClaims{
+		UserID:      1,
+		CompanyID:   1,
+		Permissions: []string{"VIEW_EMPLOYEE", "VIEW_PAYROLL"},
+		DataScopes:  map[string]constants.DataScope{"VIEW_PAYROLL": constants.DataScopeSelf},
+	}
+	at := testutil.NewAPITest(t, http.MethodGet, "/api/auth/permissions", nil).WithAuthContext(claims)
+	rec, err := at.Execute(handler.GetPermissions)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Data PermissionsResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, []string{"VIEW_EMPLOYEE", "VIEW_PAYROLL"}, body.Data.Permissions)
+	assert.Equal(t, constants.DataScopeSelf, body.Data.DataScopes["VIEW_PAYROLL"])
+	assert.Equal(t, uint(1), body.Data.CompanyID)
+}
@@ -0,0 +1,391 @@
+package auth
+
+import (
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidMFAToken     = errors.New("invalid or expired MFA token, sign in again")
+	ErrInvalidMFACode      = errors.New("invalid MFA code")
+	ErrMFAAlreadyEnabled   = errors.New("MFA is already enabled")
+	ErrMFANotEnabled       = errors.New("MFA is not enabled")
+	ErrMFASetupExpired     = errors.New("MFA setup expired, start again")
+	ErrMFARequiredByPolicy = errors.New("MFA is required for your role and cannot be disabled")
+	ErrMFAEnrolmentPending = errors.New("MFA must be set up before signing in")
+)
+
+// mfaChallenge is a login that passed the password and waits for the second step, Setup marks a user the policy
+// forces to enrol before the login completes.
+// usedStepTTL keeps a used time step for as long as a code of that step is accepted.
+const usedStepTTL = constants.TOTPPeriod * time.Duration(2*constants.TOTPSkew+1)
+
+type mfaChallenge struct {
+	UserID uint `json:"user_id"`
+	Setup  bool `json:"setup"`
+}
+
+// VerifyMFA completes a login with an authenticator or recovery code. Every attempt counts against the challenge
+// before the code is checked, once the limit is reached the challenge is dropped and the login starts over with
+// the password.
+func (s *service) VerifyMFA(ctx context.Context, req *VerifyMFARequest) (*LoginResponse, error) {
+	challenge, err := s.loadChallenge(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.Setup {
+		return nil, ErrMFAEnrolmentPending
+	}
+
+	foundUser, err := s.user.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	attempts, err := s.countChallengeAttempt(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	if !s.checkMFACode(ctx, foundUser, req.Code) {
+		return nil, s.failChallenge(ctx, req.MFAToken, attempts)
+	}
+
+	return s.completeChallenge(ctx, req.MFAToken, foundUser, req.UserAgent, req.IPAddress, nil)
+}
+
+// StartMFAEnrolment issues the authenticator secret to a user the policy forces to enrol during login.
+func (s *service) StartMFAEnrolment(ctx context.Context, req *MFAEnrolmentRequest) (*MFASetupResponse, error) {
+	challenge, err := s.loadChallenge(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	if !challenge.Setup {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	return s.SetupMFA(ctx, challenge.UserID)
+}
+
+// CompleteMFAEnrolment confirms the authenticator with its first code and finishes the login, the recovery codes
+// are returned with the tokens.
+func (s *service) CompleteMFAEnrolment(ctx context.Context, req *ConfirmMFAEnrolmentRequest) (*LoginResponse, error) {
+	challenge, err := s.loadChallenge(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	if !challenge.Setup {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	attempts, err := s.countChallengeAttempt(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := s.EnableMFA(ctx, challenge.UserID, &MFACodeRequest{Code: req.Code})
+	if errors.Is(err, ErrInvalidMFACode) {
+		return nil, s.failChallenge(ctx, req.MFAToken, attempts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	foundUser, err := s.user.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	return s.completeChallenge(ctx, req.MFAToken, foundUser, req.UserAgent, req.IPAddress, codes.RecoveryCodes)
+}
+
+func (s *service) GetMFAStatus(ctx context.Context, userID uint) (*MFAStatusResponse, error) {
+	foundUser, err := s.user.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := s.mfaRequired(ctx, foundUser)
+	if err != nil {
+		return nil, err
+	}
+
+	var left int64
+	if foundUser.MFAEnabled {
+		if left, err = s.user.CountRecoveryCodes(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &MFAStatusResponse{
+		Enabled:           foundUser.MFAEnabled,
+		Required:          required,
+		RecoveryCodesLeft: left,
+	}, nil
+}
+
+// SetupMFA generates an authenticator secret, it is kept aside until EnableMFA confirms a code from it.
+func (s *service) SetupMFA(ctx context.Context, userID uint) (*MFASetupResponse, error) {
+	foundUser, err := s.user.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if foundUser.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret := utils.GenerateTOTPSecret()
+	if err := s.cache.Set(ctx, fmt.Sprintf(constants.MFA_SETUP_CACHE_KEY, userID), secret, constants.MFASetupTTL); err != nil {
+		return nil, err
+	}
+
+	return &MFASetupResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(constants.TOTPIssuer, foundUser.Username, secret),
+	}, nil
+}
+
+// EnableMFA turns MFA on once a code from the pending secret is confirmed and returns the recovery codes, they are
+// only shown this once.
+func (s *service) EnableMFA(ctx context.Context, userID uint, req *MFACodeRequest) (*RecoveryCodesResponse, error) {
+	foundUser, err := s.user.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if foundUser.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	setupKey := fmt.Sprintf(constants.MFA_SETUP_CACHE_KEY, userID)
+	secret, err := s.cache.Get(ctx, setupKey)
+	if err != nil {
+		return nil, ErrMFASetupExpired
+	}
+
+	step, ok := utils.ValidateTOTPCode(secret, req.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes := generateRecoveryCodes()
+	if err := s.user.UpdateMFA(ctx, foundUser.ID, foundUser.CompanyID, secret, hashes); err != nil {
+		return nil, err
+	}
+
+	_ = s.cache.Del(ctx, setupKey)
+	s.markStepUsed(ctx, userID, step)
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableMFA turns MFA off with a current code, users the company policy forces to use MFA cannot turn it off.
+func (s *service) DisableMFA(ctx context.Context, userID uint, req *MFACodeRequest) error {
+	foundUser, err := s.user.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !foundUser.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+
+	required, err := s.mfaRequired(ctx, foundUser)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequiredByPolicy
+	}
+
+	if !s.checkMFACode(ctx, foundUser, req.Code) {
+		return ErrInvalidMFACode
+	}
+
+	return s.user.UpdateMFA(ctx, foundUser.ID, foundUser.CompanyID, "", nil)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, the authenticator has to confirm it.
+func (s *service) RegenerateRecoveryCodes(ctx context.Context, userID uint, req *MFACodeRequest) (*RecoveryCodesResponse, error) {
+	foundUser, err := s.user.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !foundUser.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	if !s.checkTOTPCode(ctx, foundUser, req.Code) {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes := generateRecoveryCodes()
+	if err := s.user.ReplaceRecoveryCodes(ctx, foundUser.ID, foundUser.CompanyID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// mfaRequired reports whether the company policy forces MFA on the user's role.
+func (s *service) mfaRequired(ctx context.Context, foundUser *user.User) (bool, error) {
+	if foundUser.Role == nil || len(foundUser.Role.Permissions) == 0 {
+		return false, nil
+	}
+
+	names, err := s.company.FindMFAPermissions(ctx, foundUser.CompanyID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, permission := range foundUser.Role.Permissions {
+		if slices.Contains(names, permission.Name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *service) startChallenge(ctx context.Context, foundUser *user.User) (*LoginResponse, error) {
+	challenge := mfaChallenge{UserID: foundUser.ID, Setup: !foundUser.MFAEnabled}
+	payload, err := json.Marshal(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	token := utils.GenerateSecureToken(32)
+	if err := s.cache.Set(ctx, fmt.Sprintf(constants.MFA_CHALLENGE_CACHE_KEY, token), payload, constants.MFAChallengeTTL); err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		MFARequired:      foundUser.MFAEnabled,
+		MFASetupRequired: !foundUser.MFAEnabled,
+		MFAToken:         token,
+	}, nil
+}
+
+func (s *service) loadChallenge(ctx context.Context, token string) (*mfaChallenge, error) {
+	payload, err := s.cache.Get(ctx, fmt.Sprintf(constants.MFA_CHALLENGE_CACHE_KEY, token))
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	var challenge mfaChallenge
+	if err := json.Unmarshal([]byte(payload), &challenge); err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	return &challenge, nil
+}
+
+// countChallengeAttempt counts an attempt before its code is checked, codes sent in parallel cannot all be tried
+// once the limit is reached.
+func (s *service) countChallengeAttempt(ctx context.Context, token string) (int64, error) {
+	attempts, err := s.cache.Incr(ctx, fmt.Sprintf(constants.MFA_ATTEMPT_CACHE_KEY, token), constants.MFAChallengeTTL)
+	if err != nil {
+		return 0, err
+	}
+	if attempts > constants.MFAMaxAttempts {
+		_ = s.cache.Del(ctx, fmt.Sprintf(constants.MFA_CHALLENGE_CACHE_KEY, token))
+		return 0, ErrTooManyAttempts
+	}
+	return attempts, nil
+}
+
+// failChallenge answers a wrong code, the challenge is dropped once its attempts reached the limit.
+func (s *service) failChallenge(ctx context.Context, token string, attempts int64) error {
+	if attempts >= constants.MFAMaxAttempts {
+		_ = s.cache.Del(ctx, fmt.Sprintf(constants.MFA_CHALLENGE_CACHE_KEY, token))
+		return ErrTooManyAttempts
+	}
+	return ErrInvalidMFACode
+}
+
+func (s *service) completeChallenge(ctx context.Context, token string, foundUser *user.User, userAgent, ipAddress string, recoveryCodes []string) (*LoginResponse, error) {
+	_ = s.cache.Del(ctx, fmt.Sprintf(constants.MFA_CHALLENGE_CACHE_KEY, token))
+	_ = s.cache.Del(ctx, fmt.Sprintf(constants.MFA_ATTEMPT_CACHE_KEY, token))
+
+	if !foundUser.IsActive {
+		return nil, errors.New("account is inactive")
+	}
+
+	session, refreshToken, err := s.sessions.Create(ctx, foundUser.ID, foundUser.CompanyID, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.issueTokens(foundUser, session.ID, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = recoveryCodes
+	return resp, nil
+}
+
+// checkMFACode accepts an authenticator code, or a recovery code which is spent by the check.
+func (s *service) checkMFACode(ctx context.Context, foundUser *user.User, code string) bool {
+	if isTOTPCode(code) {
+		return s.checkTOTPCode(ctx, foundUser, code)
+	}
+
+	used, err := s.user.UseRecoveryCode(ctx, foundUser.ID, hashRecoveryCode(code))
+	return err == nil && used
+}
+
+// checkTOTPCode validates an authenticator code, the time step is claimed with SETNX so an observed code cannot be
+// replayed, not even by a request racing with the one that used it.
+func (s *service) checkTOTPCode(ctx context.Context, foundUser *user.User, code string) bool {
+	if foundUser.MFASecret == "" {
+		return false
+	}
+
+	step, ok := utils.ValidateTOTPCode(foundUser.MFASecret, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	claimed, err := s.cache.SetNX(ctx, fmt.Sprintf(constants.MFA_USED_STEP_CACHE_KEY, foundUser.ID, step), 1, usedStepTTL)
+	return err == nil && claimed
+}
+
+func (s *service) markStepUsed(ctx context.Context, userID uint, step int64) {
+	_ = s.cache.Set(ctx, fmt.Sprintf(constants.MFA_USED_STEP_CACHE_KEY, userID, step), 1, usedStepTTL)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != constants.TOTPDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRecoveryCodes returns the codes to show the user and the hashes to store.
+func generateRecoveryCodes() ([]string, []string) {
+	codes := make([]string, 0, constants.MFARecoveryCodeCount)
+	hashes := make([]string, 0, constants.MFARecoveryCodeCount)
+	for i := 0; i < constants.MFARecoveryCodeCount; i++ {
+		raw := utils.GenerateSecureToken(constants.MFARecoveryCodeLength / 2)
+		code := raw[:len(raw)/2] + "-" + raw[len(raw)/2:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so a code can be typed the way it was written down.
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/rbac"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func financeUser(mfaSecret string) *user.User {
+	return &user.User{
+		ID: 7, CompanyID: 2, Username: "finance", PasswordHash: "hashed", IsActive: true,
+		MFASecret: mfaSecret, MFAEnabled: mfaSecret != "",
+		Role: &rbac.Role{Name: "FINANCE", Permissions: []rbac.Permission{{Name: constants.MARK_AS_PAID}}},
+	}
+}
+
+func TestLogin_MFA(t *testing.T) {
+	ctx := context.Background()
+	req := &LoginRequest{Username: "finance", Password: "pass123"}
+
+	t.Run("enrolled user gets a challenge instead of tokens", func(t *testing.T) {
+		svc, userProv, hasher, _, sessions, _, cache, _, company, _, _, _, sso := newTestAuthService()
+		userProv.On("FindByUsername", ctx, "finance").Return(financeUser("SECRET"), nil)
+		hasher.On("CheckPasswordHash", "pass123", "hashed").Return(true)
+		sso.On("IsSSOEnforced", ctx, uint(2)).Return(false, nil)
+		company.On("FindMFAPermissions", ctx, uint(2)).Return([]string{}, nil)
+		cache.On("Set", ctx, mock.AnythingOfType("string"), []byte(`{"user_id":7,"setup":false}`), constants.MFAChallengeTTL).Return(nil)
+
+		resp, err := svc.Login(ctx, req)
+
+		require.NoError(t, err)
+		assert.True(t, resp.MFARequired)
+		assert.False(t, resp.MFASetupRequired)
+		assert.Len(t, resp.MFAToken, 64)
+		assert.Empty(t, resp.Token)
+		sessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("policy forces enrolment", func(t *testing.T) {
+		svc, userProv, hasher, _, sessions, _, cache, _, company, _, _, _, sso := newTestAuthService()
+		userProv.On("FindByUsername", ctx, "finance").Return(financeUser(""), nil)
+		hasher.On("CheckPasswordHash", "pass123", "hashed").Return(true)
+		sso.On("IsSSOEnforced", ctx, uint(2)).Return(false, nil)
+		company.On("FindMFAPermissions", ctx, uint(2)).Return(constants.DefaultMFAPermissions, nil)
+		cache.On("Set", ctx, mock.AnythingOfType("string"), []byte(`{"user_id":7,"setup":true}`), constants.MFAChallengeTTL).Return(nil)
+
+		resp, err := svc.Login(ctx, req)
+
+		require.NoError(t, err)
+		assert.True(t, resp.MFASetupRequired)
+		assert.NotEmpty(t, resp.MFAToken)
+		sessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestVerifyMFA(t *testing.T) {
+	ctx := context.Background()
+	secret := utils.GenerateTOTPSecret()
+	challengeKey := "mfa:challenge:token"
+	attemptKey := "mfa:attempt:token"
+
+	t.Run("authenticator code completes the login", func(t *testing.T) {
+		svc, userProv, _, tokenProv, sessions, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		code, _ := utils.GenerateTOTPCode(secret, time.Now())
+		cache.On("Get", ctx, challengeKey).Return(`{"user_id":7,"setup":false}`, nil)
+		userProv.On("FindByID", ctx, uint(7)).Return(financeUser(secret), nil)
+		cache.On("Incr", ctx, attemptKey, constants.MFAChallengeTTL).Return(int64(1), nil)
+		cache.On("SetNX", ctx, mock.AnythingOfType("string"), 1, 90*time.Second).Return(true, nil)
+		cache.On("Del", ctx, challengeKey).Return(nil)
+		cache.On("Del", ctx, attemptKey).Return(nil)
+		sessions.On("Create", ctx, uint(7), uint(2), "Mozilla/5.0", "10.0.0.1").Return(&infrastructure.Session{ID: "sess-1"}, "sess-1.refresh", nil)
+		tokenProv.On("GenerateToken", "sess-1", uint(7), uint(2), false, "FINANCE", uint(0), (*uint)(nil), int64(0)).Return("jwt-token", nil)
+
+		resp, err := svc.VerifyMFA(ctx, &VerifyMFARequest{MFAToken: "token", Code: code, UserAgent: "Mozilla/5.0", IPAddress: "10.0.0.1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "jwt-token", resp.Token)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("replayed code is refused", func(t *testing.T) {
+		svc, userProv, _, _, sessions, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		code, _ := utils.GenerateTOTPCode(secret, time.Now())
+		cache.On("Get", ctx, challengeKey).Return(`{"user_id":7,"setup":false}`, nil)
+		userProv.On("FindByID", ctx, uint(7)).Return(financeUser(secret), nil)
+		cache.On("Incr", ctx, attemptKey, constants.MFAChallengeTTL).Return(int64(1), nil)
+		cache.On("SetNX", ctx, mock.AnythingOfType("string"), 1, 90*time.Second).Return(false, nil)
+
+		_, err := svc.VerifyMFA(ctx, &VerifyMFARequest{MFAToken: "token", Code: code})
+
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+		sessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("recovery code is spent", func(t *testing.T) {
+		svc, userProv, _, tokenProv, sessions, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Get", ctx, challengeKey).Return(`{"user_id":7,"setup":false}`, nil)
+		userProv.On("FindByID", ctx, uint(7)).Return(financeUser(secret), nil)
+		userProv.On("UseRecoveryCode", ctx, uint(7), hashRecoveryCode("abcde-12345")).Return(true, nil)
+		cache.On("Incr", ctx, attemptKey, constants.MFAChallengeTTL).Return(int64(1), nil)
+		cache.On("Del", ctx, mock.AnythingOfType("string")).Return(nil)
+		sessions.On("Create", ctx, uint(7), uint(2), "", "").Return(&infrastructure.Session{ID: "sess-1"}, "sess-1.refresh", nil)
+		tokenProv.On("GenerateToken", "sess-1", uint(7), uint(2), false, "FINANCE", uint(0), (*uint)(nil), int64(0)).Return("jwt-token", nil)
+
+		resp, err := svc.VerifyMFA(ctx, &VerifyMFARequest{MFAToken: "token", Code: "ABCDE 12345"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "jwt-token", resp.Token)
+	})
+
+	t.Run("too many wrong codes drop the challenge", func(t *testing.T) {
+		svc, userProv, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Get", ctx, challengeKey).Return(`{"user_id":7,"setup":false}`, nil)
+		userProv.On("FindByID", ctx, uint(7)).Return(financeUser(secret), nil)
+		userProv.On("UseRecoveryCode", ctx, uint(7), hashRecoveryCode("wrong-guess")).Return(false, nil)
+		cache.On("Incr", ctx, attemptKey, constants.MFAChallengeTTL).Return(int64(constants.MFAMaxAttempts), nil)
+		cache.On("Del", ctx, challengeKey).Return(nil)
+
+		_, err := svc.VerifyMFA(ctx, &VerifyMFARequest{MFAToken: "token", Code: "wrong-guess"})
+
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("attempt over the limit is refused before the code is checked", func(t *testing.T) {
+		svc, userProv, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Get", ctx, challengeKey).Return(`{"user_id":7,"setup":false}`, nil)
+		userProv.On("FindByID", ctx, uint(7)).Return(financeUser(secret), nil)
+		cache.On("Incr", ctx, attemptKey, constants.MFAChallengeTTL).Return(int64(constants.MFAMaxAttempts+1), nil)
+		cache.On("Del", ctx, challengeKey).Return(nil)
+
+		_, err := svc.VerifyMFA(ctx, &VerifyMFARequest{MFAToken: "token", Code: "abcde-12345"})
+
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+		userProv.AssertNotCalled(t, "UseRecoveryCode", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("enrolment challenge cannot skip the setup", func(t *testing.T) {
+		svc, _, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Get", ctx, challengeKey).Return(`{"user_id":7,"setup":true}`, nil)
+
+		_, err := svc.VerifyMFA(ctx, &VerifyMFARequest{MFAToken: "token", Code: "123456"})
+
+		assert.ErrorIs(t, err, ErrMFAEnrolmentPending)
+	})
+
+	t.Run("expired challenge", func(t *testing.T) {
+		svc, _, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Get", ctx, challengeKey).Return("", errors.New("redis: nil"))
+
+		_, err := svc.VerifyMFA(ctx, &VerifyMFARequest{MFAToken: "token", Code: "123456"})
+
+		assert.ErrorIs(t, err, ErrInvalidMFAToken)
+	})
+}
+
+func TestEnableMFA(t *testing.T) {
+	ctx := context.Background()
+	secret := utils.GenerateTOTPSecret()
+
+	t.Run("confirmed code stores the secret with recovery codes", func(t *testing.T) {
+		svc, userProv, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		code, _ := utils.GenerateTOTPCode(secret, time.Now())
+		userProv.On("FindByID", ctx, uint(7)).Return(financeUser(""), nil)
+		cache.On("Get", ctx, "mfa:setup:7").Return(secret, nil)
+		userProv.On("UpdateMFA", ctx, uint(7), uint(2), secret, mock.MatchedBy(func(hashes []string) bool {
+			return len(hashes) == constants.MFARecoveryCodeCount
+		})).Return(nil)
+		cache.On("Del", ctx, "mfa:setup:7").Return(nil)
+		cache.On("Set", ctx, mock.AnythingOfType("string"), 1, 90*time.Second).Return(nil)
+
+		resp, err := svc.EnableMFA(ctx, 7, &MFACodeRequest{Code: code})
+
+		require.NoError(t, err)
+		require.Len(t, resp.RecoveryCodes, constants.MFARecoveryCodeCount)
+		assert.Len(t, resp.RecoveryCodes[0], constants.MFARecoveryCodeLength+1)
+		userProv.AssertExpectations(t)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		svc, userProv, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		userProv.On("FindByID", ctx, uint(7)).Return(financeUser(""), nil)
+		cache.On("Get", ctx, "mfa:setup:7").Return(secret, nil)
+
+		_, err := svc.EnableMFA(ctx, 7, &MFACodeRequest{Code: "abcdef"})
+
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+		userProv.AssertNotCalled(t, "UpdateMFA", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("setup expired", func(t *testing.T) {
+		svc, userProv, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		userProv.On("FindByID", ctx, uint(7)).Return(financeUser(""), nil)
+		cache.On("Get", ctx, "mfa:setup:7").Return("", errors.New("redis: nil"))
+
+		_, err := svc.EnableMFA(ctx, 7, &MFACodeRequest{Code: "123456"})
+
+		assert.ErrorIs(t, err, ErrMFASetupExpired)
+	})
+}
+
+func TestDisableMFA(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("refused while the policy requires it", func(t *testing.T) {
+		svc, userProv, _, _, _, _, _, _, company, _, _, _, _ := newTestAuthService()
+		userProv.On("FindByID", ctx, uint(7)).Return(financeUser("SECRET"), nil)
+		company.On("FindMFAPermissions", ctx, uint(2)).Return([]string{constants.MARK_AS_PAID}, nil)
+
+		err := svc.DisableMFA(ctx, 7, &MFACodeRequest{Code: "123456"})
+
+		assert.ErrorIs(t, err, ErrMFARequiredByPolicy)
+		userProv.AssertNotCalled(t, "UpdateMFA", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("recovery code turns it off", func(t *testing.T) {
+		svc, userProv, _, _, _, _, _, _, company, _, _, _, _ := newTestAuthService()
+		userProv.On("FindByID", ctx, uint(7)).Return(financeUser("SECRET"), nil)
+		company.On("FindMFAPermissions", ctx, uint(2)).Return([]string{}, nil)
+		userProv.On("UseRecoveryCode", ctx, uint(7), hashRecoveryCode("abcde-12345")).Return(true, nil)
+		userProv.On("UpdateMFA", ctx, uint(7), uint(2), "", []string(nil)).Return(nil)
+
+		err := svc.DisableMFA(ctx, 7, &MFACodeRequest{Code: "abcde-12345"})
+
+		require.NoError(t, err)
+		userProv.AssertExpectations(t)
+	})
+}
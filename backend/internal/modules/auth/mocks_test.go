@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/internal/modules/company"
 	"basekarya-backend/internal/modules/rbac"
 	"basekarya-backend/internal/modules/user"
@@ -22,6 +23,14 @@ func (m *mockUserProvider) FindByUsername(ctx context.Context, username string)
 	return args.Get(0).(*user.User), args.Error(1)
 }
 
+func (m *mockUserProvider) FindByID(ctx context.Context, id uint) (*user.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
 func (m *mockUserProvider) FindEmployeeByEmail(ctx context.Context, email string) (*user.Employee, error) {
 	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
@@ -30,14 +39,68 @@ func (m *mockUserProvider) FindEmployeeByEmail(ctx context.Context, email string
 	return args.Get(0).(*user.Employee), args.Error(1)
 }
 
-func (m *mockUserProvider) UpdatePasswordByEmail(ctx context.Context, email string, password string) error {
-	return m.Called(ctx, email, password).Error(0)
+func (m *mockUserProvider) UpdateMFA(ctx context.Context, userID, companyID uint, secret string, recoveryHashes []string) error {
+	return m.Called(ctx, userID, companyID, secret, recoveryHashes).Error(0)
+}
+
+func (m *mockUserProvider) ReplaceRecoveryCodes(ctx context.Context, userID, companyID uint, hashes []string) error {
+	return m.Called(ctx, userID, companyID, hashes).Error(0)
+}
+
+func (m *mockUserProvider) UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error) {
+	args := m.Called(ctx, userID, hash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockUserProvider) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type mockPasswordUpdater struct{ mock.Mock }
+
+func (m *mockPasswordUpdater) UpdatePassword(ctx context.Context, userID uint, password string) error {
+	return m.Called(ctx, userID, password).Error(0)
 }
 
 func (m *mockUserProvider) CreateUser(ctx context.Context, u *user.User) error {
 	return m.Called(ctx, u).Error(0)
 }
 
+type mockSessionManager struct{ mock.Mock }
+
+func (m *mockSessionManager) Create(ctx context.Context, userID, companyID uint, userAgent, ipAddress string) (*infrastructure.Session, string, error) {
+	args := m.Called(ctx, userID, companyID, userAgent, ipAddress)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*infrastructure.Session), args.String(1), args.Error(2)
+}
+
+func (m *mockSessionManager) Rotate(ctx context.Context, refreshToken string) (*infrastructure.Session, string, error) {
+	args := m.Called(ctx, refreshToken)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*infrastructure.Session), args.String(1), args.Error(2)
+}
+
+func (m *mockSessionManager) ListByUser(ctx context.Context, userID uint) ([]infrastructure.Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]infrastructure.Session), args.Error(1)
+}
+
+func (m *mockSessionManager) Revoke(ctx context.Context, userID uint, sessionID string) error {
+	return m.Called(ctx, userID, sessionID).Error(0)
+}
+
+func (m *mockSessionManager) RevokeUser(ctx context.Context, userID uint) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
 type mockCacheProvider struct{ mock.Mock }
 
 func (m *mockCacheProvider) Get(ctx context.Context, key string) (string, error) {
@@ -49,10 +112,24 @@ func (m *mockCacheProvider) Set(ctx context.Context, key string, value any, expi
 	return m.Called(ctx, key, value, expiration).Error(0)
 }
 
+func (m *mockCacheProvider) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	args := m.Called(ctx, key, value, expiration)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *mockCacheProvider) Del(ctx context.Context, key string) error {
 	return m.Called(ctx, key).Error(0)
 }
 
+func (m *mockCacheProvider) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	args := m.Called(ctx, key, expiration)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockCacheProvider) Decr(ctx context.Context, key string) error {
+	return m.Called(ctx, key).Error(0)
+}
+
 func (m *mockCacheProvider) FlushDB(ctx context.Context) error {
 	return m.Called(ctx).Error(0)
 }
@@ -68,6 +145,18 @@ func (m *mockCompanyProvider) FindPlanIDBySlug(ctx context.Context, slug string)
 	return args.Get(0).(uint), args.Error(1)
 }
 
+func (m *mockCompanyProvider) FindMFAPermissions(ctx context.Context, companyID uint) ([]string, error) {
+	args := m.Called(ctx, companyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockCompanyProvider) ReplaceMFAPermissions(ctx context.Context, companyID uint, names []string) error {
+	return m.Called(ctx, companyID, names).Error(0)
+}
+
 type mockRoleProvider struct{ mock.Mock }
 
 func (m *mockRoleProvider) Create(ctx context.Context, role *rbac.Role) error {
@@ -102,16 +191,57 @@ func (m *mockMasterProvider) SeedDefaults(ctx context.Context, companyID uint) e
 	return m.Called(ctx, companyID).Error(0)
 }
 
+type mockReimbursementSeeder struct{ mock.Mock }
+
+func (m *mockReimbursementSeeder) SeedDefaultCategories(ctx context.Context, companyID uint) error {
+	return m.Called(ctx, companyID).Error(0)
+}
+
+type mockSSOPolicy struct{ mock.Mock }
+
+func (m *mockSSOPolicy) IsSSOEnforced(ctx context.Context, companyID uint) (bool, error) {
+	args := m.Called(ctx, companyID)
+	return args.Bool(0), args.Error(1)
+}
+
 type mockService struct{ mock.Mock }
 
-func (m *mockService) Login(ctx context.Context, username, password string) (*LoginResponse, error) {
-	args := m.Called(ctx, username, password)
+func (m *mockService) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+	args := m.Called(ctx, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*LoginResponse), args.Error(1)
 }
 
+func (m *mockService) Refresh(ctx context.Context, req *RefreshRequest) (*LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResponse), args.Error(1)
+}
+
+func (m *mockService) Logout(ctx context.Context, userID uint, sessionID string) error {
+	return m.Called(ctx, userID, sessionID).Error(0)
+}
+
+func (m *mockService) LogoutAll(ctx context.Context, userID uint) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *mockService) GetSessions(ctx context.Context, userID uint, currentSessionID string) ([]SessionResponse, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]SessionResponse), args.Error(1)
+}
+
+func (m *mockService) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	return m.Called(ctx, userID, sessionID).Error(0)
+}
+
 func (m *mockService) RegisterCompany(ctx context.Context, req *RegisterCompanyRequest) (*RegisterCompanyResponse, error) {
 	args := m.Called(ctx, req)
 	if args.Get(0) == nil {
@@ -136,15 +266,87 @@ func (m *mockService) ResetPassword(ctx context.Context, req *ResetPasswordReque
 	return m.Called(ctx, req).Error(0)
 }
 
-func newTestAuthService() (Service, *mockUserProvider, *testutil.MockHasher, *testutil.MockTokenProvider, *mockCacheProvider, *testutil.MockEmailProvider, *mockCompanyProvider, *mockRoleProvider, *mockMasterProvider) {
+func (m *mockService) VerifyMFA(ctx context.Context, req *VerifyMFARequest) (*LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResponse), args.Error(1)
+}
+
+func (m *mockService) StartMFAEnrolment(ctx context.Context, req *MFAEnrolmentRequest) (*MFASetupResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFASetupResponse), args.Error(1)
+}
+
+func (m *mockService) CompleteMFAEnrolment(ctx context.Context, req *ConfirmMFAEnrolmentRequest) (*LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResponse), args.Error(1)
+}
+
+func (m *mockService) GetMFAStatus(ctx context.Context, userID uint) (*MFAStatusResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFAStatusResponse), args.Error(1)
+}
+
+func (m *mockService) SetupMFA(ctx context.Context, userID uint) (*MFASetupResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFASetupResponse), args.Error(1)
+}
+
+func (m *mockService) EnableMFA(ctx context.Context, userID uint, req *MFACodeRequest) (*RecoveryCodesResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RecoveryCodesResponse), args.Error(1)
+}
+
+func (m *mockService) DisableMFA(ctx context.Context, userID uint, req *MFACodeRequest) error {
+	return m.Called(ctx, userID, req).Error(0)
+}
+
+func (m *mockService) RegenerateRecoveryCodes(ctx context.Context, userID uint, req *MFACodeRequest) (*RecoveryCodesResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RecoveryCodesResponse), args.Error(1)
+}
+
+func (m *mockService) CompleteExternalLogin(ctx context.Context, userID uint, userAgent, ipAddress string) (*LoginResponse, error) {
+	args := m.Called(ctx, userID, userAgent, ipAddress)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResponse), args.Error(1)
+}
+
+func newTestAuthService() (Service, *mockUserProvider, *testutil.MockHasher, *testutil.MockTokenProvider, *mockSessionManager, *mockPasswordUpdater, *mockCacheProvider, *testutil.MockEmailProvider, *mockCompanyProvider, *mockRoleProvider, *mockMasterProvider, *mockReimbursementSeeder, *mockSSOPolicy) {
 	u := new(mockUserProvider)
 	h := new(testutil.MockHasher)
 	tok := new(testutil.MockTokenProvider)
+	sm := new(mockSessionManager)
+	pu := new(mockPasswordUpdater)
 	c := new(mockCacheProvider)
 	e := new(testutil.MockEmailProvider)
 	cp := new(mockCompanyProvider)
 	rp := new(mockRoleProvider)
 	mp := new(mockMasterProvider)
+	rs := new(mockReimbursementSeeder)
+	sp := new(mockSSOPolicy)
 
-	return NewService(u, h, tok, c, e, cp, rp, mp), u, h, tok, c, e, cp, rp, mp
+	return NewService(u, h, tok, sm, pu, c, e, cp, rp, mp, rs, sp), u, h, tok, sm, pu, c, e, cp, rp, mp, rs, sp
 }
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/logger"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidOTP        = errors.New("invalid or expired OTP")
+	ErrTooManyAttempts   = errors.New("too many attempts, try again later")
+	ErrOTPCooldown       = errors.New("please wait before requesting another OTP")
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+)
+
+// SendOrResendOTP mails a reset code. It answers the same way whether or not the email is registered so the
+// endpoint cannot be used to find accounts: only the resend cooldown applies to every email alike, the account
+// lookup and the mail run in the background so both cases answer equally fast.
+func (s *service) SendOrResendOTP(ctx context.Context, req *SendOrResendOTPRequest) error {
+	email := normalizeEmail(req.Email)
+
+	cooldownKey := fmt.Sprintf(constants.OTP_COOLDOWN_CACHE_KEY, email)
+	started, err := s.cache.SetNX(ctx, cooldownKey, 1, constants.OTPResendCooldown)
+	if err != nil {
+		return err
+	}
+	if !started {
+		return ErrOTPCooldown
+	}
+
+	go s.sendOTP(utils.DetachContext(ctx), req.Email)
+
+	return nil
+}
+
+func (s *service) sendOTP(ctx context.Context, to string) {
+	if _, err := s.user.FindEmployeeByEmail(ctx, to); err != nil {
+		return
+	}
+
+	code := utils.GenerateRandomNumber(constants.OTPLength)
+	if err := s.cache.Set(ctx, fmt.Sprintf(constants.OTP_CACHE_KEY, normalizeEmail(to)), code, constants.OTPTTL); err != nil {
+		logger.Errorw("Store OTP failed : ", err)
+		return
+	}
+
+	subject := "Basekarya - Kode OTP"
+	htmlBody := fmt.Sprintf(`
+		<h1>Basekarya - Kode OTP</h1>
+		<p>Kode OTP Anda adalah: <strong>%s</strong></p>
+		<p>kode akan kadaluarsa dalam %d menit</p>
+	`, code, int(constants.OTPTTL.Minutes()))
+
+	if err := s.email.Send(to, subject, htmlBody); err != nil {
+		logger.Errorw("Send OTP email failed : ", err)
+	}
+}
+
+// VerifyOTP checks the code and exchanges it for a one-time reset token. Every attempt counts against both the
+// email and the IP address before the code is compared, so guesses sent in parallel cannot all pass the check,
+// either one going over the limit locks verification for a while.
+func (s *service) VerifyOTP(ctx context.Context, req *VerifyOTPRequest) (*VerifyOTPResponse, error) {
+	email := normalizeEmail(req.Email)
+	emailKey := fmt.Sprintf(constants.OTP_ATTEMPT_EMAIL_CACHE_KEY, email)
+	ipKey := fmt.Sprintf(constants.OTP_ATTEMPT_IP_CACHE_KEY, req.IPAddress)
+
+	emailAttempts, err := s.cache.Incr(ctx, emailKey, constants.OTPLockoutDuration)
+	if err != nil {
+		return nil, err
+	}
+	ipAttempts, err := s.cache.Incr(ctx, ipKey, constants.OTPLockoutDuration)
+	if err != nil {
+		return nil, err
+	}
+	if emailAttempts > constants.OTPMaxAttempts || ipAttempts > constants.OTPMaxAttempts {
+		return nil, ErrTooManyAttempts
+	}
+
+	otpKey := fmt.Sprintf(constants.OTP_CACHE_KEY, email)
+	storedCode, err := s.cache.Get(ctx, otpKey)
+	if err != nil || subtle.ConstantTimeCompare([]byte(storedCode), []byte(req.Code)) != 1 {
+		return nil, ErrInvalidOTP
+	}
+
+	// only wrong codes stay counted, an office behind one address is not locked out by its own resets
+	_ = s.cache.Del(ctx, otpKey)
+	_ = s.cache.Del(ctx, emailKey)
+	_ = s.cache.Decr(ctx, ipKey)
+
+	resetToken := utils.GenerateSecureToken(32)
+	if err := s.cache.Set(ctx, fmt.Sprintf(constants.PASSWORD_RESET_CACHE_KEY, resetToken), email, constants.PasswordResetTokenTTL); err != nil {
+		return nil, err
+	}
+
+	return &VerifyOTPResponse{
+		IsValid:    true,
+		ResetToken: resetToken,
+	}, nil
+}
+
+// ResetPassword sets the new password with a reset token from VerifyOTP. The token is claimed atomically so two
+// requests racing with it cannot both reset the password, a password rejected by the policy releases the claim
+// and can be retried with the same token.
+func (s *service) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
+	tokenKey := fmt.Sprintf(constants.PASSWORD_RESET_CACHE_KEY, req.ResetToken)
+	email, err := s.cache.Get(ctx, tokenKey)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	// the claim outlives the token, a used token stays spent even if deleting it fails
+	claimKey := fmt.Sprintf(constants.PASSWORD_RESET_CLAIM_CACHE_KEY, req.ResetToken)
+	claimed, err := s.cache.SetNX(ctx, claimKey, 1, constants.PasswordResetTokenTTL)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrInvalidResetToken
+	}
+
+	emp, err := s.user.FindEmployeeByEmail(ctx, email)
+	if err != nil {
+		_ = s.cache.Del(ctx, claimKey)
+		return ErrInvalidResetToken
+	}
+
+	if err := s.password.UpdatePassword(ctx, emp.UserID, req.Password); err != nil {
+		_ = s.cache.Del(ctx, claimKey)
+		return err
+	}
+
+	_ = s.cache.Del(ctx, tokenKey)
+
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
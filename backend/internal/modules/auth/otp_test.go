@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendOrResendOTP(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("registered email", func(t *testing.T) {
+		svc, userProv, _, _, _, _, cache, email, _, _, _, _, _ := newTestAuthService()
+		cache.On("SetNX", ctx, "otp:cooldown:user@email.com", 1, constants.OTPResendCooldown).Return(true, nil)
+		userProv.On("FindEmployeeByEmail", mock.Anything, "User@Email.com").Return(&user.Employee{ID: 1, UserID: 5}, nil)
+		cache.On("Set", mock.Anything, "otp:user@email.com", mock.AnythingOfType("string"), constants.OTPTTL).Return(nil)
+		sent := make(chan struct{})
+		email.On("Send", "User@Email.com", "Basekarya - Kode OTP", mock.Anything).Return(nil).Run(func(mock.Arguments) { close(sent) })
+
+		err := svc.SendOrResendOTP(ctx, &SendOrResendOTPRequest{Email: "User@Email.com"})
+
+		require.NoError(t, err)
+		select {
+		case <-sent:
+		case <-time.After(time.Second):
+			t.Fatal("OTP email not sent")
+		}
+	})
+
+	t.Run("unknown email answers the same", func(t *testing.T) {
+		svc, userProv, _, _, _, _, cache, email, _, _, _, _, _ := newTestAuthService()
+		cache.On("SetNX", ctx, "otp:cooldown:ghost@email.com", 1, constants.OTPResendCooldown).Return(true, nil)
+		looked := make(chan struct{})
+		userProv.On("FindEmployeeByEmail", mock.Anything, "ghost@email.com").Return(nil, errors.New("record not found")).Run(func(mock.Arguments) { close(looked) })
+
+		err := svc.SendOrResendOTP(ctx, &SendOrResendOTPRequest{Email: "ghost@email.com"})
+
+		require.NoError(t, err)
+		select {
+		case <-looked:
+		case <-time.After(time.Second):
+			t.Fatal("email not looked up")
+		}
+		email.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything)
+		cache.AssertNotCalled(t, "Set", mock.Anything, "otp:ghost@email.com", mock.Anything, mock.Anything)
+	})
+
+	t.Run("answer does not wait for the mail", func(t *testing.T) {
+		svc, userProv, _, _, _, _, cache, email, _, _, _, _, _ := newTestAuthService()
+		cache.On("SetNX", ctx, "otp:cooldown:user@email.com", 1, constants.OTPResendCooldown).Return(true, nil)
+		userProv.On("FindEmployeeByEmail", mock.Anything, "user@email.com").Return(&user.Employee{ID: 1, UserID: 5}, nil)
+		cache.On("Set", mock.Anything, "otp:user@email.com", mock.AnythingOfType("string"), constants.OTPTTL).Return(nil)
+		release := make(chan struct{})
+		defer close(release)
+		email.On("Send", "user@email.com", mock.Anything, mock.Anything).Return(nil).Run(func(mock.Arguments) { <-release })
+
+		done := make(chan error)
+		go func() { done <- svc.SendOrResendOTP(ctx, &SendOrResendOTPRequest{Email: "user@email.com"}) }()
+
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("SendOrResendOTP waited for the mail server")
+		}
+	})
+
+	t.Run("cooldown", func(t *testing.T) {
+		svc, userProv, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("SetNX", ctx, "otp:cooldown:user@email.com", 1, constants.OTPResendCooldown).Return(false, nil)
+
+		err := svc.SendOrResendOTP(ctx, &SendOrResendOTPRequest{Email: "user@email.com"})
+
+		assert.ErrorIs(t, err, ErrOTPCooldown)
+		userProv.AssertNotCalled(t, "FindEmployeeByEmail", mock.Anything, mock.Anything)
+	})
+}
+
+func TestVerifyOTP(t *testing.T) {
+	ctx := context.Background()
+	req := func(code string) *VerifyOTPRequest {
+		return &VerifyOTPRequest{Email: "user@email.com", Code: code, IPAddress: "10.0.0.1"}
+	}
+
+	t.Run("valid code issues a reset token", func(t *testing.T) {
+		svc, _, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Incr", ctx, "otp:attempt:email:user@email.com", constants.OTPLockoutDuration).Return(int64(3), nil)
+		cache.On("Incr", ctx, "otp:attempt:ip:10.0.0.1", constants.OTPLockoutDuration).Return(int64(1), nil)
+		cache.On("Get", ctx, "otp:user@email.com").Return("123456", nil)
+		cache.On("Del", ctx, "otp:user@email.com").Return(nil)
+		cache.On("Del", ctx, "otp:attempt:email:user@email.com").Return(nil)
+		cache.On("Decr", ctx, "otp:attempt:ip:10.0.0.1").Return(nil)
+		cache.On("Set", ctx, mock.MatchedBy(func(key string) bool {
+			return len(key) > len("password_reset:")
+		}), "user@email.com", constants.PasswordResetTokenTTL).Return(nil)
+
+		resp, err := svc.VerifyOTP(ctx, req("123456"))
+
+		require.NoError(t, err)
+		assert.True(t, resp.IsValid)
+		assert.Len(t, resp.ResetToken, 64)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("wrong code stays counted on email and ip", func(t *testing.T) {
+		svc, _, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Incr", ctx, "otp:attempt:email:user@email.com", constants.OTPLockoutDuration).Return(int64(1), nil)
+		cache.On("Incr", ctx, "otp:attempt:ip:10.0.0.1", constants.OTPLockoutDuration).Return(int64(1), nil)
+		cache.On("Get", ctx, "otp:user@email.com").Return("123456", nil)
+
+		resp, err := svc.VerifyOTP(ctx, req("000000"))
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidOTP)
+		cache.AssertExpectations(t)
+		cache.AssertNotCalled(t, "Decr", mock.Anything, mock.Anything)
+	})
+
+	t.Run("email locked out before the code is compared", func(t *testing.T) {
+		svc, _, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Incr", ctx, "otp:attempt:email:user@email.com", constants.OTPLockoutDuration).Return(int64(constants.OTPMaxAttempts+1), nil)
+		cache.On("Incr", ctx, "otp:attempt:ip:10.0.0.1", constants.OTPLockoutDuration).Return(int64(1), nil)
+
+		resp, err := svc.VerifyOTP(ctx, req("123456"))
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+		cache.AssertNotCalled(t, "Get", ctx, "otp:user@email.com")
+	})
+
+	t.Run("ip locked out", func(t *testing.T) {
+		svc, _, _, _, _, _, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Incr", ctx, "otp:attempt:email:user@email.com", constants.OTPLockoutDuration).Return(int64(1), nil)
+		cache.On("Incr", ctx, "otp:attempt:ip:10.0.0.1", constants.OTPLockoutDuration).Return(int64(7), nil)
+
+		resp, err := svc.VerifyOTP(ctx, req("123456"))
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success consumes the token", func(t *testing.T) {
+		svc, userProv, _, _, _, password, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Get", ctx, "password_reset:token").Return("user@email.com", nil)
+		cache.On("SetNX", ctx, "password_reset:claim:token", 1, constants.PasswordResetTokenTTL).Return(true, nil)
+		userProv.On("FindEmployeeByEmail", ctx, "user@email.com").Return(&user.Employee{ID: 1, UserID: 5}, nil)
+		password.On("UpdatePassword", ctx, uint(5), "karya2026new").Return(nil)
+		cache.On("Del", ctx, "password_reset:token").Return(nil)
+
+		err := svc.ResetPassword(ctx, &ResetPasswordRequest{ResetToken: "token", Password: "karya2026new"})
+
+		require.NoError(t, err)
+		cache.AssertExpectations(t)
+		cache.AssertNotCalled(t, "Del", ctx, "password_reset:claim:token")
+	})
+
+	t.Run("rejected password releases the token", func(t *testing.T) {
+		svc, userProv, _, _, _, password, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Get", ctx, "password_reset:token").Return("user@email.com", nil)
+		cache.On("SetNX", ctx, "password_reset:claim:token", 1, constants.PasswordResetTokenTTL).Return(true, nil)
+		userProv.On("FindEmployeeByEmail", ctx, "user@email.com").Return(&user.Employee{ID: 1, UserID: 5}, nil)
+		password.On("UpdatePassword", ctx, uint(5), "password123").Return(errors.New("password is too common, choose another one"))
+		cache.On("Del", ctx, "password_reset:claim:token").Return(nil)
+
+		err := svc.ResetPassword(ctx, &ResetPasswordRequest{ResetToken: "token", Password: "password123"})
+
+		assert.EqualError(t, err, "password is too common, choose another one")
+		cache.AssertExpectations(t)
+		cache.AssertNotCalled(t, "Del", ctx, "password_reset:token")
+	})
+
+	t.Run("token claimed by a concurrent request", func(t *testing.T) {
+		svc, _, _, _, _, password, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Get", ctx, "password_reset:token").Return("user@email.com", nil)
+		cache.On("SetNX", ctx, "password_reset:claim:token", 1, constants.PasswordResetTokenTTL).Return(false, nil)
+
+		err := svc.ResetPassword(ctx, &ResetPasswordRequest{ResetToken: "token", Password: "karya2026new"})
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+		password.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		svc, _, _, _, _, password, cache, _, _, _, _, _, _ := newTestAuthService()
+		cache.On("Get", ctx, "password_reset:expired").Return("", errors.New("redis: nil"))
+
+		err := svc.ResetPassword(ctx, &ResetPasswordRequest{ResetToken: "expired", Password: "karya2026new"})
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+		password.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
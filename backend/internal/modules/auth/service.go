@@ -8,71 +8,205 @@ import (
 	"basekarya-backend/pkg/utils"
 	"context"
 	"errors"
-	"fmt"
-	"time"
+	"sort"
 )
 
+var ErrSSORequired = errors.New("this company signs in through its identity provider, use SSO to log in")
+
 type Service interface {
-	Login(ctx context.Context, username, password string) (*LoginResponse, error)
+	Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error)
+	Refresh(ctx context.Context, req *RefreshRequest) (*LoginResponse, error)
+	Logout(ctx context.Context, userID uint, sessionID string) error
+	LogoutAll(ctx context.Context, userID uint) error
+	GetSessions(ctx context.Context, userID uint, currentSessionID string) ([]SessionResponse, error)
+	RevokeSession(ctx context.Context, userID uint, sessionID string) error
 	RegisterCompany(ctx context.Context, req *RegisterCompanyRequest) (*RegisterCompanyResponse, error)
 	SendOrResendOTP(ctx context.Context, req *SendOrResendOTPRequest) error
 	VerifyOTP(ctx context.Context, req *VerifyOTPRequest) (*VerifyOTPResponse, error)
 	ResetPassword(ctx context.Context, req *ResetPasswordRequest) error
+	VerifyMFA(ctx context.Context, req *VerifyMFARequest) (*LoginResponse, error)
+	StartMFAEnrolment(ctx context.Context, req *MFAEnrolmentRequest) (*MFASetupResponse, error)
+	CompleteMFAEnrolment(ctx context.Context, req *ConfirmMFAEnrolmentRequest) (*LoginResponse, error)
+	GetMFAStatus(ctx context.Context, userID uint) (*MFAStatusResponse, error)
+	SetupMFA(ctx context.Context, userID uint) (*MFASetupResponse, error)
+	EnableMFA(ctx context.Context, userID uint, req *MFACodeRequest) (*RecoveryCodesResponse, error)
+	DisableMFA(ctx context.Context, userID uint, req *MFACodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, req *MFACodeRequest) (*RecoveryCodesResponse, error)
+	CompleteExternalLogin(ctx context.Context, userID uint, userAgent, ipAddress string) (*LoginResponse, error)
 }
 
 type service struct {
-	user    UserProvider
-	hasher  Hasher
-	token   TokenProvider
-	cache   CacheProvider
-	email   EmailProvider
-	company CompanyProvider
-	role    RoleProvider
-	master  MasterProvider
+	user     UserProvider
+	hasher   Hasher
+	token    TokenProvider
+	sessions SessionManager
+	password PasswordUpdater
+	cache    CacheProvider
+	email    EmailProvider
+	company  CompanyProvider
+	role     RoleProvider
+	master   MasterProvider
+	reimb    ReimbursementSeeder
+	sso      SSOPolicy
 }
 
-func NewService(user UserProvider, hasher Hasher, token TokenProvider, cache CacheProvider, email EmailProvider, company CompanyProvider, role RoleProvider, master MasterProvider) Service {
+func NewService(user UserProvider, hasher Hasher, token TokenProvider, sessions SessionManager, password PasswordUpdater, cache CacheProvider, email EmailProvider, company CompanyProvider, role RoleProvider, master MasterProvider, reimb ReimbursementSeeder, sso SSOPolicy) Service {
 	return &service{
-		user:    user,
-		hasher:  hasher,
-		token:   token,
-		cache:   cache,
-		email:   email,
-		company: company,
-		role:    role,
-		master:  master,
+		user:     user,
+		hasher:   hasher,
+		token:    token,
+		sessions: sessions,
+		password: password,
+		cache:    cache,
+		email:    email,
+		company:  company,
+		role:     role,
+		master:   master,
+		reimb:    reimb,
+		sso:      sso,
 	}
 }
 
-func (s *service) Login(ctx context.Context, username, password string) (*LoginResponse, error) {
-	foundUser, err := s.user.FindByUsername(ctx, username)
+func (s *service) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+	foundUser, err := s.user.FindByUsername(ctx, req.Username)
 	if err != nil {
 		return nil, errors.New("invalid credentials")
 	}
 
-	if !s.hasher.CheckPasswordHash(password, foundUser.PasswordHash) {
+	if !s.hasher.CheckPasswordHash(req.Password, foundUser.PasswordHash) {
 		return nil, errors.New("invalid credentials")
 	}
 
+	if !foundUser.IsActive {
+		return nil, errors.New("account is inactive")
+	}
+
+	// checked after the password so the policy of a company is not revealed to anyone guessing usernames
+	if !foundUser.IsPlatformAdmin {
+		enforced, err := s.sso.IsSSOEnforced(ctx, foundUser.CompanyID)
+		if err != nil {
+			return nil, err
+		}
+		if enforced {
+			return nil, ErrSSORequired
+		}
+	}
+
+	return s.startLogin(ctx, foundUser, req.UserAgent, req.IPAddress)
+}
+
+// CompleteExternalLogin signs in a user an identity provider has already authenticated, the MFA policy still
+// applies as it does after a password.
+func (s *service) CompleteExternalLogin(ctx context.Context, userID uint, userAgent, ipAddress string) (*LoginResponse, error) {
+	foundUser, err := s.user.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !foundUser.IsActive {
+		return nil, errors.New("account is inactive")
+	}
+
+	return s.startLogin(ctx, foundUser, userAgent, ipAddress)
+}
+
+func (s *service) startLogin(ctx context.Context, foundUser *user.User, userAgent, ipAddress string) (*LoginResponse, error) {
+	// enrolled users, and users the company policy forces to enrol, finish the login with a second step
+	required, err := s.mfaRequired(ctx, foundUser)
+	if err != nil {
+		return nil, err
+	}
+	if foundUser.MFAEnabled || required {
+		return s.startChallenge(ctx, foundUser)
+	}
+
+	session, refreshToken, err := s.sessions.Create(ctx, foundUser.ID, foundUser.CompanyID, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(foundUser, session.ID, refreshToken)
+}
+
+func (s *service) Refresh(ctx context.Context, req *RefreshRequest) (*LoginResponse, error) {
+	session, refreshToken, err := s.sessions.Rotate(ctx, req.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// the user is reloaded so a refreshed token carries the current role and permissions
+	foundUser, err := s.user.FindByID(ctx, session.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !foundUser.IsActive {
+		_ = s.sessions.RevokeUser(ctx, foundUser.ID)
+		return nil, errors.New("account is inactive")
+	}
+
+	return s.issueTokens(foundUser, session.ID, refreshToken)
+}
+
+func (s *service) Logout(ctx context.Context, userID uint, sessionID string) error {
+	return s.sessions.Revoke(ctx, userID, sessionID)
+}
+
+func (s *service) LogoutAll(ctx context.Context, userID uint) error {
+	return s.sessions.RevokeUser(ctx, userID)
+}
+
+func (s *service) GetSessions(ctx context.Context, userID uint, currentSessionID string) ([]SessionResponse, error) {
+	sessions, err := s.sessions.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	res := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *service) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	return s.sessions.Revoke(ctx, userID, sessionID)
+}
+
+func (s *service) issueTokens(foundUser *user.User, sessionID, refreshToken string) (*LoginResponse, error) {
 	var employeeID *uint
 	if foundUser.Employee != nil && foundUser.Role != nil && foundUser.Role.Name != "PLATFORM_ADMIN" {
 		employeeID = &foundUser.Employee.ID
 	}
 
-	var permissions []string
+	var roleName string
 	if foundUser.Role != nil {
-		for _, permission := range foundUser.Role.Permissions {
-			permissions = append(permissions, permission.Name)
-		}
+		roleName = foundUser.Role.Name
 	}
 
-	tokenString, err := s.token.GenerateToken(foundUser.ID, foundUser.CompanyID, foundUser.IsPlatformAdmin, foundUser.Role.Name, employeeID, permissions)
+	// permissions are looked up from the role on every request, the token only names the role
+	tokenString, err := s.token.GenerateToken(sessionID, foundUser.ID, foundUser.CompanyID, foundUser.IsPlatformAdmin, roleName, foundUser.RoleID, employeeID, foundUser.PermissionVersion)
 	if err != nil {
 		return nil, err
 	}
 
 	return &LoginResponse{
 		Token:              tokenString,
+		RefreshToken:       refreshToken,
+		ExpiresIn:          int(s.token.AccessTTL().Seconds()),
 		MustChangePassword: foundUser.MustChangePassword,
 	}, nil
 }
@@ -108,6 +242,14 @@ func (s *service) RegisterCompany(ctx context.Context, req *RegisterCompanyReque
 		return nil, errors.New("failed to seed master data")
 	}
 
+	if err := s.reimb.SeedDefaultCategories(ctx, newCompany.ID); err != nil {
+		return nil, errors.New("failed to seed reimbursement categories")
+	}
+
+	if err := s.company.ReplaceMFAPermissions(ctx, newCompany.ID, constants.DefaultMFAPermissions); err != nil {
+		return nil, errors.New("failed to seed MFA policy")
+	}
+
 	superadminRole := &rbac.Role{
 		Name:      "SUPERADMIN",
 		CompanyID: newCompany.ID,
@@ -155,64 +297,6 @@ func (s *service) RegisterCompany(ctx context.Context, req *RegisterCompanyReque
 	}, nil
 }
 
-func (s *service) SendOrResendOTP(ctx context.Context, req *SendOrResendOTPRequest) error {
-	_, err := s.user.FindEmployeeByEmail(ctx, req.Email)
-	if err != nil {
-		return errors.New("employee not found")
-	}
-
-	code := utils.GenerateRandomNumber(6)
-	subject := "Basekarya - Kode OTP"
-	htmlBody := fmt.Sprintf(`
-		<h1>Basekarya - Kode OTP</h1>
-		<p>Kode OTP Anda adalah: <strong>%s</strong></p>
-		<p>kode akan kadaluarsa dalam 5 menit</p>
-	`, code)
-
-	err = s.email.Send(req.Email, subject, htmlBody)
-	if err != nil {
-		return err
-	}
-
-	err = s.cache.Set(ctx, fmt.Sprintf("otp:%s", req.Email), code, 5*time.Minute)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *service) VerifyOTP(ctx context.Context, req *VerifyOTPRequest) (*VerifyOTPResponse, error) {
-	return &VerifyOTPResponse{
-		IsValid: true,
-	}, nil
-}
-
-func (s *service) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
-	storedCode, err := s.cache.Get(ctx, fmt.Sprintf("otp:%s", req.Email))
-	if err != nil {
-		return errors.New("invalid or expired OTP")
-	}
-
-	if storedCode != req.Code {
-		return errors.New("invalid OTP code")
-	}
-
-	passwordHash, err := s.hasher.HashPassword(req.Password)
-	if err != nil {
-		return err
-	}
-
-	err = s.user.UpdatePasswordByEmail(ctx, req.Email, passwordHash)
-	if err != nil {
-		return err
-	}
-
-	_ = s.cache.Del(ctx, fmt.Sprintf("otp:%s", req.Email))
-
-	return nil
-}
-
 func buildAllowedGroups(planSlug string) []string {
 	groups := make([]string, len(constants.AlwaysAvailableGroups))
 	copy(groups, constants.AlwaysAvailableGroups)
@@ -1,106 +1,219 @@
 package auth
 
 import (
+	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/internal/modules/rbac"
 	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
 func TestLogin_Success(t *testing.T) {
-	svc, userProv, hasher, tokenProv, _, _, _, _, _ := newTestAuthService()
+	svc, userProv, hasher, tokenProv, sessions, _, _, _, _, _, _, _, _ := newTestAuthService()
 	ctx := context.Background()
 
 	userProv.On("FindByUsername", ctx, "admin").Return(&user.User{
-		ID: 1, Username: "admin", PasswordHash: "hashed", Role: &rbac.Role{Name: "SUPERADMIN"}, IsPlatformAdmin: true,
+		ID: 1, Username: "admin", PasswordHash: "hashed", Role: &rbac.Role{Name: "SUPERADMIN"}, IsPlatformAdmin: true, IsActive: true,
 	}, nil)
 	hasher.On("CheckPasswordHash", "pass123", "hashed").Return(true)
-	tokenProv.On("GenerateToken", uint(1), uint(0), true, "SUPERADMIN", (*uint)(nil), []string(nil)).Return("jwt-token", nil)
+	sessions.On("Create", ctx, uint(1), uint(0), "Mozilla/5.0", "10.0.0.1").Return(&infrastructure.Session{ID: "sess-1"}, "sess-1.refresh", nil)
+	tokenProv.On("GenerateToken", "sess-1", uint(1), uint(0), true, "SUPERADMIN", uint(0), (*uint)(nil), int64(0)).Return("jwt-token", nil)
 
-	resp, err := svc.Login(ctx, "admin", "pass123")
+	resp, err := svc.Login(ctx, &LoginRequest{Username: "admin", Password: "pass123", UserAgent: "Mozilla/5.0", IPAddress: "10.0.0.1"})
 
 	require.NoError(t, err)
 	assert.Equal(t, "jwt-token", resp.Token)
+	assert.Equal(t, "sess-1.refresh", resp.RefreshToken)
+	assert.Equal(t, 900, resp.ExpiresIn)
 	assert.False(t, resp.MustChangePassword)
 }
 
-func TestLogin_UserNotFound(t *testing.T) {
-	svc, userProv, _, _, _, _, _, _, _ := newTestAuthService()
+func TestLogin_InactiveUser(t *testing.T) {
+	svc, userProv, hasher, _, sessions, _, _, _, _, _, _, _, _ := newTestAuthService()
 	ctx := context.Background()
 
-	userProv.On("FindByUsername", ctx, "unknown").Return(nil, errors.New("not found"))
+	userProv.On("FindByUsername", ctx, "admin").Return(&user.User{
+		ID: 1, Username: "admin", PasswordHash: "hashed", Role: &rbac.Role{Name: "EMPLOYEE"}, IsActive: false,
+	}, nil)
+	hasher.On("CheckPasswordHash", "pass123", "hashed").Return(true)
 
-	resp, err := svc.Login(ctx, "unknown", "any")
+	resp, err := svc.Login(ctx, &LoginRequest{Username: "admin", Password: "pass123"})
 
-	assert.Error(t, err)
 	assert.Nil(t, resp)
-	assert.Equal(t, "invalid credentials", err.Error())
+	assert.EqualError(t, err, "account is inactive")
+	sessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
-func TestLogin_WrongPassword(t *testing.T) {
-	svc, userProv, hasher, _, _, _, _, _, _ := newTestAuthService()
+func TestLogin_SSOOnly(t *testing.T) {
 	ctx := context.Background()
+	staff := &user.User{ID: 3, CompanyID: 2, Username: "staff", PasswordHash: "hashed", Role: &rbac.Role{Name: "EMPLOYEE"}, IsActive: true}
 
-	userProv.On("FindByUsername", ctx, "admin").Return(&user.User{
-		ID: 1, Username: "admin", PasswordHash: "hashed", Role: &rbac.Role{Name: "SUPERADMIN"},
-	}, nil)
-	hasher.On("CheckPasswordHash", "wrong", "hashed").Return(false)
+	t.Run("password refused when the company enforces SSO", func(t *testing.T) {
+		svc, userProv, hasher, _, sessions, _, _, _, _, _, _, _, sso := newTestAuthService()
+		userProv.On("FindByUsername", ctx, "staff").Return(staff, nil)
+		hasher.On("CheckPasswordHash", "pass123", "hashed").Return(true)
+		sso.On("IsSSOEnforced", ctx, uint(2)).Return(true, nil)
 
-	resp, err := svc.Login(ctx, "admin", "wrong")
+		resp, err := svc.Login(ctx, &LoginRequest{Username: "staff", Password: "pass123"})
 
-	assert.Error(t, err)
-	assert.Nil(t, resp)
-	assert.Equal(t, "invalid credentials", err.Error())
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrSSORequired)
+		sessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong password does not reveal the policy", func(t *testing.T) {
+		svc, userProv, hasher, _, _, _, _, _, _, _, _, _, sso := newTestAuthService()
+		userProv.On("FindByUsername", ctx, "staff").Return(staff, nil)
+		hasher.On("CheckPasswordHash", "wrong", "hashed").Return(false)
+
+		_, err := svc.Login(ctx, &LoginRequest{Username: "staff", Password: "wrong"})
+
+		assert.EqualError(t, err, "invalid credentials")
+		sso.AssertNotCalled(t, "IsSSOEnforced", mock.Anything, mock.Anything)
+	})
 }
 
-func TestVerifyOTP_Valid(t *testing.T) {
-	svc, _, _, _, cache, _, _, _, _ := newTestAuthService()
+func TestCompleteExternalLogin(t *testing.T) {
 	ctx := context.Background()
 
-	cache.On("Get", ctx, "123456").Return("user@email.com", nil)
+	t.Run("issues tokens without a password", func(t *testing.T) {
+		svc, userProv, _, tokenProv, sessions, _, _, _, company, _, _, _, _ := newTestAuthService()
+		userProv.On("FindByID", ctx, uint(3)).Return(&user.User{
+			ID: 3, CompanyID: 2, Role: &rbac.Role{Name: "EMPLOYEE", Permissions: []rbac.Permission{{Name: "VIEW_PROFILE"}}}, IsActive: true,
+		}, nil)
+		company.On("FindMFAPermissions", ctx, uint(2)).Return(constants.DefaultMFAPermissions, nil)
+		sessions.On("Create", ctx, uint(3), uint(2), "Mozilla/5.0", "10.0.0.1").Return(&infrastructure.Session{ID: "sess-3"}, "sess-3.refresh", nil)
+		tokenProv.On("GenerateToken", "sess-3", uint(3), uint(2), false, "EMPLOYEE", uint(0), (*uint)(nil), int64(0)).Return("jwt-token", nil)
 
-	resp, err := svc.VerifyOTP(ctx, &VerifyOTPRequest{Code: "123456"})
+		resp, err := svc.CompleteExternalLogin(ctx, 3, "Mozilla/5.0", "10.0.0.1")
 
-	require.NoError(t, err)
-	assert.True(t, resp.IsValid)
+		require.NoError(t, err)
+		assert.Equal(t, "jwt-token", resp.Token)
+		assert.Equal(t, "sess-3.refresh", resp.RefreshToken)
+	})
+
+	t.Run("inactive user refused", func(t *testing.T) {
+		svc, userProv, _, _, sessions, _, _, _, _, _, _, _, _ := newTestAuthService()
+		userProv.On("FindByID", ctx, uint(3)).Return(&user.User{ID: 3, CompanyID: 2, IsActive: false}, nil)
+
+		_, err := svc.CompleteExternalLogin(ctx, 3, "", "")
+
+		assert.EqualError(t, err, "account is inactive")
+		sessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
 }
 
-func TestVerifyOTP_Invalid(t *testing.T) {
-	svc, _, _, _, _, _, _, _, _ := newTestAuthService()
+func TestRefresh(t *testing.T) {
 	ctx := context.Background()
+	session := &infrastructure.Session{ID: "sess-1", UserID: 1}
+
+	t.Run("success issues the current permissions", func(t *testing.T) {
+		svc, userProv, _, tokenProv, sessions, _, _, _, _, _, _, _, _ := newTestAuthService()
+		sessions.On("Rotate", ctx, "sess-1.old").Return(session, "sess-1.new", nil)
+		userProv.On("FindByID", ctx, uint(1)).Return(&user.User{
+			ID: 1, CompanyID: 2, IsActive: true,
+			Role: &rbac.Role{Name: "HR", Permissions: []rbac.Permission{{Name: "VIEW_EMPLOYEE"}}},
+		}, nil)
+		tokenProv.On("GenerateToken", "sess-1", uint(1), uint(2), false, "HR", uint(0), (*uint)(nil), int64(0)).Return("jwt-token", nil)
+
+		resp, err := svc.Refresh(ctx, &RefreshRequest{RefreshToken: "sess-1.old"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "jwt-token", resp.Token)
+		assert.Equal(t, "sess-1.new", resp.RefreshToken)
+	})
+
+	t.Run("token names the role and permission version", func(t *testing.T) {
+		svc, userProv, _, tokenProv, sessions, _, _, _, _, _, _, _, _ := newTestAuthService()
+		sessions.On("Rotate", ctx, "sess-1.old").Return(session, "sess-1.new", nil)
+		userProv.On("FindByID", ctx, uint(1)).Return(&user.User{
+			ID: 1, CompanyID: 2, RoleID: 4, PermissionVersion: 2, IsActive: true,
+			Role: &rbac.Role{ID: 4, Name: "HEAD", Permissions: []rbac.Permission{{ID: 1, Name: "VIEW_LEAVE"}}},
+		}, nil)
+		tokenProv.On("GenerateToken", "sess-1", uint(1), uint(2), false, "HEAD", uint(4), (*uint)(nil), int64(2)).Return("jwt-token", nil)
+
+		_, err := svc.Refresh(ctx, &RefreshRequest{RefreshToken: "sess-1.old"})
+
+		require.NoError(t, err)
+		tokenProv.AssertExpectations(t)
+	})
+
+	t.Run("reused token", func(t *testing.T) {
+		svc, _, _, _, sessions, _, _, _, _, _, _, _, _ := newTestAuthService()
+		sessions.On("Rotate", ctx, "sess-1.old").Return(nil, "", infrastructure.ErrRefreshTokenReused)
+
+		resp, err := svc.Refresh(ctx, &RefreshRequest{RefreshToken: "sess-1.old"})
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, infrastructure.ErrRefreshTokenReused)
+	})
+
+	t.Run("deactivated user is signed out everywhere", func(t *testing.T) {
+		svc, userProv, _, _, sessions, _, _, _, _, _, _, _, _ := newTestAuthService()
+		sessions.On("Rotate", ctx, "sess-1.old").Return(session, "sess-1.new", nil)
+		userProv.On("FindByID", ctx, uint(1)).Return(&user.User{ID: 1, IsActive: false}, nil)
+		sessions.On("RevokeUser", ctx, uint(1)).Return(nil)
+
+		resp, err := svc.Refresh(ctx, &RefreshRequest{RefreshToken: "sess-1.old"})
+
+		assert.Nil(t, resp)
+		assert.EqualError(t, err, "account is inactive")
+		sessions.AssertExpectations(t)
+	})
+}
+
+func TestGetSessions(t *testing.T) {
+	svc, _, _, _, sessions, _, _, _, _, _, _, _, _ := newTestAuthService()
+	ctx := context.Background()
+	now := time.Now()
+
+	sessions.On("ListByUser", ctx, uint(1)).Return([]infrastructure.Session{
+		{ID: "old", UserAgent: "laptop", LastSeenAt: now.Add(-time.Hour)},
+		{ID: "current", UserAgent: "phone", LastSeenAt: now},
+	}, nil)
 
-	resp, err := svc.VerifyOTP(ctx, &VerifyOTPRequest{Code: "000000"})
+	resp, err := svc.GetSessions(ctx, 1, "current")
 
 	require.NoError(t, err)
-	assert.True(t, resp.IsValid)
+	require.Len(t, resp, 2)
+	assert.Equal(t, "current", resp[0].ID)
+	assert.True(t, resp[0].Current)
+	assert.False(t, resp[1].Current)
 }
 
-func TestResetPassword_Success(t *testing.T) {
-	svc, userProv, hasher, _, cache, _, _, _, _ := newTestAuthService()
+func TestLogin_UserNotFound(t *testing.T) {
+	svc, userProv, _, _, _, _, _, _, _, _, _, _, _ := newTestAuthService()
 	ctx := context.Background()
 
-	cache.On("Get", ctx, "otp:test@email.com").Return("123456", nil)
-	hasher.On("HashPassword", "newpass123").Return("newhash", nil)
-	userProv.On("UpdatePasswordByEmail", ctx, "test@email.com", "newhash").Return(nil)
-	cache.On("Del", ctx, "otp:test@email.com").Return(nil)
+	userProv.On("FindByUsername", ctx, "unknown").Return(nil, errors.New("not found"))
 
-	err := svc.ResetPassword(ctx, &ResetPasswordRequest{Email: "test@email.com", Code: "123456", Password: "newpass123"})
+	resp, err := svc.Login(ctx, &LoginRequest{Username: "unknown", Password: "any"})
 
-	require.NoError(t, err)
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, "invalid credentials", err.Error())
 }
 
-func TestResetPassword_InvalidOTP(t *testing.T) {
-	svc, _, _, _, cache, _, _, _, _ := newTestAuthService()
+func TestLogin_WrongPassword(t *testing.T) {
+	svc, userProv, hasher, _, _, _, _, _, _, _, _, _, _ := newTestAuthService()
 	ctx := context.Background()
 
-	cache.On("Get", ctx, "otp:test@email.com").Return("", errors.New("not found"))
+	userProv.On("FindByUsername", ctx, "admin").Return(&user.User{
+		ID: 1, Username: "admin", PasswordHash: "hashed", Role: &rbac.Role{Name: "SUPERADMIN"},
+	}, nil)
+	hasher.On("CheckPasswordHash", "wrong", "hashed").Return(false)
 
-	err := svc.ResetPassword(ctx, &ResetPasswordRequest{Email: "test@email.com", Code: "000000", Password: "newpass123"})
+	resp, err := svc.Login(ctx, &LoginRequest{Username: "admin", Password: "wrong"})
 
 	assert.Error(t, err)
-	assert.Equal(t, "invalid or expired OTP", err.Error())
+	assert.Nil(t, resp)
+	assert.Equal(t, "invalid credentials", err.Error())
 }
+
@@ -24,3 +24,12 @@ type UpdateCompanyProfileRequest struct {
 	Website     string `form:"website"`
 	TaxNumber   string `form:"tax_number"`
 }
+
+type MFAPolicyResponse struct {
+	// users whose role holds any of these permissions must sign in with MFA
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateMFAPolicyRequest struct {
+	Permissions []string `json:"permissions" validate:"dive,required"`
+}
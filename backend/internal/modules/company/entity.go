@@ -22,3 +22,15 @@ type Company struct {
 func (Company) TableName() string {
 	return "companies"
 }
+
+// MFAPermission forces MFA on every user of the company whose role holds the permission.
+type MFAPermission struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	CompanyID      uint      `gorm:"uniqueIndex:idx_company_mfa_permission;not null" json:"company_id"`
+	PermissionName string    `gorm:"uniqueIndex:idx_company_mfa_permission;type:varchar(100);not null" json:"permission_name"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (MFAPermission) TableName() string {
+	return "company_mfa_permissions"
+}
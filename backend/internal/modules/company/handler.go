@@ -48,3 +48,34 @@ func (h *Handler) UpdateProfile(ctx echo.Context) error {
 
 	return response.NewResponses[any](ctx, http.StatusOK, "Update company profile successfully", nil, nil, nil)
 }
+
+func (h *Handler) GetMFAPolicy(ctx echo.Context) error {
+	policy, err := h.service.GetMFAPolicy(ctx.Request().Context())
+	if err != nil {
+		logger.Errorw("Get MFA policy failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Success get MFA policy", policy, nil, nil)
+}
+
+func (h *Handler) UpdateMFAPolicy(ctx echo.Context) error {
+	var req UpdateMFAPolicyRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	policy, err := h.service.UpdateMFAPolicy(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("Update MFA policy failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Update MFA policy successfully", policy, nil, nil)
+}
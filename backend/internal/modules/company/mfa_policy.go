@@ -0,0 +1,44 @@
+package company
+
+import (
+	"basekarya-backend/pkg/utils"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// GetMFAPolicy lists the permissions that force MFA on the roles holding them.
+func (s *service) GetMFAPolicy(ctx context.Context) (*MFAPolicyResponse, error) {
+	names, err := s.repo.FindMFAPermissions(ctx, utils.GetCompanyIDFromCtx(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAPolicyResponse{Permissions: append([]string{}, names...)}, nil
+}
+
+// UpdateMFAPolicy replaces the permissions that force MFA, users already signed in are asked for MFA at their
+// next login.
+func (s *service) UpdateMFAPolicy(ctx context.Context, req *UpdateMFAPolicyRequest) (*MFAPolicyResponse, error) {
+	names := append([]string{}, req.Permissions...)
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	if len(names) > 0 {
+		found, err := s.repo.FindPermissionNames(ctx, names)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if !slices.Contains(found, name) {
+				return nil, fmt.Errorf("unknown permission: %s", name)
+			}
+		}
+	}
+
+	if err := s.repo.ReplaceMFAPermissions(ctx, utils.GetCompanyIDFromCtx(ctx), names); err != nil {
+		return nil, err
+	}
+
+	return &MFAPolicyResponse{Permissions: names}, nil
+}
@@ -0,0 +1,47 @@
+package company
+
+import (
+	"testing"
+
+	"basekarya-backend/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_UpdateMFAPolicy(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("deduplicates and stores known permissions", func(t *testing.T) {
+		svc, repo, _, _ := newTestCompanyService()
+		repo.On("FindPermissionNames", mock.Anything, []string{"GENERATE_PAYROLL", "MARK_AS_PAID"}).Return([]string{"MARK_AS_PAID", "GENERATE_PAYROLL"}, nil)
+		repo.On("ReplaceMFAPermissions", mock.Anything, uint(1), []string{"GENERATE_PAYROLL", "MARK_AS_PAID"}).Return(nil)
+
+		resp, err := svc.UpdateMFAPolicy(ctx, &UpdateMFAPolicyRequest{Permissions: []string{"MARK_AS_PAID", "GENERATE_PAYROLL", "MARK_AS_PAID"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"GENERATE_PAYROLL", "MARK_AS_PAID"}, resp.Permissions)
+	})
+
+	t.Run("unknown permission", func(t *testing.T) {
+		svc, repo, _, _ := newTestCompanyService()
+		repo.On("FindPermissionNames", mock.Anything, []string{"GENERATE_PAYROLL", "PRINT_MONEY"}).Return([]string{"GENERATE_PAYROLL"}, nil)
+
+		_, err := svc.UpdateMFAPolicy(ctx, &UpdateMFAPolicyRequest{Permissions: []string{"PRINT_MONEY", "GENERATE_PAYROLL"}})
+
+		require.EqualError(t, err, "unknown permission: PRINT_MONEY")
+		repo.AssertNotCalled(t, "ReplaceMFAPermissions", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("empty policy turns enforcement off", func(t *testing.T) {
+		svc, repo, _, _ := newTestCompanyService()
+		repo.On("ReplaceMFAPermissions", mock.Anything, uint(1), []string{}).Return(nil)
+
+		resp, err := svc.UpdateMFAPolicy(ctx, &UpdateMFAPolicyRequest{})
+
+		require.NoError(t, err)
+		assert.Empty(t, resp.Permissions)
+		repo.AssertNotCalled(t, "FindPermissionNames", mock.Anything, mock.Anything)
+	})
+}
@@ -44,6 +44,26 @@ func (m *mockRepo) FindModulesByCompanyID(ctx context.Context, companyID uint) (
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *mockRepo) FindMFAPermissions(ctx context.Context, companyID uint) ([]string, error) {
+	args := m.Called(ctx, companyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockRepo) ReplaceMFAPermissions(ctx context.Context, companyID uint, names []string) error {
+	return m.Called(ctx, companyID, names).Error(0)
+}
+
+func (m *mockRepo) FindPermissionNames(ctx context.Context, names []string) ([]string, error) {
+	args := m.Called(ctx, names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 type mockCacheProvider struct{ mock.Mock }
 
 func (m *mockCacheProvider) Get(ctx context.Context, key string) (string, error) {
@@ -80,6 +100,22 @@ func (m *mockService) UpdateProfile(ctx context.Context, req *UpdateCompanyProfi
 	return m.Called(ctx, req, file).Error(0)
 }
 
+func (m *mockService) GetMFAPolicy(ctx context.Context) (*MFAPolicyResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFAPolicyResponse), args.Error(1)
+}
+
+func (m *mockService) UpdateMFAPolicy(ctx context.Context, req *UpdateMFAPolicyRequest) (*MFAPolicyResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFAPolicyResponse), args.Error(1)
+}
+
 func newTestCompanyService() (Service, *mockRepo, *mockCacheProvider, *mockStorageProvider) {
 	repo := new(mockRepo)
 	cache := new(mockCacheProvider)
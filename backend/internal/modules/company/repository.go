@@ -15,6 +15,9 @@ type Repository interface {
 	FindPlanIDBySlug(ctx context.Context, slug string) (uint, error)
 	FindPlanByCompanyID(ctx context.Context, companyID uint) (string, int, string, error)
 	FindModulesByCompanyID(ctx context.Context, companyID uint) ([]string, error)
+	FindMFAPermissions(ctx context.Context, companyID uint) ([]string, error)
+	ReplaceMFAPermissions(ctx context.Context, companyID uint, names []string) error
+	FindPermissionNames(ctx context.Context, names []string) ([]string, error)
 }
 
 type repository struct {
@@ -85,3 +88,40 @@ func (r *repository) FindModulesByCompanyID(ctx context.Context, companyID uint)
 
 	return features.Modules, nil
 }
+
+// FindMFAPermissions returns the permissions that force MFA on the roles holding them.
+func (r *repository) FindMFAPermissions(ctx context.Context, companyID uint) ([]string, error) {
+	var names []string
+	err := utils.GetDBFromContext(ctx, r.db).
+		Model(&MFAPermission{}).
+		Where("company_id = ?", companyID).
+		Order("permission_name ASC").
+		Pluck("permission_name", &names).Error
+	return names, err
+}
+
+// ReplaceMFAPermissions swaps the company's MFA permissions at once, a failed insert keeps the previous ones. Inside
+// a caller's transaction it nests as a savepoint.
+func (r *repository) ReplaceMFAPermissions(ctx context.Context, companyID uint, names []string) error {
+	return utils.GetDBFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("company_id = ?", companyID).Delete(&MFAPermission{}).Error; err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		permissions := make([]MFAPermission, 0, len(names))
+		for _, name := range names {
+			permissions = append(permissions, MFAPermission{CompanyID: companyID, PermissionName: name})
+		}
+		return tx.Create(&permissions).Error
+	})
+}
+
+// FindPermissionNames returns which of the given names are known permissions.
+func (r *repository) FindPermissionNames(ctx context.Context, names []string) ([]string, error) {
+	var found []string
+	err := r.db.Table("permissions").Where("name IN ?", names).Pluck("name", &found).Error
+	return found, err
+}
@@ -1,6 +1,8 @@
 package company
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"basekarya-backend/internal/testutil"
@@ -11,7 +13,7 @@ import (
 
 func setupCompanyTestDB(t *testing.T) *testutil.TestDB {
 	t.Helper()
-	tdb := testutil.NewTestDB(&Company{})
+	tdb := testutil.NewTestDB(&Company{}, &MFAPermission{})
 	t.Cleanup(tdb.Close)
 	return tdb
 }
@@ -91,3 +93,45 @@ func TestRepoCompany_Update(t *testing.T) {
 	assert.Equal(t, "Updated Company", updated.Name)
 	assert.Equal(t, "456 Updated St", updated.Address)
 }
+
+func TestRepoCompany_MFAPermissions(t *testing.T) {
+	tdb := setupCompanyTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedCompanyTestData(t, tdb)
+
+	require.NoError(t, repo.ReplaceMFAPermissions(ctx, 1, []string{"MARK_AS_PAID", "GENERATE_PAYROLL"}))
+	require.NoError(t, repo.ReplaceMFAPermissions(ctx, 2, []string{"VIEW_COMPANY"}))
+	require.NoError(t, repo.ReplaceMFAPermissions(ctx, 1, []string{"MARK_AS_PAID"}))
+
+	names, err := repo.FindMFAPermissions(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MARK_AS_PAID"}, names)
+
+	names, err = repo.FindMFAPermissions(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"VIEW_COMPANY"}, names)
+
+	t.Run("failed insert keeps the previous permissions", func(t *testing.T) {
+		require.Error(t, repo.ReplaceMFAPermissions(ctx, 1, []string{"GENERATE_PAYROLL", "GENERATE_PAYROLL"}))
+
+		names, err := repo.FindMFAPermissions(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"MARK_AS_PAID"}, names)
+	})
+
+	t.Run("nested in a rolled back transaction", func(t *testing.T) {
+		tm := testutil.NewTestTransactionManager(tdb.DB)
+
+		err := tm.RunInTransaction(ctx, func(ctx context.Context) error {
+			require.NoError(t, repo.ReplaceMFAPermissions(ctx, 1, []string{"GENERATE_PAYROLL"}))
+			return errors.New("registration failed")
+		})
+
+		require.Error(t, err)
+		names, err := repo.FindMFAPermissions(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"MARK_AS_PAID"}, names)
+	})
+}
@@ -15,6 +15,8 @@ import (
 type Service interface {
 	GetProfile(ctx context.Context) (*CompanyProfileResponse, error)
 	UpdateProfile(ctx context.Context, req *UpdateCompanyProfileRequest, file *multipart.FileHeader) error
+	GetMFAPolicy(ctx context.Context) (*MFAPolicyResponse, error)
+	UpdateMFAPolicy(ctx context.Context, req *UpdateMFAPolicyRequest) (*MFAPolicyResponse, error)
 }
 
 type service struct {
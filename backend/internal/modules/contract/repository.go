@@ -3,6 +3,7 @@ package contract
 import (
 	"context"
 
+	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/pkg/utils"
 
 	"gorm.io/gorm"
@@ -70,7 +71,7 @@ func (r *repository) FindAll(ctx context.Context, filter *ContractFilter) ([]Con
 
 	if filter.Search != "" {
 		query = query.Joins("JOIN employees ON employees.id = contracts.employee_id").
-			Where("employees.full_name LIKE ? OR employees.nik LIKE ?", "%"+filter.Search+"%", "%"+filter.Search+"%")
+			Where("employees.full_name LIKE ? OR employees.nik_bidx = ?", "%"+filter.Search+"%", infrastructure.BlindIndex(filter.Search))
 	}
 
 	if filter.ExpiringWithinDays > 0 {
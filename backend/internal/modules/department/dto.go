@@ -1,14 +1,17 @@
 package department
 
 type LookupResponse struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID             uint   `json:"id"`
+	Name           string `json:"name"`
+	HeadEmployeeID *uint  `json:"head_employee_id"`
 }
 
 type CreateDepartmentRequest struct {
-	Name string `json:"name" validate:"required,min=1,max=100"`
+	Name           string `json:"name" validate:"required,min=1,max=100"`
+	HeadEmployeeID *uint  `json:"head_employee_id"`
 }
 
 type UpdateDepartmentRequest struct {
-	Name string `json:"name" validate:"required,min=1,max=100"`
+	Name           string `json:"name" validate:"required,min=1,max=100"`
+	HeadEmployeeID *uint  `json:"head_employee_id"` // nil keeps the current head, 0 removes it
 }
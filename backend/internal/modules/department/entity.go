@@ -7,6 +7,8 @@ type Department struct {
 	Name      string    `gorm:"not null" json:"name"`
 	CompanyID uint      `gorm:"index;not null" json:"company_id"`
 	CreatedAt time.Time `json:"created_at"`
+
+	HeadEmployeeID *uint `gorm:"index" json:"head_employee_id"`
 }
 
 func (Department) TableName() string {
@@ -47,6 +47,10 @@ func (m *mockRepo) ExistsByName(ctx context.Context, name string, excludeID uint
 	args := m.Called(ctx, name, excludeID)
 	return args.Bool(0), args.Error(1)
 }
+func (m *mockRepo) ExistsEmployee(ctx context.Context, employeeID uint) (bool, error) {
+	args := m.Called(ctx, employeeID)
+	return args.Bool(0), args.Error(1)
+}
 
 type mockCacheProvider struct{ mock.Mock }
 
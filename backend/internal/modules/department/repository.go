@@ -18,6 +18,7 @@ type Repository interface {
 	Delete(ctx context.Context, id uint) error
 	CountEmployees(ctx context.Context, departmentID uint) (int64, error)
 	ExistsByName(ctx context.Context, name string, excludeID uint) (bool, error)
+	ExistsEmployee(ctx context.Context, employeeID uint) (bool, error)
 }
 
 type repository struct {
@@ -100,3 +101,12 @@ func (r *repository) ExistsByName(ctx context.Context, name string, excludeID ui
 	}
 	return count > 0, nil
 }
+
+func (r *repository) ExistsEmployee(ctx context.Context, employeeID uint) (bool, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var count int64
+	if err := db.Table("employees").Where("id = ?", employeeID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
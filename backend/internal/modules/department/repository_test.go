@@ -136,3 +136,21 @@ func TestRepo_ExistsByName(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, exists)
 }
+
+func TestRepo_ExistsEmployee(t *testing.T) {
+	tdb := setupDeptTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	require.NoError(t, tdb.DB.Create(&testEmployee{ID: 10, CompanyID: 1}).Error)
+	require.NoError(t, tdb.DB.Create(&testEmployee{ID: 20, CompanyID: 2}).Error)
+
+	exists, err := repo.ExistsEmployee(ctx, 10)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// other tenant's employee cannot head this company's department
+	exists, err = repo.ExistsEmployee(ctx, 20)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
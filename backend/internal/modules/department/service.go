@@ -39,7 +39,7 @@ func (s *service) GetAll(ctx context.Context) ([]LookupResponse, error) {
 		}
 
 		for _, d := range data {
-			results = append(results, LookupResponse{ID: d.ID, Name: d.Name})
+			results = append(results, LookupResponse{ID: d.ID, Name: d.Name, HeadEmployeeID: d.HeadEmployeeID})
 		}
 
 		parsedData, err := json.Marshal(&results)
@@ -69,7 +69,7 @@ func (s *service) GetByID(ctx context.Context, id uint) (*LookupResponse, error)
 	if err != nil {
 		return nil, err
 	}
-	return &LookupResponse{ID: dept.ID, Name: dept.Name}, nil
+	return &LookupResponse{ID: dept.ID, Name: dept.Name, HeadEmployeeID: dept.HeadEmployeeID}, nil
 }
 
 func (s *service) Create(ctx context.Context, req *CreateDepartmentRequest) (*LookupResponse, error) {
@@ -85,13 +85,20 @@ func (s *service) Create(ctx context.Context, req *CreateDepartmentRequest) (*Lo
 		Name:      req.Name,
 		CompanyID: utils.GetCompanyIDFromCtx(ctx),
 	}
+	if req.HeadEmployeeID != nil {
+		dept.HeadEmployeeID, err = s.resolveHead(ctx, *req.HeadEmployeeID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.repo.Create(ctx, dept); err != nil {
 		return nil, err
 	}
 
 	_ = s.cache.Del(context.Background(), constants.DEPARTMEN_CACHE_KEY)
 
-	return &LookupResponse{ID: dept.ID, Name: dept.Name}, nil
+	return &LookupResponse{ID: dept.ID, Name: dept.Name, HeadEmployeeID: dept.HeadEmployeeID}, nil
 }
 
 func (s *service) Update(ctx context.Context, id uint, req *UpdateDepartmentRequest) (*LookupResponse, error) {
@@ -112,13 +119,20 @@ func (s *service) Update(ctx context.Context, id uint, req *UpdateDepartmentRequ
 	}
 
 	dept.Name = req.Name
+	if req.HeadEmployeeID != nil {
+		dept.HeadEmployeeID, err = s.resolveHead(ctx, *req.HeadEmployeeID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.repo.Update(ctx, dept); err != nil {
 		return nil, err
 	}
 
 	_ = s.cache.Del(context.Background(), constants.DEPARTMEN_CACHE_KEY)
 
-	return &LookupResponse{ID: dept.ID, Name: dept.Name}, nil
+	return &LookupResponse{ID: dept.ID, Name: dept.Name, HeadEmployeeID: dept.HeadEmployeeID}, nil
 }
 
 func (s *service) Delete(ctx context.Context, id uint) error {
@@ -138,3 +152,20 @@ func (s *service) Delete(ctx context.Context, id uint) error {
 
 	return nil
 }
+
+// resolveHead checks the department head belongs to the company, 0 means the department has no head.
+func (s *service) resolveHead(ctx context.Context, employeeID uint) (*uint, error) {
+	if employeeID == 0 {
+		return nil, nil
+	}
+
+	exists, err := s.repo.ExistsEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("head employee not found")
+	}
+
+	return &employeeID, nil
+}
@@ -93,6 +93,50 @@ func TestService_Update(t *testing.T) {
 	assert.Equal(t, "Tech", result.Name)
 }
 
+func TestService_Update_Head(t *testing.T) {
+	ctx := context.Background()
+	headID := uint(7)
+	noHead := uint(0)
+
+	tests := []struct {
+		name     string
+		head     *uint
+		current  *uint
+		exists   bool
+		wantHead *uint
+		errMsg   string
+	}{
+		{name: "assigns the head", head: &headID, exists: true, wantHead: &headID},
+		{name: "keeps the head when omitted", current: &headID, wantHead: &headID},
+		{name: "zero removes the head", head: &noHead, current: &headID, wantHead: nil},
+		{name: "unknown employee", head: &headID, exists: false, errMsg: "head employee not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			cache := new(mockCacheProvider)
+
+			repo.On("FindByID", mock.Anything, uint(1)).Return(&Department{ID: 1, Name: "IT", HeadEmployeeID: tt.current}, nil)
+			repo.On("ExistsByName", mock.Anything, "IT", uint(1)).Return(false, nil)
+			repo.On("ExistsEmployee", mock.Anything, headID).Return(tt.exists, nil)
+			repo.On("Update", mock.Anything, mock.AnythingOfType("*department.Department")).Return(nil)
+			cache.On("Del", mock.Anything, "department:all").Return(nil)
+
+			svc := NewService(repo, cache)
+			result, err := svc.Update(ctx, 1, &UpdateDepartmentRequest{Name: "IT", HeadEmployeeID: tt.head})
+
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantHead, result.HeadEmployeeID)
+		})
+	}
+}
+
 func TestService_Delete(t *testing.T) {
 	ctx := context.Background()
 	repo := new(mockRepo)
@@ -1,6 +1,9 @@
 package finance
 
-import "context"
+import (
+	"basekarya-backend/internal/modules/approval"
+	"context"
+)
 
 type NotificationProvider interface {
 	SendNotification(ctx context.Context, userID uint,
@@ -15,6 +18,7 @@ type NotificationProvider interface {
 		relatedID uint) error
 }
 
-type UserProvider interface {
-	FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error)
+type ApprovalEngine interface {
+	Submit(ctx context.Context, req *approval.SubmitRequest) ([]uint, error)
+	Decide(ctx context.Context, req *approval.DecideRequest) (*approval.Decision, error)
 }
@@ -3,6 +3,7 @@ package finance
 import (
 	"context"
 
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/pkg/response"
 
 	"github.com/stretchr/testify/mock"
@@ -79,13 +80,21 @@ func (m *mockNotificationProvider) BlastNotification(ctx context.Context, userID
 	return m.Called(ctx, userIDs, notifType, title, message, relatedID).Error(0)
 }
 
-type mockUserProvider struct{ mock.Mock }
+type mockApprovalEngine struct{ mock.Mock }
 
-func (m *mockUserProvider) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
-	args := m.Called(ctx, permissionApprovalName)
+func (m *mockApprovalEngine) Submit(ctx context.Context, req *approval.SubmitRequest) ([]uint, error) {
+	args := m.Called(ctx, req)
 	return args.Get(0).([]uint), args.Error(1)
 }
 
+func (m *mockApprovalEngine) Decide(ctx context.Context, req *approval.DecideRequest) (*approval.Decision, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*approval.Decision), args.Error(1)
+}
+
 type mockExcel struct{ mock.Mock }
 
 func (m *mockExcel) GenerateSimpleExcel(sheetName string, headers []string, rows [][]interface{}) ([]byte, error) {
@@ -21,6 +21,7 @@ import (
 
 func setupFinanceTestDB(t *testing.T) *testutil.TestDB {
 	t.Helper()
+	testutil.UseTestKeyring()
 
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
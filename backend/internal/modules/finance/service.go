@@ -2,6 +2,7 @@ package finance
 
 import (
 	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
 	"basekarya-backend/pkg/utils"
@@ -31,13 +32,13 @@ type Service interface {
 type service struct {
 	repo               Repository
 	notification       NotificationProvider
-	user               UserProvider
+	approval           ApprovalEngine
 	transactionManager infrastructure.TransactionManager
 	excel              infrastructure.ExcelProvider
 }
 
-func NewService(repo Repository, notification NotificationProvider, user UserProvider, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
-	return &service{repo, notification, user, transactionManager, excel}
+func NewService(repo Repository, notification NotificationProvider, approval ApprovalEngine, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
+	return &service{repo, notification, approval, transactionManager, excel}
 }
 
 func (s *service) CreateTransaction(ctx context.Context, req *CreateTransactionRequest) error {
@@ -84,7 +85,12 @@ func (s *service) CreateTransaction(ctx context.Context, req *CreateTransactionR
 			return err
 		}
 
-		approvalUserIDs, err := s.user.FindApprovalUsers(ctx, string(constants.APPROVAL_FINANCE))
+		approvalUserIDs, err := s.approval.Submit(ctx, &approval.SubmitRequest{
+			RequestType:     constants.ApprovalRequestFinance,
+			ReferenceID:     tx.ID,
+			RequesterUserID: req.CreatedBy,
+			Amount:          req.Amount,
+		})
 		if err != nil {
 			return err
 		}
@@ -210,8 +216,37 @@ func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 			notificationMessage string
 		)
 
+		decideReq := &approval.DecideRequest{
+			RequestType:     constants.ApprovalRequestFinance,
+			ReferenceID:     data.ID,
+			RequesterUserID: data.CreatedBy,
+			Amount:          data.Amount,
+			ActorUserID:     req.SuperAdminID,
+		}
+
 		switch constants.FinanceAction(req.Action) {
 		case constants.FinanceActionApprove:
+			decideReq.Action = constants.ApprovalActionApprove
+			decision, err := s.approval.Decide(ctx, decideReq)
+			if err != nil {
+				return err
+			}
+
+			// the transaction stays pending until the last level of the approval chain approves it
+			if decision.Status == constants.ApprovalStatusPending {
+				go func() {
+					_ = s.notification.BlastNotification(
+						utils.DetachContext(ctx),
+						decision.NextApproverIDs,
+						string(constants.NotificationTypeFinanceApprovalReq),
+						"Persetujuan Transaksi Keuangan Lanjutan",
+						fmt.Sprintf("Transaksi keuangan %s sebesar Rp%.0f menunggu persetujuan Anda", data.Type, data.Amount),
+						data.ID,
+					)
+				}()
+				return nil
+			}
+
 			data.Status = constants.FinanceStatusApproved
 			data.ApprovedBy = &req.SuperAdminID
 
@@ -225,6 +260,12 @@ func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 				return fmt.Errorf("rejection reason is required")
 			}
 
+			decideReq.Action = constants.ApprovalActionReject
+			decideReq.Notes = req.RejectionReason
+			if _, err := s.approval.Decide(ctx, decideReq); err != nil {
+				return err
+			}
+
 			data.RejectionReason.String = req.RejectionReason
 			data.RejectionReason.Valid = true
 
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/internal/testutil"
 	"basekarya-backend/pkg/constants"
@@ -17,15 +18,15 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func newTestFinanceService() (Service, *mockRepo, *mockNotificationProvider, *mockUserProvider, *testutil.MockTransactionManager, *mockExcel) {
+func newTestFinanceService() (Service, *mockRepo, *mockNotificationProvider, *mockApprovalEngine, *testutil.MockTransactionManager, *mockExcel) {
 	repo := new(mockRepo)
 	notif := new(mockNotificationProvider)
-	userProv := new(mockUserProvider)
+	approvalEngine := new(mockApprovalEngine)
 	tm := testutil.NewMockTransactionManager()
 	excel := new(mockExcel)
 
-	svc := NewService(repo, notif, userProv, tm, excel)
-	return svc, repo, notif, userProv, tm, excel
+	svc := NewService(repo, notif, approvalEngine, tm, excel)
+	return svc, repo, notif, approvalEngine, tm, excel
 }
 
 func TestService_CreateTransaction(t *testing.T) {
@@ -34,7 +35,7 @@ func TestService_CreateTransaction(t *testing.T) {
 	tests := []struct {
 		name       string
 		req        *CreateTransactionRequest
-		setupMocks func(*mockRepo, *mockUserProvider, *mockNotificationProvider)
+		setupMocks func(*mockRepo, *mockApprovalEngine, *mockNotificationProvider)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -47,10 +48,12 @@ func TestService_CreateTransaction(t *testing.T) {
 				Amount:            5000000,
 				TransactionDate:   "2026-01-15",
 			},
-			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotificationProvider) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine, notif *mockNotificationProvider) {
 				repo.On("FindCategoryByID", mock.Anything, uint(1)).Return(&FinanceCategory{ID: 1, Name: "Salary"}, nil)
 				repo.On("CreateTransaction", mock.Anything, mock.AnythingOfType("*finance.FinanceTransaction")).Return(nil)
-				userProv.On("FindApprovalUsers", mock.Anything, string(constants.APPROVAL_FINANCE)).Return([]uint{10, 11}, nil)
+				approvalEngine.On("Submit", mock.Anything, mock.MatchedBy(func(req *approval.SubmitRequest) bool {
+					return req.RequestType == constants.ApprovalRequestFinance && req.RequesterUserID == 1
+				})).Return([]uint{10, 11}, nil)
 				notif.On("BlastNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
@@ -64,7 +67,7 @@ func TestService_CreateTransaction(t *testing.T) {
 				Amount:            5000000,
 				TransactionDate:   "2026-01-15",
 			},
-			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotificationProvider) {},
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine, notif *mockNotificationProvider) {},
 			wantErr:    true,
 			errMsg:     "user not found",
 		},
@@ -77,7 +80,7 @@ func TestService_CreateTransaction(t *testing.T) {
 				Amount:            5000000,
 				TransactionDate:   "not-a-date",
 			},
-			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotificationProvider) {},
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine, notif *mockNotificationProvider) {},
 			wantErr:    true,
 			errMsg:     "invalid transaction_date format, use YYYY-MM-DD",
 		},
@@ -90,7 +93,7 @@ func TestService_CreateTransaction(t *testing.T) {
 				Amount:            5000000,
 				TransactionDate:   "2026-01-15",
 			},
-			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotificationProvider) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine, notif *mockNotificationProvider) {
 				repo.On("FindCategoryByID", mock.Anything, uint(99)).Return(nil, gorm.ErrRecordNotFound)
 			},
 			wantErr: true,
@@ -105,7 +108,7 @@ func TestService_CreateTransaction(t *testing.T) {
 				Amount:            5000000,
 				TransactionDate:   "2026-01-15",
 			},
-			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotificationProvider) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine, notif *mockNotificationProvider) {
 				repo.On("FindCategoryByID", mock.Anything, uint(1)).Return(&FinanceCategory{ID: 1}, nil)
 				repo.On("CreateTransaction", mock.Anything, mock.AnythingOfType("*finance.FinanceTransaction")).Return(errors.New("db error"))
 			},
@@ -116,8 +119,8 @@ func TestService_CreateTransaction(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, notif, userProv, _, _ := newTestFinanceService()
-			tt.setupMocks(repo, userProv, notif)
+			svc, repo, notif, approvalEngine, _, _ := newTestFinanceService()
+			tt.setupMocks(repo, approvalEngine, notif)
 
 			err := svc.CreateTransaction(ctx, tt.req)
 
@@ -260,7 +263,7 @@ func TestService_ProcessAction(t *testing.T) {
 	tests := []struct {
 		name       string
 		req        *ActionRequest
-		setupMocks func(*mockRepo, *mockNotificationProvider)
+		setupMocks func(*mockRepo, *mockNotificationProvider, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -271,13 +274,14 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 2,
 				Action:       string(constants.FinanceActionApprove),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider, approvalEngine *mockApprovalEngine) {
 				repo.On("FindTransactionByID", mock.Anything, uint(1)).Return(&FinanceTransaction{
 					ID: 1, CreatedBy: 1, Status: constants.FinanceStatusPending, Type: constants.FinanceTypeIncome,
 					RejectionReason: sql.NullString{Valid: false},
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.AnythingOfType("*approval.DecideRequest")).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
 				repo.On("UpdateTransaction", mock.Anything, mock.AnythingOfType("*finance.FinanceTransaction")).Return(nil)
-				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 			},
 			wantErr: false,
 		},
@@ -289,13 +293,16 @@ func TestService_ProcessAction(t *testing.T) {
 				Action:          string(constants.FinanceActionReject),
 				RejectionReason: "Invalid",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider, approvalEngine *mockApprovalEngine) {
 				repo.On("FindTransactionByID", mock.Anything, uint(2)).Return(&FinanceTransaction{
 					ID: 2, CreatedBy: 1, Status: constants.FinanceStatusPending, Type: constants.FinanceTypeIncome,
 					RejectionReason: sql.NullString{Valid: false},
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.Action == constants.ApprovalActionReject && req.Notes == "Invalid"
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusRejected}, nil)
 				repo.On("UpdateTransaction", mock.Anything, mock.AnythingOfType("*finance.FinanceTransaction")).Return(nil)
-				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 			},
 			wantErr: false,
 		},
@@ -306,7 +313,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 2,
 				Action:       string(constants.FinanceActionApprove),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider, approvalEngine *mockApprovalEngine) {
 				repo.On("FindTransactionByID", mock.Anything, uint(3)).Return(&FinanceTransaction{
 					ID: 3, Status: constants.FinanceStatusApproved, Type: constants.FinanceTypeIncome,
 					RejectionReason: sql.NullString{Valid: false},
@@ -322,7 +329,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 2,
 				Action:       string(constants.FinanceActionReject),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider, approvalEngine *mockApprovalEngine) {
 				repo.On("FindTransactionByID", mock.Anything, uint(4)).Return(&FinanceTransaction{
 					ID: 4, Status: constants.FinanceStatusPending, Type: constants.FinanceTypeIncome,
 					RejectionReason: sql.NullString{Valid: false},
@@ -338,7 +345,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 2,
 				Action:       "INVALID",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider, approvalEngine *mockApprovalEngine) {
 				repo.On("FindTransactionByID", mock.Anything, uint(5)).Return(&FinanceTransaction{
 					ID: 5, Status: constants.FinanceStatusPending, Type: constants.FinanceTypeIncome,
 					RejectionReason: sql.NullString{Valid: false},
@@ -354,18 +361,54 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 2,
 				Action:       string(constants.FinanceActionApprove),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider, approvalEngine *mockApprovalEngine) {
 				repo.On("FindTransactionByID", mock.Anything, uint(99)).Return(nil, errors.New("not found"))
 			},
 			wantErr: true,
 			errMsg:  "not found",
 		},
+		{
+			name: "approve waits for the next approval level",
+			req: &ActionRequest{
+				ID:           6,
+				SuperAdminID: 2,
+				Action:       string(constants.FinanceActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider, approvalEngine *mockApprovalEngine) {
+				repo.On("FindTransactionByID", mock.Anything, uint(6)).Return(&FinanceTransaction{
+					ID: 6, CreatedBy: 1, Status: constants.FinanceStatusPending, Type: constants.FinanceTypeExpense, Amount: 50000000,
+					RejectionReason: sql.NullString{Valid: false},
+				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.Amount == 50000000 && req.RequesterUserID == 1
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusPending, NextApproverIDs: []uint{20}}, nil)
+				notif.On("BlastNotification", mock.Anything, []uint{20}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+			},
+			wantErr: false,
+		},
+		{
+			name: "error actor is not an approver",
+			req: &ActionRequest{
+				ID:           7,
+				SuperAdminID: 2,
+				Action:       string(constants.FinanceActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, notif *mockNotificationProvider, approvalEngine *mockApprovalEngine) {
+				repo.On("FindTransactionByID", mock.Anything, uint(7)).Return(&FinanceTransaction{
+					ID: 7, CreatedBy: 1, Status: constants.FinanceStatusPending, Type: constants.FinanceTypeIncome,
+					RejectionReason: sql.NullString{Valid: false},
+				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(nil, errors.New("you are not an approver of the current approval step"))
+			},
+			wantErr: true,
+			errMsg:  "you are not an approver of the current approval step",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, notif, _, _, _ := newTestFinanceService()
-			tt.setupMocks(repo, notif)
+			svc, repo, notif, approvalEngine, _, _ := newTestFinanceService()
+			tt.setupMocks(repo, notif, approvalEngine)
 
 			err := svc.ProcessAction(ctx, tt.req)
 
@@ -0,0 +1,51 @@
+package inbox
+
+import (
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/asset"
+	"basekarya-backend/internal/modules/finance"
+	"basekarya-backend/internal/modules/leave"
+	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/overtime"
+	"basekarya-backend/internal/modules/reimbursement"
+	"basekarya-backend/pkg/response"
+	"context"
+)
+
+type ApprovalProvider interface {
+	GetPendingApprovals(ctx context.Context, filter *approval.PendingApprovalFilter) ([]approval.PendingApprovalResponse, *response.Meta, error)
+}
+
+type ModuleAccessProvider interface {
+	HasModule(ctx context.Context, moduleName string) (bool, error)
+}
+
+type LeaveProvider interface {
+	GetDetail(ctx context.Context, id uint) (*leave.LeaveRequestDetailResponse, error)
+	RequestAction(ctx context.Context, req *leave.LeaveActionRequest) error
+}
+
+type LoanProvider interface {
+	GetLoanDetail(ctx context.Context, id uint) (*loan.LoanDetailResponse, error)
+	ProcessAction(ctx context.Context, req *loan.ActionRequest) error
+}
+
+type OvertimeProvider interface {
+	GetDetail(ctx context.Context, id uint) (*overtime.OvertimeDetailResponse, error)
+	ProcessAction(ctx context.Context, req *overtime.ActionRequest) error
+}
+
+type ReimbursementProvider interface {
+	GetReimburseDetail(ctx context.Context, id uint) (*reimbursement.ReimbursementDetailResponse, error)
+	ProcessAction(ctx context.Context, req *reimbursement.ActionRequest) error
+}
+
+type FinanceProvider interface {
+	GetTransactionDetail(ctx context.Context, id uint) (*finance.TransactionDetailResponse, error)
+	ProcessAction(ctx context.Context, req *finance.ActionRequest) error
+}
+
+type AssetProvider interface {
+	GetAssignmentDetail(ctx context.Context, id uint) (*asset.AssetAssignmentDetailResponse, error)
+	ProcessAction(ctx context.Context, req *asset.ActionRequest) error
+}
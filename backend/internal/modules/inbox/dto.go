@@ -0,0 +1,60 @@
+package inbox
+
+import (
+	"basekarya-backend/pkg/constants"
+	"time"
+)
+
+type InboxFilter struct {
+	Page        int    `json:"page"`
+	Limit       int    `json:"limit"`
+	RequestType string `json:"request_type"`
+	UserID      uint   `json:"-"`
+}
+
+type InboxItemResponse struct {
+	ApprovalID      uint                          `json:"approval_id"`
+	RequestType     constants.ApprovalRequestType `json:"request_type"`
+	ReferenceID     uint                          `json:"reference_id"`
+	RequesterUserID uint                          `json:"requester_user_id"`
+	RequesterName   string                        `json:"requester_name"`
+	Title           string                        `json:"title"`
+	// money involved in loans, reimbursements and finance transactions
+	Amount float64 `json:"amount"`
+	// leave days requested, 0 for other request types
+	TotalDays    float64    `json:"total_days"`
+	StartDate    string     `json:"start_date"`
+	EndDate      string     `json:"end_date"`
+	CurrentLevel int        `json:"current_level"`
+	SubmittedAt  time.Time  `json:"submitted_at"`
+	AgeHours     int        `json:"age_hours"`
+	DueAt        *time.Time `json:"due_at"`
+	IsOverdue    bool       `json:"is_overdue"`
+}
+
+type BulkActionRequest struct {
+	Action string           `json:"action" validate:"required,oneof=APPROVE REJECT"`
+	Reason string           `json:"reason" validate:"max=500"`
+	Items  []BulkActionItem `json:"items" validate:"required,min=1,max=50,dive"`
+	// approves leave despite WARN conflict rules, BLOCK rules still refuse
+	AcknowledgeConflicts bool `json:"acknowledge_conflicts"`
+	UserID               uint `json:"-"`
+}
+
+type BulkActionItem struct {
+	RequestType string `json:"request_type" validate:"required"`
+	ReferenceID uint   `json:"reference_id" validate:"required"`
+}
+
+type BulkActionResponse struct {
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []BulkActionResult `json:"results"`
+}
+
+type BulkActionResult struct {
+	RequestType string `json:"request_type"`
+	ReferenceID uint   `json:"reference_id"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+}
@@ -0,0 +1,79 @@
+package inbox
+
+import (
+	"basekarya-backend/pkg/logger"
+	"basekarya-backend/pkg/response"
+	"basekarya-backend/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service}
+}
+
+func (h *Handler) GetInbox(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	page, _ := strconv.Atoi(ctx.QueryParam("page"))
+	limit, _ := strconv.Atoi(ctx.QueryParam("limit"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	filter := InboxFilter{
+		Page:        page,
+		Limit:       limit,
+		RequestType: ctx.QueryParam("request_type"),
+		UserID:      userContext.UserID,
+	}
+
+	data, meta, err := h.service.GetInbox(ctx.Request().Context(), &filter)
+	if err != nil {
+		logger.Errorw("get approval inbox failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Approval Inbox Success", data, nil, meta)
+}
+
+func (h *Handler) BulkAction(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req BulkActionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.UserID = userContext.UserID
+
+	data, err := h.service.BulkAction(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("bulk approval action failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Bulk Approval Action Success", data, nil, nil)
+}
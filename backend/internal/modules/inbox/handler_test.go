@@ -0,0 +1,87 @@
+package inbox
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_GetInbox(t *testing.T) {
+	svc := new(mockService)
+	svc.On("GetInbox", mock.Anything, mock.MatchedBy(func(f *InboxFilter) bool {
+		return f.UserID == 5 && f.Page == 1 && f.Limit == 10 && f.RequestType == "leave"
+	})).Return([]InboxItemResponse{}, (*response.Meta)(nil), nil)
+	handler := NewHandler(svc)
+
+	at := testutil.NewAPITest(t, http.MethodGet, "/api/approvals/inbox?request_type=leave", nil)
+	at.WithAuthContext(&infrastructure.MyClaims{UserID: 5, CompanyID: 1})
+
+	rec, err := at.Execute(handler.GetInbox)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_BulkAction(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: BulkActionRequest{Action: "APPROVE", Items: []BulkActionItem{{RequestType: "LEAVE", ReferenceID: 10}}},
+			setupMocks: func(svc *mockService) {
+				svc.On("BulkAction", mock.Anything, mock.MatchedBy(func(req *BulkActionRequest) bool {
+					return req.UserID == 5 && len(req.Items) == 1
+				})).Return(&BulkActionResponse{Succeeded: 1}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing items",
+			body:       BulkActionRequest{Action: "APPROVE"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid action",
+			body:       BulkActionRequest{Action: "HOLD", Items: []BulkActionItem{{RequestType: "LEAVE", ReferenceID: 10}}},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: BulkActionRequest{Action: "REJECT", Items: []BulkActionItem{{RequestType: "LEAVE", ReferenceID: 10}}},
+			setupMocks: func(svc *mockService) {
+				svc.On("BulkAction", mock.Anything, mock.Anything).Return(nil, errors.New("rejection reason is required"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/approvals/inbox/actions", tt.body)
+			at.WithAuthContext(&infrastructure.MyClaims{UserID: 5, CompanyID: 1})
+
+			rec, err := at.Execute(handler.BulkAction)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package inbox
+
+import (
+	"context"
+
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/asset"
+	"basekarya-backend/internal/modules/finance"
+	"basekarya-backend/internal/modules/leave"
+	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/overtime"
+	"basekarya-backend/internal/modules/reimbursement"
+	"basekarya-backend/pkg/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockApproval struct{ mock.Mock }
+
+func (m *mockApproval) GetPendingApprovals(ctx context.Context, filter *approval.PendingApprovalFilter) ([]approval.PendingApprovalResponse, *response.Meta, error) {
+	args := m.Called(ctx, filter)
+	var meta *response.Meta
+	if args.Get(1) != nil {
+		meta = args.Get(1).(*response.Meta)
+	}
+	return args.Get(0).([]approval.PendingApprovalResponse), meta, args.Error(2)
+}
+
+type mockModuleAccess struct{ mock.Mock }
+
+func (m *mockModuleAccess) HasModule(ctx context.Context, moduleName string) (bool, error) {
+	args := m.Called(ctx, moduleName)
+	return args.Bool(0), args.Error(1)
+}
+
+type mockLeave struct{ mock.Mock }
+
+func (m *mockLeave) GetDetail(ctx context.Context, id uint) (*leave.LeaveRequestDetailResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*leave.LeaveRequestDetailResponse), args.Error(1)
+}
+
+func (m *mockLeave) RequestAction(ctx context.Context, req *leave.LeaveActionRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+type mockLoan struct{ mock.Mock }
+
+func (m *mockLoan) GetLoanDetail(ctx context.Context, id uint) (*loan.LoanDetailResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*loan.LoanDetailResponse), args.Error(1)
+}
+
+func (m *mockLoan) ProcessAction(ctx context.Context, req *loan.ActionRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+type mockOvertime struct{ mock.Mock }
+
+func (m *mockOvertime) GetDetail(ctx context.Context, id uint) (*overtime.OvertimeDetailResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*overtime.OvertimeDetailResponse), args.Error(1)
+}
+
+func (m *mockOvertime) ProcessAction(ctx context.Context, req *overtime.ActionRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+type mockReimbursement struct{ mock.Mock }
+
+func (m *mockReimbursement) GetReimburseDetail(ctx context.Context, id uint) (*reimbursement.ReimbursementDetailResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*reimbursement.ReimbursementDetailResponse), args.Error(1)
+}
+
+func (m *mockReimbursement) ProcessAction(ctx context.Context, req *reimbursement.ActionRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+type mockFinance struct{ mock.Mock }
+
+func (m *mockFinance) GetTransactionDetail(ctx context.Context, id uint) (*finance.TransactionDetailResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*finance.TransactionDetailResponse), args.Error(1)
+}
+
+func (m *mockFinance) ProcessAction(ctx context.Context, req *finance.ActionRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+type mockAsset struct{ mock.Mock }
+
+func (m *mockAsset) GetAssignmentDetail(ctx context.Context, id uint) (*asset.AssetAssignmentDetailResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*asset.AssetAssignmentDetailResponse), args.Error(1)
+}
+
+func (m *mockAsset) ProcessAction(ctx context.Context, req *asset.ActionRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+// For handler tests
+type mockService struct{ mock.Mock }
+
+func (m *mockService) GetInbox(ctx context.Context, filter *InboxFilter) ([]InboxItemResponse, *response.Meta, error) {
+	args := m.Called(ctx, filter)
+	var meta *response.Meta
+	if args.Get(1) != nil {
+		meta = args.Get(1).(*response.Meta)
+	}
+	return args.Get(0).([]InboxItemResponse), meta, args.Error(2)
+}
+
+func (m *mockService) BulkAction(ctx context.Context, req *BulkActionRequest) (*BulkActionResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*BulkActionResponse), args.Error(1)
+}
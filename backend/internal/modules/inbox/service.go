@@ -0,0 +1,243 @@
+package inbox
+
+import (
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/asset"
+	"basekarya-backend/internal/modules/finance"
+	"basekarya-backend/internal/modules/leave"
+	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/overtime"
+	"basekarya-backend/internal/modules/reimbursement"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/logger"
+	"basekarya-backend/pkg/response"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Service interface {
+	GetInbox(ctx context.Context, filter *InboxFilter) ([]InboxItemResponse, *response.Meta, error)
+	BulkAction(ctx context.Context, req *BulkActionRequest) (*BulkActionResponse, error)
+}
+
+var timeNow = time.Now
+
+// requestModules maps every request type the inbox aggregates to the subscription module it belongs to.
+var requestModules = []struct {
+	requestType constants.ApprovalRequestType
+	module      string
+}{
+	{constants.ApprovalRequestLeave, "leave"},
+	{constants.ApprovalRequestLoan, "loan"},
+	{constants.ApprovalRequestOvertime, "overtime"},
+	{constants.ApprovalRequestReimbursement, "reimbursement"},
+	{constants.ApprovalRequestFinance, "finance"},
+	{constants.ApprovalRequestAssetAssignment, "asset"},
+}
+
+type service struct {
+	approval      ApprovalProvider
+	subscription  ModuleAccessProvider
+	leave         LeaveProvider
+	loan          LoanProvider
+	overtime      OvertimeProvider
+	reimbursement ReimbursementProvider
+	finance       FinanceProvider
+	asset         AssetProvider
+}
+
+func NewService(approval ApprovalProvider, subscription ModuleAccessProvider, leave LeaveProvider, loan LoanProvider, overtime OvertimeProvider, reimbursement ReimbursementProvider, finance FinanceProvider, asset AssetProvider) Service {
+	return &service{approval, subscription, leave, loan, overtime, reimbursement, finance, asset}
+}
+
+func (s *service) GetInbox(ctx context.Context, filter *InboxFilter) ([]InboxItemResponse, *response.Meta, error) {
+	requestTypes := s.allowedRequestTypes(ctx)
+	if filter.RequestType != "" {
+		requestType := constants.ApprovalRequestType(strings.ToUpper(filter.RequestType))
+		if !slices.Contains(requestTypes, requestType) {
+			return []InboxItemResponse{}, response.NewMetaOffset(filter.Page, filter.Limit, 0), nil
+		}
+		requestTypes = []constants.ApprovalRequestType{requestType}
+	}
+
+	pending, meta, err := s.approval.GetPendingApprovals(ctx, &approval.PendingApprovalFilter{
+		Page:         filter.Page,
+		Limit:        filter.Limit,
+		UserID:       filter.UserID,
+		RequestTypes: requestTypes,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := timeNow()
+	list := make([]InboxItemResponse, 0, len(pending))
+	for _, p := range pending {
+		item := InboxItemResponse{
+			ApprovalID:      p.ID,
+			RequestType:     p.RequestType,
+			ReferenceID:     p.ReferenceID,
+			RequesterUserID: p.RequesterUserID,
+			RequesterName:   p.RequesterName,
+			CurrentLevel:    p.CurrentLevel,
+			SubmittedAt:     p.SubmittedAt,
+			AgeHours:        int(now.Sub(p.SubmittedAt).Hours()),
+			DueAt:           p.DueAt,
+			IsOverdue:       p.DueAt != nil && now.After(*p.DueAt),
+		}
+
+		// the approval amount is the leave days for leaves and money for the rest
+		if p.RequestType == constants.ApprovalRequestLeave {
+			item.TotalDays = p.Amount
+		} else {
+			item.Amount = p.Amount
+		}
+
+		// a request that cannot be described is still listed so it can be acted on
+		if err := s.describe(ctx, &item); err != nil {
+			logger.Errorw("describe inbox item failed: ", err)
+		}
+
+		list = append(list, item)
+	}
+
+	return list, meta, nil
+}
+
+// BulkAction approves or rejects every item on its own, a failing item does not stop the others.
+func (s *service) BulkAction(ctx context.Context, req *BulkActionRequest) (*BulkActionResponse, error) {
+	action := constants.ApprovalAction(strings.ToUpper(req.Action))
+	if action != constants.ApprovalActionApprove && action != constants.ApprovalActionReject {
+		return nil, fmt.Errorf("invalid action %s", req.Action)
+	}
+
+	// if rejected, reason become required
+	if action == constants.ApprovalActionReject && strings.TrimSpace(req.Reason) == "" {
+		return nil, errors.New("rejection reason is required")
+	}
+
+	allowed := s.allowedRequestTypes(ctx)
+
+	result := &BulkActionResponse{Results: make([]BulkActionResult, 0, len(req.Items))}
+	for _, item := range req.Items {
+		requestType := constants.ApprovalRequestType(strings.ToUpper(item.RequestType))
+
+		var err error
+		if !slices.Contains(allowed, requestType) {
+			err = errors.New("module not available in your subscription plan")
+		} else {
+			err = s.act(ctx, requestType, item.ReferenceID, string(action), req)
+		}
+
+		res := BulkActionResult{
+			RequestType: string(requestType),
+			ReferenceID: item.ReferenceID,
+			Success:     err == nil,
+		}
+		if err != nil {
+			res.Error = err.Error()
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+		result.Results = append(result.Results, res)
+	}
+
+	return result, nil
+}
+
+// allowedRequestTypes returns the request types whose module is part of the company's plan, a plan that
+// cannot be checked hides the module just like SubscriptionMiddleware.RequireModule does.
+func (s *service) allowedRequestTypes(ctx context.Context) []constants.ApprovalRequestType {
+	var requestTypes []constants.ApprovalRequestType
+	for _, rm := range requestModules {
+		hasAccess, err := s.subscription.HasModule(ctx, rm.module)
+		if err != nil || !hasAccess {
+			continue
+		}
+		requestTypes = append(requestTypes, rm.requestType)
+	}
+	return requestTypes
+}
+
+func (s *service) describe(ctx context.Context, item *InboxItemResponse) error {
+	switch item.RequestType {
+	case constants.ApprovalRequestLeave:
+		detail, err := s.leave.GetDetail(ctx, item.ReferenceID)
+		if err != nil {
+			return err
+		}
+		if detail.LeaveType != nil {
+			item.Title = detail.LeaveType.Name
+		}
+		item.TotalDays = detail.TotalDays
+		item.StartDate = detail.StartDate.Format(constants.DefaultTimeFormat)
+		item.EndDate = detail.EndDate.Format(constants.DefaultTimeFormat)
+	case constants.ApprovalRequestLoan:
+		detail, err := s.loan.GetLoanDetail(ctx, item.ReferenceID)
+		if err != nil {
+			return err
+		}
+		item.Title = detail.Reason
+		item.Amount = detail.TotalAmount
+	case constants.ApprovalRequestOvertime:
+		detail, err := s.overtime.GetDetail(ctx, item.ReferenceID)
+		if err != nil {
+			return err
+		}
+		item.Title = detail.Reason
+		item.StartDate = detail.Date
+		item.EndDate = detail.Date
+	case constants.ApprovalRequestReimbursement:
+		detail, err := s.reimbursement.GetReimburseDetail(ctx, item.ReferenceID)
+		if err != nil {
+			return err
+		}
+		item.Title = detail.Title
+		item.Amount = detail.Amount
+		item.StartDate = detail.DateOfExpense.Format(constants.DefaultTimeFormat)
+	case constants.ApprovalRequestFinance:
+		detail, err := s.finance.GetTransactionDetail(ctx, item.ReferenceID)
+		if err != nil {
+			return err
+		}
+		item.Title = detail.Description
+		item.Amount = detail.Amount
+		item.StartDate = detail.TransactionDate.Format(constants.DefaultTimeFormat)
+	case constants.ApprovalRequestAssetAssignment:
+		detail, err := s.asset.GetAssignmentDetail(ctx, item.ReferenceID)
+		if err != nil {
+			return err
+		}
+		item.Title = detail.AssetName
+		if detail.ExpectedReturnDate != nil {
+			item.EndDate = *detail.ExpectedReturnDate
+		}
+	}
+	return nil
+}
+
+// act runs the decision through the module so its own status changes and notifications still apply.
+func (s *service) act(ctx context.Context, requestType constants.ApprovalRequestType, referenceID uint, action string, req *BulkActionRequest) error {
+	userID, reason := req.UserID, req.Reason
+
+	switch requestType {
+	case constants.ApprovalRequestLeave:
+		return s.leave.RequestAction(ctx, &leave.LeaveActionRequest{RequestID: referenceID, ApproverID: userID, Action: action, RejectionReason: reason, AcknowledgeConflicts: req.AcknowledgeConflicts})
+	case constants.ApprovalRequestLoan:
+		return s.loan.ProcessAction(ctx, &loan.ActionRequest{ID: referenceID, SuperAdminID: userID, Action: action, RejectionReason: reason})
+	case constants.ApprovalRequestOvertime:
+		return s.overtime.ProcessAction(ctx, &overtime.ActionRequest{ID: referenceID, SuperAdminID: userID, Action: action, RejectionReason: reason})
+	case constants.ApprovalRequestReimbursement:
+		return s.reimbursement.ProcessAction(ctx, &reimbursement.ActionRequest{ID: referenceID, SuperAdminID: userID, Action: action, RejectionReason: reason})
+	case constants.ApprovalRequestFinance:
+		return s.finance.ProcessAction(ctx, &finance.ActionRequest{ID: referenceID, SuperAdminID: userID, Action: action, RejectionReason: reason})
+	case constants.ApprovalRequestAssetAssignment:
+		return s.asset.ProcessAction(ctx, &asset.ActionRequest{ID: referenceID, SuperAdminID: userID, Action: action, RejectionReason: reason})
+	}
+	return fmt.Errorf("unknown request type %s", requestType)
+}
@@ -0,0 +1,214 @@
+package inbox
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/leave"
+	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type inboxMocks struct {
+	approval      *mockApproval
+	access        *mockModuleAccess
+	leave         *mockLeave
+	loan          *mockLoan
+	overtime      *mockOvertime
+	reimbursement *mockReimbursement
+	finance       *mockFinance
+	asset         *mockAsset
+}
+
+// newTestInboxService builds the service for a company whose plan has every module except asset.
+func newTestInboxService() (Service, *inboxMocks) {
+	m := &inboxMocks{
+		approval:      new(mockApproval),
+		access:        new(mockModuleAccess),
+		leave:         new(mockLeave),
+		loan:          new(mockLoan),
+		overtime:      new(mockOvertime),
+		reimbursement: new(mockReimbursement),
+		finance:       new(mockFinance),
+		asset:         new(mockAsset),
+	}
+	m.access.On("HasModule", mock.Anything, "asset").Return(false, nil)
+	m.access.On("HasModule", mock.Anything, mock.Anything).Return(true, nil)
+
+	svc := NewService(m.approval, m.access, m.leave, m.loan, m.overtime, m.reimbursement, m.finance, m.asset)
+	return svc, m
+}
+
+func TestService_GetInbox(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 5, false)
+	now := time.Date(2026, 6, 3, 9, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	dueAt := now.Add(-time.Hour)
+	pending := []approval.PendingApprovalResponse{
+		{ID: 1, RequestType: constants.ApprovalRequestLeave, ReferenceID: 10, RequesterUserID: 1, RequesterName: "John Doe", Amount: 2, CurrentLevel: 1, SubmittedAt: now.Add(-48 * time.Hour), DueAt: &dueAt},
+		{ID: 2, RequestType: constants.ApprovalRequestLoan, ReferenceID: 20, RequesterUserID: 2, RequesterName: "Jane Doe", Amount: 3000000, CurrentLevel: 2, SubmittedAt: now.Add(-3 * time.Hour)},
+	}
+
+	t.Run("lists pending items of the modules in the plan with their details", func(t *testing.T) {
+		svc, m := newTestInboxService()
+
+		m.approval.On("GetPendingApprovals", ctx, mock.MatchedBy(func(f *approval.PendingApprovalFilter) bool {
+			return f.UserID == 5 && f.Page == 1 && f.Limit == 10 && len(f.RequestTypes) == 5 &&
+				!slices.Contains(f.RequestTypes, constants.ApprovalRequestAssetAssignment)
+		})).Return(pending, response.NewMetaOffset(1, 10, 2), nil)
+		m.leave.On("GetDetail", ctx, uint(10)).Return(&leave.LeaveRequestDetailResponse{
+			LeaveType: &master.LookupLeaveTypeResponse{Name: "Cuti Tahunan"},
+			StartDate: time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2026, 6, 11, 0, 0, 0, 0, time.UTC),
+			TotalDays: 2,
+		}, nil)
+		// an item whose details cannot be loaded is still listed
+		m.loan.On("GetLoanDetail", ctx, uint(20)).Return(nil, errors.New("record not found"))
+
+		list, meta, err := svc.GetInbox(ctx, &InboxFilter{Page: 1, Limit: 10, UserID: 5})
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, int64(2), meta.TotalData)
+
+		assert.Equal(t, "Cuti Tahunan", list[0].Title)
+		assert.Equal(t, "2026-06-10", list[0].StartDate)
+		assert.Equal(t, "2026-06-11", list[0].EndDate)
+		assert.Equal(t, float64(2), list[0].TotalDays)
+		assert.Zero(t, list[0].Amount)
+		assert.Equal(t, 48, list[0].AgeHours)
+		assert.True(t, list[0].IsOverdue)
+
+		assert.Equal(t, "Jane Doe", list[1].RequesterName)
+		assert.Equal(t, float64(3000000), list[1].Amount)
+		assert.Equal(t, 3, list[1].AgeHours)
+		assert.False(t, list[1].IsOverdue)
+		m.approval.AssertExpectations(t)
+	})
+
+	t.Run("filter narrows the request types", func(t *testing.T) {
+		svc, m := newTestInboxService()
+
+		m.approval.On("GetPendingApprovals", ctx, mock.MatchedBy(func(f *approval.PendingApprovalFilter) bool {
+			return len(f.RequestTypes) == 1 && f.RequestTypes[0] == constants.ApprovalRequestLoan
+		})).Return([]approval.PendingApprovalResponse{}, response.NewMetaOffset(1, 10, 0), nil)
+
+		list, _, err := svc.GetInbox(ctx, &InboxFilter{Page: 1, Limit: 10, UserID: 5, RequestType: "loan"})
+		require.NoError(t, err)
+		assert.Empty(t, list)
+		m.approval.AssertExpectations(t)
+	})
+
+	t.Run("module outside the plan returns nothing", func(t *testing.T) {
+		svc, m := newTestInboxService()
+
+		list, meta, err := svc.GetInbox(ctx, &InboxFilter{Page: 1, Limit: 10, UserID: 5, RequestType: "ASSET_ASSIGNMENT"})
+		require.NoError(t, err)
+		assert.Empty(t, list)
+		assert.Zero(t, meta.TotalData)
+		m.approval.AssertNotCalled(t, "GetPendingApprovals", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error from the approval engine", func(t *testing.T) {
+		svc, m := newTestInboxService()
+
+		m.approval.On("GetPendingApprovals", ctx, mock.Anything).Return([]approval.PendingApprovalResponse(nil), nil, errors.New("db error"))
+
+		_, _, err := svc.GetInbox(ctx, &InboxFilter{Page: 1, Limit: 10, UserID: 5})
+		assert.Error(t, err)
+	})
+}
+
+func TestService_BulkAction(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 5, false)
+
+	tests := []struct {
+		name       string
+		req        *BulkActionRequest
+		setupMocks func(*inboxMocks)
+		wantErr    bool
+		wantOK     int
+		wantFailed int
+	}{
+		{
+			name: "approves each item through its module",
+			req: &BulkActionRequest{
+				Action: "APPROVE",
+				UserID: 5,
+				Items:  []BulkActionItem{{RequestType: "LEAVE", ReferenceID: 10}, {RequestType: "loan", ReferenceID: 20}},
+			},
+			setupMocks: func(m *inboxMocks) {
+				m.leave.On("RequestAction", ctx, &leave.LeaveActionRequest{RequestID: 10, ApproverID: 5, Action: "APPROVE"}).Return(nil)
+				m.loan.On("ProcessAction", ctx, &loan.ActionRequest{ID: 20, SuperAdminID: 5, Action: "APPROVE"}).Return(nil)
+			},
+			wantOK: 2,
+		},
+		{
+			name: "failing items do not stop the rest",
+			req: &BulkActionRequest{
+				Action: "REJECT",
+				Reason: "Budget freeze",
+				UserID: 5,
+				Items: []BulkActionItem{
+					{RequestType: "LOAN", ReferenceID: 20},
+					{RequestType: "LOAN", ReferenceID: 21},
+					{RequestType: "ASSET_ASSIGNMENT", ReferenceID: 30},
+					{RequestType: "PAYROLL", ReferenceID: 40},
+				},
+			},
+			setupMocks: func(m *inboxMocks) {
+				m.loan.On("ProcessAction", ctx, &loan.ActionRequest{ID: 20, SuperAdminID: 5, Action: "REJECT", RejectionReason: "Budget freeze"}).Return(nil)
+				m.loan.On("ProcessAction", ctx, &loan.ActionRequest{ID: 21, SuperAdminID: 5, Action: "REJECT", RejectionReason: "Budget freeze"}).
+					Return(errors.New("user is not an approver of this request"))
+			},
+			wantOK:     1,
+			wantFailed: 3,
+		},
+		{
+			name:       "reject without a reason",
+			req:        &BulkActionRequest{Action: "REJECT", UserID: 5, Items: []BulkActionItem{{RequestType: "LOAN", ReferenceID: 20}}},
+			setupMocks: func(m *inboxMocks) {},
+			wantErr:    true,
+		},
+		{
+			name:       "unknown action",
+			req:        &BulkActionRequest{Action: "HOLD", UserID: 5, Items: []BulkActionItem{{RequestType: "LOAN", ReferenceID: 20}}},
+			setupMocks: func(m *inboxMocks) {},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newTestInboxService()
+			tt.setupMocks(m)
+
+			res, err := svc.BulkAction(ctx, tt.req)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				m.loan.AssertNotCalled(t, "ProcessAction", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOK, res.Succeeded)
+			assert.Equal(t, tt.wantFailed, res.Failed)
+			assert.Len(t, res.Results, len(tt.req.Items))
+			m.leave.AssertExpectations(t)
+			m.loan.AssertExpectations(t)
+			m.asset.AssertNotCalled(t, "ProcessAction", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package leave
+
+import (
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const annualLeaveTypeName = "Annual"
+
+// defaultPolicy is applied to leave types the company has not configured yet,
+// annual leave follows UU 13/2003 (12 days after 12 months of continuous service).
+func defaultPolicy(lt master.LeaveType) LeavePolicy {
+	policy := LeavePolicy{
+		CompanyID:    lt.CompanyID,
+		LeaveTypeID:  lt.ID,
+		AccrualType:  constants.LeaveAccrualAnnual,
+		QuotaPerYear: lt.DefaultQuota,
+	}
+
+	if lt.Name == annualLeaveTypeName {
+		policy.EligibilityMonths = constants.AnnualLeaveEligibilityMonths
+		policy.IsProRata = true
+	}
+
+	return policy
+}
+
+// accruedDays returns how many days the policy grants for the year of asOf up to asOf.
+// Employees without a join date are treated as eligible for the whole year.
+func accruedDays(policy LeavePolicy, joinDate *time.Time, asOf time.Time) int {
+	startMonth := 1
+
+	if joinDate != nil {
+		eligibleFrom := joinDate.AddDate(0, policy.EligibilityMonths, 0)
+		if eligibleFrom.After(asOf) {
+			return 0
+		}
+
+		yearStart := time.Date(asOf.Year(), time.January, 1, 0, 0, 0, 0, asOf.Location())
+		if policy.IsProRata && eligibleFrom.After(yearStart) {
+			startMonth = int(eligibleFrom.Month())
+		}
+	}
+
+	entitlement := policy.QuotaPerYear + tenureBonus(policy, joinDate, asOf)
+
+	months := 12 - startMonth + 1
+	if policy.AccrualType == constants.LeaveAccrualMonthly {
+		months = int(asOf.Month()) - startMonth + 1
+	}
+
+	return entitlement * months / 12
+}
+
+// tenureBonus returns the extra days earned for every full N years of service, capped by the policy.
+func tenureBonus(policy LeavePolicy, joinDate *time.Time, asOf time.Time) int {
+	if joinDate == nil || policy.TenureBonusEveryYears <= 0 || policy.TenureBonusDays <= 0 {
+		return 0
+	}
+
+	years := asOf.Year() - joinDate.Year()
+	if asOf.Month() < joinDate.Month() || (asOf.Month() == joinDate.Month() && asOf.Day() < joinDate.Day()) {
+		years--
+	}
+	if years <= 0 {
+		return 0
+	}
+
+	bonus := (years / policy.TenureBonusEveryYears) * policy.TenureBonusDays
+	if policy.TenureBonusMax > 0 && bonus > policy.TenureBonusMax {
+		bonus = policy.TenureBonusMax
+	}
+
+	return bonus
+}
+
+// carryOverExpiry returns when days carried into year expire, nil when they never do.
+func carryOverExpiry(policy LeavePolicy, year int, loc *time.Location) *time.Time {
+	if policy.CarryOverExpiryMonths <= 0 {
+		return nil
+	}
+
+	expiresAt := time.Date(year, time.January, 1, 0, 0, 0, 0, loc).AddDate(0, policy.CarryOverExpiryMonths, 0)
+	return &expiresAt
+}
+
+// resolvePolicies returns one policy per leave type of the tenant, falling back to defaultPolicy.
+func (s *service) resolvePolicies(ctx context.Context) ([]LeavePolicy, error) {
+	leaveTypes, err := s.repo.FindAllLeaveTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	configured, err := s.repo.FindAllPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byLeaveType := make(map[uint]LeavePolicy, len(configured))
+	for _, policy := range configured {
+		byLeaveType[policy.LeaveTypeID] = policy
+	}
+
+	policies := make([]LeavePolicy, 0, len(leaveTypes))
+	for _, lt := range leaveTypes {
+		policy, ok := byLeaveType[lt.ID]
+		if !ok {
+			policy = defaultPolicy(lt)
+		}
+		policy.LeaveType = &lt
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// accrueBalance brings the balance of asOf's year up to date with the policy.
+// It is safe to run repeatedly, every change it makes is written to the ledger.
+func (s *service) accrueBalance(ctx context.Context, emp user.Employee, policy LeavePolicy, asOf time.Time) error {
+	year := asOf.Year()
+	var entries []LeaveLedger
+
+	balance, err := s.repo.GetBalance(ctx, emp.ID, policy.LeaveTypeID, year)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		balance = &LeaveBalance{
+			CompanyID:   emp.CompanyID,
+			EmployeeID:  emp.ID,
+			LeaveTypeID: policy.LeaveTypeID,
+			Year:        year,
+		}
+
+		closing, err := s.closePreviousYear(ctx, balance, policy, asOf)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, closing...)
+	} else if err != nil {
+		return err
+	}
+
+	// usage consumes carry-over first, only the unused part expires
+	if balance.CarryOverExpiresAt != nil && !asOf.Before(*balance.CarryOverExpiresAt) {
+		used := min(balance.QuotaUsed, balance.CarryOver)
+		if expired := balance.CarryOver - used; expired > 0 {
+			balance.CarryOver = used
+			balance.QuotaTotal -= expired
+			balance.QuotaLeft -= expired
+			entries = append(entries, newLedgerEntry(balance, constants.LeaveLedgerExpiry, -expired, "Carry-over expired"))
+		}
+	}
+
+	accrued, err := s.repo.SumLedgerDays(ctx, emp.ID, policy.LeaveTypeID, year, constants.LeaveLedgerAccrual)
+	if err != nil {
+		return err
+	}
+
+	if due := float64(accruedDays(policy, emp.JoinDate, asOf)) - accrued; due > 0 {
+		balance.QuotaTotal += due
+		balance.QuotaLeft += due
+		entries = append(entries, newLedgerEntry(balance, constants.LeaveLedgerAccrual, due, fmt.Sprintf("Accrual up to %s", asOf.Format("January 2006"))))
+	}
+
+	// new balances are saved even when nothing is granted yet, so applying returns insufficient balance instead of not found
+	if balance.ID != 0 && len(entries) == 0 {
+		return nil
+	}
+
+	if err := s.repo.SaveBalance(ctx, balance); err != nil {
+		return err
+	}
+
+	return s.repo.CreateLedgers(ctx, entries)
+}
+
+// closePreviousYear moves what is left of last year's balance into the new one up to the carry-over cap,
+// the rest is forfeited.
+func (s *service) closePreviousYear(ctx context.Context, balance *LeaveBalance, policy LeavePolicy, asOf time.Time) ([]LeaveLedger, error) {
+	previous, err := s.repo.GetBalance(ctx, balance.EmployeeID, balance.LeaveTypeID, balance.Year-1)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if previous.QuotaLeft <= 0 {
+		return nil, nil
+	}
+
+	var entries []LeaveLedger
+
+	carried := min(previous.QuotaLeft, float64(policy.CarryOverMax))
+	forfeited := previous.QuotaLeft - carried
+
+	if carried > 0 {
+		expiresAt := carryOverExpiry(policy, balance.Year, asOf.Location())
+
+		balance.CarryOver = carried
+		balance.CarryOverExpiresAt = expiresAt
+		balance.QuotaTotal += carried
+		balance.QuotaLeft += carried
+
+		incoming := newLedgerEntry(balance, constants.LeaveLedgerCarryOver, carried, fmt.Sprintf("Carried over from %d", previous.Year))
+		incoming.ExpiresAt = expiresAt
+		entries = append(entries,
+			newLedgerEntry(previous, constants.LeaveLedgerCarryOver, -carried, fmt.Sprintf("Carried over to %d", balance.Year)),
+			incoming,
+		)
+	}
+
+	if forfeited > 0 {
+		entries = append(entries, newLedgerEntry(previous, constants.LeaveLedgerExpiry, -forfeited, fmt.Sprintf("Not carried over to %d", balance.Year)))
+	}
+
+	previous.QuotaTotal -= previous.QuotaLeft
+	previous.QuotaLeft = 0
+	if err := s.repo.SaveBalance(ctx, previous); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func newLedgerEntry(balance *LeaveBalance, ledgerType constants.LeaveLedgerType, days float64, notes string) LeaveLedger {
+	return LeaveLedger{
+		CompanyID:   balance.CompanyID,
+		EmployeeID:  balance.EmployeeID,
+		LeaveTypeID: balance.LeaveTypeID,
+		Year:        balance.Year,
+		Type:        ledgerType,
+		Days:        days,
+		Notes:       notes,
+	}
+}
@@ -0,0 +1,235 @@
+package leave
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func date(year int, month time.Month, day int) *time.Time {
+	d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &d
+}
+
+func TestAccruedDays(t *testing.T) {
+	annual := LeavePolicy{AccrualType: constants.LeaveAccrualAnnual, QuotaPerYear: 12, EligibilityMonths: 12, IsProRata: true}
+	monthly := LeavePolicy{AccrualType: constants.LeaveAccrualMonthly, QuotaPerYear: 12}
+
+	tests := []struct {
+		name     string
+		policy   LeavePolicy
+		joinDate *time.Time
+		asOf     time.Time
+		want     int
+	}{
+		{name: "no join date gets full entitlement", policy: annual, joinDate: nil, asOf: *date(2026, 1, 1), want: 12},
+		{name: "not eligible before 12 months of service", policy: annual, joinDate: date(2025, 9, 1), asOf: *date(2026, 8, 31), want: 0},
+		{name: "pro rata from eligibility month", policy: annual, joinDate: date(2025, 9, 1), asOf: *date(2026, 9, 1), want: 4},
+		{name: "full entitlement the year after eligibility", policy: annual, joinDate: date(2025, 9, 1), asOf: *date(2027, 1, 1), want: 12},
+		{
+			name:     "eligible mid year without pro rata gets full entitlement",
+			policy:   LeavePolicy{AccrualType: constants.LeaveAccrualAnnual, QuotaPerYear: 12, EligibilityMonths: 12},
+			joinDate: date(2025, 9, 1),
+			asOf:     *date(2026, 9, 1),
+			want:     12,
+		},
+		{name: "monthly accrues per elapsed month", policy: monthly, joinDate: date(2020, 1, 1), asOf: *date(2026, 3, 10), want: 3},
+		{
+			name:     "monthly pro rata starts from join month",
+			policy:   LeavePolicy{AccrualType: constants.LeaveAccrualMonthly, QuotaPerYear: 12, IsProRata: true},
+			joinDate: date(2026, 5, 20),
+			asOf:     *date(2026, 7, 1),
+			want:     3,
+		},
+		{
+			name:     "tenure bonus added to entitlement",
+			policy:   LeavePolicy{AccrualType: constants.LeaveAccrualAnnual, QuotaPerYear: 12, TenureBonusEveryYears: 5, TenureBonusDays: 1, TenureBonusMax: 5},
+			joinDate: date(2015, 3, 1),
+			asOf:     *date(2026, 3, 1),
+			want:     14,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, accruedDays(tt.policy, tt.joinDate, tt.asOf))
+		})
+	}
+}
+
+func TestTenureBonus(t *testing.T) {
+	policy := LeavePolicy{TenureBonusEveryYears: 3, TenureBonusDays: 2, TenureBonusMax: 4}
+
+	tests := []struct {
+		name     string
+		policy   LeavePolicy
+		joinDate *time.Time
+		asOf     time.Time
+		want     int
+	}{
+		{name: "no join date", policy: policy, joinDate: nil, asOf: *date(2026, 1, 1), want: 0},
+		{name: "one day short of three years", policy: policy, joinDate: date(2023, 6, 2), asOf: *date(2026, 6, 1), want: 0},
+		{name: "three full years", policy: policy, joinDate: date(2023, 6, 1), asOf: *date(2026, 6, 1), want: 2},
+		{name: "capped at max", policy: policy, joinDate: date(2000, 1, 1), asOf: *date(2026, 1, 1), want: 4},
+		{name: "disabled policy", policy: LeavePolicy{}, joinDate: date(2000, 1, 1), asOf: *date(2026, 1, 1), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tenureBonus(tt.policy, tt.joinDate, tt.asOf))
+		})
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	annual := defaultPolicy(master.LeaveType{ID: 1, Name: "Annual", DefaultQuota: 12, CompanyID: 1})
+	assert.Equal(t, constants.AnnualLeaveEligibilityMonths, annual.EligibilityMonths)
+	assert.True(t, annual.IsProRata)
+	assert.Equal(t, 12, annual.QuotaPerYear)
+
+	sick := defaultPolicy(master.LeaveType{ID: 2, Name: "Sick", DefaultQuota: 15, CompanyID: 1})
+	assert.Equal(t, 0, sick.EligibilityMonths)
+	assert.False(t, sick.IsProRata)
+	assert.Equal(t, 15, sick.QuotaPerYear)
+}
+
+func TestService_AccrueBalance(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	policy := LeavePolicy{LeaveTypeID: 1, AccrualType: constants.LeaveAccrualAnnual, QuotaPerYear: 12, CarryOverMax: 5, CarryOverExpiryMonths: 3}
+	emp := user.Employee{ID: 1, CompanyID: 1, JoinDate: date(2020, 1, 1)}
+
+	tests := []struct {
+		name        string
+		asOf        time.Time
+		setupMocks  func(*mockRepo)
+		wantBalance *LeaveBalance
+		wantLedger  []LeaveLedger
+		wantErr     string
+	}{
+		{
+			name: "new year carries over up to the cap and forfeits the rest",
+			asOf: *date(2026, 1, 1),
+			setupMocks: func(repo *mockRepo) {
+				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(nil, gorm.ErrRecordNotFound)
+				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2025).Return(&LeaveBalance{
+					ID: 7, CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2025, QuotaTotal: 12, QuotaUsed: 4, QuotaLeft: 8,
+				}, nil)
+				repo.On("SaveBalance", mock.Anything, mock.MatchedBy(func(b *LeaveBalance) bool {
+					return b.Year == 2025 && b.QuotaLeft == 0 && b.QuotaTotal == 4
+				})).Return(nil).Once()
+				repo.On("SumLedgerDays", mock.Anything, uint(1), uint(1), 2026, constants.LeaveLedgerAccrual).Return(float64(0), nil)
+			},
+			wantBalance: &LeaveBalance{
+				CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, QuotaTotal: 17, QuotaLeft: 17,
+				CarryOver: 5, CarryOverExpiresAt: date(2026, 4, 1),
+			},
+			wantLedger: []LeaveLedger{
+				{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2025, Type: constants.LeaveLedgerCarryOver, Days: -5, Notes: "Carried over to 2026"},
+				{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, Type: constants.LeaveLedgerCarryOver, Days: 5, ExpiresAt: date(2026, 4, 1), Notes: "Carried over from 2025"},
+				{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2025, Type: constants.LeaveLedgerExpiry, Days: -3, Notes: "Not carried over to 2026"},
+				{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, Type: constants.LeaveLedgerAccrual, Days: 12, Notes: "Accrual up to January 2026"},
+			},
+		},
+		{
+			name: "unused carry over expires after its date",
+			asOf: *date(2026, 4, 1),
+			setupMocks: func(repo *mockRepo) {
+				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(&LeaveBalance{
+					ID: 8, CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, QuotaTotal: 17, QuotaUsed: 2, QuotaLeft: 15,
+					CarryOver: 5, CarryOverExpiresAt: date(2026, 4, 1),
+				}, nil)
+				repo.On("SumLedgerDays", mock.Anything, uint(1), uint(1), 2026, constants.LeaveLedgerAccrual).Return(float64(12), nil)
+			},
+			wantBalance: &LeaveBalance{
+				ID: 8, CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, QuotaTotal: 14, QuotaUsed: 2, QuotaLeft: 12,
+				CarryOver: 2, CarryOverExpiresAt: date(2026, 4, 1),
+			},
+			wantLedger: []LeaveLedger{
+				{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, Type: constants.LeaveLedgerExpiry, Days: -3, Notes: "Carry-over expired"},
+			},
+		},
+		{
+			name: "already up to date writes nothing",
+			asOf: *date(2026, 5, 1),
+			setupMocks: func(repo *mockRepo) {
+				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(&LeaveBalance{
+					ID: 8, CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, QuotaTotal: 14, QuotaUsed: 2, QuotaLeft: 12,
+					CarryOver: 2, CarryOverExpiresAt: date(2026, 4, 1),
+				}, nil)
+				repo.On("SumLedgerDays", mock.Anything, uint(1), uint(1), 2026, constants.LeaveLedgerAccrual).Return(float64(12), nil)
+			},
+		},
+		{
+			name: "error loading balance",
+			asOf: *date(2026, 5, 1),
+			setupMocks: func(repo *mockRepo) {
+				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(nil, errors.New("db error"))
+			},
+			wantErr: "db error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaveSvc, repo, _, _, _, _, _, _ := newTestLeaveService()
+			svc := leaveSvc.(*service)
+			tt.setupMocks(repo)
+
+			if tt.wantBalance != nil {
+				repo.On("SaveBalance", mock.Anything, tt.wantBalance).Return(nil).Once()
+				repo.On("CreateLedgers", mock.Anything, tt.wantLedger).Return(nil).Once()
+			}
+
+			err := svc.accrueBalance(ctx, emp, policy, tt.asOf)
+
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			repo.AssertExpectations(t)
+			if tt.wantBalance == nil {
+				repo.AssertNotCalled(t, "SaveBalance", mock.Anything, mock.Anything)
+				repo.AssertNotCalled(t, "CreateLedgers", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestService_AccrueAllCompanies(t *testing.T) {
+	pinClock(t, time.Date(2026, 6, 15, 1, 0, 0, 0, time.UTC))
+
+	svc, repo, _, _, userProv, _, _, _ := newTestLeaveService()
+
+	repo.On("FindActiveCompanyIDs", mock.Anything).Return([]uint{1, 2}, nil)
+	repo.On("FindAllLeaveTypes", mock.MatchedBy(func(ctx context.Context) bool {
+		return utils.GetCompanyIDFromCtx(ctx) == 1
+	})).Return([]master.LeaveType{}, errors.New("db error"))
+	repo.On("FindAllLeaveTypes", mock.MatchedBy(func(ctx context.Context) bool {
+		return utils.GetCompanyIDFromCtx(ctx) == 2
+	})).Return([]master.LeaveType{}, nil)
+	repo.On("FindAllPolicies", mock.Anything).Return([]LeavePolicy{}, nil)
+	userProv.On("FindAllEmployeeActive", mock.MatchedBy(func(ctx context.Context) bool {
+		return utils.GetCompanyIDFromCtx(ctx) == 2
+	})).Return([]user.Employee{{ID: 3, CompanyID: 2}}, nil)
+
+	err := svc.AccrueAllCompanies(context.Background())
+
+	require.Error(t, err)
+	assert.Equal(t, "company 1: db error", err.Error())
+	userProv.AssertExpectations(t)
+}
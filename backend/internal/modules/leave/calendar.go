@@ -0,0 +1,185 @@
+package leave
+
+import (
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"context"
+	"errors"
+	"time"
+)
+
+// longest range the team calendar returns in one call, about two months
+const maxCalendarDays = 62
+
+func (s *service) GetTeamCalendar(ctx context.Context, filter *TeamCalendarFilter) (*TeamCalendarResponse, error) {
+	start, err := time.Parse(constants.DefaultTimeFormat, filter.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid start date format")
+	}
+	end, err := time.Parse(constants.DefaultTimeFormat, filter.EndDate)
+	if err != nil {
+		return nil, errors.New("invalid end date format")
+	}
+	if end.Before(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+	if end.Sub(start) > maxCalendarDays*24*time.Hour {
+		return nil, errors.New("calendar range must not exceed 62 days")
+	}
+
+	if !filter.CanViewAll {
+		if filter.EmployeeID == nil {
+			return nil, errors.New("employee profile not found")
+		}
+		// managers may look at their direct reports, everyone else only sees their own department
+		if filter.ManagerID != *filter.EmployeeID {
+			self, err := s.user.FindEmployeeByID(ctx, *filter.EmployeeID)
+			if err != nil {
+				return nil, err
+			}
+			filter.DepartmentID, filter.ManagerID = self.DepartmentID, 0
+		}
+	}
+	if filter.DepartmentID == 0 && filter.ManagerID == 0 {
+		return nil, errors.New("department or manager is required")
+	}
+
+	// a team picked through the view permissions is narrowed to the data scope the permission was granted with,
+	// everyone else sees their own team whatever their scope
+	findEmployees := s.user.FindAllEmployeeActive
+	if filter.CanViewAll {
+		findEmployees = s.user.FindAllEmployeeActiveInScope
+	}
+	employees, err := findEmployees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	members := teamMembers(employees, filter.DepartmentID, filter.ManagerID)
+	memberIDs := make([]uint, 0, len(members))
+	memberResp := make([]CalendarMemberResponse, 0, len(members))
+	for _, m := range members {
+		memberIDs = append(memberIDs, m.ID)
+
+		item := CalendarMemberResponse{
+			EmployeeID:   m.ID,
+			EmployeeName: m.FullName,
+			Position:     m.Position,
+			DepartmentID: m.DepartmentID,
+			ShiftName:    "-",
+		}
+		if m.Shift != nil {
+			item.ShiftName = m.Shift.Name
+			item.ShiftStart = m.Shift.StartTime
+			item.ShiftEnd = m.Shift.EndTime
+		}
+		memberResp = append(memberResp, item)
+	}
+
+	var requests []LeaveRequest
+	if len(memberIDs) > 0 {
+		requests, err = s.repo.FindTeamRequests(ctx, memberIDs, start, end, []constants.LeaveStatus{constants.LeaveStatusApproved, constants.LeaveStatusPending})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	holidays, err := s.repo.FindHolidays(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.repo.FindConflictRules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	leaves := make([]CalendarLeaveResponse, 0, len(requests))
+	var approved, pending []LeaveRequest
+	for _, r := range requests {
+		item := CalendarLeaveResponse{
+			ID:           r.ID,
+			EmployeeID:   r.EmployeeID,
+			EmployeeName: "-",
+			LeaveType:    "-",
+			StartDate:    r.StartDate.Format(constants.DefaultTimeFormat),
+			EndDate:      r.EndDate.Format(constants.DefaultTimeFormat),
+			DurationType: r.DurationType,
+			StartTime:    r.StartTime,
+			EndTime:      r.EndTime,
+			Status:       r.Status,
+		}
+		if r.Employee != nil {
+			item.EmployeeName = r.Employee.FullName
+		}
+		if r.LeaveType != nil {
+			item.LeaveType = r.LeaveType.Name
+		}
+		leaves = append(leaves, item)
+
+		if r.Status == constants.LeaveStatusApproved {
+			approved = append(approved, r)
+		} else {
+			pending = append(pending, r)
+		}
+	}
+
+	holidayNames := make(map[string]string, len(holidays))
+	for _, h := range holidays {
+		holidayNames[h.Date.Format(constants.DefaultTimeFormat)] = h.Name
+	}
+	offByDay, pendingByDay := countOffByDay(approved), countOffByDay(pending)
+
+	var days []CalendarDayResponse
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format(constants.DefaultTimeFormat)
+		day := CalendarDayResponse{
+			Date:         date,
+			IsWorkingDay: isLeaveWorkingDay(d) && holidayNames[date] == "",
+			Holiday:      holidayNames[date],
+		}
+		if day.IsWorkingDay {
+			day.OffCount = offByDay[date]
+			day.PendingCount = pendingByDay[date]
+		}
+		days = append(days, day)
+	}
+
+	blackouts := []LeaveConflictRuleResponse{}
+	for _, rule := range rules {
+		if rule.RuleType != constants.LeaveConflictBlackout || rule.StartDate == nil || rule.EndDate == nil {
+			continue
+		}
+		if rule.DepartmentID != nil && filter.DepartmentID != 0 && *rule.DepartmentID != filter.DepartmentID {
+			continue
+		}
+		if rule.StartDate.After(end) || rule.EndDate.Before(start) {
+			continue
+		}
+		blackouts = append(blackouts, toConflictRuleResponse(&rule))
+	}
+
+	return &TeamCalendarResponse{
+		StartDate: filter.StartDate,
+		EndDate:   filter.EndDate,
+		Members:   memberResp,
+		Leaves:    leaves,
+		Days:      days,
+		Blackouts: blackouts,
+	}, nil
+}
+
+// teamMembers returns the active employees of the department and/or reporting to the manager.
+func teamMembers(employees []user.Employee, departmentID, managerID uint) []user.Employee {
+	var members []user.Employee
+	for _, e := range employees {
+		if departmentID != 0 && e.DepartmentID != departmentID {
+			continue
+		}
+		if managerID != 0 && (e.ManagerID == nil || *e.ManagerID != managerID) {
+			continue
+		}
+		members = append(members, e)
+	}
+	return members
+}
@@ -0,0 +1,264 @@
+package leave
+
+import (
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func (s *service) GetConflictRules(ctx context.Context) ([]LeaveConflictRuleResponse, error) {
+	rules, err := s.repo.FindConflictRules(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]LeaveConflictRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		list = append(list, toConflictRuleResponse(&rule))
+	}
+	return list, nil
+}
+
+// SaveConflictRule creates the rule, or updates it when req.ID is set.
+func (s *service) SaveConflictRule(ctx context.Context, req *SaveLeaveConflictRuleRequest) error {
+	rule := &LeaveConflictRule{CompanyID: utils.GetCompanyIDFromCtx(ctx)}
+	if req.ID != 0 {
+		existing, err := s.repo.FindConflictRuleByID(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+		rule = existing
+	}
+
+	rule.Name = req.Name
+	rule.RuleType = constants.LeaveConflictRuleType(req.RuleType)
+	rule.DepartmentID = req.DepartmentID
+	rule.Enforcement = constants.LeaveConflictEnforcement(req.Enforcement)
+	rule.IsActive = req.IsActive
+	rule.MaxEmployees, rule.MaxPercent = 0, 0
+	rule.StartDate, rule.EndDate = nil, nil
+
+	switch rule.RuleType {
+	case constants.LeaveConflictMaxEmployees:
+		if req.MaxEmployees < 1 {
+			return errors.New("max employees must be at least 1")
+		}
+		rule.MaxEmployees = req.MaxEmployees
+	case constants.LeaveConflictMaxPercent:
+		if req.MaxPercent <= 0 {
+			return errors.New("max percent must be greater than 0")
+		}
+		rule.MaxPercent = req.MaxPercent
+	case constants.LeaveConflictBlackout:
+		start, err := time.Parse(constants.DefaultTimeFormat, req.StartDate)
+		if err != nil {
+			return errors.New("invalid start date format")
+		}
+		end, err := time.Parse(constants.DefaultTimeFormat, req.EndDate)
+		if err != nil {
+			return errors.New("invalid end date format")
+		}
+		if end.Before(start) {
+			return errors.New("end date must be after start date")
+		}
+		rule.StartDate, rule.EndDate = &start, &end
+	default:
+		return fmt.Errorf("invalid rule type: %s", req.RuleType)
+	}
+
+	return s.repo.SaveConflictRule(ctx, rule)
+}
+
+func (s *service) DeleteConflictRule(ctx context.Context, id uint) error {
+	return s.repo.DeleteConflictRule(ctx, id)
+}
+
+// findConflicts checks the active conflict rules against the employee being off between start and end,
+// counting the approved leave of the rest of their department.
+func (s *service) findConflicts(ctx context.Context, employeeID uint, start, end time.Time) ([]LeaveConflictResponse, error) {
+	rules, err := s.repo.FindConflictRules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	employee, err := s.user.FindEmployeeByID(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		applicable []LeaveConflictRule
+		needsTeam  bool
+	)
+	for _, rule := range rules {
+		if rule.DepartmentID != nil && *rule.DepartmentID != employee.DepartmentID {
+			continue
+		}
+		applicable = append(applicable, rule)
+		if rule.RuleType != constants.LeaveConflictBlackout {
+			needsTeam = true
+		}
+	}
+	if len(applicable) == 0 {
+		return nil, nil
+	}
+
+	holidays, err := s.repo.FindHolidays(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	teamSize := 1
+	offByDay := map[string]int{}
+	if needsTeam {
+		employees, err := s.user.FindAllEmployeeActive(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var colleagueIDs []uint
+		for _, e := range employees {
+			if e.DepartmentID == employee.DepartmentID && e.ID != employee.ID {
+				colleagueIDs = append(colleagueIDs, e.ID)
+			}
+		}
+		teamSize += len(colleagueIDs)
+
+		requests, err := s.repo.FindTeamRequests(ctx, colleagueIDs, start, end, []constants.LeaveStatus{constants.LeaveStatusApproved})
+		if err != nil {
+			return nil, err
+		}
+		offByDay = countOffByDay(requests)
+	}
+
+	return evaluateConflicts(applicable, teamDays(start, end, holidays), offByDay, teamSize), nil
+}
+
+// evaluateConflicts returns the rules broken on any of the days, offByDay holds the colleagues already
+// off on each day and teamSize the department headcount including the employee.
+func evaluateConflicts(rules []LeaveConflictRule, days []time.Time, offByDay map[string]int, teamSize int) []LeaveConflictResponse {
+	var conflicts []LeaveConflictResponse
+	for _, rule := range rules {
+		var (
+			dates   []string
+			message string
+		)
+
+		for _, day := range days {
+			date := day.Format(constants.DefaultTimeFormat)
+			off := offByDay[date] + 1
+
+			switch rule.RuleType {
+			case constants.LeaveConflictMaxEmployees:
+				message = fmt.Sprintf("more than %d people of the department would be off", rule.MaxEmployees)
+				if off > rule.MaxEmployees {
+					dates = append(dates, date)
+				}
+			case constants.LeaveConflictMaxPercent:
+				message = fmt.Sprintf("more than %.0f%% of the department would be off", rule.MaxPercent)
+				if float64(off)*100/float64(teamSize) > rule.MaxPercent {
+					dates = append(dates, date)
+				}
+			case constants.LeaveConflictBlackout:
+				message = "leave is not allowed in this period"
+				if rule.StartDate != nil && rule.EndDate != nil && !day.Before(*rule.StartDate) && !day.After(*rule.EndDate) {
+					dates = append(dates, date)
+				}
+			}
+		}
+
+		if len(dates) > 0 {
+			conflicts = append(conflicts, LeaveConflictResponse{
+				RuleID:      rule.ID,
+				RuleName:    rule.Name,
+				RuleType:    rule.RuleType,
+				Enforcement: rule.Enforcement,
+				Dates:       dates,
+				Message:     message,
+			})
+		}
+	}
+	return conflicts
+}
+
+// countOffByDay counts the requests covering each working day.
+func countOffByDay(requests []LeaveRequest) map[string]int {
+	offByDay := map[string]int{}
+	for _, r := range requests {
+		for _, day := range teamDays(r.StartDate, r.EndDate, nil) {
+			offByDay[day.Format(constants.DefaultTimeFormat)]++
+		}
+	}
+	return offByDay
+}
+
+// teamDays lists the working days between from and to that are not company holidays.
+func teamDays(from, to time.Time, holidays []master.Holiday) []time.Time {
+	closed := make(map[string]bool, len(holidays))
+	for _, h := range holidays {
+		closed[h.Date.Format(constants.DefaultTimeFormat)] = true
+	}
+
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	var days []time.Time
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if isLeaveWorkingDay(d) && !closed[d.Format(constants.DefaultTimeFormat)] {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+func filterConflicts(conflicts []LeaveConflictResponse, enforcement constants.LeaveConflictEnforcement) []LeaveConflictResponse {
+	var filtered []LeaveConflictResponse
+	for _, c := range conflicts {
+		if c.Enforcement == enforcement {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+func describeConflicts(conflicts []LeaveConflictResponse) string {
+	parts := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		parts = append(parts, fmt.Sprintf("%s on %s: %s", c.RuleName, strings.Join(c.Dates, ", "), c.Message))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func toConflictRuleResponse(rule *LeaveConflictRule) LeaveConflictRuleResponse {
+	resp := LeaveConflictRuleResponse{
+		ID:             rule.ID,
+		Name:           rule.Name,
+		RuleType:       rule.RuleType,
+		DepartmentID:   rule.DepartmentID,
+		DepartmentName: "-",
+		MaxEmployees:   rule.MaxEmployees,
+		MaxPercent:     rule.MaxPercent,
+		Enforcement:    rule.Enforcement,
+		IsActive:       rule.IsActive,
+	}
+	if rule.Department != nil {
+		resp.DepartmentName = rule.Department.Name
+	}
+	if rule.StartDate != nil {
+		start := rule.StartDate.Format(constants.DefaultTimeFormat)
+		resp.StartDate = &start
+	}
+	if rule.EndDate != nil {
+		end := rule.EndDate.Format(constants.DefaultTimeFormat)
+		resp.EndDate = &end
+	}
+	return resp
+}
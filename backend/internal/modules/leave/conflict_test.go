@@ -0,0 +1,291 @@
+package leave
+
+import (
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func juneDay(day int) time.Time {
+	return time.Date(2026, 6, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestTeamDays(t *testing.T) {
+	// 2026-06-01 is a monday, the 4th is a holiday and the 6th/7th the weekend
+	days := teamDays(juneDay(1), juneDay(8), []master.Holiday{{Date: juneDay(4), Name: "Company Day"}})
+
+	var got []string
+	for _, d := range days {
+		got = append(got, d.Format(constants.DefaultTimeFormat))
+	}
+	assert.Equal(t, []string{"2026-06-01", "2026-06-02", "2026-06-03", "2026-06-05", "2026-06-08"}, got)
+}
+
+func TestEvaluateConflicts(t *testing.T) {
+	days := []time.Time{juneDay(1), juneDay(2), juneDay(3)}
+	offByDay := map[string]int{"2026-06-01": 2, "2026-06-02": 1}
+	start, end := juneDay(3), juneDay(5)
+
+	tests := []struct {
+		name      string
+		rule      LeaveConflictRule
+		teamSize  int
+		wantDates []string
+	}{
+		{name: "max employees exceeded", rule: LeaveConflictRule{RuleType: constants.LeaveConflictMaxEmployees, MaxEmployees: 2}, teamSize: 10, wantDates: []string{"2026-06-01"}},
+		{name: "max employees within limit", rule: LeaveConflictRule{RuleType: constants.LeaveConflictMaxEmployees, MaxEmployees: 3}, teamSize: 10},
+		{name: "max percent exceeded", rule: LeaveConflictRule{RuleType: constants.LeaveConflictMaxPercent, MaxPercent: 25}, teamSize: 5, wantDates: []string{"2026-06-01", "2026-06-02"}},
+		{name: "max percent within limit", rule: LeaveConflictRule{RuleType: constants.LeaveConflictMaxPercent, MaxPercent: 60}, teamSize: 5},
+		{name: "blackout overlaps", rule: LeaveConflictRule{RuleType: constants.LeaveConflictBlackout, StartDate: &start, EndDate: &end}, teamSize: 5, wantDates: []string{"2026-06-03"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := evaluateConflicts([]LeaveConflictRule{tt.rule}, days, offByDay, tt.teamSize)
+
+			if tt.wantDates == nil {
+				assert.Empty(t, conflicts)
+				return
+			}
+			require.Len(t, conflicts, 1)
+			assert.Equal(t, tt.wantDates, conflicts[0].Dates)
+		})
+	}
+}
+
+func TestService_ApplyConflicts(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	otherDepartment := uint(9)
+
+	tests := []struct {
+		name    string
+		rules   []LeaveConflictRule
+		wantErr bool
+	}{
+		{
+			name:    "blocking rule refuses the request",
+			rules:   []LeaveConflictRule{{ID: 1, Name: "Two off per day", RuleType: constants.LeaveConflictMaxEmployees, MaxEmployees: 2, Enforcement: constants.LeaveConflictBlock}},
+			wantErr: true,
+		},
+		{
+			name:  "warning rule lets the request through",
+			rules: []LeaveConflictRule{{ID: 1, Name: "Two off per day", RuleType: constants.LeaveConflictMaxEmployees, MaxEmployees: 2, Enforcement: constants.LeaveConflictWarn}},
+		},
+		{
+			name:  "rule of another department is ignored",
+			rules: []LeaveConflictRule{{ID: 1, Name: "Two off per day", RuleType: constants.LeaveConflictMaxEmployees, MaxEmployees: 2, DepartmentID: &otherDepartment, Enforcement: constants.LeaveConflictBlock}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, notif, userProv, approvalEngine, _, _ := newTestLeaveService()
+
+			repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(&LeaveBalance{QuotaLeft: 5}, nil)
+			repo.On("FindConflictRules", mock.Anything, true).Return(tt.rules, nil)
+			userProv.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&user.Employee{ID: 1, DepartmentID: 3}, nil).Maybe()
+			userProv.On("FindAllEmployeeActive", mock.Anything).Return([]user.Employee{
+				{ID: 1, DepartmentID: 3}, {ID: 2, DepartmentID: 3}, {ID: 3, DepartmentID: 3}, {ID: 4, DepartmentID: 5},
+			}, nil).Maybe()
+			repo.On("FindHolidays", mock.Anything, juneDay(1), juneDay(1)).Return([]master.Holiday{}, nil).Maybe()
+			repo.On("FindTeamRequests", mock.Anything, []uint{2, 3}, juneDay(1), juneDay(1), []constants.LeaveStatus{constants.LeaveStatusApproved}).
+				Return([]LeaveRequest{
+					{EmployeeID: 2, StartDate: juneDay(1), EndDate: juneDay(1)},
+					{EmployeeID: 3, StartDate: juneDay(1), EndDate: juneDay(2)},
+				}, nil).Maybe()
+			repo.On("CreateRequest", mock.Anything, mock.Anything).Return(nil).Maybe()
+			approvalEngine.On("Submit", mock.Anything, mock.Anything).Return([]uint{10}, nil).Maybe()
+			notif.On("BlastNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			err := svc.Apply(ctx, &ApplyRequest{EmployeeID: 1, UserID: 1, LeaveTypeID: 1, StartDate: "2026-06-01", EndDate: "2026-06-01", Reason: "Family event"})
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "leave conflicts with team rules: Two off per day on 2026-06-01")
+				repo.AssertNotCalled(t, "CreateRequest", mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				repo.AssertCalled(t, "CreateRequest", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestService_RequestActionConflicts(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	stockTakeStart, stockTakeEnd := juneDay(1), juneDay(5)
+	rules := []LeaveConflictRule{{ID: 1, Name: "Stock take", RuleType: constants.LeaveConflictBlackout, StartDate: &stockTakeStart, EndDate: &stockTakeEnd, Enforcement: constants.LeaveConflictWarn}}
+
+	tests := []struct {
+		name        string
+		acknowledge bool
+		wantErr     bool
+	}{
+		{name: "warning must be acknowledged", wantErr: true},
+		{name: "acknowledged warning approves", acknowledge: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, notif, userProv, approvalEngine, _, _ := newTestLeaveService()
+
+			repo.On("FindRequestByID", mock.Anything, uint(1)).Return(&LeaveRequest{
+				ID:          1,
+				UserID:      2,
+				EmployeeID:  1,
+				LeaveTypeID: 1,
+				StartDate:   juneDay(2),
+				EndDate:     juneDay(2),
+				TotalDays:   1,
+				Status:      constants.LeaveStatusPending,
+				User:        user.User{ID: 2},
+				LeaveType:   &master.LeaveType{IsDeducted: false},
+			}, nil)
+			repo.On("FindConflictRules", mock.Anything, true).Return(rules, nil)
+			userProv.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&user.Employee{ID: 1, DepartmentID: 3}, nil)
+			repo.On("FindHolidays", mock.Anything, juneDay(2), juneDay(2)).Return([]master.Holiday{}, nil)
+			approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil).Maybe()
+			repo.On("ApproveRequest", mock.Anything, uint(1), uint(5), mock.Anything, false, float64(1)).Return(nil).Maybe()
+			notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			err := svc.RequestAction(ctx, &LeaveActionRequest{
+				RequestID:            1,
+				ApproverID:           5,
+				Action:               string(constants.LeaveActionApprove),
+				AcknowledgeConflicts: tt.acknowledge,
+			})
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "acknowledge_conflicts")
+				approvalEngine.AssertNotCalled(t, "Decide", mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				repo.AssertCalled(t, "ApproveRequest", mock.Anything, uint(1), uint(5), mock.Anything, false, float64(1))
+			}
+		})
+	}
+}
+
+func TestService_SaveConflictRule(t *testing.T) {
+	ctx := testutil.CtxWithTenant(7, 1, false)
+
+	tests := []struct {
+		name       string
+		req        *SaveLeaveConflictRuleRequest
+		setupMocks func(*mockRepo)
+		errMsg     string
+	}{
+		{
+			name: "create blackout",
+			req:  &SaveLeaveConflictRuleRequest{Name: "Stock take", RuleType: string(constants.LeaveConflictBlackout), StartDate: "2026-12-21", EndDate: "2026-12-24", Enforcement: string(constants.LeaveConflictBlock), IsActive: true},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("SaveConflictRule", mock.Anything, mock.MatchedBy(func(r *LeaveConflictRule) bool {
+					return r.CompanyID == 7 && r.StartDate.Equal(time.Date(2026, 12, 21, 0, 0, 0, 0, time.UTC)) && r.IsActive
+				})).Return(nil)
+			},
+		},
+		{
+			name: "update clears fields of the previous type",
+			req:  &SaveLeaveConflictRuleRequest{ID: 3, Name: "Max 30%", RuleType: string(constants.LeaveConflictMaxPercent), MaxPercent: 30, Enforcement: string(constants.LeaveConflictWarn)},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindConflictRuleByID", mock.Anything, uint(3)).Return(&LeaveConflictRule{ID: 3, CompanyID: 7, RuleType: constants.LeaveConflictMaxEmployees, MaxEmployees: 2}, nil)
+				repo.On("SaveConflictRule", mock.Anything, mock.MatchedBy(func(r *LeaveConflictRule) bool {
+					return r.ID == 3 && r.MaxEmployees == 0 && r.MaxPercent == 30
+				})).Return(nil)
+			},
+		},
+		{
+			name:       "max employees required",
+			req:        &SaveLeaveConflictRuleRequest{Name: "Max", RuleType: string(constants.LeaveConflictMaxEmployees), Enforcement: string(constants.LeaveConflictWarn)},
+			setupMocks: func(repo *mockRepo) {},
+			errMsg:     "max employees must be at least 1",
+		},
+		{
+			name:       "blackout end before start",
+			req:        &SaveLeaveConflictRuleRequest{Name: "Stock take", RuleType: string(constants.LeaveConflictBlackout), StartDate: "2026-12-24", EndDate: "2026-12-21", Enforcement: string(constants.LeaveConflictBlock)},
+			setupMocks: func(repo *mockRepo) {},
+			errMsg:     "end date must be after start date",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, _, _, _, _, _ := newTestLeaveService()
+			tt.setupMocks(repo)
+
+			err := svc.SaveConflictRule(ctx, tt.req)
+
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_GetTeamCalendar(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	selfID := uint(1)
+	managerID := uint(1)
+
+	svc, repo, _, _, userProv, _, _, _ := newTestLeaveService()
+
+	userProv.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&user.Employee{ID: 1, DepartmentID: 3}, nil)
+	userProv.On("FindAllEmployeeActive", mock.Anything).Return([]user.Employee{
+		{ID: 1, FullName: "Lead", DepartmentID: 3, Shift: &master.Shift{Name: "Day", StartTime: "09:00", EndTime: "17:00"}},
+		{ID: 2, FullName: "Staff", DepartmentID: 3, ManagerID: &managerID},
+		{ID: 4, FullName: "Other", DepartmentID: 5},
+	}, nil)
+	repo.On("FindTeamRequests", mock.Anything, []uint{1, 2}, juneDay(1), juneDay(7), []constants.LeaveStatus{constants.LeaveStatusApproved, constants.LeaveStatusPending}).
+		Return([]LeaveRequest{
+			{ID: 10, EmployeeID: 2, StartDate: juneDay(1), EndDate: juneDay(2), Status: constants.LeaveStatusApproved, Employee: &user.Employee{FullName: "Staff"}},
+			{ID: 11, EmployeeID: 1, StartDate: juneDay(2), EndDate: juneDay(2), Status: constants.LeaveStatusPending},
+		}, nil)
+	repo.On("FindHolidays", mock.Anything, juneDay(1), juneDay(7)).Return([]master.Holiday{{Date: juneDay(4), Name: "Company Day"}}, nil)
+	repo.On("FindConflictRules", mock.Anything, true).Return([]LeaveConflictRule{}, nil)
+
+	// without the view permission the department filter is forced to the user's own
+	res, err := svc.GetTeamCalendar(ctx, &TeamCalendarFilter{StartDate: "2026-06-01", EndDate: "2026-06-07", DepartmentID: 5, EmployeeID: &selfID})
+
+	require.NoError(t, err)
+	require.Len(t, res.Members, 2)
+	assert.Equal(t, "Day", res.Members[0].ShiftName)
+	require.Len(t, res.Leaves, 2)
+	assert.Equal(t, "Staff", res.Leaves[0].EmployeeName)
+	require.Len(t, res.Days, 7)
+	assert.Equal(t, 1, res.Days[0].OffCount)
+	assert.Equal(t, 1, res.Days[1].PendingCount)
+	assert.Equal(t, "Company Day", res.Days[3].Holiday)
+	assert.False(t, res.Days[3].IsWorkingDay)
+	assert.False(t, res.Days[5].IsWorkingDay)
+
+	// a view permission scoped to the user's department shows nobody of another department
+	userProv.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{
+		{ID: 1, FullName: "Lead", DepartmentID: 3},
+		{ID: 2, FullName: "Staff", DepartmentID: 3, ManagerID: &managerID},
+	}, nil)
+	res, err = svc.GetTeamCalendar(ctx, &TeamCalendarFilter{StartDate: "2026-06-01", EndDate: "2026-06-07", CanViewAll: true, DepartmentID: 5})
+	require.NoError(t, err)
+	assert.Empty(t, res.Members)
+	assert.Empty(t, res.Leaves)
+	repo.AssertNumberOfCalls(t, "FindTeamRequests", 1)
+
+	_, err = svc.GetTeamCalendar(ctx, &TeamCalendarFilter{StartDate: "2026-06-01", EndDate: "2026-09-01", CanViewAll: true, DepartmentID: 3})
+	assert.EqualError(t, err, "calendar range must not exceed 62 days")
+
+	_, err = svc.GetTeamCalendar(ctx, &TeamCalendarFilter{StartDate: "2026-06-01", EndDate: "2026-06-07", CanViewAll: true})
+	assert.EqualError(t, err, "department or manager is required")
+}
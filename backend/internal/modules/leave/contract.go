@@ -1,6 +1,9 @@
 package leave
 
 import (
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
 	"context"
 	"io"
 )
@@ -23,5 +26,14 @@ type NotificationProvider interface {
 }
 
 type UserProvider interface {
-	FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error)
+	FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error)
+	FindEmployeeByID(ctx context.Context, id uint) (*user.Employee, error)
+	FindAllEmployeeActive(ctx context.Context) ([]user.Employee, error)
+	FindAllEmployeeActiveInScope(ctx context.Context) ([]user.Employee, error)
+}
+
+type ApprovalEngine interface {
+	Submit(ctx context.Context, req *approval.SubmitRequest) ([]uint, error)
+	Decide(ctx context.Context, req *approval.DecideRequest) (*approval.Decision, error)
+	Cancel(ctx context.Context, requestType constants.ApprovalRequestType, referenceID, actorUserID uint) error
 }
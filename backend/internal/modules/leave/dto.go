@@ -12,6 +12,9 @@ type ApplyRequest struct {
 	LeaveTypeID      uint   `json:"leave_type_id" validate:"required"`
 	StartDate        string `json:"start_date" validate:"required"`
 	EndDate          string `json:"end_date" validate:"required"`
+	DurationType     string `json:"duration_type" validate:"omitempty,oneof=FULL_DAY HALF_DAY_MORNING HALF_DAY_AFTERNOON HOURLY"`
+	StartTime        string `json:"start_time"`
+	EndTime          string `json:"end_time"`
 	Reason           string `json:"reason" validate:"required"`
 	AttachmentBase64 string `json:"attachment_base64"`
 }
@@ -21,6 +24,22 @@ type LeaveActionRequest struct {
 	ApproverID      uint   `json:"-"`
 	Action          string `json:"action" validate:"required"`
 	RejectionReason string `json:"rejection_reason" validate:"omitempty"`
+	// approve despite warnings of the team conflict rules
+	AcknowledgeConflicts bool `json:"acknowledge_conflicts"`
+}
+
+type CancelLeaveRequest struct {
+	RequestID uint   `json:"-"`
+	UserID    uint   `json:"-"`
+	CanManage bool   `json:"-"`
+	Reason    string `json:"reason" validate:"required"`
+}
+
+type ReturnLeaveRequest struct {
+	RequestID  uint   `json:"-"`
+	UserID     uint   `json:"-"`
+	CanManage  bool   `json:"-"`
+	ReturnDate string `json:"return_date" validate:"required"`
 }
 
 type LeaveFilter struct {
@@ -38,7 +57,8 @@ type LeaveRequestListResponse struct {
 	EmployeeNIK  string                          `json:"employee_nik"`
 	LeaveTypeID  uint                            `json:"leave_type_id"`
 	LeaveType    *master.LookupLeaveTypeResponse `json:"leave_type"`
-	TotalDays    int                             `json:"total_days"`
+	DurationType constants.LeaveDurationType     `json:"duration_type"`
+	TotalDays    float64                         `json:"total_days"`
 	StartDate    time.Time                       `json:"start_date"`
 	EndDate      time.Time                       `json:"end_date"`
 	Status       constants.LeaveStatus           `json:"status"`
@@ -54,10 +74,169 @@ type LeaveRequestDetailResponse struct {
 	LeaveType       *master.LookupLeaveTypeResponse `json:"leave_type"`
 	StartDate       time.Time                       `json:"start_date"`
 	EndDate         time.Time                       `json:"end_date"`
-	TotalDays       int                             `json:"total_days"`
+	DurationType    constants.LeaveDurationType     `json:"duration_type"`
+	StartTime       string                          `json:"start_time"`
+	EndTime         string                          `json:"end_time"`
+	TotalDays       float64                         `json:"total_days"`
+	TotalHours      float64                         `json:"total_hours"`
 	Reason          string                          `json:"reason"`
 	AttachmentURL   string                          `json:"attachment_url"`
 	Status          constants.LeaveStatus           `json:"status"`
 	RejectionReason string                          `json:"rejection_reason"`
-	CreatedAt       time.Time                       `json:"created_at"`
+
+	CancellationReason string     `json:"cancellation_reason"`
+	CancelledAt        *time.Time `json:"cancelled_at"`
+	ReturnedAt         *time.Time `json:"returned_at"`
+	RefundedDays       float64    `json:"refunded_days"`
+
+	// team conflict rules the request runs into, only checked while it is pending
+	Conflicts []LeaveConflictResponse `json:"conflicts"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UpsertLeavePolicyRequest struct {
+	LeaveTypeID           uint   `json:"leave_type_id" validate:"required"`
+	AccrualType           string `json:"accrual_type" validate:"required,oneof=ANNUAL MONTHLY"`
+	QuotaPerYear          int    `json:"quota_per_year" validate:"min=0,max=365"`
+	EligibilityMonths     int    `json:"eligibility_months" validate:"min=0"`
+	IsProRata             bool   `json:"is_pro_rata"`
+	CarryOverMax          int    `json:"carry_over_max" validate:"min=0"`
+	CarryOverExpiryMonths int    `json:"carry_over_expiry_months" validate:"min=0,max=12"`
+	TenureBonusEveryYears int    `json:"tenure_bonus_every_years" validate:"min=0"`
+	TenureBonusDays       int    `json:"tenure_bonus_days" validate:"min=0"`
+	TenureBonusMax        int    `json:"tenure_bonus_max" validate:"min=0"`
+}
+
+type LeavePolicyResponse struct {
+	LeaveTypeID           uint                       `json:"leave_type_id"`
+	LeaveTypeName         string                     `json:"leave_type_name"`
+	AccrualType           constants.LeaveAccrualType `json:"accrual_type"`
+	QuotaPerYear          int                        `json:"quota_per_year"`
+	EligibilityMonths     int                        `json:"eligibility_months"`
+	IsProRata             bool                       `json:"is_pro_rata"`
+	CarryOverMax          int                        `json:"carry_over_max"`
+	CarryOverExpiryMonths int                        `json:"carry_over_expiry_months"`
+	TenureBonusEveryYears int                        `json:"tenure_bonus_every_years"`
+	TenureBonusDays       int                        `json:"tenure_bonus_days"`
+	TenureBonusMax        int                        `json:"tenure_bonus_max"`
+	IsDefault             bool                       `json:"is_default"`
+}
+
+type LeaveLedgerFilter struct {
+	Page        int  `json:"page"`
+	Limit       int  `json:"limit"`
+	EmployeeID  uint `json:"employee_id"`
+	LeaveTypeID uint `json:"leave_type_id"`
+	Year        int  `json:"year"`
+}
+
+type LeaveLedgerResponse struct {
+	ID            uint                      `json:"id"`
+	EmployeeID    uint                      `json:"employee_id"`
+	EmployeeName  string                    `json:"employee_name"`
+	LeaveTypeID   uint                      `json:"leave_type_id"`
+	LeaveTypeName string                    `json:"leave_type_name"`
+	Year          int                       `json:"year"`
+	Type          constants.LeaveLedgerType `json:"type"`
+	Days          float64                   `json:"days"`
+	ExpiresAt     *time.Time                `json:"expires_at"`
+	ReferenceID   *uint                     `json:"reference_id"`
+	Notes         string                    `json:"notes"`
+	CreatedAt     time.Time                 `json:"created_at"`
+}
+
+type LeaveConflictResponse struct {
+	RuleID      uint                               `json:"rule_id"`
+	RuleName    string                             `json:"rule_name"`
+	RuleType    constants.LeaveConflictRuleType    `json:"rule_type"`
+	Enforcement constants.LeaveConflictEnforcement `json:"enforcement"`
+	Dates       []string                           `json:"dates"`
+	Message     string                             `json:"message"`
+}
+
+type SaveLeaveConflictRuleRequest struct {
+	ID           uint    `json:"-"`
+	Name         string  `json:"name" validate:"required,max=100"`
+	RuleType     string  `json:"rule_type" validate:"required,oneof=MAX_EMPLOYEES MAX_PERCENT BLACKOUT"`
+	DepartmentID *uint   `json:"department_id"`
+	MaxEmployees int     `json:"max_employees" validate:"min=0"`
+	MaxPercent   float64 `json:"max_percent" validate:"min=0,max=100"`
+	StartDate    string  `json:"start_date"`
+	EndDate      string  `json:"end_date"`
+	Enforcement  string  `json:"enforcement" validate:"required,oneof=BLOCK WARN"`
+	IsActive     bool    `json:"is_active"`
+}
+
+type LeaveConflictRuleResponse struct {
+	ID             uint                               `json:"id"`
+	Name           string                             `json:"name"`
+	RuleType       constants.LeaveConflictRuleType    `json:"rule_type"`
+	DepartmentID   *uint                              `json:"department_id"`
+	DepartmentName string                             `json:"department_name"`
+	MaxEmployees   int                                `json:"max_employees"`
+	MaxPercent     float64                            `json:"max_percent"`
+	StartDate      *string                            `json:"start_date"`
+	EndDate        *string                            `json:"end_date"`
+	Enforcement    constants.LeaveConflictEnforcement `json:"enforcement"`
+	IsActive       bool                               `json:"is_active"`
+}
+
+type TeamCalendarFilter struct {
+	StartDate    string `json:"start_date"`
+	EndDate      string `json:"end_date"`
+	DepartmentID uint   `json:"department_id"`
+	// employee ID of a manager, lists their direct reports
+	ManagerID uint `json:"manager_id"`
+
+	// set by the handler, users who cannot view every leave only see their own department
+	EmployeeID *uint `json:"-"`
+	CanViewAll bool  `json:"-"`
+}
+
+type TeamCalendarResponse struct {
+	StartDate string                      `json:"start_date"`
+	EndDate   string                      `json:"end_date"`
+	Members   []CalendarMemberResponse    `json:"members"`
+	Leaves    []CalendarLeaveResponse     `json:"leaves"`
+	Days      []CalendarDayResponse       `json:"days"`
+	Blackouts []LeaveConflictRuleResponse `json:"blackouts"`
+}
+
+type CalendarMemberResponse struct {
+	EmployeeID   uint   `json:"employee_id"`
+	EmployeeName string `json:"employee_name"`
+	Position     string `json:"position"`
+	DepartmentID uint   `json:"department_id"`
+	ShiftName    string `json:"shift_name"`
+	ShiftStart   string `json:"shift_start"`
+	ShiftEnd     string `json:"shift_end"`
+}
+
+type CalendarLeaveResponse struct {
+	ID           uint                        `json:"id"`
+	EmployeeID   uint                        `json:"employee_id"`
+	EmployeeName string                      `json:"employee_name"`
+	LeaveType    string                      `json:"leave_type"`
+	StartDate    string                      `json:"start_date"`
+	EndDate      string                      `json:"end_date"`
+	DurationType constants.LeaveDurationType `json:"duration_type"`
+	StartTime    string                      `json:"start_time"`
+	EndTime      string                      `json:"end_time"`
+	Status       constants.LeaveStatus       `json:"status"`
+}
+
+type CalendarDayResponse struct {
+	Date         string `json:"date"`
+	IsWorkingDay bool   `json:"is_working_day"`
+	Holiday      string `json:"holiday"`
+	OffCount     int    `json:"off_count"`
+	PendingCount int    `json:"pending_count"`
+}
+
+// UnusedLeave is the quota an employee has left on a leave type that is deducted from a balance.
+type UnusedLeave struct {
+	LeaveTypeID   uint    `json:"leave_type_id"`
+	LeaveTypeName string  `json:"leave_type_name"`
+	Days          float64 `json:"days"`
 }
@@ -0,0 +1,144 @@
+package leave
+
+import (
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/pkg/constants"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// leaveDuration returns the days and hours a request takes from the quota.
+// Full days only count Monday to Friday, the same days approval turns into attendance.
+func leaveDuration(durationType constants.LeaveDurationType, start, end time.Time, startTime, endTime string) (float64, float64, error) {
+	switch durationType {
+	case constants.LeaveDurationFullDay:
+		days := countLeaveDays(start, end)
+		if days == 0 {
+			return 0, 0, errors.New("leave period has no working days")
+		}
+		return float64(days), float64(days) * constants.LeaveWorkHoursPerDay, nil
+
+	case constants.LeaveDurationHalfDayMorning, constants.LeaveDurationHalfDayAfternoon:
+		if err := validatePartialDay(start, end); err != nil {
+			return 0, 0, err
+		}
+		return 0.5, constants.LeaveWorkHoursPerDay / 2, nil
+
+	case constants.LeaveDurationHourly:
+		if err := validatePartialDay(start, end); err != nil {
+			return 0, 0, err
+		}
+
+		from, err := time.Parse(constants.ShiftHourFormat, startTime)
+		if err != nil {
+			return 0, 0, errors.New("invalid start time format")
+		}
+		to, err := time.Parse(constants.ShiftHourFormat, endTime)
+		if err != nil {
+			return 0, 0, errors.New("invalid end time format")
+		}
+		if !to.After(from) {
+			return 0, 0, errors.New("end time must be after start time")
+		}
+
+		hours := to.Sub(from).Hours()
+		if hours > constants.LeaveWorkHoursPerDay {
+			return 0, 0, errors.New("hourly leave cannot exceed one working day")
+		}
+		return roundDays(hours / constants.LeaveWorkHoursPerDay), roundDays(hours), nil
+
+	default:
+		return 0, 0, fmt.Errorf("invalid duration type: %s", durationType)
+	}
+}
+
+func validatePartialDay(start, end time.Time) error {
+	if !sameDate(start, end) {
+		return errors.New("partial day leave must start and end on the same date")
+	}
+	if !isLeaveWorkingDay(start) {
+		return errors.New("leave date is not a working day")
+	}
+	return nil
+}
+
+// excusedWindow returns the hours of the leave date covered by a half day or hourly request.
+// Half days split the employee's shift in the middle.
+func excusedWindow(req *LeaveRequest, shift *master.Shift) (time.Time, time.Time, error) {
+	date := req.StartDate
+
+	if req.DurationType == constants.LeaveDurationHourly {
+		from, err := clockOnDate(date, req.StartTime)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		until, err := clockOnDate(date, req.EndTime)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return from, until, nil
+	}
+
+	if shift == nil {
+		return time.Time{}, time.Time{}, errors.New("employee shift not assigned")
+	}
+
+	shiftStart, err := clockOnDate(date, shift.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid shift time configuration")
+	}
+	shiftEnd, err := clockOnDate(date, shift.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid shift time configuration")
+	}
+	if !shiftEnd.After(shiftStart) {
+		shiftEnd = shiftEnd.AddDate(0, 0, 1)
+	}
+
+	midday := shiftStart.Add(shiftEnd.Sub(shiftStart) / 2)
+	if req.DurationType == constants.LeaveDurationHalfDayMorning {
+		return shiftStart, midday, nil
+	}
+	return midday, shiftEnd, nil
+}
+
+// clockOnDate places a HH:MM or HH:MM:SS clock on the given date.
+func clockOnDate(date time.Time, clock string) (time.Time, error) {
+	parsed, err := time.Parse(constants.AttendanceTimeFormat, clock)
+	if err != nil {
+		parsed, err = time.Parse(constants.ShiftHourFormat, clock)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), 0, date.Location()), nil
+}
+
+// countLeaveDays counts the working days between from and to inclusive.
+func countLeaveDays(from, to time.Time) int {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	days := 0
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if isLeaveWorkingDay(d) {
+			days++
+		}
+	}
+	return days
+}
+
+func isLeaveWorkingDay(d time.Time) bool {
+	return d.Weekday() != time.Saturday && d.Weekday() != time.Sunday
+}
+
+func sameDate(a, b time.Time) bool {
+	return a.Format(constants.DefaultTimeFormat) == b.Format(constants.DefaultTimeFormat)
+}
+
+func roundDays(days float64) float64 {
+	return math.Round(days*100) / 100
+}
@@ -0,0 +1,125 @@
+package leave
+
+import (
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaveDuration(t *testing.T) {
+	monday := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		durationType constants.LeaveDurationType
+		start, end   time.Time
+		startTime    string
+		endTime      string
+		wantDays     float64
+		wantHours    float64
+		errMsg       string
+	}{
+		{name: "full days skip the weekend", durationType: constants.LeaveDurationFullDay, start: time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC), end: time.Date(2026, 6, 8, 0, 0, 0, 0, time.UTC), wantDays: 2, wantHours: 16},
+		{name: "full days on a weekend only", durationType: constants.LeaveDurationFullDay, start: time.Date(2026, 6, 6, 0, 0, 0, 0, time.UTC), end: time.Date(2026, 6, 7, 0, 0, 0, 0, time.UTC), errMsg: "leave period has no working days"},
+		{name: "half day morning", durationType: constants.LeaveDurationHalfDayMorning, start: monday, end: monday, wantDays: 0.5, wantHours: 4},
+		{name: "half day afternoon", durationType: constants.LeaveDurationHalfDayAfternoon, start: monday, end: monday, wantDays: 0.5, wantHours: 4},
+		{name: "half day across dates", durationType: constants.LeaveDurationHalfDayMorning, start: monday, end: monday.AddDate(0, 0, 1), errMsg: "partial day leave must start and end on the same date"},
+		{name: "half day on a saturday", durationType: constants.LeaveDurationHalfDayMorning, start: monday.AddDate(0, 0, 5), end: monday.AddDate(0, 0, 5), errMsg: "leave date is not a working day"},
+		{name: "hourly", durationType: constants.LeaveDurationHourly, start: monday, end: monday, startTime: "13:00", endTime: "16:00", wantDays: 0.38, wantHours: 3},
+		{name: "hourly invalid start time", durationType: constants.LeaveDurationHourly, start: monday, end: monday, startTime: "1pm", endTime: "16:00", errMsg: "invalid start time format"},
+		{name: "hourly invalid end time", durationType: constants.LeaveDurationHourly, start: monday, end: monday, startTime: "13:00", errMsg: "invalid end time format"},
+		{name: "hourly end before start", durationType: constants.LeaveDurationHourly, start: monday, end: monday, startTime: "13:00", endTime: "12:00", errMsg: "end time must be after start time"},
+		{name: "hourly longer than a working day", durationType: constants.LeaveDurationHourly, start: monday, end: monday, startTime: "07:00", endTime: "17:00", errMsg: "hourly leave cannot exceed one working day"},
+		{name: "unknown duration type", durationType: "WEEKLY", start: monday, end: monday, errMsg: "invalid duration type: WEEKLY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, hours, err := leaveDuration(tt.durationType, tt.start, tt.end, tt.startTime, tt.endTime)
+
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDays, days)
+			assert.Equal(t, tt.wantHours, hours)
+		})
+	}
+}
+
+func TestExcusedWindow(t *testing.T) {
+	leaveDate := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 6, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		req       *LeaveRequest
+		shift     *master.Shift
+		wantFrom  time.Time
+		wantUntil time.Time
+		errMsg    string
+	}{
+		{
+			name:      "morning covers the first half of the shift",
+			req:       &LeaveRequest{StartDate: leaveDate, DurationType: constants.LeaveDurationHalfDayMorning},
+			shift:     &master.Shift{StartTime: "08:00:00", EndTime: "17:00:00"},
+			wantFrom:  at(8, 0),
+			wantUntil: at(12, 30),
+		},
+		{
+			name:      "afternoon covers the second half of the shift",
+			req:       &LeaveRequest{StartDate: leaveDate, DurationType: constants.LeaveDurationHalfDayAfternoon},
+			shift:     &master.Shift{StartTime: "08:00:00", EndTime: "17:00:00"},
+			wantFrom:  at(12, 30),
+			wantUntil: at(17, 0),
+		},
+		{
+			name:      "hourly uses the requested hours",
+			req:       &LeaveRequest{StartDate: leaveDate, DurationType: constants.LeaveDurationHourly, StartTime: "09:00", EndTime: "11:30"},
+			wantFrom:  at(9, 0),
+			wantUntil: at(11, 30),
+		},
+		{
+			name:      "night shift crosses midnight",
+			req:       &LeaveRequest{StartDate: leaveDate, DurationType: constants.LeaveDurationHalfDayAfternoon},
+			shift:     &master.Shift{StartTime: "22:00", EndTime: "06:00"},
+			wantFrom:  time.Date(2026, 6, 2, 2, 0, 0, 0, time.UTC),
+			wantUntil: time.Date(2026, 6, 2, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "half day without shift",
+			req:    &LeaveRequest{StartDate: leaveDate, DurationType: constants.LeaveDurationHalfDayMorning},
+			errMsg: "employee shift not assigned",
+		},
+		{
+			name:   "invalid shift time",
+			req:    &LeaveRequest{StartDate: leaveDate, DurationType: constants.LeaveDurationHalfDayMorning},
+			shift:  &master.Shift{StartTime: "eight", EndTime: "17:00"},
+			errMsg: "invalid shift time configuration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, until, err := excusedWindow(tt.req, tt.shift)
+
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFrom, from)
+			assert.Equal(t, tt.wantUntil, until)
+		})
+	}
+}
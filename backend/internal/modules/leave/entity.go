@@ -1,6 +1,7 @@
 package leave
 
 import (
+	"basekarya-backend/internal/modules/department"
 	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/constants"
@@ -10,14 +11,18 @@ import (
 )
 
 type LeaveBalance struct {
-	ID          uint `gorm:"primaryKey" json:"id"`
-	EmployeeID  uint `gorm:"index" json:"employee_id"`
-	LeaveTypeID uint `json:"leave_type_id"`
-	CompanyID   uint `gorm:"index;not null" json:"company_id"`
-	Year        int  `json:"year"`
-	QuotaTotal  int  `json:"quota_total"`
-	QuotaUsed   int  `json:"quota_used"`
-	QuotaLeft   int  `json:"quota_left"`
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	EmployeeID  uint    `gorm:"index" json:"employee_id"`
+	LeaveTypeID uint    `json:"leave_type_id"`
+	CompanyID   uint    `gorm:"index;not null" json:"company_id"`
+	Year        int     `json:"year"`
+	QuotaTotal  float64 `gorm:"type:decimal(6,2)" json:"quota_total"`
+	QuotaUsed   float64 `gorm:"type:decimal(6,2)" json:"quota_used"`
+	QuotaLeft   float64 `gorm:"type:decimal(6,2)" json:"quota_left"`
+
+	// days brought over from the previous year, consumed before this year's accrual
+	CarryOver          float64    `gorm:"type:decimal(6,2);default:0" json:"carry_over"`
+	CarryOverExpiresAt *time.Time `gorm:"type:date" json:"carry_over_expires_at"`
 
 	Employee  *user.Employee    `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
 	LeaveType *master.LeaveType `gorm:"foreignKey:LeaveTypeID" json:"leave_type,omitempty"`
@@ -36,7 +41,13 @@ type LeaveRequest struct {
 
 	StartDate time.Time `gorm:"type:date;not null" json:"start_date"`
 	EndDate   time.Time `gorm:"type:date;not null" json:"end_date"`
-	TotalDays int       `json:"total_days"`
+
+	DurationType constants.LeaveDurationType `gorm:"type:varchar(20);default:'FULL_DAY'" json:"duration_type"`
+	// HH:MM range of hourly leave, empty for full and half day leave
+	StartTime  string  `gorm:"type:varchar(5)" json:"start_time"`
+	EndTime    string  `gorm:"type:varchar(5)" json:"end_time"`
+	TotalDays  float64 `gorm:"type:decimal(6,2)" json:"total_days"`
+	TotalHours float64 `gorm:"type:decimal(6,2)" json:"total_hours"`
 
 	Reason        string `gorm:"type:text" json:"reason"`
 	AttachmentURL string `json:"attachment_url"`
@@ -46,7 +57,80 @@ type LeaveRequest struct {
 	ApprovedBy      *uint  `json:"approved_by"`
 	RejectionReason string `json:"rejection_reason"`
 
+	CancelledBy        *uint      `json:"cancelled_by"`
+	CancelledAt        *time.Time `json:"cancelled_at"`
+	CancellationReason string     `gorm:"type:varchar(255)" json:"cancellation_reason"`
+
+	// first day back at work when the employee returns before EndDate
+	ReturnedAt   *time.Time `gorm:"type:date" json:"returned_at"`
+	RefundedDays float64    `gorm:"type:decimal(6,2);default:0" json:"refunded_days"`
+
 	User      user.User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Employee  *user.Employee    `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
 	LeaveType *master.LeaveType `gorm:"foreignKey:LeaveTypeID" json:"leave_type,omitempty"`
 }
+
+type LeavePolicy struct {
+	ID          uint                       `gorm:"primaryKey" json:"id"`
+	CompanyID   uint                       `gorm:"uniqueIndex:idx_leave_policies_company_leave_type;not null" json:"company_id"`
+	LeaveTypeID uint                       `gorm:"uniqueIndex:idx_leave_policies_company_leave_type;not null" json:"leave_type_id"`
+	AccrualType constants.LeaveAccrualType `gorm:"type:varchar(20);default:'ANNUAL'" json:"accrual_type"`
+
+	QuotaPerYear      int  `json:"quota_per_year"`
+	EligibilityMonths int  `json:"eligibility_months"`
+	IsProRata         bool `json:"is_pro_rata"`
+
+	CarryOverMax          int `json:"carry_over_max"`
+	CarryOverExpiryMonths int `json:"carry_over_expiry_months"`
+
+	TenureBonusEveryYears int `json:"tenure_bonus_every_years"`
+	TenureBonusDays       int `json:"tenure_bonus_days"`
+	TenureBonusMax        int `json:"tenure_bonus_max"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	LeaveType *master.LeaveType `gorm:"foreignKey:LeaveTypeID" json:"leave_type,omitempty"`
+}
+
+type LeaveLedger struct {
+	ID          uint                      `gorm:"primaryKey" json:"id"`
+	CompanyID   uint                      `gorm:"index;not null" json:"company_id"`
+	EmployeeID  uint                      `gorm:"index:idx_leave_ledgers_balance" json:"employee_id"`
+	LeaveTypeID uint                      `gorm:"index:idx_leave_ledgers_balance" json:"leave_type_id"`
+	Year        int                       `gorm:"index:idx_leave_ledgers_balance" json:"year"`
+	Type        constants.LeaveLedgerType `gorm:"type:varchar(20);not null" json:"type"`
+	Days        float64                   `gorm:"type:decimal(6,2)" json:"days"`
+	ExpiresAt   *time.Time                `gorm:"type:date" json:"expires_at"`
+	ReferenceID *uint                     `json:"reference_id"`
+	Notes       string                    `gorm:"type:varchar(255)" json:"notes"`
+	CreatedAt   time.Time                 `json:"created_at"`
+
+	Employee  *user.Employee    `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
+	LeaveType *master.LeaveType `gorm:"foreignKey:LeaveTypeID" json:"leave_type,omitempty"`
+}
+
+// LeaveConflictRule limits how many people of a department may be off on the same day,
+// or closes a period for leave altogether.
+type LeaveConflictRule struct {
+	ID        uint                            `gorm:"primaryKey" json:"id"`
+	CompanyID uint                            `gorm:"index;not null" json:"company_id"`
+	Name      string                          `gorm:"type:varchar(100);not null" json:"name"`
+	RuleType  constants.LeaveConflictRuleType `gorm:"type:varchar(20);not null" json:"rule_type"`
+	// nil applies the rule to every department, each department counted on its own
+	DepartmentID *uint `gorm:"index" json:"department_id"`
+
+	MaxEmployees int     `gorm:"default:0" json:"max_employees"`
+	MaxPercent   float64 `gorm:"type:decimal(5,2);default:0" json:"max_percent"`
+	// period of BLACKOUT rules
+	StartDate *time.Time `gorm:"type:date" json:"start_date"`
+	EndDate   *time.Time `gorm:"type:date" json:"end_date"`
+
+	Enforcement constants.LeaveConflictEnforcement `gorm:"type:varchar(10);not null" json:"enforcement"`
+	IsActive    bool                               `json:"is_active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Department *department.Department `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
+}
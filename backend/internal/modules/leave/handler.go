@@ -81,6 +81,74 @@ func (h *Handler) RequestAction(ctx echo.Context) error {
 	return response.NewResponses[any](ctx, http.StatusOK, "Process Approval Action Leave Request Success", nil, nil, nil)
 }
 
+func (h *Handler) Cancel(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req CancelLeaveRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.RequestID = uint(id)
+	req.UserID = userContext.UserID
+	req.CanManage = slices.Contains(userContext.Permissions, constants.APPROVAL_LEAVE)
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	err = h.service.Cancel(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("Cancel leave request failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Cancel Leave Request Success", nil, nil, nil)
+}
+
+func (h *Handler) ReturnEarly(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req ReturnLeaveRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.RequestID = uint(id)
+	req.UserID = userContext.UserID
+	req.CanManage = slices.Contains(userContext.Permissions, constants.APPROVAL_LEAVE)
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	err = h.service.ReturnEarly(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("Return early from leave failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Return Early From Leave Success", nil, nil, nil)
+}
+
 func (h *Handler) GetAll(ctx echo.Context) error {
 	userContext, err := utils.GetUserContext(ctx)
 	if err != nil {
@@ -164,3 +232,168 @@ func (h *Handler) Export(ctx echo.Context) error {
 	ctx.Response().Header().Set("Content-Disposition", "attachment; filename=leaves.xlsx")
 	return ctx.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelFile)
 }
+
+func (h *Handler) GetPolicies(ctx echo.Context) error {
+	data, err := h.service.GetPolicies(ctx.Request().Context())
+	if err != nil {
+		logger.Errorw("get leave policies failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Leave Policies Success", data, nil, nil)
+}
+
+func (h *Handler) UpsertPolicy(ctx echo.Context) error {
+	var req UpsertLeavePolicyRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := h.service.UpsertPolicy(ctx.Request().Context(), &req); err != nil {
+		logger.Errorw("upsert leave policy failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Save Leave Policy Success", nil, nil, nil)
+}
+
+func (h *Handler) AccrueBalances(ctx echo.Context) error {
+	if err := h.service.AccrueBalances(ctx.Request().Context()); err != nil {
+		logger.Errorw("accrue leave balances failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Accrue Leave Balances Success", nil, nil, nil)
+}
+
+func (h *Handler) GetLedger(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	page, _ := strconv.Atoi(ctx.QueryParam("page"))
+	limit, _ := strconv.Atoi(ctx.QueryParam("limit"))
+	employeeID, _ := strconv.Atoi(ctx.QueryParam("employee_id"))
+	leaveTypeID, _ := strconv.Atoi(ctx.QueryParam("leave_type_id"))
+	year, _ := strconv.Atoi(ctx.QueryParam("year"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	filter := LeaveLedgerFilter{
+		Page:        page,
+		Limit:       limit,
+		EmployeeID:  uint(employeeID),
+		LeaveTypeID: uint(leaveTypeID),
+		Year:        year,
+	}
+
+	if !slices.Contains(userContext.Permissions, constants.VIEW_LEAVE) && slices.Contains(userContext.Permissions, constants.VIEW_SELF_LEAVE) {
+		if userContext.EmployeeID == nil {
+			return response.NewResponses[any](ctx, http.StatusForbidden, "employee profile not found", nil, nil, nil)
+		}
+		filter.EmployeeID = *userContext.EmployeeID
+	}
+
+	data, meta, err := h.service.GetLedger(ctx.Request().Context(), &filter)
+	if err != nil {
+		logger.Errorw("get leave ledger failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Leave Ledger Success", data, nil, meta)
+}
+
+func (h *Handler) GetTeamCalendar(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	departmentID, _ := strconv.Atoi(ctx.QueryParam("department_id"))
+	managerID, _ := strconv.Atoi(ctx.QueryParam("manager_id"))
+
+	filter := TeamCalendarFilter{
+		StartDate:    ctx.QueryParam("start_date"),
+		EndDate:      ctx.QueryParam("end_date"),
+		DepartmentID: uint(departmentID),
+		ManagerID:    uint(managerID),
+		EmployeeID:   userContext.EmployeeID,
+		CanViewAll:   slices.Contains(userContext.Permissions, constants.VIEW_LEAVE) || slices.Contains(userContext.Permissions, constants.APPROVAL_LEAVE),
+	}
+
+	data, err := h.service.GetTeamCalendar(ctx.Request().Context(), &filter)
+	if err != nil {
+		logger.Errorw("get team leave calendar failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Team Leave Calendar Success", data, nil, nil)
+}
+
+func (h *Handler) GetConflictRules(ctx echo.Context) error {
+	data, err := h.service.GetConflictRules(ctx.Request().Context())
+	if err != nil {
+		logger.Errorw("get leave conflict rules failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Leave Conflict Rules Success", data, nil, nil)
+}
+
+func (h *Handler) SaveConflictRule(ctx echo.Context) error {
+	var req SaveLeaveConflictRuleRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if ctx.Param("id") != "" {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+		}
+		req.ID = uint(id)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := h.service.SaveConflictRule(ctx.Request().Context(), &req); err != nil {
+		logger.Errorw("save leave conflict rule failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Save Leave Conflict Rule Success", nil, nil, nil)
+}
+
+func (h *Handler) DeleteConflictRule(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	if err := h.service.DeleteConflictRule(ctx.Request().Context(), uint(id)); err != nil {
+		logger.Errorw("delete leave conflict rule failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Delete Leave Conflict Rule Success", nil, nil, nil)
+}
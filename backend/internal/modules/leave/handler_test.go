@@ -223,3 +223,380 @@ func TestHandler_RequestAction(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_Cancel(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathParams map[string]string
+		body       interface{}
+		perms      []string
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name:       "employee cancels own request",
+			pathParams: map[string]string{"id": "1"},
+			body:       CancelLeaveRequest{Reason: "Plans changed"},
+			perms:      []string{constants.CREATE_LEAVE},
+			setupMocks: func(svc *mockService) {
+				svc.On("Cancel", mock.Anything, mock.MatchedBy(func(req *CancelLeaveRequest) bool {
+					return req.RequestID == 1 && req.UserID == 1 && !req.CanManage
+				})).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "approver can cancel any request",
+			pathParams: map[string]string{"id": "2"},
+			body:       CancelLeaveRequest{Reason: "Project deadline"},
+			perms:      []string{constants.APPROVAL_LEAVE},
+			setupMocks: func(svc *mockService) {
+				svc.On("Cancel", mock.Anything, mock.MatchedBy(func(req *CancelLeaveRequest) bool {
+					return req.RequestID == 2 && req.CanManage
+				})).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing reason",
+			pathParams: map[string]string{"id": "1"},
+			body:       CancelLeaveRequest{},
+			perms:      []string{constants.CREATE_LEAVE},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid id",
+			pathParams: map[string]string{"id": "abc"},
+			body:       CancelLeaveRequest{Reason: "Test"},
+			perms:      []string{constants.CREATE_LEAVE},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "service error",
+			pathParams: map[string]string{"id": "1"},
+			body:       CancelLeaveRequest{Reason: "Test"},
+			perms:      []string{constants.CREATE_LEAVE},
+			setupMocks: func(svc *mockService) {
+				svc.On("Cancel", mock.Anything, mock.Anything).Return(errors.New("leave has already started, use early return instead"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPut, "/api/leave/:id/cancel", tt.body)
+			at.WithPathParams(tt.pathParams)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      1,
+				CompanyID:   1,
+				Permissions: tt.perms,
+			})
+
+			rec, err := at.Execute(handler.Cancel)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ReturnEarly(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathParams map[string]string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			pathParams: map[string]string{"id": "1"},
+			body:       ReturnLeaveRequest{ReturnDate: "2026-06-10"},
+			setupMocks: func(svc *mockService) {
+				svc.On("ReturnEarly", mock.Anything, mock.MatchedBy(func(req *ReturnLeaveRequest) bool {
+					return req.RequestID == 1 && req.UserID == 1 && req.ReturnDate == "2026-06-10"
+				})).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing return date",
+			pathParams: map[string]string{"id": "1"},
+			body:       ReturnLeaveRequest{},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "service error",
+			pathParams: map[string]string{"id": "1"},
+			body:       ReturnLeaveRequest{ReturnDate: "2026-06-20"},
+			setupMocks: func(svc *mockService) {
+				svc.On("ReturnEarly", mock.Anything, mock.Anything).Return(errors.New("return date must be within the leave period"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPut, "/api/leave/:id/return", tt.body)
+			at.WithPathParams(tt.pathParams)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      1,
+				CompanyID:   1,
+				Permissions: []string{constants.CREATE_LEAVE},
+			})
+
+			rec, err := at.Execute(handler.ReturnEarly)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_UpsertPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: UpsertLeavePolicyRequest{LeaveTypeID: 1, AccrualType: "MONTHLY", QuotaPerYear: 12, CarryOverMax: 5},
+			setupMocks: func(svc *mockService) {
+				svc.On("UpsertPolicy", mock.Anything, mock.AnythingOfType("*leave.UpsertLeavePolicyRequest")).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "validation error invalid accrual type",
+			body:       UpsertLeavePolicyRequest{LeaveTypeID: 1, AccrualType: "WEEKLY"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: UpsertLeavePolicyRequest{LeaveTypeID: 9, AccrualType: "ANNUAL"},
+			setupMocks: func(svc *mockService) {
+				svc.On("UpsertPolicy", mock.Anything, mock.AnythingOfType("*leave.UpsertLeavePolicyRequest")).Return(errors.New("leave type not found"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPut, "/api/leave/policies", tt.body)
+
+			rec, err := at.Execute(handler.UpsertPolicy)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_GetLedger(t *testing.T) {
+	employeeID := uint(5)
+
+	tests := []struct {
+		name         string
+		queryParams  string
+		setupMocks   func(*mockService)
+		setupContext func(*testutil.APITest)
+		wantStatus   int
+	}{
+		{
+			name:        "admin filters by employee",
+			queryParams: "?employee_id=3&year=2026",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetLedger", mock.Anything, mock.MatchedBy(func(f *LeaveLedgerFilter) bool {
+					return f.EmployeeID == 3 && f.Year == 2026 && f.Page == 1 && f.Limit == 10
+				})).Return([]LeaveLedgerResponse{}, (*response.Meta)(nil), nil)
+			},
+			setupContext: func(at *testutil.APITest) {
+				at.WithAuthContext(&infrastructure.MyClaims{
+					UserID:      1,
+					CompanyID:   1,
+					Permissions: []string{constants.VIEW_LEAVE},
+				})
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "self view is limited to own employee",
+			queryParams: "?employee_id=3",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetLedger", mock.Anything, mock.MatchedBy(func(f *LeaveLedgerFilter) bool {
+					return f.EmployeeID == employeeID
+				})).Return([]LeaveLedgerResponse{}, (*response.Meta)(nil), nil)
+			},
+			setupContext: func(at *testutil.APITest) {
+				at.WithAuthContext(&infrastructure.MyClaims{
+					UserID:      2,
+					CompanyID:   1,
+					EmployeeID:  &employeeID,
+					Permissions: []string{constants.VIEW_SELF_LEAVE},
+				})
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/leave/ledger"+tt.queryParams, nil)
+			tt.setupContext(at)
+
+			rec, err := at.Execute(handler.GetLedger)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_GetTeamCalendar(t *testing.T) {
+	employeeID := uint(5)
+
+	tests := []struct {
+		name       string
+		perms      []string
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name:  "approver can view any department",
+			perms: []string{constants.APPROVAL_LEAVE},
+			setupMocks: func(svc *mockService) {
+				svc.On("GetTeamCalendar", mock.Anything, mock.MatchedBy(func(f *TeamCalendarFilter) bool {
+					return f.CanViewAll && f.DepartmentID == 3 && f.StartDate == "2026-06-01" && f.EndDate == "2026-06-30"
+				})).Return(&TeamCalendarResponse{}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "self view is passed the employee",
+			perms: []string{constants.VIEW_SELF_LEAVE},
+			setupMocks: func(svc *mockService) {
+				svc.On("GetTeamCalendar", mock.Anything, mock.MatchedBy(func(f *TeamCalendarFilter) bool {
+					return !f.CanViewAll && f.EmployeeID != nil && *f.EmployeeID == employeeID
+				})).Return(&TeamCalendarResponse{}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "service error",
+			perms: []string{constants.VIEW_LEAVE},
+			setupMocks: func(svc *mockService) {
+				svc.On("GetTeamCalendar", mock.Anything, mock.Anything).Return(nil, errors.New("calendar range must not exceed 62 days"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/leave/calendar?start_date=2026-06-01&end_date=2026-06-30&department_id=3", nil)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      1,
+				CompanyID:   1,
+				EmployeeID:  &employeeID,
+				Permissions: tt.perms,
+			})
+
+			rec, err := at.Execute(handler.GetTeamCalendar)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_SaveConflictRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathParams map[string]string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "create",
+			body: map[string]interface{}{"name": "Two off per day", "rule_type": "MAX_EMPLOYEES", "max_employees": 2, "enforcement": "BLOCK", "is_active": true},
+			setupMocks: func(svc *mockService) {
+				svc.On("SaveConflictRule", mock.Anything, mock.MatchedBy(func(req *SaveLeaveConflictRuleRequest) bool {
+					return req.ID == 0 && req.MaxEmployees == 2
+				})).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "update takes the id from the path",
+			pathParams: map[string]string{"id": "4"},
+			body:       map[string]interface{}{"name": "Stock take", "rule_type": "BLACKOUT", "start_date": "2026-12-21", "end_date": "2026-12-24", "enforcement": "WARN"},
+			setupMocks: func(svc *mockService) {
+				svc.On("SaveConflictRule", mock.Anything, mock.MatchedBy(func(req *SaveLeaveConflictRuleRequest) bool {
+					return req.ID == 4
+				})).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid enforcement",
+			body:       map[string]interface{}{"name": "Two off per day", "rule_type": "MAX_EMPLOYEES", "max_employees": 2, "enforcement": "NOTIFY"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: map[string]interface{}{"name": "Two off per day", "rule_type": "MAX_EMPLOYEES", "enforcement": "BLOCK"},
+			setupMocks: func(svc *mockService) {
+				svc.On("SaveConflictRule", mock.Anything, mock.Anything).Return(errors.New("max employees must be at least 1"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/leave/conflict-rules", tt.body)
+			if tt.pathParams != nil {
+				at.WithPathParams(tt.pathParams)
+			}
+
+			rec, err := at.Execute(handler.SaveConflictRule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
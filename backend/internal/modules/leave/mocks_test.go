@@ -3,9 +3,12 @@ package leave
 import (
 	"context"
 	"io"
+	"time"
 
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/internal/modules/attendance"
 	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
 
@@ -42,10 +45,23 @@ func (m *mockRepo) GetBalance(ctx context.Context, employeeID, leaveTypeID uint,
 	return args.Get(0).(*LeaveBalance), args.Error(1)
 }
 
-func (m *mockRepo) ApproveRequest(ctx context.Context, requestID, approverID uint, attendanceRecords []attendance.Attendance, shouldDeduct bool, days int) error {
+func (m *mockRepo) FindBalancesByEmployee(ctx context.Context, employeeID uint, year int) ([]LeaveBalance, error) {
+	args := m.Called(ctx, employeeID, year)
+	return args.Get(0).([]LeaveBalance), args.Error(1)
+}
+
+func (m *mockRepo) ApproveRequest(ctx context.Context, requestID, approverID uint, attendanceRecords []attendance.Attendance, shouldDeduct bool, days float64) error {
 	return m.Called(ctx, requestID, approverID, attendanceRecords, shouldDeduct, days).Error(0)
 }
 
+func (m *mockRepo) CancelRequest(ctx context.Context, req *LeaveRequest, cancelledBy uint, reason string, refundDays float64) error {
+	return m.Called(ctx, req, cancelledBy, reason, refundDays).Error(0)
+}
+
+func (m *mockRepo) ReturnEarly(ctx context.Context, req *LeaveRequest, returnDate time.Time, unusedDays, refundDays float64) error {
+	return m.Called(ctx, req, returnDate, unusedDays, refundDays).Error(0)
+}
+
 func (m *mockRepo) RejectRequest(ctx context.Context, requestID, approverID uint, reason string) error {
 	return m.Called(ctx, requestID, approverID, reason).Error(0)
 }
@@ -55,8 +71,75 @@ func (m *mockRepo) FindAllLeaveTypes(ctx context.Context) ([]master.LeaveType, e
 	return args.Get(0).([]master.LeaveType), args.Error(1)
 }
 
-func (m *mockRepo) CreateLeaveBalances(ctx context.Context, balances []LeaveBalance) error {
-	return m.Called(ctx, balances).Error(0)
+func (m *mockRepo) FindActiveCompanyIDs(ctx context.Context) ([]uint, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *mockRepo) SaveBalance(ctx context.Context, balance *LeaveBalance) error {
+	return m.Called(ctx, balance).Error(0)
+}
+
+func (m *mockRepo) SumLedgerDays(ctx context.Context, employeeID, leaveTypeID uint, year int, ledgerType constants.LeaveLedgerType) (float64, error) {
+	args := m.Called(ctx, employeeID, leaveTypeID, year, ledgerType)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *mockRepo) CreateLedgers(ctx context.Context, entries []LeaveLedger) error {
+	return m.Called(ctx, entries).Error(0)
+}
+
+func (m *mockRepo) FindAllLedgers(ctx context.Context, filter *LeaveLedgerFilter) ([]LeaveLedger, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]LeaveLedger), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockRepo) FindAllPolicies(ctx context.Context) ([]LeavePolicy, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]LeavePolicy), args.Error(1)
+}
+
+func (m *mockRepo) UpsertPolicy(ctx context.Context, policy *LeavePolicy) error {
+	return m.Called(ctx, policy).Error(0)
+}
+
+func (m *mockRepo) GetBulkApprovedLeaveDays(ctx context.Context, startDate, endDate string) (map[uint]map[string]float64, error) {
+	args := m.Called(ctx, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint]map[string]float64), args.Error(1)
+}
+
+func (m *mockRepo) FindTeamRequests(ctx context.Context, employeeIDs []uint, from, to time.Time, statuses []constants.LeaveStatus) ([]LeaveRequest, error) {
+	args := m.Called(ctx, employeeIDs, from, to, statuses)
+	return args.Get(0).([]LeaveRequest), args.Error(1)
+}
+
+func (m *mockRepo) FindHolidays(ctx context.Context, from, to time.Time) ([]master.Holiday, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).([]master.Holiday), args.Error(1)
+}
+
+func (m *mockRepo) FindConflictRules(ctx context.Context, activeOnly bool) ([]LeaveConflictRule, error) {
+	args := m.Called(ctx, activeOnly)
+	return args.Get(0).([]LeaveConflictRule), args.Error(1)
+}
+
+func (m *mockRepo) FindConflictRuleByID(ctx context.Context, id uint) (*LeaveConflictRule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LeaveConflictRule), args.Error(1)
+}
+
+func (m *mockRepo) SaveConflictRule(ctx context.Context, rule *LeaveConflictRule) error {
+	return m.Called(ctx, rule).Error(0)
+}
+
+func (m *mockRepo) DeleteConflictRule(ctx context.Context, id uint) error {
+	return m.Called(ctx, id).Error(0)
 }
 
 // --- StorageProvider Mock ---
@@ -84,13 +167,51 @@ func (m *mockNotification) BlastNotification(ctx context.Context, userIDs []uint
 
 type mockUserProvider struct{ mock.Mock }
 
-func (m *mockUserProvider) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
-	args := m.Called(ctx, permissionApprovalName)
+func (m *mockUserProvider) FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error) {
+	args := m.Called(ctx, requesterUserID, permissionApprovalName)
 	return args.Get(0).([]uint), args.Error(1)
 }
 
+func (m *mockUserProvider) FindEmployeeByID(ctx context.Context, id uint) (*user.Employee, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Employee), args.Error(1)
+}
+
+func (m *mockUserProvider) FindAllEmployeeActive(ctx context.Context) ([]user.Employee, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]user.Employee), args.Error(1)
+}
+
+func (m *mockUserProvider) FindAllEmployeeActiveInScope(ctx context.Context) ([]user.Employee, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]user.Employee), args.Error(1)
+}
+
 // --- ExcelProvider Mock ---
 
+type mockApprovalEngine struct{ mock.Mock }
+
+func (m *mockApprovalEngine) Submit(ctx context.Context, req *approval.SubmitRequest) ([]uint, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *mockApprovalEngine) Decide(ctx context.Context, req *approval.DecideRequest) (*approval.Decision, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*approval.Decision), args.Error(1)
+}
+
+func (m *mockApprovalEngine) Cancel(ctx context.Context, requestType constants.ApprovalRequestType, referenceID, actorUserID uint) error {
+	args := m.Called(ctx, requestType, referenceID, actorUserID)
+	return args.Error(0)
+}
+
 type mockExcel struct{ mock.Mock }
 
 func (m *mockExcel) GenerateSimpleExcel(sheetName string, headers []string, rows [][]interface{}) ([]byte, error) {
@@ -129,6 +250,14 @@ func (m *mockService) RequestAction(ctx context.Context, req *LeaveActionRequest
 	return m.Called(ctx, req).Error(0)
 }
 
+func (m *mockService) Cancel(ctx context.Context, req *CancelLeaveRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) ReturnEarly(ctx context.Context, req *ReturnLeaveRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
 func (m *mockService) GetList(ctx context.Context, filter *LeaveFilter) ([]LeaveRequestListResponse, *response.Meta, error) {
 	args := m.Called(ctx, filter)
 	var meta *response.Meta
@@ -150,10 +279,32 @@ func (m *mockService) GenerateInitialBalance(ctx context.Context, employeeID uin
 	return m.Called(ctx, employeeID).Error(0)
 }
 
-func (m *mockService) GenerateAnnualBalance(ctx context.Context) error {
+func (m *mockService) AccrueBalances(ctx context.Context) error {
 	return m.Called(ctx).Error(0)
 }
 
+func (m *mockService) AccrueAllCompanies(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockService) GetPolicies(ctx context.Context) ([]LeavePolicyResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]LeavePolicyResponse), args.Error(1)
+}
+
+func (m *mockService) UpsertPolicy(ctx context.Context, req *UpsertLeavePolicyRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) GetLedger(ctx context.Context, filter *LeaveLedgerFilter) ([]LeaveLedgerResponse, *response.Meta, error) {
+	args := m.Called(ctx, filter)
+	var meta *response.Meta
+	if args.Get(1) != nil {
+		meta = args.Get(1).(*response.Meta)
+	}
+	return args.Get(0).([]LeaveLedgerResponse), meta, args.Error(2)
+}
+
 func (m *mockService) Export(ctx context.Context, filter *LeaveFilter) ([]byte, error) {
 	args := m.Called(ctx, filter)
 	if args.Get(0) == nil {
@@ -162,5 +313,35 @@ func (m *mockService) Export(ctx context.Context, filter *LeaveFilter) ([]byte,
 	return args.Get(0).([]byte), args.Error(1)
 }
 
+func (m *mockService) GetTeamCalendar(ctx context.Context, filter *TeamCalendarFilter) (*TeamCalendarResponse, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TeamCalendarResponse), args.Error(1)
+}
+
+func (m *mockService) GetConflictRules(ctx context.Context) ([]LeaveConflictRuleResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]LeaveConflictRuleResponse), args.Error(1)
+}
+
+func (m *mockService) SaveConflictRule(ctx context.Context, req *SaveLeaveConflictRuleRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) DeleteConflictRule(ctx context.Context, id uint) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *mockService) GetUnusedLeave(ctx context.Context, employeeID uint, year int) ([]UnusedLeave, error) {
+	args := m.Called(ctx, employeeID, year)
+	return args.Get(0).([]UnusedLeave), args.Error(1)
+}
+
+func (m *mockService) PayOutUnusedLeave(ctx context.Context, employeeID uint, year int, referenceID uint) error {
+	return m.Called(ctx, employeeID, year, referenceID).Error(0)
+}
+
 // helper to get constant values in tests
 func approvalLeaveKey() string { return string(constants.APPROVAL_LEAVE) }
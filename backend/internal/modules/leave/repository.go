@@ -3,6 +3,8 @@ package leave
 import (
 	"context"
 	"errors"
+	"fmt"
+	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/internal/modules/attendance"
 	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/pkg/constants"
@@ -19,13 +21,35 @@ type Repository interface {
 	FindAllRequests(ctx context.Context, filter *LeaveFilter) ([]LeaveRequest, int64, error)
 
 	GetBalance(ctx context.Context, employeeID, leaveTypeID uint, year int) (*LeaveBalance, error)
+	FindBalancesByEmployee(ctx context.Context, employeeID uint, year int) ([]LeaveBalance, error)
 
-	ApproveRequest(ctx context.Context, requestID uint, approverID uint, attendanceRecords []attendance.Attendance, shouldDeduct bool, days int) error
+	ApproveRequest(ctx context.Context, requestID uint, approverID uint, attendanceRecords []attendance.Attendance, shouldDeduct bool, days float64) error
 	RejectRequest(ctx context.Context, requestID uint, approverID uint, reason string) error
+	CancelRequest(ctx context.Context, req *LeaveRequest, cancelledBy uint, reason string, refundDays float64) error
+	ReturnEarly(ctx context.Context, req *LeaveRequest, returnDate time.Time, unusedDays, refundDays float64) error
 
-	// For Initial Balance Generation
+	// For Balance Accrual
 	FindAllLeaveTypes(ctx context.Context) ([]master.LeaveType, error)
-	CreateLeaveBalances(ctx context.Context, balances []LeaveBalance) error
+	FindActiveCompanyIDs(ctx context.Context) ([]uint, error)
+	SaveBalance(ctx context.Context, balance *LeaveBalance) error
+	SumLedgerDays(ctx context.Context, employeeID, leaveTypeID uint, year int, ledgerType constants.LeaveLedgerType) (float64, error)
+	CreateLedgers(ctx context.Context, entries []LeaveLedger) error
+	FindAllLedgers(ctx context.Context, filter *LeaveLedgerFilter) ([]LeaveLedger, int64, error)
+
+	// For Leave Policy
+	FindAllPolicies(ctx context.Context) ([]LeavePolicy, error)
+	UpsertPolicy(ctx context.Context, policy *LeavePolicy) error
+
+	// For Attendance Timesheet
+	GetBulkApprovedLeaveDays(ctx context.Context, startDate, endDate string) (map[uint]map[string]float64, error)
+
+	// For Team Calendar & Conflict Rules
+	FindTeamRequests(ctx context.Context, employeeIDs []uint, from, to time.Time, statuses []constants.LeaveStatus) ([]LeaveRequest, error)
+	FindHolidays(ctx context.Context, from, to time.Time) ([]master.Holiday, error)
+	FindConflictRules(ctx context.Context, activeOnly bool) ([]LeaveConflictRule, error)
+	FindConflictRuleByID(ctx context.Context, id uint) (*LeaveConflictRule, error)
+	SaveConflictRule(ctx context.Context, rule *LeaveConflictRule) error
+	DeleteConflictRule(ctx context.Context, id uint) error
 }
 
 type repository struct {
@@ -42,11 +66,12 @@ func (r *repository) CreateRequest(ctx context.Context, req *LeaveRequest) error
 }
 
 func (r *repository) FindRequestByID(ctx context.Context, id uint) (*LeaveRequest, error) {
-	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	db := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&LeaveRequest{})), "leave_requests.employee_id")
 	var req LeaveRequest
 	err := db.
 		Preload("User").
 		Preload("Employee").
+		Preload("Employee.Shift").
 		Preload("LeaveType").
 		First(&req, id).Error
 
@@ -60,7 +85,7 @@ func (r *repository) FindAllRequests(ctx context.Context, filter *LeaveFilter) (
 
 	offset := (filter.Page - 1) * filter.Limit
 
-	query := utils.TenantScope(ctx, db.Model(&LeaveRequest{})).
+	query := utils.DataScope(ctx, utils.TenantScope(ctx, db.Model(&LeaveRequest{})), "leave_requests.employee_id").
 		Joins("JOIN employees ON employees.id = leave_requests.employee_id").
 		Joins("JOIN ref_leave_types ON ref_leave_types.id = leave_requests.leave_type_id").
 		Preload("Employee").
@@ -74,7 +99,7 @@ func (r *repository) FindAllRequests(ctx context.Context, filter *LeaveFilter) (
 	}
 	if filter.Search != "" {
 		searchParam := "%" + filter.Search + "%"
-		query = query.Where("LOWER(employees.full_name) LIKE LOWER(?) OR LOWER(employees.nik) LIKE LOWER(?)", searchParam, searchParam)
+		query = query.Where("LOWER(employees.full_name) LIKE LOWER(?) OR employees.nik_bidx = ?", searchParam, infrastructure.BlindIndex(filter.Search))
 	}
 
 	if err := query.Count(&total).Error; err != nil {
@@ -100,7 +125,19 @@ func (r *repository) GetBalance(ctx context.Context, employeeID, leaveTypeID uin
 	return &balance, err
 }
 
-func (r *repository) ApproveRequest(ctx context.Context, requestID uint, approverID uint, attendanceRecords []attendance.Attendance, shouldDeduct bool, days int) error {
+// FindBalancesByEmployee returns every leave balance the employee holds for the year.
+func (r *repository) FindBalancesByEmployee(ctx context.Context, employeeID uint, year int) ([]LeaveBalance, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var balances []LeaveBalance
+	err := db.Preload("LeaveType").
+		Where("employee_id = ? AND year = ?", employeeID, year).
+		Order("leave_type_id ASC").
+		Find(&balances).Error
+
+	return balances, err
+}
+
+func (r *repository) ApproveRequest(ctx context.Context, requestID uint, approverID uint, attendanceRecords []attendance.Attendance, shouldDeduct bool, days float64) error {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 
 	if err := db.Model(&LeaveRequest{}).Where("id = ?", requestID).
@@ -111,7 +148,17 @@ func (r *repository) ApproveRequest(ctx context.Context, requestID uint, approve
 		return err
 	}
 
-	if len(attendanceRecords) > 0 {
+	// partial days keep whatever the employee already clocked, only the excused hours are written
+	var fullDays, partialDays []attendance.Attendance
+	for _, record := range attendanceRecords {
+		if record.ExcusedFrom != nil {
+			partialDays = append(partialDays, record)
+		} else {
+			fullDays = append(fullDays, record)
+		}
+	}
+
+	if len(fullDays) > 0 {
 		attendanceDB := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 		if err := attendanceDB.Clauses(clause.OnConflict{
 			Columns: []clause.Column{{Name: "employee_id"}, {Name: "date"}},
@@ -126,8 +173,25 @@ func (r *repository) ApproveRequest(ctx context.Context, requestID uint, approve
 				"notes",
 				"late_duration_minute",
 				"is_suspicious",
+				"leave_request_id",
+				"excused_from",
+				"excused_until",
 			}),
-		}).CreateInBatches(attendanceRecords, 31).Error; err != nil {
+		}).CreateInBatches(fullDays, 31).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(partialDays) > 0 {
+		attendanceDB := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+		if err := attendanceDB.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "employee_id"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"leave_request_id",
+				"excused_from",
+				"excused_until",
+			}),
+		}).Create(&partialDays).Error; err != nil {
 			return err
 		}
 	}
@@ -138,20 +202,127 @@ func (r *repository) ApproveRequest(ctx context.Context, requestID uint, approve
 			return err
 		}
 
+		// deduct from the balance of the year the leave is taken, not the year it is approved
 		balanceDB := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 		if err := balanceDB.Model(&LeaveBalance{}).
-			Where("employee_id = ? AND leave_type_id = ? AND year = ?", req.EmployeeID, req.LeaveTypeID, time.Now().Year()).
+			Where("employee_id = ? AND leave_type_id = ? AND year = ?", req.EmployeeID, req.LeaveTypeID, req.StartDate.Year()).
 			Updates(map[string]interface{}{
 				"quota_used": gorm.Expr("quota_used + ?", days),
 				"quota_left": gorm.Expr("quota_left - ?", days),
 			}).Error; err != nil {
 			return err
 		}
+
+		ledgerDB := utils.GetDBFromContext(ctx, r.db)
+		if err := ledgerDB.Create(&LeaveLedger{
+			CompanyID:   req.CompanyID,
+			EmployeeID:  req.EmployeeID,
+			LeaveTypeID: req.LeaveTypeID,
+			Year:        req.StartDate.Year(),
+			Type:        constants.LeaveLedgerUsage,
+			Days:        -days,
+			ReferenceID: &req.ID,
+			Notes:       fmt.Sprintf("Leave request #%d", req.ID),
+		}).Error; err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+func (r *repository) CancelRequest(ctx context.Context, req *LeaveRequest, cancelledBy uint, reason string, refundDays float64) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+
+	if err := db.Model(&LeaveRequest{}).Where("id = ?", req.ID).
+		Updates(map[string]interface{}{
+			"status":              constants.LeaveStatusCancelled,
+			"cancelled_by":        cancelledBy,
+			"cancelled_at":        time.Now(),
+			"cancellation_reason": reason,
+		}).Error; err != nil {
+		return err
+	}
+
+	if err := r.removeLeaveAttendances(ctx, req, req.StartDate); err != nil {
+		return err
+	}
+
+	return r.refundBalance(ctx, req, refundDays, fmt.Sprintf("Cancelled leave request #%d", req.ID))
+}
+
+func (r *repository) ReturnEarly(ctx context.Context, req *LeaveRequest, returnDate time.Time, unusedDays, refundDays float64) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+
+	if err := db.Model(&LeaveRequest{}).Where("id = ?", req.ID).
+		Updates(map[string]interface{}{
+			"end_date":      returnDate.AddDate(0, 0, -1),
+			"total_days":    gorm.Expr("total_days - ?", unusedDays),
+			"total_hours":   gorm.Expr("total_hours - ?", unusedDays*constants.LeaveWorkHoursPerDay),
+			"returned_at":   returnDate,
+			"refunded_days": refundDays,
+		}).Error; err != nil {
+		return err
+	}
+
+	if err := r.removeLeaveAttendances(ctx, req, returnDate); err != nil {
+		return err
+	}
+
+	return r.refundBalance(ctx, req, refundDays, fmt.Sprintf("Early return from leave request #%d", req.ID))
+}
+
+// removeLeaveAttendances drops the attendance generated for the request from the given date,
+// rows the employee actually clocked only lose their excused hours.
+func (r *repository) removeLeaveAttendances(ctx context.Context, req *LeaveRequest, from time.Time) error {
+	fromDate := from.Format(constants.DefaultTimeFormat)
+	toDate := req.EndDate.Format(constants.DefaultTimeFormat)
+
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	if err := db.
+		Where("employee_id = ? AND date BETWEEN ? AND ? AND check_in_address = ?", req.EmployeeID, fromDate, toDate, leaveAttendanceAddress).
+		Delete(&attendance.Attendance{}).Error; err != nil {
+		return err
+	}
+
+	clearDB := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	return clearDB.Model(&attendance.Attendance{}).
+		Where("leave_request_id = ? AND date >= ?", req.ID, fromDate).
+		Updates(map[string]interface{}{
+			"leave_request_id": nil,
+			"excused_from":     nil,
+			"excused_until":    nil,
+		}).Error
+}
+
+func (r *repository) refundBalance(ctx context.Context, req *LeaveRequest, days float64, notes string) error {
+	if days <= 0 {
+		return nil
+	}
+
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	if err := db.Model(&LeaveBalance{}).
+		Where("employee_id = ? AND leave_type_id = ? AND year = ?", req.EmployeeID, req.LeaveTypeID, req.StartDate.Year()).
+		Updates(map[string]interface{}{
+			"quota_used": gorm.Expr("quota_used - ?", days),
+			"quota_left": gorm.Expr("quota_left + ?", days),
+		}).Error; err != nil {
+		return err
+	}
+
+	ledgerDB := utils.GetDBFromContext(ctx, r.db)
+	return ledgerDB.Create(&LeaveLedger{
+		CompanyID:   req.CompanyID,
+		EmployeeID:  req.EmployeeID,
+		LeaveTypeID: req.LeaveTypeID,
+		Year:        req.StartDate.Year(),
+		Type:        constants.LeaveLedgerRefund,
+		Days:        days,
+		ReferenceID: &req.ID,
+		Notes:       notes,
+	}).Error
+}
+
 func (r *repository) RejectRequest(ctx context.Context, requestID uint, approverID uint, reason string) error {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 	if reason == "" {
@@ -175,10 +346,219 @@ func (r *repository) FindAllLeaveTypes(ctx context.Context) ([]master.LeaveType,
 	return leaveTypes, nil
 }
 
-func (r *repository) CreateLeaveBalances(ctx context.Context, balances []LeaveBalance) error {
-	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
-	if len(balances) == 0 {
+func (r *repository) FindActiveCompanyIDs(ctx context.Context) ([]uint, error) {
+	db := utils.GetDBFromContext(ctx, r.db)
+	var ids []uint
+	err := db.Table("companies").
+		Where("subscription_status != ?", constants.SubStatusExpired).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *repository) SaveBalance(ctx context.Context, balance *LeaveBalance) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Save(balance).Error
+}
+
+func (r *repository) SumLedgerDays(ctx context.Context, employeeID, leaveTypeID uint, year int, ledgerType constants.LeaveLedgerType) (float64, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&LeaveLedger{}))
+	var total float64
+	err := db.
+		Select("COALESCE(SUM(days), 0)").
+		Where("employee_id = ? AND leave_type_id = ? AND year = ? AND type = ?", employeeID, leaveTypeID, year, ledgerType).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *repository) CreateLedgers(ctx context.Context, entries []LeaveLedger) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	if len(entries) == 0 {
 		return nil
 	}
-	return db.Create(&balances).Error
+	return db.Create(&entries).Error
+}
+
+func (r *repository) FindAllLedgers(ctx context.Context, filter *LeaveLedgerFilter) ([]LeaveLedger, int64, error) {
+	db := utils.GetDBFromContext(ctx, r.db)
+	var entries []LeaveLedger
+	var total int64
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	query := utils.DataScope(ctx, utils.TenantScope(ctx, db.Model(&LeaveLedger{})), "leave_ledgers.employee_id").
+		Preload("Employee").
+		Preload("LeaveType")
+
+	if filter.EmployeeID > 0 {
+		query = query.Where("employee_id = ?", filter.EmployeeID)
+	}
+	if filter.LeaveTypeID > 0 {
+		query = query.Where("leave_type_id = ?", filter.LeaveTypeID)
+	}
+	if filter.Year > 0 {
+		query = query.Where("year = ?", filter.Year)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("created_at DESC, id DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&entries).Error
+
+	return entries, total, err
+}
+
+func (r *repository) FindAllPolicies(ctx context.Context) ([]LeavePolicy, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&LeavePolicy{}))
+	var policies []LeavePolicy
+	if err := db.Preload("LeaveType").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (r *repository) UpsertPolicy(ctx context.Context, policy *LeavePolicy) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "company_id"}, {Name: "leave_type_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"accrual_type",
+			"quota_per_year",
+			"eligibility_months",
+			"is_pro_rata",
+			"carry_over_max",
+			"carry_over_expiry_months",
+			"tenure_bonus_every_years",
+			"tenure_bonus_days",
+			"tenure_bonus_max",
+			"updated_at",
+		}),
+	}).Create(policy).Error
+}
+
+func (r *repository) GetBulkApprovedLeaveDays(ctx context.Context, startDate, endDate string) (map[uint]map[string]float64, error) {
+	db := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&LeaveRequest{})), "leave_requests.employee_id")
+	var requests []LeaveRequest
+
+	err := db.
+		Preload("LeaveType").
+		Where("status = ?", string(constants.LeaveStatusApproved)).
+		Where("start_date <= ? AND end_date >= ?", endDate, startDate).
+		Find(&requests).Error
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := time.ParseInLocation(constants.DefaultTimeFormat, startDate, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	to, err := time.ParseInLocation(constants.DefaultTimeFormat, endDate, time.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	dataMap := make(map[uint]map[string]float64)
+	for _, req := range requests {
+		leaveType := "Leave"
+		if req.LeaveType != nil {
+			leaveType = req.LeaveType.Name
+		}
+		if dataMap[req.EmployeeID] == nil {
+			dataMap[req.EmployeeID] = make(map[string]float64)
+		}
+
+		// half day and hourly leave sit on a single date that is already inside the period
+		if req.DurationType != "" && req.DurationType != constants.LeaveDurationFullDay {
+			dataMap[req.EmployeeID][leaveType] += req.TotalDays
+			continue
+		}
+
+		// count working days of the request that fall inside the period, same rule as approval
+		start := time.Date(req.StartDate.Year(), req.StartDate.Month(), req.StartDate.Day(), 0, 0, 0, 0, time.Local)
+		end := time.Date(req.EndDate.Year(), req.EndDate.Month(), req.EndDate.Day(), 0, 0, 0, 0, time.Local)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		dataMap[req.EmployeeID][leaveType] += float64(countLeaveDays(start, end))
+	}
+
+	return dataMap, nil
+}
+
+// FindTeamRequests returns the leave of the employees overlapping the period.
+func (r *repository) FindTeamRequests(ctx context.Context, employeeIDs []uint, from, to time.Time, statuses []constants.LeaveStatus) ([]LeaveRequest, error) {
+	if len(employeeIDs) == 0 {
+		return nil, nil
+	}
+
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&LeaveRequest{}))
+	var requests []LeaveRequest
+	err := db.Preload("Employee").
+		Preload("LeaveType").
+		Where("employee_id IN ?", employeeIDs).
+		Where("status IN ?", statuses).
+		Where("start_date <= ? AND end_date >= ?", to, from).
+		Order("start_date ASC, id ASC").
+		Find(&requests).Error
+	if err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (r *repository) FindHolidays(ctx context.Context, from, to time.Time) ([]master.Holiday, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&master.Holiday{}))
+	var holidays []master.Holiday
+	if err := db.Where("date BETWEEN ? AND ?", from, to).Order("date ASC").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+func (r *repository) FindConflictRules(ctx context.Context, activeOnly bool) ([]LeaveConflictRule, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&LeaveConflictRule{}))
+	if activeOnly {
+		db = db.Where("is_active = ?", true)
+	}
+
+	var rules []LeaveConflictRule
+	if err := db.Preload("Department").Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *repository) FindConflictRuleByID(ctx context.Context, id uint) (*LeaveConflictRule, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&LeaveConflictRule{}))
+	var rule LeaveConflictRule
+	if err := db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *repository) SaveConflictRule(ctx context.Context, rule *LeaveConflictRule) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Omit(clause.Associations).Save(rule).Error
+}
+
+func (r *repository) DeleteConflictRule(ctx context.Context, id uint) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	result := db.Delete(&LeaveConflictRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
@@ -28,6 +28,10 @@ func setupLeaveTestDB(t *testing.T) *testutil.TestDB {
 		&user.Employee{},
 		&LeaveBalance{},
 		&LeaveRequest{},
+		&LeavePolicy{},
+		&LeaveLedger{},
+		&LeaveConflictRule{},
+		&master.Holiday{},
 	)
 	t.Cleanup(tdb.Close)
 	return tdb
@@ -160,7 +164,7 @@ func TestRepo_GetBalance(t *testing.T) {
 		employeeID   uint
 		leaveTypeID  uint
 		year         int
-		wantQuotaLeft int
+		wantQuotaLeft float64
 		wantErr      bool
 	}{
 		{name: "success", employeeID: 1, leaveTypeID: 1, year: 2026, wantQuotaLeft: 10, wantErr: false},
@@ -180,6 +184,28 @@ func TestRepo_GetBalance(t *testing.T) {
 	}
 }
 
+func TestRepo_FindBalancesByEmployee(t *testing.T) {
+	tdb := setupLeaveTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLeaveTestData(t, tdb)
+
+	for _, year := range []int{2025, 2026} {
+		require.NoError(t, tdb.DB.Create(&LeaveBalance{
+			CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1,
+			Year: year, QuotaTotal: 12, QuotaLeft: 12,
+		}).Error)
+	}
+
+	balances, err := repo.FindBalancesByEmployee(ctx, 1, 2026)
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	assert.Equal(t, 2026, balances[0].Year)
+	require.NotNil(t, balances[0].LeaveType)
+	assert.Equal(t, "Annual", balances[0].LeaveType.Name)
+}
+
 func TestRepo_FindAllRequests(t *testing.T) {
 	tdb := setupLeaveTestDB(t)
 	repo := NewRepository(tdb.DB)
@@ -286,35 +312,244 @@ func TestRepo_FindAllLeaveTypes(t *testing.T) {
 	}
 }
 
-func TestRepo_CreateLeaveBalances(t *testing.T) {
+func TestRepo_SaveBalance(t *testing.T) {
 	tdb := setupLeaveTestDB(t)
 	repo := NewRepository(tdb.DB)
 	ctx := testutil.CtxWithTenant(1, 1, false)
 
 	seedLeaveTestData(t, tdb)
 
-	balances := []LeaveBalance{
-		{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, QuotaTotal: 12, QuotaUsed: 0, QuotaLeft: 12},
+	balance := &LeaveBalance{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, QuotaTotal: 12, QuotaUsed: 0, QuotaLeft: 12}
+
+	tests := []struct {
+		name      string
+		quotaLeft float64
+	}{
+		{name: "create new balance", quotaLeft: 12},
+		{name: "update existing balance", quotaLeft: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balance.QuotaLeft = tt.quotaLeft
+			require.NoError(t, repo.SaveBalance(ctx, balance))
+
+			var stored []LeaveBalance
+			require.NoError(t, tdb.DB.Find(&stored).Error)
+			require.Len(t, stored, 1)
+			assert.Equal(t, tt.quotaLeft, stored[0].QuotaLeft)
+		})
 	}
+}
+
+func TestRepo_SumLedgerDays(t *testing.T) {
+	tdb := setupLeaveTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLeaveTestData(t, tdb)
+
+	require.NoError(t, repo.CreateLedgers(ctx, []LeaveLedger{
+		{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, Type: constants.LeaveLedgerAccrual, Days: 6},
+		{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, Type: constants.LeaveLedgerAccrual, Days: 1},
+		{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, Type: constants.LeaveLedgerUsage, Days: -2},
+		{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2025, Type: constants.LeaveLedgerAccrual, Days: 12},
+		{CompanyID: 2, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, Type: constants.LeaveLedgerAccrual, Days: 5},
+	}))
 
 	tests := []struct {
-		name    string
-		wantErr bool
+		name       string
+		year       int
+		ledgerType constants.LeaveLedgerType
+		want       float64
 	}{
-		{name: "success", wantErr: false},
+		{name: "sums accruals of the year within tenant", year: 2026, ledgerType: constants.LeaveLedgerAccrual, want: 7},
+		{name: "sums usage as negative days", year: 2026, ledgerType: constants.LeaveLedgerUsage, want: -2},
+		{name: "no entries returns zero", year: 2024, ledgerType: constants.LeaveLedgerAccrual, want: 0},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := repo.CreateLeaveBalances(ctx, balances)
-			if tt.wantErr {
-				require.Error(t, err)
-			} else {
-				require.NoError(t, err)
-				var count int64
-				tdb.DB.Model(&LeaveBalance{}).Count(&count)
-				assert.Equal(t, int64(1), count)
+			total, err := repo.SumLedgerDays(ctx, 1, 1, tt.year, tt.ledgerType)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, total)
+		})
+	}
+}
+
+func TestRepo_FindAllLedgers(t *testing.T) {
+	tdb := setupLeaveTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLeaveTestData(t, tdb)
+
+	require.NoError(t, repo.CreateLedgers(ctx, []LeaveLedger{
+		{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, Type: constants.LeaveLedgerAccrual, Days: 12},
+		{CompanyID: 1, EmployeeID: 1, LeaveTypeID: 1, Year: 2025, Type: constants.LeaveLedgerAccrual, Days: 12},
+		{CompanyID: 2, EmployeeID: 1, LeaveTypeID: 1, Year: 2026, Type: constants.LeaveLedgerAccrual, Days: 5},
+	}))
+
+	tests := []struct {
+		name      string
+		filter    *LeaveLedgerFilter
+		wantTotal int64
+	}{
+		{name: "all entries of tenant", filter: &LeaveLedgerFilter{Page: 1, Limit: 10}, wantTotal: 2},
+		{name: "filter by year", filter: &LeaveLedgerFilter{Page: 1, Limit: 10, Year: 2026}, wantTotal: 1},
+		{name: "filter by other employee", filter: &LeaveLedgerFilter{Page: 1, Limit: 10, EmployeeID: 99}, wantTotal: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, total, err := repo.FindAllLedgers(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+			assert.Len(t, entries, int(tt.wantTotal))
+			for _, entry := range entries {
+				require.NotNil(t, entry.Employee)
+				require.NotNil(t, entry.LeaveType)
 			}
 		})
 	}
 }
+
+func TestRepo_UpsertPolicy(t *testing.T) {
+	tdb := setupLeaveTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLeaveTestData(t, tdb)
+
+	require.NoError(t, repo.UpsertPolicy(ctx, &LeavePolicy{
+		CompanyID: 1, LeaveTypeID: 1, AccrualType: constants.LeaveAccrualAnnual, QuotaPerYear: 12, EligibilityMonths: 12,
+	}))
+	require.NoError(t, repo.UpsertPolicy(ctx, &LeavePolicy{
+		CompanyID: 1, LeaveTypeID: 1, AccrualType: constants.LeaveAccrualMonthly, QuotaPerYear: 14, CarryOverMax: 5,
+	}))
+
+	policies, err := repo.FindAllPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, constants.LeaveAccrualMonthly, policies[0].AccrualType)
+	assert.Equal(t, 14, policies[0].QuotaPerYear)
+	assert.Equal(t, 0, policies[0].EligibilityMonths)
+	assert.Equal(t, 5, policies[0].CarryOverMax)
+	require.NotNil(t, policies[0].LeaveType)
+	assert.Equal(t, "Annual", policies[0].LeaveType.Name)
+}
+
+func TestRepo_GetBulkApprovedLeaveDays(t *testing.T) {
+	tdb := setupLeaveTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLeaveTestData(t, tdb)
+	require.NoError(t, tdb.DB.Create(&master.LeaveType{ID: 2, Name: "Sick", DefaultQuota: 15, CompanyID: 1}).Error)
+
+	requests := []LeaveRequest{
+		// crosses into June, only Mon 1 - Wed 3 June count
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, LeaveTypeID: 1, Status: constants.LeaveStatusApproved,
+			StartDate: time.Date(2026, 5, 28, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 6, 3, 0, 0, 0, 0, time.UTC)},
+		// Fri 5 - Mon 8 June, weekend skipped
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, LeaveTypeID: 2, Status: constants.LeaveStatusApproved,
+			StartDate: time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 6, 8, 0, 0, 0, 0, time.UTC)},
+		// half day counts by its recorded days
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, LeaveTypeID: 1, Status: constants.LeaveStatusApproved, DurationType: constants.LeaveDurationHalfDayMorning, TotalDays: 0.5,
+			StartDate: time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)},
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, LeaveTypeID: 1, Status: constants.LeaveStatusPending,
+			StartDate: time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{CompanyID: 2, UserID: 2, EmployeeID: 2, LeaveTypeID: 1, Status: constants.LeaveStatusApproved,
+			StartDate: time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 6, 12, 0, 0, 0, 0, time.UTC)},
+	}
+	for i := range requests {
+		require.NoError(t, tdb.DB.Create(&requests[i]).Error)
+	}
+
+	result, err := repo.GetBulkApprovedLeaveDays(ctx, "2026-06-01", "2026-06-30")
+
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]map[string]float64{1: {"Annual": 3.5, "Sick": 2}}, result)
+}
+
+func TestRepo_FindTeamRequests(t *testing.T) {
+	tdb := setupLeaveTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLeaveTestData(t, tdb)
+
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 0, 0, 0, 0, time.UTC) }
+	requests := []LeaveRequest{
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, LeaveTypeID: 1, StartDate: day(1), EndDate: day(3), Status: constants.LeaveStatusApproved},
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, LeaveTypeID: 1, StartDate: day(5), EndDate: day(5), Status: constants.LeaveStatusPending},
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, LeaveTypeID: 1, StartDate: day(2), EndDate: day(2), Status: constants.LeaveStatusRejected},
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, LeaveTypeID: 1, StartDate: day(10), EndDate: day(12), Status: constants.LeaveStatusApproved},
+	}
+	for i := range requests {
+		require.NoError(t, tdb.DB.Create(&requests[i]).Error)
+	}
+
+	found, err := repo.FindTeamRequests(ctx, []uint{1}, day(3), day(9), []constants.LeaveStatus{constants.LeaveStatusApproved, constants.LeaveStatusPending})
+
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, requests[0].ID, found[0].ID)
+	assert.Equal(t, requests[1].ID, found[1].ID)
+	require.NotNil(t, found[0].Employee)
+	assert.Equal(t, "John Doe", found[0].Employee.FullName)
+
+	found, err = repo.FindTeamRequests(ctx, nil, day(3), day(9), []constants.LeaveStatus{constants.LeaveStatusApproved})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestRepo_ConflictRules(t *testing.T) {
+	tdb := setupLeaveTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLeaveTestData(t, tdb)
+
+	departmentID := uint(1)
+	active := &LeaveConflictRule{CompanyID: 1, Name: "Two off per day", RuleType: constants.LeaveConflictMaxEmployees, DepartmentID: &departmentID, MaxEmployees: 2, Enforcement: constants.LeaveConflictBlock, IsActive: true}
+	inactive := &LeaveConflictRule{CompanyID: 1, Name: "Half the team", RuleType: constants.LeaveConflictMaxPercent, MaxPercent: 50, Enforcement: constants.LeaveConflictWarn}
+	require.NoError(t, repo.SaveConflictRule(ctx, active))
+	require.NoError(t, repo.SaveConflictRule(ctx, inactive))
+	require.NoError(t, repo.SaveConflictRule(ctx, &LeaveConflictRule{CompanyID: 2, Name: "Other tenant", RuleType: constants.LeaveConflictMaxEmployees, MaxEmployees: 1, Enforcement: constants.LeaveConflictBlock, IsActive: true}))
+
+	rules, err := repo.FindConflictRules(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.NotNil(t, rules[0].Department)
+	assert.Equal(t, "Engineering", rules[0].Department.Name)
+
+	rules, err = repo.FindConflictRules(ctx, false)
+	require.NoError(t, err)
+	assert.Len(t, rules, 2)
+
+	found, err := repo.FindConflictRuleByID(ctx, inactive.ID)
+	require.NoError(t, err)
+	assert.False(t, found.IsActive)
+
+	require.NoError(t, repo.DeleteConflictRule(ctx, inactive.ID))
+	assert.Error(t, repo.DeleteConflictRule(ctx, inactive.ID))
+	_, err = repo.FindConflictRuleByID(ctx, inactive.ID)
+	assert.Error(t, err)
+}
+
+func TestRepo_FindHolidays(t *testing.T) {
+	tdb := setupLeaveTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	require.NoError(t, tdb.DB.Create(&master.Holiday{CompanyID: 1, Date: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), Name: "Hari Lahir Pancasila"}).Error)
+	require.NoError(t, tdb.DB.Create(&master.Holiday{CompanyID: 2, Date: time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC), Name: "Other Tenant"}).Error)
+	require.NoError(t, tdb.DB.Create(&master.Holiday{CompanyID: 1, Date: time.Date(2026, 8, 17, 0, 0, 0, 0, time.UTC), Name: "Hari Kemerdekaan"}).Error)
+
+	holidays, err := repo.FindHolidays(ctx, time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	require.Len(t, holidays, 1)
+	assert.Equal(t, "Hari Lahir Pancasila", holidays[0].Name)
+}
@@ -23,13 +23,14 @@ func NewScheduler(cronProvider *infrastructure.CronProvider, service Service) Sc
 func (sch *scheduler) Start() {
 	logger.Info("Leave Scheduler Started...")
 
-	_, err := sch.cronProvider.GetCron().AddFunc("0 0 1 1 *", func() {
-		logger.Info("[SCHEDULER] Starting Annual Leave Balance Generation...")
+	// daily so monthly accrual, new eligibility and carry-over expiry are picked up on time
+	_, err := sch.cronProvider.GetCron().AddFunc("0 1 * * *", func() {
+		logger.Info("[SCHEDULER] Starting Leave Balance Accrual...")
 
-		if err := sch.service.GenerateAnnualBalance(context.Background()); err != nil {
+		if err := sch.service.AccrueAllCompanies(context.Background()); err != nil {
 			logger.Errorf("[SCHEDULER] Failed: %v\n", err)
 		} else {
-			logger.Info("[SCHEDULER] Success! Annual balances generated.")
+			logger.Info("[SCHEDULER] Success! Leave balances accrued.")
 		}
 	})
 
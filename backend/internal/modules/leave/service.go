@@ -2,40 +2,60 @@ package leave
 
 import (
 	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/internal/modules/attendance"
 	"basekarya-backend/internal/modules/master"
-	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/logger"
 	"basekarya-backend/pkg/response"
 	"basekarya-backend/pkg/utils"
 	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 )
 
 type Service interface {
 	Apply(ctx context.Context, req *ApplyRequest) error
 	RequestAction(ctx context.Context, req *LeaveActionRequest) error
+	Cancel(ctx context.Context, req *CancelLeaveRequest) error
+	ReturnEarly(ctx context.Context, req *ReturnLeaveRequest) error
 	GetList(ctx context.Context, filter *LeaveFilter) ([]LeaveRequestListResponse, *response.Meta, error)
 	GetDetail(ctx context.Context, id uint) (*LeaveRequestDetailResponse, error)
 	GenerateInitialBalance(ctx context.Context, employeeID uint) error
-	GenerateAnnualBalance(ctx context.Context) error
+	AccrueBalances(ctx context.Context) error
+	AccrueAllCompanies(ctx context.Context) error
+	GetPolicies(ctx context.Context) ([]LeavePolicyResponse, error)
+	UpsertPolicy(ctx context.Context, req *UpsertLeavePolicyRequest) error
+	GetLedger(ctx context.Context, filter *LeaveLedgerFilter) ([]LeaveLedgerResponse, *response.Meta, error)
 	Export(ctx context.Context, filter *LeaveFilter) ([]byte, error)
+	GetTeamCalendar(ctx context.Context, filter *TeamCalendarFilter) (*TeamCalendarResponse, error)
+	GetConflictRules(ctx context.Context) ([]LeaveConflictRuleResponse, error)
+	SaveConflictRule(ctx context.Context, req *SaveLeaveConflictRuleRequest) error
+	DeleteConflictRule(ctx context.Context, id uint) error
+	GetUnusedLeave(ctx context.Context, employeeID uint, year int) ([]UnusedLeave, error)
+	PayOutUnusedLeave(ctx context.Context, employeeID uint, year int, referenceID uint) error
 }
 
+var timeNow = time.Now
+
+// check-in address of attendance rows generated by leave approval
+const leaveAttendanceAddress = "SYSTEM_GENERATED"
+
 type service struct {
 	repo               Repository
 	storage            StorageProvider
 	notification       NotificationProvider
 	user               UserProvider
+	approval           ApprovalEngine
 	transactionManager infrastructure.TransactionManager
 	excel              infrastructure.ExcelProvider
 }
 
-func NewService(repo Repository, storage StorageProvider, notification NotificationProvider, user UserProvider, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
-	return &service{repo, storage, notification, user, transactionManager, excel}
+func NewService(repo Repository, storage StorageProvider, notification NotificationProvider, user UserProvider, approval ApprovalEngine, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
+	return &service{repo, storage, notification, user, approval, transactionManager, excel}
 }
 
 func (s *service) Apply(ctx context.Context, req *ApplyRequest) error {
@@ -54,8 +74,16 @@ func (s *service) Apply(ctx context.Context, req *ApplyRequest) error {
 			return errors.New("end date must be after start date")
 		}
 
+		durationType := constants.LeaveDurationType(req.DurationType)
+		if durationType == "" {
+			durationType = constants.LeaveDurationFullDay
+		}
+
 		// calculate total days
-		totalDays := int(end.Sub(start).Hours()/24) + 1
+		totalDays, totalHours, err := leaveDuration(durationType, start, end, req.StartTime, req.EndTime)
+		if err != nil {
+			return err
+		}
 
 		// check balance still available or not
 		balance, err := s.repo.GetBalance(ctx, req.EmployeeID, req.LeaveTypeID, start.Year())
@@ -67,6 +95,14 @@ func (s *service) Apply(ctx context.Context, req *ApplyRequest) error {
 			return errors.New("insufficient leave balance")
 		}
 
+		conflicts, err := s.findConflicts(ctx, req.EmployeeID, start, end)
+		if err != nil {
+			return err
+		}
+		if blocking := filterConflicts(conflicts, constants.LeaveConflictBlock); len(blocking) > 0 {
+			return fmt.Errorf("leave conflicts with team rules: %s", describeConflicts(blocking))
+		}
+
 		attachmentUrl := ""
 		if req.AttachmentBase64 != "" {
 			// construct attachment_base64 if not empty
@@ -87,6 +123,10 @@ func (s *service) Apply(ctx context.Context, req *ApplyRequest) error {
 		}
 
 		// construct leave request and save it to db
+		if durationType != constants.LeaveDurationHourly {
+			req.StartTime, req.EndTime = "", ""
+		}
+
 		leaveReq := &LeaveRequest{
 			CompanyID:     utils.GetCompanyIDFromCtx(ctx),
 			UserID:        req.UserID,
@@ -94,9 +134,13 @@ func (s *service) Apply(ctx context.Context, req *ApplyRequest) error {
 			LeaveTypeID:   req.LeaveTypeID,
 			StartDate:     start,
 			EndDate:       end,
+			DurationType:  durationType,
 			TotalDays:     totalDays,
+			TotalHours:    totalHours,
 			Reason:        req.Reason,
 			AttachmentURL: attachmentUrl,
+			StartTime:     req.StartTime,
+			EndTime:       req.EndTime,
 			Status:        constants.LeaveStatusPending,
 		}
 
@@ -105,7 +149,12 @@ func (s *service) Apply(ctx context.Context, req *ApplyRequest) error {
 			return err
 		}
 
-		approvalUserIDs, err := s.user.FindApprovalUsers(ctx, string(constants.APPROVAL_LEAVE))
+		approvalUserIDs, err := s.approval.Submit(ctx, &approval.SubmitRequest{
+			RequestType:     constants.ApprovalRequestLeave,
+			ReferenceID:     leaveReq.ID,
+			RequesterUserID: req.UserID,
+			Amount:          totalDays,
+		})
 		if err != nil {
 			return err
 		}
@@ -140,8 +189,48 @@ func (s *service) RequestAction(ctx context.Context, req *LeaveActionRequest) er
 			notificationTitle   string
 			notificationMessage string
 		)
+		decideReq := &approval.DecideRequest{
+			RequestType:     constants.ApprovalRequestLeave,
+			ReferenceID:     leaveRequest.ID,
+			RequesterUserID: leaveRequest.UserID,
+			Amount:          leaveRequest.TotalDays,
+			ActorUserID:     req.ApproverID,
+		}
+
 		switch constants.LeaveAction(req.Action) {
 		case constants.LeaveActionApprove:
+			conflicts, err := s.findConflicts(ctx, leaveRequest.EmployeeID, leaveRequest.StartDate, leaveRequest.EndDate)
+			if err != nil {
+				return err
+			}
+			if blocking := filterConflicts(conflicts, constants.LeaveConflictBlock); len(blocking) > 0 {
+				return fmt.Errorf("leave conflicts with team rules: %s", describeConflicts(blocking))
+			}
+			if warnings := filterConflicts(conflicts, constants.LeaveConflictWarn); len(warnings) > 0 && !req.AcknowledgeConflicts {
+				return fmt.Errorf("leave conflicts with team rules, set acknowledge_conflicts to approve anyway: %s", describeConflicts(warnings))
+			}
+
+			decideReq.Action = constants.ApprovalActionApprove
+			decision, err := s.approval.Decide(ctx, decideReq)
+			if err != nil {
+				return err
+			}
+
+			// the balance is only deducted once the last level of the approval chain approves the leave
+			if decision.Status == constants.ApprovalStatusPending {
+				go func() {
+					_ = s.notification.BlastNotification(
+						utils.DetachContext(ctx),
+						decision.NextApproverIDs,
+						string(constants.NotificationTypeLeaveApprovalReq),
+						"Persetujuan Cuti Lanjutan",
+						fmt.Sprintf("Cuti karyawan pada tanggal %s s.d %s menunggu persetujuan Anda", leaveRequest.StartDate.Format(constants.DefaultTimeFormat), leaveRequest.EndDate.Format(constants.DefaultTimeFormat)),
+						leaveRequest.ID,
+					)
+				}()
+				return nil
+			}
+
 			shouldDeduct := leaveRequest.LeaveType.IsDeducted
 			if shouldDeduct {
 				balance, err := s.repo.GetBalance(ctx, leaveRequest.EmployeeID, leaveRequest.LeaveTypeID, leaveRequest.StartDate.Year())
@@ -153,42 +242,12 @@ func (s *service) RequestAction(ctx context.Context, req *LeaveActionRequest) er
 				}
 			}
 
-			var attendanceRecords []attendance.Attendance
-
-			currentDate := leaveRequest.StartDate
-			for !currentDate.After(leaveRequest.EndDate) {
-
-				if currentDate.Weekday() == time.Saturday || currentDate.Weekday() == time.Sunday {
-					currentDate = currentDate.AddDate(0, 0, 1)
-					continue
-				}
-
-				status := constants.AttendanceStatusExcused
-				if leaveRequest.LeaveType.Name == "Sick" {
-					status = constants.AttendanceStatusSick
-				}
-
-				att := attendance.Attendance{
-					CompanyID:          utils.GetCompanyIDFromCtx(ctx),
-					EmployeeID:         leaveRequest.EmployeeID,
-					ShiftID:            leaveRequest.Employee.ShiftID,
-					Date:               currentDate,
-					CheckInTime:        currentDate,
-					CheckInLat:         0,
-					CheckInLong:        0,
-					CheckInAddress:     "SYSTEM_GENERATED",
-					CheckInImageURL:    "",
-					Status:             string(status),
-					Notes:              "",
-					LateDurationMinute: 0,
-					IsSuspicious:       false,
-				}
-				attendanceRecords = append(attendanceRecords, att)
-
-				currentDate = currentDate.AddDate(0, 0, 1)
+			attendanceRecords, err := buildLeaveAttendances(ctx, leaveRequest)
+			if err != nil {
+				return err
 			}
 
-			err := s.repo.ApproveRequest(ctx, req.RequestID, req.ApproverID, attendanceRecords, shouldDeduct, leaveRequest.TotalDays)
+			err = s.repo.ApproveRequest(ctx, req.RequestID, req.ApproverID, attendanceRecords, shouldDeduct, leaveRequest.TotalDays)
 			if err != nil {
 				return err
 			}
@@ -201,6 +260,12 @@ func (s *service) RequestAction(ctx context.Context, req *LeaveActionRequest) er
 				return errors.New("rejection reason required")
 			}
 
+			decideReq.Action = constants.ApprovalActionReject
+			decideReq.Notes = req.RejectionReason
+			if _, err := s.approval.Decide(ctx, decideReq); err != nil {
+				return err
+			}
+
 			err := s.repo.RejectRequest(ctx, req.RequestID, req.ApproverID, req.RejectionReason)
 			if err != nil {
 				return err
@@ -229,6 +294,117 @@ func (s *service) RequestAction(ctx context.Context, req *LeaveActionRequest) er
 	})
 }
 
+func (s *service) Cancel(ctx context.Context, req *CancelLeaveRequest) error {
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		leaveRequest, err := s.repo.FindRequestByID(ctx, req.RequestID)
+		if err != nil {
+			return err
+		}
+
+		if !req.CanManage && leaveRequest.UserID != req.UserID {
+			return errors.New("you can only cancel your own leave request")
+		}
+
+		refundDays := 0.0
+		switch leaveRequest.Status {
+		case constants.LeaveStatusPending:
+		case constants.LeaveStatusApproved:
+			today := timeNow().Format(constants.DefaultTimeFormat)
+			if leaveRequest.StartDate.Format(constants.DefaultTimeFormat) <= today {
+				return errors.New("leave has already started, use early return instead")
+			}
+			if leaveRequest.LeaveType != nil && leaveRequest.LeaveType.IsDeducted {
+				refundDays = leaveRequest.TotalDays
+			}
+		default:
+			return fmt.Errorf("cannot cancel leave request with status %s", leaveRequest.Status)
+		}
+
+		if err := s.repo.CancelRequest(ctx, leaveRequest, req.UserID, req.Reason, refundDays); err != nil {
+			return err
+		}
+
+		if err := s.approval.Cancel(ctx, constants.ApprovalRequestLeave, leaveRequest.ID, req.UserID); err != nil {
+			return err
+		}
+
+		// let the other side know, approvers when the employee cancels and the employee when an approver does
+		if leaveRequest.UserID == req.UserID {
+			approvalUserIDs, err := s.user.FindRequestApprovers(ctx, leaveRequest.UserID, string(constants.APPROVAL_LEAVE))
+			if err != nil {
+				return err
+			}
+
+			go func() {
+				_ = s.notification.BlastNotification(
+					utils.DetachContext(ctx),
+					approvalUserIDs,
+					string(constants.NotificationTypeLeaveCancelled),
+					"Cuti Dibatalkan",
+					fmt.Sprintf("Karyawan membatalkan cuti pada tanggal %s s.d %s", leaveRequest.StartDate.Format(constants.DefaultTimeFormat), leaveRequest.EndDate.Format(constants.DefaultTimeFormat)),
+					leaveRequest.ID,
+				)
+			}()
+			return nil
+		}
+
+		go func() {
+			_ = s.notification.SendNotification(
+				utils.DetachContext(ctx),
+				leaveRequest.UserID,
+				string(constants.NotificationTypeLeaveCancelled),
+				"Cuti Dibatalkan",
+				"Cuti Anda telah dibatalkan oleh Admin.",
+				leaveRequest.ID,
+			)
+		}()
+		return nil
+	})
+}
+
+func (s *service) ReturnEarly(ctx context.Context, req *ReturnLeaveRequest) error {
+	returnDate, err := time.Parse(constants.DefaultTimeFormat, req.ReturnDate)
+	if err != nil {
+		return errors.New("invalid return date format")
+	}
+
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		leaveRequest, err := s.repo.FindRequestByID(ctx, req.RequestID)
+		if err != nil {
+			return err
+		}
+
+		if !req.CanManage && leaveRequest.UserID != req.UserID {
+			return errors.New("you can only update your own leave request")
+		}
+
+		if leaveRequest.Status != constants.LeaveStatusApproved {
+			return fmt.Errorf("cannot return early from leave request with status %s", leaveRequest.Status)
+		}
+		if leaveRequest.DurationType != "" && leaveRequest.DurationType != constants.LeaveDurationFullDay {
+			return errors.New("early return only applies to full day leave")
+		}
+
+		if req.ReturnDate <= leaveRequest.StartDate.Format(constants.DefaultTimeFormat) {
+			return errors.New("return date must be after the leave start date, cancel the leave instead")
+		}
+		if req.ReturnDate > leaveRequest.EndDate.Format(constants.DefaultTimeFormat) {
+			return errors.New("return date must be within the leave period")
+		}
+		if req.ReturnDate > timeNow().Format(constants.DefaultTimeFormat) {
+			return errors.New("return date cannot be in the future")
+		}
+
+		unusedDays := float64(countLeaveDays(returnDate, leaveRequest.EndDate))
+		refundDays := 0.0
+		if leaveRequest.LeaveType != nil && leaveRequest.LeaveType.IsDeducted {
+			refundDays = unusedDays
+		}
+
+		return s.repo.ReturnEarly(ctx, leaveRequest, returnDate, unusedDays, refundDays)
+	})
+}
+
 func (s *service) GetList(ctx context.Context, filter *LeaveFilter) ([]LeaveRequestListResponse, *response.Meta, error) {
 	requests, total, err := s.repo.FindAllRequests(ctx, filter)
 	if err != nil {
@@ -265,6 +441,7 @@ func (s *service) GetList(ctx context.Context, filter *LeaveFilter) ([]LeaveRequ
 			EmployeeID:   req.EmployeeID,
 			EmployeeName: empName,
 			EmployeeNIK:  empNik,
+			DurationType: req.DurationType,
 			TotalDays:    req.TotalDays,
 			LeaveTypeID:  req.LeaveTypeID,
 			LeaveType:    leaveTypeResp,
@@ -299,6 +476,15 @@ func (s *service) GetDetail(ctx context.Context, id uint) (*LeaveRequestDetailRe
 		leaveTypeResp.IsDeducted = detail.LeaveType.IsDeducted
 	}
 
+	var conflicts []LeaveConflictResponse
+	if detail.Status == constants.LeaveStatusPending {
+		// conflicts are advisory on the detail page, a failed check must not hide the request
+		conflicts, err = s.findConflicts(ctx, detail.EmployeeID, detail.StartDate, detail.EndDate)
+		if err != nil {
+			logger.Errorw("find leave conflicts failed: ", err)
+		}
+	}
+
 	return &LeaveRequestDetailResponse{
 		ID:              id,
 		StartDate:       detail.StartDate,
@@ -309,45 +495,40 @@ func (s *service) GetDetail(ctx context.Context, id uint) (*LeaveRequestDetailRe
 		EmployeeNIK:     empNik,
 		LeaveTypeID:     detail.LeaveTypeID,
 		LeaveType:       leaveTypeResp,
+		DurationType:    detail.DurationType,
+		StartTime:       detail.StartTime,
+		EndTime:         detail.EndTime,
 		TotalDays:       detail.TotalDays,
+		TotalHours:      detail.TotalHours,
 		Reason:          detail.Reason,
 		AttachmentURL:   detail.AttachmentURL,
 		RejectionReason: detail.RejectionReason,
-		CreatedAt:       detail.CreatedAt,
+
+		CancellationReason: detail.CancellationReason,
+		CancelledAt:        detail.CancelledAt,
+		ReturnedAt:         detail.ReturnedAt,
+		RefundedDays:       detail.RefundedDays,
+
+		Conflicts: conflicts,
+
+		CreatedAt: detail.CreatedAt,
 	}, nil
 }
 
 func (s *service) GenerateInitialBalance(ctx context.Context, employeeID uint) error {
-	leaveTypes, err := s.repo.FindAllLeaveTypes(ctx)
+	emp, err := s.user.FindEmployeeByID(ctx, employeeID)
 	if err != nil {
 		return err
 	}
 
-	currentYear := time.Now().Year()
-	var balances []LeaveBalance
-
-	for _, lt := range leaveTypes {
-		quota := lt.DefaultQuota
-
-		if lt.Name == "Annual" {
-			currentMonth := int(time.Now().Month())
-			remainingMonths := 12 - currentMonth + 1
-			quota = (lt.DefaultQuota * remainingMonths) / 12
-		}
-
-		balances = append(balances, LeaveBalance{
-			CompanyID:    utils.GetCompanyIDFromCtx(ctx),
-			EmployeeID:  employeeID,
-			LeaveTypeID: lt.ID,
-			Year:        currentYear,
-			QuotaTotal:  quota,
-			QuotaUsed:   0,
-			QuotaLeft:   quota,
-		})
+	policies, err := s.resolvePolicies(ctx)
+	if err != nil {
+		return err
 	}
 
-	if len(balances) > 0 {
-		if err := s.repo.CreateLeaveBalances(ctx, balances); err != nil {
+	asOf := timeNow()
+	for _, policy := range policies {
+		if err := s.accrueBalance(ctx, *emp, policy, asOf); err != nil {
 			return err
 		}
 	}
@@ -355,55 +536,150 @@ func (s *service) GenerateInitialBalance(ctx context.Context, employeeID uint) e
 	return nil
 }
 
-func (s *service) GenerateAnnualBalance(ctx context.Context) error {
+func (s *service) AccrueBalances(ctx context.Context) error {
 	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
-		tx := utils.GetDBFromContext(ctx, nil)
-
-		currentYear := time.Now().Year()
-
-		var employees []user.Employee
-		if err := tx.Find(&employees).Error; err != nil {
+		policies, err := s.resolvePolicies(ctx)
+		if err != nil {
 			return err
 		}
 
-		var leaveTypes []master.LeaveType
-		if err := tx.Find(&leaveTypes).Error; err != nil {
+		employees, err := s.user.FindAllEmployeeActive(ctx)
+		if err != nil {
 			return err
 		}
 
-		var newBalances []LeaveBalance
-
+		asOf := timeNow()
 		for _, emp := range employees {
-			for _, lt := range leaveTypes {
-				var count int64
-				tx.Model(&LeaveBalance{}).
-					Where("employee_id = ? AND leave_type_id = ? AND year = ?", emp.ID, lt.ID, currentYear).
-					Count(&count)
-
-				if count == 0 {
-				newBalances = append(newBalances, LeaveBalance{
-					CompanyID:    utils.GetCompanyIDFromCtx(ctx),
-					EmployeeID:  emp.ID,
-						LeaveTypeID: lt.ID,
-						Year:        currentYear,
-						QuotaTotal:  lt.DefaultQuota,
-						QuotaUsed:   0,
-						QuotaLeft:   lt.DefaultQuota,
-					})
+			for _, policy := range policies {
+				if err := s.accrueBalance(ctx, emp, policy, asOf); err != nil {
+					return fmt.Errorf("failed to accrue balance for employee %d: %w", emp.ID, err)
 				}
 			}
 		}
 
-		if len(newBalances) > 0 {
-			if err := tx.CreateInBatches(newBalances, 100).Error; err != nil {
-				return err
-			}
+		return nil
+	})
+}
+
+func (s *service) AccrueAllCompanies(ctx context.Context) error {
+	companyIDs, err := s.repo.FindActiveCompanyIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	// run every company in its own tenant context and transaction, one failing company must not block the others
+	var errs []error
+	for _, companyID := range companyIDs {
+		tenantCtx := context.WithValue(ctx, constants.CompanyIDContextKey, companyID)
+		if err := s.AccrueBalances(tenantCtx); err != nil {
+			errs = append(errs, fmt.Errorf("company %d: %w", companyID, err))
 		}
+	}
 
-		return nil
+	return errors.Join(errs...)
+}
+
+func (s *service) GetPolicies(ctx context.Context) ([]LeavePolicyResponse, error) {
+	policies, err := s.resolvePolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]LeavePolicyResponse, 0, len(policies))
+	for _, policy := range policies {
+		leaveTypeName := "-"
+		if policy.LeaveType != nil {
+			leaveTypeName = policy.LeaveType.Name
+		}
+
+		list = append(list, LeavePolicyResponse{
+			LeaveTypeID:           policy.LeaveTypeID,
+			LeaveTypeName:         leaveTypeName,
+			AccrualType:           policy.AccrualType,
+			QuotaPerYear:          policy.QuotaPerYear,
+			EligibilityMonths:     policy.EligibilityMonths,
+			IsProRata:             policy.IsProRata,
+			CarryOverMax:          policy.CarryOverMax,
+			CarryOverExpiryMonths: policy.CarryOverExpiryMonths,
+			TenureBonusEveryYears: policy.TenureBonusEveryYears,
+			TenureBonusDays:       policy.TenureBonusDays,
+			TenureBonusMax:        policy.TenureBonusMax,
+			IsDefault:             policy.ID == 0,
+		})
+	}
+
+	return list, nil
+}
+
+func (s *service) UpsertPolicy(ctx context.Context, req *UpsertLeavePolicyRequest) error {
+	leaveTypes, err := s.repo.FindAllLeaveTypes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(leaveTypes, func(lt master.LeaveType) bool { return lt.ID == req.LeaveTypeID }) {
+		return errors.New("leave type not found")
+	}
+
+	switch constants.LeaveAccrualType(req.AccrualType) {
+	case constants.LeaveAccrualAnnual, constants.LeaveAccrualMonthly:
+	default:
+		return fmt.Errorf("invalid accrual type: %s", req.AccrualType)
+	}
+
+	return s.repo.UpsertPolicy(ctx, &LeavePolicy{
+		CompanyID:             utils.GetCompanyIDFromCtx(ctx),
+		LeaveTypeID:           req.LeaveTypeID,
+		AccrualType:           constants.LeaveAccrualType(req.AccrualType),
+		QuotaPerYear:          req.QuotaPerYear,
+		EligibilityMonths:     req.EligibilityMonths,
+		IsProRata:             req.IsProRata,
+		CarryOverMax:          req.CarryOverMax,
+		CarryOverExpiryMonths: req.CarryOverExpiryMonths,
+		TenureBonusEveryYears: req.TenureBonusEveryYears,
+		TenureBonusDays:       req.TenureBonusDays,
+		TenureBonusMax:        req.TenureBonusMax,
 	})
 }
 
+func (s *service) GetLedger(ctx context.Context, filter *LeaveLedgerFilter) ([]LeaveLedgerResponse, *response.Meta, error) {
+	entries, total, err := s.repo.FindAllLedgers(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := make([]LeaveLedgerResponse, 0, len(entries))
+	for _, entry := range entries {
+		empName := "-"
+		leaveTypeName := "-"
+
+		if entry.Employee != nil {
+			empName = entry.Employee.FullName
+		}
+		if entry.LeaveType != nil {
+			leaveTypeName = entry.LeaveType.Name
+		}
+
+		list = append(list, LeaveLedgerResponse{
+			ID:            entry.ID,
+			EmployeeID:    entry.EmployeeID,
+			EmployeeName:  empName,
+			LeaveTypeID:   entry.LeaveTypeID,
+			LeaveTypeName: leaveTypeName,
+			Year:          entry.Year,
+			Type:          entry.Type,
+			Days:          entry.Days,
+			ExpiresAt:     entry.ExpiresAt,
+			ReferenceID:   entry.ReferenceID,
+			Notes:         entry.Notes,
+			CreatedAt:     entry.CreatedAt,
+		})
+	}
+
+	meta := response.NewMetaOffset(filter.Page, filter.Limit, total)
+	return list, meta, nil
+}
+
 func (s *service) Export(ctx context.Context, filter *LeaveFilter) ([]byte, error) {
 	// Fetch all data matching filter
 	filter.Page = 1
@@ -449,3 +725,74 @@ func (s *service) Export(ctx context.Context, filter *LeaveFilter) ([]byte, erro
 
 	return s.excel.GenerateSimpleExcel("Leaves", headers, rows)
 }
+
+// buildLeaveAttendances turns an approved request into attendance rows, EXCUSED for every working day
+// or only for the covered hours of a half day / hourly leave.
+func buildLeaveAttendances(ctx context.Context, leaveRequest *LeaveRequest) ([]attendance.Attendance, error) {
+	status := constants.AttendanceStatusExcused
+	if leaveRequest.LeaveType != nil && leaveRequest.LeaveType.Name == "Sick" {
+		status = constants.AttendanceStatusSick
+	}
+
+	var shiftID uint
+	var shift *master.Shift
+	if leaveRequest.Employee != nil {
+		shiftID = leaveRequest.Employee.ShiftID
+		shift = leaveRequest.Employee.Shift
+	}
+
+	if leaveRequest.DurationType != "" && leaveRequest.DurationType != constants.LeaveDurationFullDay {
+		from, until, err := excusedWindow(leaveRequest, shift)
+		if err != nil {
+			return nil, err
+		}
+
+		return []attendance.Attendance{{
+			CompanyID:      utils.GetCompanyIDFromCtx(ctx),
+			EmployeeID:     leaveRequest.EmployeeID,
+			ShiftID:        shiftID,
+			Date:           leaveRequest.StartDate,
+			CheckInTime:    from,
+			CheckInAddress: leaveAttendanceAddress,
+			Status:         string(status),
+			Notes:          fmt.Sprintf("Leave %s - %s", from.Format(constants.ShiftHourFormat), until.Format(constants.ShiftHourFormat)),
+			LeaveRequestID: &leaveRequest.ID,
+			ExcusedFrom:    &from,
+			ExcusedUntil:   &until,
+			IsSuspicious:   false,
+		}}, nil
+	}
+
+	var attendanceRecords []attendance.Attendance
+
+	currentDate := leaveRequest.StartDate
+	for !currentDate.After(leaveRequest.EndDate) {
+
+		if !isLeaveWorkingDay(currentDate) {
+			currentDate = currentDate.AddDate(0, 0, 1)
+			continue
+		}
+
+		att := attendance.Attendance{
+			CompanyID:          utils.GetCompanyIDFromCtx(ctx),
+			EmployeeID:         leaveRequest.EmployeeID,
+			ShiftID:            shiftID,
+			Date:               currentDate,
+			CheckInTime:        currentDate,
+			CheckInLat:         0,
+			CheckInLong:        0,
+			CheckInAddress:     leaveAttendanceAddress,
+			CheckInImageURL:    "",
+			Status:             string(status),
+			Notes:              "",
+			LateDurationMinute: 0,
+			IsSuspicious:       false,
+			LeaveRequestID:     &leaveRequest.ID,
+		}
+		attendanceRecords = append(attendanceRecords, att)
+
+		currentDate = currentDate.AddDate(0, 0, 1)
+	}
+
+	return attendanceRecords, nil
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/constants"
@@ -13,18 +14,27 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
-func newTestLeaveService() (Service, *mockRepo, *mockStorage, *mockNotification, *mockUserProvider, *testutil.MockTransactionManager, *mockExcel) {
+func newTestLeaveService() (Service, *mockRepo, *mockStorage, *mockNotification, *mockUserProvider, *mockApprovalEngine, *testutil.MockTransactionManager, *mockExcel) {
 	repo := new(mockRepo)
 	storage := new(mockStorage)
 	notif := new(mockNotification)
 	userProv := new(mockUserProvider)
+	approvalEngine := new(mockApprovalEngine)
 	tm := testutil.NewMockTransactionManager()
 	excel := new(mockExcel)
 
-	svc := NewService(repo, storage, notif, userProv, tm, excel)
-	return svc, repo, storage, notif, userProv, tm, excel
+	svc := NewService(repo, storage, notif, userProv, approvalEngine, tm, excel)
+	return svc, repo, storage, notif, userProv, approvalEngine, tm, excel
+}
+
+// pinClock freezes the service clock so accrual does not depend on the day the tests run
+func pinClock(t *testing.T, at time.Time) {
+	t.Helper()
+	timeNow = func() time.Time { return at }
+	t.Cleanup(func() { timeNow = time.Now })
 }
 
 func TestService_Apply(t *testing.T) {
@@ -33,7 +43,7 @@ func TestService_Apply(t *testing.T) {
 	tests := []struct {
 		name       string
 		req        *ApplyRequest
-		setupMocks func(*mockRepo, *mockStorage, *mockNotification, *mockUserProvider)
+		setupMocks func(*mockRepo, *mockStorage, *mockNotification, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -46,10 +56,13 @@ func TestService_Apply(t *testing.T) {
 				EndDate:     "2026-06-02",
 				Reason:      "Family event",
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(&LeaveBalance{QuotaLeft: 5}, nil)
+				repo.On("FindConflictRules", mock.Anything, true).Return([]LeaveConflictRule{}, nil)
 				repo.On("CreateRequest", mock.Anything, mock.AnythingOfType("*leave.LeaveRequest")).Return(nil)
-				userProv.On("FindApprovalUsers", mock.Anything, string(constants.APPROVAL_LEAVE)).Return([]uint{10, 11}, nil)
+				approvalEngine.On("Submit", mock.Anything, mock.MatchedBy(func(req *approval.SubmitRequest) bool {
+					return req.RequestType == constants.ApprovalRequestLeave && req.Amount == 2
+				})).Return([]uint{10, 11}, nil)
 				notif.On("BlastNotification", mock.Anything, []uint{10, 11}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
@@ -63,7 +76,7 @@ func TestService_Apply(t *testing.T) {
 				EndDate:     "2026-06-02",
 				Reason:      "Test",
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {},
 			wantErr: true,
 			errMsg: "invalid start date format",
 		},
@@ -76,7 +89,7 @@ func TestService_Apply(t *testing.T) {
 				EndDate:     "not-a-date",
 				Reason:      "Test",
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {},
 			wantErr: true,
 			errMsg: "invalid end date format",
 		},
@@ -89,7 +102,7 @@ func TestService_Apply(t *testing.T) {
 				EndDate:     "2026-06-01",
 				Reason:      "Test",
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {},
 			wantErr: true,
 			errMsg: "end date must be after start date",
 		},
@@ -102,7 +115,7 @@ func TestService_Apply(t *testing.T) {
 				EndDate:     "2026-06-05",
 				Reason:      "Vacation",
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(&LeaveBalance{QuotaLeft: 2}, nil)
 			},
 			wantErr: true,
@@ -117,18 +130,90 @@ func TestService_Apply(t *testing.T) {
 				EndDate:     "2026-06-02",
 				Reason:      "Test",
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(nil, errors.New("db error"))
 			},
 			wantErr: true,
 			errMsg: "db error",
 		},
+		{
+			name: "success half day takes half of the quota",
+			req: &ApplyRequest{
+				EmployeeID:   1,
+				LeaveTypeID:  1,
+				StartDate:    "2026-06-01",
+				EndDate:      "2026-06-01",
+				DurationType: string(constants.LeaveDurationHalfDayMorning),
+				StartTime:    "08:00",
+				Reason:       "Doctor appointment",
+			},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(&LeaveBalance{QuotaLeft: 0.5}, nil)
+				repo.On("FindConflictRules", mock.Anything, true).Return([]LeaveConflictRule{}, nil)
+				repo.On("CreateRequest", mock.Anything, mock.MatchedBy(func(r *LeaveRequest) bool {
+					return r.TotalDays == 0.5 && r.TotalHours == 4 && r.StartTime == "" && r.DurationType == constants.LeaveDurationHalfDayMorning
+				})).Return(nil)
+				approvalEngine.On("Submit", mock.Anything, mock.AnythingOfType("*approval.SubmitRequest")).Return([]uint{10}, nil)
+				notif.On("BlastNotification", mock.Anything, []uint{10}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "success hourly takes the covered hours",
+			req: &ApplyRequest{
+				EmployeeID:   1,
+				LeaveTypeID:  1,
+				StartDate:    "2026-06-01",
+				EndDate:      "2026-06-01",
+				DurationType: string(constants.LeaveDurationHourly),
+				StartTime:    "09:00",
+				EndTime:      "11:00",
+				Reason:       "Bank errand",
+			},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(&LeaveBalance{QuotaLeft: 1}, nil)
+				repo.On("FindConflictRules", mock.Anything, true).Return([]LeaveConflictRule{}, nil)
+				repo.On("CreateRequest", mock.Anything, mock.MatchedBy(func(r *LeaveRequest) bool {
+					return r.TotalDays == 0.25 && r.TotalHours == 2 && r.StartTime == "09:00" && r.EndTime == "11:00"
+				})).Return(nil)
+				approvalEngine.On("Submit", mock.Anything, mock.AnythingOfType("*approval.SubmitRequest")).Return([]uint{10}, nil)
+				notif.On("BlastNotification", mock.Anything, []uint{10}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "error half day across several dates",
+			req: &ApplyRequest{
+				EmployeeID:   1,
+				LeaveTypeID:  1,
+				StartDate:    "2026-06-01",
+				EndDate:      "2026-06-02",
+				DurationType: string(constants.LeaveDurationHalfDayAfternoon),
+				Reason:       "Test",
+			},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {},
+			wantErr:    true,
+			errMsg:     "partial day leave must start and end on the same date",
+		},
+		{
+			name: "error full day leave on a weekend",
+			req: &ApplyRequest{
+				EmployeeID:  1,
+				LeaveTypeID: 1,
+				StartDate:   "2026-06-06",
+				EndDate:     "2026-06-07",
+				Reason:      "Test",
+			},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {},
+			wantErr:    true,
+			errMsg:     "leave period has no working days",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, storage, notif, userProv, _, _ := newTestLeaveService()
-			tt.setupMocks(repo, storage, notif, userProv)
+			svc, repo, storage, notif, _, approvalEngine, _, _ := newTestLeaveService()
+			tt.setupMocks(repo, storage, notif, approvalEngine)
 
 			err := svc.Apply(ctx, tt.req)
 
@@ -148,7 +233,7 @@ func TestService_RequestAction(t *testing.T) {
 	tests := []struct {
 		name       string
 		req        *LeaveActionRequest
-		setupMocks func(*mockRepo)
+		setupMocks func(*mockRepo, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -159,7 +244,7 @@ func TestService_RequestAction(t *testing.T) {
 				ApproverID: 10,
 				Action:     string(constants.LeaveActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindRequestByID", mock.Anything, uint(1)).Return(&LeaveRequest{
 					ID:          1,
 					EmployeeID:  1,
@@ -172,7 +257,9 @@ func TestService_RequestAction(t *testing.T) {
 					Employee:    &user.Employee{ID: 1, ShiftID: 1},
 					User:        user.User{ID: 1},
 				}, nil)
-				repo.On("ApproveRequest", mock.Anything, uint(1), uint(10), mock.Anything, false, 2).Return(nil)
+				repo.On("FindConflictRules", mock.Anything, true).Return([]LeaveConflictRule{}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.AnythingOfType("*approval.DecideRequest")).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
+				repo.On("ApproveRequest", mock.Anything, uint(1), uint(10), mock.Anything, false, float64(2)).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -183,7 +270,7 @@ func TestService_RequestAction(t *testing.T) {
 				ApproverID: 10,
 				Action:     string(constants.LeaveActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindRequestByID", mock.Anything, uint(2)).Return(&LeaveRequest{
 					ID:          2,
 					EmployeeID:  1,
@@ -197,7 +284,9 @@ func TestService_RequestAction(t *testing.T) {
 					User:        user.User{ID: 1},
 				}, nil)
 				repo.On("GetBalance", mock.Anything, uint(1), uint(1), 2026).Return(&LeaveBalance{QuotaLeft: 5}, nil)
-				repo.On("ApproveRequest", mock.Anything, uint(2), uint(10), mock.Anything, true, 2).Return(nil)
+				repo.On("FindConflictRules", mock.Anything, true).Return([]LeaveConflictRule{}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.AnythingOfType("*approval.DecideRequest")).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
+				repo.On("ApproveRequest", mock.Anything, uint(2), uint(10), mock.Anything, true, float64(2)).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -209,12 +298,15 @@ func TestService_RequestAction(t *testing.T) {
 				Action:          string(constants.LeaveActionReject),
 				RejectionReason: "Not eligible",
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindRequestByID", mock.Anything, uint(3)).Return(&LeaveRequest{
 					ID:     3,
 					Status: constants.LeaveStatusPending,
 					User:   user.User{ID: 1},
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.Action == constants.ApprovalActionReject && req.Notes == "Not eligible"
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusRejected}, nil)
 				repo.On("RejectRequest", mock.Anything, uint(3), uint(10), "Not eligible").Return(nil)
 			},
 			wantErr: false,
@@ -226,7 +318,7 @@ func TestService_RequestAction(t *testing.T) {
 				ApproverID: 10,
 				Action:     string(constants.LeaveActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindRequestByID", mock.Anything, uint(4)).Return(&LeaveRequest{
 					ID:     4,
 					Status: constants.LeaveStatusApproved,
@@ -243,7 +335,7 @@ func TestService_RequestAction(t *testing.T) {
 				Action:          string(constants.LeaveActionReject),
 				RejectionReason: "",
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindRequestByID", mock.Anything, uint(5)).Return(&LeaveRequest{
 					ID:     5,
 					Status: constants.LeaveStatusPending,
@@ -260,7 +352,7 @@ func TestService_RequestAction(t *testing.T) {
 				ApproverID: 10,
 				Action:     string(constants.LeaveActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindRequestByID", mock.Anything, uint(99)).Return(nil, errors.New("not found"))
 			},
 			wantErr: true,
@@ -273,7 +365,7 @@ func TestService_RequestAction(t *testing.T) {
 				ApproverID: 10,
 				Action:     "INVALID",
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindRequestByID", mock.Anything, uint(6)).Return(&LeaveRequest{
 					ID:     6,
 					Status: constants.LeaveStatusPending,
@@ -283,16 +375,61 @@ func TestService_RequestAction(t *testing.T) {
 			wantErr: true,
 			errMsg: "invalid action",
 		},
+		{
+			name: "approve waits for the next approval level without deducting",
+			req: &LeaveActionRequest{
+				RequestID:  7,
+				ApproverID: 10,
+				Action:     string(constants.LeaveActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
+				repo.On("FindRequestByID", mock.Anything, uint(7)).Return(&LeaveRequest{
+					ID:        7,
+					UserID:    1,
+					TotalDays: 5,
+					Status:    constants.LeaveStatusPending,
+					StartDate: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+					EndDate:   time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC),
+					LeaveType: &master.LeaveType{ID: 1, IsDeducted: true},
+					User:      user.User{ID: 1},
+				}, nil)
+				repo.On("FindConflictRules", mock.Anything, true).Return([]LeaveConflictRule{}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.RequesterUserID == 1 && req.Amount == 5 && req.ActorUserID == 10
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusPending, NextApproverIDs: []uint{20}}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "error actor is not an approver",
+			req: &LeaveActionRequest{
+				RequestID:  8,
+				ApproverID: 10,
+				Action:     string(constants.LeaveActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
+				repo.On("FindRequestByID", mock.Anything, uint(8)).Return(&LeaveRequest{
+					ID:     8,
+					Status: constants.LeaveStatusPending,
+					User:   user.User{ID: 1},
+				}, nil)
+				repo.On("FindConflictRules", mock.Anything, true).Return([]LeaveConflictRule{}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(nil, errors.New("you are not an approver of the current approval step"))
+			},
+			wantErr: true,
+			errMsg: "you are not an approver of the current approval step",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, notif, _, _, _ := newTestLeaveService()
-			tt.setupMocks(repo)
+			svc, repo, _, notif, _, approvalEngine, _, _ := newTestLeaveService()
+			tt.setupMocks(repo, approvalEngine)
 
 			// Only set notification mock for success cases
 			if !tt.wantErr && (tt.req.Action == string(constants.LeaveActionApprove) || tt.req.Action == string(constants.LeaveActionReject)) {
-				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+				notif.On("BlastNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 			}
 
 			err := svc.RequestAction(ctx, tt.req)
@@ -302,6 +439,245 @@ func TestService_RequestAction(t *testing.T) {
 				assert.Equal(t, tt.errMsg, err.Error())
 			} else {
 				require.NoError(t, err)
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestService_Cancel(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	pinClock(t, time.Date(2026, 6, 3, 9, 0, 0, 0, time.UTC))
+
+	annual := &master.LeaveType{ID: 1, Name: "Annual", IsDeducted: true}
+
+	tests := []struct {
+		name       string
+		req        *CancelLeaveRequest
+		setupMocks func(*mockRepo, *mockNotification, *mockUserProvider)
+		wantErr    bool
+		errMsg     string
+	}{
+		{
+			name: "employee cancels pending request without refund",
+			req:  &CancelLeaveRequest{RequestID: 1, UserID: 1, Reason: "Plans changed"},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+				repo.On("FindRequestByID", mock.Anything, uint(1)).Return(&LeaveRequest{
+					ID: 1, UserID: 1, TotalDays: 2, Status: constants.LeaveStatusPending, LeaveType: annual,
+					StartDate: time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 6, 11, 0, 0, 0, 0, time.UTC),
+				}, nil)
+				repo.On("CancelRequest", mock.Anything, mock.Anything, uint(1), "Plans changed", float64(0)).Return(nil)
+				userProv.On("FindRequestApprovers", mock.Anything, mock.Anything, string(constants.APPROVAL_LEAVE)).Return([]uint{10}, nil)
+				notif.On("BlastNotification", mock.Anything, []uint{10}, string(constants.NotificationTypeLeaveCancelled), mock.Anything, mock.Anything, uint(1)).Return(nil).Maybe()
+			},
+			wantErr: false,
+		},
+		{
+			name: "employee cancels future approved leave and gets the days back",
+			req:  &CancelLeaveRequest{RequestID: 2, UserID: 1, Reason: "Plans changed"},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+				repo.On("FindRequestByID", mock.Anything, uint(2)).Return(&LeaveRequest{
+					ID: 2, UserID: 1, TotalDays: 2, Status: constants.LeaveStatusApproved, LeaveType: annual,
+					StartDate: time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 6, 11, 0, 0, 0, 0, time.UTC),
+				}, nil)
+				repo.On("CancelRequest", mock.Anything, mock.Anything, uint(1), "Plans changed", float64(2)).Return(nil)
+				userProv.On("FindRequestApprovers", mock.Anything, mock.Anything, string(constants.APPROVAL_LEAVE)).Return([]uint{10}, nil)
+				notif.On("BlastNotification", mock.Anything, []uint{10}, string(constants.NotificationTypeLeaveCancelled), mock.Anything, mock.Anything, uint(2)).Return(nil).Maybe()
+			},
+			wantErr: false,
+		},
+		{
+			name: "approver cancels someone else's leave",
+			req:  &CancelLeaveRequest{RequestID: 3, UserID: 10, CanManage: true, Reason: "Project deadline"},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+				repo.On("FindRequestByID", mock.Anything, uint(3)).Return(&LeaveRequest{
+					ID: 3, UserID: 1, TotalDays: 0.5, Status: constants.LeaveStatusApproved, LeaveType: annual,
+					DurationType: constants.LeaveDurationHalfDayMorning,
+					StartDate:    time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC),
+				}, nil)
+				repo.On("CancelRequest", mock.Anything, mock.Anything, uint(10), "Project deadline", float64(0.5)).Return(nil)
+				notif.On("SendNotification", mock.Anything, uint(1), string(constants.NotificationTypeLeaveCancelled), mock.Anything, mock.Anything, uint(3)).Return(nil).Maybe()
+			},
+			wantErr: false,
+		},
+		{
+			name: "error cancelling someone else's leave",
+			req:  &CancelLeaveRequest{RequestID: 4, UserID: 2, Reason: "Test"},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+				repo.On("FindRequestByID", mock.Anything, uint(4)).Return(&LeaveRequest{
+					ID: 4, UserID: 1, Status: constants.LeaveStatusPending,
+				}, nil)
+			},
+			wantErr: true,
+			errMsg:  "you can only cancel your own leave request",
+		},
+		{
+			name: "error approved leave already started",
+			req:  &CancelLeaveRequest{RequestID: 5, UserID: 1, Reason: "Test"},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+				repo.On("FindRequestByID", mock.Anything, uint(5)).Return(&LeaveRequest{
+					ID: 5, UserID: 1, Status: constants.LeaveStatusApproved, LeaveType: annual,
+					StartDate: time.Date(2026, 6, 3, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC),
+				}, nil)
+			},
+			wantErr: true,
+			errMsg:  "leave has already started, use early return instead",
+		},
+		{
+			name: "error rejected request",
+			req:  &CancelLeaveRequest{RequestID: 6, UserID: 1, Reason: "Test"},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+				repo.On("FindRequestByID", mock.Anything, uint(6)).Return(&LeaveRequest{
+					ID: 6, UserID: 1, Status: constants.LeaveStatusRejected,
+				}, nil)
+			},
+			wantErr: true,
+			errMsg:  "cannot cancel leave request with status REJECTED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, notif, userProv, approvalEngine, _, _ := newTestLeaveService()
+			tt.setupMocks(repo, notif, userProv)
+			approvalEngine.On("Cancel", mock.Anything, constants.ApprovalRequestLeave, tt.req.RequestID, tt.req.UserID).Return(nil).Maybe()
+
+			err := svc.Cancel(ctx, tt.req)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+				repo.AssertNotCalled(t, "CancelRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				approvalEngine.AssertNotCalled(t, "Cancel", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				repo.AssertExpectations(t)
+				approvalEngine.AssertCalled(t, "Cancel", mock.Anything, constants.ApprovalRequestLeave, tt.req.RequestID, tt.req.UserID)
+			}
+		})
+	}
+}
+
+func TestService_ReturnEarly(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	pinClock(t, time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC))
+
+	// Mon 8 - Fri 12 June
+	approved := func(isDeducted bool) *LeaveRequest {
+		return &LeaveRequest{
+			ID: 1, UserID: 1, TotalDays: 5, Status: constants.LeaveStatusApproved,
+			DurationType: constants.LeaveDurationFullDay,
+			LeaveType:    &master.LeaveType{ID: 1, IsDeducted: isDeducted},
+			StartDate:    time.Date(2026, 6, 8, 0, 0, 0, 0, time.UTC),
+			EndDate:      time.Date(2026, 6, 12, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		req        *ReturnLeaveRequest
+		setupMocks func(*mockRepo)
+		wantErr    bool
+		errMsg     string
+	}{
+		{
+			name: "refunds the unused working days",
+			req:  &ReturnLeaveRequest{RequestID: 1, UserID: 1, ReturnDate: "2026-06-10"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindRequestByID", mock.Anything, uint(1)).Return(approved(true), nil)
+				repo.On("ReturnEarly", mock.Anything, mock.Anything, time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC), float64(3), float64(3)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "shortens leave that is not deducted without refund",
+			req:  &ReturnLeaveRequest{RequestID: 1, UserID: 1, ReturnDate: "2026-06-09"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindRequestByID", mock.Anything, uint(1)).Return(approved(false), nil)
+				repo.On("ReturnEarly", mock.Anything, mock.Anything, time.Date(2026, 6, 9, 0, 0, 0, 0, time.UTC), float64(4), float64(0)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:       "error invalid return date format",
+			req:        &ReturnLeaveRequest{RequestID: 1, UserID: 1, ReturnDate: "10-06-2026"},
+			setupMocks: func(repo *mockRepo) {},
+			wantErr:    true,
+			errMsg:     "invalid return date format",
+		},
+		{
+			name: "error returning from someone else's leave",
+			req:  &ReturnLeaveRequest{RequestID: 1, UserID: 2, ReturnDate: "2026-06-10"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindRequestByID", mock.Anything, uint(1)).Return(approved(true), nil)
+			},
+			wantErr: true,
+			errMsg:  "you can only update your own leave request",
+		},
+		{
+			name: "error return on the first leave day",
+			req:  &ReturnLeaveRequest{RequestID: 1, UserID: 1, ReturnDate: "2026-06-08"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindRequestByID", mock.Anything, uint(1)).Return(approved(true), nil)
+			},
+			wantErr: true,
+			errMsg:  "return date must be after the leave start date, cancel the leave instead",
+		},
+		{
+			name: "error return after the leave ends",
+			req:  &ReturnLeaveRequest{RequestID: 1, UserID: 1, ReturnDate: "2026-06-15"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindRequestByID", mock.Anything, uint(1)).Return(approved(true), nil)
+			},
+			wantErr: true,
+			errMsg:  "return date must be within the leave period",
+		},
+		{
+			name: "error return date in the future",
+			req:  &ReturnLeaveRequest{RequestID: 1, UserID: 1, ReturnDate: "2026-06-11"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindRequestByID", mock.Anything, uint(1)).Return(approved(true), nil)
+			},
+			wantErr: true,
+			errMsg:  "return date cannot be in the future",
+		},
+		{
+			name: "error partial day leave",
+			req:  &ReturnLeaveRequest{RequestID: 1, UserID: 1, ReturnDate: "2026-06-10"},
+			setupMocks: func(repo *mockRepo) {
+				req := approved(true)
+				req.DurationType = constants.LeaveDurationHourly
+				repo.On("FindRequestByID", mock.Anything, uint(1)).Return(req, nil)
+			},
+			wantErr: true,
+			errMsg:  "early return only applies to full day leave",
+		},
+		{
+			name: "error pending request",
+			req:  &ReturnLeaveRequest{RequestID: 1, UserID: 1, ReturnDate: "2026-06-10"},
+			setupMocks: func(repo *mockRepo) {
+				req := approved(true)
+				req.Status = constants.LeaveStatusPending
+				repo.On("FindRequestByID", mock.Anything, uint(1)).Return(req, nil)
+			},
+			wantErr: true,
+			errMsg:  "cannot return early from leave request with status PENDING",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, _, _, _, _, _ := newTestLeaveService()
+			tt.setupMocks(repo)
+
+			err := svc.ReturnEarly(ctx, tt.req)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				require.NoError(t, err)
+				repo.AssertExpectations(t)
 			}
 		})
 	}
@@ -331,6 +707,7 @@ func TestService_GetDetail(t *testing.T) {
 					Employee:   &user.Employee{FullName: "John", NIK: "001"},
 					LeaveType:  &master.LeaveType{ID: 1, Name: "Annual", DefaultQuota: 12, IsDeducted: true},
 				}, nil)
+				repo.On("FindConflictRules", mock.Anything, true).Return([]LeaveConflictRule{}, nil)
 			},
 			wantErr: false,
 		},
@@ -347,7 +724,7 @@ func TestService_GetDetail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, _ := newTestLeaveService()
+			svc, repo, _, _, _, _, _, _ := newTestLeaveService()
 			tt.setupMocks(repo)
 
 			resp, err := svc.GetDetail(ctx, tt.id)
@@ -416,7 +793,7 @@ func TestService_GetList(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, _ := newTestLeaveService()
+			svc, repo, _, _, _, _, _, _ := newTestLeaveService()
 			tt.setupMocks(repo)
 
 			list, meta, err := svc.GetList(ctx, tt.filter)
@@ -432,41 +809,66 @@ func TestService_GetList(t *testing.T) {
 
 func TestService_GenerateInitialBalance(t *testing.T) {
 	ctx := testutil.CtxWithTenant(1, 1, false)
+	pinClock(t, time.Date(2026, 6, 15, 8, 0, 0, 0, time.UTC))
+	joinDate := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
 
 	tests := []struct {
 		name       string
 		employeeID uint
-		setupMocks func(*mockRepo)
+		setupMocks func(*mockRepo, *mockUserProvider)
 		wantErr    bool
 		errMsg     string
 	}{
 		{
-			name:       "success with leave types",
+			name:       "success new joiner only gets leave types without eligibility wait",
 			employeeID: 1,
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				userProv.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&user.Employee{ID: 1, CompanyID: 1, JoinDate: &joinDate}, nil)
 				repo.On("FindAllLeaveTypes", mock.Anything).Return([]master.LeaveType{
 					{ID: 1, Name: "Annual", DefaultQuota: 12},
 					{ID: 2, Name: "Sick", DefaultQuota: 6},
 				}, nil)
-				repo.On("CreateLeaveBalances", mock.Anything, mock.AnythingOfType("[]leave.LeaveBalance")).Return(nil)
+				repo.On("FindAllPolicies", mock.Anything).Return([]LeavePolicy{}, nil)
+				repo.On("GetBalance", mock.Anything, uint(1), mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+				repo.On("SumLedgerDays", mock.Anything, uint(1), mock.Anything, 2026, constants.LeaveLedgerAccrual).Return(float64(0), nil)
+				repo.On("SaveBalance", mock.Anything, mock.MatchedBy(func(b *LeaveBalance) bool {
+					return b.LeaveTypeID == 1 && b.QuotaLeft == 0
+				})).Return(nil).Once()
+				repo.On("SaveBalance", mock.Anything, mock.MatchedBy(func(b *LeaveBalance) bool {
+					return b.LeaveTypeID == 2 && b.QuotaLeft == 6
+				})).Return(nil).Once()
+				repo.On("CreateLedgers", mock.Anything, []LeaveLedger(nil)).Return(nil).Once()
+				repo.On("CreateLedgers", mock.Anything, mock.MatchedBy(func(entries []LeaveLedger) bool {
+					return len(entries) == 1 && entries[0].Type == constants.LeaveLedgerAccrual && entries[0].Days == 6
+				})).Return(nil).Once()
 			},
 			wantErr: false,
 		},
+		{
+			name:       "error employee not found",
+			employeeID: 1,
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				userProv.On("FindEmployeeByID", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErr: true,
+			errMsg:  "record not found",
+		},
 		{
 			name:       "error find leave types fails",
 			employeeID: 1,
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider) {
+				userProv.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&user.Employee{ID: 1, CompanyID: 1}, nil)
 				repo.On("FindAllLeaveTypes", mock.Anything).Return([]master.LeaveType(nil), errors.New("db error"))
 			},
 			wantErr: true,
-			errMsg: "db error",
+			errMsg:  "db error",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, _ := newTestLeaveService()
-			tt.setupMocks(repo)
+			svc, repo, _, _, userProv, _, _, _ := newTestLeaveService()
+			tt.setupMocks(repo, userProv)
 
 			err := svc.GenerateInitialBalance(ctx, tt.employeeID)
 
@@ -475,6 +877,7 @@ func TestService_GenerateInitialBalance(t *testing.T) {
 				assert.Equal(t, tt.errMsg, err.Error())
 			} else {
 				require.NoError(t, err)
+				repo.AssertExpectations(t)
 			}
 		})
 	}
@@ -524,7 +927,7 @@ func TestService_Export(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, excel := newTestLeaveService()
+			svc, repo, _, _, _, _, _, excel := newTestLeaveService()
 			tt.setupMocks(repo, excel)
 
 			data, err := svc.Export(ctx, tt.filter)
@@ -539,3 +942,62 @@ func TestService_Export(t *testing.T) {
 		})
 	}
 }
+
+func TestService_UpsertPolicy(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	tests := []struct {
+		name       string
+		req        *UpsertLeavePolicyRequest
+		setupMocks func(*mockRepo)
+		wantErr    bool
+		errMsg     string
+	}{
+		{
+			name: "success",
+			req:  &UpsertLeavePolicyRequest{LeaveTypeID: 1, AccrualType: "MONTHLY", QuotaPerYear: 12, CarryOverMax: 5, CarryOverExpiryMonths: 3},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindAllLeaveTypes", mock.Anything).Return([]master.LeaveType{{ID: 1, Name: "Annual"}}, nil)
+				repo.On("UpsertPolicy", mock.Anything, mock.MatchedBy(func(p *LeavePolicy) bool {
+					return p.CompanyID == 1 && p.LeaveTypeID == 1 && p.AccrualType == constants.LeaveAccrualMonthly && p.CarryOverMax == 5
+				})).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "error leave type of another tenant",
+			req:  &UpsertLeavePolicyRequest{LeaveTypeID: 9, AccrualType: "ANNUAL"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindAllLeaveTypes", mock.Anything).Return([]master.LeaveType{{ID: 1, Name: "Annual"}}, nil)
+			},
+			wantErr: true,
+			errMsg:  "leave type not found",
+		},
+		{
+			name: "error invalid accrual type",
+			req:  &UpsertLeavePolicyRequest{LeaveTypeID: 1, AccrualType: "WEEKLY"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindAllLeaveTypes", mock.Anything).Return([]master.LeaveType{{ID: 1, Name: "Annual"}}, nil)
+			},
+			wantErr: true,
+			errMsg:  "invalid accrual type: WEEKLY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, _, _, _, _, _ := newTestLeaveService()
+			tt.setupMocks(repo)
+
+			err := svc.UpsertPolicy(ctx, tt.req)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				require.NoError(t, err)
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package leave
+
+import (
+	"basekarya-backend/pkg/constants"
+	"context"
+)
+
+// GetUnusedLeave lists the days left on the employee's balances for the year. Leave types that are not deducted
+// from a balance, such as sick leave, have nothing to pay out and are skipped.
+func (s *service) GetUnusedLeave(ctx context.Context, employeeID uint, year int) ([]UnusedLeave, error) {
+	balances, err := s.repo.FindBalancesByEmployee(ctx, employeeID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]UnusedLeave, 0, len(balances))
+	for _, b := range balances {
+		if !isPayable(&b) {
+			continue
+		}
+
+		list = append(list, UnusedLeave{
+			LeaveTypeID:   b.LeaveTypeID,
+			LeaveTypeName: b.LeaveType.Name,
+			Days:          b.QuotaLeft,
+		})
+	}
+
+	return list, nil
+}
+
+// PayOutUnusedLeave closes the balances GetUnusedLeave lists once they are paid in a final settlement, the
+// ledger keeps the settlement as reference.
+func (s *service) PayOutUnusedLeave(ctx context.Context, employeeID uint, year int, referenceID uint) error {
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		balances, err := s.repo.FindBalancesByEmployee(ctx, employeeID, year)
+		if err != nil {
+			return err
+		}
+
+		var entries []LeaveLedger
+		for i := range balances {
+			balance := &balances[i]
+			if !isPayable(balance) {
+				continue
+			}
+
+			entry := newLedgerEntry(balance, constants.LeaveLedgerPayout, -balance.QuotaLeft, "Paid out in final settlement")
+			entry.ReferenceID = &referenceID
+			entries = append(entries, entry)
+
+			balance.QuotaTotal -= balance.QuotaLeft
+			balance.QuotaLeft = 0
+			if err := s.repo.SaveBalance(ctx, balance); err != nil {
+				return err
+			}
+		}
+
+		if len(entries) == 0 {
+			return nil
+		}
+		return s.repo.CreateLedgers(ctx, entries)
+	})
+}
+
+func isPayable(balance *LeaveBalance) bool {
+	return balance.LeaveType != nil && balance.LeaveType.IsDeducted && balance.QuotaLeft > 0
+}
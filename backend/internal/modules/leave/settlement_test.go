@@ -0,0 +1,60 @@
+package leave
+
+import (
+	"testing"
+
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// settlementBalances holds annual leave with days left, sick leave that is not deducted and a used up balance.
+func settlementBalances() []LeaveBalance {
+	return []LeaveBalance{
+		{ID: 1, CompanyID: 1, EmployeeID: 7, LeaveTypeID: 1, Year: 2026, QuotaTotal: 12, QuotaUsed: 7.5, QuotaLeft: 4.5, LeaveType: &master.LeaveType{ID: 1, Name: "Annual", IsDeducted: true}},
+		{ID: 2, CompanyID: 1, EmployeeID: 7, LeaveTypeID: 2, Year: 2026, QuotaTotal: 15, QuotaLeft: 15, LeaveType: &master.LeaveType{ID: 2, Name: "Sick"}},
+		{ID: 3, CompanyID: 1, EmployeeID: 7, LeaveTypeID: 3, Year: 2026, QuotaTotal: 3, QuotaUsed: 3, LeaveType: &master.LeaveType{ID: 3, Name: "Marriage", IsDeducted: true}},
+	}
+}
+
+func TestService_GetUnusedLeave(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, repo, _, _, _, _, _, _ := newTestLeaveService()
+
+	repo.On("FindBalancesByEmployee", mock.Anything, uint(7), 2026).Return(settlementBalances(), nil)
+
+	unused, err := svc.GetUnusedLeave(ctx, 7, 2026)
+	require.NoError(t, err)
+	assert.Equal(t, []UnusedLeave{{LeaveTypeID: 1, LeaveTypeName: "Annual", Days: 4.5}}, unused)
+}
+
+func TestService_PayOutUnusedLeave(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("closes the payable balances", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _ := newTestLeaveService()
+		repo.On("FindBalancesByEmployee", mock.Anything, uint(7), 2026).Return(settlementBalances(), nil)
+		repo.On("SaveBalance", mock.Anything, mock.MatchedBy(func(b *LeaveBalance) bool {
+			return b.ID == 1 && b.QuotaLeft == 0 && b.QuotaTotal == 7.5
+		})).Return(nil).Once()
+		repo.On("CreateLedgers", mock.Anything, mock.MatchedBy(func(entries []LeaveLedger) bool {
+			return len(entries) == 1 && entries[0].Type == constants.LeaveLedgerPayout &&
+				entries[0].Days == -4.5 && *entries[0].ReferenceID == 3
+		})).Return(nil)
+
+		require.NoError(t, svc.PayOutUnusedLeave(ctx, 7, 2026, 3))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("nothing to pay out", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _ := newTestLeaveService()
+		repo.On("FindBalancesByEmployee", mock.Anything, uint(7), 2026).Return([]LeaveBalance{}, nil)
+
+		require.NoError(t, svc.PayOutUnusedLeave(ctx, 7, 2026, 3))
+		repo.AssertNotCalled(t, "CreateLedgers", mock.Anything, mock.Anything)
+	})
+}
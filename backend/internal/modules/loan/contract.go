@@ -1,6 +1,10 @@
 package loan
 
-import "context"
+import (
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/user"
+	"context"
+)
 
 type NotificationProvider interface {
 	SendNotification(ctx context.Context, userID uint,
@@ -15,6 +19,11 @@ type NotificationProvider interface {
 		relatedID uint) error
 }
 
+type ApprovalEngine interface {
+	Submit(ctx context.Context, req *approval.SubmitRequest) ([]uint, error)
+	Decide(ctx context.Context, req *approval.DecideRequest) (*approval.Decision, error)
+}
+
 type UserProvider interface {
-	FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error)
+	FindEmployeeByID(ctx context.Context, id uint) (*user.Employee, error)
 }
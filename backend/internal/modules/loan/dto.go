@@ -13,11 +13,19 @@ type LoanFilter struct {
 }
 
 type LoanRequest struct {
-	UserID            uint    `json:"-"`
-	EmployeeID        uint    `json:"-"`
-	TotalAmount       float64 `json:"total_amount" validate:"required"`
-	InstallmentAmount float64 `json:"installment_amount" validate:"required"`
-	Reason            string  `json:"reason" validate:"required"`
+	UserID      uint    `json:"-"`
+	EmployeeID  uint    `json:"-"`
+	ProductID   uint    `json:"product_id" validate:"required"`
+	TotalAmount float64 `json:"total_amount" validate:"required,gt=0"`
+	TenorMonths int     `json:"tenor_months" validate:"required,min=1"`
+	Reason      string  `json:"reason" validate:"required"`
+}
+
+type LoanSimulationRequest struct {
+	EmployeeID  uint    `json:"-"`
+	ProductID   uint    `json:"product_id" validate:"required"`
+	TotalAmount float64 `json:"total_amount" validate:"required,gt=0"`
+	TenorMonths int     `json:"tenor_months" validate:"required,min=1"`
 }
 
 type ActionRequest struct {
@@ -47,8 +55,164 @@ type LoanDetailResponse struct {
 	TotalAmount       float64              `json:"total_amount"`
 	InstallmentAmount float64              `json:"installment_amount"`
 	RemainingAmount   float64              `json:"remaining_amount"`
+	ProductID         *uint                `json:"product_id"`
+	ProductName       string               `json:"product_name"`
+	TenorMonths       int                  `json:"tenor_months"`
+	InterestAmount    float64              `json:"interest_amount"`
+	AdminFee          float64              `json:"admin_fee"`
 	Reason            string               `json:"reason"`
 	Status            constants.LoanStatus `json:"status"`
 	RejectionReason   string               `json:"rejection_reason"`
 	CreatedAt         time.Time            `json:"created_at"`
+
+	Installments []LoanInstallmentResponse `json:"installments"`
+}
+
+type SaveLoanProductRequest struct {
+	ID                    uint    `json:"-"`
+	Name                  string  `json:"name" validate:"required,max=100"`
+	MaxSalaryMultiplier   float64 `json:"max_salary_multiplier" validate:"gt=0,lte=100"`
+	MaxTenorMonths        int     `json:"max_tenor_months" validate:"min=1,max=360"`
+	MaxInstallmentPercent float64 `json:"max_installment_percent" validate:"gt=0,lte=100"`
+	InterestRate          float64 `json:"interest_rate" validate:"min=0,max=100"`
+	AdminFee              float64 `json:"admin_fee" validate:"min=0"`
+	MinTenureMonths       int     `json:"min_tenure_months" validate:"min=0"`
+	IsActive              bool    `json:"is_active"`
+}
+
+type LoanProductResponse struct {
+	ID                    uint    `json:"id"`
+	Name                  string  `json:"name"`
+	MaxSalaryMultiplier   float64 `json:"max_salary_multiplier"`
+	MaxTenorMonths        int     `json:"max_tenor_months"`
+	MaxInstallmentPercent float64 `json:"max_installment_percent"`
+	InterestRate          float64 `json:"interest_rate"`
+	AdminFee              float64 `json:"admin_fee"`
+	MinTenureMonths       int     `json:"min_tenure_months"`
+	IsActive              bool    `json:"is_active"`
+}
+
+type LoanInstallmentResponse struct {
+	ID              uint                            `json:"id"`
+	Sequence        int                             `json:"sequence"`
+	DueDate         string                          `json:"due_date"`
+	PrincipalAmount float64                         `json:"principal_amount"`
+	InterestAmount  float64                         `json:"interest_amount"`
+	Amount          float64                         `json:"amount"`
+	Status          constants.LoanInstallmentStatus `json:"status"`
+	PaidAt          *time.Time                      `json:"paid_at"`
+	Notes           string                          `json:"notes"`
+}
+
+// LoanSimulationResponse is the offer for a loan request, the schedule starts the month after approval.
+type LoanSimulationResponse struct {
+	ProductID         uint                      `json:"product_id"`
+	TotalAmount       float64                   `json:"total_amount"`
+	TenorMonths       int                       `json:"tenor_months"`
+	InterestAmount    float64                   `json:"interest_amount"`
+	AdminFee          float64                   `json:"admin_fee"`
+	DisbursedAmount   float64                   `json:"disbursed_amount"`
+	TotalPayable      float64                   `json:"total_payable"`
+	InstallmentAmount float64                   `json:"installment_amount"`
+	MaxAmount         float64                   `json:"max_amount"`
+	MaxInstallment    float64                   `json:"max_installment"`
+	Schedule          []LoanInstallmentResponse `json:"schedule"`
+}
+
+type DeferInstallmentRequest struct {
+	LoanID        uint   `json:"-"`
+	InstallmentID uint   `json:"-"`
+	Reason        string `json:"reason" validate:"required,max=255"`
+}
+
+type LoanPayoffResponse struct {
+	LoanID         uint    `json:"loan_id"`
+	PayoffAmount   float64 `json:"payoff_amount"`
+	WaivedInterest float64 `json:"waived_interest"`
+}
+
+// InstallmentPayment is an installment payroll deducted, with the payroll detail it was deducted in.
+type InstallmentPayment struct {
+	InstallmentID   uint
+	PayrollDetailID uint
+	Amount          float64
+}
+
+type RepaymentRequest struct {
+	LoanID         uint   `json:"-"`
+	ActorID        uint   `json:"-"`
+	InstallmentIDs []uint `json:"installment_ids" validate:"required,min=1"`
+	Notes          string `json:"notes" validate:"max=255"`
+}
+
+type WriteOffRequest struct {
+	LoanID  uint   `json:"-"`
+	ActorID uint   `json:"-"`
+	Reason  string `json:"reason" validate:"required,max=255"`
+}
+
+type LoanStatementEntry struct {
+	ID              uint                          `json:"id"`
+	TransactionDate string                        `json:"transaction_date"`
+	Type            constants.LoanTransactionType `json:"type"`
+	Amount          float64                       `json:"amount"`
+	Balance         float64                       `json:"balance"`
+	InstallmentID   *uint                         `json:"installment_id"`
+	PayrollDetailID *uint                         `json:"payroll_detail_id"`
+	Notes           string                        `json:"notes"`
+}
+
+type LoanStatementResponse struct {
+	LoanID       uint                 `json:"loan_id"`
+	EmployeeName string               `json:"employee_name"`
+	EmployeeNIK  string               `json:"employee_nik"`
+	Status       constants.LoanStatus `json:"status"`
+	TotalPayable float64              `json:"total_payable"`
+	TotalPaid    float64              `json:"total_paid"`
+	WrittenOff   float64              `json:"written_off"`
+	Balance      float64              `json:"balance"`
+	Entries      []LoanStatementEntry `json:"entries"`
+}
+
+type ReconciliationFilter struct {
+	Status       string
+	MismatchOnly bool
+}
+
+// LoanReconciliationResponse compares the balance stored on the loan, its ledger and its pending installments.
+type LoanReconciliationResponse struct {
+	LoanID           uint                 `json:"loan_id"`
+	EmployeeName     string               `json:"employee_name"`
+	EmployeeNIK      string               `json:"employee_nik"`
+	Status           constants.LoanStatus `json:"status"`
+	RecordedBalance  float64              `json:"recorded_balance"`
+	LedgerBalance    float64              `json:"ledger_balance"`
+	ScheduledBalance float64              `json:"scheduled_balance"`
+	IsMatched        bool                 `json:"is_matched"`
+	Issues           []string             `json:"issues"`
+}
+
+// UnpostedDeduction is a loan deduction of a paid payroll missing from the ledger or posted with another amount.
+type UnpostedDeduction struct {
+	PayrollDetailID uint
+	LoanID          uint
+	PeriodDate      time.Time
+	DeductedAmount  float64
+	PostedAmount    *float64
+}
+
+// OutstandingLoan is an approved loan with the balance left on its ledger.
+type OutstandingLoan struct {
+	LoanID      uint    `json:"loan_id"`
+	ProductName string  `json:"product_name"`
+	Reason      string  `json:"reason"`
+	Balance     float64 `json:"balance"`
+}
+
+// LoanSettlementRequest repays a loan from the final settlement of a leaving employee.
+type LoanSettlementRequest struct {
+	LoanID  uint
+	ActorID uint
+	Amount  float64
+	Notes   string
 }
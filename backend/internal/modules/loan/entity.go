@@ -19,18 +19,99 @@ type Loan struct {
 	EmployeeID uint          `gorm:"not null" json:"employee_id"`
 	Employee   user.Employee `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
 
+	ProductID *uint        `gorm:"index" json:"product_id"`
+	Product   *LoanProduct `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+
 	ApprovedBy *uint      `json:"approved_by"`
 	Approver   *user.User `gorm:"foreignKey:ApprovedBy" json:"approver,omitempty"`
 
 	TotalAmount       float64 `gorm:"type:decimal(15,2);not null" json:"total_amount"`
 	InstallmentAmount float64 `gorm:"type:decimal(15,2);not null" json:"installment_amount"`
 	RemainingAmount   float64 `gorm:"type:decimal(15,2);not null" json:"remaining_amount"`
+	TenorMonths       int     `gorm:"not null;default:0" json:"tenor_months"`
+	InterestAmount    float64 `gorm:"type:decimal(15,2);not null;default:0" json:"interest_amount"`
+	AdminFee          float64 `gorm:"type:decimal(15,2);not null;default:0" json:"admin_fee"`
 	Reason            string  `gorm:"type:text" json:"reason"`
 
-	Status          constants.LoanStatus `gorm:"type:enum('PENDING','APPROVED','REJECTED','PAID_OFF');default:'PENDING'" json:"status"`
+	Status          constants.LoanStatus `gorm:"type:enum('PENDING','APPROVED','REJECTED','PAID_OFF','WRITTEN_OFF');default:'PENDING'" json:"status"`
 	RejectionReason sql.NullString       `gorm:"type:text" json:"rejection_reason"`
+
+	Installments []LoanInstallment `gorm:"foreignKey:LoanID" json:"installments,omitempty"`
 }
 
 func (Loan) TableName() string {
 	return "loans"
 }
+
+// LoanProduct holds the limits a company sets for one kind of employee loan.
+type LoanProduct struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	CompanyID uint   `gorm:"index;not null" json:"company_id"`
+	Name      string `gorm:"size:100;not null" json:"name"`
+	// maximum principal as a multiple of the base salary
+	MaxSalaryMultiplier float64 `gorm:"type:decimal(5,2);not null" json:"max_salary_multiplier"`
+	MaxTenorMonths      int     `gorm:"not null" json:"max_tenor_months"`
+	// all monthly installments together may not exceed this share of the net pay
+	MaxInstallmentPercent float64 `gorm:"type:decimal(5,2);not null" json:"max_installment_percent"`
+	// flat yearly interest on the principal
+	InterestRate    float64   `gorm:"type:decimal(5,2);not null" json:"interest_rate"`
+	AdminFee        float64   `gorm:"type:decimal(15,2);not null" json:"admin_fee"`
+	MinTenureMonths int       `gorm:"not null" json:"min_tenure_months"`
+	IsActive        bool      `gorm:"not null" json:"is_active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (LoanProduct) TableName() string {
+	return "loan_products"
+}
+
+type LoanInstallment struct {
+	ID        uint `gorm:"primaryKey" json:"id"`
+	CompanyID uint `gorm:"index;not null" json:"company_id"`
+	LoanID    uint `gorm:"index;not null" json:"loan_id"`
+	Sequence  int  `gorm:"not null" json:"sequence"`
+	// first day of the payroll month the installment is deducted in
+	DueDate         time.Time                       `gorm:"type:date;not null" json:"due_date"`
+	PrincipalAmount float64                         `gorm:"type:decimal(15,2);not null" json:"principal_amount"`
+	InterestAmount  float64                         `gorm:"type:decimal(15,2);not null" json:"interest_amount"`
+	Amount          float64                         `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Status          constants.LoanInstallmentStatus `gorm:"type:varchar(20);not null" json:"status"`
+	PaidAt          *time.Time                      `json:"paid_at"`
+	Notes           string                          `gorm:"size:255" json:"notes"`
+	CreatedAt       time.Time                       `json:"created_at"`
+	UpdatedAt       time.Time                       `json:"updated_at"`
+}
+
+func (LoanInstallment) TableName() string {
+	return "loan_installments"
+}
+
+// LoanTransaction is one movement of a loan balance, the balance of a loan is the sum of its ledger.
+type LoanTransaction struct {
+	ID        uint                          `gorm:"primaryKey" json:"id"`
+	CompanyID uint                          `gorm:"index;not null" json:"company_id"`
+	LoanID    uint                          `gorm:"index;not null" json:"loan_id"`
+	Type      constants.LoanTransactionType `gorm:"type:varchar(20);not null" json:"type"`
+	// always positive, the type tells whether it raises or lowers the balance
+	Amount        float64 `gorm:"type:decimal(15,2);not null" json:"amount"`
+	InstallmentID *uint   `json:"installment_id"`
+	// the payroll deduction an installment was paid with
+	PayrollDetailID *uint     `gorm:"uniqueIndex" json:"payroll_detail_id"`
+	TransactionDate time.Time `gorm:"type:date;not null" json:"transaction_date"`
+	Notes           string    `gorm:"size:255" json:"notes"`
+	CreatedBy       *uint     `json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (LoanTransaction) TableName() string {
+	return "loan_transactions"
+}
+
+// signedAmount is the effect of the transaction on the balance.
+func (t *LoanTransaction) signedAmount() float64 {
+	if t.Type == constants.LoanTransactionDisbursement {
+		return t.Amount
+	}
+	return -t.Amount
+}
@@ -159,3 +159,224 @@ func (h *Handler) Export(ctx echo.Context) error {
 	ctx.Response().Header().Set("Content-Disposition", "attachment; filename=loans.xlsx")
 	return ctx.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelFile)
 }
+
+func (h *Handler) Simulate(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req LoanSimulationRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.EmployeeID = *userContext.EmployeeID
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	data, err := h.service.Simulate(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("simulate loan failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Simulate Loan Success", data, nil, nil)
+}
+
+func (h *Handler) DeferInstallment(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	installmentID, err := strconv.Atoi(ctx.Param("installmentId"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid installment id", nil, err, nil)
+	}
+
+	var req DeferInstallmentRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.LoanID = uint(id)
+	req.InstallmentID = uint(installmentID)
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	err = h.service.DeferInstallment(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("defer loan installment failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Defer Loan Installment Success", nil, nil, nil)
+}
+
+func (h *Handler) EarlyPayoff(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	data, err := h.service.EarlyPayoff(ctx.Request().Context(), uint(id))
+	if err != nil {
+		logger.Errorw("loan early payoff failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Loan Early Payoff Success", data, nil, nil)
+}
+
+func (h *Handler) GetProducts(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	// inactive products are only listed to the ones managing them
+	activeOnly := !slices.Contains(userContext.Permissions, constants.MANAGE_LOAN_PRODUCT)
+
+	data, err := h.service.GetProducts(ctx.Request().Context(), activeOnly)
+	if err != nil {
+		logger.Errorw("get loan products failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Loan Products Success", data, nil, nil)
+}
+
+func (h *Handler) SaveProduct(ctx echo.Context) error {
+	var req SaveLoanProductRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if ctx.Param("id") != "" {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+		}
+		req.ID = uint(id)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	err := h.service.SaveProduct(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("save loan product failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Save Loan Product Success", nil, nil, nil)
+}
+
+func (h *Handler) GetStatement(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	data, err := h.service.GetStatement(ctx.Request().Context(), uint(id))
+	if err != nil {
+		logger.Errorw("get loan statement failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Loan Statement Success", data, nil, nil)
+}
+
+func (h *Handler) Repay(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req RepaymentRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.LoanID = uint(id)
+	req.ActorID = userContext.UserID
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	err = h.service.Repay(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("loan repayment failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Loan Repayment Success", nil, nil, nil)
+}
+
+func (h *Handler) WriteOff(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req WriteOffRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.LoanID = uint(id)
+	req.ActorID = userContext.UserID
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	err = h.service.WriteOff(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("loan write off failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Loan Write Off Success", nil, nil, nil)
+}
+
+func (h *Handler) GetReconciliation(ctx echo.Context) error {
+	filter := ReconciliationFilter{
+		Status:       ctx.QueryParam("status"),
+		MismatchOnly: ctx.QueryParam("mismatch_only") == "true",
+	}
+
+	data, err := h.service.GetReconciliation(ctx.Request().Context(), filter)
+	if err != nil {
+		logger.Errorw("get loan reconciliation failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Loan Reconciliation Success", data, nil, nil)
+}
@@ -29,9 +29,10 @@ func TestHandler_Create(t *testing.T) {
 		{
 			name: "success",
 			body: LoanRequest{
-				TotalAmount:       5000000,
-				InstallmentAmount: 500000,
-				Reason:            "Emergency",
+				ProductID:   3,
+				TotalAmount: 5000000,
+				TenorMonths: 10,
+				Reason:      "Emergency",
 			},
 			setupMocks: func(svc *mockService) {
 				svc.On("Create", mock.Anything, mock.AnythingOfType("*loan.LoanRequest")).Return(nil)
@@ -49,9 +50,10 @@ func TestHandler_Create(t *testing.T) {
 		{
 			name: "service error",
 			body: LoanRequest{
-				TotalAmount:       5000000,
-				InstallmentAmount: 500000,
-				Reason:            "Emergency",
+				ProductID:   3,
+				TotalAmount: 5000000,
+				TenorMonths: 10,
+				Reason:      "Emergency",
 			},
 			setupMocks: func(svc *mockService) {
 				svc.On("Create", mock.Anything, mock.AnythingOfType("*loan.LoanRequest")).Return(errors.New("users still have loan"))
@@ -440,3 +442,430 @@ func TestHandler_Export(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_Simulate(t *testing.T) {
+	employeeID := uint(1)
+
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: LoanSimulationRequest{ProductID: 3, TotalAmount: 5000000, TenorMonths: 10},
+			setupMocks: func(svc *mockService) {
+				svc.On("Simulate", mock.Anything, mock.MatchedBy(func(req *LoanSimulationRequest) bool {
+					return req.EmployeeID == 1 && req.ProductID == 3
+				})).Return(&LoanSimulationResponse{InstallmentAmount: 550000}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "over the limit",
+			body: LoanSimulationRequest{ProductID: 3, TotalAmount: 50000000, TenorMonths: 10},
+			setupMocks: func(svc *mockService) {
+				svc.On("Simulate", mock.Anything, mock.Anything).Return(nil, errors.New("cannot exceed maximum loan amount of Rp.10000000"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "validation error missing tenor",
+			body:       LoanSimulationRequest{ProductID: 3, TotalAmount: 5000000},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/loans/simulate", tt.body)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:      1,
+				CompanyID:   1,
+				EmployeeID:  &employeeID,
+				Permissions: []string{constants.CREATE_LOAN},
+			})
+
+			rec, err := at.Execute(handler.Simulate)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_DeferInstallment(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathParams map[string]string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			pathParams: map[string]string{"id": "1", "installmentId": "4"},
+			body:       map[string]string{"reason": "Biaya rumah sakit"},
+			setupMocks: func(svc *mockService) {
+				svc.On("DeferInstallment", mock.Anything, &DeferInstallmentRequest{LoanID: 1, InstallmentID: 4, Reason: "Biaya rumah sakit"}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "service error",
+			pathParams: map[string]string{"id": "1", "installmentId": "4"},
+			body:       map[string]string{"reason": "Biaya rumah sakit"},
+			setupMocks: func(svc *mockService) {
+				svc.On("DeferInstallment", mock.Anything, mock.Anything).Return(errors.New("cannot defer installment with status PAID"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing reason",
+			pathParams: map[string]string{"id": "1", "installmentId": "4"},
+			body:       map[string]string{},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid installment id",
+			pathParams: map[string]string{"id": "1", "installmentId": "abc"},
+			body:       map[string]string{"reason": "Biaya rumah sakit"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPut, "/api/loans/:id/installments/:installmentId/defer", tt.body)
+			at.WithPathParams(tt.pathParams)
+
+			rec, err := at.Execute(handler.DeferInstallment)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_EarlyPayoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathParams map[string]string
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			pathParams: map[string]string{"id": "1"},
+			setupMocks: func(svc *mockService) {
+				svc.On("EarlyPayoff", mock.Anything, uint(1)).Return(&LoanPayoffResponse{LoanID: 1, PayoffAmount: 3000000}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "service error",
+			pathParams: map[string]string{"id": "1"},
+			setupMocks: func(svc *mockService) {
+				svc.On("EarlyPayoff", mock.Anything, uint(1)).Return(nil, errors.New("cannot pay off loan with status PAID_OFF"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid id",
+			pathParams: map[string]string{"id": "abc"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/loans/:id/payoff", nil)
+			at.WithPathParams(tt.pathParams)
+
+			rec, err := at.Execute(handler.EarlyPayoff)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_GetProducts(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		activeOnly  bool
+	}{
+		{name: "employee sees active products", permissions: []string{constants.CREATE_LOAN}, activeOnly: true},
+		{name: "manager sees every product", permissions: []string{constants.MANAGE_LOAN_PRODUCT}, activeOnly: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			svc.On("GetProducts", mock.Anything, tt.activeOnly).Return([]LoanProductResponse{{ID: 3, Name: "Kasbon"}}, nil)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/loans/products", nil)
+			at.WithAuthContext(&infrastructure.MyClaims{UserID: 1, CompanyID: 1, Permissions: tt.permissions})
+
+			rec, err := at.Execute(handler.GetProducts)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_SaveProduct(t *testing.T) {
+	validBody := SaveLoanProductRequest{
+		Name: "Kasbon", MaxSalaryMultiplier: 2, MaxTenorMonths: 12, MaxInstallmentPercent: 30, IsActive: true,
+	}
+
+	tests := []struct {
+		name       string
+		pathParams map[string]string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "create",
+			body: validBody,
+			setupMocks: func(svc *mockService) {
+				svc.On("SaveProduct", mock.Anything, mock.MatchedBy(func(req *SaveLoanProductRequest) bool {
+					return req.ID == 0 && req.Name == "Kasbon"
+				})).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "update",
+			pathParams: map[string]string{"id": "3"},
+			body:       validBody,
+			setupMocks: func(svc *mockService) {
+				svc.On("SaveProduct", mock.Anything, mock.MatchedBy(func(req *SaveLoanProductRequest) bool {
+					return req.ID == 3
+				})).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "validation error zero tenor",
+			body:       SaveLoanProductRequest{Name: "Kasbon", MaxSalaryMultiplier: 2, MaxInstallmentPercent: 30},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: validBody,
+			setupMocks: func(svc *mockService) {
+				svc.On("SaveProduct", mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/loans/products", tt.body)
+			if tt.pathParams != nil {
+				at.WithPathParams(tt.pathParams)
+			}
+
+			rec, err := at.Execute(handler.SaveProduct)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_GetStatement(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathParams map[string]string
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			pathParams: map[string]string{"id": "1"},
+			setupMocks: func(svc *mockService) {
+				svc.On("GetStatement", mock.Anything, uint(1)).Return(&LoanStatementResponse{LoanID: 1, Balance: 500000}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "not found",
+			pathParams: map[string]string{"id": "99"},
+			setupMocks: func(svc *mockService) {
+				svc.On("GetStatement", mock.Anything, uint(99)).Return(nil, errors.New("record not found"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid id",
+			pathParams: map[string]string{"id": "abc"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/loans/:id/statement", nil)
+			at.WithPathParams(tt.pathParams)
+
+			rec, err := at.Execute(handler.GetStatement)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_Repay(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: map[string]interface{}{"installment_ids": []uint{2, 3}, "notes": "Transfer"},
+			setupMocks: func(svc *mockService) {
+				svc.On("Repay", mock.Anything, &RepaymentRequest{LoanID: 1, ActorID: 10, InstallmentIDs: []uint{2, 3}, Notes: "Transfer"}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "service error",
+			body: map[string]interface{}{"installment_ids": []uint{1}},
+			setupMocks: func(svc *mockService) {
+				svc.On("Repay", mock.Anything, mock.Anything).Return(errors.New("cannot repay installment 1 with status PAID"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing installments",
+			body:       map[string]interface{}{"notes": "Transfer"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/loans/:id/repayments", tt.body)
+			at.WithPathParams(map[string]string{"id": "1"})
+			at.WithAuthContext(&infrastructure.MyClaims{UserID: 10, CompanyID: 1, Permissions: []string{constants.APPROVAL_LOAN}})
+
+			rec, err := at.Execute(handler.Repay)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_WriteOff(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: map[string]string{"reason": "Resigned"},
+			setupMocks: func(svc *mockService) {
+				svc.On("WriteOff", mock.Anything, &WriteOffRequest{LoanID: 1, ActorID: 10, Reason: "Resigned"}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "service error",
+			body: map[string]string{"reason": "Resigned"},
+			setupMocks: func(svc *mockService) {
+				svc.On("WriteOff", mock.Anything, mock.Anything).Return(errors.New("loan has no outstanding balance"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing reason",
+			body:       map[string]string{},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/loans/:id/write-off", tt.body)
+			at.WithPathParams(map[string]string{"id": "1"})
+			at.WithAuthContext(&infrastructure.MyClaims{UserID: 10, CompanyID: 1, Permissions: []string{constants.APPROVAL_LOAN}})
+
+			rec, err := at.Execute(handler.WriteOff)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_GetReconciliation(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		filter     ReconciliationFilter
+		err        error
+		wantStatus int
+	}{
+		{name: "success", path: "/api/loans/reconciliation", filter: ReconciliationFilter{}, wantStatus: http.StatusOK},
+		{name: "mismatches only", path: "/api/loans/reconciliation?mismatch_only=true&status=APPROVED", filter: ReconciliationFilter{Status: "APPROVED", MismatchOnly: true}, wantStatus: http.StatusOK},
+		{name: "service error", path: "/api/loans/reconciliation", filter: ReconciliationFilter{}, err: errors.New("db error"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			svc.On("GetReconciliation", mock.Anything, tt.filter).Return([]LoanReconciliationResponse{}, tt.err)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, tt.path, nil)
+
+			rec, err := at.Execute(handler.GetReconciliation)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
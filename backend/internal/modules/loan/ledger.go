@@ -0,0 +1,361 @@
+package loan
+
+import (
+	"basekarya-backend/pkg/constants"
+	"context"
+	"fmt"
+	"math"
+)
+
+// GetStatement lists the ledger of a loan with the balance after every transaction.
+func (s *service) GetStatement(ctx context.Context, id uint) (*LoanStatementResponse, error) {
+	data, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.repo.FindTransactions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &LoanStatementResponse{
+		LoanID:       data.ID,
+		EmployeeName: data.Employee.FullName,
+		EmployeeNIK:  data.Employee.NIK,
+		Status:       data.Status,
+		Entries:      make([]LoanStatementEntry, 0, len(transactions)),
+	}
+
+	for _, t := range transactions {
+		statement.Balance += t.signedAmount()
+
+		switch t.Type {
+		case constants.LoanTransactionDisbursement:
+			statement.TotalPayable += t.Amount
+		case constants.LoanTransactionWriteOff:
+			statement.WrittenOff += t.Amount
+		default:
+			statement.TotalPaid += t.Amount
+		}
+
+		statement.Entries = append(statement.Entries, LoanStatementEntry{
+			ID:              t.ID,
+			TransactionDate: t.TransactionDate.Format(constants.DefaultTimeFormat),
+			Type:            t.Type,
+			Amount:          t.Amount,
+			Balance:         statement.Balance,
+			InstallmentID:   t.InstallmentID,
+			PayrollDetailID: t.PayrollDetailID,
+			Notes:           t.Notes,
+		})
+	}
+
+	return statement, nil
+}
+
+// Repay records installments the employee paid outside payroll.
+func (s *service) Repay(ctx context.Context, req *RepaymentRequest) error {
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		data, err := s.repo.FindByID(ctx, req.LoanID)
+		if err != nil {
+			return err
+		}
+
+		if data.Status != constants.LoanStatusApproved {
+			return fmt.Errorf("cannot repay loan with status %s", data.Status)
+		}
+
+		installmentMap := make(map[uint]LoanInstallment, len(data.Installments))
+		for _, inst := range data.Installments {
+			installmentMap[inst.ID] = inst
+		}
+
+		now := timeNow()
+		var (
+			paid         []LoanInstallment
+			transactions []LoanTransaction
+		)
+		for _, id := range req.InstallmentIDs {
+			inst, ok := installmentMap[id]
+			if !ok {
+				return fmt.Errorf("installment %d not found", id)
+			}
+			// an installment listed twice is refused instead of paid twice
+			delete(installmentMap, id)
+
+			if inst.Status != constants.LoanInstallmentPending {
+				return fmt.Errorf("cannot repay installment %d with status %s", inst.Sequence, inst.Status)
+			}
+
+			inst.Status = constants.LoanInstallmentPaid
+			inst.PaidAt = &now
+			inst.Notes = req.Notes
+			paid = append(paid, inst)
+
+			transactions = append(transactions, LoanTransaction{
+				CompanyID:       data.CompanyID,
+				LoanID:          data.ID,
+				Type:            constants.LoanTransactionRepayment,
+				Amount:          inst.Amount,
+				InstallmentID:   &inst.ID,
+				TransactionDate: now,
+				Notes:           req.Notes,
+				CreatedBy:       &req.ActorID,
+			})
+		}
+
+		if err := s.repo.SaveInstallments(ctx, paid); err != nil {
+			return err
+		}
+
+		return s.repo.PostTransactions(ctx, transactions)
+	})
+}
+
+// WriteOff forgives the remaining balance of a loan and closes its pending installments.
+func (s *service) WriteOff(ctx context.Context, req *WriteOffRequest) error {
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		data, err := s.repo.FindByID(ctx, req.LoanID)
+		if err != nil {
+			return err
+		}
+
+		if data.Status != constants.LoanStatusApproved {
+			return fmt.Errorf("cannot write off loan with status %s", data.Status)
+		}
+
+		balances, err := s.repo.GetLedgerBalances(ctx, []uint{data.ID})
+		if err != nil {
+			return err
+		}
+
+		balance := balances[data.ID]
+		if balance <= 0 {
+			return fmt.Errorf("loan has no outstanding balance")
+		}
+
+		var closed []LoanInstallment
+		for _, inst := range data.Installments {
+			if inst.Status != constants.LoanInstallmentPending {
+				continue
+			}
+			inst.Status = constants.LoanInstallmentWrittenOff
+			inst.Notes = req.Reason
+			closed = append(closed, inst)
+		}
+
+		if err := s.repo.SaveInstallments(ctx, closed); err != nil {
+			return err
+		}
+
+		err = s.repo.PostTransactions(ctx, []LoanTransaction{{
+			CompanyID:       data.CompanyID,
+			LoanID:          data.ID,
+			Type:            constants.LoanTransactionWriteOff,
+			Amount:          balance,
+			TransactionDate: timeNow(),
+			Notes:           req.Reason,
+			CreatedBy:       &req.ActorID,
+		}})
+		if err != nil {
+			return err
+		}
+
+		data.RemainingAmount = 0
+		data.Status = constants.LoanStatusWrittenOff
+		return s.repo.Update(ctx, data)
+	})
+}
+
+// GetReconciliation checks every loan's stored balance and pending installments against its ledger, and the
+// loan deductions of paid payrolls against their ledger transactions.
+func (s *service) GetReconciliation(ctx context.Context, filter ReconciliationFilter) ([]LoanReconciliationResponse, error) {
+	loans, err := s.repo.FindLedgerLoans(ctx, filter.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]LoanReconciliationResponse, 0, len(loans))
+	if len(loans) == 0 {
+		return list, nil
+	}
+
+	loanIDs := make([]uint, 0, len(loans))
+	for _, l := range loans {
+		loanIDs = append(loanIDs, l.ID)
+	}
+
+	balances, err := s.repo.GetLedgerBalances(ctx, loanIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	deductions, err := s.repo.FindUnpostedDeductions(ctx, loanIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	deductionMap := make(map[uint][]UnpostedDeduction)
+	for _, d := range deductions {
+		deductionMap[d.LoanID] = append(deductionMap[d.LoanID], d)
+	}
+
+	for _, l := range loans {
+		row := LoanReconciliationResponse{
+			LoanID:          l.ID,
+			EmployeeName:    l.Employee.FullName,
+			EmployeeNIK:     l.Employee.NIK,
+			Status:          l.Status,
+			RecordedBalance: l.RemainingAmount,
+			Issues:          []string{},
+		}
+
+		ledgerBalance, hasLedger := balances[l.ID]
+		row.LedgerBalance = ledgerBalance
+
+		for _, inst := range l.Installments {
+			if inst.Status == constants.LoanInstallmentPending {
+				row.ScheduledBalance += inst.Amount
+			}
+		}
+
+		if !hasLedger {
+			row.Issues = append(row.Issues, "loan has no ledger transactions")
+		}
+		if ledgerBalance < 0 {
+			row.Issues = append(row.Issues, fmt.Sprintf("overpaid by Rp.%.0f", -ledgerBalance))
+		}
+		if !amountsMatch(row.RecordedBalance, ledgerBalance) {
+			row.Issues = append(row.Issues, fmt.Sprintf("remaining amount Rp.%.0f differs from ledger balance Rp.%.0f", row.RecordedBalance, ledgerBalance))
+		}
+		if !amountsMatch(row.ScheduledBalance, ledgerBalance) {
+			row.Issues = append(row.Issues, fmt.Sprintf("pending installments Rp.%.0f differ from ledger balance Rp.%.0f", row.ScheduledBalance, ledgerBalance))
+		}
+
+		for _, d := range deductionMap[l.ID] {
+			period := d.PeriodDate.Format(constants.PayrollTimeFormat)
+			if d.PostedAmount == nil {
+				row.Issues = append(row.Issues, fmt.Sprintf("payroll deduction #%d of %s (Rp.%.0f) is not in the ledger", d.PayrollDetailID, period, d.DeductedAmount))
+			} else {
+				row.Issues = append(row.Issues, fmt.Sprintf("payroll deduction #%d of %s deducted Rp.%.0f but the ledger holds Rp.%.0f", d.PayrollDetailID, period, d.DeductedAmount, *d.PostedAmount))
+			}
+		}
+
+		row.IsMatched = len(row.Issues) == 0
+		if filter.MismatchOnly && row.IsMatched {
+			continue
+		}
+		list = append(list, row)
+	}
+
+	return list, nil
+}
+
+// GetOutstandingLoans lists the approved loans of an employee with the balance left on their ledger.
+func (s *service) GetOutstandingLoans(ctx context.Context, employeeID uint) ([]OutstandingLoan, error) {
+	loans, err := s.repo.FindApprovedByEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]OutstandingLoan, 0, len(loans))
+	if len(loans) == 0 {
+		return list, nil
+	}
+
+	loanIDs := make([]uint, 0, len(loans))
+	for _, l := range loans {
+		loanIDs = append(loanIDs, l.ID)
+	}
+
+	balances, err := s.repo.GetLedgerBalances(ctx, loanIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range loans {
+		if balances[l.ID] <= 0 {
+			continue
+		}
+
+		item := OutstandingLoan{LoanID: l.ID, Reason: l.Reason, Balance: balances[l.ID]}
+		if l.Product != nil {
+			item.ProductName = l.Product.Name
+		}
+		list = append(list, item)
+	}
+
+	return list, nil
+}
+
+// Settle repays a loan from an employee's final settlement. Paying the whole balance closes the pending
+// installments, a partial payment leaves them for the rest to be collected or written off.
+func (s *service) Settle(ctx context.Context, req *LoanSettlementRequest) error {
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		data, err := s.repo.FindByID(ctx, req.LoanID)
+		if err != nil {
+			return err
+		}
+
+		if data.Status != constants.LoanStatusApproved {
+			return fmt.Errorf("cannot settle loan with status %s", data.Status)
+		}
+
+		balances, err := s.repo.GetLedgerBalances(ctx, []uint{data.ID})
+		if err != nil {
+			return err
+		}
+
+		balance := balances[data.ID]
+		if req.Amount <= 0 || req.Amount-balance >= 0.01 {
+			return fmt.Errorf("settlement amount must be between Rp.1 and the outstanding balance of Rp.%.0f", balance)
+		}
+
+		now := timeNow()
+		if amountsMatch(req.Amount, balance) {
+			var paid []LoanInstallment
+			for _, inst := range data.Installments {
+				if inst.Status != constants.LoanInstallmentPending {
+					continue
+				}
+				inst.Status = constants.LoanInstallmentPaid
+				inst.PaidAt = &now
+				inst.Notes = req.Notes
+				paid = append(paid, inst)
+			}
+
+			if err := s.repo.SaveInstallments(ctx, paid); err != nil {
+				return err
+			}
+		}
+
+		return s.repo.PostTransactions(ctx, []LoanTransaction{{
+			CompanyID:       data.CompanyID,
+			LoanID:          data.ID,
+			Type:            constants.LoanTransactionRepayment,
+			Amount:          req.Amount,
+			TransactionDate: now,
+			Notes:           req.Notes,
+			CreatedBy:       &req.ActorID,
+		}})
+	})
+}
+
+// amountsMatch compares amounts stored with two decimals.
+func amountsMatch(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+// disbursement is the ledger transaction of an approved loan, the employee owes the principal plus interest.
+func disbursement(data *Loan) LoanTransaction {
+	return LoanTransaction{
+		CompanyID:       data.CompanyID,
+		LoanID:          data.ID,
+		Type:            constants.LoanTransactionDisbursement,
+		Amount:          data.TotalAmount + data.InterestAmount,
+		TransactionDate: timeNow(),
+		Notes:           fmt.Sprintf("Principal Rp.%.0f, interest Rp.%.0f", data.TotalAmount, data.InterestAmount),
+		CreatedBy:       data.ApprovedBy,
+	}
+}
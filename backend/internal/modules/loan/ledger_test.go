@@ -0,0 +1,261 @@
+package loan
+
+import (
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ledgerLoan is an approved loan of 1.500.000 in three installments, the first deducted by payroll.
+func ledgerLoan() *Loan {
+	installments := buildSchedule(1, 1, 1500000, 0, 3, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+	for i := range installments {
+		installments[i].ID = uint(i + 1)
+	}
+	installments[0].Status = constants.LoanInstallmentPaid
+
+	return &Loan{
+		ID: 1, CompanyID: 1, Status: constants.LoanStatusApproved,
+		TotalAmount: 1500000, RemainingAmount: 1000000,
+		Employee:     user.Employee{FullName: "John Doe", NIK: "EMP001"},
+		Installments: installments,
+	}
+}
+
+func TestService_GetStatement(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, repo, _, _, _, _, _ := newTestLoanService()
+
+	detailID := uint(21)
+	repo.On("FindByID", mock.Anything, uint(1)).Return(ledgerLoan(), nil)
+	repo.On("FindTransactions", mock.Anything, uint(1)).Return([]LoanTransaction{
+		{ID: 1, Type: constants.LoanTransactionDisbursement, Amount: 1500000, TransactionDate: time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, Type: constants.LoanTransactionInstallment, Amount: 500000, PayrollDetailID: &detailID, TransactionDate: time.Date(2025, 7, 28, 0, 0, 0, 0, time.UTC)},
+		{ID: 3, Type: constants.LoanTransactionWriteOff, Amount: 100000, TransactionDate: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)},
+	}, nil)
+
+	statement, err := svc.GetStatement(ctx, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1500000.0, statement.TotalPayable)
+	assert.Equal(t, 500000.0, statement.TotalPaid)
+	assert.Equal(t, 100000.0, statement.WrittenOff)
+	assert.Equal(t, 900000.0, statement.Balance)
+	require.Len(t, statement.Entries, 3)
+	assert.Equal(t, 1000000.0, statement.Entries[1].Balance)
+	assert.Equal(t, &detailID, statement.Entries[1].PayrollDetailID)
+	assert.Equal(t, "2025-07-28", statement.Entries[1].TransactionDate)
+}
+
+func TestService_Repay(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	timeNow = func() time.Time { return time.Date(2025, 8, 5, 9, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	tests := []struct {
+		name       string
+		req        *RepaymentRequest
+		setupMocks func(*mockRepo)
+		errMsg     string
+	}{
+		{
+			name: "success",
+			req:  &RepaymentRequest{LoanID: 1, ActorID: 10, InstallmentIDs: []uint{2, 3}, Notes: "Transfer"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("SaveInstallments", mock.Anything, mock.MatchedBy(func(installments []LoanInstallment) bool {
+					return len(installments) == 2 && installments[0].Status == constants.LoanInstallmentPaid && installments[1].PaidAt != nil
+				})).Return(nil)
+				repo.On("PostTransactions", mock.Anything, mock.MatchedBy(func(transactions []LoanTransaction) bool {
+					return len(transactions) == 2 &&
+						transactions[0].Type == constants.LoanTransactionRepayment && transactions[0].Amount == 500000 &&
+						*transactions[0].InstallmentID == 2 && *transactions[1].InstallmentID == 3 && *transactions[1].CreatedBy == 10
+				})).Return(nil)
+			},
+		},
+		{
+			name:   "error installment already deducted",
+			req:    &RepaymentRequest{LoanID: 1, ActorID: 10, InstallmentIDs: []uint{1}},
+			errMsg: "cannot repay installment 1 with status PAID",
+		},
+		{
+			name:   "error installment listed twice",
+			req:    &RepaymentRequest{LoanID: 1, ActorID: 10, InstallmentIDs: []uint{2, 2}},
+			errMsg: "installment 2 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, _, _, _, _ := newTestLoanService()
+			repo.On("FindByID", mock.Anything, uint(1)).Return(ledgerLoan(), nil)
+			if tt.setupMocks != nil {
+				tt.setupMocks(repo)
+			}
+
+			err := svc.Repay(ctx, tt.req)
+
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				require.NoError(t, err)
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestService_WriteOff(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("success forgives the ledger balance", func(t *testing.T) {
+		svc, repo, _, _, _, _, _ := newTestLoanService()
+		repo.On("FindByID", mock.Anything, uint(1)).Return(ledgerLoan(), nil)
+		repo.On("GetLedgerBalances", mock.Anything, []uint{1}).Return(map[uint]float64{1: 1000000}, nil)
+		repo.On("SaveInstallments", mock.Anything, mock.MatchedBy(func(installments []LoanInstallment) bool {
+			return len(installments) == 2 && installments[0].Status == constants.LoanInstallmentWrittenOff && installments[0].Notes == "Resigned"
+		})).Return(nil)
+		repo.On("PostTransactions", mock.Anything, mock.MatchedBy(func(transactions []LoanTransaction) bool {
+			return len(transactions) == 1 && transactions[0].Type == constants.LoanTransactionWriteOff && transactions[0].Amount == 1000000
+		})).Return(nil)
+		repo.On("Update", mock.Anything, mock.MatchedBy(func(l *Loan) bool {
+			return l.Status == constants.LoanStatusWrittenOff && l.RemainingAmount == 0
+		})).Return(nil)
+
+		require.NoError(t, svc.WriteOff(ctx, &WriteOffRequest{LoanID: 1, ActorID: 10, Reason: "Resigned"}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("error nothing outstanding", func(t *testing.T) {
+		svc, repo, _, _, _, _, _ := newTestLoanService()
+		repo.On("FindByID", mock.Anything, uint(1)).Return(ledgerLoan(), nil)
+		repo.On("GetLedgerBalances", mock.Anything, []uint{1}).Return(map[uint]float64{1: 0}, nil)
+
+		err := svc.WriteOff(ctx, &WriteOffRequest{LoanID: 1, ActorID: 10, Reason: "Resigned"})
+		require.Error(t, err)
+		assert.Equal(t, "loan has no outstanding balance", err.Error())
+	})
+}
+
+func TestService_GetReconciliation(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	matched := ledgerLoan()
+	drifted := ledgerLoan()
+	drifted.ID = 2
+	drifted.RemainingAmount = 1500000
+	closed := &Loan{ID: 3, Status: constants.LoanStatusPaidOff}
+
+	posted := 450000.0
+	deductions := []UnpostedDeduction{
+		{PayrollDetailID: 31, LoanID: 2, PeriodDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), DeductedAmount: 500000},
+		{PayrollDetailID: 32, LoanID: 2, PeriodDate: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), DeductedAmount: 500000, PostedAmount: &posted},
+	}
+
+	tests := []struct {
+		name      string
+		filter    ReconciliationFilter
+		wantCount int
+	}{
+		{name: "every loan", filter: ReconciliationFilter{}, wantCount: 3},
+		{name: "mismatches only", filter: ReconciliationFilter{MismatchOnly: true}, wantCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, _, _, _, _ := newTestLoanService()
+			repo.On("FindLedgerLoans", mock.Anything, "").Return([]Loan{*matched, *drifted, *closed}, nil)
+			repo.On("GetLedgerBalances", mock.Anything, []uint{1, 2, 3}).Return(map[uint]float64{1: 1000000, 2: 1000000}, nil)
+			repo.On("FindUnpostedDeductions", mock.Anything, []uint{1, 2, 3}).Return(deductions, nil)
+
+			rows, err := svc.GetReconciliation(ctx, tt.filter)
+			require.NoError(t, err)
+			require.Len(t, rows, tt.wantCount)
+
+			byID := make(map[uint]LoanReconciliationResponse)
+			for _, row := range rows {
+				byID[row.LoanID] = row
+			}
+
+			assert.Equal(t, []string{
+				"remaining amount Rp.1500000 differs from ledger balance Rp.1000000",
+				"payroll deduction #31 of July 2025 (Rp.500000) is not in the ledger",
+				"payroll deduction #32 of August 2025 deducted Rp.500000 but the ledger holds Rp.450000",
+			}, byID[2].Issues)
+			assert.Equal(t, []string{"loan has no ledger transactions"}, byID[3].Issues)
+			if !tt.filter.MismatchOnly {
+				assert.True(t, byID[1].IsMatched)
+				assert.Equal(t, 1000000.0, byID[1].ScheduledBalance)
+			}
+		})
+	}
+}
+
+func TestService_GetOutstandingLoans(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, repo, _, _, _, _, _ := newTestLoanService()
+
+	repo.On("FindApprovedByEmployee", mock.Anything, uint(7)).Return([]Loan{
+		{ID: 1, Reason: "Emergency", Product: &LoanProduct{Name: "Kasbon"}},
+		{ID: 2, Reason: "Repaid by the last payroll"},
+	}, nil)
+	repo.On("GetLedgerBalances", mock.Anything, []uint{1, 2}).Return(map[uint]float64{1: 1000000, 2: 0}, nil)
+
+	loans, err := svc.GetOutstandingLoans(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, loans, 1)
+	assert.Equal(t, OutstandingLoan{LoanID: 1, ProductName: "Kasbon", Reason: "Emergency", Balance: 1000000}, loans[0])
+}
+
+func TestService_Settle(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	timeNow = func() time.Time { return time.Date(2025, 8, 20, 9, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	t.Run("full balance closes the pending installments", func(t *testing.T) {
+		svc, repo, _, _, _, _, _ := newTestLoanService()
+		repo.On("FindByID", mock.Anything, uint(1)).Return(ledgerLoan(), nil)
+		repo.On("GetLedgerBalances", mock.Anything, []uint{1}).Return(map[uint]float64{1: 1000000}, nil)
+		repo.On("SaveInstallments", mock.Anything, mock.MatchedBy(func(installments []LoanInstallment) bool {
+			return len(installments) == 2 && installments[1].Status == constants.LoanInstallmentPaid && installments[1].Notes == "Final settlement"
+		})).Return(nil)
+		repo.On("PostTransactions", mock.Anything, mock.MatchedBy(func(transactions []LoanTransaction) bool {
+			return len(transactions) == 1 && transactions[0].Type == constants.LoanTransactionRepayment &&
+				transactions[0].Amount == 1000000 && *transactions[0].CreatedBy == 10
+		})).Return(nil)
+
+		require.NoError(t, svc.Settle(ctx, &LoanSettlementRequest{LoanID: 1, ActorID: 10, Amount: 1000000, Notes: "Final settlement"}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("partial payment keeps the installments pending", func(t *testing.T) {
+		svc, repo, _, _, _, _, _ := newTestLoanService()
+		repo.On("FindByID", mock.Anything, uint(1)).Return(ledgerLoan(), nil)
+		repo.On("GetLedgerBalances", mock.Anything, []uint{1}).Return(map[uint]float64{1: 1000000}, nil)
+		repo.On("PostTransactions", mock.Anything, mock.MatchedBy(func(transactions []LoanTransaction) bool {
+			return len(transactions) == 1 && transactions[0].Amount == 400000
+		})).Return(nil)
+
+		require.NoError(t, svc.Settle(ctx, &LoanSettlementRequest{LoanID: 1, ActorID: 10, Amount: 400000}))
+		repo.AssertNotCalled(t, "SaveInstallments", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error amount above the balance", func(t *testing.T) {
+		svc, repo, _, _, _, _, _ := newTestLoanService()
+		repo.On("FindByID", mock.Anything, uint(1)).Return(ledgerLoan(), nil)
+		repo.On("GetLedgerBalances", mock.Anything, []uint{1}).Return(map[uint]float64{1: 1000000}, nil)
+
+		err := svc.Settle(ctx, &LoanSettlementRequest{LoanID: 1, ActorID: 10, Amount: 1200000})
+		require.Error(t, err)
+		assert.Equal(t, "settlement amount must be between Rp.1 and the outstanding balance of Rp.1000000", err.Error())
+	})
+}
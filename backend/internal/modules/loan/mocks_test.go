@@ -2,7 +2,10 @@ package loan
 
 import (
 	"context"
+	"time"
 
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
 
@@ -24,8 +27,8 @@ func (m *mockRepo) FindByID(ctx context.Context, id uint) (*Loan, error) {
 	return args.Get(0).(*Loan), args.Error(1)
 }
 
-func (m *mockRepo) FindActiveLoanByUserID(ctx context.Context, userID uint) (*Loan, error) {
-	args := m.Called(ctx, userID)
+func (m *mockRepo) FindActiveLoan(ctx context.Context, userID, productID uint) (*Loan, error) {
+	args := m.Called(ctx, userID, productID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -41,12 +44,89 @@ func (m *mockRepo) Update(ctx context.Context, loan *Loan) error {
 	return m.Called(ctx, loan).Error(0)
 }
 
-func (m *mockRepo) GetBulkActiveLoansByEmployeeIds(ctx context.Context, ids []uint) (map[uint]Loan, error) {
-	args := m.Called(ctx, ids)
+func (m *mockRepo) SumActiveInstallments(ctx context.Context, employeeID uint) (float64, error) {
+	args := m.Called(ctx, employeeID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *mockRepo) FindLatestNetSalary(ctx context.Context, employeeID uint) (float64, error) {
+	args := m.Called(ctx, employeeID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *mockRepo) FindAllProducts(ctx context.Context, activeOnly bool) ([]LoanProduct, error) {
+	args := m.Called(ctx, activeOnly)
+	return args.Get(0).([]LoanProduct), args.Error(1)
+}
+
+func (m *mockRepo) FindProductByID(ctx context.Context, id uint) (*LoanProduct, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoanProduct), args.Error(1)
+}
+
+func (m *mockRepo) SaveProduct(ctx context.Context, product *LoanProduct) error {
+	return m.Called(ctx, product).Error(0)
+}
+
+func (m *mockRepo) SaveInstallments(ctx context.Context, installments []LoanInstallment) error {
+	return m.Called(ctx, installments).Error(0)
+}
+
+func (m *mockRepo) GetBulkDueInstallments(ctx context.Context, ids []uint, month, year int) (map[uint][]LoanInstallment, error) {
+	args := m.Called(ctx, ids, month, year)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(map[uint]Loan), args.Error(1)
+	return args.Get(0).(map[uint][]LoanInstallment), args.Error(1)
+}
+
+func (m *mockRepo) PayInstallments(ctx context.Context, payments []InstallmentPayment, paidAt time.Time) error {
+	return m.Called(ctx, payments, paidAt).Error(0)
+}
+
+func (m *mockRepo) PostTransactions(ctx context.Context, transactions []LoanTransaction) error {
+	return m.Called(ctx, transactions).Error(0)
+}
+
+func (m *mockRepo) FindTransactions(ctx context.Context, loanID uint) ([]LoanTransaction, error) {
+	args := m.Called(ctx, loanID)
+	return args.Get(0).([]LoanTransaction), args.Error(1)
+}
+
+func (m *mockRepo) GetLedgerBalances(ctx context.Context, loanIDs []uint) (map[uint]float64, error) {
+	args := m.Called(ctx, loanIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint]float64), args.Error(1)
+}
+
+func (m *mockRepo) FindLedgerLoans(ctx context.Context, status string) ([]Loan, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]Loan), args.Error(1)
+}
+
+func (m *mockRepo) FindApprovedByEmployee(ctx context.Context, employeeID uint) ([]Loan, error) {
+	args := m.Called(ctx, employeeID)
+	return args.Get(0).([]Loan), args.Error(1)
+}
+
+func (m *mockRepo) FindUnpostedDeductions(ctx context.Context, loanIDs []uint) ([]UnpostedDeduction, error) {
+	args := m.Called(ctx, loanIDs)
+	return args.Get(0).([]UnpostedDeduction), args.Error(1)
+}
+
+type mockUserProvider struct{ mock.Mock }
+
+func (m *mockUserProvider) FindEmployeeByID(ctx context.Context, id uint) (*user.Employee, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Employee), args.Error(1)
 }
 
 type mockNotification struct{ mock.Mock }
@@ -59,13 +139,21 @@ func (m *mockNotification) BlastNotification(ctx context.Context, userIDs []uint
 	return m.Called(ctx, userIDs, notifType, title, message, relatedID).Error(0)
 }
 
-type mockUserProvider struct{ mock.Mock }
+type mockApprovalEngine struct{ mock.Mock }
 
-func (m *mockUserProvider) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
-	args := m.Called(ctx, permissionApprovalName)
+func (m *mockApprovalEngine) Submit(ctx context.Context, req *approval.SubmitRequest) ([]uint, error) {
+	args := m.Called(ctx, req)
 	return args.Get(0).([]uint), args.Error(1)
 }
 
+func (m *mockApprovalEngine) Decide(ctx context.Context, req *approval.DecideRequest) (*approval.Decision, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*approval.Decision), args.Error(1)
+}
+
 type mockExcel struct{ mock.Mock }
 
 func (m *mockExcel) GenerateSimpleExcel(sheetName string, headers []string, rows [][]interface{}) ([]byte, error) {
@@ -127,4 +215,63 @@ func (m *mockService) Export(ctx context.Context, filter LoanFilter) ([]byte, er
 	return args.Get(0).([]byte), args.Error(1)
 }
 
+func (m *mockService) Simulate(ctx context.Context, req *LoanSimulationRequest) (*LoanSimulationResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoanSimulationResponse), args.Error(1)
+}
+
+func (m *mockService) DeferInstallment(ctx context.Context, req *DeferInstallmentRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) EarlyPayoff(ctx context.Context, id uint) (*LoanPayoffResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoanPayoffResponse), args.Error(1)
+}
+
+func (m *mockService) GetProducts(ctx context.Context, activeOnly bool) ([]LoanProductResponse, error) {
+	args := m.Called(ctx, activeOnly)
+	return args.Get(0).([]LoanProductResponse), args.Error(1)
+}
+
+func (m *mockService) SaveProduct(ctx context.Context, req *SaveLoanProductRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) GetStatement(ctx context.Context, id uint) (*LoanStatementResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoanStatementResponse), args.Error(1)
+}
+
+func (m *mockService) Repay(ctx context.Context, req *RepaymentRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) WriteOff(ctx context.Context, req *WriteOffRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) GetReconciliation(ctx context.Context, filter ReconciliationFilter) ([]LoanReconciliationResponse, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]LoanReconciliationResponse), args.Error(1)
+}
+
+func (m *mockService) GetOutstandingLoans(ctx context.Context, employeeID uint) ([]OutstandingLoan, error) {
+	args := m.Called(ctx, employeeID)
+	return args.Get(0).([]OutstandingLoan), args.Error(1)
+}
+
+func (m *mockService) Settle(ctx context.Context, req *LoanSettlementRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
 func approvalLoanKey() string { return string(constants.APPROVAL_LOAN) }
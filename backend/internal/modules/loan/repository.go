@@ -4,17 +4,33 @@ import (
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/utils"
 	"context"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	Create(ctx context.Context, loan *Loan) error
 	FindByID(ctx context.Context, id uint) (*Loan, error)
-	FindActiveLoanByUserID(ctx context.Context, userID uint) (*Loan, error)
+	FindActiveLoan(ctx context.Context, userID, productID uint) (*Loan, error)
 	FindAll(ctx context.Context, filter LoanFilter) ([]Loan, int64, error)
 	Update(ctx context.Context, loan *Loan) error
-	GetBulkActiveLoansByEmployeeIds(ctx context.Context, ids []uint) (map[uint]Loan, error)
+	SumActiveInstallments(ctx context.Context, employeeID uint) (float64, error)
+	FindLatestNetSalary(ctx context.Context, employeeID uint) (float64, error)
+	FindAllProducts(ctx context.Context, activeOnly bool) ([]LoanProduct, error)
+	FindProductByID(ctx context.Context, id uint) (*LoanProduct, error)
+	SaveProduct(ctx context.Context, product *LoanProduct) error
+	SaveInstallments(ctx context.Context, installments []LoanInstallment) error
+	GetBulkDueInstallments(ctx context.Context, ids []uint, month, year int) (map[uint][]LoanInstallment, error)
+	PayInstallments(ctx context.Context, payments []InstallmentPayment, paidAt time.Time) error
+	PostTransactions(ctx context.Context, transactions []LoanTransaction) error
+	FindTransactions(ctx context.Context, loanID uint) ([]LoanTransaction, error)
+	GetLedgerBalances(ctx context.Context, loanIDs []uint) (map[uint]float64, error)
+	FindLedgerLoans(ctx context.Context, status string) ([]Loan, error)
+	FindApprovedByEmployee(ctx context.Context, employeeID uint) ([]Loan, error)
+	FindUnpostedDeductions(ctx context.Context, loanIDs []uint) ([]UnpostedDeduction, error)
 }
 
 type repository struct {
@@ -31,12 +47,16 @@ func (r *repository) Create(ctx context.Context, loan *Loan) error {
 }
 
 func (r *repository) FindByID(ctx context.Context, id uint) (*Loan, error) {
-	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	db := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Loan{})), "loans.employee_id")
 	var loan Loan
 
 	err := db.
 		Preload("User").
-		Preload("Employee").First(&loan, id).Error
+		Preload("Employee").
+		Preload("Product").
+		Preload("Installments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence ASC")
+		}).First(&loan, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -44,7 +64,8 @@ func (r *repository) FindByID(ctx context.Context, id uint) (*Loan, error) {
 	return &loan, nil
 }
 
-func (r *repository) FindActiveLoanByUserID(ctx context.Context, userID uint) (*Loan, error) {
+// FindActiveLoan returns the pending or running loan of the user on the product.
+func (r *repository) FindActiveLoan(ctx context.Context, userID, productID uint) (*Loan, error) {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 	var loan Loan
 
@@ -52,6 +73,7 @@ func (r *repository) FindActiveLoanByUserID(ctx context.Context, userID uint) (*
 		Preload("User").
 		Preload("Employee").
 		Where("user_id = ?", userID).
+		Where("product_id = ?", productID).
 		Where("remaining_amount > 0").
 		Where("status != ?", string(constants.LoanStatusPaidOff)).
 		Where("status != ?", string(constants.LoanStatusRejected)).
@@ -68,7 +90,7 @@ func (r *repository) FindAll(ctx context.Context, filter LoanFilter) ([]Loan, in
 	var loans []Loan
 	var total int64
 
-	query := utils.TenantScope(ctx, db.Model(&Loan{})).
+	query := utils.DataScope(ctx, utils.TenantScope(ctx, db.Model(&Loan{})), "loans.employee_id").
 		Joins("JOIN users ON users.id = loans.user_id").
 		Joins("JOIN employees ON employees.id = loans.employee_id").
 		Preload("User").
@@ -98,26 +120,291 @@ func (r *repository) FindAll(ctx context.Context, filter LoanFilter) ([]Loan, in
 
 func (r *repository) Update(ctx context.Context, loan *Loan) error {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
-	return db.Save(loan).Error
+	return db.Omit(clause.Associations).Save(loan).Error
 }
 
-func (r *repository) GetBulkActiveLoansByEmployeeIds(ctx context.Context, ids []uint) (map[uint]Loan, error) {
+// SumActiveInstallments adds up the monthly installments of the employee's approved loans still being repaid.
+func (r *repository) SumActiveInstallments(ctx context.Context, employeeID uint) (float64, error) {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
-	var loans []Loan
+	var total float64
 
 	err := db.Model(&Loan{}).
+		Select("COALESCE(SUM(installment_amount), 0)").
+		Where("employee_id = ?", employeeID).
 		Where("status = ?", string(constants.LoanStatusApproved)).
 		Where("remaining_amount > 0").
-		Where("employee_id IN ?", ids).
-		Find(&loans).Error
+		Scan(&total).Error
+
+	return total, err
+}
+
+// FindLatestNetSalary returns the net salary of the employee's most recent payroll, 0 when there is none.
+func (r *repository) FindLatestNetSalary(ctx context.Context, employeeID uint) (float64, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var netSalaries []float64
+
+	err := db.Table("payrolls").
+		Where("employee_id = ?", employeeID).
+		Order("period_date DESC").
+		Limit(1).
+		Pluck("net_salary", &netSalaries).Error
+	if err != nil || len(netSalaries) == 0 {
+		return 0, err
+	}
+
+	return netSalaries[0], nil
+}
+
+func (r *repository) FindAllProducts(ctx context.Context, activeOnly bool) ([]LoanProduct, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&LoanProduct{}))
+	if activeOnly {
+		db = db.Where("is_active = ?", true)
+	}
+
+	var products []LoanProduct
+	if err := db.Order("name ASC").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *repository) FindProductByID(ctx context.Context, id uint) (*LoanProduct, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var product LoanProduct
+	if err := db.First(&product, id).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *repository) SaveProduct(ctx context.Context, product *LoanProduct) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Save(product).Error
+}
+
+func (r *repository) SaveInstallments(ctx context.Context, installments []LoanInstallment) error {
+	if len(installments) == 0 {
+		return nil
+	}
+
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Save(&installments).Error
+}
+
+// GetBulkDueInstallments returns the pending installments of approved loans due in the payroll month, by employee.
+func (r *repository) GetBulkDueInstallments(ctx context.Context, ids []uint, month, year int) (map[uint][]LoanInstallment, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&LoanInstallment{}))
+
+	periodStart := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := periodStart.AddDate(0, 1, -1)
+
+	var rows []struct {
+		LoanInstallment
+		EmployeeID uint
+	}
+	err := db.Select("loan_installments.*, loans.employee_id").
+		Joins("JOIN loans ON loans.id = loan_installments.loan_id").
+		Where("loans.status = ?", string(constants.LoanStatusApproved)).
+		Where("loans.employee_id IN ?", ids).
+		Where("loan_installments.status = ?", string(constants.LoanInstallmentPending)).
+		Where("loan_installments.due_date BETWEEN ? AND ?", periodStart, periodEnd).
+		Order("loan_installments.loan_id ASC, loan_installments.sequence ASC").
+		Scan(&rows).Error
 	if err != nil {
 		return nil, err
 	}
 
-	dataMap := make(map[uint]Loan)
-	for _, loan := range loans {
-		dataMap[loan.EmployeeID] = loan
+	dataMap := make(map[uint][]LoanInstallment)
+	for _, row := range rows {
+		dataMap[row.EmployeeID] = append(dataMap[row.EmployeeID], row.LoanInstallment)
 	}
 
 	return dataMap, nil
 }
+
+// PayInstallments marks the installments payroll deducted as paid and posts every deduction to the ledger,
+// linked to the payroll detail it was deducted in.
+func (r *repository) PayInstallments(ctx context.Context, payments []InstallmentPayment, paidAt time.Time) error {
+	if len(payments) == 0 {
+		return nil
+	}
+
+	db := utils.GetDBFromContext(ctx, r.db)
+
+	ids := make([]uint, 0, len(payments))
+	for _, p := range payments {
+		ids = append(ids, p.InstallmentID)
+	}
+
+	var installments []LoanInstallment
+	if err := utils.TenantScope(ctx, db).Where("id IN ?", ids).Find(&installments).Error; err != nil {
+		return err
+	}
+
+	installmentMap := make(map[uint]LoanInstallment, len(installments))
+	for _, inst := range installments {
+		installmentMap[inst.ID] = inst
+	}
+
+	transactions := make([]LoanTransaction, 0, len(payments))
+	for _, p := range payments {
+		inst, ok := installmentMap[p.InstallmentID]
+		if !ok {
+			return fmt.Errorf("loan installment %d not found", p.InstallmentID)
+		}
+
+		// the money was deducted either way, an installment closed meanwhile shows up in the reconciliation
+		err := db.Model(&LoanInstallment{}).
+			Where("id = ? AND status = ?", inst.ID, string(constants.LoanInstallmentPending)).
+			Updates(map[string]interface{}{
+				"status":  constants.LoanInstallmentPaid,
+				"paid_at": paidAt,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		transactions = append(transactions, LoanTransaction{
+			CompanyID:       inst.CompanyID,
+			LoanID:          inst.LoanID,
+			Type:            constants.LoanTransactionInstallment,
+			Amount:          p.Amount,
+			InstallmentID:   &inst.ID,
+			PayrollDetailID: &p.PayrollDetailID,
+			TransactionDate: paidAt,
+			Notes:           fmt.Sprintf("Installment %d deducted by payroll", inst.Sequence),
+		})
+	}
+
+	return r.PostTransactions(ctx, transactions)
+}
+
+// PostTransactions records the transactions and sets the remaining amount of their loans to the ledger balance,
+// an approved loan with nothing left to pay is paid off.
+func (r *repository) PostTransactions(ctx context.Context, transactions []LoanTransaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	db := utils.GetDBFromContext(ctx, r.db)
+	if err := db.Create(&transactions).Error; err != nil {
+		return err
+	}
+
+	var loanIDs []uint
+	seen := make(map[uint]bool)
+	for _, t := range transactions {
+		if !seen[t.LoanID] {
+			seen[t.LoanID] = true
+			loanIDs = append(loanIDs, t.LoanID)
+		}
+	}
+
+	balances, err := r.GetLedgerBalances(ctx, loanIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, loanID := range loanIDs {
+		balance := balances[loanID]
+		if err := db.Model(&Loan{}).Where("id = ?", loanID).Update("remaining_amount", balance).Error; err != nil {
+			return err
+		}
+
+		if balance <= 0 {
+			err := db.Model(&Loan{}).
+				Where("id = ? AND status = ?", loanID, string(constants.LoanStatusApproved)).
+				Update("status", constants.LoanStatusPaidOff).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *repository) FindTransactions(ctx context.Context, loanID uint) ([]LoanTransaction, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var transactions []LoanTransaction
+
+	err := db.Where("loan_id = ?", loanID).
+		Order("transaction_date ASC, id ASC").
+		Find(&transactions).Error
+
+	return transactions, err
+}
+
+// GetLedgerBalances returns the balance of every loan by its ledger, disbursements raise it and the rest lower it.
+func (r *repository) GetLedgerBalances(ctx context.Context, loanIDs []uint) (map[uint]float64, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&LoanTransaction{}))
+
+	var rows []struct {
+		LoanID  uint
+		Balance float64
+	}
+	err := db.Select("loan_id, SUM(CASE WHEN type = ? THEN amount ELSE -amount END) AS balance", string(constants.LoanTransactionDisbursement)).
+		Where("loan_id IN ?", loanIDs).
+		Group("loan_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		balances[row.LoanID] = row.Balance
+	}
+	return balances, nil
+}
+
+// FindLedgerLoans returns the loans that have a ledger, every loan past approval, with their installments.
+func (r *repository) FindLedgerLoans(ctx context.Context, status string) ([]Loan, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+
+	query := db.Preload("Employee").Preload("Installments")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ?", []string{
+			string(constants.LoanStatusApproved),
+			string(constants.LoanStatusPaidOff),
+			string(constants.LoanStatusWrittenOff),
+		})
+	}
+
+	var loans []Loan
+	err := query.Order("id ASC").Find(&loans).Error
+	return loans, err
+}
+
+// FindApprovedByEmployee returns the loans the employee is still repaying.
+func (r *repository) FindApprovedByEmployee(ctx context.Context, employeeID uint) ([]Loan, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+
+	var loans []Loan
+	err := db.Preload("Product").
+		Where("employee_id = ? AND status = ?", employeeID, constants.LoanStatusApproved).
+		Order("id ASC").
+		Find(&loans).Error
+	return loans, err
+}
+
+// FindUnpostedDeductions returns the loan deductions of paid payrolls the ledger misses or holds with another amount.
+func (r *repository) FindUnpostedDeductions(ctx context.Context, loanIDs []uint) ([]UnpostedDeduction, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Table("payroll_details"))
+
+	var rows []UnpostedDeduction
+	err := db.
+		Select("payroll_details.id AS payroll_detail_id, loan_installments.loan_id, payrolls.period_date, payroll_details.amount AS deducted_amount, loan_transactions.amount AS posted_amount").
+		Joins("JOIN payrolls ON payrolls.id = payroll_details.payroll_id").
+		Joins("JOIN loan_installments ON loan_installments.id = payroll_details.loan_installment_id").
+		Joins("LEFT JOIN loan_transactions ON loan_transactions.payroll_detail_id = payroll_details.id").
+		Where("payrolls.status = ?", string(constants.PayrollStatusPaid)).
+		Where("loan_installments.loan_id IN ?", loanIDs).
+		Where("loan_transactions.id IS NULL OR loan_transactions.amount <> payroll_details.amount").
+		Order("payrolls.period_date ASC").
+		Scan(&rows).Error
+
+	return rows, err
+}
@@ -3,6 +3,7 @@ package loan
 import (
 	"database/sql"
 	"testing"
+	"time"
 
 	"basekarya-backend/internal/modules/department"
 	"basekarya-backend/internal/modules/master"
@@ -20,6 +21,7 @@ import (
 
 func setupLoanTestDB(t *testing.T) *testutil.TestDB {
 	t.Helper()
+	testutil.UseTestKeyring()
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
@@ -31,6 +33,9 @@ func setupLoanTestDB(t *testing.T) *testutil.TestDB {
 		&master.Shift{},
 		&user.User{},
 		&user.Employee{},
+		&LoanProduct{},
+		&LoanInstallment{},
+		&LoanTransaction{},
 	))
 
 	require.NoError(t, db.Exec(`CREATE TABLE IF NOT EXISTS loans (
@@ -46,7 +51,11 @@ func setupLoanTestDB(t *testing.T) *testutil.TestDB {
 		remaining_amount REAL NOT NULL,
 		reason TEXT,
 		status TEXT DEFAULT 'PENDING',
-		rejection_reason TEXT
+		rejection_reason TEXT,
+		product_id INTEGER,
+		tenor_months INTEGER DEFAULT 0,
+		interest_amount REAL DEFAULT 0,
+		admin_fee REAL DEFAULT 0
 	)`).Error)
 
 	t.Cleanup(func() {
@@ -160,32 +169,35 @@ func TestRepo_FindByID(t *testing.T) {
 	}
 }
 
-func TestRepo_FindActiveLoanByUserID(t *testing.T) {
+func TestRepo_FindActiveLoan(t *testing.T) {
 	tdb := setupLoanTestDB(t)
 	repo := NewRepository(tdb.DB)
 	ctx := testutil.CtxWithTenant(1, 1, false)
 
 	seedLoanTestData(t, tdb)
 
+	productID := uint(3)
 	loan := &Loan{
-		CompanyID: 1, UserID: 1, EmployeeID: 1,
+		CompanyID: 1, UserID: 1, EmployeeID: 1, ProductID: &productID,
 		TotalAmount: 5000000, InstallmentAmount: 500000, RemainingAmount: 5000000,
 		Status: constants.LoanStatusApproved, Reason: "Emergency",
 	}
 	require.NoError(t, repo.Create(ctx, loan))
 
 	tests := []struct {
-		name     string
-		userID   uint
-		wantErr  bool
+		name      string
+		userID    uint
+		productID uint
+		wantErr   bool
 	}{
-		{name: "success", userID: 1, wantErr: false},
-		{name: "not found", userID: 99, wantErr: true},
+		{name: "success", userID: 1, productID: 3, wantErr: false},
+		{name: "other product", userID: 1, productID: 4, wantErr: true},
+		{name: "not found", userID: 99, productID: 3, wantErr: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			found, err := repo.FindActiveLoanByUserID(ctx, tt.userID)
+			found, err := repo.FindActiveLoan(ctx, tt.userID, tt.productID)
 			if tt.wantErr {
 				require.Error(t, err)
 			} else {
@@ -275,7 +287,7 @@ func TestRepo_Update(t *testing.T) {
 	}
 }
 
-func TestRepo_GetBulkActiveLoansByEmployeeIds(t *testing.T) {
+func TestRepo_GetBulkDueInstallments(t *testing.T) {
 	tdb := setupLoanTestDB(t)
 	repo := NewRepository(tdb.DB)
 	ctx := testutil.CtxWithTenant(1, 1, false)
@@ -284,35 +296,212 @@ func TestRepo_GetBulkActiveLoansByEmployeeIds(t *testing.T) {
 
 	loan := &Loan{
 		CompanyID: 1, UserID: 1, EmployeeID: 1,
-		TotalAmount: 5000000, InstallmentAmount: 500000, RemainingAmount: 5000000,
+		TotalAmount: 1000000, InstallmentAmount: 500000, RemainingAmount: 1000000, TenorMonths: 2,
 		Status: constants.LoanStatusApproved, Reason: "Emergency",
 	}
 	require.NoError(t, repo.Create(ctx, loan))
+	require.NoError(t, repo.SaveInstallments(ctx, buildSchedule(1, loan.ID, 1000000, 0, 2, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))))
 
 	tests := []struct {
 		name      string
 		ids       []uint
+		month     int
 		wantCount int
-		wantErr   bool
 	}{
-		{name: "found", ids: []uint{1}, wantCount: 1, wantErr: false},
-		{name: "not found", ids: []uint{99}, wantCount: 0, wantErr: false},
+		{name: "due this month", ids: []uint{1}, month: 6, wantCount: 1},
+		{name: "nothing due", ids: []uint{1}, month: 9, wantCount: 0},
+		{name: "other employee", ids: []uint{99}, month: 6, wantCount: 0},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := repo.GetBulkActiveLoansByEmployeeIds(ctx, tt.ids)
-			if tt.wantErr {
-				require.Error(t, err)
-			} else {
-				require.NoError(t, err)
-				assert.Len(t, result, tt.wantCount)
+			result, err := repo.GetBulkDueInstallments(ctx, tt.ids, tt.month, 2025)
+			require.NoError(t, err)
+			assert.Len(t, result, tt.wantCount)
+			if tt.wantCount > 0 {
+				assert.Equal(t, 1, result[1][0].Sequence)
+				assert.Equal(t, 500000.0, result[1][0].Amount)
 			}
 		})
 	}
 }
 
-func TestRepo_FindActiveLoanByUserID_Rejected(t *testing.T) {
+// seedLedgerLoan creates an approved loan of 1.000.000 repaid in June and July 2025, with its disbursement posted.
+func seedLedgerLoan(t *testing.T, repo Repository) *Loan {
+	t.Helper()
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	loan := &Loan{
+		CompanyID: 1, UserID: 1, EmployeeID: 1,
+		TotalAmount: 1000000, InstallmentAmount: 500000, RemainingAmount: 1000000, TenorMonths: 2,
+		Status: constants.LoanStatusApproved, Reason: "Emergency",
+	}
+	require.NoError(t, repo.Create(ctx, loan))
+	require.NoError(t, repo.SaveInstallments(ctx, buildSchedule(1, loan.ID, 1000000, 0, 2, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, repo.PostTransactions(ctx, []LoanTransaction{disbursement(loan)}))
+	return loan
+}
+
+func TestRepo_PayInstallments(t *testing.T) {
+	tdb := setupLoanTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLoanTestData(t, tdb)
+	loan := seedLedgerLoan(t, repo)
+
+	paidAt := time.Now()
+	// the loan is paid off with its last installment
+	for _, step := range []struct {
+		month           int
+		payrollDetailID uint
+		wantStatus      constants.LoanStatus
+		wantRemaining   float64
+	}{
+		{month: 6, payrollDetailID: 21, wantStatus: constants.LoanStatusApproved, wantRemaining: 500000},
+		{month: 7, payrollDetailID: 22, wantStatus: constants.LoanStatusPaidOff, wantRemaining: 0},
+	} {
+		due, err := repo.GetBulkDueInstallments(ctx, []uint{1}, step.month, 2025)
+		require.NoError(t, err)
+		require.Len(t, due[1], 1)
+
+		inst := due[1][0]
+		require.NoError(t, repo.PayInstallments(ctx, []InstallmentPayment{{InstallmentID: inst.ID, PayrollDetailID: step.payrollDetailID, Amount: inst.Amount}}, paidAt))
+
+		found, err := repo.FindByID(ctx, loan.ID)
+		require.NoError(t, err)
+		assert.Equal(t, step.wantStatus, found.Status)
+		assert.Equal(t, step.wantRemaining, found.RemainingAmount)
+		assert.Equal(t, constants.LoanInstallmentPaid, found.Installments[step.month-6].Status)
+	}
+
+	transactions, err := repo.FindTransactions(ctx, loan.ID)
+	require.NoError(t, err)
+	require.Len(t, transactions, 3)
+	assert.Equal(t, constants.LoanTransactionDisbursement, transactions[0].Type)
+	assert.Equal(t, constants.LoanTransactionInstallment, transactions[2].Type)
+	assert.Equal(t, uint(22), *transactions[2].PayrollDetailID)
+
+	balances, err := repo.GetLedgerBalances(ctx, []uint{loan.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, balances[loan.ID])
+}
+
+func TestRepo_FindUnpostedDeductions(t *testing.T) {
+	tdb := setupLoanTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLoanTestData(t, tdb)
+	loan := seedLedgerLoan(t, repo)
+
+	require.NoError(t, tdb.DB.Exec(`CREATE TABLE payrolls (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		company_id INTEGER NOT NULL,
+		employee_id INTEGER NOT NULL,
+		period_date DATE NOT NULL,
+		net_salary REAL,
+		status TEXT
+	)`).Error)
+	require.NoError(t, tdb.DB.Exec(`CREATE TABLE payroll_details (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		payroll_id INTEGER NOT NULL,
+		company_id INTEGER NOT NULL,
+		amount REAL NOT NULL,
+		loan_installment_id INTEGER
+	)`).Error)
+
+	found, err := repo.FindByID(ctx, loan.ID)
+	require.NoError(t, err)
+	june, july := found.Installments[0].ID, found.Installments[1].ID
+
+	require.NoError(t, tdb.DB.Exec(`INSERT INTO payrolls (id, company_id, employee_id, period_date, status) VALUES
+		(1, 1, 1, '2025-06-01', 'PAID'), (2, 1, 1, '2025-07-01', 'PAID'), (3, 1, 1, '2025-08-01', 'DRAFT')`).Error)
+	require.NoError(t, tdb.DB.Exec(`INSERT INTO payroll_details (id, payroll_id, company_id, amount, loan_installment_id) VALUES
+		(21, 1, 1, 500000, ?), (22, 2, 1, 500000, ?), (23, 3, 1, 500000, ?)`, june, july, july).Error)
+
+	// june is posted with the amount deducted, july was never posted, the draft of august is not paid yet
+	require.NoError(t, repo.PayInstallments(ctx, []InstallmentPayment{{InstallmentID: june, PayrollDetailID: 21, Amount: 500000}}, time.Now()))
+
+	rows, err := repo.FindUnpostedDeductions(ctx, []uint{loan.ID})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, uint(22), rows[0].PayrollDetailID)
+	assert.Equal(t, loan.ID, rows[0].LoanID)
+	assert.Nil(t, rows[0].PostedAmount)
+}
+
+func TestRepo_FindLedgerLoans(t *testing.T) {
+	tdb := setupLoanTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLoanTestData(t, tdb)
+	for _, status := range []constants.LoanStatus{constants.LoanStatusPending, constants.LoanStatusApproved, constants.LoanStatusWrittenOff} {
+		require.NoError(t, repo.Create(ctx, &Loan{
+			CompanyID: 1, UserID: 1, EmployeeID: 1,
+			TotalAmount: 1000000, InstallmentAmount: 500000, RemainingAmount: 1000000,
+			Status: status, Reason: "Test",
+		}))
+	}
+
+	loans, err := repo.FindLedgerLoans(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, loans, 2)
+
+	loans, err = repo.FindLedgerLoans(ctx, string(constants.LoanStatusWrittenOff))
+	require.NoError(t, err)
+	require.Len(t, loans, 1)
+	assert.Equal(t, constants.LoanStatusWrittenOff, loans[0].Status)
+}
+
+func TestRepo_FindApprovedByEmployee(t *testing.T) {
+	tdb := setupLoanTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedLoanTestData(t, tdb)
+	for _, status := range []constants.LoanStatus{constants.LoanStatusApproved, constants.LoanStatusPaidOff, constants.LoanStatusPending} {
+		require.NoError(t, repo.Create(ctx, &Loan{
+			CompanyID: 1, UserID: 1, EmployeeID: 1,
+			TotalAmount: 1000000, InstallmentAmount: 500000, RemainingAmount: 1000000,
+			Status: status, Reason: "Test",
+		}))
+	}
+
+	loans, err := repo.FindApprovedByEmployee(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, loans, 1)
+	assert.Equal(t, constants.LoanStatusApproved, loans[0].Status)
+
+	loans, err = repo.FindApprovedByEmployee(ctx, 2)
+	require.NoError(t, err)
+	assert.Empty(t, loans)
+}
+
+func TestRepo_Products(t *testing.T) {
+	tdb := setupLoanTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	require.NoError(t, repo.SaveProduct(ctx, &LoanProduct{CompanyID: 1, Name: "Kasbon", MaxSalaryMultiplier: 1, MaxTenorMonths: 6, MaxInstallmentPercent: 30, IsActive: true}))
+	require.NoError(t, repo.SaveProduct(ctx, &LoanProduct{CompanyID: 1, Name: "Darurat", MaxSalaryMultiplier: 2, MaxTenorMonths: 12, MaxInstallmentPercent: 30}))
+	require.NoError(t, repo.SaveProduct(ctx, &LoanProduct{CompanyID: 2, Name: "Other Company", MaxSalaryMultiplier: 1, MaxTenorMonths: 6, MaxInstallmentPercent: 30, IsActive: true}))
+
+	all, err := repo.FindAllProducts(ctx, false)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	active, err := repo.FindAllProducts(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "Kasbon", active[0].Name)
+
+	found, err := repo.FindProductByID(ctx, active[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 6, found.MaxTenorMonths)
+}
+func TestRepo_FindActiveLoan_Rejected(t *testing.T) {
 	tdb := setupLoanTestDB(t)
 	repo := NewRepository(tdb.DB)
 	ctx := testutil.CtxWithTenant(1, 1, false)
@@ -327,11 +516,11 @@ func TestRepo_FindActiveLoanByUserID_Rejected(t *testing.T) {
 	}
 	require.NoError(t, repo.Create(ctx, loan))
 
-	_, err := repo.FindActiveLoanByUserID(ctx, 1)
+	_, err := repo.FindActiveLoan(ctx, 1, 0)
 	require.Error(t, err)
 }
 
-func TestRepo_FindActiveLoanByUserID_PaidOff(t *testing.T) {
+func TestRepo_FindActiveLoan_PaidOff(t *testing.T) {
 	tdb := setupLoanTestDB(t)
 	repo := NewRepository(tdb.DB)
 	ctx := testutil.CtxWithTenant(1, 1, false)
@@ -345,7 +534,7 @@ func TestRepo_FindActiveLoanByUserID_PaidOff(t *testing.T) {
 	}
 	require.NoError(t, repo.Create(ctx, loan))
 
-	_, err := repo.FindActiveLoanByUserID(ctx, 1)
+	_, err := repo.FindActiveLoan(ctx, 1, 0)
 	require.Error(t, err)
 }
 
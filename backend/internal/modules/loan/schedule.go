@@ -0,0 +1,127 @@
+package loan
+
+import (
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"fmt"
+	"math"
+	"time"
+)
+
+// loanOffer is a loan request priced and checked against its product.
+type loanOffer struct {
+	InterestAmount    float64
+	InstallmentAmount float64
+	MaxAmount         float64
+	MaxInstallment    float64
+}
+
+// priceLoan checks the request against the product limits and prices it. netPay is the latest net salary
+// and activeInstallments the monthly installments the employee already repays.
+func priceLoan(product *LoanProduct, employee *user.Employee, netPay, activeInstallments, amount float64, tenor int, now time.Time) (*loanOffer, error) {
+	if !product.IsActive {
+		return nil, fmt.Errorf("loan product is not active")
+	}
+
+	if product.MinTenureMonths > 0 {
+		if employee.JoinDate == nil {
+			return nil, fmt.Errorf("join date is not set, tenure cannot be checked")
+		}
+		if tenureMonths(*employee.JoinDate, now) < product.MinTenureMonths {
+			return nil, fmt.Errorf("requires at least %d months of service", product.MinTenureMonths)
+		}
+	}
+
+	if tenor < 1 || tenor > product.MaxTenorMonths {
+		return nil, fmt.Errorf("tenor must be between 1 and %d months", product.MaxTenorMonths)
+	}
+
+	offer := &loanOffer{MaxAmount: math.Floor(employee.BaseSalary * product.MaxSalaryMultiplier)}
+	if amount > offer.MaxAmount {
+		return nil, fmt.Errorf("cannot exceed maximum loan amount of Rp.%.0f", offer.MaxAmount)
+	}
+
+	// flat interest: the yearly rate on the full principal for the whole tenor
+	offer.InterestAmount = math.Round(amount * product.InterestRate / 100 * float64(tenor) / 12)
+	offer.InstallmentAmount = math.Ceil((amount + offer.InterestAmount) / float64(tenor))
+
+	// a first payroll has no net pay yet, the base salary stands in for it
+	if netPay <= 0 {
+		netPay = employee.BaseSalary
+	}
+	offer.MaxInstallment = math.Max(0, math.Floor(netPay*product.MaxInstallmentPercent/100)-activeInstallments)
+	if offer.InstallmentAmount > offer.MaxInstallment {
+		return nil, fmt.Errorf("monthly installment of Rp.%.0f exceeds the limit of Rp.%.0f, choose a longer tenor or a smaller amount", offer.InstallmentAmount, offer.MaxInstallment)
+	}
+
+	return offer, nil
+}
+
+// buildSchedule splits principal and interest over the tenor in whole rupiah, the last installment takes the
+// rounding remainder. Installments are due on the first day of every month starting at firstDue.
+func buildSchedule(companyID, loanID uint, principal, interest float64, tenor int, firstDue time.Time) []LoanInstallment {
+	monthlyPrincipal := math.Floor(principal / float64(tenor))
+	monthlyInterest := math.Floor(interest / float64(tenor))
+
+	installments := make([]LoanInstallment, 0, tenor)
+	for i := 0; i < tenor; i++ {
+		p, in := monthlyPrincipal, monthlyInterest
+		if i == tenor-1 {
+			p = principal - monthlyPrincipal*float64(tenor-1)
+			in = interest - monthlyInterest*float64(tenor-1)
+		}
+
+		installments = append(installments, LoanInstallment{
+			CompanyID:       companyID,
+			LoanID:          loanID,
+			Sequence:        i + 1,
+			DueDate:         firstDue.AddDate(0, i, 0),
+			PrincipalAmount: p,
+			InterestAmount:  in,
+			Amount:          p + in,
+			Status:          constants.LoanInstallmentPending,
+		})
+	}
+	return installments
+}
+
+// nextMonth returns the first day of the month after t.
+func nextMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+}
+
+func tenureMonths(joinDate, now time.Time) int {
+	months := (now.Year()-joinDate.Year())*12 + int(now.Month()-joinDate.Month())
+	if now.Day() < joinDate.Day() {
+		months--
+	}
+	return months
+}
+
+func toInstallmentResponse(inst *LoanInstallment) LoanInstallmentResponse {
+	return LoanInstallmentResponse{
+		ID:              inst.ID,
+		Sequence:        inst.Sequence,
+		DueDate:         inst.DueDate.Format(constants.DefaultTimeFormat),
+		PrincipalAmount: inst.PrincipalAmount,
+		InterestAmount:  inst.InterestAmount,
+		Amount:          inst.Amount,
+		Status:          inst.Status,
+		PaidAt:          inst.PaidAt,
+		Notes:           inst.Notes,
+	}
+}
+
+func toProductResponse(product *LoanProduct) LoanProductResponse {
+	return LoanProductResponse{
+		ID:                    product.ID,
+		Name:                  product.Name,
+		MaxSalaryMultiplier:   product.MaxSalaryMultiplier,
+		MaxTenorMonths:        product.MaxTenorMonths,
+		MaxInstallmentPercent: product.MaxInstallmentPercent,
+		InterestRate:          product.InterestRate,
+		AdminFee:              product.AdminFee,
+		MinTenureMonths:       product.MinTenureMonths,
+		IsActive:              product.IsActive,
+	}
+}
@@ -0,0 +1,85 @@
+package loan
+
+import (
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceLoan(t *testing.T) {
+	now := time.Date(2025, 6, 18, 0, 0, 0, 0, time.UTC)
+	joined := time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)
+	employee := &user.Employee{ID: 1, BaseSalary: 5000000, JoinDate: &joined}
+
+	tests := []struct {
+		name            string
+		product         func(*LoanProduct)
+		netPay          float64
+		active          float64
+		amount          float64
+		tenor           int
+		wantInterest    float64
+		wantInstallment float64
+		errMsg          string
+	}{
+		{name: "priced with flat interest", netPay: 4500000, amount: 5000000, tenor: 10, wantInterest: 500000, wantInstallment: 550000},
+		{name: "installment rounded up", netPay: 4500000, amount: 1000000, tenor: 3, wantInterest: 30000, wantInstallment: 343334},
+		{name: "base salary stands in for missing net pay", amount: 5000000, tenor: 12, wantInterest: 600000, wantInstallment: 466667},
+		{name: "inactive product", product: func(p *LoanProduct) { p.IsActive = false }, amount: 1000000, tenor: 3, errMsg: "loan product is not active"},
+		{name: "tenure too short", product: func(p *LoanProduct) { p.MinTenureMonths = 6 }, amount: 1000000, tenor: 3, errMsg: "requires at least 6 months of service"},
+		{name: "tenor too long", amount: 1000000, tenor: 13, errMsg: "tenor must be between 1 and 12 months"},
+		{name: "over salary multiplier", amount: 10000001, tenor: 12, errMsg: "cannot exceed maximum loan amount of Rp.10000000"},
+		{name: "over installment limit", netPay: 4500000, active: 1000000, amount: 5000000, tenor: 10, errMsg: "monthly installment of Rp.550000 exceeds the limit of Rp.350000, choose a longer tenor or a smaller amount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := testProduct()
+			if tt.product != nil {
+				tt.product(product)
+			}
+
+			offer, err := priceLoan(product, employee, tt.netPay, tt.active, tt.amount, tt.tenor, now)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantInterest, offer.InterestAmount)
+			assert.Equal(t, tt.wantInstallment, offer.InstallmentAmount)
+		})
+	}
+}
+
+func TestBuildSchedule(t *testing.T) {
+	installments := buildSchedule(1, 7, 1000000, 30000, 3, time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC))
+	require.Len(t, installments, 3)
+
+	var principal, interest float64
+	for i, inst := range installments {
+		principal += inst.PrincipalAmount
+		interest += inst.InterestAmount
+		assert.Equal(t, i+1, inst.Sequence)
+		assert.Equal(t, uint(7), inst.LoanID)
+	}
+
+	// whole rupiah every month, the last installment takes the remainder
+	assert.Equal(t, 1000000.0, principal)
+	assert.Equal(t, 30000.0, interest)
+	assert.Equal(t, 343333.0, installments[0].Amount)
+	assert.Equal(t, 343334.0, installments[2].Amount)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), installments[2].DueDate)
+}
+
+func TestTenureMonths(t *testing.T) {
+	joined := time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 4, tenureMonths(joined, time.Date(2025, 6, 18, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 5, tenureMonths(joined, time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC)))
+}
@@ -2,12 +2,15 @@ package loan
 
 import (
 	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
 	"basekarya-backend/pkg/utils"
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -18,18 +21,32 @@ type Service interface {
 	GetLoans(ctx context.Context, filter LoanFilter) ([]LoanListResponse, *response.Meta, error)
 	ProcessAction(ctx context.Context, req *ActionRequest) error
 	Export(ctx context.Context, filter LoanFilter) ([]byte, error)
+	Simulate(ctx context.Context, req *LoanSimulationRequest) (*LoanSimulationResponse, error)
+	DeferInstallment(ctx context.Context, req *DeferInstallmentRequest) error
+	EarlyPayoff(ctx context.Context, id uint) (*LoanPayoffResponse, error)
+	GetProducts(ctx context.Context, activeOnly bool) ([]LoanProductResponse, error)
+	SaveProduct(ctx context.Context, req *SaveLoanProductRequest) error
+	GetStatement(ctx context.Context, id uint) (*LoanStatementResponse, error)
+	Repay(ctx context.Context, req *RepaymentRequest) error
+	WriteOff(ctx context.Context, req *WriteOffRequest) error
+	GetReconciliation(ctx context.Context, filter ReconciliationFilter) ([]LoanReconciliationResponse, error)
+	GetOutstandingLoans(ctx context.Context, employeeID uint) ([]OutstandingLoan, error)
+	Settle(ctx context.Context, req *LoanSettlementRequest) error
 }
 
+var timeNow = time.Now
+
 type service struct {
 	repo               Repository
-	notification       NotificationProvider
 	user               UserProvider
+	notification       NotificationProvider
+	approval           ApprovalEngine
 	transactionManager infrastructure.TransactionManager
 	excel              infrastructure.ExcelProvider
 }
 
-func NewService(repo Repository, notification NotificationProvider, user UserProvider, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
-	return &service{repo, notification, user, transactionManager, excel}
+func NewService(repo Repository, user UserProvider, notification NotificationProvider, approval ApprovalEngine, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
+	return &service{repo, user, notification, approval, transactionManager, excel}
 }
 
 func (s *service) Create(ctx context.Context, req *LoanRequest) error {
@@ -38,8 +55,8 @@ func (s *service) Create(ctx context.Context, req *LoanRequest) error {
 			return fmt.Errorf("user not found")
 		}
 
-		// check if users still have active loan or not
-		exist, err := s.repo.FindActiveLoanByUserID(ctx, req.UserID)
+		// check if users still have active loan on the product or not
+		exist, err := s.repo.FindActiveLoan(ctx, req.UserID, req.ProductID)
 		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			return err
 		}
@@ -47,18 +64,23 @@ func (s *service) Create(ctx context.Context, req *LoanRequest) error {
 			return fmt.Errorf("users still have loan")
 		}
 
-		// validate maximum loan request amount
-		if req.TotalAmount > constants.LoanMaximumTotalAmount {
-			return fmt.Errorf("cannot exceed maximum loan request")
+		product, offer, err := s.offer(ctx, req)
+		if err != nil {
+			return err
 		}
 
 		loan := &Loan{
 			CompanyID:         utils.GetCompanyIDFromCtx(ctx),
 			UserID:            req.UserID,
 			EmployeeID:        req.EmployeeID,
+			ProductID:         &product.ID,
 			TotalAmount:       req.TotalAmount,
-			InstallmentAmount: req.InstallmentAmount,
-			RemainingAmount:   req.TotalAmount,
+			InstallmentAmount: offer.InstallmentAmount,
+			RemainingAmount:   req.TotalAmount + offer.InterestAmount,
+			TenorMonths:       req.TenorMonths,
+			InterestAmount:    offer.InterestAmount,
+			AdminFee:          product.AdminFee,
+			Reason:            req.Reason,
 			Status:            constants.LoanStatusPending,
 		}
 
@@ -67,7 +89,12 @@ func (s *service) Create(ctx context.Context, req *LoanRequest) error {
 			return err
 		}
 
-		approvalUserIDs, err := s.user.FindApprovalUsers(ctx, string(constants.APPROVAL_LOAN))
+		approvalUserIDs, err := s.approval.Submit(ctx, &approval.SubmitRequest{
+			RequestType:     constants.ApprovalRequestLoan,
+			ReferenceID:     loan.ID,
+			RequesterUserID: req.UserID,
+			Amount:          req.TotalAmount,
+		})
 		if err != nil {
 			return err
 		}
@@ -102,6 +129,16 @@ func (s *service) GetLoanDetail(ctx context.Context, id uint) (*LoanDetailRespon
 		rejectionReason = detail.RejectionReason.String
 	}
 
+	productName := "-"
+	if detail.Product != nil {
+		productName = detail.Product.Name
+	}
+
+	installments := make([]LoanInstallmentResponse, 0, len(detail.Installments))
+	for _, inst := range detail.Installments {
+		installments = append(installments, toInstallmentResponse(&inst))
+	}
+
 	return &LoanDetailResponse{
 		ID:                detail.ID,
 		EmployeeID:        detail.EmployeeID,
@@ -110,10 +147,16 @@ func (s *service) GetLoanDetail(ctx context.Context, id uint) (*LoanDetailRespon
 		TotalAmount:       detail.TotalAmount,
 		InstallmentAmount: detail.InstallmentAmount,
 		RemainingAmount:   detail.RemainingAmount,
+		ProductID:         detail.ProductID,
+		ProductName:       productName,
+		TenorMonths:       detail.TenorMonths,
+		InterestAmount:    detail.InterestAmount,
+		AdminFee:          detail.AdminFee,
 		Reason:            detail.Reason,
 		Status:            detail.Status,
 		RejectionReason:   rejectionReason,
 		CreatedAt:         detail.CreatedAt,
+		Installments:      installments,
 	}, nil
 }
 
@@ -163,11 +206,55 @@ func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 			notificationTitle   string
 			notificationMessage string
 		)
+		decideReq := &approval.DecideRequest{
+			RequestType:     constants.ApprovalRequestLoan,
+			ReferenceID:     data.ID,
+			RequesterUserID: data.UserID,
+			Amount:          data.TotalAmount,
+			ActorUserID:     req.SuperAdminID,
+		}
+
 		switch constants.LoanAction(req.Action) {
 		case constants.LoanActionApprove:
+			decideReq.Action = constants.ApprovalActionApprove
+			decision, err := s.approval.Decide(ctx, decideReq)
+			if err != nil {
+				return err
+			}
+
+			// the loan stays pending until the last level of the approval chain approves it
+			if decision.Status == constants.ApprovalStatusPending {
+				go func() {
+					_ = s.notification.BlastNotification(
+						utils.DetachContext(ctx),
+						decision.NextApproverIDs,
+						string(constants.NotificationTypeLoanApprovalReq),
+						"Persetujuan Kasbon Lanjutan",
+						fmt.Sprintf("Kasbon karyawan dengan total Rp.%2.f menunggu persetujuan Anda", data.TotalAmount),
+						data.ID,
+					)
+				}()
+				return nil
+			}
+
 			data.Status = constants.LoanStatusApproved
 			data.ApprovedBy = &req.SuperAdminID
 
+			// loans requested before products existed repay their chosen installment without interest
+			if data.TenorMonths == 0 && data.InstallmentAmount > 0 {
+				data.TenorMonths = int(math.Ceil(data.TotalAmount / data.InstallmentAmount))
+			}
+
+			// repayment starts on the payroll of the month after approval
+			installments := buildSchedule(data.CompanyID, data.ID, data.TotalAmount, data.InterestAmount, data.TenorMonths, nextMonth(timeNow()))
+			if err := s.repo.SaveInstallments(ctx, installments); err != nil {
+				return err
+			}
+
+			if err := s.repo.PostTransactions(ctx, []LoanTransaction{disbursement(data)}); err != nil {
+				return err
+			}
+
 			notificationType = constants.NotificationTypeApproved
 			notificationTitle = "Permintaan Disetujui"
 			notificationMessage = "Kasbon Anda telah disetujui oleh Admin."
@@ -179,6 +266,12 @@ func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 				return fmt.Errorf("rejection reason is required")
 			}
 
+			decideReq.Action = constants.ApprovalActionReject
+			decideReq.Notes = req.RejectionReason
+			if _, err := s.approval.Decide(ctx, decideReq); err != nil {
+				return err
+			}
+
 			data.RejectionReason.String = req.RejectionReason
 			data.RejectionReason.Valid = true
 
@@ -243,3 +336,223 @@ func (s *service) Export(ctx context.Context, filter LoanFilter) ([]byte, error)
 
 	return s.excel.GenerateSimpleExcel("Loans", headers, rows)
 }
+
+func (s *service) Simulate(ctx context.Context, req *LoanSimulationRequest) (*LoanSimulationResponse, error) {
+	product, offer, err := s.offer(ctx, &LoanRequest{
+		EmployeeID:  req.EmployeeID,
+		ProductID:   req.ProductID,
+		TotalAmount: req.TotalAmount,
+		TenorMonths: req.TenorMonths,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	installments := buildSchedule(utils.GetCompanyIDFromCtx(ctx), 0, req.TotalAmount, offer.InterestAmount, req.TenorMonths, nextMonth(timeNow()))
+	schedule := make([]LoanInstallmentResponse, 0, len(installments))
+	for _, inst := range installments {
+		schedule = append(schedule, toInstallmentResponse(&inst))
+	}
+
+	return &LoanSimulationResponse{
+		ProductID:         product.ID,
+		TotalAmount:       req.TotalAmount,
+		TenorMonths:       req.TenorMonths,
+		InterestAmount:    offer.InterestAmount,
+		AdminFee:          product.AdminFee,
+		DisbursedAmount:   req.TotalAmount - product.AdminFee,
+		TotalPayable:      req.TotalAmount + offer.InterestAmount,
+		InstallmentAmount: offer.InstallmentAmount,
+		MaxAmount:         offer.MaxAmount,
+		MaxInstallment:    offer.MaxInstallment,
+		Schedule:          schedule,
+	}, nil
+}
+
+// offer prices the request with the product and the employee's salary and running loans.
+func (s *service) offer(ctx context.Context, req *LoanRequest) (*LoanProduct, *loanOffer, error) {
+	product, err := s.repo.FindProductByID(ctx, req.ProductID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("loan product not found")
+		}
+		return nil, nil, err
+	}
+
+	employee, err := s.user.FindEmployeeByID(ctx, req.EmployeeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	netPay, err := s.repo.FindLatestNetSalary(ctx, req.EmployeeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	activeInstallments, err := s.repo.SumActiveInstallments(ctx, req.EmployeeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	offer, err := priceLoan(product, employee, netPay, activeInstallments, req.TotalAmount, req.TenorMonths, timeNow())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return product, offer, nil
+}
+
+// DeferInstallment skips the month of a pending installment and adds the same installment after the last one.
+func (s *service) DeferInstallment(ctx context.Context, req *DeferInstallmentRequest) error {
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		data, err := s.repo.FindByID(ctx, req.LoanID)
+		if err != nil {
+			return err
+		}
+
+		if data.Status != constants.LoanStatusApproved {
+			return fmt.Errorf("cannot defer installment of loan with status %s", data.Status)
+		}
+
+		var target *LoanInstallment
+		for i := range data.Installments {
+			if data.Installments[i].ID == req.InstallmentID {
+				target = &data.Installments[i]
+			}
+		}
+		if target == nil {
+			return fmt.Errorf("installment not found")
+		}
+
+		if target.Status != constants.LoanInstallmentPending {
+			return fmt.Errorf("cannot defer installment with status %s", target.Status)
+		}
+
+		now := timeNow()
+		if target.DueDate.Before(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)) {
+			return fmt.Errorf("cannot defer installment of a past month")
+		}
+
+		last := data.Installments[len(data.Installments)-1]
+
+		target.Status = constants.LoanInstallmentDeferred
+		target.Notes = req.Reason
+
+		moved := LoanInstallment{
+			CompanyID:       target.CompanyID,
+			LoanID:          target.LoanID,
+			Sequence:        last.Sequence + 1,
+			DueDate:         last.DueDate.AddDate(0, 1, 0),
+			PrincipalAmount: target.PrincipalAmount,
+			InterestAmount:  target.InterestAmount,
+			Amount:          target.Amount,
+			Status:          constants.LoanInstallmentPending,
+			Notes:           fmt.Sprintf("deferred from %s", target.DueDate.Format(constants.PayrollTimeFormat)),
+		}
+
+		return s.repo.SaveInstallments(ctx, []LoanInstallment{*target, moved})
+	})
+}
+
+// EarlyPayoff settles every pending installment at once, only the remaining principal is owed.
+func (s *service) EarlyPayoff(ctx context.Context, id uint) (*LoanPayoffResponse, error) {
+	var result *LoanPayoffResponse
+
+	err := s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		data, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if data.Status != constants.LoanStatusApproved {
+			return fmt.Errorf("cannot pay off loan with status %s", data.Status)
+		}
+
+		now := timeNow()
+		result = &LoanPayoffResponse{LoanID: data.ID}
+
+		var settled []LoanInstallment
+		for _, inst := range data.Installments {
+			if inst.Status != constants.LoanInstallmentPending {
+				continue
+			}
+
+			result.PayoffAmount += inst.PrincipalAmount
+			result.WaivedInterest += inst.InterestAmount
+
+			inst.Status = constants.LoanInstallmentSettled
+			inst.PaidAt = &now
+			settled = append(settled, inst)
+		}
+		if len(settled) == 0 {
+			return fmt.Errorf("loan has no pending installments")
+		}
+
+		if err := s.repo.SaveInstallments(ctx, settled); err != nil {
+			return err
+		}
+
+		// the principal is repaid and the interest of the remaining months forgiven, the ledger closes the loan
+		transactions := []LoanTransaction{{
+			CompanyID:       data.CompanyID,
+			LoanID:          data.ID,
+			Type:            constants.LoanTransactionRepayment,
+			Amount:          result.PayoffAmount,
+			TransactionDate: now,
+			Notes:           "Early payoff",
+		}}
+		if result.WaivedInterest > 0 {
+			transactions = append(transactions, LoanTransaction{
+				CompanyID:       data.CompanyID,
+				LoanID:          data.ID,
+				Type:            constants.LoanTransactionWriteOff,
+				Amount:          result.WaivedInterest,
+				TransactionDate: now,
+				Notes:           "Interest waived on early payoff",
+			})
+		}
+
+		return s.repo.PostTransactions(ctx, transactions)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *service) GetProducts(ctx context.Context, activeOnly bool) ([]LoanProductResponse, error) {
+	products, err := s.repo.FindAllProducts(ctx, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]LoanProductResponse, 0, len(products))
+	for _, product := range products {
+		list = append(list, toProductResponse(&product))
+	}
+	return list, nil
+}
+
+// SaveProduct creates the product, or updates it when req.ID is set.
+func (s *service) SaveProduct(ctx context.Context, req *SaveLoanProductRequest) error {
+	product := &LoanProduct{CompanyID: utils.GetCompanyIDFromCtx(ctx)}
+	if req.ID != 0 {
+		existing, err := s.repo.FindProductByID(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+		product = existing
+	}
+
+	product.Name = req.Name
+	product.MaxSalaryMultiplier = req.MaxSalaryMultiplier
+	product.MaxTenorMonths = req.MaxTenorMonths
+	product.MaxInstallmentPercent = req.MaxInstallmentPercent
+	product.InterestRate = req.InterestRate
+	product.AdminFee = req.AdminFee
+	product.MinTenureMonths = req.MinTenureMonths
+	product.IsActive = req.IsActive
+
+	return s.repo.SaveProduct(ctx, product)
+}
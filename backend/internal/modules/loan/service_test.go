@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/internal/testutil"
 	"basekarya-backend/pkg/constants"
@@ -16,55 +17,73 @@ import (
 	"gorm.io/gorm"
 )
 
-func newTestLoanService() (Service, *mockRepo, *mockNotification, *mockUserProvider, *testutil.MockTransactionManager, *mockExcel) {
+func newTestLoanService() (Service, *mockRepo, *mockUserProvider, *mockNotification, *mockApprovalEngine, *testutil.MockTransactionManager, *mockExcel) {
 	repo := new(mockRepo)
-	notif := new(mockNotification)
 	userProv := new(mockUserProvider)
+	notif := new(mockNotification)
+	approvalEngine := new(mockApprovalEngine)
 	tm := testutil.NewMockTransactionManager()
 	excel := new(mockExcel)
 
-	svc := NewService(repo, notif, userProv, tm, excel)
-	return svc, repo, notif, userProv, tm, excel
+	svc := NewService(repo, userProv, notif, approvalEngine, tm, excel)
+	return svc, repo, userProv, notif, approvalEngine, tm, excel
+}
+
+// testProduct lends up to twice the base salary over 12 months at 12% a year, the installment may take 30% of net pay.
+func testProduct() *LoanProduct {
+	return &LoanProduct{
+		ID: 3, CompanyID: 1, Name: "Kasbon",
+		MaxSalaryMultiplier: 2, MaxTenorMonths: 12, MaxInstallmentPercent: 30,
+		InterestRate: 12, AdminFee: 50000, IsActive: true,
+	}
+}
+
+// expectOffer sets up the lookups done to price a request of employee 1 for testProduct.
+func expectOffer(repo *mockRepo, userProv *mockUserProvider, activeInstallments float64) {
+	repo.On("FindProductByID", mock.Anything, uint(3)).Return(testProduct(), nil)
+	userProv.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&user.Employee{ID: 1, BaseSalary: 5000000}, nil)
+	repo.On("FindLatestNetSalary", mock.Anything, uint(1)).Return(4500000.0, nil)
+	repo.On("SumActiveInstallments", mock.Anything, uint(1)).Return(activeInstallments, nil)
 }
 
 func TestService_Create(t *testing.T) {
 	ctx := testutil.CtxWithTenant(1, 1, false)
 
+	validReq := func() *LoanRequest {
+		return &LoanRequest{UserID: 1, EmployeeID: 1, ProductID: 3, TotalAmount: 5000000, TenorMonths: 10, Reason: "Emergency"}
+	}
+
 	tests := []struct {
 		name       string
 		req        *LoanRequest
-		setupMocks func(*mockRepo, *mockNotification, *mockUserProvider)
+		setupMocks func(*mockRepo, *mockUserProvider, *mockNotification, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
 		{
 			name: "success",
-			req: &LoanRequest{
-				UserID:            1,
-				EmployeeID:        1,
-				TotalAmount:       5000000,
-				InstallmentAmount: 500000,
-				Reason:            "Emergency",
-			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
-				repo.On("FindActiveLoanByUserID", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
-				repo.On("Create", mock.Anything, mock.AnythingOfType("*loan.Loan")).Return(nil)
-				userProv.On("FindApprovalUsers", mock.Anything, string(constants.APPROVAL_LOAN)).Return([]uint{10, 11}, nil)
+			req:  validReq(),
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindActiveLoan", mock.Anything, uint(1), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+				expectOffer(repo, userProv, 0)
+				// 5.000.000 at 12% a year over 10 months is 500.000 interest, repaid as 10 x 550.000
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(l *Loan) bool {
+					return *l.ProductID == 3 && l.TenorMonths == 10 && l.InterestAmount == 500000 &&
+						l.InstallmentAmount == 550000 && l.RemainingAmount == 5500000 && l.AdminFee == 50000 &&
+						l.Reason == "Emergency" && l.Status == constants.LoanStatusPending
+				})).Return(nil)
+				approvalEngine.On("Submit", mock.Anything, mock.MatchedBy(func(req *approval.SubmitRequest) bool {
+					return req.RequestType == constants.ApprovalRequestLoan && req.RequesterUserID == 1 && req.Amount == 5000000
+				})).Return([]uint{10, 11}, nil)
 				notif.On("BlastNotification", mock.Anything, []uint{10, 11}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
 		},
 		{
 			name: "error user has active loan",
-			req: &LoanRequest{
-				UserID:            1,
-				EmployeeID:        1,
-				TotalAmount:       5000000,
-				InstallmentAmount: 500000,
-				Reason:            "Emergency",
-			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
-				repo.On("FindActiveLoanByUserID", mock.Anything, uint(1)).Return(&Loan{ID: 1, Status: constants.LoanStatusApproved}, nil)
+			req:  validReq(),
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindActiveLoan", mock.Anything, uint(1), uint(3)).Return(&Loan{ID: 1, Status: constants.LoanStatusApproved}, nil)
 			},
 			wantErr: true,
 			errMsg:  "users still have loan",
@@ -75,37 +94,47 @@ func TestService_Create(t *testing.T) {
 				UserID:     0,
 				EmployeeID: 0,
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 			},
 			wantErr: true,
 			errMsg:  "user not found",
 		},
+		{
+			name: "error product not found",
+			req:  validReq(),
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindActiveLoan", mock.Anything, uint(1), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+				repo.On("FindProductByID", mock.Anything, uint(3)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErr: true,
+			errMsg:  "loan product not found",
+		},
 		{
 			name: "error exceeds maximum amount",
-			req: &LoanRequest{
-				UserID:            1,
-				EmployeeID:        1,
-				TotalAmount:       constants.LoanMaximumTotalAmount + 1,
-				InstallmentAmount: 500000,
-				Reason:            "Emergency",
+			req:  &LoanRequest{UserID: 1, EmployeeID: 1, ProductID: 3, TotalAmount: 10000001, TenorMonths: 12, Reason: "Emergency"},
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindActiveLoan", mock.Anything, uint(1), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+				expectOffer(repo, userProv, 0)
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
-				repo.On("FindActiveLoanByUserID", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+			wantErr: true,
+			errMsg:  "cannot exceed maximum loan amount of Rp.10000000",
+		},
+		{
+			name: "error installment over limit with running loans",
+			req:  validReq(),
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindActiveLoan", mock.Anything, uint(1), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+				expectOffer(repo, userProv, 1000000)
 			},
 			wantErr: true,
-			errMsg:  "cannot exceed maximum loan request",
+			errMsg:  "monthly installment of Rp.550000 exceeds the limit of Rp.350000, choose a longer tenor or a smaller amount",
 		},
 		{
 			name: "error repo create fails",
-			req: &LoanRequest{
-				UserID:            1,
-				EmployeeID:        1,
-				TotalAmount:       5000000,
-				InstallmentAmount: 500000,
-				Reason:            "Emergency",
-			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
-				repo.On("FindActiveLoanByUserID", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+			req:  validReq(),
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindActiveLoan", mock.Anything, uint(1), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+				expectOffer(repo, userProv, 0)
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*loan.Loan")).Return(errors.New("db error"))
 			},
 			wantErr: true,
@@ -113,32 +142,21 @@ func TestService_Create(t *testing.T) {
 		},
 		{
 			name: "error find approval users fails",
-			req: &LoanRequest{
-				UserID:            1,
-				EmployeeID:        1,
-				TotalAmount:       5000000,
-				InstallmentAmount: 500000,
-				Reason:            "Emergency",
-			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
-				repo.On("FindActiveLoanByUserID", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+			req:  validReq(),
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindActiveLoan", mock.Anything, uint(1), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+				expectOffer(repo, userProv, 0)
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*loan.Loan")).Return(nil)
-				userProv.On("FindApprovalUsers", mock.Anything, string(constants.APPROVAL_LOAN)).Return([]uint(nil), errors.New("user service error"))
+				approvalEngine.On("Submit", mock.Anything, mock.Anything).Return([]uint(nil), errors.New("user service error"))
 			},
 			wantErr: true,
 			errMsg:  "user service error",
 		},
 		{
 			name: "error find active loan db error",
-			req: &LoanRequest{
-				UserID:            1,
-				EmployeeID:        1,
-				TotalAmount:       5000000,
-				InstallmentAmount: 500000,
-				Reason:            "Emergency",
-			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
-				repo.On("FindActiveLoanByUserID", mock.Anything, uint(1)).Return(nil, errors.New("db connection error"))
+			req:  validReq(),
+			setupMocks: func(repo *mockRepo, userProv *mockUserProvider, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindActiveLoan", mock.Anything, uint(1), uint(3)).Return(nil, errors.New("db connection error"))
 			},
 			wantErr: true,
 			errMsg:  "db connection error",
@@ -147,8 +165,8 @@ func TestService_Create(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, notif, userProv, _, _ := newTestLoanService()
-			tt.setupMocks(repo, notif, userProv)
+			svc, repo, userProv, notif, approvalEngine, _, _ := newTestLoanService()
+			tt.setupMocks(repo, userProv, notif, approvalEngine)
 
 			err := svc.Create(ctx, tt.req)
 
@@ -240,7 +258,7 @@ func TestService_GetLoanDetail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _ := newTestLoanService()
+			svc, repo, _, _, _, _, _ := newTestLoanService()
 			tt.setupMocks(repo)
 
 			resp, err := svc.GetLoanDetail(ctx, tt.id)
@@ -312,7 +330,7 @@ func TestService_GetLoans(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _ := newTestLoanService()
+			svc, repo, _, _, _, _, _ := newTestLoanService()
 			tt.setupMocks(repo)
 
 			list, meta, err := svc.GetLoans(ctx, tt.filter)
@@ -329,10 +347,13 @@ func TestService_GetLoans(t *testing.T) {
 func TestService_ProcessAction(t *testing.T) {
 	ctx := testutil.CtxWithTenant(1, 1, false)
 
+	timeNow = func() time.Time { return time.Date(2025, 6, 18, 9, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = time.Now })
+
 	tests := []struct {
 		name       string
 		req        *ActionRequest
-		setupMocks func(*mockRepo, *mockNotification)
+		setupMocks func(*mockRepo, *mockNotification, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -343,21 +364,104 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.LoanActionApprove),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(1)).Return(&Loan{
 					ID:                1,
+					CompanyID:         1,
 					UserID:            1,
 					EmployeeID:        1,
 					Status:            constants.LoanStatusPending,
 					TotalAmount:       5000000,
-					InstallmentAmount: 500000,
-					RemainingAmount:   5000000,
+					InstallmentAmount: 550000,
+					RemainingAmount:   5500000,
+					TenorMonths:       10,
+					InterestAmount:    500000,
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.Action == constants.ApprovalActionApprove && req.ActorUserID == 10 && req.RequesterUserID == 1
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
+				// the schedule starts on the payroll after approval
+				repo.On("SaveInstallments", mock.Anything, mock.MatchedBy(func(installments []LoanInstallment) bool {
+					return len(installments) == 10 && installments[0].LoanID == 1 && installments[0].Amount == 550000 &&
+						installments[0].DueDate.Equal(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+				})).Return(nil)
+				// the ledger opens with the principal plus interest owed
+				repo.On("PostTransactions", mock.Anything, mock.MatchedBy(func(transactions []LoanTransaction) bool {
+					return len(transactions) == 1 && transactions[0].Type == constants.LoanTransactionDisbursement &&
+						transactions[0].Amount == 5500000 && *transactions[0].CreatedBy == 10
+				})).Return(nil)
 				repo.On("Update", mock.Anything, mock.AnythingOfType("*loan.Loan")).Return(nil)
 				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "approve loan requested before products",
+			req: &ActionRequest{
+				ID:           9,
+				SuperAdminID: 10,
+				Action:       string(constants.LoanActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindByID", mock.Anything, uint(9)).Return(&Loan{
+					ID:                9,
+					UserID:            1,
+					EmployeeID:        1,
+					Status:            constants.LoanStatusPending,
+					TotalAmount:       1200000,
+					InstallmentAmount: 500000,
+					RemainingAmount:   1200000,
+				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
+				repo.On("SaveInstallments", mock.Anything, mock.MatchedBy(func(installments []LoanInstallment) bool {
+					return len(installments) == 3 && installments[2].Amount == 400000 && installments[2].InterestAmount == 0
+				})).Return(nil)
+				repo.On("PostTransactions", mock.Anything, mock.Anything).Return(nil)
+				repo.On("Update", mock.Anything, mock.MatchedBy(func(l *Loan) bool {
+					return l.TenorMonths == 3
+				})).Return(nil)
+				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "approve waits for the next approval level",
+			req: &ActionRequest{
+				ID:           7,
+				SuperAdminID: 10,
+				Action:       string(constants.LoanActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindByID", mock.Anything, uint(7)).Return(&Loan{
+					ID:          7,
+					UserID:      1,
+					EmployeeID:  1,
+					Status:      constants.LoanStatusPending,
+					TotalAmount: 5000000,
+				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(&approval.Decision{Status: constants.ApprovalStatusPending, NextApproverIDs: []uint{20}}, nil)
+				notif.On("BlastNotification", mock.Anything, []uint{20}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+			},
+			wantErr: false,
+		},
+		{
+			name: "error actor is not an approver",
+			req: &ActionRequest{
+				ID:           8,
+				SuperAdminID: 10,
+				Action:       string(constants.LoanActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+				repo.On("FindByID", mock.Anything, uint(8)).Return(&Loan{
+					ID:     8,
+					UserID: 1,
+					Status: constants.LoanStatusPending,
+				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(nil, errors.New("you are not an approver of the current approval step"))
+			},
+			wantErr: true,
+			errMsg:  "you are not an approver of the current approval step",
+		},
 		{
 			name: "reject success",
 			req: &ActionRequest{
@@ -366,7 +470,7 @@ func TestService_ProcessAction(t *testing.T) {
 				Action:          string(constants.LoanActionReject),
 				RejectionReason: "Not eligible",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(2)).Return(&Loan{
 					ID:                2,
 					UserID:            1,
@@ -376,6 +480,9 @@ func TestService_ProcessAction(t *testing.T) {
 					InstallmentAmount: 500000,
 					RemainingAmount:   5000000,
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.Action == constants.ApprovalActionReject && req.Notes == "Not eligible"
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusRejected}, nil)
 				repo.On("Update", mock.Anything, mock.AnythingOfType("*loan.Loan")).Return(nil)
 				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
@@ -388,7 +495,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.LoanActionApprove),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(3)).Return(&Loan{
 					ID:     3,
 					Status: constants.LoanStatusApproved,
@@ -405,7 +512,7 @@ func TestService_ProcessAction(t *testing.T) {
 				Action:          string(constants.LoanActionReject),
 				RejectionReason: "",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(4)).Return(&Loan{
 					ID:     4,
 					UserID: 1,
@@ -422,7 +529,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.LoanActionApprove),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(99)).Return(nil, errors.New("not found"))
 			},
 			wantErr: true,
@@ -435,7 +542,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       "INVALID",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(5)).Return(&Loan{
 					ID:     5,
 					UserID: 1,
@@ -452,7 +559,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.LoanActionApprove),
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(6)).Return(&Loan{
 					ID:                6,
 					UserID:            1,
@@ -462,6 +569,9 @@ func TestService_ProcessAction(t *testing.T) {
 					InstallmentAmount: 500000,
 					RemainingAmount:   5000000,
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
+				repo.On("SaveInstallments", mock.Anything, mock.Anything).Return(nil)
+				repo.On("PostTransactions", mock.Anything, mock.Anything).Return(nil)
 				repo.On("Update", mock.Anything, mock.AnythingOfType("*loan.Loan")).Return(errors.New("db error"))
 			},
 			wantErr: true,
@@ -471,8 +581,8 @@ func TestService_ProcessAction(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, notif, _, _, _ := newTestLoanService()
-			tt.setupMocks(repo, notif)
+			svc, repo, _, notif, approvalEngine, _, _ := newTestLoanService()
+			tt.setupMocks(repo, notif, approvalEngine)
 
 			err := svc.ProcessAction(ctx, tt.req)
 
@@ -570,7 +680,7 @@ func TestService_Export(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, excel := newTestLoanService()
+			svc, repo, _, _, _, _, excel := newTestLoanService()
 			tt.setupMocks(repo, excel)
 
 			data, err := svc.Export(ctx, tt.filter)
@@ -585,3 +695,177 @@ func TestService_Export(t *testing.T) {
 		})
 	}
 }
+
+func TestService_Simulate(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	timeNow = func() time.Time { return time.Date(2025, 6, 18, 9, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	svc, repo, userProv, _, _, _, _ := newTestLoanService()
+	expectOffer(repo, userProv, 0)
+
+	result, err := svc.Simulate(ctx, &LoanSimulationRequest{EmployeeID: 1, ProductID: 3, TotalAmount: 5000000, TenorMonths: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, 500000.0, result.InterestAmount)
+	assert.Equal(t, 550000.0, result.InstallmentAmount)
+	assert.Equal(t, 4950000.0, result.DisbursedAmount)
+	assert.Equal(t, 5500000.0, result.TotalPayable)
+	assert.Equal(t, 10000000.0, result.MaxAmount)
+	require.Len(t, result.Schedule, 10)
+	assert.Equal(t, "2025-07-01", result.Schedule[0].DueDate)
+	assert.Equal(t, "2026-04-01", result.Schedule[9].DueDate)
+}
+
+func TestService_DeferInstallment(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	timeNow = func() time.Time { return time.Date(2025, 8, 10, 9, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	runningLoan := func() *Loan {
+		installments := buildSchedule(1, 1, 1500000, 0, 3, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+		for i := range installments {
+			installments[i].ID = uint(i + 1)
+		}
+		installments[0].Status = constants.LoanInstallmentPaid
+		return &Loan{ID: 1, CompanyID: 1, Status: constants.LoanStatusApproved, Installments: installments}
+	}
+
+	tests := []struct {
+		name       string
+		req        *DeferInstallmentRequest
+		loan       *Loan
+		setupMocks func(*mockRepo)
+		errMsg     string
+	}{
+		{
+			name: "success",
+			req:  &DeferInstallmentRequest{LoanID: 1, InstallmentID: 2, Reason: "Biaya rumah sakit"},
+			loan: runningLoan(),
+			setupMocks: func(repo *mockRepo) {
+				// august is skipped and repaid after the last installment in october
+				repo.On("SaveInstallments", mock.Anything, mock.MatchedBy(func(installments []LoanInstallment) bool {
+					return len(installments) == 2 &&
+						installments[0].ID == 2 && installments[0].Status == constants.LoanInstallmentDeferred && installments[0].Notes == "Biaya rumah sakit" &&
+						installments[1].ID == 0 && installments[1].Sequence == 4 && installments[1].Amount == 500000 &&
+						installments[1].Status == constants.LoanInstallmentPending &&
+						installments[1].DueDate.Equal(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC))
+				})).Return(nil)
+			},
+		},
+		{
+			name:   "error installment already paid",
+			req:    &DeferInstallmentRequest{LoanID: 1, InstallmentID: 1, Reason: "Biaya rumah sakit"},
+			loan:   runningLoan(),
+			errMsg: "cannot defer installment with status PAID",
+		},
+		{
+			name:   "error installment of another loan",
+			req:    &DeferInstallmentRequest{LoanID: 1, InstallmentID: 99, Reason: "Biaya rumah sakit"},
+			loan:   runningLoan(),
+			errMsg: "installment not found",
+		},
+		{
+			name:   "error loan not running",
+			req:    &DeferInstallmentRequest{LoanID: 1, InstallmentID: 2, Reason: "Biaya rumah sakit"},
+			loan:   &Loan{ID: 1, Status: constants.LoanStatusPaidOff},
+			errMsg: "cannot defer installment of loan with status PAID_OFF",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, _, _, _, _ := newTestLoanService()
+			repo.On("FindByID", mock.Anything, uint(1)).Return(tt.loan, nil)
+			if tt.setupMocks != nil {
+				tt.setupMocks(repo)
+			}
+
+			err := svc.DeferInstallment(ctx, tt.req)
+
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				require.NoError(t, err)
+				repo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestService_EarlyPayoff(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("success waives the remaining interest", func(t *testing.T) {
+		svc, repo, _, _, _, _, _ := newTestLoanService()
+
+		installments := buildSchedule(1, 1, 1000000, 30000, 3, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+		installments[0].Status = constants.LoanInstallmentPaid
+		repo.On("FindByID", mock.Anything, uint(1)).Return(&Loan{
+			ID: 1, Status: constants.LoanStatusApproved, RemainingAmount: 686667, Installments: installments,
+		}, nil)
+		repo.On("SaveInstallments", mock.Anything, mock.MatchedBy(func(settled []LoanInstallment) bool {
+			return len(settled) == 2 && settled[0].Status == constants.LoanInstallmentSettled && settled[0].PaidAt != nil
+		})).Return(nil)
+		repo.On("PostTransactions", mock.Anything, mock.MatchedBy(func(transactions []LoanTransaction) bool {
+			return len(transactions) == 2 &&
+				transactions[0].Type == constants.LoanTransactionRepayment && transactions[0].Amount == 666667 &&
+				transactions[1].Type == constants.LoanTransactionWriteOff && transactions[1].Amount == 20000
+		})).Return(nil)
+
+		result, err := svc.EarlyPayoff(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 666667.0, result.PayoffAmount)
+		assert.Equal(t, 20000.0, result.WaivedInterest)
+	})
+
+	t.Run("error loan not approved", func(t *testing.T) {
+		svc, repo, _, _, _, _, _ := newTestLoanService()
+		repo.On("FindByID", mock.Anything, uint(2)).Return(&Loan{ID: 2, Status: constants.LoanStatusPending}, nil)
+
+		_, err := svc.EarlyPayoff(ctx, 2)
+		require.Error(t, err)
+		assert.Equal(t, "cannot pay off loan with status PENDING", err.Error())
+	})
+}
+
+func TestService_SaveProduct(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	req := &SaveLoanProductRequest{Name: "Kasbon", MaxSalaryMultiplier: 1, MaxTenorMonths: 6, MaxInstallmentPercent: 30, IsActive: true}
+
+	t.Run("create", func(t *testing.T) {
+		svc, repo, _, _, _, _, _ := newTestLoanService()
+		repo.On("SaveProduct", mock.Anything, mock.MatchedBy(func(p *LoanProduct) bool {
+			return p.ID == 0 && p.CompanyID == 1 && p.Name == "Kasbon" && p.IsActive
+		})).Return(nil)
+
+		require.NoError(t, svc.SaveProduct(ctx, req))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		svc, repo, _, _, _, _, _ := newTestLoanService()
+		repo.On("FindProductByID", mock.Anything, uint(3)).Return(testProduct(), nil)
+		repo.On("SaveProduct", mock.Anything, mock.MatchedBy(func(p *LoanProduct) bool {
+			return p.ID == 3 && p.MaxTenorMonths == 6
+		})).Return(nil)
+
+		update := *req
+		update.ID = 3
+		require.NoError(t, svc.SaveProduct(ctx, &update))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("error product not found", func(t *testing.T) {
+		svc, repo, _, _, _, _, _ := newTestLoanService()
+		repo.On("FindProductByID", mock.Anything, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+		update := *req
+		update.ID = 9
+		require.Error(t, svc.SaveProduct(ctx, &update))
+	})
+}
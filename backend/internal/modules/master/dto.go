@@ -5,9 +5,35 @@ type LookupResponse struct {
 	Name string `json:"name"`
 }
 
+type WorkLocationRequest struct {
+	Name      string  `json:"name" validate:"required,max=100"`
+	Address   string  `json:"address" validate:"max=255"`
+	Latitude  float64 `json:"latitude" validate:"required,latitude"`
+	Longitude float64 `json:"longitude" validate:"required,longitude"`
+}
+
+type WorkLocationResponse struct {
+	ID        uint    `json:"id"`
+	Name      string  `json:"name"`
+	Address   string  `json:"address"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
 type LookupLeaveTypeResponse struct {
 	ID           uint   `json:"id"`
 	Name         string `json:"name"`
 	DefaultQuota int    `json:"default_quota"`
 	IsDeducted   bool   `json:"is_deducted"`
 }
+
+type HolidayRequest struct {
+	Date string `json:"date" validate:"required"`
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type HolidayResponse struct {
+	ID   uint   `json:"id"`
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
@@ -21,6 +21,26 @@ type LeaveType struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+type WorkLocation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Address   string    `json:"address"`
+	Latitude  float64   `gorm:"type:decimal(10,8);not null" json:"latitude"`
+	Longitude float64   `gorm:"type:decimal(11,8);not null" json:"longitude"`
+	CompanyID uint      `gorm:"index;not null" json:"company_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Holiday is a company day off, such as a public holiday or collective leave day.
+type Holiday struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CompanyID uint      `gorm:"uniqueIndex:idx_ref_holidays_company_date;not null" json:"company_id"`
+	Date      time.Time `gorm:"type:date;uniqueIndex:idx_ref_holidays_company_date;not null" json:"date"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 func (Shift) TableName() string {
 	return "ref_shifts"
 }
@@ -28,3 +48,11 @@ func (Shift) TableName() string {
 func (LeaveType) TableName() string {
 	return "ref_leave_types"
 }
+
+func (WorkLocation) TableName() string {
+	return "ref_work_locations"
+}
+
+func (Holiday) TableName() string {
+	return "ref_holidays"
+}
@@ -4,6 +4,8 @@ import (
 	"basekarya-backend/pkg/logger"
 	"basekarya-backend/pkg/response"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -38,3 +40,85 @@ func (h *Handler) GetLeaveTypes(ctx echo.Context) error {
 
 	return response.NewResponses[any](ctx, http.StatusOK, "Get Leave Types Successfully", resp, nil, nil)
 }
+
+func (h *Handler) GetWorkLocations(ctx echo.Context) error {
+	resp, err := h.service.GetAllWorkLocations(ctx.Request().Context())
+	if err != nil {
+		logger.Errorw("get work locations failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Work Locations Successfully", resp, nil, nil)
+}
+
+func (h *Handler) CreateWorkLocation(ctx echo.Context) error {
+	var req WorkLocationRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	resp, err := h.service.CreateWorkLocation(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("create work location failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusCreated, "Create Work Location Successfully", resp, nil, nil)
+}
+
+func (h *Handler) GetHolidays(ctx echo.Context) error {
+	year, _ := strconv.Atoi(ctx.QueryParam("year"))
+	if year < 1 {
+		year = time.Now().Year()
+	}
+
+	resp, err := h.service.GetHolidays(ctx.Request().Context(), year)
+	if err != nil {
+		logger.Errorw("get holidays failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Holidays Successfully", resp, nil, nil)
+}
+
+func (h *Handler) CreateHoliday(ctx echo.Context) error {
+	var req HolidayRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	resp, err := h.service.CreateHoliday(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("create holiday failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusCreated, "Create Holiday Successfully", resp, nil, nil)
+}
+
+func (h *Handler) DeleteHoliday(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	if err := h.service.DeleteHoliday(ctx.Request().Context(), uint(id)); err != nil {
+		logger.Errorw("delete holiday failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Delete Holiday Successfully", nil, nil, nil)
+}
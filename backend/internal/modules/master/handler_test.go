@@ -89,3 +89,95 @@ func TestHandler_GetLeaveTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_CreateWorkLocation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: map[string]interface{}{"name": "Head Office", "latitude": -6.2, "longitude": 106.8},
+			setupMocks: func(svc *mockService) {
+				svc.On("CreateWorkLocation", mock.Anything, mock.Anything).Return(&WorkLocationResponse{ID: 1, Name: "Head Office"}, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "invalid coordinates",
+			body:       map[string]interface{}{"name": "Head Office", "latitude": -200, "longitude": 106.8},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: map[string]interface{}{"name": "Head Office", "latitude": -6.2, "longitude": 106.8},
+			setupMocks: func(svc *mockService) {
+				svc.On("CreateWorkLocation", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/master/work-locations", tt.body)
+			rec, err := at.Execute(handler.CreateWorkLocation)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_CreateHoliday(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: map[string]interface{}{"date": "2026-08-17", "name": "Hari Kemerdekaan"},
+			setupMocks: func(svc *mockService) {
+				svc.On("CreateHoliday", mock.Anything, mock.Anything).Return(&HolidayResponse{ID: 1, Date: "2026-08-17", Name: "Hari Kemerdekaan"}, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "missing name",
+			body:       map[string]interface{}{"date": "2026-08-17"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: map[string]interface{}{"date": "17-08-2026", "name": "Hari Kemerdekaan"},
+			setupMocks: func(svc *mockService) {
+				svc.On("CreateHoliday", mock.Anything, mock.Anything).Return(nil, errors.New("invalid date format"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/master/holidays", tt.body)
+			rec, err := at.Execute(handler.CreateHoliday)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
@@ -33,6 +33,34 @@ func (m *mockRepo) FindShiftByName(ctx context.Context, name string) (*Shift, er
 	return args.Get(0).(*Shift), args.Error(1)
 }
 
+func (m *mockRepo) FindAllWorkLocations(ctx context.Context) ([]WorkLocation, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]WorkLocation), args.Error(1)
+}
+
+func (m *mockRepo) CreateWorkLocation(ctx context.Context, location *WorkLocation) error {
+	return m.Called(ctx, location).Error(0)
+}
+
+func (m *mockRepo) FindHolidays(ctx context.Context, from, to time.Time) ([]Holiday, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Holiday), args.Error(1)
+}
+
+func (m *mockRepo) CreateHoliday(ctx context.Context, holiday *Holiday) error {
+	return m.Called(ctx, holiday).Error(0)
+}
+
+func (m *mockRepo) DeleteHoliday(ctx context.Context, id uint) error {
+	return m.Called(ctx, id).Error(0)
+}
+
 func (m *mockRepo) SeedDefaults(ctx context.Context, companyID uint) error {
 	return m.Called(ctx, companyID).Error(0)
 }
@@ -70,6 +98,42 @@ func (m *mockService) GetAllLeaveTypes(ctx context.Context) ([]LookupLeaveTypeRe
 	return args.Get(0).([]LookupLeaveTypeResponse), args.Error(1)
 }
 
+func (m *mockService) GetAllWorkLocations(ctx context.Context) ([]WorkLocationResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]WorkLocationResponse), args.Error(1)
+}
+
+func (m *mockService) CreateWorkLocation(ctx context.Context, req *WorkLocationRequest) (*WorkLocationResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*WorkLocationResponse), args.Error(1)
+}
+
+func (m *mockService) GetHolidays(ctx context.Context, year int) ([]HolidayResponse, error) {
+	args := m.Called(ctx, year)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]HolidayResponse), args.Error(1)
+}
+
+func (m *mockService) CreateHoliday(ctx context.Context, req *HolidayRequest) (*HolidayResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*HolidayResponse), args.Error(1)
+}
+
+func (m *mockService) DeleteHoliday(ctx context.Context, id uint) error {
+	return m.Called(ctx, id).Error(0)
+}
+
 func newTestMasterService() (Service, *mockRepo, *mockCacheProvider) {
 	repo := new(mockRepo)
 	cache := new(mockCacheProvider)
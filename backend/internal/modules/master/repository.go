@@ -2,6 +2,7 @@ package master
 
 import (
 	"context"
+	"time"
 
 	"basekarya-backend/pkg/utils"
 
@@ -12,6 +13,11 @@ type Repository interface {
 	FindAllShifts(ctx context.Context) ([]Shift, error)
 	FindAllLeaveTypes(ctx context.Context) ([]LeaveType, error)
 	FindShiftByName(ctx context.Context, name string) (*Shift, error)
+	FindAllWorkLocations(ctx context.Context) ([]WorkLocation, error)
+	CreateWorkLocation(ctx context.Context, location *WorkLocation) error
+	FindHolidays(ctx context.Context, from, to time.Time) ([]Holiday, error)
+	CreateHoliday(ctx context.Context, holiday *Holiday) error
+	DeleteHoliday(ctx context.Context, id uint) error
 	SeedDefaults(ctx context.Context, companyID uint) error
 }
 type repository struct {
@@ -52,6 +58,46 @@ func (r *repository) FindShiftByName(ctx context.Context, name string) (*Shift,
 	return &shift, nil
 }
 
+func (r *repository) FindAllWorkLocations(ctx context.Context) ([]WorkLocation, error) {
+	var locations []WorkLocation
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	if err := db.Model(&WorkLocation{}).Order("name ASC").Find(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+func (r *repository) CreateWorkLocation(ctx context.Context, location *WorkLocation) error {
+	return utils.GetDBFromContext(ctx, r.db).Create(location).Error
+}
+
+func (r *repository) FindHolidays(ctx context.Context, from, to time.Time) ([]Holiday, error) {
+	var holidays []Holiday
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	if err := db.Model(&Holiday{}).Where("date BETWEEN ? AND ?", from, to).Order("date ASC").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+func (r *repository) CreateHoliday(ctx context.Context, holiday *Holiday) error {
+	return utils.GetDBFromContext(ctx, r.db).Create(holiday).Error
+}
+
+func (r *repository) DeleteHoliday(ctx context.Context, id uint) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	result := db.Delete(&Holiday{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (r *repository) SeedDefaults(ctx context.Context, companyID uint) error {
 	db := utils.GetDBFromContext(ctx, r.db)
 
@@ -2,6 +2,7 @@ package master
 
 import (
 	"testing"
+	"time"
 
 	"basekarya-backend/internal/modules/department"
 	"basekarya-backend/internal/testutil"
@@ -16,6 +17,8 @@ func setupMasterTestDB(t *testing.T) *testutil.TestDB {
 		&department.Department{},
 		&Shift{},
 		&LeaveType{},
+		&WorkLocation{},
+		&Holiday{},
 	)
 	t.Cleanup(tdb.Close)
 	return tdb
@@ -102,3 +105,44 @@ func TestRepoMaster_SeedDefaults(t *testing.T) {
 	types, _ := repo.FindAllLeaveTypes(ctx)
 	assert.Len(t, types, 3)
 }
+
+func TestRepoMaster_FindAllWorkLocations(t *testing.T) {
+	tdb := setupMasterTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	require.NoError(t, repo.CreateWorkLocation(ctx, &WorkLocation{Name: "Warehouse", Latitude: -6.3, Longitude: 106.9, CompanyID: 1}))
+	require.NoError(t, repo.CreateWorkLocation(ctx, &WorkLocation{Name: "Head Office", Latitude: -6.2, Longitude: 106.8, CompanyID: 1}))
+	require.NoError(t, repo.CreateWorkLocation(ctx, &WorkLocation{Name: "Other Tenant", Latitude: -6.1, Longitude: 106.7, CompanyID: 2}))
+
+	locations, err := repo.FindAllWorkLocations(ctx)
+
+	require.NoError(t, err)
+	require.Len(t, locations, 2)
+	assert.Equal(t, "Head Office", locations[0].Name)
+	assert.Equal(t, "Warehouse", locations[1].Name)
+}
+
+func TestRepoMaster_Holidays(t *testing.T) {
+	tdb := setupMasterTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	newYear := &Holiday{CompanyID: 1, Date: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Name: "Tahun Baru"}
+	require.NoError(t, repo.CreateHoliday(ctx, newYear))
+	require.NoError(t, repo.CreateHoliday(ctx, &Holiday{CompanyID: 1, Date: time.Date(2026, 8, 17, 0, 0, 0, 0, time.UTC), Name: "Hari Kemerdekaan"}))
+	require.NoError(t, repo.CreateHoliday(ctx, &Holiday{CompanyID: 2, Date: time.Date(2026, 8, 17, 0, 0, 0, 0, time.UTC), Name: "Other Tenant"}))
+
+	holidays, err := repo.FindHolidays(ctx, time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, holidays, 1)
+	assert.Equal(t, "Hari Kemerdekaan", holidays[0].Name)
+
+	// holidays of another company cannot be deleted
+	assert.Error(t, repo.DeleteHoliday(testutil.CtxWithTenant(2, 1, false), newYear.ID))
+	require.NoError(t, repo.DeleteHoliday(ctx, newYear.ID))
+
+	holidays, err = repo.FindHolidays(ctx, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Len(t, holidays, 1)
+}
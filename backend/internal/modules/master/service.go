@@ -2,8 +2,10 @@ package master
 
 import (
 	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,6 +14,11 @@ import (
 type Service interface {
 	GetAllShifts(ctx context.Context) ([]LookupResponse, error)
 	GetAllLeaveTypes(ctx context.Context) ([]LookupLeaveTypeResponse, error)
+	GetAllWorkLocations(ctx context.Context) ([]WorkLocationResponse, error)
+	CreateWorkLocation(ctx context.Context, req *WorkLocationRequest) (*WorkLocationResponse, error)
+	GetHolidays(ctx context.Context, year int) ([]HolidayResponse, error)
+	CreateHoliday(ctx context.Context, req *HolidayRequest) (*HolidayResponse, error)
+	DeleteHoliday(ctx context.Context, id uint) error
 }
 
 type service struct {
@@ -104,3 +111,93 @@ func (s *service) GetAllLeaveTypes(ctx context.Context) ([]LookupLeaveTypeRespon
 
 	return results, nil
 }
+
+func (s *service) GetAllWorkLocations(ctx context.Context) ([]WorkLocationResponse, error) {
+	data, err := s.repo.FindAllWorkLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []WorkLocationResponse{}
+	for _, d := range data {
+		results = append(results, toWorkLocationResponse(&d))
+	}
+
+	return results, nil
+}
+
+func (s *service) CreateWorkLocation(ctx context.Context, req *WorkLocationRequest) (*WorkLocationResponse, error) {
+	location := WorkLocation{
+		Name:      req.Name,
+		Address:   req.Address,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		CompanyID: utils.GetCompanyIDFromCtx(ctx),
+	}
+
+	if err := s.repo.CreateWorkLocation(ctx, &location); err != nil {
+		return nil, err
+	}
+
+	result := toWorkLocationResponse(&location)
+	return &result, nil
+}
+
+func (s *service) GetHolidays(ctx context.Context, year int) ([]HolidayResponse, error) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	data, err := s.repo.FindHolidays(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []HolidayResponse{}
+	for _, d := range data {
+		results = append(results, toHolidayResponse(&d))
+	}
+
+	return results, nil
+}
+
+func (s *service) CreateHoliday(ctx context.Context, req *HolidayRequest) (*HolidayResponse, error) {
+	date, err := time.Parse(constants.DefaultTimeFormat, req.Date)
+	if err != nil {
+		return nil, errors.New("invalid date format")
+	}
+
+	holiday := Holiday{
+		CompanyID: utils.GetCompanyIDFromCtx(ctx),
+		Date:      date,
+		Name:      req.Name,
+	}
+
+	if err := s.repo.CreateHoliday(ctx, &holiday); err != nil {
+		return nil, err
+	}
+
+	result := toHolidayResponse(&holiday)
+	return &result, nil
+}
+
+func (s *service) DeleteHoliday(ctx context.Context, id uint) error {
+	return s.repo.DeleteHoliday(ctx, id)
+}
+
+func toHolidayResponse(holiday *Holiday) HolidayResponse {
+	return HolidayResponse{
+		ID:   holiday.ID,
+		Date: holiday.Date.Format(constants.DefaultTimeFormat),
+		Name: holiday.Name,
+	}
+}
+
+func toWorkLocationResponse(location *WorkLocation) WorkLocationResponse {
+	return WorkLocationResponse{
+		ID:        location.ID,
+		Name:      location.Name,
+		Address:   location.Address,
+		Latitude:  location.Latitude,
+		Longitude: location.Longitude,
+	}
+}
@@ -1,7 +1,9 @@
 package master
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"basekarya-backend/internal/testutil"
 
@@ -99,3 +101,120 @@ func TestService_GetAllLeaveTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestService_CreateWorkLocation(t *testing.T) {
+	ctx := testutil.CtxWithTenant(7, 1, false)
+
+	tests := []struct {
+		name       string
+		setupMocks func(*mockRepo)
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			setupMocks: func(repo *mockRepo) {
+				repo.On("CreateWorkLocation", mock.Anything, mock.MatchedBy(func(l *WorkLocation) bool {
+					return l.CompanyID == 7 && l.Name == "Head Office"
+				})).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "repo error",
+			setupMocks: func(repo *mockRepo) {
+				repo.On("CreateWorkLocation", mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _ := newTestMasterService()
+			tt.setupMocks(repo)
+
+			res, err := svc.CreateWorkLocation(ctx, &WorkLocationRequest{
+				Name:      "Head Office",
+				Latitude:  -6.2,
+				Longitude: 106.8,
+			})
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, res)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "Head Office", res.Name)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_GetHolidays(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, repo, _ := newTestMasterService()
+
+	repo.On("FindHolidays", mock.Anything, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)).
+		Return([]Holiday{{ID: 1, Date: time.Date(2026, 8, 17, 0, 0, 0, 0, time.UTC), Name: "Hari Kemerdekaan"}}, nil)
+
+	res, err := svc.GetHolidays(ctx, 2026)
+
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "2026-08-17", res[0].Date)
+	repo.AssertExpectations(t)
+}
+
+func TestService_CreateHoliday(t *testing.T) {
+	ctx := testutil.CtxWithTenant(7, 1, false)
+
+	tests := []struct {
+		name       string
+		req        *HolidayRequest
+		setupMocks func(*mockRepo)
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			req:  &HolidayRequest{Date: "2026-08-17", Name: "Hari Kemerdekaan"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("CreateHoliday", mock.Anything, mock.MatchedBy(func(h *Holiday) bool {
+					return h.CompanyID == 7 && h.Date.Equal(time.Date(2026, 8, 17, 0, 0, 0, 0, time.UTC))
+				})).Return(nil)
+			},
+		},
+		{
+			name:       "invalid date",
+			req:        &HolidayRequest{Date: "17-08-2026", Name: "Hari Kemerdekaan"},
+			setupMocks: func(repo *mockRepo) {},
+			wantErr:    true,
+		},
+		{
+			name: "repo error",
+			req:  &HolidayRequest{Date: "2026-08-17", Name: "Hari Kemerdekaan"},
+			setupMocks: func(repo *mockRepo) {
+				repo.On("CreateHoliday", mock.Anything, mock.Anything).Return(errors.New("duplicate entry"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _ := newTestMasterService()
+			tt.setupMocks(repo)
+
+			res, err := svc.CreateHoliday(ctx, tt.req)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, res)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "2026-08-17", res.Date)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
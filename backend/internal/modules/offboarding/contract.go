@@ -0,0 +1,44 @@
+package offboarding
+
+import (
+	"basekarya-backend/internal/modules/asset"
+	"basekarya-backend/internal/modules/leave"
+	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/tax"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"context"
+	"time"
+)
+
+type UserProvider interface {
+	FindEmployeeByID(ctx context.Context, id uint) (*user.Employee, error)
+	TerminateEmployee(ctx context.Context, emp *user.Employee, terminationDate time.Time, reason constants.TerminationReason) error
+}
+
+type LeaveProvider interface {
+	GetUnusedLeave(ctx context.Context, employeeID uint, year int) ([]leave.UnusedLeave, error)
+	PayOutUnusedLeave(ctx context.Context, employeeID uint, year int, referenceID uint) error
+}
+
+type LoanProvider interface {
+	GetOutstandingLoans(ctx context.Context, employeeID uint) ([]loan.OutstandingLoan, error)
+	Settle(ctx context.Context, req *loan.LoanSettlementRequest) error
+}
+
+type AssetProvider interface {
+	GetActiveAssignments(ctx context.Context, employeeID uint) ([]asset.AssetAssignmentListResponse, error)
+}
+
+type PayrollProvider interface {
+	HasPayroll(ctx context.Context, employeeID uint, month, year int) (bool, error)
+	GetTaxEntries(ctx context.Context, employeeID uint, year int) ([]tax.MonthlyTaxEntry, error)
+}
+
+type TaxProvider interface {
+	SettleTermination(ctx context.Context, req *tax.TerminationTaxRequest) (*tax.TerminationTax, error)
+}
+
+type SessionRevoker interface {
+	RevokeUser(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,75 @@
+package offboarding
+
+import (
+	"basekarya-backend/internal/modules/asset"
+	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/pkg/constants"
+	"time"
+)
+
+type SettlementFilter struct {
+	Status string
+	Page   int
+	Limit  int
+}
+
+type CreateSettlementRequest struct {
+	ActorID         uint   `json:"-"`
+	EmployeeID      uint   `json:"employee_id" validate:"required"`
+	Reason          string `json:"reason" validate:"required,oneof=RESIGNATION ABSENCE MISCONDUCT EFFICIENCY EFFICIENCY_LOSS LONG_ILLNESS RETIREMENT DEATH"`
+	LastWorkingDate string `json:"last_working_date" validate:"required"` // YYYY-MM-DD
+	Notes           string `json:"notes"`
+}
+
+type SettlementActionRequest struct {
+	ID      uint `json:"-"`
+	ActorID uint `json:"-"`
+}
+
+type SettlementListResponse struct {
+	ID              uint                        `json:"id"`
+	EmployeeID      uint                        `json:"employee_id"`
+	EmployeeName    string                      `json:"employee_name"`
+	EmployeeNIK     string                      `json:"employee_nik"`
+	Reason          constants.TerminationReason `json:"reason"`
+	LastWorkingDate string                      `json:"last_working_date"`
+	NetAmount       float64                     `json:"net_amount"`
+	Status          constants.SettlementStatus  `json:"status"`
+	CreatedAt       time.Time                   `json:"created_at"`
+}
+
+type SettlementItemResponse struct {
+	Code   string                      `json:"code"`
+	Title  string                      `json:"title"`
+	Type   constants.PayrollDetailType `json:"type"`
+	Amount float64                     `json:"amount"`
+	LoanID *uint                       `json:"loan_id"`
+}
+
+// SettlementDetailResponse is a settlement with what still blocks it: the assets to return and, while it is a
+// draft, the loans it repays.
+type SettlementDetailResponse struct {
+	ID                   uint                        `json:"id"`
+	EmployeeID           uint                        `json:"employee_id"`
+	EmployeeName         string                      `json:"employee_name"`
+	EmployeeNIK          string                      `json:"employee_nik"`
+	Reason               constants.TerminationReason `json:"reason"`
+	LastWorkingDate      string                      `json:"last_working_date"`
+	BaseSalary           float64                     `json:"base_salary"`
+	TenureMonths         int                         `json:"tenure_months"`
+	WorkedDays           int                         `json:"worked_days"`
+	PeriodDays           int                         `json:"period_days"`
+	UnusedLeaveDays      float64                     `json:"unused_leave_days"`
+	TotalEarnings        float64                     `json:"total_earnings"`
+	TotalDeductions      float64                     `json:"total_deductions"`
+	NetAmount            float64                     `json:"net_amount"`
+	UncoveredLoanBalance float64                     `json:"uncovered_loan_balance"`
+	Status               constants.SettlementStatus  `json:"status"`
+	Notes                string                      `json:"notes"`
+	FinalizedAt          *time.Time                  `json:"finalized_at"`
+	CreatedAt            time.Time                   `json:"created_at"`
+
+	Items            []SettlementItemResponse            `json:"items"`
+	AssetChecklist   []asset.AssetAssignmentListResponse `json:"asset_checklist"`
+	OutstandingLoans []loan.OutstandingLoan              `json:"outstanding_loans"`
+}
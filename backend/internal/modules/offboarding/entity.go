@@ -0,0 +1,69 @@
+package offboarding
+
+import (
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"time"
+)
+
+// Settlement is the final settlement of an employee leaving the company. A draft is recalculated when it is
+// finalized, finalizing pays out the leave, repays the loans and ends the employment.
+type Settlement struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	CompanyID       uint                        `gorm:"index;not null" json:"company_id"`
+	EmployeeID      uint                        `gorm:"not null" json:"employee_id"`
+	Reason          constants.TerminationReason `gorm:"type:varchar(30);not null" json:"reason"`
+	LastWorkingDate time.Time                   `gorm:"type:date;not null" json:"last_working_date"`
+
+	BaseSalary      float64 `gorm:"type:decimal(15,2)" json:"base_salary"`
+	TenureMonths    int     `json:"tenure_months"`
+	WorkedDays      int     `json:"worked_days"`
+	PeriodDays      int     `json:"period_days"`
+	UnusedLeaveDays float64 `gorm:"type:decimal(6,2)" json:"unused_leave_days"`
+
+	TotalEarnings   float64 `gorm:"type:decimal(15,2)" json:"total_earnings"`
+	TotalDeductions float64 `gorm:"type:decimal(15,2)" json:"total_deductions"`
+	NetAmount       float64 `gorm:"type:decimal(15,2)" json:"net_amount"`
+	// loan balance the settlement could not cover, left on the loan ledger
+	UncoveredLoanBalance float64 `gorm:"type:decimal(15,2)" json:"uncovered_loan_balance"`
+
+	Status      constants.SettlementStatus `gorm:"type:varchar(15);default:'DRAFT'" json:"status"`
+	Notes       string                     `gorm:"type:text" json:"notes"`
+	CreatedBy   *uint                      `json:"created_by"`
+	FinalizedBy *uint                      `json:"finalized_by"`
+	FinalizedAt *time.Time                 `json:"finalized_at"`
+
+	Employee *user.Employee   `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
+	Items    []SettlementItem `gorm:"foreignKey:SettlementID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+}
+
+func (Settlement) TableName() string { return "offboarding_settlements" }
+
+// SettlementItem is an earning or a deduction of a final settlement.
+type SettlementItem struct {
+	ID           uint                        `gorm:"primaryKey" json:"id"`
+	SettlementID uint                        `gorm:"not null" json:"settlement_id"`
+	CompanyID    uint                        `gorm:"index;not null" json:"company_id"`
+	Code         string                      `gorm:"type:varchar(30);not null" json:"code"`
+	Title        string                      `gorm:"type:varchar(150);not null" json:"title"`
+	Type         constants.PayrollDetailType `gorm:"type:varchar(20);not null" json:"type"`
+	Amount       float64                     `gorm:"type:decimal(15,2);not null" json:"amount"`
+	// set on loan deductions, the loan the deduction repays
+	LoanID *uint `json:"loan_id"`
+}
+
+func (SettlementItem) TableName() string { return "offboarding_settlement_items" }
+
+// Settlement item codes.
+const (
+	ItemProratedSalary = "PRORATED_SALARY"
+	ItemLeavePayout    = "LEAVE_PAYOUT"
+	ItemSeverancePay   = "SEVERANCE_PAY"
+	ItemServicePay     = "SERVICE_PAY"
+	ItemPPh21          = "PPH21"
+	ItemSeveranceTax   = "PPH21_SEVERANCE"
+	ItemLoan           = "LOAN"
+)
@@ -0,0 +1,134 @@
+package offboarding
+
+import (
+	"basekarya-backend/pkg/logger"
+	"basekarya-backend/pkg/response"
+	"basekarya-backend/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service}
+}
+
+func (h *Handler) Create(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req CreateSettlementRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.ActorID = userContext.UserID
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	data, err := h.service.Create(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("settlement create failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusCreated, "Settlement created successfully", data, nil, nil)
+}
+
+func (h *Handler) GetAll(ctx echo.Context) error {
+	page, _ := strconv.Atoi(ctx.QueryParam("page"))
+	limit, _ := strconv.Atoi(ctx.QueryParam("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	filter := SettlementFilter{
+		Status: ctx.QueryParam("status"),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	data, meta, err := h.service.GetList(ctx.Request().Context(), filter)
+	if err != nil {
+		logger.Errorw("get settlements failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Settlements List Success", data, nil, meta)
+}
+
+func (h *Handler) GetDetail(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	data, err := h.service.GetDetail(ctx.Request().Context(), uint(id))
+	if err != nil {
+		logger.Errorw("get settlement detail failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Settlement Detail Success", data, nil, nil)
+}
+
+func (h *Handler) Finalize(ctx echo.Context) error {
+	req, err := actionRequest(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	err = h.service.Finalize(ctx.Request().Context(), req)
+	if err != nil {
+		logger.Errorw("settlement finalize failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Settlement Finalize Success", nil, nil, nil)
+}
+
+func (h *Handler) Cancel(ctx echo.Context) error {
+	req, err := actionRequest(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	err = h.service.Cancel(ctx.Request().Context(), req)
+	if err != nil {
+		logger.Errorw("settlement cancel failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Settlement Cancel Success", nil, nil, nil)
+}
+
+func actionRequest(ctx echo.Context) (*SettlementActionRequest, error) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SettlementActionRequest{ID: uint(id), ActorID: userContext.UserID}, nil
+}
@@ -0,0 +1,114 @@
+package offboarding
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func adminClaims() *infrastructure.MyClaims {
+	return &infrastructure.MyClaims{UserID: 9, CompanyID: 1, Permissions: []string{constants.MANAGE_OFFBOARDING}}
+}
+
+func TestHandler_Create(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: CreateSettlementRequest{EmployeeID: 1, Reason: "RESIGNATION", LastWorkingDate: "2025-08-15"},
+			setupMocks: func(svc *mockService) {
+				svc.On("Create", mock.Anything, mock.MatchedBy(func(req *CreateSettlementRequest) bool {
+					return req.ActorID == 9
+				})).Return(&SettlementDetailResponse{ID: 3}, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "invalid reason",
+			body:       CreateSettlementRequest{EmployeeID: 1, Reason: "LAYOFF", LastWorkingDate: "2025-08-15"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: CreateSettlementRequest{EmployeeID: 1, Reason: "RESIGNATION", LastWorkingDate: "2025-08-15"},
+			setupMocks: func(svc *mockService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, errors.New("employee already has a draft settlement"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			rec, err := testutil.NewAPITest(t, http.MethodPost, "/api/offboarding/settlements", tt.body).
+				WithAuthContext(adminClaims()).
+				Execute(handler.Create)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_Finalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			id:   "3",
+			setupMocks: func(svc *mockService) {
+				svc.On("Finalize", mock.Anything, &SettlementActionRequest{ID: 3, ActorID: 9}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "assets not returned",
+			id:   "3",
+			setupMocks: func(svc *mockService) {
+				svc.On("Finalize", mock.Anything, mock.Anything).Return(errors.New("employee has not returned: Laptop"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid id",
+			id:         "abc",
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			rec, err := testutil.NewAPITest(t, http.MethodPost, "/api/offboarding/settlements/"+tt.id+"/finalize", nil).
+				WithAuthContext(adminClaims()).
+				WithPathParams(map[string]string{"id": tt.id}).
+				Execute(handler.Finalize)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
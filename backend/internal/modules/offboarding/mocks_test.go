@@ -0,0 +1,185 @@
+package offboarding
+
+import (
+	"context"
+	"time"
+
+	"basekarya-backend/internal/modules/asset"
+	"basekarya-backend/internal/modules/leave"
+	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/tax"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) Create(ctx context.Context, settlement *Settlement) error {
+	return m.Called(ctx, settlement).Error(0)
+}
+
+func (m *mockRepo) FindByID(ctx context.Context, id uint) (*Settlement, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Settlement), args.Error(1)
+}
+
+func (m *mockRepo) FindAll(ctx context.Context, filter SettlementFilter) ([]Settlement, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]Settlement), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockRepo) FindDraftByEmployee(ctx context.Context, employeeID uint) (*Settlement, error) {
+	args := m.Called(ctx, employeeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Settlement), args.Error(1)
+}
+
+func (m *mockRepo) Update(ctx context.Context, settlement *Settlement) error {
+	return m.Called(ctx, settlement).Error(0)
+}
+
+func (m *mockRepo) ReplaceItems(ctx context.Context, settlementID uint, items []SettlementItem) error {
+	return m.Called(ctx, settlementID, items).Error(0)
+}
+
+type mockUserProvider struct{ mock.Mock }
+
+func (m *mockUserProvider) FindEmployeeByID(ctx context.Context, id uint) (*user.Employee, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Employee), args.Error(1)
+}
+
+func (m *mockUserProvider) TerminateEmployee(ctx context.Context, emp *user.Employee, terminationDate time.Time, reason constants.TerminationReason) error {
+	return m.Called(ctx, emp, terminationDate, reason).Error(0)
+}
+
+type mockLeaveProvider struct{ mock.Mock }
+
+func (m *mockLeaveProvider) GetUnusedLeave(ctx context.Context, employeeID uint, year int) ([]leave.UnusedLeave, error) {
+	args := m.Called(ctx, employeeID, year)
+	return args.Get(0).([]leave.UnusedLeave), args.Error(1)
+}
+
+func (m *mockLeaveProvider) PayOutUnusedLeave(ctx context.Context, employeeID uint, year int, referenceID uint) error {
+	return m.Called(ctx, employeeID, year, referenceID).Error(0)
+}
+
+type mockLoanProvider struct{ mock.Mock }
+
+func (m *mockLoanProvider) GetOutstandingLoans(ctx context.Context, employeeID uint) ([]loan.OutstandingLoan, error) {
+	args := m.Called(ctx, employeeID)
+	return args.Get(0).([]loan.OutstandingLoan), args.Error(1)
+}
+
+func (m *mockLoanProvider) Settle(ctx context.Context, req *loan.LoanSettlementRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+type mockAssetProvider struct{ mock.Mock }
+
+func (m *mockAssetProvider) GetActiveAssignments(ctx context.Context, employeeID uint) ([]asset.AssetAssignmentListResponse, error) {
+	args := m.Called(ctx, employeeID)
+	return args.Get(0).([]asset.AssetAssignmentListResponse), args.Error(1)
+}
+
+type mockPayrollProvider struct{ mock.Mock }
+
+func (m *mockPayrollProvider) HasPayroll(ctx context.Context, employeeID uint, month, year int) (bool, error) {
+	args := m.Called(ctx, employeeID, month, year)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockPayrollProvider) GetTaxEntries(ctx context.Context, employeeID uint, year int) ([]tax.MonthlyTaxEntry, error) {
+	args := m.Called(ctx, employeeID, year)
+	return args.Get(0).([]tax.MonthlyTaxEntry), args.Error(1)
+}
+
+type mockTaxProvider struct{ mock.Mock }
+
+func (m *mockTaxProvider) SettleTermination(ctx context.Context, req *tax.TerminationTaxRequest) (*tax.TerminationTax, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*tax.TerminationTax), args.Error(1)
+}
+
+type mockService struct{ mock.Mock }
+
+func (m *mockService) Create(ctx context.Context, req *CreateSettlementRequest) (*SettlementDetailResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*SettlementDetailResponse), args.Error(1)
+}
+
+func (m *mockService) GetList(ctx context.Context, filter SettlementFilter) ([]SettlementListResponse, *response.Meta, error) {
+	args := m.Called(ctx, filter)
+	var meta *response.Meta
+	if args.Get(1) != nil {
+		meta = args.Get(1).(*response.Meta)
+	}
+	return args.Get(0).([]SettlementListResponse), meta, args.Error(2)
+}
+
+func (m *mockService) GetDetail(ctx context.Context, id uint) (*SettlementDetailResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*SettlementDetailResponse), args.Error(1)
+}
+
+func (m *mockService) Finalize(ctx context.Context, req *SettlementActionRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) Cancel(ctx context.Context, req *SettlementActionRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+type testDeps struct {
+	repo    *mockRepo
+	user    *mockUserProvider
+	leave   *mockLeaveProvider
+	loan    *mockLoanProvider
+	asset   *mockAssetProvider
+	payroll *mockPayrollProvider
+	tax     *mockTaxProvider
+	session *mockSessionRevoker
+}
+
+type mockSessionRevoker struct{ mock.Mock }
+
+func (m *mockSessionRevoker) RevokeUser(ctx context.Context, userID uint) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func newTestService() (Service, *testDeps) {
+	d := &testDeps{
+		repo:    new(mockRepo),
+		user:    new(mockUserProvider),
+		leave:   new(mockLeaveProvider),
+		loan:    new(mockLoanProvider),
+		asset:   new(mockAssetProvider),
+		payroll: new(mockPayrollProvider),
+		tax:     new(mockTaxProvider),
+		session: new(mockSessionRevoker),
+	}
+
+	svc := NewService(d.repo, d.user, d.leave, d.loan, d.asset, d.payroll, d.tax, d.session, testutil.NewMockTransactionManager())
+	return svc, d
+}
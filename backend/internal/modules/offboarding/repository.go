@@ -0,0 +1,113 @@
+package offboarding
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(ctx context.Context, settlement *Settlement) error
+	FindByID(ctx context.Context, id uint) (*Settlement, error)
+	FindAll(ctx context.Context, filter SettlementFilter) ([]Settlement, int64, error)
+	FindDraftByEmployee(ctx context.Context, employeeID uint) (*Settlement, error)
+	Update(ctx context.Context, settlement *Settlement) error
+	ReplaceItems(ctx context.Context, settlementID uint, items []SettlementItem) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) Create(ctx context.Context, settlement *Settlement) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Create(settlement).Error
+}
+
+func (r *repository) FindByID(ctx context.Context, id uint) (*Settlement, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var settlement Settlement
+
+	err := db.
+		Preload("Employee").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).First(&settlement, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &settlement, nil
+}
+
+func (r *repository) FindAll(ctx context.Context, filter SettlementFilter) ([]Settlement, int64, error) {
+	db := utils.GetDBFromContext(ctx, r.db)
+	var settlements []Settlement
+	var total int64
+
+	query := utils.TenantScope(ctx, db.Model(&Settlement{})).Preload("Employee")
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.
+		Limit(filter.Limit).
+		Offset(offset).
+		Order("created_at DESC").
+		Find(&settlements).Error
+
+	return settlements, total, err
+}
+
+// FindDraftByEmployee returns the employee's settlement still in draft, nil when there is none.
+func (r *repository) FindDraftByEmployee(ctx context.Context, employeeID uint) (*Settlement, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var settlements []Settlement
+
+	err := db.
+		Where("employee_id = ?", employeeID).
+		Where("status = ?", string(constants.SettlementStatusDraft)).
+		Limit(1).
+		Find(&settlements).Error
+	if err != nil || len(settlements) == 0 {
+		return nil, err
+	}
+
+	return &settlements[0], nil
+}
+
+func (r *repository) Update(ctx context.Context, settlement *Settlement) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	return db.Omit(clause.Associations).Save(settlement).Error
+}
+
+// ReplaceItems swaps the items of the settlement for the recalculated ones.
+func (r *repository) ReplaceItems(ctx context.Context, settlementID uint, items []SettlementItem) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	if err := db.Where("settlement_id = ?", settlementID).Delete(&SettlementItem{}).Error; err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	for i := range items {
+		items[i].ID = 0
+		items[i].SettlementID = settlementID
+	}
+	return db.Create(&items).Error
+}
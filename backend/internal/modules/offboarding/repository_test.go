@@ -0,0 +1,71 @@
+package offboarding
+
+import (
+	"testing"
+
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupOffboardingTestDB(t *testing.T) *testutil.TestDB {
+	t.Helper()
+	tdb := testutil.NewTestDB(&Settlement{}, &SettlementItem{})
+	t.Cleanup(tdb.Close)
+	return tdb
+}
+
+func TestRepo_FindDraftByEmployee(t *testing.T) {
+	tdb := setupOffboardingTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	require.NoError(t, tdb.DB.Create(&Settlement{CompanyID: 1, EmployeeID: 1, Reason: constants.TerminationResignation, LastWorkingDate: lastWorkingDate, Status: constants.SettlementStatusCancelled}).Error)
+
+	draft, err := repo.FindDraftByEmployee(ctx, 1)
+	require.NoError(t, err)
+	assert.Nil(t, draft)
+
+	require.NoError(t, tdb.DB.Create(&Settlement{CompanyID: 1, EmployeeID: 1, Reason: constants.TerminationResignation, LastWorkingDate: lastWorkingDate, Status: constants.SettlementStatusDraft}).Error)
+
+	draft, err = repo.FindDraftByEmployee(ctx, 1)
+	require.NoError(t, err)
+	require.NotNil(t, draft)
+	assert.Equal(t, constants.SettlementStatusDraft, draft.Status)
+
+	// another company's draft is out of scope
+	draft, err = repo.FindDraftByEmployee(testutil.CtxWithTenant(2, 1, false), 1)
+	require.NoError(t, err)
+	assert.Nil(t, draft)
+}
+
+func TestRepo_ReplaceItems(t *testing.T) {
+	tdb := setupOffboardingTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	st := &Settlement{
+		CompanyID: 1, EmployeeID: 1, Reason: constants.TerminationEfficiency, LastWorkingDate: lastWorkingDate,
+		Status: constants.SettlementStatusDraft,
+		Items: []SettlementItem{
+			{CompanyID: 1, Code: ItemProratedSalary, Title: "Salary", Type: constants.DetailTypeAllowance, Amount: 100},
+			{CompanyID: 1, Code: ItemSeverancePay, Title: "Severance Pay", Type: constants.DetailTypeAllowance, Amount: 200},
+		},
+	}
+	require.NoError(t, repo.Create(ctx, st))
+
+	loanID := uint(7)
+	err := repo.ReplaceItems(ctx, st.ID, []SettlementItem{
+		{ID: st.Items[0].ID, CompanyID: 1, Code: ItemProratedSalary, Title: "Salary", Type: constants.DetailTypeAllowance, Amount: 150},
+		{CompanyID: 1, Code: ItemLoan, Title: "Loan Repayment", Type: constants.DetailTypeDeduction, Amount: 50, LoanID: &loanID},
+	})
+	require.NoError(t, err)
+
+	found, err := repo.FindByID(ctx, st.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Items, 2)
+	assert.Equal(t, 150.0, found.Items[0].Amount)
+	assert.Equal(t, &loanID, found.Items[1].LoanID)
+}
@@ -0,0 +1,453 @@
+package offboarding
+
+import (
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/tax"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/response"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type Service interface {
+	Create(ctx context.Context, req *CreateSettlementRequest) (*SettlementDetailResponse, error)
+	GetList(ctx context.Context, filter SettlementFilter) ([]SettlementListResponse, *response.Meta, error)
+	GetDetail(ctx context.Context, id uint) (*SettlementDetailResponse, error)
+	Finalize(ctx context.Context, req *SettlementActionRequest) error
+	Cancel(ctx context.Context, req *SettlementActionRequest) error
+}
+
+var timeNow = time.Now
+
+// workingDaysPerMonth turns the monthly salary into the daily wage of a five-day week, used to pay out leave.
+const workingDaysPerMonth = 21
+
+type service struct {
+	repo               Repository
+	user               UserProvider
+	leave              LeaveProvider
+	loan               LoanProvider
+	asset              AssetProvider
+	payroll            PayrollProvider
+	tax                TaxProvider
+	sessions           SessionRevoker
+	transactionManager infrastructure.TransactionManager
+}
+
+func NewService(repo Repository, user UserProvider, leave LeaveProvider, loan LoanProvider, asset AssetProvider, payroll PayrollProvider, tax TaxProvider, sessions SessionRevoker, transactionManager infrastructure.TransactionManager) Service {
+	return &service{repo, user, leave, loan, asset, payroll, tax, sessions, transactionManager}
+}
+
+// Create drafts the final settlement of an employee. Nothing is paid or terminated until it is finalized.
+func (s *service) Create(ctx context.Context, req *CreateSettlementRequest) (*SettlementDetailResponse, error) {
+	lastWorkingDate, err := time.Parse(constants.DefaultTimeFormat, req.LastWorkingDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid last working date format")
+	}
+
+	emp, err := s.user.FindEmployeeByID(ctx, req.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if emp.TerminationDate != nil {
+		return nil, fmt.Errorf("employee already terminated on %s", emp.TerminationDate.Format(constants.DefaultTimeFormat))
+	}
+	if emp.JoinDate == nil {
+		return nil, fmt.Errorf("employee has no join date")
+	}
+	if lastWorkingDate.Before(*emp.JoinDate) {
+		return nil, fmt.Errorf("last working date is before the join date")
+	}
+
+	draft, err := s.repo.FindDraftByEmployee(ctx, emp.ID)
+	if err != nil {
+		return nil, err
+	}
+	if draft != nil {
+		return nil, fmt.Errorf("employee already has a draft settlement")
+	}
+
+	if err := s.ensureNoPayroll(ctx, emp.ID, lastWorkingDate); err != nil {
+		return nil, err
+	}
+
+	settlement := &Settlement{
+		CompanyID:       emp.CompanyID,
+		EmployeeID:      emp.ID,
+		Reason:          constants.TerminationReason(req.Reason),
+		LastWorkingDate: lastWorkingDate,
+		Status:          constants.SettlementStatusDraft,
+		Notes:           req.Notes,
+		CreatedBy:       &req.ActorID,
+	}
+
+	items, err := s.calculate(ctx, settlement, emp)
+	if err != nil {
+		return nil, err
+	}
+	settlement.Items = items
+
+	if err := s.repo.Create(ctx, settlement); err != nil {
+		return nil, err
+	}
+
+	return s.GetDetail(ctx, settlement.ID)
+}
+
+func (s *service) GetList(ctx context.Context, filter SettlementFilter) ([]SettlementListResponse, *response.Meta, error) {
+	settlements, total, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := make([]SettlementListResponse, 0, len(settlements))
+	for _, st := range settlements {
+		item := SettlementListResponse{
+			ID:              st.ID,
+			EmployeeID:      st.EmployeeID,
+			Reason:          st.Reason,
+			LastWorkingDate: st.LastWorkingDate.Format(constants.DefaultTimeFormat),
+			NetAmount:       st.NetAmount,
+			Status:          st.Status,
+			CreatedAt:       st.CreatedAt,
+		}
+		if st.Employee != nil {
+			item.EmployeeName = st.Employee.FullName
+			item.EmployeeNIK = st.Employee.NIK
+		}
+		list = append(list, item)
+	}
+
+	meta := response.NewMetaOffset(filter.Page, filter.Limit, total)
+	return list, meta, nil
+}
+
+func (s *service) GetDetail(ctx context.Context, id uint) (*SettlementDetailResponse, error) {
+	st, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SettlementDetailResponse{
+		ID:                   st.ID,
+		EmployeeID:           st.EmployeeID,
+		Reason:               st.Reason,
+		LastWorkingDate:      st.LastWorkingDate.Format(constants.DefaultTimeFormat),
+		BaseSalary:           st.BaseSalary,
+		TenureMonths:         st.TenureMonths,
+		WorkedDays:           st.WorkedDays,
+		PeriodDays:           st.PeriodDays,
+		UnusedLeaveDays:      st.UnusedLeaveDays,
+		TotalEarnings:        st.TotalEarnings,
+		TotalDeductions:      st.TotalDeductions,
+		NetAmount:            st.NetAmount,
+		UncoveredLoanBalance: st.UncoveredLoanBalance,
+		Status:               st.Status,
+		Notes:                st.Notes,
+		FinalizedAt:          st.FinalizedAt,
+		CreatedAt:            st.CreatedAt,
+		Items:                make([]SettlementItemResponse, 0, len(st.Items)),
+	}
+	if st.Employee != nil {
+		res.EmployeeName = st.Employee.FullName
+		res.EmployeeNIK = st.Employee.NIK
+	}
+
+	for _, item := range st.Items {
+		res.Items = append(res.Items, SettlementItemResponse{
+			Code:   item.Code,
+			Title:  item.Title,
+			Type:   item.Type,
+			Amount: item.Amount,
+			LoanID: item.LoanID,
+		})
+	}
+
+	if st.Status != constants.SettlementStatusDraft {
+		return res, nil
+	}
+
+	res.AssetChecklist, err = s.asset.GetActiveAssignments(ctx, st.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	res.OutstandingLoans, err = s.loan.GetOutstandingLoans(ctx, st.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Finalize recalculates the draft, then pays out the unused leave, repays the loans it covers and ends the
+// employment in one transaction. It is refused while the employee still holds company assets.
+func (s *service) Finalize(ctx context.Context, req *SettlementActionRequest) error {
+	st, err := s.repo.FindByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if st.Status != constants.SettlementStatusDraft {
+		return fmt.Errorf("cannot finalize settlement with status %s", st.Status)
+	}
+
+	assignments, err := s.asset.GetActiveAssignments(ctx, st.EmployeeID)
+	if err != nil {
+		return err
+	}
+	if len(assignments) > 0 {
+		names := make([]string, 0, len(assignments))
+		for _, a := range assignments {
+			names = append(names, a.AssetName)
+		}
+		return fmt.Errorf("employee has not returned: %s", strings.Join(names, ", "))
+	}
+
+	emp, err := s.user.FindEmployeeByID(ctx, st.EmployeeID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ensureNoPayroll(ctx, emp.ID, st.LastWorkingDate); err != nil {
+		return err
+	}
+
+	// balances, loans and tax may have moved since the draft
+	items, err := s.calculate(ctx, st, emp)
+	if err != nil {
+		return err
+	}
+
+	now := timeNow()
+	st.Status = constants.SettlementStatusFinalized
+	st.FinalizedBy = &req.ActorID
+	st.FinalizedAt = &now
+
+	err = s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, st); err != nil {
+			return err
+		}
+
+		if err := s.repo.ReplaceItems(ctx, st.ID, items); err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if item.Code != ItemLoan || item.LoanID == nil {
+				continue
+			}
+
+			err := s.loan.Settle(ctx, &loan.LoanSettlementRequest{
+				LoanID:  *item.LoanID,
+				ActorID: req.ActorID,
+				Amount:  item.Amount,
+				Notes:   fmt.Sprintf("Final settlement #%d", st.ID),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if st.UnusedLeaveDays > 0 {
+			if err := s.leave.PayOutUnusedLeave(ctx, emp.ID, st.LastWorkingDate.Year(), st.ID); err != nil {
+				return err
+			}
+		}
+
+		return s.user.TerminateEmployee(ctx, emp, st.LastWorkingDate, st.Reason)
+	})
+	if err != nil {
+		return err
+	}
+
+	// the deactivated account is signed out everywhere
+	return s.sessions.RevokeUser(ctx, emp.UserID)
+}
+
+func (s *service) Cancel(ctx context.Context, req *SettlementActionRequest) error {
+	st, err := s.repo.FindByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if st.Status != constants.SettlementStatusDraft {
+		return fmt.Errorf("cannot cancel settlement with status %s", st.Status)
+	}
+
+	st.Status = constants.SettlementStatusCancelled
+	return s.repo.Update(ctx, st)
+}
+
+// ensureNoPayroll refuses a settlement whose last month was already paid by payroll, the settlement pays the
+// prorated salary of that month itself.
+func (s *service) ensureNoPayroll(ctx context.Context, employeeID uint, lastWorkingDate time.Time) error {
+	paid, err := s.payroll.HasPayroll(ctx, employeeID, int(lastWorkingDate.Month()), lastWorkingDate.Year())
+	if err != nil {
+		return err
+	}
+	if paid {
+		return fmt.Errorf("payroll of %s already generated for the employee", lastWorkingDate.Format(constants.PayrollTimeFormat))
+	}
+	return nil
+}
+
+// calculate fills the figures of the settlement and returns its items: the prorated salary of the last month,
+// the unused leave, the severance and service pay of PP 35/2021, the tax adjustment of the year and the
+// loans the remaining amount can repay.
+func (s *service) calculate(ctx context.Context, st *Settlement, emp *user.Employee) ([]SettlementItem, error) {
+	lastDay := st.LastWorkingDate
+	joinDate := *emp.JoinDate
+	base := emp.BaseSalary
+
+	periodStart := time.Date(lastDay.Year(), lastDay.Month(), 1, 0, 0, 0, 0, lastDay.Location())
+	if joinDate.After(periodStart) {
+		periodStart = joinDate
+	}
+
+	st.BaseSalary = base
+	st.PeriodDays = periodStart.AddDate(0, 1, -periodStart.Day()).Day()
+	st.WorkedDays = lastDay.Day() - periodStart.Day() + 1
+	st.TenureMonths = tenureMonths(joinDate, lastDay)
+
+	unused, err := s.leave.GetUnusedLeave(ctx, emp.ID, lastDay.Year())
+	if err != nil {
+		return nil, err
+	}
+	st.UnusedLeaveDays = 0
+	for _, u := range unused {
+		st.UnusedLeaveDays += u.Days
+	}
+
+	var items []SettlementItem
+	add := func(code, title string, detailType constants.PayrollDetailType, amount float64, loanID *uint) {
+		if amount <= 0 {
+			return
+		}
+		items = append(items, SettlementItem{
+			CompanyID: st.CompanyID,
+			Code:      code,
+			Title:     title,
+			Type:      detailType,
+			Amount:    amount,
+			LoanID:    loanID,
+		})
+	}
+
+	prorated := math.Round(base * float64(st.WorkedDays) / float64(st.PeriodDays))
+	leavePayout := math.Round(st.UnusedLeaveDays * base / workingDaysPerMonth)
+
+	severanceRate, serviceRate := reasonMultipliers(st.Reason)
+	years := st.TenureMonths / 12
+	severance := math.Round(float64(severanceMonths(years)) * severanceRate * base)
+	servicePay := math.Round(float64(servicePayMonths(years)) * serviceRate * base)
+
+	add(ItemProratedSalary, fmt.Sprintf("Salary %d/%d days", st.WorkedDays, st.PeriodDays), constants.DetailTypeAllowance, prorated, nil)
+	add(ItemLeavePayout, fmt.Sprintf("Unused Leave %.1f days", st.UnusedLeaveDays), constants.DetailTypeAllowance, leavePayout, nil)
+	add(ItemSeverancePay, "Severance Pay", constants.DetailTypeAllowance, severance, nil)
+	add(ItemServicePay, "Service Pay", constants.DetailTypeAllowance, servicePay, nil)
+
+	entries, err := s.payroll.GetTaxEntries(ctx, emp.ID, lastDay.Year())
+	if err != nil {
+		return nil, err
+	}
+
+	taxResult, err := s.tax.SettleTermination(ctx, &tax.TerminationTaxRequest{
+		Year:            lastDay.Year(),
+		MaritalStatus:   emp.MaritalStatus,
+		DependentsCount: emp.DependentsCount,
+		MonthlyDetails:  entries,
+		FinalIncome:     prorated,
+		SeverancePay:    severance + servicePay + leavePayout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a positive delta is tax still owed for the year, a negative one was withheld too much and is refunded
+	delta := math.Round(taxResult.Settlement.Delta)
+	add(ItemPPh21, "PPh 21 Year-End Adjustment", constants.DetailTypeDeduction, delta, nil)
+	add(ItemPPh21, "PPh 21 Refund", constants.DetailTypeAllowance, -delta, nil)
+	add(ItemSeveranceTax, "PPh 21 Severance", constants.DetailTypeDeduction, math.Round(taxResult.SeveranceTax), nil)
+
+	loans, err := s.loan.GetOutstandingLoans(ctx, emp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	available := sumItems(items, constants.DetailTypeAllowance) - sumItems(items, constants.DetailTypeDeduction)
+	st.UncoveredLoanBalance = 0
+	for _, l := range loans {
+		covered := math.Max(0, math.Min(l.Balance, available))
+		available -= covered
+		st.UncoveredLoanBalance += l.Balance - covered
+
+		loanID := l.LoanID
+		add(ItemLoan, fmt.Sprintf("Loan Repayment %s", l.ProductName), constants.DetailTypeDeduction, covered, &loanID)
+	}
+
+	st.TotalEarnings = sumItems(items, constants.DetailTypeAllowance)
+	st.TotalDeductions = sumItems(items, constants.DetailTypeDeduction)
+	st.NetAmount = st.TotalEarnings - st.TotalDeductions
+
+	return items, nil
+}
+
+func sumItems(items []SettlementItem, detailType constants.PayrollDetailType) float64 {
+	var total float64
+	for _, item := range items {
+		if item.Type == detailType {
+			total += item.Amount
+		}
+	}
+	return total
+}
+
+// tenureMonths counts the full months worked from the join date up to the last working date.
+func tenureMonths(joinDate, lastDay time.Time) int {
+	months := (lastDay.Year()-joinDate.Year())*12 + int(lastDay.Month()-joinDate.Month())
+	if lastDay.Day() < joinDate.Day() {
+		months--
+	}
+	return max(months, 0)
+}
+
+// severanceMonths is the uang pesangon of PP 35/2021 article 40(2) in months of wages by full years of service.
+func severanceMonths(years int) int {
+	return min(years+1, 9)
+}
+
+// servicePayMonths is the uang penghargaan masa kerja of PP 35/2021 article 40(3) in months of wages.
+func servicePayMonths(years int) int {
+	switch {
+	case years >= 24:
+		return 10
+	case years >= 3:
+		return years/3 + 1
+	default:
+		return 0
+	}
+}
+
+// reasonMultipliers returns the multipliers of the severance and service pay for the reason of termination.
+func reasonMultipliers(reason constants.TerminationReason) (severance, service float64) {
+	switch reason {
+	case constants.TerminationMisconduct, constants.TerminationEfficiencyLoss:
+		return 0.5, 1
+	case constants.TerminationEfficiency:
+		return 1, 1
+	case constants.TerminationRetirement:
+		return 1.75, 1
+	case constants.TerminationLongIllness, constants.TerminationDeath:
+		return 2, 1
+	default:
+		// resignation and absence are only owed the compensation pay, paid here as the unused leave
+		return 0, 0
+	}
+}
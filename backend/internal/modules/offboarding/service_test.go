@@ -0,0 +1,269 @@
+package offboarding
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/asset"
+	"basekarya-backend/internal/modules/leave"
+	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/tax"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var lastWorkingDate = time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC)
+
+// settlementEmployee joined in March 2019 with a base salary of 10.000.000, six full years by August 2025.
+func settlementEmployee() *user.Employee {
+	joinDate := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	return &user.Employee{
+		ID: 1, CompanyID: 1, FullName: "John Doe", NIK: "EMP001",
+		BaseSalary: 10000000, MaritalStatus: constants.MaritalStatusMarried, DependentsCount: 1,
+		JoinDate: &joinDate,
+	}
+}
+
+func mockCalculation(d *testDeps, delta float64, loans []loan.OutstandingLoan) {
+	d.leave.On("GetUnusedLeave", mock.Anything, uint(1), 2025).Return([]leave.UnusedLeave{
+		{LeaveTypeID: 1, LeaveTypeName: "Annual Leave", Days: 4},
+	}, nil)
+	d.payroll.On("GetTaxEntries", mock.Anything, uint(1), 2025).Return([]tax.MonthlyTaxEntry{
+		{Month: 7, GrossIncome: 10000000, PPh21Paid: 200000},
+	}, nil)
+	d.tax.On("SettleTermination", mock.Anything, mock.AnythingOfType("*tax.TerminationTaxRequest")).Return(&tax.TerminationTax{
+		Settlement:   tax.AnnualSettlement{Delta: delta},
+		SeveranceTax: 5000000,
+	}, nil)
+	d.loan.On("GetOutstandingLoans", mock.Anything, uint(1)).Return(loans, nil)
+}
+
+func itemsByCode(items []SettlementItem) map[string]SettlementItem {
+	byCode := make(map[string]SettlementItem, len(items))
+	for _, item := range items {
+		byCode[item.Code+string(item.Type)] = item
+	}
+	return byCode
+}
+
+func TestService_Calculate(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, d := newTestService()
+	mockCalculation(d, 250000, []loan.OutstandingLoan{{LoanID: 7, ProductName: "Cash Loan", Balance: 3000000}})
+
+	st := &Settlement{CompanyID: 1, Reason: constants.TerminationEfficiency, LastWorkingDate: lastWorkingDate}
+	items, err := svc.(*service).calculate(ctx, st, settlementEmployee())
+	require.NoError(t, err)
+
+	assert.Equal(t, 77, st.TenureMonths)
+	assert.Equal(t, 15, st.WorkedDays)
+	assert.Equal(t, 31, st.PeriodDays)
+	assert.Equal(t, 4.0, st.UnusedLeaveDays)
+
+	byCode := itemsByCode(items)
+	assert.Equal(t, 4838710.0, byCode[ItemProratedSalary+"ALLOWANCE"].Amount)
+	assert.Equal(t, 1904762.0, byCode[ItemLeavePayout+"ALLOWANCE"].Amount)
+	// six years of service: seven months of severance and three of service pay
+	assert.Equal(t, 70000000.0, byCode[ItemSeverancePay+"ALLOWANCE"].Amount)
+	assert.Equal(t, 30000000.0, byCode[ItemServicePay+"ALLOWANCE"].Amount)
+	assert.Equal(t, 250000.0, byCode[ItemPPh21+"DEDUCTION"].Amount)
+	assert.Equal(t, 5000000.0, byCode[ItemSeveranceTax+"DEDUCTION"].Amount)
+	assert.Equal(t, 3000000.0, byCode[ItemLoan+"DEDUCTION"].Amount)
+	assert.Equal(t, uint(7), *byCode[ItemLoan+"DEDUCTION"].LoanID)
+
+	assert.Equal(t, 106743472.0, st.TotalEarnings)
+	assert.Equal(t, 8250000.0, st.TotalDeductions)
+	assert.Equal(t, 98493472.0, st.NetAmount)
+	assert.Zero(t, st.UncoveredLoanBalance)
+
+	req := d.tax.Calls[0].Arguments.Get(1).(*tax.TerminationTaxRequest)
+	assert.Equal(t, 4838710.0, req.FinalIncome)
+	assert.Equal(t, 101904762.0, req.SeverancePay)
+}
+
+func TestService_Calculate_ResignationWithRefundAndUncoveredLoan(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, d := newTestService()
+	mockCalculation(d, -150000, []loan.OutstandingLoan{{LoanID: 7, Balance: 10000000}})
+
+	st := &Settlement{CompanyID: 1, Reason: constants.TerminationResignation, LastWorkingDate: lastWorkingDate}
+	items, err := svc.(*service).calculate(ctx, st, settlementEmployee())
+	require.NoError(t, err)
+
+	byCode := itemsByCode(items)
+	assert.NotContains(t, byCode, ItemSeverancePay+"ALLOWANCE")
+	assert.NotContains(t, byCode, ItemServicePay+"ALLOWANCE")
+	assert.Equal(t, 150000.0, byCode[ItemPPh21+"ALLOWANCE"].Amount)
+
+	// the loan takes everything left after the severance tax, the rest stays on the loan ledger
+	assert.Equal(t, 1893472.0, byCode[ItemLoan+"DEDUCTION"].Amount)
+	assert.Equal(t, 8106528.0, st.UncoveredLoanBalance)
+	assert.Zero(t, st.NetAmount)
+}
+
+func TestService_Create(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	req := &CreateSettlementRequest{ActorID: 9, EmployeeID: 1, Reason: "EFFICIENCY", LastWorkingDate: "2025-08-15"}
+
+	t.Run("success", func(t *testing.T) {
+		svc, d := newTestService()
+		d.user.On("FindEmployeeByID", mock.Anything, uint(1)).Return(settlementEmployee(), nil)
+		d.repo.On("FindDraftByEmployee", mock.Anything, uint(1)).Return(nil, nil)
+		d.payroll.On("HasPayroll", mock.Anything, uint(1), 8, 2025).Return(false, nil)
+		mockCalculation(d, 0, []loan.OutstandingLoan{})
+		d.repo.On("Create", mock.Anything, mock.MatchedBy(func(st *Settlement) bool {
+			st.ID = 3
+			return st.Status == constants.SettlementStatusDraft && st.EmployeeID == 1 && len(st.Items) == 5
+		})).Return(nil)
+		d.repo.On("FindByID", mock.Anything, uint(3)).Return(&Settlement{ID: 3, EmployeeID: 1, Status: constants.SettlementStatusDraft}, nil)
+		d.asset.On("GetActiveAssignments", mock.Anything, uint(1)).Return([]asset.AssetAssignmentListResponse{{AssetName: "Laptop"}}, nil)
+
+		res, err := svc.Create(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), res.ID)
+		assert.Len(t, res.AssetChecklist, 1)
+	})
+
+	t.Run("already terminated", func(t *testing.T) {
+		svc, d := newTestService()
+		emp := settlementEmployee()
+		emp.TerminationDate = &lastWorkingDate
+		d.user.On("FindEmployeeByID", mock.Anything, uint(1)).Return(emp, nil)
+
+		_, err := svc.Create(ctx, req)
+		assert.EqualError(t, err, "employee already terminated on 2025-08-15")
+	})
+
+	t.Run("draft exists", func(t *testing.T) {
+		svc, d := newTestService()
+		d.user.On("FindEmployeeByID", mock.Anything, uint(1)).Return(settlementEmployee(), nil)
+		d.repo.On("FindDraftByEmployee", mock.Anything, uint(1)).Return(&Settlement{ID: 2}, nil)
+
+		_, err := svc.Create(ctx, req)
+		assert.EqualError(t, err, "employee already has a draft settlement")
+	})
+
+	t.Run("payroll already generated", func(t *testing.T) {
+		svc, d := newTestService()
+		d.user.On("FindEmployeeByID", mock.Anything, uint(1)).Return(settlementEmployee(), nil)
+		d.repo.On("FindDraftByEmployee", mock.Anything, uint(1)).Return(nil, nil)
+		d.payroll.On("HasPayroll", mock.Anything, uint(1), 8, 2025).Return(true, nil)
+
+		_, err := svc.Create(ctx, req)
+		assert.EqualError(t, err, "payroll of August 2025 already generated for the employee")
+		d.repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_Finalize(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	timeNow = func() time.Time { return time.Date(2025, 8, 20, 9, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	draft := func() *Settlement {
+		return &Settlement{ID: 3, CompanyID: 1, EmployeeID: 1, Reason: constants.TerminationEfficiency, LastWorkingDate: lastWorkingDate, Status: constants.SettlementStatusDraft}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		svc, d := newTestService()
+		emp := settlementEmployee()
+		d.repo.On("FindByID", mock.Anything, uint(3)).Return(draft(), nil)
+		d.asset.On("GetActiveAssignments", mock.Anything, uint(1)).Return([]asset.AssetAssignmentListResponse{}, nil)
+		d.user.On("FindEmployeeByID", mock.Anything, uint(1)).Return(emp, nil)
+		d.payroll.On("HasPayroll", mock.Anything, uint(1), 8, 2025).Return(false, nil)
+		mockCalculation(d, 0, []loan.OutstandingLoan{{LoanID: 7, Balance: 3000000}})
+		d.repo.On("Update", mock.Anything, mock.MatchedBy(func(st *Settlement) bool {
+			return st.Status == constants.SettlementStatusFinalized && *st.FinalizedBy == 9 && st.FinalizedAt != nil
+		})).Return(nil)
+		d.repo.On("ReplaceItems", mock.Anything, uint(3), mock.Anything).Return(nil)
+		d.loan.On("Settle", mock.Anything, &loan.LoanSettlementRequest{LoanID: 7, ActorID: 9, Amount: 3000000, Notes: "Final settlement #3"}).Return(nil)
+		d.leave.On("PayOutUnusedLeave", mock.Anything, uint(1), 2025, uint(3)).Return(nil)
+		d.user.On("TerminateEmployee", mock.Anything, emp, lastWorkingDate, constants.TerminationEfficiency).Return(nil)
+		d.session.On("RevokeUser", mock.Anything, emp.UserID).Return(nil)
+
+		err := svc.Finalize(ctx, &SettlementActionRequest{ID: 3, ActorID: 9})
+		require.NoError(t, err)
+		d.loan.AssertExpectations(t)
+		d.leave.AssertExpectations(t)
+		d.user.AssertExpectations(t)
+		d.session.AssertExpectations(t)
+	})
+
+	t.Run("assets not returned", func(t *testing.T) {
+		svc, d := newTestService()
+		d.repo.On("FindByID", mock.Anything, uint(3)).Return(draft(), nil)
+		d.asset.On("GetActiveAssignments", mock.Anything, uint(1)).Return([]asset.AssetAssignmentListResponse{
+			{AssetName: "Laptop"}, {AssetName: "Access Card"},
+		}, nil)
+
+		err := svc.Finalize(ctx, &SettlementActionRequest{ID: 3, ActorID: 9})
+		assert.EqualError(t, err, "employee has not returned: Laptop, Access Card")
+		d.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("asset lookup fails", func(t *testing.T) {
+		svc, d := newTestService()
+		d.repo.On("FindByID", mock.Anything, uint(3)).Return(draft(), nil)
+		d.asset.On("GetActiveAssignments", mock.Anything, uint(1)).Return([]asset.AssetAssignmentListResponse(nil), errors.New("db down"))
+
+		err := svc.Finalize(ctx, &SettlementActionRequest{ID: 3, ActorID: 9})
+		assert.EqualError(t, err, "db down")
+	})
+
+	t.Run("not a draft", func(t *testing.T) {
+		svc, d := newTestService()
+		st := draft()
+		st.Status = constants.SettlementStatusFinalized
+		d.repo.On("FindByID", mock.Anything, uint(3)).Return(st, nil)
+
+		err := svc.Finalize(ctx, &SettlementActionRequest{ID: 3, ActorID: 9})
+		assert.EqualError(t, err, "cannot finalize settlement with status FINALIZED")
+	})
+}
+
+func TestService_Cancel(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, d := newTestService()
+	d.repo.On("FindByID", mock.Anything, uint(3)).Return(&Settlement{ID: 3, Status: constants.SettlementStatusDraft}, nil)
+	d.repo.On("Update", mock.Anything, mock.MatchedBy(func(st *Settlement) bool {
+		return st.Status == constants.SettlementStatusCancelled
+	})).Return(nil)
+
+	require.NoError(t, svc.Cancel(ctx, &SettlementActionRequest{ID: 3, ActorID: 9}))
+	d.repo.AssertExpectations(t)
+}
+
+func TestSeveranceTables(t *testing.T) {
+	tests := []struct {
+		years     int
+		severance int
+		service   int
+	}{
+		{0, 1, 0},
+		{2, 3, 0},
+		{3, 4, 2},
+		{6, 7, 3},
+		{8, 9, 3},
+		{12, 9, 5},
+		{21, 9, 8},
+		{24, 9, 10},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.severance, severanceMonths(tt.years), "severance of %d years", tt.years)
+		assert.Equal(t, tt.service, servicePayMonths(tt.years), "service pay of %d years", tt.years)
+	}
+}
+
+func TestTenureMonths(t *testing.T) {
+	join := time.Date(2020, 5, 20, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 0, tenureMonths(join, time.Date(2020, 6, 19, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 1, tenureMonths(join, time.Date(2020, 6, 20, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 36, tenureMonths(join, time.Date(2023, 5, 31, 0, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,93 @@
+package overtime
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Assign pre-approves overtime a manager plans for an employee, the employee claims it once it is worked.
+func (s *service) Assign(ctx context.Context, req *AssignRequest) error {
+	employee, err := s.user.FindEmployeeByID(ctx, req.EmployeeID)
+	if err != nil {
+		return fmt.Errorf("employee not found")
+	}
+
+	date, err := time.Parse(constants.DefaultTimeFormat, req.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date format %s", constants.DefaultTimeFormat)
+	}
+	if req.Date < timeNow().Format(constants.DefaultTimeFormat) {
+		return fmt.Errorf("overtime can only be assigned ahead of time")
+	}
+
+	plan, err := clockWindow(req.StartTime, req.EndTime)
+	if err != nil {
+		return err
+	}
+	if plan.minutes() <= 0 {
+		return fmt.Errorf("duration must be greater than 0")
+	}
+
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		_, err := s.repo.FindAssignment(ctx, req.EmployeeID, req.Date)
+		if err == nil {
+			return fmt.Errorf("overtime already assigned to %s on %s", employee.FullName, req.Date)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := s.checkLimits(ctx, req.EmployeeID, date, plan.minutes(), 0); err != nil {
+			return err
+		}
+
+		overtime := &Overtime{
+			CompanyID:       utils.GetCompanyIDFromCtx(ctx),
+			UserID:          employee.UserID,
+			EmployeeID:      employee.ID,
+			AssignedBy:      &req.AssignedBy,
+			Date:            req.Date,
+			StartTime:       req.StartTime,
+			EndTime:         req.EndTime,
+			DurationMinutes: plan.minutes(),
+			Reason:          req.Reason,
+			Status:          constants.OvertimeStatusAssigned,
+		}
+
+		if err := s.repo.Create(ctx, overtime); err != nil {
+			return err
+		}
+
+		go func() {
+			_ = s.notification.SendNotification(
+				utils.DetachContext(ctx),
+				employee.UserID,
+				string(constants.NotificationTypeOvertimeAssigned),
+				"Penugasan Lembur",
+				fmt.Sprintf("Anda ditugaskan lembur pada %s pukul %s - %s", req.Date, req.StartTime, req.EndTime),
+				overtime.ID,
+			)
+		}()
+
+		return nil
+	})
+}
+
+// claimAssignment settles assigned overtime with the minutes actually worked, it was approved when it was assigned.
+func (s *service) claimAssignment(ctx context.Context, assignment *Overtime, req *OvertimeRequest, durationMinutes, claimedMinutes int) error {
+	assignment.StartTime = req.StartTime
+	assignment.EndTime = req.EndTime
+	assignment.DurationMinutes = durationMinutes
+	assignment.ClaimedMinutes = claimedMinutes
+	assignment.Reason = req.Reason
+	assignment.Status = constants.OvertimeStatusApproved
+	assignment.ApprovedBy = assignment.AssignedBy
+
+	return s.repo.Update(ctx, assignment)
+}
@@ -0,0 +1,93 @@
+package overtime
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestService_Assign(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	timeNow = func() time.Time { return time.Date(2026, 6, 3, 10, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	tests := []struct {
+		name       string
+		req        *AssignRequest
+		assignment *Overtime
+		weekly     int
+		wantErr    string
+	}{
+		{
+			name: "success",
+			req:  &AssignRequest{AssignedBy: 3, EmployeeID: 1, Date: "2026-06-03", StartTime: "17:00", EndTime: "20:00", Reason: "Go live"},
+		},
+		{
+			name:    "date already passed",
+			req:     &AssignRequest{AssignedBy: 3, EmployeeID: 1, Date: "2026-06-02", StartTime: "17:00", EndTime: "20:00", Reason: "Go live"},
+			wantErr: "overtime can only be assigned ahead of time",
+		},
+		{
+			name:       "already assigned",
+			req:        &AssignRequest{AssignedBy: 3, EmployeeID: 1, Date: "2026-06-04", StartTime: "17:00", EndTime: "20:00", Reason: "Go live"},
+			assignment: &Overtime{ID: 4},
+			wantErr:    "overtime already assigned to John Doe on 2026-06-04",
+		},
+		{
+			name:    "above the daily limit",
+			req:     &AssignRequest{AssignedBy: 3, EmployeeID: 1, Date: "2026-06-04", StartTime: "17:00", EndTime: "22:00", Reason: "Go live"},
+			wantErr: "overtime exceeds the daily limit of 4 hours (0 minutes already recorded on 2026-06-04)",
+		},
+		{
+			name:    "above the weekly limit",
+			req:     &AssignRequest{AssignedBy: 3, EmployeeID: 1, Date: "2026-06-04", StartTime: "17:00", EndTime: "20:00", Reason: "Go live"},
+			weekly:  960,
+			wantErr: "overtime exceeds the weekly limit of 18 hours (960 minutes already recorded that week)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, notif, _, _, _, _, userProv := newTestOvertimeService()
+			userProv.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&user.Employee{ID: 1, UserID: 11, FullName: "John Doe"}, nil)
+			if tt.assignment != nil {
+				repo.On("FindAssignment", mock.Anything, uint(1), tt.req.Date).Return(tt.assignment, nil)
+			} else {
+				repo.On("FindAssignment", mock.Anything, uint(1), tt.req.Date).Return(nil, gorm.ErrRecordNotFound)
+			}
+			repo.On("SumMinutesByEmployee", mock.Anything, uint(1), tt.req.Date, tt.req.Date, uint(0)).Return(0, nil).Maybe()
+			repo.On("SumMinutesByEmployee", mock.Anything, uint(1), "2026-06-01", "2026-06-07", uint(0)).Return(tt.weekly, nil).Maybe()
+			repo.On("Create", mock.Anything, mock.AnythingOfType("*overtime.Overtime")).Return(nil).Maybe()
+			notif.On("SendNotification", mock.Anything, uint(11), string(constants.NotificationTypeOvertimeAssigned), mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			err := svc.Assign(ctx, tt.req)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			repo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(o *Overtime) bool {
+				return o.Status == constants.OvertimeStatusAssigned && o.UserID == 11 && *o.AssignedBy == 3 && o.DurationMinutes == 180
+			}))
+		})
+	}
+
+	t.Run("unknown employee", func(t *testing.T) {
+		svc, _, _, _, _, _, _, userProv := newTestOvertimeService()
+		userProv.On("FindEmployeeByID", mock.Anything, uint(9)).Return(nil, errors.New("record not found"))
+
+		err := svc.Assign(ctx, &AssignRequest{AssignedBy: 3, EmployeeID: 9, Date: "2026-06-04", StartTime: "17:00", EndTime: "20:00", Reason: "Go live"})
+		require.EqualError(t, err, "employee not found")
+	})
+}
@@ -21,6 +21,15 @@ type OvertimeRequest struct {
 	Reason     string `json:"reason" validate:"required"`
 }
 
+type AssignRequest struct {
+	AssignedBy uint   `json:"-"`
+	EmployeeID uint   `json:"employee_id" validate:"required"`
+	Date       string `json:"date" validate:"required"`
+	StartTime  string `json:"start_time" validate:"required"`
+	EndTime    string `json:"end_time" validate:"required"`
+	Reason     string `json:"reason" validate:"required"`
+}
+
 type ActionRequest struct {
 	ID              uint   `json:"-"`
 	SuperAdminID    uint   `json:"-"`
@@ -37,6 +46,8 @@ type OvertimeListResponse struct {
 	StartTime       string                   `json:"start_time"`
 	EndTime         string                   `json:"end_time"`
 	DurationMinutes int                      `json:"duration_minutes"`
+	ClaimedMinutes  int                      `json:"claimed_minutes"`
+	AssignedBy      *uint                    `json:"assigned_by"`
 	Status          constants.OvertimeStatus `json:"status"`
 	CreatedAt       time.Time                `json:"created_at"`
 }
@@ -50,8 +61,34 @@ type OvertimeDetailResponse struct {
 	StartTime       string                   `json:"start_time"`
 	EndTime         string                   `json:"end_time"`
 	DurationMinutes int                      `json:"duration_minutes"`
+	ClaimedMinutes  int                      `json:"claimed_minutes"`
+	AssignedBy      *uint                    `json:"assigned_by"`
 	Reason          string                   `json:"reason"`
 	Status          constants.OvertimeStatus `json:"status"`
 	RejectionReason string                   `json:"rejection_reason"`
 	CreatedAt       time.Time                `json:"created_at"`
 }
+
+type DiscrepancyFilter struct {
+	StartDate string
+	EndDate   string
+}
+
+// DiscrepancyResponse is a claim whose minutes are not covered by the attendance beyond the shift.
+type DiscrepancyResponse struct {
+	ID              uint                     `json:"id"`
+	EmployeeID      uint                     `json:"employee_id"`
+	EmployeeName    string                   `json:"employee_name"`
+	EmployeeNIK     string                   `json:"employee_nik"`
+	Date            string                   `json:"date"`
+	StartTime       string                   `json:"start_time"`
+	EndTime         string                   `json:"end_time"`
+	CheckInTime     string                   `json:"check_in_time"`
+	CheckOutTime    string                   `json:"check_out_time"`
+	ClaimedMinutes  int                      `json:"claimed_minutes"`
+	DurationMinutes int                      `json:"duration_minutes"`
+	ActualMinutes   int                      `json:"actual_minutes"`
+	DiffMinutes     int                      `json:"diff_minutes"`
+	Status          constants.OvertimeStatus `json:"status"`
+	Note            string                   `json:"note"`
+}
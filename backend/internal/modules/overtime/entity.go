@@ -22,13 +22,17 @@ type Overtime struct {
 	ApprovedBy *uint      `json:"approved_by"`
 	Approver   *user.User `gorm:"foreignKey:ApprovedBy" json:"approver,omitempty"`
 
+	// set when a manager assigned the overtime before it was worked
+	AssignedBy *uint `gorm:"index" json:"assigned_by"`
+
 	Date            string `gorm:"type:date;not null" json:"date"`
 	StartTime       string `gorm:"type:time;not null" json:"start_time"`
 	EndTime         string `gorm:"type:time;not null" json:"end_time"`
 	DurationMinutes int    `gorm:"type:int;not null" json:"duration_minutes"`
+	ClaimedMinutes  int    `gorm:"type:int;not null;default:0" json:"claimed_minutes"`
 	Reason          string `gorm:"type:text" json:"reason"`
 
-	Status          constants.OvertimeStatus `gorm:"type:enum('PENDING','APPROVED','REJECTED','PAID');default:'PENDING'" json:"status"`
+	Status          constants.OvertimeStatus `gorm:"type:enum('ASSIGNED','PENDING','APPROVED','REJECTED','PAID');default:'PENDING'" json:"status"`
 	RejectionReason sql.NullString           `gorm:"type:text" json:"rejection_reason"`
 }
 
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"slices"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -47,6 +48,32 @@ func (h *Handler) Create(ctx echo.Context) error {
 	return response.NewResponses[any](ctx, http.StatusCreated, "Overtime created successfully", nil, nil, nil)
 }
 
+func (h *Handler) Assign(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req AssignRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.AssignedBy = userContext.UserID
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	err = h.service.Assign(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("overtime assign failed: ", err)
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusCreated, "Overtime assigned successfully", nil, nil, nil)
+}
+
 func (h *Handler) GetAll(ctx echo.Context) error {
 	userContext, err := utils.GetUserContext(ctx)
 	if err != nil {
@@ -155,3 +182,34 @@ func (h *Handler) Export(ctx echo.Context) error {
 	ctx.Response().Header().Set("Content-Disposition", "attachment; filename=overtimes.xlsx")
 	return ctx.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelFile)
 }
+
+func (h *Handler) GetDiscrepancies(ctx echo.Context) error {
+	now := time.Now()
+	filter := DiscrepancyFilter{
+		StartDate: ctx.QueryParam("start_date"),
+		EndDate:   ctx.QueryParam("end_date"),
+	}
+	if filter.StartDate == "" {
+		filter.StartDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format(constants.DefaultTimeFormat)
+	}
+	if filter.EndDate == "" {
+		filter.EndDate = now.Format(constants.DefaultTimeFormat)
+	}
+
+	start, err := time.Parse(constants.DefaultTimeFormat, filter.StartDate)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid start_date", nil, err, nil)
+	}
+	end, err := time.Parse(constants.DefaultTimeFormat, filter.EndDate)
+	if err != nil || end.Before(start) {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid end_date", nil, err, nil)
+	}
+
+	data, err := h.service.GetDiscrepancies(ctx.Request().Context(), filter)
+	if err != nil {
+		logger.Errorw("get overtime discrepancies failed: ", err)
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Overtime Discrepancies Success", data, nil, nil)
+}
@@ -388,3 +388,98 @@ func TestHandler_Export(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_Assign(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: AssignRequest{EmployeeID: 1, Date: "2026-06-04", StartTime: "17:00", EndTime: "20:00", Reason: "Go live"},
+			setupMocks: func(svc *mockService) {
+				svc.On("Assign", mock.Anything, mock.MatchedBy(func(req *AssignRequest) bool {
+					return req.AssignedBy == 3 && req.EmployeeID == 1
+				})).Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "service error",
+			body: AssignRequest{EmployeeID: 1, Date: "2026-06-04", StartTime: "17:00", EndTime: "22:00", Reason: "Go live"},
+			setupMocks: func(svc *mockService) {
+				svc.On("Assign", mock.Anything, mock.Anything).Return(errors.New("overtime exceeds the daily limit of 4 hours"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "missing employee",
+			body:       AssignRequest{Date: "2026-06-04", StartTime: "17:00", EndTime: "20:00", Reason: "Go live"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			rec, err := testutil.NewAPITest(t, http.MethodPost, "/api/overtime/assign", tt.body).
+				WithAuthContext(&infrastructure.MyClaims{UserID: 3, CompanyID: 1, Permissions: []string{constants.ASSIGN_OVERTIME}}).
+				Execute(handler.Assign)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_GetDiscrepancies(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			url:  "/api/overtime/discrepancies?start_date=2026-06-01&end_date=2026-06-30",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetDiscrepancies", mock.Anything, DiscrepancyFilter{StartDate: "2026-06-01", EndDate: "2026-06-30"}).
+					Return([]DiscrepancyResponse{{ID: 1, ClaimedMinutes: 240, DiffMinutes: 240}}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "end before start",
+			url:        "/api/overtime/discrepancies?start_date=2026-06-30&end_date=2026-06-01",
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid start date",
+			url:        "/api/overtime/discrepancies?start_date=June",
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			rec, err := testutil.NewAPITest(t, http.MethodGet, tt.url, nil).
+				WithAuthContext(&infrastructure.MyClaims{UserID: 1, CompanyID: 1, Permissions: []string{constants.VIEW_OVERTIME}}).
+				Execute(handler.GetDiscrepancies)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
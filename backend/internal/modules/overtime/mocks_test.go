@@ -3,7 +3,11 @@ package overtime
 import (
 	"context"
 	"io"
+	"time"
 
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/attendance"
+	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
@@ -41,6 +45,14 @@ func (m *mockRepo) GetBulkActiveOvertimesByEmployeeIds(ctx context.Context, mont
 	return args.Get(0).(map[uint]int), args.Error(1)
 }
 
+func (m *mockRepo) GetBulkApprovedMinutes(ctx context.Context, startDate, endDate string) (map[uint]int, error) {
+	args := m.Called(ctx, startDate, endDate)
+	if args.Get(0) == nil {
+		return map[uint]int{}, args.Error(1)
+	}
+	return args.Get(0).(map[uint]int), args.Error(1)
+}
+
 func (m *mockRepo) UpdateBulkStatusByEmployeeId(ctx context.Context, employeeID uint, periodMonth, periodYear int, status constants.OvertimeStatus) error {
 	return m.Called(ctx, employeeID, periodMonth, periodYear, status).Error(0)
 }
@@ -49,6 +61,27 @@ func (m *mockRepo) Update(ctx context.Context, overtime *Overtime) error {
 	return m.Called(ctx, overtime).Error(0)
 }
 
+func (m *mockRepo) FindAssignment(ctx context.Context, employeeID uint, date string) (*Overtime, error) {
+	args := m.Called(ctx, employeeID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Overtime), args.Error(1)
+}
+
+func (m *mockRepo) SumMinutesByEmployee(ctx context.Context, employeeID uint, startDate, endDate string, excludeID uint) (int, error) {
+	args := m.Called(ctx, employeeID, startDate, endDate, excludeID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockRepo) FindClaimsByPeriod(ctx context.Context, startDate, endDate string) ([]Overtime, error) {
+	args := m.Called(ctx, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Overtime), args.Error(1)
+}
+
 // --- NotificationProvider Mock ---
 
 type mockNotification struct{ mock.Mock }
@@ -63,13 +96,45 @@ func (m *mockNotification) BlastNotification(ctx context.Context, userIDs []uint
 
 // --- UserProvider Mock ---
 
-type mockUserProvider struct{ mock.Mock }
+type mockApprovalEngine struct{ mock.Mock }
 
-func (m *mockUserProvider) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
-	args := m.Called(ctx, permissionApprovalName)
+func (m *mockApprovalEngine) Submit(ctx context.Context, req *approval.SubmitRequest) ([]uint, error) {
+	args := m.Called(ctx, req)
 	return args.Get(0).([]uint), args.Error(1)
 }
 
+func (m *mockApprovalEngine) Decide(ctx context.Context, req *approval.DecideRequest) (*approval.Decision, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*approval.Decision), args.Error(1)
+}
+
+// --- AttendanceProvider Mock ---
+
+type mockAttendance struct{ mock.Mock }
+
+func (m *mockAttendance) FindByEmployeesAndPeriod(ctx context.Context, employeeIDs []uint, startDate, endDate string) ([]attendance.Attendance, error) {
+	args := m.Called(ctx, employeeIDs, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]attendance.Attendance), args.Error(1)
+}
+
+// --- UserProvider Mock ---
+
+type mockUserProvider struct{ mock.Mock }
+
+func (m *mockUserProvider) FindEmployeeByID(ctx context.Context, id uint) (*user.Employee, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Employee), args.Error(1)
+}
+
 // --- ExcelProvider Mock ---
 
 type mockExcel struct{ mock.Mock }
@@ -144,6 +209,41 @@ func (m *mockService) Export(ctx context.Context, filter OvertimeFilter) ([]byte
 	return args.Get(0).([]byte), args.Error(1)
 }
 
+func (m *mockService) Assign(ctx context.Context, req *AssignRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) GetDiscrepancies(ctx context.Context, filter DiscrepancyFilter) ([]DiscrepancyResponse, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]DiscrepancyResponse), args.Error(1)
+}
+
+// workedDay is an attendance on the 09:00 - 17:00 shift, an empty checkOut has not checked out yet
+func workedDay(employeeID uint, date, checkIn, checkOut string) attendance.Attendance {
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", date+" "+clock)
+		return t
+	}
+
+	att := attendance.Attendance{
+		EmployeeID:  employeeID,
+		Date:        at("00:00"),
+		CheckInTime: at(checkIn),
+		Shift:       &master.Shift{StartTime: "09:00", EndTime: "17:00"},
+	}
+	if checkOut != "" {
+		out := at(checkOut)
+		if out.Before(att.CheckInTime) {
+			out = out.AddDate(0, 0, 1)
+		}
+		att.CheckOutTime = &out
+	}
+	return att
+}
+
 // helper for tests needing user.Employee
 func makeEmployee(id, shiftID uint) *user.Employee {
 	return &user.Employee{ID: id, ShiftID: shiftID, FullName: "John Doe", NIK: "EMP001"}
@@ -13,8 +13,12 @@ type Repository interface {
 	FindByID(ctx context.Context, id uint) (*Overtime, error)
 	FindAll(ctx context.Context, filter OvertimeFilter) ([]Overtime, int64, error)
 	GetBulkActiveOvertimesByEmployeeIds(ctx context.Context, month, year int, ids []uint) (map[uint]int, error)
+	GetBulkApprovedMinutes(ctx context.Context, startDate, endDate string) (map[uint]int, error)
 	UpdateBulkStatusByEmployeeId(ctx context.Context, employeeID uint, periodMonth, periodYear int, status constants.OvertimeStatus) error
 	Update(ctx context.Context, overtime *Overtime) error
+	FindAssignment(ctx context.Context, employeeID uint, date string) (*Overtime, error)
+	SumMinutesByEmployee(ctx context.Context, employeeID uint, startDate, endDate string, excludeID uint) (int, error)
+	FindClaimsByPeriod(ctx context.Context, startDate, endDate string) ([]Overtime, error)
 }
 
 type repository struct {
@@ -31,7 +35,7 @@ func (r *repository) Create(ctx context.Context, overtime *Overtime) error {
 }
 
 func (r *repository) FindByID(ctx context.Context, id uint) (*Overtime, error) {
-	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	db := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Overtime{})), "overtimes.employee_id")
 	var overtime Overtime
 
 	err := db.
@@ -49,7 +53,7 @@ func (r *repository) FindAll(ctx context.Context, filter OvertimeFilter) ([]Over
 	var overtimes []Overtime
 	var total int64
 
-	query := utils.TenantScope(ctx, db.Model(&Overtime{})).
+	query := utils.DataScope(ctx, utils.TenantScope(ctx, db.Model(&Overtime{})), "overtimes.employee_id").
 		Joins("JOIN users ON users.id = overtimes.user_id").
 		Joins("JOIN employees ON employees.id = overtimes.employee_id").
 		Preload("User").
@@ -110,6 +114,34 @@ func (r *repository) GetBulkActiveOvertimesByEmployeeIds(ctx context.Context, mo
 	return dataMap, nil
 }
 
+func (r *repository) GetBulkApprovedMinutes(ctx context.Context, startDate, endDate string) (map[uint]int, error) {
+	db := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Overtime{})), "overtimes.employee_id")
+	type Result struct {
+		EmployeeID      uint
+		DurationMinutes int
+	}
+
+	var results []Result
+
+	// paid overtime was approved before payroll picked it up, it still counts as worked
+	err := db.
+		Select("employee_id, SUM(duration_minutes) as duration_minutes").
+		Where("status IN ?", []string{string(constants.OvertimeStatusApproved), string(constants.OvertimeStatusPaid)}).
+		Where("date BETWEEN ? AND ?", startDate, endDate).
+		Group("employee_id").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dataMap := make(map[uint]int)
+	for _, res := range results {
+		dataMap[res.EmployeeID] = res.DurationMinutes
+	}
+
+	return dataMap, nil
+}
+
 func (r *repository) UpdateBulkStatusByEmployeeId(ctx context.Context, employeeID uint, periodMonth, periodYear int, status constants.OvertimeStatus) error {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 	return db.Model(&Overtime{}).
@@ -118,3 +150,55 @@ func (r *repository) UpdateBulkStatusByEmployeeId(ctx context.Context, employeeI
 		Where("MONTH(date) = ? AND YEAR(date) = ?", periodMonth, periodYear).
 		Update("status", string(status)).Error
 }
+
+func (r *repository) FindAssignment(ctx context.Context, employeeID uint, date string) (*Overtime, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var overtime Overtime
+
+	err := db.
+		Where("employee_id = ? AND date = ?", employeeID, date).
+		Where("status = ?", string(constants.OvertimeStatusAssigned)).
+		First(&overtime).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &overtime, nil
+}
+
+func (r *repository) SumMinutesByEmployee(ctx context.Context, employeeID uint, startDate, endDate string, excludeID uint) (int, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var total int
+
+	// assigned overtime is planned work, it counts against the limits until it is claimed
+	err := db.Model(&Overtime{}).
+		Select("COALESCE(SUM(duration_minutes), 0)").
+		Where("employee_id = ?", employeeID).
+		Where("status <> ?", string(constants.OvertimeStatusRejected)).
+		Where("date BETWEEN ? AND ?", startDate, endDate).
+		Where("id <> ?", excludeID).
+		Scan(&total).Error
+
+	return total, err
+}
+
+func (r *repository) FindClaimsByPeriod(ctx context.Context, startDate, endDate string) ([]Overtime, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var overtimes []Overtime
+
+	err := db.
+		Preload("Employee").
+		Where("status IN ?", []string{
+			string(constants.OvertimeStatusPending),
+			string(constants.OvertimeStatusApproved),
+			string(constants.OvertimeStatusPaid),
+		}).
+		Where("date BETWEEN ? AND ?", startDate, endDate).
+		Order("date ASC, employee_id ASC").
+		Find(&overtimes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return overtimes, nil
+}
@@ -34,10 +34,12 @@ func setupOvertimeTestDB(t *testing.T) *testutil.TestDB {
 		company_id INTEGER NOT NULL,
 		employee_id INTEGER NOT NULL,
 		approved_by INTEGER,
+		assigned_by INTEGER,
 		date DATE NOT NULL,
 		start_time TIME NOT NULL,
 		end_time TIME NOT NULL,
 		duration_minutes INTEGER NOT NULL,
+		claimed_minutes INTEGER NOT NULL DEFAULT 0,
 		reason TEXT,
 		status TEXT DEFAULT 'PENDING',
 		rejection_reason TEXT
@@ -225,3 +227,60 @@ func TestRepoOT_Update(t *testing.T) {
 		})
 	}
 }
+
+func TestRepoOT_GetBulkApprovedMinutes(t *testing.T) {
+	tdb := setupOvertimeTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedOvertimeTestData(t, tdb)
+
+	overtimes := []Overtime{
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, Date: "2026-06-01", StartTime: "18:00", EndTime: "20:00", DurationMinutes: 120, Status: constants.OvertimeStatusApproved},
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, Date: "2026-06-15", StartTime: "18:00", EndTime: "19:00", DurationMinutes: 60, Status: constants.OvertimeStatusPaid},
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, Date: "2026-06-16", StartTime: "18:00", EndTime: "19:00", DurationMinutes: 60, Status: constants.OvertimeStatusPending},
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, Date: "2026-07-01", StartTime: "18:00", EndTime: "19:00", DurationMinutes: 60, Status: constants.OvertimeStatusApproved},
+		{CompanyID: 2, UserID: 2, EmployeeID: 2, Date: "2026-06-01", StartTime: "18:00", EndTime: "19:00", DurationMinutes: 60, Status: constants.OvertimeStatusApproved},
+	}
+	for i := range overtimes {
+		require.NoError(t, tdb.DB.Create(&overtimes[i]).Error)
+	}
+
+	result, err := repo.GetBulkApprovedMinutes(ctx, "2026-06-01", "2026-06-30")
+
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]int{1: 180}, result)
+}
+
+func TestRepoOT_SumMinutesByEmployee(t *testing.T) {
+	tdb := setupOvertimeTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedOvertimeTestData(t, tdb)
+
+	overtimes := []Overtime{
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, Date: "2026-06-01", StartTime: "18:00", EndTime: "20:00", DurationMinutes: 120, Status: constants.OvertimeStatusApproved},
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, Date: "2026-06-02", StartTime: "18:00", EndTime: "19:00", DurationMinutes: 60, Status: constants.OvertimeStatusAssigned},
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, Date: "2026-06-03", StartTime: "18:00", EndTime: "19:00", DurationMinutes: 60, Status: constants.OvertimeStatusRejected},
+		{CompanyID: 1, UserID: 1, EmployeeID: 1, Date: "2026-06-09", StartTime: "18:00", EndTime: "19:00", DurationMinutes: 60, Status: constants.OvertimeStatusPending},
+	}
+	for i := range overtimes {
+		require.NoError(t, tdb.DB.Create(&overtimes[i]).Error)
+	}
+
+	total, err := repo.SumMinutesByEmployee(ctx, 1, "2026-06-01", "2026-06-07", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 180, total)
+
+	total, err = repo.SumMinutesByEmployee(ctx, 1, "2026-06-01", "2026-06-07", overtimes[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 120, total)
+
+	assignment, err := repo.FindAssignment(ctx, 1, "2026-06-02")
+	require.NoError(t, err)
+	assert.Equal(t, overtimes[1].ID, assignment.ID)
+
+	_, err = repo.FindAssignment(ctx, 1, "2026-06-01")
+	require.Error(t, err)
+}
@@ -2,12 +2,16 @@ package overtime
 
 import (
 	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
 	"basekarya-backend/pkg/utils"
 	"context"
+	"errors"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Service interface {
@@ -16,18 +20,24 @@ type Service interface {
 	GetList(ctx context.Context, filter OvertimeFilter) ([]OvertimeListResponse, *response.Meta, error)
 	ProcessAction(ctx context.Context, req *ActionRequest) error
 	Export(ctx context.Context, filter OvertimeFilter) ([]byte, error)
+	Assign(ctx context.Context, req *AssignRequest) error
+	GetDiscrepancies(ctx context.Context, filter DiscrepancyFilter) ([]DiscrepancyResponse, error)
 }
 
+var timeNow = time.Now
+
 type service struct {
 	repo               Repository
 	notification       NotificationProvider
-	user               UserProvider
+	approval           ApprovalEngine
 	transactionManager infrastructure.TransactionManager
 	excel              infrastructure.ExcelProvider
+	attendance         AttendanceProvider
+	user               UserProvider
 }
 
-func NewService(repo Repository, notification NotificationProvider, user UserProvider, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
-	return &service{repo, notification, user, transactionManager, excel}
+func NewService(repo Repository, notification NotificationProvider, approval ApprovalEngine, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider, attendance AttendanceProvider, user UserProvider) Service {
+	return &service{repo, notification, approval, transactionManager, excel, attendance, user}
 }
 
 func (s *service) Create(ctx context.Context, req *OvertimeRequest) error {
@@ -36,26 +46,61 @@ func (s *service) Create(ctx context.Context, req *OvertimeRequest) error {
 			return fmt.Errorf("user not found")
 		}
 
-		// Calculate duration
-		start, err := time.Parse(constants.ShiftHourFormat, req.StartTime)
+		claim, err := clockWindow(req.StartTime, req.EndTime)
+		if err != nil {
+			return err
+		}
+
+		claimedMinutes := claim.minutes()
+		if claimedMinutes <= 0 {
+			return fmt.Errorf("duration must be greater than 0")
+		}
+
+		date, err := time.Parse(constants.DefaultTimeFormat, req.Date)
 		if err != nil {
-			return fmt.Errorf("invalid start time format %s", constants.ShiftHourFormat)
+			return fmt.Errorf("invalid date format %s", constants.DefaultTimeFormat)
 		}
-		end, err := time.Parse(constants.ShiftHourFormat, req.EndTime)
+
+		att, err := s.attendanceOn(ctx, req.EmployeeID, req.Date)
 		if err != nil {
-			return fmt.Errorf("invalid end time format %s", constants.ShiftHourFormat)
+			return err
+		}
+		if att == nil {
+			return fmt.Errorf("no attendance recorded on %s", req.Date)
+		}
+		if att.CheckOutTime == nil {
+			return fmt.Errorf("check out before claiming overtime on %s", req.Date)
 		}
 
-		duration := end.Sub(start)
-		if duration < 0 {
-			// Crossed midnight
-			duration += 24 * time.Hour
+		assignment, err := s.repo.FindAssignment(ctx, req.EmployeeID, req.Date)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
 		}
 
-		durationMinutes := int(duration.Minutes())
+		// pre-approved overtime only covers the assigned hours
+		payable := claim
+		var excludeID uint
+		if assignment != nil {
+			assigned, err := clockWindow(assignment.StartTime, assignment.EndTime)
+			if err != nil {
+				return err
+			}
+			payable = claim.overlap(assigned)
+			excludeID = assignment.ID
+		}
 
+		// the claim is capped to the time the employee was actually checked in beyond the shift
+		durationMinutes := workedMinutes(payable, att, date)
 		if durationMinutes <= 0 {
-			return fmt.Errorf("duration must be greater than 0")
+			return fmt.Errorf("no overtime recorded outside the shift, checked out at %s", att.CheckOutTime.Format(constants.ShiftHourFormat))
+		}
+
+		if err := s.checkLimits(ctx, req.EmployeeID, date, durationMinutes, excludeID); err != nil {
+			return err
+		}
+
+		if assignment != nil {
+			return s.claimAssignment(ctx, assignment, req, durationMinutes, claimedMinutes)
 		}
 
 		overtime := &Overtime{
@@ -66,6 +111,7 @@ func (s *service) Create(ctx context.Context, req *OvertimeRequest) error {
 			StartTime:       req.StartTime,
 			EndTime:         req.EndTime,
 			DurationMinutes: durationMinutes,
+			ClaimedMinutes:  claimedMinutes,
 			Reason:          req.Reason,
 			Status:          constants.OvertimeStatusPending,
 		}
@@ -75,7 +121,12 @@ func (s *service) Create(ctx context.Context, req *OvertimeRequest) error {
 			return err
 		}
 
-		approvalUserIDs, err := s.user.FindApprovalUsers(ctx, string(constants.APPROVAL_OVERTIME))
+		approvalUserIDs, err := s.approval.Submit(ctx, &approval.SubmitRequest{
+			RequestType:     constants.ApprovalRequestOvertime,
+			ReferenceID:     overtime.ID,
+			RequesterUserID: req.UserID,
+			Amount:          overtimeHours(durationMinutes),
+		})
 		if err != nil {
 			return err
 		}
@@ -119,6 +170,8 @@ func (s *service) GetDetail(ctx context.Context, id uint) (*OvertimeDetailRespon
 		StartTime:       detail.StartTime,
 		EndTime:         detail.EndTime,
 		DurationMinutes: detail.DurationMinutes,
+		ClaimedMinutes:  detail.ClaimedMinutes,
+		AssignedBy:      detail.AssignedBy,
 		Reason:          detail.Reason,
 		Status:          detail.Status,
 		RejectionReason: rejectionReason,
@@ -147,6 +200,8 @@ func (s *service) GetList(ctx context.Context, filter OvertimeFilter) ([]Overtim
 			StartTime:       overtime.StartTime,
 			EndTime:         overtime.EndTime,
 			DurationMinutes: overtime.DurationMinutes,
+			ClaimedMinutes:  overtime.ClaimedMinutes,
+			AssignedBy:      overtime.AssignedBy,
 			Status:          overtime.Status,
 			CreatedAt:       overtime.CreatedAt,
 		})
@@ -172,8 +227,37 @@ func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 			notificationTitle   string
 			notificationMessage string
 		)
+		decideReq := &approval.DecideRequest{
+			RequestType:     constants.ApprovalRequestOvertime,
+			ReferenceID:     data.ID,
+			RequesterUserID: data.UserID,
+			Amount:          overtimeHours(data.DurationMinutes),
+			ActorUserID:     req.SuperAdminID,
+		}
+
 		switch constants.OvertimeAction(req.Action) {
 		case constants.OvertimeActionApprove:
+			decideReq.Action = constants.ApprovalActionApprove
+			decision, err := s.approval.Decide(ctx, decideReq)
+			if err != nil {
+				return err
+			}
+
+			// the overtime stays pending until the last level of the approval chain approves it
+			if decision.Status == constants.ApprovalStatusPending {
+				go func() {
+					_ = s.notification.BlastNotification(
+						utils.DetachContext(ctx),
+						decision.NextApproverIDs,
+						string(constants.NotificationTypeOvertimeApprovalReq),
+						"Persetujuan Lembur Lanjutan",
+						fmt.Sprintf("Lembur karyawan selama %d menit menunggu persetujuan Anda", data.DurationMinutes),
+						data.ID,
+					)
+				}()
+				return nil
+			}
+
 			data.Status = constants.OvertimeStatusApproved
 			data.ApprovedBy = &req.SuperAdminID
 
@@ -187,6 +271,12 @@ func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 				return fmt.Errorf("rejection reason is required")
 			}
 
+			decideReq.Action = constants.ApprovalActionReject
+			decideReq.Notes = req.RejectionReason
+			if _, err := s.approval.Decide(ctx, decideReq); err != nil {
+				return err
+			}
+
 			data.RejectionReason.String = req.RejectionReason
 			data.RejectionReason.Valid = true
 
@@ -227,7 +317,7 @@ func (s *service) Export(ctx context.Context, filter OvertimeFilter) ([]byte, er
 	}
 
 	headers := []string{
-		"ID", "Karyawan", "Tanggal", "Mulai", "Selesai", "Durasi (Menit)", "Alasan", "Status", "Dibuat Pada",
+		"ID", "Karyawan", "Tanggal", "Mulai", "Selesai", "Diklaim (Menit)", "Durasi (Menit)", "Alasan", "Status", "Dibuat Pada",
 	}
 
 	var rows [][]interface{}
@@ -243,6 +333,7 @@ func (s *service) Export(ctx context.Context, filter OvertimeFilter) ([]byte, er
 			ot.Date,
 			ot.StartTime,
 			ot.EndTime,
+			ot.ClaimedMinutes,
 			ot.DurationMinutes,
 			ot.Reason,
 			ot.Status,
@@ -253,3 +344,8 @@ func (s *service) Export(ctx context.Context, filter OvertimeFilter) ([]byte, er
 
 	return s.excel.GenerateSimpleExcel("Overtimes", headers, rows)
 }
+
+// overtimeHours is the amount approval chain thresholds are compared against.
+func overtimeHours(durationMinutes int) float64 {
+	return float64(durationMinutes) / 60
+}
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/attendance"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/internal/testutil"
@@ -13,17 +15,20 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
-func newTestOvertimeService() (Service, *mockRepo, *mockNotification, *mockUserProvider, *testutil.MockTransactionManager, *mockExcel) {
+func newTestOvertimeService() (Service, *mockRepo, *mockNotification, *mockApprovalEngine, *testutil.MockTransactionManager, *mockExcel, *mockAttendance, *mockUserProvider) {
 	repo := new(mockRepo)
 	notif := new(mockNotification)
-	userProv := new(mockUserProvider)
+	approvalEngine := new(mockApprovalEngine)
 	tm := testutil.NewMockTransactionManager()
 	excel := new(mockExcel)
+	attend := new(mockAttendance)
+	userProv := new(mockUserProvider)
 
-	svc := NewService(repo, notif, userProv, tm, excel)
-	return svc, repo, notif, userProv, tm, excel
+	svc := NewService(repo, notif, approvalEngine, tm, excel, attend, userProv)
+	return svc, repo, notif, approvalEngine, tm, excel, attend, userProv
 }
 
 func TestService_Create(t *testing.T) {
@@ -32,7 +37,7 @@ func TestService_Create(t *testing.T) {
 	tests := []struct {
 		name       string
 		req        *OvertimeRequest
-		setupMocks func(*mockRepo, *mockNotification, *mockUserProvider)
+		setupMocks func(*mockRepo, *mockNotification, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -46,9 +51,11 @@ func TestService_Create(t *testing.T) {
 				EndTime:    "20:00",
 				Reason:     "Project deadline",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*overtime.Overtime")).Return(nil)
-				userProv.On("FindApprovalUsers", mock.Anything, string(constants.APPROVAL_OVERTIME)).Return([]uint{10}, nil)
+				approvalEngine.On("Submit", mock.Anything, mock.MatchedBy(func(req *approval.SubmitRequest) bool {
+					return req.RequestType == constants.ApprovalRequestOvertime && req.RequesterUserID == 1 && req.Amount == 2
+				})).Return([]uint{10}, nil)
 				notif.On("BlastNotification", mock.Anything, []uint{10}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
@@ -63,7 +70,7 @@ func TestService_Create(t *testing.T) {
 				EndTime:    "20:00",
 				Reason:     "Test",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {},
 			wantErr:    true,
 			errMsg:     "user not found",
 		},
@@ -77,7 +84,7 @@ func TestService_Create(t *testing.T) {
 				EndTime:    "20:00",
 				Reason:     "Test",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {},
 			wantErr:    true,
 			errMsg:     "invalid start time format",
 		},
@@ -91,7 +98,7 @@ func TestService_Create(t *testing.T) {
 				EndTime:    "invalid",
 				Reason:     "Test",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {},
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {},
 			wantErr:    true,
 			errMsg:     "invalid end time format",
 		},
@@ -105,7 +112,7 @@ func TestService_Create(t *testing.T) {
 				EndTime:    "20:00",
 				Reason:     "Test",
 			},
-			setupMocks: func(repo *mockRepo, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*overtime.Overtime")).Return(errors.New("db error"))
 			},
 			wantErr: true,
@@ -115,8 +122,12 @@ func TestService_Create(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, notif, userProv, _, _ := newTestOvertimeService()
-			tt.setupMocks(repo, notif, userProv)
+			svc, repo, notif, approvalEngine, _, _, attend, _ := newTestOvertimeService()
+			attend.On("FindByEmployeesAndPeriod", mock.Anything, []uint{1}, "2026-06-01", "2026-06-01").
+				Return([]attendance.Attendance{workedDay(1, "2026-06-01", "08:55", "20:10")}, nil).Maybe()
+			repo.On("FindAssignment", mock.Anything, uint(1), "2026-06-01").Return(nil, gorm.ErrRecordNotFound).Maybe()
+			repo.On("SumMinutesByEmployee", mock.Anything, uint(1), mock.Anything, mock.Anything, uint(0)).Return(0, nil).Maybe()
+			tt.setupMocks(repo, notif, approvalEngine)
 
 			err := svc.Create(ctx, tt.req)
 
@@ -187,7 +198,7 @@ func TestService_GetDetail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _ := newTestOvertimeService()
+			svc, repo, _, _, _, _, _, _ := newTestOvertimeService()
 			tt.setupMocks(repo)
 
 			resp, err := svc.GetDetail(ctx, tt.id)
@@ -209,7 +220,7 @@ func TestService_ProcessAction(t *testing.T) {
 	tests := []struct {
 		name       string
 		req        *ActionRequest
-		setupMocks func(*mockRepo)
+		setupMocks func(*mockRepo, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -220,12 +231,15 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.OvertimeActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(1)).Return(&Overtime{
 					ID:     1,
 					UserID: 1,
 					Status: constants.OvertimeStatusPending,
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.Action == constants.ApprovalActionApprove && req.ActorUserID == 10
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
 				repo.On("Update", mock.Anything, mock.AnythingOfType("*overtime.Overtime")).Return(nil)
 			},
 			wantErr: false,
@@ -238,16 +252,39 @@ func TestService_ProcessAction(t *testing.T) {
 				Action:          string(constants.OvertimeActionReject),
 				RejectionReason: "Not eligible",
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(2)).Return(&Overtime{
 					ID:     2,
 					UserID: 1,
 					Status: constants.OvertimeStatusPending,
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.Action == constants.ApprovalActionReject && req.Notes == "Not eligible"
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusRejected}, nil)
 				repo.On("Update", mock.Anything, mock.AnythingOfType("*overtime.Overtime")).Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "approve waits for the next approval level",
+			req: &ActionRequest{
+				ID:           7,
+				SuperAdminID: 10,
+				Action:       string(constants.OvertimeActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
+				repo.On("FindByID", mock.Anything, uint(7)).Return(&Overtime{
+					ID:              7,
+					UserID:          1,
+					DurationMinutes: 240,
+					Status:          constants.OvertimeStatusPending,
+				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.Amount == 4
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusPending, NextApproverIDs: []uint{20}}, nil)
+			},
+			wantErr: false,
+		},
 		{
 			name: "error not pending",
 			req: &ActionRequest{
@@ -255,7 +292,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.OvertimeActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(3)).Return(&Overtime{
 					ID:     3,
 					Status: constants.OvertimeStatusApproved,
@@ -270,7 +307,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.OvertimeActionReject),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(4)).Return(&Overtime{
 					ID:     4,
 					UserID: 1,
@@ -287,7 +324,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       "INVALID",
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(5)).Return(&Overtime{
 					ID:     5,
 					UserID: 1,
@@ -304,7 +341,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.OvertimeActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(6)).Return(nil, errors.New("db error"))
 			},
 			wantErr: true,
@@ -314,11 +351,12 @@ func TestService_ProcessAction(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, notif, _, _, _ := newTestOvertimeService()
-			tt.setupMocks(repo)
+			svc, repo, notif, approvalEngine, _, _, _, _ := newTestOvertimeService()
+			tt.setupMocks(repo, approvalEngine)
 
 			if !tt.wantErr && (tt.req.Action == string(constants.OvertimeActionApprove) || tt.req.Action == string(constants.OvertimeActionReject)) {
-				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+				notif.On("BlastNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 			}
 
 			err := svc.ProcessAction(ctx, tt.req)
@@ -372,7 +410,7 @@ func TestService_GetList(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _ := newTestOvertimeService()
+			svc, repo, _, _, _, _, _, _ := newTestOvertimeService()
 			tt.setupMocks(repo)
 
 			list, meta, err := svc.GetList(ctx, tt.filter)
@@ -430,7 +468,7 @@ func TestService_Export(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, excel := newTestOvertimeService()
+			svc, repo, _, _, _, excel, _, _ := newTestOvertimeService()
 			tt.setupMocks(repo, excel)
 
 			data, err := svc.Export(ctx, tt.filter)
@@ -0,0 +1,203 @@
+package overtime
+
+import (
+	"basekarya-backend/internal/modules/attendance"
+	"basekarya-backend/pkg/constants"
+	"context"
+	"fmt"
+	"time"
+)
+
+// window is a span in minutes from the midnight of the overtime date, an end past 1440 runs into the next day.
+type window struct {
+	start int
+	end   int
+}
+
+func (w window) minutes() int {
+	return max(w.end-w.start, 0)
+}
+
+func (w window) overlap(o window) window {
+	start := max(w.start, o.start)
+	return window{start: start, end: max(min(w.end, o.end), start)}
+}
+
+// clockWindow turns wall clock times into a window, an end before the start crossed midnight.
+func clockWindow(startTime, endTime string) (window, error) {
+	start, err := parseClock(startTime)
+	if err != nil {
+		return window{}, fmt.Errorf("invalid start time format %s", constants.ShiftHourFormat)
+	}
+	end, err := parseClock(endTime)
+	if err != nil {
+		return window{}, fmt.Errorf("invalid end time format %s", constants.ShiftHourFormat)
+	}
+
+	if end < start {
+		end += 24 * 60
+	}
+	return window{start: start, end: end}, nil
+}
+
+// parseClock reads the minute of day, time columns come back from the database with seconds.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse(constants.ShiftHourFormat, value)
+	if err != nil {
+		t, err = time.Parse(constants.AttendanceTimeFormat, value)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// overtimeDate reads the date of an overtime, date columns come back from the database as a timestamp.
+func overtimeDate(value string) (time.Time, error) {
+	if len(value) > len(constants.DefaultTimeFormat) {
+		value = value[:len(constants.DefaultTimeFormat)]
+	}
+	return time.Parse(constants.DefaultTimeFormat, value)
+}
+
+// workedMinutes is the part of the claim the employee was checked in for outside of the shift.
+func workedMinutes(claim window, att *attendance.Attendance, date time.Time) int {
+	if att.CheckOutTime == nil {
+		return 0
+	}
+
+	loc := att.CheckInTime.Location()
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	present := window{
+		start: int(att.CheckInTime.Sub(midnight).Minutes()),
+		end:   int(att.CheckOutTime.In(loc).Sub(midnight).Minutes()),
+	}
+
+	worked := claim.overlap(present)
+	if att.Shift == nil {
+		return worked.minutes()
+	}
+
+	shift, err := clockWindow(att.Shift.StartTime, att.Shift.EndTime)
+	if err != nil {
+		return worked.minutes()
+	}
+	return worked.minutes() - worked.overlap(shift).minutes()
+}
+
+// attendanceOn returns the attendance the overtime was worked on, nil when the employee did not check in.
+func (s *service) attendanceOn(ctx context.Context, employeeID uint, date string) (*attendance.Attendance, error) {
+	logs, err := s.attendance.FindByEmployeesAndPeriod(ctx, []uint{employeeID}, date, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return nil, nil
+	}
+	return &logs[0], nil
+}
+
+// checkLimits enforces the statutory daily and weekly overtime limits, excludeID is the overtime being replaced.
+func (s *service) checkLimits(ctx context.Context, employeeID uint, date time.Time, minutes int, excludeID uint) error {
+	day := date.Format(constants.DefaultTimeFormat)
+	daily, err := s.repo.SumMinutesByEmployee(ctx, employeeID, day, day, excludeID)
+	if err != nil {
+		return err
+	}
+	if daily+minutes > constants.OvertimeMaxMinutesPerDay {
+		return fmt.Errorf("overtime exceeds the daily limit of %d hours (%d minutes already recorded on %s)", constants.OvertimeMaxMinutesPerDay/60, daily, day)
+	}
+
+	// weeks run from monday to sunday
+	monday := date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+	weekly, err := s.repo.SumMinutesByEmployee(ctx, employeeID, monday.Format(constants.DefaultTimeFormat), monday.AddDate(0, 0, 6).Format(constants.DefaultTimeFormat), excludeID)
+	if err != nil {
+		return err
+	}
+	if weekly+minutes > constants.OvertimeMaxMinutesPerWeek {
+		return fmt.Errorf("overtime exceeds the weekly limit of %d hours (%d minutes already recorded that week)", constants.OvertimeMaxMinutesPerWeek/60, weekly)
+	}
+
+	return nil
+}
+
+func (s *service) GetDiscrepancies(ctx context.Context, filter DiscrepancyFilter) ([]DiscrepancyResponse, error) {
+	claims, err := s.repo.FindClaimsByPeriod(ctx, filter.StartDate, filter.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims) == 0 {
+		return []DiscrepancyResponse{}, nil
+	}
+
+	employeeIDs := make([]uint, 0, len(claims))
+	seen := make(map[uint]bool, len(claims))
+	for _, c := range claims {
+		if !seen[c.EmployeeID] {
+			seen[c.EmployeeID] = true
+			employeeIDs = append(employeeIDs, c.EmployeeID)
+		}
+	}
+
+	logs, err := s.attendance.FindByEmployeesAndPeriod(ctx, employeeIDs, filter.StartDate, filter.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attendance by period: %w", err)
+	}
+
+	attendances := make(map[string]*attendance.Attendance, len(logs))
+	for i := range logs {
+		attendances[fmt.Sprintf("%d|%s", logs[i].EmployeeID, logs[i].Date.Format(constants.DefaultTimeFormat))] = &logs[i]
+	}
+
+	res := []DiscrepancyResponse{}
+	for _, c := range claims {
+		date, err := overtimeDate(c.Date)
+		if err != nil {
+			return nil, err
+		}
+		claim, err := clockWindow(c.StartTime, c.EndTime)
+		if err != nil {
+			return nil, err
+		}
+
+		// claims made before capping only recorded the duration
+		claimed := c.ClaimedMinutes
+		if claimed == 0 {
+			claimed = c.DurationMinutes
+		}
+
+		row := DiscrepancyResponse{
+			ID:              c.ID,
+			EmployeeID:      c.EmployeeID,
+			EmployeeName:    c.Employee.FullName,
+			EmployeeNIK:     c.Employee.NIK,
+			Date:            date.Format(constants.DefaultTimeFormat),
+			StartTime:       c.StartTime,
+			EndTime:         c.EndTime,
+			ClaimedMinutes:  claimed,
+			DurationMinutes: c.DurationMinutes,
+			Status:          c.Status,
+		}
+
+		att := attendances[fmt.Sprintf("%d|%s", c.EmployeeID, row.Date)]
+		switch {
+		case att == nil:
+			row.Note = "no attendance"
+		case att.CheckOutTime == nil:
+			row.CheckInTime = att.CheckInTime.Format(constants.ShiftHourFormat)
+			row.Note = "no check out"
+		default:
+			row.CheckInTime = att.CheckInTime.Format(constants.ShiftHourFormat)
+			row.CheckOutTime = att.CheckOutTime.Format(constants.ShiftHourFormat)
+			row.ActualMinutes = workedMinutes(claim, att, date)
+		}
+
+		if claimed <= row.ActualMinutes && c.DurationMinutes <= row.ActualMinutes {
+			continue
+		}
+		row.DiffMinutes = claimed - row.ActualMinutes
+		res = append(res, row)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,196 @@
+package overtime
+
+import (
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/attendance"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestWorkedMinutes(t *testing.T) {
+	date := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		start    string
+		end      string
+		checkIn  string
+		checkOut string
+		want     int
+	}{
+		{name: "fully worked", start: "17:00", end: "19:00", checkIn: "08:55", checkOut: "19:30", want: 120},
+		{name: "capped to check out", start: "17:00", end: "21:00", checkIn: "08:55", checkOut: "17:00", want: 0},
+		{name: "partly worked", start: "17:00", end: "21:00", checkIn: "08:55", checkOut: "18:15", want: 75},
+		{name: "shift hours are not overtime", start: "15:00", end: "19:00", checkIn: "08:55", checkOut: "19:00", want: 120},
+		{name: "before the shift", start: "06:00", end: "09:00", checkIn: "07:00", checkOut: "17:05", want: 120},
+		{name: "across midnight", start: "22:00", end: "01:00", checkIn: "08:55", checkOut: "00:30", want: 150},
+		{name: "not checked out", start: "17:00", end: "19:00", checkIn: "08:55", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim, err := clockWindow(tt.start, tt.end)
+			require.NoError(t, err)
+
+			att := workedDay(1, "2026-06-01", tt.checkIn, tt.checkOut)
+			assert.Equal(t, tt.want, workedMinutes(claim, &att, date))
+		})
+	}
+}
+
+func TestService_Create_Validation(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	req := func(start, end string) *OvertimeRequest {
+		return &OvertimeRequest{UserID: 1, EmployeeID: 1, Date: "2026-06-03", StartTime: start, EndTime: end, Reason: "Release"}
+	}
+
+	tests := []struct {
+		name         string
+		req          *OvertimeRequest
+		attendances  []attendance.Attendance
+		assignment   *Overtime
+		dailyMinutes int
+		weekMinutes  int
+		wantErr      string
+		wantMinutes  int
+	}{
+		{
+			name:        "claim is capped to check out",
+			req:         req("17:00", "21:00"),
+			attendances: []attendance.Attendance{workedDay(1, "2026-06-03", "08:50", "18:30")},
+			wantMinutes: 90,
+		},
+		{
+			name:        "checked out at the end of the shift",
+			req:         req("17:00", "21:00"),
+			attendances: []attendance.Attendance{workedDay(1, "2026-06-03", "08:50", "17:00")},
+			wantErr:     "no overtime recorded outside the shift, checked out at 17:00",
+		},
+		{
+			name:    "no attendance",
+			req:     req("17:00", "19:00"),
+			wantErr: "no attendance recorded on 2026-06-03",
+		},
+		{
+			name:        "not checked out yet",
+			req:         req("17:00", "19:00"),
+			attendances: []attendance.Attendance{workedDay(1, "2026-06-03", "08:50", "")},
+			wantErr:     "check out before claiming overtime on 2026-06-03",
+		},
+		{
+			name:         "daily limit",
+			req:          req("17:00", "20:00"),
+			attendances:  []attendance.Attendance{workedDay(1, "2026-06-03", "08:50", "20:00")},
+			dailyMinutes: 90,
+			wantErr:      "overtime exceeds the daily limit of 4 hours (90 minutes already recorded on 2026-06-03)",
+		},
+		{
+			name:        "weekly limit",
+			req:         req("17:00", "19:00"),
+			attendances: []attendance.Attendance{workedDay(1, "2026-06-03", "08:50", "19:00")},
+			weekMinutes: 1000,
+			wantErr:     "overtime exceeds the weekly limit of 18 hours (1000 minutes already recorded that week)",
+		},
+		{
+			name:        "assigned overtime is approved up to the assigned hours",
+			req:         req("17:00", "21:00"),
+			attendances: []attendance.Attendance{workedDay(1, "2026-06-03", "08:50", "21:00")},
+			assignment: &Overtime{
+				ID: 7, UserID: 1, EmployeeID: 1, AssignedBy: uintPtr(3), Date: "2026-06-03",
+				StartTime: "17:00", EndTime: "19:00", DurationMinutes: 120, Status: constants.OvertimeStatusAssigned,
+			},
+			wantMinutes: 120,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, notif, approvalEngine, _, _, attend, _ := newTestOvertimeService()
+			attend.On("FindByEmployeesAndPeriod", mock.Anything, []uint{1}, "2026-06-03", "2026-06-03").Return(tt.attendances, nil)
+
+			var excludeID uint
+			if tt.assignment != nil {
+				excludeID = tt.assignment.ID
+				repo.On("FindAssignment", mock.Anything, uint(1), "2026-06-03").Return(tt.assignment, nil)
+			} else {
+				repo.On("FindAssignment", mock.Anything, uint(1), "2026-06-03").Return(nil, gorm.ErrRecordNotFound)
+			}
+			repo.On("SumMinutesByEmployee", mock.Anything, uint(1), "2026-06-03", "2026-06-03", excludeID).Return(tt.dailyMinutes, nil).Maybe()
+			repo.On("SumMinutesByEmployee", mock.Anything, uint(1), "2026-06-01", "2026-06-07", excludeID).Return(tt.weekMinutes, nil).Maybe()
+
+			repo.On("Create", mock.Anything, mock.AnythingOfType("*overtime.Overtime")).Return(nil).Maybe()
+			repo.On("Update", mock.Anything, mock.AnythingOfType("*overtime.Overtime")).Return(nil).Maybe()
+			approvalEngine.On("Submit", mock.Anything, mock.Anything).Return([]uint{10}, nil).Maybe()
+			notif.On("BlastNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			err := svc.Create(ctx, tt.req)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			if tt.assignment != nil {
+				repo.AssertCalled(t, "Update", mock.Anything, mock.MatchedBy(func(o *Overtime) bool {
+					return o.ID == 7 && o.Status == constants.OvertimeStatusApproved && *o.ApprovedBy == 3 &&
+						o.DurationMinutes == tt.wantMinutes && o.ClaimedMinutes == 240
+				}))
+				approvalEngine.AssertNotCalled(t, "Submit", mock.Anything, mock.Anything)
+				return
+			}
+
+			repo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(o *Overtime) bool {
+				return o.Status == constants.OvertimeStatusPending && o.DurationMinutes == tt.wantMinutes && o.ClaimedMinutes == 240
+			}))
+			approvalEngine.AssertCalled(t, "Submit", mock.Anything, mock.MatchedBy(func(r *approval.SubmitRequest) bool {
+				return r.Amount == overtimeHours(tt.wantMinutes)
+			}))
+		})
+	}
+}
+
+func TestService_GetDiscrepancies(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	svc, repo, _, _, _, _, attend, _ := newTestOvertimeService()
+	repo.On("FindClaimsByPeriod", mock.Anything, "2026-06-01", "2026-06-30").Return([]Overtime{
+		{ID: 1, EmployeeID: 1, Employee: user.Employee{FullName: "John"}, Date: "2026-06-01T00:00:00+07:00", StartTime: "17:00:00", EndTime: "21:00:00", DurationMinutes: 240, Status: constants.OvertimeStatusPaid},
+		{ID: 2, EmployeeID: 1, Date: "2026-06-02", StartTime: "17:00", EndTime: "19:00", DurationMinutes: 120, ClaimedMinutes: 120, Status: constants.OvertimeStatusApproved},
+		{ID: 3, EmployeeID: 2, Date: "2026-06-02", StartTime: "17:00", EndTime: "18:00", DurationMinutes: 60, ClaimedMinutes: 60, Status: constants.OvertimeStatusPending},
+	}, nil)
+	attend.On("FindByEmployeesAndPeriod", mock.Anything, []uint{1, 2}, "2026-06-01", "2026-06-30").Return([]attendance.Attendance{
+		workedDay(1, "2026-06-01", "08:55", "17:00"),
+		workedDay(1, "2026-06-02", "08:55", "19:05"),
+	}, nil)
+
+	res, err := svc.GetDiscrepancies(ctx, DiscrepancyFilter{StartDate: "2026-06-01", EndDate: "2026-06-30"})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	assert.Equal(t, uint(1), res[0].ID)
+	assert.Equal(t, "2026-06-01", res[0].Date)
+	assert.Equal(t, "17:00", res[0].CheckOutTime)
+	assert.Equal(t, 240, res[0].ClaimedMinutes)
+	assert.Equal(t, 0, res[0].ActualMinutes)
+	assert.Equal(t, 240, res[0].DiffMinutes)
+
+	assert.Equal(t, uint(3), res[1].ID)
+	assert.Equal(t, "no attendance", res[1].Note)
+	assert.Equal(t, 60, res[1].DiffMinutes)
+}
+
+func uintPtr(v uint) *uint {
+	return &v
+}
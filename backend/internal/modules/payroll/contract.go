@@ -1,25 +1,30 @@
 package payroll
 
 import (
+	"basekarya-backend/internal/modules/attendance"
 	"basekarya-backend/internal/modules/bpjs"
 	"basekarya-backend/internal/modules/company"
 	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/reimbursement"
 	"basekarya-backend/internal/modules/tax"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/constants"
 	"context"
+	"time"
 )
 
 type UserProvider interface {
-	FindAllEmployeeActive(ctx context.Context) ([]user.Employee, error)
+	FindAllEmployeeActiveInScope(ctx context.Context) ([]user.Employee, error)
 }
 
 type AttendanceProvider interface {
-	GetBulkLateDuration(ctx context.Context, month, year int) (map[uint]int, error)
+	GetBulkMonthlySummary(ctx context.Context, month, year int) (map[uint]attendance.TimesheetSummary, error)
 }
 
 type ReimbursementProvider interface {
-	GetBulkApprovedAmount(ctx context.Context, month, year int) (map[uint]float64, error)
+	GetBulkPayableClaims(ctx context.Context) (map[uint][]reimbursement.Reimbursement, error)
+	QueuePayrollClaims(ctx context.Context, ids []uint) error
+	MarkClaimsPaid(ctx context.Context, ids []uint, paidAt time.Time) error
 }
 
 type CompanyProvider interface {
@@ -38,8 +43,8 @@ type EmailProvider interface {
 }
 
 type LoanProvider interface {
-	GetBulkActiveLoansByEmployeeIds(ctx context.Context, ids []uint) (map[uint]loan.Loan, error)
-	Update(ctx context.Context, loan *loan.Loan) error
+	GetBulkDueInstallments(ctx context.Context, ids []uint, month, year int) (map[uint][]loan.LoanInstallment, error)
+	PayInstallments(ctx context.Context, payments []loan.InstallmentPayment, paidAt time.Time) error
 }
 
 type OvertimeProvider interface {
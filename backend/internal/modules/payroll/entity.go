@@ -40,6 +40,10 @@ type PayrollDetail struct {
 	Code            *string                    `gorm:"type:varchar(30)" json:"code"`
 	Group           *string                    `gorm:"type:varchar(30)" json:"group"`
 	IsEmployerBorne bool                       `gorm:"default:false" json:"is_employer_borne"`
+	// set on loan deductions, the installment the deduction pays
+	LoanInstallmentID *uint `gorm:"index" json:"loan_installment_id"`
+	// set on reimbursement allowances, the claim the allowance pays back
+	ReimbursementID *uint `gorm:"index" json:"reimbursement_id"`
 	Type            constants.PayrollDetailType `gorm:"type:varchar(20);not null" json:"type"`
 
 	Amount float64 `gorm:"type:decimal(15,2);not null" json:"amount"`
@@ -2,9 +2,13 @@ package payroll
 
 import (
 	"context"
+	"time"
 
+	"basekarya-backend/internal/modules/attendance"
 	"basekarya-backend/internal/modules/company"
 	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/reimbursement"
+	"basekarya-backend/internal/modules/tax"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/internal/testutil"
 	"basekarya-backend/pkg/constants"
@@ -41,35 +45,48 @@ func (m *mockRepo) GetExistingEmployeeID(ctx context.Context, month, year int) (
 	return args.Get(0).(map[uint]bool), args.Error(1)
 }
 
+func (m *mockRepo) FindPaidByEmployeeYear(ctx context.Context, employeeID uint, year int) ([]Payroll, error) {
+	args := m.Called(ctx, employeeID, year)
+	return args.Get(0).([]Payroll), args.Error(1)
+}
+
 func (m *mockRepo) UpdateStatus(ctx context.Context, id uint, status constants.PayrollStatus) error {
 	return m.Called(ctx, id, status).Error(0)
 }
 
 type mockUserProvider struct{ mock.Mock }
 
-func (m *mockUserProvider) FindAllEmployeeActive(ctx context.Context) ([]user.Employee, error) {
+func (m *mockUserProvider) FindAllEmployeeActiveInScope(ctx context.Context) ([]user.Employee, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]user.Employee), args.Error(1)
 }
 
 type mockAttendanceProvider struct{ mock.Mock }
 
-func (m *mockAttendanceProvider) GetBulkLateDuration(ctx context.Context, month, year int) (map[uint]int, error) {
+func (m *mockAttendanceProvider) GetBulkMonthlySummary(ctx context.Context, month, year int) (map[uint]attendance.TimesheetSummary, error) {
 	args := m.Called(ctx, month, year)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(map[uint]int), args.Error(1)
+	return args.Get(0).(map[uint]attendance.TimesheetSummary), args.Error(1)
 }
 
 type mockReimbursementProvider struct{ mock.Mock }
 
-func (m *mockReimbursementProvider) GetBulkApprovedAmount(ctx context.Context, month, year int) (map[uint]float64, error) {
-	args := m.Called(ctx, month, year)
+func (m *mockReimbursementProvider) GetBulkPayableClaims(ctx context.Context) (map[uint][]reimbursement.Reimbursement, error) {
+	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(map[uint]float64), args.Error(1)
+	return args.Get(0).(map[uint][]reimbursement.Reimbursement), args.Error(1)
+}
+
+func (m *mockReimbursementProvider) QueuePayrollClaims(ctx context.Context, ids []uint) error {
+	return m.Called(ctx, ids).Error(0)
+}
+
+func (m *mockReimbursementProvider) MarkClaimsPaid(ctx context.Context, ids []uint, paidAt time.Time) error {
+	return m.Called(ctx, ids, paidAt).Error(0)
 }
 
 type mockCompanyProvider struct{ mock.Mock }
@@ -96,16 +113,16 @@ func (m *mockEmailProvider) SendWithAttachment(to, subject, htmlBody, fileName s
 
 type mockLoanProvider struct{ mock.Mock }
 
-func (m *mockLoanProvider) GetBulkActiveLoansByEmployeeIds(ctx context.Context, ids []uint) (map[uint]loan.Loan, error) {
-	args := m.Called(ctx, ids)
+func (m *mockLoanProvider) GetBulkDueInstallments(ctx context.Context, ids []uint, month, year int) (map[uint][]loan.LoanInstallment, error) {
+	args := m.Called(ctx, ids, month, year)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(map[uint]loan.Loan), args.Error(1)
+	return args.Get(0).(map[uint][]loan.LoanInstallment), args.Error(1)
 }
 
-func (m *mockLoanProvider) Update(ctx context.Context, l *loan.Loan) error {
-	return m.Called(ctx, l).Error(0)
+func (m *mockLoanProvider) PayInstallments(ctx context.Context, payments []loan.InstallmentPayment, paidAt time.Time) error {
+	return m.Called(ctx, payments, paidAt).Error(0)
 }
 
 type mockOvertimeProvider struct{ mock.Mock }
@@ -165,6 +182,16 @@ func (m *mockService) BlastPayslipEmail(ctx context.Context, id uint) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+func (m *mockService) HasPayroll(ctx context.Context, employeeID uint, month, year int) (bool, error) {
+	args := m.Called(ctx, employeeID, month, year)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockService) GetTaxEntries(ctx context.Context, employeeID uint, year int) ([]tax.MonthlyTaxEntry, error) {
+	args := m.Called(ctx, employeeID, year)
+	return args.Get(0).([]tax.MonthlyTaxEntry), args.Error(1)
+}
+
 func newTestService() (Service, *mockRepo, *mockUserProvider, *mockReimbursementProvider, *mockAttendanceProvider, *mockCompanyProvider, *mockNotificationProvider, *testutil.MockTransactionManager, *mockEmailProvider, *mockLoanProvider, *mockOvertimeProvider) {
 	repo := new(mockRepo)
 	userP := new(mockUserProvider)
@@ -2,6 +2,7 @@ package payroll
 
 import (
 	"context"
+	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/utils"
 	"time"
@@ -14,6 +15,7 @@ type Repository interface {
 	FindAll(ctx context.Context, filter *PayrollFilter) ([]Payroll, int64, error)
 	FindByID(ctx context.Context, id uint) (*Payroll, error)
 	GetExistingEmployeeID(ctx context.Context, month, year int) (map[uint]bool, error)
+	FindPaidByEmployeeYear(ctx context.Context, employeeID uint, year int) ([]Payroll, error)
 	UpdateStatus(ctx context.Context, id uint, status constants.PayrollStatus) error
 }
 
@@ -40,7 +42,7 @@ func (r *repository) FindAll(ctx context.Context, filter *PayrollFilter) ([]Payr
 	var payrolls []Payroll
 	var total int64
 
-	query := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Payroll{})).
+	query := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Payroll{})), "payrolls.employee_id").
 		Joins("JOIN employees ON employees.id = payrolls.employee_id").
 		Preload("Employee")
 
@@ -52,7 +54,7 @@ func (r *repository) FindAll(ctx context.Context, filter *PayrollFilter) ([]Payr
 
 	if filter.Keyword != "" {
 		keywordParam := "%" + filter.Keyword + "%"
-		query = query.Where("LOWER(employees.full_name) LIKE LOWER(?) OR LOWER(employees.nik) LIKE LOWER(?)", keywordParam, keywordParam)
+		query = query.Where("LOWER(employees.full_name) LIKE LOWER(?) OR employees.nik_bidx = ?", keywordParam, infrastructure.BlindIndex(filter.Keyword))
 	}
 
 	if err := query.Count(&total).Error; err != nil {
@@ -71,7 +73,7 @@ func (r *repository) FindAll(ctx context.Context, filter *PayrollFilter) ([]Payr
 
 func (r *repository) FindByID(ctx context.Context, id uint) (*Payroll, error) {
 	var payroll Payroll
-	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	db := utils.DataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Payroll{})), "payrolls.employee_id")
 	err := db.
 		Preload("Employee").
 		Preload("Details").
@@ -105,6 +107,21 @@ func (r *repository) GetExistingEmployeeID(ctx context.Context, month, year int)
 	return existingMap, nil
 }
 
+// FindPaidByEmployeeYear returns the employee's paid payrolls of the year with their details, oldest first.
+func (r *repository) FindPaidByEmployeeYear(ctx context.Context, employeeID uint, year int) ([]Payroll, error) {
+	startDate := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	endDate := startDate.AddDate(1, 0, -1)
+
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var payrolls []Payroll
+	err := db.Preload("Details").
+		Where("employee_id = ? AND status = ? AND period_date BETWEEN ? AND ?", employeeID, constants.PayrollStatusPaid, startDate, endDate).
+		Order("period_date ASC").
+		Find(&payrolls).Error
+
+	return payrolls, err
+}
+
 func (r *repository) UpdateStatus(ctx context.Context, id uint, status constants.PayrollStatus) error {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 	return db.Model(&Payroll{}).
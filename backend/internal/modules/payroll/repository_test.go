@@ -250,6 +250,31 @@ func TestRepo_GetExistingEmployeeID(t *testing.T) {
 	}
 }
 
+func TestRepo_FindPaidByEmployeeYear(t *testing.T) {
+	tdb := setupPayrollTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedPayrollTestData(t, tdb)
+	seedPayrollWithDetails(t, tdb, 1)
+
+	// the seeded payroll is still a draft
+	payrolls, err := repo.FindPaidByEmployeeYear(ctx, 1, 2025)
+	require.NoError(t, err)
+	assert.Empty(t, payrolls)
+
+	require.NoError(t, tdb.DB.Model(&Payroll{}).Where("employee_id = ?", 1).Update("status", constants.PayrollStatusPaid).Error)
+
+	payrolls, err = repo.FindPaidByEmployeeYear(ctx, 1, 2025)
+	require.NoError(t, err)
+	require.Len(t, payrolls, 1)
+	assert.Len(t, payrolls[0].Details, 3)
+
+	payrolls, err = repo.FindPaidByEmployeeYear(ctx, 1, 2026)
+	require.NoError(t, err)
+	assert.Empty(t, payrolls)
+}
+
 func TestRepo_UpdateStatus(t *testing.T) {
 	tdb := setupPayrollTestDB(t)
 	repo := NewRepository(tdb.DB)
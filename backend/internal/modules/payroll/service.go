@@ -3,6 +3,8 @@ package payroll
 import (
 	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/internal/modules/bpjs"
+	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/tax"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/logger"
 	"basekarya-backend/pkg/response"
@@ -24,8 +26,17 @@ type Service interface {
 	GeneratePayslipPDF(ctx context.Context, id uint) (*gopdf.GoPdf, *Payroll, error)
 	MarkAsPaid(ctx context.Context, id uint) error
 	BlastPayslipEmail(ctx context.Context, id uint) error
+	HasPayroll(ctx context.Context, employeeID uint, month, year int) (bool, error)
+	GetTaxEntries(ctx context.Context, employeeID uint, year int) ([]tax.MonthlyTaxEntry, error)
 }
 
+const (
+	// detail code of the PPh 21 withheld, the annual settlement adds these up
+	pph21Code = "PPH21"
+	// reimbursements are paid back expenses, not income
+	reimbursementTitle = "Reimbursement"
+)
+
 type service struct {
 	repo               Repository
 	user               UserProvider
@@ -60,7 +71,7 @@ func NewService(repo Repository,
 }
 
 func (s *service) GenerateAll(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
-	employees, err := s.user.FindAllEmployeeActive(ctx)
+	employees, err := s.user.FindAllEmployeeActiveInScope(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch all employee active: %w", err)
 	}
@@ -75,19 +86,20 @@ func (s *service) GenerateAll(ctx context.Context, req *GenerateRequest) (*Gener
 		return nil, fmt.Errorf("failed to fetch existing employee id: %w", err)
 	}
 
-	attendanceMap, err := s.attendance.GetBulkLateDuration(ctx, req.Month, req.Year)
+	attendanceMap, err := s.attendance.GetBulkMonthlySummary(ctx, req.Month, req.Year)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch bulk late duration: %w", err)
+		return nil, fmt.Errorf("failed to fetch bulk attendance summary: %w", err)
 	}
 
-	reimburseMap, err := s.reimbursement.GetBulkApprovedAmount(ctx, req.Month, req.Year)
+	// approved claims not paid yet, whatever month the expense was in
+	claimMap, err := s.reimbursement.GetBulkPayableClaims(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch bulk approved amount: %w", err)
+		return nil, fmt.Errorf("failed to fetch bulk payable reimbursements: %w", err)
 	}
 
-	loanMap, err := s.loan.GetBulkActiveLoansByEmployeeIds(ctx, employeeIds)
+	loanMap, err := s.loan.GetBulkDueInstallments(ctx, employeeIds, req.Month, req.Year)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch bulk active loans by employee ids: %w", err)
+		return nil, fmt.Errorf("failed to fetch bulk due loan installments: %w", err)
 	}
 
 	overtimeMap, err := s.overtime.GetBulkActiveOvertimesByEmployeeIds(ctx, req.Month, req.Year, employeeIds)
@@ -99,6 +111,7 @@ func (s *service) GenerateAll(ctx context.Context, req *GenerateRequest) (*Gener
 	periodDate := time.Date(req.Year, time.Month(req.Month), 1, 0, 0, 0, 0, time.Local)
 
 	var payrollsToInsert []Payroll
+	var queuedClaimIDs []uint
 
 	for _, emp := range employees {
 		// if already exist on this year & month, skip
@@ -108,13 +121,20 @@ func (s *service) GenerateAll(ctx context.Context, req *GenerateRequest) (*Gener
 
 		// take data with O(1) lookup
 		baseSalary := emp.BaseSalary
-		totalLateMinutes := attendanceMap[emp.ID]
-		reimburseAmount := reimburseMap[emp.UserID]
+		totalLateMinutes := attendanceMap[emp.ID].LateMinutes
 
-		// calculate loan
-		loanData := loanMap[emp.ID]
-		loanAmount := loanData.InstallmentAmount
-		loanData.RemainingAmount -= loanAmount
+		claims := claimMap[emp.UserID]
+		reimburseAmount := 0.0
+		for _, claim := range claims {
+			reimburseAmount += claim.Amount
+		}
+
+		// calculate loan from the installments due this period
+		loanInstallments := loanMap[emp.ID]
+		loanAmount := 0.0
+		for _, inst := range loanInstallments {
+			loanAmount += inst.Amount
+		}
 
 		// calculate overtime nominal
 		// hourly wage = 1/173 * base salary
@@ -190,14 +210,17 @@ func (s *service) GenerateAll(ctx context.Context, req *GenerateRequest) (*Gener
 			Amount:    baseSalary,
 		})
 
-		// check if reimburse amount not zero
-		if reimburseAmount > 0 {
+		// one allowance per claim so paying the payroll marks each claim paid
+		for _, claim := range claims {
+			claimID := claim.ID
 			payroll.Details = append(payroll.Details, PayrollDetail{
-				CompanyID: companyID,
-				Title:     "Reimbursement",
-				Type:      constants.DetailTypeAllowance,
-				Amount:    reimburseAmount,
+				CompanyID:       companyID,
+				Title:           reimbursementTitle,
+				Type:            constants.DetailTypeAllowance,
+				Amount:          claim.Amount,
+				ReimbursementID: &claimID,
 			})
+			queuedClaimIDs = append(queuedClaimIDs, claimID)
 		}
 
 		if overtimeAmount > 0 {
@@ -218,12 +241,15 @@ func (s *service) GenerateAll(ctx context.Context, req *GenerateRequest) (*Gener
 			})
 		}
 
-		if loanAmount > 0 {
+		// one deduction per installment so paying the payroll posts each to its loan ledger
+		for _, inst := range loanInstallments {
+			installmentID := inst.ID
 			payroll.Details = append(payroll.Details, PayrollDetail{
-				CompanyID: companyID,
-				Title:     "Potongan Kasbon",
-				Type:      constants.DetailTypeDeduction,
-				Amount:    loanAmount,
+				CompanyID:         companyID,
+				Title:             "Potongan Kasbon",
+				Type:              constants.DetailTypeDeduction,
+				Amount:            inst.Amount,
+				LoanInstallmentID: &installmentID,
 			})
 		}
 
@@ -245,7 +271,7 @@ func (s *service) GenerateAll(ctx context.Context, req *GenerateRequest) (*Gener
 
 		// PPh 21
 		if pph21Amount > 0 {
-			code := "PPH21"
+			code := pph21Code
 			group := "TAX"
 			payroll.Details = append(payroll.Details, PayrollDetail{
 				CompanyID: companyID,
@@ -284,10 +310,24 @@ func (s *service) GenerateAll(ctx context.Context, req *GenerateRequest) (*Gener
 		return nil, nil
 	}
 
-	// bulk insert payrolls
-	if err := s.repo.CreateBulk(ctx, &payrollsToInsert); err != nil {
-		logger.Errorf("Failed create bulk payrolls %w", err)
+	err = s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		// bulk insert payrolls
+		if err := s.repo.CreateBulk(ctx, &payrollsToInsert); err != nil {
+			logger.Errorf("Failed create bulk payrolls %w", err)
+
+			return err
+		}
+
+		// the claims now wait on these payrolls, the next period must not pay them again
+		if len(queuedClaimIDs) > 0 {
+			if err := s.reimbursement.QueuePayrollClaims(ctx, queuedClaimIDs); err != nil {
+				return fmt.Errorf("failed to queue reimbursements: %w", err)
+			}
+		}
 
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -619,29 +659,30 @@ func (s *service) MarkAsPaid(ctx context.Context, id uint) error {
 			return err
 		}
 
-		var deductedLoan float64
+		var loanPayments []loan.InstallmentPayment
+		var paidClaimIDs []uint
 		for _, detail := range payroll.Details {
-			if detail.Title == "Potongan Kasbon" && detail.Type == constants.DetailTypeDeduction {
-				deductedLoan += detail.Amount
+			if detail.ReimbursementID != nil {
+				paidClaimIDs = append(paidClaimIDs, *detail.ReimbursementID)
+			}
+			if detail.LoanInstallmentID != nil {
+				loanPayments = append(loanPayments, loan.InstallmentPayment{
+					InstallmentID:   *detail.LoanInstallmentID,
+					PayrollDetailID: detail.ID,
+					Amount:          detail.Amount,
+				})
 			}
 		}
 
-		if deductedLoan > 0 {
-			loanMap, err := s.loan.GetBulkActiveLoansByEmployeeIds(ctx, []uint{payroll.EmployeeID})
-			if err != nil {
-				return fmt.Errorf("failed to fetch active loan: %w", err)
+		if len(loanPayments) > 0 {
+			if err := s.loan.PayInstallments(ctx, loanPayments, time.Now()); err != nil {
+				return fmt.Errorf("failed to pay loan installments: %w", err)
 			}
+		}
 
-			if activeLoan, exists := loanMap[payroll.EmployeeID]; exists {
-				activeLoan.RemainingAmount -= deductedLoan
-				if activeLoan.RemainingAmount <= 0 {
-					activeLoan.RemainingAmount = 0
-					activeLoan.Status = constants.LoanStatusPaidOff
-				}
-
-				if err := s.loan.Update(ctx, &activeLoan); err != nil {
-					return fmt.Errorf("failed to update loan status: %w", err)
-				}
+		if len(paidClaimIDs) > 0 {
+			if err := s.reimbursement.MarkClaimsPaid(ctx, paidClaimIDs, time.Now()); err != nil {
+				return fmt.Errorf("failed to mark reimbursements paid: %w", err)
 			}
 		}
 
@@ -707,6 +748,40 @@ func (s *service) BlastPayslipEmail(ctx context.Context, id uint) error {
 	return nil
 }
 
+// HasPayroll reports whether a payroll of the period was already generated for the employee.
+func (s *service) HasPayroll(ctx context.Context, employeeID uint, month, year int) (bool, error) {
+	existing, err := s.repo.GetExistingEmployeeID(ctx, month, year)
+	if err != nil {
+		return false, err
+	}
+	return existing[employeeID], nil
+}
+
+// GetTaxEntries returns the taxable income and the PPh 21 withheld of every paid payroll of the year. Employer
+// borne BPJS is a benefit taxed as income, reimbursements are not.
+func (s *service) GetTaxEntries(ctx context.Context, employeeID uint, year int) ([]tax.MonthlyTaxEntry, error) {
+	payrolls, err := s.repo.FindPaidByEmployeeYear(ctx, employeeID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]tax.MonthlyTaxEntry, 0, len(payrolls))
+	for _, p := range payrolls {
+		entry := tax.MonthlyTaxEntry{Month: int(p.PeriodDate.Month())}
+		for _, d := range p.Details {
+			switch {
+			case d.Type == constants.DetailTypeAllowance && d.Title != reimbursementTitle:
+				entry.GrossIncome += d.Amount
+			case d.Code != nil && *d.Code == pph21Code:
+				entry.PPh21Paid += d.Amount
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
 func (s *service) generatePayslipPDFBytes(ctx context.Context, id uint) ([]byte, *Payroll, error) {
 	pdf, payroll, err := s.GeneratePayslipPDF(ctx, id)
 	if err != nil {
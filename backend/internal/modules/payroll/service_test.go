@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"basekarya-backend/internal/modules/attendance"
 	"basekarya-backend/internal/modules/loan"
+	"basekarya-backend/internal/modules/reimbursement"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/internal/testutil"
 	"basekarya-backend/pkg/constants"
@@ -29,15 +31,24 @@ func TestService_GenerateAll(t *testing.T) {
 			name: "success with one employee",
 			req:  &GenerateRequest{Month: 6, Year: 2025},
 			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
-				userP.On("FindAllEmployeeActive", mock.Anything).Return([]user.Employee{
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{
 					{ID: 1, UserID: 10, BaseSalary: 5000000},
 				}, nil)
 				repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool{}, nil)
-				attend.On("GetBulkLateDuration", mock.Anything, 6, 2025).Return(map[uint]int{1: 30}, nil)
-				reimburse.On("GetBulkApprovedAmount", mock.Anything, 6, 2025).Return(map[uint]float64{10: 200000}, nil)
-				loanP.On("GetBulkActiveLoansByEmployeeIds", mock.Anything, mock.Anything).Return(map[uint]loan.Loan{}, nil)
+				attend.On("GetBulkMonthlySummary", mock.Anything, 6, 2025).Return(map[uint]attendance.TimesheetSummary{1: {EmployeeID: 1, LateMinutes: 30}}, nil)
+				reimburse.On("GetBulkPayableClaims", mock.Anything).Return(map[uint][]reimbursement.Reimbursement{10: {{ID: 7, UserID: 10, Amount: 200000}}}, nil)
+				reimburse.On("QueuePayrollClaims", mock.Anything, []uint{7}).Return(nil)
+				loanP.On("GetBulkDueInstallments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(map[uint][]loan.LoanInstallment{}, nil)
 				overtimeP.On("GetBulkActiveOvertimesByEmployeeIds", mock.Anything, 6, 2025, mock.Anything).Return(map[uint]int{}, nil)
-				repo.On("CreateBulk", mock.Anything, mock.Anything).Return(nil)
+				// late minutes are keyed by employee ID and must reach the payroll deduction
+				repo.On("CreateBulk", mock.Anything, mock.MatchedBy(func(payrolls *[]Payroll) bool {
+					for _, d := range (*payrolls)[0].Details {
+						if d.Title == "Potongan Terlambat (30 menit)" {
+							return true
+						}
+					}
+					return false
+				})).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -45,29 +56,87 @@ func TestService_GenerateAll(t *testing.T) {
 			name: "success with overtime",
 			req:  &GenerateRequest{Month: 6, Year: 2025},
 			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
-				userP.On("FindAllEmployeeActive", mock.Anything).Return([]user.Employee{
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{
 					{ID: 2, UserID: 20, BaseSalary: 5000000},
 				}, nil)
 				repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool{}, nil)
-				attend.On("GetBulkLateDuration", mock.Anything, 6, 2025).Return(map[uint]int{}, nil)
-				reimburse.On("GetBulkApprovedAmount", mock.Anything, 6, 2025).Return(map[uint]float64{}, nil)
-				loanP.On("GetBulkActiveLoansByEmployeeIds", mock.Anything, mock.Anything).Return(map[uint]loan.Loan{}, nil)
+				attend.On("GetBulkMonthlySummary", mock.Anything, 6, 2025).Return(map[uint]attendance.TimesheetSummary{}, nil)
+				reimburse.On("GetBulkPayableClaims", mock.Anything).Return(map[uint][]reimbursement.Reimbursement{}, nil)
+				loanP.On("GetBulkDueInstallments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(map[uint][]loan.LoanInstallment{}, nil)
 				overtimeP.On("GetBulkActiveOvertimesByEmployeeIds", mock.Anything, 6, 2025, mock.Anything).Return(map[uint]int{2: 120}, nil)
 				repo.On("CreateBulk", mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "success with due loan installments",
+			req:  &GenerateRequest{Month: 6, Year: 2025},
+			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{
+					{ID: 3, UserID: 30, BaseSalary: 5000000},
+				}, nil)
+				repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool{}, nil)
+				attend.On("GetBulkMonthlySummary", mock.Anything, 6, 2025).Return(map[uint]attendance.TimesheetSummary{}, nil)
+				reimburse.On("GetBulkPayableClaims", mock.Anything).Return(map[uint][]reimbursement.Reimbursement{}, nil)
+				loanP.On("GetBulkDueInstallments", mock.Anything, []uint{3}, 6, 2025).Return(map[uint][]loan.LoanInstallment{
+					3: {{ID: 1, LoanID: 1, Amount: 300000}, {ID: 2, LoanID: 2, Amount: 200000}},
+				}, nil)
+				overtimeP.On("GetBulkActiveOvertimesByEmployeeIds", mock.Anything, 6, 2025, mock.Anything).Return(map[uint]int{}, nil)
+				// every installment of every running loan gets its own deduction
+				repo.On("CreateBulk", mock.Anything, mock.MatchedBy(func(payrolls *[]Payroll) bool {
+					var loanDetails []PayrollDetail
+					for _, d := range (*payrolls)[0].Details {
+						if d.LoanInstallmentID != nil {
+							loanDetails = append(loanDetails, d)
+						}
+					}
+					return len(loanDetails) == 2 && *loanDetails[0].LoanInstallmentID == 1 && loanDetails[0].Amount == 300000 &&
+						*loanDetails[1].LoanInstallmentID == 2 && (*payrolls)[0].TotalDeduction >= 500000
+				})).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "success with payable reimbursements",
+			req:  &GenerateRequest{Month: 6, Year: 2025},
+			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{
+					{ID: 4, UserID: 40, BaseSalary: 5000000},
+				}, nil)
+				repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool{}, nil)
+				attend.On("GetBulkMonthlySummary", mock.Anything, 6, 2025).Return(map[uint]attendance.TimesheetSummary{}, nil)
+				// a claim for an expense of the month before is still paid
+				reimburse.On("GetBulkPayableClaims", mock.Anything).Return(map[uint][]reimbursement.Reimbursement{40: {
+					{ID: 11, UserID: 40, Amount: 150000, DateOfExpense: time.Date(2025, 5, 28, 0, 0, 0, 0, time.UTC)},
+					{ID: 12, UserID: 40, Amount: 50000, DateOfExpense: time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC)},
+				}}, nil)
+				loanP.On("GetBulkDueInstallments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(map[uint][]loan.LoanInstallment{}, nil)
+				overtimeP.On("GetBulkActiveOvertimesByEmployeeIds", mock.Anything, 6, 2025, mock.Anything).Return(map[uint]int{}, nil)
+				repo.On("CreateBulk", mock.Anything, mock.MatchedBy(func(payrolls *[]Payroll) bool {
+					var claimDetails []PayrollDetail
+					for _, d := range (*payrolls)[0].Details {
+						if d.ReimbursementID != nil {
+							claimDetails = append(claimDetails, d)
+						}
+					}
+					return len(claimDetails) == 2 && *claimDetails[0].ReimbursementID == 11 && *claimDetails[1].ReimbursementID == 12 &&
+						(*payrolls)[0].TotalAllowance == 5200000
+				})).Return(nil)
+				reimburse.On("QueuePayrollClaims", mock.Anything, []uint{11, 12}).Return(nil)
+			},
+			wantErr: false,
+		},
 		{
 			name: "skip existing employee",
 			req:  &GenerateRequest{Month: 6, Year: 2025},
 			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
-				userP.On("FindAllEmployeeActive", mock.Anything).Return([]user.Employee{
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{
 					{ID: 1, UserID: 10, BaseSalary: 5000000},
 				}, nil)
 				repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool{1: true}, nil)
-				attend.On("GetBulkLateDuration", mock.Anything, 6, 2025).Return(map[uint]int{}, nil)
-				reimburse.On("GetBulkApprovedAmount", mock.Anything, 6, 2025).Return(map[uint]float64{}, nil)
-				loanP.On("GetBulkActiveLoansByEmployeeIds", mock.Anything, mock.Anything).Return(map[uint]loan.Loan{}, nil)
+				attend.On("GetBulkMonthlySummary", mock.Anything, 6, 2025).Return(map[uint]attendance.TimesheetSummary{}, nil)
+				reimburse.On("GetBulkPayableClaims", mock.Anything).Return(map[uint][]reimbursement.Reimbursement{}, nil)
+				loanP.On("GetBulkDueInstallments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(map[uint][]loan.LoanInstallment{}, nil)
 				overtimeP.On("GetBulkActiveOvertimesByEmployeeIds", mock.Anything, 6, 2025, mock.Anything).Return(map[uint]int{}, nil)
 			},
 			wantErr: false,
@@ -76,7 +145,7 @@ func TestService_GenerateAll(t *testing.T) {
 			name: "error fetch employees",
 			req:  &GenerateRequest{Month: 6, Year: 2025},
 			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
-				userP.On("FindAllEmployeeActive", mock.Anything).Return([]user.Employee(nil), errors.New("db error"))
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee(nil), errors.New("db error"))
 			},
 			wantErr: true,
 			errMsg:  "failed to fetch all employee active: db error",
@@ -85,7 +154,7 @@ func TestService_GenerateAll(t *testing.T) {
 			name: "error fetch existing",
 			req:  &GenerateRequest{Month: 6, Year: 2025},
 			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
-				userP.On("FindAllEmployeeActive", mock.Anything).Return([]user.Employee{{ID: 1, BaseSalary: 5000000}}, nil)
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{{ID: 1, BaseSalary: 5000000}}, nil)
 				repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool(nil), errors.New("db error"))
 			},
 			wantErr: true,
@@ -95,34 +164,50 @@ func TestService_GenerateAll(t *testing.T) {
 			name: "error fetch attendance",
 			req:  &GenerateRequest{Month: 6, Year: 2025},
 			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
-				userP.On("FindAllEmployeeActive", mock.Anything).Return([]user.Employee{{ID: 1, BaseSalary: 5000000}}, nil)
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{{ID: 1, BaseSalary: 5000000}}, nil)
 				repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool{}, nil)
-				attend.On("GetBulkLateDuration", mock.Anything, 6, 2025).Return(map[uint]int(nil), errors.New("attend error"))
+				attend.On("GetBulkMonthlySummary", mock.Anything, 6, 2025).Return(map[uint]attendance.TimesheetSummary(nil), errors.New("attend error"))
 			},
 			wantErr: true,
-			errMsg:  "failed to fetch bulk late duration: attend error",
+			errMsg:  "failed to fetch bulk attendance summary: attend error",
 		},
 		{
 			name: "error fetch reimbursement",
 			req:  &GenerateRequest{Month: 6, Year: 2025},
 			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
-				userP.On("FindAllEmployeeActive", mock.Anything).Return([]user.Employee{{ID: 1, BaseSalary: 5000000}}, nil)
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{{ID: 1, BaseSalary: 5000000}}, nil)
+				repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool{}, nil)
+				attend.On("GetBulkMonthlySummary", mock.Anything, 6, 2025).Return(map[uint]attendance.TimesheetSummary{}, nil)
+				reimburse.On("GetBulkPayableClaims", mock.Anything).Return(map[uint][]reimbursement.Reimbursement(nil), errors.New("reimburse error"))
+			},
+			wantErr: true,
+			errMsg:  "failed to fetch bulk payable reimbursements: reimburse error",
+		},
+		{
+			name: "error claims queued by a concurrent generation",
+			req:  &GenerateRequest{Month: 6, Year: 2025},
+			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{{ID: 1, UserID: 10, BaseSalary: 5000000}}, nil)
 				repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool{}, nil)
-				attend.On("GetBulkLateDuration", mock.Anything, 6, 2025).Return(map[uint]int{}, nil)
-				reimburse.On("GetBulkApprovedAmount", mock.Anything, 6, 2025).Return(map[uint]float64(nil), errors.New("reimburse error"))
+				attend.On("GetBulkMonthlySummary", mock.Anything, 6, 2025).Return(map[uint]attendance.TimesheetSummary{}, nil)
+				reimburse.On("GetBulkPayableClaims", mock.Anything).Return(map[uint][]reimbursement.Reimbursement{10: {{ID: 7, UserID: 10, Amount: 200000}}}, nil)
+				reimburse.On("QueuePayrollClaims", mock.Anything, []uint{7}).Return(reimbursement.ErrClaimsNoLongerPayable)
+				loanP.On("GetBulkDueInstallments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(map[uint][]loan.LoanInstallment{}, nil)
+				overtimeP.On("GetBulkActiveOvertimesByEmployeeIds", mock.Anything, 6, 2025, mock.Anything).Return(map[uint]int{}, nil)
+				repo.On("CreateBulk", mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: true,
-			errMsg:  "failed to fetch bulk approved amount: reimburse error",
+			errMsg:  "no longer payable",
 		},
 		{
 			name: "error create bulk",
 			req:  &GenerateRequest{Month: 6, Year: 2025},
 			setupMocks: func(repo *mockRepo, userP *mockUserProvider, reimburse *mockReimbursementProvider, attend *mockAttendanceProvider, loanP *mockLoanProvider, overtimeP *mockOvertimeProvider) {
-				userP.On("FindAllEmployeeActive", mock.Anything).Return([]user.Employee{{ID: 1, UserID: 10, BaseSalary: 5000000}}, nil)
+				userP.On("FindAllEmployeeActiveInScope", mock.Anything).Return([]user.Employee{{ID: 1, UserID: 10, BaseSalary: 5000000}}, nil)
 				repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool{}, nil)
-				attend.On("GetBulkLateDuration", mock.Anything, 6, 2025).Return(map[uint]int{}, nil)
-				reimburse.On("GetBulkApprovedAmount", mock.Anything, 6, 2025).Return(map[uint]float64{}, nil)
-				loanP.On("GetBulkActiveLoansByEmployeeIds", mock.Anything, mock.Anything).Return(map[uint]loan.Loan{}, nil)
+				attend.On("GetBulkMonthlySummary", mock.Anything, 6, 2025).Return(map[uint]attendance.TimesheetSummary{}, nil)
+				reimburse.On("GetBulkPayableClaims", mock.Anything).Return(map[uint][]reimbursement.Reimbursement{}, nil)
+				loanP.On("GetBulkDueInstallments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(map[uint][]loan.LoanInstallment{}, nil)
 				overtimeP.On("GetBulkActiveOvertimesByEmployeeIds", mock.Anything, 6, 2025, mock.Anything).Return(map[uint]int{}, nil)
 				repo.On("CreateBulk", mock.Anything, mock.Anything).Return(errors.New("insert error"))
 			},
@@ -291,6 +376,7 @@ func TestService_GetDetail(t *testing.T) {
 
 func TestService_MarkAsPaid(t *testing.T) {
 	ctx := testutil.CtxWithTenant(1, 1, false)
+	installmentID := uint(7)
 
 	tests := []struct {
 		name       string
@@ -339,14 +425,13 @@ func TestService_MarkAsPaid(t *testing.T) {
 					Status:     constants.PayrollStatusDraft,
 					Employee:   &user.Employee{UserID: 10},
 					Details: []PayrollDetail{
-						{Title: "Potongan Kasbon", Type: constants.DetailTypeDeduction, Amount: 500000},
+						{ID: 21, Title: "Potongan Kasbon", Type: constants.DetailTypeDeduction, Amount: 500000, LoanInstallmentID: &installmentID},
+						{ID: 22, Title: "Potongan Terlambat (10 menit)", Type: constants.DetailTypeDeduction, Amount: 25000},
 					},
 				}, nil)
 				repo.On("UpdateStatus", mock.Anything, uint(3), constants.PayrollStatusPaid).Return(nil)
-				loanP.On("GetBulkActiveLoansByEmployeeIds", mock.Anything, []uint{5}).Return(map[uint]loan.Loan{
-					5: {ID: 1, InstallmentAmount: 500000, RemainingAmount: 1000000},
-				}, nil)
-				loanP.On("Update", mock.Anything, mock.AnythingOfType("*loan.Loan")).Return(nil)
+				// the deduction is posted by its installment link, not by its title
+				loanP.On("PayInstallments", mock.Anything, []loan.InstallmentPayment{{InstallmentID: 7, PayrollDetailID: 21, Amount: 500000}}, mock.AnythingOfType("time.Time")).Return(nil)
 				overtimeP.On("UpdateBulkStatusByEmployeeId", mock.Anything, uint(5), 6, 2025, constants.OvertimeStatusPaid).Return(nil)
 				notif.On("SendNotification", mock.Anything, uint(10), mock.Anything, mock.Anything, mock.Anything, uint(3)).Return(nil)
 			},
@@ -397,3 +482,66 @@ func TestService_MarkAsPaid(t *testing.T) {
 		})
 	}
 }
+
+func TestService_MarkAsPaid_Reimbursements(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	claimID := uint(11)
+
+	svc, repo, _, reimburse, _, _, notif, _, _, _, overtimeP := newTestService()
+	repo.On("FindByID", mock.Anything, uint(4)).Return(&Payroll{
+		ID: 4, EmployeeID: 4,
+		PeriodDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local),
+		Status:     constants.PayrollStatusDraft,
+		Employee:   &user.Employee{UserID: 40},
+		Details: []PayrollDetail{
+			{ID: 31, Title: "Base Salary", Type: constants.DetailTypeAllowance, Amount: 5000000},
+			{ID: 32, Title: "Reimbursement", Type: constants.DetailTypeAllowance, Amount: 150000, ReimbursementID: &claimID},
+		},
+	}, nil)
+	repo.On("UpdateStatus", mock.Anything, uint(4), constants.PayrollStatusPaid).Return(nil)
+	reimburse.On("MarkClaimsPaid", mock.Anything, []uint{11}, mock.AnythingOfType("time.Time")).Return(nil)
+	overtimeP.On("UpdateBulkStatusByEmployeeId", mock.Anything, uint(4), 6, 2025, constants.OvertimeStatusPaid).Return(nil)
+	notif.On("SendNotification", mock.Anything, uint(40), mock.Anything, mock.Anything, mock.Anything, uint(4)).Return(nil).Maybe()
+
+	require.NoError(t, svc.MarkAsPaid(ctx, 4))
+	reimburse.AssertExpectations(t)
+}
+
+func TestService_HasPayroll(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, repo, _, _, _, _, _, _, _, _, _ := newTestService()
+
+	repo.On("GetExistingEmployeeID", mock.Anything, 6, 2025).Return(map[uint]bool{1: true}, nil)
+
+	exists, err := svc.HasPayroll(ctx, 1, 6, 2025)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = svc.HasPayroll(ctx, 2, 6, 2025)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestService_GetTaxEntries(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, repo, _, _, _, _, _, _, _, _, _ := newTestService()
+
+	code, bpjsCode := "PPH21", "JKK"
+	repo.On("FindPaidByEmployeeYear", mock.Anything, uint(1), 2025).Return([]Payroll{{
+		PeriodDate: time.Date(2025, time.March, 1, 0, 0, 0, 0, time.Local),
+		Details: []PayrollDetail{
+			{Title: "Base Salary", Type: constants.DetailTypeAllowance, Amount: 10000000},
+			{Title: "Reimbursement", Type: constants.DetailTypeAllowance, Amount: 750000},
+			{Title: "BPJS JKK (Employer)", Type: constants.DetailTypeAllowance, Amount: 24000, Code: &bpjsCode, IsEmployerBorne: true},
+			{Title: "PPh 21", Type: constants.DetailTypeDeduction, Amount: 200000, Code: &code},
+			{Title: "Potongan Kasbon", Type: constants.DetailTypeDeduction, Amount: 500000},
+		},
+	}}, nil)
+
+	entries, err := svc.GetTaxEntries(ctx, 1, 2025)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 3, entries[0].Month)
+	assert.Equal(t, 10024000.0, entries[0].GrossIncome)
+	assert.Equal(t, 200000.0, entries[0].PPh21Paid)
+}
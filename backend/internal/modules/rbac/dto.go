@@ -1,17 +1,22 @@
 package rbac
 
+import "basekarya-backend/pkg/constants"
+
 type CreateRoleRequest struct {
 	Name string `json:"name" validate:"required"`
 }
 
 type RolePermissionsResponse struct {
-	RoleID      uint         `json:"role_id"`
-	RoleName    string       `json:"role_name"`
-	Permissions []Permission `json:"permissions"`
+	RoleID      uint                         `json:"role_id"`
+	RoleName    string                       `json:"role_name"`
+	Permissions []Permission                 `json:"permissions"`
+	DataScopes  map[uint]constants.DataScope `json:"data_scopes"`
 }
 
 type AssignPermissionsRequest struct {
 	PermissionIDs []uint `json:"permission_ids" validate:"required,min=1"`
+	// keyed by permission ID, permissions left out reach the whole company
+	DataScopes map[uint]constants.DataScope `json:"data_scopes" validate:"omitempty,dive,oneof=SELF DEPARTMENT SUBORDINATES COMPANY"`
 }
 
 type PermissionResponse struct {
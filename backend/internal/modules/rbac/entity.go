@@ -1,6 +1,9 @@
 package rbac
 
-import "time"
+import (
+	"basekarya-backend/pkg/constants"
+	"time"
+)
 
 type Role struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
@@ -8,7 +11,8 @@ type Role struct {
 	CompanyID uint      `gorm:"index;not null" json:"company_id"`
 	CreatedAt time.Time `json:"created_at"`
 
-	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions,omitempty"`
+	Permissions     []Permission     `gorm:"many2many:role_permissions;" json:"permissions,omitempty"`
+	RolePermissions []RolePermission `gorm:"foreignKey:RoleID" json:"-"`
 }
 
 type Permission struct {
@@ -29,11 +33,21 @@ type PermissionGroup struct {
 }
 
 type RolePermission struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	RoleID       uint      `gorm:"uniqueIndex:idx_role_permission" json:"role_id"`
-	PermissionID uint      `gorm:"uniqueIndex:idx_role_permission" json:"permission_id"`
-	CompanyID    uint      `gorm:"index;not null" json:"company_id"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           uint                `gorm:"primaryKey" json:"id"`
+	RoleID       uint                `gorm:"uniqueIndex:idx_role_permission" json:"role_id"`
+	PermissionID uint                `gorm:"uniqueIndex:idx_role_permission" json:"permission_id"`
+	CompanyID    uint                `gorm:"index;not null" json:"company_id"`
+	DataScope    constants.DataScope `gorm:"type:varchar(20);not null;default:COMPANY" json:"data_scope"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+// DataScopes maps each permission of the role to how far it reaches.
+func (r *Role) DataScopes() map[uint]constants.DataScope {
+	scopes := make(map[uint]constants.DataScope, len(r.RolePermissions))
+	for _, rp := range r.RolePermissions {
+		scopes[rp.PermissionID] = rp.DataScope
+	}
+	return scopes
 }
 
 func (Role) TableName() string {
@@ -6,6 +6,7 @@ import (
 
 	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -32,8 +33,8 @@ func (m *mockRepo) FindRoleByName(ctx context.Context, name string) (*Role, erro
 	return args.Get(0).(*Role), args.Error(1)
 }
 
-func (m *mockRepo) ReplacingRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint, companyID uint) error {
-	return m.Called(ctx, roleID, permissionIDs, companyID).Error(0)
+func (m *mockRepo) ReplacingRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint, dataScopes map[uint]constants.DataScope, companyID uint) error {
+	return m.Called(ctx, roleID, permissionIDs, dataScopes, companyID).Error(0)
 }
 
 func (m *mockRepo) FindPermissionsByIDs(ctx context.Context, ids []uint) ([]Permission, error) {
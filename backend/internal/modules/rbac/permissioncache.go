@@ -0,0 +1,108 @@
+package rbac
+
+import (
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// PermissionCacheService resolves what a token's role may do at request time, so permission changes apply
+// without signing in again.
+type PermissionCacheService struct {
+	db    *gorm.DB
+	redis *infrastructure.RedisClientProvider
+}
+
+type roleGrants struct {
+	Permissions []string                       `json:"permissions"`
+	DataScopes  map[string]constants.DataScope `json:"data_scopes,omitempty"`
+}
+
+func NewPermissionCacheService(db *gorm.DB, redis *infrastructure.RedisClientProvider) *PermissionCacheService {
+	return &PermissionCacheService{db: db, redis: redis}
+}
+
+// RolePermissions returns the role's permission names and the data scopes of those narrower than the company.
+func (s *PermissionCacheService) RolePermissions(ctx context.Context, roleID uint) ([]string, map[string]constants.DataScope, error) {
+	key := fmt.Sprintf(constants.ROLE_GRANTS_CACHE_KEY, roleID)
+
+	cached, err := s.redis.Get(ctx, key)
+	if err == nil && cached != "" {
+		var grants roleGrants
+		if err := json.Unmarshal([]byte(cached), &grants); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse cached permissions: %w", err)
+		}
+		return grants.Permissions, grants.DataScopes, nil
+	}
+
+	var rows []struct {
+		Name      string
+		DataScope constants.DataScope
+	}
+	err = s.db.WithContext(ctx).Table("role_permissions").
+		Select("permissions.name, role_permissions.data_scope").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("role_permissions.role_id = ?", roleID).
+		Order("permissions.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query role permissions: %w", err)
+	}
+
+	grants := roleGrants{Permissions: make([]string, 0, len(rows))}
+	for _, row := range rows {
+		grants.Permissions = append(grants.Permissions, row.Name)
+		if row.DataScope == "" || row.DataScope == constants.DataScopeCompany {
+			continue
+		}
+		if grants.DataScopes == nil {
+			grants.DataScopes = make(map[string]constants.DataScope)
+		}
+		grants.DataScopes[row.Name] = row.DataScope
+	}
+
+	if data, err := json.Marshal(grants); err == nil {
+		if setErr := s.redis.Set(ctx, key, data, 24*time.Hour); setErr != nil {
+			logger.Errorw("PermissionCacheService: failed to cache permissions", "key", key, "err", setErr)
+		}
+	}
+
+	return grants.Permissions, grants.DataScopes, nil
+}
+
+// PermissionVersion returns the user's current permission version, tokens carrying another one are stale.
+func (s *PermissionCacheService) PermissionVersion(ctx context.Context, userID uint) (int64, error) {
+	key := fmt.Sprintf(constants.PERMISSION_VERSION_CACHE_KEY, userID)
+
+	cached, err := s.redis.Get(ctx, key)
+	if err == nil {
+		return strconv.ParseInt(cached, 10, 64)
+	}
+	if !errors.Is(err, redis.Nil) {
+		return 0, err
+	}
+
+	var version int64
+	err = s.db.WithContext(ctx).Table("users").
+		Select("permission_version").
+		Where("id = ?", userID).
+		Scan(&version).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to query permission version: %w", err)
+	}
+
+	if setErr := s.redis.Set(ctx, key, version, 24*time.Hour); setErr != nil {
+		logger.Errorw("PermissionCacheService: failed to cache permission version", "key", key, "err", setErr)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,76 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPermissionCacheTest(t *testing.T) (*PermissionCacheService, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	tdb := setupRBACTestDB(t)
+	seedRBACTestData(t, tdb)
+	require.NoError(t, tdb.DB.Create(&RolePermission{RoleID: 1, PermissionID: 2, CompanyID: 1, DataScope: constants.DataScopeDepartment}).Error)
+	require.NoError(t, tdb.DB.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, role_id INTEGER, permission_version INTEGER NOT NULL DEFAULT 0)`).Error)
+	require.NoError(t, tdb.DB.Exec(`INSERT INTO users (id, role_id, permission_version) VALUES (10, 1, 3)`).Error)
+
+	redisProvider := &infrastructure.RedisClientProvider{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	return NewPermissionCacheService(tdb.DB, redisProvider), mr
+}
+
+func TestPermissionCacheService_RolePermissions(t *testing.T) {
+	svc, mr := setupPermissionCacheTest(t)
+	ctx := context.Background()
+
+	permissions, scopes, err := svc.RolePermissions(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"VIEW_EMPLOYEE", "CREATE_EMPLOYEE"}, permissions)
+	assert.Equal(t, map[string]constants.DataScope{"CREATE_EMPLOYEE": constants.DataScopeDepartment}, scopes)
+	assert.True(t, mr.Exists("role:grants:1"))
+
+	// served from redis until the role's permissions change
+	mr.Set("role:grants:1", `{"permissions":["VIEW_PAYROLL"]}`)
+	permissions, scopes, err = svc.RolePermissions(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"VIEW_PAYROLL"}, permissions)
+	assert.Nil(t, scopes)
+}
+
+func TestPermissionCacheService_RolePermissions_UnknownRole(t *testing.T) {
+	svc, _ := setupPermissionCacheTest(t)
+
+	permissions, scopes, err := svc.RolePermissions(context.Background(), 99)
+	require.NoError(t, err)
+	assert.Empty(t, permissions)
+	assert.Nil(t, scopes)
+}
+
+func TestPermissionCacheService_PermissionVersion(t *testing.T) {
+	svc, mr := setupPermissionCacheTest(t)
+	ctx := context.Background()
+
+	version, err := svc.PermissionVersion(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+
+	cached, err := mr.Get("user:permission_version:10")
+	require.NoError(t, err)
+	assert.Equal(t, "3", cached)
+
+	mr.Set("user:permission_version:10", "4")
+	version, err = svc.PermissionVersion(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), version)
+}
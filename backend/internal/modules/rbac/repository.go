@@ -12,7 +12,7 @@ type Repository interface {
 	Create(ctx context.Context, role *Role) error
 	FindRoleByID(ctx context.Context, id uint) (*Role, error)
 	FindRoleByName(ctx context.Context, name string) (*Role, error)
-	ReplacingRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint, companyID uint) error
+	ReplacingRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint, dataScopes map[uint]constants.DataScope, companyID uint) error
 	FindPermissionsByIDs(ctx context.Context, ids []uint) ([]Permission, error)
 	FindAllPermissions(ctx context.Context) ([]Permission, error)
 	FindAllRoles(ctx context.Context) ([]Role, error)
@@ -42,7 +42,7 @@ func (r *repository) FindRoleByID(ctx context.Context, id uint) (*Role, error) {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 	var role Role
 
-	err := db.Preload("Permissions").First(&role, id).Error
+	err := db.Preload("Permissions").Preload("RolePermissions").First(&role, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +74,7 @@ func (r *repository) FindRoleByName(ctx context.Context, name string) (*Role, er
 	return &role, nil
 }
 
-func (r *repository) ReplacingRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint, companyID uint) error {
+func (r *repository) ReplacingRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint, dataScopes map[uint]constants.DataScope, companyID uint) error {
 	db := utils.GetDBFromContext(ctx, r.db)
 
 	return db.Transaction(func(tx *gorm.DB) error {
@@ -85,10 +85,15 @@ func (r *repository) ReplacingRolePermissions(ctx context.Context, roleID uint,
 		if len(permissionIDs) > 0 {
 			var rolePermissions []RolePermission
 			for _, pid := range permissionIDs {
+				scope, ok := dataScopes[pid]
+				if !ok {
+					scope = constants.DataScopeCompany
+				}
 				rolePermissions = append(rolePermissions, RolePermission{
 					RoleID:       roleID,
 					PermissionID: pid,
 					CompanyID:    companyID,
+					DataScope:    scope,
 				})
 			}
 
@@ -153,18 +158,34 @@ func (r *repository) FindAllPermissionsByGroupNames(ctx context.Context, groupNa
 	return perms, err
 }
 
+// AssignPermissions replaces the role's permissions, permissions the role keeps keep their data scope.
 func (r *repository) AssignPermissions(ctx context.Context, roleID uint, permissionIDs []uint, companyID uint) error {
 	db := utils.GetDBFromContext(ctx, r.db)
+
+	var existing []RolePermission
+	if err := db.Where("role_id = ?", roleID).Find(&existing).Error; err != nil {
+		return err
+	}
+	scopes := make(map[uint]constants.DataScope, len(existing))
+	for _, rp := range existing {
+		scopes[rp.PermissionID] = rp.DataScope
+	}
+
 	if err := db.Where("role_id = ?", roleID).Delete(&RolePermission{}).Error; err != nil {
 		return err
 	}
 
 	var rolePermissions []RolePermission
 	for _, pid := range permissionIDs {
+		scope, ok := scopes[pid]
+		if !ok {
+			scope = constants.DataScopeCompany
+		}
 		rolePermissions = append(rolePermissions, RolePermission{
 			RoleID:       roleID,
 			PermissionID: pid,
 			CompanyID:    companyID,
+			DataScope:    scope,
 		})
 	}
 	return db.Create(&rolePermissions).Error
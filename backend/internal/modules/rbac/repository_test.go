@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -196,12 +197,13 @@ func TestRepoRBAC_ReplacingRolePermissions(t *testing.T) {
 
 	seedRBACTestData(t, tdb)
 
-	err := repo.ReplacingRolePermissions(ctx, 1, []uint{1, 2}, 1)
+	err := repo.ReplacingRolePermissions(ctx, 1, []uint{1, 2}, map[uint]constants.DataScope{1: constants.DataScopeDepartment}, 1)
 	require.NoError(t, err)
 
 	role, err := repo.FindRoleByID(ctx, 1)
 	require.NoError(t, err)
 	assert.Len(t, role.Permissions, 2)
+	assert.Equal(t, map[uint]constants.DataScope{1: constants.DataScopeDepartment, 2: constants.DataScopeCompany}, role.DataScopes())
 }
 
 func TestRepoRBAC_FindAllPermissionIDs(t *testing.T) {
@@ -223,12 +225,15 @@ func TestRepoRBAC_AssignPermissions(t *testing.T) {
 
 	seedRBACTestData(t, tdb)
 
+	require.NoError(t, repo.ReplacingRolePermissions(ctx, 1, []uint{1}, map[uint]constants.DataScope{1: constants.DataScopeSelf}, 1))
+
 	err := repo.AssignPermissions(ctx, 1, []uint{1, 2}, 1)
 	require.NoError(t, err)
 
 	role, err := repo.FindRoleByID(ctx, 1)
 	require.NoError(t, err)
 	assert.Len(t, role.Permissions, 2)
+	assert.Equal(t, map[uint]constants.DataScope{1: constants.DataScopeSelf, 2: constants.DataScopeCompany}, role.DataScopes())
 }
 
 func TestRepoRBAC_FindRolesByCompanyID(t *testing.T) {
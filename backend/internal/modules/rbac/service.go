@@ -3,11 +3,13 @@ package rbac
 import (
 	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/logger"
 	"basekarya-backend/pkg/utils"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -75,11 +77,13 @@ func (s *service) GetRolePermissions(ctx context.Context, roleID uint) (*RolePer
 		if permissions == nil {
 			permissions = []Permission{}
 		}
+		dataScopes := role.DataScopes()
 
 		parsedData, err := json.Marshal(&RolePermissionsResponse{
 			RoleID:      role.ID,
 			RoleName:    role.Name,
 			Permissions: permissions,
+			DataScopes:  dataScopes,
 		})
 		if err != nil {
 			return nil, err
@@ -94,6 +98,7 @@ func (s *service) GetRolePermissions(ctx context.Context, roleID uint) (*RolePer
 			RoleID:      role.ID,
 			RoleName:    role.Name,
 			Permissions: permissions,
+			DataScopes:  dataScopes,
 		}, nil
 	} else if err != nil {
 		return nil, err
@@ -124,16 +129,30 @@ func (s *service) AssignPermissions(ctx context.Context, roleID uint, req *Assig
 			return errors.New("one or more permissions are invalid")
 		}
 
-		err = s.repo.ReplacingRolePermissions(ctx, roleID, req.PermissionIDs, utils.GetCompanyIDFromCtx(ctx))
-		if err != nil {
-			return err
+		for permissionID := range req.DataScopes {
+			if !slices.Contains(req.PermissionIDs, permissionID) {
+				return errors.New("data scope given for a permission the role is not assigned")
+			}
 		}
 
-		err = s.cache.Del(ctx, fmt.Sprintf(constants.ROLE_PERMISSION_CACHE_KEY, roleID))
+		err = s.repo.ReplacingRolePermissions(ctx, roleID, req.PermissionIDs, req.DataScopes, utils.GetCompanyIDFromCtx(ctx))
 		if err != nil {
 			return err
 		}
 
+		// evicted only after commit, a request in between would cache the old permissions again.
+		// signed in users of the role pick up the new permissions on their next request
+		infrastructure.AfterCommit(ctx, func() {
+			for _, key := range []string{
+				fmt.Sprintf(constants.ROLE_PERMISSION_CACHE_KEY, roleID),
+				fmt.Sprintf(constants.ROLE_GRANTS_CACHE_KEY, roleID),
+			} {
+				if err := s.cache.Del(ctx, key); err != nil {
+					logger.Errorw("Failed to evict role permission cache", "error", err, "key", key)
+				}
+			}
+		})
+
 		return nil
 	})
 }
@@ -1,10 +1,12 @@
 package rbac
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
@@ -85,9 +87,10 @@ func TestService_GetRolePermissions(t *testing.T) {
 			setupMocks: func(repo *mockRepo, cache *mockCacheProvider) {
 				cache.On("Get", mock.Anything, mock.AnythingOfType("string")).Return("", redis.Nil)
 				repo.On("FindRoleByID", mock.Anything, uint(1)).Return(&Role{
-					ID:          1,
-					Name:        "SUPERADMIN",
-					Permissions: []Permission{{ID: 1, Name: "VIEW_EMPLOYEE"}},
+					ID:              1,
+					Name:            "SUPERADMIN",
+					Permissions:     []Permission{{ID: 1, Name: "VIEW_EMPLOYEE"}},
+					RolePermissions: []RolePermission{{RoleID: 1, PermissionID: 1, DataScope: constants.DataScopeDepartment}},
 				}, nil)
 				cache.On("Set", mock.Anything, mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(nil)
 			},
@@ -150,11 +153,41 @@ func TestService_AssignPermissions(t *testing.T) {
 					{ID: 1, Name: "VIEW_EMPLOYEE"},
 					{ID: 2, Name: "CREATE_EMPLOYEE"},
 				}, nil)
-				repo.On("ReplacingRolePermissions", mock.Anything, uint(1), []uint{1, 2}, uint(1)).Return(nil)
+				repo.On("ReplacingRolePermissions", mock.Anything, uint(1), []uint{1, 2}, map[uint]constants.DataScope(nil), uint(1)).Return(nil)
+				cache.On("Del", mock.Anything, "role:permission:1").Return(nil)
+				cache.On("Del", mock.Anything, "role:grants:1").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:   "success with data scopes",
+			roleID: 1,
+			req: &AssignPermissionsRequest{
+				PermissionIDs: []uint{1, 2},
+				DataScopes:    map[uint]constants.DataScope{1: constants.DataScopeDepartment},
+			},
+			setupMocks: func(repo *mockRepo, cache *mockCacheProvider) {
+				repo.On("FindRoleByID", mock.Anything, uint(1)).Return(&Role{ID: 1, Name: "HEAD"}, nil)
+				repo.On("FindPermissionsByIDs", mock.Anything, []uint{1, 2}).Return([]Permission{{ID: 1}, {ID: 2}}, nil)
+				repo.On("ReplacingRolePermissions", mock.Anything, uint(1), []uint{1, 2}, map[uint]constants.DataScope{1: constants.DataScopeDepartment}, uint(1)).Return(nil)
 				cache.On("Del", mock.Anything, mock.AnythingOfType("string")).Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name:   "data scope for unassigned permission",
+			roleID: 1,
+			req: &AssignPermissionsRequest{
+				PermissionIDs: []uint{1},
+				DataScopes:    map[uint]constants.DataScope{2: constants.DataScopeSelf},
+			},
+			setupMocks: func(repo *mockRepo, cache *mockCacheProvider) {
+				repo.On("FindRoleByID", mock.Anything, uint(1)).Return(&Role{ID: 1}, nil)
+				repo.On("FindPermissionsByIDs", mock.Anything, []uint{1}).Return([]Permission{{ID: 1}}, nil)
+			},
+			wantErr: true,
+			errMsg:  "data scope given for a permission the role is not assigned",
+		},
 		{
 			name:   "role not found",
 			roleID: 99,
@@ -190,13 +223,42 @@ func TestService_AssignPermissions(t *testing.T) {
 			if tt.wantErr {
 				require.Error(t, err)
 				assert.Equal(t, tt.errMsg, err.Error())
+				cache.AssertNotCalled(t, "Del", mock.Anything, mock.Anything)
 			} else {
 				require.NoError(t, err)
+				cache.AssertExpectations(t)
 			}
 		})
 	}
 }
 
+func TestService_AssignPermissions_EvictsCacheAfterCommit(t *testing.T) {
+	tdb := testutil.NewTestDB()
+	t.Cleanup(tdb.Close)
+	tm := testutil.NewTestTransactionManager(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	repo, cache := new(mockRepo), new(mockCacheProvider)
+	svc := NewService(repo, cache, new(mockPlanProvider), tm)
+	repo.On("FindRoleByID", mock.Anything, uint(1)).Return(&Role{ID: 1}, nil)
+	repo.On("FindPermissionsByIDs", mock.Anything, []uint{1}).Return([]Permission{{ID: 1}}, nil)
+	repo.On("ReplacingRolePermissions", mock.Anything, uint(1), []uint{1}, map[uint]constants.DataScope(nil), uint(1)).Return(nil)
+
+	// rolled back by the surrounding transaction, the cached permissions are still the committed ones
+	err := tm.RunInTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, svc.AssignPermissions(ctx, 1, &AssignPermissionsRequest{PermissionIDs: []uint{1}}))
+		cache.AssertNotCalled(t, "Del", mock.Anything, mock.Anything)
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+	cache.AssertNotCalled(t, "Del", mock.Anything, mock.Anything)
+
+	cache.On("Del", mock.Anything, "role:permission:1").Return(nil).Once()
+	cache.On("Del", mock.Anything, "role:grants:1").Return(nil).Once()
+	require.NoError(t, svc.AssignPermissions(ctx, 1, &AssignPermissionsRequest{PermissionIDs: []uint{1}}))
+	cache.AssertExpectations(t)
+}
+
 func TestService_GetAllPermissions(t *testing.T) {
 	ctx := testutil.CtxWithTenant(1, 1, false)
 
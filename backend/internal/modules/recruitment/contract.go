@@ -12,7 +12,7 @@ type NotificationProvider interface {
 }
 
 type UserProvider interface {
-	FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error)
+	FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error)
 }
 
 type StorageProvider interface {
@@ -91,8 +91,8 @@ func (m *mockNotification) BlastNotification(ctx context.Context, userIDs []uint
 
 type mockUserProvider struct{ mock.Mock }
 
-func (m *mockUserProvider) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
-	args := m.Called(ctx, permissionApprovalName)
+func (m *mockUserProvider) FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error) {
+	args := m.Called(ctx, requesterUserID, permissionApprovalName)
 	return args.Get(0).([]uint), args.Error(1)
 }
 
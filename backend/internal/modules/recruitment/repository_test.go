@@ -20,6 +20,7 @@ import (
 
 func setupRecruitmentTestDB(t *testing.T) *testutil.TestDB {
 	t.Helper()
+	testutil.UseTestKeyring()
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
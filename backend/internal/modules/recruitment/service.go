@@ -102,7 +102,7 @@ func (s *service) SubmitRequisition(ctx context.Context, id uint, requesterID ui
 			return err
 		}
 
-		approverIDs, err := s.user.FindApprovalUsers(ctx, constants.APPROVAL_REQUISITION)
+		approverIDs, err := s.user.FindRequestApprovers(ctx, requesterID, constants.APPROVAL_REQUISITION)
 		if err != nil {
 			return err
 		}
@@ -121,7 +121,7 @@ func TestService_SubmitRequisition(t *testing.T) {
 					ID: 1, RequesterID: 1, Status: constants.RequisitionStatusDraft, Title: "Dev",
 				}, nil)
 				repo.On("UpdateRequisitionStatus", mock.Anything, uint(1), constants.RequisitionStatusPending, (*uint)(nil), "").Return(nil)
-				userProv.On("FindApprovalUsers", mock.Anything, string(constants.APPROVAL_REQUISITION)).Return([]uint{10, 11}, nil)
+				userProv.On("FindRequestApprovers", mock.Anything, mock.Anything, string(constants.APPROVAL_REQUISITION)).Return([]uint{10, 11}, nil)
 				notif.On("BlastNotification", mock.Anything, []uint{10, 11}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
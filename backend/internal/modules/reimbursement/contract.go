@@ -1,6 +1,8 @@
 package reimbursement
 
 import (
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/user"
 	"context"
 	"mime/multipart"
 )
@@ -22,6 +24,15 @@ type NotificationProvider interface {
 		relatedID uint) error
 }
 
+type ApprovalEngine interface {
+	Submit(ctx context.Context, req *approval.SubmitRequest) ([]uint, error)
+	Decide(ctx context.Context, req *approval.DecideRequest) (*approval.Decision, error)
+}
+
 type UserProvider interface {
-	FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error)
+	FindByID(ctx context.Context, id uint) (*user.User, error)
+}
+
+type OCRProvider interface {
+	ExtractText(ctx context.Context, image []byte) (string, error)
 }
@@ -1,12 +1,21 @@
 package reimbursement
 
 import (
+	"basekarya-backend/pkg/constants"
 	"mime/multipart"
 	"time"
 )
 
 type ReimbursementFilter struct {
-	UserID uint
+	UserID        uint
+	Status        string
+	PayoutStatus  string
+	PayoutChannel string
+	Page          int
+	Limit         int
+}
+
+type PayoutBatchFilter struct {
 	Status string
 	Page   int
 	Limit  int
@@ -14,6 +23,7 @@ type ReimbursementFilter struct {
 
 type ReimbursementRequest struct {
 	UserID      uint                  `form:"-"`
+	CategoryID  uint                  `form:"category_id" validate:"required"`
 	Title       string                `form:"title" validate:"required,max=255"`
 	Description string                `form:"description" validate:"omitempty"`
 	Amount      float64               `form:"amount" validate:"required,min=1000"`
@@ -26,26 +36,125 @@ type ActionRequest struct {
 	SuperAdminID    uint   `json:"-"`
 	Action          string `json:"action" validate:"required"`
 	RejectionReason string `json:"rejection_reason" validate:"omitempty"`
+	// how the claim is paid once approved, the next payroll when empty
+	PayoutChannel string `json:"payout_channel" validate:"omitempty,oneof=PAYROLL TRANSFER"`
 }
 
 type ReimbursementDetailResponse struct {
-	ID              uint      `json:"id"`
-	Title           string    `json:"title"`
-	Description     string    `json:"description"`
-	Amount          float64   `json:"amount"`
-	DateOfExpense   time.Time `json:"date_of_expense"`
-	ProofFileURL    string    `json:"proof_file_url"`
-	Status          string    `json:"status"`
-	RejectionReason *string   `json:"rejection_reason"`
+	ID               uint       `json:"id"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	Amount           float64    `json:"amount"`
+	DateOfExpense    time.Time  `json:"date_of_expense"`
+	ProofFileURL     string     `json:"proof_file_url"`
+	Status           string     `json:"status"`
+	RejectionReason  *string    `json:"rejection_reason"`
+	CategoryID       *uint      `json:"category_id"`
+	CategoryName     string     `json:"category_name"`
+	LimitWarning     string     `json:"limit_warning"`
+	DuplicateWarning string     `json:"duplicate_warning"`
+	PayoutChannel    string     `json:"payout_channel"`
+	PayoutStatus     string     `json:"payout_status"`
+	PayoutBatchID    *uint      `json:"payout_batch_id"`
+	PaidAt           *time.Time `json:"paid_at"`
 
 	RequesterName string `json:"requester_name"`
 }
 
 type ReimbursementListResponse struct {
-	ID            uint      `json:"id"`
-	Title         string    `json:"title"`
-	Amount        float64   `json:"amount"`
-	DateOfExpense time.Time `json:"date_of_expense"`
-	ProofFileURL  string    `json:"proof_file_url"`
-	Status        string    `json:"status"`
+	ID               uint      `json:"id"`
+	Title            string    `json:"title"`
+	CategoryName     string    `json:"category_name"`
+	Amount           float64   `json:"amount"`
+	DateOfExpense    time.Time `json:"date_of_expense"`
+	ProofFileURL     string    `json:"proof_file_url"`
+	Status           string    `json:"status"`
+	LimitWarning     string    `json:"limit_warning"`
+	DuplicateWarning string    `json:"duplicate_warning"`
+	PayoutChannel    string    `json:"payout_channel"`
+	PayoutStatus     string    `json:"payout_status"`
+}
+
+type SaveCategoryRequest struct {
+	ID          uint              `json:"-"`
+	Code        string            `json:"code" validate:"required,max=30"`
+	Name        string            `json:"name" validate:"required,max=100"`
+	AnnualCap   float64           `json:"annual_cap" validate:"min=0"`
+	MonthlyCap  float64           `json:"monthly_cap" validate:"min=0"`
+	PerClaimCap float64           `json:"per_claim_cap" validate:"min=0"`
+	LimitAction string            `json:"limit_action" validate:"required,oneof=REJECT WARN"`
+	IsActive    *bool             `json:"is_active"`
+	GradeCaps   []GradeCapRequest `json:"grade_caps" validate:"dive"`
+}
+
+type GradeCapRequest struct {
+	Grade       string  `json:"grade" validate:"required,max=20"`
+	PerClaimCap float64 `json:"per_claim_cap" validate:"gt=0"`
+}
+
+// EntitlementResponse is what the user may still claim on a category. A nil cap or remaining is no cap.
+type EntitlementResponse struct {
+	CategoryID       uint                               `json:"category_id"`
+	CategoryCode     string                             `json:"category_code"`
+	CategoryName     string                             `json:"category_name"`
+	LimitAction      constants.ReimbursementLimitAction `json:"limit_action"`
+	PerClaimCap      *float64                           `json:"per_claim_cap"`
+	AnnualCap        *float64                           `json:"annual_cap"`
+	AnnualClaimed    float64                            `json:"annual_claimed"`
+	AnnualRemaining  *float64                           `json:"annual_remaining"`
+	MonthlyCap       *float64                           `json:"monthly_cap"`
+	MonthlyClaimed   float64                            `json:"monthly_claimed"`
+	MonthlyRemaining *float64                           `json:"monthly_remaining"`
+}
+
+// ReceiptScanResponse pre-fills a claim from its proof. Amount and date are nil when the receipt could not be
+// read, DuplicateOf is the claim the same receipt was already submitted with and SimilarTo the claims whose
+// receipt looks like it.
+type ReceiptScanResponse struct {
+	Amount      *float64 `json:"amount"`
+	Date        *string  `json:"date"`
+	DuplicateOf *uint    `json:"duplicate_of"`
+	SimilarTo   []uint   `json:"similar_to"`
+	Text        string   `json:"text"`
+}
+
+type CreatePayoutBatchRequest struct {
+	CreatedBy        uint   `json:"-"`
+	Name             string `json:"name" validate:"required,max=100"`
+	Notes            string `json:"notes" validate:"omitempty"`
+	ReimbursementIDs []uint `json:"reimbursement_ids" validate:"required,min=1,dive,gt=0"`
+}
+
+type PayPayoutBatchRequest struct {
+	ID                uint   `json:"-"`
+	PaidBy            uint   `json:"-"`
+	TransferReference string `json:"transfer_reference" validate:"required,max=100"`
+}
+
+type PayoutBatchListResponse struct {
+	ID                uint       `json:"id"`
+	Name              string     `json:"name"`
+	Status            string     `json:"status"`
+	TotalAmount       float64    `json:"total_amount"`
+	TransferReference string     `json:"transfer_reference"`
+	CreatedAt         time.Time  `json:"created_at"`
+	PaidAt            *time.Time `json:"paid_at"`
+}
+
+type PayoutBatchDetailResponse struct {
+	PayoutBatchListResponse
+	Notes string                    `json:"notes"`
+	Items []PayoutBatchItemResponse `json:"items"`
+}
+
+type PayoutBatchItemResponse struct {
+	ReimbursementID   uint      `json:"reimbursement_id"`
+	Title             string    `json:"title"`
+	CategoryName      string    `json:"category_name"`
+	DateOfExpense     time.Time `json:"date_of_expense"`
+	Amount            float64   `json:"amount"`
+	EmployeeName      string    `json:"employee_name"`
+	BankName          string    `json:"bank_name"`
+	BankAccountNumber string    `json:"bank_account_number"`
+	BankAccountHolder string    `json:"bank_account_holder"`
 }
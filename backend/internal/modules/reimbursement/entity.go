@@ -1,9 +1,9 @@
 package reimbursement
 
 import (
-	"database/sql"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/constants"
+	"database/sql"
 	"time"
 
 	"gorm.io/gorm"
@@ -22,6 +22,9 @@ type Reimbursement struct {
 	ApprovedBy *uint      `json:"approved_by"`
 	Approver   *user.User `gorm:"foreignKey:ApprovedBy" json:"approver,omitempty"`
 
+	CategoryID *uint                  `gorm:"index" json:"category_id"`
+	Category   *ReimbursementCategory `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+
 	Title           string                        `gorm:"type:varchar(255);not null" json:"title"`
 	Description     string                        `gorm:"type:text" json:"description"`
 	Amount          float64                       `gorm:"type:decimal(15,2);not null" json:"amount"`
@@ -29,4 +32,92 @@ type Reimbursement struct {
 	ProofFileURL    string                        `gorm:"type:varchar(255);not null" json:"proof_file_url"`
 	Status          constants.ReimbursementStatus `gorm:"type:enum('PENDING','APPROVED','REJECTED');default:'PENDING'" json:"status"`
 	RejectionReason sql.NullString                `gorm:"type:text" json:"rejection_reason"`
+
+	// caps the claim breached when its category only warns, shown to the approvers
+	LimitWarning string `gorm:"type:text" json:"limit_warning"`
+	// claims whose receipt looks like this one, shown to the approvers
+	DuplicateWarning string `gorm:"type:text" json:"duplicate_warning"`
+	// perceptual hash of a proof image, or the sha256 of any other file, used to catch duplicate receipts
+	ProofHash string `gorm:"type:varchar(64);index" json:"-"`
+	// sha256 of the proof file, the same file is refused whatever it holds
+	ProofDigest string `gorm:"type:varchar(64);index" json:"-"`
+
+	PayoutChannel constants.ReimbursementPayoutChannel `gorm:"type:varchar(10);default:'PAYROLL'" json:"payout_channel"`
+	PayoutStatus  constants.ReimbursementPayoutStatus  `gorm:"type:varchar(10);default:'UNPAID';index" json:"payout_status"`
+	// the transfer batch paying the claim, nil when it is paid through payroll
+	PayoutBatchID *uint      `gorm:"index" json:"payout_batch_id"`
+	PaidAt        *time.Time `json:"paid_at"`
+}
+
+// ReimbursementPayoutBatch pays approved claims by bank transfer outside payroll.
+type ReimbursementPayoutBatch struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	CompanyID   uint                                     `gorm:"index;not null" json:"company_id"`
+	Name        string                                   `gorm:"type:varchar(100);not null" json:"name"`
+	Status      constants.ReimbursementPayoutBatchStatus `gorm:"type:varchar(10);default:'DRAFT'" json:"status"`
+	TotalAmount float64                                  `gorm:"type:decimal(15,2);default:0" json:"total_amount"`
+	Notes       string                                   `gorm:"type:text" json:"notes"`
+
+	// bank reference of the transfer, recorded when the batch is paid
+	TransferReference string     `gorm:"type:varchar(100)" json:"transfer_reference"`
+	CreatedBy         *uint      `json:"created_by"`
+	PaidBy            *uint      `json:"paid_by"`
+	PaidAt            *time.Time `json:"paid_at"`
+
+	Items []Reimbursement `gorm:"foreignKey:PayoutBatchID" json:"items,omitempty"`
+}
+
+func (ReimbursementPayoutBatch) TableName() string { return "reimbursement_payout_batches" }
+
+// ReimbursementCategory is a kind of claim with its caps. A cap of 0 is no cap.
+type ReimbursementCategory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	CompanyID   uint                               `gorm:"index;not null" json:"company_id"`
+	Code        string                             `gorm:"type:varchar(30);not null" json:"code"`
+	Name        string                             `gorm:"type:varchar(100);not null" json:"name"`
+	AnnualCap   float64                            `gorm:"type:decimal(15,2);default:0" json:"annual_cap"`
+	MonthlyCap  float64                            `gorm:"type:decimal(15,2);default:0" json:"monthly_cap"`
+	PerClaimCap float64                            `gorm:"type:decimal(15,2);default:0" json:"per_claim_cap"`
+	LimitAction constants.ReimbursementLimitAction `gorm:"type:varchar(10);default:'REJECT'" json:"limit_action"`
+	IsActive    bool                               `gorm:"default:true" json:"is_active"`
+
+	GradeCaps []ReimbursementGradeCap `gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE" json:"grade_caps,omitempty"`
+}
+
+func (ReimbursementCategory) TableName() string { return "reimbursement_categories" }
+
+// ReimbursementGradeCap overrides the per-claim cap of a category for employees of a grade.
+type ReimbursementGradeCap struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	CategoryID  uint    `gorm:"not null" json:"category_id"`
+	CompanyID   uint    `gorm:"index;not null" json:"company_id"`
+	Grade       string  `gorm:"type:varchar(20);not null" json:"grade"`
+	PerClaimCap float64 `gorm:"type:decimal(15,2);not null" json:"per_claim_cap"`
+}
+
+func (ReimbursementGradeCap) TableName() string { return "reimbursement_grade_caps" }
+
+// perClaimCap returns the per-claim cap of the category for the grade.
+func (c *ReimbursementCategory) perClaimCap(grade string) float64 {
+	for _, gc := range c.GradeCaps {
+		if grade != "" && gc.Grade == grade {
+			return gc.PerClaimCap
+		}
+	}
+	return c.PerClaimCap
+}
+
+// defaultCategories are created for every new company, without caps until the company sets them.
+var defaultCategories = []ReimbursementCategory{
+	{Code: "MEDICAL", Name: "Medical"},
+	{Code: "TRANSPORT", Name: "Transport"},
+	{Code: "GLASSES", Name: "Glasses"},
+	{Code: "MEAL", Name: "Meal"},
+	{Code: "OTHER", Name: "Other"},
 }
@@ -59,9 +59,11 @@ func (h *Handler) GetAll(ctx echo.Context) error {
 	}
 
 	filter := ReimbursementFilter{
-		Status: status,
-		Page:   page,
-		Limit:  limit,
+		Status:        status,
+		PayoutStatus:  ctx.QueryParam("payout_status"),
+		PayoutChannel: ctx.QueryParam("payout_channel"),
+		Page:          page,
+		Limit:         limit,
 	}
 
 	if !slices.Contains(userContext.Permissions, constants.VIEW_REIMBURSEMENT) && slices.Contains(userContext.Permissions, constants.VIEW_SELF_REIMBURSEMENT) {
@@ -142,8 +144,14 @@ func (h *Handler) parseAndValidateFormData(ctx echo.Context, userID uint) (*Reim
 		return nil, fmt.Errorf("file size exceeds 5MB limit")
 	}
 
+	categoryID, err := strconv.Atoi(ctx.FormValue("category_id"))
+	if err != nil || categoryID <= 0 {
+		return nil, fmt.Errorf("invalid category")
+	}
+
 	return &ReimbursementRequest{
 		UserID:      userID,
+		CategoryID:  uint(categoryID),
 		Title:       ctx.FormValue("title"),
 		Description: ctx.FormValue("description"),
 		Date:        ctx.FormValue("date"),
@@ -178,3 +186,237 @@ func (h *Handler) Export(ctx echo.Context) error {
 	ctx.Response().Header().Set("Content-Disposition", "attachment; filename=reimbursements.xlsx")
 	return ctx.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelFile)
 }
+
+func (h *Handler) ScanReceipt(ctx echo.Context) error {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "file required", nil, err, nil)
+	}
+
+	if fileHeader.Size > 5*1024*1024 {
+		err = fmt.Errorf("file size exceeds 5MB limit")
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	data, err := h.service.ScanReceipt(ctx.Request().Context(), fileHeader)
+	if err != nil {
+		logger.Errorw("scan receipt failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Scan Receipt Success", data, nil, nil)
+}
+
+func (h *Handler) GetEntitlements(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	data, err := h.service.GetEntitlements(ctx.Request().Context(), userContext.UserID)
+	if err != nil {
+		logger.Errorw("get reimbursement entitlements failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Reimbursement Entitlements Success", data, nil, nil)
+}
+
+func (h *Handler) GetCategories(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	// inactive categories are only listed to those who can turn them back on
+	activeOnly := !slices.Contains(userContext.Permissions, constants.MANAGE_REIMBURSEMENT_CATEGORY)
+
+	data, err := h.service.GetCategories(ctx.Request().Context(), activeOnly)
+	if err != nil {
+		logger.Errorw("get reimbursement categories failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Reimbursement Categories Success", data, nil, nil)
+}
+
+func (h *Handler) CreateCategory(ctx echo.Context) error {
+	var req SaveCategoryRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := h.service.SaveCategory(ctx.Request().Context(), &req); err != nil {
+		logger.Errorw("create reimbursement category failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusCreated, "Reimbursement Category Created Successfully", nil, nil, nil)
+}
+
+func (h *Handler) UpdateCategory(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	var req SaveCategoryRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.ID = uint(id)
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := h.service.SaveCategory(ctx.Request().Context(), &req); err != nil {
+		logger.Errorw("update reimbursement category failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Reimbursement Category Updated Successfully", nil, nil, nil)
+}
+
+func (h *Handler) CreatePayoutBatch(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req CreatePayoutBatchRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.CreatedBy = userContext.UserID
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	data, err := h.service.CreatePayoutBatch(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("create reimbursement payout batch failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusCreated, "Payout Batch Created Successfully", data, nil, nil)
+}
+
+func (h *Handler) GetPayoutBatches(ctx echo.Context) error {
+	page, _ := strconv.Atoi(ctx.QueryParam("page"))
+	limit, _ := strconv.Atoi(ctx.QueryParam("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	filter := PayoutBatchFilter{
+		Status: ctx.QueryParam("status"),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	data, meta, err := h.service.GetPayoutBatches(ctx.Request().Context(), filter)
+	if err != nil {
+		logger.Errorw("get reimbursement payout batches failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Payout Batches Success", data, nil, meta)
+}
+
+func (h *Handler) GetPayoutBatchDetail(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	data, err := h.service.GetPayoutBatchDetail(ctx.Request().Context(), uint(id))
+	if err != nil {
+		logger.Errorw("get reimbursement payout batch detail failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Get Payout Batch Detail Success", data, nil, nil)
+}
+
+func (h *Handler) PayPayoutBatch(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	var req PayPayoutBatchRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.ID = uint(id)
+	req.PaidBy = userContext.UserID
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := h.service.PayPayoutBatch(ctx.Request().Context(), &req); err != nil {
+		logger.Errorw("pay reimbursement payout batch failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Payout Batch Marked As Paid", nil, nil, nil)
+}
+
+func (h *Handler) CancelPayoutBatch(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	if err := h.service.CancelPayoutBatch(ctx.Request().Context(), uint(id)); err != nil {
+		logger.Errorw("cancel reimbursement payout batch failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Payout Batch Cancelled", nil, nil, nil)
+}
+
+func (h *Handler) ExportPayoutBatch(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "invalid id", nil, err, nil)
+	}
+
+	excelFile, err := h.service.ExportPayoutBatch(ctx.Request().Context(), uint(id))
+	if err != nil {
+		logger.Errorw("export reimbursement payout batch failed: ", err)
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	ctx.Response().Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=reimbursement-payout-%d.xlsx", id))
+	return ctx.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelFile)
+}
@@ -66,6 +66,7 @@ func TestHandler_Create(t *testing.T) {
 
 			body := &bytes.Buffer{}
 			writer := multipart.NewWriter(body)
+			writer.WriteField("category_id", "1")
 			writer.WriteField("title", "Office Supplies")
 			writer.WriteField("description", "Purchased supplies")
 			writer.WriteField("amount", "50000")
@@ -306,3 +307,158 @@ func TestHandler_Export(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_GetCategories(t *testing.T) {
+	tests := []struct {
+		name           string
+		permissions    []string
+		wantActiveOnly bool
+	}{
+		{name: "employee sees active categories", permissions: []string{constants.CREATE_REIMBURSEMENT}, wantActiveOnly: true},
+		{name: "manager sees all categories", permissions: []string{constants.MANAGE_REIMBURSEMENT_CATEGORY}, wantActiveOnly: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			svc.On("GetCategories", mock.Anything, tt.wantActiveOnly).Return([]ReimbursementCategory{{ID: 1, Code: "MEDICAL"}}, nil)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/reimbursements/categories", nil)
+			at.WithAuthContext(&infrastructure.MyClaims{UserID: 1, CompanyID: 1, Permissions: tt.permissions})
+
+			rec, err := at.Execute(handler.GetCategories)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_UpdateCategory(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathParams map[string]string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			pathParams: map[string]string{"id": "3"},
+			body:       SaveCategoryRequest{Code: "MEDICAL", Name: "Medical", AnnualCap: 5000000, LimitAction: "WARN"},
+			setupMocks: func(svc *mockService) {
+				svc.On("SaveCategory", mock.Anything, mock.MatchedBy(func(req *SaveCategoryRequest) bool {
+					return req.ID == 3 && req.AnnualCap == 5000000
+				})).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid limit action",
+			pathParams: map[string]string{"id": "3"},
+			body:       SaveCategoryRequest{Code: "MEDICAL", Name: "Medical", LimitAction: "BLOCK"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid id",
+			pathParams: map[string]string{"id": "abc"},
+			body:       SaveCategoryRequest{Code: "MEDICAL", Name: "Medical", LimitAction: "WARN"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPut, "/api/reimbursements/categories/:id", tt.body)
+			at.WithPathParams(tt.pathParams)
+
+			rec, err := at.Execute(handler.UpdateCategory)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_ScanReceipt(t *testing.T) {
+	amount := 125000.0
+	svc := new(mockService)
+	svc.On("ScanReceipt", mock.Anything, mock.AnythingOfType("*multipart.FileHeader")).Return(&ReceiptScanResponse{Amount: &amount}, nil)
+	handler := NewHandler(svc)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "receipt.jpg")
+	part.Write([]byte("fake content"))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/reimbursements/scan", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	e := echo.New()
+
+	require.NoError(t, handler.ScanReceipt(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data ReceiptScanResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Data.Amount)
+	assert.Equal(t, amount, *resp.Data.Amount)
+}
+
+func TestHandler_CreatePayoutBatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: CreatePayoutBatchRequest{Name: "Urgent claims", ReimbursementIDs: []uint{1, 2}},
+			setupMocks: func(svc *mockService) {
+				svc.On("CreatePayoutBatch", mock.Anything, mock.MatchedBy(func(req *CreatePayoutBatchRequest) bool {
+					return req.CreatedBy == 1 && len(req.ReimbursementIDs) == 2
+				})).Return(&PayoutBatchListResponse{ID: 9}, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "no claims",
+			body:       CreatePayoutBatchRequest{Name: "Urgent claims"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "claim not payable",
+			body: CreatePayoutBatchRequest{Name: "Urgent claims", ReimbursementIDs: []uint{3}},
+			setupMocks: func(svc *mockService) {
+				svc.On("CreatePayoutBatch", mock.Anything, mock.Anything).Return(nil, errors.New("reimbursement #3 is not approved or already paid"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/reimbursements/payout-batches", tt.body)
+			at.WithAuthContext(&infrastructure.MyClaims{UserID: 1, CompanyID: 1, Permissions: []string{constants.PAYOUT_REIMBURSEMENT}})
+
+			rec, err := at.Execute(handler.CreatePayoutBatch)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
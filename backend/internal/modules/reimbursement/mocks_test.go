@@ -3,7 +3,10 @@ package reimbursement
 import (
 	"context"
 	"mime/multipart"
+	"time"
 
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/pkg/response"
 
 	"github.com/stretchr/testify/mock"
@@ -33,14 +36,116 @@ func (m *mockRepo) Update(ctx context.Context, reimbursement *Reimbursement) err
 	return m.Called(ctx, reimbursement).Error(0)
 }
 
-func (m *mockRepo) GetBulkApprovedAmount(ctx context.Context, month, year int) (map[uint]float64, error) {
-	args := m.Called(ctx, month, year)
+func (m *mockRepo) GetBulkPayableClaims(ctx context.Context) (map[uint][]Reimbursement, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint][]Reimbursement), args.Error(1)
+}
+
+func (m *mockRepo) QueuePayrollClaims(ctx context.Context, ids []uint) error {
+	return m.Called(ctx, ids).Error(0)
+}
+
+func (m *mockRepo) MarkClaimsPaid(ctx context.Context, ids []uint, paidAt time.Time) error {
+	return m.Called(ctx, ids, paidAt).Error(0)
+}
+
+func (m *mockRepo) FindPayableByIDs(ctx context.Context, ids []uint) ([]Reimbursement, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]Reimbursement), args.Error(1)
+}
+
+func (m *mockRepo) CreatePayoutBatch(ctx context.Context, batch *ReimbursementPayoutBatch) error {
+	return m.Called(ctx, batch).Error(0)
+}
+
+func (m *mockRepo) FindPayoutBatchByID(ctx context.Context, id uint) (*ReimbursementPayoutBatch, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ReimbursementPayoutBatch), args.Error(1)
+}
+
+func (m *mockRepo) FindPayoutBatches(ctx context.Context, filter PayoutBatchFilter) ([]ReimbursementPayoutBatch, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]ReimbursementPayoutBatch), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockRepo) UpdatePayoutBatch(ctx context.Context, batch *ReimbursementPayoutBatch) error {
+	return m.Called(ctx, batch).Error(0)
+}
+
+func (m *mockRepo) AssignPayoutBatch(ctx context.Context, ids []uint, batchID uint) error {
+	return m.Called(ctx, ids, batchID).Error(0)
+}
+
+func (m *mockRepo) ReleasePayoutBatch(ctx context.Context, batchID uint) error {
+	return m.Called(ctx, batchID).Error(0)
+}
+
+func (m *mockRepo) SumClaimedByCategory(ctx context.Context, userID uint, from, to time.Time) (map[uint]float64, error) {
+	args := m.Called(ctx, userID, from, to)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(map[uint]float64), args.Error(1)
 }
 
+func (m *mockRepo) FindClaimIDByProof(ctx context.Context, digest, hash string) (uint, error) {
+	args := m.Called(ctx, digest, hash)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *mockRepo) FindProofHashes(ctx context.Context) ([]Reimbursement, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Reimbursement), args.Error(1)
+}
+
+func (m *mockRepo) FindCategories(ctx context.Context, activeOnly bool) ([]ReimbursementCategory, error) {
+	args := m.Called(ctx, activeOnly)
+	return args.Get(0).([]ReimbursementCategory), args.Error(1)
+}
+
+func (m *mockRepo) FindCategoryByID(ctx context.Context, id uint) (*ReimbursementCategory, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ReimbursementCategory), args.Error(1)
+}
+
+func (m *mockRepo) SaveCategory(ctx context.Context, category *ReimbursementCategory) error {
+	return m.Called(ctx, category).Error(0)
+}
+
+func (m *mockRepo) ReplaceGradeCaps(ctx context.Context, categoryID uint, caps []ReimbursementGradeCap) error {
+	return m.Called(ctx, categoryID, caps).Error(0)
+}
+
+func (m *mockRepo) SeedDefaultCategories(ctx context.Context, companyID uint) error {
+	return m.Called(ctx, companyID).Error(0)
+}
+
+type mockUserProvider struct{ mock.Mock }
+
+func (m *mockUserProvider) FindByID(ctx context.Context, id uint) (*user.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+type mockOCR struct{ mock.Mock }
+
+func (m *mockOCR) ExtractText(ctx context.Context, image []byte) (string, error) {
+	args := m.Called(ctx, image)
+	return args.String(0), args.Error(1)
+}
+
 type mockStorage struct{ mock.Mock }
 
 func (m *mockStorage) UploadFileMultipart(ctx context.Context, file *multipart.FileHeader, objectName string) (string, error) {
@@ -58,13 +163,21 @@ func (m *mockNotification) BlastNotification(ctx context.Context, userIDs []uint
 	return m.Called(ctx, userIDs, Type, Title, Message, relatedID).Error(0)
 }
 
-type mockUserProvider struct{ mock.Mock }
+type mockApprovalEngine struct{ mock.Mock }
 
-func (m *mockUserProvider) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
-	args := m.Called(ctx, permissionApprovalName)
+func (m *mockApprovalEngine) Submit(ctx context.Context, req *approval.SubmitRequest) ([]uint, error) {
+	args := m.Called(ctx, req)
 	return args.Get(0).([]uint), args.Error(1)
 }
 
+func (m *mockApprovalEngine) Decide(ctx context.Context, req *approval.DecideRequest) (*approval.Decision, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*approval.Decision), args.Error(1)
+}
+
 type mockExcel struct{ mock.Mock }
 
 func (m *mockExcel) GenerateSimpleExcel(sheetName string, headers []string, rows [][]interface{}) ([]byte, error) {
@@ -125,3 +238,66 @@ func (m *mockService) Export(ctx context.Context, filter ReimbursementFilter) ([
 	}
 	return args.Get(0).([]byte), args.Error(1)
 }
+
+func (m *mockService) GetCategories(ctx context.Context, activeOnly bool) ([]ReimbursementCategory, error) {
+	args := m.Called(ctx, activeOnly)
+	return args.Get(0).([]ReimbursementCategory), args.Error(1)
+}
+
+func (m *mockService) SaveCategory(ctx context.Context, req *SaveCategoryRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) GetEntitlements(ctx context.Context, userID uint) ([]EntitlementResponse, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]EntitlementResponse), args.Error(1)
+}
+
+func (m *mockService) ScanReceipt(ctx context.Context, file *multipart.FileHeader) (*ReceiptScanResponse, error) {
+	args := m.Called(ctx, file)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ReceiptScanResponse), args.Error(1)
+}
+
+func (m *mockService) CreatePayoutBatch(ctx context.Context, req *CreatePayoutBatchRequest) (*PayoutBatchListResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PayoutBatchListResponse), args.Error(1)
+}
+
+func (m *mockService) GetPayoutBatches(ctx context.Context, filter PayoutBatchFilter) ([]PayoutBatchListResponse, *response.Meta, error) {
+	args := m.Called(ctx, filter)
+	var meta *response.Meta
+	if args.Get(1) != nil {
+		meta = args.Get(1).(*response.Meta)
+	}
+	return args.Get(0).([]PayoutBatchListResponse), meta, args.Error(2)
+}
+
+func (m *mockService) GetPayoutBatchDetail(ctx context.Context, id uint) (*PayoutBatchDetailResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PayoutBatchDetailResponse), args.Error(1)
+}
+
+func (m *mockService) PayPayoutBatch(ctx context.Context, req *PayPayoutBatchRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockService) CancelPayoutBatch(ctx context.Context, id uint) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *mockService) ExportPayoutBatch(ctx context.Context, id uint) ([]byte, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
@@ -0,0 +1,226 @@
+package reimbursement
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/response"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"fmt"
+	"strings"
+)
+
+func (s *service) CreatePayoutBatch(ctx context.Context, req *CreatePayoutBatchRequest) (*PayoutBatchListResponse, error) {
+	var batch *ReimbursementPayoutBatch
+
+	err := s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		claims, err := s.repo.FindPayableByIDs(ctx, req.ReimbursementIDs)
+		if err != nil {
+			return err
+		}
+
+		found := make(map[uint]bool, len(claims))
+		for _, c := range claims {
+			found[c.ID] = true
+		}
+		for _, id := range req.ReimbursementIDs {
+			if !found[id] {
+				return fmt.Errorf("reimbursement #%d is not approved or already paid", id)
+			}
+		}
+
+		var total float64
+		var missingBank []string
+		for _, c := range claims {
+			total += c.Amount
+			if c.User.Employee == nil || c.User.Employee.BankAccountNumber == "" {
+				missingBank = append(missingBank, c.User.Username)
+			}
+		}
+		if len(missingBank) > 0 {
+			return fmt.Errorf("no bank account for %s", strings.Join(missingBank, ", "))
+		}
+
+		batch = &ReimbursementPayoutBatch{
+			CompanyID:   utils.GetCompanyIDFromCtx(ctx),
+			Name:        req.Name,
+			Status:      constants.ReimbursementPayoutBatchDraft,
+			TotalAmount: total,
+			Notes:       req.Notes,
+			CreatedBy:   &req.CreatedBy,
+		}
+		if err := s.repo.CreatePayoutBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		return s.repo.AssignPayoutBatch(ctx, req.ReimbursementIDs, batch.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := toPayoutBatchListResponse(batch)
+	return &res, nil
+}
+
+func (s *service) GetPayoutBatches(ctx context.Context, filter PayoutBatchFilter) ([]PayoutBatchListResponse, *response.Meta, error) {
+	batches, total, err := s.repo.FindPayoutBatches(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := make([]PayoutBatchListResponse, 0, len(batches))
+	for i := range batches {
+		list = append(list, toPayoutBatchListResponse(&batches[i]))
+	}
+
+	return list, response.NewMetaOffset(filter.Page, filter.Limit, total), nil
+}
+
+func (s *service) GetPayoutBatchDetail(ctx context.Context, id uint) (*PayoutBatchDetailResponse, error) {
+	batch, err := s.repo.FindPayoutBatchByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("payout batch not found")
+	}
+
+	res := &PayoutBatchDetailResponse{
+		PayoutBatchListResponse: toPayoutBatchListResponse(batch),
+		Notes:                   batch.Notes,
+		Items:                   make([]PayoutBatchItemResponse, 0, len(batch.Items)),
+	}
+	for _, item := range batch.Items {
+		res.Items = append(res.Items, toPayoutBatchItemResponse(&item))
+	}
+
+	return res, nil
+}
+
+func (s *service) PayPayoutBatch(ctx context.Context, req *PayPayoutBatchRequest) error {
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		batch, err := s.repo.FindPayoutBatchByID(ctx, req.ID)
+		if err != nil {
+			return fmt.Errorf("payout batch not found")
+		}
+
+		if batch.Status != constants.ReimbursementPayoutBatchDraft {
+			return fmt.Errorf("cannot pay payout batch with status %s", batch.Status)
+		}
+
+		now := timeNow()
+		batch.Status = constants.ReimbursementPayoutBatchPaid
+		batch.TransferReference = req.TransferReference
+		batch.PaidBy = &req.PaidBy
+		batch.PaidAt = &now
+		if err := s.repo.UpdatePayoutBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		ids := make([]uint, 0, len(batch.Items))
+		userIDs := make([]uint, 0, len(batch.Items))
+		for _, item := range batch.Items {
+			ids = append(ids, item.ID)
+			userIDs = append(userIDs, item.UserID)
+		}
+		if err := s.repo.MarkClaimsPaid(ctx, ids, now); err != nil {
+			return err
+		}
+
+		go func() {
+			_ = s.notification.BlastNotification(
+				utils.DetachContext(ctx),
+				userIDs,
+				string(constants.NotificationTypeReimbursePaid),
+				"Reimburse Sudah Dibayarkan",
+				"Reimburse Anda sudah ditransfer ke rekening Anda.",
+				batch.ID,
+			)
+		}()
+
+		return nil
+	})
+}
+
+func (s *service) CancelPayoutBatch(ctx context.Context, id uint) error {
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		batch, err := s.repo.FindPayoutBatchByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("payout batch not found")
+		}
+
+		if batch.Status != constants.ReimbursementPayoutBatchDraft {
+			return fmt.Errorf("cannot cancel payout batch with status %s", batch.Status)
+		}
+
+		batch.Status = constants.ReimbursementPayoutBatchCancelled
+		if err := s.repo.UpdatePayoutBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		return s.repo.ReleasePayoutBatch(ctx, batch.ID)
+	})
+}
+
+// ExportPayoutBatch builds the transfer list of a batch for upload to the bank.
+func (s *service) ExportPayoutBatch(ctx context.Context, id uint) ([]byte, error) {
+	batch, err := s.repo.FindPayoutBatchByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("payout batch not found")
+	}
+
+	headers := []string{
+		"No", "NIK", "Nama Karyawan", "Bank", "No Rekening", "Atas Nama", "Nominal", "Keterangan",
+	}
+
+	var rows [][]interface{}
+	for i, item := range batch.Items {
+		res := toPayoutBatchItemResponse(&item)
+		var nik string
+		if item.User.Employee != nil {
+			nik = item.User.Employee.NIK
+		}
+
+		rows = append(rows, []interface{}{
+			i + 1,
+			nik,
+			res.EmployeeName,
+			res.BankName,
+			res.BankAccountNumber,
+			res.BankAccountHolder,
+			item.Amount,
+			fmt.Sprintf("Reimburse #%d %s", item.ID, item.Title),
+		})
+	}
+
+	return s.excel.GenerateSimpleExcel("Transfer", headers, rows)
+}
+
+func toPayoutBatchListResponse(batch *ReimbursementPayoutBatch) PayoutBatchListResponse {
+	return PayoutBatchListResponse{
+		ID:                batch.ID,
+		Name:              batch.Name,
+		Status:            string(batch.Status),
+		TotalAmount:       batch.TotalAmount,
+		TransferReference: batch.TransferReference,
+		CreatedAt:         batch.CreatedAt,
+		PaidAt:            batch.PaidAt,
+	}
+}
+
+func toPayoutBatchItemResponse(item *Reimbursement) PayoutBatchItemResponse {
+	res := PayoutBatchItemResponse{
+		ReimbursementID: item.ID,
+		Title:           item.Title,
+		DateOfExpense:   item.DateOfExpense,
+		Amount:          item.Amount,
+		EmployeeName:    item.User.Username,
+	}
+	if item.Category != nil {
+		res.CategoryName = item.Category.Name
+	}
+	if emp := item.User.Employee; emp != nil {
+		res.EmployeeName = emp.FullName
+		res.BankName = emp.BankName
+		res.BankAccountNumber = emp.BankAccountNumber
+		res.BankAccountHolder = emp.BankAccountHolder
+	}
+	return res
+}
@@ -0,0 +1,172 @@
+package reimbursement
+
+import (
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/approval"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func payableClaim(id uint, amount float64, account string) Reimbursement {
+	return Reimbursement{
+		ID: id, UserID: id * 10, Title: "Medical", Amount: amount,
+		Status: constants.ReimbursementStatusApproved, PayoutStatus: constants.ReimbursementPayoutUnpaid,
+		User: user.User{ID: id * 10, Username: "user", Employee: &user.Employee{
+			NIK: "EMP", FullName: "Employee", BankName: "BCA", BankAccountNumber: account, BankAccountHolder: "Employee",
+		}},
+	}
+}
+
+func TestService_CreatePayoutBatch(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	tests := []struct {
+		name    string
+		ids     []uint
+		claims  []Reimbursement
+		wantErr string
+	}{
+		{
+			name:   "success",
+			ids:    []uint{1, 2},
+			claims: []Reimbursement{payableClaim(1, 150000, "123"), payableClaim(2, 50000, "456")},
+		},
+		{
+			name:    "claim not payable",
+			ids:     []uint{1, 3},
+			claims:  []Reimbursement{payableClaim(1, 150000, "123")},
+			wantErr: "reimbursement #3 is not approved or already paid",
+		},
+		{
+			name:    "employee without bank account",
+			ids:     []uint{1},
+			claims:  []Reimbursement{payableClaim(1, 150000, "")},
+			wantErr: "no bank account for user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, _, _, _, _, _, _ := newTestReimbursementService()
+			repo.On("FindPayableByIDs", mock.Anything, tt.ids).Return(tt.claims, nil)
+			repo.On("CreatePayoutBatch", mock.Anything, mock.MatchedBy(func(b *ReimbursementPayoutBatch) bool {
+				return b.TotalAmount == 200000 && b.Status == constants.ReimbursementPayoutBatchDraft && *b.CreatedBy == 5
+			})).Run(func(args mock.Arguments) {
+				args.Get(1).(*ReimbursementPayoutBatch).ID = 9
+			}).Return(nil).Maybe()
+			repo.On("AssignPayoutBatch", mock.Anything, tt.ids, uint(9)).Return(nil).Maybe()
+
+			res, err := svc.CreatePayoutBatch(ctx, &CreatePayoutBatchRequest{CreatedBy: 5, Name: "Urgent claims", ReimbursementIDs: tt.ids})
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "CreatePayoutBatch", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, uint(9), res.ID)
+			assert.Equal(t, 200000.0, res.TotalAmount)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_PayPayoutBatch(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	t.Run("marks batch and claims paid", func(t *testing.T) {
+		svc, repo, _, notif, _, _, _, _, _ := newTestReimbursementService()
+		repo.On("FindPayoutBatchByID", mock.Anything, uint(9)).Return(&ReimbursementPayoutBatch{
+			ID: 9, Status: constants.ReimbursementPayoutBatchDraft,
+			Items: []Reimbursement{payableClaim(1, 150000, "123"), payableClaim(2, 50000, "456")},
+		}, nil)
+		repo.On("UpdatePayoutBatch", mock.Anything, mock.MatchedBy(func(b *ReimbursementPayoutBatch) bool {
+			return b.Status == constants.ReimbursementPayoutBatchPaid && b.TransferReference == "TRF-001" && *b.PaidBy == 5 && b.PaidAt.Equal(now)
+		})).Return(nil)
+		repo.On("MarkClaimsPaid", mock.Anything, []uint{1, 2}, now).Return(nil)
+		notif.On("BlastNotification", mock.Anything, []uint{10, 20}, string(constants.NotificationTypeReimbursePaid), mock.Anything, mock.Anything, uint(9)).Return(nil).Maybe()
+
+		err := svc.PayPayoutBatch(ctx, &PayPayoutBatchRequest{ID: 9, PaidBy: 5, TransferReference: "TRF-001"})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a cancelled batch", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _ := newTestReimbursementService()
+		repo.On("FindPayoutBatchByID", mock.Anything, uint(9)).Return(&ReimbursementPayoutBatch{ID: 9, Status: constants.ReimbursementPayoutBatchCancelled}, nil)
+
+		err := svc.PayPayoutBatch(ctx, &PayPayoutBatchRequest{ID: 9, PaidBy: 5, TransferReference: "TRF-001"})
+		require.EqualError(t, err, "cannot pay payout batch with status CANCELLED")
+		repo.AssertNotCalled(t, "MarkClaimsPaid", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_CancelPayoutBatch(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("hands claims back to payroll", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _ := newTestReimbursementService()
+		repo.On("FindPayoutBatchByID", mock.Anything, uint(9)).Return(&ReimbursementPayoutBatch{ID: 9, Status: constants.ReimbursementPayoutBatchDraft}, nil)
+		repo.On("UpdatePayoutBatch", mock.Anything, mock.MatchedBy(func(b *ReimbursementPayoutBatch) bool {
+			return b.Status == constants.ReimbursementPayoutBatchCancelled
+		})).Return(nil)
+		repo.On("ReleasePayoutBatch", mock.Anything, uint(9)).Return(nil)
+
+		require.NoError(t, svc.CancelPayoutBatch(ctx, 9))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("paid batch cannot be cancelled", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _ := newTestReimbursementService()
+		repo.On("FindPayoutBatchByID", mock.Anything, uint(9)).Return(&ReimbursementPayoutBatch{ID: 9, Status: constants.ReimbursementPayoutBatchPaid}, nil)
+
+		require.EqualError(t, svc.CancelPayoutBatch(ctx, 9), "cannot cancel payout batch with status PAID")
+	})
+}
+
+func TestService_ExportPayoutBatch(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	svc, repo, _, _, _, _, excel, _, _ := newTestReimbursementService()
+	repo.On("FindPayoutBatchByID", mock.Anything, uint(9)).Return(&ReimbursementPayoutBatch{
+		ID: 9, Status: constants.ReimbursementPayoutBatchDraft,
+		Items: []Reimbursement{payableClaim(1, 150000, "123")},
+	}, nil)
+	excel.On("GenerateSimpleExcel", "Transfer", mock.Anything, [][]interface{}{
+		{1, "EMP", "Employee", "BCA", "123", "Employee", 150000.0, "Reimburse #1 Medical"},
+	}).Return([]byte("xlsx"), nil)
+
+	data, err := svc.ExportPayoutBatch(ctx, 9)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("xlsx"), data)
+}
+
+func TestService_ProcessAction_PayoutChannel(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	svc, repo, _, notif, approvalEngine, _, _, _, _ := newTestReimbursementService()
+	repo.On("FindByID", mock.Anything, uint(1)).Return(&Reimbursement{
+		ID: 1, UserID: 10, Amount: 50000,
+		Status: constants.ReimbursementStatusPending, PayoutChannel: constants.ReimbursementPayoutPayroll,
+	}, nil)
+	approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
+	repo.On("Update", mock.Anything, mock.MatchedBy(func(r *Reimbursement) bool {
+		return r.Status == constants.ReimbursementStatusApproved && r.PayoutChannel == constants.ReimbursementPayoutTransfer
+	})).Return(nil)
+	notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	err := svc.ProcessAction(ctx, &ActionRequest{ID: 1, SuperAdminID: 2, Action: "APPROVE", PayoutChannel: "TRANSFER"})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,247 @@
+package reimbursement
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func (s *service) GetCategories(ctx context.Context, activeOnly bool) ([]ReimbursementCategory, error) {
+	return s.repo.FindCategories(ctx, activeOnly)
+}
+
+func (s *service) SaveCategory(ctx context.Context, req *SaveCategoryRequest) error {
+	category := &ReimbursementCategory{
+		CompanyID: utils.GetCompanyIDFromCtx(ctx),
+		IsActive:  true,
+	}
+	if req.ID != 0 {
+		existing, err := s.repo.FindCategoryByID(ctx, req.ID)
+		if err != nil {
+			return fmt.Errorf("category not found")
+		}
+		category = existing
+	}
+
+	grades := make(map[string]bool, len(req.GradeCaps))
+	caps := make([]ReimbursementGradeCap, 0, len(req.GradeCaps))
+	for _, gc := range req.GradeCaps {
+		grade := strings.TrimSpace(gc.Grade)
+		if grades[grade] {
+			return fmt.Errorf("grade %s is capped twice", grade)
+		}
+		grades[grade] = true
+		caps = append(caps, ReimbursementGradeCap{CompanyID: category.CompanyID, Grade: grade, PerClaimCap: gc.PerClaimCap})
+	}
+
+	category.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	category.Name = req.Name
+	category.AnnualCap = req.AnnualCap
+	category.MonthlyCap = req.MonthlyCap
+	category.PerClaimCap = req.PerClaimCap
+	category.LimitAction = constants.ReimbursementLimitAction(req.LimitAction)
+	if req.IsActive != nil {
+		category.IsActive = *req.IsActive
+	}
+
+	return s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.SaveCategory(ctx, category); err != nil {
+			return err
+		}
+		return s.repo.ReplaceGradeCaps(ctx, category.ID, caps)
+	})
+}
+
+func (s *service) GetEntitlements(ctx context.Context, userID uint) ([]EntitlementResponse, error) {
+	categories, err := s.repo.FindCategories(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	grade, err := s.gradeOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := timeNow()
+	yearFrom, yearTo := yearPeriod(now)
+	annual, err := s.repo.SumClaimedByCategory(ctx, userID, yearFrom, yearTo)
+	if err != nil {
+		return nil, err
+	}
+	monthFrom, monthTo := monthPeriod(now)
+	monthly, err := s.repo.SumClaimedByCategory(ctx, userID, monthFrom, monthTo)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]EntitlementResponse, 0, len(categories))
+	for i := range categories {
+		c := &categories[i]
+		item := EntitlementResponse{
+			CategoryID:     c.ID,
+			CategoryCode:   c.Code,
+			CategoryName:   c.Name,
+			LimitAction:    c.LimitAction,
+			AnnualClaimed:  annual[c.ID],
+			MonthlyClaimed: monthly[c.ID],
+		}
+		if cap := c.perClaimCap(grade); cap > 0 {
+			item.PerClaimCap = &cap
+		}
+		item.AnnualCap, item.AnnualRemaining = remaining(c.AnnualCap, item.AnnualClaimed)
+		item.MonthlyCap, item.MonthlyRemaining = remaining(c.MonthlyCap, item.MonthlyClaimed)
+
+		res = append(res, item)
+	}
+
+	return res, nil
+}
+
+func (s *service) ScanReceipt(ctx context.Context, file *multipart.FileHeader) (*ReceiptScanResponse, error) {
+	proof, err := readProof(file)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ReceiptScanResponse{}
+	hash := receiptHash(proof)
+	duplicateOf, err := s.findDuplicate(ctx, proofDigest(proof), hash)
+	if err != nil {
+		return nil, err
+	}
+	if duplicateOf > 0 {
+		res.DuplicateOf = &duplicateOf
+	} else if res.SimilarTo, err = s.findSimilar(ctx, hash); err != nil {
+		return nil, err
+	}
+
+	// only images can be read, a pdf proof is still checked for duplicates
+	if !strings.HasPrefix(http.DetectContentType(proof), "image/") {
+		return res, nil
+	}
+
+	text, err := s.ocr.ExtractText(ctx, proof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
+	}
+	res.Text = text
+
+	if amount, ok := parseReceiptAmount(text); ok {
+		res.Amount = &amount
+	}
+	if date, ok := parseReceiptDate(text); ok {
+		formatted := date.Format(constants.DefaultTimeFormat)
+		res.Date = &formatted
+	}
+
+	return res, nil
+}
+
+// checkLimits returns the caps of the category a new claim would breach.
+func (s *service) checkLimits(ctx context.Context, category *ReimbursementCategory, userID uint, amount float64, date time.Time) ([]string, error) {
+	grade, err := s.gradeOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var breaches []string
+	if cap := category.perClaimCap(grade); cap > 0 && amount > cap {
+		breaches = append(breaches, fmt.Sprintf("per-claim cap of %.2f", cap))
+	}
+
+	if category.AnnualCap > 0 {
+		from, to := yearPeriod(date)
+		claimed, err := s.repo.SumClaimedByCategory(ctx, userID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if claimed[category.ID]+amount > category.AnnualCap {
+			breaches = append(breaches, fmt.Sprintf("annual cap of %.2f (%.2f already claimed)", category.AnnualCap, claimed[category.ID]))
+		}
+	}
+
+	if category.MonthlyCap > 0 {
+		from, to := monthPeriod(date)
+		claimed, err := s.repo.SumClaimedByCategory(ctx, userID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if claimed[category.ID]+amount > category.MonthlyCap {
+			breaches = append(breaches, fmt.Sprintf("monthly cap of %.2f (%.2f already claimed)", category.MonthlyCap, claimed[category.ID]))
+		}
+	}
+
+	return breaches, nil
+}
+
+func (s *service) gradeOf(ctx context.Context, userID uint) (string, error) {
+	u, err := s.user.FindByID(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("user not found")
+	}
+	if u.Employee == nil {
+		return "", nil
+	}
+	return u.Employee.Grade, nil
+}
+
+// findDuplicate returns the claim the same receipt was already submitted with, 0 when there is none. Rejected
+// claims may be submitted again.
+func (s *service) findDuplicate(ctx context.Context, digest, hash string) (uint, error) {
+	return s.repo.FindClaimIDByProof(ctx, digest, hash)
+}
+
+// findSimilar returns the claims whose receipt image looks like this one without being the same.
+func (s *service) findSimilar(ctx context.Context, hash string) ([]uint, error) {
+	claims, err := s.repo.FindProofHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	for _, c := range claims {
+		if isSimilarReceipt(hash, c.ProofHash) {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids, nil
+}
+
+func readProof(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open proof: %w", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof: %w", err)
+	}
+	return data, nil
+}
+
+func remaining(cap, claimed float64) (*float64, *float64) {
+	if cap <= 0 {
+		return nil, nil
+	}
+	left := max(cap-claimed, 0)
+	return &cap, &left
+}
+
+func yearPeriod(t time.Time) (time.Time, time.Time) {
+	from := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	return from, from.AddDate(1, 0, -1)
+}
+
+func monthPeriod(t time.Time) (time.Time, time.Time) {
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return from, from.AddDate(0, 1, -1)
+}
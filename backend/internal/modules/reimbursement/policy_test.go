@@ -0,0 +1,242 @@
+package reimbursement
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strconv"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Create_Limits(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	fileHeader, _ := testutil.CreateMultipartFileHeader("receipt.pdf", "%PDF-1.4 receipt")
+
+	glasses := func(action constants.ReimbursementLimitAction) *ReimbursementCategory {
+		return &ReimbursementCategory{
+			ID: 3, Name: "Glasses", IsActive: true, LimitAction: action,
+			AnnualCap: 2000000, PerClaimCap: 1000000,
+			GradeCaps: []ReimbursementGradeCap{{Grade: "M1", PerClaimCap: 1500000}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		category    *ReimbursementCategory
+		grade       string
+		amount      float64
+		claimed     float64
+		duplicateOf uint
+		wantErr     string
+		wantWarning string
+	}{
+		{name: "within caps", category: glasses(constants.ReimbursementLimitReject), amount: 900000},
+		{name: "grade lifts per-claim cap", category: glasses(constants.ReimbursementLimitReject), grade: "M1", amount: 1200000},
+		{name: "per-claim cap rejects", category: glasses(constants.ReimbursementLimitReject), amount: 1200000, wantErr: "claim exceeds the per-claim cap of 1000000.00"},
+		{name: "annual cap rejects", category: glasses(constants.ReimbursementLimitReject), amount: 600000, claimed: 1500000, wantErr: "annual cap of 2000000.00 (1500000.00 already claimed)"},
+		{name: "warn keeps the claim", category: glasses(constants.ReimbursementLimitWarn), amount: 1200000, wantWarning: "Exceeds the per-claim cap of 1000000.00"},
+		{name: "inactive category", category: &ReimbursementCategory{ID: 3, Name: "Glasses"}, amount: 100, wantErr: "category Glasses is not active"},
+		{
+			name:        "duplicate receipt",
+			category:    glasses(constants.ReimbursementLimitReject),
+			amount:      100,
+			duplicateOf: 7,
+			wantErr:     "receipt already claimed in reimbursement #7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, storage, notif, approvalEngine, _, _, userProv, _ := newTestReimbursementService()
+			repo.On("FindCategoryByID", mock.Anything, uint(3)).Return(tt.category, nil)
+			digest := proofDigest([]byte("%PDF-1.4 receipt"))
+			repo.On("FindClaimIDByProof", mock.Anything, digest, digest).Return(tt.duplicateOf, nil)
+			repo.On("FindProofHashes", mock.Anything).Return([]Reimbursement{}, nil).Maybe()
+			repo.On("SumClaimedByCategory", mock.Anything, uint(1), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)).
+				Return(map[uint]float64{3: tt.claimed}, nil).Maybe()
+			userProv.On("FindByID", mock.Anything, uint(1)).Return(&user.User{ID: 1, Employee: &user.Employee{Grade: tt.grade}}, nil)
+			storage.On("UploadFileMultipart", mock.Anything, mock.Anything, mock.Anything).Return("https://storage.example.com/file.pdf", nil).Maybe()
+			repo.On("Create", mock.Anything, mock.AnythingOfType("*reimbursement.Reimbursement")).Return(nil).Maybe()
+			approvalEngine.On("Submit", mock.Anything, mock.Anything).Return([]uint{10}, nil).Maybe()
+			notif.On("BlastNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			err := svc.Create(ctx, &ReimbursementRequest{UserID: 1, CategoryID: 3, Amount: tt.amount, Date: "2026-03-05", File: fileHeader})
+
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			repo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(r *Reimbursement) bool {
+				return *r.CategoryID == 3 && r.Title == "Glasses" && r.LimitWarning == tt.wantWarning && r.ProofHash != "" &&
+					r.ProofDigest == digest && r.DuplicateWarning == ""
+			}))
+		})
+	}
+}
+
+func TestService_Create_SimilarReceipt(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	img := image.NewGray(image.Rect(0, 0, 90, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 90; x++ {
+			img.Pix[y*img.Stride+x] = uint8(x*3 + y*5)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	fileHeader, _ := testutil.CreateMultipartFileHeader("receipt.png", buf.String())
+
+	hash := receiptHash(buf.Bytes())
+	x, err := strconv.ParseUint(hash, 16, 64)
+	require.NoError(t, err)
+	// another photo of the same receipt, two bits off
+	nearHash := fmt.Sprintf("%016x", x^0b101)
+
+	svc, repo, storage, notif, approvalEngine, _, _, userProv, _ := newTestReimbursementService()
+	repo.On("FindCategoryByID", mock.Anything, uint(3)).Return(&ReimbursementCategory{ID: 3, Name: "Medical", IsActive: true}, nil)
+	repo.On("FindClaimIDByProof", mock.Anything, proofDigest(buf.Bytes()), hash).Return(uint(0), nil)
+	repo.On("FindProofHashes", mock.Anything).Return([]Reimbursement{{ID: 8, ProofHash: nearHash}, {ID: 9, ProofHash: fmt.Sprintf("%016x", ^x)}}, nil)
+	userProv.On("FindByID", mock.Anything, uint(1)).Return(&user.User{ID: 1}, nil)
+	storage.On("UploadFileMultipart", mock.Anything, mock.Anything, mock.Anything).Return("https://storage.example.com/file.png", nil)
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(r *Reimbursement) bool {
+		return r.DuplicateWarning == "Receipt looks like the one of reimbursement #8" && r.ProofHash == hash
+	})).Return(nil)
+	approvalEngine.On("Submit", mock.Anything, mock.Anything).Return([]uint{10}, nil)
+	notif.On("BlastNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	// a near match is left to the approvers instead of refusing the claim
+	require.NoError(t, svc.Create(ctx, &ReimbursementRequest{UserID: 1, CategoryID: 3, Amount: 100, Date: "2026-03-05", File: fileHeader}))
+	repo.AssertExpectations(t)
+}
+
+func TestService_GetEntitlements(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	timeNow = func() time.Time { return time.Date(2026, 5, 20, 10, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	svc, repo, _, _, _, _, _, userProv, _ := newTestReimbursementService()
+	repo.On("FindCategories", mock.Anything, true).Return([]ReimbursementCategory{
+		{ID: 1, Code: "MEDICAL", Name: "Medical", AnnualCap: 5000000, MonthlyCap: 1000000, LimitAction: constants.ReimbursementLimitReject},
+		{ID: 2, Code: "OTHER", Name: "Other", LimitAction: constants.ReimbursementLimitWarn, GradeCaps: []ReimbursementGradeCap{{Grade: "S2", PerClaimCap: 300000}}},
+	}, nil)
+	userProv.On("FindByID", mock.Anything, uint(1)).Return(&user.User{ID: 1, Employee: &user.Employee{Grade: "S2"}}, nil)
+	repo.On("SumClaimedByCategory", mock.Anything, uint(1), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)).
+		Return(map[uint]float64{1: 5200000}, nil)
+	repo.On("SumClaimedByCategory", mock.Anything, uint(1), time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)).
+		Return(map[uint]float64{1: 250000}, nil)
+
+	res, err := svc.GetEntitlements(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	medical := res[0]
+	assert.Nil(t, medical.PerClaimCap)
+	assert.Equal(t, 5200000.0, medical.AnnualClaimed)
+	assert.Equal(t, 0.0, *medical.AnnualRemaining)
+	assert.Equal(t, 750000.0, *medical.MonthlyRemaining)
+
+	other := res[1]
+	assert.Equal(t, 300000.0, *other.PerClaimCap)
+	assert.Nil(t, other.AnnualCap)
+	assert.Nil(t, other.MonthlyRemaining)
+}
+
+func TestService_SaveCategory(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("creates with grade caps", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _ := newTestReimbursementService()
+		repo.On("SaveCategory", mock.Anything, mock.MatchedBy(func(c *ReimbursementCategory) bool {
+			return c.Code == "DENTAL" && c.CompanyID == 1 && c.IsActive && c.LimitAction == constants.ReimbursementLimitWarn
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*ReimbursementCategory).ID = 9
+		}).Return(nil)
+		repo.On("ReplaceGradeCaps", mock.Anything, uint(9), []ReimbursementGradeCap{{CompanyID: 1, Grade: "M1", PerClaimCap: 800000}}).Return(nil)
+
+		err := svc.SaveCategory(ctx, &SaveCategoryRequest{
+			Code: " dental ", Name: "Dental", LimitAction: "WARN",
+			GradeCaps: []GradeCapRequest{{Grade: "M1", PerClaimCap: 800000}},
+		})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a grade capped twice", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _ := newTestReimbursementService()
+
+		err := svc.SaveCategory(ctx, &SaveCategoryRequest{
+			Code: "DENTAL", Name: "Dental", LimitAction: "REJECT",
+			GradeCaps: []GradeCapRequest{{Grade: "M1", PerClaimCap: 1}, {Grade: "M1", PerClaimCap: 2}},
+		})
+		require.EqualError(t, err, "grade M1 is capped twice")
+		repo.AssertNotCalled(t, "SaveCategory", mock.Anything, mock.Anything)
+	})
+
+	t.Run("deactivates an existing category", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _ := newTestReimbursementService()
+		inactive := false
+		repo.On("FindCategoryByID", mock.Anything, uint(4)).Return(&ReimbursementCategory{ID: 4, CompanyID: 1, Code: "MEAL", IsActive: true}, nil)
+		repo.On("SaveCategory", mock.Anything, mock.MatchedBy(func(c *ReimbursementCategory) bool {
+			return c.ID == 4 && !c.IsActive
+		})).Return(nil)
+		repo.On("ReplaceGradeCaps", mock.Anything, uint(4), []ReimbursementGradeCap{}).Return(nil)
+
+		err := svc.SaveCategory(ctx, &SaveCategoryRequest{ID: 4, Code: "MEAL", Name: "Meal", LimitAction: "REJECT", IsActive: &inactive})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestService_ScanReceipt(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("reads amount and date from an image", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 20, 20))))
+		fileHeader, _ := testutil.CreateMultipartFileHeader("receipt.png", buf.String())
+
+		svc, repo, _, _, _, _, _, _, ocr := newTestReimbursementService()
+		repo.On("FindClaimIDByProof", mock.Anything, proofDigest(buf.Bytes()), receiptHash(buf.Bytes())).Return(uint(0), nil)
+		repo.On("FindProofHashes", mock.Anything).Return([]Reimbursement{{ID: 5, ProofHash: receiptHash(buf.Bytes())}}, nil)
+		ocr.On("ExtractText", mock.Anything, buf.Bytes()).Return("KLINIK PRATAMA\n12/04/2026\nTOTAL Rp 350.000", nil)
+
+		res, err := svc.ScanReceipt(ctx, fileHeader)
+		require.NoError(t, err)
+		require.NotNil(t, res.Amount)
+		require.NotNil(t, res.Date)
+		assert.Equal(t, 350000.0, *res.Amount)
+		assert.Equal(t, "2026-04-12", *res.Date)
+		assert.Nil(t, res.DuplicateOf)
+		assert.Equal(t, []uint{5}, res.SimilarTo)
+	})
+
+	t.Run("pdf is only checked for duplicates", func(t *testing.T) {
+		fileHeader, _ := testutil.CreateMultipartFileHeader("receipt.pdf", "%PDF-1.4 receipt")
+
+		svc, repo, _, _, _, _, _, _, ocr := newTestReimbursementService()
+		digest := proofDigest([]byte("%PDF-1.4 receipt"))
+		repo.On("FindClaimIDByProof", mock.Anything, digest, digest).Return(uint(12), nil)
+
+		res, err := svc.ScanReceipt(ctx, fileHeader)
+		require.NoError(t, err)
+		require.NotNil(t, res.DuplicateOf)
+		assert.Equal(t, uint(12), *res.DuplicateOf)
+		assert.Nil(t, res.Amount)
+		ocr.AssertNotCalled(t, "ExtractText", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,210 @@
+package reimbursement
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"math/bits"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// similarDistance is how many bits two image hashes may differ by and still look like the same receipt, a photo
+// re-saved or resized keeps its hash within a few bits. Different receipts of one shop can come as close, so a
+// near match is only flagged to the approvers.
+const similarDistance = 4
+
+var (
+	currencyPattern = regexp.MustCompile(`(?i)\brp\.?`)
+	amountPattern   = regexp.MustCompile(`\b(\d{1,3}(?:[.,]\d{3})+(?:[.,]\d{2})?|\d{4,9}(?:[.,]\d{2})?)\b`)
+	totalPattern    = regexp.MustCompile(`(?i)\b(grand\s*total|total|jumlah|tagihan|bayar)\b`)
+	subtotalWord    = regexp.MustCompile(`(?i)\bsub\s*-?\s*total\b`)
+
+	numericDatePattern = regexp.MustCompile(`\b(\d{1,4})[/\-.](\d{1,2})[/\-.](\d{2,4})\b`)
+	namedDatePattern   = regexp.MustCompile(`(?i)\b(\d{1,2})\s+([a-z]{3,9})\.?\s+(\d{4})\b`)
+)
+
+var monthNames = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "mei": time.May, "jun": time.June, "jul": time.July,
+	"aug": time.August, "agu": time.August, "agt": time.August, "sep": time.September,
+	"oct": time.October, "okt": time.October, "nov": time.November, "nop": time.November,
+	"dec": time.December, "des": time.December,
+}
+
+// parseReceiptAmount finds the amount paid on a receipt: the largest amount on a total line, or the largest
+// amount of the receipt when no line reads as a total.
+func parseReceiptAmount(text string) (float64, bool) {
+	var best, bestTotal float64
+	for _, line := range strings.Split(text, "\n") {
+		isTotal := totalPattern.MatchString(line) && !subtotalWord.MatchString(line)
+
+		// Rp125.000 reads as Rp 125.000
+		line = currencyPattern.ReplaceAllString(line, " ")
+		for _, m := range amountPattern.FindAllStringSubmatch(line, -1) {
+			amount, ok := parseAmount(m[1])
+			if !ok {
+				continue
+			}
+			best = max(best, amount)
+			if isTotal {
+				bestTotal = max(bestTotal, amount)
+			}
+		}
+	}
+
+	if bestTotal > 0 {
+		return bestTotal, true
+	}
+	return best, best > 0
+}
+
+// parseAmount reads 125.000, 125,000, 125.000,00 and 125,000.00 alike. A separator followed by exactly two
+// digits at the end is the decimal separator, any other separator groups thousands.
+func parseAmount(raw string) (float64, bool) {
+	decimals := ""
+	if n := len(raw); n > 3 && (raw[n-3] == '.' || raw[n-3] == ',') {
+		decimals = raw[n-2:]
+		raw = raw[:n-3]
+	}
+
+	digits := strings.NewReplacer(".", "", ",", "").Replace(raw)
+	if decimals != "" {
+		digits += "." + decimals
+	}
+
+	amount, err := strconv.ParseFloat(digits, 64)
+	if err != nil || amount <= 0 {
+		return 0, false
+	}
+	return amount, true
+}
+
+// parseReceiptDate finds the first date on a receipt, day first as receipts in Indonesia are printed, or year
+// first when it starts with four digits.
+func parseReceiptDate(text string) (time.Time, bool) {
+	for _, m := range numericDatePattern.FindAllStringSubmatch(text, -1) {
+		a, _ := strconv.Atoi(m[1])
+		b, _ := strconv.Atoi(m[2])
+		c, _ := strconv.Atoi(m[3])
+
+		day, month, year := a, b, c
+		if len(m[1]) == 4 {
+			day, year = c, a
+		} else if len(m[3]) != 4 && len(m[3]) != 2 {
+			continue
+		}
+		if year < 100 {
+			year += 2000
+		}
+
+		if date, ok := validDate(year, month, day); ok {
+			return date, true
+		}
+	}
+
+	for _, m := range namedDatePattern.FindAllStringSubmatch(text, -1) {
+		month, ok := monthNames[strings.ToLower(m[2][:3])]
+		if !ok {
+			continue
+		}
+		day, _ := strconv.Atoi(m[1])
+		year, _ := strconv.Atoi(m[3])
+
+		if date, ok := validDate(year, int(month), day); ok {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func validDate(year, month, day int) (time.Time, bool) {
+	if month < 1 || month > 12 || day < 1 || year < 2000 {
+		return time.Time{}, false
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	// time.Date normalises 31 February into March, such a date was misread
+	if date.Day() != day {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// proofDigest returns the sha256 of a proof file, the same file submitted twice has the same digest.
+func proofDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// receiptHash returns the difference hash of a proof image, which survives re-encoding and resizing, or the
+// sha256 of a proof that is not an image.
+func receiptHash(data []byte) string {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return proofDigest(data)
+	}
+
+	return fmt.Sprintf("%016x", differenceHash(img))
+}
+
+// differenceHash shrinks the image to 9x8 grey cells and sets a bit for every cell brighter than its right
+// neighbour.
+func differenceHash(img image.Image) uint64 {
+	const width, height = 9, 8
+	var cells [height][width]float64
+
+	bounds := img.Bounds()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+			y0 := bounds.Min.Y + y*bounds.Dy()/height
+			y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+
+			var sum float64
+			var count int
+			for py := y0; py < y1; py++ {
+				for px := x0; px < x1; px++ {
+					r, g, b, _ := img.At(px, py).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					count++
+				}
+			}
+			cells[y][x] = sum / float64(count)
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			hash <<= 1
+			if cells[y][x] > cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// isSimilarReceipt reports whether two image hashes differ by a few bits at most, other proofs only match
+// exactly.
+func isSimilarReceipt(a, b string) bool {
+	if len(a) != 16 || len(b) != 16 {
+		return false
+	}
+
+	x, errA := strconv.ParseUint(a, 16, 64)
+	y, errB := strconv.ParseUint(b, 16, 64)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return bits.OnesCount64(x^y) <= similarDistance
+}
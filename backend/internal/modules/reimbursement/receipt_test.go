@@ -0,0 +1,129 @@
+package reimbursement
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReceiptAmount(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   float64
+		wantOK bool
+	}{
+		{
+			name:   "total line wins over larger item",
+			text:   "APOTEK SEHAT\nVitamin C 1.250.000\nSubtotal 1.300.000\nTOTAL Rp 135.000\nTunai 150.000",
+			want:   135000,
+			wantOK: true,
+		},
+		{
+			name:   "rupiah prefix without space",
+			text:   "Jumlah Rp125.000",
+			want:   125000,
+			wantOK: true,
+		},
+		{
+			name:   "decimals with comma",
+			text:   "Grand Total 87.500,00",
+			want:   87500,
+			wantOK: true,
+		},
+		{
+			name:   "decimals with dot",
+			text:   "TOTAL 1,250,000.50",
+			want:   1250000.50,
+			wantOK: true,
+		},
+		{
+			name:   "largest amount without total line",
+			text:   "Taxi\n45.000\n5.000",
+			want:   45000,
+			wantOK: true,
+		},
+		{
+			name:   "nothing to read",
+			text:   "terima kasih",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseReceiptAmount(tt.text)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.InDelta(t, tt.want, got, 0.001)
+		})
+	}
+}
+
+func TestParseReceiptDate(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "day first", text: "Tgl: 05/03/2026 14:02", want: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), wantOK: true},
+		{name: "two digit year", text: "05-03-26", want: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), wantOK: true},
+		{name: "year first", text: "2026-03-05", want: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), wantOK: true},
+		{name: "indonesian month", text: "17 Agustus 2026", want: time.Date(2026, 8, 17, 0, 0, 0, 0, time.UTC), wantOK: true},
+		{name: "english month", text: "3 Dec 2025", want: time.Date(2025, 12, 3, 0, 0, 0, 0, time.UTC), wantOK: true},
+		{name: "skips impossible date", text: "31/02/2026 then 01/03/2026", want: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), wantOK: true},
+		{name: "no date", text: "TOTAL 125.000", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseReceiptDate(tt.text)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.True(t, tt.want.Equal(got), "got %s", got)
+			}
+		})
+	}
+}
+
+func TestReceiptHash(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 180, 160))
+	for y := 0; y < 160; y++ {
+		for x := 0; x < 180; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 255})
+		}
+	}
+
+	var asPNG, asJPEG bytes.Buffer
+	require.NoError(t, png.Encode(&asPNG, img))
+	require.NoError(t, jpeg.Encode(&asJPEG, img, &jpeg.Options{Quality: 60}))
+
+	pngHash := receiptHash(asPNG.Bytes())
+	jpegHash := receiptHash(asJPEG.Bytes())
+	assert.Len(t, pngHash, 16)
+	assert.True(t, isSimilarReceipt(pngHash, jpegHash), "re-encoded receipt should look alike: %s %s", pngHash, jpegHash)
+	assert.NotEqual(t, proofDigest(asPNG.Bytes()), proofDigest(asJPEG.Bytes()))
+
+	other := image.NewRGBA(image.Rect(0, 0, 180, 160))
+	for y := 0; y < 160; y++ {
+		for x := 0; x < 180; x++ {
+			other.Set(x, y, color.RGBA{R: uint8(255 - x), G: uint8(y * 3), B: uint8(x * y), A: 255})
+		}
+	}
+	var otherPNG bytes.Buffer
+	require.NoError(t, png.Encode(&otherPNG, other))
+	assert.False(t, isSimilarReceipt(pngHash, receiptHash(otherPNG.Bytes())))
+
+	pdfHash := receiptHash([]byte("%PDF-1.4 receipt"))
+	assert.Len(t, pdfHash, 64)
+	assert.Equal(t, proofDigest([]byte("%PDF-1.4 receipt")), pdfHash)
+	// a pdf is never a near match, only the same file is caught
+	assert.False(t, isSimilarReceipt(pdfHash, pdfHash))
+}
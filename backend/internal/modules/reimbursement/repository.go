@@ -2,17 +2,41 @@ package reimbursement
 
 import (
 	"context"
+	"errors"
+	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/utils"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// ErrClaimsNoLongerPayable is returned when claims picked for a payroll were queued, paid or moved to a transfer
+// batch by someone else in the meantime.
+var ErrClaimsNoLongerPayable = errors.New("some reimbursements are no longer payable, generate the payroll again")
+
 type Repository interface {
 	Create(ctx context.Context, reimbursement *Reimbursement) error
 	FindByID(ctx context.Context, id uint) (*Reimbursement, error)
 	FindAll(ctx context.Context, filter ReimbursementFilter) ([]Reimbursement, int64, error)
 	Update(ctx context.Context, reimbursement *Reimbursement) error
-	GetBulkApprovedAmount(ctx context.Context, month, year int) (map[uint]float64, error)
+	GetBulkPayableClaims(ctx context.Context) (map[uint][]Reimbursement, error)
+	QueuePayrollClaims(ctx context.Context, ids []uint) error
+	MarkClaimsPaid(ctx context.Context, ids []uint, paidAt time.Time) error
+	FindPayableByIDs(ctx context.Context, ids []uint) ([]Reimbursement, error)
+	CreatePayoutBatch(ctx context.Context, batch *ReimbursementPayoutBatch) error
+	FindPayoutBatchByID(ctx context.Context, id uint) (*ReimbursementPayoutBatch, error)
+	FindPayoutBatches(ctx context.Context, filter PayoutBatchFilter) ([]ReimbursementPayoutBatch, int64, error)
+	UpdatePayoutBatch(ctx context.Context, batch *ReimbursementPayoutBatch) error
+	AssignPayoutBatch(ctx context.Context, ids []uint, batchID uint) error
+	ReleasePayoutBatch(ctx context.Context, batchID uint) error
+	SumClaimedByCategory(ctx context.Context, userID uint, from, to time.Time) (map[uint]float64, error)
+	FindClaimIDByProof(ctx context.Context, digest, hash string) (uint, error)
+	FindProofHashes(ctx context.Context) ([]Reimbursement, error)
+	FindCategories(ctx context.Context, activeOnly bool) ([]ReimbursementCategory, error)
+	FindCategoryByID(ctx context.Context, id uint) (*ReimbursementCategory, error)
+	SaveCategory(ctx context.Context, category *ReimbursementCategory) error
+	ReplaceGradeCaps(ctx context.Context, categoryID uint, caps []ReimbursementGradeCap) error
+	SeedDefaultCategories(ctx context.Context, companyID uint) error
 }
 
 type repository struct {
@@ -29,10 +53,10 @@ func (r *repository) Create(ctx context.Context, reimbursement *Reimbursement) e
 }
 
 func (r *repository) FindByID(ctx context.Context, id uint) (*Reimbursement, error) {
-	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	db := utils.UserDataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Reimbursement{})), "reimbursements.user_id")
 	var reimburstment Reimbursement
 
-	err := db.Preload("User").First(&reimburstment, id).Error
+	err := db.Preload("User").Preload("Category").First(&reimburstment, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -41,11 +65,11 @@ func (r *repository) FindByID(ctx context.Context, id uint) (*Reimbursement, err
 }
 
 func (r *repository) FindAll(ctx context.Context, filter ReimbursementFilter) ([]Reimbursement, int64, error) {
-	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	db := utils.UserDataScope(ctx, utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Reimbursement{})), "reimbursements.user_id")
 	var reimbursements []Reimbursement
 	var total int64
 
-	query := db.Model(&Reimbursement{})
+	query := db.Model(&Reimbursement{}).Preload("Category")
 
 	if filter.UserID > 0 {
 		query = query.Where("user_id = ?", filter.UserID)
@@ -55,6 +79,14 @@ func (r *repository) FindAll(ctx context.Context, filter ReimbursementFilter) ([
 		query = query.Where("status = ?", filter.Status)
 	}
 
+	if filter.PayoutStatus != "" {
+		query = query.Where("payout_status = ?", filter.PayoutStatus)
+	}
+
+	if filter.PayoutChannel != "" {
+		query = query.Where("payout_channel = ?", filter.PayoutChannel)
+	}
+
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
@@ -74,29 +106,262 @@ func (r *repository) Update(ctx context.Context, reimbursement *Reimbursement) e
 	return db.Save(reimbursement).Error
 }
 
-func (r *repository) GetBulkApprovedAmount(ctx context.Context, month, year int) (map[uint]float64, error) {
+// SumClaimedByCategory adds up the pending and approved claims of the user with an expense date in [from, to],
+// by category.
+func (r *repository) SumClaimedByCategory(ctx context.Context, userID uint, from, to time.Time) (map[uint]float64, error) {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
-	type Result struct {
-		UserID      uint
+	var results []struct {
+		CategoryID  uint
 		TotalAmount float64
 	}
-	var results []Result
 
 	err := db.Model(&Reimbursement{}).
-		Select("user_id, COALESCE(SUM(amount), 0) as total_amount").
-		Where("status = ?", "APPROVED").
-		Where("MONTH(date_of_expense) = ? AND YEAR(date_of_expense) = ?", month, year).
-		Group("user_id").
+		Select("category_id, COALESCE(SUM(amount), 0) as total_amount").
+		Where("user_id = ?", userID).
+		Where("category_id IS NOT NULL").
+		Where("status IN ?", []string{string(constants.ReimbursementStatusPending), string(constants.ReimbursementStatusApproved)}).
+		Where("date_of_expense BETWEEN ? AND ?", from, to).
+		Group("category_id").
 		Scan(&results).Error
-
 	if err != nil {
 		return nil, err
 	}
 
-	dataMap := make(map[uint]float64)
+	claimed := make(map[uint]float64, len(results))
 	for _, res := range results {
-		dataMap[res.UserID] = res.TotalAmount
+		claimed[res.CategoryID] = res.TotalAmount
+	}
+	return claimed, nil
+}
+
+// FindClaimIDByProof returns the first claim not rejected with the same proof file or the same proof hash, 0 when
+// there is none. A rejected receipt may be claimed again.
+func (r *repository) FindClaimIDByProof(ctx context.Context, digest, hash string) (uint, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var ids []uint
+
+	err := db.Model(&Reimbursement{}).
+		Where("(proof_digest = ? OR proof_hash = ?)", digest, hash).
+		Where("status <> ?", string(constants.ReimbursementStatusRejected)).
+		Order("id ASC").
+		Limit(1).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	return ids[0], nil
+}
+
+// FindProofHashes returns the id and image hash of every claim not rejected with an image proof, the hashes other
+// proofs have only ever match exactly.
+func (r *repository) FindProofHashes(ctx context.Context) ([]Reimbursement, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var reimbursements []Reimbursement
+
+	err := db.Model(&Reimbursement{}).
+		Select("id", "proof_hash").
+		Where("LENGTH(proof_hash) = 16").
+		Where("status <> ?", string(constants.ReimbursementStatusRejected)).
+		Find(&reimbursements).Error
+
+	return reimbursements, err
+}
+
+func (r *repository) FindCategories(ctx context.Context, activeOnly bool) ([]ReimbursementCategory, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&ReimbursementCategory{}))
+	if activeOnly {
+		db = db.Where("is_active = ?", true)
+	}
+
+	var categories []ReimbursementCategory
+	if err := db.Preload("GradeCaps").Order("name ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *repository) FindCategoryByID(ctx context.Context, id uint) (*ReimbursementCategory, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var category ReimbursementCategory
+	if err := db.Preload("GradeCaps").First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *repository) SaveCategory(ctx context.Context, category *ReimbursementCategory) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Omit("GradeCaps").Save(category).Error
+}
+
+func (r *repository) ReplaceGradeCaps(ctx context.Context, categoryID uint, caps []ReimbursementGradeCap) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	if err := db.Where("category_id = ?", categoryID).Delete(&ReimbursementGradeCap{}).Error; err != nil {
+		return err
+	}
+
+	if len(caps) == 0 {
+		return nil
+	}
+	return db.Create(&caps).Error
+}
+
+func (r *repository) SeedDefaultCategories(ctx context.Context, companyID uint) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+
+	for _, c := range defaultCategories {
+		category := ReimbursementCategory{Code: c.Code, Name: c.Name, CompanyID: companyID, LimitAction: constants.ReimbursementLimitReject, IsActive: true}
+		if err := db.Where(ReimbursementCategory{Code: c.Code, CompanyID: companyID}).FirstOrCreate(&category).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBulkPayableClaims returns the approved claims payroll still has to pay, whatever month their expense is
+// in, by user.
+func (r *repository) GetBulkPayableClaims(ctx context.Context) (map[uint][]Reimbursement, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var claims []Reimbursement
+
+	err := db.Model(&Reimbursement{}).
+		Where("status = ?", string(constants.ReimbursementStatusApproved)).
+		Where("payout_channel = ?", string(constants.ReimbursementPayoutPayroll)).
+		Where("payout_status = ?", string(constants.ReimbursementPayoutUnpaid)).
+		Order("date_of_expense ASC, id ASC").
+		Find(&claims).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dataMap := make(map[uint][]Reimbursement)
+	for _, c := range claims {
+		dataMap[c.UserID] = append(dataMap[c.UserID], c)
 	}
 
-	return dataMap, err
+	return dataMap, nil
+}
+
+// QueuePayrollClaims marks the claims a generated payroll carries, so the payroll of the next period leaves
+// them out. The claims were read before the payroll transaction started, a claim another payroll or a transfer
+// batch took meanwhile fails the whole call instead of being paid twice.
+func (r *repository) QueuePayrollClaims(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	result := db.Model(&Reimbursement{}).
+		Where("id IN ?", ids).
+		Where("payout_channel = ?", string(constants.ReimbursementPayoutPayroll)).
+		Where("payout_status = ?", string(constants.ReimbursementPayoutUnpaid)).
+		Update("payout_status", constants.ReimbursementPayoutQueued)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(ids)) {
+		return ErrClaimsNoLongerPayable
+	}
+	return nil
+}
+
+func (r *repository) MarkClaimsPaid(ctx context.Context, ids []uint, paidAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	return db.Model(&Reimbursement{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"payout_status": constants.ReimbursementPayoutPaid,
+			"paid_at":       paidAt,
+		}).Error
+}
+
+// FindPayableByIDs returns those of the claims that are approved and not paid or queued yet.
+func (r *repository) FindPayableByIDs(ctx context.Context, ids []uint) ([]Reimbursement, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var claims []Reimbursement
+
+	err := db.Preload("User.Employee").
+		Where("id IN ?", ids).
+		Where("status = ?", string(constants.ReimbursementStatusApproved)).
+		Where("payout_status = ?", string(constants.ReimbursementPayoutUnpaid)).
+		Find(&claims).Error
+
+	return claims, err
+}
+
+func (r *repository) CreatePayoutBatch(ctx context.Context, batch *ReimbursementPayoutBatch) error {
+	db := utils.GetDBFromContext(ctx, r.db)
+	return db.Omit("Items").Create(batch).Error
+}
+
+func (r *repository) FindPayoutBatchByID(ctx context.Context, id uint) (*ReimbursementPayoutBatch, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var batch ReimbursementPayoutBatch
+
+	err := db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("Items.User.Employee").Preload("Items.Category").First(&batch, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+func (r *repository) FindPayoutBatches(ctx context.Context, filter PayoutBatchFilter) ([]ReimbursementPayoutBatch, int64, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var batches []ReimbursementPayoutBatch
+	var total int64
+
+	query := db.Model(&ReimbursementPayoutBatch{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.
+		Limit(filter.Limit).
+		Offset(offset).
+		Order("created_at DESC").
+		Find(&batches).Error
+
+	return batches, total, err
+}
+
+func (r *repository) UpdatePayoutBatch(ctx context.Context, batch *ReimbursementPayoutBatch) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	return db.Omit("Items").Save(batch).Error
+}
+
+// AssignPayoutBatch moves the claims to a transfer batch, out of reach of payroll.
+func (r *repository) AssignPayoutBatch(ctx context.Context, ids []uint, batchID uint) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	return db.Model(&Reimbursement{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"payout_batch_id": batchID,
+			"payout_channel":  constants.ReimbursementPayoutTransfer,
+			"payout_status":   constants.ReimbursementPayoutQueued,
+		}).Error
+}
+
+// ReleasePayoutBatch hands the claims of a cancelled batch back to payroll.
+func (r *repository) ReleasePayoutBatch(ctx context.Context, batchID uint) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	return db.Model(&Reimbursement{}).
+		Where("payout_batch_id = ?", batchID).
+		Updates(map[string]interface{}{
+			"payout_batch_id": nil,
+			"payout_channel":  constants.ReimbursementPayoutPayroll,
+			"payout_status":   constants.ReimbursementPayoutUnpaid,
+		}).Error
 }
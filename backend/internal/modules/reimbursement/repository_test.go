@@ -2,6 +2,9 @@ package reimbursement
 
 import (
 	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,6 +28,9 @@ func setupReimbursementTestDB(t *testing.T) *testutil.TestDB {
 		&master.Shift{},
 		&user.User{},
 		&user.Employee{},
+		&ReimbursementCategory{},
+		&ReimbursementGradeCap{},
+		&ReimbursementPayoutBatch{},
 	)
 
 	err := tdb.DB.Exec(`CREATE TABLE IF NOT EXISTS reimbursements (
@@ -35,13 +41,22 @@ func setupReimbursementTestDB(t *testing.T) *testutil.TestDB {
 		user_id INTEGER NOT NULL,
 		company_id INTEGER NOT NULL,
 		approved_by INTEGER,
+		category_id INTEGER,
 		title VARCHAR(255) NOT NULL,
 		description TEXT,
 		amount REAL NOT NULL,
 		date_of_expense DATE NOT NULL,
 		proof_file_url VARCHAR(255) NOT NULL,
 		status TEXT DEFAULT 'PENDING',
-		rejection_reason TEXT
+		rejection_reason TEXT,
+		limit_warning TEXT,
+		duplicate_warning TEXT,
+		proof_hash VARCHAR(64),
+		proof_digest VARCHAR(64),
+		payout_channel VARCHAR(10) DEFAULT 'PAYROLL',
+		payout_status VARCHAR(10) DEFAULT 'UNPAID',
+		payout_batch_id INTEGER,
+		paid_at DATETIME
 	)`).Error
 	require.NoError(t, err)
 
@@ -299,3 +314,137 @@ func TestRepo_Update_Reject(t *testing.T) {
 	assert.Equal(t, constants.ReimbursementStatusRejected, found.Status)
 	assert.Equal(t, "Not eligible", found.RejectionReason.String)
 }
+
+func TestRepo_SumClaimedByCategory(t *testing.T) {
+	tdb := setupReimbursementTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedReimbursementTestData(t, tdb)
+	require.NoError(t, repo.SeedDefaultCategories(ctx, 1))
+
+	categories, err := repo.FindCategories(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, categories, len(defaultCategories))
+	medicalID := categories[slices.IndexFunc(categories, func(c ReimbursementCategory) bool { return c.Code == "MEDICAL" })].ID
+
+	claims := []struct {
+		date   time.Time
+		amount float64
+		status constants.ReimbursementStatus
+	}{
+		{time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), 100000, constants.ReimbursementStatusApproved},
+		{time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), 50000, constants.ReimbursementStatusPending},
+		{time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), 70000, constants.ReimbursementStatusRejected},
+		{time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), 90000, constants.ReimbursementStatusApproved},
+	}
+	for i, c := range claims {
+		require.NoError(t, repo.Create(ctx, &Reimbursement{
+			CompanyID: 1, UserID: 1, CategoryID: &medicalID,
+			Title: "Medical", Amount: c.amount, DateOfExpense: c.date, Status: c.status,
+			ProofFileURL: "https://storage.example.com/receipt.jpg", ProofHash: fmt.Sprintf("%016x", i),
+			ProofDigest: fmt.Sprintf("digest-%d", i),
+		}))
+	}
+	// a pdf proof keeps its sha256 as hash, it can only match exactly
+	require.NoError(t, repo.Create(ctx, &Reimbursement{
+		CompanyID: 1, UserID: 1, CategoryID: &medicalID, Title: "Medical", Amount: 10000, DateOfExpense: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+		Status: constants.ReimbursementStatusPending, ProofFileURL: "https://storage.example.com/receipt.pdf",
+		ProofHash: strings.Repeat("a", 64), ProofDigest: strings.Repeat("a", 64),
+	}))
+
+	claimed, err := repo.SumClaimedByCategory(ctx, 1, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]float64{medicalID: 150000}, claimed)
+
+	hashes, err := repo.FindProofHashes(ctx)
+	require.NoError(t, err)
+	assert.Len(t, hashes, 3, "rejected claims and non-image proofs are left out")
+
+	id, err := repo.FindClaimIDByProof(ctx, "digest-1", "ffffffffffffffff")
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), id, "same file")
+	id, err = repo.FindClaimIDByProof(ctx, "other", fmt.Sprintf("%016x", 3))
+	require.NoError(t, err)
+	assert.Equal(t, uint(4), id, "same image hash")
+	id, err = repo.FindClaimIDByProof(ctx, "digest-2", "ffffffffffffffff")
+	require.NoError(t, err)
+	assert.Zero(t, id, "a rejected receipt may be claimed again")
+
+	// seeding again keeps the categories a company already has
+	require.NoError(t, repo.SeedDefaultCategories(ctx, 1))
+	categories, err = repo.FindCategories(ctx, false)
+	require.NoError(t, err)
+	assert.Len(t, categories, len(defaultCategories))
+}
+
+func TestRepo_PayoutFlow(t *testing.T) {
+	tdb := setupReimbursementTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedReimbursementTestData(t, tdb)
+
+	newClaim := func(status constants.ReimbursementStatus, expense time.Time) *Reimbursement {
+		r := &Reimbursement{
+			CompanyID: 1, UserID: 1, Title: "Medical", Amount: 100000, DateOfExpense: expense, Status: status,
+			ProofFileURL:  "https://storage.example.com/receipt.jpg",
+			PayoutChannel: constants.ReimbursementPayoutPayroll, PayoutStatus: constants.ReimbursementPayoutUnpaid,
+		}
+		require.NoError(t, repo.Create(ctx, r))
+		return r
+	}
+	lastYear := newClaim(constants.ReimbursementStatusApproved, time.Date(2025, 12, 20, 0, 0, 0, 0, time.UTC))
+	thisMonth := newClaim(constants.ReimbursementStatusApproved, time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC))
+	newClaim(constants.ReimbursementStatusPending, time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC))
+
+	// approved claims are payable whatever month the expense is in
+	payable, err := repo.GetBulkPayableClaims(ctx)
+	require.NoError(t, err)
+	require.Len(t, payable[1], 2)
+	assert.Equal(t, lastYear.ID, payable[1][0].ID)
+
+	// a claim moved to a transfer batch is out of reach of payroll
+	batch := &ReimbursementPayoutBatch{CompanyID: 1, Name: "Urgent", Status: constants.ReimbursementPayoutBatchDraft}
+	require.NoError(t, repo.CreatePayoutBatch(ctx, batch))
+	require.NoError(t, repo.AssignPayoutBatch(ctx, []uint{thisMonth.ID}, batch.ID))
+
+	payable, err = repo.GetBulkPayableClaims(ctx)
+	require.NoError(t, err)
+	require.Len(t, payable[1], 1)
+
+	found, err := repo.FindPayoutBatchByID(ctx, batch.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Items, 1)
+	assert.Equal(t, constants.ReimbursementPayoutTransfer, found.Items[0].PayoutChannel)
+	assert.Equal(t, "John Doe", found.Items[0].User.Employee.FullName)
+
+	// queued by a payroll, the claim is not picked up again
+	require.NoError(t, repo.QueuePayrollClaims(ctx, []uint{lastYear.ID}))
+	payable, err = repo.GetBulkPayableClaims(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, payable[1])
+
+	// a claim taken by another payroll or a transfer batch meanwhile fails the queueing
+	assert.ErrorIs(t, repo.QueuePayrollClaims(ctx, []uint{lastYear.ID}), ErrClaimsNoLongerPayable)
+	assert.ErrorIs(t, repo.QueuePayrollClaims(ctx, []uint{thisMonth.ID}), ErrClaimsNoLongerPayable)
+
+	// cancelling the batch hands its claim back to payroll
+	require.NoError(t, repo.ReleasePayoutBatch(ctx, batch.ID))
+	payable, err = repo.GetBulkPayableClaims(ctx)
+	require.NoError(t, err)
+	require.Len(t, payable[1], 1)
+	assert.Equal(t, thisMonth.ID, payable[1][0].ID)
+
+	paidAt := time.Date(2026, 10, 25, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.MarkClaimsPaid(ctx, []uint{lastYear.ID}, paidAt))
+	paid, err := repo.FindByID(ctx, lastYear.ID)
+	require.NoError(t, err)
+	assert.Equal(t, constants.ReimbursementPayoutPaid, paid.PayoutStatus)
+	require.NotNil(t, paid.PaidAt)
+
+	claims, err := repo.FindPayableByIDs(ctx, []uint{lastYear.ID, thisMonth.ID})
+	require.NoError(t, err)
+	require.Len(t, claims, 1)
+	assert.Equal(t, thisMonth.ID, claims[0].ID)
+}
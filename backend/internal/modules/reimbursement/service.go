@@ -2,12 +2,15 @@ package reimbursement
 
 import (
 	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
 	"basekarya-backend/pkg/utils"
 	"context"
 	"fmt"
+	"mime/multipart"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,19 +22,33 @@ type Service interface {
 	GetReimbursements(ctx context.Context, filter ReimbursementFilter) ([]ReimbursementListResponse, *response.Meta, error)
 	ProcessAction(ctx context.Context, req *ActionRequest) error
 	Export(ctx context.Context, filter ReimbursementFilter) ([]byte, error)
+	GetCategories(ctx context.Context, activeOnly bool) ([]ReimbursementCategory, error)
+	SaveCategory(ctx context.Context, req *SaveCategoryRequest) error
+	GetEntitlements(ctx context.Context, userID uint) ([]EntitlementResponse, error)
+	ScanReceipt(ctx context.Context, file *multipart.FileHeader) (*ReceiptScanResponse, error)
+	CreatePayoutBatch(ctx context.Context, req *CreatePayoutBatchRequest) (*PayoutBatchListResponse, error)
+	GetPayoutBatches(ctx context.Context, filter PayoutBatchFilter) ([]PayoutBatchListResponse, *response.Meta, error)
+	GetPayoutBatchDetail(ctx context.Context, id uint) (*PayoutBatchDetailResponse, error)
+	PayPayoutBatch(ctx context.Context, req *PayPayoutBatchRequest) error
+	CancelPayoutBatch(ctx context.Context, id uint) error
+	ExportPayoutBatch(ctx context.Context, id uint) ([]byte, error)
 }
 
+var timeNow = time.Now
+
 type service struct {
 	repo               Repository
 	storage            StorageProvider
 	notification       NotificationProvider
-	user               UserProvider
+	approval           ApprovalEngine
 	transactionManager infrastructure.TransactionManager
 	excel              infrastructure.ExcelProvider
+	user               UserProvider
+	ocr                OCRProvider
 }
 
-func NewService(repo Repository, storage StorageProvider, notification NotificationProvider, user UserProvider, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider) Service {
-	return &service{repo, storage, notification, user, transactionManager, excel}
+func NewService(repo Repository, storage StorageProvider, notification NotificationProvider, approval ApprovalEngine, transactionManager infrastructure.TransactionManager, excel infrastructure.ExcelProvider, user UserProvider, ocr OCRProvider) Service {
+	return &service{repo, storage, notification, approval, transactionManager, excel, user, ocr}
 }
 
 func (s *service) Create(ctx context.Context, req *ReimbursementRequest) error {
@@ -40,6 +57,60 @@ func (s *service) Create(ctx context.Context, req *ReimbursementRequest) error {
 			return fmt.Errorf("user id is invalid")
 		}
 
+		dateExpense, err := time.Parse(constants.DefaultTimeFormat, req.Date)
+		if err != nil {
+			return fmt.Errorf("invalid date format: %w", err)
+		}
+
+		category, err := s.repo.FindCategoryByID(ctx, req.CategoryID)
+		if err != nil {
+			return fmt.Errorf("category not found")
+		}
+		if !category.IsActive {
+			return fmt.Errorf("category %s is not active", category.Name)
+		}
+
+		proof, err := readProof(req.File)
+		if err != nil {
+			return err
+		}
+
+		digest, proofHash := proofDigest(proof), receiptHash(proof)
+		duplicateOf, err := s.findDuplicate(ctx, digest, proofHash)
+		if err != nil {
+			return err
+		}
+		if duplicateOf > 0 {
+			return fmt.Errorf("receipt already claimed in reimbursement #%d", duplicateOf)
+		}
+
+		// a receipt that only looks like an earlier one may be another receipt of the same shop, the approvers decide
+		similar, err := s.findSimilar(ctx, proofHash)
+		if err != nil {
+			return err
+		}
+		var duplicateWarning string
+		if len(similar) > 0 {
+			refs := make([]string, len(similar))
+			for i, id := range similar {
+				refs[i] = fmt.Sprintf("#%d", id)
+			}
+			duplicateWarning = fmt.Sprintf("Receipt looks like the one of reimbursement %s", strings.Join(refs, ", "))
+		}
+
+		breaches, err := s.checkLimits(ctx, category, req.UserID, req.Amount, dateExpense)
+		if err != nil {
+			return err
+		}
+
+		var limitWarning string
+		if len(breaches) > 0 {
+			if category.LimitAction != constants.ReimbursementLimitWarn {
+				return fmt.Errorf("claim exceeds the %s", strings.Join(breaches, ", "))
+			}
+			limitWarning = fmt.Sprintf("Exceeds the %s", strings.Join(breaches, ", "))
+		}
+
 		ext := filepath.Ext(req.File.Filename)
 		newFileName := fmt.Sprintf("%s%s", uuid.New().String(), ext)
 
@@ -51,20 +122,27 @@ func (s *service) Create(ctx context.Context, req *ReimbursementRequest) error {
 			return fmt.Errorf("failed to upload proof: %w", err)
 		}
 
-		dateExpense, err := time.Parse(constants.DefaultTimeFormat, req.Date)
-		if err != nil {
-			return fmt.Errorf("invalid date format: %w", err)
+		title := req.Title
+		if title == "" {
+			title = category.Name
 		}
 
 		reimburstment := &Reimbursement{
-			CompanyID:     utils.GetCompanyIDFromCtx(ctx),
-			UserID:        req.UserID,
-			Title:         req.Title,
-			Description:   req.Description,
-			Amount:        req.Amount,
-			DateOfExpense: dateExpense,
-			ProofFileURL:  fileURL,
-			Status:        constants.ReimbursementStatusPending,
+			CompanyID:        utils.GetCompanyIDFromCtx(ctx),
+			UserID:           req.UserID,
+			CategoryID:       &category.ID,
+			Title:            title,
+			Description:      req.Description,
+			Amount:           req.Amount,
+			DateOfExpense:    dateExpense,
+			ProofFileURL:     fileURL,
+			Status:           constants.ReimbursementStatusPending,
+			LimitWarning:     limitWarning,
+			DuplicateWarning: duplicateWarning,
+			ProofHash:        proofHash,
+			ProofDigest:      digest,
+			PayoutChannel:    constants.ReimbursementPayoutPayroll,
+			PayoutStatus:     constants.ReimbursementPayoutUnpaid,
 		}
 
 		err = s.repo.Create(ctx, reimburstment)
@@ -72,7 +150,12 @@ func (s *service) Create(ctx context.Context, req *ReimbursementRequest) error {
 			return err
 		}
 
-		approvalUserIDs, err := s.user.FindApprovalUsers(ctx, string(constants.APPROVAL_REIMBURSEMENT))
+		approvalUserIDs, err := s.approval.Submit(ctx, &approval.SubmitRequest{
+			RequestType:     constants.ApprovalRequestReimbursement,
+			ReferenceID:     reimburstment.ID,
+			RequesterUserID: req.UserID,
+			Amount:          req.Amount,
+		})
 		if err != nil {
 			return err
 		}
@@ -107,17 +190,29 @@ func (s *service) GetReimburseDetail(ctx context.Context, id uint) (*Reimburseme
 		rejectionReason = detail.RejectionReason.String
 	}
 
-	return &ReimbursementDetailResponse{
-		ID:              detail.ID,
-		Title:           detail.Title,
-		Description:     detail.Description,
-		Amount:          detail.Amount,
-		DateOfExpense:   detail.DateOfExpense,
-		ProofFileURL:    detail.ProofFileURL,
-		Status:          string(detail.Status),
-		RejectionReason: &rejectionReason,
-		RequesterName:   detail.User.Username,
-	}, nil
+	res := &ReimbursementDetailResponse{
+		ID:               detail.ID,
+		Title:            detail.Title,
+		Description:      detail.Description,
+		Amount:           detail.Amount,
+		DateOfExpense:    detail.DateOfExpense,
+		ProofFileURL:     detail.ProofFileURL,
+		Status:           string(detail.Status),
+		RejectionReason:  &rejectionReason,
+		CategoryID:       detail.CategoryID,
+		LimitWarning:     detail.LimitWarning,
+		DuplicateWarning: detail.DuplicateWarning,
+		PayoutChannel:    string(detail.PayoutChannel),
+		PayoutStatus:     string(detail.PayoutStatus),
+		PayoutBatchID:    detail.PayoutBatchID,
+		PaidAt:           detail.PaidAt,
+		RequesterName:    detail.User.Username,
+	}
+	if detail.Category != nil {
+		res.CategoryName = detail.Category.Name
+	}
+
+	return res, nil
 }
 
 func (s *service) GetReimbursements(ctx context.Context, filter ReimbursementFilter) ([]ReimbursementListResponse, *response.Meta, error) {
@@ -132,14 +227,22 @@ func (s *service) GetReimbursements(ctx context.Context, filter ReimbursementFil
 
 	var list []ReimbursementListResponse
 	for _, rem := range reimbursements {
-		list = append(list, ReimbursementListResponse{
-			ID:            rem.ID,
-			Title:         rem.Title,
-			Amount:        rem.Amount,
-			DateOfExpense: rem.DateOfExpense,
-			ProofFileURL:  rem.ProofFileURL,
-			Status:        string(rem.Status),
-		})
+		item := ReimbursementListResponse{
+			ID:               rem.ID,
+			Title:            rem.Title,
+			Amount:           rem.Amount,
+			DateOfExpense:    rem.DateOfExpense,
+			ProofFileURL:     rem.ProofFileURL,
+			Status:           string(rem.Status),
+			LimitWarning:     rem.LimitWarning,
+			DuplicateWarning: rem.DuplicateWarning,
+			PayoutChannel:    string(rem.PayoutChannel),
+			PayoutStatus:     string(rem.PayoutStatus),
+		}
+		if rem.Category != nil {
+			item.CategoryName = rem.Category.Name
+		}
+		list = append(list, item)
 	}
 
 	meta := response.NewMetaOffset(filter.Page, filter.Limit, total)
@@ -163,10 +266,42 @@ func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 			notificationTitle   string
 			notificationMessage string
 		)
+		decideReq := &approval.DecideRequest{
+			RequestType:     constants.ApprovalRequestReimbursement,
+			ReferenceID:     data.ID,
+			RequesterUserID: data.UserID,
+			Amount:          data.Amount,
+			ActorUserID:     req.SuperAdminID,
+		}
+
 		switch constants.ReimbursementAction(req.Action) {
 		case constants.ReimbursementActionApprove:
+			decideReq.Action = constants.ApprovalActionApprove
+			decision, err := s.approval.Decide(ctx, decideReq)
+			if err != nil {
+				return err
+			}
+
+			// the reimbursement stays pending until the last level of the approval chain approves it
+			if decision.Status == constants.ApprovalStatusPending {
+				go func() {
+					_ = s.notification.BlastNotification(
+						utils.DetachContext(ctx),
+						decision.NextApproverIDs,
+						string(constants.NotificationTypeReimburseApprovalReq),
+						"Persetujuan Reimbursement Lanjutan",
+						fmt.Sprintf("Reimburse karyawan sebesar Rp.%2.f menunggu persetujuan Anda", data.Amount),
+						data.ID,
+					)
+				}()
+				return nil
+			}
+
 			data.Status = constants.ReimbursementStatusApproved
 			data.ApprovedBy = &req.SuperAdminID
+			if req.PayoutChannel != "" {
+				data.PayoutChannel = constants.ReimbursementPayoutChannel(req.PayoutChannel)
+			}
 
 			notificationType = constants.NotificationTypeApproved
 			notificationTitle = "Permintaan Disetujui"
@@ -179,6 +314,12 @@ func (s *service) ProcessAction(ctx context.Context, req *ActionRequest) error {
 				return fmt.Errorf("rejection reason is required")
 			}
 
+			decideReq.Action = constants.ApprovalActionReject
+			decideReq.Notes = req.RejectionReason
+			if _, err := s.approval.Decide(ctx, decideReq); err != nil {
+				return err
+			}
+
 			data.RejectionReason.String = req.RejectionReason
 			data.RejectionReason.Valid = true
 
@@ -220,7 +361,7 @@ func (s *service) Export(ctx context.Context, filter ReimbursementFilter) ([]byt
 	}
 
 	headers := []string{
-		"ID", "Karyawan", "Judul", "Nominal", "Tanggal Bon", "Status", "Alasan", "Dibuat Pada",
+		"ID", "Karyawan", "Kategori", "Judul", "Nominal", "Tanggal Bon", "Status", "Pembayaran", "Alasan", "Dibuat Pada",
 	}
 
 	var rows [][]interface{}
@@ -230,13 +371,20 @@ func (s *service) Export(ctx context.Context, filter ReimbursementFilter) ([]byt
 			empName = rem.User.Username
 		}
 
+		categoryName := "-"
+		if rem.Category != nil {
+			categoryName = rem.Category.Name
+		}
+
 		row := []interface{}{
 			rem.ID,
 			empName,
+			categoryName,
 			rem.Title,
 			rem.Amount,
 			rem.DateOfExpense.Format("2006-01-02"),
 			rem.Status,
+			rem.PayoutStatus,
 			rem.Description,
 			rem.CreatedAt.Format("2006-01-02 15:04:05"),
 		}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"basekarya-backend/internal/modules/approval"
 	"basekarya-backend/internal/modules/user"
 	"basekarya-backend/internal/testutil"
 	"basekarya-backend/pkg/constants"
@@ -15,16 +16,18 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func newTestReimbursementService() (Service, *mockRepo, *mockStorage, *mockNotification, *mockUserProvider, *testutil.MockTransactionManager, *mockExcel) {
+func newTestReimbursementService() (Service, *mockRepo, *mockStorage, *mockNotification, *mockApprovalEngine, *testutil.MockTransactionManager, *mockExcel, *mockUserProvider, *mockOCR) {
 	repo := new(mockRepo)
 	storage := new(mockStorage)
 	notif := new(mockNotification)
-	userProv := new(mockUserProvider)
+	approvalEngine := new(mockApprovalEngine)
 	tm := testutil.NewMockTransactionManager()
 	excel := new(mockExcel)
+	userProv := new(mockUserProvider)
+	ocr := new(mockOCR)
 
-	svc := NewService(repo, storage, notif, userProv, tm, excel)
-	return svc, repo, storage, notif, userProv, tm, excel
+	svc := NewService(repo, storage, notif, approvalEngine, tm, excel, userProv, ocr)
+	return svc, repo, storage, notif, approvalEngine, tm, excel, userProv, ocr
 }
 
 func TestService_Create(t *testing.T) {
@@ -34,7 +37,7 @@ func TestService_Create(t *testing.T) {
 	tests := []struct {
 		name       string
 		req        *ReimbursementRequest
-		setupMocks func(*mockRepo, *mockStorage, *mockNotification, *mockUserProvider)
+		setupMocks func(*mockRepo, *mockStorage, *mockNotification, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -48,10 +51,12 @@ func TestService_Create(t *testing.T) {
 				Date:        "2026-01-15",
 				File:        fileHeader,
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				storage.On("UploadFileMultipart", mock.Anything, mock.Anything, mock.Anything).Return("https://storage.example.com/file.jpg", nil)
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*reimbursement.Reimbursement")).Return(nil)
-				userProv.On("FindApprovalUsers", mock.Anything, string(constants.APPROVAL_REIMBURSEMENT)).Return([]uint{10, 11}, nil)
+				approvalEngine.On("Submit", mock.Anything, mock.MatchedBy(func(req *approval.SubmitRequest) bool {
+					return req.RequestType == constants.ApprovalRequestReimbursement && req.Amount == 50000
+				})).Return([]uint{10, 11}, nil)
 				notif.On("BlastNotification", mock.Anything, []uint{10, 11}, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
@@ -65,9 +70,10 @@ func TestService_Create(t *testing.T) {
 				Date:   "2026-01-15",
 				File:   fileHeader,
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {},
-			wantErr:    true,
-			errMsg:     "user id is invalid",
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
+			},
+			wantErr: true,
+			errMsg:  "user id is invalid",
 		},
 		{
 			name: "error upload fails",
@@ -78,7 +84,7 @@ func TestService_Create(t *testing.T) {
 				Date:   "2026-01-15",
 				File:   fileHeader,
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				storage.On("UploadFileMultipart", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("upload failed"))
 			},
 			wantErr: true,
@@ -92,7 +98,7 @@ func TestService_Create(t *testing.T) {
 				Date:   "not-a-date",
 				File:   fileHeader,
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				storage.On("UploadFileMultipart", mock.Anything, mock.Anything, mock.Anything).Return("https://storage.example.com/file.jpg", nil)
 			},
 			wantErr: true,
@@ -107,7 +113,7 @@ func TestService_Create(t *testing.T) {
 				Date:   "2026-01-15",
 				File:   fileHeader,
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				storage.On("UploadFileMultipart", mock.Anything, mock.Anything, mock.Anything).Return("https://storage.example.com/file.jpg", nil)
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*reimbursement.Reimbursement")).Return(errors.New("db error"))
 			},
@@ -123,10 +129,10 @@ func TestService_Create(t *testing.T) {
 				Date:   "2026-01-15",
 				File:   fileHeader,
 			},
-			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, userProv *mockUserProvider) {
+			setupMocks: func(repo *mockRepo, storage *mockStorage, notif *mockNotification, approvalEngine *mockApprovalEngine) {
 				storage.On("UploadFileMultipart", mock.Anything, mock.Anything, mock.Anything).Return("https://storage.example.com/file.jpg", nil)
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*reimbursement.Reimbursement")).Return(nil)
-				userProv.On("FindApprovalUsers", mock.Anything, string(constants.APPROVAL_REIMBURSEMENT)).Return([]uint(nil), errors.New("db error"))
+				approvalEngine.On("Submit", mock.Anything, mock.Anything).Return([]uint(nil), errors.New("db error"))
 			},
 			wantErr: true,
 			errMsg:  "db error",
@@ -135,8 +141,12 @@ func TestService_Create(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, storage, notif, userProv, _, _ := newTestReimbursementService()
-			tt.setupMocks(repo, storage, notif, userProv)
+			svc, repo, storage, notif, approvalEngine, _, _, userProv, _ := newTestReimbursementService()
+			repo.On("FindCategoryByID", mock.Anything, mock.Anything).Return(&ReimbursementCategory{ID: 1, Name: "Medical", IsActive: true}, nil).Maybe()
+			repo.On("FindClaimIDByProof", mock.Anything, mock.Anything, mock.Anything).Return(uint(0), nil).Maybe()
+			repo.On("FindProofHashes", mock.Anything).Return([]Reimbursement{}, nil).Maybe()
+			userProv.On("FindByID", mock.Anything, uint(1)).Return(&user.User{ID: 1}, nil).Maybe()
+			tt.setupMocks(repo, storage, notif, approvalEngine)
 
 			err := svc.Create(ctx, tt.req)
 
@@ -208,7 +218,7 @@ func TestService_GetReimburseDetail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, _ := newTestReimbursementService()
+			svc, repo, _, _, _, _, _, _, _ := newTestReimbursementService()
 			tt.setupMocks(repo)
 
 			resp, err := svc.GetReimburseDetail(ctx, tt.id)
@@ -280,7 +290,7 @@ func TestService_GetReimbursements(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, _ := newTestReimbursementService()
+			svc, repo, _, _, _, _, _, _, _ := newTestReimbursementService()
 			tt.setupMocks(repo)
 
 			list, meta, err := svc.GetReimbursements(ctx, tt.filter)
@@ -300,7 +310,7 @@ func TestService_ProcessAction(t *testing.T) {
 	tests := []struct {
 		name       string
 		req        *ActionRequest
-		setupMocks func(*mockRepo)
+		setupMocks func(*mockRepo, *mockApprovalEngine)
 		wantErr    bool
 		errMsg     string
 	}{
@@ -311,12 +321,13 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.ReimbursementActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(1)).Return(&Reimbursement{
 					ID:     1,
 					Status: constants.ReimbursementStatusPending,
 					User:   user.User{ID: 1},
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.AnythingOfType("*approval.DecideRequest")).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
 				repo.On("Update", mock.Anything, mock.AnythingOfType("*reimbursement.Reimbursement")).Return(nil)
 			},
 			wantErr: false,
@@ -329,12 +340,15 @@ func TestService_ProcessAction(t *testing.T) {
 				Action:          string(constants.ReimbursementActionReject),
 				RejectionReason: "Not eligible",
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(2)).Return(&Reimbursement{
 					ID:     2,
 					Status: constants.ReimbursementStatusPending,
 					User:   user.User{ID: 1},
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.MatchedBy(func(req *approval.DecideRequest) bool {
+					return req.Action == constants.ApprovalActionReject && req.Notes == "Not eligible"
+				})).Return(&approval.Decision{Status: constants.ApprovalStatusRejected}, nil)
 				repo.On("Update", mock.Anything, mock.AnythingOfType("*reimbursement.Reimbursement")).Return(nil)
 			},
 			wantErr: false,
@@ -346,7 +360,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.ReimbursementActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(3)).Return(&Reimbursement{
 					ID:     3,
 					Status: constants.ReimbursementStatusApproved,
@@ -364,7 +378,7 @@ func TestService_ProcessAction(t *testing.T) {
 				Action:          string(constants.ReimbursementActionReject),
 				RejectionReason: "",
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(4)).Return(&Reimbursement{
 					ID:     4,
 					Status: constants.ReimbursementStatusPending,
@@ -381,7 +395,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.ReimbursementActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(99)).Return(nil, errors.New("not found"))
 			},
 			wantErr: true,
@@ -394,7 +408,7 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       "INVALID",
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(5)).Return(&Reimbursement{
 					ID:     5,
 					Status: constants.ReimbursementStatusPending,
@@ -411,27 +425,65 @@ func TestService_ProcessAction(t *testing.T) {
 				SuperAdminID: 10,
 				Action:       string(constants.ReimbursementActionApprove),
 			},
-			setupMocks: func(repo *mockRepo) {
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
 				repo.On("FindByID", mock.Anything, uint(6)).Return(&Reimbursement{
 					ID:     6,
 					Status: constants.ReimbursementStatusPending,
 					User:   user.User{ID: 1},
 				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(&approval.Decision{Status: constants.ApprovalStatusApproved}, nil)
 				repo.On("Update", mock.Anything, mock.AnythingOfType("*reimbursement.Reimbursement")).Return(errors.New("db error"))
 			},
 			wantErr: true,
 			errMsg:  "db error",
 		},
+		{
+			name: "approve waits for the next approval level",
+			req: &ActionRequest{
+				ID:           7,
+				SuperAdminID: 10,
+				Action:       string(constants.ReimbursementActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
+				repo.On("FindByID", mock.Anything, uint(7)).Return(&Reimbursement{
+					ID:     7,
+					Status: constants.ReimbursementStatusPending,
+					User:   user.User{ID: 1},
+				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(&approval.Decision{
+					Status:          constants.ApprovalStatusPending,
+					NextApproverIDs: []uint{20},
+				}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "error actor is not an approver",
+			req: &ActionRequest{
+				ID:           8,
+				SuperAdminID: 10,
+				Action:       string(constants.ReimbursementActionApprove),
+			},
+			setupMocks: func(repo *mockRepo, approvalEngine *mockApprovalEngine) {
+				repo.On("FindByID", mock.Anything, uint(8)).Return(&Reimbursement{
+					ID:     8,
+					Status: constants.ReimbursementStatusPending,
+					User:   user.User{ID: 1},
+				}, nil)
+				approvalEngine.On("Decide", mock.Anything, mock.Anything).Return(nil, errors.New("you are not an approver of the current approval step"))
+			},
+			wantErr: true,
+			errMsg:  "you are not an approver of the current approval step",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, notif, _, _, _ := newTestReimbursementService()
-			tt.setupMocks(repo)
+			svc, repo, _, notif, approvalEngine, _, _, _, _ := newTestReimbursementService()
+			tt.setupMocks(repo, approvalEngine)
 
-			if !tt.wantErr {
-				notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
-			}
+			notif.On("SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+			notif.On("BlastNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 
 			err := svc.ProcessAction(ctx, tt.req)
 
@@ -489,7 +541,7 @@ func TestService_Export(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, excel := newTestReimbursementService()
+			svc, repo, _, _, _, _, excel, _, _ := newTestReimbursementService()
 			tt.setupMocks(repo, excel)
 
 			data, err := svc.Export(ctx, tt.filter)
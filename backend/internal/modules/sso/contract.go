@@ -0,0 +1,32 @@
+package sso
+
+import (
+	"basekarya-backend/internal/modules/auth"
+	"basekarya-backend/internal/modules/user"
+	"context"
+	"time"
+)
+
+type CacheProvider interface {
+	Set(ctx context.Context, key string, value any, expiration time.Duration) error
+	GetDel(ctx context.Context, key string) (string, error)
+}
+
+// LoginIssuer finishes a login the identity provider has authenticated, with the same session and MFA rules as a
+// password login.
+type LoginIssuer interface {
+	CompleteExternalLogin(ctx context.Context, userID uint, userAgent, ipAddress string) (*auth.LoginResponse, error)
+}
+
+type UserProvider interface {
+	FindEmployeeByCompanyEmail(ctx context.Context, companyID uint, email string) (*user.Employee, error)
+}
+
+type EmployeeCreator interface {
+	CreateEmployee(ctx context.Context, req *user.CreateEmployeeRequest) (*user.CreateEmployeeResponse, error)
+}
+
+// DNSResolver reads the TXT records a company publishes to prove it controls its SSO domain.
+type DNSResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
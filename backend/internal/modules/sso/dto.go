@@ -0,0 +1,60 @@
+package sso
+
+type SaveIdentityProviderRequest struct {
+	Protocol string `json:"protocol" validate:"required,oneof=OIDC SAML"`
+	Domain   string `json:"domain" validate:"required,fqdn"`
+	Enabled  bool   `json:"enabled"`
+	SSOOnly  bool   `json:"sso_only"`
+
+	IssuerURL string `json:"issuer_url" validate:"omitempty,url"`
+	ClientID  string `json:"client_id" validate:"max=255"`
+	// left empty on update to keep the stored secret
+	ClientSecret string `json:"client_secret"`
+
+	MetadataXML string `json:"metadata_xml"`
+
+	JITProvisioning     bool  `json:"jit_provisioning"`
+	DefaultRoleID       *uint `json:"default_role_id"`
+	DefaultDepartmentID *uint `json:"default_department_id"`
+	DefaultShiftID      *uint `json:"default_shift_id"`
+}
+
+// IdentityProviderResponse carries the addresses the IdP administrator registers for this application.
+type IdentityProviderResponse struct {
+	IdentityProvider
+	HasClientSecret bool   `json:"has_client_secret"`
+	RedirectURI     string `json:"redirect_uri"`
+	SPEntityID      string `json:"sp_entity_id"`
+	ACSURL          string `json:"acs_url"`
+	// the TXT record the company publishes on its domain before the provider takes effect
+	DomainVerified     bool   `json:"domain_verified"`
+	VerificationRecord string `json:"verification_record"`
+	VerificationValue  string `json:"verification_value"`
+}
+
+type StartSSORequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type StartSSOResponse struct {
+	Protocol    string `json:"protocol"`
+	RedirectURL string `json:"redirect_url"`
+}
+
+type OIDCCallbackRequest struct {
+	State            string
+	Code             string
+	Error            string
+	ErrorDescription string
+}
+
+type SAMLResponseRequest struct {
+	SAMLResponse string
+	RelayState   string
+}
+
+type ExchangeTicketRequest struct {
+	Ticket    string `json:"ticket" validate:"required"`
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
@@ -0,0 +1,47 @@
+package sso
+
+import (
+	"basekarya-backend/pkg/constants"
+	"time"
+)
+
+// IdentityProvider is the single sign-on configuration of a company, users whose email is on Domain sign in
+// through it.
+type IdentityProvider struct {
+	ID        uint                  `gorm:"primaryKey" json:"id"`
+	CompanyID uint                  `gorm:"uniqueIndex;not null" json:"company_id"`
+	Protocol  constants.SSOProtocol `gorm:"type:varchar(10);not null" json:"protocol"`
+	Domain    string                `gorm:"type:varchar(255);uniqueIndex;not null" json:"domain"`
+	// published by the company in a TXT record of Domain, Enabled has no effect until the record is found
+	VerificationToken string     `gorm:"type:varchar(64)" json:"-"`
+	DomainVerifiedAt  *time.Time `json:"domain_verified_at"`
+	Enabled           bool       `gorm:"default:false" json:"enabled"`
+	// password logins are refused for the company's users, platform admins excepted
+	SSOOnly bool `gorm:"column:sso_only;default:false" json:"sso_only"`
+
+	// OIDC, endpoints and signing keys are read from the issuer's discovery document
+	IssuerURL    string `gorm:"type:varchar(500)" json:"issuer_url"`
+	ClientID     string `gorm:"type:varchar(255)" json:"client_id"`
+	ClientSecret string `gorm:"type:text" json:"-"`
+
+	// SAML, the IdP metadata carries its entity ID, sign-on URL and signing certificate
+	MetadataXML string `gorm:"column:metadata_xml;type:mediumtext" json:"metadata_xml"`
+
+	// unknown emails on the domain get an employee account with these defaults instead of being refused
+	JITProvisioning     bool  `gorm:"column:jit_provisioning;default:false" json:"jit_provisioning"`
+	DefaultRoleID       *uint `json:"default_role_id"`
+	DefaultDepartmentID *uint `json:"default_department_id"`
+	DefaultShiftID      *uint `json:"default_shift_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (IdentityProvider) TableName() string {
+	return "identity_providers"
+}
+
+// IsActive reports whether users of the domain sign in through this provider.
+func (p *IdentityProvider) IsActive() bool {
+	return p.Enabled && p.DomainVerifiedAt != nil
+}
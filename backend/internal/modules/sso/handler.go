@@ -0,0 +1,168 @@
+package sso
+
+import (
+	"basekarya-backend/pkg/logger"
+	"basekarya-backend/pkg/response"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{s}
+}
+
+func (h *Handler) GetProvider(ctx echo.Context) error {
+	provider, err := h.service.GetProvider(ctx.Request().Context())
+	if err != nil {
+		logger.Errorw("Get identity provider failed : ", err)
+
+		if errors.Is(err, ErrSSONotConfigured) {
+			return response.NewResponses[any](ctx, http.StatusNotFound, err.Error(), nil, err, nil)
+		}
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "failed to get identity provider", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Success get identity provider", provider, nil, nil)
+}
+
+func (h *Handler) SaveProvider(ctx echo.Context) error {
+	var req SaveIdentityProviderRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	provider, err := h.service.SaveProvider(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("Save identity provider failed : ", err)
+
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Save identity provider successfully", provider, nil, nil)
+}
+
+func (h *Handler) DeleteProvider(ctx echo.Context) error {
+	if err := h.service.DeleteProvider(ctx.Request().Context()); err != nil {
+		logger.Errorw("Delete identity provider failed : ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "failed to delete identity provider", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Delete identity provider successfully", nil, nil, nil)
+}
+
+func (h *Handler) VerifyDomain(ctx echo.Context) error {
+	provider, err := h.service.VerifyDomain(ctx.Request().Context())
+	if err != nil {
+		logger.Errorw("Verify SSO domain failed : ", err)
+
+		if errors.Is(err, ErrSSONotConfigured) {
+			return response.NewResponses[any](ctx, http.StatusNotFound, err.Error(), nil, err, nil)
+		}
+		if errors.Is(err, ErrDomainNotVerified) {
+			return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+		}
+		return response.NewResponses[any](ctx, http.StatusBadGateway, "failed to look up the verification record", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Domain verified successfully", provider, nil, nil)
+}
+
+func (h *Handler) Start(ctx echo.Context) error {
+	var req StartSSORequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	resp, err := h.service.Start(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("Start SSO failed : ", err)
+
+		if errors.Is(err, ErrSSONotConfigured) {
+			return response.NewResponses[any](ctx, http.StatusNotFound, err.Error(), nil, err, nil)
+		}
+		return response.NewResponses[any](ctx, http.StatusBadGateway, "failed to reach the identity provider", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Start SSO Success", resp, nil, nil)
+}
+
+// OIDCCallback is where the identity provider sends the browser back, the browser is redirected on to the frontend.
+func (h *Handler) OIDCCallback(ctx echo.Context) error {
+	redirectURL, err := h.service.HandleOIDCCallback(ctx.Request().Context(), &OIDCCallbackRequest{
+		State:            ctx.QueryParam("state"),
+		Code:             ctx.QueryParam("code"),
+		Error:            ctx.QueryParam("error"),
+		ErrorDescription: ctx.QueryParam("error_description"),
+	})
+	if err != nil {
+		logger.Errorw("OIDC callback failed : ", err)
+	}
+
+	return ctx.Redirect(http.StatusFound, redirectURL)
+}
+
+// SAMLACS receives the SAML response the identity provider posts through the browser.
+func (h *Handler) SAMLACS(ctx echo.Context) error {
+	redirectURL, err := h.service.HandleSAMLResponse(ctx.Request().Context(), &SAMLResponseRequest{
+		SAMLResponse: ctx.FormValue("SAMLResponse"),
+		RelayState:   ctx.FormValue("RelayState"),
+	})
+	if err != nil {
+		logger.Errorw("SAML ACS failed : ", err)
+	}
+
+	// 303 turns the POST into a GET on the frontend
+	return ctx.Redirect(http.StatusSeeOther, redirectURL)
+}
+
+func (h *Handler) SAMLMetadata(ctx echo.Context) error {
+	metadata, err := h.service.ServiceProviderMetadata()
+	if err != nil {
+		logger.Errorw("SAML metadata failed : ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "failed to build SAML metadata", nil, err, nil)
+	}
+
+	return ctx.Blob(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+func (h *Handler) Exchange(ctx echo.Context) error {
+	var req ExchangeTicketRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	req.UserAgent = ctx.Request().UserAgent()
+	req.IPAddress = ctx.RealIP()
+
+	resp, err := h.service.Exchange(ctx.Request().Context(), &req)
+	if err != nil {
+		logger.Errorw("SSO exchange failed : ", err)
+
+		if errors.Is(err, ErrInvalidSSOTicket) {
+			return response.NewResponses[any](ctx, http.StatusUnauthorized, err.Error(), nil, err, nil)
+		}
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "login failed", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Login Success", resp, nil, nil)
+}
@@ -0,0 +1,112 @@
+package sso
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"basekarya-backend/internal/modules/auth"
+	"basekarya-backend/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Start(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: StartSSORequest{Email: "jane@acme.com"},
+			setupMocks: func(svc *mockService) {
+				svc.On("Start", mock.Anything, &StartSSORequest{Email: "jane@acme.com"}).
+					Return(&StartSSOResponse{Protocol: "OIDC", RedirectURL: "https://idp/authorize"}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "domain without a provider",
+			body: StartSSORequest{Email: "jane@elsewhere.com"},
+			setupMocks: func(svc *mockService) {
+				svc.On("Start", mock.Anything, mock.Anything).Return(nil, ErrSSONotConfigured)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid email",
+			body:       StartSSORequest{Email: "jane"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/v1/auth/sso/start", tt.body)
+			rec, err := at.Execute(handler.Start)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_OIDCCallback(t *testing.T) {
+	svc := new(mockService)
+	svc.On("HandleOIDCCallback", mock.Anything, &OIDCCallbackRequest{State: "abc", Code: "xyz"}).
+		Return("http://localhost:5173/sso/callback?error=failed", errors.New("invalid ID token"))
+	handler := NewHandler(svc)
+
+	at := testutil.NewAPITest(t, http.MethodGet, "/api/v1/auth/sso/oidc/callback?state=abc&code=xyz", nil)
+	rec, err := at.Execute(handler.OIDCCallback)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "http://localhost:5173/sso/callback?error=failed", rec.Header().Get("Location"))
+}
+
+func TestHandler_Exchange(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			setupMocks: func(svc *mockService) {
+				svc.On("Exchange", mock.Anything, mock.Anything).Return(&auth.LoginResponse{Token: "jwt-token"}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "spent ticket",
+			setupMocks: func(svc *mockService) {
+				svc.On("Exchange", mock.Anything, mock.Anything).Return(nil, ErrInvalidSSOTicket)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodPost, "/api/v1/auth/sso/exchange", ExchangeTicketRequest{Ticket: "ticket"})
+			rec, err := at.Execute(handler.Exchange)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
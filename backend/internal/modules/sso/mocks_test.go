@@ -0,0 +1,113 @@
+package sso
+
+import (
+	"basekarya-backend/internal/modules/auth"
+	"basekarya-backend/internal/modules/user"
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockLoginIssuer struct{ mock.Mock }
+
+func (m *mockLoginIssuer) CompleteExternalLogin(ctx context.Context, userID uint, userAgent, ipAddress string) (*auth.LoginResponse, error) {
+	args := m.Called(ctx, userID, userAgent, ipAddress)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.LoginResponse), args.Error(1)
+}
+
+type mockUserProvider struct{ mock.Mock }
+
+func (m *mockUserProvider) FindEmployeeByCompanyEmail(ctx context.Context, companyID uint, email string) (*user.Employee, error) {
+	args := m.Called(ctx, companyID, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Employee), args.Error(1)
+}
+
+type mockEmployeeCreator struct{ mock.Mock }
+
+func (m *mockEmployeeCreator) CreateEmployee(ctx context.Context, req *user.CreateEmployeeRequest) (*user.CreateEmployeeResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.CreateEmployeeResponse), args.Error(1)
+}
+
+type mockService struct{ mock.Mock }
+
+func (m *mockService) GetProvider(ctx context.Context) (*IdentityProviderResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*IdentityProviderResponse), args.Error(1)
+}
+
+func (m *mockService) SaveProvider(ctx context.Context, req *SaveIdentityProviderRequest) (*IdentityProviderResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*IdentityProviderResponse), args.Error(1)
+}
+
+func (m *mockService) DeleteProvider(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockService) VerifyDomain(ctx context.Context) (*IdentityProviderResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*IdentityProviderResponse), args.Error(1)
+}
+
+func (m *mockService) Start(ctx context.Context, req *StartSSORequest) (*StartSSOResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*StartSSOResponse), args.Error(1)
+}
+
+func (m *mockService) HandleOIDCCallback(ctx context.Context, req *OIDCCallbackRequest) (string, error) {
+	args := m.Called(ctx, req)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockService) HandleSAMLResponse(ctx context.Context, req *SAMLResponseRequest) (string, error) {
+	args := m.Called(ctx, req)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockService) Exchange(ctx context.Context, req *ExchangeTicketRequest) (*auth.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.LoginResponse), args.Error(1)
+}
+
+func (m *mockService) ServiceProviderMetadata() ([]byte, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+type mockDNSResolver struct{ mock.Mock }
+
+func (m *mockDNSResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
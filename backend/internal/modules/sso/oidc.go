@@ -0,0 +1,301 @@
+package sso
+
+import (
+	"basekarya-backend/pkg/constants"
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// oidcDiscovery is the part of an issuer's /.well-known/openid-configuration the login needs.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcTokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type idTokenClaims struct {
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// discoverOIDC reads the issuer's discovery document, the issuer it names must be the configured one.
+func (s *service) discoverOIDC(ctx context.Context, issuerURL string) (*oidcDiscovery, error) {
+	issuer := strings.TrimRight(issuerURL, "/")
+
+	var discovery oidcDiscovery
+	if err := s.getJSON(ctx, issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to read OIDC discovery document: %w", err)
+	}
+
+	if strings.TrimRight(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery document names issuer %s, expected %s", discovery.Issuer, issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing the authorization, token or jwks endpoint")
+	}
+	for _, endpoint := range []string{discovery.AuthorizationEndpoint, discovery.TokenEndpoint, discovery.JWKSURI} {
+		if err := requireHTTPS(endpoint); err != nil {
+			return nil, err
+		}
+	}
+	return &discovery, nil
+}
+
+// oidcAuthURL builds the authorization code request, PKCE binds the code to this login's verifier.
+func oidcAuthURL(discovery *oidcDiscovery, clientID, redirectURI string, state *ssoState) string {
+	challenge := sha256.Sum256([]byte(state.CodeVerifier))
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", clientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", "openid email profile")
+	params.Set("state", state.ID)
+	params.Set("nonce", state.Nonce)
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// exchangeOIDCCode trades the authorization code for the ID token at the token endpoint.
+func (s *service) exchangeOIDCCode(ctx context.Context, discovery *oidcDiscovery, provider *IdentityProvider, code, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", s.oidcRedirectURI())
+	form.Set("client_id", provider.ClientID)
+	form.Set("client_secret", provider.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	if err := requireHTTPS(discovery.TokenEndpoint); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token oidcTokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || token.IDToken == "" {
+		if token.Error != "" {
+			return "", fmt.Errorf("token endpoint refused the code: %s %s", token.Error, token.ErrorDescription)
+		}
+		return "", fmt.Errorf("token endpoint answered %d without an ID token", resp.StatusCode)
+	}
+	return token.IDToken, nil
+}
+
+// verifyIDToken checks the ID token signature against the issuer's keys, then its issuer, audience, expiry and
+// the nonce of this login.
+func (s *service) verifyIDToken(ctx context.Context, discovery *oidcDiscovery, provider *IdentityProvider, rawToken, nonce string) (*ssoIdentity, error) {
+	keys, err := s.fetchJWKS(ctx, discovery.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		// a key set with a single key may leave kid out
+		if kid == "" && len(keys) == 1 {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(provider.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(constants.SSOClockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("invalid ID token: nonce does not match the login")
+	}
+
+	// preferred_username is not a verified address at most IdPs, only the email claim maps to an employee
+	email := claims.Email
+	if email == "" {
+		return nil, errors.New("ID token carries no email")
+	}
+	if verified, ok := claims.EmailVerified.(bool); ok && !verified {
+		return nil, errors.New("email is not verified by the identity provider")
+	}
+	if verified, ok := claims.EmailVerified.(string); ok && verified != "true" {
+		return nil, errors.New("email is not verified by the identity provider")
+	}
+
+	return &ssoIdentity{Email: email, Name: claims.Name}, nil
+}
+
+// fetchJWKS returns the RSA signing keys of the issuer by key ID.
+func (s *service) fetchJWKS(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := s.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to read signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			continue
+		}
+		keys[key.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("issuer publishes no RSA signing keys")
+	}
+	return keys, nil
+}
+
+func (s *service) getJSON(ctx context.Context, endpoint string, out any) error {
+	if err := requireHTTPS(endpoint); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// allowAddress decides which addresses the IdP endpoints may resolve to, tests let the loopback IdP through.
+var allowAddress = isPublicAddress
+
+// publicHTTPSClient keeps the admin supplied issuer from reaching into the internal network: every connection,
+// redirects included, is checked against the address it actually dials, so a DNS answer changed after the URL
+// was checked does not get through.
+func publicHTTPSClient(client *http.Client) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if base, ok := client.Transport.(*http.Transport); ok {
+		transport = base.Clone()
+	}
+	// a proxy would be dialed instead of the IdP and hide its address
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowAddress(ip) {
+				return fmt.Errorf("refusing to connect to non-public address %s", host)
+			}
+			return nil
+		},
+	}).DialContext
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   client.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return requireHTTPS(req.URL.String())
+		},
+	}
+}
+
+func isPublicAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	// carrier-grade NAT is as internal as the private ranges
+	_, sharedSpace, _ := net.ParseCIDR("100.64.0.0/10")
+	return !sharedSpace.Contains(ip)
+}
+
+func requireHTTPS(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("identity provider endpoint %s must use https", endpoint)
+	}
+	return nil
+}
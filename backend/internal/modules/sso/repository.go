@@ -0,0 +1,62 @@
+package sso
+
+import (
+	"basekarya-backend/pkg/utils"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	FindByID(ctx context.Context, id uint) (*IdentityProvider, error)
+	FindByCompanyID(ctx context.Context, companyID uint) (*IdentityProvider, error)
+	FindByDomain(ctx context.Context, domain string) (*IdentityProvider, error)
+	Save(ctx context.Context, provider *IdentityProvider) error
+	DeleteByCompanyID(ctx context.Context, companyID uint) error
+	IsSSOEnforced(ctx context.Context, companyID uint) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) FindByID(ctx context.Context, id uint) (*IdentityProvider, error) {
+	var provider IdentityProvider
+	err := utils.GetDBFromContext(ctx, r.db).First(&provider, id).Error
+	return &provider, err
+}
+
+func (r *repository) FindByCompanyID(ctx context.Context, companyID uint) (*IdentityProvider, error) {
+	var provider IdentityProvider
+	err := utils.GetDBFromContext(ctx, r.db).Where("company_id = ?", companyID).First(&provider).Error
+	return &provider, err
+}
+
+// FindByDomain resolves the provider of an email domain, the login flow starts here before any company is known.
+func (r *repository) FindByDomain(ctx context.Context, domain string) (*IdentityProvider, error) {
+	var provider IdentityProvider
+	err := utils.GetDBFromContext(ctx, r.db).Where("domain = ?", domain).First(&provider).Error
+	return &provider, err
+}
+
+func (r *repository) Save(ctx context.Context, provider *IdentityProvider) error {
+	return utils.GetDBFromContext(ctx, r.db).Save(provider).Error
+}
+
+func (r *repository) DeleteByCompanyID(ctx context.Context, companyID uint) error {
+	return utils.GetDBFromContext(ctx, r.db).Where("company_id = ?", companyID).Delete(&IdentityProvider{}).Error
+}
+
+// IsSSOEnforced reports whether the company has an enabled provider on a verified domain that replaces password
+// logins.
+func (r *repository) IsSSOEnforced(ctx context.Context, companyID uint) (bool, error) {
+	var count int64
+	err := utils.GetDBFromContext(ctx, r.db).Model(&IdentityProvider{}).
+		Where("company_id = ? AND enabled = ? AND sso_only = ? AND domain_verified_at IS NOT NULL", companyID, true, true).
+		Count(&count).Error
+	return count > 0, err
+}
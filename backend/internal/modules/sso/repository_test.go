@@ -0,0 +1,71 @@
+package sso
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSSOTestDB(t *testing.T) *testutil.TestDB {
+	t.Helper()
+	tdb := testutil.NewTestDB(&IdentityProvider{})
+	t.Cleanup(tdb.Close)
+	return tdb
+}
+
+func TestRepo_IdentityProvider(t *testing.T) {
+	tdb := setupSSOTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := context.Background()
+
+	provider := &IdentityProvider{
+		CompanyID: 1, Protocol: constants.SSOProtocolOIDC, Domain: "acme.com",
+		IssuerURL: "https://idp.acme.com", ClientID: "client", ClientSecret: "secret", Enabled: true,
+	}
+	require.NoError(t, repo.Save(ctx, provider))
+
+	byDomain, err := repo.FindByDomain(ctx, "acme.com")
+	require.NoError(t, err)
+	assert.Equal(t, provider.ID, byDomain.ID)
+
+	byCompany, err := repo.FindByCompanyID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", byCompany.ClientSecret)
+
+	enforced, err := repo.IsSSOEnforced(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, enforced)
+
+	byCompany.SSOOnly = true
+	require.NoError(t, repo.Save(ctx, byCompany))
+
+	// not before the domain is verified
+	enforced, err = repo.IsSSOEnforced(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, enforced)
+
+	verifiedAt := time.Now()
+	byCompany.DomainVerifiedAt = &verifiedAt
+	require.NoError(t, repo.Save(ctx, byCompany))
+
+	enforced, err = repo.IsSSOEnforced(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, enforced)
+
+	// another company cannot claim the same domain
+	require.Error(t, repo.Save(ctx, &IdentityProvider{CompanyID: 2, Protocol: constants.SSOProtocolSAML, Domain: "acme.com"}))
+
+	require.NoError(t, repo.DeleteByCompanyID(ctx, 1))
+	_, err = repo.FindByCompanyID(ctx, 1)
+	require.Error(t, err)
+
+	enforced, err = repo.IsSSOEnforced(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, enforced)
+}
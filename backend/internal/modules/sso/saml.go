@@ -0,0 +1,321 @@
+package sso
+
+import (
+	"basekarya-backend/pkg/constants"
+	"bytes"
+	"compress/flate"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	samlMetadataNS      = "urn:oasis:names:tc:SAML:2.0:metadata"
+	samlProtocolNS      = "urn:oasis:names:tc:SAML:2.0:protocol"
+	samlAssertionNS     = "urn:oasis:names:tc:SAML:2.0:assertion"
+	samlBindingPOST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	samlBindingRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	samlStatusSuccess   = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	samlBearer          = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	samlEmailFormat     = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+)
+
+// attribute names IdPs commonly use for the email and the display name, Azure AD sends the claim URIs
+var (
+	samlEmailAttributes = []string{"email", "mail", "emailaddress", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress"}
+	samlNameAttributes  = []string{"name", "displayname", "http://schemas.microsoft.com/identity/claims/displayname", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/name"}
+)
+
+// samlMetadata is what the login needs from an IdP metadata document.
+type samlMetadata struct {
+	EntityID     string
+	SSOURL       string
+	Certificates []*x509.Certificate
+}
+
+type samlEntityDescriptor struct {
+	XMLName          xml.Name `xml:"EntityDescriptor"`
+	EntityID         string   `xml:"entityID,attr"`
+	IDPSSODescriptor struct {
+		KeyDescriptors []struct {
+			Use         string `xml:"use,attr"`
+			Certificate string `xml:"KeyInfo>X509Data>X509Certificate"`
+		} `xml:"KeyDescriptor"`
+		SingleSignOnServices []struct {
+			Binding  string `xml:"Binding,attr"`
+			Location string `xml:"Location,attr"`
+		} `xml:"SingleSignOnService"`
+	} `xml:"IDPSSODescriptor"`
+}
+
+// samlExpectation binds a response to the request this login sent and to this service provider.
+type samlExpectation struct {
+	RequestID  string
+	SPEntityID string
+	ACSURL     string
+}
+
+// parseSAMLMetadata reads the IdP entity ID, its HTTP-Redirect sign-on URL and its signing certificates.
+func parseSAMLMetadata(raw string) (*samlMetadata, error) {
+	var descriptor samlEntityDescriptor
+	if err := xml.Unmarshal([]byte(raw), &descriptor); err != nil {
+		return nil, fmt.Errorf("invalid SAML metadata: %w", err)
+	}
+
+	metadata := &samlMetadata{EntityID: descriptor.EntityID}
+	if metadata.EntityID == "" {
+		return nil, errors.New("SAML metadata has no entityID")
+	}
+
+	for _, service := range descriptor.IDPSSODescriptor.SingleSignOnServices {
+		if service.Binding == samlBindingRedirect {
+			metadata.SSOURL = service.Location
+			break
+		}
+	}
+	if metadata.SSOURL == "" {
+		return nil, errors.New("SAML metadata has no HTTP-Redirect single sign-on service")
+	}
+
+	for _, key := range descriptor.IDPSSODescriptor.KeyDescriptors {
+		if key.Use != "" && key.Use != "signing" {
+			continue
+		}
+		der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(key.Certificate), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in SAML metadata: %w", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in SAML metadata: %w", err)
+		}
+		metadata.Certificates = append(metadata.Certificates, cert)
+	}
+	if len(metadata.Certificates) == 0 {
+		return nil, errors.New("SAML metadata has no signing certificate")
+	}
+
+	return metadata, nil
+}
+
+// samlAuthnRequestURL builds the HTTP-Redirect binding of an AuthnRequest, the response is posted back to the ACS.
+func samlAuthnRequestURL(metadata *samlMetadata, expect samlExpectation, relayState string, now time.Time) (string, error) {
+	doc := etree.NewDocument()
+	request := doc.CreateElement("samlp:AuthnRequest")
+	request.CreateAttr("xmlns:samlp", samlProtocolNS)
+	request.CreateAttr("xmlns:saml", samlAssertionNS)
+	request.CreateAttr("ID", expect.RequestID)
+	request.CreateAttr("Version", "2.0")
+	request.CreateAttr("IssueInstant", now.UTC().Format(time.RFC3339))
+	request.CreateAttr("Destination", metadata.SSOURL)
+	request.CreateAttr("AssertionConsumerServiceURL", expect.ACSURL)
+	request.CreateAttr("ProtocolBinding", samlBindingPOST)
+	request.CreateElement("saml:Issuer").SetText(expect.SPEntityID)
+	policy := request.CreateElement("samlp:NameIDPolicy")
+	policy.CreateAttr("Format", samlEmailFormat)
+	policy.CreateAttr("AllowCreate", "true")
+
+	raw, err := doc.WriteToBytes()
+	if err != nil {
+		return "", err
+	}
+
+	var deflated bytes.Buffer
+	writer, err := flate.NewWriter(&deflated, flate.DefaultCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := writer.Write(raw); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	params.Set("RelayState", relayState)
+
+	separator := "?"
+	if strings.Contains(metadata.SSOURL, "?") {
+		separator = "&"
+	}
+	return metadata.SSOURL + separator + params.Encode(), nil
+}
+
+func samlServiceProviderMetadata(entityID, acsURL string) ([]byte, error) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	descriptor := doc.CreateElement("md:EntityDescriptor")
+	descriptor.CreateAttr("xmlns:md", samlMetadataNS)
+	descriptor.CreateAttr("entityID", entityID)
+
+	sp := descriptor.CreateElement("md:SPSSODescriptor")
+	sp.CreateAttr("AuthnRequestsSigned", "false")
+	sp.CreateAttr("WantAssertionsSigned", "true")
+	sp.CreateAttr("protocolSupportEnumeration", samlProtocolNS)
+	sp.CreateElement("md:NameIDFormat").SetText(samlEmailFormat)
+
+	acs := sp.CreateElement("md:AssertionConsumerService")
+	acs.CreateAttr("Binding", samlBindingPOST)
+	acs.CreateAttr("Location", acsURL)
+	acs.CreateAttr("index", "0")
+	acs.CreateAttr("isDefault", "true")
+
+	doc.Indent(2)
+	return doc.WriteToBytes()
+}
+
+// parseSAMLResponse verifies a posted SAML response and returns the identity it asserts. Either the response or
+// its assertion must carry a valid signature from the IdP, and only the signed copy is read, so nothing outside
+// the signature can change the result.
+func parseSAMLResponse(encoded string, metadata *samlMetadata, expect samlExpectation, now time.Time) (*ssoIdentity, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+	if err != nil {
+		return nil, errors.New("SAML response is not valid base64")
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil {
+		return nil, errors.New("SAML response is not valid XML")
+	}
+	response := doc.Root()
+	if response == nil || response.Tag != "Response" {
+		return nil, errors.New("SAML response has no Response element")
+	}
+	if response.FindElement("./EncryptedAssertion") != nil {
+		return nil, errors.New("encrypted SAML assertions are not supported, turn assertion encryption off at the IdP")
+	}
+	if len(response.FindElements("./Assertion")) != 1 {
+		return nil, errors.New("SAML response must carry exactly one assertion")
+	}
+
+	validator := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: metadata.Certificates})
+	validator.Clock = dsig.NewFakeClockAt(now)
+
+	var assertion *etree.Element
+	signedResponse, err := validator.Validate(response)
+	switch {
+	case err == nil:
+		response = signedResponse
+		assertion = signedResponse.FindElement("./Assertion")
+	case errors.Is(err, dsig.ErrMissingSignature):
+		assertion, err = validator.Validate(response.FindElement("./Assertion"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SAML assertion signature: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid SAML response signature: %w", err)
+	}
+
+	if status := response.FindElement("./Status/StatusCode"); status == nil || status.SelectAttrValue("Value", "") != samlStatusSuccess {
+		return nil, errors.New("identity provider did not authenticate the user")
+	}
+	if destination := response.SelectAttrValue("Destination", ""); destination != "" && destination != expect.ACSURL {
+		return nil, errors.New("SAML response is addressed to another service")
+	}
+	if inResponseTo := response.SelectAttrValue("InResponseTo", ""); inResponseTo != "" && inResponseTo != expect.RequestID {
+		return nil, errors.New("SAML response answers another request")
+	}
+
+	if err := checkSAMLAssertion(assertion, metadata, expect, now); err != nil {
+		return nil, err
+	}
+
+	return samlIdentity(assertion)
+}
+
+func checkSAMLAssertion(assertion *etree.Element, metadata *samlMetadata, expect samlExpectation, now time.Time) error {
+	if issuer := assertion.FindElement("./Issuer"); issuer == nil || strings.TrimSpace(issuer.Text()) != metadata.EntityID {
+		return errors.New("SAML assertion comes from another issuer")
+	}
+
+	conditions := assertion.FindElement("./Conditions")
+	if conditions == nil {
+		return errors.New("SAML assertion has no conditions")
+	}
+	if err := checkSAMLWindow(conditions, now); err != nil {
+		return err
+	}
+
+	audiences := conditions.FindElements("./AudienceRestriction/Audience")
+	if !slices.ContainsFunc(audiences, func(audience *etree.Element) bool {
+		return strings.TrimSpace(audience.Text()) == expect.SPEntityID
+	}) {
+		return errors.New("SAML assertion is meant for another audience")
+	}
+
+	// the bearer confirmation ties the assertion to this ACS and to the request of this login, a replayed or
+	// unsolicited assertion has no pending request to match
+	for _, confirmation := range assertion.FindElements("./Subject/SubjectConfirmation") {
+		if confirmation.SelectAttrValue("Method", "") != samlBearer {
+			continue
+		}
+		data := confirmation.FindElement("./SubjectConfirmationData")
+		if data == nil {
+			continue
+		}
+		if data.SelectAttrValue("Recipient", "") != expect.ACSURL || data.SelectAttrValue("InResponseTo", "") != expect.RequestID {
+			continue
+		}
+		if err := checkSAMLWindow(data, now); err != nil {
+			continue
+		}
+		return nil
+	}
+	return errors.New("SAML assertion has no bearer confirmation for this login")
+}
+
+// checkSAMLWindow enforces the NotBefore and NotOnOrAfter attributes of an element, with clock skew.
+func checkSAMLWindow(el *etree.Element, now time.Time) error {
+	if value := el.SelectAttrValue("NotBefore", ""); value != "" {
+		notBefore, err := time.Parse(time.RFC3339, value)
+		if err != nil || now.Add(constants.SSOClockSkew).Before(notBefore) {
+			return errors.New("SAML assertion is not valid yet")
+		}
+	}
+	if value := el.SelectAttrValue("NotOnOrAfter", ""); value != "" {
+		notOnOrAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil || !now.Add(-constants.SSOClockSkew).Before(notOnOrAfter) {
+			return errors.New("SAML assertion has expired")
+		}
+	}
+	return nil
+}
+
+// samlIdentity reads the email from the NameID or, when the NameID is opaque, from the email attribute.
+func samlIdentity(assertion *etree.Element) (*ssoIdentity, error) {
+	identity := &ssoIdentity{}
+
+	if nameID := assertion.FindElement("./Subject/NameID"); nameID != nil && strings.Contains(nameID.Text(), "@") {
+		identity.Email = strings.TrimSpace(nameID.Text())
+	}
+
+	for _, attribute := range assertion.FindElements("./AttributeStatement/Attribute") {
+		name := strings.ToLower(attribute.SelectAttrValue("Name", ""))
+		value := attribute.FindElement("./AttributeValue")
+		if value == nil {
+			continue
+		}
+		switch {
+		case identity.Email == "" && slices.Contains(samlEmailAttributes, name):
+			identity.Email = strings.TrimSpace(value.Text())
+		case identity.Name == "" && slices.Contains(samlNameAttributes, name):
+			identity.Name = strings.TrimSpace(value.Text())
+		}
+	}
+
+	if identity.Email == "" {
+		return nil, errors.New("SAML assertion carries no email")
+	}
+	return identity, nil
+}
@@ -0,0 +1,232 @@
+package sso
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIdPEntityID = "https://idp.acme.test/saml"
+	testIdPSSOURL   = "https://idp.acme.test/saml/sso"
+)
+
+var testSAMLExpectation = samlExpectation{
+	RequestID:  "_request",
+	SPEntityID: "http://localhost:8080/api/v1/auth/sso/saml/metadata",
+	ACSURL:     "http://localhost:8080/api/v1/auth/sso/saml/acs",
+}
+
+// samlIdP is a local identity provider that signs responses with a throwaway key.
+type samlIdP struct {
+	keys dsig.X509KeyStore
+}
+
+func newSAMLIdP() *samlIdP {
+	return &samlIdP{keys: dsig.RandomKeyStoreForTest()}
+}
+
+func (idp *samlIdP) metadata(t *testing.T) string {
+	t.Helper()
+	_, cert, err := idp.keys.GetKeyPair()
+	require.NoError(t, err)
+
+	return fmt.Sprintf(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="%s">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="%s/post"/>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="%s"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, testIdPEntityID, base64.StdEncoding.EncodeToString(cert), testIdPSSOURL, testIdPSSOURL)
+}
+
+type samlResponseOptions struct {
+	email         string
+	audience      string
+	inResponseTo  string
+	notOnOrAfter  time.Time
+	signResponse  bool
+	unsigned      bool
+	tamperedEmail string
+}
+
+func (idp *samlIdP) response(t *testing.T, expect samlExpectation, opts samlResponseOptions) string {
+	t.Helper()
+	now := time.Now().UTC()
+	if opts.email == "" {
+		opts.email = "jane@acme.com"
+	}
+	if opts.audience == "" {
+		opts.audience = expect.SPEntityID
+	}
+	if opts.inResponseTo == "" {
+		opts.inResponseTo = expect.RequestID
+	}
+	if opts.notOnOrAfter.IsZero() {
+		opts.notOnOrAfter = now.Add(5 * time.Minute)
+	}
+
+	doc := etree.NewDocument()
+	response := doc.CreateElement("samlp:Response")
+	response.CreateAttr("xmlns:samlp", samlProtocolNS)
+	response.CreateAttr("xmlns:saml", samlAssertionNS)
+	response.CreateAttr("ID", "_response")
+	response.CreateAttr("Version", "2.0")
+	response.CreateAttr("IssueInstant", now.Format(time.RFC3339))
+	response.CreateAttr("Destination", expect.ACSURL)
+	response.CreateAttr("InResponseTo", opts.inResponseTo)
+	response.CreateElement("saml:Issuer").SetText(testIdPEntityID)
+	response.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", samlStatusSuccess)
+
+	assertion := etree.NewElement("saml:Assertion")
+	assertion.CreateAttr("xmlns:saml", samlAssertionNS)
+	assertion.CreateAttr("ID", "_assertion")
+	assertion.CreateAttr("Version", "2.0")
+	assertion.CreateAttr("IssueInstant", now.Format(time.RFC3339))
+	assertion.CreateElement("saml:Issuer").SetText(testIdPEntityID)
+
+	subject := assertion.CreateElement("saml:Subject")
+	nameID := subject.CreateElement("saml:NameID")
+	nameID.CreateAttr("Format", samlEmailFormat)
+	nameID.SetText(opts.email)
+	confirmation := subject.CreateElement("saml:SubjectConfirmation")
+	confirmation.CreateAttr("Method", samlBearer)
+	data := confirmation.CreateElement("saml:SubjectConfirmationData")
+	data.CreateAttr("InResponseTo", opts.inResponseTo)
+	data.CreateAttr("Recipient", expect.ACSURL)
+	data.CreateAttr("NotOnOrAfter", opts.notOnOrAfter.Format(time.RFC3339))
+
+	conditions := assertion.CreateElement("saml:Conditions")
+	conditions.CreateAttr("NotBefore", now.Add(-time.Minute).Format(time.RFC3339))
+	conditions.CreateAttr("NotOnOrAfter", opts.notOnOrAfter.Format(time.RFC3339))
+	conditions.CreateElement("saml:AudienceRestriction").CreateElement("saml:Audience").SetText(opts.audience)
+
+	attribute := assertion.CreateElement("saml:AttributeStatement").CreateElement("saml:Attribute")
+	attribute.CreateAttr("Name", "displayName")
+	attribute.CreateElement("saml:AttributeValue").SetText("Jane Doe")
+
+	signer := dsig.NewDefaultSigningContext(idp.keys)
+	switch {
+	case opts.unsigned:
+		response.AddChild(assertion)
+	case opts.signResponse:
+		response.AddChild(assertion)
+		signed, err := signer.SignEnveloped(response)
+		require.NoError(t, err)
+		doc.SetRoot(signed)
+	default:
+		signed, err := signer.SignEnveloped(assertion)
+		require.NoError(t, err)
+		response.AddChild(signed)
+	}
+
+	if opts.tamperedEmail != "" {
+		doc.Root().FindElement("./Assertion/Subject/NameID").SetText(opts.tamperedEmail)
+	}
+
+	raw, err := doc.WriteToBytes()
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestParseSAMLMetadata(t *testing.T) {
+	idp := newSAMLIdP()
+
+	metadata, err := parseSAMLMetadata(idp.metadata(t))
+
+	require.NoError(t, err)
+	assert.Equal(t, testIdPEntityID, metadata.EntityID)
+	assert.Equal(t, testIdPSSOURL, metadata.SSOURL)
+	assert.Len(t, metadata.Certificates, 1)
+
+	_, err = parseSAMLMetadata(`<EntityDescriptor entityID="x"><IDPSSODescriptor/></EntityDescriptor>`)
+	assert.Error(t, err)
+}
+
+func TestSAMLAuthnRequestURL(t *testing.T) {
+	metadata, err := parseSAMLMetadata(newSAMLIdP().metadata(t))
+	require.NoError(t, err)
+
+	redirect, err := samlAuthnRequestURL(metadata, testSAMLExpectation, "relay", time.Now())
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(redirect, testIdPSSOURL+"?"))
+
+	parsed, err := url.Parse(redirect)
+	require.NoError(t, err)
+	assert.Equal(t, "relay", parsed.Query().Get("RelayState"))
+
+	request := inflateSAMLRequest(t, parsed.Query().Get("SAMLRequest"))
+	assert.Contains(t, request, `ID="_request"`)
+	assert.Contains(t, request, `AssertionConsumerServiceURL="`+testSAMLExpectation.ACSURL+`"`)
+	assert.Contains(t, request, testSAMLExpectation.SPEntityID)
+}
+
+func TestParseSAMLResponse(t *testing.T) {
+	idp := newSAMLIdP()
+	metadata, err := parseSAMLMetadata(idp.metadata(t))
+	require.NoError(t, err)
+
+	t.Run("signed assertion", func(t *testing.T) {
+		identity, err := parseSAMLResponse(idp.response(t, testSAMLExpectation, samlResponseOptions{}), metadata, testSAMLExpectation, time.Now())
+
+		require.NoError(t, err)
+		assert.Equal(t, "jane@acme.com", identity.Email)
+		assert.Equal(t, "Jane Doe", identity.Name)
+	})
+
+	t.Run("signed response", func(t *testing.T) {
+		identity, err := parseSAMLResponse(idp.response(t, testSAMLExpectation, samlResponseOptions{signResponse: true}), metadata, testSAMLExpectation, time.Now())
+
+		require.NoError(t, err)
+		assert.Equal(t, "jane@acme.com", identity.Email)
+	})
+
+	refused := []struct {
+		name string
+		opts samlResponseOptions
+	}{
+		{name: "unsigned", opts: samlResponseOptions{unsigned: true}},
+		{name: "email changed after signing", opts: samlResponseOptions{tamperedEmail: "ceo@acme.com"}},
+		{name: "other audience", opts: samlResponseOptions{audience: "https://other.app"}},
+		{name: "other request", opts: samlResponseOptions{inResponseTo: "_other"}},
+		{name: "expired", opts: samlResponseOptions{notOnOrAfter: time.Now().Add(-time.Hour)}},
+	}
+	for _, tt := range refused {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSAMLResponse(idp.response(t, testSAMLExpectation, tt.opts), metadata, testSAMLExpectation, time.Now())
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("signed by another key", func(t *testing.T) {
+		other := newSAMLIdP()
+
+		_, err := parseSAMLResponse(other.response(t, testSAMLExpectation, samlResponseOptions{}), metadata, testSAMLExpectation, time.Now())
+		assert.Error(t, err)
+	})
+}
+
+func inflateSAMLRequest(t *testing.T, encoded string) string {
+	t.Helper()
+	deflated, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+
+	raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	require.NoError(t, err)
+	return string(raw)
+}
@@ -0,0 +1,465 @@
+package sso
+
+import (
+	"basekarya-backend/internal/config"
+	"basekarya-backend/internal/modules/auth"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSSONotConfigured  = errors.New("single sign-on is not configured for this email domain")
+	ErrInvalidSSOState   = errors.New("invalid or expired SSO login, start again")
+	ErrInvalidSSOTicket  = errors.New("invalid or expired SSO ticket, start again")
+	ErrSSOUserNotFound   = errors.New("no employee with this email, ask your administrator for access")
+	ErrDomainNotVerified = errors.New("verification record not found on the domain, DNS changes may take a while to show")
+)
+
+type Service interface {
+	GetProvider(ctx context.Context) (*IdentityProviderResponse, error)
+	SaveProvider(ctx context.Context, req *SaveIdentityProviderRequest) (*IdentityProviderResponse, error)
+	DeleteProvider(ctx context.Context) error
+	VerifyDomain(ctx context.Context) (*IdentityProviderResponse, error)
+	Start(ctx context.Context, req *StartSSORequest) (*StartSSOResponse, error)
+	HandleOIDCCallback(ctx context.Context, req *OIDCCallbackRequest) (string, error)
+	HandleSAMLResponse(ctx context.Context, req *SAMLResponseRequest) (string, error)
+	Exchange(ctx context.Context, req *ExchangeTicketRequest) (*auth.LoginResponse, error)
+	ServiceProviderMetadata() ([]byte, error)
+}
+
+type service struct {
+	repo     Repository
+	cache    CacheProvider
+	login    LoginIssuer
+	user     UserProvider
+	employee EmployeeCreator
+	client   *http.Client
+	resolver DNSResolver
+	config   *config.SSOConfig
+}
+
+func NewService(repo Repository, cache CacheProvider, login LoginIssuer, user UserProvider, employee EmployeeCreator, client *http.Client, resolver DNSResolver, cfg *config.SSOConfig) Service {
+	return &service{
+		repo:     repo,
+		cache:    cache,
+		login:    login,
+		user:     user,
+		employee: employee,
+		client:   publicHTTPSClient(client),
+		resolver: resolver,
+		config:   cfg,
+	}
+}
+
+// ssoState is a login sent to the identity provider and waiting for its callback, it is spent by the callback.
+type ssoState struct {
+	ID           string `json:"id"`
+	ProviderID   uint   `json:"provider_id"`
+	Nonce        string `json:"nonce,omitempty"`
+	CodeVerifier string `json:"code_verifier,omitempty"`
+	RequestID    string `json:"request_id,omitempty"`
+}
+
+// ssoIdentity is the user an identity provider vouched for.
+type ssoIdentity struct {
+	Email string
+	Name  string
+}
+
+func (s *service) GetProvider(ctx context.Context) (*IdentityProviderResponse, error) {
+	provider, err := s.repo.FindByCompanyID(ctx, utils.GetCompanyIDFromCtx(ctx))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSSONotConfigured
+		}
+		return nil, err
+	}
+
+	return s.toResponse(provider), nil
+}
+
+// SaveProvider creates or replaces the company's identity provider. The OIDC discovery document or the SAML
+// metadata is read here so a wrong configuration is refused before anyone depends on it.
+func (s *service) SaveProvider(ctx context.Context, req *SaveIdentityProviderRequest) (*IdentityProviderResponse, error) {
+	companyID := utils.GetCompanyIDFromCtx(ctx)
+	domain := strings.ToLower(strings.TrimSpace(req.Domain))
+
+	provider, err := s.repo.FindByCompanyID(ctx, companyID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		provider = &IdentityProvider{CompanyID: companyID}
+	}
+
+	owner, err := s.repo.FindByDomain(ctx, domain)
+	if err == nil && owner.CompanyID != companyID {
+		return nil, errors.New("this domain is already used by another company")
+	}
+
+	if req.SSOOnly && !req.Enabled {
+		return nil, errors.New("SSO only requires the identity provider to be enabled")
+	}
+	if req.JITProvisioning && (req.DefaultRoleID == nil || req.DefaultDepartmentID == nil || req.DefaultShiftID == nil) {
+		return nil, errors.New("just-in-time provisioning requires a default role, department and shift")
+	}
+
+	// a new domain has to be proven again before the provider takes effect
+	if provider.Domain != domain || provider.VerificationToken == "" {
+		provider.VerificationToken = utils.GenerateSecureToken(16)
+		provider.DomainVerifiedAt = nil
+	}
+
+	provider.Protocol = constants.SSOProtocol(req.Protocol)
+	provider.Domain = domain
+	provider.Enabled = req.Enabled
+	provider.SSOOnly = req.SSOOnly
+	provider.JITProvisioning = req.JITProvisioning
+	provider.DefaultRoleID = req.DefaultRoleID
+	provider.DefaultDepartmentID = req.DefaultDepartmentID
+	provider.DefaultShiftID = req.DefaultShiftID
+
+	switch provider.Protocol {
+	case constants.SSOProtocolOIDC:
+		if req.IssuerURL == "" || req.ClientID == "" {
+			return nil, errors.New("OIDC requires an issuer URL and a client ID")
+		}
+		if req.ClientSecret != "" {
+			provider.ClientSecret = req.ClientSecret
+		}
+		if provider.ClientSecret == "" {
+			return nil, errors.New("OIDC requires a client secret")
+		}
+		if _, err := s.discoverOIDC(ctx, req.IssuerURL); err != nil {
+			return nil, err
+		}
+		provider.IssuerURL = strings.TrimRight(req.IssuerURL, "/")
+		provider.ClientID = req.ClientID
+		provider.MetadataXML = ""
+	case constants.SSOProtocolSAML:
+		if _, err := parseSAMLMetadata(req.MetadataXML); err != nil {
+			return nil, err
+		}
+		provider.MetadataXML = req.MetadataXML
+		provider.IssuerURL = ""
+		provider.ClientID = ""
+		provider.ClientSecret = ""
+	}
+
+	if err := s.repo.Save(ctx, provider); err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(provider), nil
+}
+
+func (s *service) DeleteProvider(ctx context.Context) error {
+	return s.repo.DeleteByCompanyID(ctx, utils.GetCompanyIDFromCtx(ctx))
+}
+
+// VerifyDomain looks for the verification TXT record on the provider's domain, until it is found nobody signs in
+// through the provider, so a company cannot take over logins of a domain it does not control.
+func (s *service) VerifyDomain(ctx context.Context) (*IdentityProviderResponse, error) {
+	provider, err := s.repo.FindByCompanyID(ctx, utils.GetCompanyIDFromCtx(ctx))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSSONotConfigured
+		}
+		return nil, err
+	}
+	if provider.DomainVerifiedAt != nil {
+		return s.toResponse(provider), nil
+	}
+
+	records, err := s.resolver.LookupTXT(ctx, constants.SSODomainVerificationHost+provider.Domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			return nil, fmt.Errorf("failed to look up the verification record: %w", err)
+		}
+	}
+
+	expected := constants.SSODomainVerificationPrefix + provider.VerificationToken
+	for _, record := range records {
+		if strings.TrimSpace(record) == expected {
+			now := time.Now()
+			provider.DomainVerifiedAt = &now
+			if err := s.repo.Save(ctx, provider); err != nil {
+				return nil, err
+			}
+			return s.toResponse(provider), nil
+		}
+	}
+	return nil, ErrDomainNotVerified
+}
+
+// Start resolves the identity provider from the email domain and returns where to send the browser.
+func (s *service) Start(ctx context.Context, req *StartSSORequest) (*StartSSOResponse, error) {
+	domain := emailDomain(req.Email)
+	provider, err := s.repo.FindByDomain(ctx, domain)
+	if err != nil || !provider.IsActive() {
+		return nil, ErrSSONotConfigured
+	}
+
+	state := &ssoState{ID: utils.GenerateSecureToken(32), ProviderID: provider.ID}
+
+	var redirectURL string
+	switch provider.Protocol {
+	case constants.SSOProtocolOIDC:
+		discovery, err := s.discoverOIDC(ctx, provider.IssuerURL)
+		if err != nil {
+			return nil, err
+		}
+		state.Nonce = utils.GenerateSecureToken(16)
+		state.CodeVerifier = utils.GenerateSecureToken(32)
+		redirectURL = oidcAuthURL(discovery, provider.ClientID, s.oidcRedirectURI(), state)
+	case constants.SSOProtocolSAML:
+		metadata, err := parseSAMLMetadata(provider.MetadataXML)
+		if err != nil {
+			return nil, err
+		}
+		// SAML IDs must not start with a digit
+		state.RequestID = "_" + utils.GenerateSecureToken(20)
+		redirectURL, err = samlAuthnRequestURL(metadata, s.samlExpectation(state.RequestID), state.ID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrSSONotConfigured
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, fmt.Sprintf(constants.SSO_STATE_CACHE_KEY, state.ID), payload, constants.SSOStateTTL); err != nil {
+		return nil, err
+	}
+
+	return &StartSSOResponse{Protocol: string(provider.Protocol), RedirectURL: redirectURL}, nil
+}
+
+// HandleOIDCCallback completes the authorization code flow. The returned URL sends the browser back to the
+// frontend, with a ticket on success or the reason on failure.
+func (s *service) HandleOIDCCallback(ctx context.Context, req *OIDCCallbackRequest) (string, error) {
+	state, provider, err := s.consumeState(ctx, req.State, constants.SSOProtocolOIDC)
+	if err != nil {
+		return s.failureURL(err), err
+	}
+
+	if req.Error != "" {
+		err := fmt.Errorf("identity provider refused the login: %s %s", req.Error, req.ErrorDescription)
+		return s.failureURL(err), err
+	}
+
+	identity, err := s.verifyOIDCLogin(ctx, provider, state, req.Code)
+	if err != nil {
+		return s.failureURL(err), err
+	}
+
+	return s.issueTicket(ctx, provider, identity)
+}
+
+// HandleSAMLResponse completes a SAML login posted to the ACS, the RelayState carries the login state.
+func (s *service) HandleSAMLResponse(ctx context.Context, req *SAMLResponseRequest) (string, error) {
+	state, provider, err := s.consumeState(ctx, req.RelayState, constants.SSOProtocolSAML)
+	if err != nil {
+		return s.failureURL(err), err
+	}
+
+	metadata, err := parseSAMLMetadata(provider.MetadataXML)
+	if err != nil {
+		return s.failureURL(err), err
+	}
+
+	identity, err := parseSAMLResponse(req.SAMLResponse, metadata, s.samlExpectation(state.RequestID), time.Now())
+	if err != nil {
+		return s.failureURL(err), err
+	}
+
+	return s.issueTicket(ctx, provider, identity)
+}
+
+// Exchange trades the single use ticket from the callback redirect for the login tokens, so tokens never travel
+// in a URL. The ticket is read and deleted in one step, two requests racing with it cannot both log in.
+func (s *service) Exchange(ctx context.Context, req *ExchangeTicketRequest) (*auth.LoginResponse, error) {
+	payload, err := s.cache.GetDel(ctx, fmt.Sprintf(constants.SSO_TICKET_CACHE_KEY, req.Ticket))
+	if err != nil {
+		return nil, ErrInvalidSSOTicket
+	}
+
+	userID, err := strconv.ParseUint(payload, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSSOTicket
+	}
+
+	return s.login.CompleteExternalLogin(ctx, uint(userID), req.UserAgent, req.IPAddress)
+}
+
+// ServiceProviderMetadata describes this application to a SAML IdP, most IdPs can import it instead of having the
+// entity ID and ACS URL typed in.
+func (s *service) ServiceProviderMetadata() ([]byte, error) {
+	return samlServiceProviderMetadata(s.samlEntityID(), s.samlACSURL())
+}
+
+func (s *service) verifyOIDCLogin(ctx context.Context, provider *IdentityProvider, state *ssoState, code string) (*ssoIdentity, error) {
+	if code == "" {
+		return nil, errors.New("identity provider returned no authorization code")
+	}
+
+	discovery, err := s.discoverOIDC(ctx, provider.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := s.exchangeOIDCCode(ctx, discovery, provider, code, state.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.verifyIDToken(ctx, discovery, provider, idToken, state.Nonce)
+}
+
+func (s *service) consumeState(ctx context.Context, id string, protocol constants.SSOProtocol) (*ssoState, *IdentityProvider, error) {
+	if id == "" {
+		return nil, nil, ErrInvalidSSOState
+	}
+
+	// read and deleted in one step so a callback replayed in parallel finds no state
+	payload, err := s.cache.GetDel(ctx, fmt.Sprintf(constants.SSO_STATE_CACHE_KEY, id))
+	if err != nil {
+		return nil, nil, ErrInvalidSSOState
+	}
+
+	var state ssoState
+	if err := json.Unmarshal([]byte(payload), &state); err != nil {
+		return nil, nil, ErrInvalidSSOState
+	}
+
+	provider, err := s.repo.FindByID(ctx, state.ProviderID)
+	if err != nil || !provider.IsActive() || provider.Protocol != protocol {
+		return nil, nil, ErrSSONotConfigured
+	}
+	return &state, provider, nil
+}
+
+// issueTicket maps the IdP email to an employee of the provider's company, provisioning one when allowed, and
+// hands the frontend a short lived ticket for the login.
+func (s *service) issueTicket(ctx context.Context, provider *IdentityProvider, identity *ssoIdentity) (string, error) {
+	userID, err := s.resolveUser(ctx, provider, identity)
+	if err != nil {
+		return s.failureURL(err), err
+	}
+
+	ticket := utils.GenerateSecureToken(32)
+	if err := s.cache.Set(ctx, fmt.Sprintf(constants.SSO_TICKET_CACHE_KEY, ticket), strconv.FormatUint(uint64(userID), 10), constants.SSOTicketTTL); err != nil {
+		return s.failureURL(err), err
+	}
+
+	return s.frontendURL(url.Values{"ticket": {ticket}}), nil
+}
+
+func (s *service) resolveUser(ctx context.Context, provider *IdentityProvider, identity *ssoIdentity) (uint, error) {
+	employee, err := s.user.FindEmployeeByCompanyEmail(ctx, provider.CompanyID, identity.Email)
+	if err == nil {
+		return employee.UserID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	// only addresses on the company's own domain are provisioned, the IdP may know guests from elsewhere
+	if !provider.JITProvisioning || emailDomain(identity.Email) != provider.Domain {
+		return 0, ErrSSOUserNotFound
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = strings.Split(identity.Email, "@")[0]
+	}
+
+	tenantCtx := context.WithValue(ctx, constants.CompanyIDContextKey, provider.CompanyID)
+	_, err = s.employee.CreateEmployee(tenantCtx, &user.CreateEmployeeRequest{
+		NIK:          "SSO-" + strings.ToUpper(utils.GenerateSecureToken(6)),
+		FullName:     name,
+		Email:        strings.ToLower(identity.Email),
+		RoleID:       *provider.DefaultRoleID,
+		DepartmentID: *provider.DefaultDepartmentID,
+		ShiftID:      *provider.DefaultShiftID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to provision employee: %w", err)
+	}
+
+	employee, err = s.user.FindEmployeeByCompanyEmail(ctx, provider.CompanyID, identity.Email)
+	if err != nil {
+		return 0, err
+	}
+	return employee.UserID, nil
+}
+
+func (s *service) toResponse(provider *IdentityProvider) *IdentityProviderResponse {
+	return &IdentityProviderResponse{
+		IdentityProvider: *provider,
+		HasClientSecret:  provider.ClientSecret != "",
+		RedirectURI:      s.oidcRedirectURI(),
+		SPEntityID:       s.samlEntityID(),
+		ACSURL:           s.samlACSURL(),
+
+		DomainVerified:     provider.DomainVerifiedAt != nil,
+		VerificationRecord: constants.SSODomainVerificationHost + provider.Domain,
+		VerificationValue:  constants.SSODomainVerificationPrefix + provider.VerificationToken,
+	}
+}
+
+func (s *service) samlExpectation(requestID string) samlExpectation {
+	return samlExpectation{RequestID: requestID, SPEntityID: s.samlEntityID(), ACSURL: s.samlACSURL()}
+}
+
+func (s *service) oidcRedirectURI() string {
+	return strings.TrimRight(s.config.BaseURL, "/") + "/api/v1/auth/sso/oidc/callback"
+}
+
+func (s *service) samlEntityID() string {
+	return strings.TrimRight(s.config.BaseURL, "/") + "/api/v1/auth/sso/saml/metadata"
+}
+
+func (s *service) samlACSURL() string {
+	return strings.TrimRight(s.config.BaseURL, "/") + "/api/v1/auth/sso/saml/acs"
+}
+
+// failureURL explains the errors a user can act on and keeps anything internal out of the browser.
+func (s *service) failureURL(err error) string {
+	message := "single sign-on failed, try again or contact your administrator"
+	for _, known := range []error{ErrSSONotConfigured, ErrInvalidSSOState, ErrSSOUserNotFound} {
+		if errors.Is(err, known) {
+			message = known.Error()
+		}
+	}
+	return s.frontendURL(url.Values{"error": {message}})
+}
+
+func (s *service) frontendURL(params url.Values) string {
+	return strings.TrimRight(s.config.FrontendURL, "/") + "/sso/callback?" + params.Encode()
+}
+
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
@@ -0,0 +1,516 @@
+package sso
+
+import (
+	"basekarya-backend/internal/config"
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/auth"
+	"basekarya-backend/internal/modules/user"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testSSOConfig = &config.SSOConfig{BaseURL: "http://localhost:8080", FrontendURL: "http://localhost:5173"}
+
+// oidcIdP is a local OpenID provider serving discovery, signing keys and the token endpoint.
+type oidcIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]oidcGrant
+}
+
+// oidcGrant is what the IdP remembers about a code it handed to the browser.
+type oidcGrant struct {
+	claims    jwt.MapClaims
+	challenge string
+}
+
+func newOIDCIdP(t *testing.T) *oidcIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &oidcIdP{key: key, codes: map[string]oidcGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "test-key", "kty": "RSA", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewTLSServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// authorize stands in for the user signing in at the IdP, it returns the code the browser brings back.
+func (idp *oidcIdP) authorize(t *testing.T, redirectURL string, claims jwt.MapClaims) (string, string) {
+	t.Helper()
+	parsed, err := url.Parse(redirectURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	require.Equal(t, idp.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	base := jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   "basekarya",
+		"sub":   "idp-user-1",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": query.Get("nonce"),
+	}
+	for k, v := range claims {
+		base[k] = v
+	}
+
+	code := utils.GenerateSecureToken(8)
+	idp.mu.Lock()
+	idp.codes[code] = oidcGrant{claims: base, challenge: query.Get("code_challenge")}
+	idp.mu.Unlock()
+	return query.Get("state"), code
+}
+
+func (idp *oidcIdP) token(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	idp.mu.Lock()
+	grant, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || r.PostForm.Get("client_secret") != "s3cret" ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, grant.claims)
+	token.Header["kid"] = "test-key"
+	signed, _ := token.SignedString(idp.key)
+	_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+}
+
+func newTestSSOService(t *testing.T) (Service, Repository, *mockLoginIssuer, *mockUserProvider, *mockEmployeeCreator) {
+	t.Helper()
+	return newTestSSOServiceWithResolver(t, new(mockDNSResolver))
+}
+
+func newTestSSOServiceWithResolver(t *testing.T, resolver DNSResolver) (Service, Repository, *mockLoginIssuer, *mockUserProvider, *mockEmployeeCreator) {
+	t.Helper()
+	// the test IdP listens on loopback with a self-signed certificate
+	allowAddress = func(ip net.IP) bool { return ip.IsLoopback() }
+	t.Cleanup(func() { allowAddress = isPublicAddress })
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	cache := &infrastructure.RedisClientProvider{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+
+	tdb := setupSSOTestDB(t)
+	repo := NewRepository(tdb.DB)
+	login := new(mockLoginIssuer)
+	users := new(mockUserProvider)
+	employees := new(mockEmployeeCreator)
+
+	return NewService(repo, cache, login, users, employees, client, resolver, testSSOConfig), repo, login, users, employees
+}
+
+func seedOIDCProvider(t *testing.T, repo Repository, idp *oidcIdP, jit bool) {
+	t.Helper()
+	roleID, departmentID, shiftID := uint(3), uint(4), uint(5)
+	verifiedAt := time.Now()
+	require.NoError(t, repo.Save(context.Background(), &IdentityProvider{
+		CompanyID: 1, Protocol: constants.SSOProtocolOIDC, Domain: "acme.com", Enabled: true, DomainVerifiedAt: &verifiedAt,
+		IssuerURL: idp.server.URL, ClientID: "basekarya", ClientSecret: "s3cret",
+		JITProvisioning: jit, DefaultRoleID: &roleID, DefaultDepartmentID: &departmentID, DefaultShiftID: &shiftID,
+	}))
+}
+
+var ticketPattern = regexp.MustCompile(`^http://localhost:5173/sso/callback\?ticket=([0-9a-f]{64})$`)
+
+func TestOIDCLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("existing employee signs in", func(t *testing.T) {
+		svc, repo, login, users, _ := newTestSSOService(t)
+		idp := newOIDCIdP(t)
+		seedOIDCProvider(t, repo, idp, false)
+		users.On("FindEmployeeByCompanyEmail", ctx, uint(1), "jane@acme.com").Return(&user.Employee{ID: 2, UserID: 5}, nil)
+		login.On("CompleteExternalLogin", ctx, uint(5), "Mozilla/5.0", "10.0.0.1").Return(&auth.LoginResponse{Token: "jwt-token"}, nil)
+
+		start, err := svc.Start(ctx, &StartSSORequest{Email: "jane@acme.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "OIDC", start.Protocol)
+		assert.Contains(t, start.RedirectURL, url.QueryEscape("http://localhost:8080/api/v1/auth/sso/oidc/callback"))
+
+		state, code := idp.authorize(t, start.RedirectURL, jwt.MapClaims{"email": "jane@acme.com", "email_verified": true})
+		redirect, err := svc.HandleOIDCCallback(ctx, &OIDCCallbackRequest{State: state, Code: code})
+		require.NoError(t, err)
+		match := ticketPattern.FindStringSubmatch(redirect)
+		require.NotNil(t, match, redirect)
+
+		resp, err := svc.Exchange(ctx, &ExchangeTicketRequest{Ticket: match[1], UserAgent: "Mozilla/5.0", IPAddress: "10.0.0.1"})
+		require.NoError(t, err)
+		assert.Equal(t, "jwt-token", resp.Token)
+
+		// ticket and state are single use
+		_, err = svc.Exchange(ctx, &ExchangeTicketRequest{Ticket: match[1]})
+		assert.ErrorIs(t, err, ErrInvalidSSOTicket)
+		_, err = svc.HandleOIDCCallback(ctx, &OIDCCallbackRequest{State: state, Code: code})
+		assert.ErrorIs(t, err, ErrInvalidSSOState)
+	})
+
+	t.Run("ticket exchanged in parallel logs in once", func(t *testing.T) {
+		svc, _, login, _, _ := newTestSSOService(t)
+		login.On("CompleteExternalLogin", ctx, uint(5), "", "").Return(&auth.LoginResponse{Token: "jwt-token"}, nil)
+		require.NoError(t, svc.(*service).cache.Set(ctx, "sso:ticket:parallel", "5", constants.SSOTicketTTL))
+
+		var wg sync.WaitGroup
+		var exchanged atomic.Int32
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := svc.Exchange(ctx, &ExchangeTicketRequest{Ticket: "parallel"}); err == nil {
+					exchanged.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), exchanged.Load())
+		login.AssertNumberOfCalls(t, "CompleteExternalLogin", 1)
+	})
+
+	t.Run("unknown employee provisioned just in time", func(t *testing.T) {
+		svc, repo, _, users, employees := newTestSSOService(t)
+		idp := newOIDCIdP(t)
+		seedOIDCProvider(t, repo, idp, true)
+		users.On("FindEmployeeByCompanyEmail", ctx, uint(1), "new.hire@acme.com").Return(nil, gorm.ErrRecordNotFound).Once()
+		users.On("FindEmployeeByCompanyEmail", ctx, uint(1), "new.hire@acme.com").Return(&user.Employee{ID: 8, UserID: 9}, nil).Once()
+		employees.On("CreateEmployee", mock.MatchedBy(func(c context.Context) bool {
+			return utils.GetCompanyIDFromCtx(c) == 1
+		}), mock.MatchedBy(func(req *user.CreateEmployeeRequest) bool {
+			return req.Email == "new.hire@acme.com" && req.FullName == "New Hire" && req.RoleID == 3 &&
+				req.DepartmentID == 4 && req.ShiftID == 5 && strings.HasPrefix(req.NIK, "SSO-")
+		})).Return(&user.CreateEmployeeResponse{Username: "new_abc123"}, nil)
+
+		start, err := svc.Start(ctx, &StartSSORequest{Email: "new.hire@acme.com"})
+		require.NoError(t, err)
+		state, code := idp.authorize(t, start.RedirectURL, jwt.MapClaims{"email": "new.hire@acme.com", "name": "New Hire"})
+
+		redirect, err := svc.HandleOIDCCallback(ctx, &OIDCCallbackRequest{State: state, Code: code})
+
+		require.NoError(t, err)
+		assert.Regexp(t, ticketPattern, redirect)
+		employees.AssertExpectations(t)
+	})
+
+	t.Run("unknown employee refused without provisioning", func(t *testing.T) {
+		svc, repo, _, users, employees := newTestSSOService(t)
+		idp := newOIDCIdP(t)
+		seedOIDCProvider(t, repo, idp, false)
+		users.On("FindEmployeeByCompanyEmail", ctx, uint(1), "stranger@acme.com").Return(nil, gorm.ErrRecordNotFound)
+
+		start, err := svc.Start(ctx, &StartSSORequest{Email: "stranger@acme.com"})
+		require.NoError(t, err)
+		state, code := idp.authorize(t, start.RedirectURL, jwt.MapClaims{"email": "stranger@acme.com"})
+
+		redirect, err := svc.HandleOIDCCallback(ctx, &OIDCCallbackRequest{State: state, Code: code})
+
+		assert.ErrorIs(t, err, ErrSSOUserNotFound)
+		assert.Equal(t, "http://localhost:5173/sso/callback?error="+url.QueryEscape(ErrSSOUserNotFound.Error()), redirect)
+		employees.AssertNotCalled(t, "CreateEmployee", mock.Anything, mock.Anything)
+	})
+
+	refused := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{name: "nonce of another login", claims: jwt.MapClaims{"email": "jane@acme.com", "nonce": "replayed"}},
+		{name: "token for another client", claims: jwt.MapClaims{"email": "jane@acme.com", "aud": "other-app"}},
+		{name: "expired token", claims: jwt.MapClaims{"email": "jane@acme.com", "exp": time.Now().Add(-time.Hour).Unix()}},
+		{name: "unverified email", claims: jwt.MapClaims{"email": "jane@acme.com", "email_verified": false}},
+		{name: "username instead of email", claims: jwt.MapClaims{"preferred_username": "jane@acme.com"}},
+	}
+	for _, tt := range refused {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, users, _ := newTestSSOService(t)
+			idp := newOIDCIdP(t)
+			seedOIDCProvider(t, repo, idp, false)
+
+			start, err := svc.Start(ctx, &StartSSORequest{Email: "jane@acme.com"})
+			require.NoError(t, err)
+			state, code := idp.authorize(t, start.RedirectURL, tt.claims)
+
+			redirect, err := svc.HandleOIDCCallback(ctx, &OIDCCallbackRequest{State: state, Code: code})
+
+			assert.Error(t, err)
+			assert.Contains(t, redirect, "error=")
+			users.AssertNotCalled(t, "FindEmployeeByCompanyEmail", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("domain without a provider", func(t *testing.T) {
+		svc, _, _, _, _ := newTestSSOService(t)
+
+		_, err := svc.Start(ctx, &StartSSORequest{Email: "jane@elsewhere.com"})
+
+		assert.ErrorIs(t, err, ErrSSONotConfigured)
+	})
+
+	t.Run("enabled provider on an unverified domain", func(t *testing.T) {
+		svc, repo, _, _, _ := newTestSSOService(t)
+		idp := newOIDCIdP(t)
+		require.NoError(t, repo.Save(ctx, &IdentityProvider{
+			CompanyID: 1, Protocol: constants.SSOProtocolOIDC, Domain: "acme.com", Enabled: true,
+			IssuerURL: idp.server.URL, ClientID: "basekarya", ClientSecret: "s3cret",
+		}))
+
+		_, err := svc.Start(ctx, &StartSSORequest{Email: "jane@acme.com"})
+
+		assert.ErrorIs(t, err, ErrSSONotConfigured)
+	})
+}
+
+func TestSAMLLogin(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, _, users, _ := newTestSSOService(t)
+	idp := newSAMLIdP()
+	verifiedAt := time.Now()
+	require.NoError(t, repo.Save(ctx, &IdentityProvider{
+		CompanyID: 1, Protocol: constants.SSOProtocolSAML, Domain: "acme.com", Enabled: true, DomainVerifiedAt: &verifiedAt,
+		MetadataXML: idp.metadata(t),
+	}))
+	users.On("FindEmployeeByCompanyEmail", ctx, uint(1), "jane@acme.com").Return(&user.Employee{ID: 2, UserID: 5}, nil)
+
+	start, err := svc.Start(ctx, &StartSSORequest{Email: "jane@acme.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "SAML", start.Protocol)
+
+	parsed, err := url.Parse(start.RedirectURL)
+	require.NoError(t, err)
+	relayState := parsed.Query().Get("RelayState")
+	requestID := regexp.MustCompile(`ID="(_[0-9a-f]+)"`).FindStringSubmatch(inflateSAMLRequest(t, parsed.Query().Get("SAMLRequest")))
+	require.NotNil(t, requestID)
+
+	expect := samlExpectation{
+		RequestID:  requestID[1],
+		SPEntityID: "http://localhost:8080/api/v1/auth/sso/saml/metadata",
+		ACSURL:     "http://localhost:8080/api/v1/auth/sso/saml/acs",
+	}
+
+	t.Run("response for another request refused", func(t *testing.T) {
+		other := expect
+		other.RequestID = "_other"
+		_, err := svc.HandleSAMLResponse(ctx, &SAMLResponseRequest{SAMLResponse: idp.response(t, other, samlResponseOptions{}), RelayState: relayState})
+		assert.Error(t, err)
+	})
+
+	t.Run("state spent by the refused response", func(t *testing.T) {
+		_, err := svc.HandleSAMLResponse(ctx, &SAMLResponseRequest{SAMLResponse: idp.response(t, expect, samlResponseOptions{}), RelayState: relayState})
+		assert.ErrorIs(t, err, ErrInvalidSSOState)
+	})
+
+	t.Run("signed response issues a ticket", func(t *testing.T) {
+		start, err := svc.Start(ctx, &StartSSORequest{Email: "jane@acme.com"})
+		require.NoError(t, err)
+		parsed, _ := url.Parse(start.RedirectURL)
+		requestID := regexp.MustCompile(`ID="(_[0-9a-f]+)"`).FindStringSubmatch(inflateSAMLRequest(t, parsed.Query().Get("SAMLRequest")))
+		expect.RequestID = requestID[1]
+
+		redirect, err := svc.HandleSAMLResponse(ctx, &SAMLResponseRequest{
+			SAMLResponse: idp.response(t, expect, samlResponseOptions{}),
+			RelayState:   parsed.Query().Get("RelayState"),
+		})
+
+		require.NoError(t, err)
+		assert.Regexp(t, ticketPattern, redirect)
+	})
+}
+
+func TestSaveProvider(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("OIDC provider checked against its discovery document", func(t *testing.T) {
+		svc, repo, _, _, _ := newTestSSOService(t)
+		idp := newOIDCIdP(t)
+
+		resp, err := svc.SaveProvider(ctx, &SaveIdentityProviderRequest{
+			Protocol: "OIDC", Domain: "ACME.com", Enabled: true, SSOOnly: true,
+			IssuerURL: idp.server.URL + "/", ClientID: "basekarya", ClientSecret: "s3cret",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "acme.com", resp.Domain)
+		assert.True(t, resp.HasClientSecret)
+		assert.Equal(t, "http://localhost:8080/api/v1/auth/sso/oidc/callback", resp.RedirectURI)
+		assert.False(t, resp.DomainVerified)
+		assert.Equal(t, "_basekarya-verification.acme.com", resp.VerificationRecord)
+		assert.Regexp(t, `^basekarya-domain-verification=[0-9a-f]{32}$`, resp.VerificationValue)
+
+		// a blank secret on update keeps the stored one
+		_, err = svc.SaveProvider(ctx, &SaveIdentityProviderRequest{
+			Protocol: "OIDC", Domain: "acme.com", Enabled: true, IssuerURL: idp.server.URL, ClientID: "basekarya",
+		})
+		require.NoError(t, err)
+		stored, err := repo.FindByCompanyID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", stored.ClientSecret)
+		assert.False(t, stored.SSOOnly)
+		assert.Equal(t, resp.VerificationValue, "basekarya-domain-verification="+stored.VerificationToken)
+	})
+
+	t.Run("plain http issuer refused", func(t *testing.T) {
+		svc, _, _, _, _ := newTestSSOService(t)
+
+		_, err := svc.SaveProvider(ctx, &SaveIdentityProviderRequest{
+			Protocol: "OIDC", Domain: "acme.com", IssuerURL: "http://idp.acme.com", ClientID: "basekarya", ClientSecret: "s3cret",
+		})
+
+		assert.ErrorContains(t, err, "must use https")
+	})
+
+	t.Run("issuer on an internal address refused", func(t *testing.T) {
+		svc, _, _, _, _ := newTestSSOService(t)
+		idp := newOIDCIdP(t)
+		allowAddress = isPublicAddress
+
+		_, err := svc.SaveProvider(ctx, &SaveIdentityProviderRequest{
+			Protocol: "OIDC", Domain: "acme.com", IssuerURL: idp.server.URL, ClientID: "basekarya", ClientSecret: "s3cret",
+		})
+
+		assert.ErrorContains(t, err, "non-public address")
+	})
+
+	t.Run("unreachable issuer refused", func(t *testing.T) {
+		svc, _, _, _, _ := newTestSSOService(t)
+		idp := newOIDCIdP(t)
+		idp.server.Close()
+
+		_, err := svc.SaveProvider(ctx, &SaveIdentityProviderRequest{
+			Protocol: "OIDC", Domain: "acme.com", IssuerURL: idp.server.URL, ClientID: "basekarya", ClientSecret: "s3cret",
+		})
+
+		assert.ErrorContains(t, err, "discovery document")
+	})
+
+	t.Run("invalid SAML metadata refused", func(t *testing.T) {
+		svc, _, _, _, _ := newTestSSOService(t)
+
+		_, err := svc.SaveProvider(ctx, &SaveIdentityProviderRequest{Protocol: "SAML", Domain: "acme.com", MetadataXML: "<nope/>"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("domain of another company refused", func(t *testing.T) {
+		svc, repo, _, _, _ := newTestSSOService(t)
+		require.NoError(t, repo.Save(ctx, &IdentityProvider{CompanyID: 2, Protocol: constants.SSOProtocolSAML, Domain: "acme.com"}))
+
+		_, err := svc.SaveProvider(ctx, &SaveIdentityProviderRequest{Protocol: "SAML", Domain: "acme.com", MetadataXML: newSAMLIdP().metadata(t)})
+
+		assert.EqualError(t, err, "this domain is already used by another company")
+	})
+
+	t.Run("SSO only needs an enabled provider", func(t *testing.T) {
+		svc, _, _, _, _ := newTestSSOService(t)
+
+		_, err := svc.SaveProvider(ctx, &SaveIdentityProviderRequest{Protocol: "SAML", Domain: "acme.com", SSOOnly: true, MetadataXML: newSAMLIdP().metadata(t)})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyDomain(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	resolver := new(mockDNSResolver)
+	svc, repo, _, _, _ := newTestSSOServiceWithResolver(t, resolver)
+
+	_, err := svc.VerifyDomain(ctx)
+	assert.ErrorIs(t, err, ErrSSONotConfigured)
+
+	saved, err := svc.SaveProvider(ctx, &SaveIdentityProviderRequest{Protocol: "SAML", Domain: "acme.com", Enabled: true, MetadataXML: newSAMLIdP().metadata(t)})
+	require.NoError(t, err)
+
+	t.Run("missing record", func(t *testing.T) {
+		resolver.On("LookupTXT", ctx, "_basekarya-verification.acme.com").Return(nil, &net.DNSError{Err: "no such host", IsNotFound: true}).Once()
+
+		_, err := svc.VerifyDomain(ctx)
+
+		assert.ErrorIs(t, err, ErrDomainNotVerified)
+		_, err = svc.Start(ctx, &StartSSORequest{Email: "jane@acme.com"})
+		assert.ErrorIs(t, err, ErrSSONotConfigured)
+	})
+
+	t.Run("record of another token", func(t *testing.T) {
+		resolver.On("LookupTXT", ctx, "_basekarya-verification.acme.com").Return([]string{"basekarya-domain-verification=other"}, nil).Once()
+
+		_, err := svc.VerifyDomain(ctx)
+
+		assert.ErrorIs(t, err, ErrDomainNotVerified)
+	})
+
+	t.Run("published record verifies the domain", func(t *testing.T) {
+		resolver.On("LookupTXT", ctx, "_basekarya-verification.acme.com").Return([]string{"v=spf1 -all", saved.VerificationValue}, nil).Once()
+
+		resp, err := svc.VerifyDomain(ctx)
+
+		require.NoError(t, err)
+		assert.True(t, resp.DomainVerified)
+		start, err := svc.Start(ctx, &StartSSORequest{Email: "jane@acme.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "SAML", start.Protocol)
+	})
+
+	t.Run("moving to another domain asks for a new record", func(t *testing.T) {
+		resp, err := svc.SaveProvider(ctx, &SaveIdentityProviderRequest{Protocol: "SAML", Domain: "acme.io", Enabled: true, MetadataXML: newSAMLIdP().metadata(t)})
+
+		require.NoError(t, err)
+		assert.False(t, resp.DomainVerified)
+		assert.NotEqual(t, saved.VerificationValue, resp.VerificationValue)
+		stored, err := repo.FindByCompanyID(ctx, 1)
+		require.NoError(t, err)
+		assert.Nil(t, stored.DomainVerifiedAt)
+	})
+}
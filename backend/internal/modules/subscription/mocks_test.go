@@ -146,6 +146,26 @@ func (m *mockCompanyRepo) FindModulesByCompanyID(ctx context.Context, companyID
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *mockCompanyRepo) FindMFAPermissions(ctx context.Context, companyID uint) ([]string, error) {
+	args := m.Called(ctx, companyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockCompanyRepo) ReplaceMFAPermissions(ctx context.Context, companyID uint, names []string) error {
+	return m.Called(ctx, companyID, names).Error(0)
+}
+
+func (m *mockCompanyRepo) FindPermissionNames(ctx context.Context, names []string) ([]string, error) {
+	args := m.Called(ctx, names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 type mockRole struct{ mock.Mock }
 
 func (m *mockRole) FindPermissionIDsByGroupNames(ctx context.Context, groupNames []string) ([]uint, error) {
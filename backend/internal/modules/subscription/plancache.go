@@ -63,6 +63,11 @@ func (s *PlanCacheService) HasAccess(ctx context.Context, companyID uint, module
 }
 
 func (s *PlanCacheService) CheckEmployeeLimit(ctx context.Context) (bool, error) {
+	return s.CheckEmployeeCapacity(ctx, 1)
+}
+
+// CheckEmployeeCapacity reports whether the company's plan leaves room for that many more active employees.
+func (s *PlanCacheService) CheckEmployeeCapacity(ctx context.Context, additional int) (bool, error) {
 	companyID := utils.GetCompanyIDFromCtx(ctx)
 	if companyID == 0 {
 		return true, nil
@@ -91,7 +96,7 @@ func (s *PlanCacheService) CheckEmployeeLimit(ctx context.Context) (bool, error)
 		return false, err
 	}
 
-	return count < int64(maxEmployees), nil
+	return count+int64(additional) <= int64(maxEmployees), nil
 }
 
 func (s *PlanCacheService) Del(ctx context.Context, key string) error {
@@ -143,3 +143,23 @@ func TestPlanCacheService_CheckEmployeeLimit(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, allowed)
 }
+
+func TestPlanCacheService_CheckEmployeeCapacity(t *testing.T) {
+	svc, mr, db := setupPlanCacheTest(t)
+	defer mr.Close()
+
+	db.Exec(`INSERT INTO subscription_plans (id, name, slug, max_employees, price_monthly, features, is_active) VALUES (1, 'Basic', 'basic', 5, 29.00, '{"modules":["attendance"]}', 1)`)
+	db.Exec(`INSERT INTO companies (id, name, subscription_plan_id, subscription_status) VALUES (1, 'TestCo', 1, 'ACTIVE')`)
+	db.Exec(`INSERT INTO roles (id, name, company_id) VALUES (1, 'EMPLOYEE', 1)`)
+	db.Exec(`INSERT INTO users (id, company_id, role_id, is_active) VALUES (1, 1, 1, 1), (2, 1, 1, 1), (3, 1, 1, 1)`)
+
+	ctx := context.WithValue(context.Background(), constants.CompanyIDContextKey, uint(1))
+
+	allowed, err := svc.CheckEmployeeCapacity(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = svc.CheckEmployeeCapacity(ctx, 3)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
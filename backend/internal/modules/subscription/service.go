@@ -161,6 +161,7 @@ func (s *service) ReviewRequest(ctx context.Context, requestID uint, req *Review
 				roleIDs, _ := s.role.FindRoleIDsByCompanyID(ctx, subReq.CompanyID)
 				for _, roleID := range roleIDs {
 					_ = s.role.AssignPermissions(ctx, roleID, permissionIDs, subReq.CompanyID)
+					_ = s.cache.Del(ctx, fmt.Sprintf(constants.ROLE_GRANTS_CACHE_KEY, roleID))
 				}
 			}
 		}
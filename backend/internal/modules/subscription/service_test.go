@@ -297,6 +297,7 @@ func TestService_ReviewRequest(t *testing.T) {
 				role.On("FindPermissionIDsByGroupNames", mock.Anything, mock.Anything).Return([]uint{1, 2}, nil)
 				role.On("FindRoleIDsByCompanyID", mock.Anything, uint(1)).Return([]uint{1}, nil)
 				role.On("AssignPermissions", mock.Anything, uint(1), []uint{1, 2}, uint(1)).Return(nil)
+				cache.On("Del", mock.Anything, "role:grants:1").Return(nil)
 				cache.On("Del", mock.Anything, "subscription:features:1").Return(nil)
 				cache.On("Del", mock.Anything, "company:profile:1").Return(nil)
 				user.On("ForceResetPasswordByCompanyID", mock.Anything, uint(1)).Return(nil)
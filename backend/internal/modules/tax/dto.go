@@ -1,5 +1,7 @@
 package tax
 
+import "basekarya-backend/pkg/constants"
+
 type PPh21Result struct {
 	TERCategory  string  `json:"ter_category"`
 	PTKPCode     string  `json:"ptkp_code"`
@@ -18,6 +20,24 @@ type AnnualSettlement struct {
 	Delta        float64 `json:"delta"`
 }
 
+// TerminationTaxRequest is the income of the year of an employee leaving the company.
+type TerminationTaxRequest struct {
+	Year            int
+	MaritalStatus   constants.MaritalStatus
+	DependentsCount int
+	// regular income paid by payroll this year with the PPh 21 withheld on it
+	MonthlyDetails []MonthlyTaxEntry
+	// regular income paid with the final settlement, such as the prorated salary
+	FinalIncome float64
+	// severance, service and compensation pay, taxed final apart from the regular income
+	SeverancePay float64
+}
+
+type TerminationTax struct {
+	Settlement   AnnualSettlement `json:"settlement"`
+	SeveranceTax float64          `json:"severance_tax"`
+}
+
 type Form1721A1 struct {
 	Year             int               `json:"year"`
 	EmployeeName     string            `json:"employee_name"`
@@ -34,6 +34,14 @@ func (m *mockServiceHandler) ReconcileAnnual(ctx context.Context, year int, code
 	return args.Get(0).(*AnnualSettlement), args.Error(1)
 }
 
+func (m *mockServiceHandler) SettleTermination(ctx context.Context, req *TerminationTaxRequest) (*TerminationTax, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TerminationTax), args.Error(1)
+}
+
 func (m *mockServiceHandler) CreateTERBracket(ctx context.Context, req *TERBracketRequest) error {
 	return m.Called(ctx, req).Error(0)
 }
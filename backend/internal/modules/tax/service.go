@@ -13,6 +13,7 @@ import (
 type Service interface {
 	CalculateTER(ctx context.Context, grossMonthlyIncome float64, maritalStatus constants.MaritalStatus, dependentsCount int) (*PPh21Result, error)
 	ReconcileAnnual(ctx context.Context, year int, ptkpCode string, grossAnnual float64, monthlyDetails []MonthlyTaxEntry) (*AnnualSettlement, error)
+	SettleTermination(ctx context.Context, req *TerminationTaxRequest) (*TerminationTax, error)
 	CreateTERBracket(ctx context.Context, req *TERBracketRequest) error
 	GetTERBracketByID(ctx context.Context, id uint) (*TERBracket, error)
 	UpdateTERBracket(ctx context.Context, id uint, req *TERBracketRequest) error
@@ -92,6 +93,25 @@ func (s *service) ReconcileAnnual(ctx context.Context, year int, ptkpCode string
 	}, nil
 }
 
+// SettleTermination settles the PPh 21 of an employee leaving during the year. The regular income is reconciled
+// over the months worked instead of annualized, severance is taxed final apart from it (PP 68/2009).
+func (s *service) SettleTermination(ctx context.Context, req *TerminationTaxRequest) (*TerminationTax, error) {
+	grossAnnual := req.FinalIncome
+	for _, m := range req.MonthlyDetails {
+		grossAnnual += m.GrossIncome
+	}
+
+	settlement, err := s.ReconcileAnnual(ctx, req.Year, derivePTKPCode(req.MaritalStatus, req.DependentsCount), grossAnnual, req.MonthlyDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TerminationTax{
+		Settlement:   *settlement,
+		SeveranceTax: calculateSeveranceTax(req.SeverancePay),
+	}, nil
+}
+
 func (s *service) CreateTERBracket(ctx context.Context, req *TERBracketRequest) error {
 	from, err := time.Parse("2006-01-02", req.EffectiveFrom)
 	if err != nil {
@@ -266,3 +286,32 @@ func calculateProgressiveTax(pkp float64) float64 {
 	}
 	return math.Floor(totalTax)
 }
+
+// calculateSeveranceTax applies the final tax brackets on severance paid at once (PP 68/2009 art. 5).
+func calculateSeveranceTax(severance float64) float64 {
+	if severance <= 0 {
+		return 0
+	}
+	brackets := []struct {
+		limit, rate float64
+	}{
+		{50000000, 0},
+		{100000000, 0.05},
+		{500000000, 0.15},
+	}
+	var totalTax, prevLimit float64
+	remaining := severance
+	for _, b := range brackets {
+		if remaining <= 0 {
+			break
+		}
+		taxable := math.Min(remaining, b.limit-prevLimit)
+		totalTax += taxable * b.rate
+		remaining -= taxable
+		prevLimit = b.limit
+	}
+	if remaining > 0 {
+		totalTax += remaining * 0.25
+	}
+	return math.Floor(totalTax)
+}
@@ -397,3 +397,52 @@ func TestService_CRUD_PTKPConfig(t *testing.T) {
 
 	m.AssertExpectations(t)
 }
+
+func TestCalculateSeveranceTax(t *testing.T) {
+	tests := []struct {
+		severance float64
+		expected  float64
+	}{
+		{0, 0},
+		{50000000, 0},
+		{100000000, 2500000},
+		{500000000, 62500000},
+		{600000000, 87500000},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, calculateSeveranceTax(tt.severance), "severance=%.0f", tt.severance)
+	}
+}
+
+func TestSettleTermination(t *testing.T) {
+	m := newMockRepo()
+	svc := NewService(m)
+	ctx := context.Background()
+
+	m.On("FindPTKPByYear", ctx, 2026).Return([]PTKPConfig{
+		{Code: "TK/0", AnnualAmount: 54000000, EffectiveYear: 2026},
+		{Code: "K/1", AnnualAmount: 63000000, EffectiveYear: 2026},
+	}, nil)
+
+	// left in April with three paid payrolls and half a month of salary in the settlement
+	result, err := svc.SettleTermination(ctx, &TerminationTaxRequest{
+		Year:            2026,
+		MaritalStatus:   constants.MaritalStatusMarried,
+		DependentsCount: 1,
+		MonthlyDetails: []MonthlyTaxEntry{
+			{Month: 1, GrossIncome: 20000000, PPh21Paid: 300000},
+			{Month: 2, GrossIncome: 20000000, PPh21Paid: 300000},
+			{Month: 3, GrossIncome: 20000000, PPh21Paid: 300000},
+		},
+		FinalIncome:  10000000,
+		SeverancePay: 100000000,
+	})
+	assert.NoError(t, err)
+
+	// 70.000.000 gross - 3.500.000 biaya jabatan - 63.000.000 PTKP K/1
+	assert.Equal(t, 70000000.0, result.Settlement.GrossAnnual)
+	assert.Equal(t, 3500000.0, result.Settlement.PKP)
+	assert.Equal(t, 175000.0, result.Settlement.TaxPayable)
+	assert.Equal(t, 175000.0-900000.0, result.Settlement.Delta)
+	assert.Equal(t, 2500000.0, result.SeveranceTax)
+}
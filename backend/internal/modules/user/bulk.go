@@ -0,0 +1,533 @@
+package user
+
+import (
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	employeeImportSheet   = "Employees"
+	employeeImportMaxRows = 1000
+)
+
+// ErrInvalidEmployeeImport is returned with the report when any row fails validation, nothing is imported then.
+var ErrInvalidEmployeeImport = errors.New("the file has errors, no employee was imported")
+
+// employeeImportColumns is the template's header row, an import reads columns by these names in any order.
+var employeeImportColumns = []string{
+	"Full Name", "NIK", "Email", "Department", "Shift", "Role", "Position", "Grade", "Base Salary",
+	"Marital Status", "Dependents", "Join Date",
+}
+
+// employeeImportRow is a validated row, ready to be created as is.
+type employeeImportRow struct {
+	row      int
+	req      CreateEmployeeRequest
+	joinDate time.Time
+}
+
+func (s *service) GenerateEmployeeImportTemplate(ctx context.Context) ([]byte, error) {
+	departments, err := s.repo.FindDepartments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch departments: %w", err)
+	}
+	shifts, err := s.repo.FindShifts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch shifts: %w", err)
+	}
+	roles, err := s.repo.FindRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+
+	f := s.excel.NewFile()
+	styleHeader, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#DDEBF7"}, Pattern: 1},
+		Font: &excelize.Font{Color: "#1F4E78", Bold: true},
+	})
+
+	f.SetSheetName("Sheet1", employeeImportSheet)
+	for col, header := range employeeImportColumns {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+		f.SetCellValue(employeeImportSheet, cell, header)
+		f.SetCellStyle(employeeImportSheet, cell, cell, styleHeader)
+	}
+	f.SetColWidth(employeeImportSheet, "A", "L", 18)
+
+	// the names an import accepts, with the formats of the free text columns
+	const referenceSheet = "Reference"
+	if _, err := f.NewSheet(referenceSheet); err != nil {
+		return nil, err
+	}
+
+	reference := [][]string{
+		{"Department"}, {"Shift"}, {"Role"},
+		{"Marital Status", "TK", "K"},
+		{"Join Date", "YYYY-MM-DD, empty for today"},
+	}
+	for _, dept := range departments {
+		reference[0] = append(reference[0], dept.Name)
+	}
+	for _, shift := range shifts {
+		reference[1] = append(reference[1], shift.Name)
+	}
+	for _, role := range roles {
+		reference[2] = append(reference[2], role.Name)
+	}
+
+	for col, values := range reference {
+		for row, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(col+1, row+1)
+			f.SetCellValue(referenceSheet, cell, value)
+			if row == 0 {
+				f.SetCellStyle(referenceSheet, cell, cell, styleHeader)
+			}
+		}
+	}
+	f.SetColWidth(referenceSheet, "A", "E", 24)
+
+	return s.excel.WriteToBuffer(f)
+}
+
+func (s *service) ValidateEmployeeImport(ctx context.Context, file *multipart.FileHeader) (*EmployeeImportReport, error) {
+	report, _, err := s.checkEmployeeImport(ctx, file)
+	return report, err
+}
+
+// ImportEmployees creates every employee of the file in one transaction, or none when a row is invalid.
+func (s *service) ImportEmployees(ctx context.Context, file *multipart.FileHeader) (*EmployeeImportReport, error) {
+	report, rows, err := s.checkEmployeeImport(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	if report.HasErrors() {
+		return report, ErrInvalidEmployeeImport
+	}
+
+	created := make([]*createdEmployee, 0, len(rows))
+	err = s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		for _, row := range rows {
+			employee, err := s.createEmployee(ctx, &row.req, row.req.RoleID, row.joinDate, nil, nil)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", row.row, err)
+			}
+			created = append(created, employee)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Imported = make([]ImportedEmployee, 0, len(created))
+	for i, employee := range created {
+		s.sendCredentials(employee)
+		report.Imported = append(report.Imported, ImportedEmployee{
+			Row:      rows[i].row,
+			FullName: employee.fullName,
+			Username: employee.username,
+		})
+	}
+
+	return report, nil
+}
+
+// checkEmployeeImport reads the file and validates every row, problems with the file itself go into the report
+// and only failures to check it are returned as an error.
+func (s *service) checkEmployeeImport(ctx context.Context, file *multipart.FileHeader) (*EmployeeImportReport, []employeeImportRow, error) {
+	report := &EmployeeImportReport{FileErrors: []string{}, RowErrors: []EmployeeImportRowError{}}
+
+	sheetRows, err := readEmployeeImportSheet(file)
+	if err != nil {
+		report.FileErrors = append(report.FileErrors, err.Error())
+		return report, nil, nil
+	}
+
+	columns := make(map[string]int)
+	if len(sheetRows) > 0 {
+		for i, header := range sheetRows[0] {
+			columns[strings.ToLower(strings.TrimSpace(header))] = i
+		}
+	}
+	for _, column := range employeeImportColumns {
+		if _, ok := columns[strings.ToLower(column)]; !ok {
+			report.FileErrors = append(report.FileErrors, fmt.Sprintf("column %q is missing, use the import template", column))
+		}
+	}
+	if len(report.FileErrors) > 0 {
+		return report, nil, nil
+	}
+
+	departments, shifts, roles, err := s.employeeImportReferences(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []employeeImportRow
+	rowErrors := make(map[int][]string)
+	nikRows := make(map[string]int)
+	emailRows := make(map[string]int)
+
+	for i := 1; i < len(sheetRows); i++ {
+		cell := func(column string) string {
+			index := columns[strings.ToLower(column)]
+			if index >= len(sheetRows[i]) {
+				return ""
+			}
+			return strings.TrimSpace(sheetRows[i][index])
+		}
+		if isBlankRow(sheetRows[i]) {
+			continue
+		}
+
+		report.TotalRows++
+		if report.TotalRows > employeeImportMaxRows {
+			report.FileErrors = append(report.FileErrors, fmt.Sprintf("the file has more than %d employees, split it into several imports", employeeImportMaxRows))
+			return report, nil, nil
+		}
+
+		rowNumber := i + 1
+		row, errs := parseEmployeeImportRow(cell, departments, shifts, roles)
+		row.row = rowNumber
+
+		if row.req.NIK != "" {
+			// compared as the blind index compares them
+			nik := strings.ToUpper(row.req.NIK)
+			if first, ok := nikRows[nik]; ok {
+				errs = append(errs, fmt.Sprintf("NIK is already used on row %d", first))
+			} else {
+				nikRows[nik] = rowNumber
+			}
+		}
+		if row.req.Email != "" {
+			email := strings.ToLower(row.req.Email)
+			if first, ok := emailRows[email]; ok {
+				errs = append(errs, fmt.Sprintf("Email is already used on row %d", first))
+			} else {
+				emailRows[email] = rowNumber
+			}
+		}
+
+		if len(errs) > 0 {
+			rowErrors[rowNumber] = errs
+		}
+		rows = append(rows, row)
+	}
+
+	if report.TotalRows == 0 {
+		report.FileErrors = append(report.FileErrors, "the file has no employees")
+		return report, nil, nil
+	}
+
+	// NIKs and emails already on record, the NIK is matched through its blind index as the column is encrypted
+	nikByIndex := make(map[string]string, len(nikRows))
+	indexes := make([]string, 0, len(nikRows))
+	for nik := range nikRows {
+		index := infrastructure.BlindIndex(nik)
+		nikByIndex[index] = nik
+		indexes = append(indexes, index)
+	}
+	existingIndexes, err := s.repo.FindExistingNIKIndexes(ctx, indexes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check existing NIKs: %w", err)
+	}
+	for _, index := range existingIndexes {
+		rowNumber := nikRows[nikByIndex[index]]
+		rowErrors[rowNumber] = append(rowErrors[rowNumber], "NIK is already registered")
+	}
+
+	emails := make([]string, 0, len(emailRows))
+	for email := range emailRows {
+		emails = append(emails, email)
+	}
+	existingEmails, err := s.repo.FindExistingEmails(ctx, emails)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check existing emails: %w", err)
+	}
+	for _, email := range existingEmails {
+		rowNumber := emailRows[strings.ToLower(email)]
+		rowErrors[rowNumber] = append(rowErrors[rowNumber], "Email is already registered")
+	}
+
+	// the plan limit counts employees in the EMPLOYEE role, as for a single new employee
+	newEmployees := 0
+	for _, row := range rows {
+		if roles.employeeRoleID != 0 && row.req.RoleID == roles.employeeRoleID {
+			newEmployees++
+		}
+	}
+	if s.subscription != nil && newEmployees > 0 {
+		allowed, err := s.subscription.CheckEmployeeCapacity(ctx, newEmployees)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check employee limit: %w", err)
+		}
+		if !allowed {
+			report.FileErrors = append(report.FileErrors, fmt.Sprintf("adding %d employees exceeds the employee limit of your subscription plan, please upgrade or import fewer employees", newEmployees))
+		}
+	}
+
+	for _, row := range rows {
+		if errs, ok := rowErrors[row.row]; ok {
+			report.RowErrors = append(report.RowErrors, EmployeeImportRowError{Row: row.row, Errors: errs})
+		}
+	}
+	report.ValidRows = report.TotalRows - len(report.RowErrors)
+
+	return report, rows, nil
+}
+
+// employeeImportRefs maps the lowercased names a row may use to their ids.
+type employeeImportRefs struct {
+	ids            map[string]uint
+	employeeRoleID uint
+}
+
+func (s *service) employeeImportReferences(ctx context.Context) (departments, shifts map[string]uint, roles employeeImportRefs, err error) {
+	departmentList, err := s.repo.FindDepartments(ctx)
+	if err != nil {
+		return nil, nil, roles, fmt.Errorf("failed to fetch departments: %w", err)
+	}
+	shiftList, err := s.repo.FindShifts(ctx)
+	if err != nil {
+		return nil, nil, roles, fmt.Errorf("failed to fetch shifts: %w", err)
+	}
+	roleList, err := s.repo.FindRoles(ctx)
+	if err != nil {
+		return nil, nil, roles, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+
+	departments = make(map[string]uint, len(departmentList))
+	for _, dept := range departmentList {
+		departments[strings.ToLower(dept.Name)] = dept.ID
+	}
+	shifts = make(map[string]uint, len(shiftList))
+	for _, shift := range shiftList {
+		shifts[strings.ToLower(shift.Name)] = shift.ID
+	}
+	roles.ids = make(map[string]uint, len(roleList))
+	for _, role := range roleList {
+		roles.ids[strings.ToLower(role.Name)] = role.ID
+		if role.Name == string(constants.UserRoleEmployee) {
+			roles.employeeRoleID = role.ID
+		}
+	}
+
+	return departments, shifts, roles, nil
+}
+
+// parseEmployeeImportRow applies the rules of a single new employee to one row and resolves its names to ids.
+func parseEmployeeImportRow(cell func(column string) string, departments, shifts map[string]uint, roles employeeImportRefs) (employeeImportRow, []string) {
+	var errs []string
+	row := employeeImportRow{joinDate: time.Now()}
+	req := &row.req
+
+	req.FullName = cell("Full Name")
+	if req.FullName == "" {
+		errs = append(errs, "Full Name is required")
+	}
+
+	req.NIK = cell("NIK")
+	if req.NIK == "" {
+		errs = append(errs, "NIK is required")
+	}
+
+	req.Email = cell("Email")
+	if req.Email == "" {
+		errs = append(errs, "Email is required")
+	} else if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != req.Email {
+		errs = append(errs, "Email is not a valid email address")
+	}
+
+	lookup := func(column string, ids map[string]uint) uint {
+		name := cell(column)
+		if name == "" {
+			errs = append(errs, column+" is required")
+			return 0
+		}
+		id, ok := ids[strings.ToLower(name)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s %q does not exist", column, name))
+		}
+		return id
+	}
+	req.DepartmentID = lookup("Department", departments)
+	req.ShiftID = lookup("Shift", shifts)
+	req.RoleID = lookup("Role", roles.ids)
+
+	req.Position = cell("Position")
+	if length := len([]rune(req.Position)); length < 3 || length > 100 {
+		errs = append(errs, "Position must be between 3 and 100 characters")
+	}
+
+	req.Grade = cell("Grade")
+	if len([]rune(req.Grade)) > 20 {
+		errs = append(errs, "Grade must be at most 20 characters")
+	}
+
+	if salary := cell("Base Salary"); salary == "" {
+		errs = append(errs, "Base Salary is required")
+	} else if amount, err := strconv.ParseFloat(salary, 64); err != nil || amount <= 0 {
+		errs = append(errs, "Base Salary must be a number above 0")
+	} else {
+		req.BaseSalary = amount
+	}
+
+	req.MaritalStatus = strings.ToUpper(cell("Marital Status"))
+	if req.MaritalStatus != "" && req.MaritalStatus != string(constants.MaritalStatusSingle) && req.MaritalStatus != string(constants.MaritalStatusMarried) {
+		errs = append(errs, "Marital Status must be TK or K")
+	}
+
+	if dependents := cell("Dependents"); dependents != "" {
+		count, err := strconv.Atoi(dependents)
+		if err != nil || count < 0 || count > 3 {
+			errs = append(errs, "Dependents must be a whole number from 0 to 3")
+		} else {
+			req.DependentsCount = &count
+		}
+	}
+
+	if joinDate := cell("Join Date"); joinDate != "" {
+		parsed, err := parseImportDate(joinDate)
+		if err != nil {
+			errs = append(errs, "Join Date must be a date in YYYY-MM-DD format")
+		} else {
+			row.joinDate = parsed
+			req.JoinDate = parsed.Format(constants.DefaultTimeFormat)
+		}
+	}
+
+	return row, errs
+}
+
+// parseImportDate accepts a YYYY-MM-DD text or a date cell, which is read as its spreadsheet serial number.
+func parseImportDate(value string) (time.Time, error) {
+	if parsed, err := time.Parse(constants.DefaultTimeFormat, value); err == nil {
+		return parsed, nil
+	}
+
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	parsed, err := excelize.ExcelDateToTime(serial, false)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// readEmployeeImportSheet returns the raw cell values of the workbook's first sheet, numbers and dates unformatted.
+func readEmployeeImportSheet(file *multipart.FileHeader) ([][]string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.New("the file could not be read")
+	}
+	defer src.Close()
+
+	f, err := excelize.OpenReader(src)
+	if err != nil {
+		return nil, errors.New("the file is not an Excel workbook, use the import template")
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("the file has no sheets")
+	}
+
+	rows, err := f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, errors.New("the file could not be read")
+	}
+	return rows, nil
+}
+
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ExportEmployees lists every employee of the company, NIK and salary stay masked without the sensitive permission.
+func (s *service) ExportEmployees(ctx context.Context, showSensitive bool) ([]byte, error) {
+	users, _, err := s.repo.FindAllEmployees(ctx, 1, 999999, "")
+	if err != nil {
+		return nil, err
+	}
+
+	headers := append([]string{"Username"}, employeeImportColumns...)
+	headers = append(headers, "Manager", "Status", "Termination Date")
+
+	var rows [][]interface{}
+	for _, u := range users {
+		if u.Employee == nil {
+			continue
+		}
+		emp := u.Employee
+
+		nik := emp.NIK
+		var salary interface{} = emp.BaseSalary
+		if !showSensitive {
+			nik = utils.MaskString(nik, 4)
+			salary = "-"
+		}
+
+		var deptName, shiftName, roleName, managerName string
+		if emp.Department != nil {
+			deptName = emp.Department.Name
+		}
+		if emp.Shift != nil {
+			shiftName = emp.Shift.Name
+		}
+		if u.Role != nil {
+			roleName = u.Role.Name
+		}
+		if emp.Manager != nil {
+			managerName = emp.Manager.FullName
+		}
+
+		joinDate := ""
+		if emp.JoinDate != nil {
+			joinDate = emp.JoinDate.Format(constants.DefaultTimeFormat)
+		}
+		terminationDate := ""
+		if emp.TerminationDate != nil {
+			terminationDate = emp.TerminationDate.Format(constants.DefaultTimeFormat)
+		}
+
+		rows = append(rows, []interface{}{
+			u.Username,
+			emp.FullName,
+			nik,
+			emp.Email,
+			deptName,
+			shiftName,
+			roleName,
+			emp.Position,
+			emp.Grade,
+			salary,
+			string(emp.MaritalStatus),
+			emp.DependentsCount,
+			joinDate,
+			managerName,
+			string(emp.EmploymentStatus),
+			terminationDate,
+		})
+	}
+
+	return s.excel.GenerateSimpleExcel(employeeImportSheet, headers, rows)
+}
@@ -0,0 +1,292 @@
+package user
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"testing"
+
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/department"
+	"basekarya-backend/internal/modules/master"
+	"basekarya-backend/internal/modules/rbac"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func importFileHeader(t *testing.T, rows [][]interface{}) *multipart.FileHeader {
+	t.Helper()
+
+	f := excelize.NewFile()
+	defer f.Close()
+	for r, row := range rows {
+		for c, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(c+1, r+1)
+			require.NoError(t, f.SetCellValue("Sheet1", cell, value))
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	header, err := testutil.CreateMultipartFileHeader("employees.xlsx", buf.String())
+	require.NoError(t, err)
+	return header
+}
+
+func importHeaderRow() []interface{} {
+	row := make([]interface{}, 0, len(employeeImportColumns))
+	for _, column := range employeeImportColumns {
+		row = append(row, column)
+	}
+	return row
+}
+
+func mockImportReferences(repo *mockRepo) {
+	repo.On("FindDepartments", mock.Anything).Return([]department.Department{{ID: 1, Name: "Engineering"}}, nil)
+	repo.On("FindShifts", mock.Anything).Return([]master.Shift{{ID: 2, Name: "Day"}}, nil)
+	repo.On("FindRoles", mock.Anything).Return([]rbac.Role{{ID: 3, Name: "EMPLOYEE"}, {ID: 4, Name: "HR"}}, nil)
+}
+
+func TestService_ImportEmployees(t *testing.T) {
+	testutil.UseTestKeyring()
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	t.Run("imports every row", func(t *testing.T) {
+		svc, repo, hasher, _, _, leaveGen, _, sub, _, _ := newTestUserService()
+		mockImportReferences(repo)
+		repo.On("FindExistingNIKIndexes", mock.Anything, mock.Anything).Return([]string{}, nil)
+		repo.On("FindExistingEmails", mock.Anything, mock.Anything).Return([]string{}, nil)
+		sub.On("CheckEmployeeCapacity", mock.Anything, 1).Return(true, nil)
+		hasher.On("HashPassword", mock.AnythingOfType("string")).Return("hashedpass", nil)
+		repo.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *User) bool { return u.RoleID == 3 || u.RoleID == 4 })).Return(nil)
+		repo.On("CreateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+		repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool { return e.Type == constants.EmploymentEventHire })).Return(nil)
+		leaveGen.On("GenerateInitialBalance", mock.Anything, mock.Anything).Return(nil)
+
+		file := importFileHeader(t, [][]interface{}{
+			importHeaderRow(),
+			{"Jane Doe", "3201", "jane@example.com", "engineering", "Day", "EMPLOYEE", "Designer", "G3", 5000000, "K", 1, "2026-01-05"},
+			{},
+			{"Budi Santoso", "3202", "budi@example.com", "Engineering", "day", "HR", "HR Officer", "", "7500000", "", "", ""},
+		})
+
+		report, err := svc.ImportEmployees(ctx, file)
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.TotalRows)
+		assert.Equal(t, 2, report.ValidRows)
+		assert.Empty(t, report.RowErrors)
+		require.Len(t, report.Imported, 2)
+		assert.Equal(t, 2, report.Imported[0].Row)
+		assert.Equal(t, 4, report.Imported[1].Row)
+		assert.Equal(t, "Budi Santoso", report.Imported[1].FullName)
+
+		repo.AssertNumberOfCalls(t, "CreateEmployee", 2)
+		repo.AssertCalled(t, "CreateEmployee", mock.Anything, mock.MatchedBy(func(e *Employee) bool {
+			return e.NIK == "3201" && e.DepartmentID == 1 && e.ShiftID == 2 && e.BaseSalary == 5000000 &&
+				e.DependentsCount == 1 && e.JoinDate.Format("2006-01-02") == "2026-01-05"
+		}))
+	})
+
+	t.Run("reports every invalid row and imports nothing", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, sub, _, _ := newTestUserService()
+		mockImportReferences(repo)
+		repo.On("FindExistingNIKIndexes", mock.Anything, mock.Anything).Return([]string{infrastructure.BlindIndex("3201")}, nil)
+		repo.On("FindExistingEmails", mock.Anything, mock.Anything).Return([]string{}, nil)
+		sub.On("CheckEmployeeCapacity", mock.Anything, 3).Return(true, nil)
+
+		file := importFileHeader(t, [][]interface{}{
+			importHeaderRow(),
+			{"Jane Doe", "3201", "jane@example.com", "Engineering", "Day", "EMPLOYEE", "Designer", "", 5000000},
+			{"", "3203", "not-an-email", "Finance", "Day", "EMPLOYEE", "Designer", "", -1, "X", 5, "05/01/2026"},
+			{"Ani", "3204", "JANE@example.com", "Engineering", "Day", "EMPLOYEE", "Designer", "", 5000000},
+		})
+
+		report, err := svc.ImportEmployees(ctx, file)
+		require.ErrorIs(t, err, ErrInvalidEmployeeImport)
+		assert.Equal(t, 3, report.TotalRows)
+		assert.Equal(t, 0, report.ValidRows)
+		require.Len(t, report.RowErrors, 3)
+
+		assert.Equal(t, 2, report.RowErrors[0].Row)
+		assert.Equal(t, []string{"NIK is already registered"}, report.RowErrors[0].Errors)
+
+		assert.Equal(t, 3, report.RowErrors[1].Row)
+		assert.Equal(t, []string{
+			"Full Name is required",
+			"Email is not a valid email address",
+			`Department "Finance" does not exist`,
+			"Base Salary must be a number above 0",
+			"Marital Status must be TK or K",
+			"Dependents must be a whole number from 0 to 3",
+			"Join Date must be a date in YYYY-MM-DD format",
+		}, report.RowErrors[1].Errors)
+
+		assert.Equal(t, 4, report.RowErrors[2].Row)
+		assert.Equal(t, []string{"Email is already used on row 2"}, report.RowErrors[2].Errors)
+
+		repo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("employee limit of the plan", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, sub, _, _ := newTestUserService()
+		mockImportReferences(repo)
+		repo.On("FindExistingNIKIndexes", mock.Anything, mock.Anything).Return([]string{}, nil)
+		repo.On("FindExistingEmails", mock.Anything, mock.Anything).Return([]string{}, nil)
+		sub.On("CheckEmployeeCapacity", mock.Anything, 2).Return(false, nil)
+
+		file := importFileHeader(t, [][]interface{}{
+			importHeaderRow(),
+			{"Jane Doe", "3201", "jane@example.com", "Engineering", "Day", "EMPLOYEE", "Designer", "", 5000000},
+			{"Budi", "3202", "budi@example.com", "Engineering", "Day", "EMPLOYEE", "Designer", "", 5000000},
+		})
+
+		report, err := svc.ImportEmployees(ctx, file)
+		require.ErrorIs(t, err, ErrInvalidEmployeeImport)
+		assert.Equal(t, 2, report.ValidRows)
+		require.Len(t, report.FileErrors, 1)
+		assert.Contains(t, report.FileErrors[0], "employee limit")
+	})
+
+	t.Run("missing columns", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _ := newTestUserService()
+
+		file := importFileHeader(t, [][]interface{}{{"Full Name", "NIK"}, {"Jane Doe", "3201"}})
+
+		report, err := svc.ImportEmployees(ctx, file)
+		require.ErrorIs(t, err, ErrInvalidEmployeeImport)
+		assert.Contains(t, report.FileErrors, `column "Email" is missing, use the import template`)
+		repo.AssertNotCalled(t, "FindDepartments", mock.Anything)
+	})
+
+	t.Run("not a workbook", func(t *testing.T) {
+		svc, _, _, _, _, _, _, _, _, _ := newTestUserService()
+
+		file, err := testutil.CreateMultipartFileHeader("employees.csv", "Full Name,NIK\nJane,3201")
+		require.NoError(t, err)
+
+		report, err := svc.ImportEmployees(ctx, file)
+		require.ErrorIs(t, err, ErrInvalidEmployeeImport)
+		assert.Equal(t, []string{"the file is not an Excel workbook, use the import template"}, report.FileErrors)
+	})
+
+	t.Run("create fails rolls the import back", func(t *testing.T) {
+		svc, repo, hasher, _, _, _, _, sub, _, _ := newTestUserService()
+		mockImportReferences(repo)
+		repo.On("FindExistingNIKIndexes", mock.Anything, mock.Anything).Return([]string{}, nil)
+		repo.On("FindExistingEmails", mock.Anything, mock.Anything).Return([]string{}, nil)
+		sub.On("CheckEmployeeCapacity", mock.Anything, 1).Return(true, nil)
+		hasher.On("HashPassword", mock.AnythingOfType("string")).Return("hashedpass", nil)
+		repo.On("CreateUser", mock.Anything, mock.Anything).Return(errors.New("db error"))
+
+		file := importFileHeader(t, [][]interface{}{
+			importHeaderRow(),
+			{"Jane Doe", "3201", "jane@example.com", "Engineering", "Day", "EMPLOYEE", "Designer", "", 5000000},
+		})
+
+		report, err := svc.ImportEmployees(ctx, file)
+		require.EqualError(t, err, "row 2: db error")
+		assert.Nil(t, report)
+	})
+}
+
+func TestService_ValidateEmployeeImport(t *testing.T) {
+	testutil.UseTestKeyring()
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, repo, _, _, _, _, _, sub, _, _ := newTestUserService()
+	mockImportReferences(repo)
+	repo.On("FindExistingNIKIndexes", mock.Anything, mock.Anything).Return([]string{}, nil)
+	repo.On("FindExistingEmails", mock.Anything, mock.Anything).Return([]string{"jane@example.com"}, nil)
+	sub.On("CheckEmployeeCapacity", mock.Anything, 1).Return(true, nil)
+
+	file := importFileHeader(t, [][]interface{}{
+		importHeaderRow(),
+		{"Jane Doe", "3201", "Jane@Example.com", "Engineering", "Day", "EMPLOYEE", "Designer", "", 5000000},
+	})
+
+	report, err := svc.ValidateEmployeeImport(ctx, file)
+	require.NoError(t, err)
+	require.Len(t, report.RowErrors, 1)
+	assert.Equal(t, []string{"Email is already registered"}, report.RowErrors[0].Errors)
+	repo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+func TestService_GenerateEmployeeImportTemplate(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	svc, repo, _, _, _, _, _, _, _, _ := newTestUserService()
+	mockImportReferences(repo)
+
+	data, err := svc.GenerateEmployeeImportTemplate(ctx)
+	require.NoError(t, err)
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	defer f.Close()
+
+	rows, err := f.GetRows(employeeImportSheet)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, employeeImportColumns, rows[0])
+
+	reference, err := f.GetCols("Reference")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Department", "Engineering"}, reference[0])
+	assert.Equal(t, []string{"Role", "EMPLOYEE", "HR"}, reference[2])
+}
+
+func TestService_ExportEmployees(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	users := []User{{
+		Username: "jane.ab12cd",
+		IsActive: true,
+		Role:     &rbac.Role{Name: "EMPLOYEE"},
+		Employee: &Employee{
+			FullName:         "Jane Doe",
+			NIK:              "3201123456780001",
+			Email:            "jane@example.com",
+			BaseSalary:       5000000,
+			EmploymentStatus: constants.EmploymentStatusProbation,
+			Department:       &department.Department{Name: "Engineering"},
+			Manager:          &Employee{FullName: "John Doe"},
+		},
+	}}
+
+	readRows := func(t *testing.T, data []byte) [][]string {
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		defer f.Close()
+		rows, err := f.GetRows(employeeImportSheet)
+		require.NoError(t, err)
+		return rows
+	}
+
+	t.Run("with sensitive data", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _ := newTestUserService()
+		repo.On("FindAllEmployees", mock.Anything, 1, 999999, "").Return(users, int64(1), nil)
+
+		data, err := svc.ExportEmployees(ctx, true)
+		require.NoError(t, err)
+
+		rows := readRows(t, data)
+		require.Len(t, rows, 2)
+		assert.Equal(t, "Username", rows[0][0])
+		assert.Equal(t, []string{"jane.ab12cd", "Jane Doe", "3201123456780001", "jane@example.com", "Engineering", "", "EMPLOYEE", "", "", "5000000", "", "0", "", "John Doe", "PROBATION"}, rows[1])
+	})
+
+	t.Run("masked", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _ := newTestUserService()
+		repo.On("FindAllEmployees", mock.Anything, 1, 999999, "").Return(users, int64(1), nil)
+
+		data, err := svc.ExportEmployees(ctx, false)
+		require.NoError(t, err)
+
+		rows := readRows(t, data)
+		assert.NotEqual(t, "3201123456780001", rows[1][2])
+		assert.Equal(t, "-", rows[1][9])
+	})
+}
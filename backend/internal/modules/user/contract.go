@@ -27,8 +27,13 @@ type CacheProvider interface {
 
 type SubscriptionProvider interface {
 	CheckEmployeeLimit(ctx context.Context) (bool, error)
+	CheckEmployeeCapacity(ctx context.Context, additional int) (bool, error)
 }
 
 type EmailProvider interface {
 	Send(to, subject, htmlBody string) error
 }
+
+type SessionRevoker interface {
+	RevokeUser(ctx context.Context, userID uint) error
+}
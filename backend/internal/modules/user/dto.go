@@ -38,25 +38,31 @@ type UpdateProfileRequest struct {
 
 type ChangePasswordRequest struct {
 	OldPassword     string `json:"old_password" validate:"required"`
-	NewPassword     string `json:"new_password" validate:"required,min=6,max=72,nefield=OldPassword"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72,nefield=OldPassword"`
 	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
 }
 
 type EmployeeListResponse struct {
-	ID              uint    `json:"id"`
-	FullName        string  `json:"full_name"`
-	NIK             string  `json:"nik"`
-	Username        string  `json:"username"`
-	DepartmentID    uint    `json:"department_id"`
-	DepartmentName  string  `json:"department_name"`
-	ShiftID         uint    `json:"shift_id"`
-	ShiftName       string  `json:"shift_name"`
-	RoleID          uint    `json:"role_id"`
-	BaseSalary      float64 `json:"base_salary"`
-	Email           string  `json:"email"`
-	Position        string  `json:"position"`
-	MaritalStatus   string  `json:"marital_status"`
-	DependentsCount int     `json:"dependents_count"`
+	ID               uint     `json:"id"`
+	FullName         string   `json:"full_name"`
+	NIK              string   `json:"nik"`
+	Username         string   `json:"username"`
+	DepartmentID     uint     `json:"department_id"`
+	DepartmentName   string   `json:"department_name"`
+	ShiftID          uint     `json:"shift_id"`
+	ShiftName        string   `json:"shift_name"`
+	RoleID           uint     `json:"role_id"`
+	BaseSalary       *float64 `json:"base_salary"`
+	Email            string   `json:"email"`
+	Position         string   `json:"position"`
+	Grade            string   `json:"grade"`
+	MaritalStatus    string   `json:"marital_status"`
+	DependentsCount  int      `json:"dependents_count"`
+	JoinDate         string   `json:"join_date"`
+	ManagerID        *uint    `json:"manager_id"`
+	ManagerName      string   `json:"manager_name"`
+	EmploymentStatus string   `json:"employment_status"`
+	TerminationDate  string   `json:"termination_date"`
 }
 
 type CreateEmployeeRequest struct {
@@ -68,8 +74,13 @@ type CreateEmployeeRequest struct {
 	BaseSalary      float64 `json:"base_salary" validate:"required"`
 	Email           string  `json:"email" validate:"required,email"`
 	Position        string  `json:"position" validate:"required,min=3,max=100"`
+	Grade           string  `json:"grade" validate:"omitempty,max=20"`
 	MaritalStatus   string  `json:"marital_status" validate:"omitempty,oneof=TK K ''"`
 	DependentsCount *int    `json:"dependents_count" validate:"omitempty,min=0,max=3"`
+	JoinDate        string  `json:"join_date"`
+	ManagerID       *uint   `json:"manager_id"`
+	// the employee starts on probation until this date, left empty they start active
+	ProbationEndDate string `json:"probation_end_date"`
 }
 
 type CreateEmployeeResponse struct {
@@ -85,6 +96,84 @@ type UpdateEmployeeRequest struct {
 	BaseSalary      float64 `json:"base_salary"`
 	Email           string  `json:"email" validate:"omitempty,email"`
 	Position        string  `json:"position"`
+	Grade           string  `json:"grade" validate:"omitempty,max=20"`
 	MaritalStatus   *string `json:"marital_status" validate:"omitempty,oneof=TK K ''"`
 	DependentsCount *int    `json:"dependents_count" validate:"omitempty,min=0,max=3"`
+	JoinDate        string  `json:"join_date"`
+	ManagerID       *uint   `json:"manager_id"` // nil keeps the current manager, 0 removes it
+}
+
+type OrgChartNode struct {
+	EmployeeID        uint           `json:"employee_id"`
+	FullName          string         `json:"full_name"`
+	Position          string         `json:"position"`
+	DepartmentID      uint           `json:"department_id"`
+	DepartmentName    string         `json:"department_name"`
+	ProfilePictureUrl string         `json:"profile_picture_url"`
+	ManagerID         *uint          `json:"manager_id"`
+	IsDepartmentHead  bool           `json:"is_department_head"`
+	Reports           []OrgChartNode `json:"reports"`
+}
+
+// EmployeeImportReport is the outcome of checking or importing an employee spreadsheet. Rows are numbered as
+// they appear in the sheet, the header being row 1.
+type EmployeeImportReport struct {
+	TotalRows  int                      `json:"total_rows"`
+	ValidRows  int                      `json:"valid_rows"`
+	FileErrors []string                 `json:"file_errors"`
+	RowErrors  []EmployeeImportRowError `json:"row_errors"`
+	Imported   []ImportedEmployee       `json:"imported,omitempty"`
+}
+
+type EmployeeImportRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+type ImportedEmployee struct {
+	Row      int    `json:"row"`
+	FullName string `json:"full_name"`
+	Username string `json:"username"`
+}
+
+// HasErrors reports whether anything in the file keeps it from being imported.
+func (r *EmployeeImportReport) HasErrors() bool {
+	return len(r.FileErrors) > 0 || len(r.RowErrors) > 0
+}
+
+// EmploymentEventRequest records a change in the employment of an employee, sent as a form so a letter or decree
+// can be attached. Hires are recorded when the employee is created.
+type EmploymentEventRequest struct {
+	Type              string  `form:"type" json:"type" validate:"required,oneof=PROBATION_PASS PROMOTION TRANSFER SALARY_CHANGE SUSPENSION REINSTATEMENT RESIGNATION TERMINATION REHIRE"`
+	EffectiveDate     string  `form:"effective_date" json:"effective_date" validate:"required"`
+	EndDate           string  `form:"end_date" json:"end_date"`
+	Position          string  `form:"position" json:"position" validate:"omitempty,min=3,max=100"`
+	DepartmentID      uint    `form:"department_id" json:"department_id"`
+	Grade             string  `form:"grade" json:"grade" validate:"omitempty,max=20"`
+	BaseSalary        float64 `form:"base_salary" json:"base_salary" validate:"omitempty,min=0"`
+	TerminationReason string  `form:"termination_reason" json:"termination_reason" validate:"omitempty,oneof=RESIGNATION ABSENCE MISCONDUCT EFFICIENCY EFFICIENCY_LOSS LONG_ILLNESS RETIREMENT DEATH"`
+	Notes             string  `form:"notes" json:"notes" validate:"omitempty,max=1000"`
+}
+
+// EmploymentEventResponse is an entry of the employment history, the salaries are left out without the sensitive
+// permission.
+type EmploymentEventResponse struct {
+	ID                     uint     `json:"id"`
+	Type                   string   `json:"type"`
+	EffectiveDate          string   `json:"effective_date"`
+	EndDate                string   `json:"end_date"`
+	Position               string   `json:"position"`
+	DepartmentName         string   `json:"department_name"`
+	Grade                  string   `json:"grade"`
+	BaseSalary             *float64 `json:"base_salary"`
+	PreviousPosition       string   `json:"previous_position"`
+	PreviousDepartmentName string   `json:"previous_department_name"`
+	PreviousGrade          string   `json:"previous_grade"`
+	PreviousBaseSalary     *float64 `json:"previous_base_salary"`
+	TerminationReason      string   `json:"termination_reason"`
+	Notes                  string   `json:"notes"`
+	AttachmentURL          string   `json:"attachment_url"`
+	Applied                bool     `json:"applied"`
+	Voided                 bool     `json:"voided"`
+	CreatedAt              string   `json:"created_at"`
 }
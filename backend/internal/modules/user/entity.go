@@ -1,24 +1,32 @@
 package user
 
 import (
+	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/internal/modules/department"
 	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/rbac"
 	"basekarya-backend/pkg/constants"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID                 uint      `gorm:"primaryKey" json:"id"`
-	Username           string    `gorm:"uniqueIndex:idx_users_username_company_id;not null" json:"username"`
-	PasswordHash       string    `json:"-"`
-	RoleID             uint      `gorm:"not null" json:"role_id"`
-	CompanyID          uint      `gorm:"index;not null" json:"company_id"`
-	IsPlatformAdmin    bool      `gorm:"default:false" json:"is_platform_admin"`
-	MustChangePassword bool      `json:"must_change_password"`
-	IsActive           bool      `gorm:"default:true" json:"is_active"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	ID                 uint   `gorm:"primaryKey" json:"id"`
+	Username           string `gorm:"uniqueIndex:idx_users_username_company_id;not null" json:"username"`
+	PasswordHash       string `json:"-"`
+	RoleID             uint   `gorm:"not null" json:"role_id"`
+	CompanyID          uint   `gorm:"index;not null" json:"company_id"`
+	IsPlatformAdmin    bool   `gorm:"default:false" json:"is_platform_admin"`
+	MustChangePassword bool   `json:"must_change_password"`
+	IsActive           bool   `gorm:"default:true" json:"is_active"`
+	PermissionVersion  int64  `gorm:"not null;default:0" json:"-"`
+	// TOTP secret of an enrolled authenticator, logins ask for a code once MFA is enabled
+	MFASecret    string     `gorm:"column:mfa_secret;type:varchar(64)" json:"-"`
+	MFAEnabled   bool       `gorm:"column:mfa_enabled;default:false" json:"mfa_enabled"`
+	MFAEnabledAt *time.Time `gorm:"column:mfa_enabled_at" json:"mfa_enabled_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 
 	Role     *rbac.Role `gorm:"foreignKey:RoleID" json:"role,omitempty"`
 	Employee *Employee  `gorm:"foreignKey:UserID;references:ID" json:"employee,omitempty"`
@@ -30,25 +38,117 @@ type Employee struct {
 	CompanyID         uint   `gorm:"index;not null" json:"company_id"`
 	DepartmentID      uint   `json:"department_id"`
 	ShiftID           uint   `json:"shift_id"`
-	NIK               string `gorm:"unique;not null" json:"nik"`
+	NIK               string `gorm:"serializer:encrypted;type:varchar(255);not null" json:"nik"`
+	NIKIndex          string `gorm:"column:nik_bidx;type:varchar(64);uniqueIndex" json:"-"`
 	FullName          string `json:"full_name"`
 	PhoneNumber       string `json:"phone_number"`
 	ProfilePictureUrl string `json:"profile_picture_url"`
 	Position          string `json:"position"`
+	// job grade, per-claim reimbursement caps are set by grade
+	Grade string `gorm:"type:varchar(20)" json:"grade"`
 
-	BaseSalary float64 `gorm:"type:decimal(15,2);default:0" json:"base_salary"`
+	// salary, bank account and tax id are sealed with the company's data key, see infrastructure.Keyring
+	BaseSalary float64 `gorm:"serializer:encrypted;type:varchar(255)" json:"base_salary"`
 
 	BankName          string `gorm:"type:varchar(50)" json:"bank_name"`
-	BankAccountNumber string `gorm:"type:varchar(50)" json:"bank_account_number"`
+	BankAccountNumber string `gorm:"serializer:encrypted;type:varchar(255)" json:"bank_account_number"`
 	BankAccountHolder string `gorm:"type:varchar(100)" json:"bank_account_holder"`
 
-	NPWP             string                  `gorm:"type:varchar(30)" json:"npwp"`
+	NPWP             string                  `gorm:"serializer:encrypted;type:varchar(255)" json:"npwp"`
 	MaritalStatus    constants.MaritalStatus `gorm:"type:varchar(5)" json:"marital_status"`
 	DependentsCount  int                     `gorm:"type:tinyint;default:0" json:"dependents_count"`
 	Email            string                  `gorm:"type:varchar(255)" json:"email"`
+	JoinDate         *time.Time              `gorm:"type:date" json:"join_date"`
+	ManagerID        *uint                   `gorm:"index" json:"manager_id"`
+
+	// follows the applied employment events, see EmploymentEvent
+	EmploymentStatus constants.EmploymentStatus `gorm:"type:varchar(20);not null;default:ACTIVE" json:"employment_status"`
+
+	// set when the employee is terminated, the user is deactivated with it and cleared again on a rehire
+	TerminationDate   *time.Time                  `gorm:"type:date" json:"termination_date"`
+	TerminationReason constants.TerminationReason `gorm:"type:varchar(30)" json:"termination_reason"`
 
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 
 	Department *department.Department `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
 	Shift      *master.Shift      `gorm:"foreignKey:ShiftID" json:"shift,omitempty"`
+	Manager    *Employee          `gorm:"foreignKey:ManagerID" json:"manager,omitempty"`
+}
+
+// BeforeSave keeps the NIK blind index in step with the NIK, the encrypted column itself can neither be unique
+// nor searched.
+func (e *Employee) BeforeSave(tx *gorm.DB) error {
+	e.NIKIndex = infrastructure.BlindIndex(e.NIK)
+	return nil
+}
+
+// EmploymentEvent is one entry of the employment history of an employee. An event dated in the future waits with
+// no AppliedAt until the user scheduler applies it on its effective date, the Previous fields are filled in then.
+type EmploymentEvent struct {
+	ID            uint                          `gorm:"primaryKey" json:"id"`
+	EmployeeID    uint                          `gorm:"index;not null" json:"employee_id"`
+	CompanyID     uint                          `gorm:"index;not null" json:"company_id"`
+	Type          constants.EmploymentEventType `gorm:"type:varchar(20);not null" json:"type"`
+	EffectiveDate time.Time                     `gorm:"type:date;not null" json:"effective_date"`
+	// end of the probation of a hire, end of a suspension or last working day of a resignation
+	EndDate *time.Time `gorm:"type:date" json:"end_date"`
+
+	Position     string  `gorm:"type:varchar(100)" json:"position"`
+	DepartmentID *uint   `json:"department_id"`
+	Grade        string  `gorm:"type:varchar(20)" json:"grade"`
+	BaseSalary   float64 `gorm:"serializer:encrypted;type:varchar(255)" json:"base_salary"`
+
+	PreviousPosition     string  `gorm:"type:varchar(100)" json:"previous_position"`
+	PreviousDepartmentID *uint   `json:"previous_department_id"`
+	PreviousGrade        string  `gorm:"type:varchar(20)" json:"previous_grade"`
+	PreviousBaseSalary   float64 `gorm:"serializer:encrypted;type:varchar(255)" json:"previous_base_salary"`
+
+	TerminationReason constants.TerminationReason `gorm:"type:varchar(30)" json:"termination_reason"`
+	Notes             string                      `gorm:"type:text" json:"notes"`
+	AttachmentURL     string                      `gorm:"type:varchar(500)" json:"attachment_url"`
+	CreatedBy         uint                        `json:"created_by"`
+	AppliedAt         *time.Time                  `json:"applied_at"`
+	// set on a pending event dropped by a termination or no longer valid when it takes effect
+	VoidedAt  *time.Time `json:"voided_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	Department         *department.Department `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
+	PreviousDepartment *department.Department `gorm:"foreignKey:PreviousDepartmentID" json:"previous_department,omitempty"`
+}
+
+// PasswordHistory keeps the hashes of replaced passwords so a user cannot switch back to a recent one.
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"index;not null" json:"user_id"`
+	CompanyID    uint      `gorm:"index;not null" json:"company_id"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// MFARecoveryCode is a single use code that replaces the authenticator when it is lost, only its hash is kept.
+type MFARecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CompanyID uint       `gorm:"index;not null" json:"company_id"`
+	CodeHash  string     `gorm:"type:varchar(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// reportingLine lists who the employee reports to in order: the direct manager, then the department head.
+func (e *Employee) reportingLine() []uint {
+	var line []uint
+	if e.ManagerID != nil {
+		line = append(line, *e.ManagerID)
+	}
+	if e.Department != nil && e.Department.HeadEmployeeID != nil && *e.Department.HeadEmployeeID != e.ID {
+		if len(line) == 0 || line[0] != *e.Department.HeadEmployeeID {
+			line = append(line, *e.Department.HeadEmployeeID)
+		}
+	}
+	return line
 }
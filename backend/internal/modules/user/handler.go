@@ -1,11 +1,15 @@
 package user
 
 import (
+	"errors"
 	"fmt"
+	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/logger"
 	"basekarya-backend/pkg/response"
 	"basekarya-backend/pkg/utils"
+	"mime/multipart"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
@@ -99,7 +103,15 @@ func (h *Handler) GetAllEmployees(ctx echo.Context) error {
 		fmt.Sscanf(l, "%d", &limit)
 	}
 
-	data, meta, err := h.service.GetAllEmployees(ctx.Request().Context(), page, limit, search)
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	// NIK and salary stay masked for those only allowed to see the employee list
+	showSensitive := userContext.IsPlatformAdmin || slices.Contains(userContext.Permissions, constants.VIEW_SENSITIVE_EMPLOYEE)
+
+	data, meta, err := h.service.GetAllEmployees(ctx.Request().Context(), page, limit, search, showSensitive)
 	if err != nil {
 		logger.Errorw("Get All Employees failed: ", err)
 
@@ -109,6 +121,17 @@ func (h *Handler) GetAllEmployees(ctx echo.Context) error {
 	return response.NewResponses[any](ctx, http.StatusOK, "Success get all employees", data, nil, meta)
 }
 
+func (h *Handler) GetOrgChart(ctx echo.Context) error {
+	data, err := h.service.GetOrgChart(ctx.Request().Context())
+	if err != nil {
+		logger.Errorw("Get Org Chart failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "Failed to get org chart", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Success get org chart", data, nil, nil)
+}
+
 func (h *Handler) CreateEmployee(ctx echo.Context) error {
 	var req CreateEmployeeRequest
 	if err := ctx.Bind(&req); err != nil {
@@ -157,3 +180,137 @@ func (h *Handler) DeleteEmployee(ctx echo.Context) error {
 
 	return response.NewResponses[any](ctx, http.StatusOK, "Employee deleted successfully", nil, nil, nil)
 }
+
+func (h *Handler) GetEmploymentHistory(ctx echo.Context) error {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	// salaries before and after each change stay hidden like on the employee list
+	showSensitive := userContext.IsPlatformAdmin || slices.Contains(userContext.Permissions, constants.VIEW_SENSITIVE_EMPLOYEE)
+
+	data, err := h.service.GetEmploymentHistory(ctx.Request().Context(), uint(id), showSensitive)
+	if err != nil {
+		logger.Errorw("Get employment history failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "Failed to get employment history", nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusOK, "Success get employment history", data, nil, nil)
+}
+
+// RecordEmploymentEvent takes a multipart form, the supporting letter is an optional "file".
+func (h *Handler) RecordEmploymentEvent(ctx echo.Context) error {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	var req EmploymentEventRequest
+	if err := ctx.Bind(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, "Invalid Request", nil, err, nil)
+	}
+
+	file, _ := ctx.FormFile("file")
+	if file != nil && file.Size > 5*1024*1024 {
+		err := fmt.Errorf("file size exceeds 5MB limit")
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	err := h.service.RecordEmploymentEvent(ctx.Request().Context(), uint(id), &req, file)
+	if err != nil {
+		logger.Errorw("failed to record employment event: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	return response.NewResponses[any](ctx, http.StatusCreated, "Employment event recorded successfully", nil, nil, nil)
+}
+
+func (h *Handler) DownloadImportTemplate(ctx echo.Context) error {
+	excelFile, err := h.service.GenerateEmployeeImportTemplate(ctx.Request().Context())
+	if err != nil {
+		logger.Errorw("Generate employee import template failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "Failed to generate import template", nil, err, nil)
+	}
+
+	ctx.Response().Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Response().Header().Set("Content-Disposition", "attachment; filename=employee_import_template.xlsx")
+
+	return ctx.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelFile)
+}
+
+// ValidateImport checks an employee spreadsheet row by row without importing anything.
+func (h *Handler) ValidateImport(ctx echo.Context) error {
+	file, err := importFile(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	report, err := h.service.ValidateEmployeeImport(ctx.Request().Context(), file)
+	if err != nil {
+		logger.Errorw("Validate employee import failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "Failed to validate import", nil, err, nil)
+	}
+
+	return response.NewResponses(ctx, http.StatusOK, "Import validated", report, nil, nil)
+}
+
+func (h *Handler) ImportEmployees(ctx echo.Context) error {
+	file, err := importFile(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusBadRequest, err.Error(), nil, err, nil)
+	}
+
+	report, err := h.service.ImportEmployees(ctx.Request().Context(), file)
+	if err != nil {
+		if errors.Is(err, ErrInvalidEmployeeImport) {
+			return response.NewResponses(ctx, http.StatusUnprocessableEntity, err.Error(), report, err, nil)
+		}
+		logger.Errorw("Import employees failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "Failed to import employees", nil, err, nil)
+	}
+
+	return response.NewResponses(ctx, http.StatusCreated, "Employees imported successfully", report, nil, nil)
+}
+
+func (h *Handler) ExportEmployees(ctx echo.Context) error {
+	userContext, err := utils.GetUserContext(ctx)
+	if err != nil {
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, err.Error(), nil, err, nil)
+	}
+
+	showSensitive := userContext.IsPlatformAdmin || slices.Contains(userContext.Permissions, constants.VIEW_SENSITIVE_EMPLOYEE)
+
+	excelFile, err := h.service.ExportEmployees(ctx.Request().Context(), showSensitive)
+	if err != nil {
+		logger.Errorw("Export employees failed: ", err)
+
+		return response.NewResponses[any](ctx, http.StatusInternalServerError, "Failed to export employees", nil, err, nil)
+	}
+
+	ctx.Response().Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Response().Header().Set("Content-Disposition", "attachment; filename=employees.xlsx")
+
+	return ctx.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelFile)
+}
+
+func importFile(ctx echo.Context) (*multipart.FileHeader, error) {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("file required")
+	}
+
+	if file.Size > 5*1024*1024 {
+		return nil, fmt.Errorf("file size exceeds 5MB limit")
+	}
+
+	return file, nil
+}
@@ -1,15 +1,21 @@
 package user
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
+	"basekarya-backend/pkg/utils"
 
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -234,7 +240,7 @@ func TestHandler_GetAllEmployees(t *testing.T) {
 			name:        "success",
 			queryParams: "?page=1&limit=10",
 			setupMocks: func(svc *mockService) {
-				svc.On("GetAllEmployees", mock.Anything, 1, 10, "").
+				svc.On("GetAllEmployees", mock.Anything, 1, 10, "", false).
 					Return([]EmployeeListResponse{}, (*response.Meta)(nil), nil)
 			},
 			setupContext: func(at *testutil.APITest) {
@@ -245,11 +251,27 @@ func TestHandler_GetAllEmployees(t *testing.T) {
 			},
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:        "success with sensitive fields",
+			queryParams: "?page=1&limit=10",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetAllEmployees", mock.Anything, 1, 10, "", true).
+					Return([]EmployeeListResponse{}, (*response.Meta)(nil), nil)
+			},
+			setupContext: func(at *testutil.APITest) {
+				at.WithAuthContext(&infrastructure.MyClaims{
+					UserID:      1,
+					CompanyID:   1,
+					Permissions: []string{constants.VIEW_EMPLOYEE, constants.VIEW_SENSITIVE_EMPLOYEE},
+				})
+			},
+			wantStatus: http.StatusOK,
+		},
 		{
 			name:        "service error",
 			queryParams: "?page=1&limit=10",
 			setupMocks: func(svc *mockService) {
-				svc.On("GetAllEmployees", mock.Anything, 1, 10, "").
+				svc.On("GetAllEmployees", mock.Anything, 1, 10, "", false).
 					Return([]EmployeeListResponse(nil), (*response.Meta)(nil), errors.New("db error"))
 			},
 			setupContext: func(at *testutil.APITest) {
@@ -284,6 +306,49 @@ func TestHandler_GetAllEmployees(t *testing.T) {
 	}
 }
 
+func TestHandler_GetOrgChart(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetOrgChart", mock.Anything).Return([]OrgChartNode{
+					{EmployeeID: 1, FullName: "Andi", Reports: []OrgChartNode{{EmployeeID: 2, FullName: "Budi", Reports: []OrgChartNode{}}}},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "service error",
+			setupMocks: func(svc *mockService) {
+				svc.On("GetOrgChart", mock.Anything).Return(nil, errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/employees/org-chart", nil)
+			at.WithAuthContext(&infrastructure.MyClaims{
+				UserID:    1,
+				CompanyID: 1,
+			})
+
+			rec, err := at.Execute(handler.GetOrgChart)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
 func TestHandler_CreateEmployee(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -465,3 +530,224 @@ func TestHandler_DeleteEmployee(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_ImportEmployees(t *testing.T) {
+	invalid := &EmployeeImportReport{
+		TotalRows: 2,
+		ValidRows: 1,
+		RowErrors: []EmployeeImportRowError{{Row: 3, Errors: []string{"NIK is already registered"}}},
+	}
+
+	tests := []struct {
+		name       string
+		withFile   bool
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name:     "success",
+			withFile: true,
+			setupMocks: func(svc *mockService) {
+				svc.On("ImportEmployees", mock.Anything, mock.AnythingOfType("*multipart.FileHeader")).
+					Return(&EmployeeImportReport{TotalRows: 1, ValidRows: 1, Imported: []ImportedEmployee{{Row: 2, FullName: "Jane Doe", Username: "jane.ab12cd"}}}, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:     "invalid rows return the report",
+			withFile: true,
+			setupMocks: func(svc *mockService) {
+				svc.On("ImportEmployees", mock.Anything, mock.AnythingOfType("*multipart.FileHeader")).Return(invalid, ErrInvalidEmployeeImport)
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "file required",
+			withFile:   false,
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			if tt.withFile {
+				part, _ := writer.CreateFormFile("file", "employees.xlsx")
+				part.Write([]byte("fake content"))
+			}
+			writer.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/employees/import", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, handler.ImportEmployees(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			if tt.wantStatus == http.StatusUnprocessableEntity {
+				var resp struct {
+					Data EmployeeImportReport `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, invalid.RowErrors, resp.Data.RowErrors)
+			}
+		})
+	}
+}
+
+func TestHandler_ExportEmployees(t *testing.T) {
+	svc := new(mockService)
+	svc.On("ExportEmployees", mock.Anything, false).Return([]byte("fake-excel"), nil)
+	handler := NewHandler(svc)
+
+	claims := &infrastructure.MyClaims{UserID: 1, CompanyID: 1, Permissions: []string{constants.EXPORT_EMPLOYEE}}
+	at := testutil.NewAPITest(t, http.MethodGet, "/api/employees/export", nil).WithAuthContext(claims)
+	rec, err := at.Execute(handler.ExportEmployees)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "fake-excel", rec.Body.String())
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "employees.xlsx")
+}
+
+func TestHandler_GetEmploymentHistory(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		setupMocks  func(*mockService)
+		wantStatus  int
+	}{
+		{
+			name:        "success masked",
+			permissions: []string{constants.VIEW_EMPLOYEE},
+			setupMocks: func(svc *mockService) {
+				svc.On("GetEmploymentHistory", mock.Anything, uint(1), false).
+					Return([]EmploymentEventResponse{{ID: 1, Type: "HIRE", EffectiveDate: "2026-01-05", Applied: true}}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "success with salaries",
+			permissions: []string{constants.VIEW_EMPLOYEE, constants.VIEW_SENSITIVE_EMPLOYEE},
+			setupMocks: func(svc *mockService) {
+				svc.On("GetEmploymentHistory", mock.Anything, uint(1), true).Return([]EmploymentEventResponse{}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "service error",
+			permissions: []string{constants.VIEW_EMPLOYEE},
+			setupMocks: func(svc *mockService) {
+				svc.On("GetEmploymentHistory", mock.Anything, uint(1), false).Return(nil, errors.New("employee not found"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			at := testutil.NewAPITest(t, http.MethodGet, "/api/employees/:id/events", nil)
+			at.WithAuthContext(&infrastructure.MyClaims{UserID: 1, CompanyID: 1, Permissions: tt.permissions})
+			at.WithPathParams(map[string]string{"id": "1"})
+
+			rec, err := at.Execute(handler.GetEmploymentHistory)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_RecordEmploymentEvent(t *testing.T) {
+	tests := []struct {
+		name       string
+		fields     map[string]string
+		withFile   bool
+		setupMocks func(*mockService)
+		wantStatus int
+	}{
+		{
+			name:     "success with attachment",
+			fields:   map[string]string{"type": "PROMOTION", "effective_date": "2026-03-10", "position": "Senior Developer", "base_salary": "7000000"},
+			withFile: true,
+			setupMocks: func(svc *mockService) {
+				svc.On("RecordEmploymentEvent", mock.Anything, uint(1), mock.MatchedBy(func(req *EmploymentEventRequest) bool {
+					return req.Type == "PROMOTION" && req.Position == "Senior Developer" && req.BaseSalary == 7000000
+				}), mock.AnythingOfType("*multipart.FileHeader")).Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:   "success without attachment",
+			fields: map[string]string{"type": "TERMINATION", "effective_date": "2026-03-10", "termination_reason": "MISCONDUCT"},
+			setupMocks: func(svc *mockService) {
+				svc.On("RecordEmploymentEvent", mock.Anything, uint(1), mock.AnythingOfType("*user.EmploymentEventRequest"), (*multipart.FileHeader)(nil)).Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "hires are not recorded by hand",
+			fields:     map[string]string{"type": "HIRE", "effective_date": "2026-03-10"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown termination reason",
+			fields:     map[string]string{"type": "TERMINATION", "effective_date": "2026-03-10", "termination_reason": "BORED"},
+			setupMocks: func(svc *mockService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "service error",
+			fields: map[string]string{"type": "PROBATION_PASS", "effective_date": "2026-03-10"},
+			setupMocks: func(svc *mockService) {
+				svc.On("RecordEmploymentEvent", mock.Anything, uint(1), mock.AnythingOfType("*user.EmploymentEventRequest"), (*multipart.FileHeader)(nil)).
+					Return(errors.New("employee is not on probation"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockService)
+			tt.setupMocks(svc)
+			handler := NewHandler(svc)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			for key, value := range tt.fields {
+				writer.WriteField(key, value)
+			}
+			if tt.withFile {
+				part, _ := writer.CreateFormFile("file", "decree.pdf")
+				part.Write([]byte("fake content"))
+			}
+			writer.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/employees/1/events", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			e.Validator = utils.NewValidator()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			require.NoError(t, handler.RecordEmploymentEvent(c))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,455 @@
+package user
+
+import (
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/logger"
+	"basekarya-backend/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// timeNow is replaced in tests to move the calendar.
+var timeNow = time.Now
+
+// today is the current date at midnight UTC, the way dates parsed from requests are stored.
+func today() time.Time {
+	now := timeNow()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// statusAfterHire puts a hired or rehired employee on probation until the probation end date passes.
+func statusAfterHire(probationEnd *time.Time) constants.EmploymentStatus {
+	if probationEnd != nil && probationEnd.After(today()) {
+		return constants.EmploymentStatusProbation
+	}
+	return constants.EmploymentStatusActive
+}
+
+func (s *service) GetEmploymentHistory(ctx context.Context, employeeID uint, showSensitive bool) ([]EmploymentEventResponse, error) {
+	if _, err := s.repo.FindEmployeeByID(ctx, employeeID); err != nil {
+		return nil, errors.New("employee not found")
+	}
+
+	events, err := s.repo.FindEmploymentEvents(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]EmploymentEventResponse, 0, len(events))
+	for i := range events {
+		history = append(history, toEmploymentEventResponse(&events[i], showSensitive))
+	}
+
+	return history, nil
+}
+
+// RecordEmploymentEvent logs a change in the employment of an employee. An event effective today or earlier is
+// applied at once, a later one is left to ApplyDueEmploymentEvents.
+func (s *service) RecordEmploymentEvent(ctx context.Context, employeeID uint, req *EmploymentEventRequest, file *multipart.FileHeader) error {
+	emp, err := s.repo.FindEmployeeByID(ctx, employeeID)
+	if err != nil {
+		return errors.New("employee not found")
+	}
+
+	event, err := s.newEmploymentEvent(ctx, emp, req)
+	if err != nil {
+		return err
+	}
+
+	if event.Type == constants.EmploymentEventRehire && s.subscription != nil {
+		allowed, err := s.subscription.CheckEmployeeLimit(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check employee limit: %w", err)
+		}
+		if !allowed {
+			return errors.New("employee limit reached for your subscription plan. please upgrade to rehire employees")
+		}
+	}
+
+	if file != nil {
+		objectName := fmt.Sprintf("employment-events/%d/%s%s", emp.ID, uuid.New().String(), filepath.Ext(file.Filename))
+		fileURL, err := s.storage.UploadFileMultipart(ctx, file, objectName)
+		if err != nil {
+			return err
+		}
+		event.AttachmentURL = fileURL
+	}
+
+	due := !event.EffectiveDate.After(today())
+	err = s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateEmploymentEvent(ctx, event); err != nil {
+			return err
+		}
+		if !due {
+			return nil
+		}
+		return s.applyEmploymentEvent(ctx, emp, event)
+	})
+	if err != nil || !due {
+		return err
+	}
+
+	return s.afterEmploymentChange(ctx, emp, event)
+}
+
+// ApplyDueEmploymentEvents applies the events that have taken effect, each in its own transaction within the
+// company of the event. A failing event is logged and tried again on the next run.
+func (s *service) ApplyDueEmploymentEvents(ctx context.Context) error {
+	events, err := s.repo.FindDueEmploymentEvents(ctx, today())
+	if err != nil {
+		return err
+	}
+
+	for i := range events {
+		event := &events[i]
+		tenantCtx := context.WithValue(ctx, constants.CompanyIDContextKey, event.CompanyID)
+
+		var emp *Employee
+		var invalid error
+		err := s.transactionManager.RunInTransaction(tenantCtx, func(ctx context.Context) error {
+			var err error
+			emp, err = s.repo.FindEmployeeByID(ctx, event.EmployeeID)
+			if err != nil {
+				return err
+			}
+
+			// the employee may have changed since the event was recorded, an event that no longer fits is voided
+			if invalid = validateEmploymentEvent(emp, event); invalid != nil {
+				now := timeNow()
+				event.VoidedAt = &now
+				return s.repo.UpdateEmploymentEvent(ctx, event)
+			}
+			return s.applyEmploymentEvent(ctx, emp, event)
+		})
+		if err == nil && invalid != nil {
+			logger.Warnf("Voided employment event %d: %v", event.ID, invalid)
+			continue
+		}
+		if err == nil {
+			err = s.afterEmploymentChange(tenantCtx, emp, event)
+		}
+		if err != nil {
+			logger.Errorf("Failed to apply employment event %d: %v", event.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// newEmploymentEvent checks the request against the current status of the employee and builds the event from it.
+func (s *service) newEmploymentEvent(ctx context.Context, emp *Employee, req *EmploymentEventRequest) (*EmploymentEvent, error) {
+	effectiveDate, err := time.Parse(constants.DefaultTimeFormat, req.EffectiveDate)
+	if err != nil {
+		return nil, errors.New("invalid effective date format")
+	}
+
+	var endDate *time.Time
+	if req.EndDate != "" {
+		parsed, err := time.Parse(constants.DefaultTimeFormat, req.EndDate)
+		if err != nil {
+			return nil, errors.New("invalid end date format")
+		}
+		if !parsed.After(effectiveDate) {
+			return nil, errors.New("end date must be after the effective date")
+		}
+		endDate = &parsed
+	}
+
+	event := &EmploymentEvent{
+		EmployeeID:        emp.ID,
+		CompanyID:         emp.CompanyID,
+		Type:              constants.EmploymentEventType(req.Type),
+		EffectiveDate:     effectiveDate,
+		EndDate:           endDate,
+		Position:          req.Position,
+		Grade:             req.Grade,
+		BaseSalary:        req.BaseSalary,
+		TerminationReason: constants.TerminationReason(req.TerminationReason),
+		Notes:             req.Notes,
+		CreatedBy:         utils.GetUserIDFromCtx(ctx),
+	}
+
+	if req.DepartmentID > 0 {
+		if _, err := s.repo.FindDepartmentByID(ctx, req.DepartmentID); err != nil {
+			return nil, errors.New("department not found")
+		}
+		event.DepartmentID = &req.DepartmentID
+	}
+
+	if err := validateEmploymentEvent(emp, event); err != nil {
+		return nil, err
+	}
+	if event.Type == constants.EmploymentEventResignation {
+		event.TerminationReason = constants.TerminationResignation
+	}
+
+	return event, nil
+}
+
+// validateEmploymentEvent checks an event against the current status of the employee, when it is recorded and
+// again when it takes effect.
+func validateEmploymentEvent(emp *Employee, event *EmploymentEvent) error {
+	terminated := emp.EmploymentStatus == constants.EmploymentStatusTerminated
+	if event.Type == constants.EmploymentEventRehire {
+		if !terminated {
+			return errors.New("only a terminated employee can be rehired")
+		}
+		return nil
+	}
+	if terminated {
+		return errors.New("employee is terminated, rehire them first")
+	}
+
+	switch event.Type {
+	case constants.EmploymentEventProbationPass:
+		if emp.EmploymentStatus != constants.EmploymentStatusProbation {
+			return errors.New("employee is not on probation")
+		}
+	case constants.EmploymentEventPromotion:
+		if event.Position == "" && event.Grade == "" && event.BaseSalary == 0 {
+			return errors.New("promotion needs a new position, grade or salary")
+		}
+	case constants.EmploymentEventTransfer:
+		if event.DepartmentID == nil {
+			return errors.New("transfer needs the new department")
+		}
+		if *event.DepartmentID == emp.DepartmentID {
+			return errors.New("employee is already in this department")
+		}
+	case constants.EmploymentEventSalaryChange:
+		if event.BaseSalary <= 0 {
+			return errors.New("salary change needs the new salary")
+		}
+	case constants.EmploymentEventSuspension:
+		if emp.EmploymentStatus != constants.EmploymentStatusActive {
+			return errors.New("only an active employee can be suspended")
+		}
+		if event.EndDate == nil {
+			return errors.New("suspension needs an end date")
+		}
+	case constants.EmploymentEventReinstatement:
+		if emp.EmploymentStatus != constants.EmploymentStatusSuspended {
+			return errors.New("employee is not suspended")
+		}
+	case constants.EmploymentEventResignation:
+		if emp.EmploymentStatus == constants.EmploymentStatusNotice {
+			return errors.New("employee has already resigned")
+		}
+		if event.EndDate == nil {
+			return errors.New("resignation needs the last working day as end date")
+		}
+	}
+
+	return nil
+}
+
+// applyEmploymentEvent carries an event over to the employee and marks it applied, the Previous fields of the event
+// keep what it replaced. The caller runs it in a transaction.
+func (s *service) applyEmploymentEvent(ctx context.Context, emp *Employee, event *EmploymentEvent) error {
+	event.PreviousPosition = emp.Position
+	event.PreviousDepartmentID = optionalID(emp.DepartmentID)
+	event.PreviousGrade = emp.Grade
+	event.PreviousBaseSalary = emp.BaseSalary
+
+	switch event.Type {
+	case constants.EmploymentEventProbationPass:
+		emp.EmploymentStatus = constants.EmploymentStatusActive
+	case constants.EmploymentEventPromotion, constants.EmploymentEventTransfer, constants.EmploymentEventSalaryChange:
+		applyJobChange(emp, event)
+	case constants.EmploymentEventSuspension:
+		// the employee keeps their account, the status marks them off work until the reinstatement
+		emp.EmploymentStatus = constants.EmploymentStatusSuspended
+	case constants.EmploymentEventReinstatement:
+		emp.EmploymentStatus = constants.EmploymentStatusActive
+	case constants.EmploymentEventResignation:
+		// the employee works out the notice, the final settlement terminates them
+		emp.EmploymentStatus = constants.EmploymentStatusNotice
+	case constants.EmploymentEventTermination:
+		terminationDate := event.EffectiveDate
+		emp.EmploymentStatus = constants.EmploymentStatusTerminated
+		emp.TerminationDate = &terminationDate
+		emp.TerminationReason = event.TerminationReason
+		emp.User.IsActive = false
+	case constants.EmploymentEventRehire:
+		// the NIK and the history stay, the employment starts over from the rehire date
+		applyJobChange(emp, event)
+		joinDate := event.EffectiveDate
+		emp.JoinDate = &joinDate
+		emp.TerminationDate = nil
+		emp.TerminationReason = ""
+		emp.EmploymentStatus = statusAfterHire(event.EndDate)
+		emp.User.IsActive = true
+	}
+
+	if err := s.repo.UpdateEmployee(ctx, emp); err != nil {
+		return err
+	}
+
+	if event.Type == constants.EmploymentEventTermination || event.Type == constants.EmploymentEventRehire {
+		if err := s.repo.UpdateUser(ctx, &emp.User); err != nil {
+			return err
+		}
+	}
+
+	if event.Type == constants.EmploymentEventSuspension {
+		if err := s.scheduleReinstatement(ctx, emp, event.EndDate); err != nil {
+			return err
+		}
+	}
+
+	if event.Type == constants.EmploymentEventRehire {
+		if err := s.scheduleProbationPass(ctx, emp, event.EndDate); err != nil {
+			return err
+		}
+		// leave accrues again from the new join date
+		if err := s.leaveGenerator.GenerateInitialBalance(ctx, emp.ID); err != nil {
+			return err
+		}
+	}
+
+	now := timeNow()
+	event.AppliedAt = &now
+	if err := s.repo.UpdateEmploymentEvent(ctx, event); err != nil {
+		return err
+	}
+
+	// nothing queued for the employee takes effect after the termination, including a pending probation pass
+	if event.Type == constants.EmploymentEventTermination {
+		return s.repo.VoidPendingEmploymentEvents(ctx, emp.ID)
+	}
+	return nil
+}
+
+// afterEmploymentChange drops the cached profile of the employee and signs them out everywhere once terminated.
+func (s *service) afterEmploymentChange(ctx context.Context, emp *Employee, event *EmploymentEvent) error {
+	_ = s.cache.Del(ctx, fmt.Sprintf(constants.USER_CACHE_KEY, emp.UserID))
+
+	if event.Type == constants.EmploymentEventTermination {
+		return s.sessions.RevokeUser(ctx, emp.UserID)
+	}
+	return nil
+}
+
+// recordHire logs the hire of a new employee, already applied since the employee is created with it.
+func (s *service) recordHire(ctx context.Context, emp *Employee, joinDate time.Time, probationEnd *time.Time) error {
+	now := timeNow()
+	if err := s.repo.CreateEmploymentEvent(ctx, &EmploymentEvent{
+		EmployeeID:    emp.ID,
+		CompanyID:     emp.CompanyID,
+		Type:          constants.EmploymentEventHire,
+		EffectiveDate: joinDate,
+		EndDate:       probationEnd,
+		Position:      emp.Position,
+		DepartmentID:  optionalID(emp.DepartmentID),
+		Grade:         emp.Grade,
+		BaseSalary:    emp.BaseSalary,
+		CreatedBy:     utils.GetUserIDFromCtx(ctx),
+		AppliedAt:     &now,
+	}); err != nil {
+		return err
+	}
+
+	return s.scheduleProbationPass(ctx, emp, probationEnd)
+}
+
+// scheduleProbationPass queues the end of the probation of an employee who is on one.
+func (s *service) scheduleProbationPass(ctx context.Context, emp *Employee, probationEnd *time.Time) error {
+	if emp.EmploymentStatus != constants.EmploymentStatusProbation || probationEnd == nil {
+		return nil
+	}
+
+	return s.repo.CreateEmploymentEvent(ctx, &EmploymentEvent{
+		EmployeeID:    emp.ID,
+		CompanyID:     emp.CompanyID,
+		Type:          constants.EmploymentEventProbationPass,
+		EffectiveDate: *probationEnd,
+		CreatedBy:     utils.GetUserIDFromCtx(ctx),
+	})
+}
+
+// scheduleReinstatement queues the return of a suspended employee for the end date of the suspension. One recorded
+// earlier by hand leaves the queued one with nothing to do, it is voided when it comes due.
+func (s *service) scheduleReinstatement(ctx context.Context, emp *Employee, suspensionEnd *time.Time) error {
+	if suspensionEnd == nil {
+		return nil
+	}
+
+	return s.repo.CreateEmploymentEvent(ctx, &EmploymentEvent{
+		EmployeeID:    emp.ID,
+		CompanyID:     emp.CompanyID,
+		Type:          constants.EmploymentEventReinstatement,
+		EffectiveDate: *suspensionEnd,
+		CreatedBy:     utils.GetUserIDFromCtx(ctx),
+	})
+}
+
+// applyJobChange moves the employee to the position, department, grade and salary the event sets.
+func applyJobChange(emp *Employee, event *EmploymentEvent) {
+	if event.Position != "" {
+		emp.Position = event.Position
+	}
+	if event.DepartmentID != nil {
+		emp.DepartmentID = *event.DepartmentID
+	}
+	if event.Grade != "" {
+		emp.Grade = event.Grade
+	}
+	if event.BaseSalary > 0 {
+		emp.BaseSalary = event.BaseSalary
+	}
+}
+
+func optionalID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// toEmploymentEventResponse leaves the salaries out unless showSensitive is set.
+func toEmploymentEventResponse(event *EmploymentEvent, showSensitive bool) EmploymentEventResponse {
+	resp := EmploymentEventResponse{
+		ID:                event.ID,
+		Type:              string(event.Type),
+		EffectiveDate:     event.EffectiveDate.Format(constants.DefaultTimeFormat),
+		Position:          event.Position,
+		Grade:             event.Grade,
+		PreviousPosition:  event.PreviousPosition,
+		PreviousGrade:     event.PreviousGrade,
+		TerminationReason: string(event.TerminationReason),
+		Notes:             event.Notes,
+		AttachmentURL:     event.AttachmentURL,
+		Applied:           event.AppliedAt != nil,
+		Voided:            event.VoidedAt != nil,
+		CreatedAt:         event.CreatedAt.Format(time.RFC3339),
+	}
+
+	if event.EndDate != nil {
+		resp.EndDate = event.EndDate.Format(constants.DefaultTimeFormat)
+	}
+	if event.Department != nil {
+		resp.DepartmentName = event.Department.Name
+	}
+	if event.PreviousDepartment != nil {
+		resp.PreviousDepartmentName = event.PreviousDepartment.Name
+	}
+
+	if showSensitive {
+		if event.BaseSalary > 0 {
+			salary := event.BaseSalary
+			resp.BaseSalary = &salary
+		}
+		if event.PreviousBaseSalary > 0 {
+			previous := event.PreviousBaseSalary
+			resp.PreviousBaseSalary = &previous
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,451 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"basekarya-backend/internal/modules/department"
+	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
+	"basekarya-backend/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func fixLifecycleClock(t *testing.T) {
+	t.Helper()
+	timeNow = func() time.Time { return time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = time.Now })
+}
+
+func lifecycleEmployee(status constants.EmploymentStatus) *Employee {
+	return &Employee{
+		ID: 1, UserID: 7, CompanyID: 1, DepartmentID: 1,
+		Position: "Developer", Grade: "G2", BaseSalary: 5000000,
+		EmploymentStatus: status,
+		User:             User{ID: 7, IsActive: status != constants.EmploymentStatusTerminated},
+	}
+}
+
+func TestService_RecordEmploymentEvent(t *testing.T) {
+	fixLifecycleClock(t)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	tests := []struct {
+		name       string
+		emp        *Employee
+		req        *EmploymentEventRequest
+		file       *multipart.FileHeader
+		setupMocks func(*mockRepo, *mockStorage, *mockLeaveGen, *mockSubscription)
+		wantErr    string
+		check      func(t *testing.T, emp *Employee, repo *mockRepo, sessions *mockSessionRevoker)
+	}{
+		{
+			name: "promotion effective today is applied",
+			emp:  lifecycleEmployee(constants.EmploymentStatusActive),
+			req:  &EmploymentEventRequest{Type: "PROMOTION", EffectiveDate: "2026-03-10", Position: "Senior Developer", Grade: "G3", BaseSalary: 7000000},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, leaveGen *mockLeaveGen, sub *mockSubscription) {
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
+				repo.On("UpdateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+				repo.On("UpdateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.AppliedAt != nil && e.PreviousPosition == "Developer" && e.PreviousGrade == "G2" &&
+						e.PreviousBaseSalary == 5000000 && *e.PreviousDepartmentID == 1
+				})).Return(nil)
+			},
+			check: func(t *testing.T, emp *Employee, repo *mockRepo, sessions *mockSessionRevoker) {
+				assert.Equal(t, "Senior Developer", emp.Position)
+				assert.Equal(t, "G3", emp.Grade)
+				assert.Equal(t, 7000000.0, emp.BaseSalary)
+				sessions.AssertNotCalled(t, "RevokeUser", mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name: "future transfer waits for its effective date",
+			emp:  lifecycleEmployee(constants.EmploymentStatusActive),
+			req:  &EmploymentEventRequest{Type: "TRANSFER", EffectiveDate: "2026-04-01", DepartmentID: 2},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, leaveGen *mockLeaveGen, sub *mockSubscription) {
+				repo.On("FindDepartmentByID", mock.Anything, uint(2)).Return(&department.Department{ID: 2}, nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.AppliedAt == nil && *e.DepartmentID == 2 && e.CreatedBy == 1
+				})).Return(nil)
+			},
+			check: func(t *testing.T, emp *Employee, repo *mockRepo, sessions *mockSessionRevoker) {
+				assert.Equal(t, uint(1), emp.DepartmentID)
+				repo.AssertNotCalled(t, "UpdateEmployee", mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name: "termination deactivates and signs out",
+			emp:  lifecycleEmployee(constants.EmploymentStatusNotice),
+			req:  &EmploymentEventRequest{Type: "TERMINATION", EffectiveDate: "2026-03-01", TerminationReason: "RESIGNATION"},
+			file: &multipart.FileHeader{Filename: "letter.pdf"},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, leaveGen *mockLeaveGen, sub *mockSubscription) {
+				storage.On("UploadFileMultipart", mock.Anything, mock.Anything, mock.MatchedBy(func(name string) bool {
+					return strings.HasPrefix(name, "employment-events/1/") && strings.HasSuffix(name, ".pdf")
+				})).Return("https://files/letter.pdf", nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.AttachmentURL == "https://files/letter.pdf"
+				})).Return(nil)
+				repo.On("UpdateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+				repo.On("UpdateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
+				repo.On("UpdateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
+				repo.On("VoidPendingEmploymentEvents", mock.Anything, uint(1)).Return(nil)
+			},
+			check: func(t *testing.T, emp *Employee, repo *mockRepo, sessions *mockSessionRevoker) {
+				assert.Equal(t, constants.EmploymentStatusTerminated, emp.EmploymentStatus)
+				assert.Equal(t, "2026-03-01", emp.TerminationDate.Format(constants.DefaultTimeFormat))
+				assert.Equal(t, constants.TerminationResignation, emp.TerminationReason)
+				assert.False(t, emp.User.IsActive)
+				sessions.AssertCalled(t, "RevokeUser", mock.Anything, uint(7))
+			},
+		},
+		{
+			name: "resignation puts the employee on notice",
+			emp:  lifecycleEmployee(constants.EmploymentStatusActive),
+			req:  &EmploymentEventRequest{Type: "RESIGNATION", EffectiveDate: "2026-03-10", EndDate: "2026-04-10"},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, leaveGen *mockLeaveGen, sub *mockSubscription) {
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.TerminationReason == constants.TerminationResignation
+				})).Return(nil)
+				repo.On("UpdateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+				repo.On("UpdateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
+			},
+			check: func(t *testing.T, emp *Employee, repo *mockRepo, sessions *mockSessionRevoker) {
+				assert.Equal(t, constants.EmploymentStatusNotice, emp.EmploymentStatus)
+				assert.True(t, emp.User.IsActive)
+			},
+		},
+		{
+			name: "suspension takes the employee off work and queues the reinstatement",
+			emp:  lifecycleEmployee(constants.EmploymentStatusActive),
+			req:  &EmploymentEventRequest{Type: "SUSPENSION", EffectiveDate: "2026-03-10", EndDate: "2026-03-24"},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, leaveGen *mockLeaveGen, sub *mockSubscription) {
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.Type == constants.EmploymentEventSuspension
+				})).Return(nil).Once()
+				repo.On("UpdateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.Type == constants.EmploymentEventReinstatement && e.AppliedAt == nil &&
+						e.EffectiveDate.Format(constants.DefaultTimeFormat) == "2026-03-24"
+				})).Return(nil).Once()
+				repo.On("UpdateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
+			},
+			check: func(t *testing.T, emp *Employee, repo *mockRepo, sessions *mockSessionRevoker) {
+				assert.Equal(t, constants.EmploymentStatusSuspended, emp.EmploymentStatus)
+				assert.True(t, emp.User.IsActive)
+				repo.AssertNumberOfCalls(t, "CreateEmploymentEvent", 2)
+			},
+		},
+		{
+			name: "reinstatement brings a suspended employee back",
+			emp:  lifecycleEmployee(constants.EmploymentStatusSuspended),
+			req:  &EmploymentEventRequest{Type: "REINSTATEMENT", EffectiveDate: "2026-03-10"},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, leaveGen *mockLeaveGen, sub *mockSubscription) {
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
+				repo.On("UpdateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+				repo.On("UpdateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
+			},
+			check: func(t *testing.T, emp *Employee, repo *mockRepo, sessions *mockSessionRevoker) {
+				assert.Equal(t, constants.EmploymentStatusActive, emp.EmploymentStatus)
+			},
+		},
+		{
+			name: "rehire keeps the record and starts a new probation",
+			emp: func() *Employee {
+				emp := lifecycleEmployee(constants.EmploymentStatusTerminated)
+				terminated := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+				emp.TerminationDate = &terminated
+				emp.TerminationReason = constants.TerminationResignation
+				return emp
+			}(),
+			req: &EmploymentEventRequest{Type: "REHIRE", EffectiveDate: "2026-03-01", EndDate: "2026-06-01", Position: "Lead Developer"},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, leaveGen *mockLeaveGen, sub *mockSubscription) {
+				sub.On("CheckEmployeeLimit", mock.Anything).Return(true, nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.Type == constants.EmploymentEventRehire
+				})).Return(nil).Once()
+				repo.On("UpdateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+				repo.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *User) bool { return u.IsActive })).Return(nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.Type == constants.EmploymentEventProbationPass && e.AppliedAt == nil &&
+						e.EffectiveDate.Format(constants.DefaultTimeFormat) == "2026-06-01"
+				})).Return(nil).Once()
+				leaveGen.On("GenerateInitialBalance", mock.Anything, uint(1)).Return(nil)
+				repo.On("UpdateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
+			},
+			check: func(t *testing.T, emp *Employee, repo *mockRepo, sessions *mockSessionRevoker) {
+				assert.Equal(t, constants.EmploymentStatusProbation, emp.EmploymentStatus)
+				assert.Equal(t, "2026-03-01", emp.JoinDate.Format(constants.DefaultTimeFormat))
+				assert.Nil(t, emp.TerminationDate)
+				assert.Empty(t, emp.TerminationReason)
+				assert.Equal(t, "Lead Developer", emp.Position)
+				assert.True(t, emp.User.IsActive)
+				repo.AssertNumberOfCalls(t, "CreateEmploymentEvent", 2)
+			},
+		},
+		{
+			name: "error rehire over the plan limit",
+			emp:  lifecycleEmployee(constants.EmploymentStatusTerminated),
+			req:  &EmploymentEventRequest{Type: "REHIRE", EffectiveDate: "2026-03-01"},
+			setupMocks: func(repo *mockRepo, storage *mockStorage, leaveGen *mockLeaveGen, sub *mockSubscription) {
+				sub.On("CheckEmployeeLimit", mock.Anything).Return(false, nil)
+			},
+			wantErr: "employee limit reached for your subscription plan. please upgrade to rehire employees",
+		},
+		{
+			name:    "error rehire of an active employee",
+			emp:     lifecycleEmployee(constants.EmploymentStatusActive),
+			req:     &EmploymentEventRequest{Type: "REHIRE", EffectiveDate: "2026-03-01"},
+			wantErr: "only a terminated employee can be rehired",
+		},
+		{
+			name:    "error change to a terminated employee",
+			emp:     lifecycleEmployee(constants.EmploymentStatusTerminated),
+			req:     &EmploymentEventRequest{Type: "SALARY_CHANGE", EffectiveDate: "2026-03-01", BaseSalary: 6000000},
+			wantErr: "employee is terminated, rehire them first",
+		},
+		{
+			name:    "error probation pass when not on probation",
+			emp:     lifecycleEmployee(constants.EmploymentStatusActive),
+			req:     &EmploymentEventRequest{Type: "PROBATION_PASS", EffectiveDate: "2026-03-01"},
+			wantErr: "employee is not on probation",
+		},
+		{
+			name:    "error suspension without end date",
+			emp:     lifecycleEmployee(constants.EmploymentStatusActive),
+			req:     &EmploymentEventRequest{Type: "SUSPENSION", EffectiveDate: "2026-03-01"},
+			wantErr: "suspension needs an end date",
+		},
+		{
+			name:    "error suspension of an employee on notice",
+			emp:     lifecycleEmployee(constants.EmploymentStatusNotice),
+			req:     &EmploymentEventRequest{Type: "SUSPENSION", EffectiveDate: "2026-03-01", EndDate: "2026-03-15"},
+			wantErr: "only an active employee can be suspended",
+		},
+		{
+			name:    "error reinstatement of an employee not suspended",
+			emp:     lifecycleEmployee(constants.EmploymentStatusActive),
+			req:     &EmploymentEventRequest{Type: "REINSTATEMENT", EffectiveDate: "2026-03-01"},
+			wantErr: "employee is not suspended",
+		},
+		{
+			name:    "error transfer to the same department",
+			emp:     lifecycleEmployee(constants.EmploymentStatusActive),
+			req:     &EmploymentEventRequest{Type: "TRANSFER", EffectiveDate: "2026-03-01", DepartmentID: 1},
+			wantErr: "employee is already in this department",
+			setupMocks: func(repo *mockRepo, storage *mockStorage, leaveGen *mockLeaveGen, sub *mockSubscription) {
+				repo.On("FindDepartmentByID", mock.Anything, uint(1)).Return(&department.Department{ID: 1}, nil)
+			},
+		},
+		{
+			name:    "error end date before effective date",
+			emp:     lifecycleEmployee(constants.EmploymentStatusActive),
+			req:     &EmploymentEventRequest{Type: "SUSPENSION", EffectiveDate: "2026-03-01", EndDate: "2026-02-01"},
+			wantErr: "end date must be after the effective date",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _, storage, cache, leaveGen, _, sub, _, sessions := newTestUserService()
+			repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(tt.emp, nil)
+			cache.On("Del", mock.Anything, mock.Anything).Return(nil).Maybe()
+			sessions.On("RevokeUser", mock.Anything, mock.Anything).Return(nil).Maybe()
+			if tt.setupMocks != nil {
+				tt.setupMocks(repo, storage, leaveGen, sub)
+			}
+
+			err := svc.RecordEmploymentEvent(ctx, 1, tt.req, tt.file)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "CreateEmploymentEvent", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, tt.emp, repo, sessions)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_ApplyDueEmploymentEvents(t *testing.T) {
+	fixLifecycleClock(t)
+	svc, repo, _, _, cache, _, _, _, _, sessions := newTestUserService()
+
+	onProbation := lifecycleEmployee(constants.EmploymentStatusProbation)
+	terminated := lifecycleEmployee(constants.EmploymentStatusTerminated)
+	terminated.ID = 2
+
+	repo.On("FindDueEmploymentEvents", mock.Anything, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)).Return([]EmploymentEvent{
+		{ID: 10, EmployeeID: 1, CompanyID: 1, Type: constants.EmploymentEventProbationPass, EffectiveDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 11, EmployeeID: 3, CompanyID: 2, Type: constants.EmploymentEventSalaryChange, EffectiveDate: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), BaseSalary: 9000000},
+		{ID: 12, EmployeeID: 2, CompanyID: 1, Type: constants.EmploymentEventProbationPass, EffectiveDate: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+	}, nil)
+	repo.On("FindEmployeeByID", mock.MatchedBy(func(ctx context.Context) bool { return utils.GetCompanyIDFromCtx(ctx) == 1 }), uint(1)).Return(onProbation, nil)
+	repo.On("FindEmployeeByID", mock.MatchedBy(func(ctx context.Context) bool { return utils.GetCompanyIDFromCtx(ctx) == 2 }), uint(3)).Return(nil, errors.New("db error"))
+	repo.On("FindEmployeeByID", mock.Anything, uint(2)).Return(terminated, nil)
+	repo.On("UpdateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil).Once()
+	repo.On("UpdateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+		return e.ID == 10 && e.AppliedAt != nil && e.VoidedAt == nil
+	})).Return(nil).Once()
+	// the queued probation pass of someone terminated meanwhile is voided, not applied
+	repo.On("UpdateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+		return e.ID == 12 && e.AppliedAt == nil && e.VoidedAt != nil
+	})).Return(nil).Once()
+	cache.On("Del", mock.Anything, mock.Anything).Return(nil).Once()
+
+	require.NoError(t, svc.ApplyDueEmploymentEvents(context.Background()))
+
+	assert.Equal(t, constants.EmploymentStatusActive, onProbation.EmploymentStatus)
+	assert.Equal(t, constants.EmploymentStatusTerminated, terminated.EmploymentStatus)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+	sessions.AssertNotCalled(t, "RevokeUser", mock.Anything, mock.Anything)
+}
+
+func TestService_TerminateThenRehire_VoidsPendingProbationPass(t *testing.T) {
+	fixLifecycleClock(t)
+	tdb := setupUserTestDB(t)
+	repo := NewRepository(tdb.DB)
+	seedUserTestData(t, tdb)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	_, _, _, storage, cache, leaveGen, _, sub, _, sessions := newTestUserService()
+	svc := NewService(repo, new(mockHasher), storage, cache, leaveGen, testutil.NewTestTransactionManager(tdb.DB), sub, new(mockEmail), sessions, nil)
+	cache.On("Del", mock.Anything, mock.Anything).Return(nil)
+	sessions.On("RevokeUser", mock.Anything, uint(1)).Return(nil)
+	sub.On("CheckEmployeeLimit", mock.Anything).Return(true, nil)
+	leaveGen.On("GenerateInitialBalance", mock.Anything, uint(1)).Return(nil)
+
+	// hired on probation until April, the probation pass is queued
+	emp, err := repo.FindEmployeeByID(ctx, 1)
+	require.NoError(t, err)
+	emp.EmploymentStatus = constants.EmploymentStatusProbation
+	require.NoError(t, repo.UpdateEmployee(ctx, emp))
+	probationEnd := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, svc.(*service).scheduleProbationPass(ctx, emp, &probationEnd))
+
+	require.NoError(t, svc.RecordEmploymentEvent(ctx, 1, &EmploymentEventRequest{Type: "TERMINATION", EffectiveDate: "2026-03-10", TerminationReason: "RESIGNATION"}, nil))
+	require.NoError(t, svc.RecordEmploymentEvent(ctx, 1, &EmploymentEventRequest{Type: "REHIRE", EffectiveDate: "2026-03-10"}, nil))
+
+	// the probation pass queued before the termination does not carry over into the new employment
+	timeNow = func() time.Time { return time.Date(2026, 4, 2, 9, 0, 0, 0, time.UTC) }
+	due, err := repo.FindDueEmploymentEvents(ctx, today())
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	require.NoError(t, svc.ApplyDueEmploymentEvents(ctx))
+
+	emp, err = repo.FindEmployeeByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, constants.EmploymentStatusActive, emp.EmploymentStatus)
+	assert.True(t, emp.User.IsActive)
+
+	history, err := svc.GetEmploymentHistory(ctx, 1, false)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	var probationPass EmploymentEventResponse
+	for _, event := range history {
+		if event.Type == string(constants.EmploymentEventProbationPass) {
+			probationPass = event
+		}
+	}
+	assert.True(t, probationPass.Voided)
+	assert.False(t, probationPass.Applied)
+}
+
+func TestService_ApplyDueEmploymentEvents_RehireOnProbationAfterVoidedPass(t *testing.T) {
+	fixLifecycleClock(t)
+	tdb := setupUserTestDB(t)
+	repo := NewRepository(tdb.DB)
+	seedUserTestData(t, tdb)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	_, _, _, storage, cache, leaveGen, _, sub, _, sessions := newTestUserService()
+	svc := NewService(repo, new(mockHasher), storage, cache, leaveGen, testutil.NewTestTransactionManager(tdb.DB), sub, new(mockEmail), sessions, nil)
+	cache.On("Del", mock.Anything, mock.Anything).Return(nil)
+	sessions.On("RevokeUser", mock.Anything, uint(1)).Return(nil)
+	sub.On("CheckEmployeeLimit", mock.Anything).Return(true, nil)
+	leaveGen.On("GenerateInitialBalance", mock.Anything, uint(1)).Return(nil)
+
+	emp, err := repo.FindEmployeeByID(ctx, 1)
+	require.NoError(t, err)
+	emp.EmploymentStatus = constants.EmploymentStatusProbation
+	require.NoError(t, repo.UpdateEmployee(ctx, emp))
+	firstProbationEnd := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, svc.(*service).scheduleProbationPass(ctx, emp, &firstProbationEnd))
+
+	// terminated and rehired on a fresh probation that ends after the old one would have
+	require.NoError(t, svc.RecordEmploymentEvent(ctx, 1, &EmploymentEventRequest{Type: "TERMINATION", EffectiveDate: "2026-03-10", TerminationReason: "RESIGNATION"}, nil))
+	require.NoError(t, svc.RecordEmploymentEvent(ctx, 1, &EmploymentEventRequest{Type: "REHIRE", EffectiveDate: "2026-03-10", EndDate: "2026-06-01"}, nil))
+
+	timeNow = func() time.Time { return time.Date(2026, 3, 21, 9, 0, 0, 0, time.UTC) }
+	require.NoError(t, svc.ApplyDueEmploymentEvents(ctx))
+
+	emp, err = repo.FindEmployeeByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, constants.EmploymentStatusProbation, emp.EmploymentStatus)
+
+	timeNow = func() time.Time { return time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC) }
+	require.NoError(t, svc.ApplyDueEmploymentEvents(ctx))
+
+	emp, err = repo.FindEmployeeByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, constants.EmploymentStatusActive, emp.EmploymentStatus)
+}
+
+func TestService_GetEmploymentHistory(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	applied := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	events := []EmploymentEvent{{
+		ID: 2, Type: constants.EmploymentEventPromotion,
+		EffectiveDate: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		Position:      "Senior Developer", BaseSalary: 7000000,
+		PreviousPosition: "Developer", PreviousBaseSalary: 5000000,
+		Department: &department.Department{Name: "Engineering"},
+		AppliedAt:  &applied,
+	}}
+
+	t.Run("masked", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _ := newTestUserService()
+		repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(lifecycleEmployee(constants.EmploymentStatusActive), nil)
+		repo.On("FindEmploymentEvents", mock.Anything, uint(1)).Return(events, nil)
+
+		history, err := svc.GetEmploymentHistory(ctx, 1, false)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, "PROMOTION", history[0].Type)
+		assert.Equal(t, "2026-03-10", history[0].EffectiveDate)
+		assert.Equal(t, "Engineering", history[0].DepartmentName)
+		assert.Equal(t, "Developer", history[0].PreviousPosition)
+		assert.True(t, history[0].Applied)
+		assert.Nil(t, history[0].BaseSalary)
+		assert.Nil(t, history[0].PreviousBaseSalary)
+	})
+
+	t.Run("with sensitive data", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _ := newTestUserService()
+		repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(lifecycleEmployee(constants.EmploymentStatusActive), nil)
+		repo.On("FindEmploymentEvents", mock.Anything, uint(1)).Return(events, nil)
+
+		history, err := svc.GetEmploymentHistory(ctx, 1, true)
+		require.NoError(t, err)
+		require.NotNil(t, history[0].BaseSalary)
+		assert.Equal(t, 7000000.0, *history[0].BaseSalary)
+		assert.Equal(t, 5000000.0, *history[0].PreviousBaseSalary)
+	})
+
+	t.Run("employee not found", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _ := newTestUserService()
+		repo.On("FindEmployeeByID", mock.Anything, uint(99)).Return(nil, errors.New("record not found"))
+
+		_, err := svc.GetEmploymentHistory(ctx, 99, true)
+		require.EqualError(t, err, "employee not found")
+	})
+}
@@ -5,8 +5,12 @@ import (
 	"mime/multipart"
 	"time"
 
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/department"
+	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/rbac"
 	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/response"
 
 	"github.com/stretchr/testify/mock"
@@ -55,6 +59,33 @@ func (m *mockRepo) DeleteUser(ctx context.Context, id uint) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+func (m *mockRepo) TerminateEmployee(ctx context.Context, emp *Employee, terminationDate time.Time, reason constants.TerminationReason) error {
+	return m.Called(ctx, emp, terminationDate, reason).Error(0)
+}
+
+func (m *mockRepo) CreateEmploymentEvent(ctx context.Context, event *EmploymentEvent) error {
+	return m.Called(ctx, event).Error(0)
+}
+
+func (m *mockRepo) UpdateEmploymentEvent(ctx context.Context, event *EmploymentEvent) error {
+	return m.Called(ctx, event).Error(0)
+}
+
+func (m *mockRepo) FindEmploymentEvents(ctx context.Context, employeeID uint) ([]EmploymentEvent, error) {
+	args := m.Called(ctx, employeeID)
+	return args.Get(0).([]EmploymentEvent), args.Error(1)
+}
+
+func (m *mockRepo) FindDueEmploymentEvents(ctx context.Context, date time.Time) ([]EmploymentEvent, error) {
+	args := m.Called(ctx, date)
+	return args.Get(0).([]EmploymentEvent), args.Error(1)
+}
+
+func (m *mockRepo) VoidPendingEmploymentEvents(ctx context.Context, employeeID uint) error {
+	args := m.Called(ctx, employeeID)
+	return args.Error(0)
+}
+
 func (m *mockRepo) FindEmployeeByID(ctx context.Context, id uint) (*Employee, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -63,6 +94,14 @@ func (m *mockRepo) FindEmployeeByID(ctx context.Context, id uint) (*Employee, er
 	return args.Get(0).(*Employee), args.Error(1)
 }
 
+func (m *mockRepo) FindSupervisor(ctx context.Context, employeeID uint) (*Employee, error) {
+	args := m.Called(ctx, employeeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Employee), args.Error(1)
+}
+
 func (m *mockRepo) FindEmployeeByEmail(ctx context.Context, email string) (*Employee, error) {
 	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
@@ -71,8 +110,42 @@ func (m *mockRepo) FindEmployeeByEmail(ctx context.Context, email string) (*Empl
 	return args.Get(0).(*Employee), args.Error(1)
 }
 
-func (m *mockRepo) UpdatePasswordByEmail(ctx context.Context, email string, password string) error {
-	return m.Called(ctx, email, password).Error(0)
+func (m *mockRepo) FindEmployeeByCompanyEmail(ctx context.Context, companyID uint, email string) (*Employee, error) {
+	args := m.Called(ctx, companyID, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Employee), args.Error(1)
+}
+
+func (m *mockRepo) FindPasswordHistory(ctx context.Context, userID uint, limit int) ([]string, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockRepo) CreatePasswordHistory(ctx context.Context, history *PasswordHistory) error {
+	return m.Called(ctx, history).Error(0)
+}
+
+func (m *mockRepo) UpdateMFA(ctx context.Context, userID, companyID uint, secret string, recoveryHashes []string) error {
+	return m.Called(ctx, userID, companyID, secret, recoveryHashes).Error(0)
+}
+
+func (m *mockRepo) ReplaceRecoveryCodes(ctx context.Context, userID, companyID uint, hashes []string) error {
+	return m.Called(ctx, userID, companyID, hashes).Error(0)
+}
+
+func (m *mockRepo) UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error) {
+	args := m.Called(ctx, userID, hash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepo) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func (m *mockRepo) CountActiveEmployee(ctx context.Context) (int64, error) {
@@ -85,11 +158,21 @@ func (m *mockRepo) FindAllEmployeeActive(ctx context.Context) ([]Employee, error
 	return args.Get(0).([]Employee), args.Error(1)
 }
 
+func (m *mockRepo) FindAllEmployeeActiveInScope(ctx context.Context) ([]Employee, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Employee), args.Error(1)
+}
+
 func (m *mockRepo) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
 	args := m.Called(ctx, permissionApprovalName)
 	return args.Get(0).([]uint), args.Error(1)
 }
 
+func (m *mockRepo) FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error) {
+	args := m.Called(ctx, requesterUserID, permissionApprovalName)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
 func (m *mockRepo) FindRoleByID(ctx context.Context, id uint) (*rbac.Role, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -98,6 +181,39 @@ func (m *mockRepo) FindRoleByID(ctx context.Context, id uint) (*rbac.Role, error
 	return args.Get(0).(*rbac.Role), args.Error(1)
 }
 
+func (m *mockRepo) FindRoles(ctx context.Context) ([]rbac.Role, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]rbac.Role), args.Error(1)
+}
+
+func (m *mockRepo) FindDepartments(ctx context.Context) ([]department.Department, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]department.Department), args.Error(1)
+}
+
+func (m *mockRepo) FindDepartmentByID(ctx context.Context, id uint) (*department.Department, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*department.Department), args.Error(1)
+}
+
+func (m *mockRepo) FindShifts(ctx context.Context) ([]master.Shift, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]master.Shift), args.Error(1)
+}
+
+func (m *mockRepo) FindExistingNIKIndexes(ctx context.Context, indexes []string) ([]string, error) {
+	args := m.Called(ctx, indexes)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockRepo) FindExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	args := m.Called(ctx, emails)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *mockRepo) FindAllUserIDs(ctx context.Context) ([]uint, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]uint), args.Error(1)
@@ -156,6 +272,11 @@ func (m *mockSubscription) CheckEmployeeLimit(ctx context.Context) (bool, error)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *mockSubscription) CheckEmployeeCapacity(ctx context.Context, additional int) (bool, error) {
+	args := m.Called(ctx, additional)
+	return args.Bool(0), args.Error(1)
+}
+
 type mockService struct{ mock.Mock }
 
 func (m *mockService) GetProfile(userID uint) (*UserProfileResponse, error) {
@@ -174,8 +295,12 @@ func (m *mockService) ChangePassword(ctx context.Context, userID uint, req *Chan
 	return m.Called(ctx, userID, req).Error(0)
 }
 
-func (m *mockService) GetAllEmployees(ctx context.Context, page, limit int, search string) ([]EmployeeListResponse, *response.Meta, error) {
-	args := m.Called(ctx, page, limit, search)
+func (m *mockService) UpdatePassword(ctx context.Context, userID uint, password string) error {
+	return m.Called(ctx, userID, password).Error(0)
+}
+
+func (m *mockService) GetAllEmployees(ctx context.Context, page, limit int, search string, showSensitive bool) ([]EmployeeListResponse, *response.Meta, error) {
+	args := m.Called(ctx, page, limit, search, showSensitive)
 	var meta *response.Meta
 	if args.Get(1) != nil {
 		meta = args.Get(1).(*response.Meta)
@@ -199,11 +324,72 @@ func (m *mockService) DeleteEmployee(ctx context.Context, id uint) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+func (m *mockService) GetEmploymentHistory(ctx context.Context, employeeID uint, showSensitive bool) ([]EmploymentEventResponse, error) {
+	args := m.Called(ctx, employeeID, showSensitive)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]EmploymentEventResponse), args.Error(1)
+}
+
+func (m *mockService) RecordEmploymentEvent(ctx context.Context, employeeID uint, req *EmploymentEventRequest, file *multipart.FileHeader) error {
+	return m.Called(ctx, employeeID, req, file).Error(0)
+}
+
+func (m *mockService) ApplyDueEmploymentEvents(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockService) GenerateEmployeeImportTemplate(ctx context.Context) ([]byte, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *mockService) ValidateEmployeeImport(ctx context.Context, file *multipart.FileHeader) (*EmployeeImportReport, error) {
+	args := m.Called(ctx, file)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*EmployeeImportReport), args.Error(1)
+}
+
+func (m *mockService) ImportEmployees(ctx context.Context, file *multipart.FileHeader) (*EmployeeImportReport, error) {
+	args := m.Called(ctx, file)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*EmployeeImportReport), args.Error(1)
+}
+
+func (m *mockService) ExportEmployees(ctx context.Context, showSensitive bool) ([]byte, error) {
+	args := m.Called(ctx, showSensitive)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *mockService) GetOrgChart(ctx context.Context) ([]OrgChartNode, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]OrgChartNode), args.Error(1)
+}
+
 func (m *mockService) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
 	args := m.Called(ctx, permissionApprovalName)
 	return args.Get(0).([]uint), args.Error(1)
 }
 
+func (m *mockService) FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error) {
+	args := m.Called(ctx, requesterUserID, permissionApprovalName)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
 type mockEmail struct{ mock.Mock }
 
 func (m *mockEmail) Send(to, subject, htmlBody string) error {
@@ -222,7 +408,13 @@ func (m *mockEmail) isMethodCallable(method string) bool {
 	return false
 }
 
-func newTestUserService() (Service, *mockRepo, *mockHasher, *mockStorage, *mockCache, *mockLeaveGen, *testutil.MockTransactionManager, *mockSubscription, *mockEmail) {
+type mockSessionRevoker struct{ mock.Mock }
+
+func (m *mockSessionRevoker) RevokeUser(ctx context.Context, userID uint) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func newTestUserService() (Service, *mockRepo, *mockHasher, *mockStorage, *mockCache, *mockLeaveGen, *testutil.MockTransactionManager, *mockSubscription, *mockEmail, *mockSessionRevoker) {
 	repo := new(mockRepo)
 	hasher := new(mockHasher)
 	storage := new(mockStorage)
@@ -231,7 +423,8 @@ func newTestUserService() (Service, *mockRepo, *mockHasher, *mockStorage, *mockC
 	tm := testutil.NewMockTransactionManager()
 	sub := new(mockSubscription)
 	email := new(mockEmail)
+	sessions := new(mockSessionRevoker)
 
-	svc := NewService(repo, hasher, storage, cache, leaveGen, tm, sub, email)
-	return svc, repo, hasher, storage, cache, leaveGen, tm, sub, email
+	svc := NewService(repo, hasher, storage, cache, leaveGen, tm, sub, email, sessions, infrastructure.NewExcelProvider())
+	return svc, repo, hasher, storage, cache, leaveGen, tm, sub, email, sessions
 }
@@ -1,11 +1,18 @@
 package user
 
 import (
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/internal/modules/department"
+	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/rbac"
 	"basekarya-backend/pkg/constants"
 	"basekarya-backend/pkg/logger"
 	"basekarya-backend/pkg/utils"
 	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -19,13 +26,34 @@ type Repository interface {
 	CreateUser(ctx context.Context, user *User) error
 	CreateEmployee(ctx context.Context, emp *Employee) error
 	DeleteUser(ctx context.Context, id uint) error
+	TerminateEmployee(ctx context.Context, emp *Employee, terminationDate time.Time, reason constants.TerminationReason) error
+	CreateEmploymentEvent(ctx context.Context, event *EmploymentEvent) error
+	UpdateEmploymentEvent(ctx context.Context, event *EmploymentEvent) error
+	FindEmploymentEvents(ctx context.Context, employeeID uint) ([]EmploymentEvent, error)
+	FindDueEmploymentEvents(ctx context.Context, date time.Time) ([]EmploymentEvent, error)
+	VoidPendingEmploymentEvents(ctx context.Context, employeeID uint) error
 	FindEmployeeByID(ctx context.Context, id uint) (*Employee, error)
 	FindEmployeeByEmail(ctx context.Context, email string) (*Employee, error)
-	UpdatePasswordByEmail(ctx context.Context, email string, password string) error
+	FindEmployeeByCompanyEmail(ctx context.Context, companyID uint, email string) (*Employee, error)
+	FindPasswordHistory(ctx context.Context, userID uint, limit int) ([]string, error)
+	CreatePasswordHistory(ctx context.Context, history *PasswordHistory) error
+	UpdateMFA(ctx context.Context, userID, companyID uint, secret string, recoveryHashes []string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID, companyID uint, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uint) (int64, error)
 	CountActiveEmployee(ctx context.Context) (int64, error)
 	FindAllEmployeeActive(ctx context.Context) ([]Employee, error)
+	FindAllEmployeeActiveInScope(ctx context.Context) ([]Employee, error)
 	FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error)
+	FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error)
+	FindSupervisor(ctx context.Context, employeeID uint) (*Employee, error)
 	FindRoleByID(ctx context.Context, id uint) (*rbac.Role, error)
+	FindRoles(ctx context.Context) ([]rbac.Role, error)
+	FindDepartments(ctx context.Context) ([]department.Department, error)
+	FindDepartmentByID(ctx context.Context, id uint) (*department.Department, error)
+	FindShifts(ctx context.Context) ([]master.Shift, error)
+	FindExistingNIKIndexes(ctx context.Context, indexes []string) ([]string, error)
+	FindExistingEmails(ctx context.Context, emails []string) ([]string, error)
 	FindAllUserIDs(ctx context.Context) ([]uint, error)
 	ForceResetPasswordByCompanyID(ctx context.Context, companyID uint) error
 }
@@ -56,7 +84,7 @@ func (r *repository) FindByID(ctx context.Context, id uint) (*User, error) {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 	var user User
 
-	err := db.Preload("Employee.Department").Preload("Employee.Shift").Preload("Role").First(&user, id).Error
+	err := db.Preload("Employee.Department").Preload("Employee.Shift").Preload("Role.Permissions").First(&user, id).Error
 	if err != nil {
 		logger.Errorw("UserRepository.FindByID ERROR: ", err)
 
@@ -86,12 +114,13 @@ func (r *repository) FindAllEmployees(ctx context.Context, page, limit int, sear
 		Preload("Role").
 		Preload("Employee").
 		Preload("Employee.Department").
-		Preload("Employee.Shift")
+		Preload("Employee.Shift").
+		Preload("Employee.Manager")
 
-	// filter search by fullname or NIK/ID
+	// filter search by fullname or exact NIK/ID, the NIK is encrypted so only its blind index can be matched
 	if search != "" {
 		searchParam := "%" + search + "%"
-		query = query.Where("LOWER(employees.full_name) LIKE LOWER(?) OR LOWER(employees.nik) LIKE LOWER(?)", searchParam, searchParam)
+		query = query.Where("LOWER(employees.full_name) LIKE LOWER(?) OR employees.nik_bidx = ?", searchParam, infrastructure.BlindIndex(search))
 	}
 
 	if err := query.Count(&total).Error; err != nil {
@@ -119,6 +148,85 @@ func (r *repository) DeleteUser(ctx context.Context, id uint) error {
 	return db.Delete(&User{}, id).Error
 }
 
+// TerminateEmployee records the end of the employment as a termination event and deactivates the user, so the
+// employee leaves payroll and can no longer sign in while their history is kept.
+func (r *repository) TerminateEmployee(ctx context.Context, emp *Employee, terminationDate time.Time, reason constants.TerminationReason) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	if err := db.Model(&Employee{}).
+		Where("id = ?", emp.ID).
+		Updates(map[string]interface{}{
+			"employment_status":  constants.EmploymentStatusTerminated,
+			"termination_date":   terminationDate,
+			"termination_reason": reason,
+		}).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := r.CreateEmploymentEvent(ctx, &EmploymentEvent{
+		EmployeeID:        emp.ID,
+		CompanyID:         emp.CompanyID,
+		Type:              constants.EmploymentEventTermination,
+		EffectiveDate:     terminationDate,
+		TerminationReason: reason,
+		CreatedBy:         utils.GetUserIDFromCtx(ctx),
+		AppliedAt:         &now,
+	}); err != nil {
+		return err
+	}
+
+	if err := r.VoidPendingEmploymentEvents(ctx, emp.ID); err != nil {
+		return err
+	}
+
+	db = utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	return db.Model(&User{}).
+		Where("id = ?", emp.UserID).
+		Update("is_active", false).Error
+}
+
+func (r *repository) CreateEmploymentEvent(ctx context.Context, event *EmploymentEvent) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	return db.Create(event).Error
+}
+
+func (r *repository) UpdateEmploymentEvent(ctx context.Context, event *EmploymentEvent) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	return db.Omit("Department", "PreviousDepartment").Save(event).Error
+}
+
+// FindEmploymentEvents returns the employment history of an employee, latest first.
+func (r *repository) FindEmploymentEvents(ctx context.Context, employeeID uint) ([]EmploymentEvent, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var events []EmploymentEvent
+	err := db.Preload("Department").
+		Preload("PreviousDepartment").
+		Where("employee_id = ?", employeeID).
+		Order("effective_date DESC, id DESC").
+		Find(&events).Error
+	return events, err
+}
+
+// FindDueEmploymentEvents returns the events not applied yet that take effect on or before the date, in the order
+// they have to be applied.
+func (r *repository) FindDueEmploymentEvents(ctx context.Context, date time.Time) ([]EmploymentEvent, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var events []EmploymentEvent
+	err := db.Where("applied_at IS NULL AND voided_at IS NULL AND effective_date <= ?", date).
+		Order("effective_date ASC, id ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// VoidPendingEmploymentEvents drops the events of a terminated employee that have not taken effect yet,
+// a rehire starts with a history of its own.
+func (r *repository) VoidPendingEmploymentEvents(ctx context.Context, employeeID uint) error {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	return db.Model(&EmploymentEvent{}).
+		Where("employee_id = ? AND applied_at IS NULL AND voided_at IS NULL", employeeID).
+		Update("voided_at", time.Now()).Error
+}
+
 func (r *repository) FindEmployeeByID(ctx context.Context, id uint) (*Employee, error) {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 	var emp Employee
@@ -133,20 +241,99 @@ func (r *repository) FindEmployeeByEmail(ctx context.Context, email string) (*Em
 	return &emp, err
 }
 
-func (r *repository) UpdatePasswordByEmail(ctx context.Context, email string, password string) error {
+// FindEmployeeByCompanyEmail looks an employee up by email within one company, case-insensitively.
+func (r *repository) FindEmployeeByCompanyEmail(ctx context.Context, companyID uint, email string) (*Employee, error) {
 	db := utils.GetDBFromContext(ctx, r.db)
 	var emp Employee
-	err := db.Preload("User").Where("email = ?", email).First(&emp).Error
-	if err != nil {
+	err := db.Preload("User").
+		Where("company_id = ? AND LOWER(email) = ?", companyID, strings.ToLower(email)).
+		First(&emp).Error
+	return &emp, err
+}
+
+// FindPasswordHistory returns the most recent replaced password hashes of a user, newest first.
+func (r *repository) FindPasswordHistory(ctx context.Context, userID uint, limit int) ([]string, error) {
+	var hashes []string
+	err := utils.GetDBFromContext(ctx, r.db).Model(&PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes).Error
+	return hashes, err
+}
+
+func (r *repository) CreatePasswordHistory(ctx context.Context, history *PasswordHistory) error {
+	return utils.GetDBFromContext(ctx, r.db).Create(history).Error
+}
+
+// UpdateMFA stores the authenticator secret of a user with a fresh set of recovery codes, an empty secret disables
+// MFA and drops the codes.
+func (r *repository) UpdateMFA(ctx context.Context, userID, companyID uint, secret string, recoveryHashes []string) error {
+	var enabledAt *time.Time
+	if secret != "" {
+		now := time.Now()
+		enabledAt = &now
+	}
+
+	return utils.GetDBFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&User{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"mfa_secret":     secret,
+				"mfa_enabled":    secret != "",
+				"mfa_enabled_at": enabledAt,
+			}).Error; err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(tx, userID, companyID, recoveryHashes)
+	})
+}
+
+// ReplaceRecoveryCodes drops the previous recovery codes of a user, used or not, and stores the new hashes.
+func (r *repository) ReplaceRecoveryCodes(ctx context.Context, userID, companyID uint, hashes []string) error {
+	return utils.GetDBFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, companyID, hashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID, companyID uint, hashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&MFARecoveryCode{}).Error; err != nil {
 		return err
 	}
-	return db.Model(&User{}).Where("id = ?", emp.User.ID).Update("password_hash", password).Error
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	codes := make([]MFARecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, MFARecoveryCode{UserID: userID, CompanyID: companyID, CodeHash: hash})
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseRecoveryCode marks an unused code as used, it reports false when the code is unknown or already spent.
+func (r *repository) UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error) {
+	result := utils.GetDBFromContext(ctx, r.db).
+		Model(&MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *repository) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := utils.GetDBFromContext(ctx, r.db).
+		Model(&MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
 }
 
 func (r *repository) CountActiveEmployee(ctx context.Context) (int64, error) {
 	db := utils.GetDBFromContext(ctx, r.db)
 	var totalActive int64
-	if err := utils.TenantScope(ctx, db.Model(&User{})).
+	if err := utils.UserDataScope(ctx, utils.TenantScope(ctx, db.Model(&User{})), "users.id").
 		Joins("JOIN roles on roles.id = users.role_id").
 		Where("users.is_active = ? AND roles.name = ?", true, string(constants.UserRoleEmployee)).
 		Count(&totalActive).Error; err != nil {
@@ -157,10 +344,20 @@ func (r *repository) CountActiveEmployee(ctx context.Context) (int64, error) {
 }
 
 func (r *repository) FindAllEmployeeActive(ctx context.Context) ([]Employee, error) {
-	db := utils.GetDBFromContext(ctx, r.db)
+	return r.findAllEmployeeActive(utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Employee{})))
+}
+
+// FindAllEmployeeActiveInScope is FindAllEmployeeActive narrowed to the data scope of the caller's permission,
+// for reports and bulk runs started by a user rather than company wide jobs.
+func (r *repository) FindAllEmployeeActiveInScope(ctx context.Context) ([]Employee, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db).Model(&Employee{}))
+	return r.findAllEmployeeActive(utils.DataScope(ctx, db, "employees.id"))
+}
+
+func (r *repository) findAllEmployeeActive(db *gorm.DB) ([]Employee, error) {
 	var employees []Employee
 
-	if err := utils.TenantScope(ctx, db.Model(&Employee{})).
+	if err := db.
 		Joins("User").
 		Joins("JOIN roles on roles.id = User.role_id").
 		Where("User.is_active = ? AND roles.name NOT IN ?", true, []string{string(constants.UserRolePlatformAdmin), "SUPERADMIN"}).
@@ -195,6 +392,67 @@ func (r *repository) FindApprovalUsers(ctx context.Context, permissionApprovalNa
 	return ids, nil
 }
 
+// FindSupervisor returns the active employee the given one reports to: the direct manager, else the head of
+// their department. gorm.ErrRecordNotFound when neither is set or active.
+func (r *repository) FindSupervisor(ctx context.Context, employeeID uint) (*Employee, error) {
+	var emp Employee
+	if err := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db)).Preload("Department").First(&emp, employeeID).Error; err != nil {
+		return nil, err
+	}
+
+	for _, supervisorID := range emp.reportingLine() {
+		supervisor, err := r.FindEmployeeByID(ctx, supervisorID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if supervisor.User.IsActive {
+			return supervisor, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+// FindRequestApprovers returns who is asked to approve a request: the requester's direct manager,
+// else the head of their department, else every user holding the approval permission.
+// A manager is skipped when inactive or unable to approve.
+func (r *repository) FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error) {
+	approverIDs, err := r.FindApprovalUsers(ctx, permissionApprovalName)
+	if err != nil {
+		return nil, err
+	}
+
+	requester, err := r.FindByID(ctx, requesterUserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return approverIDs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if requester.Employee == nil {
+		return approverIDs, nil
+	}
+
+	for _, managerID := range requester.Employee.reportingLine() {
+		manager, err := r.FindEmployeeByID(ctx, managerID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if manager.UserID != requesterUserID && manager.User.IsActive && slices.Contains(approverIDs, manager.UserID) {
+			return []uint{manager.UserID}, nil
+		}
+	}
+
+	return approverIDs, nil
+}
+
 func (r *repository) ForceResetPasswordByCompanyID(ctx context.Context, companyID uint) error {
 	return utils.GetDBFromContext(ctx, r.db).
 		Model(&User{}).
@@ -212,6 +470,65 @@ func (r *repository) FindRoleByID(ctx context.Context, id uint) (*rbac.Role, err
 	return &role, nil
 }
 
+func (r *repository) FindRoles(ctx context.Context) ([]rbac.Role, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var roles []rbac.Role
+	err := db.Order("name ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r *repository) FindDepartments(ctx context.Context) ([]department.Department, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var departments []department.Department
+	err := db.Order("name ASC").Find(&departments).Error
+	return departments, err
+}
+
+func (r *repository) FindDepartmentByID(ctx context.Context, id uint) (*department.Department, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var dept department.Department
+	if err := db.First(&dept, id).Error; err != nil {
+		return nil, err
+	}
+	return &dept, nil
+}
+
+func (r *repository) FindShifts(ctx context.Context) ([]master.Shift, error) {
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var shifts []master.Shift
+	err := db.Order("name ASC").Find(&shifts).Error
+	return shifts, err
+}
+
+// FindExistingNIKIndexes returns which of the NIK blind indexes are taken. The index is unique across companies,
+// so the lookup is not tenant scoped.
+func (r *repository) FindExistingNIKIndexes(ctx context.Context, indexes []string) ([]string, error) {
+	if len(indexes) == 0 {
+		return nil, nil
+	}
+
+	db := utils.GetDBFromContext(ctx, r.db)
+	var existing []string
+	err := db.Model(&Employee{}).
+		Where("nik_bidx IN ?", indexes).
+		Pluck("nik_bidx", &existing).Error
+	return existing, err
+}
+
+// FindExistingEmails returns which of the emails, lowercased, already belong to an employee of the company.
+func (r *repository) FindExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+
+	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
+	var existing []string
+	err := db.Model(&Employee{}).
+		Where("LOWER(email) IN ?", emails).
+		Pluck("LOWER(email)", &existing).Error
+	return existing, err
+}
+
 func (r *repository) FindAllUserIDs(ctx context.Context) ([]uint, error) {
 	db := utils.TenantScope(ctx, utils.GetDBFromContext(ctx, r.db))
 	var ids []uint
@@ -1,15 +1,21 @@
 package user
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"basekarya-backend/internal/infrastructure"
 	"basekarya-backend/internal/modules/department"
 	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/rbac"
 	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func setupUserTestDB(t *testing.T) *testutil.TestDB {
@@ -20,6 +26,9 @@ func setupUserTestDB(t *testing.T) *testutil.TestDB {
 		&master.Shift{},
 		&User{},
 		&Employee{},
+		&PasswordHistory{},
+		&MFARecoveryCode{},
+		&EmploymentEvent{},
 	)
 	t.Cleanup(tdb.Close)
 	return tdb
@@ -275,6 +284,175 @@ func TestRepo_DeleteUser(t *testing.T) {
 	}
 }
 
+func TestRepo_TerminateEmployee(t *testing.T) {
+	tdb := setupUserTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedUserTestData(t, tdb)
+
+	emp, err := repo.FindEmployeeByID(ctx, 1)
+	require.NoError(t, err)
+
+	pending := &EmploymentEvent{
+		EmployeeID: 1, CompanyID: 1, Type: constants.EmploymentEventProbationPass,
+		EffectiveDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, repo.CreateEmploymentEvent(ctx, pending))
+
+	lastDay := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.TerminateEmployee(ctx, emp, lastDay, constants.TerminationResignation))
+
+	emp, err = repo.FindEmployeeByID(ctx, 1)
+	require.NoError(t, err)
+	require.NotNil(t, emp.TerminationDate)
+	assert.Equal(t, "2026-03-15", emp.TerminationDate.Format(constants.DefaultTimeFormat))
+	assert.Equal(t, constants.TerminationResignation, emp.TerminationReason)
+	assert.Equal(t, constants.EmploymentStatusTerminated, emp.EmploymentStatus)
+	assert.False(t, emp.User.IsActive)
+
+	events, err := repo.FindEmploymentEvents(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, constants.EmploymentEventTermination, events[0].Type)
+	assert.Equal(t, constants.TerminationResignation, events[0].TerminationReason)
+	assert.NotNil(t, events[0].AppliedAt)
+	assert.Nil(t, events[0].VoidedAt)
+	// the probation pass still pending is dropped with the termination
+	assert.Equal(t, pending.ID, events[1].ID)
+	assert.NotNil(t, events[1].VoidedAt)
+
+	due, err := repo.FindDueEmploymentEvents(ctx, lastDay)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+}
+
+func TestRepo_EmploymentEvents(t *testing.T) {
+	testutil.UseTestKeyring()
+	tdb := setupUserTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedUserTestData(t, tdb)
+
+	applied := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	deptID := uint(1)
+	hire := &EmploymentEvent{
+		EmployeeID: 1, CompanyID: 1, Type: constants.EmploymentEventHire,
+		EffectiveDate: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		Position:      "Developer", DepartmentID: &deptID, BaseSalary: 5000000, AppliedAt: &applied,
+	}
+	raise := &EmploymentEvent{
+		EmployeeID: 1, CompanyID: 1, Type: constants.EmploymentEventSalaryChange,
+		EffectiveDate: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), BaseSalary: 6000000,
+	}
+	later := &EmploymentEvent{
+		EmployeeID: 1, CompanyID: 1, Type: constants.EmploymentEventPromotion,
+		EffectiveDate: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), Position: "Senior Developer",
+	}
+	for _, event := range []*EmploymentEvent{hire, raise, later} {
+		require.NoError(t, repo.CreateEmploymentEvent(ctx, event))
+	}
+
+	t.Run("history is latest first", func(t *testing.T) {
+		events, err := repo.FindEmploymentEvents(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+		assert.Equal(t, constants.EmploymentEventPromotion, events[0].Type)
+		assert.Equal(t, constants.EmploymentEventHire, events[2].Type)
+		require.NotNil(t, events[2].Department)
+		assert.Equal(t, "Engineering", events[2].Department.Name)
+		assert.Equal(t, 5000000.0, events[2].BaseSalary)
+	})
+
+	t.Run("other tenant sees nothing", func(t *testing.T) {
+		events, err := repo.FindEmploymentEvents(testutil.CtxWithTenant(2, 1, false), 1)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("due events are the unapplied ones up to the date", func(t *testing.T) {
+		events, err := repo.FindDueEmploymentEvents(context.Background(), time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, raise.ID, events[0].ID)
+	})
+
+	t.Run("applied events are no longer due", func(t *testing.T) {
+		now := time.Now()
+		raise.AppliedAt = &now
+		raise.PreviousBaseSalary = 5000000
+		require.NoError(t, repo.UpdateEmploymentEvent(ctx, raise))
+
+		events, err := repo.FindDueEmploymentEvents(context.Background(), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, later.ID, events[0].ID)
+	})
+}
+
+func TestRepo_PasswordHistory(t *testing.T) {
+	tdb := setupUserTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedUserTestData(t, tdb)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, hash := range []string{"hash-1", "hash-2", "hash-3"} {
+		require.NoError(t, repo.CreatePasswordHistory(ctx, &PasswordHistory{UserID: 1, CompanyID: 1, PasswordHash: hash, CreatedAt: base.AddDate(0, i, 0)}))
+	}
+	require.NoError(t, repo.CreatePasswordHistory(ctx, &PasswordHistory{UserID: 2, CompanyID: 1, PasswordHash: "other"}))
+
+	hashes, err := repo.FindPasswordHistory(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hash-3", "hash-2"}, hashes)
+}
+
+func TestRepo_MFA(t *testing.T) {
+	tdb := setupUserTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedUserTestData(t, tdb)
+
+	require.NoError(t, repo.UpdateMFA(ctx, 1, 1, "SECRET", []string{"old-1", "old-2"}))
+	usr, err := repo.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, usr.MFAEnabled)
+	assert.Equal(t, "SECRET", usr.MFASecret)
+	assert.NotNil(t, usr.MFAEnabledAt)
+
+	require.NoError(t, repo.ReplaceRecoveryCodes(ctx, 1, 1, []string{"new-1", "new-2", "new-3"}))
+
+	used, err := repo.UseRecoveryCode(ctx, 1, "old-1")
+	require.NoError(t, err)
+	assert.False(t, used, "replaced codes no longer work")
+
+	used, err = repo.UseRecoveryCode(ctx, 1, "new-2")
+	require.NoError(t, err)
+	assert.True(t, used)
+
+	used, err = repo.UseRecoveryCode(ctx, 1, "new-2")
+	require.NoError(t, err)
+	assert.False(t, used, "a code works once")
+
+	count, err := repo.CountRecoveryCodes(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	require.NoError(t, repo.UpdateMFA(ctx, 1, 1, "", nil))
+	usr, err = repo.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, usr.MFAEnabled)
+	assert.Empty(t, usr.MFASecret)
+	assert.Nil(t, usr.MFAEnabledAt)
+
+	count, err = repo.CountRecoveryCodes(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
 func TestRepo_FindEmployeeByID(t *testing.T) {
 	tdb := setupUserTestDB(t)
 	repo := NewRepository(tdb.DB)
@@ -305,6 +483,22 @@ func TestRepo_FindEmployeeByID(t *testing.T) {
 	}
 }
 
+func TestRepo_FindEmployeeByCompanyEmail(t *testing.T) {
+	tdb := setupUserTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedUserTestData(t, tdb)
+
+	emp, err := repo.FindEmployeeByCompanyEmail(ctx, 1, "John@Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), emp.ID)
+	assert.Equal(t, "john.doe", emp.User.Username)
+
+	_, err = repo.FindEmployeeByCompanyEmail(ctx, 2, "john@example.com")
+	require.Error(t, err)
+}
+
 func TestRepo_FindAllEmployees(t *testing.T) {
 	tdb := setupUserTestDB(t)
 	repo := NewRepository(tdb.DB)
@@ -398,3 +592,124 @@ func TestRepo_FindAllUserIDs(t *testing.T) {
 		})
 	}
 }
+
+func TestRepo_FindRequestApprovers(t *testing.T) {
+	tdb := testutil.NewTestDB(
+		&rbac.Permission{},
+		&rbac.Role{},
+		&department.Department{},
+		&master.Shift{},
+		&User{},
+		&Employee{},
+	)
+	t.Cleanup(tdb.Close)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	db := tdb.DB
+	require.NoError(t, db.Create(&rbac.Permission{ID: 1, Name: "APPROVAL_LEAVE", DisplayName: "Approve Leave"}).Error)
+	require.NoError(t, db.Create(&rbac.Role{ID: 1, Name: "EMPLOYEE", CompanyID: 1}).Error)
+	require.NoError(t, db.Create(&rbac.Role{ID: 2, Name: "MANAGER", CompanyID: 1}).Error)
+	require.NoError(t, db.Exec("INSERT INTO role_permissions (role_id, permission_id) VALUES (2, 1)").Error)
+
+	head := uint(3)
+	require.NoError(t, db.Create(&department.Department{ID: 1, Name: "Engineering", CompanyID: 1, HeadEmployeeID: &head}).Error)
+	require.NoError(t, db.Create(&department.Department{ID: 2, Name: "Sales", CompanyID: 1}).Error)
+
+	// user and employee share the id, roles: 2 can approve leave
+	people := []struct {
+		id         uint
+		roleID     uint
+		department uint
+		manager    *uint
+	}{
+		{id: 1, roleID: 1, department: 1, manager: uintPtr(2)},
+		{id: 2, roleID: 2, department: 1},
+		{id: 3, roleID: 2, department: 1},
+		{id: 4, roleID: 2, department: 2},
+		{id: 5, roleID: 1, department: 1},
+		{id: 6, roleID: 1, department: 1},
+		{id: 7, roleID: 1, department: 2, manager: uintPtr(4)},
+		{id: 8, roleID: 1, department: 1, manager: uintPtr(5)},
+	}
+	for _, p := range people {
+		require.NoError(t, db.Create(&User{ID: p.id, Username: fmt.Sprintf("user%d", p.id), RoleID: p.roleID, CompanyID: 1, IsActive: true}).Error)
+		require.NoError(t, db.Create(&Employee{
+			ID: p.id, UserID: p.id, CompanyID: 1, DepartmentID: p.department, ShiftID: 1,
+			NIK: fmt.Sprintf("EMP%03d", p.id), FullName: fmt.Sprintf("Employee %d", p.id), ManagerID: p.manager,
+		}).Error)
+	}
+	require.NoError(t, db.Model(&User{}).Where("id = ?", 4).Update("is_active", false).Error)
+
+	tests := []struct {
+		name            string
+		requesterUserID uint
+		want            []uint
+	}{
+		{name: "direct manager", requesterUserID: 1, want: []uint{2}},
+		{name: "department head when there is no manager", requesterUserID: 6, want: []uint{3}},
+		{name: "department head when the manager cannot approve", requesterUserID: 8, want: []uint{3}},
+		{name: "permission holders when the manager is inactive and there is no head", requesterUserID: 7, want: []uint{2, 3, 4}},
+		{name: "permission holders for the department head's own request", requesterUserID: 3, want: []uint{2, 3, 4}},
+		{name: "permission holders for an unknown requester", requesterUserID: 99, want: []uint{2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.FindRequestApprovers(ctx, tt.requesterUserID, "APPROVAL_LEAVE")
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+
+	t.Run("supervisor", func(t *testing.T) {
+		supervisor, err := repo.FindSupervisor(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Employee 2", supervisor.FullName)
+
+		supervisor, err = repo.FindSupervisor(ctx, 6)
+		require.NoError(t, err)
+		assert.Equal(t, "Employee 3", supervisor.FullName)
+
+		// inactive manager and no department head
+		_, err = repo.FindSupervisor(ctx, 7)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		// the head reports to nobody
+		_, err = repo.FindSupervisor(ctx, 3)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestRepo_ImportLookups(t *testing.T) {
+	tdb := setupUserTestDB(t)
+	repo := NewRepository(tdb.DB)
+	ctx := testutil.CtxWithTenant(1, 1, false)
+
+	seedUserTestData(t, tdb)
+	require.NoError(t, tdb.DB.Create(&department.Department{ID: 2, Name: "Finance", CompanyID: 2}).Error)
+
+	departments, err := repo.FindDepartments(ctx)
+	require.NoError(t, err)
+	require.Len(t, departments, 1)
+	assert.Equal(t, "Engineering", departments[0].Name)
+
+	roles, err := repo.FindRoles(ctx)
+	require.NoError(t, err)
+	assert.Len(t, roles, 1)
+
+	shifts, err := repo.FindShifts(ctx)
+	require.NoError(t, err)
+	assert.Len(t, shifts, 1)
+
+	indexes, err := repo.FindExistingNIKIndexes(ctx, []string{infrastructure.BlindIndex("emp001"), infrastructure.BlindIndex("EMP999")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{infrastructure.BlindIndex("EMP001")}, indexes)
+
+	emails, err := repo.FindExistingEmails(ctx, []string{"john@example.com", "jane@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"john@example.com"}, emails)
+
+	emails, err = repo.FindExistingEmails(testutil.CtxWithTenant(2, 1, false), []string{"john@example.com"})
+	require.NoError(t, err)
+	assert.Empty(t, emails)
+}
@@ -0,0 +1,47 @@
+package user
+
+import (
+	"basekarya-backend/internal/infrastructure"
+	"basekarya-backend/pkg/logger"
+	"context"
+)
+
+type Scheduler interface {
+	Start()
+	Stop()
+}
+
+type scheduler struct {
+	cronProvider *infrastructure.CronProvider
+	service      Service
+}
+
+func NewScheduler(cronProvider *infrastructure.CronProvider, service Service) Scheduler {
+	return &scheduler{cronProvider, service}
+}
+
+func (sch *scheduler) Start() {
+	logger.Info("Employment Scheduler Started...")
+
+	// Run every day at 00:30, events dated today take effect from the start of the day
+	_, err := sch.cronProvider.GetCron().AddFunc("30 0 * * *", func() {
+		logger.Info("[SCHEDULER] Applying due employment events...")
+
+		if err := sch.service.ApplyDueEmploymentEvents(context.Background()); err != nil {
+			logger.Errorf("[SCHEDULER] Failed: %v\n", err)
+		}
+	})
+
+	if err != nil {
+		logger.Errorf("Failed to start employment scheduler ", err)
+	}
+
+	sch.cronProvider.GetCron().Start()
+}
+
+func (sch *scheduler) Stop() {
+	if sch.cronProvider != nil && sch.cronProvider.GetCron() != nil {
+		sch.cronProvider.GetCron().Stop()
+		logger.Info("Employment Scheduler Stopped.")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"mime/multipart"
+	"sort"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -20,12 +21,25 @@ type Service interface {
 	GetProfile(userID uint) (*UserProfileResponse, error)
 	UpdateProfile(ctx context.Context, userID uint, req *UpdateProfileRequest, file *multipart.FileHeader) error
 	ChangePassword(ctx context.Context, userID uint, req *ChangePasswordRequest) error
-	GetAllEmployees(ctx context.Context, page, limit int, search string) ([]EmployeeListResponse, *response.Meta, error)
+	UpdatePassword(ctx context.Context, userID uint, password string) error
+	GetAllEmployees(ctx context.Context, page, limit int, search string, showSensitive bool) ([]EmployeeListResponse, *response.Meta, error)
 	CreateEmployee(ctx context.Context, req *CreateEmployeeRequest) (*CreateEmployeeResponse, error)
 	UpdateEmployee(ctx context.Context, id uint, req *UpdateEmployeeRequest) error
 	DeleteEmployee(ctx context.Context, id uint) error
 
+	GetEmploymentHistory(ctx context.Context, employeeID uint, showSensitive bool) ([]EmploymentEventResponse, error)
+	RecordEmploymentEvent(ctx context.Context, employeeID uint, req *EmploymentEventRequest, file *multipart.FileHeader) error
+	ApplyDueEmploymentEvents(ctx context.Context) error
+
+	GenerateEmployeeImportTemplate(ctx context.Context) ([]byte, error)
+	ValidateEmployeeImport(ctx context.Context, file *multipart.FileHeader) (*EmployeeImportReport, error)
+	ImportEmployees(ctx context.Context, file *multipart.FileHeader) (*EmployeeImportReport, error)
+	ExportEmployees(ctx context.Context, showSensitive bool) ([]byte, error)
+
+	GetOrgChart(ctx context.Context) ([]OrgChartNode, error)
+
 	FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error)
+	FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error)
 }
 
 type service struct {
@@ -37,10 +51,12 @@ type service struct {
 	transactionManager infrastructure.TransactionManager
 	subscription       SubscriptionProvider
 	email              EmailProvider
+	sessions           SessionRevoker
+	excel              infrastructure.ExcelProvider
 }
 
-func NewService(repo Repository, bcrypt Hasher, storage StorageProvider, cache CacheProvider, leaveGenerator LeaveBalanceGenerator, transactionManager infrastructure.TransactionManager, subscription SubscriptionProvider, email EmailProvider) Service {
-	return &service{repo, bcrypt, storage, cache, leaveGenerator, transactionManager, subscription, email}
+func NewService(repo Repository, bcrypt Hasher, storage StorageProvider, cache CacheProvider, leaveGenerator LeaveBalanceGenerator, transactionManager infrastructure.TransactionManager, subscription SubscriptionProvider, email EmailProvider, sessions SessionRevoker, excel infrastructure.ExcelProvider) Service {
+	return &service{repo, bcrypt, storage, cache, leaveGenerator, transactionManager, subscription, email, sessions, excel}
 }
 
 func (s *service) GetProfile(userID uint) (*UserProfileResponse, error) {
@@ -152,28 +168,71 @@ func (s *service) ChangePassword(ctx context.Context, userID uint, req *ChangePa
 		return errors.New("invalid old password")
 	}
 
-	hashedPassword, err := s.bcrypt.HashPassword(req.NewPassword)
+	return s.setPassword(ctx, user, req.NewPassword)
+}
+
+// UpdatePassword sets a new password without the old one, used once a password reset has been verified.
+func (s *service) UpdatePassword(ctx context.Context, userID uint, password string) error {
+	user, err := s.repo.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
-	user.PasswordHash = hashedPassword
-	user.MustChangePassword = false
+	return s.setPassword(ctx, user, password)
+}
+
+// setPassword applies the password policy, refuses the current and recent passwords, keeps the replaced hash in
+// the history and signs the user out everywhere.
+func (s *service) setPassword(ctx context.Context, user *User, password string) error {
+	if err := utils.CheckPasswordPolicy(password); err != nil {
+		return err
+	}
 
-	err = s.repo.UpdateUser(ctx, user)
+	history, err := s.repo.FindPasswordHistory(ctx, user.ID, constants.PasswordHistoryCount-1)
 	if err != nil {
 		return err
 	}
+	for _, hash := range append([]string{user.PasswordHash}, history...) {
+		if hash != "" && s.bcrypt.CheckPasswordHash(password, hash) {
+			return fmt.Errorf("password must differ from your last %d passwords", constants.PasswordHistoryCount)
+		}
+	}
 
-	err = s.cache.Del(ctx, fmt.Sprintf(constants.USER_CACHE_KEY, userID))
+	hashedPassword, err := s.bcrypt.HashPassword(password)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	err = s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		if user.PasswordHash != "" {
+			if err := s.repo.CreatePasswordHistory(ctx, &PasswordHistory{
+				UserID:       user.ID,
+				CompanyID:    user.CompanyID,
+				PasswordHash: user.PasswordHash,
+			}); err != nil {
+				return err
+			}
+		}
+
+		user.PasswordHash = hashedPassword
+		user.MustChangePassword = false
+		return s.repo.UpdateUser(ctx, user)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.cache.Del(ctx, fmt.Sprintf(constants.USER_CACHE_KEY, user.ID))
+	if err != nil {
+		return err
+	}
+
+	// sessions signed in with the old password are signed out, the user logs in again
+	return s.sessions.RevokeUser(ctx, user.ID)
 }
 
-func (s *service) GetAllEmployees(ctx context.Context, page, limit int, search string) ([]EmployeeListResponse, *response.Meta, error) {
+// GetAllEmployees masks the NIK and leaves out the salary unless showSensitive is set.
+func (s *service) GetAllEmployees(ctx context.Context, page, limit int, search string, showSensitive bool) ([]EmployeeListResponse, *response.Meta, error) {
 	users, total, err := s.repo.FindAllEmployees(ctx, page, limit, search)
 	if err != nil {
 		return nil, nil, err
@@ -187,7 +246,7 @@ func (s *service) GetAllEmployees(ctx context.Context, page, limit int, search s
 	for _, u := range users {
 		deptName := "-"
 		shiftName := "-"
-		baseSalary := 0.0
+		var baseSalary *float64
 		var deptID uint
 		var shiftID uint
 
@@ -200,25 +259,47 @@ func (s *service) GetAllEmployees(ctx context.Context, page, limit int, search s
 				shiftName = u.Employee.Shift.Name
 				shiftID = u.Employee.ShiftID
 			}
-			if u.Employee.BaseSalary != 0 {
-				baseSalary = u.Employee.BaseSalary
+			nik := u.Employee.NIK
+			if showSensitive {
+				salary := u.Employee.BaseSalary
+				baseSalary = &salary
+			} else {
+				nik = utils.MaskString(nik, 4)
+			}
+			joinDate := ""
+			if u.Employee.JoinDate != nil {
+				joinDate = u.Employee.JoinDate.Format(constants.DefaultTimeFormat)
+			}
+			managerName := ""
+			if u.Employee.Manager != nil {
+				managerName = u.Employee.Manager.FullName
+			}
+			terminationDate := ""
+			if u.Employee.TerminationDate != nil {
+				terminationDate = u.Employee.TerminationDate.Format(constants.DefaultTimeFormat)
 			}
 
 			list = append(list, EmployeeListResponse{
-				ID:              u.Employee.ID,
-				FullName:        u.Employee.FullName,
-				NIK:             u.Employee.NIK,
-				Username:        u.Username,
-				DepartmentID:    deptID,
-				DepartmentName:  deptName,
-				ShiftID:         shiftID,
-				ShiftName:       shiftName,
-				RoleID:          u.Role.ID,
-				BaseSalary:      baseSalary,
-				Email:           u.Employee.Email,
-				Position:        u.Employee.Position,
-				MaritalStatus:   string(u.Employee.MaritalStatus),
-				DependentsCount: u.Employee.DependentsCount,
+				ID:               u.Employee.ID,
+				FullName:         u.Employee.FullName,
+				NIK:              nik,
+				Username:         u.Username,
+				DepartmentID:     deptID,
+				DepartmentName:   deptName,
+				ShiftID:          shiftID,
+				ShiftName:        shiftName,
+				RoleID:           u.Role.ID,
+				BaseSalary:       baseSalary,
+				Email:            u.Employee.Email,
+				Position:         u.Employee.Position,
+				Grade:            u.Employee.Grade,
+				MaritalStatus:    string(u.Employee.MaritalStatus),
+				DependentsCount:  u.Employee.DependentsCount,
+				JoinDate:         joinDate,
+				ManagerID:        u.Employee.ManagerID,
+				ManagerName:      managerName,
+				EmploymentStatus: string(u.Employee.EmploymentStatus),
+				TerminationDate:  terminationDate,
 			})
 		}
 	}
@@ -238,85 +319,154 @@ func (s *service) CreateEmployee(ctx context.Context, req *CreateEmployeeRequest
 		}
 	}
 
-	var generatedUsername string
-
-	err := s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
-		generatedUsername = utils.GenerateUsername(req.FullName)
-
-		plainPassword := config.GenerateRandomPassword(12)
-		hashPass, err := s.bcrypt.HashPassword(plainPassword)
+	// join date drives leave eligibility, default to the day the employee is registered
+	joinDate := time.Now()
+	if req.JoinDate != "" {
+		parsed, err := time.Parse(constants.DefaultTimeFormat, req.JoinDate)
 		if err != nil {
-			return err
+			return nil, errors.New("invalid join date format")
 		}
+		joinDate = parsed
+	}
 
-		role, err := s.repo.FindRoleByID(ctx, req.RoleID)
+	var probationEnd *time.Time
+	if req.ProbationEndDate != "" {
+		parsed, err := time.Parse(constants.DefaultTimeFormat, req.ProbationEndDate)
 		if err != nil {
-			return errors.New("role not found")
+			return nil, errors.New("invalid probation end date format")
 		}
-
-		newUser := User{
-			Username:           generatedUsername,
-			PasswordHash:       hashPass,
-			RoleID:             role.ID,
-			CompanyID:          utils.GetCompanyIDFromCtx(ctx),
-			MustChangePassword: true,
+		if !parsed.After(joinDate) {
+			return nil, errors.New("probation end date must be after the join date")
 		}
+		probationEnd = &parsed
+	}
 
-		if err := s.repo.CreateUser(ctx, &newUser); err != nil {
-			return err
+	var managerID *uint
+	if req.ManagerID != nil && *req.ManagerID > 0 {
+		if err := s.validateManager(ctx, 0, *req.ManagerID); err != nil {
+			return nil, err
 		}
+		managerID = req.ManagerID
+	}
 
-		newEmp := Employee{
-			UserID:       newUser.ID,
-			CompanyID:    utils.GetCompanyIDFromCtx(ctx),
-			FullName:     req.FullName,
-			NIK:          req.NIK,
-			DepartmentID: req.DepartmentID,
-			ShiftID:      req.ShiftID,
-			BaseSalary:   req.BaseSalary,
-			Email:        req.Email,
-			Position:     req.Position,
-		}
+	var created *createdEmployee
 
-		if req.MaritalStatus != "" {
-			newEmp.MaritalStatus = constants.MaritalStatus(req.MaritalStatus)
-		}
-		if req.DependentsCount != nil {
-			newEmp.DependentsCount = *req.DependentsCount
+	err := s.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		role, err := s.repo.FindRoleByID(ctx, req.RoleID)
+		if err != nil {
+			return errors.New("role not found")
 		}
 
-		if err := s.repo.CreateEmployee(ctx, &newEmp); err != nil {
-			return err
-		}
+		created, err = s.createEmployee(ctx, req, role.ID, joinDate, probationEnd, managerID)
+		return err
+	})
 
-		if req.Email != "" {
-			go func() {
-				subject := "Basekarya - Akun Karyawan Baru"
-				htmlBody := fmt.Sprintf(`
-					<h2>Selamat datang di Basekarya!</h2>
-					<p>Halo <strong>%s</strong>,</p>
-					<p>Akun karyawan Anda telah dibuat. Berikut kredensial login Anda:</p>
-					<table style="border-collapse: collapse; margin: 16px 0;">
-						<tr><td style="padding: 8px 16px; border: 1px solid #ddd; background: #f9f9f9; font-weight: bold;">Username</td><td style="padding: 8px 16px; border: 1px solid #ddd;">%s</td></tr>
-						<tr><td style="padding: 8px 16px; border: 1px solid #ddd; background: #f9f9f9; font-weight: bold;">Password</td><td style="padding: 8px 16px; border: 1px solid #ddd;">%s</td></tr>
-					</table>
-					<p style="color: #e74c3c; font-weight: bold;">Penting: Segera ubah password Anda setelah login pertama.</p>
-					<br>
-					<p>Terima kasih,</p>
-					<p><strong>HR Team</strong></p>
-				`, req.FullName, generatedUsername, plainPassword)
-				_ = s.email.Send(req.Email, subject, htmlBody)
-			}()
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		return s.leaveGenerator.GenerateInitialBalance(ctx, newEmp.ID)
-	})
+	s.sendCredentials(created)
+
+	return &CreateEmployeeResponse{Username: created.username}, nil
+}
+
+// createdEmployee holds what the new employee is told once the transaction that created them commits.
+type createdEmployee struct {
+	fullName string
+	email    string
+	username string
+	password string
+}
 
+// createEmployee creates the user and employee records with a generated username and password, the hire event and
+// the initial leave balance, the caller runs it in a transaction. probationEnd is nil for a hire without probation.
+func (s *service) createEmployee(ctx context.Context, req *CreateEmployeeRequest, roleID uint, joinDate time.Time, probationEnd *time.Time, managerID *uint) (*createdEmployee, error) {
+	generatedUsername := utils.GenerateUsername(req.FullName)
+
+	plainPassword := config.GenerateRandomPassword(12)
+	hashPass, err := s.bcrypt.HashPassword(plainPassword)
 	if err != nil {
 		return nil, err
 	}
 
-	return &CreateEmployeeResponse{Username: generatedUsername}, nil
+	newUser := User{
+		Username:           generatedUsername,
+		PasswordHash:       hashPass,
+		RoleID:             roleID,
+		CompanyID:          utils.GetCompanyIDFromCtx(ctx),
+		MustChangePassword: true,
+	}
+
+	if err := s.repo.CreateUser(ctx, &newUser); err != nil {
+		return nil, err
+	}
+
+	newEmp := Employee{
+		UserID:       newUser.ID,
+		CompanyID:    utils.GetCompanyIDFromCtx(ctx),
+		FullName:     req.FullName,
+		NIK:          req.NIK,
+		DepartmentID: req.DepartmentID,
+		ShiftID:      req.ShiftID,
+		BaseSalary:   req.BaseSalary,
+		Email:        req.Email,
+		Position:     req.Position,
+		Grade:        req.Grade,
+		JoinDate:     &joinDate,
+		ManagerID:    managerID,
+	}
+
+	if req.MaritalStatus != "" {
+		newEmp.MaritalStatus = constants.MaritalStatus(req.MaritalStatus)
+	}
+	if req.DependentsCount != nil {
+		newEmp.DependentsCount = *req.DependentsCount
+	}
+
+	newEmp.EmploymentStatus = statusAfterHire(probationEnd)
+	if err := s.repo.CreateEmployee(ctx, &newEmp); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordHire(ctx, &newEmp, joinDate, probationEnd); err != nil {
+		return nil, err
+	}
+
+	if err := s.leaveGenerator.GenerateInitialBalance(ctx, newEmp.ID); err != nil {
+		return nil, err
+	}
+
+	return &createdEmployee{
+		fullName: req.FullName,
+		email:    req.Email,
+		username: generatedUsername,
+		password: plainPassword,
+	}, nil
+}
+
+// sendCredentials emails the new employee their login, only after the records exist.
+func (s *service) sendCredentials(created *createdEmployee) {
+	if created.email == "" {
+		return
+	}
+
+	go func() {
+		subject := "Basekarya - Akun Karyawan Baru"
+		htmlBody := fmt.Sprintf(`
+			<h2>Selamat datang di Basekarya!</h2>
+			<p>Halo <strong>%s</strong>,</p>
+			<p>Akun karyawan Anda telah dibuat. Berikut kredensial login Anda:</p>
+			<table style="border-collapse: collapse; margin: 16px 0;">
+				<tr><td style="padding: 8px 16px; border: 1px solid #ddd; background: #f9f9f9; font-weight: bold;">Username</td><td style="padding: 8px 16px; border: 1px solid #ddd;">%s</td></tr>
+				<tr><td style="padding: 8px 16px; border: 1px solid #ddd; background: #f9f9f9; font-weight: bold;">Password</td><td style="padding: 8px 16px; border: 1px solid #ddd;">%s</td></tr>
+			</table>
+			<p style="color: #e74c3c; font-weight: bold;">Penting: Segera ubah password Anda setelah login pertama.</p>
+			<br>
+			<p>Terima kasih,</p>
+			<p><strong>HR Team</strong></p>
+		`, created.fullName, created.username, created.password)
+		_ = s.email.Send(created.email, subject, htmlBody)
+	}()
 }
 
 func (s *service) UpdateEmployee(ctx context.Context, id uint, req *UpdateEmployeeRequest) error {
@@ -325,43 +475,62 @@ func (s *service) UpdateEmployee(ctx context.Context, id uint, req *UpdateEmploy
 		return errors.New("employee not found")
 	}
 
+	// the job is changed by an employment event, so the change is dated and what it replaced stays in the history
+	if jobChanged(emp, req) {
+		return errors.New("department, position, grade and salary are changed by recording a promotion, transfer or salary change in the employment history")
+	}
+
 	if req.FullName != "" {
 		emp.FullName = req.FullName
 	}
 	if req.NIK != "" {
 		emp.NIK = req.NIK
 	}
-	if req.DepartmentID > 0 {
-		emp.DepartmentID = req.DepartmentID
-	}
 	if req.ShiftID > 0 {
 		emp.ShiftID = req.ShiftID
 	}
-	if req.BaseSalary > 0 {
-		emp.BaseSalary = req.BaseSalary
-	}
 	if req.Email != "" {
 		emp.Email = req.Email
 	}
-	if req.Position != "" {
-		emp.Position = req.Position
-	}
 	if req.MaritalStatus != nil {
 		emp.MaritalStatus = constants.MaritalStatus(*req.MaritalStatus)
 	}
 	if req.DependentsCount != nil {
 		emp.DependentsCount = *req.DependentsCount
 	}
+	if req.JoinDate != "" {
+		joinDate, err := time.Parse(constants.DefaultTimeFormat, req.JoinDate)
+		if err != nil {
+			return errors.New("invalid join date format")
+		}
+		emp.JoinDate = &joinDate
+	}
+	if req.ManagerID != nil {
+		if *req.ManagerID == 0 {
+			emp.ManagerID = nil
+		} else {
+			if err := s.validateManager(ctx, emp.ID, *req.ManagerID); err != nil {
+				return err
+			}
+			emp.ManagerID = req.ManagerID
+		}
+	}
 
 	if err := s.repo.UpdateEmployee(ctx, emp); err != nil {
 		return err
 	}
 
-	if req.RoleID > 0 && emp.User.ID > 0 {
+	if req.RoleID > 0 && emp.User.ID > 0 && emp.User.RoleID != req.RoleID {
 		emp.User.RoleID = req.RoleID
+		// tokens naming the old role stop being accepted, the user refreshes to act under the new one
+		emp.User.PermissionVersion++
 		if err := s.repo.UpdateUser(ctx, &emp.User); err != nil {
 			return err
 		}
+
+		if err := s.cache.Del(ctx, fmt.Sprintf(constants.PERMISSION_VERSION_CACHE_KEY, emp.UserID)); err != nil {
+			return err
+		}
 	}
 
 	_ = s.cache.Del(ctx, fmt.Sprintf(constants.USER_CACHE_KEY, emp.UserID))
@@ -369,13 +538,22 @@ func (s *service) UpdateEmployee(ctx context.Context, id uint, req *UpdateEmploy
 	return nil
 }
 
-func (s *service) DeleteEmployee(ctx context.Context, id uint) error {
-	emp, err := s.repo.FindEmployeeByID(ctx, id)
-	if err != nil {
-		return errors.New("employee not found")
-	}
+// jobChanged reports whether the request sets another department, position, grade or salary than the employee has,
+// the edit form sends them back unchanged.
+func jobChanged(emp *Employee, req *UpdateEmployeeRequest) bool {
+	return (req.DepartmentID > 0 && req.DepartmentID != emp.DepartmentID) ||
+		(req.Position != "" && req.Position != emp.Position) ||
+		(req.Grade != "" && req.Grade != emp.Grade) ||
+		(req.BaseSalary > 0 && req.BaseSalary != emp.BaseSalary)
+}
 
-	return s.repo.DeleteUser(ctx, emp.UserID)
+// DeleteEmployee terminates the employee as of today instead of deleting them, so their history and NIK are kept
+// and they can be rehired.
+func (s *service) DeleteEmployee(ctx context.Context, id uint) error {
+	return s.RecordEmploymentEvent(ctx, id, &EmploymentEventRequest{
+		Type:          string(constants.EmploymentEventTermination),
+		EffectiveDate: today().Format(constants.DefaultTimeFormat),
+	}, nil)
 }
 
 func (s *service) buildEmployeeData(ctx context.Context, user *User, req *UpdateProfileRequest, file *multipart.FileHeader) (*User, error) {
@@ -430,3 +608,114 @@ func (s *service) buildEmployeeData(ctx context.Context, user *User, req *Update
 func (s *service) FindApprovalUsers(ctx context.Context, permissionApprovalName string) ([]uint, error) {
 	return s.repo.FindApprovalUsers(ctx, permissionApprovalName)
 }
+
+func (s *service) FindRequestApprovers(ctx context.Context, requesterUserID uint, permissionApprovalName string) ([]uint, error) {
+	return s.repo.FindRequestApprovers(ctx, requesterUserID, permissionApprovalName)
+}
+
+func (s *service) GetOrgChart(ctx context.Context) ([]OrgChartNode, error) {
+	employees, err := s.repo.FindAllEmployeeActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildOrgChart(employees), nil
+}
+
+// validateManager makes sure the manager exists and the employee does not end up managing themselves
+// somewhere up the chain, employeeID is 0 for a new employee.
+func (s *service) validateManager(ctx context.Context, employeeID, managerID uint) error {
+	if managerID == employeeID {
+		return errors.New("employee cannot be their own manager")
+	}
+
+	manager, err := s.repo.FindEmployeeByID(ctx, managerID)
+	if err != nil {
+		return errors.New("manager not found")
+	}
+
+	visited := map[uint]bool{managerID: true}
+	for manager.ManagerID != nil {
+		next := *manager.ManagerID
+		if next == employeeID {
+			return errors.New("reporting line cannot be circular")
+		}
+		if visited[next] {
+			break
+		}
+		visited[next] = true
+
+		manager, err = s.repo.FindEmployeeByID(ctx, next)
+		if err != nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+// buildOrgChart nests employees under who they report to, employees reporting to nobody in the list are roots.
+func buildOrgChart(employees []Employee) []OrgChartNode {
+	sort.Slice(employees, func(i, j int) bool {
+		return employees[i].FullName < employees[j].FullName
+	})
+
+	active := make(map[uint]bool, len(employees))
+	for _, emp := range employees {
+		active[emp.ID] = true
+	}
+
+	reports := make(map[uint][]Employee)
+	var roots []Employee
+	for _, emp := range employees {
+		line := emp.reportingLine()
+		if len(line) == 0 || !active[line[0]] {
+			roots = append(roots, emp)
+			continue
+		}
+		reports[line[0]] = append(reports[line[0]], emp)
+	}
+
+	placed := make(map[uint]bool, len(employees))
+	var build func(emp Employee) OrgChartNode
+	build = func(emp Employee) OrgChartNode {
+		placed[emp.ID] = true
+
+		node := OrgChartNode{
+			EmployeeID:        emp.ID,
+			FullName:          emp.FullName,
+			Position:          emp.Position,
+			DepartmentID:      emp.DepartmentID,
+			ProfilePictureUrl: emp.ProfilePictureUrl,
+			Reports:           []OrgChartNode{},
+		}
+		if line := emp.reportingLine(); len(line) > 0 && active[line[0]] {
+			node.ManagerID = &line[0]
+		}
+		if emp.Department != nil {
+			node.DepartmentName = emp.Department.Name
+			node.IsDepartmentHead = emp.Department.HeadEmployeeID != nil && *emp.Department.HeadEmployeeID == emp.ID
+		}
+
+		for _, report := range reports[emp.ID] {
+			if !placed[report.ID] {
+				node.Reports = append(node.Reports, build(report))
+			}
+		}
+		return node
+	}
+
+	chart := []OrgChartNode{}
+	for _, root := range roots {
+		chart = append(chart, build(root))
+	}
+
+	// employees caught in a reporting cycle have no root, list them at the top instead of dropping them
+	for _, emp := range employees {
+		if !placed[emp.ID] {
+			chart = append(chart, build(emp))
+		}
+	}
+
+	return chart
+}
@@ -9,6 +9,7 @@ import (
 	"basekarya-backend/internal/modules/master"
 	"basekarya-backend/internal/modules/rbac"
 	"basekarya-backend/internal/testutil"
+	"basekarya-backend/pkg/constants"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
@@ -114,7 +115,7 @@ func TestService_GetProfile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, cache, _, _, _, _ := newTestUserService()
+			svc, repo, _, _, cache, _, _, _, _, _ := newTestUserService()
 			tt.setupMocks(repo, cache)
 
 			resp, err := svc.GetProfile(tt.userID)
@@ -217,7 +218,7 @@ func TestService_UpdateProfile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, storage, cache, _, _, _, _ := newTestUserService()
+			svc, repo, _, storage, cache, _, _, _, _, _ := newTestUserService()
 			tt.setupMocks(repo, storage, cache)
 
 			err := svc.UpdateProfile(ctx, tt.userID, tt.req, nil)
@@ -234,6 +235,12 @@ func TestService_UpdateProfile(t *testing.T) {
 
 func TestService_ChangePassword(t *testing.T) {
 	ctx := testutil.CtxWithTenant(1, 1, false)
+	req := func(newPassword string) *ChangePasswordRequest {
+		return &ChangePasswordRequest{OldPassword: "oldpass", NewPassword: newPassword, ConfirmPassword: newPassword}
+	}
+	foundUser := func(repo *mockRepo) {
+		repo.On("FindByID", mock.Anything, uint(1)).Return(&User{ID: 1, CompanyID: 1, PasswordHash: "oldhash"}, nil)
+	}
 
 	tests := []struct {
 		name       string
@@ -246,19 +253,17 @@ func TestService_ChangePassword(t *testing.T) {
 		{
 			name:   "success",
 			userID: 1,
-			req: &ChangePasswordRequest{
-				OldPassword:     "oldpass",
-				NewPassword:     "newpass",
-				ConfirmPassword: "newpass",
-			},
+			req:    req("karya2026new"),
 			setupMocks: func(repo *mockRepo, hasher *mockHasher, cache *mockCache) {
-				repo.On("FindByID", mock.Anything, uint(1)).Return(&User{
-					ID:           1,
-					PasswordHash: "oldhash",
-				}, nil)
+				foundUser(repo)
 				hasher.On("CheckPasswordHash", "oldpass", "oldhash").Return(true)
-				hasher.On("HashPassword", "newpass").Return("newhash", nil)
-				repo.On("UpdateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
+				repo.On("FindPasswordHistory", mock.Anything, uint(1), 4).Return([]string{"hash-1"}, nil)
+				hasher.On("CheckPasswordHash", "karya2026new", mock.Anything).Return(false)
+				hasher.On("HashPassword", "karya2026new").Return("newhash", nil)
+				repo.On("CreatePasswordHistory", mock.Anything, &PasswordHistory{UserID: 1, CompanyID: 1, PasswordHash: "oldhash"}).Return(nil)
+				repo.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *User) bool {
+					return u.PasswordHash == "newhash" && !u.MustChangePassword
+				})).Return(nil)
 				cache.On("Del", mock.Anything, "user:1").Return(nil)
 			},
 			wantErr: false,
@@ -266,9 +271,7 @@ func TestService_ChangePassword(t *testing.T) {
 		{
 			name:   "error user not found",
 			userID: 99,
-			req: &ChangePasswordRequest{
-				OldPassword: "oldpass", NewPassword: "newpass", ConfirmPassword: "newpass",
-			},
+			req:    req("karya2026new"),
 			setupMocks: func(repo *mockRepo, hasher *mockHasher, cache *mockCache) {
 				repo.On("FindByID", mock.Anything, uint(99)).Return(nil, errors.New("not found"))
 			},
@@ -278,32 +281,50 @@ func TestService_ChangePassword(t *testing.T) {
 		{
 			name:   "error invalid old password",
 			userID: 1,
-			req: &ChangePasswordRequest{
-				OldPassword: "wrongpass", NewPassword: "newpass", ConfirmPassword: "newpass",
-			},
+			req:    &ChangePasswordRequest{OldPassword: "wrongpass", NewPassword: "karya2026new", ConfirmPassword: "karya2026new"},
 			setupMocks: func(repo *mockRepo, hasher *mockHasher, cache *mockCache) {
-				repo.On("FindByID", mock.Anything, uint(1)).Return(&User{
-					ID:           1,
-					PasswordHash: "oldhash",
-				}, nil)
+				foundUser(repo)
 				hasher.On("CheckPasswordHash", "wrongpass", "oldhash").Return(false)
 			},
 			wantErr: true,
 			errMsg:  "invalid old password",
 		},
 		{
-			name:   "error hash password fails",
+			name:   "error breached password",
 			userID: 1,
-			req: &ChangePasswordRequest{
-				OldPassword: "oldpass", NewPassword: "newpass", ConfirmPassword: "newpass",
+			req:    req("password123"),
+			setupMocks: func(repo *mockRepo, hasher *mockHasher, cache *mockCache) {
+				foundUser(repo)
+				hasher.On("CheckPasswordHash", "oldpass", "oldhash").Return(true)
 			},
+			wantErr: true,
+			errMsg:  "password is too common, choose another one",
+		},
+		{
+			name:   "error recently used password",
+			userID: 1,
+			req:    req("karya2025old"),
 			setupMocks: func(repo *mockRepo, hasher *mockHasher, cache *mockCache) {
-				repo.On("FindByID", mock.Anything, uint(1)).Return(&User{
-					ID:           1,
-					PasswordHash: "oldhash",
-				}, nil)
+				foundUser(repo)
 				hasher.On("CheckPasswordHash", "oldpass", "oldhash").Return(true)
-				hasher.On("HashPassword", "newpass").Return("", errors.New("hash error"))
+				repo.On("FindPasswordHistory", mock.Anything, uint(1), 4).Return([]string{"hash-1", "hash-2"}, nil)
+				hasher.On("CheckPasswordHash", "karya2025old", "oldhash").Return(false)
+				hasher.On("CheckPasswordHash", "karya2025old", "hash-1").Return(false)
+				hasher.On("CheckPasswordHash", "karya2025old", "hash-2").Return(true)
+			},
+			wantErr: true,
+			errMsg:  "password must differ from your last 5 passwords",
+		},
+		{
+			name:   "error hash password fails",
+			userID: 1,
+			req:    req("karya2026new"),
+			setupMocks: func(repo *mockRepo, hasher *mockHasher, cache *mockCache) {
+				foundUser(repo)
+				hasher.On("CheckPasswordHash", "oldpass", "oldhash").Return(true)
+				repo.On("FindPasswordHistory", mock.Anything, uint(1), 4).Return([]string{}, nil)
+				hasher.On("CheckPasswordHash", "karya2026new", "oldhash").Return(false)
+				hasher.On("HashPassword", "karya2026new").Return("", errors.New("hash error"))
 			},
 			wantErr: true,
 			errMsg:  "hash error",
@@ -311,16 +332,14 @@ func TestService_ChangePassword(t *testing.T) {
 		{
 			name:   "error update user fails",
 			userID: 1,
-			req: &ChangePasswordRequest{
-				OldPassword: "oldpass", NewPassword: "newpass", ConfirmPassword: "newpass",
-			},
+			req:    req("karya2026new"),
 			setupMocks: func(repo *mockRepo, hasher *mockHasher, cache *mockCache) {
-				repo.On("FindByID", mock.Anything, uint(1)).Return(&User{
-					ID:           1,
-					PasswordHash: "oldhash",
-				}, nil)
+				foundUser(repo)
 				hasher.On("CheckPasswordHash", "oldpass", "oldhash").Return(true)
-				hasher.On("HashPassword", "newpass").Return("newhash", nil)
+				repo.On("FindPasswordHistory", mock.Anything, uint(1), 4).Return([]string{}, nil)
+				hasher.On("CheckPasswordHash", "karya2026new", "oldhash").Return(false)
+				hasher.On("HashPassword", "karya2026new").Return("newhash", nil)
+				repo.On("CreatePasswordHistory", mock.Anything, mock.Anything).Return(nil)
 				repo.On("UpdateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(errors.New("db error"))
 			},
 			wantErr: true,
@@ -330,21 +349,46 @@ func TestService_ChangePassword(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, hasher, _, cache, _, _, _, _ := newTestUserService()
+			svc, repo, hasher, _, cache, _, _, _, _, sessions := newTestUserService()
 			tt.setupMocks(repo, hasher, cache)
+			sessions.On("RevokeUser", mock.Anything, tt.userID).Return(nil).Maybe()
 
 			err := svc.ChangePassword(ctx, tt.userID, tt.req)
 
 			if tt.wantErr {
 				require.Error(t, err)
 				assert.Equal(t, tt.errMsg, err.Error())
+				sessions.AssertNotCalled(t, "RevokeUser", mock.Anything, mock.Anything)
 			} else {
 				require.NoError(t, err)
+				sessions.AssertCalled(t, "RevokeUser", mock.Anything, tt.userID)
 			}
 		})
 	}
 }
 
+func TestService_UpdatePassword(t *testing.T) {
+	ctx := testutil.CtxWithTenant(0, 0, false)
+
+	svc, repo, hasher, _, cache, _, _, _, _, sessions := newTestUserService()
+	repo.On("FindByID", mock.Anything, uint(5)).Return(&User{ID: 5, CompanyID: 2, PasswordHash: "oldhash", MustChangePassword: true}, nil)
+	repo.On("FindPasswordHistory", mock.Anything, uint(5), 4).Return([]string{}, nil)
+	hasher.On("CheckPasswordHash", "karya2026new", "oldhash").Return(false)
+	hasher.On("HashPassword", "karya2026new").Return("newhash", nil)
+	repo.On("CreatePasswordHistory", mock.Anything, &PasswordHistory{UserID: 5, CompanyID: 2, PasswordHash: "oldhash"}).Return(nil)
+	repo.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *User) bool {
+		return u.PasswordHash == "newhash" && !u.MustChangePassword
+	})).Return(nil)
+	cache.On("Del", mock.Anything, "user:5").Return(nil)
+	sessions.On("RevokeUser", mock.Anything, uint(5)).Return(nil)
+
+	err := svc.UpdatePassword(ctx, 5, "karya2026new")
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+	sessions.AssertExpectations(t)
+}
+
 func TestService_GetAllEmployees(t *testing.T) {
 	ctx := testutil.CtxWithTenant(1, 1, false)
 
@@ -408,10 +452,10 @@ func TestService_GetAllEmployees(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, _, _, _ := newTestUserService()
+			svc, repo, _, _, _, _, _, _, _, _ := newTestUserService()
 			tt.setupMocks(repo)
 
-			list, meta, err := svc.GetAllEmployees(ctx, tt.page, tt.limit, tt.search)
+			list, meta, err := svc.GetAllEmployees(ctx, tt.page, tt.limit, tt.search, true)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -426,6 +470,35 @@ func TestService_GetAllEmployees(t *testing.T) {
 	}
 }
 
+func TestService_GetAllEmployees_MasksSensitiveFields(t *testing.T) {
+	ctx := testutil.CtxWithTenant(1, 1, false)
+	users := []User{
+		{
+			ID:   1,
+			Role: &rbac.Role{ID: 1, Name: "EMPLOYEE"},
+			Employee: &Employee{
+				ID: 1, FullName: "John Doe", NIK: "3201123456780001", BaseSalary: 5000000,
+			},
+		},
+	}
+
+	svc, repo, _, _, _, _, _, _, _, _ := newTestUserService()
+	repo.On("FindAllEmployees", mock.Anything, 1, 10, "").Return(users, int64(1), nil)
+
+	masked, _, err := svc.GetAllEmployees(ctx, 1, 10, "", false)
+	require.NoError(t, err)
+	require.Len(t, masked, 1)
+	assert.Equal(t, "************0001", masked[0].NIK)
+	assert.Nil(t, masked[0].BaseSalary)
+
+	full, _, err := svc.GetAllEmployees(ctx, 1, 10, "", true)
+	require.NoError(t, err)
+	require.Len(t, full, 1)
+	assert.Equal(t, "3201123456780001", full[0].NIK)
+	require.NotNil(t, full[0].BaseSalary)
+	assert.Equal(t, 5000000.0, *full[0].BaseSalary)
+}
+
 func TestService_CreateEmployee(t *testing.T) {
 	ctx := testutil.CtxWithTenant(1, 1, false)
 
@@ -454,6 +527,7 @@ func TestService_CreateEmployee(t *testing.T) {
 				repo.On("FindRoleByID", mock.Anything, uint(1)).Return(&rbac.Role{ID: 1, Name: "EMPLOYEE"}, nil)
 				repo.On("CreateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
 				repo.On("CreateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
 				leaveGen.On("GenerateInitialBalance", mock.Anything, mock.Anything).Return(nil)
 				email.On("Send", "jane@example.com", "Basekarya - Akun Karyawan Baru", mock.AnythingOfType("string")).Return(nil)
 			},
@@ -477,10 +551,53 @@ func TestService_CreateEmployee(t *testing.T) {
 				repo.On("FindRoleByID", mock.Anything, uint(1)).Return(&rbac.Role{ID: 1, Name: "EMPLOYEE"}, nil)
 				repo.On("CreateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
 				repo.On("CreateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
 				leaveGen.On("GenerateInitialBalance", mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "success on probation",
+			req: &CreateEmployeeRequest{
+				FullName: "Jane Doe", NIK: "EMP002",
+				DepartmentID: 1, ShiftID: 1, RoleID: 1,
+				BaseSalary: 5000000, Position: "Designer",
+				JoinDate: "2026-01-05", ProbationEndDate: "2099-04-05",
+			},
+			setupMocks: func(repo *mockRepo, hasher *mockHasher, leaveGen *mockLeaveGen, sub *mockSubscription, email *mockEmail) {
+				sub.On("CheckEmployeeLimit", mock.Anything).Return(true, nil)
+				hasher.On("HashPassword", mock.AnythingOfType("string")).Return("hashedpass", nil)
+				repo.On("FindRoleByID", mock.Anything, uint(1)).Return(&rbac.Role{ID: 1, Name: "EMPLOYEE"}, nil)
+				repo.On("CreateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
+				repo.On("CreateEmployee", mock.Anything, mock.MatchedBy(func(e *Employee) bool {
+					return e.EmploymentStatus == constants.EmploymentStatusProbation
+				})).Return(nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.Type == constants.EmploymentEventHire && e.AppliedAt != nil &&
+						e.EndDate != nil && e.EndDate.Format(constants.DefaultTimeFormat) == "2099-04-05"
+				})).Return(nil).Once()
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.Type == constants.EmploymentEventProbationPass && e.AppliedAt == nil &&
+						e.EffectiveDate.Format(constants.DefaultTimeFormat) == "2099-04-05"
+				})).Return(nil).Once()
+				leaveGen.On("GenerateInitialBalance", mock.Anything, mock.Anything).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "error probation ends before join date",
+			req: &CreateEmployeeRequest{
+				FullName: "Jane Doe", NIK: "EMP002",
+				DepartmentID: 1, ShiftID: 1, RoleID: 1,
+				BaseSalary: 5000000, Email: "jane@example.com", Position: "Designer",
+				JoinDate: "2026-01-05", ProbationEndDate: "2026-01-05",
+			},
+			setupMocks: func(repo *mockRepo, hasher *mockHasher, leaveGen *mockLeaveGen, sub *mockSubscription, email *mockEmail) {
+				sub.On("CheckEmployeeLimit", mock.Anything).Return(true, nil)
+			},
+			wantErr: true,
+			errMsg:  "probation end date must be after the join date",
+		},
 		{
 			name: "error subscription limit reached",
 			req: &CreateEmployeeRequest{
@@ -568,6 +685,7 @@ func TestService_CreateEmployee(t *testing.T) {
 				repo.On("FindRoleByID", mock.Anything, uint(1)).Return(&rbac.Role{ID: 1}, nil)
 				repo.On("CreateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
 				repo.On("CreateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
 				leaveGen.On("GenerateInitialBalance", mock.Anything, mock.Anything).Return(errors.New("leave error"))
 			},
 			wantErr: true,
@@ -577,7 +695,7 @@ func TestService_CreateEmployee(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, hasher, _, _, leaveGen, _, sub, email := newTestUserService()
+			svc, repo, hasher, _, _, leaveGen, _, sub, email, _ := newTestUserService()
 			tt.setupMocks(repo, hasher, leaveGen, sub, email)
 
 			resp, err := svc.CreateEmployee(ctx, tt.req)
@@ -610,18 +728,37 @@ func TestService_UpdateEmployee(t *testing.T) {
 			name: "success",
 			id:   1,
 			req: &UpdateEmployeeRequest{
-				FullName: "John Updated",
-				Position: "Senior Developer",
+				FullName:     "John Updated",
+				Position:     "Developer",
+				DepartmentID: 2,
+				BaseSalary:   5000000,
 			},
 			setupMocks: func(repo *mockRepo, cache *mockCache) {
 				repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&Employee{
-					ID: 1, FullName: "John Doe", Position: "Developer", UserID: 10,
+					ID: 1, FullName: "John Doe", Position: "Developer", DepartmentID: 2, BaseSalary: 5000000, UserID: 10,
 				}, nil)
-				repo.On("UpdateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
+				repo.On("UpdateEmployee", mock.Anything, mock.MatchedBy(func(e *Employee) bool {
+					return e.FullName == "John Updated"
+				})).Return(nil)
 				cache.On("Del", mock.Anything, "user:10").Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "error job change outside the employment history",
+			id:   1,
+			req: &UpdateEmployeeRequest{
+				FullName:   "John Updated",
+				BaseSalary: 9000000,
+			},
+			setupMocks: func(repo *mockRepo, cache *mockCache) {
+				repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&Employee{
+					ID: 1, FullName: "John Doe", Position: "Developer", BaseSalary: 5000000, UserID: 10,
+				}, nil)
+			},
+			wantErr: true,
+			errMsg:  "department, position, grade and salary are changed by recording a promotion, transfer or salary change in the employment history",
+		},
 		{
 			name: "success with role update",
 			id:   1,
@@ -635,11 +772,75 @@ func TestService_UpdateEmployee(t *testing.T) {
 					User: User{ID: 10, RoleID: 1},
 				}, nil)
 				repo.On("UpdateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(nil)
-				repo.On("UpdateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
+				repo.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *User) bool {
+					return u.RoleID == 2 && u.PermissionVersion == 1
+				})).Return(nil)
+				cache.On("Del", mock.Anything, "user:permission_version:10").Return(nil)
 				cache.On("Del", mock.Anything, "user:10").Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "success assigns manager",
+			id:   1,
+			req:  &UpdateEmployeeRequest{ManagerID: uintPtr(2)},
+			setupMocks: func(repo *mockRepo, cache *mockCache) {
+				repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&Employee{ID: 1, UserID: 10}, nil)
+				repo.On("FindEmployeeByID", mock.Anything, uint(2)).Return(&Employee{ID: 2, ManagerID: uintPtr(3)}, nil)
+				repo.On("FindEmployeeByID", mock.Anything, uint(3)).Return(&Employee{ID: 3}, nil)
+				repo.On("UpdateEmployee", mock.Anything, mock.MatchedBy(func(emp *Employee) bool {
+					return emp.ManagerID != nil && *emp.ManagerID == 2
+				})).Return(nil)
+				cache.On("Del", mock.Anything, "user:10").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "success zero removes manager",
+			id:   1,
+			req:  &UpdateEmployeeRequest{ManagerID: uintPtr(0)},
+			setupMocks: func(repo *mockRepo, cache *mockCache) {
+				repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&Employee{ID: 1, UserID: 10, ManagerID: uintPtr(2)}, nil)
+				repo.On("UpdateEmployee", mock.Anything, mock.MatchedBy(func(emp *Employee) bool {
+					return emp.ManagerID == nil
+				})).Return(nil)
+				cache.On("Del", mock.Anything, "user:10").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "error employee managing themselves",
+			id:   1,
+			req:  &UpdateEmployeeRequest{ManagerID: uintPtr(1)},
+			setupMocks: func(repo *mockRepo, cache *mockCache) {
+				repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&Employee{ID: 1, UserID: 10}, nil)
+			},
+			wantErr: true,
+			errMsg:  "employee cannot be their own manager",
+		},
+		{
+			name: "error circular reporting line",
+			id:   1,
+			req:  &UpdateEmployeeRequest{ManagerID: uintPtr(2)},
+			setupMocks: func(repo *mockRepo, cache *mockCache) {
+				repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&Employee{ID: 1, UserID: 10}, nil)
+				repo.On("FindEmployeeByID", mock.Anything, uint(2)).Return(&Employee{ID: 2, ManagerID: uintPtr(3)}, nil)
+				repo.On("FindEmployeeByID", mock.Anything, uint(3)).Return(&Employee{ID: 3, ManagerID: uintPtr(1)}, nil)
+			},
+			wantErr: true,
+			errMsg:  "reporting line cannot be circular",
+		},
+		{
+			name: "error manager not found",
+			id:   1,
+			req:  &UpdateEmployeeRequest{ManagerID: uintPtr(50)},
+			setupMocks: func(repo *mockRepo, cache *mockCache) {
+				repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&Employee{ID: 1, UserID: 10}, nil)
+				repo.On("FindEmployeeByID", mock.Anything, uint(50)).Return(nil, errors.New("record not found"))
+			},
+			wantErr: true,
+			errMsg:  "manager not found",
+		},
 		{
 			name: "error employee not found",
 			id:   99,
@@ -667,7 +868,7 @@ func TestService_UpdateEmployee(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, cache, _, _, _, _ := newTestUserService()
+			svc, repo, _, _, cache, _, _, _, _, sessions := newTestUserService()
 			tt.setupMocks(repo, cache)
 
 			err := svc.UpdateEmployee(ctx, tt.id, tt.req)
@@ -677,7 +878,10 @@ func TestService_UpdateEmployee(t *testing.T) {
 				assert.Equal(t, tt.errMsg, err.Error())
 			} else {
 				require.NoError(t, err)
+				cache.AssertExpectations(t)
 			}
+			// a role change no longer signs the user out
+			sessions.AssertNotCalled(t, "RevokeUser", mock.Anything, mock.Anything)
 		})
 	}
 }
@@ -693,13 +897,24 @@ func TestService_DeleteEmployee(t *testing.T) {
 		errMsg     string
 	}{
 		{
-			name: "success",
+			name: "success terminates instead of deleting",
 			id:   1,
 			setupMocks: func(repo *mockRepo) {
 				repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&Employee{
-					ID: 1, UserID: 1,
+					ID: 1, UserID: 1, EmploymentStatus: constants.EmploymentStatusActive,
+					User: User{ID: 1, IsActive: true},
 				}, nil)
-				repo.On("DeleteUser", mock.Anything, uint(1)).Return(nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.Type == constants.EmploymentEventTermination
+				})).Return(nil)
+				repo.On("UpdateEmployee", mock.Anything, mock.MatchedBy(func(e *Employee) bool {
+					return e.EmploymentStatus == constants.EmploymentStatusTerminated && e.TerminationDate != nil
+				})).Return(nil)
+				repo.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *User) bool { return !u.IsActive })).Return(nil)
+				repo.On("UpdateEmploymentEvent", mock.Anything, mock.MatchedBy(func(e *EmploymentEvent) bool {
+					return e.AppliedAt != nil
+				})).Return(nil)
+				repo.On("VoidPendingEmploymentEvents", mock.Anything, uint(1)).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -713,13 +928,25 @@ func TestService_DeleteEmployee(t *testing.T) {
 			errMsg:  "employee not found",
 		},
 		{
-			name: "error delete user fails",
+			name: "error already terminated",
 			id:   1,
 			setupMocks: func(repo *mockRepo) {
 				repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&Employee{
-					ID: 1, UserID: 1,
+					ID: 1, UserID: 1, EmploymentStatus: constants.EmploymentStatusTerminated,
 				}, nil)
-				repo.On("DeleteUser", mock.Anything, uint(1)).Return(errors.New("db error"))
+			},
+			wantErr: true,
+			errMsg:  "employee is terminated, rehire them first",
+		},
+		{
+			name: "error update employee fails",
+			id:   1,
+			setupMocks: func(repo *mockRepo) {
+				repo.On("FindEmployeeByID", mock.Anything, uint(1)).Return(&Employee{
+					ID: 1, UserID: 1, EmploymentStatus: constants.EmploymentStatusActive,
+				}, nil)
+				repo.On("CreateEmploymentEvent", mock.Anything, mock.AnythingOfType("*user.EmploymentEvent")).Return(nil)
+				repo.On("UpdateEmployee", mock.Anything, mock.AnythingOfType("*user.Employee")).Return(errors.New("db error"))
 			},
 			wantErr: true,
 			errMsg:  "db error",
@@ -728,17 +955,72 @@ func TestService_DeleteEmployee(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc, repo, _, _, _, _, _, _, _ := newTestUserService()
+			svc, repo, _, _, cache, _, _, _, _, sessions := newTestUserService()
 			tt.setupMocks(repo)
+			cache.On("Del", mock.Anything, mock.Anything).Return(nil).Maybe()
+			sessions.On("RevokeUser", mock.Anything, uint(1)).Return(nil).Maybe()
 
 			err := svc.DeleteEmployee(ctx, tt.id)
 
 			if tt.wantErr {
 				require.Error(t, err)
 				assert.Equal(t, tt.errMsg, err.Error())
+				sessions.AssertNotCalled(t, "RevokeUser", mock.Anything, mock.Anything)
 			} else {
 				require.NoError(t, err)
+				repo.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+				sessions.AssertCalled(t, "RevokeUser", mock.Anything, uint(1))
 			}
 		})
 	}
 }
+
+func TestBuildOrgChart(t *testing.T) {
+	engineering := &department.Department{ID: 1, Name: "Engineering", HeadEmployeeID: uintPtr(1)}
+
+	employees := []Employee{
+		{ID: 3, FullName: "Citra", DepartmentID: 1, Department: engineering, ManagerID: uintPtr(2)},
+		{ID: 1, FullName: "Andi", DepartmentID: 1, Department: engineering},
+		{ID: 4, FullName: "Dewi", DepartmentID: 1, Department: engineering},
+		{ID: 2, FullName: "Budi", DepartmentID: 1, Department: engineering, ManagerID: uintPtr(1)},
+		// manager left the company
+		{ID: 5, FullName: "Eka", ManagerID: uintPtr(99)},
+	}
+
+	chart := buildOrgChart(employees)
+
+	require.Len(t, chart, 2)
+	assert.Equal(t, "Andi", chart[0].FullName)
+	assert.True(t, chart[0].IsDepartmentHead)
+	assert.Nil(t, chart[0].ManagerID)
+	assert.Equal(t, "Eka", chart[1].FullName)
+	assert.Nil(t, chart[1].ManagerID)
+
+	// Dewi has no manager and reports to the head of her department
+	require.Len(t, chart[0].Reports, 2)
+	assert.Equal(t, "Budi", chart[0].Reports[0].FullName)
+	assert.Equal(t, "Dewi", chart[0].Reports[1].FullName)
+	assert.Equal(t, uint(1), *chart[0].Reports[1].ManagerID)
+
+	require.Len(t, chart[0].Reports[0].Reports, 1)
+	assert.Equal(t, "Citra", chart[0].Reports[0].Reports[0].FullName)
+	assert.Empty(t, chart[0].Reports[0].Reports[0].Reports)
+}
+
+func TestBuildOrgChart_Cycle(t *testing.T) {
+	employees := []Employee{
+		{ID: 1, FullName: "Andi", ManagerID: uintPtr(2)},
+		{ID: 2, FullName: "Budi", ManagerID: uintPtr(1)},
+	}
+
+	chart := buildOrgChart(employees)
+
+	require.Len(t, chart, 1)
+	assert.Equal(t, "Andi", chart[0].FullName)
+	require.Len(t, chart[0].Reports, 1)
+	assert.Equal(t, "Budi", chart[0].Reports[0].FullName)
+}
+
+func uintPtr(v uint) *uint {
+	return &v
+}
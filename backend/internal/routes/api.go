@@ -34,6 +34,7 @@ func (r *Router) setupMiddleware() {
 		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
 	}))
 	r.app.Use(middleware.RequestID())
+	r.app.Use(customMiddleware.RequestContext())
 	r.app.Validator = utils.NewValidator()
 }
 
@@ -43,6 +44,7 @@ func (r *Router) setupRoutes() {
 
 	api := r.app.Group("/api/v1")
 	r.SetupAuthRoutes(api.Group("/auth"))
+	r.SetupSSORoutes(api.Group("/auth/sso"))
 	api.GET("/subscriptions/plans", r.container.SubscriptionHandler.ListPlans)
 
 	// protected global
@@ -74,8 +76,12 @@ func (r *Router) setupRoutes() {
 	r.SetupTaxRoutes(protected.Group("/admin/tax"))
 	r.SetupBpjsRoutes(protected.Group("/admin/bpjs"))
 	r.SetupAssetRoutes(protected.Group("/assets"), r.container.SubscriptionMiddleware)
+	r.SetupApprovalRoutes(protected.Group("/approvals"))
+	r.SetupInboxRoutes(protected.Group("/approvals/inbox"))
+	r.SetupOffboardingRoutes(protected.Group("/offboarding"), r.container.SubscriptionMiddleware)
 	r.SetupSubscriptionRoutes(protected.Group("/subscriptions"))
 	r.SetupSubscriptionAdminRoutes(protected.Group("/admin/subscriptions"))
+	r.SetupAuditRoutes(protected.Group("/audit-logs"))
 }
 
 func ServeHTTP(container *bootstrap.Container) *echo.Echo {
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"basekarya-backend/pkg/constants"
+
+	"github.com/labstack/echo/v4"
+)
+
+// approverPermissions lets anyone who approves requests see approval progress and delegate their approvals.
+var approverPermissions = []string{
+	constants.APPROVAL_LEAVE,
+	constants.APPROVAL_LOAN,
+	constants.APPROVAL_OVERTIME,
+	constants.APPROVAL_REIMBURSEMENT,
+	constants.APPROVAL_FINANCE,
+	constants.APPROVAL_ASSET,
+	constants.MANAGE_APPROVAL_CHAIN,
+}
+
+func (r *Router) SetupApprovalRoutes(e *echo.Group) {
+	e.GET("/chains", r.container.ApprovalHandler.GetChains, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_APPROVAL_CHAIN))
+	e.PUT("/chains/:type", r.container.ApprovalHandler.SaveChain, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_APPROVAL_CHAIN))
+	e.GET("/delegations", r.container.ApprovalHandler.GetDelegations, r.container.AuthMiddleware.GrantAnyPermission(approverPermissions...))
+	e.POST("/delegations", r.container.ApprovalHandler.CreateDelegation, r.container.AuthMiddleware.GrantAnyPermission(approverPermissions...))
+	e.DELETE("/delegations/:id", r.container.ApprovalHandler.RevokeDelegation, r.container.AuthMiddleware.GrantAnyPermission(approverPermissions...))
+	e.GET("/:type/:referenceId", r.container.ApprovalHandler.GetApproval, r.container.AuthMiddleware.GrantAnyPermission(approverPermissions...))
+}
@@ -13,4 +13,9 @@ func (r *Router) SetupAttendanceRoutes(e *echo.Group) {
 	e.GET("/recap", r.container.AttendanceHandler.GetAllAttendanceRecap, r.container.AuthMiddleware.GrantPermission(constants.VIEW_ATTENDANCE))
 	e.GET("/export", r.container.AttendanceHandler.ExportAttendance, r.container.AuthMiddleware.GrantPermission(constants.EXPORT_ATTENDANCE))
 	e.GET("/dashboard/stats", r.container.AttendanceHandler.GetDashboardStats, r.container.AuthMiddleware.GrantPermission(constants.VIEW_ATTENDANCE))
+	e.GET("/timesheet", r.container.AttendanceHandler.GetMonthlyTimesheet, r.container.AuthMiddleware.GrantPermission(constants.VIEW_ATTENDANCE))
+	e.GET("/timesheet/export", r.container.AttendanceHandler.ExportTimesheet, r.container.AuthMiddleware.GrantPermission(constants.EXPORT_ATTENDANCE))
+	e.GET("/timesheet/employees/:id/pdf", r.container.AttendanceHandler.DownloadTimesheetPDF, r.container.AuthMiddleware.GrantPermission(constants.EXPORT_ATTENDANCE))
+	e.GET("/anomalies", r.container.AttendanceHandler.GetAnomalyQueue, r.container.AuthMiddleware.GrantPermission(constants.REVIEW_ATTENDANCE))
+	e.PUT("/anomalies/:id/review", r.container.AttendanceHandler.ReviewAnomaly, r.container.AuthMiddleware.GrantPermission(constants.REVIEW_ATTENDANCE))
 }
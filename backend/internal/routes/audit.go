@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"basekarya-backend/pkg/constants"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (r *Router) SetupAuditRoutes(e *echo.Group) {
+	e.GET("", r.container.AuditHandler.GetAll, r.container.AuthMiddleware.GrantPermission(constants.VIEW_AUDIT_LOG))
+	e.GET("/export", r.container.AuditHandler.Export, r.container.AuthMiddleware.GrantPermission(constants.EXPORT_AUDIT_LOG))
+}
@@ -9,4 +9,24 @@ func (r *Router) SetupAuthRoutes(e *echo.Group) {
 	e.POST("/resend-otp", r.container.AuthHandler.ResendOTP, r.container.RateLimiterMiddleware.Init())
 	e.POST("/verify-otp", r.container.AuthHandler.VerifyOTP, r.container.RateLimiterMiddleware.Init())
 	e.POST("/reset-password", r.container.AuthHandler.ResetPassword, r.container.RateLimiterMiddleware.Init())
+	e.POST("/refresh", r.container.AuthHandler.Refresh, r.container.RateLimiterMiddleware.Init())
+
+	// second login step, the mfa token from login stands in for the password
+	e.POST("/mfa/verify", r.container.AuthHandler.VerifyMFA, r.container.RateLimiterMiddleware.Init())
+	e.POST("/mfa/enrol", r.container.AuthHandler.StartMFAEnrolment, r.container.RateLimiterMiddleware.Init())
+	e.POST("/mfa/enrol/confirm", r.container.AuthHandler.CompleteMFAEnrolment, r.container.RateLimiterMiddleware.Init())
+
+	// sessions of the signed in user
+	e.POST("/logout", r.container.AuthHandler.Logout, r.container.AuthMiddleware.VerifyToken)
+	e.POST("/logout-all", r.container.AuthHandler.LogoutAll, r.container.AuthMiddleware.VerifyToken)
+	e.GET("/sessions", r.container.AuthHandler.GetSessions, r.container.AuthMiddleware.VerifyToken)
+	e.DELETE("/sessions/:id", r.container.AuthHandler.RevokeSession, r.container.AuthMiddleware.VerifyToken)
+	e.GET("/permissions", r.container.AuthHandler.GetPermissions, r.container.AuthMiddleware.VerifyToken)
+
+	// authenticator of the signed in user
+	e.GET("/mfa", r.container.AuthHandler.GetMFAStatus, r.container.AuthMiddleware.VerifyToken)
+	e.POST("/mfa/setup", r.container.AuthHandler.SetupMFA, r.container.AuthMiddleware.VerifyToken)
+	e.POST("/mfa/enable", r.container.AuthHandler.EnableMFA, r.container.AuthMiddleware.VerifyToken)
+	e.POST("/mfa/disable", r.container.AuthHandler.DisableMFA, r.container.AuthMiddleware.VerifyToken)
+	e.POST("/mfa/recovery-codes", r.container.AuthHandler.RegenerateRecoveryCodes, r.container.AuthMiddleware.VerifyToken)
 }
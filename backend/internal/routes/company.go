@@ -9,4 +9,14 @@ import (
 func (r *Router) SetupCompanyRoutes(e *echo.Group) {
 	e.GET("/profile", r.container.CompanyHandler.GetProfile, r.container.AuthMiddleware.GrantPermission(constants.VIEW_COMPANY))
 	e.PUT("/profile", r.container.CompanyHandler.UpdateProfile, r.container.AuthMiddleware.GrantPermission(constants.UPDATE_COMPANY))
+
+	// roles holding any of these permissions must sign in with MFA
+	e.GET("/mfa-policy", r.container.CompanyHandler.GetMFAPolicy, r.container.AuthMiddleware.GrantPermission(constants.VIEW_COMPANY))
+	e.PUT("/mfa-policy", r.container.CompanyHandler.UpdateMFAPolicy, r.container.AuthMiddleware.GrantPermission(constants.UPDATE_COMPANY))
+
+	// identity provider the company's users sign in with
+	e.GET("/sso", r.container.SSOHandler.GetProvider, r.container.AuthMiddleware.GrantPermission(constants.VIEW_COMPANY))
+	e.PUT("/sso", r.container.SSOHandler.SaveProvider, r.container.AuthMiddleware.GrantPermission(constants.UPDATE_COMPANY))
+	e.DELETE("/sso", r.container.SSOHandler.DeleteProvider, r.container.AuthMiddleware.GrantPermission(constants.UPDATE_COMPANY))
+	e.POST("/sso/verify-domain", r.container.SSOHandler.VerifyDomain, r.container.AuthMiddleware.GrantPermission(constants.UPDATE_COMPANY))
 }
@@ -8,7 +8,16 @@ import (
 
 func (r *Router) SetupEmployeeRoutes(e *echo.Group) {
 	e.GET("", r.container.UserHandler.GetAllEmployees, r.container.AuthMiddleware.GrantPermission(constants.VIEW_EMPLOYEE))
+	e.GET("/org-chart", r.container.UserHandler.GetOrgChart, r.container.AuthMiddleware.GrantPermission(constants.VIEW_EMPLOYEE))
 	e.POST("", r.container.UserHandler.CreateEmployee, r.container.AuthMiddleware.GrantPermission(constants.CREATE_EMPLOYEE))
+	e.GET("/export", r.container.UserHandler.ExportEmployees, r.container.AuthMiddleware.GrantPermission(constants.EXPORT_EMPLOYEE))
+
+	// bulk onboarding, a file is validated first and then imported as a whole
+	e.GET("/import/template", r.container.UserHandler.DownloadImportTemplate, r.container.AuthMiddleware.GrantPermission(constants.CREATE_EMPLOYEE))
+	e.POST("/import/validate", r.container.UserHandler.ValidateImport, r.container.AuthMiddleware.GrantPermission(constants.CREATE_EMPLOYEE))
+	e.POST("/import", r.container.UserHandler.ImportEmployees, r.container.AuthMiddleware.GrantPermission(constants.CREATE_EMPLOYEE))
 	e.PUT("/:id", r.container.UserHandler.UpdateEmployee, r.container.AuthMiddleware.GrantPermission(constants.UPDATE_EMPLOYEE))
+	e.GET("/:id/events", r.container.UserHandler.GetEmploymentHistory, r.container.AuthMiddleware.GrantPermission(constants.VIEW_EMPLOYEE))
+	e.POST("/:id/events", r.container.UserHandler.RecordEmploymentEvent, r.container.AuthMiddleware.GrantPermission(constants.UPDATE_EMPLOYEE))
 	e.DELETE("/:id", r.container.UserHandler.DeleteEmployee, r.container.AuthMiddleware.GrantPermission(constants.DELETE_EMPLOYEE))
 }
\ No newline at end of file
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// SetupInboxRoutes is open to every user, the inbox only ever lists requests the user was asked to approve.
+func (r *Router) SetupInboxRoutes(e *echo.Group) {
+	e.GET("", r.container.InboxHandler.GetInbox)
+	e.POST("/actions", r.container.InboxHandler.BulkAction)
+}
@@ -11,5 +11,17 @@ func (r *Router) SetupLeaveRoutes(e *echo.Group) {
 	e.GET("/:id", r.container.LeaveHandler.GetDetail, r.container.AuthMiddleware.GrantAnyPermission(constants.VIEW_LEAVE, constants.VIEW_SELF_LEAVE))
 	e.POST("/apply", r.container.LeaveHandler.Apply, r.container.AuthMiddleware.GrantPermission(constants.CREATE_LEAVE))
 	e.PUT("/:id/action", r.container.LeaveHandler.RequestAction, r.container.AuthMiddleware.GrantPermission(constants.APPROVAL_LEAVE))
+	e.PUT("/:id/cancel", r.container.LeaveHandler.Cancel, r.container.AuthMiddleware.GrantAnyPermission(constants.CREATE_LEAVE, constants.APPROVAL_LEAVE))
+	e.PUT("/:id/return", r.container.LeaveHandler.ReturnEarly, r.container.AuthMiddleware.GrantAnyPermission(constants.CREATE_LEAVE, constants.APPROVAL_LEAVE))
 	e.GET("/export", r.container.LeaveHandler.Export, r.container.AuthMiddleware.GrantPermission(constants.EXPORT_LEAVE))
+	e.GET("/ledger", r.container.LeaveHandler.GetLedger, r.container.AuthMiddleware.GrantAnyPermission(constants.VIEW_LEAVE, constants.VIEW_SELF_LEAVE))
+	e.GET("/policies", r.container.LeaveHandler.GetPolicies, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_LEAVE_POLICY))
+	e.PUT("/policies", r.container.LeaveHandler.UpsertPolicy, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_LEAVE_POLICY))
+	e.POST("/policies/accrue", r.container.LeaveHandler.AccrueBalances, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_LEAVE_POLICY))
+	// the view permissions go first so their data scope is the one that narrows the calendar
+	e.GET("/calendar", r.container.LeaveHandler.GetTeamCalendar, r.container.AuthMiddleware.GrantAnyPermission(constants.VIEW_LEAVE, constants.APPROVAL_LEAVE, constants.VIEW_SELF_LEAVE))
+	e.GET("/conflict-rules", r.container.LeaveHandler.GetConflictRules, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_LEAVE_POLICY))
+	e.POST("/conflict-rules", r.container.LeaveHandler.SaveConflictRule, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_LEAVE_POLICY))
+	e.PUT("/conflict-rules/:id", r.container.LeaveHandler.SaveConflictRule, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_LEAVE_POLICY))
+	e.DELETE("/conflict-rules/:id", r.container.LeaveHandler.DeleteConflictRule, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_LEAVE_POLICY))
 }
@@ -12,4 +12,14 @@ func (r *Router) SetupLoanRoutes(e *echo.Group) {
 	e.POST("", r.container.LoanHandler.Create, r.container.AuthMiddleware.GrantPermission(constants.CREATE_LOAN))
 	e.PUT("/:id/action", r.container.LoanHandler.ProcessAction, r.container.AuthMiddleware.GrantPermission(constants.APPROVAL_LOAN))
 	e.GET("/export", r.container.LoanHandler.Export, r.container.AuthMiddleware.GrantPermission(constants.EXPORT_LOAN))
+	e.GET("/products", r.container.LoanHandler.GetProducts, r.container.AuthMiddleware.GrantAnyPermission(constants.VIEW_LOAN, constants.VIEW_SELF_LOAN, constants.CREATE_LOAN, constants.MANAGE_LOAN_PRODUCT))
+	e.POST("/products", r.container.LoanHandler.SaveProduct, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_LOAN_PRODUCT))
+	e.PUT("/products/:id", r.container.LoanHandler.SaveProduct, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_LOAN_PRODUCT))
+	e.POST("/simulate", r.container.LoanHandler.Simulate, r.container.AuthMiddleware.GrantPermission(constants.CREATE_LOAN))
+	e.POST("/:id/payoff", r.container.LoanHandler.EarlyPayoff, r.container.AuthMiddleware.GrantPermission(constants.APPROVAL_LOAN))
+	e.PUT("/:id/installments/:installmentId/defer", r.container.LoanHandler.DeferInstallment, r.container.AuthMiddleware.GrantPermission(constants.APPROVAL_LOAN))
+	e.GET("/reconciliation", r.container.LoanHandler.GetReconciliation, r.container.AuthMiddleware.GrantPermission(constants.VIEW_LOAN))
+	e.GET("/:id/statement", r.container.LoanHandler.GetStatement, r.container.AuthMiddleware.GrantAnyPermission(constants.VIEW_LOAN, constants.VIEW_SELF_LOAN))
+	e.POST("/:id/repayments", r.container.LoanHandler.Repay, r.container.AuthMiddleware.GrantPermission(constants.APPROVAL_LOAN))
+	e.POST("/:id/write-off", r.container.LoanHandler.WriteOff, r.container.AuthMiddleware.GrantPermission(constants.APPROVAL_LOAN))
 }
@@ -9,4 +9,9 @@ import (
 func (r *Router) SetupMasterRoutes(e *echo.Group) {
 	e.GET("/shifts", r.container.MasterHandler.GetShifts, r.container.AuthMiddleware.GrantPermission(constants.VIEW_MASTER))
 	e.GET("/leaves/types", r.container.MasterHandler.GetLeaveTypes, r.container.AuthMiddleware.GrantPermission(constants.VIEW_MASTER))
+	e.GET("/work-locations", r.container.MasterHandler.GetWorkLocations, r.container.AuthMiddleware.GrantPermission(constants.VIEW_MASTER))
+	e.POST("/work-locations", r.container.MasterHandler.CreateWorkLocation, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_MASTER))
+	e.GET("/holidays", r.container.MasterHandler.GetHolidays, r.container.AuthMiddleware.GrantPermission(constants.VIEW_MASTER))
+	e.POST("/holidays", r.container.MasterHandler.CreateHoliday, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_MASTER))
+	e.DELETE("/holidays/:id", r.container.MasterHandler.DeleteHoliday, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_MASTER))
 }
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"basekarya-backend/internal/middleware"
+	"basekarya-backend/pkg/constants"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (r *Router) SetupOffboardingRoutes(e *echo.Group, sub *middleware.SubscriptionMiddleware) {
+	g := e.Group("", sub.RequireModule("payroll"))
+	g.GET("/settlements", r.container.OffboardingHandler.GetAll, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_OFFBOARDING))
+	g.POST("/settlements", r.container.OffboardingHandler.Create, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_OFFBOARDING))
+	g.GET("/settlements/:id", r.container.OffboardingHandler.GetDetail, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_OFFBOARDING))
+	g.POST("/settlements/:id/finalize", r.container.OffboardingHandler.Finalize, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_OFFBOARDING))
+	g.POST("/settlements/:id/cancel", r.container.OffboardingHandler.Cancel, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_OFFBOARDING))
+}
@@ -10,6 +10,17 @@ func (r *Router) SetupReimbursementRoutes(e *echo.Group) {
 	e.GET("", r.container.ReimbursementHandler.GetAll, r.container.AuthMiddleware.GrantAnyPermission(constants.VIEW_REIMBURSEMENT, constants.VIEW_SELF_REIMBURSEMENT))
 	e.GET("/export", r.container.ReimbursementHandler.Export, r.container.AuthMiddleware.GrantPermission(constants.EXPORT_REIMBURSEMENT))
 	e.POST("", r.container.ReimbursementHandler.Create, r.container.AuthMiddleware.GrantPermission(constants.CREATE_REIMBURSEMENT))
+	e.POST("/scan", r.container.ReimbursementHandler.ScanReceipt, r.container.AuthMiddleware.GrantPermission(constants.CREATE_REIMBURSEMENT))
+	e.GET("/entitlements", r.container.ReimbursementHandler.GetEntitlements, r.container.AuthMiddleware.GrantAnyPermission(constants.CREATE_REIMBURSEMENT, constants.VIEW_SELF_REIMBURSEMENT))
+	e.GET("/categories", r.container.ReimbursementHandler.GetCategories, r.container.AuthMiddleware.GrantAnyPermission(constants.CREATE_REIMBURSEMENT, constants.VIEW_REIMBURSEMENT, constants.MANAGE_REIMBURSEMENT_CATEGORY))
+	e.POST("/categories", r.container.ReimbursementHandler.CreateCategory, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_REIMBURSEMENT_CATEGORY))
+	e.PUT("/categories/:id", r.container.ReimbursementHandler.UpdateCategory, r.container.AuthMiddleware.GrantPermission(constants.MANAGE_REIMBURSEMENT_CATEGORY))
+	e.GET("/payout-batches", r.container.ReimbursementHandler.GetPayoutBatches, r.container.AuthMiddleware.GrantPermission(constants.PAYOUT_REIMBURSEMENT))
+	e.POST("/payout-batches", r.container.ReimbursementHandler.CreatePayoutBatch, r.container.AuthMiddleware.GrantPermission(constants.PAYOUT_REIMBURSEMENT))
+	e.GET("/payout-batches/:id", r.container.ReimbursementHandler.GetPayoutBatchDetail, r.container.AuthMiddleware.GrantPermission(constants.PAYOUT_REIMBURSEMENT))
+	e.GET("/payout-batches/:id/export", r.container.ReimbursementHandler.ExportPayoutBatch, r.container.AuthMiddleware.GrantPermission(constants.PAYOUT_REIMBURSEMENT))
+	e.PUT("/payout-batches/:id/paid", r.container.ReimbursementHandler.PayPayoutBatch, r.container.AuthMiddleware.GrantPermission(constants.PAYOUT_REIMBURSEMENT))
+	e.PUT("/payout-batches/:id/cancel", r.container.ReimbursementHandler.CancelPayoutBatch, r.container.AuthMiddleware.GrantPermission(constants.PAYOUT_REIMBURSEMENT))
 	e.GET("/:id", r.container.ReimbursementHandler.GetDetail, r.container.AuthMiddleware.GrantAnyPermission(constants.VIEW_REIMBURSEMENT, constants.VIEW_SELF_REIMBURSEMENT))
 	e.PUT("/:id/action", r.container.ReimbursementHandler.ProcessAction, r.container.AuthMiddleware.GrantPermission(constants.APPROVAL_REIMBURSEMENT))
 }
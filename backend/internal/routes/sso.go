@@ -0,0 +1,13 @@
+package routes
+
+import "github.com/labstack/echo/v4"
+
+func (r *Router) SetupSSORoutes(e *echo.Group) {
+	e.POST("/start", r.container.SSOHandler.Start, r.container.RateLimiterMiddleware.Init())
+	e.POST("/exchange", r.container.SSOHandler.Exchange, r.container.RateLimiterMiddleware.Init())
+
+	// called by the identity provider through the browser
+	e.GET("/oidc/callback", r.container.SSOHandler.OIDCCallback, r.container.RateLimiterMiddleware.Init())
+	e.POST("/saml/acs", r.container.SSOHandler.SAMLACS, r.container.RateLimiterMiddleware.Init())
+	e.GET("/saml/metadata", r.container.SSOHandler.SAMLMetadata)
+}
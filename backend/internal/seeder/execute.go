@@ -155,13 +155,13 @@ func seedPermissions(tx *gorm.DB, roleSuperadmin *rbac.Role) error {
 		{"Permission", []string{constants.VIEW_PERMISSION}},
 		{"Role", []string{constants.CREATE_ROLE, constants.VIEW_ROLE, constants.ASSIGN_ROLE}},
 		{"Master", []string{constants.VIEW_MASTER, constants.MANAGE_MASTER}},
-		{"Employee", []string{constants.VIEW_EMPLOYEE, constants.CREATE_EMPLOYEE, constants.UPDATE_EMPLOYEE, constants.DELETE_EMPLOYEE, constants.EXPORT_EMPLOYEE}},
-		{"Attendance", []string{constants.VIEW_ATTENDANCE, constants.VIEW_SELF_ATTENDANCE, constants.CREATE_ATTENDANCE, constants.EXPORT_ATTENDANCE}},
-		{"Payroll", []string{constants.VIEW_PAYROLL, constants.GENERATE_PAYROLL, constants.DOWNLOAD_PAYSLIP, constants.MARK_AS_PAID, constants.SEND_PAYSLIP}},
-		{"Leave", []string{constants.VIEW_LEAVE, constants.VIEW_SELF_LEAVE, constants.CREATE_LEAVE, constants.APPROVAL_LEAVE, constants.EXPORT_LEAVE}},
-		{"Loan", []string{constants.VIEW_LOAN, constants.VIEW_SELF_LOAN, constants.CREATE_LOAN, constants.APPROVAL_LOAN, constants.EXPORT_LOAN}},
-		{"Overtime", []string{constants.VIEW_OVERTIME, constants.VIEW_SELF_OVERTIME, constants.CREATE_OVERTIME, constants.APPROVAL_OVERTIME, constants.EXPORT_OVERTIME}},
-		{"Reimbursement", []string{constants.VIEW_REIMBURSEMENT, constants.VIEW_SELF_REIMBURSEMENT, constants.CREATE_REIMBURSEMENT, constants.APPROVAL_REIMBURSEMENT, constants.EXPORT_REIMBURSEMENT}},
+		{"Employee", []string{constants.VIEW_EMPLOYEE, constants.CREATE_EMPLOYEE, constants.UPDATE_EMPLOYEE, constants.DELETE_EMPLOYEE, constants.EXPORT_EMPLOYEE, constants.VIEW_SENSITIVE_EMPLOYEE}},
+		{"Attendance", []string{constants.VIEW_ATTENDANCE, constants.VIEW_SELF_ATTENDANCE, constants.CREATE_ATTENDANCE, constants.EXPORT_ATTENDANCE, constants.REVIEW_ATTENDANCE}},
+		{"Payroll", []string{constants.VIEW_PAYROLL, constants.GENERATE_PAYROLL, constants.DOWNLOAD_PAYSLIP, constants.MARK_AS_PAID, constants.SEND_PAYSLIP, constants.MANAGE_OFFBOARDING}},
+		{"Leave", []string{constants.VIEW_LEAVE, constants.VIEW_SELF_LEAVE, constants.CREATE_LEAVE, constants.APPROVAL_LEAVE, constants.EXPORT_LEAVE, constants.MANAGE_LEAVE_POLICY}},
+		{"Loan", []string{constants.VIEW_LOAN, constants.VIEW_SELF_LOAN, constants.CREATE_LOAN, constants.APPROVAL_LOAN, constants.EXPORT_LOAN, constants.MANAGE_LOAN_PRODUCT}},
+		{"Overtime", []string{constants.VIEW_OVERTIME, constants.VIEW_SELF_OVERTIME, constants.CREATE_OVERTIME, constants.APPROVAL_OVERTIME, constants.EXPORT_OVERTIME, constants.ASSIGN_OVERTIME}},
+		{"Reimbursement", []string{constants.VIEW_REIMBURSEMENT, constants.VIEW_SELF_REIMBURSEMENT, constants.CREATE_REIMBURSEMENT, constants.APPROVAL_REIMBURSEMENT, constants.EXPORT_REIMBURSEMENT, constants.MANAGE_REIMBURSEMENT_CATEGORY, constants.PAYOUT_REIMBURSEMENT}},
 		{"Company", []string{constants.VIEW_COMPANY, constants.UPDATE_COMPANY}},
 		{"Announcement", []string{constants.CREATE_ANNOUNCEMENT}},
 		{"Contract", []string{constants.VIEW_CONTRACT, constants.CREATE_CONTRACT, constants.UPDATE_CONTRACT, constants.EXPORT_CONTRACT}},
@@ -171,6 +171,8 @@ func seedPermissions(tx *gorm.DB, roleSuperadmin *rbac.Role) error {
 		{"Asset", []string{constants.MANAGE_ASSET, constants.VIEW_ASSET, constants.VIEW_SELF_ASSET, constants.CREATE_ASSET, constants.APPROVAL_ASSET, constants.EXPORT_ASSET}},
 		{"BPJS", []string{constants.VIEW_BPJS_CONFIG, constants.MANAGE_BPJS_CONFIG}},
 		{"Tax", []string{constants.VIEW_TAX_CONFIG, constants.MANAGE_TAX_CONFIG}},
+		{"Approval", []string{constants.MANAGE_APPROVAL_CHAIN}},
+		{"Audit", []string{constants.VIEW_AUDIT_LOG, constants.EXPORT_AUDIT_LOG}},
 	}
 
 	var permissionIDs []uint
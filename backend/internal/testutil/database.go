@@ -30,6 +30,8 @@ func NewTestDB(models ...interface{}) *TestDB {
 		panic("failed to connect test database: " + err.Error())
 	}
 
+	UseTestKeyring()
+
 	// Register a callback to rewrite enum types in CREATE TABLE SQL
 	db.Callback().Create().Before("gorm:create_table").Register("testutil:enum_rewrite", rewriteEnumCallback)
 
@@ -78,21 +80,24 @@ func CtxWithTenant(companyID uint, userID uint, isPlatformAdmin bool) context.Co
 func NewTestConfig() *config.Config {
 	return &config.Config{
 		JWT: config.JWTConfig{
-			Secret:    "test-secret",
-			ExpiresIn: 1,
+			Secret:                "test-secret",
+			AccessExpiresInMinute: 1,
+			RefreshExpiresInDay:   1,
 		},
 		Server: config.ServerConfig{
 			Port: 8081,
 			Env:  "test",
 		},
+		Encryption: *NewTestEncryptionConfig(),
 	}
 }
 
 // NewTestJWT creates a JWT provider for tests.
 func NewTestJWT() *infrastructure.JwtProvider {
 	return infrastructure.NewJWTProvider(&config.JWTConfig{
-		Secret:    "test-secret",
-		ExpiresIn: 1,
+		Secret:                "test-secret",
+		AccessExpiresInMinute: 1,
+		RefreshExpiresInDay:   1,
 	})
 }
 
@@ -0,0 +1,100 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+
+	"basekarya-backend/internal/config"
+	"basekarya-backend/internal/infrastructure"
+)
+
+var installKeyring sync.Once
+
+// NewTestEncryptionConfig returns fixed keys, only ever use them in tests.
+func NewTestEncryptionConfig() *config.EncryptionConfig {
+	return &config.EncryptionConfig{
+		MasterKey:     "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+		BlindIndexKey: "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=",
+	}
+}
+
+// NewTestKeyring creates a keyring whose data keys live in memory. SQLite :memory: gives every
+// connection its own database, so keys cannot be stored next to a row written in a transaction.
+func NewTestKeyring() *infrastructure.Keyring {
+	keyring, err := infrastructure.NewKeyring(NewTestEncryptionConfig(), NewMemoryDataKeyStore())
+	if err != nil {
+		panic("failed to create test keyring: " + err.Error())
+	}
+	return keyring
+}
+
+// UseTestKeyring installs a test keyring for encrypted columns once per test binary.
+func UseTestKeyring() {
+	installKeyring.Do(func() {
+		infrastructure.UseKeyring(NewTestKeyring())
+	})
+}
+
+type memoryDataKeyStore struct {
+	mu   sync.Mutex
+	keys []infrastructure.EncryptionKey
+}
+
+func NewMemoryDataKeyStore() infrastructure.DataKeyStore {
+	return &memoryDataKeyStore{}
+}
+
+func (s *memoryDataKeyStore) FindActive(ctx context.Context, companyID uint) (*infrastructure.EncryptionKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].CompanyID == companyID && s.keys[i].Active {
+			key := s.keys[i]
+			return &key, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryDataKeyStore) Find(ctx context.Context, companyID uint, version int) (*infrastructure.EncryptionKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].CompanyID == companyID && s.keys[i].Version == version {
+			key := s.keys[i]
+			return &key, nil
+		}
+	}
+	return nil, infrastructure.ErrDataKeyNotFound
+}
+
+func (s *memoryDataKeyStore) FindAll(ctx context.Context) ([]infrastructure.EncryptionKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]infrastructure.EncryptionKey(nil), s.keys...), nil
+}
+
+func (s *memoryDataKeyStore) Create(ctx context.Context, key *infrastructure.EncryptionKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].CompanyID == key.CompanyID {
+			s.keys[i].Active = false
+		}
+	}
+	key.ID = uint(len(s.keys) + 1)
+	key.Active = true
+	s.keys = append(s.keys, *key)
+	return nil
+}
+
+func (s *memoryDataKeyStore) UpdateWrappedKey(ctx context.Context, id uint, wrappedKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].ID == id {
+			s.keys[i].WrappedKey = wrappedKey
+		}
+	}
+	return nil
+}
@@ -100,11 +100,17 @@ func DecodeResponse(t *testing.T, rec *httptest.ResponseRecorder, target interfa
 	}
 }
 
+// TestSessionID is the session GenerateTestToken binds its tokens to.
+const TestSessionID = "test-session"
+
+// TestRoleID is the role GenerateTestToken puts in its tokens.
+const TestRoleID = 1
+
 // GenerateTestToken generates a valid JWT token for the given user details.
-func GenerateTestToken(t *testing.T, jwt *infrastructure.JwtProvider, userID, companyID uint, isPlatformAdmin bool, role string, permissions []string) string {
+func GenerateTestToken(t *testing.T, jwt *infrastructure.JwtProvider, userID, companyID uint, isPlatformAdmin bool, role string) string {
 	t.Helper()
 	employeeID := uint(1)
-	token, err := jwt.GenerateToken(userID, companyID, isPlatformAdmin, role, &employeeID, permissions)
+	token, err := jwt.GenerateToken(TestSessionID, userID, companyID, isPlatformAdmin, role, TestRoleID, &employeeID, 0)
 	if err != nil {
 		t.Fatalf("failed to generate test token: %v", err)
 	}
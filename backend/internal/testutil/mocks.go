@@ -6,7 +6,6 @@ import (
 	"io"
 	"mime/multipart"
 	"time"
-
 	"github.com/stretchr/testify/mock"
 )
 
@@ -73,11 +72,15 @@ type MockTokenProvider struct {
 	mock.Mock
 }
 
-func (m *MockTokenProvider) GenerateToken(userID uint, companyID uint, isPlatformAdmin bool, role string, employeeID *uint, permissions []string) (string, error) {
-	args := m.Called(userID, companyID, isPlatformAdmin, role, employeeID, permissions)
+func (m *MockTokenProvider) GenerateToken(sessionID string, userID uint, companyID uint, isPlatformAdmin bool, role string, roleID uint, employeeID *uint, permissionVersion int64) (string, error) {
+	args := m.Called(sessionID, userID, companyID, isPlatformAdmin, role, roleID, employeeID, permissionVersion)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockTokenProvider) AccessTTL() time.Duration {
+	return 15 * time.Minute
+}
+
 // MockEmailProvider mocks the EmailProvider interface.
 type MockEmailProvider struct {
 	mock.Mock
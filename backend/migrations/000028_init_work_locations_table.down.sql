@@ -0,0 +1,4 @@
+DROP INDEX idx_attendances_check_out_address ON attendances;
+DROP INDEX idx_attendances_check_in_address ON attendances;
+
+DROP TABLE IF EXISTS ref_work_locations;
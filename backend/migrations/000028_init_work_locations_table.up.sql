@@ -0,0 +1,20 @@
+-- Known work locations, used as offline fallback when reverse geocoding is unavailable
+CREATE TABLE ref_work_locations (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  address VARCHAR(255) NULL,
+  latitude DECIMAL(10,8) NOT NULL,
+  longitude DECIMAL(11,8) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX idx_ref_work_locations_company_id (company_id),
+  CONSTRAINT fk_ref_work_locations_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Speed up recovery of attendances still waiting for geocoding
+CREATE INDEX idx_attendances_check_in_address ON attendances (check_in_address(32));
+CREATE INDEX idx_attendances_check_out_address ON attendances (check_out_address(32));
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS attendance_anomalies;
+
+ALTER TABLE attendances
+  DROP INDEX idx_attendances_review_status,
+  DROP INDEX idx_attendances_check_out_device_id,
+  DROP INDEX idx_attendances_check_out_image_hash,
+  DROP INDEX idx_attendances_check_in_device_id,
+  DROP INDEX idx_attendances_check_in_image_hash,
+  DROP COLUMN review_notes,
+  DROP COLUMN reviewed_at,
+  DROP COLUMN reviewed_by,
+  DROP COLUMN review_status,
+  DROP COLUMN risk_score,
+  DROP COLUMN check_out_device_id,
+  DROP COLUMN check_out_image_hash,
+  DROP COLUMN check_in_device_id,
+  DROP COLUMN check_in_image_hash;
@@ -0,0 +1,39 @@
+ALTER TABLE attendances
+  ADD COLUMN check_in_image_hash VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN check_in_device_id VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN check_out_image_hash VARCHAR(64) NULL,
+  ADD COLUMN check_out_device_id VARCHAR(100) NULL,
+  ADD COLUMN risk_score INT NOT NULL DEFAULT 0,
+  ADD COLUMN review_status ENUM('NONE','PENDING','CLEARED','CONFIRMED') NOT NULL DEFAULT 'NONE',
+  ADD COLUMN reviewed_by BIGINT NULL,
+  ADD COLUMN reviewed_at TIMESTAMP NULL,
+  ADD COLUMN review_notes VARCHAR(500) NULL,
+  ADD INDEX idx_attendances_check_in_image_hash (check_in_image_hash),
+  ADD INDEX idx_attendances_check_in_device_id (check_in_device_id),
+  ADD INDEX idx_attendances_check_out_image_hash (check_out_image_hash),
+  ADD INDEX idx_attendances_check_out_device_id (check_out_device_id),
+  ADD INDEX idx_attendances_review_status (review_status);
+
+-- Existing suspicious attendances go to the review queue
+UPDATE attendances SET review_status = 'PENDING' WHERE is_suspicious = true;
+
+-- Structured reasons raised by the attendance anomaly rules
+CREATE TABLE attendance_anomalies (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  attendance_id BIGINT NOT NULL,
+  rule VARCHAR(50) NOT NULL,
+  score INT NOT NULL DEFAULT 0,
+  detail VARCHAR(255) NULL,
+  is_checkout BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX idx_attendance_anomalies_company_id (company_id),
+  INDEX idx_attendance_anomalies_attendance_id (attendance_id),
+  CONSTRAINT fk_attendance_anomalies_attendance
+    FOREIGN KEY (attendance_id) REFERENCES attendances(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_attendance_anomalies_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS leave_ledgers;
+DROP TABLE IF EXISTS leave_policies;
+
+ALTER TABLE leave_balances
+  DROP COLUMN carry_over_expires_at,
+  DROP COLUMN carry_over;
+
+ALTER TABLE employees
+  DROP COLUMN join_date;
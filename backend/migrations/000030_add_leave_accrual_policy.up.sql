@@ -0,0 +1,70 @@
+ALTER TABLE employees
+  ADD COLUMN join_date DATE NULL;
+
+ALTER TABLE leave_balances
+  ADD COLUMN carry_over INT NOT NULL DEFAULT 0,
+  ADD COLUMN carry_over_expires_at DATE NULL;
+
+-- Accrual rules per company and leave type
+CREATE TABLE leave_policies (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  leave_type_id BIGINT NOT NULL,
+  accrual_type VARCHAR(20) NOT NULL DEFAULT 'ANNUAL',
+  quota_per_year INT NOT NULL DEFAULT 0,
+  eligibility_months INT NOT NULL DEFAULT 0,
+  is_pro_rata BOOLEAN NOT NULL DEFAULT FALSE,
+  carry_over_max INT NOT NULL DEFAULT 0,
+  carry_over_expiry_months INT NOT NULL DEFAULT 0,
+  tenure_bonus_every_years INT NOT NULL DEFAULT 0,
+  tenure_bonus_days INT NOT NULL DEFAULT 0,
+  tenure_bonus_max INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  UNIQUE KEY idx_leave_policies_company_leave_type (company_id, leave_type_id),
+  CONSTRAINT fk_leave_policies_leave_type
+    FOREIGN KEY (leave_type_id) REFERENCES ref_leave_types(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_leave_policies_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Every accrual, carry-over, usage and expiry behind leave_balances
+CREATE TABLE leave_ledgers (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  employee_id BIGINT NOT NULL,
+  leave_type_id BIGINT NOT NULL,
+  year INT NOT NULL,
+  type VARCHAR(20) NOT NULL,
+  days INT NOT NULL,
+  expires_at DATE NULL,
+  reference_id BIGINT NULL,
+  notes VARCHAR(255) NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX idx_leave_ledgers_company_id (company_id),
+  INDEX idx_leave_ledgers_balance (employee_id, leave_type_id, year),
+  CONSTRAINT fk_leave_ledgers_employee
+    FOREIGN KEY (employee_id) REFERENCES employees(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_leave_ledgers_leave_type
+    FOREIGN KEY (leave_type_id) REFERENCES ref_leave_types(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_leave_ledgers_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Opening entries so existing balances are not accrued twice
+INSERT INTO leave_ledgers (company_id, employee_id, leave_type_id, year, type, days, notes)
+SELECT company_id, employee_id, leave_type_id, year, 'ACCRUAL', quota_total, 'Opening balance'
+FROM leave_balances
+WHERE quota_total > 0;
+
+INSERT INTO leave_ledgers (company_id, employee_id, leave_type_id, year, type, days, notes)
+SELECT company_id, employee_id, leave_type_id, year, 'USAGE', -quota_used, 'Opening balance'
+FROM leave_balances
+WHERE quota_used > 0;
@@ -0,0 +1,26 @@
+ALTER TABLE attendances
+  DROP INDEX idx_attendances_leave_request_id,
+  DROP COLUMN excused_until,
+  DROP COLUMN excused_from,
+  DROP COLUMN leave_request_id;
+
+ALTER TABLE leave_ledgers
+  MODIFY COLUMN days INT NOT NULL;
+
+ALTER TABLE leave_balances
+  MODIFY COLUMN carry_over INT NOT NULL DEFAULT 0,
+  MODIFY COLUMN quota_left INT DEFAULT 0,
+  MODIFY COLUMN quota_used INT DEFAULT 0,
+  MODIFY COLUMN quota_total INT DEFAULT 0;
+
+ALTER TABLE leave_requests
+  DROP COLUMN refunded_days,
+  DROP COLUMN returned_at,
+  DROP COLUMN cancellation_reason,
+  DROP COLUMN cancelled_at,
+  DROP COLUMN cancelled_by,
+  DROP COLUMN total_hours,
+  MODIFY COLUMN total_days INT NOT NULL,
+  DROP COLUMN end_time,
+  DROP COLUMN start_time,
+  DROP COLUMN duration_type;
@@ -0,0 +1,30 @@
+ALTER TABLE leave_requests
+  ADD COLUMN duration_type VARCHAR(20) NOT NULL DEFAULT 'FULL_DAY',
+  ADD COLUMN start_time VARCHAR(5) NULL,
+  ADD COLUMN end_time VARCHAR(5) NULL,
+  MODIFY COLUMN total_days DECIMAL(6,2) NOT NULL,
+  ADD COLUMN total_hours DECIMAL(6,2) NOT NULL DEFAULT 0,
+  ADD COLUMN cancelled_by BIGINT NULL,
+  ADD COLUMN cancelled_at TIMESTAMP NULL,
+  ADD COLUMN cancellation_reason VARCHAR(255) NULL,
+  ADD COLUMN returned_at DATE NULL,
+  ADD COLUMN refunded_days DECIMAL(6,2) NOT NULL DEFAULT 0;
+
+UPDATE leave_requests SET total_hours = total_days * 8;
+
+-- Half day and hourly leave take fractions of a day from the quota
+ALTER TABLE leave_balances
+  MODIFY COLUMN quota_total DECIMAL(6,2) DEFAULT 0,
+  MODIFY COLUMN quota_used DECIMAL(6,2) DEFAULT 0,
+  MODIFY COLUMN quota_left DECIMAL(6,2) DEFAULT 0,
+  MODIFY COLUMN carry_over DECIMAL(6,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE leave_ledgers
+  MODIFY COLUMN days DECIMAL(6,2) NOT NULL;
+
+-- Attendances generated from a leave request, partial days keep the excused hours
+ALTER TABLE attendances
+  ADD COLUMN leave_request_id BIGINT NULL,
+  ADD COLUMN excused_from DATETIME NULL,
+  ADD COLUMN excused_until DATETIME NULL,
+  ADD INDEX idx_attendances_leave_request_id (leave_request_id);
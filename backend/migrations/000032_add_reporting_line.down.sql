@@ -0,0 +1,9 @@
+ALTER TABLE ref_departments
+  DROP FOREIGN KEY fk_ref_departments_head_employee,
+  DROP INDEX idx_ref_departments_head_employee_id,
+  DROP COLUMN head_employee_id;
+
+ALTER TABLE employees
+  DROP FOREIGN KEY fk_employees_manager,
+  DROP INDEX idx_employees_manager_id,
+  DROP COLUMN manager_id;
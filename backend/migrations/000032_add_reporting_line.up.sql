@@ -0,0 +1,15 @@
+-- Direct manager of each employee, approvals are routed to them first
+ALTER TABLE employees
+  ADD COLUMN manager_id BIGINT NULL,
+  ADD INDEX idx_employees_manager_id (manager_id),
+  ADD CONSTRAINT fk_employees_manager
+    FOREIGN KEY (manager_id) REFERENCES employees(id)
+    ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- Department heads approve for employees without a direct manager
+ALTER TABLE ref_departments
+  ADD COLUMN head_employee_id BIGINT NULL,
+  ADD INDEX idx_ref_departments_head_employee_id (head_employee_id),
+  ADD CONSTRAINT fk_ref_departments_head_employee
+    FOREIGN KEY (head_employee_id) REFERENCES employees(id)
+    ON DELETE SET NULL ON UPDATE CASCADE;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS approval_delegations;
+DROP TABLE IF EXISTS approval_histories;
+DROP TABLE IF EXISTS approval_step_assignees;
+DROP TABLE IF EXISTS approval_steps;
+DROP TABLE IF EXISTS approval_requests;
+DROP TABLE IF EXISTS approval_chain_steps;
+DROP TABLE IF EXISTS approval_chains;
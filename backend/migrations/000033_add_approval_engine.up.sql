@@ -0,0 +1,152 @@
+-- Configurable approval chain per company and request type
+CREATE TABLE approval_chains (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  request_type VARCHAR(30) NOT NULL,
+  name VARCHAR(100) NULL,
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  UNIQUE KEY idx_approval_chains_company_type (company_id, request_type),
+  CONSTRAINT fk_approval_chains_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Steps sharing a level run in parallel, levels run one after another
+CREATE TABLE approval_chain_steps (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  chain_id BIGINT NOT NULL,
+  level INT NOT NULL,
+  approver_type VARCHAR(20) NOT NULL,
+  permission VARCHAR(100) NULL,
+  approver_user_id BIGINT NULL,
+  min_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+  sla_hours INT NOT NULL DEFAULT 0,
+
+  INDEX idx_approval_chain_steps_chain_id (chain_id),
+  CONSTRAINT fk_approval_chain_steps_chain
+    FOREIGN KEY (chain_id) REFERENCES approval_chains(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_approval_chain_steps_approver
+    FOREIGN KEY (approver_user_id) REFERENCES users(id)
+    ON DELETE SET NULL ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Running approval of a leave, loan, overtime, reimbursement, finance transaction or asset assignment
+CREATE TABLE approval_requests (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  request_type VARCHAR(30) NOT NULL,
+  reference_id BIGINT NOT NULL,
+  requester_user_id BIGINT NOT NULL,
+  amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+  status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+  current_level INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  UNIQUE KEY idx_approval_requests_reference (request_type, reference_id),
+  INDEX idx_approval_requests_company_id (company_id),
+  INDEX idx_approval_requests_requester_user_id (requester_user_id),
+  CONSTRAINT fk_approval_requests_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_approval_requests_requester
+    FOREIGN KEY (requester_user_id) REFERENCES users(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE approval_steps (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  approval_request_id BIGINT NOT NULL,
+  level INT NOT NULL,
+  approver_type VARCHAR(20) NOT NULL,
+  permission VARCHAR(100) NULL,
+  approver_user_id BIGINT NULL,
+  sla_hours INT NOT NULL DEFAULT 0,
+  status VARCHAR(20) NOT NULL DEFAULT 'WAITING',
+  due_at DATETIME NULL,
+  escalated_at DATETIME NULL,
+  acted_by BIGINT NULL,
+  acted_at DATETIME NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX idx_approval_steps_approval_request_id (approval_request_id),
+  INDEX idx_approval_steps_status (status),
+  INDEX idx_approval_steps_due_at (due_at),
+  CONSTRAINT fk_approval_steps_request
+    FOREIGN KEY (approval_request_id) REFERENCES approval_requests(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_approval_steps_acted_by
+    FOREIGN KEY (acted_by) REFERENCES users(id)
+    ON DELETE SET NULL ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Users allowed to act on a step, delegated_from is set when they stand in for someone
+CREATE TABLE approval_step_assignees (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  approval_step_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  delegated_from BIGINT NULL,
+
+  INDEX idx_approval_step_assignees_approval_step_id (approval_step_id),
+  INDEX idx_approval_step_assignees_user_id (user_id),
+  CONSTRAINT fk_approval_step_assignees_step
+    FOREIGN KEY (approval_step_id) REFERENCES approval_steps(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_approval_step_assignees_user
+    FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE approval_histories (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  approval_request_id BIGINT NOT NULL,
+  approval_step_id BIGINT NULL,
+  level INT NOT NULL DEFAULT 0,
+  action VARCHAR(20) NOT NULL,
+  actor_user_id BIGINT NULL,
+  on_behalf_of BIGINT NULL,
+  notes VARCHAR(500) NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX idx_approval_histories_company_id (company_id),
+  INDEX idx_approval_histories_approval_request_id (approval_request_id),
+  CONSTRAINT fk_approval_histories_request
+    FOREIGN KEY (approval_request_id) REFERENCES approval_requests(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_approval_histories_actor
+    FOREIGN KEY (actor_user_id) REFERENCES users(id)
+    ON DELETE SET NULL ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Lets a delegate act on the delegator's approvals, typically while they are on leave
+CREATE TABLE approval_delegations (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  delegator_user_id BIGINT NOT NULL,
+  delegate_user_id BIGINT NOT NULL,
+  start_date DATE NOT NULL,
+  end_date DATE NOT NULL,
+  reason VARCHAR(255) NULL,
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX idx_approval_delegations_company_id (company_id),
+  INDEX idx_approval_delegations_delegator_user_id (delegator_user_id),
+  INDEX idx_approval_delegations_delegate_user_id (delegate_user_id),
+  CONSTRAINT fk_approval_delegations_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_approval_delegations_delegator
+    FOREIGN KEY (delegator_user_id) REFERENCES users(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_approval_delegations_delegate
+    FOREIGN KEY (delegate_user_id) REFERENCES users(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
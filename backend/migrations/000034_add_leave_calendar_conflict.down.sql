@@ -0,0 +1,3 @@
+DROP INDEX idx_leave_requests_period ON leave_requests;
+DROP TABLE IF EXISTS leave_conflict_rules;
+DROP TABLE IF EXISTS ref_holidays;
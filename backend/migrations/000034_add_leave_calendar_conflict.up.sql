@@ -0,0 +1,43 @@
+-- Company holidays shown on the team leave calendar and skipped by leave conflict rules
+CREATE TABLE ref_holidays (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  date DATE NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  UNIQUE KEY idx_ref_holidays_company_date (company_id, date),
+  CONSTRAINT fk_ref_holidays_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Limits on how many people of a department may be off on the same day, and blackout periods
+CREATE TABLE leave_conflict_rules (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  rule_type VARCHAR(20) NOT NULL,
+  -- NULL applies the rule to every department, each counted on its own
+  department_id BIGINT NULL,
+  max_employees INT NOT NULL DEFAULT 0,
+  max_percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+  start_date DATE NULL,
+  end_date DATE NULL,
+  enforcement VARCHAR(10) NOT NULL DEFAULT 'WARN',
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX idx_leave_conflict_rules_company_id (company_id),
+  INDEX idx_leave_conflict_rules_department_id (department_id),
+  CONSTRAINT fk_leave_conflict_rules_department
+    FOREIGN KEY (department_id) REFERENCES ref_departments(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_leave_conflict_rules_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Overlap lookups of the team calendar
+CREATE INDEX idx_leave_requests_period ON leave_requests (company_id, start_date, end_date);
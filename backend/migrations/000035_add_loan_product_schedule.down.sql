@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS loan_installments;
+
+ALTER TABLE loans
+  DROP FOREIGN KEY fk_loans_product,
+  DROP INDEX idx_loans_product_id,
+  DROP COLUMN admin_fee,
+  DROP COLUMN interest_amount,
+  DROP COLUMN tenor_months,
+  DROP COLUMN product_id;
+
+DROP TABLE IF EXISTS loan_products;
@@ -0,0 +1,85 @@
+-- Per-company loan products with the limits an employee loan is checked against
+CREATE TABLE loan_products (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  -- maximum principal as a multiple of the employee's base salary
+  max_salary_multiplier DECIMAL(5,2) NOT NULL DEFAULT 0,
+  max_tenor_months INT NOT NULL DEFAULT 0,
+  -- all monthly installments together may not exceed this share of the net pay
+  max_installment_percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+  -- flat yearly interest on the principal
+  interest_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+  admin_fee DECIMAL(15,2) NOT NULL DEFAULT 0,
+  min_tenure_months INT NOT NULL DEFAULT 0,
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX idx_loan_products_company_id (company_id),
+  CONSTRAINT fk_loan_products_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE loans
+  ADD COLUMN product_id BIGINT NULL AFTER employee_id,
+  ADD COLUMN tenor_months INT NOT NULL DEFAULT 0 AFTER remaining_amount,
+  ADD COLUMN interest_amount DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER tenor_months,
+  ADD COLUMN admin_fee DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER interest_amount,
+  ADD INDEX idx_loans_product_id (product_id),
+  ADD CONSTRAINT fk_loans_product
+    FOREIGN KEY (product_id) REFERENCES loan_products(id)
+    ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- Monthly installments payroll deducts, generated when a loan is fully approved
+CREATE TABLE loan_installments (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  loan_id BIGINT NOT NULL,
+  sequence INT NOT NULL,
+  -- first day of the payroll month the installment is deducted in
+  due_date DATE NOT NULL,
+  principal_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+  interest_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+  amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+  status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+  paid_at TIMESTAMP NULL,
+  notes VARCHAR(255) NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  UNIQUE KEY idx_loan_installments_loan_sequence (loan_id, sequence),
+  INDEX idx_loan_installments_company_id (company_id),
+  INDEX idx_loan_installments_due (due_date, status),
+  CONSTRAINT fk_loan_installments_loan
+    FOREIGN KEY (loan_id) REFERENCES loans(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_loan_installments_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Approved loans from before the schedule keep their monthly installment, starting this month
+INSERT INTO loan_installments (company_id, loan_id, sequence, due_date, principal_amount, interest_amount, amount, status)
+WITH RECURSIVE months AS (
+  SELECT 1 AS n
+  UNION ALL
+  SELECT n + 1 FROM months WHERE n < 360
+)
+SELECT
+  l.company_id,
+  l.id,
+  months.n,
+  DATE_ADD(DATE_FORMAT(CURRENT_DATE, '%Y-%m-01'), INTERVAL months.n - 1 MONTH),
+  LEAST(l.installment_amount, l.remaining_amount - (months.n - 1) * l.installment_amount),
+  0,
+  LEAST(l.installment_amount, l.remaining_amount - (months.n - 1) * l.installment_amount),
+  'PENDING'
+FROM loans l
+JOIN months ON months.n <= CEIL(l.remaining_amount / l.installment_amount)
+WHERE l.status = 'APPROVED' AND l.remaining_amount > 0 AND l.installment_amount > 0;
+
+UPDATE loans
+SET tenor_months = CEIL(remaining_amount / installment_amount)
+WHERE status = 'APPROVED' AND remaining_amount > 0 AND installment_amount > 0;
@@ -0,0 +1,11 @@
+UPDATE loans SET status = 'PAID_OFF' WHERE status = 'WRITTEN_OFF';
+
+ALTER TABLE loans
+  MODIFY COLUMN status ENUM('PENDING', 'APPROVED', 'REJECTED', 'PAID_OFF') NOT NULL DEFAULT 'PENDING';
+
+ALTER TABLE payroll_details
+  DROP FOREIGN KEY fk_payroll_details_loan_installment,
+  DROP INDEX idx_payroll_details_loan_installment,
+  DROP COLUMN loan_installment_id;
+
+DROP TABLE IF EXISTS loan_transactions;
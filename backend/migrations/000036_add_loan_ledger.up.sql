@@ -0,0 +1,57 @@
+-- Every movement of a loan balance, the balance of a loan is the sum of its ledger
+CREATE TABLE loan_transactions (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  loan_id BIGINT NOT NULL,
+  -- DISBURSEMENT raises the balance, INSTALLMENT, REPAYMENT and WRITE_OFF lower it
+  type VARCHAR(20) NOT NULL,
+  amount DECIMAL(15,2) NOT NULL,
+  installment_id BIGINT NULL,
+  -- the payroll deduction an INSTALLMENT was paid with
+  payroll_detail_id BIGINT NULL,
+  transaction_date DATE NOT NULL,
+  notes VARCHAR(255) NULL,
+  created_by BIGINT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  UNIQUE KEY idx_loan_transactions_payroll_detail (payroll_detail_id),
+  INDEX idx_loan_transactions_company_id (company_id),
+  INDEX idx_loan_transactions_loan (loan_id, transaction_date),
+  CONSTRAINT fk_loan_transactions_loan
+    FOREIGN KEY (loan_id) REFERENCES loans(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_loan_transactions_installment
+    FOREIGN KEY (installment_id) REFERENCES loan_installments(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT fk_loan_transactions_payroll_detail
+    FOREIGN KEY (payroll_detail_id) REFERENCES payroll_details(id)
+    ON DELETE RESTRICT ON UPDATE CASCADE,
+  CONSTRAINT fk_loan_transactions_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT fk_loan_transactions_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Loan deductions point at the installment they pay instead of being recognised by their title
+ALTER TABLE payroll_details
+  ADD COLUMN loan_installment_id BIGINT NULL AFTER is_employer_borne,
+  ADD INDEX idx_payroll_details_loan_installment (loan_installment_id),
+  ADD CONSTRAINT fk_payroll_details_loan_installment
+    FOREIGN KEY (loan_installment_id) REFERENCES loan_installments(id)
+    ON DELETE SET NULL ON UPDATE CASCADE;
+
+ALTER TABLE loans
+  MODIFY COLUMN status ENUM('PENDING', 'APPROVED', 'REJECTED', 'PAID_OFF', 'WRITTEN_OFF') NOT NULL DEFAULT 'PENDING';
+
+-- Opening ledger of loans approved before the ledger: the amount owed, then everything repaid so far
+INSERT INTO loan_transactions (company_id, loan_id, type, amount, transaction_date, notes)
+SELECT company_id, id, 'DISBURSEMENT', total_amount + interest_amount, DATE(COALESCE(created_at, CURRENT_TIMESTAMP)), 'Opening balance'
+FROM loans
+WHERE status IN ('APPROVED', 'PAID_OFF');
+
+INSERT INTO loan_transactions (company_id, loan_id, type, amount, transaction_date, notes)
+SELECT company_id, id, 'INSTALLMENT', total_amount + interest_amount - remaining_amount, CURRENT_DATE, 'Repaid before the ledger'
+FROM loans
+WHERE status IN ('APPROVED', 'PAID_OFF') AND total_amount + interest_amount - remaining_amount > 0;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS offboarding_settlement_items;
+DROP TABLE IF EXISTS offboarding_settlements;
+
+ALTER TABLE employees
+  DROP COLUMN termination_reason,
+  DROP COLUMN termination_date;
@@ -0,0 +1,72 @@
+-- End of employment, set when the final settlement of a leaving employee is finalized
+ALTER TABLE employees
+  ADD COLUMN termination_date DATE NULL,
+  ADD COLUMN termination_reason VARCHAR(30) NULL;
+
+-- Final settlement of an employee leaving the company
+CREATE TABLE offboarding_settlements (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  employee_id BIGINT NOT NULL,
+  -- RESIGNATION, ABSENCE, MISCONDUCT, EFFICIENCY, EFFICIENCY_LOSS, LONG_ILLNESS, RETIREMENT or DEATH
+  reason VARCHAR(30) NOT NULL,
+  last_working_date DATE NOT NULL,
+  -- figures the settlement was calculated with
+  base_salary DECIMAL(15,2) NOT NULL DEFAULT 0,
+  tenure_months INT NOT NULL DEFAULT 0,
+  worked_days INT NOT NULL DEFAULT 0,
+  period_days INT NOT NULL DEFAULT 0,
+  unused_leave_days DECIMAL(6,2) NOT NULL DEFAULT 0,
+  total_earnings DECIMAL(15,2) NOT NULL DEFAULT 0,
+  total_deductions DECIMAL(15,2) NOT NULL DEFAULT 0,
+  net_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+  -- loan balance the settlement could not cover, left on the loan ledger
+  uncovered_loan_balance DECIMAL(15,2) NOT NULL DEFAULT 0,
+  status VARCHAR(15) NOT NULL DEFAULT 'DRAFT',
+  notes TEXT NULL,
+  created_by BIGINT NULL,
+  finalized_by BIGINT NULL,
+  finalized_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX idx_offboarding_settlements_company_id (company_id),
+  INDEX idx_offboarding_settlements_employee (employee_id, status),
+  CONSTRAINT fk_offboarding_settlements_employee
+    FOREIGN KEY (employee_id) REFERENCES employees(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_offboarding_settlements_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT fk_offboarding_settlements_finalized_by
+    FOREIGN KEY (finalized_by) REFERENCES users(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT fk_offboarding_settlements_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Earnings and deductions making up a final settlement
+CREATE TABLE offboarding_settlement_items (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  settlement_id BIGINT NOT NULL,
+  company_id BIGINT NOT NULL,
+  code VARCHAR(30) NOT NULL,
+  title VARCHAR(150) NOT NULL,
+  -- ALLOWANCE or DEDUCTION, as on payroll details
+  type VARCHAR(20) NOT NULL,
+  amount DECIMAL(15,2) NOT NULL,
+  -- the loan a LOAN deduction repays
+  loan_id BIGINT NULL,
+
+  INDEX idx_offboarding_settlement_items_company_id (company_id),
+  CONSTRAINT fk_offboarding_settlement_items_settlement
+    FOREIGN KEY (settlement_id) REFERENCES offboarding_settlements(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_offboarding_settlement_items_loan
+    FOREIGN KEY (loan_id) REFERENCES loans(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT fk_offboarding_settlement_items_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
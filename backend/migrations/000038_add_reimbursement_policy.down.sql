@@ -0,0 +1,13 @@
+ALTER TABLE reimbursements
+  DROP FOREIGN KEY fk_reimbursements_category,
+  DROP INDEX idx_reimbursements_proof_hash,
+  DROP INDEX idx_reimbursements_category_id,
+  DROP COLUMN proof_hash,
+  DROP COLUMN limit_warning,
+  DROP COLUMN category_id;
+
+DROP TABLE IF EXISTS reimbursement_grade_caps;
+DROP TABLE IF EXISTS reimbursement_categories;
+
+ALTER TABLE employees
+  DROP COLUMN grade;
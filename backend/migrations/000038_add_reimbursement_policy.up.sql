@@ -0,0 +1,63 @@
+-- Grade of the employee, used for grade-based reimbursement caps
+ALTER TABLE employees
+  ADD COLUMN grade VARCHAR(20) NULL;
+
+-- Kinds of claim with their caps, a cap of 0 is no cap
+CREATE TABLE reimbursement_categories (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  code VARCHAR(30) NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  annual_cap DECIMAL(15,2) NOT NULL DEFAULT 0,
+  monthly_cap DECIMAL(15,2) NOT NULL DEFAULT 0,
+  per_claim_cap DECIMAL(15,2) NOT NULL DEFAULT 0,
+  -- REJECT refuses a claim over a cap, WARN keeps it with a warning for the approvers
+  limit_action VARCHAR(10) NOT NULL DEFAULT 'REJECT',
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  UNIQUE KEY uq_reimbursement_categories_code (company_id, code),
+  CONSTRAINT fk_reimbursement_categories_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Per-claim cap of a category for one grade, in place of the category's own
+CREATE TABLE reimbursement_grade_caps (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  category_id BIGINT NOT NULL,
+  company_id BIGINT NOT NULL,
+  grade VARCHAR(20) NOT NULL,
+  per_claim_cap DECIMAL(15,2) NOT NULL,
+
+  UNIQUE KEY uq_reimbursement_grade_caps_grade (category_id, grade),
+  INDEX idx_reimbursement_grade_caps_company_id (company_id),
+  CONSTRAINT fk_reimbursement_grade_caps_category
+    FOREIGN KEY (category_id) REFERENCES reimbursement_categories(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE reimbursements
+  ADD COLUMN category_id BIGINT NULL AFTER approved_by,
+  -- caps the claim breached when its category only warns
+  ADD COLUMN limit_warning TEXT NULL,
+  -- perceptual hash of the proof image, or the sha256 of any other file
+  ADD COLUMN proof_hash VARCHAR(64) NULL,
+  ADD INDEX idx_reimbursements_category_id (category_id),
+  ADD INDEX idx_reimbursements_proof_hash (proof_hash),
+  ADD CONSTRAINT fk_reimbursements_category
+    FOREIGN KEY (category_id) REFERENCES reimbursement_categories(id)
+    ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- Default categories for the companies already registered, new companies get them on registration
+INSERT INTO reimbursement_categories (company_id, code, name)
+SELECT c.id, d.code, d.name
+FROM companies c
+CROSS JOIN (
+  SELECT 'MEDICAL' AS code, 'Medical' AS name
+  UNION ALL SELECT 'TRANSPORT', 'Transport'
+  UNION ALL SELECT 'GLASSES', 'Glasses'
+  UNION ALL SELECT 'MEAL', 'Meal'
+  UNION ALL SELECT 'OTHER', 'Other'
+) d;
@@ -0,0 +1,15 @@
+ALTER TABLE payroll_details
+  DROP FOREIGN KEY fk_payroll_details_reimbursement,
+  DROP INDEX idx_payroll_details_reimbursement,
+  DROP COLUMN reimbursement_id;
+
+ALTER TABLE reimbursements
+  DROP FOREIGN KEY fk_reimbursements_payout_batch,
+  DROP INDEX idx_reimbursements_payout_batch_id,
+  DROP INDEX idx_reimbursements_payout_status,
+  DROP COLUMN paid_at,
+  DROP COLUMN payout_batch_id,
+  DROP COLUMN payout_status,
+  DROP COLUMN payout_channel;
+
+DROP TABLE IF EXISTS reimbursement_payout_batches;
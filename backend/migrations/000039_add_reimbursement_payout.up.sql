@@ -0,0 +1,61 @@
+-- Transfer batches paying approved claims outside payroll
+CREATE TABLE reimbursement_payout_batches (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  -- DRAFT, PAID or CANCELLED
+  status VARCHAR(10) NOT NULL DEFAULT 'DRAFT',
+  total_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+  notes TEXT NULL,
+  -- bank reference of the transfer, recorded when the batch is paid
+  transfer_reference VARCHAR(100) NULL,
+  created_by BIGINT NULL,
+  paid_by BIGINT NULL,
+  paid_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX idx_reimbursement_payout_batches_company_id (company_id),
+  CONSTRAINT fk_reimbursement_payout_batches_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT fk_reimbursement_payout_batches_paid_by
+    FOREIGN KEY (paid_by) REFERENCES users(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT fk_reimbursement_payout_batches_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE reimbursements
+  -- PAYROLL pays the claim with the next payroll, TRANSFER in a payout batch
+  ADD COLUMN payout_channel VARCHAR(10) NOT NULL DEFAULT 'PAYROLL',
+  -- UNPAID, QUEUED on a draft payroll or batch, or PAID
+  ADD COLUMN payout_status VARCHAR(10) NOT NULL DEFAULT 'UNPAID',
+  ADD COLUMN payout_batch_id BIGINT NULL,
+  ADD COLUMN paid_at TIMESTAMP NULL,
+  ADD INDEX idx_reimbursements_payout_status (payout_status),
+  ADD INDEX idx_reimbursements_payout_batch_id (payout_batch_id),
+  ADD CONSTRAINT fk_reimbursements_payout_batch
+    FOREIGN KEY (payout_batch_id) REFERENCES reimbursement_payout_batches(id)
+    ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- Reimbursement allowances point at the claim they pay back
+ALTER TABLE payroll_details
+  ADD COLUMN reimbursement_id BIGINT NULL AFTER loan_installment_id,
+  ADD INDEX idx_payroll_details_reimbursement (reimbursement_id),
+  ADD CONSTRAINT fk_payroll_details_reimbursement
+    FOREIGN KEY (reimbursement_id) REFERENCES reimbursements(id)
+    ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- Approved claims already summed into the payroll of their expense month, before claims were linked to
+-- payroll details, are settled by that payroll
+UPDATE reimbursements r
+JOIN employees e ON e.user_id = r.user_id
+JOIN payrolls p ON p.employee_id = e.id
+  AND p.deleted_at IS NULL
+  AND YEAR(p.period_date) = YEAR(r.date_of_expense)
+  AND MONTH(p.period_date) = MONTH(r.date_of_expense)
+SET r.payout_status = 'PAID',
+    r.paid_at = p.updated_at
+WHERE r.status = 'APPROVED';
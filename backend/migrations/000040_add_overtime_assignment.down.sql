@@ -0,0 +1,8 @@
+DELETE FROM overtimes WHERE status = 'ASSIGNED';
+
+ALTER TABLE overtimes
+  DROP FOREIGN KEY fk_overtimes_assigner,
+  DROP INDEX idx_overtimes_employee_date,
+  DROP COLUMN claimed_minutes,
+  DROP COLUMN assigned_by,
+  MODIFY COLUMN status ENUM('PENDING', 'APPROVED', 'REJECTED', 'PAID') NOT NULL DEFAULT 'PENDING';
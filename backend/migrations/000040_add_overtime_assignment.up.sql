@@ -0,0 +1,13 @@
+-- ASSIGNED overtime is pre-approved by a manager before it is worked
+ALTER TABLE overtimes
+  MODIFY COLUMN status ENUM('ASSIGNED', 'PENDING', 'APPROVED', 'REJECTED', 'PAID') NOT NULL DEFAULT 'PENDING',
+  ADD COLUMN assigned_by BIGINT NULL AFTER approved_by,
+  -- minutes the employee claimed, duration_minutes is capped to the attendance beyond the shift
+  ADD COLUMN claimed_minutes INT NOT NULL DEFAULT 0 AFTER duration_minutes,
+  ADD INDEX idx_overtimes_employee_date (employee_id, date),
+  ADD CONSTRAINT fk_overtimes_assigner
+    FOREIGN KEY (assigned_by) REFERENCES users(id)
+    ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- Claims made before capping were taken as claimed
+UPDATE overtimes SET claimed_minutes = duration_minutes;
@@ -0,0 +1,14 @@
+-- Hashes of replaced passwords, a new password may not match the last few
+CREATE TABLE password_histories (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  company_id BIGINT NOT NULL,
+  password_hash VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX idx_password_histories_user_id (user_id, created_at),
+  INDEX idx_password_histories_company_id (company_id),
+  CONSTRAINT fk_password_histories_user
+    FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS company_mfa_permissions;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+
+ALTER TABLE users
+  DROP COLUMN mfa_enabled_at,
+  DROP COLUMN mfa_enabled,
+  DROP COLUMN mfa_secret;
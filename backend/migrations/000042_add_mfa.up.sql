@@ -0,0 +1,41 @@
+-- TOTP authenticator of a user, logins ask for a code once MFA is enabled
+ALTER TABLE users
+  ADD COLUMN mfa_secret VARCHAR(64) NULL AFTER is_active,
+  ADD COLUMN mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE AFTER mfa_secret,
+  ADD COLUMN mfa_enabled_at TIMESTAMP NULL AFTER mfa_enabled;
+
+-- Single use codes for a lost authenticator, only the hashes are kept
+CREATE TABLE mfa_recovery_codes (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  company_id BIGINT NOT NULL,
+  code_hash VARCHAR(64) NOT NULL,
+  used_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX idx_mfa_recovery_codes_user_id (user_id, code_hash),
+  INDEX idx_mfa_recovery_codes_company_id (company_id),
+  CONSTRAINT fk_mfa_recovery_codes_user
+    FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Roles holding any of these permissions must sign in with MFA
+CREATE TABLE company_mfa_permissions (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  permission_name VARCHAR(100) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  UNIQUE INDEX idx_company_mfa_permission (company_id, permission_name),
+  CONSTRAINT fk_company_mfa_permissions_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Existing companies start with the default policy, affected users enrol at their next login
+INSERT INTO company_mfa_permissions (company_id, permission_name)
+SELECT companies.id, permissions.name
+FROM companies
+CROSS JOIN permissions
+WHERE permissions.name IN ('GENERATE_PAYROLL', 'MARK_AS_PAID');
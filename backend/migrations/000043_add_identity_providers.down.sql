@@ -0,0 +1,3 @@
+DROP INDEX idx_employees_company_email ON employees;
+
+DROP TABLE IF EXISTS identity_providers;
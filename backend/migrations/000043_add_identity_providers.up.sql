@@ -0,0 +1,32 @@
+-- Single sign-on configuration of a company, users on the email domain sign in through the IdP
+CREATE TABLE identity_providers (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  protocol VARCHAR(10) NOT NULL,
+  domain VARCHAR(255) NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  sso_only BOOLEAN NOT NULL DEFAULT FALSE,
+
+  issuer_url VARCHAR(500) NULL,
+  client_id VARCHAR(255) NULL,
+  client_secret TEXT NULL,
+
+  metadata_xml MEDIUMTEXT NULL,
+
+  jit_provisioning BOOLEAN NOT NULL DEFAULT FALSE,
+  default_role_id BIGINT NULL,
+  default_department_id BIGINT NULL,
+  default_shift_id BIGINT NULL,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  UNIQUE INDEX idx_identity_providers_company_id (company_id),
+  UNIQUE INDEX idx_identity_providers_domain (domain),
+  CONSTRAINT fk_identity_providers_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- SSO logins map the IdP email to an employee of the company
+CREATE INDEX idx_employees_company_email ON employees (company_id, email);
@@ -0,0 +1,20 @@
+-- Trail of row changes made through the application, written by the audit callbacks.
+-- No foreign keys: entries must outlive the rows, users and companies they describe.
+CREATE TABLE audit_logs (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL DEFAULT 0,
+  user_id BIGINT NOT NULL DEFAULT 0,
+  entity_type VARCHAR(100) NOT NULL,
+  entity_id BIGINT NOT NULL DEFAULT 0,
+  action VARCHAR(10) NOT NULL,
+  changes JSON NOT NULL,
+  request_id VARCHAR(64) NULL,
+  ip_address VARCHAR(45) NULL,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX idx_audit_logs_company_created (company_id, created_at),
+  INDEX idx_audit_logs_entity (company_id, entity_type, entity_id),
+  INDEX idx_audit_logs_user (user_id),
+  INDEX idx_audit_logs_request (request_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
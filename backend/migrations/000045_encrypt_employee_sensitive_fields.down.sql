@@ -0,0 +1,11 @@
+-- Only safe while the columns still hold plaintext, ciphertext cannot be converted back in SQL.
+ALTER TABLE employees
+  DROP INDEX idx_employees_nik_bidx,
+  DROP COLUMN nik_bidx,
+  MODIFY nik VARCHAR(20) NOT NULL,
+  MODIFY base_salary DECIMAL(15, 2) DEFAULT 0,
+  MODIFY bank_account_number VARCHAR(50) NULL,
+  MODIFY npwp VARCHAR(30) NULL,
+  ADD UNIQUE INDEX nik (nik);
+
+DROP TABLE IF EXISTS encryption_keys;
@@ -0,0 +1,24 @@
+-- Per-company data keys for encrypted columns, stored wrapped by ENCRYPTION_MASTER_KEY.
+-- Older versions are kept to read values written before a rotation.
+CREATE TABLE encryption_keys (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  company_id BIGINT NOT NULL,
+  version INT NOT NULL,
+  wrapped_key VARCHAR(255) NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT FALSE,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  UNIQUE INDEX idx_encryption_keys_company_version (company_id, version)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Sensitive employee columns hold ciphertext from now on, NIK uniqueness moves to its blind index.
+-- Existing plaintext stays readable, run the rotate-keys mode afterwards to encrypt it and fill nik_bidx.
+ALTER TABLE employees
+  DROP INDEX nik,
+  MODIFY nik VARCHAR(255) NOT NULL,
+  MODIFY base_salary VARCHAR(255) NULL,
+  MODIFY bank_account_number VARCHAR(255) NULL,
+  MODIFY npwp VARCHAR(255) NULL,
+  ADD COLUMN nik_bidx VARCHAR(64) NULL AFTER nik,
+  ADD UNIQUE INDEX idx_employees_nik_bidx (nik_bidx);
@@ -0,0 +1 @@
+ALTER TABLE role_permissions DROP COLUMN data_scope;
@@ -0,0 +1,4 @@
+-- How far a granted permission reaches: SELF, DEPARTMENT, SUBORDINATES or COMPANY.
+-- Existing grants keep seeing the whole company.
+ALTER TABLE role_permissions
+  ADD COLUMN data_scope VARCHAR(20) NOT NULL DEFAULT 'COMPANY' AFTER company_id;
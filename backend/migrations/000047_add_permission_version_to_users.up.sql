@@ -0,0 +1,4 @@
+-- Access tokens carry the role ID and this version instead of a permission list,
+-- moving a user to another role bumps it so their older tokens have to be refreshed.
+ALTER TABLE users
+  ADD COLUMN permission_version BIGINT NOT NULL DEFAULT 0 AFTER is_active;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS employment_events;
+
+ALTER TABLE employees DROP COLUMN employment_status;
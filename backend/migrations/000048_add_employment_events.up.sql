@@ -0,0 +1,66 @@
+-- Employment status follows the employment events applied to the employee, employees are terminated instead of deleted
+ALTER TABLE employees
+  ADD COLUMN employment_status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' AFTER manager_id;
+
+-- Employment history: hires, probation, promotions, transfers, salary changes, suspensions, resignations,
+-- terminations and rehires. Events dated in the future stay unapplied until their effective date.
+CREATE TABLE employment_events (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  employee_id BIGINT NOT NULL,
+  company_id BIGINT NOT NULL,
+  type VARCHAR(20) NOT NULL,
+  effective_date DATE NOT NULL,
+  -- end of the probation of a hire, end of a suspension or last working day of a resignation
+  end_date DATE NULL,
+
+  position VARCHAR(100) NULL,
+  department_id BIGINT NULL,
+  grade VARCHAR(20) NULL,
+  base_salary VARCHAR(255) NULL,
+
+  -- what the event replaced, filled in when it is applied
+  previous_position VARCHAR(100) NULL,
+  previous_department_id BIGINT NULL,
+  previous_grade VARCHAR(20) NULL,
+  previous_base_salary VARCHAR(255) NULL,
+
+  termination_reason VARCHAR(30) NULL,
+  notes TEXT NULL,
+  attachment_url VARCHAR(500) NULL,
+  -- 0 when recorded by the system
+  created_by BIGINT NOT NULL DEFAULT 0,
+  applied_at TIMESTAMP NULL,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX idx_employment_events_company_id (company_id),
+  INDEX idx_employment_events_employee (employee_id, effective_date),
+  INDEX idx_employment_events_pending (applied_at, effective_date),
+  CONSTRAINT fk_employment_events_employee
+    FOREIGN KEY (employee_id) REFERENCES employees(id)
+    ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT fk_employment_events_department
+    FOREIGN KEY (department_id) REFERENCES ref_departments(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT fk_employment_events_previous_department
+    FOREIGN KEY (previous_department_id) REFERENCES ref_departments(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT fk_employment_events_company
+    FOREIGN KEY (company_id) REFERENCES companies(id)
+    ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Every current employee starts their history with a hire. Encrypted salaries are bound to their column and
+-- cannot be copied, only salaries still in plaintext are carried over.
+INSERT INTO employment_events (employee_id, company_id, type, effective_date, position, department_id, grade, base_salary, applied_at)
+SELECT id, company_id, 'HIRE', COALESCE(join_date, DATE(created_at)), position, NULLIF(department_id, 0), grade,
+  CASE WHEN base_salary LIKE 'enc:%' THEN NULL ELSE base_salary END, CURRENT_TIMESTAMP
+FROM employees;
+
+-- Employees who left through a final settlement
+INSERT INTO employment_events (employee_id, company_id, type, effective_date, termination_reason, applied_at)
+SELECT id, company_id, 'TERMINATION', termination_date, termination_reason, CURRENT_TIMESTAMP
+FROM employees
+WHERE termination_date IS NOT NULL;
+
+UPDATE employees SET employment_status = 'TERMINATED' WHERE termination_date IS NOT NULL;
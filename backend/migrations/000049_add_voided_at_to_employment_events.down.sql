@@ -0,0 +1,2 @@
+ALTER TABLE employment_events
+  DROP COLUMN voided_at;
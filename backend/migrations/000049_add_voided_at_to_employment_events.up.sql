@@ -0,0 +1,4 @@
+-- Events still pending when the employee is terminated, or that no longer fit the employee by their effective date,
+-- are voided instead of applied. They stay in the history.
+ALTER TABLE employment_events
+  ADD COLUMN voided_at TIMESTAMP NULL AFTER applied_at;
@@ -0,0 +1,4 @@
+ALTER TABLE reimbursements
+  DROP INDEX idx_reimbursements_proof_digest,
+  DROP COLUMN duplicate_warning,
+  DROP COLUMN proof_digest;
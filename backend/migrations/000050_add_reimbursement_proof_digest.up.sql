@@ -0,0 +1,7 @@
+-- Only the same receipt is refused: the same file by its sha256, or the same image hash. Receipts whose image
+-- hash is a few bits off are kept as a warning for the approvers.
+ALTER TABLE reimbursements
+  ADD COLUMN proof_digest VARCHAR(64) NULL AFTER proof_hash,
+  -- claims whose receipt looks like this one, shown to the approvers
+  ADD COLUMN duplicate_warning TEXT NULL AFTER limit_warning,
+  ADD INDEX idx_reimbursements_proof_digest (proof_digest);
@@ -0,0 +1,3 @@
+ALTER TABLE identity_providers
+  DROP COLUMN domain_verified_at,
+  DROP COLUMN verification_token;
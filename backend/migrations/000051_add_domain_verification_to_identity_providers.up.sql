@@ -0,0 +1,7 @@
+-- A provider only signs users in once the company proved it controls the domain with a DNS TXT record
+ALTER TABLE identity_providers
+  ADD COLUMN verification_token VARCHAR(64) NULL AFTER domain,
+  ADD COLUMN domain_verified_at TIMESTAMP NULL AFTER verification_token;
+
+-- providers saved before the check have to verify their domain too
+UPDATE identity_providers SET verification_token = SHA2(CONCAT(UUID(), RAND()), 256);
@@ -0,0 +1,58 @@
+package constants
+
+type ApprovalRequestType string
+
+const (
+	ApprovalRequestLeave           ApprovalRequestType = "LEAVE"
+	ApprovalRequestLoan            ApprovalRequestType = "LOAN"
+	ApprovalRequestOvertime        ApprovalRequestType = "OVERTIME"
+	ApprovalRequestReimbursement   ApprovalRequestType = "REIMBURSEMENT"
+	ApprovalRequestFinance         ApprovalRequestType = "FINANCE"
+	ApprovalRequestAssetAssignment ApprovalRequestType = "ASSET_ASSIGNMENT"
+)
+
+type ApprovalStatus string
+
+const (
+	ApprovalStatusPending   ApprovalStatus = "PENDING"
+	ApprovalStatusApproved  ApprovalStatus = "APPROVED"
+	ApprovalStatusRejected  ApprovalStatus = "REJECTED"
+	ApprovalStatusCancelled ApprovalStatus = "CANCELLED"
+)
+
+type ApprovalStepStatus string
+
+const (
+	// steps of a later level wait until every step of the current level is approved
+	ApprovalStepWaiting  ApprovalStepStatus = "WAITING"
+	ApprovalStepPending  ApprovalStepStatus = "PENDING"
+	ApprovalStepApproved ApprovalStepStatus = "APPROVED"
+	ApprovalStepRejected ApprovalStepStatus = "REJECTED"
+	ApprovalStepSkipped  ApprovalStepStatus = "SKIPPED"
+)
+
+type ApproverType string
+
+const (
+	ApproverManager        ApproverType = "MANAGER"
+	ApproverDepartmentHead ApproverType = "DEPARTMENT_HEAD"
+	ApproverPermission     ApproverType = "PERMISSION"
+	ApproverUser           ApproverType = "USER"
+)
+
+type ApprovalAction string
+
+const (
+	ApprovalActionApprove ApprovalAction = "APPROVE"
+	ApprovalActionReject  ApprovalAction = "REJECT"
+)
+
+type ApprovalHistoryAction string
+
+const (
+	ApprovalHistorySubmitted ApprovalHistoryAction = "SUBMITTED"
+	ApprovalHistoryApproved  ApprovalHistoryAction = "APPROVED"
+	ApprovalHistoryRejected  ApprovalHistoryAction = "REJECTED"
+	ApprovalHistoryEscalated ApprovalHistoryAction = "ESCALATED"
+	ApprovalHistoryCancelled ApprovalHistoryAction = "CANCELLED"
+)
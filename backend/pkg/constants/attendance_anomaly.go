@@ -0,0 +1,39 @@
+package constants
+
+type AttendanceAnomalyRule string
+
+const (
+	AttendanceAnomalyMockLocation         AttendanceAnomalyRule = "MOCK_LOCATION"
+	AttendanceAnomalyIdenticalCoordinates AttendanceAnomalyRule = "IDENTICAL_COORDINATES"
+	AttendanceAnomalyImageReused          AttendanceAnomalyRule = "IMAGE_REUSED"
+	AttendanceAnomalySharedDevice         AttendanceAnomalyRule = "SHARED_DEVICE"
+	AttendanceAnomalyImpossibleTravel     AttendanceAnomalyRule = "IMPOSSIBLE_TRAVEL"
+)
+
+type AttendanceReviewStatus string
+
+const (
+	AttendanceReviewNone      AttendanceReviewStatus = "NONE"
+	AttendanceReviewPending   AttendanceReviewStatus = "PENDING"
+	AttendanceReviewCleared   AttendanceReviewStatus = "CLEARED"
+	AttendanceReviewConfirmed AttendanceReviewStatus = "CONFIRMED"
+)
+
+type AttendanceReviewAction string
+
+const (
+	AttendanceReviewActionClear   AttendanceReviewAction = "CLEAR"
+	AttendanceReviewActionConfirm AttendanceReviewAction = "CONFIRM"
+)
+
+const (
+	// look back window used by history based rules
+	AttendanceAnomalyLookbackDays = 30
+	// previous days with the exact same coordinates before it is flagged
+	AttendanceIdenticalCoordinateDays = 2
+	// distinct employees on one device before it is flagged
+	AttendanceSharedDeviceEmployees = 3
+	// travel speed between two consecutive points considered impossible
+	AttendanceMaxTravelSpeedKmH   = 200.0
+	AttendanceMinTravelDistanceKm = 2.0
+)
@@ -0,0 +1,21 @@
+package constants
+
+import "time"
+
+// AuditAction is the kind of change an audit entry records.
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "CREATE"
+	AuditActionUpdate AuditAction = "UPDATE"
+	AuditActionDelete AuditAction = "DELETE"
+)
+
+const (
+	AuditQueueSize     = 10000
+	AuditBatchSize     = 100
+	AuditFlushInterval = time.Second
+	// an update or delete touching more rows than this is audited for the first rows only
+	AuditMaxSnapshotRows = 1000
+	AuditMaskedValue     = "[REDACTED]"
+)
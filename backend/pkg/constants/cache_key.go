@@ -4,10 +4,28 @@ const (
 	PERMISSION_CACHE_KEY      = "permission:all"
 	ROLE_CACHE_KEY            = "role:all"
 	ROLE_PERMISSION_CACHE_KEY = "role:permission:%d"
+	ROLE_GRANTS_CACHE_KEY     = "role:grants:%d"
 	USER_CACHE_KEY            = "user:%d"
 	DEPARTMEN_CACHE_KEY       = "department:all"
 	SHIFT_CACHE_KEY           = "shift:all"
 	LEAVE_TYPE_CACHE_KEY      = "leave_type:all"
 	COMPANY_PROFILE_CACHE_KEY      = "company:profile:%d"
 	SUBSCRIPTION_FEATURES_CACHE_KEY = "subscription:features:%d"
+	GEOCODE_CACHE_KEY               = "geocode:%.4f:%.4f"
+	SESSION_CACHE_KEY               = "session:%s"
+	USER_SESSIONS_CACHE_KEY         = "session:user:%d"
+	SESSION_LAST_SEEN_CACHE_KEY     = "session:last_seen:%s"
+	OTP_CACHE_KEY                   = "otp:%s"
+	OTP_COOLDOWN_CACHE_KEY          = "otp:cooldown:%s"
+	OTP_ATTEMPT_EMAIL_CACHE_KEY     = "otp:attempt:email:%s"
+	OTP_ATTEMPT_IP_CACHE_KEY        = "otp:attempt:ip:%s"
+	PASSWORD_RESET_CACHE_KEY        = "password_reset:%s"
+	PASSWORD_RESET_CLAIM_CACHE_KEY  = "password_reset:claim:%s"
+	MFA_CHALLENGE_CACHE_KEY         = "mfa:challenge:%s"
+	MFA_ATTEMPT_CACHE_KEY           = "mfa:attempt:%s"
+	MFA_SETUP_CACHE_KEY             = "mfa:setup:%d"
+	MFA_USED_STEP_CACHE_KEY         = "mfa:used:%d:%d"
+	SSO_STATE_CACHE_KEY             = "sso:state:%s"
+	SSO_TICKET_CACHE_KEY            = "sso:ticket:%s"
+	PERMISSION_VERSION_CACHE_KEY    = "user:permission_version:%d"
 )
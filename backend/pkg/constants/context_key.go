@@ -6,3 +6,8 @@ const TxContextKey ContextKey = "tx_db"
 const CompanyIDContextKey ContextKey = "company_id"
 const IsPlatformAdminContextKey ContextKey = "is_platform_admin"
 const UserIDContextKey ContextKey = "user_id"
+const RequestIDContextKey ContextKey = "request_id"
+const IPAddressContextKey ContextKey = "ip_address"
+const AfterCommitContextKey ContextKey = "after_commit"
+const EmployeeIDContextKey ContextKey = "employee_id"
+const DataScopeContextKey ContextKey = "data_scope"
@@ -0,0 +1,11 @@
+package constants
+
+// DataScope narrows which employees' records a role permission reaches.
+type DataScope string
+
+const (
+	DataScopeSelf         DataScope = "SELF"
+	DataScopeDepartment   DataScope = "DEPARTMENT"
+	DataScopeSubordinates DataScope = "SUBORDINATES"
+	DataScopeCompany      DataScope = "COMPANY"
+)
@@ -0,0 +1,29 @@
+package constants
+
+// EmploymentEventType is a change recorded in the employment history of an employee.
+type EmploymentEventType string
+
+const (
+	EmploymentEventHire          EmploymentEventType = "HIRE"
+	EmploymentEventProbationPass EmploymentEventType = "PROBATION_PASS"
+	EmploymentEventPromotion     EmploymentEventType = "PROMOTION"
+	EmploymentEventTransfer      EmploymentEventType = "TRANSFER"
+	EmploymentEventSalaryChange  EmploymentEventType = "SALARY_CHANGE"
+	EmploymentEventSuspension    EmploymentEventType = "SUSPENSION"
+	// EmploymentEventReinstatement ends a suspension, it is queued for the end date when the suspension applies
+	EmploymentEventReinstatement EmploymentEventType = "REINSTATEMENT"
+	EmploymentEventResignation   EmploymentEventType = "RESIGNATION"
+	EmploymentEventTermination   EmploymentEventType = "TERMINATION"
+	EmploymentEventRehire        EmploymentEventType = "REHIRE"
+)
+
+// EmploymentStatus follows from the employment events applied so far, employees are terminated instead of deleted.
+type EmploymentStatus string
+
+const (
+	EmploymentStatusActive     EmploymentStatus = "ACTIVE"
+	EmploymentStatusProbation  EmploymentStatus = "PROBATION"
+	EmploymentStatusNotice     EmploymentStatus = "NOTICE"
+	EmploymentStatusSuspended  EmploymentStatus = "SUSPENDED"
+	EmploymentStatusTerminated EmploymentStatus = "TERMINATED"
+)
@@ -0,0 +1,21 @@
+package constants
+
+type LeaveConflictRuleType string
+
+const (
+	// at most MaxEmployees people of the department off on the same day
+	LeaveConflictMaxEmployees LeaveConflictRuleType = "MAX_EMPLOYEES"
+	// at most MaxPercent of the department off on the same day
+	LeaveConflictMaxPercent LeaveConflictRuleType = "MAX_PERCENT"
+	// no leave between StartDate and EndDate, e.g. stock-take week
+	LeaveConflictBlackout LeaveConflictRuleType = "BLACKOUT"
+)
+
+type LeaveConflictEnforcement string
+
+const (
+	// Apply refuses the leave
+	LeaveConflictBlock LeaveConflictEnforcement = "BLOCK"
+	// the approver is warned and has to acknowledge the conflict to approve
+	LeaveConflictWarn LeaveConflictEnforcement = "WARN"
+)
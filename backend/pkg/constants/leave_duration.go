@@ -0,0 +1,15 @@
+package constants
+
+type LeaveDurationType string
+
+const (
+	LeaveDurationFullDay          LeaveDurationType = "FULL_DAY"
+	LeaveDurationHalfDayMorning   LeaveDurationType = "HALF_DAY_MORNING"
+	LeaveDurationHalfDayAfternoon LeaveDurationType = "HALF_DAY_AFTERNOON"
+	LeaveDurationHourly           LeaveDurationType = "HOURLY"
+)
+
+const (
+	// working hours counted as one leave day when converting hourly leave into quota
+	LeaveWorkHoursPerDay = 8.0
+)
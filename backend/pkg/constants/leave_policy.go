@@ -0,0 +1,24 @@
+package constants
+
+type LeaveAccrualType string
+
+const (
+	LeaveAccrualAnnual  LeaveAccrualType = "ANNUAL"
+	LeaveAccrualMonthly LeaveAccrualType = "MONTHLY"
+)
+
+type LeaveLedgerType string
+
+const (
+	LeaveLedgerAccrual   LeaveLedgerType = "ACCRUAL"
+	LeaveLedgerCarryOver LeaveLedgerType = "CARRY_OVER"
+	LeaveLedgerUsage     LeaveLedgerType = "USAGE"
+	LeaveLedgerExpiry    LeaveLedgerType = "EXPIRY"
+	LeaveLedgerRefund    LeaveLedgerType = "REFUND"
+	LeaveLedgerPayout    LeaveLedgerType = "PAYOUT"
+)
+
+const (
+	// months of continuous service before annual leave is granted (UU 13/2003 art. 79)
+	AnnualLeaveEligibilityMonths = 12
+)
@@ -3,7 +3,8 @@ package constants
 type LeaveStatus string
 
 const (
-	LeaveStatusPending  LeaveStatus = "PENDING"
-	LeaveStatusApproved LeaveStatus = "APPROVED"
-	LeaveStatusRejected LeaveStatus = "REJECTED"
+	LeaveStatusPending   LeaveStatus = "PENDING"
+	LeaveStatusApproved  LeaveStatus = "APPROVED"
+	LeaveStatusRejected  LeaveStatus = "REJECTED"
+	LeaveStatusCancelled LeaveStatus = "CANCELLED"
 )
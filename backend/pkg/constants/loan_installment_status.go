@@ -0,0 +1,14 @@
+package constants
+
+type LoanInstallmentStatus string
+
+const (
+	LoanInstallmentPending LoanInstallmentStatus = "PENDING"
+	LoanInstallmentPaid    LoanInstallmentStatus = "PAID"
+	// moved to the end of the schedule, replaced by a new installment
+	LoanInstallmentDeferred LoanInstallmentStatus = "DEFERRED"
+	// closed by an early payoff, the interest of the month is waived
+	LoanInstallmentSettled LoanInstallmentStatus = "SETTLED"
+	// closed without payment when the loan is written off
+	LoanInstallmentWrittenOff LoanInstallmentStatus = "WRITTEN_OFF"
+)
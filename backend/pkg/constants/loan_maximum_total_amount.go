@@ -1,3 +0,0 @@
-package constants
-
-const LoanMaximumTotalAmount float64 = 10000000
@@ -7,4 +7,6 @@ const (
 	LoanStatusApproved LoanStatus = "APPROVED"
 	LoanStatusRejected LoanStatus = "REJECTED"
 	LoanStatusPaidOff  LoanStatus = "PAID_OFF"
+	// closed with the remaining balance forgiven
+	LoanStatusWrittenOff LoanStatus = "WRITTEN_OFF"
 )
@@ -0,0 +1,14 @@
+package constants
+
+type LoanTransactionType string
+
+const (
+	// raises the balance by the principal plus interest the employee owes
+	LoanTransactionDisbursement LoanTransactionType = "DISBURSEMENT"
+	// deducted by payroll, linked to the payroll detail
+	LoanTransactionInstallment LoanTransactionType = "INSTALLMENT"
+	// paid outside payroll
+	LoanTransactionRepayment LoanTransactionType = "REPAYMENT"
+	// forgiven by the company, e.g. interest waived on an early payoff
+	LoanTransactionWriteOff LoanTransactionType = "WRITE_OFF"
+)
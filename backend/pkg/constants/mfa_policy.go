@@ -0,0 +1,30 @@
+package constants
+
+import "time"
+
+const (
+	TOTPIssuer     = "Basekarya"
+	TOTPSecretSize = 20
+	TOTPDigits     = 6
+	TOTPPeriod     = 30 * time.Second
+	// codes from one step before or after the current one are accepted for clock drift
+	TOTPSkew = 1
+)
+
+const (
+	// a login that passed the password waits this long for the second step
+	MFAChallengeTTL = 5 * time.Minute
+	// an enrolment secret must be confirmed with a code before it expires
+	MFASetupTTL = 10 * time.Minute
+	// wrong codes on one challenge before it is dropped and the login starts over
+	MFAMaxAttempts = 5
+
+	MFARecoveryCodeCount  = 10
+	MFARecoveryCodeLength = 10
+)
+
+// DefaultMFAPermissions force MFA on new companies for the roles that can pay out money.
+var DefaultMFAPermissions = []string{
+	GENERATE_PAYROLL,
+	MARK_AS_PAID,
+}
@@ -18,4 +18,8 @@ const (
 	NotificationTypeOnboardingTask         NotificationType = "ONBOARDING_TASK"
 	NotificationTypeFinanceApprovalReq     NotificationType = "FINANCE_APPROVAL_REQ"
 	NotificationTypeAssetApprovalReq       NotificationType = "ASSET_APPROVAL_REQ"
+	NotificationTypeLeaveCancelled         NotificationType = "LEAVE_CANCELLED"
+	NotificationTypeApprovalEscalated      NotificationType = "APPROVAL_ESCALATED"
+	NotificationTypeReimbursePaid          NotificationType = "REIMBURSE_PAID"
+	NotificationTypeOvertimeAssigned       NotificationType = "OVERTIME_ASSIGNED"
 )
@@ -3,8 +3,15 @@ package constants
 type OvertimeStatus string
 
 const (
+	OvertimeStatusAssigned OvertimeStatus = "ASSIGNED"
 	OvertimeStatusPending  OvertimeStatus = "PENDING"
 	OvertimeStatusApproved OvertimeStatus = "APPROVED"
 	OvertimeStatusRejected OvertimeStatus = "REJECTED"
 	OvertimeStatusPaid     OvertimeStatus = "PAID"
 )
+
+// statutory overtime limits of PP 35/2021
+const (
+	OvertimeMaxMinutesPerDay  = 4 * 60
+	OvertimeMaxMinutesPerWeek = 18 * 60
+)
@@ -0,0 +1,21 @@
+package constants
+
+import "time"
+
+const (
+	PasswordMinLength = 8
+	// a new password may not match the current one or any of this many before it
+	PasswordHistoryCount = 5
+)
+
+const (
+	OTPLength = 6
+	OTPTTL    = 5 * time.Minute
+	// a new OTP can be requested for the same email once the cooldown has passed
+	OTPResendCooldown = time.Minute
+	// wrong codes per email or per IP before verification is locked for the lockout duration
+	OTPMaxAttempts     = 5
+	OTPLockoutDuration = 15 * time.Minute
+	// a verified OTP is exchanged for a reset token, ResetPassword consumes it once
+	PasswordResetTokenTTL = 10 * time.Minute
+)
@@ -14,17 +14,19 @@ const (
 	MANAGE_MASTER = "MANAGE_MASTER"
 
 	// employee
-	VIEW_EMPLOYEE   = "VIEW_EMPLOYEE"
-	CREATE_EMPLOYEE = "CREATE_EMPLOYEE"
-	UPDATE_EMPLOYEE = "UPDATE_EMPLOYEE"
-	DELETE_EMPLOYEE = "DELETE_EMPLOYEE"
-	EXPORT_EMPLOYEE = "EXPORT_EMPLOYEE"
+	VIEW_EMPLOYEE           = "VIEW_EMPLOYEE"
+	CREATE_EMPLOYEE         = "CREATE_EMPLOYEE"
+	UPDATE_EMPLOYEE         = "UPDATE_EMPLOYEE"
+	DELETE_EMPLOYEE         = "DELETE_EMPLOYEE"
+	EXPORT_EMPLOYEE         = "EXPORT_EMPLOYEE"
+	VIEW_SENSITIVE_EMPLOYEE = "VIEW_SENSITIVE_EMPLOYEE"
 
 	// attendance
 	VIEW_ATTENDANCE      = "VIEW_ATTENDANCE"
 	VIEW_SELF_ATTENDANCE = "VIEW_SELF_ATTENDANCE"
 	CREATE_ATTENDANCE    = "CREATE_ATTENDANCE"
 	EXPORT_ATTENDANCE    = "EXPORT_ATTENDANCE"
+	REVIEW_ATTENDANCE    = "REVIEW_ATTENDANCE"
 
 	// payroll
 	VIEW_PAYROLL     = "VIEW_PAYROLL"
@@ -33,19 +35,24 @@ const (
 	MARK_AS_PAID     = "MARK_AS_PAID"
 	SEND_PAYSLIP     = "SEND_PAYSLIP"
 
+	// offboarding
+	MANAGE_OFFBOARDING = "MANAGE_OFFBOARDING"
+
 	// leave
-	VIEW_LEAVE      = "VIEW_LEAVE"
-	VIEW_SELF_LEAVE = "VIEW_SELF_LEAVE"
-	CREATE_LEAVE    = "CREATE_LEAVE"
-	APPROVAL_LEAVE  = "APPROVAL_LEAVE"
-	EXPORT_LEAVE    = "EXPORT_LEAVE"
+	VIEW_LEAVE          = "VIEW_LEAVE"
+	VIEW_SELF_LEAVE     = "VIEW_SELF_LEAVE"
+	CREATE_LEAVE        = "CREATE_LEAVE"
+	APPROVAL_LEAVE      = "APPROVAL_LEAVE"
+	EXPORT_LEAVE        = "EXPORT_LEAVE"
+	MANAGE_LEAVE_POLICY = "MANAGE_LEAVE_POLICY"
 
 	// loan
-	VIEW_LOAN      = "VIEW_LOAN"
-	VIEW_SELF_LOAN = "VIEW_SELF_LOAN"
-	CREATE_LOAN    = "CREATE_LOAN"
-	APPROVAL_LOAN  = "APPROVAL_LOAN"
-	EXPORT_LOAN    = "EXPORT_LOAN"
+	VIEW_LOAN           = "VIEW_LOAN"
+	VIEW_SELF_LOAN      = "VIEW_SELF_LOAN"
+	CREATE_LOAN         = "CREATE_LOAN"
+	APPROVAL_LOAN       = "APPROVAL_LOAN"
+	EXPORT_LOAN         = "EXPORT_LOAN"
+	MANAGE_LOAN_PRODUCT = "MANAGE_LOAN_PRODUCT"
 
 	// overtime
 	VIEW_OVERTIME      = "VIEW_OVERTIME"
@@ -53,13 +60,16 @@ const (
 	CREATE_OVERTIME    = "CREATE_OVERTIME"
 	APPROVAL_OVERTIME  = "APPROVAL_OVERTIME"
 	EXPORT_OVERTIME    = "EXPORT_OVERTIME"
+	ASSIGN_OVERTIME    = "ASSIGN_OVERTIME"
 
 	// reimbursement
-	VIEW_REIMBURSEMENT      = "VIEW_REIMBURSEMENT"
-	VIEW_SELF_REIMBURSEMENT = "VIEW_SELF_REIMBURSEMENT"
-	CREATE_REIMBURSEMENT    = "CREATE_REIMBURSEMENT"
-	APPROVAL_REIMBURSEMENT  = "APPROVAL_REIMBURSEMENT"
-	EXPORT_REIMBURSEMENT    = "EXPORT_REIMBURSEMENT"
+	VIEW_REIMBURSEMENT            = "VIEW_REIMBURSEMENT"
+	VIEW_SELF_REIMBURSEMENT       = "VIEW_SELF_REIMBURSEMENT"
+	CREATE_REIMBURSEMENT          = "CREATE_REIMBURSEMENT"
+	APPROVAL_REIMBURSEMENT        = "APPROVAL_REIMBURSEMENT"
+	EXPORT_REIMBURSEMENT          = "EXPORT_REIMBURSEMENT"
+	MANAGE_REIMBURSEMENT_CATEGORY = "MANAGE_REIMBURSEMENT_CATEGORY"
+	PAYOUT_REIMBURSEMENT          = "PAYOUT_REIMBURSEMENT"
 
 	// company
 	VIEW_COMPANY   = "VIEW_COMPANY"
@@ -111,4 +121,11 @@ const (
 	// tax
 	VIEW_TAX_CONFIG   = "VIEW_TAX_CONFIG"
 	MANAGE_TAX_CONFIG = "MANAGE_TAX_CONFIG"
+
+	// approval
+	MANAGE_APPROVAL_CHAIN = "MANAGE_APPROVAL_CHAIN"
+
+	// audit
+	VIEW_AUDIT_LOG   = "VIEW_AUDIT_LOG"
+	EXPORT_AUDIT_LOG = "EXPORT_AUDIT_LOG"
 )
@@ -29,4 +29,5 @@ var AlwaysAvailableGroups = []string{
 	"Employee",
 	"Company",
 	"Announcement",
+	"Audit",
 }
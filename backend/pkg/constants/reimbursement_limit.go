@@ -0,0 +1,9 @@
+package constants
+
+// ReimbursementLimitAction is what happens to a claim that breaches a cap of its category.
+type ReimbursementLimitAction string
+
+const (
+	ReimbursementLimitReject ReimbursementLimitAction = "REJECT"
+	ReimbursementLimitWarn   ReimbursementLimitAction = "WARN"
+)
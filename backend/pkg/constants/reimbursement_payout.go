@@ -0,0 +1,28 @@
+package constants
+
+// ReimbursementPayoutChannel is how an approved claim is paid back.
+type ReimbursementPayoutChannel string
+
+const (
+	// added to the next payroll generated for the employee
+	ReimbursementPayoutPayroll ReimbursementPayoutChannel = "PAYROLL"
+	// paid by bank transfer in a payout batch, outside payroll
+	ReimbursementPayoutTransfer ReimbursementPayoutChannel = "TRANSFER"
+)
+
+type ReimbursementPayoutStatus string
+
+const (
+	ReimbursementPayoutUnpaid ReimbursementPayoutStatus = "UNPAID"
+	// carried by a draft payroll or payout batch, not paid yet
+	ReimbursementPayoutQueued ReimbursementPayoutStatus = "QUEUED"
+	ReimbursementPayoutPaid   ReimbursementPayoutStatus = "PAID"
+)
+
+type ReimbursementPayoutBatchStatus string
+
+const (
+	ReimbursementPayoutBatchDraft     ReimbursementPayoutBatchStatus = "DRAFT"
+	ReimbursementPayoutBatchPaid      ReimbursementPayoutBatchStatus = "PAID"
+	ReimbursementPayoutBatchCancelled ReimbursementPayoutBatchStatus = "CANCELLED"
+)
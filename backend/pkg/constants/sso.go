@@ -0,0 +1,26 @@
+package constants
+
+import "time"
+
+// SSOProtocol is how a company's identity provider signs its users in.
+type SSOProtocol string
+
+const (
+	SSOProtocolOIDC SSOProtocol = "OIDC"
+	SSOProtocolSAML SSOProtocol = "SAML"
+)
+
+const (
+	// a login redirected to the identity provider must come back before its state expires
+	SSOStateTTL = 10 * time.Minute
+	// the ticket handed to the frontend after the callback is exchanged for tokens right away
+	SSOTicketTTL = time.Minute
+	// tolerated clock difference when checking the validity window of an ID token or SAML assertion
+	SSOClockSkew = 2 * time.Minute
+)
+
+const (
+	// a company proves it controls its SSO domain with a TXT record named SSODomainVerificationHost + domain
+	SSODomainVerificationHost   = "_basekarya-verification."
+	SSODomainVerificationPrefix = "basekarya-domain-verification="
+)
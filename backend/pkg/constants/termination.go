@@ -0,0 +1,23 @@
+package constants
+
+// TerminationReason is why the employment ends, it sets the severance owed under PP 35/2021.
+type TerminationReason string
+
+const (
+	TerminationResignation    TerminationReason = "RESIGNATION"
+	TerminationAbsence        TerminationReason = "ABSENCE"
+	TerminationMisconduct     TerminationReason = "MISCONDUCT"
+	TerminationEfficiency     TerminationReason = "EFFICIENCY"
+	TerminationEfficiencyLoss TerminationReason = "EFFICIENCY_LOSS"
+	TerminationLongIllness    TerminationReason = "LONG_ILLNESS"
+	TerminationRetirement     TerminationReason = "RETIREMENT"
+	TerminationDeath          TerminationReason = "DEATH"
+)
+
+type SettlementStatus string
+
+const (
+	SettlementStatusDraft     SettlementStatus = "DRAFT"
+	SettlementStatusFinalized SettlementStatus = "FINALIZED"
+	SettlementStatusCancelled SettlementStatus = "CANCELLED"
+)
@@ -18,11 +18,14 @@ func GetUserContext(ctx echo.Context) (*infrastructure.MyClaims, error) {
 	return nil, errors.New("failed to get user from context")
 }
 
+// GetDBFromContext returns the transaction carried by ctx, or defaultDB bound to ctx
+// so callbacks can read the actor and request values. Cancellation is not passed on,
+// writes are not cut short when the client goes away.
 func GetDBFromContext(ctx context.Context, defaultDB *gorm.DB) *gorm.DB {
 	if tx, ok := ctx.Value(constants.TxContextKey).(*gorm.DB); ok {
 		return tx
 	}
-	return defaultDB
+	return defaultDB.WithContext(context.WithoutCancel(ctx))
 }
 
 func GetCompanyIDFromCtx(ctx context.Context) uint {
@@ -39,6 +42,20 @@ func GetUserIDFromCtx(ctx context.Context) uint {
 	return 0
 }
 
+func GetRequestIDFromCtx(ctx context.Context) string {
+	if v, ok := ctx.Value(constants.RequestIDContextKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+func GetIPAddressFromCtx(ctx context.Context) string {
+	if v, ok := ctx.Value(constants.IPAddressContextKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
 func IsPlatformAdminFromCtx(ctx context.Context) bool {
 	if v, ok := ctx.Value(constants.IsPlatformAdminContextKey).(bool); ok {
 		return v
@@ -46,6 +63,22 @@ func IsPlatformAdminFromCtx(ctx context.Context) bool {
 	return false
 }
 
+func GetEmployeeIDFromCtx(ctx context.Context) uint {
+	if v, ok := ctx.Value(constants.EmployeeIDContextKey).(uint); ok {
+		return v
+	}
+	return 0
+}
+
+// GetDataScopeFromCtx returns the data scope of the permission the request was granted with,
+// requests outside a permission check see the whole company.
+func GetDataScopeFromCtx(ctx context.Context) constants.DataScope {
+	if v, ok := ctx.Value(constants.DataScopeContextKey).(constants.DataScope); ok {
+		return v
+	}
+	return constants.DataScopeCompany
+}
+
 func TenantScope(ctx context.Context, db *gorm.DB) *gorm.DB {
 	if IsPlatformAdminFromCtx(ctx) {
 		return db
@@ -68,6 +101,45 @@ func TenantScope(ctx context.Context, db *gorm.DB) *gorm.DB {
 	return db.Where("company_id = ?", companyID)
 }
 
+// DataScope narrows db to the records whose employeeColumn falls inside the caller's data scope.
+func DataScope(ctx context.Context, db *gorm.DB, employeeColumn string) *gorm.DB {
+	return scopeEmployees(ctx, db, employeeColumn, "id")
+}
+
+// UserDataScope is DataScope for tables that point at the user instead of the employee.
+func UserDataScope(ctx context.Context, db *gorm.DB, userColumn string) *gorm.DB {
+	return scopeEmployees(ctx, db, userColumn, "user_id")
+}
+
+func scopeEmployees(ctx context.Context, db *gorm.DB, column, employeeKey string) *gorm.DB {
+	scope := GetDataScopeFromCtx(ctx)
+	if IsPlatformAdminFromCtx(ctx) || scope == constants.DataScopeCompany {
+		return db
+	}
+
+	employeeID := GetEmployeeIDFromCtx(ctx)
+	if employeeID == 0 {
+		// a narrowed scope is anchored on the caller's employee record, without one nothing is in scope
+		return db.Where("1 = 0")
+	}
+
+	var condition string
+	switch scope {
+	case constants.DataScopeSelf:
+		condition = "id = ?"
+	case constants.DataScopeDepartment:
+		condition = "department_id = (SELECT department_id FROM employees WHERE id = ?)"
+	case constants.DataScopeSubordinates:
+		// the caller and everyone reporting to them, directly or down the line
+		condition = "id IN (WITH RECURSIVE team (id) AS (SELECT id FROM employees WHERE id = ? " +
+			"UNION SELECT employees.id FROM employees JOIN team ON employees.manager_id = team.id) SELECT id FROM team)"
+	default:
+		return db.Where("1 = 0")
+	}
+
+	return db.Where(column+" IN (SELECT "+employeeKey+" FROM employees WHERE "+condition+")", employeeID)
+}
+
 // DetachContext returns a new context with tenant values preserved but
 // without any transaction reference. Use this when spawning goroutines
 // that need to perform DB operations independently of the parent transaction.
@@ -76,5 +148,7 @@ func DetachContext(ctx context.Context) context.Context {
 	detached = context.WithValue(detached, constants.CompanyIDContextKey, GetCompanyIDFromCtx(ctx))
 	detached = context.WithValue(detached, constants.IsPlatformAdminContextKey, IsPlatformAdminFromCtx(ctx))
 	detached = context.WithValue(detached, constants.UserIDContextKey, GetUserIDFromCtx(ctx))
+	detached = context.WithValue(detached, constants.RequestIDContextKey, GetRequestIDFromCtx(ctx))
+	detached = context.WithValue(detached, constants.IPAddressContextKey, GetIPAddressFromCtx(ctx))
 	return detached
 }
@@ -61,12 +61,41 @@ func TestGetDBFromContext_WithTx(t *testing.T) {
 
 func TestGetDBFromContext_DefaultDB(t *testing.T) {
 	defaultDB, _ := gorm.Open(nil, &gorm.Config{})
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), constants.UserIDContextKey, uint(7)))
+	cancel()
 
 	result := GetDBFromContext(ctx, defaultDB)
-	if result != defaultDB {
+	if result.Statement.ConnPool != defaultDB.Statement.ConnPool {
 		t.Error("expected default DB")
 	}
+	if got := GetUserIDFromCtx(result.Statement.Context); got != 7 {
+		t.Errorf("expected statement context to carry UserID 7, got %d", got)
+	}
+	if result.Statement.Context.Err() != nil {
+		t.Error("expected request cancellation not to reach the statement")
+	}
+}
+
+func TestGetRequestIDFromCtx(t *testing.T) {
+	ctx := context.WithValue(context.Background(), constants.RequestIDContextKey, "req-1")
+
+	if got := GetRequestIDFromCtx(ctx); got != "req-1" {
+		t.Errorf("expected req-1, got %q", got)
+	}
+	if got := GetRequestIDFromCtx(context.Background()); got != "" {
+		t.Errorf("expected empty, got %q", got)
+	}
+}
+
+func TestGetIPAddressFromCtx(t *testing.T) {
+	ctx := context.WithValue(context.Background(), constants.IPAddressContextKey, "10.0.0.1")
+
+	if got := GetIPAddressFromCtx(ctx); got != "10.0.0.1" {
+		t.Errorf("expected 10.0.0.1, got %q", got)
+	}
+	if got := GetIPAddressFromCtx(context.Background()); got != "" {
+		t.Errorf("expected empty, got %q", got)
+	}
 }
 
 func TestGetCompanyIDFromCtx_Set(t *testing.T) {
@@ -116,6 +145,7 @@ func TestDetachContext(t *testing.T) {
 	origCtx = context.WithValue(origCtx, constants.CompanyIDContextKey, uint(5))
 	origCtx = context.WithValue(origCtx, constants.UserIDContextKey, uint(10))
 	origCtx = context.WithValue(origCtx, constants.IsPlatformAdminContextKey, true)
+	origCtx = context.WithValue(origCtx, constants.RequestIDContextKey, "req-1")
 
 	detached := DetachContext(origCtx)
 
@@ -128,6 +158,9 @@ func TestDetachContext(t *testing.T) {
 	if got := IsPlatformAdminFromCtx(detached); !got {
 		t.Errorf("expected IsPlatformAdmin true, got %v", got)
 	}
+	if got := GetRequestIDFromCtx(detached); got != "req-1" {
+		t.Errorf("expected RequestID req-1, got %q", got)
+	}
 
 	if detached.Value(constants.TxContextKey) != nil {
 		t.Error("expected tx reference to be removed")
@@ -184,6 +217,91 @@ func TestTenantScope_RegularUser(t *testing.T) {
 	}
 }
 
+type scopeTestEmployee struct {
+	ID           uint
+	UserID       uint
+	DepartmentID uint
+	ManagerID    *uint
+}
+
+func (scopeTestEmployee) TableName() string { return "employees" }
+
+type scopeTestRecord struct {
+	ID         uint
+	EmployeeID uint
+	UserID     uint
+}
+
+func (scopeTestRecord) TableName() string { return "scope_records" }
+
+func TestDataScope(t *testing.T) {
+	db := newTestDB(&scopeTestEmployee{}, &scopeTestRecord{})
+	head, lead := uint(1), uint(2)
+	// 1 heads department 10, 2 reports to 1, 3 reports to 2, 4 shares department 10 outside the line, 5 is elsewhere
+	db.Create(&[]scopeTestEmployee{
+		{ID: 1, UserID: 11, DepartmentID: 10},
+		{ID: 2, UserID: 12, DepartmentID: 10, ManagerID: &head},
+		{ID: 3, UserID: 13, DepartmentID: 20, ManagerID: &lead},
+		{ID: 4, UserID: 14, DepartmentID: 10},
+		{ID: 5, UserID: 15, DepartmentID: 20},
+	})
+	db.Create(&[]scopeTestRecord{
+		{ID: 1, EmployeeID: 1, UserID: 11},
+		{ID: 2, EmployeeID: 2, UserID: 12},
+		{ID: 3, EmployeeID: 3, UserID: 13},
+		{ID: 4, EmployeeID: 4, UserID: 14},
+		{ID: 5, EmployeeID: 5, UserID: 15},
+	})
+
+	tests := []struct {
+		name       string
+		scope      constants.DataScope
+		employeeID uint
+		admin      bool
+		expected   []uint
+	}{
+		{"company", constants.DataScopeCompany, 1, false, []uint{1, 2, 3, 4, 5}},
+		{"self", constants.DataScopeSelf, 2, false, []uint{2}},
+		{"department", constants.DataScopeDepartment, 1, false, []uint{1, 2, 4}},
+		{"subordinates down the line", constants.DataScopeSubordinates, 1, false, []uint{1, 2, 3}},
+		{"no employee record", constants.DataScopeSelf, 0, false, nil},
+		{"platform admin", constants.DataScopeSelf, 0, true, []uint{1, 2, 3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), constants.DataScopeContextKey, tt.scope)
+			ctx = context.WithValue(ctx, constants.EmployeeIDContextKey, tt.employeeID)
+			ctx = context.WithValue(ctx, constants.IsPlatformAdminContextKey, tt.admin)
+
+			for column, scoped := range map[string]*gorm.DB{
+				"employee_id": DataScope(ctx, db.Model(&scopeTestRecord{}), "scope_records.employee_id"),
+				"user_id":     UserDataScope(ctx, db.Model(&scopeTestRecord{}), "scope_records.user_id"),
+			} {
+				var ids []uint
+				if err := scoped.Order("id").Pluck("id", &ids).Error; err != nil {
+					t.Fatalf("%s: unexpected error: %v", column, err)
+				}
+				if len(ids) != len(tt.expected) {
+					t.Fatalf("%s: expected %v, got %v", column, tt.expected, ids)
+				}
+				for i := range ids {
+					if ids[i] != tt.expected[i] {
+						t.Errorf("%s: expected %v, got %v", column, tt.expected, ids)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGetDataScopeFromCtx_NotSet(t *testing.T) {
+	if got := GetDataScopeFromCtx(context.Background()); got != constants.DataScopeCompany {
+		t.Errorf("expected COMPANY, got %q", got)
+	}
+}
+
 func containsSubstring(s, sub string) bool {
 	for i := 0; i <= len(s)-len(sub); i++ {
 		if s[i:i+len(sub)] == sub {
@@ -1,6 +1,9 @@
 package utils
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 func FormatNumber(n float64) string {
 	s := fmt.Sprintf("%.0f", n)
@@ -13,3 +16,11 @@ func FormatNumber(n float64) string {
 	}
 	return nStr
 }
+
+// MaskString hides all but the last visible characters of value, e.g. "************3456".
+func MaskString(value string, visible int) string {
+	if len(value) <= visible {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", len(value)-visible) + value[len(value)-visible:]
+}
@@ -31,3 +31,17 @@ func TestFormatNumber_Thousands(t *testing.T) {
 		t.Errorf("expected '1.234', got '%s'", result)
 	}
 }
+
+func TestMaskString(t *testing.T) {
+	tests := map[string]string{
+		"3201123456780001": "************0001",
+		"1234":             "****",
+		"12":               "**",
+		"":                 "",
+	}
+	for value, expected := range tests {
+		if result := MaskString(value, 4); result != expected {
+			t.Errorf("MaskString(%q): expected '%s', got '%s'", value, expected, result)
+		}
+	}
+}
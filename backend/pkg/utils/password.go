@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"basekarya-backend/pkg/constants"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+//go:embed breached_passwords.txt
+var breachedPasswordList string
+
+var breachedPasswords = loadBreachedPasswords(breachedPasswordList)
+
+func loadBreachedPasswords(list string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
+
+// CheckPasswordPolicy validates a new password: a minimum length, letters and digits, and not a known breached
+// password. Reuse of previous passwords is checked by the user module against the stored hashes.
+func CheckPasswordPolicy(password string) error {
+	if len(password) < constants.PasswordMinLength {
+		return fmt.Errorf("password must be at least %d characters", constants.PasswordMinLength)
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("password must contain both letters and digits")
+	}
+
+	if _, found := breachedPasswords[strings.ToLower(password)]; found {
+		return errors.New("password is too common, choose another one")
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package utils
+
+import "testing"
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  string
+	}{
+		{name: "valid", password: "Karya2026pass"},
+		{name: "too short", password: "ab12", wantErr: "password must be at least 8 characters"},
+		{name: "no digits", password: "onlyletters", wantErr: "password must contain both letters and digits"},
+		{name: "no letters", password: "1234567890", wantErr: "password must contain both letters and digits"},
+		{name: "breached", password: "Password123", wantErr: "password is too common, choose another one"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPasswordPolicy(tt.password)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("expected %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadBreachedPasswords_SkipsComments(t *testing.T) {
+	passwords := loadBreachedPasswords("# comment\n\nSecret99\n")
+	if len(passwords) != 1 {
+		t.Fatalf("expected 1 password, got %d", len(passwords))
+	}
+	if _, ok := passwords["secret99"]; !ok {
+		t.Errorf("expected lowercased entry")
+	}
+}
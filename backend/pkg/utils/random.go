@@ -1,12 +1,30 @@
 package utils
 
-import "math/rand"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+)
 
+// GenerateRandomNumber returns a string of random digits, it reads crypto/rand since the digits are used as OTPs.
 func GenerateRandomNumber(length int) string {
 	const charset = "0123456789"
 	result := make([]byte, length)
 	for i := range result {
-		result[i] = charset[rand.Intn(len(charset))]
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			panic(err)
+		}
+		result[i] = charset[n.Int64()]
 	}
 	return string(result)
 }
+
+// GenerateSecureToken returns a random hex token of the given number of bytes.
+func GenerateSecureToken(size int) string {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -29,3 +29,13 @@ func TestGenerateRandomNumber_OnlyDigits(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateSecureToken(t *testing.T) {
+	first := GenerateSecureToken(32)
+	if len(first) != 64 {
+		t.Errorf("expected length 64, got %d", len(first))
+	}
+	if first == GenerateSecureToken(32) {
+		t.Errorf("expected different tokens")
+	}
+}
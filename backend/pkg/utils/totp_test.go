@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B, SHA1 seed, the last 6 digits of the 8 digit reference codes
+func TestGenerateTOTPCode_RFCVectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, c := range cases {
+		got, err := GenerateTOTPCode(secret, time.Unix(c.unix, 0))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != c.want {
+			t.Errorf("at %d: expected %s, got %s", c.unix, c.want, got)
+		}
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	secret := GenerateTOTPSecret()
+	now := time.Unix(1700000000, 0)
+
+	previous, _ := GenerateTOTPCode(secret, now.Add(-30*time.Second))
+	if step, ok := ValidateTOTPCode(secret, previous, now); !ok || step != now.Unix()/30-1 {
+		t.Errorf("expected the previous step to be accepted, got step %d ok %v", step, ok)
+	}
+
+	stale, _ := GenerateTOTPCode(secret, now.Add(-90*time.Second))
+	if _, ok := ValidateTOTPCode(secret, stale, now); ok {
+		t.Errorf("expected a code three steps old to be refused")
+	}
+
+	if _, ok := ValidateTOTPCode("not base32!", "000000", now); ok {
+		t.Errorf("expected an invalid secret to be refused")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Basekarya", "admin@company.com", "JBSWY3DPEHPK3PXP")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/Basekarya:admin@company.com?") {
+		t.Errorf("unexpected label in %s", uri)
+	}
+	for _, part := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=Basekarya", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("expected %s in %s", part, uri)
+		}
+	}
+}
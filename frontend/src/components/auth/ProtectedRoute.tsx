@@ -14,9 +14,9 @@ export const ProtectedRoute = ({
   requiredPermissions,
 }: ProtectedRouteProps) => {
   const { data: user, isLoading } = useProfile();
-  const { hasAnyPermission } = usePermissions();
+  const { hasAnyPermission, isLoading: isLoadingPermissions } = usePermissions();
 
-  if (isLoading) {
+  if (isLoading || isLoadingPermissions) {
     return (
       <div className="h-screen w-full flex items-center justify-center bg-slate-50">
         <div className="flex flex-col items-center gap-2">
@@ -21,7 +21,8 @@ import { toast } from "sonner";
 import { useCallback, useEffect } from "react";
 import { useNavigate } from "react-router-dom";
 import { jwtDecode } from "jwt-decode";
-import type { DecodedToken } from "../types";
+import { api } from "@/lib/axios";
+import type { DecodedToken, UserPermissions } from "../types";
 
 const formSchema = z.object({
   username: z.string().min(1, {
@@ -39,13 +40,18 @@ export function LoginForm() {
   const navigate = useNavigate();
 
   const handleRedirect = useCallback(
-    (token: string) => {
+    async (token: string) => {
       try {
         const decoded = jwtDecode<DecodedToken>(token);
 
         if (decoded.company_id === 0) {
           navigate("/admin/platform-dashboard", { replace: true });
-        } else if (decoded.permissions?.includes("EXPORT_ATTENDANCE")) {
+          return;
+        }
+
+        // the token only names the role, its permissions come from the server
+        const { data } = await api.get<{ data: UserPermissions }>("/auth/permissions");
+        if (data.data.permissions.includes("EXPORT_ATTENDANCE")) {
           navigate("/admin/recap", { replace: true });
         } else {
           navigate("/dashboard", { replace: true });
@@ -14,12 +14,20 @@ export interface LoginResponse {
 export interface DecodedToken {
   user_id: number;
   role: string;
-  permissions: string[];
+  role_id: number;
+  permission_version: number;
   company_id: number;
   is_platform_admin: boolean;
   exp: number;
 }
 
+export interface UserPermissions {
+  permissions: string[];
+  data_scopes: Record<string, "SELF" | "DEPARTMENT" | "SUBORDINATES" | "COMPANY">;
+  is_platform_admin: boolean;
+  company_id: number;
+}
+
 export interface ForgotPasswordPayload {
   email: string;
 }
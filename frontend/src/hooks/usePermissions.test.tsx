@@ -1,201 +1,141 @@
-import { describe, it, expect, beforeEach } from "vitest";
-import { renderHook } from "@testing-library/react";
+import { describe, it, expect, beforeEach, vi } from "vitest";
+import { renderHook, waitFor } from "@testing-library/react";
+import { createQueryWrapper } from "@/test/utils";
 import { usePermissions } from "@/hooks/usePermissions";
 
-function createMockToken(payload: Record<string, unknown>): string {
-  const header = btoa(JSON.stringify({ alg: "HS256", typ: "JWT" }));
-  const body = btoa(JSON.stringify(payload));
-  const signature = btoa("mock-signature");
-  return `${header}.${body}.${signature}`;
+vi.mock("@/lib/axios", () => ({
+  api: { get: vi.fn() },
+}));
+
+import { api } from "@/lib/axios";
+
+function mockPermissions(payload: {
+  permissions: string[];
+  company_id: number;
+  is_platform_admin: boolean;
+}) {
+  localStorage.setItem("token", "mock-token");
+  (api.get as ReturnType<typeof vi.fn>).mockResolvedValue({
+    data: { data: { data_scopes: {}, ...payload } },
+  });
+}
+
+async function renderPermissions() {
+  const { wrapper } = createQueryWrapper();
+  const hook = renderHook(() => usePermissions(), { wrapper });
+  await waitFor(() => expect(hook.result.current.isLoading).toBe(false));
+  return hook;
 }
 
 describe("usePermissions", () => {
   beforeEach(() => {
     localStorage.clear();
+    vi.clearAllMocks();
   });
 
-  it("should return empty permissions when no token", () => {
-    const { result } = renderHook(() => usePermissions());
+  it("should return empty permissions when no token", async () => {
+    const { result } = await renderPermissions();
     expect(result.current.permissions).toEqual([]);
     expect(result.current.isPlatformAdmin).toBe(false);
     expect(result.current.companyId).toBe(0);
+    expect(api.get).not.toHaveBeenCalled();
   });
 
-  it("should decode permissions from valid token", () => {
-    const token = createMockToken({
-      user_id: 1,
-      role: "admin",
-      permissions: ["VIEW_EMPLOYEE", "CREATE_EMPLOYEE"],
-      company_id: 5,
-      is_platform_admin: false,
-      exp: Date.now() / 1000 + 3600,
-    });
-    localStorage.setItem("token", token);
+  it("should load permissions from the server", async () => {
+    mockPermissions({ permissions: ["VIEW_EMPLOYEE", "CREATE_EMPLOYEE"], company_id: 5, is_platform_admin: false });
 
-    const { result } = renderHook(() => usePermissions());
+    const { result } = await renderPermissions();
+    expect(api.get).toHaveBeenCalledWith("/auth/permissions");
     expect(result.current.permissions).toEqual(["VIEW_EMPLOYEE", "CREATE_EMPLOYEE"]);
     expect(result.current.isPlatformAdmin).toBe(false);
     expect(result.current.companyId).toBe(5);
   });
 
-  it("should detect platform admin", () => {
-    const token = createMockToken({
-      user_id: 1,
-      role: "superadmin",
-      permissions: [],
-      company_id: 0,
-      is_platform_admin: true,
-      exp: Date.now() / 1000 + 3600,
-    });
-    localStorage.setItem("token", token);
+  it("should detect platform admin", async () => {
+    mockPermissions({ permissions: [], company_id: 0, is_platform_admin: true });
 
-    const { result } = renderHook(() => usePermissions());
+    const { result } = await renderPermissions();
     expect(result.current.isPlatformAdmin).toBe(true);
   });
 
   describe("hasPermission", () => {
-    it("should return true for platform admin regardless of permission", () => {
-      const token = createMockToken({
-        user_id: 1,
-        role: "superadmin",
-        permissions: [],
-        company_id: 0,
-        is_platform_admin: true,
-        exp: Date.now() / 1000 + 3600,
-      });
-      localStorage.setItem("token", token);
-
-      const { result } = renderHook(() => usePermissions());
+    it("should return true for platform admin regardless of permission", async () => {
+      mockPermissions({ permissions: [], company_id: 0, is_platform_admin: true });
+
+      const { result } = await renderPermissions();
       expect(result.current.hasPermission("VIEW_EMPLOYEE")).toBe(true);
     });
 
-    it("should return true when user has the permission", () => {
-      const token = createMockToken({
-        user_id: 1,
-        role: "admin",
-        permissions: ["VIEW_EMPLOYEE"],
-        company_id: 1,
-        is_platform_admin: false,
-        exp: Date.now() / 1000 + 3600,
-      });
-      localStorage.setItem("token", token);
-
-      const { result } = renderHook(() => usePermissions());
+    it("should return true when user has the permission", async () => {
+      mockPermissions({ permissions: ["VIEW_EMPLOYEE"], company_id: 1, is_platform_admin: false });
+
+      const { result } = await renderPermissions();
       expect(result.current.hasPermission("VIEW_EMPLOYEE")).toBe(true);
     });
 
-    it("should return false when user lacks the permission", () => {
-      const token = createMockToken({
-        user_id: 1,
-        role: "employee",
-        permissions: ["VIEW_SELF_ATTENDANCE"],
-        company_id: 1,
-        is_platform_admin: false,
-        exp: Date.now() / 1000 + 3600,
-      });
-      localStorage.setItem("token", token);
-
-      const { result } = renderHook(() => usePermissions());
+    it("should return false when user lacks the permission", async () => {
+      mockPermissions({ permissions: ["VIEW_SELF_ATTENDANCE"], company_id: 1, is_platform_admin: false });
+
+      const { result } = await renderPermissions();
       expect(result.current.hasPermission("VIEW_EMPLOYEE")).toBe(false);
     });
   });
 
   describe("hasAnyPermission", () => {
-    it("should return true when empty array", () => {
-      const { result } = renderHook(() => usePermissions());
+    it("should return true when empty array", async () => {
+      const { result } = await renderPermissions();
       expect(result.current.hasAnyPermission([])).toBe(true);
     });
 
-    it("should return true when platform admin", () => {
-      const token = createMockToken({
-        user_id: 1,
-        role: "superadmin",
-        permissions: [],
-        company_id: 0,
-        is_platform_admin: true,
-        exp: Date.now() / 1000 + 3600,
-      });
-      localStorage.setItem("token", token);
-
-      const { result } = renderHook(() => usePermissions());
+    it("should return true when platform admin", async () => {
+      mockPermissions({ permissions: [], company_id: 0, is_platform_admin: true });
+
+      const { result } = await renderPermissions();
       expect(result.current.hasAnyPermission(["VIEW_EMPLOYEE"])).toBe(true);
     });
 
-    it("should return true when user has at least one permission", () => {
-      const token = createMockToken({
-        user_id: 1,
-        role: "admin",
-        permissions: ["CREATE_EMPLOYEE"],
-        company_id: 1,
-        is_platform_admin: false,
-        exp: Date.now() / 1000 + 3600,
-      });
-      localStorage.setItem("token", token);
-
-      const { result } = renderHook(() => usePermissions());
+    it("should return true when user has at least one permission", async () => {
+      mockPermissions({ permissions: ["CREATE_EMPLOYEE"], company_id: 1, is_platform_admin: false });
+
+      const { result } = await renderPermissions();
       expect(result.current.hasAnyPermission(["VIEW_EMPLOYEE", "CREATE_EMPLOYEE"])).toBe(true);
     });
 
-    it("should return false when user has none of the permissions", () => {
-      const token = createMockToken({
-        user_id: 1,
-        role: "employee",
-        permissions: ["VIEW_SELF_ATTENDANCE"],
-        company_id: 1,
-        is_platform_admin: false,
-        exp: Date.now() / 1000 + 3600,
-      });
-      localStorage.setItem("token", token);
-
-      const { result } = renderHook(() => usePermissions());
+    it("should return false when user has none of the permissions", async () => {
+      mockPermissions({ permissions: ["VIEW_SELF_ATTENDANCE"], company_id: 1, is_platform_admin: false });
+
+      const { result } = await renderPermissions();
       expect(result.current.hasAnyPermission(["VIEW_EMPLOYEE", "CREATE_EMPLOYEE"])).toBe(false);
     });
   });
 
   describe("hasAllPermissions", () => {
-    it("should return true when empty array", () => {
-      const { result } = renderHook(() => usePermissions());
+    it("should return true when empty array", async () => {
+      const { result } = await renderPermissions();
       expect(result.current.hasAllPermissions([])).toBe(true);
     });
 
-    it("should return true when user has all permissions", () => {
-      const token = createMockToken({
-        user_id: 1,
-        role: "admin",
-        permissions: ["VIEW_EMPLOYEE", "CREATE_EMPLOYEE"],
-        company_id: 1,
-        is_platform_admin: false,
-        exp: Date.now() / 1000 + 3600,
-      });
-      localStorage.setItem("token", token);
-
-      const { result } = renderHook(() => usePermissions());
+    it("should return true when user has all permissions", async () => {
+      mockPermissions({ permissions: ["VIEW_EMPLOYEE", "CREATE_EMPLOYEE"], company_id: 1, is_platform_admin: false });
+
+      const { result } = await renderPermissions();
       expect(result.current.hasAllPermissions(["VIEW_EMPLOYEE", "CREATE_EMPLOYEE"])).toBe(true);
     });
 
-    it("should return false when user is missing a permission", () => {
-      const token = createMockToken({
-        user_id: 1,
-        role: "admin",
-        permissions: ["VIEW_EMPLOYEE"],
-        company_id: 1,
-        is_platform_admin: false,
-        exp: Date.now() / 1000 + 3600,
-      });
-      localStorage.setItem("token", token);
-
-      const { result } = renderHook(() => usePermissions());
+    it("should return false when user is missing a permission", async () => {
+      mockPermissions({ permissions: ["VIEW_EMPLOYEE"], company_id: 1, is_platform_admin: false });
+
+      const { result } = await renderPermissions();
       expect(result.current.hasAllPermissions(["VIEW_EMPLOYEE", "CREATE_EMPLOYEE"])).toBe(false);
     });
   });
 
-  it("should handle invalid token gracefully", () => {
-    const errorSpy = vi.spyOn(console, "error").mockImplementation(() => {});
-    localStorage.setItem("token", "not-a-valid-jwt");
+  it("should return empty permissions when the request fails", async () => {
+    localStorage.setItem("token", "mock-token");
+    (api.get as ReturnType<typeof vi.fn>).mockRejectedValue(new Error("Unauthorized"));
 
-    const { result } = renderHook(() => usePermissions());
+    const { result } = await renderPermissions();
     expect(result.current.permissions).toEqual([]);
     expect(result.current.isPlatformAdmin).toBe(false);
-    errorSpy.mockRestore();
   });
 });
@@ -1,24 +1,24 @@
-import { useMemo } from "react";
-import { jwtDecode } from "jwt-decode";
-import type { DecodedToken } from "@/features/auth/types";
+import { useQuery } from "@tanstack/react-query";
+import { api } from "@/lib/axios";
+import type { UserPermissions } from "@/features/auth/types";
 
+// permissions are resolved from the role on the server, the token only names the role
 export const usePermissions = () => {
-  const { permissions, isPlatformAdmin, companyId } = useMemo(() => {
-    try {
-      const token = localStorage.getItem("token");
-      if (!token) return { permissions: [] as string[], isPlatformAdmin: false, companyId: 0 };
+  const token = localStorage.getItem("token");
 
-      const decoded = jwtDecode<DecodedToken>(token);
-      return {
-        permissions: decoded.permissions || [],
-        isPlatformAdmin: decoded.is_platform_admin || false,
-        companyId: decoded.company_id || 0,
-      };
-    } catch (error) {
-      console.error("Failed to decode token for permissions:", error);
-      return { permissions: [] as string[], isPlatformAdmin: false, companyId: 0 };
-    }
-  }, []);
+  const { data, isLoading } = useQuery({
+    queryKey: ["permissions"],
+    queryFn: async () => {
+      const { data } = await api.get<{ data: UserPermissions }>("/auth/permissions");
+      return data.data;
+    },
+    enabled: !!token,
+    staleTime: 1000 * 60,
+  });
+
+  const permissions = data?.permissions || [];
+  const isPlatformAdmin = data?.is_platform_admin || false;
+  const companyId = data?.company_id || 0;
 
   const hasPermission = (permission: string) => {
     if (isPlatformAdmin) return true;
@@ -44,5 +44,6 @@ export const usePermissions = () => {
     hasAllPermissions,
     isPlatformAdmin,
     companyId,
+    isLoading: !!token && isLoading,
   };
 };